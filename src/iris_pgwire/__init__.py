@@ -24,6 +24,9 @@ from .type_mapping import (
     dump_type_mappings_to_json,
 )
 
+# Export stats/hooks API for embedders
+from .stats_hooks import QueryEvent, SessionStats, StatsHooks, get_stats
+
 __all__ = [
     "__version__",
     "__author__",
@@ -35,4 +38,9 @@ __all__ = [
     "reset_type_mappings",
     "load_type_mappings_from_file",
     "dump_type_mappings_to_json",
+    # Stats/hooks API
+    "QueryEvent",
+    "SessionStats",
+    "StatsHooks",
+    "get_stats",
 ]
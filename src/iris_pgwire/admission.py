@@ -0,0 +1,235 @@
+"""
+Admission Control for IRIS Statements
+
+Limits how many statements run against IRIS at once, globally and per role, so
+a burst from one client (a dashboard refresh storm) queues instead of starving
+everyone else's traffic through the bridge.
+
+Configuration (0 = unlimited):
+- PGWIRE_MAX_CONCURRENT_QUERIES: statements in flight across all sessions
+- PGWIRE_MAX_QUERIES_PER_ROLE: default cap for each role
+- PGWIRE_ROLE_QUERY_LIMITS: per-role overrides, e.g. "dashboard=2,etl=4"
+- PGWIRE_WORKLOAD_LIMITS: caps per workload class (see workload.py), e.g. "etl=4"
+- PGWIRE_ADMISSION_TIMEOUT: seconds a statement may wait in the queue (default 30)
+
+Fairness: when a slot frees up, it goes to the waiting role with the fewest
+statements in flight (ties by arrival order), and a role held back by its own
+cap never blocks other roles queued behind it. Statements that wait longer
+than the timeout fail with SQLSTATE 53000.
+
+The role is taken from the connection's task context (set_session_role() after
+authentication), so the shared executor needs no per-session plumbing.
+"""
+
+import asyncio
+import itertools
+import os
+import time
+from contextlib import asynccontextmanager
+from contextvars import ContextVar
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+DEFAULT_ADMISSION_TIMEOUT = 30.0
+
+_session_role: ContextVar[str | None] = ContextVar("pgwire_session_role", default=None)
+
+
+def set_session_role(role: str | None) -> None:
+    """Record the authenticated role for the current connection task."""
+    _session_role.set(role)
+
+
+def current_session_role() -> str | None:
+    """Role of the connection the current task serves (None outside a session)."""
+    return _session_role.get()
+
+
+class AdmissionTimeout(Exception):
+    """Statement waited longer than PGWIRE_ADMISSION_TIMEOUT (SQLSTATE 53000)."""
+
+    sqlstate = "53000"
+    condition_name = "insufficient_resources"
+
+
+def parse_limit_spec(spec: str) -> dict[str, int]:
+    """Parse "name=N,other=M" into {name: N} (invalid entries are skipped)."""
+    limits = {}
+    for entry in spec.split(","):
+        if "=" not in entry:
+            continue
+        role, _, value = entry.partition("=")
+        try:
+            limits[role.strip()] = int(value)
+        except ValueError:
+            logger.warning("Ignoring invalid limit entry", entry=entry.strip())
+    return limits
+
+
+@dataclass
+class _Waiter:
+    sequence: int
+    role: str
+    workload: str | None
+    future: asyncio.Future
+
+
+class AdmissionController:
+    """Global and per-role concurrency limits with a fair wait queue."""
+
+    def __init__(
+        self,
+        max_concurrent: int | None = None,
+        per_role: int | None = None,
+        role_limits: dict[str, int] | None = None,
+        timeout: float | None = None,
+        workload_limits: dict[str, int] | None = None,
+    ):
+        if max_concurrent is None:
+            max_concurrent = int(os.getenv("PGWIRE_MAX_CONCURRENT_QUERIES", "0"))
+        if per_role is None:
+            per_role = int(os.getenv("PGWIRE_MAX_QUERIES_PER_ROLE", "0"))
+        if role_limits is None:
+            role_limits = parse_limit_spec(os.getenv("PGWIRE_ROLE_QUERY_LIMITS", ""))
+        if timeout is None:
+            timeout = float(os.getenv("PGWIRE_ADMISSION_TIMEOUT", str(DEFAULT_ADMISSION_TIMEOUT)))
+        if workload_limits is None:
+            workload_limits = parse_limit_spec(os.getenv("PGWIRE_WORKLOAD_LIMITS", ""))
+
+        self.max_concurrent = max_concurrent
+        self.per_role = per_role
+        self.role_limits = role_limits
+        self.timeout = timeout
+        self.workload_limits = {name.lower(): n for name, n in workload_limits.items()}
+
+        self._running: dict[str, int] = {}
+        self._workload_running: dict[str, int] = {}
+        self._total_running = 0
+        self._waiters: list[_Waiter] = []
+        self._sequence = itertools.count()
+
+    @property
+    def enabled(self) -> bool:
+        return (
+            self.max_concurrent > 0
+            or self.per_role > 0
+            or bool(self.role_limits)
+            or bool(self.workload_limits)
+        )
+
+    def role_limit(self, role: str) -> int:
+        """Concurrency cap for a role (0 = unlimited)."""
+        return self.role_limits.get(role, self.per_role)
+
+    def _can_run(self, role: str, workload: str | None = None) -> bool:
+        if self.max_concurrent and self._total_running >= self.max_concurrent:
+            return False
+        workload_limit = self.workload_limits.get(workload or "", 0)
+        if workload_limit and self._workload_running.get(workload, 0) >= workload_limit:
+            return False
+        limit = self.role_limit(role)
+        return not limit or self._running.get(role, 0) < limit
+
+    def _grant(self, role: str, workload: str | None = None) -> None:
+        self._running[role] = self._running.get(role, 0) + 1
+        if workload:
+            self._workload_running[workload] = self._workload_running.get(workload, 0) + 1
+        self._total_running += 1
+
+    def _dispatch(self) -> None:
+        """Hand free slots to eligible waiters, least-served role first."""
+        while True:
+            self._waiters = [w for w in self._waiters if not w.future.done()]
+            eligible = [w for w in self._waiters if self._can_run(w.role, w.workload)]
+            if not eligible:
+                return
+            waiter = min(eligible, key=lambda w: (self._running.get(w.role, 0), w.sequence))
+            self._waiters.remove(waiter)
+            self._grant(waiter.role, waiter.workload)
+            waiter.future.set_result(None)
+
+    async def acquire(self, role: str | None, workload: str | None = None) -> None:
+        """
+        Wait for a slot for `role` (and its statement's workload class).
+
+        Raises:
+            AdmissionTimeout: no slot became free within the queue timeout
+        """
+        role = role or ""
+        if not self.enabled:
+            return
+
+        if self._can_run(role, workload) and not any(
+            self._can_run(w.role, w.workload) for w in self._waiters
+        ):
+            self._grant(role, workload)
+            return
+
+        waiter = _Waiter(
+            next(self._sequence), role, workload, asyncio.get_running_loop().create_future()
+        )
+        self._waiters.append(waiter)
+        started = time.perf_counter()
+        try:
+            await asyncio.wait_for(asyncio.shield(waiter.future), timeout=self.timeout or None)
+        except asyncio.TimeoutError:
+            if waiter.future.done():
+                return  # Granted just as the timeout fired
+            waiter.future.cancel()
+            self._waiters = [w for w in self._waiters if w is not waiter]
+            logger.warning(
+                "Statement admission timed out",
+                role=role,
+                workload=workload,
+                waited_s=round(time.perf_counter() - started, 3),
+                running=self._total_running,
+                queued=len(self._waiters),
+            )
+            raise AdmissionTimeout(
+                f"statement for role \"{role}\" waited more than {self.timeout:g}s for an "
+                "IRIS execution slot"
+            ) from None
+        except asyncio.CancelledError:
+            if waiter.future.done() and not waiter.future.cancelled():
+                self.release(role, workload)
+            else:
+                waiter.future.cancel()
+                self._waiters = [w for w in self._waiters if w is not waiter]
+            raise
+
+    def release(self, role: str | None, workload: str | None = None) -> None:
+        """Return a slot and admit queued statements."""
+        role = role or ""
+        if not self.enabled:
+            return
+        self._running[role] = max(0, self._running.get(role, 0) - 1)
+        if workload:
+            self._workload_running[workload] = max(0, self._workload_running.get(workload, 0) - 1)
+        self._total_running = max(0, self._total_running - 1)
+        self._dispatch()
+
+    @asynccontextmanager
+    async def admit(self, role: str | None = None, workload: str | None = None):
+        """Hold an execution slot for the duration of the block."""
+        await self.acquire(role, workload)
+        try:
+            yield
+        finally:
+            self.release(role, workload)
+
+    def stats(self) -> dict:
+        """Current load: statements in flight and queued, per role."""
+        queued: dict[str, int] = {}
+        for waiter in self._waiters:
+            if not waiter.future.done():
+                queued[waiter.role] = queued.get(waiter.role, 0) + 1
+        return {
+            "running": self._total_running,
+            "queued": sum(queued.values()),
+            "running_by_role": {r: n for r, n in self._running.items() if n},
+            "queued_by_role": queued,
+            "running_by_workload": {w: n for w, n in self._workload_running.items() if n},
+        }
@@ -0,0 +1,286 @@
+"""
+One-Dimensional Arrays on the Wire
+
+IRIS has no array type: arrays reach clients as %List result columns
+(iris_list.py) and reach IRIS as Bind parameters. pgx sends Go slices as
+arrays by default, Npgsql and asyncpg send lists the same way, so both
+directions speak PostgreSQL's array formats for int2, int4, int8, float4,
+float8, numeric, bool and text elements:
+
+- Results: a %List column is typed by its elements (int4[], or int8[] when
+  a value needs it; float8[], numeric[], and text[] for anything else) and
+  sent as the text literal ({1,2,NULL}) or, when the client asks for binary,
+  in the binary array form. $LIST has no booleans, so IRIS BITs come back as
+  int4[] elements 0 and 1.
+- Parameters: an array parameter, text ('{1,2,3}') or binary, is bound as
+  its literal text (bind_params.py) that still knows its elements
+  (ArrayParameter).
+- x = ANY(?) / x = SOME(?) with an array parameter is expanded before the
+  statement runs into x IN (?, ?, ...), one parameter per element, since
+  IRIS has no ANY over a value. An empty array becomes IN (NULL), which
+  matches no row. ANY over a '{...}' string literal is expanded the same way.
+  Catalog queries (pg_*, information_schema) are left to the executor.
+
+Multi-dimensional arrays are flattened by ANY, as PostgreSQL does; elsewhere
+they are passed on as text.
+"""
+
+import re
+import struct
+from decimal import Decimal
+
+from .bool_values import BOOL_OID, parse_bool
+from .copy_binary import encode_value
+
+# Array type OID -> element type OID, of the arrays with a binary send form
+ARRAY_TYPES: dict[int, int] = {
+    1000: 16,  # bool[]
+    1005: 21,  # int2[]
+    1007: 23,  # int4[]
+    1009: 25,  # text[]
+    1015: 1043,  # varchar[]
+    1016: 20,  # int8[]
+    1021: 700,  # float4[]
+    1022: 701,  # float8[]
+    1231: 1700,  # numeric[]
+}
+
+# Catalog relations, whose queries the executor answers reading ANY's array itself
+_CATALOG_NAME = re.compile(r'^"?(?:PG_|INFORMATION_SCHEMA\b)', re.IGNORECASE)
+
+_INT4_RANGE = range(-(2**31), 2**31)
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+(?:\.\d*)?)"
+    r"|(?P<op>::|<>|!=|[-+*/%(),.?;:=<>\[\]])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+
+class ArrayParameter(str):
+    """An array Bind parameter: its literal text, and its elements as IRIS values."""
+
+    elements: list
+
+    def __new__(cls, text: str, elements: list):
+        parameter = super().__new__(cls, text)
+        parameter.elements = elements
+        return parameter
+
+
+def parse_array_literal(text: str) -> list[str | None]:
+    """
+    Elements of an array literal ({a,"b c",NULL}) as text, None for NULL;
+    nested arrays are flattened.
+
+    Raises:
+        ValueError: text is not an array literal
+    """
+    text = text.strip()
+    if text.startswith("["):
+        # Dimension decoration: [1:3]={1,2,3}
+        text = text.partition("=")[2].strip()
+    if not (text.startswith("{") and text.endswith("}")):
+        raise ValueError("not an array literal")
+
+    elements: list[str | None] = []
+    depth, position, length = 0, 0, len(text)
+    while position < length:
+        char = text[position]
+        if char == "{":
+            depth += 1
+            position += 1
+        elif char == "}":
+            depth -= 1
+            position += 1
+        elif char == "," or char.isspace():
+            position += 1
+        elif char == '"':
+            value, position = [], position + 1
+            while position < length and text[position] != '"':
+                if text[position] == "\\":
+                    position += 1
+                value.append(text[position : position + 1])
+                position += 1
+            if position >= length:
+                raise ValueError("unterminated quoted element")
+            elements.append("".join(value))
+            position += 1
+        else:
+            end = position
+            while end < length and text[end] not in ',{}"':
+                end += 1
+            value = text[position:end].strip()
+            elements.append(None if value.upper() == "NULL" else value)
+            position = end
+        if depth < 0 or (depth == 0 and position < length):
+            raise ValueError("unbalanced braces")
+    if depth != 0:
+        raise ValueError("unbalanced braces")
+    return elements
+
+
+def element_value(text: str | None, element_oid: int):
+    """IRIS parameter value of an array element given as text."""
+    if text is None:
+        return None
+    if element_oid == BOOL_OID:
+        return parse_bool(text)
+    if element_oid in (20, 21, 23):
+        return int(text)
+    if element_oid in (700, 701):
+        return float(text)
+    return text
+
+
+def array_parameter(text: str, element_oid: int) -> ArrayParameter:
+    """
+    A text Bind parameter of an array type with its elements.
+
+    Raises:
+        ValueError: text is not a literal of the array type
+    """
+    elements = [element_value(element, element_oid) for element in parse_array_literal(text)]
+    return ArrayParameter(text, elements)
+
+
+def array_type_oid(elements: list) -> int:
+    """Array type of a %List result column, from the elements of all its values."""
+    values = [element for element in elements if element is not None]
+    if not values:
+        return 1009
+    if all(isinstance(value, int) for value in values):
+        return 1007 if all(value in _INT4_RANGE for value in values) else 1016
+    if all(isinstance(value, int | float) for value in values):
+        return 1022
+    if all(isinstance(value, int | Decimal) for value in values):
+        return 1231
+    return 1009
+
+
+def encode_array(value, array_oid: int) -> bytes:
+    """
+    Binary form of a one-dimensional array result value (its literal text):
+    ndim, NULL flag, element type, size and lower bound, then each element's
+    length (-1 for NULL) and binary value.
+
+    Raises:
+        ValueError: value is not an array literal of the type
+    """
+    element_oid = ARRAY_TYPES[array_oid]
+    elements = parse_array_literal(str(value))
+    if not elements:
+        return struct.pack("!iiI", 0, 0, element_oid)
+    has_null = any(element is None for element in elements)
+    out = bytearray(struct.pack("!iiIii", 1, int(has_null), element_oid, len(elements), 1))
+    for element in elements:
+        if element is None:
+            out += struct.pack("!i", -1)
+            continue
+        if element_oid == 16:
+            data = encode_value(element_value(element, 16), 16)
+        else:
+            data = encode_value(element, element_oid)
+        out += struct.pack("!i", len(data)) + data
+    return bytes(out)
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _literal(element: str | None) -> str:
+    if element is None:
+        return "NULL"
+    return "'" + element.replace("'", "''") + "'"
+
+
+def _any_elements(value) -> list | None:
+    """Elements of an ANY(?) parameter, None when it is not an array."""
+    if isinstance(value, ArrayParameter):
+        return value.elements
+    if isinstance(value, list | tuple):
+        return list(value)
+    if isinstance(value, str):
+        try:
+            return parse_array_literal(value)
+        except ValueError:
+            return None
+    return None
+
+
+def _plain(params: list | None) -> list | None:
+    if params is None:
+        return None
+    return [str(value) if isinstance(value, ArrayParameter) else value for value in params]
+
+
+def expand_any_parameters(sql: str, params: list | None) -> tuple[str, list | None]:
+    """
+    sql with each x = ANY(?) of an array parameter (or of an array string
+    literal) as x IN (?, ...), and its parameters with the array's elements
+    in place of the array. ArrayParameters elsewhere are bound as plain text.
+    """
+    upper = sql.upper()
+    if "ANY" not in upper and "SOME" not in upper:
+        return sql, _plain(params)
+
+    tokens = _tokens(sql)
+    if any(token[0] == "word" and _CATALOG_NAME.match(token[1]) for token in tokens):
+        return sql, params
+    pieces, expanded, cursor, number = [], [], 0, 0
+    for index, token in enumerate(tokens):
+        if token[1] == "?":
+            number += 1
+        if not (
+            token[1] == "="
+            and index + 4 < len(tokens)
+            and tokens[index + 1][0] == "word"
+            and tokens[index + 1][1].upper() in ("ANY", "SOME")
+            and tokens[index + 2][1] == "("
+        ):
+            continue
+        operand = tokens[index + 3]
+        # An optional ::type[] cast of the operand before the closing parenthesis
+        close = index + 4
+        if close + 3 < len(tokens) and tokens[close][1] == "::" and tokens[close + 2][1] == "[":
+            close += 4
+        if close >= len(tokens) or tokens[close][1] != ")":
+            continue
+
+        if operand[1] == "?" and params is not None and number < len(params):
+            elements = _any_elements(params[number])
+            if elements is None:
+                continue
+            placeholders = ", ".join("?" for _ in elements) or "NULL"
+            expanded.append((number, elements))
+        elif operand[0] == "string":
+            try:
+                elements = parse_array_literal(operand[1][1:-1].replace("''", "'"))
+            except ValueError:
+                continue
+            placeholders = ", ".join(_literal(element) for element in elements) or "NULL"
+        else:
+            continue
+        pieces.append(sql[cursor : token[2]])
+        pieces.append(f"IN ({placeholders})")
+        cursor = tokens[close][3]
+
+    if not pieces:
+        return sql, _plain(params)
+    pieces.append(sql[cursor:])
+
+    by_number = dict(expanded)
+    bound = []
+    for position, value in enumerate(_plain(params) or []):
+        bound.extend(by_number.get(position, [value]))
+    return "".join(pieces), bound or None
@@ -0,0 +1,120 @@
+"""
+Asynchronous Backend Messages
+
+NoticeResponse, NotificationResponse and ParameterStatus are asynchronous in
+the PostgreSQL protocol: the backend may send them between any two other
+messages, also while a query runs, and drivers (libpq, JDBC, asyncpg,
+Npgsql, pgx) accept them there. IRIS statements run in worker threads while
+the connection's event loop stays free, so the bridge writes these messages
+as soon as they are known instead of holding them until the next
+ReadyForQuery:
+
+- a notification from another session is sent while this session's query
+  runs, unless the session is in a transaction block (see notifications.py)
+- ParameterStatus reports for SET/RESET are sent before the next statement
+  runs, not after the whole multi-statement query or pipeline
+- notices from code serving the session reach the client while the
+  statement is still running
+
+Each backend message is written whole by a single write() with no await in
+between, so an asynchronous message never lands inside another message.
+Messages produced before the session is ready for queries (TLS,
+authentication) are held until it is; replication connections never get
+them. Messages from worker threads are handed to the event loop.
+"""
+
+import asyncio
+import struct
+from collections.abc import Callable
+
+import structlog
+
+from .notifications import Notification
+
+logger = structlog.get_logger()
+
+MSG_NOTICE_RESPONSE = b"N"
+MSG_NOTIFICATION_RESPONSE = b"A"
+MSG_PARAMETER_STATUS = b"S"
+
+
+def _message(kind: bytes, body: bytes) -> bytes:
+    return struct.pack("!cI", kind, 4 + len(body)) + body
+
+
+def notice_response(severity: str, code: str, message: str, detail: str | None = None) -> bytes:
+    """NoticeResponse: N + length + severity, SQLSTATE, message [, detail] fields"""
+    fields = [
+        b"S" + severity.encode("utf-8") + b"\x00",
+        b"C" + code.encode("utf-8") + b"\x00",
+        b"M" + message.encode("utf-8") + b"\x00",
+    ]
+    if detail:
+        fields.append(b"D" + detail.encode("utf-8") + b"\x00")
+    return _message(MSG_NOTICE_RESPONSE, b"".join(fields) + b"\x00")
+
+
+def notification_response(notification: Notification) -> bytes:
+    """NotificationResponse: A + length + pid + channel + payload"""
+    return _message(
+        MSG_NOTIFICATION_RESPONSE,
+        struct.pack("!I", notification.sender_pid)
+        + notification.channel.encode("utf-8")
+        + b"\x00"
+        + notification.payload.encode("utf-8")
+        + b"\x00",
+    )
+
+
+def parameter_status(name: str, value: str) -> bytes:
+    """ParameterStatus: S + length + name + value"""
+    return _message(
+        MSG_PARAMETER_STATUS, name.encode("utf-8") + b"\x00" + value.encode("utf-8") + b"\x00"
+    )
+
+
+class AsyncMessages:
+    """A session's asynchronous messages, written between its other backend messages."""
+
+    def __init__(self):
+        self._write: Callable[[bytes], None] | None = None
+        self._loop: asyncio.AbstractEventLoop | None = None
+        self._held: list[bytes] = []
+        self._closed = False
+
+    def open(self, write: Callable[[bytes], None]) -> None:
+        """Start writing with write (held messages first); call from the event loop."""
+        self._loop = asyncio.get_running_loop()
+        self._write = write
+        held, self._held = self._held, []
+        for message in held:
+            write(message)
+
+    def close(self) -> None:
+        """Drop held messages and ignore new ones (connection closing, replication)."""
+        self._closed = True
+        self._write = None
+        self._held = []
+
+    def send(self, message: bytes) -> None:
+        """Write message now, from any thread, or hold it until the session is ready."""
+        if self._closed:
+            return
+        if self._loop is not None and not self._in_loop():
+            self._loop.call_soon_threadsafe(self.send, message)
+        elif self._write is None:
+            self._held.append(message)
+        else:
+            try:
+                self._write(message)
+            except Exception as e:  # Transport gone: the message loop reports it
+                logger.debug("Asynchronous message not written", error=str(e))
+
+    def notice(self, severity: str, code: str, message: str, detail: str | None = None) -> None:
+        self.send(notice_response(severity, code, message, detail))
+
+    def _in_loop(self) -> bool:
+        try:
+            return asyncio.get_running_loop() is self._loop
+        except RuntimeError:
+            return False
@@ -0,0 +1,81 @@
+"""
+Security Audit Log
+
+Connection-level security events, one JSON object per event:
+
+    {"ts": "2026-01-05T10:31:02.114Z", "event": "auth_failed",
+     "client": "203.0.113.9", "user": "admin", "failures": 3}
+
+Events:
+- auth_success / auth_failed: outcome of each authentication attempt
+- auth_lockout: a client address reached the failure limit and is locked out
+- connection_rejected: connection refused by the rate limit or an active
+  lockout (with "reason")
+
+Every event is also logged to the server log. Configuration:
+- PGWIRE_AUDIT_LOG: destination - a file path (JSON Lines, rotated like the
+  query log) or "syslog" / "syslog:<host>:<port>" (default: server log only)
+"""
+
+import datetime
+import json
+import logging
+import os
+import time
+
+import structlog
+
+from .query_log import create_handler
+
+logger = structlog.get_logger()
+
+
+class AuditLog:
+    """Write security events to the server log and an optional dedicated handler."""
+
+    def __init__(self, handler: logging.Handler | None = None):
+        self.handler = handler
+        self._log = None
+        if handler is not None:
+            handler.setFormatter(logging.Formatter("%(message)s"))
+            self._log = logging.Logger("iris_pgwire.audit", level=logging.INFO)
+            self._log.propagate = False
+            self._log.addHandler(handler)
+
+    def record(self, event: str, **fields) -> None:
+        logger.info("Audit event", audit_event=event, **fields)
+        if self._log is None:
+            return
+        record = {
+            "ts": datetime.datetime.fromtimestamp(time.time(), datetime.UTC)
+            .isoformat(timespec="milliseconds")
+            .replace("+00:00", "Z"),
+            "event": event,
+            **fields,
+        }
+        self._log.info(json.dumps(record, default=str, ensure_ascii=False))
+
+    def close(self) -> None:
+        if self.handler is not None:
+            self._log.removeHandler(self.handler)
+            self.handler.close()
+            self.handler = None
+            self._log = None
+
+
+_audit_log: AuditLog | None = None
+
+
+def get_audit_log() -> AuditLog:
+    """Process-wide audit log configured by PGWIRE_AUDIT_LOG."""
+    global _audit_log
+    if _audit_log is None:
+        destination = os.getenv("PGWIRE_AUDIT_LOG", "").strip()
+        handler = None
+        if destination:
+            try:
+                handler = create_handler(destination)
+            except (OSError, ValueError) as e:
+                logger.error("Audit log file disabled", destination=destination, error=str(e))
+        _audit_log = AuditLog(handler)
+    return _audit_log
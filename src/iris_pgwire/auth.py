@@ -28,6 +28,7 @@ from typing import Any
 import structlog
 
 from .constitutional import get_governor
+from .iris_tls import iris_tls_kwargs
 from .performance_monitor import get_monitor
 
 logger = logging.getLogger(__name__)
@@ -117,6 +118,7 @@ class IRISAuthenticationProvider:
                         namespace=self.iris_config["namespace"],
                         username=username,
                         password=password,
+                        **iris_tls_kwargs(),
                     )
 
                     # Test connection by executing simple query
@@ -201,6 +203,7 @@ class IRISAuthenticationProvider:
                         namespace=self.iris_config["namespace"],
                         username=self.iris_config.get("system_user", "_SYSTEM"),
                         password=self.iris_config.get("system_password", "SYS"),
+                        **iris_tls_kwargs(),
                     )
 
                     # Check if user exists in IRIS security tables
@@ -8,11 +8,25 @@ via principal mapping.
 Architecture:
     PostgreSQL Client (GSSAPI) → GSSAPIAuthenticator → Kerberos KDC → IRIS User
 
+Protocol flow (auth method "gss", PGWIRE_AUTH_METHODS or a pg_hba.conf line):
+    The bridge sends AuthenticationGSS; the client answers with GSSResponse
+    messages carrying its tokens, each accepted by accept_context()/step()
+    against the service keys in KRB5_KTNAME and answered with
+    AuthenticationGSSContinue while the context needs more. Point KRB5_KTNAME
+    at the keytab of IRIS's own Kerberos service (postgres/host@REALM
+    entries), so the bridge accepts the tickets IRIS does. The authenticated
+    principal must then allow the StartupMessage user (authorize_principal):
+    without a map, the principal's name (with the realm when include_realm)
+    equals the user, ignoring case like IRIS user names; with a map, the
+    pg_ident.conf-style map in PGWIRE_IDENT_FILE allows it.
+
 Key Features:
     - Multi-step GSSAPI token exchange (RFC 4752)
     - Kerberos ticket validation via IRIS %Service_Bindings
     - Principal mapping (alice@EXAMPLE.COM → ALICE)
     - IRIS user existence validation via INFORMATION_SCHEMA
+    - Optional delegation: IRIS sessions run as the mapped user instead of the
+      service account (PGWIRE_KERBEROS_DELEGATION, see backend_auth.py)
 
 Constitutional Requirements:
     - Uses IRIS %Service_Bindings for ticket validation (FR-014)
@@ -31,6 +45,9 @@ from datetime import datetime, timedelta
 
 import structlog
 
+from ..backend_auth import BackendCredentials
+from ..ident_map import get_ident_map
+
 # Import GSSAPI library
 try:
     import gssapi
@@ -62,13 +79,15 @@ class KerberosPrincipal:
 class KerberosAuthenticationError(Exception):
     """Raised when Kerberos authentication fails"""
 
-    pass
+    sqlstate = "28000"
+    condition_name = "invalid_authorization_specification"
 
 
 class KerberosTimeoutError(Exception):
     """Raised when GSSAPI handshake exceeds timeout"""
 
-    pass
+    sqlstate = "28000"
+    condition_name = "invalid_authorization_specification"
 
 
 @dataclass
@@ -79,6 +98,9 @@ class KerberosConfig:
     keytab_path: str = "/etc/krb5.keytab"  # KRB5_KTNAME
     realm: str | None = None  # Optional realm restriction
     handshake_timeout: int = 5  # Seconds (FR-028)
+    delegate_credentials: bool = False  # PGWIRE_KERBEROS_DELEGATION
+    include_realm: bool = False  # PGWIRE_KERBEROS_INCLUDE_REALM
+    map_name: str | None = None  # PGWIRE_KERBEROS_MAP (pg_ident.conf map)
 
 
 class GSSAPIAuthenticator:
@@ -116,12 +138,122 @@ class GSSAPIAuthenticator:
         keytab_path = os.getenv("KRB5_KTNAME", "/etc/krb5.keytab")
         realm = os.getenv("PGWIRE_KERBEROS_REALM")
         handshake_timeout = int(os.getenv("PGWIRE_KERBEROS_TIMEOUT", "5"))
+        delegate_credentials = os.getenv("PGWIRE_KERBEROS_DELEGATION", "false").lower() in (
+            "1",
+            "true",
+            "yes",
+            "on",
+        )
+
+        include_realm = os.getenv("PGWIRE_KERBEROS_INCLUDE_REALM", "false").lower() in (
+            "1",
+            "true",
+            "yes",
+            "on",
+        )
 
         return KerberosConfig(
             service_name=service_name,
             keytab_path=keytab_path,
             realm=realm,
             handshake_timeout=handshake_timeout,
+            delegate_credentials=delegate_credentials,
+            include_realm=include_realm,
+            map_name=os.getenv("PGWIRE_KERBEROS_MAP") or None,
+        )
+
+    async def accept_context(self):
+        """
+        A server-side SecurityContext for one client's token exchange.
+
+        The context accepts tickets for any service principal in the keytab,
+        as PostgreSQL does with krb_server_keyfile.
+
+        Raises:
+            KerberosAuthenticationError: the keytab cannot be read
+        """
+
+        def _accept():
+            credentials = Credentials(usage="accept", store={"keytab": self.config.keytab_path})
+            return SecurityContext(creds=credentials, usage="accept")
+
+        try:
+            return await asyncio.to_thread(_accept)
+        except Exception as e:
+            logger.error("gssapi_acceptor_unavailable", error=str(e))
+            raise KerberosAuthenticationError(
+                f"could not acquire GSSAPI credentials from {self.config.keytab_path}"
+            ) from None
+
+    async def step(self, security_context, token: bytes) -> bytes | None:
+        """
+        Accept one client token.
+
+        Returns:
+            The token to send back (AuthenticationGSSContinue), if any
+
+        Raises:
+            KerberosAuthenticationError: the ticket is invalid or expired
+            KerberosTimeoutError: the step exceeded the handshake timeout
+        """
+        try:
+            return await asyncio.wait_for(
+                asyncio.to_thread(security_context.step, token),
+                timeout=self.config.handshake_timeout,
+            )
+        except TimeoutError:
+            raise KerberosTimeoutError(
+                f"GSSAPI handshake exceeded {self.config.handshake_timeout} second timeout"
+            ) from None
+        except Exception as e:
+            logger.warning("gssapi_accept_failed", error=str(e))
+            raise KerberosAuthenticationError(
+                f"accepting GSS security context failed: {e}"
+            ) from None
+
+    def authorize_principal(
+        self,
+        principal: str,
+        user: str,
+        include_realm: bool | None = None,
+        realm: str | None = None,
+        map_name: str | None = None,
+    ) -> KerberosPrincipal:
+        """
+        Check that an authenticated principal may log in as user.
+
+        Args:
+            principal: The client's principal (alice@EXAMPLE.COM)
+            user: User name of the StartupMessage
+            include_realm, realm, map_name: pg_hba.conf line options
+                (include_realm, krb_realm, map); None takes the configuration's
+
+        Raises:
+            KerberosAuthenticationError: a foreign realm, or the principal
+                does not allow the user
+        """
+        include_realm = self.config.include_realm if include_realm is None else include_realm
+        realm = realm or self.config.realm
+        map_name = map_name or self.config.map_name
+        name, _, principal_realm = principal.partition("@")
+        failed = KerberosAuthenticationError(f'GSSAPI authentication failed for user "{user}"')
+        if realm and principal_realm != realm:
+            logger.warning("kerberos_realm_mismatch", principal=principal, realm=realm)
+            raise failed
+        system_user = principal if include_realm else name
+        if map_name:
+            allowed = get_ident_map().check(map_name, system_user, user)
+        else:
+            allowed = system_user.lower() == user.lower()
+        if not allowed:
+            logger.warning("kerberos_principal_not_allowed", principal=principal, user=user)
+            raise failed
+        return KerberosPrincipal(
+            principal=principal,
+            username=name,
+            realm=principal_realm,
+            mapped_iris_user=user,
+            authenticated_at=datetime.utcnow(),
         )
 
     async def handle_gssapi_handshake(self, connection_id: str) -> KerberosPrincipal:
@@ -266,6 +398,42 @@ class GSSAPIAuthenticator:
 
         return principal
 
+    def delegated_backend_credentials(
+        self, principal: KerberosPrincipal, security_context=None
+    ) -> BackendCredentials | None:
+        """
+        IRIS identity for an authenticated GSSAPI session.
+
+        With PGWIRE_KERBEROS_DELEGATION enabled, the session's IRIS connections
+        run as principal.mapped_iris_user. The client must have forwarded its
+        credentials (libpq gssdelegation=1); otherwise the service account is
+        kept.
+
+        Args:
+            principal: Authenticated principal
+            security_context: Completed server-side SecurityContext, if any
+
+        Returns:
+            Delegated BackendCredentials, or None for the service account
+        """
+        if not self.config.delegate_credentials:
+            return None
+        forwarded = getattr(security_context, "delegated_creds", None) is not None
+        if security_context is not None and not forwarded:
+            logger.warning(
+                "kerberos_delegation_not_forwarded",
+                principal=principal.principal,
+                hint="connect with gssdelegation=1 to delegate credentials",
+            )
+            return None
+
+        logger.info(
+            "kerberos_delegation_enabled",
+            principal=principal.principal,
+            iris_user=principal.mapped_iris_user,
+        )
+        return BackendCredentials(principal.mapped_iris_user)
+
     async def validate_kerberos_ticket(self, gssapi_token: bytes) -> bool:
         """
         Validate Kerberos ticket via IRIS %Service_Bindings.
@@ -0,0 +1,118 @@
+"""
+Authentication Methods per Listener
+
+The bridge can listen on several addresses, each offering its own password
+authentication methods, as pg_hba.conf lines do per address - for example
+SCRAM for applications and MD5 on a port only a legacy pooler reaches:
+
+    PGWIRE_AUTH_METHODS=scram-sha-256           # PGWIRE_HOST:PGWIRE_PORT
+    PGWIRE_LISTENERS=10.0.0.5:6432=md5;0.0.0.0:5433=scram-sha-256,md5
+
+Methods:
+- trust: no password
+- password: cleartext password, checked by logging in to IRIS
+- md5: AuthenticationMD5Password against a stored MD5 secret (md5_auth.py)
+- scram-sha-256: SCRAM-SHA-256 (-PLUS over TLS) against a stored verifier
+  (scram.py)
+- gss: Kerberos tickets through GSSAPI (auth/gssapi_auth.py); the client
+  cannot fall back to a password, so gss is listed alone
+- ldap: cleartext password, checked by an LDAP server (ldap_auth.py,
+  configured by PGWIRE_LDAP_OPTIONS)
+
+The protocol authenticates a connection with one method: the first listed
+method the user has a stored secret for (trust, password and ldap always
+qualify). A listener offering "scram-sha-256,md5" so uses SCRAM for users
+with a verifier and MD5 for users provisioned with an MD5 secret only; a
+user with neither gets the first listed of the two and enrolls (see
+PGWIRE_SCRAM_ENROLL), which stores the secrets of both.
+
+Without PGWIRE_AUTH_METHODS the main listener offers scram-sha-256 when
+PGWIRE_ENABLE_SCRAM=true and trust otherwise. Client certificates
+(cert_auth.py) and PGWIRE_BACKEND_AUTH_MODE=passthrough, which needs the
+cleartext password, take precedence on every listener; passthrough checks
+that password with LDAP instead of IRIS where ldap is offered.
+"""
+
+import os
+from dataclasses import dataclass
+
+TRUST = "trust"
+PASSWORD = "password"
+MD5 = "md5"
+SCRAM_SHA_256 = "scram-sha-256"
+GSS = "gss"
+LDAP = "ldap"
+AUTH_METHODS = (TRUST, PASSWORD, MD5, SCRAM_SHA_256, GSS, LDAP)
+
+
+def parse_auth_methods(text: str, setting: str = "PGWIRE_AUTH_METHODS") -> tuple[str, ...]:
+    """
+    Comma-separated methods, in order of preference.
+
+    Raises:
+        ValueError: an unknown method, none, or gss with others
+    """
+    methods = []
+    for name in text.split(","):
+        method = name.strip().lower()
+        if not method:
+            continue
+        if method not in AUTH_METHODS:
+            raise ValueError(
+                f"invalid {setting} method {name.strip()!r} (expected {', '.join(AUTH_METHODS)})"
+            )
+        if method not in methods:
+            methods.append(method)
+    if not methods:
+        raise ValueError(f"{setting} lists no authentication method")
+    if GSS in methods and len(methods) > 1:
+        raise ValueError(f"{setting} cannot combine gss with other methods")
+    return tuple(methods)
+
+
+def load_auth_methods(enable_scram: bool = False) -> tuple[str, ...]:
+    """Methods of the main listener (PGWIRE_AUTH_METHODS)."""
+    value = os.getenv("PGWIRE_AUTH_METHODS")
+    if value is None or not value.strip():
+        return (SCRAM_SHA_256,) if enable_scram else (TRUST,)
+    return parse_auth_methods(value)
+
+
+@dataclass(frozen=True)
+class Listener:
+    """An additional address the bridge accepts connections on."""
+
+    host: str
+    port: int
+    auth_methods: tuple[str, ...]
+
+
+def parse_listeners(text: str) -> list[Listener]:
+    """
+    host:port=method[,method] entries separated by semicolons ([::1]:6432 for IPv6).
+
+    Raises:
+        ValueError: a malformed entry
+    """
+    listeners = []
+    for entry in text.split(";"):
+        entry = entry.strip()
+        if not entry:
+            continue
+        address, equals, methods = entry.partition("=")
+        host, colon, port = address.strip().rpartition(":")
+        if not equals or not colon or not host or not port.isdigit():
+            raise ValueError(
+                f"invalid PGWIRE_LISTENERS entry {entry!r} (expected host:port=methods)"
+            )
+        listeners.append(
+            Listener(
+                host.strip("[]"), int(port), parse_auth_methods(methods, "PGWIRE_LISTENERS")
+            )
+        )
+    return listeners
+
+
+def load_listeners() -> list[Listener]:
+    """Additional listeners (PGWIRE_LISTENERS)."""
+    return parse_listeners(os.getenv("PGWIRE_LISTENERS", ""))
@@ -0,0 +1,129 @@
+"""
+Backend Authentication Modes
+
+How statements are authorized on IRIS (PGWIRE_BACKEND_AUTH_MODE):
+
+- service (default): every statement runs as the configured IRIS account
+  (--iris-user). The client's identity is recorded for auditing: an audit
+  log "session_start" event maps it to the service account, and the query
+  log / stats hooks carry it on every statement.
+- passthrough: the client's own username and password open its IRIS
+  connections (pooled per user), so IRIS privileges, row-level security and
+  IRIS auditing apply to the real user. The password is requested with
+  cleartext password authentication and verified by logging in to IRIS;
+  run client-facing TLS in this mode. SCRAM cannot be combined with it (the
+  server never sees the password), and embedded mode always uses the service
+  account.
+
+Kerberos delegation (PGWIRE_KERBEROS_DELEGATION, see auth/gssapi_auth.py) is
+a passthrough variant for GSSAPI logins, where there is no password: the
+session's connections log in as the service account and then switch the IRIS
+process to the client's mapped user with $SYSTEM.Security.Login(user). The
+service account needs the %Service_Login:Use privilege for that switch.
+
+The credentials live in the connection's task context (like the admission
+role), so the shared executor needs no per-session plumbing; code running in
+the thread pool must be handed them explicitly.
+"""
+
+import os
+from contextvars import ContextVar
+from dataclasses import dataclass, field
+
+import structlog
+
+logger = structlog.get_logger()
+
+SERVICE = "service"
+PASSTHROUGH = "passthrough"
+BACKEND_AUTH_MODES = (SERVICE, PASSTHROUGH)
+
+
+@dataclass(frozen=True)
+class BackendCredentials:
+    """
+    IRIS login used for one client session in passthrough mode.
+
+    A credential without a password is a delegated identity (Kerberos): the
+    connection is opened by the service account and switched to user.
+    """
+
+    user: str
+    password: str | None = field(default=None, repr=False)
+
+    @property
+    def delegated(self) -> bool:
+        return self.password is None
+
+
+class PasswordAuthenticationFailed(Exception):
+    """IRIS rejected the client's credentials (SQLSTATE 28P01)."""
+
+    sqlstate = "28P01"
+    condition_name = "invalid_password"
+
+    def __init__(self, user: str):
+        super().__init__(f'password authentication failed for user "{user}"')
+        self.user = user
+
+
+class BackendLoginUnavailable(Exception):
+    """IRIS could not be reached to check the client's credentials (SQLSTATE 08006)."""
+
+    sqlstate = "08006"
+    condition_name = "connection_failure"
+
+    def __init__(self, user: str):
+        super().__init__(f'could not connect to IRIS to authenticate user "{user}"')
+        self.user = user
+
+
+def login_rejected(error: Exception) -> bool:
+    """Whether an IRIS login failed because IRIS refused the credentials."""
+    return "access denied" in str(error).lower()
+
+
+class DelegationFailed(Exception):
+    """IRIS refused to switch a connection to the delegated user (SQLSTATE 28000)."""
+
+    sqlstate = "28000"
+    condition_name = "invalid_authorization_specification"
+
+
+_backend_credentials: ContextVar[BackendCredentials | None] = ContextVar(
+    "pgwire_backend_credentials", default=None
+)
+
+
+def set_backend_credentials(credentials: BackendCredentials | None) -> None:
+    """Record the IRIS login for the current connection task (passthrough mode)."""
+    _backend_credentials.set(credentials)
+
+
+def current_backend_credentials() -> BackendCredentials | None:
+    """IRIS login of the session the current task serves (None = service account)."""
+    return _backend_credentials.get()
+
+
+def load_backend_auth_mode() -> str:
+    """PGWIRE_BACKEND_AUTH_MODE (unknown values fall back to service)."""
+    mode = os.getenv("PGWIRE_BACKEND_AUTH_MODE", SERVICE).strip().lower() or SERVICE
+    if mode not in BACKEND_AUTH_MODES:
+        logger.warning("Ignoring invalid PGWIRE_BACKEND_AUTH_MODE", value=mode)
+        return SERVICE
+    return mode
+
+
+def apply_delegated_login(connection, user: str) -> None:
+    """
+    Switch a service-account connection's IRIS process to user.
+
+    Raises:
+        DelegationFailed: $SYSTEM.Security.Login returned an error status
+    """
+    import iris
+
+    status = iris.createIRIS(connection).classMethodValue("%SYSTEM.Security", "Login", user)
+    if str(status) != "1":
+        raise DelegationFailed(f'could not run IRIS session as delegated user "{user}": {status}')
+    logger.debug("IRIS process switched to delegated user", user=user)
@@ -0,0 +1,282 @@
+"""
+Binary Bind Parameters
+
+Npgsql and pgx send the parameters of prepared statements in binary (format
+code 1) for every type they know, asyncpg for all of them. Bind decodes each
+binary parameter by its declared type into the form IRIS parameters accept,
+as copy_binary.decode_value does for COPY FROM:
+
+- int2, int4 and int8 as integers of any wire width, range-checked against
+  the declared type (22003, numeric_range.py); oid as an integer
+- float4 and float8 as floats, numeric as decimal text with every digit
+- bool as 1 / 0 (IRIS BIT), uuid as its text form, bytea as bytes, money
+  as decimal text
+- date as YYYY-MM-DD, time as HH:MM:SS.ffffff, timetz as the UTC time
+- timestamp as YYYY-MM-DD HH:MM:SS.ffffff, timestamptz likewise in UTC (its
+  binary form is UTC already, as the text path converts it with to_utc)
+- interval as PostgreSQL interval text (1 year 2 mons 3 days 04:05:06)
+- text, varchar, bpchar, name, json and xml as text, jsonb without its
+  version byte
+- pgvector's vector as its text form [0.5,1.25] (vector_values.py)
+- one-dimensional arrays of numbers without NULLs as IRIS vector text
+  ([0.5,1.25], for VECTOR columns and TO_VECTOR); other arrays as PostgreSQL
+  array literals ({a,"b c",NULL}). Either way the value keeps its elements
+  for = ANY(?) (array_values.ArrayParameter)
+
+A parameter whose type was left unspecified (OID 0) is read by its length:
+1 byte as bool, 2, 4 and 8 bytes as integers, an array header as an array,
+anything else as text. A value whose length or content does not fit its
+declared type fails Bind with 22P03, as PostgreSQL's receive functions do.
+"""
+
+import struct
+import uuid
+from datetime import datetime, timedelta
+
+from .array_values import ArrayParameter
+from .copy_binary import decode_numeric
+from .numeric_range import (
+    BINARY_INTEGER_FORMATS,
+    NumericValueOutOfRange,
+    check_integer_range,
+    is_integer_type,
+)
+from .vector_values import VECTOR_OID, decode_vector
+
+_PG_EPOCH = datetime(2000, 1, 1)
+_PG_EPOCH_DATE = _PG_EPOCH.date()
+
+# Sentinels of infinite dates and timestamps
+_DATE_INFINITY, _DATE_NEG_INFINITY = 2**31 - 1, -(2**31)
+_TIMESTAMP_INFINITY, _TIMESTAMP_NEG_INFINITY = 2**63 - 1, -(2**63)
+
+_FLOAT_WIDTHS = {4: "!f", 8: "!d"}
+
+# Scalar types with a fixed binary width
+_FIXED_WIDTHS = {
+    16: 1,  # bool
+    26: 4,  # oid
+    790: 8,  # money
+    1082: 4,  # date
+    1083: 8,  # time
+    1114: 8,  # timestamp
+    1184: 8,  # timestamptz
+    1186: 16,  # interval
+    1266: 12,  # timetz
+    2950: 16,  # uuid
+}
+
+# Array type OID -> element type OID
+ARRAY_ELEMENT_TYPES: dict[int, int] = {
+    199: 114,  # json[]
+    1000: 16,  # bool[]
+    1001: 17,  # bytea[]
+    1003: 19,  # name[]
+    1005: 21,  # int2[]
+    1007: 23,  # int4[]
+    1009: 25,  # text[]
+    1014: 1042,  # bpchar[]
+    1015: 1043,  # varchar[]
+    1016: 20,  # int8[]
+    1021: 700,  # float4[]
+    1022: 701,  # float8[]
+    1028: 26,  # oid[]
+    1115: 1114,  # timestamp[]
+    1182: 1082,  # date[]
+    1183: 1083,  # time[]
+    1185: 1184,  # timestamptz[]
+    1187: 1186,  # interval[]
+    1231: 1700,  # numeric[]
+    2951: 2950,  # uuid[]
+    3807: 3802,  # jsonb[]
+}
+
+# Element types of arrays sent to IRIS as vector text
+_VECTOR_ELEMENT_TYPES = frozenset({20, 21, 23, 700, 701, 1700})
+
+
+class InvalidBinaryParameter(ValueError):
+    """A binary Bind parameter that is not valid for its type (SQLSTATE 22P03)."""
+
+    sqlstate = "22P03"
+    condition_name = "invalid_binary_representation"
+
+    def __init__(self, param_index: int):
+        super().__init__(f"incorrect binary data format in bind parameter {param_index + 1}")
+        self.param_index = param_index
+
+
+def decode_binary_parameter(data: bytes, param_index: int, type_oid: int = 0):
+    """
+    IRIS parameter value of binary Bind parameter param_index (0-based),
+    declared as type_oid (0 = unspecified).
+
+    Raises:
+        NumericValueOutOfRange: an integer outside its declared type's range
+        InvalidBinaryParameter: data that is not a value of the type
+    """
+    try:
+        if type_oid == 0:
+            return _decode_unspecified(data)
+        if type_oid in ARRAY_ELEMENT_TYPES:
+            return _decode_array(data, ARRAY_ELEMENT_TYPES[type_oid])
+        return _decode_scalar(data, type_oid)
+    except NumericValueOutOfRange:
+        raise
+    except (ValueError, struct.error, IndexError, OverflowError):
+        raise InvalidBinaryParameter(param_index) from None
+
+
+def _decode_unspecified(data: bytes):
+    if len(data) == 1:
+        return 1 if data[0] else 0
+    if len(data) in BINARY_INTEGER_FORMATS:
+        return struct.unpack(BINARY_INTEGER_FORMATS[len(data)], data)[0]
+    if len(data) >= 12:
+        ndim, flags = struct.unpack("!ii", data[:8])
+        if 0 <= ndim <= 6 and flags in (0, 1):
+            try:
+                return _decode_array(data, 0)
+            except (ValueError, struct.error, IndexError):
+                pass  # Not an array after all
+    return data.decode("utf-8", errors="replace")
+
+
+def _decode_scalar(data: bytes, type_oid: int):
+    """IRIS parameter value of one binary value of a scalar type."""
+    if is_integer_type(type_oid):
+        if len(data) not in BINARY_INTEGER_FORMATS:
+            raise ValueError("integer width")
+        value = struct.unpack(BINARY_INTEGER_FORMATS[len(data)], data)[0]
+        return check_integer_range(value, type_oid)
+    if type_oid in (700, 701):
+        if len(data) not in _FLOAT_WIDTHS:
+            raise ValueError("float width")
+        return struct.unpack(_FLOAT_WIDTHS[len(data)], data)[0]
+    if type_oid in _FIXED_WIDTHS and len(data) != _FIXED_WIDTHS[type_oid]:
+        raise ValueError("fixed width")
+    if type_oid == 16:
+        if data[0] > 1:
+            raise ValueError("bool")
+        return data[0]
+    if type_oid == 26:
+        return struct.unpack("!I", data)[0]
+    if type_oid == 1700:
+        return decode_numeric(data)
+    if type_oid == 1082:
+        days = struct.unpack("!i", data)[0]
+        if days in (_DATE_INFINITY, _DATE_NEG_INFINITY):
+            return "infinity" if days > 0 else "-infinity"
+        return (_PG_EPOCH_DATE + timedelta(days=days)).isoformat()
+    if type_oid == 1083:
+        return _time_text(struct.unpack("!q", data)[0])
+    if type_oid == 1266:
+        micros, zone = struct.unpack("!qi", data)
+        # The zone is in seconds west of UTC
+        return _time_text((micros + zone * 1_000_000) % 86_400_000_000)
+    if type_oid in (1114, 1184):
+        micros = struct.unpack("!q", data)[0]
+        if micros in (_TIMESTAMP_INFINITY, _TIMESTAMP_NEG_INFINITY):
+            return "infinity" if micros > 0 else "-infinity"
+        return (_PG_EPOCH + timedelta(microseconds=micros)).strftime("%Y-%m-%d %H:%M:%S.%f")
+    if type_oid == 1186:
+        micros, days, months = struct.unpack("!qii", data)
+        return interval_text(micros, days, months)
+    if type_oid == 790:
+        cents = struct.unpack("!q", data)[0]  # money: int64 in hundredths
+        return f"{'-' if cents < 0 else ''}{abs(cents) // 100}.{abs(cents) % 100:02d}"
+    if type_oid == 17:
+        return bytes(data)
+    if type_oid == 2950:
+        return str(uuid.UUID(bytes=bytes(data)))
+    if type_oid == VECTOR_OID:
+        return decode_vector(data)
+    if type_oid == 3802:
+        if data[:1] != b"\x01":
+            raise ValueError("jsonb version")
+        return data[1:].decode("utf-8")
+    return data.decode("utf-8")
+
+
+def _time_text(micros: int) -> str:
+    if not 0 <= micros <= 86_400_000_000:
+        raise ValueError("time out of range")
+    seconds, fraction = divmod(micros, 1_000_000)
+    minutes, second = divmod(seconds, 60)
+    hour, minute = divmod(minutes, 60)
+    return f"{hour:02d}:{minute:02d}:{second:02d}.{fraction:06d}"
+
+
+def interval_text(micros: int, days: int, months: int) -> str:
+    """
+    PostgreSQL's (postgres style) text of an interval. A field following a
+    negative one carries an explicit + ('-1 days +02:00:00').
+    """
+    parts = []
+    before = False  # The previous field was negative
+    years, months = int(months / 12), months - int(months / 12) * 12
+    for count, unit in ((years, "year"), (months, "mon"), (days, "day")):
+        if count:
+            plus = "+" if before and count > 0 else ""
+            parts.append(f"{plus}{count} {unit}{'' if count == 1 else 's'}")
+            before = count < 0
+    if micros or not parts:
+        sign = "-" if micros < 0 else "+" if before else ""
+        seconds, fraction = divmod(abs(micros), 1_000_000)
+        minutes, second = divmod(seconds, 60)
+        hour, minute = divmod(minutes, 60)
+        clock = f"{sign}{hour:02d}:{minute:02d}:{second:02d}"
+        parts.append(f"{clock}.{fraction:06d}".rstrip("0") if fraction else clock)
+    return " ".join(parts)
+
+
+def _decode_array(data: bytes, element_oid: int):
+    """
+    IRIS parameter value of a binary array: ndim, flags, element type, then
+    per dimension its size and lower bound, then each element's length (-1
+    for NULL) and value.
+    """
+    ndim, _, wire_element_oid = struct.unpack("!iiI", data[:12])
+    element_oid = wire_element_oid or element_oid
+    if ndim == 0:
+        return ArrayParameter("{}", [])
+    if not 0 < ndim <= 6:
+        raise ValueError("array dimensions")
+    dimensions = [struct.unpack("!ii", data[12 + 8 * i : 20 + 8 * i])[0] for i in range(ndim)]
+    position = 12 + 8 * ndim
+    elements = []
+    count = 1
+    for size in dimensions:
+        count *= size
+    for _ in range(count):
+        length = struct.unpack("!i", data[position : position + 4])[0]
+        position += 4
+        if length == -1:
+            elements.append(None)
+            continue
+        if length < 0 or position + length > len(data):
+            raise ValueError("array element length")
+        elements.append(_decode_scalar(data[position : position + length], element_oid))
+        position += length
+    if position != len(data):
+        raise ValueError("array trailing data")
+
+    if ndim == 1 and element_oid in _VECTOR_ELEMENT_TYPES and None not in elements:
+        return ArrayParameter("[" + ",".join(str(element) for element in elements) + "]", elements)
+    texts = [_element_text(element, element_oid) for element in elements]
+    for size in reversed(dimensions[1:]):
+        texts = ["{" + ",".join(texts[i : i + size]) + "}" for i in range(0, len(texts), size)]
+    return ArrayParameter("{" + ",".join(texts) + "}", elements)
+
+
+def _element_text(value, element_oid: int) -> str:
+    """An array element as it appears in an array literal."""
+    if value is None:
+        return "NULL"
+    if element_oid == 16:
+        return "t" if value else "f"
+    text = "\\x" + value.hex() if isinstance(value, bytes) else str(value)
+    special = any(char in '{}",\\' or char.isspace() for char in text)
+    if special or not text or text.upper() == "NULL":
+        return '"' + text.replace("\\", "\\\\").replace('"', '\\"') + '"'
+    return text
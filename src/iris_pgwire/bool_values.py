@@ -0,0 +1,53 @@
+"""
+Boolean Columns and Parameters
+
+IRIS stores booleans in BIT (%Library.Boolean) columns as 1 and 0, and has
+no TRUE / FALSE literals. PostgreSQL clients see bool (OID 16) instead:
+
+- BIT and %Library.Boolean result columns are described as bool
+  (column_types.py) and sent as t / f in text, one byte in binary
+- Bind parameters declared bool are bound as 1 / 0, from binary (Go's bool,
+  Npgsql) or from any text boolin accepts: t, true, yes, on, 1, f, false,
+  no, off, 0 and unique prefixes of them, in any case (pgjdbc's setBoolean
+  sends TRUE). Other text fails Bind with 22P02
+- TRUE / FALSE and string literals cast to boolean ('t'::bool,
+  CAST('yes' AS BOOLEAN)) are rewritten onto 1 / 0 (boolean_translator.py)
+"""
+
+BOOL_OID = 16
+
+# boolin's words: any unique prefix of these is accepted (o alone is ambiguous)
+_TRUE_WORDS = ("true", "yes", "on")
+_FALSE_WORDS = ("false", "no", "off")
+
+
+class InvalidBoolean(ValueError):
+    """A bool Bind parameter that is not a boolean (SQLSTATE 22P02)."""
+
+    sqlstate = "22P02"
+    condition_name = "invalid_text_representation"
+
+
+def parse_bool(text: str) -> int:
+    """
+    1 or 0 for boolean input text, as IRIS BIT columns store it.
+
+    Raises:
+        InvalidBoolean: text is not a boolean
+    """
+    word = text.strip().lower()
+    true = word == "1" or any(name.startswith(word) for name in _TRUE_WORDS)
+    false = word == "0" or any(name.startswith(word) for name in _FALSE_WORDS)
+    if word and true != false:
+        return 1 if true else 0
+    raise InvalidBoolean(f'invalid input syntax for type boolean: "{text}"')
+
+
+def format_bool(value) -> str:
+    """PostgreSQL's text form (t / f) of a stored boolean: 1 / 0, True / False or text."""
+    if isinstance(value, str):
+        try:
+            return "t" if parse_bool(value) else "f"
+        except InvalidBoolean:
+            pass
+    return "t" if value else "f"
@@ -10,10 +10,22 @@ Constitutional Requirements:
 - Principle IV: Use asyncio.to_thread() for non-blocking IRIS operations
 """
 
-import logging
 from collections.abc import AsyncIterator
 
-logger = logging.getLogger(__name__)
+import structlog
+
+from .check_constraints import _unquote, relation
+from .copy_export import (
+    CopyExportError,
+    QueryExport,
+    TableExport,
+    columns_sql,
+    fetch_size,
+    table_export,
+)
+from .schema_mapper import get_schema_config
+
+logger = structlog.get_logger(__name__)
 
 
 class BulkExecutor:
@@ -131,10 +143,11 @@ class BulkExecutor:
         # Get column data types to handle DATE conversion
         column_types = await self._get_column_types(table_name, column_names)
 
-        # Build INSERT SQL template
+        # Build INSERT SQL template (public.t and t both name the default IRIS schema)
+        table_sql = relation(table_name, get_schema_config()["iris_schema"]).sql
         column_list = ", ".join(column_names)
         placeholders = ", ".join(["?" for _ in column_names])
-        sql = f"INSERT INTO {table_name} ({column_list}) VALUES ({placeholders})"
+        sql = f"INSERT INTO {table_sql} ({column_list}) VALUES ({placeholders})"
 
         logger.info(
             "🚀 Batch INSERT with try/catch architecture", table=table_name, batch_size=len(batch)
@@ -217,7 +230,7 @@ class BulkExecutor:
                         value_parts.append(f"'{escaped_value}'")
 
                 values_clause = ", ".join(value_parts)
-                row_sql = f"INSERT INTO {table_name} ({column_list}) VALUES ({values_clause})"
+                row_sql = f"INSERT INTO {table_sql} ({column_list}) VALUES ({values_clause})"
 
                 result = await self.iris_executor.execute_query(row_sql, [])
 
@@ -245,25 +258,16 @@ class BulkExecutor:
         Get data types for specific columns in a table.
 
         Args:
-            table_name: Table name
-            column_names: Column names to get types for
+            table_name: Table name (may be schema-qualified)
+            column_names: Column names to get types for (as written, may be quoted)
 
         Returns:
             Dict mapping column name to data type (e.g., {'DateOfBirth': 'DATE'})
         """
         # Query INFORMATION_SCHEMA for column types
         # IRIS stores column names in mixed case, so we need to match case-insensitively
-        ", ".join(["?" for _ in column_names])
-        query = f"""
-            SELECT COLUMN_NAME, DATA_TYPE
-            FROM INFORMATION_SCHEMA.COLUMNS
-            WHERE LOWER(TABLE_NAME) = LOWER(?)
-            AND UPPER(COLUMN_NAME) IN ({', '.join(['UPPER(?)' for _ in column_names])})
-        """
-
-        params = [table_name] + column_names
-        logger.debug(f"Querying column types with params: {params}")
-        result = await self.iris_executor.execute_query(query, params)
+        target = relation(table_name, get_schema_config()["iris_schema"])
+        result = await self.iris_executor.execute_query(columns_sql(target), [])
 
         # Build column type mapping (key by original input column name)
         column_types = {}
@@ -274,7 +278,7 @@ class BulkExecutor:
 
             # Map back to input column names
             for col_name in column_names:
-                db_type = db_columns.get(col_name.upper())
+                db_type = db_columns.get(_unquote(col_name).upper())
                 if db_type:
                     column_types[col_name] = db_type
         else:
@@ -289,7 +293,8 @@ class BulkExecutor:
         """
         Execute SELECT query and stream results.
 
-        Uses batched fetching (1000 rows at a time) to avoid memory exhaustion.
+        The query runs through the usual translation path, so its result is
+        fetched at once; table exports use stream_table() instead.
 
         Args:
             query: SELECT query
@@ -302,30 +307,76 @@ class BulkExecutor:
         """
         logger.info(f"Streaming query results: {query[:100]}")
 
-        # Execute query via IRIS (already async)
-        try:
-            # Execute query
-            cursor_result = await self.iris_executor.execute_query(query, [])
+        result = await self.iris_executor.execute_query(query, [])
+        if not result.get("success", False):
+            error_msg = result.get("error", "Unknown error")
+            logger.error(f"Query execution failed: {error_msg}")
+            raise RuntimeError(f"Query failed: {error_msg}")
 
-            # Stream results in batches
-            # Note: This is a simplified implementation
-            # Real implementation would use IRIS cursor.fetchmany()
-            if cursor_result:
-                for row in cursor_result:
-                    yield row
+        for row in result.get("rows", []):
+            yield tuple(row)
 
-            logger.debug("Query streaming complete")
+        logger.debug("Query streaming complete")
 
-        except Exception as e:
-            logger.error(f"Query execution failed: {e}")
-            raise
+    async def table_export(self, table_name: str, column_list: list[str] | None) -> TableExport:
+        """
+        Resolve the columns a table COPY TO exports (see copy_export.py).
+
+        Args:
+            table_name: Table name as written (may be schema-qualified)
+            column_list: Column names as written (None = all columns)
+
+        Raises:
+            CopyExportError: Unknown table or column
+        """
+        target = relation(table_name, get_schema_config()["iris_schema"])
+        result = await self.iris_executor.execute_query(columns_sql(target), [])
+        if not result.get("success", False):
+            raise RuntimeError(f"Column lookup failed: {result.get('error', 'Unknown error')}")
+        return table_export(target, result.get("rows") or [], column_list)
+
+    async def query_export(self, query: str) -> QueryExport:
+        """
+        Execute the query of COPY (query) TO STDOUT (see copy_export.py).
+
+        Raises:
+            CopyExportError: The query failed (with its SQLSTATE)
+        """
+        logger.info(f"Executing COPY query: {query[:100]}")
+        result = await self.iris_executor.execute_query(query, [])
+        if not result.get("success", False):
+            raise CopyExportError(
+                result.get("error", "Query execution failed"),
+                result.get("sqlstate", "42000"),
+                result.get("condition_name", "syntax_error"),
+            )
+        columns = result.get("columns") or []
+        return QueryExport(
+            [column["name"] for column in columns],
+            result.get("rows") or [],
+            [column.get("type_oid") or 25 for column in columns],
+        )
+
+    async def stream_table(self, export: TableExport) -> AsyncIterator[list[tuple]]:
+        """
+        Stream a table export in batches of PGWIRE_COPY_FETCH_SIZE rows.
+
+        Rows come straight from IRIS (no translation or per-value conversion),
+        which keeps full-table exports such as pg_dump's data phase fast.
+
+        Yields:
+            Lists of row tuples
+        """
+        logger.info(f"Streaming table export: {export.sql[:100]}")
+        async for batch in self.iris_executor.stream_rows(export.sql, fetch_size()):
+            yield batch
 
     async def get_table_columns(self, table_name: str) -> list[str]:
         """
         Get column names for a table using INFORMATION_SCHEMA.
 
         Args:
-            table_name: Table name
+            table_name: Table name (may be schema-qualified)
 
         Returns:
             List of column names
@@ -333,14 +384,8 @@ class BulkExecutor:
         Raises:
             Exception: IRIS query error
         """
-        query = f"""
-            SELECT column_name
-            FROM INFORMATION_SCHEMA.COLUMNS
-            WHERE LOWER(table_name) = LOWER('{table_name}')
-            ORDER BY ordinal_position
-        """
-
-        result = await self.iris_executor.execute_query(query, [])
+        target = relation(table_name, get_schema_config()["iris_schema"])
+        result = await self.iris_executor.execute_query(columns_sql(target), [])
 
         # Extract column names from result
         columns = []
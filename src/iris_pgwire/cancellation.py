@@ -0,0 +1,137 @@
+"""
+Query Cancellation (CancelRequest)
+
+Each session is given a backend key at startup - a process ID unique among
+the bridge's live sessions and a random 32-bit secret - sent to the client in
+BackendKeyData. To cancel, a client (psql on Ctrl-C, pgx when a context is
+cancelled) opens a second connection and sends CancelRequest with that key;
+the bridge then:
+
+1. cancels the session's running statement: the client gets
+   57014 (query_canceled) "canceling statement due to user request" and the
+   session stays usable, as in PostgreSQL
+2. interrupts the statement in IRIS: with external connections the IRIS
+   process running it is terminated ($SYSTEM.Process.Terminate, which needs
+   %Admin_Operate) and its pooled connection is replaced. The process is
+   found through the session labels (session_labels.py), so with
+   PGWIRE_SESSION_LABELS=off, and in embedded mode where statements run in
+   the bridge's own process, IRIS finishes the statement in the background.
+
+A CancelRequest with an unknown key, or for a session that is not running a
+statement, does nothing; the cancel connection is closed without a reply
+either way.
+"""
+
+import asyncio
+import secrets
+import threading
+from collections.abc import Awaitable
+from contextvars import ContextVar
+from typing import Any
+
+import structlog
+
+logger = structlog.get_logger()
+
+CANCELED_MESSAGE = "canceling statement due to user request"
+
+# PostgreSQL-like process IDs, positive int32 as BackendKeyData carries them
+_MIN_PID = 1000
+_MAX_PID = 2**22
+
+
+class BackendKeys:
+    """Backend keys of live sessions: unique process IDs with random secrets."""
+
+    def __init__(self):
+        self._lock = threading.Lock()
+        self._live: set[int] = set()
+
+    def allocate(self) -> tuple[int, int]:
+        """A (process ID, secret) pair whose process ID no live session has."""
+        with self._lock:
+            while True:
+                pid = _MIN_PID + secrets.randbelow(_MAX_PID - _MIN_PID)
+                if pid not in self._live:
+                    self._live.add(pid)
+                    return pid, secrets.randbits(32)
+
+    def release(self, pid: int) -> None:
+        with self._lock:
+            self._live.discard(pid)
+
+
+_backend_keys = BackendKeys()
+
+
+def get_backend_keys() -> BackendKeys:
+    """Process-wide backend key allocator."""
+    return _backend_keys
+
+
+def canceled_result() -> dict[str, Any]:
+    """Executor result of a statement cancelled by CancelRequest."""
+    return {
+        "success": False,
+        "error": CANCELED_MESSAGE,
+        "sqlstate": "57014",
+        "condition_name": "query_canceled",
+        "rows": [],
+        "columns": [],
+        "row_count": 0,
+    }
+
+
+class StatementCancel:
+    """The running statement of one session, cancellable from a CancelRequest."""
+
+    def __init__(self):
+        self._task: asyncio.Future | None = None
+        self._requested = False
+
+    @property
+    def running(self) -> bool:
+        return self._task is not None and not self._task.done()
+
+    async def run(self, statement: Awaitable[dict[str, Any]]) -> dict[str, Any]:
+        """
+        Await a statement's result; canceled_result() if cancel() interrupts it.
+
+        Statements issued while one is running (COPY's batches, internal
+        lookups) belong to it and are cancelled with it.
+        """
+        if self._task is not None:
+            return await statement
+        task = asyncio.ensure_future(statement)
+        self._task = task
+        try:
+            return await task
+        except asyncio.CancelledError:
+            if not self._requested or not task.cancelled():
+                raise  # The session itself is being shut down
+            return canceled_result()
+        finally:
+            self._task = None
+            self._requested = False
+
+    def cancel(self) -> bool:
+        """Cancel the running statement; False when none is running."""
+        if not self.running:
+            return False
+        self._requested = True
+        self._task.cancel()
+        return True
+
+
+_statement_cancel: ContextVar[StatementCancel | None] = ContextVar(
+    "pgwire_statement_cancel", default=None
+)
+
+
+def set_statement_cancel(statement_cancel: StatementCancel | None) -> None:
+    """Record the current connection task's session for cancellation."""
+    _statement_cancel.set(statement_cancel)
+
+
+def current_statement_cancel() -> StatementCancel | None:
+    return _statement_cancel.get()
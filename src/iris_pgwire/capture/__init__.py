@@ -0,0 +1,28 @@
+"""
+Wire protocol capture and replay for iris-pgwire.
+
+`pgwire record` runs a recording proxy in front of the server and writes every
+client session to a capture file; `pgwire replay` re-drives the captured
+sessions against a server and reports where responses diverge. Together they
+reproduce customer-reported protocol bugs and run regression workloads.
+"""
+
+from iris_pgwire.capture.capture_file import (
+    CaptureFormatError,
+    CapturedMessage,
+    CaptureWriter,
+    read_capture,
+)
+from iris_pgwire.capture.recorder import RecordingProxy
+from iris_pgwire.capture.replayer import ReplayReport, SessionReplay, replay_capture
+
+__all__ = [
+    "CaptureFormatError",
+    "CapturedMessage",
+    "CaptureWriter",
+    "RecordingProxy",
+    "ReplayReport",
+    "SessionReplay",
+    "read_capture",
+    "replay_capture",
+]
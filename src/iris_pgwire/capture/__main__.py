@@ -0,0 +1,128 @@
+"""
+CLI for Wire Protocol Capture and Replay
+
+Usage:
+    pgwire record --target HOST:PORT [--listen HOST:PORT] --output FILE
+    pgwire replay FILE [--server HOST:PORT] [--speed N] [--timeout S]
+
+Examples:
+    # Put the recorder between the application and the server, reproduce the bug
+    pgwire record --target localhost:5432 --listen 127.0.0.1:5433 -o bug.capture
+
+    # Re-drive the captured sessions against a fixed build
+    pgwire replay bug.capture --server localhost:5432
+
+    (python -m iris_pgwire.capture works the same way)
+"""
+
+import argparse
+import asyncio
+import sys
+
+from iris_pgwire.capture.capture_file import CaptureFormatError, CaptureWriter
+from iris_pgwire.capture.recorder import RecordingProxy
+from iris_pgwire.capture.replayer import DEFAULT_SYNC_TIMEOUT, replay_capture
+
+
+def _host_port(value: str, default_host: str = "127.0.0.1") -> tuple[str, int]:
+    host, _, port = value.rpartition(":")
+    try:
+        return host or default_host, int(port)
+    except ValueError:
+        raise argparse.ArgumentTypeError(f"expected HOST:PORT, got {value!r}") from None
+
+
+async def _record(args) -> int:
+    target_host, target_port = args.target
+    listen_host, listen_port = args.listen
+    proxy = RecordingProxy(
+        CaptureWriter(args.output, target=f"{target_host}:{target_port}"),
+        target_host,
+        target_port,
+        listen_host,
+        listen_port,
+    )
+    await proxy.start()
+    print(
+        f"Recording {listen_host}:{proxy.listen_port} -> {target_host}:{target_port} "
+        f"into {args.output} (Ctrl+C to stop)",
+        file=sys.stderr,
+    )
+    try:
+        await proxy.server.serve_forever()
+    except asyncio.CancelledError:
+        pass
+    finally:
+        await proxy.stop()
+        print(f"Recorded {proxy.sessions} sessions", file=sys.stderr)
+    return 0
+
+
+async def _replay(args) -> int:
+    host, port = args.server
+    report = await replay_capture(args.capture, host, port, args.speed, args.timeout)
+    print(report.summary())
+    return 0 if report.ok else 1
+
+
+def main():
+    """Main CLI entry point for capture and replay"""
+    parser = argparse.ArgumentParser(
+        prog="pgwire",
+        description="Capture PostgreSQL wire protocol sessions and replay them",
+        formatter_class=argparse.RawDescriptionHelpFormatter,
+        epilog="""
+Exit codes:
+  0 - Success (replay: every session matched the recording)
+  1 - Replay found diverging sessions
+  2 - Error (unreadable capture, connection failure)
+""",
+    )
+    subcommands = parser.add_subparsers(dest="command", required=True)
+
+    record = subcommands.add_parser("record", help="Run a recording proxy")
+    record.add_argument(
+        "--target", type=_host_port, required=True, help="Server to forward to (HOST:PORT)"
+    )
+    record.add_argument(
+        "--listen",
+        type=_host_port,
+        default=("127.0.0.1", 5433),
+        help="Address the proxy listens on (default: 127.0.0.1:5433)",
+    )
+    record.add_argument("-o", "--output", required=True, help="Capture file to write")
+
+    replay = subcommands.add_parser("replay", help="Replay a capture file")
+    replay.add_argument("capture", help="Capture file written by `pgwire record`")
+    replay.add_argument(
+        "--server",
+        type=_host_port,
+        default=("127.0.0.1", 5432),
+        help="Server to replay against (default: 127.0.0.1:5432)",
+    )
+    replay.add_argument(
+        "--speed",
+        type=float,
+        default=0.0,
+        help="Replay at recorded pace scaled by N (default: 0 = as fast as possible)",
+    )
+    replay.add_argument(
+        "--timeout",
+        type=float,
+        default=DEFAULT_SYNC_TIMEOUT,
+        help=f"Seconds to wait for each server response (default: {DEFAULT_SYNC_TIMEOUT:g})",
+    )
+
+    args = parser.parse_args()
+    runner = _record if args.command == "record" else _replay
+    try:
+        sys.exit(asyncio.run(runner(args)))
+    except KeyboardInterrupt:
+        sys.exit(0)
+    except (CaptureFormatError, OSError) as e:
+        print(f"❌ Error: {e}", file=sys.stderr)
+        sys.exit(2)
+
+
+if __name__ == "__main__":
+    main()
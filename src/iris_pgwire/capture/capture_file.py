@@ -0,0 +1,167 @@
+"""
+Capture File Format and Message Framing
+
+A capture is a JSON Lines file. The first line is a header, every further
+line one protocol message:
+
+    {"format": "iris-pgwire-capture", "version": 1, "created": "...", "target": "host:port"}
+    {"s": 1, "t": 0.0012, "d": "F", "m": "<base64 message>"}
+    {"s": 1, "t": 0.0031, "d": "B", "m": "<base64 message>"}
+
+s is the session number, t the seconds since the capture started, d the
+direction (F = frontend/client, B = backend/server) and m the complete
+message including its type byte and length word (startup-phase frontend
+messages have no type byte, exactly as on the wire).
+"""
+
+import asyncio
+import base64
+import datetime
+import json
+import struct
+import time
+from dataclasses import dataclass
+from pathlib import Path
+from typing import TextIO
+
+CAPTURE_FORMAT = "iris-pgwire-capture"
+CAPTURE_VERSION = 1
+
+FRONTEND = "F"
+BACKEND = "B"
+
+# Request codes of untyped startup-phase messages
+SSL_REQUEST_CODE = 80877103
+GSSENC_REQUEST_CODE = 80877104
+CANCEL_REQUEST_CODE = 80877102
+
+# Largest message the tools will frame (protects against reading garbage as a length)
+MAX_MESSAGE_LENGTH = 1024 * 1024 * 1024
+
+
+class CaptureFormatError(ValueError):
+    """File is not a readable iris-pgwire capture."""
+
+
+@dataclass
+class CapturedMessage:
+    """One protocol message of a captured session."""
+
+    session: int
+    offset: float  # Seconds since the capture started
+    direction: str  # FRONTEND or BACKEND
+    data: bytes
+
+    @property
+    def message_type(self) -> str:
+        """Type byte as a character ('' for untyped startup-phase messages)."""
+        if self.direction == FRONTEND and self.untyped:
+            return ""
+        return chr(self.data[0])
+
+    @property
+    def untyped(self) -> bool:
+        """Whether this is a length-prefixed startup-phase message without type byte."""
+        if len(self.data) < 8:
+            return False
+        return struct.unpack("!I", self.data[:4])[0] == len(self.data)
+
+    @property
+    def request_code(self) -> int | None:
+        """Protocol version / request code of an untyped message."""
+        return struct.unpack("!I", self.data[4:8])[0] if self.untyped else None
+
+
+async def read_untyped_message(reader: asyncio.StreamReader) -> bytes:
+    """Read a startup-phase message (length word, then body)."""
+    header = await reader.readexactly(4)
+    length = struct.unpack("!I", header)[0]
+    if length < 4 or length > MAX_MESSAGE_LENGTH:
+        raise CaptureFormatError(f"invalid startup message length {length}")
+    return header + await reader.readexactly(length - 4)
+
+
+async def read_typed_message(reader: asyncio.StreamReader) -> bytes:
+    """Read a regular message (type byte, length word, body)."""
+    header = await reader.readexactly(5)
+    length = struct.unpack("!I", header[1:])[0]
+    if length < 4 or length > MAX_MESSAGE_LENGTH:
+        raise CaptureFormatError(f"invalid message length {length}")
+    return header + await reader.readexactly(length - 4)
+
+
+class CaptureWriter:
+    """Append captured messages to a capture file."""
+
+    def __init__(self, path: str | Path, target: str = ""):
+        self.path = Path(path)
+        self._file: TextIO = self.path.open("w", encoding="utf-8")
+        self._started = time.perf_counter()
+        self._next_session = 0
+        self._write(
+            {
+                "format": CAPTURE_FORMAT,
+                "version": CAPTURE_VERSION,
+                "created": datetime.datetime.now(datetime.UTC).isoformat(timespec="seconds"),
+                "target": target,
+            }
+        )
+
+    def _write(self, record: dict) -> None:
+        self._file.write(json.dumps(record, separators=(",", ":")) + "\n")
+        self._file.flush()
+
+    def new_session(self) -> int:
+        self._next_session += 1
+        return self._next_session
+
+    def record(self, session: int, direction: str, data: bytes) -> None:
+        self._write(
+            {
+                "s": session,
+                "t": round(time.perf_counter() - self._started, 6),
+                "d": direction,
+                "m": base64.b64encode(data).decode("ascii"),
+            }
+        )
+
+    def close(self) -> None:
+        self._file.close()
+
+
+def read_capture(path: str | Path) -> dict[int, list[CapturedMessage]]:
+    """
+    Load a capture file.
+
+    Returns:
+        Messages per session number, in capture order
+
+    Raises:
+        CaptureFormatError: missing/unknown header or malformed line
+    """
+    sessions: dict[int, list[CapturedMessage]] = {}
+    with Path(path).open(encoding="utf-8") as capture:
+        try:
+            header = json.loads(capture.readline() or "{}")
+        except json.JSONDecodeError as e:
+            raise CaptureFormatError(f"invalid capture header: {e}") from e
+        if header.get("format") != CAPTURE_FORMAT:
+            raise CaptureFormatError(f"{path} is not an iris-pgwire capture file")
+        if header.get("version") != CAPTURE_VERSION:
+            raise CaptureFormatError(f"unsupported capture version {header.get('version')}")
+
+        for line_number, line in enumerate(capture, start=2):
+            if not line.strip():
+                continue
+            try:
+                record = json.loads(line)
+                message = CapturedMessage(
+                    session=int(record["s"]),
+                    offset=float(record["t"]),
+                    direction=record["d"],
+                    data=base64.b64decode(record["m"]),
+                )
+            except (KeyError, TypeError, ValueError) as e:
+                raise CaptureFormatError(f"line {line_number}: {e}") from e
+            sessions.setdefault(message.session, []).append(message)
+    return sessions
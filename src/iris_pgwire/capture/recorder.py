@@ -0,0 +1,123 @@
+"""
+Recording Proxy (`pgwire record`)
+
+Listens like a PostgreSQL server, forwards every connection to the real
+server and writes both directions of each session to a capture file.
+
+The proxy answers SSLRequest / GSSENCRequest with 'N' itself so the captured
+stream is always plaintext; clients must allow unencrypted connections
+(sslmode=prefer or disable) while recording.
+"""
+
+import asyncio
+
+import structlog
+
+from .capture_file import (
+    BACKEND,
+    FRONTEND,
+    GSSENC_REQUEST_CODE,
+    SSL_REQUEST_CODE,
+    CaptureWriter,
+    read_typed_message,
+    read_untyped_message,
+)
+
+logger = structlog.get_logger()
+
+
+class RecordingProxy:
+    """Transparent PostgreSQL proxy that records sessions."""
+
+    def __init__(
+        self,
+        writer: CaptureWriter,
+        target_host: str,
+        target_port: int,
+        listen_host: str = "127.0.0.1",
+        listen_port: int = 5433,
+    ):
+        self.capture = writer
+        self.target_host = target_host
+        self.target_port = target_port
+        self.listen_host = listen_host
+        self.listen_port = listen_port
+        self.server: asyncio.AbstractServer | None = None
+        self.sessions = 0
+
+    async def start(self) -> None:
+        self.server = await asyncio.start_server(
+            self.handle_client, self.listen_host, self.listen_port
+        )
+        self.listen_port = self.server.sockets[0].getsockname()[1]
+        logger.info(
+            "Recording proxy listening",
+            listen=f"{self.listen_host}:{self.listen_port}",
+            target=f"{self.target_host}:{self.target_port}",
+            capture=str(self.capture.path),
+        )
+
+    async def stop(self) -> None:
+        if self.server:
+            self.server.close()
+            await self.server.wait_closed()
+        self.capture.close()
+
+    async def handle_client(
+        self, client_reader: asyncio.StreamReader, client_writer: asyncio.StreamWriter
+    ) -> None:
+        session = self.capture.new_session()
+        self.sessions += 1
+        server_writer = None
+        try:
+            # Startup phase: refuse encryption ourselves, forward the StartupMessage
+            while True:
+                message = await read_untyped_message(client_reader)
+                code = int.from_bytes(message[4:8], "big")
+                if code in (SSL_REQUEST_CODE, GSSENC_REQUEST_CODE):
+                    client_writer.write(b"N")
+                    await client_writer.drain()
+                    continue
+                break
+
+            server_reader, server_writer = await asyncio.open_connection(
+                self.target_host, self.target_port
+            )
+            self.capture.record(session, FRONTEND, message)
+            server_writer.write(message)
+            await server_writer.drain()
+
+            await asyncio.gather(
+                self._pump(client_reader, server_writer, session, FRONTEND),
+                self._pump(server_reader, client_writer, session, BACKEND),
+            )
+        except (asyncio.IncompleteReadError, ConnectionError):
+            pass
+        except Exception as e:
+            logger.warning("Recording session failed", session=session, error=str(e))
+        finally:
+            for writer in (client_writer, server_writer):
+                if writer is not None and not writer.is_closing():
+                    writer.close()
+            logger.info("Recorded session closed", session=session)
+
+    async def _pump(
+        self,
+        reader: asyncio.StreamReader,
+        writer: asyncio.StreamWriter,
+        session: int,
+        direction: str,
+    ) -> None:
+        """Forward and record messages until one side closes."""
+        try:
+            while True:
+                message = await read_typed_message(reader)
+                self.capture.record(session, direction, message)
+                writer.write(message)
+                await writer.drain()
+        except (asyncio.IncompleteReadError, ConnectionError):
+            pass
+        finally:
+            # Propagate the close so the other pump finishes too
+            if not writer.is_closing():
+                writer.close()
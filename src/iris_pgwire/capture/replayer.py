@@ -0,0 +1,264 @@
+"""
+Capture Replay (`pgwire replay`)
+
+Re-drives the frontend side of each captured session against a server and
+compares the backend responses with the recording.
+
+Sessions run concurrently, starting at their recorded offsets (scaled by
+--speed; 0 = as fast as possible). Within a session, the replayer sends the
+recorded frontend messages in order and, before each one, waits for the
+backend messages the client was waiting for in the recording (ReadyForQuery,
+authentication requests and COPY starts) - so the replay never runs ahead of
+the server, whatever its timing.
+
+Responses are compared by message type sequence (contents such as backend
+keys or timings legitimately differ). A session diverges when the sequence
+differs, e.g. an ErrorResponse where the recording had DataRows.
+
+Limitations: challenge/response authentication (MD5, SCRAM) cannot be
+replayed byte-for-byte; replay against a server with trust or cleartext
+password authentication. CancelRequest sessions are skipped (their backend
+key refers to the recorded server process).
+"""
+
+import asyncio
+from dataclasses import dataclass, field
+
+import structlog
+
+from .capture_file import (
+    BACKEND,
+    CANCEL_REQUEST_CODE,
+    FRONTEND,
+    CapturedMessage,
+    read_capture,
+    read_typed_message,
+)
+
+logger = structlog.get_logger()
+
+# Backend messages after which the client has to act (the replay sync points)
+SYNC_MESSAGE_TYPES = frozenset("RZGW")
+
+DEFAULT_SYNC_TIMEOUT = 30.0
+
+
+@dataclass
+class SessionReplay:
+    """Outcome of replaying one session."""
+
+    session: int
+    expected: list[str] = field(default_factory=list)  # Recorded backend message types
+    received: list[str] = field(default_factory=list)  # Replayed backend message types
+    skipped: str | None = None
+    error: str | None = None
+
+    @property
+    def diverged(self) -> bool:
+        return self.skipped is None and (self.error is not None or self.expected != self.received)
+
+    def first_divergence(self) -> int | None:
+        """Index of the first differing backend message (None if identical)."""
+        for index, (expected, received) in enumerate(zip(self.expected, self.received)):
+            if expected != received:
+                return index
+        if len(self.expected) != len(self.received):
+            return min(len(self.expected), len(self.received))
+        return None
+
+    def describe(self) -> str:
+        if self.skipped:
+            return f"session {self.session}: skipped ({self.skipped})"
+        if not self.diverged:
+            return f"session {self.session}: ok ({len(self.received)} backend messages)"
+        index = self.first_divergence()
+        detail = f"session {self.session}: DIVERGED"
+        if index is not None:
+            expected = self.expected[index] if index < len(self.expected) else "<end>"
+            received = self.received[index] if index < len(self.received) else "<end>"
+            detail += f" at backend message {index}: expected {expected!r}, got {received!r}"
+        if self.error:
+            detail += f" ({self.error})"
+        return detail
+
+
+@dataclass
+class ReplayReport:
+    """Outcome of a replay run."""
+
+    sessions: list[SessionReplay]
+
+    @property
+    def diverged(self) -> list[SessionReplay]:
+        return [session for session in self.sessions if session.diverged]
+
+    @property
+    def ok(self) -> bool:
+        return not self.diverged
+
+    def summary(self) -> str:
+        lines = [session.describe() for session in self.sessions]
+        replayed = sum(1 for session in self.sessions if not session.skipped)
+        lines.append(f"{replayed} sessions replayed, {len(self.diverged)} diverged")
+        return "\n".join(lines)
+
+
+def _plan(messages: list[CapturedMessage]) -> list[tuple[CapturedMessage, int]]:
+    """Frontend messages with the number of sync messages to await before each."""
+    plan = []
+    syncs = 0
+    for message in messages:
+        if message.direction == BACKEND:
+            if message.message_type in SYNC_MESSAGE_TYPES:
+                syncs += 1
+        else:
+            plan.append((message, syncs))
+    return plan
+
+
+async def replay_session(
+    session: int,
+    messages: list[CapturedMessage],
+    host: str,
+    port: int,
+    capture_start: float = 0.0,
+    speed: float = 0.0,
+    sync_timeout: float = DEFAULT_SYNC_TIMEOUT,
+) -> SessionReplay:
+    """Replay one captured session and collect the backend message types."""
+    outcome = SessionReplay(
+        session=session,
+        expected=[m.message_type for m in messages if m.direction == BACKEND],
+    )
+    frontend = [m for m in messages if m.direction == FRONTEND]
+    if not frontend:
+        outcome.skipped = "no frontend messages"
+        return outcome
+    if frontend[0].request_code == CANCEL_REQUEST_CODE:
+        outcome.skipped = "CancelRequest"
+        return outcome
+
+    loop = asyncio.get_running_loop()
+    started = loop.time()
+    session_start = frontend[0].offset
+    if speed > 0:
+        await asyncio.sleep(max(0.0, (session_start - capture_start) / speed))
+        started = loop.time()
+
+    reader, writer = await asyncio.open_connection(host, port)
+    synced = asyncio.Condition()
+    sync_count = 0
+    closed = False
+
+    async def _collect():
+        nonlocal sync_count, closed
+        try:
+            while True:
+                message = await read_typed_message(reader)
+                message_type = chr(message[0])
+                outcome.received.append(message_type)
+                if message_type in SYNC_MESSAGE_TYPES:
+                    async with synced:
+                        sync_count += 1
+                        synced.notify_all()
+        except (asyncio.IncompleteReadError, ConnectionError):
+            pass
+        finally:
+            async with synced:
+                closed = True  # Release any waiter
+                synced.notify_all()
+
+    async def _await_syncs(count: int) -> bool:
+        async with synced:
+            await asyncio.wait_for(
+                synced.wait_for(lambda: sync_count >= count or closed),
+                timeout=sync_timeout,
+            )
+            return sync_count >= count
+
+    collector = asyncio.create_task(_collect())
+    plan = _plan(messages)
+    try:
+        for message, awaited in plan:
+            try:
+                if not await _await_syncs(awaited):
+                    outcome.error = "server closed the connection early"
+                    break
+            except asyncio.TimeoutError:
+                outcome.error = (
+                    f"timed out waiting for the server before frontend message "
+                    f"{message.message_type!r}"
+                )
+                break
+            if speed > 0:
+                delay = (message.offset - session_start) / speed - (loop.time() - started)
+                if delay > 0:
+                    await asyncio.sleep(delay)
+            writer.write(message.data)
+            await writer.drain()
+
+        if outcome.error is None:
+            # Collect the responses to the last frontend message
+            total_syncs = sum(
+                1
+                for m in messages
+                if m.direction == BACKEND and m.message_type in SYNC_MESSAGE_TYPES
+            )
+            try:
+                await _await_syncs(total_syncs)
+                if plan[-1][0].message_type == "X":
+                    await asyncio.wait_for(asyncio.shield(collector), timeout=1.0)
+            except asyncio.TimeoutError:
+                pass
+    except (ConnectionError, OSError) as e:
+        outcome.error = str(e)
+    finally:
+        collector.cancel()
+        try:
+            await collector
+        except asyncio.CancelledError:
+            pass
+        if not writer.is_closing():
+            writer.close()
+
+    return outcome
+
+
+async def replay_capture(
+    path: str,
+    host: str,
+    port: int,
+    speed: float = 0.0,
+    sync_timeout: float = DEFAULT_SYNC_TIMEOUT,
+) -> ReplayReport:
+    """Replay every session of a capture file against host:port."""
+    sessions = read_capture(path)
+    capture_start = min(
+        (messages[0].offset for messages in sessions.values() if messages), default=0.0
+    )
+    results = await asyncio.gather(
+        *(
+            replay_session(session, messages, host, port, capture_start, speed, sync_timeout)
+            for session, messages in sorted(sessions.items())
+        ),
+        return_exceptions=True,
+    )
+
+    outcomes = []
+    for session, result in zip(sorted(sessions), results):
+        if isinstance(result, BaseException):
+            outcome = SessionReplay(session=session, error=str(result))
+            outcome.expected = [
+                m.message_type for m in sessions[session] if m.direction == BACKEND
+            ]
+            outcomes.append(outcome)
+        else:
+            outcomes.append(result)
+    report = ReplayReport(outcomes)
+    logger.info(
+        "Replay finished",
+        capture=path,
+        sessions=len(outcomes),
+        diverged=len(report.diverged),
+    )
+    return report
@@ -0,0 +1,209 @@
+"""
+DROP ... CASCADE
+
+IRIS refuses to drop a table that views or foreign keys still reference, and
+what its own CASCADE covers differs between statements and versions. For
+
+    DROP TABLE | VIEW | SCHEMA name [, ...] CASCADE
+
+the bridge resolves the dependents from INFORMATION_SCHEMA and drops them
+first, as PostgreSQL does:
+
+    views selecting from a dropped table or view (VIEW_TABLE_USAGE),
+        recursively, each before the views it selects from
+    foreign keys of other tables referencing a dropped table
+        (REFERENTIAL_CONSTRAINTS), with ALTER TABLE ... DROP CONSTRAINT
+    every table and view of a dropped schema
+
+then drops the named objects without CASCADE and sends PostgreSQL's NOTICE
+("drop cascades to view v", or "drop cascades to 2 other objects" with the
+list as DETAIL). The default schema (public) is emptied but not dropped.
+"""
+
+import re
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass, field
+
+from .check_constraints import _IDENTIFIER, _unquote, relation
+from .partitioning import sql_identifier
+from .sql_text import split_top_level
+
+_NAME = rf"{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?"
+
+_DROP_CASCADE = re.compile(
+    r"^\s*DROP\s+(?P<type>TABLE|VIEW|SCHEMA)\s+"
+    rf"(?P<names>{_NAME}(?:\s*,\s*{_NAME})*)\s+CASCADE\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+
+@dataclass
+class CascadeDrop:
+    object_type: str  # table, view or schema
+    names: list[str]  # As written
+
+    @property
+    def tag(self) -> str:
+        return f"DROP {self.object_type.upper()}"
+
+
+@dataclass(frozen=True)
+class DroppedObject:
+    kind: str  # table, view, constraint or schema
+    schema: str  # IRIS schema
+    name: str
+    table: str | None = None  # Table of a constraint
+
+    def describe(self) -> str:
+        """The object as PostgreSQL names it in "drop cascades to ..." messages."""
+        if self.kind == "constraint":
+            return f"constraint {self.name} on table {self.table}"
+        return f"{self.kind} {self.name}"
+
+    def drop_sql(self) -> str:
+        if self.kind == "schema":
+            return f"DROP SCHEMA {sql_identifier(self.name)}"
+        if self.kind == "constraint":
+            return (
+                f"ALTER TABLE {self.schema}.{sql_identifier(self.table)} "
+                f"DROP CONSTRAINT {sql_identifier(self.name)}"
+            )
+        return f"DROP {self.kind.upper()} {self.schema}.{sql_identifier(self.name)}"
+
+
+@dataclass
+class CascadePlan:
+    drops: list[DroppedObject] = field(default_factory=list)  # In execution order
+    cascaded: list[DroppedObject] = field(default_factory=list)  # Dropped but not named
+
+    def notice(self) -> tuple[str, ...] | None:
+        """PostgreSQL's NOTICE listing the objects dropped along with the named ones."""
+        if not self.cascaded:
+            return None
+        if len(self.cascaded) == 1:
+            return "NOTICE", "00000", f"drop cascades to {self.cascaded[0].describe()}"
+        detail = "\n".join(f"drop cascades to {dropped.describe()}" for dropped in self.cascaded)
+        return "NOTICE", "00000", f"drop cascades to {len(self.cascaded)} other objects", detail
+
+
+def parse_cascade_drop(sql: str) -> CascadeDrop | None:
+    """DROP TABLE / VIEW / SCHEMA ... CASCADE (None for any other statement)."""
+    match = _DROP_CASCADE.match(sql)
+    if not match:
+        return None
+    return CascadeDrop(
+        match.group("type").lower(),
+        [name.strip() for name in split_top_level(match.group("names"))],
+    )
+
+
+def _literal(value: str) -> str:
+    return "'" + value.replace("'", "''") + "'"
+
+
+def schema_objects_sql(schema: str) -> str:
+    """Tables and views of an IRIS schema: (TABLE_NAME, TABLE_TYPE) rows."""
+    return (
+        "SELECT TABLE_NAME, TABLE_TYPE FROM INFORMATION_SCHEMA.TABLES "
+        f"WHERE LOWER(TABLE_SCHEMA) = LOWER({_literal(schema)}) "
+        "AND TABLE_TYPE IN ('BASE TABLE', 'VIEW')"
+    )
+
+
+def dependent_views_sql(schema: str, name: str) -> str:
+    """Views selecting from a table or view: (VIEW_SCHEMA, VIEW_NAME) rows."""
+    return (
+        "SELECT VIEW_SCHEMA, VIEW_NAME FROM INFORMATION_SCHEMA.VIEW_TABLE_USAGE "
+        f"WHERE LOWER(TABLE_SCHEMA) = LOWER({_literal(schema)}) "
+        f"AND LOWER(TABLE_NAME) = LOWER({_literal(name)})"
+    )
+
+
+def referencing_keys_sql(schema: str, table: str) -> str:
+    """Foreign keys referencing a table: (TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME) rows."""
+    return (
+        "SELECT fk.TABLE_SCHEMA, fk.TABLE_NAME, fk.CONSTRAINT_NAME "
+        "FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc "
+        "JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS fk "
+        "ON fk.CONSTRAINT_SCHEMA = rc.CONSTRAINT_SCHEMA "
+        "AND fk.CONSTRAINT_NAME = rc.CONSTRAINT_NAME "
+        "JOIN INFORMATION_SCHEMA.TABLE_CONSTRAINTS uk "
+        "ON uk.CONSTRAINT_SCHEMA = rc.UNIQUE_CONSTRAINT_SCHEMA "
+        "AND uk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME "
+        f"WHERE LOWER(uk.TABLE_SCHEMA) = LOWER({_literal(schema)}) "
+        f"AND LOWER(uk.TABLE_NAME) = LOWER({_literal(table)})"
+    )
+
+
+def _key(schema: str, name: str) -> tuple[str, str]:
+    return schema.lower(), name.lower()
+
+
+async def plan_cascade(
+    drop: CascadeDrop, iris_schema: str, lookup: Callable[[str], Awaitable[list]]
+) -> CascadePlan:
+    """
+    Objects to drop, dependents first; lookup runs an INFORMATION_SCHEMA query
+    and returns its rows.
+    """
+    plan = CascadePlan()
+    targets: list[DroppedObject] = []
+    schemas: list[DroppedObject] = []
+    if drop.object_type == "schema":
+        for name in drop.names:
+            schema = _unquote(name)
+            if schema == "public" or schema.lower() == iris_schema.lower():
+                schema = iris_schema
+            else:
+                schemas.append(DroppedObject("schema", schema, schema))
+            for table, table_type in await lookup(schema_objects_sql(schema)):
+                kind = "view" if table_type.upper() == "VIEW" else "table"
+                targets.append(DroppedObject(kind, schema, table))
+    else:
+        for name in drop.names:
+            target = relation(name, iris_schema)
+            targets.append(DroppedObject(drop.object_type, target.schema, target.table))
+    # A dropped schema's objects are all reported; otherwise only those not named
+    named = set()
+    if drop.object_type != "schema":
+        named = {_key(target.schema, target.name) for target in targets}
+
+    # Views, each after the views selecting from it (post-order)
+    visited: set[tuple[str, str]] = set()
+    views: list[DroppedObject] = []
+
+    async def visit(dropped: DroppedObject):
+        visited.add(_key(dropped.schema, dropped.name))
+        for schema, view in await lookup(dependent_views_sql(dropped.schema, dropped.name)):
+            if _key(schema, view) not in visited:
+                await visit(DroppedObject("view", schema, view))
+        if dropped.kind == "view":
+            views.append(dropped)
+
+    for target in targets:
+        if _key(target.schema, target.name) not in visited:
+            await visit(target)
+
+    # Foreign keys of other tables; those of dropped tables only need to go first
+    tables = [target for target in targets if target.kind == "table"]
+    dropped_tables = {_key(table.schema, table.name) for table in tables}
+    constraints: list[DroppedObject] = []
+    for table in tables:
+        for schema, referencing, constraint in await lookup(
+            referencing_keys_sql(table.schema, table.name)
+        ):
+            if _key(schema, referencing) == _key(table.schema, table.name):
+                continue  # Self-reference, dropped with the table
+            dropped = DroppedObject("constraint", schema, constraint, referencing)
+            if dropped not in constraints:
+                constraints.append(dropped)
+                if _key(schema, referencing) not in dropped_tables:
+                    plan.cascaded.append(dropped)
+
+    plan.drops = [*views, *constraints, *tables, *schemas]
+    plan.cascaded = [
+        *(view for view in views if _key(view.schema, view.name) not in named),
+        *plan.cascaded,
+        *(table for table in tables if _key(table.schema, table.name) not in named),
+    ]
+    return plan
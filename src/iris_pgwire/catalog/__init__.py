@@ -13,6 +13,13 @@ Key Components:
 - PgConstraintEmulator: Constraint catalog
 - PgIndexEmulator: Index catalog
 - PgAttrdefEmulator: Default value catalog
+- PgProcEmulator: Bridge function catalog (iFind functions)
+- PgInheritsEmulator: Table inheritance from IRIS class hierarchy
+- PgStatEmulator: pg_stat_database, pg_stat_user_tables, pg_stat_io, pg_stat_bgwriter
+- SystemViewEmulator: pg_tables, pg_views, pg_indexes, pg_matviews
+- CommentStore / CommentHandler: COMMENT ON, pg_description, pg_shdescription
+- SchemaCache: IRIS dictionary listings cached per namespace
+- fast_path: Well-known driver catalog queries answered from the schema cache
 - CatalogRouter: Query routing to appropriate emulators
 """
 
@@ -36,6 +43,10 @@ __all__ = [
     "PgIndexEmulator",
     "PgAttrdef",
     "PgAttrdefEmulator",
+    "PgProc",
+    "PgProcEmulator",
+    "PgInherits",
+    "PgInheritsEmulator",
     # Router
     "CatalogRouter",
     "CatalogQueryResult",
@@ -62,6 +73,12 @@ def __getattr__(name: str):
     elif name in ("PgAttrdef", "PgAttrdefEmulator"):
         from .pg_attrdef import PgAttrdef, PgAttrdefEmulator
         return PgAttrdef if name == "PgAttrdef" else PgAttrdefEmulator
+    elif name in ("PgProc", "PgProcEmulator"):
+        from .pg_proc import PgProc, PgProcEmulator
+        return PgProc if name == "PgProc" else PgProcEmulator
+    elif name in ("PgInherits", "PgInheritsEmulator"):
+        from .pg_inherits import PgInherits, PgInheritsEmulator
+        return PgInherits if name == "PgInherits" else PgInheritsEmulator
     elif name in ("CatalogRouter", "CatalogQueryResult"):
         from .catalog_router import CatalogRouter, CatalogQueryResult
         return CatalogRouter if name == "CatalogRouter" else CatalogQueryResult
@@ -68,10 +68,18 @@ class CatalogRouter:
         "pg_enum",
         "pg_extension",
         "pg_foreign_table",
+        "pg_indexes",
         "pg_inherits",
+        "pg_matviews",
+        "pg_partitioned_table",
         "pg_roles",
         "pg_settings",
+        "pg_shdescription",
+        "pg_stat_bgwriter",
+        "pg_stat_database",
+        "pg_stat_io",
         "pg_stat_user_tables",
+        "pg_tables",
         "pg_trigger",
         "pg_views",
     }
@@ -0,0 +1,117 @@
+"""
+Catalog Query Fast Path
+
+Drivers and tools send the same catalog queries on every connection: Npgsql
+loads its type registry (pg_type, composite fields, enum labels), pgJDBC's
+DatabaseMetaData.getTables() lists relations, psql's \\d lists relations and
+describes a table in three steps. Each is answered by catalog emulation over
+IRIS dictionary listings, which costs several IRIS round trips per connection.
+
+These queries are recognized by their shape - the statement with comments,
+whitespace, case and literal values normalized away - against the markers of
+the known driver queries below. The first execution runs as usual; its result
+is kept in the schema cache (catalog/schema_cache.py) for the exact statement
+and parameters, per IRIS namespace and login, and later connections get it
+without running the query against IRIS. Entries are dropped with the
+namespace's other cached listings, when the %Dictionary change stamp moves or
+DDL runs through the bridge.
+
+Only successful results are cached. PGWIRE_CATALOG_FAST_PATH=off disables the
+fast path (so does PGWIRE_SCHEMA_CACHE=off).
+"""
+
+import hashlib
+import os
+import re
+from dataclasses import dataclass
+
+# String literals and comments, in one pass so neither hides inside the other
+_STRING_OR_COMMENT = re.compile(r"'(?:[^']|'')*'|--[^\n]*|/\*.*?\*/", re.DOTALL)
+_NUMBER = re.compile(r"(?<![\w$.])\d+(?:\.\d+)?\b")
+_SPACE = re.compile(r"\s+")
+_PUNCTUATION_SPACE = re.compile(r"\s*([(),=])\s*")
+
+
+@dataclass(frozen=True)
+class CatalogQuery:
+    """A driver catalog query, recognized when its normalized text has all markers."""
+
+    name: str
+    markers: tuple[str, ...]
+
+    def matches(self, shape: str) -> bool:
+        return all(marker in shape for marker in self.markers)
+
+
+# Markers are in normalized form: lower case, single spaces, none around ( ) , =
+KNOWN_QUERIES = (
+    CatalogQuery("npgsql_types", ("from pg_type", "typnotnull", "elemtypoid")),
+    CatalogQuery("npgsql_composite_fields", ("from pg_type as typ", "att.attname", "att.atttypid")),
+    CatalogQuery("npgsql_enum_labels", ("from pg_enum", "enumlabel", "enumsortorder")),
+    CatalogQuery(
+        "jdbc_get_tables",
+        ("as table_cat", "as table_schem", "as table_name", "as table_type", "pg_class"),
+    ),
+    CatalogQuery(
+        "psql_list_relations",
+        ('as "schema"', 'as "name"', 'as "type"', 'as "owner"', "from pg_catalog.pg_class c"),
+    ),
+    CatalogQuery(
+        "psql_describe_lookup",
+        ("c.relname operator(pg_catalog.~)", "from pg_catalog.pg_class c", "pg_table_is_visible"),
+    ),
+    CatalogQuery(
+        "psql_describe_table",
+        ("c.relchecks", "c.relkind", "c.relhasindex", "from pg_catalog.pg_class c"),
+    ),
+    CatalogQuery(
+        "psql_describe_columns",
+        (
+            "pg_catalog.format_type(a.atttypid,a.atttypmod)",
+            "from pg_catalog.pg_attribute a",
+            "not a.attisdropped",
+        ),
+    ),
+)
+
+
+def fast_path_enabled() -> bool:
+    return os.getenv("PGWIRE_CATALOG_FAST_PATH", "on").strip().lower() not in (
+        "off",
+        "false",
+        "0",
+    )
+
+
+def shape(sql: str) -> str:
+    """
+    Statement without comments, in lower case, with whitespace collapsed and
+    literal values replaced by ?.
+    """
+    text = _STRING_OR_COMMENT.sub(lambda m: "?" if m.group().startswith("'") else " ", sql)
+    text = text.lower()
+    text = _PUNCTUATION_SPACE.sub(r"\1", _SPACE.sub(" ", text))
+    return _NUMBER.sub("?", text).strip().rstrip(";").strip()
+
+
+def fingerprint(sql: str) -> str:
+    """Short hash of the statement's shape, the same for any literal values."""
+    return hashlib.sha1(shape(sql).encode("utf-8")).hexdigest()[:16]
+
+
+def recognize(sql: str) -> CatalogQuery | None:
+    """The known driver catalog query sql is, if any."""
+    if "pg_" not in sql.lower():
+        return None
+    query_shape = shape(sql)
+    if not query_shape.startswith(("select", "with")):
+        return None
+    for query in KNOWN_QUERIES:
+        if query.matches(query_shape):
+            return query
+    return None
+
+
+def cache_key(sql: str, params: list | None) -> str:
+    """Schema cache key of one instance of a catalog query: its text and parameters."""
+    return f"{sql.strip()}\x00{params!r}" if params else sql.strip()
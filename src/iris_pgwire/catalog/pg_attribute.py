@@ -11,12 +11,14 @@ Key fields:
 - attnum: Column position (1-indexed)
 - attnotnull: NOT NULL constraint
 - atthasdef: Has default value
-- atttypmod: Type modifier (e.g., VARCHAR length)
+- atttypmod: Type modifier (e.g., VARCHAR length, NUMERIC precision and scale)
 """
 
 from dataclasses import dataclass
 from typing import Any
 
+from ..column_types import character_typmod, numeric_typmod
+from ..money_values import MONEY_OID, is_money_column
 from .oid_generator import OIDGenerator
 
 
@@ -74,6 +76,7 @@ class PgAttributeEmulator:
         "CHAR": 1042,  # bpchar
         "DATE": 1082,  # date
         "DECIMAL": 1700,  # numeric
+        "MONEY": 790,  # money
         "DOUBLE": 701,  # float8
         "INTEGER": 23,  # int4
         "INT": 23,  # int4 (alias)
@@ -90,6 +93,7 @@ class PgAttributeEmulator:
         "BOOLEAN": 16,  # bool
         "REAL": 700,  # float4
         "FLOAT": 701,  # float8
+        "UNIQUEIDENTIFIER": 2950,  # uuid
     }
 
     # Type length mapping
@@ -102,11 +106,13 @@ class PgAttributeEmulator:
         "FLOAT": 8,
         "INTEGER": 4,
         "INT": 4,
+        "MONEY": 8,
         "REAL": 4,
         "SMALLINT": 2,
         "TIME": 8,
         "TIMESTAMP": 8,
         "TINYINT": 2,
+        "UNIQUEIDENTIFIER": 16,
     }
 
     def __init__(self, oid_generator: OIDGenerator):
@@ -129,6 +135,7 @@ class PgAttributeEmulator:
         ordinal_position: int,
         is_nullable: str,
         column_default: str | None,
+        description: str | None = None,
     ) -> PgAttribute:
         """
         Convert IRIS column metadata to pg_attribute row.
@@ -141,6 +148,7 @@ class PgAttributeEmulator:
             ordinal_position: Column position (1-indexed)
             is_nullable: 'YES' or 'NO'
             column_default: Default value expression or None
+            description: IRIS column description (marks columns created as MONEY)
 
         Returns:
             PgAttribute instance
@@ -152,14 +160,19 @@ class PgAttributeEmulator:
         type_oid = self.TYPE_OID_MAP.get(base_type, 25)  # Default to text
         type_len = self.TYPE_LEN_MAP.get(base_type, -1)  # Default to variable
 
-        # Calculate type modifier for VARCHAR(n), CHAR(n)
+        # Calculate type modifier for VARCHAR(n), CHAR(n), NUMERIC(p,s)
         atttypmod = -1
         if "(" in data_type:
             try:
-                size_str = data_type.split("(")[1].rstrip(")").split(",")[0]
-                size = int(size_str)
+                sizes = [int(size) for size in data_type.split("(")[1].rstrip(")").split(",")]
                 if base_type in ("VARCHAR", "CHAR"):
-                    atttypmod = size + 4  # PostgreSQL adds 4 to varchar length
+                    atttypmod = character_typmod(sizes[0])  # PostgreSQL adds 4 to the length
+                elif base_type in ("NUMERIC", "DECIMAL"):
+                    precision, scale = sizes[0], sizes[1] if len(sizes) > 1 else 0
+                    if is_money_column(description):
+                        type_oid, type_len = MONEY_OID, self.TYPE_LEN_MAP["MONEY"]
+                    else:
+                        atttypmod = numeric_typmod(precision, scale)
             except (ValueError, IndexError):
                 pass
 
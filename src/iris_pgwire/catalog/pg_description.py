@@ -0,0 +1,359 @@
+"""
+Object Comments (COMMENT ON, pg_description, obj_description)
+
+Schema documentation written with COMMENT ON is kept by the bridge:
+
+    COMMENT ON TABLE orders IS 'One row per customer order';
+    COMMENT ON COLUMN orders.status IS 'open, shipped or cancelled';
+    COMMENT ON FUNCTION ifind_match(text, text) IS 'iFind search';
+    COMMENT ON SCHEMA reporting IS 'BI views';
+    COMMENT ON DATABASE "USER" IS 'Application namespace';
+
+IRIS has no COMMENT statement, so comments are stored in an IRIS global
+(PGWIRE_COMMENTS_GLOBAL, default ^PGWire.Comments) with the subscripts of a
+pg_description row:
+
+    ^PGWire.Comments(classoid, objoid, objsubid) = description
+
+where objoid is the OID the emulated catalogs report for the object (the
+table OID pg_class shows, the pg_proc OID, ...) and objsubid the column
+number for column comments. Comments live in the namespace the bridge
+connects to, so they survive restarts and are shared by every bridge
+instance. IS NULL or IS '' removes a comment.
+
+Tables, views and columns must exist (42P01 / 42703), as must functions
+(42883). Comments are read back through:
+- pg_description and pg_shdescription (database comments), with the simple
+  single-view SELECTs of catalog/view_query.py
+- SELECTs of obj_description(oid, 'pg_class'), col_description(table_oid,
+  column_number) and shobj_description(oid, 'pg_database') calls with literal
+  arguments; 'orders'::regclass arguments are resolved to OIDs beforehand
+  (see reg_casts.py)
+"""
+
+import os
+import re
+from dataclasses import dataclass
+from typing import Any
+
+from ..sql_text import split_top_level
+from .oid_generator import OIDGenerator
+from .reg_casts import RegCastResolver
+
+_OID = 26
+_INT4 = 23
+_TEXT = 25
+
+DEFAULT_COMMENTS_GLOBAL = "^PGWire.Comments"
+
+# pg_description.classoid: OIDs of the system catalogs holding the objects
+CATALOG_OIDS = {
+    "pg_class": 1259,
+    "pg_proc": 1255,
+    "pg_namespace": 2615,
+    "pg_database": 1262,
+}
+# Catalogs whose comments are shared between databases (pg_shdescription)
+SHARED_CATALOGS = frozenset({CATALOG_OIDS["pg_database"]})
+
+VIEW_NAMES = ("pg_description", "pg_shdescription")
+
+PG_DESCRIPTION_COLUMNS = [
+    ("objoid", _OID),
+    ("classoid", _OID),
+    ("objsubid", _INT4),
+    ("description", _TEXT),
+]
+PG_SHDESCRIPTION_COLUMNS = [
+    ("objoid", _OID),
+    ("classoid", _OID),
+    ("description", _TEXT),
+]
+VIEW_COLUMNS = {
+    "pg_description": PG_DESCRIPTION_COLUMNS,
+    "pg_shdescription": PG_SHDESCRIPTION_COLUMNS,
+}
+
+_COMMENT = re.compile(
+    r"^\s*COMMENT\s+ON\s+(?P<type>TABLE|VIEW|MATERIALIZED\s+VIEW|FOREIGN\s+TABLE|COLUMN|"
+    r"FUNCTION|PROCEDURE|SCHEMA|DATABASE)\s+(?P<name>.+?)\s+IS\s+"
+    r"(?P<text>NULL|'(?:[^']|'')*')\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_VIEW_REFERENCE = re.compile(
+    r"\bFROM\s+(?:pg_catalog\.)?(" + "|".join(VIEW_NAMES) + r")\b", re.IGNORECASE
+)
+_JOIN = re.compile(r"\bJOIN\b|\bFROM\s+[\w.]+(?:\s+(?:AS\s+)?\w+)?\s*,", re.IGNORECASE)
+
+_SELECT = re.compile(r"^\s*SELECT\s+(?P<items>.+?)\s*;?\s*$", re.IGNORECASE | re.DOTALL)
+_CALL = re.compile(
+    r"^(?:pg_catalog\.)?(?P<name>obj_description|col_description|shobj_description)\s*"
+    r"\((?P<args>.*)\)(?:\s+(?:AS\s+)?(?P<alias>\w+|\"[^\"]+\"))?$",
+    re.IGNORECASE | re.DOTALL,
+)
+_ARGUMENT = re.compile(r"^(?:'(?P<text>(?:[^']|'')*)'|(?P<number>\d+))$")
+
+
+class CommentTargetMissing(LookupError):
+    """Object named in COMMENT ON does not exist."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+@dataclass
+class CommentStatement:
+    object_type: str  # TABLE, VIEW, COLUMN, FUNCTION, SCHEMA or DATABASE
+    name: str  # Object name as written; relation name for COLUMN
+    column: str | None
+    text: str | None  # None removes the comment
+
+
+@dataclass
+class DescriptionCall:
+    function: str  # obj_description | col_description | shobj_description
+    arguments: list[str]
+    column_name: str
+
+
+def _unquote(identifier: str) -> str:
+    identifier = identifier.strip()
+    if len(identifier) >= 2 and identifier[0] == identifier[-1] == '"':
+        return identifier[1:-1].replace('""', '"')
+    return identifier.lower()
+
+
+def parse_comment(sql: str) -> CommentStatement | None:
+    """COMMENT ON statement for a supported object type (None for anything else)."""
+    match = _COMMENT.match(sql)
+    if not match:
+        return None
+
+    object_type = re.sub(r"\s+", " ", match.group("type").upper())
+    object_type = {
+        "MATERIALIZED VIEW": "VIEW",
+        "FOREIGN TABLE": "TABLE",
+        "PROCEDURE": "FUNCTION",
+    }.get(object_type, object_type)
+    name = match.group("name").strip()
+    column = None
+    if object_type == "COLUMN":
+        parts = re.findall(r'"(?:[^"]|"")*"|[^."]+', name)
+        if len(parts) < 2:
+            return None
+        name, column = ".".join(parts[:-1]), _unquote(parts[-1])
+
+    text = match.group("text")
+    text = None if text.upper() == "NULL" else text[1:-1].replace("''", "'")
+    return CommentStatement(object_type, name, column, text or None)
+
+
+def referenced_view(sql: str) -> str | None:
+    """pg_description / pg_shdescription a single-view SELECT reads (None otherwise)."""
+    match = _VIEW_REFERENCE.search(sql)
+    if not match or _JOIN.search(sql):
+        return None
+    return match.group(1).lower()
+
+
+def columns_sql(iris_schema: str, table: str) -> str:
+    """IRIS listing of a table's columns and their numbers (attnum)."""
+    schema = iris_schema.replace("'", "''")
+    table = table.replace("'", "''")
+    return (
+        "SELECT COLUMN_NAME, ORDINAL_POSITION FROM INFORMATION_SCHEMA.COLUMNS "
+        f"WHERE LOWER(TABLE_SCHEMA) = LOWER('{schema}') AND LOWER(TABLE_NAME) = LOWER('{table}') "
+        "ORDER BY ORDINAL_POSITION"
+    )
+
+
+class CommentStore:
+    """Comments in the IRIS global, read and written through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv(
+            "PGWIRE_COMMENTS_GLOBAL", DEFAULT_COMMENTS_GLOBAL
+        )
+
+    def set(self, classoid: int, objoid: int, objsubid: int, text: str | None) -> None:
+        subscripts = [classoid, objoid, objsubid]
+        if text is None:
+            self.accessor.kill(self.global_name, subscripts)
+        else:
+            self.accessor.set(self.global_name, subscripts, text)
+
+    def get(self, classoid: int, objoid: int, objsubid: int = 0) -> str | None:
+        subscripts = [classoid, objoid, objsubid]
+        if not self.accessor.data(self.global_name, subscripts):
+            return None
+        return self.accessor.get(self.global_name, subscripts)
+
+    def rows(self) -> list[dict[str, Any]]:
+        """Every stored comment as a pg_description row."""
+        rows = []
+
+        def subscripts_under(prefix: list) -> list:
+            found, previous = [], ""
+            while True:
+                previous = self.accessor.next_subscript(self.global_name, prefix, previous)
+                if previous is None:
+                    return found
+                found.append(previous)
+
+        for classoid in subscripts_under([]):
+            for objoid in subscripts_under([classoid]):
+                for objsubid in subscripts_under([classoid, objoid]):
+                    rows.append(
+                        {
+                            "objoid": int(objoid),
+                            "classoid": int(classoid),
+                            "objsubid": int(objsubid),
+                            "description": self.accessor.get(
+                                self.global_name, [classoid, objoid, objsubid]
+                            ),
+                        }
+                    )
+        return rows
+
+    def view_rows(self, view: str) -> list[dict[str, Any]]:
+        """Rows of pg_description or pg_shdescription."""
+        shared = view == "pg_shdescription"
+        return [row for row in self.rows() if (row["classoid"] in SHARED_CATALOGS) == shared]
+
+
+class CommentHandler:
+    """Resolves COMMENT ON targets and answers the description functions."""
+
+    def __init__(self, oid_generator: OIDGenerator | None = None, iris_schema: str = "SQLUser"):
+        self.oid_gen = oid_generator or OIDGenerator()
+        self.iris_schema = iris_schema
+        self.resolver = RegCastResolver(self.oid_gen, iris_schema)
+
+    def relation(self, name: str) -> tuple[str, str]:
+        """(IRIS schema, table) of a relation name, public meaning the default schema."""
+        parts = [_unquote(part) for part in re.findall(r'"(?:[^"]|"")*"|[^."]+', name)]
+        if len(parts) == 2 and parts[0] != "public":
+            return parts[0], parts[1]
+        return self.iris_schema, parts[-1]
+
+    def target(
+        self, statement: CommentStatement, columns: list[tuple] | None = None
+    ) -> tuple[int, int, int]:
+        """
+        pg_description key (classoid, objoid, objsubid) of a COMMENT ON target.
+
+        Args:
+            statement: Parsed COMMENT ON
+            columns: (column name, number) rows of columns_sql() for TABLE,
+                VIEW and COLUMN targets
+
+        Raises:
+            CommentTargetMissing: relation (42P01), column (42703) or
+                function (42883) does not exist
+        """
+        if statement.object_type == "FUNCTION":
+            try:
+                return CATALOG_OIDS["pg_proc"], self.resolver.function_oid(statement.name), 0
+            except LookupError as e:
+                raise CommentTargetMissing(str(e), "42883", "undefined_function") from e
+        if statement.object_type == "SCHEMA":
+            oid = self.oid_gen.get_namespace_oid(_unquote(statement.name))
+            return CATALOG_OIDS["pg_namespace"], oid, 0
+        if statement.object_type == "DATABASE":
+            oid = self.oid_gen.get_oid("", "database", _unquote(statement.name))
+            return CATALOG_OIDS["pg_database"], oid, 0
+
+        if not columns:
+            raise CommentTargetMissing(
+                f'relation "{statement.name}" does not exist', "42P01", "undefined_table"
+            )
+        table_oid = self.resolver.relation_oid(statement.name)
+        if statement.object_type != "COLUMN":
+            return CATALOG_OIDS["pg_class"], table_oid, 0
+        for column_name, number in columns:
+            if str(column_name).lower() == statement.column.lower():
+                return CATALOG_OIDS["pg_class"], table_oid, int(number)
+        raise CommentTargetMissing(
+            f'column "{statement.column}" of relation "{statement.name}" does not exist',
+            "42703",
+            "undefined_column",
+        )
+
+    @staticmethod
+    def references_description_function(sql: str) -> bool:
+        return "_description" in sql.lower()
+
+    def parse_functions(self, sql: str) -> list[DescriptionCall] | None:
+        """Description function calls of a SELECT made only of them (None otherwise)."""
+        match = _SELECT.match(sql)
+        if not match or re.search(r"\bFROM\b", match.group("items"), re.IGNORECASE):
+            return None
+
+        calls = []
+        for item in split_top_level(match.group("items")):
+            call = _CALL.match(item)
+            if not call:
+                return None
+            arguments = [
+                _ARGUMENT.match(arg.strip()) for arg in split_top_level(call.group("args"))
+            ]
+            if not arguments or len(arguments) > 2 or not all(arguments):
+                return None
+            function = call.group("name").lower()
+            calls.append(
+                DescriptionCall(
+                    function=function,
+                    arguments=[
+                        a.group("number") or a.group("text").replace("''", "'") for a in arguments
+                    ],
+                    column_name=(call.group("alias") or function).strip('"'),
+                )
+            )
+        return calls
+
+    def describe(self, call: DescriptionCall, store: CommentStore) -> str | None:
+        """Comment a description function call returns (None = NULL)."""
+        try:
+            oid = int(call.arguments[0])
+            second = call.arguments[1] if len(call.arguments) > 1 else None
+            if call.function == "col_description":
+                return store.get(CATALOG_OIDS["pg_class"], oid, int(second or 0))
+        except ValueError:
+            return None
+
+        if second is None:
+            # Deprecated one-argument form: any catalog
+            for row in store.rows():
+                if row["objoid"] == oid and row["objsubid"] == 0:
+                    return row["description"]
+            return None
+        catalog = re.sub(r"^pg_catalog\.", "", second.strip().lower())
+        classoid = CATALOG_OIDS.get(catalog)
+        return store.get(classoid, oid) if classoid is not None else None
+
+    def execute_functions(self, sql: str, store: CommentStore) -> dict[str, Any] | None:
+        """Evaluate a SELECT of description functions (None if it is not one)."""
+        calls = self.parse_functions(sql)
+        if calls is None:
+            return None
+
+        return {
+            "success": True,
+            "rows": [[self.describe(call, store) for call in calls]],
+            "columns": [
+                {
+                    "name": call.column_name,
+                    "type_oid": _TEXT,
+                    "type_size": -1,
+                    "type_modifier": -1,
+                    "format_code": 0,
+                }
+                for call in calls
+            ],
+            "row_count": 1,
+            "command": "SELECT",
+            "command_tag": "SELECT 1",
+        }
@@ -0,0 +1,173 @@
+"""
+pg_inherits Catalog Emulation
+
+Emulates PostgreSQL pg_catalog.pg_inherits from IRIS class inheritance.
+
+A persistent IRIS class that extends another persistent class shares its
+extent: rows of the subclass table are also rows of the superclass table,
+which is exactly PostgreSQL table inheritance. Exposing the edges lets schema
+tools draw the hierarchy instead of listing every extent table as unrelated.
+
+Query source:
+SELECT Name, Super, SqlSchemaName, SqlTableName
+FROM %Dictionary.CompiledClass
+WHERE ClassType = 'persistent'
+
+Parents are flagged relhassubclass in pg_class. Children are also flagged
+relispartition (so tools nest them under the parent) unless
+PGWIRE_INHERITANCE_AS_PARTITIONS=false.
+"""
+
+import os
+import re
+from dataclasses import dataclass
+from typing import Any
+
+from .oid_generator import OIDGenerator
+
+HIERARCHY_SQL = (
+    "SELECT Name, Super, SqlSchemaName, SqlTableName "
+    "FROM %Dictionary.CompiledClass WHERE ClassType = 'persistent'"
+)
+
+
+@dataclass
+class PgInherits:
+    """
+    pg_catalog.pg_inherits row.
+
+    PostgreSQL Documentation:
+    https://www.postgresql.org/docs/current/catalog-pg-inherits.html
+    """
+
+    inhrelid: int  # Child table OID (pg_class.oid)
+    inhparent: int  # Parent table OID (pg_class.oid)
+    inhseqno: int  # Position among the child's parents (1-based)
+    inhdetachpending: bool  # Partition detach in progress (always false)
+    child_name: str  # Child relation name (for ::regclass output)
+    parent_name: str  # Parent relation name (for ::regclass output)
+
+
+def inheritance_as_partitions() -> bool:
+    """Whether child extents are reported with relispartition = true."""
+    return os.getenv("PGWIRE_INHERITANCE_AS_PARTITIONS", "true").lower() not in (
+        "false",
+        "0",
+        "no",
+        "off",
+    )
+
+
+class PgInheritsEmulator:
+    """Emulate pg_inherits from IRIS %Dictionary class metadata."""
+
+    COLUMNS = [
+        ("inhrelid", 26),
+        ("inhparent", 26),
+        ("inhseqno", 23),
+        ("inhdetachpending", 16),
+    ]
+
+    def __init__(self, oid_generator: OIDGenerator | None = None):
+        """Initialize pg_inherits emulator."""
+        self.oid_gen = oid_generator or OIDGenerator()
+        self._entries: list[PgInherits] = []
+
+    def load_classes(self, classes: list[tuple[str, str, str, str]]) -> None:
+        """
+        Build inheritance edges from compiled class rows.
+
+        Args:
+            classes: (class name, Super, SQL schema, SQL table) tuples; Super is
+                the comma-separated superclass list
+        """
+        tables = {
+            name: (schema, table)
+            for name, _, schema, table in classes
+            if schema and table and not name.startswith("%")
+        }
+        self._entries = []
+        for name, supers, _, _ in classes:
+            if name not in tables:
+                continue
+            parents = [s.strip() for s in (supers or "").split(",") if s.strip() in tables]
+            child_schema, child_table = tables[name]
+            for seqno, parent in enumerate(parents, start=1):
+                parent_schema, parent_table = tables[parent]
+                self._entries.append(
+                    PgInherits(
+                        inhrelid=self.oid_gen.get_table_oid(child_schema, child_table),
+                        inhparent=self.oid_gen.get_table_oid(parent_schema, parent_table),
+                        inhseqno=seqno,
+                        inhdetachpending=False,
+                        child_name=child_table.lower(),
+                        parent_name=parent_table.lower(),
+                    )
+                )
+
+    def get_all(self) -> list[PgInherits]:
+        """Return all inheritance edges."""
+        return list(self._entries)
+
+    def child_tables(self) -> set[str]:
+        """Lowercase names of tables that inherit from another table."""
+        return {entry.child_name for entry in self._entries}
+
+    def parent_tables(self) -> set[str]:
+        """Lowercase names of tables with inheritance children."""
+        return {entry.parent_name for entry in self._entries}
+
+    @classmethod
+    def get_column_definitions(cls) -> list[dict[str, Any]]:
+        """PostgreSQL column definitions for pg_inherits."""
+        return [{"name": name, "type_oid": type_oid} for name, type_oid in cls.COLUMNS]
+
+    def query(self, sql: str) -> tuple[list[dict[str, Any]], list[tuple[Any, ...]]]:
+        """
+        Answer a simple pg_inherits query.
+
+        Supports `SELECT *` or a list of pg_inherits columns (optionally
+        alias-qualified, `::regclass` casts render the table name) and filters
+        of the form inhrelid = <oid> / inhparent = <oid>.
+
+        Returns:
+            (column definitions, rows)
+        """
+        entries = self._entries
+        for column in ("inhrelid", "inhparent"):
+            oid_filter = re.search(rf"\b{column.upper()}\s*=\s*(\d+)", sql, re.IGNORECASE)
+            if oid_filter:
+                oid = int(oid_filter.group(1))
+                entries = [entry for entry in entries if getattr(entry, column) == oid]
+
+        known = dict(self.COLUMNS)
+        selected = []  # (column, alias, regclass)
+        select_list = re.search(r"^\s*SELECT\s+(.*?)\s+FROM\b", sql, re.IGNORECASE | re.DOTALL)
+        if select_list and select_list.group(1).strip() != "*":
+            for item in select_list.group(1).split(","):
+                parts = re.split(r"\s+AS\s+", item.strip(), flags=re.IGNORECASE)
+                expression = parts[0].strip()
+                regclass = expression.lower().endswith("::regclass")
+                column = expression.split("::")[0].split(".")[-1].strip().lower()
+                if column in known:
+                    alias = parts[-1].strip().lower() if len(parts) > 1 else column
+                    selected.append((column, alias, regclass))
+        if not selected:
+            selected = [(name, name, False) for name, _ in self.COLUMNS]
+
+        columns = [
+            {"name": alias, "type_oid": 2205 if regclass else known[column]}
+            for column, alias, regclass in selected
+        ]
+        rows = []
+        for entry in entries:
+            row = []
+            for column, _, regclass in selected:
+                if regclass and column == "inhrelid":
+                    row.append(entry.child_name)
+                elif regclass and column == "inhparent":
+                    row.append(entry.parent_name)
+                else:
+                    row.append(getattr(entry, column))
+            rows.append(tuple(row))
+        return columns, rows
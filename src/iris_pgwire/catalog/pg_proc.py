@@ -0,0 +1,190 @@
+"""
+pg_proc Catalog Emulation
+
+Emulates PostgreSQL pg_catalog.pg_proc for the functions the bridge adds on
+top of IRIS: the iFind search functions and the iris_system.* $SYSTEM bridge.
+Listing them lets SQL tools discover and autocomplete ifind_match() and friends.
+
+IRIS stored procedures are not exposed. iFind functions are registered in the
+'public' namespace, system functions in 'iris_system', with deterministic OIDs
+from OIDGenerator.
+"""
+
+import re
+from dataclasses import dataclass
+from typing import Any
+
+from ..system_functions import SCHEMA_NAME as SYSTEM_SCHEMA
+from ..system_functions import SYSTEM_FUNCTIONS
+from .oid_generator import OIDGenerator
+
+# Type OIDs used in function signatures
+_TEXT = 25
+_BOOL = 16
+_FLOAT8 = 701
+
+
+@dataclass
+class PgProc:
+    """
+    pg_catalog.pg_proc row (subset of columns).
+
+    PostgreSQL Documentation:
+    https://www.postgresql.org/docs/current/catalog-pg-proc.html
+    """
+
+    oid: int
+    proname: str
+    pronamespace: int  # 2200 = public, generated OID for iris_system
+    proowner: int  # 10 = bootstrap superuser
+    prolang: int  # 14 = sql
+    prokind: str  # 'f' = function
+    prorettype: int
+    pronargs: int
+    proargtypes: str  # oidvector text form ('25 25')
+    proargnames: str | None  # text[] text form ('{col,query}')
+    prosrc: str  # Translation target, shown by \df+ style tools
+    description: str
+
+
+# (name, argument types, argument names, return type, translation, description)
+_BRIDGE_FUNCTIONS = [
+    (
+        "ifind_match",
+        (_TEXT, _TEXT),
+        ("col", "query"),
+        _BOOL,
+        "%ID %FIND search_index(<index>, query)",
+        "iFind search predicate on an indexed column",
+    ),
+    (
+        "ifind_match",
+        (_TEXT, _TEXT, _TEXT),
+        ("col", "query", "index_name"),
+        _BOOL,
+        "%ID %FIND search_index(index_name, query)",
+        "iFind search predicate using an explicit index",
+    ),
+    (
+        "ifind_rank",
+        (_TEXT, _TEXT),
+        ("col", "query"),
+        _FLOAT8,
+        "<table>_<index>Rank(%ID, query)",
+        "iFind relevance rank of a row",
+    ),
+    (
+        "ifind_highlight",
+        (_TEXT, _TEXT),
+        ("col", "query"),
+        _TEXT,
+        "<table>_<index>Highlight(%ID, query)",
+        "Column text with iFind matches highlighted",
+    ),
+]
+
+
+class PgProcEmulator:
+    """Emulate pg_proc with the bridge's registered functions."""
+
+    COLUMNS = [
+        ("oid", 26),
+        ("proname", 19),
+        ("pronamespace", 26),
+        ("proowner", 26),
+        ("prolang", 26),
+        ("prokind", 18),
+        ("prorettype", 26),
+        ("pronargs", 21),
+        ("proargtypes", 30),
+        ("proargnames", 1009),
+        ("prosrc", 25),
+    ]
+
+    def __init__(self, oid_generator: OIDGenerator | None = None):
+        """Initialize pg_proc emulator."""
+        oid_gen = oid_generator or OIDGenerator()
+        system_functions = [
+            (
+                f.name,
+                tuple(_TEXT for _ in f.arg_names),
+                f.arg_names,
+                f.return_type,
+                f"{f.class_name}.{f.method}()",
+                f.description,
+                SYSTEM_SCHEMA,
+            )
+            for f in SYSTEM_FUNCTIONS.values()
+        ]
+        self._functions = []
+        for name, arg_types, arg_names, return_type, source, description, schema in [
+            (*function, "public") for function in _BRIDGE_FUNCTIONS
+        ] + system_functions:
+            signature = f"{name}({','.join(str(t) for t in arg_types)})"
+            self._functions.append(
+                PgProc(
+                    oid=oid_gen.get_oid(schema, "function", signature),
+                    proname=name,
+                    pronamespace=oid_gen.get_namespace_oid(schema),
+                    proowner=10,
+                    prolang=14,
+                    prokind="f",
+                    prorettype=return_type,
+                    pronargs=len(arg_types),
+                    proargtypes=" ".join(str(t) for t in arg_types),
+                    proargnames="{" + ",".join(arg_names) + "}" if arg_names else None,
+                    prosrc=source,
+                    description=description,
+                )
+            )
+
+    def get_all(self) -> list[PgProc]:
+        """Return all registered functions."""
+        return list(self._functions)
+
+    def get_by_name(self, name: str) -> list[PgProc]:
+        """Return all overloads of a function."""
+        return [proc for proc in self._functions if proc.proname == name.lower()]
+
+    @classmethod
+    def get_column_definitions(cls) -> list[dict[str, Any]]:
+        """PostgreSQL column definitions for pg_proc."""
+        return [{"name": name, "type_oid": type_oid} for name, type_oid in cls.COLUMNS]
+
+    def query(self, sql: str) -> tuple[list[dict[str, Any]], list[tuple[Any, ...]]]:
+        """
+        Answer a simple pg_proc query.
+
+        Supports `SELECT *` or a list of pg_proc column names (optionally
+        alias-qualified) and filters of the form proname = 'x' / LIKE 'x%'.
+
+        Returns:
+            (column definitions, rows)
+        """
+        procs = self._functions
+        name_filter = re.search(
+            r"\bPRONAME\s*(=|LIKE|ILIKE)\s*'((?:[^']|'')*)'", sql, re.IGNORECASE
+        )
+        if name_filter:
+            operator, value = name_filter.group(1).upper(), name_filter.group(2).lower()
+            if operator == "=":
+                procs = [proc for proc in procs if proc.proname == value]
+            else:
+                pattern = re.escape(value).replace("%", ".*").replace("_", ".")
+                procs = [proc for proc in procs if re.fullmatch(pattern, proc.proname)]
+
+        known = dict(self.COLUMNS)
+        selected = []
+        select_list = re.search(r"^\s*SELECT\s+(.*?)\s+FROM\b", sql, re.IGNORECASE | re.DOTALL)
+        if select_list and select_list.group(1).strip() != "*":
+            for item in select_list.group(1).split(","):
+                parts = re.split(r"\s+AS\s+", item.strip(), flags=re.IGNORECASE)
+                column = parts[0].split(".")[-1].strip().lower()
+                if column in known:
+                    selected.append((column, parts[-1].strip().lower() if len(parts) > 1 else column))
+        if not selected:
+            selected = [(name, name) for name, _ in self.COLUMNS]
+
+        columns = [{"name": alias, "type_oid": known[column]} for column, alias in selected]
+        rows = [tuple(getattr(proc, column) for column, _ in selected) for proc in procs]
+        return columns, rows
@@ -0,0 +1,552 @@
+"""
+pg_stat_* Statistics View Emulation
+
+Cumulative statistics views for monitoring dashboards (Grafana, pganalyze,
+Datadog's Postgres check). IRIS keeps no per-table scan or tuple counters that
+map onto these views, so the numbers come from the bridge's own accounting
+(stats_hooks.StatsRegistry) of the statements it executed:
+
+- pg_stat_database: one row per database clients connected to. Every
+  statement runs in its own IRIS transaction, so xact_commit/xact_rollback
+  count successful/failed statements; tup_returned/tup_fetched count rows
+  returned by queries and tup_inserted/updated/deleted rows affected by DML.
+  temp_files/temp_bytes count results the bridge spilled to disk
+  (temp_spool.py).
+- pg_stat_user_tables: one row per IRIS table (INFORMATION_SCHEMA.TABLES),
+  with n_tup_ins/upd/del from DML routed through the bridge.
+- pg_stat_io: bridge I/O in PostgreSQL's 8 kB units (op_bytes). The
+  "client backend"/"relation" row reads are result data read from IRIS
+  (read_time = time spent executing those statements) and writes are
+  protocol bytes sent to clients; other backend types report zero.
+- pg_stat_bgwriter: one row; buffers_alloc is the IRIS read volume and
+  buffers_backend the client write volume, checkpoint counters are zero.
+- pg_stat_activity: one row per open session, with its state ("active"
+  while a statement runs) and current or last statement. Replication
+  connections (replication.py) are "walsender" backends, other sessions
+  "client backend".
+- pg_stat_replication: one row per replication connection, with the
+  positions sent to and confirmed by the subscriber and the write/flush/
+  replay lag times (replication.WalSenderProgress). Until a connection
+  streams changes its state is "startup" and its positions NULL.
+- pg_replication_slots: the replication slots (replication_slots.py) and the
+  slots connected subscribers are streaming, with the active connection's
+  pid and the subscriber's flushed position as confirmed_flush_lsn.
+
+Counters that only IRIS internals could provide (block I/O, seq_scan,
+idx_scan, n_live_tup, vacuum/analyze times) are NULL, which dashboards
+render as "no data" rather than a misleading zero. Writes made by other IRIS
+clients are not counted.
+"""
+
+import re
+from datetime import datetime, timezone
+from typing import Any
+
+from ..replication import WALSENDER, WalSenderProgress, WalSenders, format_lsn, get_wal_senders
+from ..stats_hooks import SessionStats, StatsRegistry
+from .oid_generator import OIDGenerator
+
+VIEW_NAMES = (
+    "pg_stat_database",
+    "pg_stat_user_tables",
+    "pg_stat_io",
+    "pg_stat_bgwriter",
+    "pg_stat_activity",
+    "pg_stat_replication",
+    "pg_replication_slots",
+)
+
+_VIEW_REFERENCE = re.compile(
+    r"\bFROM\s+(?:pg_catalog\.)?(" + "|".join(VIEW_NAMES) + r")\b", re.IGNORECASE
+)
+
+# pg_stat_io / pg_stat_bgwriter count in PostgreSQL block-sized operations
+OP_BYTES = 8192
+
+# Type OIDs
+_BOOL = 16
+_OID = 26
+_NAME = 19
+_INT4 = 23
+_INT8 = 20
+_FLOAT8 = 701
+_TEXT = 25
+_XID = 28
+_INET = 869
+_TIMESTAMPTZ = 1184
+_INTERVAL = 1186
+_PG_LSN = 3220
+
+PG_STAT_DATABASE_COLUMNS = [
+    ("datid", _OID),
+    ("datname", _NAME),
+    ("numbackends", _INT4),
+    ("xact_commit", _INT8),
+    ("xact_rollback", _INT8),
+    ("blks_read", _INT8),
+    ("blks_hit", _INT8),
+    ("tup_returned", _INT8),
+    ("tup_fetched", _INT8),
+    ("tup_inserted", _INT8),
+    ("tup_updated", _INT8),
+    ("tup_deleted", _INT8),
+    ("conflicts", _INT8),
+    ("temp_files", _INT8),
+    ("temp_bytes", _INT8),
+    ("deadlocks", _INT8),
+    ("checksum_failures", _INT8),
+    ("checksum_last_failure", _TIMESTAMPTZ),
+    ("blk_read_time", _FLOAT8),
+    ("blk_write_time", _FLOAT8),
+    ("session_time", _FLOAT8),
+    ("active_time", _FLOAT8),
+    ("idle_in_transaction_time", _FLOAT8),
+    ("sessions", _INT8),
+    ("sessions_abandoned", _INT8),
+    ("sessions_fatal", _INT8),
+    ("sessions_killed", _INT8),
+    ("stats_reset", _TIMESTAMPTZ),
+]
+
+PG_STAT_USER_TABLES_COLUMNS = [
+    ("relid", _OID),
+    ("schemaname", _NAME),
+    ("relname", _NAME),
+    ("seq_scan", _INT8),
+    ("last_seq_scan", _TIMESTAMPTZ),
+    ("seq_tup_read", _INT8),
+    ("idx_scan", _INT8),
+    ("last_idx_scan", _TIMESTAMPTZ),
+    ("idx_tup_fetch", _INT8),
+    ("n_tup_ins", _INT8),
+    ("n_tup_upd", _INT8),
+    ("n_tup_del", _INT8),
+    ("n_tup_hot_upd", _INT8),
+    ("n_tup_newpage_upd", _INT8),
+    ("n_live_tup", _INT8),
+    ("n_dead_tup", _INT8),
+    ("n_mod_since_analyze", _INT8),
+    ("n_ins_since_vacuum", _INT8),
+    ("last_vacuum", _TIMESTAMPTZ),
+    ("last_autovacuum", _TIMESTAMPTZ),
+    ("last_analyze", _TIMESTAMPTZ),
+    ("last_autoanalyze", _TIMESTAMPTZ),
+    ("vacuum_count", _INT8),
+    ("autovacuum_count", _INT8),
+    ("analyze_count", _INT8),
+    ("autoanalyze_count", _INT8),
+]
+
+PG_STAT_IO_COLUMNS = [
+    ("backend_type", _TEXT),
+    ("object", _TEXT),
+    ("context", _TEXT),
+    ("reads", _INT8),
+    ("read_time", _FLOAT8),
+    ("writes", _INT8),
+    ("write_time", _FLOAT8),
+    ("writebacks", _INT8),
+    ("writeback_time", _FLOAT8),
+    ("extends", _INT8),
+    ("extend_time", _FLOAT8),
+    ("op_bytes", _INT8),
+    ("hits", _INT8),
+    ("evictions", _INT8),
+    ("reuses", _INT8),
+    ("fsyncs", _INT8),
+    ("fsync_time", _FLOAT8),
+    ("stats_reset", _TIMESTAMPTZ),
+]
+
+PG_STAT_BGWRITER_COLUMNS = [
+    ("checkpoints_timed", _INT8),
+    ("checkpoints_req", _INT8),
+    ("checkpoint_write_time", _FLOAT8),
+    ("checkpoint_sync_time", _FLOAT8),
+    ("buffers_checkpoint", _INT8),
+    ("buffers_clean", _INT8),
+    ("maxwritten_clean", _INT8),
+    ("buffers_backend", _INT8),
+    ("buffers_backend_fsync", _INT8),
+    ("buffers_alloc", _INT8),
+    ("stats_reset", _TIMESTAMPTZ),
+]
+
+PG_STAT_ACTIVITY_COLUMNS = [
+    ("datid", _OID),
+    ("datname", _NAME),
+    ("pid", _INT4),
+    ("leader_pid", _INT4),
+    ("usesysid", _OID),
+    ("usename", _NAME),
+    ("application_name", _TEXT),
+    ("client_addr", _INET),
+    ("client_hostname", _TEXT),
+    ("client_port", _INT4),
+    ("backend_start", _TIMESTAMPTZ),
+    ("xact_start", _TIMESTAMPTZ),
+    ("query_start", _TIMESTAMPTZ),
+    ("state_change", _TIMESTAMPTZ),
+    ("wait_event_type", _TEXT),
+    ("wait_event", _TEXT),
+    ("state", _TEXT),
+    ("backend_xid", _XID),
+    ("backend_xmin", _XID),
+    ("query_id", _INT8),
+    ("query", _TEXT),
+    ("backend_type", _TEXT),
+]
+
+PG_STAT_REPLICATION_COLUMNS = [
+    ("pid", _INT4),
+    ("usesysid", _OID),
+    ("usename", _NAME),
+    ("application_name", _TEXT),
+    ("client_addr", _INET),
+    ("client_hostname", _TEXT),
+    ("client_port", _INT4),
+    ("backend_start", _TIMESTAMPTZ),
+    ("backend_xmin", _XID),
+    ("state", _TEXT),
+    ("sent_lsn", _PG_LSN),
+    ("write_lsn", _PG_LSN),
+    ("flush_lsn", _PG_LSN),
+    ("replay_lsn", _PG_LSN),
+    ("write_lag", _INTERVAL),
+    ("flush_lag", _INTERVAL),
+    ("replay_lag", _INTERVAL),
+    ("sync_priority", _INT4),
+    ("sync_state", _TEXT),
+    ("reply_time", _TIMESTAMPTZ),
+]
+
+PG_REPLICATION_SLOTS_COLUMNS = [
+    ("slot_name", _NAME),
+    ("plugin", _NAME),
+    ("slot_type", _TEXT),
+    ("datoid", _OID),
+    ("database", _NAME),
+    ("temporary", _BOOL),
+    ("active", _BOOL),
+    ("active_pid", _INT4),
+    ("xmin", _XID),
+    ("catalog_xmin", _XID),
+    ("restart_lsn", _PG_LSN),
+    ("confirmed_flush_lsn", _PG_LSN),
+    ("wal_status", _TEXT),
+    ("safe_wal_size", _INT8),
+    ("two_phase", _BOOL),
+    ("conflicting", _BOOL),
+]
+
+# Backend types listed in pg_stat_io besides the client backend row
+_IDLE_IO_BACKENDS = ("autovacuum worker", "background writer", "checkpointer", WALSENDER)
+
+USER_TABLES_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES "
+    "WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT %STARTSWITH '%' "
+    "AND TABLE_SCHEMA <> 'INFORMATION_SCHEMA'"
+)
+
+
+def _blocks(n_bytes: int) -> int:
+    return -(-n_bytes // OP_BYTES)
+
+
+def _timestamp(epoch: float | None) -> str | None:
+    if epoch is None:
+        return None
+    return datetime.fromtimestamp(epoch, timezone.utc).strftime("%Y-%m-%d %H:%M:%S.%f+00")
+
+
+def _lsn(position: int | None) -> str | None:
+    return None if position is None else format_lsn(position)
+
+
+def _interval(seconds: float | None) -> str | None:
+    if seconds is None:
+        return None
+    minutes, micros = divmod(round(seconds * 1_000_000), 60_000_000)
+    hours, minutes = divmod(minutes, 60)
+    return f"{hours:02d}:{minutes:02d}:{micros // 1_000_000:02d}.{micros % 1_000_000:06d}"
+
+
+def referenced_view(sql: str) -> str | None:
+    """pg_stat view a SELECT reads from (None for any other statement)."""
+    match = _VIEW_REFERENCE.search(sql)
+    return match.group(1).lower() if match else None
+
+
+class PgStatEmulator:
+    """Build pg_stat_* view rows from bridge counters and open sessions."""
+
+    def __init__(
+        self,
+        registry: StatsRegistry,
+        oid_generator: OIDGenerator | None = None,
+        iris_schema: str = "SQLUser",
+        wal_senders: WalSenders | None = None,
+    ):
+        self.registry = registry
+        self.oid_gen = oid_generator or OIDGenerator()
+        self.iris_schema = iris_schema
+        self.wal_senders = wal_senders or get_wal_senders()
+
+    def database_rows(self) -> list[dict[str, Any]]:
+        """pg_stat_database rows (databases that have seen a session)."""
+        rows = []
+        for activity in self.registry.database_activity():
+            row = dict.fromkeys(name for name, _ in PG_STAT_DATABASE_COLUMNS)
+            row.update(
+                datid=self.oid_gen.get_oid("", "database", activity.datname),
+                datname=activity.datname,
+                numbackends=self.registry.active_sessions(activity.datname),
+                xact_commit=activity.xact_commit,
+                xact_rollback=activity.xact_rollback,
+                tup_returned=activity.tup_returned,
+                tup_fetched=activity.tup_returned,
+                tup_inserted=activity.tup_inserted,
+                tup_updated=activity.tup_updated,
+                tup_deleted=activity.tup_deleted,
+                conflicts=0,
+                temp_files=activity.temp_files,
+                temp_bytes=activity.temp_bytes,
+                deadlocks=0,
+                session_time=activity.session_time_ms,
+                active_time=activity.active_time_ms,
+                sessions=activity.sessions,
+                stats_reset=_timestamp(self.registry.stats_reset),
+            )
+            rows.append(row)
+        return rows
+
+    def user_table_rows(self, tables: list[tuple[str, str]]) -> list[dict[str, Any]]:
+        """
+        pg_stat_user_tables rows.
+
+        Args:
+            tables: (IRIS schema, table) of the namespace's user tables; tables
+                written through the bridge but missing here are added
+        """
+        # "public.orders" and "SQLUser.orders" are the same table
+        activity: dict[tuple[str, str], list[int]] = {}
+        for (schema, table), counters in self.registry.table_activity().items():
+            totals = activity.setdefault((self._pg_schema(schema), table), [0, 0, 0])
+            totals[0] += counters.n_tup_ins
+            totals[1] += counters.n_tup_upd
+            totals[2] += counters.n_tup_del
+
+        listed: dict[tuple[str, str], tuple[str, str]] = {}
+        for schema, table in tables:
+            listed[(self._pg_schema(schema), table.lower())] = (schema, table)
+        for schema, table in activity:
+            listed.setdefault((schema, table), (self._iris_schema(schema), table))
+
+        rows = []
+        for key, (iris_schema, iris_table) in sorted(listed.items()):
+            inserted, updated, deleted = activity.get(key, (0, 0, 0))
+            row = dict.fromkeys(name for name, _ in PG_STAT_USER_TABLES_COLUMNS)
+            row.update(
+                relid=self.oid_gen.get_table_oid(iris_schema, iris_table),
+                schemaname=key[0],
+                relname=key[1],
+                n_tup_ins=inserted,
+                n_tup_upd=updated,
+                n_tup_del=deleted,
+                n_tup_hot_upd=0,
+                n_tup_newpage_upd=0,
+            )
+            rows.append(row)
+        return rows
+
+    def io_rows(self) -> list[dict[str, Any]]:
+        """pg_stat_io rows (bridge I/O on the client backend row)."""
+        io = self.registry.io
+        stats_reset = _timestamp(self.registry.stats_reset)
+        rows = [
+            {
+                "backend_type": "client backend",
+                "object": "relation",
+                "context": "normal",
+                "reads": _blocks(io.iris_bytes_read),
+                "read_time": io.iris_read_time_ms,
+                "writes": _blocks(io.client_bytes_sent),
+                "write_time": 0.0,
+                "writebacks": 0,
+                "writeback_time": 0.0,
+                "extends": 0,
+                "extend_time": 0.0,
+                "op_bytes": OP_BYTES,
+                "hits": 0,
+                "evictions": 0,
+                "reuses": None,
+                "fsyncs": 0,
+                "fsync_time": 0.0,
+                "stats_reset": stats_reset,
+            }
+        ]
+        for backend_type in _IDLE_IO_BACKENDS:
+            row = dict.fromkeys((name for name, _ in PG_STAT_IO_COLUMNS), 0)
+            row.update(
+                backend_type=backend_type,
+                object="relation",
+                context="normal",
+                op_bytes=OP_BYTES,
+                reuses=None,
+                stats_reset=stats_reset,
+            )
+            rows.append(row)
+        return rows
+
+    def bgwriter_rows(self) -> list[dict[str, Any]]:
+        """The single pg_stat_bgwriter row."""
+        row = dict.fromkeys((name for name, _ in PG_STAT_BGWRITER_COLUMNS), 0)
+        row.update(
+            checkpoint_write_time=0.0,
+            checkpoint_sync_time=0.0,
+            buffers_backend=_blocks(self.registry.io.client_bytes_sent),
+            buffers_alloc=_blocks(self.registry.io.iris_bytes_read),
+            stats_reset=_timestamp(self.registry.stats_reset),
+        )
+        return [row]
+
+    def activity_rows(self) -> list[dict[str, Any]]:
+        """pg_stat_activity rows (open sessions)."""
+        rows = []
+        for session in self.registry.sessions():
+            row = dict.fromkeys(name for name, _ in PG_STAT_ACTIVITY_COLUMNS)
+            row.update(
+                self._backend_columns(session),
+                datid=(
+                    self.oid_gen.get_oid("", "database", session.database)
+                    if session.database
+                    else None
+                ),
+                datname=session.database,
+                query_start=_timestamp(session.query_started_at),
+                state_change=_timestamp(
+                    session.query_started_at if session.state == "active" else session.last_query_at
+                ),
+                state=session.state,
+                query=session.query or "",
+                backend_type=session.backend_type,
+            )
+            rows.append(row)
+        return rows
+
+    def replication_rows(self) -> list[dict[str, Any]]:
+        """pg_stat_replication rows (replication connections)."""
+        rows = []
+        for session, progress in self._wal_senders():
+            row = dict.fromkeys(name for name, _ in PG_STAT_REPLICATION_COLUMNS)
+            row.update(
+                self._backend_columns(session),
+                state=progress.state,
+                sent_lsn=_lsn(progress.sent_lsn),
+                write_lsn=_lsn(progress.write_lsn),
+                flush_lsn=_lsn(progress.flush_lsn),
+                replay_lsn=_lsn(progress.replay_lsn),
+                write_lag=_interval(progress.write_lag),
+                flush_lag=_interval(progress.flush_lag),
+                replay_lag=_interval(progress.replay_lag),
+                sync_priority=0,
+                sync_state="async",
+                reply_time=_timestamp(progress.reply_time),
+            )
+            rows.append(row)
+        return rows
+
+    def replication_slot_rows(self, slots: list | None = None) -> list[dict[str, Any]]:
+        """
+        pg_replication_slots rows: the defined slots (ReplicationSlot, see
+        replication_slots.py) and the slots streamed by connected subscribers.
+        """
+        streaming = {
+            progress.slot_name: (session, progress)
+            for session, progress in self._wal_senders()
+            if progress.slot_name is not None
+        }
+        rows = []
+        for slot in slots or []:
+            session, progress = streaming.pop(slot.name, (None, None))
+            flushed = max(slot.confirmed_flush_lsn, (progress and progress.flush_lsn) or 0)
+            rows.append(
+                self._slot_row(
+                    slot.name,
+                    slot.plugin,
+                    slot.database if slot.logical else None,
+                    temporary=slot.temporary,
+                    active_pid=session.backend_pid if session else None,
+                    restart_lsn=max(slot.restart_lsn, flushed) or None,
+                    confirmed_flush_lsn=flushed or None,
+                )
+            )
+        for name, (session, progress) in streaming.items():
+            rows.append(
+                self._slot_row(
+                    name,
+                    progress.plugin,
+                    session.database,
+                    temporary=False,
+                    active_pid=session.backend_pid,
+                    restart_lsn=progress.flush_lsn,
+                    confirmed_flush_lsn=progress.flush_lsn,
+                )
+            )
+        return rows
+
+    def _slot_row(
+        self,
+        name: str,
+        plugin: str | None,
+        database: str | None,
+        temporary: bool,
+        active_pid: int | None,
+        restart_lsn: int | None,
+        confirmed_flush_lsn: int | None,
+    ) -> dict[str, Any]:
+        """A pg_replication_slots row; database is None for a physical slot."""
+        logical = database is not None
+        row = dict.fromkeys(column for column, _ in PG_REPLICATION_SLOTS_COLUMNS)
+        row.update(
+            slot_name=name,
+            plugin=plugin if logical else None,
+            slot_type="logical" if logical else "physical",
+            datoid=self.oid_gen.get_oid("", "database", database) if logical else None,
+            database=database,
+            temporary=temporary,
+            active=active_pid is not None,
+            active_pid=active_pid,
+            restart_lsn=_lsn(restart_lsn),
+            confirmed_flush_lsn=_lsn(confirmed_flush_lsn) if logical else None,
+            wal_status="reserved",
+            two_phase=False,
+            conflicting=False if logical else None,
+        )
+        return row
+
+    def _wal_senders(self) -> list[tuple[SessionStats, WalSenderProgress]]:
+        """Open replication connections with their streaming progress."""
+        return [
+            (session, self.wal_senders.get(session.backend_pid) or WalSenderProgress())
+            for session in self.registry.sessions()
+            if session.backend_type == WALSENDER
+        ]
+
+    def _backend_columns(self, session: SessionStats) -> dict[str, Any]:
+        """Columns identifying a session's backend, shared by activity and replication."""
+        return {
+            "pid": session.backend_pid,
+            "usesysid": self.oid_gen.get_oid("", "role", session.user) if session.user else None,
+            "usename": session.user,
+            "application_name": session.application_name or "",
+            "client_addr": session.client_addr,
+            "client_port": session.client_port,
+            "backend_start": _timestamp(session.connected_at),
+        }
+
+    def _pg_schema(self, schema: str) -> str:
+        return "public" if schema.lower() == self.iris_schema.lower() else schema.lower()
+
+    def _iris_schema(self, schema: str) -> str:
+        return self.iris_schema if schema == "public" else schema
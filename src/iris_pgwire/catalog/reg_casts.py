@@ -0,0 +1,200 @@
+"""
+Object Identifier Casts (::regclass, ::regtype, ::regproc, ::regnamespace)
+
+Introspection queries and ORMs name catalog objects through OID alias types:
+
+    SELECT attname FROM pg_attribute WHERE attrelid = 'orders'::regclass
+    SELECT 'int4'::regtype::oid
+
+IRIS has no such types, so literal casts are resolved before the statement
+is translated:
+
+- name literal -> OID: 'orders'::regclass becomes the emulated table OID
+  (the same OID pg_class and pg_attribute report), 'varchar'::regtype the
+  PostgreSQL type OID, 'ifind_match'::regproc the pg_proc OID and
+  'public'::regnamespace the namespace OID. A trailing ::oid is dropped.
+- OID literal -> name: 1043::regtype becomes 'character varying', and
+  relation, function and namespace OIDs render as their names when known
+  (unknown OIDs render as the number, as in PostgreSQL).
+
+Relation names resolve like unquoted identifiers (case-folded, unqualified
+names in the default IRIS schema, public meaning that schema); existence is
+not checked, so a cast of a missing table yields an OID no catalog row has.
+Unknown types and functions are errors (42704 / 42883). Casts of columns
+and parameters are left alone: the catalog emulators render those.
+"""
+
+import re
+
+from ..type_mapping import OID_TO_TYPE
+from .oid_generator import OIDGenerator
+from .pg_proc import PgProcEmulator
+
+REG_TYPES = ("regclass", "regtype", "regproc", "regprocedure", "regnamespace")
+
+# A literal consumed whole (so casts inside strings are never rewritten),
+# optionally followed by a reg* cast; or a number with a reg* cast
+_REG_CAST = re.compile(
+    r"(?P<literal>'(?:[^']|'')*'|(?<![\w$.])\d+\b)"
+    r"(?:\s*::\s*(?P<type>" + "|".join(REG_TYPES) + r")\b(?P<oid>\s*::\s*oid\b)?)?",
+    re.IGNORECASE,
+)
+
+# Spellings PostgreSQL accepts besides the canonical and internal type names
+_TYPE_ALIASES = {
+    "int": 23,
+    "float": 701,
+    "decimal": 1700,
+    "char": 1042,
+    "varchar": 1043,
+    "bool": 16,
+}
+
+# Types absent from the IRIS type mapping that catalog queries still name
+_EXTRA_TYPES = {
+    18: ("\"char\"", "char"),
+    19: ("name", "name"),
+    24: ("regproc", "regproc"),
+    26: ("oid", "oid"),
+    1007: ("integer[]", "_int4"),
+    1009: ("text[]", "_text"),
+    1016: ("bigint[]", "_int8"),
+    1186: ("interval", "interval"),
+    2205: ("regclass", "regclass"),
+    2206: ("regtype", "regtype"),
+    4089: ("regnamespace", "regnamespace"),
+}
+
+# Relation OIDs resolved so far, for OID -> name casts
+_relation_names: dict[int, str] = {}
+
+
+class UndefinedRegName(LookupError):
+    """Type or function named in a reg* cast does not exist."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+def _type_oids() -> dict[str, int]:
+    names = {}
+    for oid, (data_type, udt_name) in {**OID_TO_TYPE, **_EXTRA_TYPES}.items():
+        names.setdefault(data_type.lower(), oid)
+        names.setdefault(udt_name.lower(), oid)
+    return {**names, **_TYPE_ALIASES}
+
+
+def _unquote_identifier(identifier: str) -> str:
+    identifier = identifier.strip()
+    if len(identifier) >= 2 and identifier[0] == identifier[-1] == '"':
+        return identifier[1:-1].replace('""', '"')
+    return identifier.lower()
+
+
+def _split_qualified(name: str) -> tuple[str | None, str]:
+    """('schema', 'object') for schema.object, (None, 'object') otherwise."""
+    parts = re.findall(r'"(?:[^"]|"")*"|[^."]+', name)
+    if len(parts) == 2:
+        return _unquote_identifier(parts[0]), _unquote_identifier(parts[1])
+    return None, _unquote_identifier(name)
+
+
+def has_reg_cast(sql: str) -> bool:
+    """Cheap pre-check before RegCastResolver.resolve()."""
+    return "::reg" in sql.lower()
+
+
+class RegCastResolver:
+    """Resolve reg* casts of literals to OIDs and OIDs back to names."""
+
+    def __init__(self, oid_generator: OIDGenerator | None = None, iris_schema: str = "SQLUser"):
+        self.oid_gen = oid_generator or OIDGenerator()
+        self.iris_schema = iris_schema
+        self._type_oids = _type_oids()
+        self._functions = PgProcEmulator(self.oid_gen).get_all()
+
+    def resolve(self, sql: str) -> str:
+        """
+        Rewrite the reg* casts of literals in a statement.
+
+        Raises:
+            UndefinedRegName: unknown type (42704) or function (42883)
+        """
+
+        def replace(match: re.Match) -> str:
+            reg_type = match.group("type")
+            if reg_type is None:
+                return match.group(0)
+            literal = match.group("literal")
+            reg_type = reg_type.lower()
+            if literal.startswith("'"):
+                text = literal[1:-1].replace("''", "'").strip()
+                if not text.isdigit():
+                    return str(self.to_oid(reg_type, text))
+                oid = int(text)
+            else:
+                oid = int(literal)
+            if match.group("oid"):
+                return str(oid)
+            name = self.to_name(reg_type, oid)
+            return "'" + name.replace("'", "''") + "'"
+
+        return _REG_CAST.sub(replace, sql)
+
+    def to_oid(self, reg_type: str, name: str) -> int:
+        """OID of a relation, type, function or namespace name."""
+        if reg_type == "regclass":
+            return self.relation_oid(name)
+        if reg_type == "regtype":
+            return self.type_oid(name)
+        if reg_type == "regnamespace":
+            return self.oid_gen.get_namespace_oid(_unquote_identifier(name))
+        return self.function_oid(name)
+
+    def to_name(self, reg_type: str, oid: int) -> str:
+        """Display name for an OID (the number itself when unknown)."""
+        if reg_type == "regclass":
+            return _relation_names.get(oid, str(oid))
+        if reg_type == "regtype":
+            known = {**OID_TO_TYPE, **_EXTRA_TYPES}.get(oid)
+            return known[0] if known else str(oid)
+        if reg_type == "regnamespace":
+            for name, namespace_oid in OIDGenerator.WELL_KNOWN_NAMESPACES.items():
+                if namespace_oid == oid:
+                    return name
+            return str(oid)
+        for function in self._functions:
+            if function.oid == oid:
+                return function.proname
+        return str(oid)
+
+    def relation_oid(self, name: str) -> int:
+        schema, table = _split_qualified(name)
+        iris_schema = self.iris_schema if schema in (None, "public") else schema
+        oid = self.oid_gen.get_table_oid(iris_schema, table)
+        # pg_class shows the default schema as public, which is on the search_path
+        _relation_names[oid] = table if schema in (None, "public") else f"{schema}.{table}"
+        return oid
+
+    def type_oid(self, name: str) -> int:
+        normalized = re.sub(r"\s+", " ", name.strip().lower())
+        normalized = re.sub(r"^pg_catalog\.", "", normalized)
+        # varchar(255), numeric(10,2): the modifier does not change the type
+        normalized = re.sub(r"\s*\([\d\s,]*\)", "", normalized)
+        if normalized not in self._type_oids:
+            raise UndefinedRegName(
+                f'type "{name}" does not exist', "42704", "undefined_object"
+            )
+        return self._type_oids[normalized]
+
+    def function_oid(self, name: str) -> int:
+        # regprocedure input carries the argument list: name(text, text)
+        _, function_name = _split_qualified(name.split("(", 1)[0])
+        matches = [f for f in self._functions if f.proname == function_name]
+        if not matches:
+            raise UndefinedRegName(
+                f'function "{name}" does not exist', "42883", "undefined_function"
+            )
+        return matches[0].oid
@@ -0,0 +1,242 @@
+"""
+Schema Cache
+
+Catalog emulation (pg_tables, pg_views, pg_indexes, pg_stat_user_tables,
+pg_partitioned_table) is built from IRIS dictionary listings -
+INFORMATION_SCHEMA.TABLES, INDEXES, COLUMNS - and catalog-heavy clients
+(Npgsql's type loading, Metabase sync, DBeaver's navigator) read those views
+on every connection. The listings are cached per IRIS namespace and
+invalidated when the dictionary changes:
+
+- a change stamp, the count and latest TimeChanged of the compiled classes
+  (%Dictionary.CompiledClass), is read at most once per
+  PGWIRE_SCHEMA_CACHE_CHECK_INTERVAL seconds (default 2); when it differs the
+  namespace's listings are dropped. A table created, altered or dropped
+  outside the bridge is therefore seen within the interval.
+- DDL and GRANT / REVOKE run through the bridge drop the listings at once.
+- 0 checks the stamp before every cached listing.
+
+Whole results of well-known driver catalog queries are kept alongside the
+listings and invalidated with them (see catalog/fast_path.py), and so are the
+result columns of described prepared statements: drivers with statement
+caches (pgx, Npgsql, asyncpg) describe each statement once per connection,
+and the bridge can only describe one by running it, so the columns a
+statement text returns are kept for the next connection that prepares it.
+
+INFORMATION_SCHEMA only lists what the IRIS login may see, so with per-user
+backend logins (PGWIRE_BACKEND_AUTH_MODE) each user has its own entries. If
+the stamp cannot be read (no %Dictionary access), listings are not cached.
+PGWIRE_SCHEMA_CACHE=off disables the cache.
+"""
+
+import os
+import re
+import time
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass, field
+from typing import Any
+
+import structlog
+
+from ..transactional_ddl import ddl_command
+
+logger = structlog.get_logger()
+
+DEFAULT_CHECK_INTERVAL = 2.0
+
+STAMP_SQL = "SELECT COUNT(*), MAX(TimeChanged) FROM %Dictionary.CompiledClass"
+
+_PRIVILEGE_CHANGE = re.compile(r"^\s*(GRANT|REVOKE)\b", re.IGNORECASE)
+
+
+def changes_schema(sql: str) -> bool:
+    """Whether a statement can change what the dictionary listings return."""
+    return ddl_command(sql) is not None or bool(_PRIVILEGE_CHANGE.match(sql))
+
+
+def _check_interval() -> float:
+    try:
+        interval = os.getenv("PGWIRE_SCHEMA_CACHE_CHECK_INTERVAL", DEFAULT_CHECK_INTERVAL)
+        return max(0.0, float(interval))
+    except ValueError:
+        return DEFAULT_CHECK_INTERVAL
+
+
+@dataclass
+class _NamespaceEntry:
+    stamp: tuple | None = None
+    checked_at: float = 0.0
+    listings: dict[str, list[tuple]] = field(default_factory=dict)
+    results: dict[str, dict[str, Any]] = field(default_factory=dict)  # Catalog fast path
+    descriptions: dict[str, list[dict[str, Any]]] = field(default_factory=dict)
+
+    def clear(self) -> None:
+        self.listings.clear()
+        self.results.clear()
+        self.descriptions.clear()
+
+
+class SchemaCache:
+    """Dictionary listings by (namespace, IRIS login), checked against the change stamp."""
+
+    def __init__(self, enabled: bool | None = None, check_interval: float | None = None):
+        if enabled is None:
+            enabled = os.getenv("PGWIRE_SCHEMA_CACHE", "on").strip().lower() not in (
+                "off",
+                "false",
+                "0",
+            )
+        self.enabled = enabled
+        self.check_interval = _check_interval() if check_interval is None else check_interval
+        self._entries: dict[tuple[str, str | None], _NamespaceEntry] = {}
+        self.hits = 0
+        self.misses = 0
+        self.describe_hits = 0
+        self.describe_misses = 0
+
+    async def listing(
+        self,
+        namespace: str,
+        login: str | None,
+        sql: str,
+        run: Callable[[str], Awaitable[dict[str, Any]]],
+    ) -> dict[str, Any]:
+        """
+        Result of a dictionary listing, from the cache while the namespace's
+        dictionary is unchanged.
+
+        Args:
+            namespace: IRIS namespace the listing reads
+            login: IRIS login the listing runs as (None = service account)
+            sql: Listing query
+            run: Executes a query and returns the executor's result dict;
+                failed listings are returned as they are and not cached
+        """
+        if not self.enabled:
+            return await run(sql)
+
+        entry = await self._entry(namespace, login, run)
+        rows = entry.listings.get(sql)
+        if rows is not None:
+            self.hits += 1
+            return {"success": True, "rows": rows, "columns": [], "row_count": len(rows)}
+
+        self.misses += 1
+        result = await run(sql)
+        if result.get("success") and entry.stamp is not None:
+            entry.listings[sql] = [tuple(row) for row in result.get("rows") or []]
+        return result
+
+    async def result(
+        self,
+        namespace: str,
+        login: str | None,
+        key: str,
+        compute: Callable[[], Awaitable[dict[str, Any]]],
+        run: Callable[[str], Awaitable[dict[str, Any]]],
+    ) -> dict[str, Any]:
+        """
+        Whole result of a recognized catalog query (see catalog/fast_path.py),
+        from the cache while the namespace's dictionary is unchanged.
+
+        Args:
+            key: The query's cache key (text and parameters)
+            compute: Executes the query; failed results are not cached
+            run: Executes a query (the change stamp)
+        """
+        if not self.enabled:
+            return await compute()
+
+        entry = await self._entry(namespace, login, run)
+        cached = entry.results.get(key)
+        if cached is not None:
+            self.hits += 1
+            return {**cached, "rows": list(cached["rows"])}
+
+        self.misses += 1
+        result = await compute()
+        if result.get("success") and entry.stamp is not None:
+            rows = [tuple(row) for row in result.get("rows") or []]
+            entry.results[key] = {**result, "rows": rows}
+        return result
+
+    async def description(
+        self,
+        namespace: str,
+        login: str | None,
+        key: str,
+        describe: Callable[[], Awaitable[dict[str, Any]]],
+        run: Callable[[str], Awaitable[dict[str, Any]]],
+    ) -> tuple[list[dict[str, Any]] | None, bool]:
+        """
+        Result columns of a prepared statement, from the cache while the
+        namespace's dictionary is unchanged.
+
+        Args:
+            key: The statement's cache key (text and parameter types)
+            describe: Runs the statement; failures and results without
+                columns are not cached
+            run: Executes a query (the change stamp)
+
+        Returns:
+            The columns (None when the statement returns none) and whether
+            they came from the cache
+        """
+        if not self.enabled:
+            return (await describe()).get("columns") or None, False
+
+        entry = await self._entry(namespace, login, run)
+        columns = entry.descriptions.get(key)
+        if columns is not None:
+            self.describe_hits += 1
+            return [dict(column) for column in columns], True
+
+        self.describe_misses += 1
+        result = await describe()
+        columns = result.get("columns") if result.get("success") else None
+        if columns and entry.stamp is not None:
+            entry.descriptions[key] = [dict(column) for column in columns]
+        return columns or None, False
+
+    def forget_description(self, namespace: str, login: str | None, key: str) -> None:
+        """Drop one statement's cached columns."""
+        entry = self._entries.get((namespace.upper(), login))
+        if entry is not None:
+            entry.descriptions.pop(key, None)
+
+    async def _entry(self, namespace: str, login: str | None, run) -> _NamespaceEntry:
+        """The namespace's entry, cleared when the change stamp has moved."""
+        entry = self._entries.setdefault((namespace.upper(), login), _NamespaceEntry())
+        now = time.monotonic()
+        if entry.stamp is None or now - entry.checked_at >= self.check_interval:
+            stamp = await self._stamp(run)
+            if stamp is None or stamp != entry.stamp:
+                entry.clear()
+            entry.stamp, entry.checked_at = stamp, now
+        return entry
+
+    @staticmethod
+    async def _stamp(run) -> tuple | None:
+        result = await run(STAMP_SQL)
+        if not result.get("success") or not result.get("rows"):
+            logger.debug("Dictionary change stamp unavailable", error=result.get("error"))
+            return None
+        return tuple(result["rows"][0])
+
+    def invalidate(self, namespace: str | None = None) -> None:
+        """Drop the cached listings of a namespace (all namespaces when None)."""
+        for (entry_namespace, _), entry in self._entries.items():
+            if namespace is None or entry_namespace == namespace.upper():
+                entry.clear()
+                entry.stamp = None
+
+
+_schema_cache: SchemaCache | None = None
+
+
+def get_schema_cache() -> SchemaCache:
+    """Process-wide schema cache, shared by the executors of all sessions."""
+    global _schema_cache
+    if _schema_cache is None:
+        _schema_cache = SchemaCache()
+    return _schema_cache
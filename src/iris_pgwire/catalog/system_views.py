@@ -0,0 +1,205 @@
+"""
+pg_tables / pg_views / pg_indexes / pg_matviews Emulation
+
+The convenience views PostgreSQL layers over pg_class, used by ad-hoc
+scripts and ORM helpers ("does table x exist?", "which indexes does y
+have?"), built from IRIS INFORMATION_SCHEMA:
+
+- pg_tables: base tables (INFORMATION_SCHEMA.TABLES); hasindexes is true
+  when INFORMATION_SCHEMA.INDEXES lists an index for the table.
+- pg_views: views with their IRIS view definition.
+- pg_indexes: one row per IRIS index, indexdef rendered as the equivalent
+  PostgreSQL CREATE [UNIQUE] INDEX ... USING btree (...) statement.
+- pg_matviews: always empty (IRIS has no materialized views).
+
+Names follow the rest of the catalog emulation: the default IRIS schema
+(SQLUser) is reported as public, schema and object names are lowercased.
+Only single-view SELECTs are answered here (see view_query.py); queries
+joining these views with other catalogs keep their existing handling.
+"""
+
+import re
+from typing import Any
+
+VIEW_NAMES = ("pg_tables", "pg_views", "pg_indexes", "pg_matviews")
+
+_VIEW_REFERENCE = re.compile(
+    r"\bFROM\s+(?:pg_catalog\.)?(" + "|".join(VIEW_NAMES) + r")\b", re.IGNORECASE
+)
+_JOIN = re.compile(r"\bJOIN\b|\bFROM\s+[\w.\"]+(?:\s+(?:AS\s+)?\w+)?\s*,", re.IGNORECASE)
+
+# Type OIDs
+_BOOL = 16
+_NAME = 19
+_TEXT = 25
+
+PG_TABLES_COLUMNS = [
+    ("schemaname", _NAME),
+    ("tablename", _NAME),
+    ("tableowner", _NAME),
+    ("tablespace", _NAME),
+    ("hasindexes", _BOOL),
+    ("hasrules", _BOOL),
+    ("hastriggers", _BOOL),
+    ("rowsecurity", _BOOL),
+]
+
+PG_VIEWS_COLUMNS = [
+    ("schemaname", _NAME),
+    ("viewname", _NAME),
+    ("viewowner", _NAME),
+    ("definition", _TEXT),
+]
+
+PG_INDEXES_COLUMNS = [
+    ("schemaname", _NAME),
+    ("tablename", _NAME),
+    ("indexname", _NAME),
+    ("tablespace", _NAME),
+    ("indexdef", _TEXT),
+]
+
+PG_MATVIEWS_COLUMNS = [
+    ("schemaname", _NAME),
+    ("matviewname", _NAME),
+    ("matviewowner", _NAME),
+    ("tablespace", _NAME),
+    ("hasindexes", _BOOL),
+    ("ispopulated", _BOOL),
+    ("definition", _TEXT),
+]
+
+VIEW_COLUMNS = {
+    "pg_tables": PG_TABLES_COLUMNS,
+    "pg_views": PG_VIEWS_COLUMNS,
+    "pg_indexes": PG_INDEXES_COLUMNS,
+    "pg_matviews": PG_MATVIEWS_COLUMNS,
+}
+
+TABLES_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE, OWNER FROM INFORMATION_SCHEMA.TABLES "
+    "WHERE TABLE_TYPE IN ('BASE TABLE', 'VIEW') ORDER BY TABLE_SCHEMA, TABLE_NAME"
+)
+VIEWS_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, VIEW_DEFINITION FROM INFORMATION_SCHEMA.VIEWS "
+    "ORDER BY TABLE_SCHEMA, TABLE_NAME"
+)
+INDEXES_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, COLUMN_NAME, PRIMARY_KEY, NON_UNIQUE "
+    "FROM INFORMATION_SCHEMA.INDEXES "
+    "ORDER BY TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, ORDINAL_POSITION"
+)
+
+# IRIS listings each view needs, in the order build_rows() takes them
+VIEW_SOURCES = {
+    "pg_tables": (TABLES_SQL, INDEXES_SQL),
+    "pg_views": (TABLES_SQL, VIEWS_SQL),
+    "pg_indexes": (INDEXES_SQL,),
+    "pg_matviews": (),
+}
+
+
+def referenced_view(sql: str) -> str | None:
+    """Convenience view a single-view SELECT reads (None otherwise)."""
+    match = _VIEW_REFERENCE.search(sql)
+    if not match or _JOIN.search(sql):
+        return None
+    return match.group(1).lower()
+
+
+class SystemViewEmulator:
+    """Build pg_tables / pg_views / pg_indexes rows from IRIS listings."""
+
+    def __init__(self, iris_schema: str = "SQLUser"):
+        self.iris_schema = iris_schema
+
+    def build_rows(self, view: str, *listings: list[tuple]) -> list[dict[str, Any]]:
+        """
+        Rows of one view.
+
+        Args:
+            view: One of VIEW_NAMES
+            listings: Rows of the VIEW_SOURCES queries for the view, in order
+        """
+        if view == "pg_tables":
+            return self.table_rows(*listings)
+        if view == "pg_views":
+            return self.view_rows(*listings)
+        if view == "pg_indexes":
+            return self.index_rows(*listings)
+        return []
+
+    def table_rows(self, tables: list[tuple], indexes: list[tuple]) -> list[dict[str, Any]]:
+        """pg_tables rows from TABLES_SQL and INDEXES_SQL listings."""
+        indexed = {
+            (self._pg_schema(row[0]), row[1].lower())
+            for row in indexes
+            if not self._internal_index(row[2])
+        }
+        rows = []
+        for schema, table, table_type, owner in tables:
+            if table_type != "BASE TABLE":
+                continue
+            key = (self._pg_schema(schema), table.lower())
+            rows.append(
+                {
+                    "schemaname": key[0],
+                    "tablename": key[1],
+                    "tableowner": owner,
+                    "tablespace": None,
+                    "hasindexes": key in indexed,
+                    "hasrules": False,
+                    "hastriggers": False,
+                    "rowsecurity": False,
+                }
+            )
+        return rows
+
+    def view_rows(self, tables: list[tuple], views: list[tuple]) -> list[dict[str, Any]]:
+        """pg_views rows from TABLES_SQL (owners) and VIEWS_SQL listings."""
+        owners = {(schema, name): owner for schema, name, table_type, owner in tables}
+        return [
+            {
+                "schemaname": self._pg_schema(schema),
+                "viewname": name.lower(),
+                "viewowner": owners.get((schema, name)),
+                "definition": definition,
+            }
+            for schema, name, definition in views
+        ]
+
+    def index_rows(self, indexes: list[tuple]) -> list[dict[str, Any]]:
+        """pg_indexes rows from an INDEXES_SQL listing (one row per index column)."""
+        columns: dict[tuple[str, str, str], list[str]] = {}
+        unique: dict[tuple[str, str, str], bool] = {}
+        for schema, table, index, column, primary_key, non_unique in indexes:
+            if self._internal_index(index):
+                continue
+            key = (self._pg_schema(schema), table.lower(), index.lower())
+            columns.setdefault(key, []).append(column.lower())
+            unique[key] = bool(primary_key) or not non_unique
+
+        rows = []
+        for (schema, table, index), index_columns in columns.items():
+            create = "CREATE UNIQUE INDEX" if unique[(schema, table, index)] else "CREATE INDEX"
+            rows.append(
+                {
+                    "schemaname": schema,
+                    "tablename": table,
+                    "indexname": index,
+                    "tablespace": None,
+                    "indexdef": (
+                        f"{create} {index} ON {schema}.{table} "
+                        f"USING btree ({', '.join(index_columns)})"
+                    ),
+                }
+            )
+        return rows
+
+    @staticmethod
+    def _internal_index(name: str) -> bool:
+        # Bitmap extent indexes ($ClassName) exist on every bitmap-indexed class
+        return name.startswith("$")
+
+    def _pg_schema(self, schema: str) -> str:
+        return "public" if schema.lower() == self.iris_schema.lower() else schema.lower()
@@ -0,0 +1,188 @@
+"""
+Simple SELECTs over Emulated Views
+
+Shared evaluator for views the bridge materializes in Python (pg_stat_*,
+pg_tables, pg_indexes, ...). Monitoring tools and ad-hoc scripts query these
+views with short single-table statements, so instead of routing them through
+the SQL translator the bridge builds the view rows and applies the statement
+here:
+
+- select list: `*`, columns (optionally alias-qualified, with AS aliases),
+  sum(column)/count(*) aggregates over the whole view
+- WHERE: column = / <> / != literal, column [NOT] IN (literals), joined by AND
+- ORDER BY column [ASC|DESC], ...
+- SELECT EXISTS (SELECT ... FROM view WHERE ...)
+
+Unknown select items return NULL; unsupported WHERE conditions are ignored.
+"""
+
+import re
+from typing import Any
+
+_BOOL = 16
+_INT8 = 20
+_TEXT = 25
+_FLOAT8 = 701
+
+_LITERAL = r"'(?:[^']|'')*'|-?\d+(?:\.\d+)?|true|false"
+
+_CONDITION = re.compile(
+    rf"^\s*(?:\w+\.)?(\w+)\s*(=|<>|!=)\s*({_LITERAL})\s*$", re.IGNORECASE
+)
+_IN_CONDITION = re.compile(
+    r"^\s*(?:\w+\.)?(\w+)\s+(NOT\s+)?IN\s*\((.*)\)\s*$", re.IGNORECASE | re.DOTALL
+)
+_EXISTS = re.compile(
+    r"^\s*SELECT\s+EXISTS\s*\((.*)\)\s*(?:AS\s+\"?(\w+)\"?)?\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_CLAUSE_END = r"(?=\s+ORDER\s+BY\b|\s+GROUP\s+BY\b|\s+LIMIT\b|\s*;|\s*$)"
+
+
+def _literal(text: str) -> str:
+    """Literal as the string form its column value is compared with."""
+    if text.startswith("'"):
+        return text[1:-1].replace("''", "'")
+    if text.lower() in ("true", "false"):
+        return text.capitalize()
+    return text
+
+
+def _split_list(text: str) -> list[str]:
+    """Split on top-level commas (not inside parentheses or quotes)."""
+    items, depth, quoted, current = [], 0, False, ""
+    for char in text:
+        if char == "'":
+            quoted = not quoted
+        elif not quoted and char == "(":
+            depth += 1
+        elif not quoted and char == ")":
+            depth -= 1
+        elif not quoted and depth == 0 and char == ",":
+            items.append(current.strip())
+            current = ""
+            continue
+        current += char
+    if current.strip():
+        items.append(current.strip())
+    return items
+
+
+def _filter(sql: str, known: dict[str, int], rows: list[dict[str, Any]]) -> list[dict[str, Any]]:
+    where = re.search(rf"\bWHERE\s+(.+?){_CLAUSE_END}", sql, re.IGNORECASE | re.DOTALL)
+    if not where:
+        return rows
+
+    for condition in re.split(r"\s+AND\s+", where.group(1), flags=re.IGNORECASE):
+        match = _CONDITION.match(condition)
+        if match and match.group(1).lower() in known:
+            column, operator = match.group(1).lower(), match.group(2)
+            value = _literal(match.group(3))
+            if operator == "=":
+                rows = [row for row in rows if str(row[column]) == value]
+            else:
+                rows = [row for row in rows if str(row[column]) != value]
+            continue
+
+        match = _IN_CONDITION.match(condition)
+        if match and match.group(1).lower() in known:
+            column, negated = match.group(1).lower(), bool(match.group(2))
+            values = {_literal(item) for item in _split_list(match.group(3))}
+            rows = [row for row in rows if (str(row[column]) in values) != negated]
+    return rows
+
+
+def _order(sql: str, rows: list[tuple], names: list[str]) -> list[tuple]:
+    order_by = re.search(
+        r"\bORDER\s+BY\s+(.+?)(?=\s+LIMIT\b|\s*;|\s*$)", sql, re.IGNORECASE | re.DOTALL
+    )
+    if not order_by:
+        return rows
+
+    # Stable sorts applied from the last key to the first
+    for key in reversed(_split_list(order_by.group(1))):
+        parts = key.split()
+        column = parts[0].split(".")[-1].strip('"').lower()
+        if column not in names:
+            continue
+        index = names.index(column)
+        descending = len(parts) > 1 and parts[1].upper() == "DESC"
+        rows = sorted(
+            rows,
+            key=lambda row: (row[index] is None, row[index] if row[index] is not None else 0),
+            reverse=descending,
+        )
+    return rows
+
+
+def query_view(
+    sql: str, view_columns: list[tuple[str, int]], view_rows: list[dict[str, Any]]
+) -> tuple[list[dict[str, Any]], list[tuple[Any, ...]]]:
+    """
+    Answer a simple SELECT on an emulated view.
+
+    Args:
+        sql: Statement referencing the view
+        view_columns: (name, type OID) of every view column, in view order
+        view_rows: View rows keyed by column name
+
+    Returns:
+        (column definitions with name/type_oid, rows)
+    """
+    exists = _EXISTS.match(sql)
+    if exists:
+        _, rows = query_view(exists.group(1), view_columns, view_rows)
+        return [{"name": exists.group(2) or "exists", "type_oid": _BOOL}], [(bool(rows),)]
+
+    known = dict(view_columns)
+    rows = _filter(sql, known, view_rows)
+
+    select_list = re.search(r"^\s*SELECT\s+(.*?)\s+FROM\b", sql, re.IGNORECASE | re.DOTALL)
+    items = [] if not select_list else _split_list(select_list.group(1))
+    if not items or items == ["*"]:
+        columns = [{"name": name, "type_oid": type_oid} for name, type_oid in view_columns]
+        result = [tuple(row[name] for name, _ in view_columns) for row in rows]
+        return columns, _order(sql, result, [name for name, _ in view_columns])
+
+    columns = []
+    extractors = []  # (aggregate?, function of the rows or of one row)
+    for item in items:
+        parts = re.split(r"\s+AS\s+", item, flags=re.IGNORECASE)
+        expression = parts[0].strip()
+        alias = parts[-1].strip().strip('"') if len(parts) > 1 else None
+        column = expression.split(".")[-1].strip().strip('"').lower()
+
+        function = re.match(
+            r"^(sum|count)\s*\(\s*(?:\w+\.)?(\*|\w+)\s*\)$", expression, re.IGNORECASE
+        )
+        if function and function.group(1).lower() == "count":
+            columns.append({"name": alias or "count", "type_oid": _INT8})
+            extractors.append((True, len))
+        elif function:
+            argument = function.group(2).lower()
+            type_oid = _FLOAT8 if known.get(argument) == _FLOAT8 else _INT8
+            columns.append({"name": alias or "sum", "type_oid": type_oid})
+            extractors.append(
+                (True, lambda rows, c=argument: sum(r[c] or 0 for r in rows) if rows else None)
+            )
+        elif column in known:
+            columns.append({"name": alias or column, "type_oid": known[column]})
+            extractors.append((False, lambda row, c=column: row[c]))
+        else:
+            columns.append({"name": alias or column, "type_oid": _TEXT})
+            extractors.append((False, lambda row: None))
+
+    if any(aggregate for aggregate, _ in extractors):
+        # Plain columns next to aggregates take the first row's value
+        first = rows[0] if rows else dict.fromkeys(known)
+        return columns, [
+            tuple(extract(rows if aggregate else first) for aggregate, extract in extractors)
+        ]
+
+    # ORDER BY may name view columns that are not selected
+    ordered = _order(
+        sql,
+        [tuple(row[name] for name, _ in view_columns) + (row,) for row in rows],
+        [name for name, _ in view_columns],
+    )
+    return columns, [tuple(extract(row[-1]) for _, extract in extractors) for row in ordered]
@@ -0,0 +1,175 @@
+"""
+Client Certificate Authentication
+
+Services can log in with a TLS client certificate instead of a password,
+like PostgreSQL's pg_hba "cert" method. The TLS handshake asks clients for a
+certificate signed by PGWIRE_SSL_CA_FILE (libpq sslcert/sslkey, JDBC
+sslcert/sslkey, Npgsql SSL Certificate); the bridge then takes a name from
+the verified certificate and checks it against the user in the
+StartupMessage:
+
+- without a map, the name must equal the user name
+- with PGWIRE_CERT_MAP, the user name map of that name in PGWIRE_IDENT_FILE
+  (pg_ident.conf format, see ident_map.py) must allow it
+
+A match sends AuthenticationOk without asking for a password. A certificate
+whose name does not match fails with 28000, as in PostgreSQL; it does not
+fall back to a password. In PGWIRE_BACKEND_AUTH_MODE=passthrough the
+session's IRIS connections are switched to the user as for Kerberos
+delegation (see backend_auth.py), since there is no password to log in with.
+
+Configuration:
+- PGWIRE_SSL_CA_FILE: PEM CA certificates that sign client certificates;
+  when set, TLS clients are asked for a certificate. With
+  PGWIRE_SSL_IRIS_CONFIG the configuration's CA file is used.
+- PGWIRE_SSL_CRL_FILE: PEM certificate revocation list checked for client
+  certificates
+- PGWIRE_CERT_AUTH: off (default); optional, where clients without a
+  certificate use the password methods; or required, where connections
+  without a verified certificate are refused
+- PGWIRE_CERT_MAP: user name map checked for certificate names
+- PGWIRE_CERT_CLIENTNAME: the name checked: CN (default) for the subject's
+  common name, DN for the whole subject in RFC 2253 form
+  (CN=svc,OU=Billing,O=Example), or SAN for any DNS, email or URI
+  subjectAltName
+"""
+
+import os
+from dataclasses import dataclass
+
+import structlog
+
+from .ident_map import IdentMap, get_ident_map
+
+logger = structlog.get_logger()
+
+CERT_AUTH_MODES = ("off", "optional", "required")
+CLIENT_NAMES = ("CN", "DN", "SAN")
+
+# Attribute names in RFC 2253 distinguished names (others keep Python's long name)
+_DN_KEYS = {
+    "commonName": "CN",
+    "countryName": "C",
+    "localityName": "L",
+    "stateOrProvinceName": "ST",
+    "organizationName": "O",
+    "organizationalUnitName": "OU",
+    "streetAddress": "STREET",
+    "domainComponent": "DC",
+    "userId": "UID",
+}
+
+
+class CertificateAuthenticationFailed(Exception):
+    """No certificate, or its name does not allow the user (SQLSTATE 28000)."""
+
+    sqlstate = "28000"
+    condition_name = "invalid_authorization_specification"
+
+
+@dataclass
+class CertAuthConfig:
+    """How client certificates authenticate users."""
+
+    mode: str = "off"
+    map_name: str | None = None
+    client_name: str = "CN"
+
+    def __post_init__(self):
+        if self.mode not in CERT_AUTH_MODES:
+            raise ValueError(
+                f"invalid PGWIRE_CERT_AUTH {self.mode!r} (expected {', '.join(CERT_AUTH_MODES)})"
+            )
+        if self.client_name not in CLIENT_NAMES:
+            raise ValueError(
+                f"invalid PGWIRE_CERT_CLIENTNAME {self.client_name!r}"
+                f" (expected {', '.join(CLIENT_NAMES)})"
+            )
+
+    @classmethod
+    def from_env(cls) -> "CertAuthConfig":
+        return cls(
+            mode=os.getenv("PGWIRE_CERT_AUTH", "off").strip().lower() or "off",
+            map_name=os.getenv("PGWIRE_CERT_MAP") or None,
+            client_name=os.getenv("PGWIRE_CERT_CLIENTNAME", "CN").strip().upper() or "CN",
+        )
+
+    @property
+    def enabled(self) -> bool:
+        return self.mode != "off"
+
+
+def _escape_dn_value(value: str) -> str:
+    escaped = "".join(f"\\{c}" if c in ',+"\\<>;=' else c for c in value)
+    if escaped[:1] in ("#", " "):
+        escaped = "\\" + escaped
+    if escaped.endswith(" ") and not escaped.endswith("\\ "):
+        escaped = escaped[:-1] + "\\ "
+    return escaped
+
+
+def distinguished_name(subject) -> str:
+    """RFC 2253 form of a getpeercert() subject (most specific RDN first)."""
+    rdns = []
+    for rdn in reversed(subject):
+        rdns.append(
+            "+".join(f"{_DN_KEYS.get(key, key)}={_escape_dn_value(value)}" for key, value in rdn)
+        )
+    return ",".join(rdns)
+
+
+def certificate_names(peercert: dict, client_name: str = "CN") -> list[str]:
+    """Names of a verified certificate (getpeercert() form) that are checked."""
+    subject = peercert.get("subject", ())
+    if client_name == "DN":
+        return [distinguished_name(subject)] if subject else []
+    if client_name == "SAN":
+        return [
+            value
+            for kind, value in peercert.get("subjectAltName", ())
+            if kind in ("DNS", "email", "URI")
+        ]
+    # The most specific common name, as PostgreSQL takes it
+    names = [value for rdn in subject for key, value in rdn if key == "commonName"]
+    return names[-1:]
+
+
+def authenticate_certificate(
+    config: CertAuthConfig, peercert: dict | None, user: str, ident_map: IdentMap | None = None
+) -> bool:
+    """
+    Check a client certificate against the requested user.
+
+    Returns:
+        True when the certificate authenticates user; False when certificate
+        authentication does not apply (disabled, or optional and no certificate)
+
+    Raises:
+        CertificateAuthenticationFailed: required certificate missing, or its
+            name does not allow user
+    """
+    if not config.enabled:
+        return False
+    if not peercert:
+        if config.mode == "required":
+            raise CertificateAuthenticationFailed("connection requires a valid client certificate")
+        return False
+
+    names = certificate_names(peercert, config.client_name)
+    if config.map_name:
+        ident_map = get_ident_map() if ident_map is None else ident_map
+        allowed = any(ident_map.check(config.map_name, name, user) for name in names)
+    else:
+        allowed = user in names
+    if not allowed:
+        logger.warning(
+            "Client certificate does not match user",
+            user=user,
+            certificate_names=names,
+            map_name=config.map_name,
+        )
+        raise CertificateAuthenticationFailed(
+            f'certificate authentication failed for user "{user}"'
+        )
+    logger.info("Client certificate authenticated", user=user, certificate_name=names[0])
+    return True
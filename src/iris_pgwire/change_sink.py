@@ -0,0 +1,372 @@
+"""
+Change Data Sinks (Kafka / webhook)
+
+For users who do not want to run Debezium against the replication protocol,
+the bridge can push the changes made through it to Kafka topics or HTTP
+webhooks itself. Routes are configured per table:
+
+    PGWIRE_CDC_SINKS="orders=kafka:orders;sales.*=webhook:https://hooks.example.com/cdc"
+
+A route selects `table` (public schema), `schema.table`, `schema.*` or `*`;
+a table's changes go once to every destination with a matching route.
+Destinations:
+
+- kafka:<topic> - one record per change, keyed by "schema.table", through
+  kafka-python (pip install iris-pgwire[cdc]) and the brokers in
+  PGWIRE_CDC_KAFKA_BOOTSTRAP_SERVERS (default localhost:9092)
+- webhook:<url> - HTTP POST of a JSON array of changes
+
+Every successful INSERT, UPDATE or DELETE a session runs on a routed table
+becomes one change event:
+
+    {"schema": "public", "table": "orders", "op": "INSERT", "rows": 1,
+     "sql": "INSERT INTO orders VALUES (?, ?)", "params": [42, "open"],
+     "ts_ms": 1767609062114,
+     "source": {"database": "USER", "user": "app", "pid": 1234}}
+
+The bridge sees statements, not row images, so an event carries the
+statement with its parameters and the number of rows it changed; writes made
+by other IRIS clients are not captured. As for NOTIFY, changes made in a
+transaction block are exported when it commits, in statement order, and
+dropped on rollback.
+
+Each destination has its own queue (PGWIRE_CDC_QUEUE_SIZE events, default
+10000) drained by a background task in batches of up to 100 events. A failed
+delivery is retried with exponential backoff (1s up to 60s) before later
+events are sent, so a destination receives changes in commit order, at least
+once. When a destination stays unreachable and its queue fills, the oldest
+events are dropped with a warning.
+"""
+
+import asyncio
+import datetime
+import importlib.util
+import itertools
+import json
+import os
+import re
+import time
+import urllib.request
+from collections import deque
+from dataclasses import dataclass, field
+from typing import Any
+
+import structlog
+
+from .schema_mapper import get_schema_config
+from .stats_hooks import dml_target
+
+logger = structlog.get_logger()
+
+DEFAULT_KAFKA_BOOTSTRAP_SERVERS = "localhost:9092"
+DEFAULT_QUEUE_SIZE = 10_000
+BATCH_SIZE = 100
+WEBHOOK_TIMEOUT = 10.0
+_INITIAL_BACKOFF = 1.0
+_MAX_BACKOFF = 60.0
+
+_ROUTE = re.compile(
+    r"^\s*(?P<selector>\*|[\w$]+(?:\.(?:\*|[\w$]+))?)\s*="
+    r"\s*(?P<kind>kafka|webhook):(?P<target>\S+)\s*$",
+    re.IGNORECASE,
+)
+
+
+class SinkConfigError(ValueError):
+    """An unusable PGWIRE_CDC_SINKS route."""
+
+
+def _json_default(value: Any) -> Any:
+    if isinstance(value, bytes | bytearray | memoryview):
+        return bytes(value).hex()
+    if isinstance(value, datetime.date | datetime.time):
+        return value.isoformat()
+    return str(value)
+
+
+@dataclass
+class ChangeEvent:
+    """One INSERT / UPDATE / DELETE statement a session ran on a routed table."""
+
+    schema: str
+    table: str
+    op: str
+    rows: int
+    sql: str
+    params: list | None = None
+    ts_ms: int = field(default_factory=lambda: time.time_ns() // 1_000_000)
+    source: dict[str, Any] = field(default_factory=dict)
+
+    def to_json(self) -> str:
+        return json.dumps(self.__dict__, default=_json_default)
+
+
+class WebhookSink:
+    """POST batches of changes as JSON arrays."""
+
+    def __init__(self, url: str, timeout: float = WEBHOOK_TIMEOUT):
+        if not url.startswith(("http://", "https://")):
+            raise SinkConfigError(f"webhook URL must be http or https: {url}")
+        self.url = url
+        self.timeout = timeout
+
+    @property
+    def name(self) -> str:
+        return f"webhook:{self.url}"
+
+    def send(self, events: list[ChangeEvent]) -> None:
+        body = ("[" + ",".join(event.to_json() for event in events) + "]").encode()
+        request = urllib.request.Request(
+            self.url, data=body, headers={"Content-Type": "application/json"}, method="POST"
+        )
+        with urllib.request.urlopen(request, timeout=self.timeout) as response:
+            response.read()
+
+
+class KafkaSink:
+    """Produce one record per change, keyed by schema.table."""
+
+    def __init__(self, topic: str, producer=None):
+        self.topic = topic
+        self._producer = producer
+
+    @property
+    def name(self) -> str:
+        return f"kafka:{self.topic}"
+
+    @property
+    def producer(self):
+        if self._producer is None:
+            try:
+                from kafka import KafkaProducer
+            except ImportError:
+                raise SinkConfigError(
+                    "kafka sinks require kafka-python (pip install iris-pgwire[cdc])"
+                ) from None
+            self._producer = KafkaProducer(
+                bootstrap_servers=os.getenv(
+                    "PGWIRE_CDC_KAFKA_BOOTSTRAP_SERVERS", DEFAULT_KAFKA_BOOTSTRAP_SERVERS
+                ).split(",")
+            )
+        return self._producer
+
+    def send(self, events: list[ChangeEvent]) -> None:
+        for event in events:
+            key = f"{event.schema}.{event.table}".encode()
+            self.producer.send(self.topic, key=key, value=event.to_json().encode())
+        self.producer.flush()
+
+
+@dataclass
+class SinkRoute:
+    schema: str  # "*" for any schema
+    table: str  # "*" for any table
+    sink: WebhookSink | KafkaSink
+
+    def matches(self, schema: str, table: str) -> bool:
+        return self.schema in ("*", schema) and self.table in ("*", table)
+
+
+def parse_sink_routes(spec: str) -> list[SinkRoute]:
+    """
+    Parse PGWIRE_CDC_SINKS ('selector=kind:target;...').
+
+    Raises:
+        SinkConfigError: a malformed route or destination
+    """
+    routes, sinks = [], {}
+    for entry in spec.split(";"):
+        if not entry.strip():
+            continue
+        match = _ROUTE.match(entry)
+        if not match:
+            raise SinkConfigError(f"invalid change sink route: {entry.strip()}")
+        selector = match.group("selector").lower()
+        if selector == "*":
+            schema, table = "*", "*"
+        elif "." in selector:
+            schema, table = selector.split(".", 1)
+        else:
+            schema, table = "public", selector
+        kind, target = match.group("kind").lower(), match.group("target")
+        # Routes to the same destination share its queue
+        sink = sinks.get((kind, target))
+        if sink is None:
+            sink = KafkaSink(target) if kind == "kafka" else WebhookSink(target)
+            sinks[(kind, target)] = sink
+        routes.append(SinkRoute(schema, table, sink))
+    return routes
+
+
+class SinkQueue:
+    """Changes waiting for one destination, delivered in order."""
+
+    def __init__(self, sink, max_size: int = DEFAULT_QUEUE_SIZE):
+        self.sink = sink
+        self.max_size = max_size
+        self.events: deque[ChangeEvent] = deque()
+        self.dropped = 0
+        self.delivered = 0
+        self.ready = asyncio.Event()
+        self.backoff = 0.0  # Seconds to wait after a failed delivery
+
+    def put(self, event: ChangeEvent) -> None:
+        if len(self.events) >= self.max_size:
+            self.events.popleft()
+            self.dropped += 1
+            if self.dropped == 1 or self.dropped % 1000 == 0:
+                logger.warning(
+                    "Change sink queue full, dropping", sink=self.sink.name, dropped=self.dropped
+                )
+        self.events.append(event)
+        self.ready.set()
+
+    async def deliver_batch(self) -> bool:
+        """Send the next batch; False (and a longer backoff) if delivery failed."""
+        batch = list(itertools.islice(self.events, BATCH_SIZE))
+        try:
+            await asyncio.to_thread(self.sink.send, batch)
+        except Exception as e:
+            self.backoff = min(self.backoff * 2 or _INITIAL_BACKOFF, _MAX_BACKOFF)
+            logger.warning(
+                "Change sink delivery failed",
+                sink=self.sink.name,
+                error=str(e),
+                retry_in_s=self.backoff,
+            )
+            return False
+        # Events may have been dropped from the head meanwhile (queue full)
+        for event in batch:
+            if self.events and self.events[0] is event:
+                self.events.popleft()
+        self.delivered += len(batch)
+        self.backoff = 0.0
+        return True
+
+    async def run(self) -> None:
+        """Deliver queued changes until cancelled."""
+        while True:
+            if not self.events:
+                self.ready.clear()
+                await self.ready.wait()
+            if not await self.deliver_batch():
+                await asyncio.sleep(self.backoff)
+
+
+class ChangeExporter:
+    """Routes committed changes to the destinations' queues."""
+
+    def __init__(self, routes: list[SinkRoute], queue_size: int = DEFAULT_QUEUE_SIZE):
+        self.routes = routes
+        self.queues = {}
+        for route in routes:
+            if id(route.sink) not in self.queues:
+                self.queues[id(route.sink)] = SinkQueue(route.sink, queue_size)
+
+    def routed(self, schema: str, table: str) -> list[SinkQueue]:
+        """Queues of the destinations that receive a table's changes."""
+        queues = {}
+        for route in self.routes:
+            if route.matches(schema, table):
+                queues.setdefault(id(route.sink), self.queues[id(route.sink)])
+        return list(queues.values())
+
+    def publish(self, events: list[ChangeEvent]) -> None:
+        for event in events:
+            for queue in self.routed(event.schema, event.table):
+                queue.put(event)
+
+
+_exporter: ChangeExporter | None = None
+
+
+def get_change_exporter() -> ChangeExporter | None:
+    """Process-wide exporter (None when PGWIRE_CDC_SINKS is not configured)."""
+    return _exporter
+
+
+def set_change_exporter(exporter: ChangeExporter | None) -> None:
+    global _exporter
+    _exporter = exporter
+
+
+class SessionChanges:
+    """One session's changes, exported as their transaction commits."""
+
+    def __init__(self, pid: int):
+        self.pid = pid
+        self.user: str | None = None
+        self.database: str | None = None
+        self._pending: list[ChangeEvent] = []
+
+    def record(
+        self, sql: str, params: list | None, result: dict[str, Any], in_transaction: bool
+    ) -> None:
+        """Note a statement's change if it wrote a routed table."""
+        exporter = get_change_exporter()
+        if exporter is None or not result.get("success"):
+            return
+        target = dml_target(sql)
+        if target is None:
+            return
+        op, schema, table = target
+        if schema == get_schema_config()["iris_schema"].lower():
+            schema = "public"
+        rows = result.get("row_count") or 0
+        if not rows or not exporter.routed(schema, table):
+            return
+        source = {"database": self.database, "user": self.user, "pid": self.pid}
+        event = ChangeEvent(
+            schema, table, op, rows, sql, list(params) if params else None, source=source
+        )
+        if in_transaction:
+            self._pending.append(event)
+        else:
+            exporter.publish([event])
+
+    def end_transaction(self, committed: bool) -> None:
+        """Export the transaction's changes on COMMIT, drop them on ROLLBACK."""
+        pending, self._pending = self._pending, []
+        exporter = get_change_exporter()
+        if committed and pending and exporter is not None:
+            exporter.publish(pending)
+
+
+def load_queue_size() -> int:
+    """PGWIRE_CDC_QUEUE_SIZE (events held per destination)."""
+    value = os.getenv("PGWIRE_CDC_QUEUE_SIZE")
+    try:
+        size = int(value) if value else DEFAULT_QUEUE_SIZE
+    except ValueError:
+        size = 0
+    if size <= 0:
+        logger.warning("Ignoring invalid PGWIRE_CDC_QUEUE_SIZE", value=value)
+        size = DEFAULT_QUEUE_SIZE
+    return size
+
+
+def start_change_exporter() -> list[asyncio.Task]:
+    """Start delivering to the PGWIRE_CDC_SINKS destinations (no tasks when not configured)."""
+    spec = os.getenv("PGWIRE_CDC_SINKS", "")
+    if not spec.strip():
+        set_change_exporter(None)
+        return []
+    try:
+        routes = parse_sink_routes(spec)
+    except SinkConfigError as e:
+        logger.error("Change sinks disabled", error=str(e))
+        set_change_exporter(None)
+        return []
+    if importlib.util.find_spec("kafka") is None and any(
+        isinstance(route.sink, KafkaSink) for route in routes
+    ):
+        logger.error(
+            "Change sinks disabled",
+            error="kafka sinks require kafka-python (pip install iris-pgwire[cdc])",
+        )
+        set_change_exporter(None)
+        return []
+    exporter = ChangeExporter(routes, load_queue_size())
+    set_change_exporter(exporter)
+    logger.info("Change sinks started", sinks=[q.sink.name for q in exporter.queues.values()])
+    return [asyncio.ensure_future(queue.run()) for queue in exporter.queues.values()]
@@ -0,0 +1,390 @@
+"""
+CHECK Constraints
+
+IRIS DDL has no CHECK constraint, so CREATE TABLE / ALTER TABLE statements
+carrying one would fail. The bridge removes the CHECK clauses, runs the rest
+of the statement, and enforces each constraint with a row trigger:
+
+    CREATE TABLE orders (qty INT CHECK (qty > 0), CONSTRAINT max_qty CHECK (qty < 1000));
+    ALTER TABLE orders ADD CONSTRAINT positive_total CHECK (total >= 0);
+
+become a plain CREATE TABLE / ALTER TABLE and, per constraint, an ObjectScript
+AFTER INSERT,UPDATE trigger named after it. The trigger reads the stored row
+back with embedded SQL and fails the statement when NOT (<expression>) holds,
+so the expression is evaluated by IRIS SQL and a NULL result passes, as in
+PostgreSQL. Clients receive 23514 (check_violation) with PostgreSQL's message:
+
+    new row for relation "orders" violates check constraint "orders_qty_check"
+
+Unnamed constraints get PostgreSQL's default names (<table>_<column>_check
+when the expression references one column, <table>_check otherwise).
+ALTER TABLE ... ADD CHECK validates existing rows first (23514) unless NOT
+VALID is given, and ALTER TABLE ... DROP CONSTRAINT removes the trigger.
+
+Definitions are kept in an IRIS global (PGWIRE_CHECKS_GLOBAL, default
+^PGWire.Checks) so introspection (Prisma's check constraint query) can report
+them:
+
+    ^PGWire.Checks(schema, table, constraint) = expression
+
+Expressions must be valid IRIS SQL; PostgreSQL-only syntax in them (::casts,
+regular expression operators) makes the trigger creation fail, and CREATE
+TABLE is then undone.
+"""
+
+import os
+import re
+from collections.abc import Callable
+from dataclasses import dataclass
+from typing import Any
+
+from .sql_text import split_top_level
+
+DEFAULT_CHECKS_GLOBAL = "^PGWire.Checks"
+
+CHECK_VIOLATION = "23514"
+
+_IDENTIFIER = r'(?:"(?:[^"]|"")*"|[\w$]+)'
+_RELATION = rf"(?P<name>{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?)"
+
+_CREATE_TABLE = re.compile(
+    r"^\s*CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+"
+    rf"(?:IF\s+NOT\s+EXISTS\s+)?{_RELATION}\s*\(",
+    re.IGNORECASE,
+)
+_ALTER_TABLE = re.compile(
+    rf"^\s*ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?{_RELATION}\s+(?P<actions>.+)$",
+    re.IGNORECASE | re.DOTALL,
+)
+# String literals are matched first so their contents are never taken for a CHECK
+_CHECK = re.compile(
+    rf"(?P<literal>'(?:[^']|'')*')|(?:\bCONSTRAINT\s+(?P<name>{_IDENTIFIER})\s+)?\bCHECK\s*\(",
+    re.IGNORECASE,
+)
+_CHECK_OPTION = re.compile(r"\s*\b(?P<option>NO\s+INHERIT|NOT\s+VALID)\b", re.IGNORECASE)
+_ADD_CONSTRAINT = re.compile(rf"^ADD\s+(?=(?:CONSTRAINT\s+{_IDENTIFIER}\s+)?CHECK\b)", re.I)
+_ADD_COLUMN = re.compile(
+    rf"^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?P<column>{_IDENTIFIER})", re.IGNORECASE
+)
+_DROP_CONSTRAINT = re.compile(
+    rf"^DROP\s+CONSTRAINT\s+(?:IF\s+EXISTS\s+)?(?P<name>{_IDENTIFIER})"
+    r"(?:\s+(?:CASCADE|RESTRICT))?$",
+    re.IGNORECASE,
+)
+_TABLE_CONSTRAINT = re.compile(r"^(?:CONSTRAINT|CHECK)\b", re.IGNORECASE)
+
+_EXPRESSION_TOKEN = re.compile(r"'(?:[^']|'')*'|::\s*\w+|\"(?:[^\"]|\"\")*\"|[A-Za-z_]\w*\s*\(?")
+_NOT_COLUMNS = frozenset(
+    "AND OR NOT NULL IS IN BETWEEN LIKE ILIKE SIMILAR TO TRUE FALSE CASE WHEN THEN ELSE END "
+    "ANY ALL SOME ESCAPE DISTINCT FROM ARRAY UNKNOWN CURRENT_DATE CURRENT_TIMESTAMP".split()
+)
+
+_VIOLATION = re.compile(r'new row for relation "(?:[^"]|"")*" violates check constraint "[^"]*"')
+
+
+class CheckViolation(Exception):
+    """Existing rows violate a CHECK constraint being added."""
+
+    sqlstate = CHECK_VIOLATION
+    condition_name = "check_violation"
+
+
+@dataclass
+class CheckConstraint:
+    name: str
+    expression: str
+    validate: bool = True  # False for NOT VALID: existing rows are not checked
+
+
+@dataclass
+class CheckDDL:
+    relation: str  # Table name as written
+    statement: str | None  # What IRIS still runs once CHECK clauses are removed
+    checks: list[CheckConstraint]
+    drop: str | None = None  # Constraint of ALTER TABLE ... DROP CONSTRAINT
+    creates_table: bool = False
+
+
+@dataclass
+class Relation:
+    schema: str  # IRIS schema
+    table: str  # Table name as PostgreSQL reports it
+    sql: str  # Qualified name for IRIS statements
+
+
+def _unquote(identifier: str) -> str:
+    identifier = identifier.strip()
+    if len(identifier) >= 2 and identifier[0] == identifier[-1] == '"':
+        return identifier[1:-1].replace('""', '"')
+    return identifier.lower()
+
+
+def _closing_paren(text: str, start: int) -> int:
+    """Index of the parenthesis closing the one at text[start] (-1 if unbalanced)."""
+    depth, quote = 0, None
+    for index in range(start, len(text)):
+        char = text[index]
+        if quote:
+            if char == quote:
+                quote = None
+        elif char in "'\"":
+            quote = char
+        elif char == "(":
+            depth += 1
+        elif char == ")":
+            depth -= 1
+            if depth == 0:
+                return index
+    return -1
+
+
+def _take_checks(text: str) -> tuple[str, list[tuple[str | None, str, bool]]]:
+    """Remove [CONSTRAINT name] CHECK (...) clauses: (rest, [(name, expression, validate)])."""
+    checks, kept, position = [], [], 0
+    while True:
+        match = _CHECK.search(text, position)
+        while match and match.group("literal"):
+            match = _CHECK.search(text, match.end())
+        if not match:
+            break
+        close = _closing_paren(text, match.end() - 1)
+        if close < 0:
+            break
+        end, validate = close + 1, True
+        while option := _CHECK_OPTION.match(text, end):
+            validate = validate and not option.group("option").upper().startswith("NOT")
+            end = option.end()
+        name = _unquote(match.group("name")) if match.group("name") else None
+        checks.append((name, text[match.end() : close].strip(), validate))
+        kept.append(text[position : match.start()])
+        position = end
+    if not checks:
+        return text.strip(), checks
+    kept.append(text[position:])
+    return " ".join(piece.strip() for piece in kept if piece.strip()), checks
+
+
+def _default_name(table: str, expression: str, taken: set[str]) -> str:
+    """PostgreSQL's name for an unnamed CHECK constraint, unique among taken."""
+    columns = set()
+    for token in _EXPRESSION_TOKEN.findall(expression):
+        token = token.strip()
+        if token.startswith(("'", "::")) or token.endswith("("):
+            continue
+        if token.upper() not in _NOT_COLUMNS:
+            columns.add(_unquote(token))
+    base = f"{table}_{columns.pop()}_check" if len(columns) == 1 else f"{table}_check"
+    name, suffix = base, 0
+    while name in taken:
+        suffix += 1
+        name = f"{base}{suffix}"
+    return name
+
+
+def _constraints(
+    table: str, found: list[tuple[str | None, str, bool]], taken: set[str]
+) -> list[CheckConstraint]:
+    constraints = []
+    for name, expression, validate in found:
+        name = name or _default_name(table, expression, taken)
+        taken.add(name)
+        constraints.append(CheckConstraint(name, expression, validate))
+    return constraints
+
+
+def parse_check_ddl(sql: str) -> CheckDDL | None:
+    """
+    CREATE TABLE / ALTER TABLE with CHECK constraints, or ALTER TABLE ... DROP
+    CONSTRAINT (None for any other statement).
+    """
+    sql = sql.strip().rstrip(";").rstrip()
+    if "CHECK" not in sql.upper() and "DROP" not in sql.upper():
+        return None
+
+    if match := _CREATE_TABLE.match(sql):
+        close = _closing_paren(sql, match.end() - 1)
+        if close < 0:
+            return None
+        table = relation(match.group("name"), "").table
+        elements, checks, taken = [], [], set()
+        for element in split_top_level(sql[match.end() : close]):
+            rest, found = _take_checks(element)
+            checks.extend(_constraints(table, found, taken))
+            if rest or not _TABLE_CONSTRAINT.match(element):
+                elements.append(rest)
+        if not checks:
+            return None
+        statement = f"{sql[: match.end()]}{', '.join(elements)}{sql[close:]}"
+        return CheckDDL(match.group("name"), statement, checks, creates_table=True)
+
+    match = _ALTER_TABLE.match(sql)
+    if not match:
+        return None
+    table = relation(match.group("name"), "").table
+    actions = split_top_level(match.group("actions"))
+    if len(actions) == 1 and (drop := _DROP_CONSTRAINT.match(actions[0])):
+        return CheckDDL(match.group("name"), sql, [], drop=_unquote(drop.group("name")))
+
+    remaining, checks, taken = [], [], set()
+    for action in actions:
+        if added := _ADD_CONSTRAINT.match(action):
+            rest, found = _take_checks(action[added.end() :])
+            checks.extend(_constraints(table, found, taken))
+            if rest:
+                remaining.append(f"ADD {rest}")
+        elif _ADD_COLUMN.match(action):
+            rest, found = _take_checks(action)
+            checks.extend(_constraints(table, found, taken))
+            remaining.append(rest)
+        else:
+            remaining.append(action)
+    if not checks:
+        return None
+    statement = f"ALTER TABLE {match.group('name')} {', '.join(remaining)}" if remaining else None
+    return CheckDDL(match.group("name"), statement, checks)
+
+
+def relation(name: str, iris_schema: str) -> Relation:
+    """Relation of a table name as written, public meaning the default IRIS schema."""
+    parts = [part.strip() for part in re.findall(r'"(?:[^"]|"")*"|[^."]+', name)]
+    schema = iris_schema
+    if len(parts) == 2 and _unquote(parts[0]) not in ("public", iris_schema.lower()):
+        schema = _unquote(parts[0])
+    return Relation(schema, _unquote(parts[-1]), f"{schema}.{parts[-1]}")
+
+
+def _quote(identifier: str) -> str:
+    return '"' + identifier.replace('"', '""') + '"'
+
+
+def violation_message(table: str, name: str) -> str:
+    return f'new row for relation "{table}" violates check constraint "{name}"'
+
+
+def trigger_sql(target: Relation, check: CheckConstraint) -> str:
+    """CREATE TRIGGER enforcing a CHECK constraint on inserted and updated rows."""
+    message = violation_message(target.table, check.name).replace('"', '""')
+    return (
+        f"CREATE TRIGGER {_quote(check.name)} AFTER INSERT,UPDATE ON {target.sql} "
+        "LANGUAGE OBJECTSCRIPT {\n"
+        " NEW id,violated SET id={%%ID},violated=0\n"
+        f" &sql(SELECT 1 INTO :violated FROM {target.sql} WHERE %ID = :id "
+        f"AND NOT ({check.expression}))\n"
+        f' IF violated SET %ok=0,%msg="{message}"\n'
+        "}"
+    )
+
+
+def drop_trigger_sql(target: Relation, name: str) -> str:
+    return f"DROP TRIGGER {_quote(name)} FROM {target.sql}"
+
+
+def violations_sql(target: Relation, check: CheckConstraint) -> str:
+    """Count of existing rows violating a constraint about to be added."""
+    return f"SELECT COUNT(*) FROM {target.sql} WHERE NOT ({check.expression})"
+
+
+def annotate_violation(result: dict[str, Any]) -> dict[str, Any]:
+    """Report a failed statement's CHECK trigger error as check_violation."""
+    if result.get("success") or result.get("sqlstate"):
+        return result
+    match = _VIOLATION.search(str(result.get("error") or ""))
+    if match:
+        result["error"] = match.group(0)
+        result["sqlstate"] = CHECK_VIOLATION
+        result["condition_name"] = CheckViolation.condition_name
+    return result
+
+
+class CheckConstraintStore:
+    """CHECK definitions in the IRIS global, read and written through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv(
+            "PGWIRE_CHECKS_GLOBAL", DEFAULT_CHECKS_GLOBAL
+        )
+
+    def set(self, schema: str, table: str, name: str, expression: str | None) -> None:
+        """Store a definition; None removes it, and name None all of the table's."""
+        subscripts = [schema, table] if name is None else [schema, table, name]
+        if expression is None:
+            self.accessor.kill(self.global_name, subscripts)
+        else:
+            self.accessor.set(self.global_name, subscripts, expression)
+
+    def get(self, schema: str, table: str, name: str) -> str | None:
+        subscripts = [schema, table, name]
+        if not self.accessor.data(self.global_name, subscripts):
+            return None
+        return self.accessor.get(self.global_name, subscripts)
+
+    def rows(self) -> list[tuple[str, str, str, str]]:
+        """Every stored constraint as (schema, table, name, expression)."""
+        rows = []
+
+        def subscripts_under(prefix: list) -> list:
+            found, previous = [], ""
+            while True:
+                previous = self.accessor.next_subscript(self.global_name, prefix, previous)
+                if previous is None:
+                    return found
+                found.append(previous)
+
+        for schema in subscripts_under([]):
+            for table in subscripts_under([schema]):
+                for name in subscripts_under([schema, table]):
+                    expression = self.accessor.get(self.global_name, [schema, table, name])
+                    rows.append((schema, table, name, expression))
+        return rows
+
+
+class CheckConstraints:
+    """Creates, validates and drops the triggers enforcing CHECK constraints."""
+
+    def __init__(self, run: Callable[[str], list], store: CheckConstraintStore):
+        """
+        Args:
+            run: Executes an IRIS statement as written (no SQL translation, which
+                would change the ObjectScript trigger bodies) and returns its rows
+            store: Where definitions are kept for introspection
+        """
+        self.run = run
+        self.store = store
+
+    def add(self, target: Relation, checks: list[CheckConstraint], new_table: bool) -> None:
+        """
+        Enforce checks on a table, all or none of them.
+
+        Raises:
+            CheckViolation: Existing rows violate a constraint
+        """
+        if new_table:
+            # Definitions left behind by a dropped table of the same name
+            self.store.set(target.schema, target.table, None, None)
+
+        added = []
+        try:
+            for check in checks:
+                if check.validate and not new_table:
+                    rows = self.run(violations_sql(target, check))
+                    if rows and rows[0][0]:
+                        raise CheckViolation(
+                            f'check constraint "{check.name}" of relation "{target.table}" '
+                            "is violated by some row"
+                        )
+                self.run(trigger_sql(target, check))
+                added.append(check)
+                self.store.set(target.schema, target.table, check.name, check.expression)
+        except Exception:
+            for check in added:
+                self.drop(target, check.name)
+            raise
+
+    def drop(self, target: Relation, name: str) -> bool:
+        """Remove a constraint's trigger (False if the bridge did not create it)."""
+        if self.store.get(target.schema, target.table, name) is None:
+            return False
+        self.run(drop_trigger_sql(target, name))
+        self.store.set(target.schema, target.table, name, None)
+        return True
@@ -0,0 +1,540 @@
+"""
+Result Column Names
+
+PostgreSQL names each result column from its select-list item, and some
+frameworks key their scan targets by these names (sqlx, Dapper, pandas), so
+the bridge names columns from the client's SQL rather than from IRIS's
+metadata (which uppercases, and invents HostVar_1 or Expression_2 for
+unnamed items):
+
+- An alias names the column as written: "Total" keeps its case, total and
+  Total are folded to total. Names longer than 63 bytes are truncated like
+  identifiers (NAMEDATALEN).
+- A column reference is named by its last part (t.id -> id).
+- A function call is named by the function (count(*) -> count,
+  pg_catalog.lower(x) -> lower), also with FILTER, OVER or WITHIN GROUP.
+- A cast keeps the name of what it casts when that has one (id::text -> id);
+  otherwise the column is named by the type's internal name (1::int -> int4,
+  'x'::varchar(3) -> varchar, TRUE -> bool, DATE '2024-01-01' -> date).
+- CASE is named case (or by a cast around it), and COALESCE, NULLIF,
+  GREATEST, LEAST, EXISTS, ARRAY, ROW, EXTRACT, CURRENT_DATE and the other
+  keyword functions by their keyword; TRIM by btrim, ltrim or rtrim.
+- A scalar subquery takes the name of its first column.
+- Everything else (literals, operators, parameters) is ?column?.
+
+Duplicate names are sent as they are, not suffixed: PostgreSQL does not
+de-duplicate (SELECT 1, 2 returns two ?column? columns). A set operation is
+named by its first branch, VALUES by column1, column2, ... and RETURNING by
+its list like a select list. A * expands to the table's columns, whose names
+come from IRIS; the items before the first and after the last * are still
+named from the SQL.
+"""
+
+import re
+from typing import Any
+
+UNNAMED = "?column?"
+
+# Maximum identifier length in bytes (NAMEDATALEN - 1)
+MAX_NAME_BYTES = 63
+
+_TOKEN = re.compile(
+    r"\s*(?:(?P<comment>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>[EeBbXxNn]?'(?:[^']|'')*'|(?P<tag>\$[A-Za-z_]*\$).*?(?P=tag))"
+    r"|(?P<parameter>\$\d+|\?)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r'|(?P<quoted>"(?:[^"]|"")*")'
+    r"|(?P<identifier>[A-Za-z_\u0080-\uffff][\w$\u0080-\uffff]*)"
+    r"|(?P<operator>::|[(),.\[\];*]|[+\-/<>=~!@#%^&|`?]+))",
+    re.DOTALL,
+)
+
+# Keywords that end a select list at depth 0
+_LIST_END = frozenset(
+    {
+        "FROM", "INTO", "WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT",
+        "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "RETURNING",
+    }
+)
+
+# Keywords that cannot be a bare (AS-less) alias after an expression
+_NOT_ALIAS = frozenset(
+    {
+        "END", "NULL", "TRUE", "FALSE", "UNKNOWN", "ISNULL", "NOTNULL", "PRECISION",
+        "ZONE", "VARYING", "YEAR", "MONTH", "DAY", "HOUR", "MINUTE", "SECOND",
+        "AND", "OR", "NOT", "IS", "IN", "LIKE", "ILIKE", "SIMILAR", "BETWEEN",
+        "ESCAPE", "COLLATE", "AT", "DESC", "ASC",
+    }
+)
+
+# Keyword functions that take no parentheses (SQLValueFunction)
+_VALUE_FUNCTIONS = frozenset(
+    {
+        "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "LOCALTIME",
+        "LOCALTIMESTAMP", "CURRENT_ROLE", "CURRENT_USER", "SESSION_USER", "USER",
+        "CURRENT_CATALOG", "CURRENT_SCHEMA",
+    }
+)
+
+# Type names as written -> the internal name PostgreSQL names a cast by
+_TYPE_NAMES = {
+    "int": "int4",
+    "integer": "int4",
+    "smallint": "int2",
+    "bigint": "int8",
+    "real": "float4",
+    "float": "float8",
+    "double precision": "float8",
+    "decimal": "numeric",
+    "dec": "numeric",
+    "boolean": "bool",
+    "char": "bpchar",
+    "character": "bpchar",
+    "character varying": "varchar",
+    "char varying": "varchar",
+    "national character": "bpchar",
+    "nchar": "bpchar",
+    "timestamp without time zone": "timestamp",
+    "timestamp with time zone": "timestamptz",
+    "time without time zone": "time",
+    "time with time zone": "timetz",
+    "bit varying": "varbit",
+}
+
+# Multi-word type names, and their prefixes, read by _Item.type_name
+_MULTIWORD_PREFIXES = frozenset(
+    prefix
+    for written in _TYPE_NAMES
+    for prefix in (" ".join(written.split()[:n]) for n in range(2, len(written.split()) + 1))
+)
+
+_INTERVAL_FIELDS = frozenset({"YEAR", "MONTH", "DAY", "HOUR", "MINUTE", "SECOND", "TO"})
+
+
+def _tokens(sql: str) -> list[tuple[str, str]] | None:
+    """(kind, text) tokens of sql, without comments; None if it cannot be read."""
+    tokens = []
+    position = 0
+    length = len(sql)
+    while position < length:
+        match = _TOKEN.match(sql, position)
+        if not match:
+            if sql[position:].strip():
+                return None
+            break
+        kind = match.lastgroup
+        if kind != "comment":
+            tokens.append((kind, match.group(kind)))
+        position = match.end()
+    return tokens
+
+
+def _is_keyword(token: tuple[str, str] | None, *words: str) -> bool:
+    return token is not None and token[0] == "identifier" and token[1].upper() in words
+
+
+def _is_operator(token: tuple[str, str] | None, *operators: str) -> bool:
+    return token is not None and token[0] == "operator" and token[1] in operators
+
+
+def _identifier(token: tuple[str, str]) -> str:
+    """Name of an identifier token: quoted as written, unquoted folded to lower case."""
+    kind, text = token
+    if kind == "quoted":
+        return text[1:-1].replace('""', '"')
+    return text.lower()
+
+
+def truncate_name(name: str) -> str:
+    """name truncated to MAX_NAME_BYTES bytes, at a character boundary."""
+    encoded = name.encode("utf-8")
+    if len(encoded) <= MAX_NAME_BYTES:
+        return name
+    return encoded[:MAX_NAME_BYTES].decode("utf-8", errors="ignore")
+
+
+def _closing(tokens: list[tuple[str, str]], start: int) -> int:
+    """Index of the bracket closing the one at start (len(tokens) if unclosed)."""
+    depth = 0
+    for index in range(start, len(tokens)):
+        if _is_operator(tokens[index], "(", "["):
+            depth += 1
+        elif _is_operator(tokens[index], ")", "]"):
+            depth -= 1
+            if depth == 0:
+                return index
+    return len(tokens)
+
+
+def _split(tokens: list[tuple[str, str]]) -> tuple[list[list[tuple[str, str]]], int]:
+    """
+    Depth-0 comma-separated items of a list, up to its end keyword.
+
+    Returns the items and the index after the list.
+    """
+    items: list[list[tuple[str, str]]] = [[]]
+    index = 0
+    while index < len(tokens):
+        token = tokens[index]
+        if _is_operator(token, "(", "["):
+            end = _closing(tokens, index)
+            items[-1].extend(tokens[index : end + 1])
+            index = end + 1
+            continue
+        if _is_operator(token, ")", "]", ";") or (
+            token[0] == "identifier"
+            and token[1].upper() in _LIST_END
+            and not (index and _is_keyword(tokens[index - 1], "DISTINCT"))  # IS DISTINCT FROM
+        ):
+            break
+        if _is_operator(token, ","):
+            items.append([])
+        else:
+            items[-1].append(token)
+        index += 1
+    if items == [[]]:
+        return [], index
+    return items, index
+
+
+class _Item:
+    """One select-list item, read far enough to name it (see FigureColname)."""
+
+    def __init__(self, tokens: list[tuple[str, str]]):
+        self.tokens = tokens
+        self.index = 0
+
+    def peek(self, offset: int = 0) -> tuple[str, str] | None:
+        index = self.index + offset
+        return self.tokens[index] if index < len(self.tokens) else None
+
+    def at_end(self) -> bool:
+        return self.index >= len(self.tokens)
+
+    def skip_brackets(self) -> None:
+        """Move past the bracketed group starting at the current token."""
+        self.index = _closing(self.tokens, self.index) + 1
+
+    def type_name(self) -> str:
+        """Read a type name (after :: or AS in CAST) and return its internal name."""
+        token = self.peek()
+        if token is None or token[0] not in ("identifier", "quoted"):
+            return UNNAMED
+        name = _identifier(token)
+        written = name if token[0] == "identifier" else None
+        self.index += 1
+        while _is_operator(self.peek(), ".") and self.peek(1) is not None:
+            # Schema-qualified: pg_catalog.int4
+            token = self.peek(1)
+            name = _identifier(token)
+            written = name if token[0] == "identifier" else None
+            self.index += 2
+        while (
+            written is not None
+            and self.peek() is not None
+            and self.peek()[0] == "identifier"
+            and f"{written} {self.peek()[1].lower()}" in _MULTIWORD_PREFIXES
+        ):
+            written = f"{written} {self.peek()[1].lower()}"
+            self.index += 1
+        if _is_operator(self.peek(), "("):
+            self.skip_brackets()  # Type modifiers: varchar(10), timestamp(3)
+        if written in ("timestamp", "time") and _is_keyword(self.peek(), "WITH", "WITHOUT"):
+            written = f"{written} {self.peek()[1].lower()} time zone"
+            self.index += 3
+        while _is_operator(self.peek(), "["):
+            self.skip_brackets()  # Array types: int[]
+        if _is_keyword(self.peek(), "ARRAY"):
+            self.index += 1
+            while _is_operator(self.peek(), "["):
+                self.skip_brackets()
+        if written is None:
+            return name  # Quoted type names are used as written
+        return _TYPE_NAMES.get(written, written)
+
+    def name(self) -> tuple[str | None, int]:
+        """
+        (name, strength) of the expression at the current position, reading a
+        primary and its postfix casts, subscripts and field selections; 0
+        strength (no name) for any operator expression.
+        """
+        name, strength = self.primary()
+        while not self.at_end():
+            if _is_operator(self.peek(), "::"):
+                self.index += 1
+                type_name = self.type_name()
+                if strength <= 1:
+                    name, strength = type_name, 1
+            elif _is_operator(self.peek(), "["):
+                self.skip_brackets()
+            elif _is_operator(self.peek(), ".") and self.peek(1) is not None:
+                field = self.peek(1)
+                self.index += 2
+                if field[0] in ("identifier", "quoted"):
+                    name, strength = _identifier(field), 2
+                elif _is_operator(field, "*"):
+                    return None, -1
+            elif _is_keyword(self.peek(), "COLLATE"):
+                self.index += 2
+                while _is_operator(self.peek(), "."):
+                    self.index += 2
+            elif (
+                _is_keyword(self.peek(), "AT")
+                and _is_keyword(self.peek(1), "TIME")
+                and _is_keyword(self.peek(2), "ZONE")
+            ):
+                self.index += 3
+                self.primary()
+                name, strength = "timezone", 2
+            else:
+                return None, 0  # An operator expression
+        return name, strength
+
+    def primary(self) -> tuple[str | None, int]:
+        token = self.peek()
+        if token is None:
+            return None, 0
+        kind, text = token
+        upper = text.upper() if kind == "identifier" else ""
+
+        if _is_operator(token, "("):
+            end = _closing(self.tokens, self.index)
+            inner = self.tokens[self.index + 1 : end]
+            self.index = end + 1
+            if _is_keyword(inner[0] if inner else None, "SELECT", "WITH", "VALUES"):
+                names = _statement_names(inner)
+                if names and names[0] is not None:
+                    return names[0], 2
+                return UNNAMED, 2
+            items, _ = _split(inner)
+            if len(items) > 1:
+                return "row", 2  # Implicit ROW constructor: (a, b)
+            if len(items) == 1:
+                return _Item(items[0]).name()
+            return None, 0
+        if kind in ("number", "parameter"):
+            self.index += 1
+            return None, 0
+        if kind == "string":
+            self.index += 1
+            return None, 0
+        if _is_operator(token, "*"):
+            self.index += 1
+            return None, -1
+        if kind == "operator":
+            return None, 0  # Prefix operator: -x, NOT is a keyword below
+        if upper in ("TRUE", "FALSE"):
+            self.index += 1
+            return "bool", 1
+        if upper in ("NULL", "NOT"):
+            self.index += 1
+            return None, 0
+        if upper == "CASE":
+            depth = 0
+            while not self.at_end():
+                if _is_keyword(self.peek(), "CASE"):
+                    depth += 1
+                elif _is_keyword(self.peek(), "END"):
+                    depth -= 1
+                    if depth == 0:
+                        self.index += 1
+                        break
+                self.index += 1
+            return "case", 1
+        if upper == "CAST" and _is_operator(self.peek(1), "("):
+            end = _closing(self.tokens, self.index + 1)
+            inner = self.tokens[self.index + 2 : end]
+            self.index = end + 1
+            depth = 0
+            for position, inner_token in enumerate(inner):
+                if _is_operator(inner_token, "(", "["):
+                    depth += 1
+                elif _is_operator(inner_token, ")", "]"):
+                    depth -= 1
+                elif depth == 0 and _is_keyword(inner_token, "AS"):
+                    name, strength = _Item(inner[:position]).name()
+                    if strength > 1:
+                        return name, strength
+                    return _Item(inner[position + 1 :]).type_name(), 1
+            return None, 0
+        if upper == "TRIM" and _is_operator(self.peek(1), "("):
+            mode = self.peek(2)
+            self.index += 1
+            self.skip_brackets()
+            if _is_keyword(mode, "LEADING"):
+                return "ltrim", 2
+            if _is_keyword(mode, "TRAILING"):
+                return "rtrim", 2
+            return "btrim", 2
+        if upper == "ARRAY" and _is_operator(self.peek(1), "(", "["):
+            self.index += 1
+            self.skip_brackets()
+            return "array", 2
+        if upper in _VALUE_FUNCTIONS:
+            self.index += 1
+            if _is_operator(self.peek(), "("):
+                self.skip_brackets()  # CURRENT_TIMESTAMP(3)
+            return text.lower(), 2
+        if kind == "identifier" and self.peek(1) is not None and self.peek(1)[0] == "string":
+            # Typed literal: DATE '2024-01-01', INTERVAL '1' DAY
+            type_name = _TYPE_NAMES.get(text.lower(), text.lower())
+            self.index += 2
+            if upper == "INTERVAL":
+                while _is_keyword(self.peek(), *_INTERVAL_FIELDS):
+                    self.index += 1
+            return type_name, 1
+        if kind in ("identifier", "quoted"):
+            # Column reference or function call: a, t.a, s.f(x)
+            name = _identifier(token)
+            self.index += 1
+            while _is_operator(self.peek(), ".") and self.peek(1) is not None:
+                part = self.peek(1)
+                if _is_operator(part, "*"):
+                    self.index += 2
+                    return None, -1
+                if part[0] not in ("identifier", "quoted"):
+                    break
+                name = _identifier(part)
+                self.index += 2
+            if _is_operator(self.peek(), "("):
+                self.skip_brackets()
+                if _is_keyword(self.peek(), "WITHIN"):
+                    self.index += 2  # WITHIN GROUP
+                    self.skip_brackets()
+                if _is_keyword(self.peek(), "FILTER"):
+                    self.index += 1
+                    self.skip_brackets()
+                if _is_keyword(self.peek(), "OVER"):
+                    self.index += 1
+                    if _is_operator(self.peek(), "("):
+                        self.skip_brackets()
+                    else:
+                        self.index += 1  # Named window
+            return name, 2
+        return None, 0
+
+
+def _item_name(tokens: list[tuple[str, str]]) -> str | None:
+    """Name of a select-list item; None for a * (its columns come from IRIS)."""
+    if (
+        len(tokens) >= 2
+        and _is_keyword(tokens[-2], "AS")
+        and tokens[-1][0] in ("identifier", "quoted")
+    ):
+        return truncate_name(_identifier(tokens[-1]))
+    if len(tokens) >= 2 and tokens[-1][0] in ("identifier", "quoted"):
+        previous = tokens[-2]
+        ends_expression = previous[0] in ("identifier", "quoted", "string", "number") or (
+            _is_operator(previous, ")", "]")
+        )
+        if (
+            ends_expression
+            and not (tokens[-1][0] == "identifier" and tokens[-1][1].upper() in _NOT_ALIAS)
+            and not _is_keyword(previous, "COLLATE", "AT", "ZONE")
+        ):
+            # Bare alias: SELECT count(*) total
+            head = _Item(tokens[:-1])
+            head.name()
+            if head.at_end():
+                return truncate_name(_identifier(tokens[-1]))
+    item = _Item(tokens)
+    name, strength = item.name()
+    if strength < 0:
+        return None
+    if strength == 0 or not name:
+        return UNNAMED
+    return truncate_name(name)
+
+
+def _statement_names(tokens: list[tuple[str, str]]) -> list[str | None] | None:
+    """Column names of a SELECT, WITH, VALUES or DML ... RETURNING statement."""
+    if not tokens:
+        return None
+    first = tokens[0]
+    if _is_keyword(first, "VALUES"):
+        if not _is_operator(tokens[1] if len(tokens) > 1 else None, "("):
+            return None
+        end = _closing(tokens, 1)
+        row, _ = _split(tokens[2:end])
+        return [f"column{number}" for number in range(1, len(row) + 1)]
+    if _is_keyword(first, "INSERT", "UPDATE", "DELETE", "MERGE", "WITH", "SELECT"):
+        index = 0
+        while index < len(tokens):
+            token = tokens[index]
+            if _is_operator(token, "(", "["):
+                index = _closing(tokens, index) + 1
+                continue
+            if _is_keyword(token, "SELECT") and not _is_keyword(
+                first, "INSERT", "UPDATE", "DELETE", "MERGE"
+            ):
+                index += 1
+                if _is_keyword(tokens[index] if index < len(tokens) else None, "ALL"):
+                    index += 1
+                elif _is_keyword(tokens[index] if index < len(tokens) else None, "DISTINCT"):
+                    index += 1
+                    if _is_keyword(tokens[index] if index < len(tokens) else None, "ON"):
+                        index = _closing(tokens, index + 1) + 1
+                items, _ = _split(tokens[index:])
+                return [_item_name(item) for item in items]
+            if _is_keyword(token, "RETURNING"):
+                items, _ = _split(tokens[index + 1 :])
+                return [_item_name(item) for item in items]
+            if _is_keyword(token, "VALUES") and _is_keyword(first, "WITH"):
+                return _statement_names(tokens[index:])
+            if _is_keyword(token, "INSERT", "UPDATE", "DELETE", "MERGE") and _is_keyword(
+                first, "WITH"
+            ):
+                return _statement_names(tokens[index:])
+            index += 1
+    return None
+
+
+def result_column_names(sql: str) -> list[str | None] | None:
+    """
+    PostgreSQL's names for the result columns of sql, one per select-list item
+    (None for a *), or None when sql is not a statement the bridge can name.
+    """
+    tokens = _tokens(sql)
+    if not tokens:
+        return None
+    while tokens and _is_operator(tokens[-1], ";"):
+        tokens.pop()
+    try:
+        return _statement_names(tokens)
+    except (IndexError, TypeError):
+        return None
+
+
+def _aligned_names(names: list[str | None], count: int) -> list[str | None] | None:
+    """names placed on count result columns (None where IRIS's name stays)."""
+    if None not in names:
+        return names if len(names) == count else None
+    first_star = names.index(None)
+    last_star = len(names) - 1 - names[::-1].index(None)
+    prefix, suffix = names[:first_star], names[last_star + 1 :]
+    if len(prefix) + len(suffix) > count:
+        return None
+    return [*prefix, *[None] * (count - len(prefix) - len(suffix)), *suffix]
+
+
+def name_result_columns(
+    columns: list[dict[str, Any]] | None, sql: str
+) -> list[dict[str, Any]] | None:
+    """
+    columns renamed after the select list of the client's sql.
+
+    Named columns are marked name_from_sql, so RowDescription keeps their case;
+    columns that cannot be matched to an item keep IRIS's name.
+    """
+    if not columns or not sql:
+        return columns
+    names = result_column_names(sql)
+    if names is None:
+        return columns
+    aligned = _aligned_names(names, len(columns))
+    if aligned is None:
+        return columns
+    return [
+        column if name is None else {**column, "name": name, "name_from_sql": True}
+        for column, name in zip(columns, aligned, strict=True)
+    ]
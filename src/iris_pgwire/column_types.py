@@ -0,0 +1,181 @@
+"""
+Result Column Types from IRIS Metadata
+
+IRIS describes result columns by ODBC SQL type code (DB-API
+cursor.description, %SQL.StatementColumn ODBCType) with precision and scale,
+or by type name (INFORMATION_SCHEMA.COLUMNS data_type). RowDescription reports
+each column's PostgreSQL type OID, typlen and typmod from them, so drivers
+decode int2/int4/int8, numeric, varchar, date, timestamp, bool and bytea
+columns into their native types instead of strings:
+
+- type codes are mapped to IRIS type names, and type names to OIDs through
+  type_mapping.py, so PGWIRE_TYPE_MAP_* overrides apply to results too
+- numeric(p,s) reports typmod ((p << 16) | s) + 4, varchar(n) and char(n)
+  report n + 4; lengths beyond PostgreSQL's limit report none (-1)
+- typlen is the type's fixed width (int4 4, timestamp 8) or -1
+- BIT and %Library.Boolean columns are bool (bool_values.py)
+- UNIQUEIDENTIFIER columns, and columns of the PGWIRE_UUID_COLUMN_TYPE
+  character type (CHAR(36)), are uuid (uuid_values.py); columns of the
+  PGWIRE_JSON_COLUMN_TYPE (VARCHAR(3641144)) are jsonb (json_values.py)
+- IRIS MONEY columns are money; columns created as MONEY are described as
+  money by the executor, which knows their marker (money_values.py)
+"""
+
+import re
+
+from .json_values import JSONB_OID, is_json_column
+from .type_mapping import get_type_mapping
+from .uuid_values import UUID_OID, is_uuid_column
+
+# ODBC SQL type code -> IRIS type name
+ODBC_TYPE_NAMES: dict[int, str] = {
+    -11: "UNIQUEIDENTIFIER",  # SQL_GUID
+    -10: "LONGVARCHAR",  # SQL_WLONGVARCHAR
+    -9: "VARCHAR",  # SQL_WVARCHAR
+    -8: "CHAR",  # SQL_WCHAR
+    -7: "BIT",
+    -6: "TINYINT",
+    -5: "BIGINT",
+    -4: "LONGVARBINARY",
+    -3: "VARBINARY",
+    -2: "BINARY",
+    -1: "LONGVARCHAR",
+    1: "CHAR",
+    2: "NUMERIC",
+    3: "DECIMAL",
+    4: "INTEGER",
+    5: "SMALLINT",
+    6: "DOUBLE",  # SQL_FLOAT is double precision
+    7: "REAL",
+    8: "DOUBLE",
+    9: "DATE",  # ODBC 2 codes 9-11 and ODBC 3 codes 91-93
+    10: "TIME",
+    11: "TIMESTAMP",
+    12: "VARCHAR",
+    16: "BOOLEAN",
+    91: "DATE",
+    92: "TIME",
+    93: "TIMESTAMP",
+}
+
+# Type names IRIS uses that type_mapping.py knows under another name
+_TYPE_NAME_ALIASES = {
+    "INT": "INTEGER",
+    "DATETIME": "TIMESTAMP",
+    "POSIXTIME": "TIMESTAMP",
+    "NVARCHAR": "VARCHAR",
+    "NCHAR": "CHAR",
+    "CHARACTER": "CHAR",
+    "DOUBLE PRECISION": "DOUBLE",
+    "%LIBRARY.BOOLEAN": "BIT",
+    "%BOOLEAN": "BIT",
+    "SMALLMONEY": "MONEY",
+    "CURRENCY": "MONEY",
+    "%LIBRARY.CURRENCY": "MONEY",
+}
+
+# Type OID -> typlen (types not listed are variable length, -1)
+TYPE_LENGTHS: dict[int, int] = {
+    16: 1,  # bool
+    18: 1,  # char
+    19: 64,  # name
+    20: 8,  # int8
+    21: 2,  # int2
+    23: 4,  # int4
+    26: 4,  # oid
+    700: 4,  # float4
+    701: 8,  # float8
+    790: 8,  # money
+    1082: 4,  # date
+    1083: 8,  # time
+    1114: 8,  # timestamp
+    1184: 8,  # timestamptz
+    1186: 16,  # interval
+    1266: 12,  # timetz
+    2950: 16,  # uuid
+}
+
+_NUMERIC_OID = 1700
+_CHARACTER_OIDS = frozenset({1042, 1043})  # bpchar, varchar
+
+# Largest numeric precision and varchar length PostgreSQL accepts in a typmod
+_MAX_NUMERIC_PRECISION = 1000
+_MAX_CHARACTER_LENGTH = 10_485_760
+
+# Names IRIS gives unnamed result columns, and literals it names by their value
+_GENERATED_NAME = re.compile(r"^(?:(?:Expression|HostVar|Aggregate)_\d+|-?[\d.]+)$", re.IGNORECASE)
+
+_TYPE_NAME = re.compile(r"^\s*([A-Za-z][\w ]*?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?\s*$")
+
+
+def _integer(value) -> int | None:
+    try:
+        return int(value) if value is not None else None
+    except (TypeError, ValueError):
+        return None
+
+
+def numeric_typmod(precision: int | None, scale: int | None) -> int:
+    """typmod of numeric(precision, scale), or -1 when the precision is unknown."""
+    if not precision or not 0 < precision <= _MAX_NUMERIC_PRECISION:
+        return -1
+    scale = scale or 0
+    if not 0 <= scale <= precision:
+        return -1
+    return ((precision << 16) | scale) + 4
+
+
+def character_typmod(length: int | None) -> int:
+    """typmod of varchar(length) / char(length), or -1 when unlimited or unknown."""
+    if not length or not 0 < length <= _MAX_CHARACTER_LENGTH:
+        return -1
+    return length + 4
+
+
+def is_generated_column_name(name) -> bool:
+    """Whether IRIS named a result column itself (a computed value, not a table column)."""
+    return isinstance(name, str) and bool(_GENERATED_NAME.match(name))
+
+
+def iris_type_name(iris_type: str | int) -> str:
+    """IRIS type name of an ODBC type code or a type name (VARCHAR(50) -> VARCHAR)."""
+    if isinstance(iris_type, int):
+        return ODBC_TYPE_NAMES.get(iris_type, "TEXT")
+    match = _TYPE_NAME.match(str(iris_type))
+    name = match.group(1).upper() if match else str(iris_type).upper()
+    return _TYPE_NAME_ALIASES.get(name, name)
+
+
+def describe_column(
+    iris_type: str | int,
+    precision: int | None = None,
+    scale: int | None = None,
+) -> tuple[int, int, int]:
+    """
+    (type OID, typlen, typmod) of a result column.
+
+    Args:
+        iris_type: ODBC SQL type code, or IRIS type name with optional
+            parameters (NUMERIC(10,2), VARCHAR(50))
+        precision: Numeric precision or character length, when not in the name
+        scale: Numeric scale, when not in the name
+    """
+    precision, scale = _integer(precision), _integer(scale)
+    if isinstance(iris_type, str):
+        match = _TYPE_NAME.match(iris_type)
+        if match and match.group(2):
+            precision = int(match.group(2))
+            scale = int(match.group(3)) if match.group(3) else 0
+    type_name = iris_type_name(iris_type)
+    type_oid = get_type_mapping(type_name)[2]
+    if type_oid in _CHARACTER_OIDS and is_uuid_column(type_name, precision):
+        type_oid = UUID_OID
+    elif type_oid in _CHARACTER_OIDS and is_json_column(type_name, precision):
+        type_oid = JSONB_OID
+
+    type_modifier = -1
+    if type_oid == _NUMERIC_OID:
+        type_modifier = numeric_typmod(precision, scale)
+    elif type_oid in _CHARACTER_OIDS:
+        type_modifier = character_typmod(precision)
+    return type_oid, TYPE_LENGTHS.get(type_oid, -1), type_modifier
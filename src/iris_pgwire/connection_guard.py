@@ -0,0 +1,171 @@
+"""
+Connection Rate Limiting and Failed-Authentication Lockout
+
+Brute-force protection for exposed listeners, keyed by client IP address:
+
+- rate limit: at most N new connections per IP per window; further
+  connections are refused with FATAL 53300 until the window slides
+- lockout: after N failed authentications within a window, the IP is refused
+  (FATAL 28000) for the lockout period; a successful login resets the count.
+  Only rejected credentials (password, certificate, GSSAPI or LDAP) count, not
+  clients that disconnect at the password prompt, time out or fail TLS
+
+Refusals, failures and lockouts are recorded in the audit log (audit.py).
+
+Configuration:
+- PGWIRE_CONNECTION_RATE_LIMIT: connections per IP per window (default 0 = off)
+- PGWIRE_CONNECTION_RATE_WINDOW: seconds (default 60)
+- PGWIRE_AUTH_FAILURE_LIMIT: failures before lockout (default 10, 0 = off)
+- PGWIRE_AUTH_FAILURE_WINDOW: seconds failures are counted over (default 300)
+- PGWIRE_AUTH_LOCKOUT_SECONDS: lockout duration (default 300)
+- PGWIRE_AUTH_EXEMPT_HOSTS: comma-separated IPs never limited (e.g. 127.0.0.1)
+"""
+
+import os
+import struct
+import time
+from collections import deque
+
+import structlog
+
+from .audit import AuditLog, get_audit_log
+
+logger = structlog.get_logger()
+
+# Forget idle addresses once this many are tracked
+_SWEEP_THRESHOLD = 10000
+
+
+class ConnectionRejected(Exception):
+    """Connection refused before the protocol starts."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+    def error_response(self) -> bytes:
+        """FATAL ErrorResponse message to send before closing."""
+        fields = (
+            b"SFATAL\x00C" + self.sqlstate.encode() + b"\x00M" + str(self).encode() + b"\x00\x00"
+        )
+        return b"E" + struct.pack("!I", len(fields) + 4) + fields
+
+
+class ConnectionGuard:
+    """Per-IP connection rate limit and authentication failure lockout."""
+
+    def __init__(
+        self,
+        rate_limit: int = 0,
+        rate_window: float = 60.0,
+        failure_limit: int = 10,
+        failure_window: float = 300.0,
+        lockout_seconds: float = 300.0,
+        exempt_hosts: frozenset[str] = frozenset(),
+        audit: AuditLog | None = None,
+        clock=time.monotonic,
+    ):
+        self.rate_limit = rate_limit
+        self.rate_window = rate_window
+        self.failure_limit = failure_limit
+        self.failure_window = failure_window
+        self.lockout_seconds = lockout_seconds
+        self.exempt_hosts = exempt_hosts
+        self.audit = audit or get_audit_log()
+        self._clock = clock
+        self._connections: dict[str, deque[float]] = {}
+        self._failures: dict[str, deque[float]] = {}
+        self._locked_until: dict[str, float] = {}
+
+    @classmethod
+    def from_env(cls) -> "ConnectionGuard":
+        exempt = os.getenv("PGWIRE_AUTH_EXEMPT_HOSTS", "")
+        return cls(
+            rate_limit=int(os.getenv("PGWIRE_CONNECTION_RATE_LIMIT", "0")),
+            rate_window=float(os.getenv("PGWIRE_CONNECTION_RATE_WINDOW", "60")),
+            failure_limit=int(os.getenv("PGWIRE_AUTH_FAILURE_LIMIT", "10")),
+            failure_window=float(os.getenv("PGWIRE_AUTH_FAILURE_WINDOW", "300")),
+            lockout_seconds=float(os.getenv("PGWIRE_AUTH_LOCKOUT_SECONDS", "300")),
+            exempt_hosts=frozenset(h.strip() for h in exempt.split(",") if h.strip()),
+        )
+
+    @staticmethod
+    def _prune(events: deque[float], cutoff: float) -> None:
+        while events and events[0] <= cutoff:
+            events.popleft()
+
+    def _sweep(self, now: float) -> None:
+        tracked = ((self._connections, self.rate_window), (self._failures, self.failure_window))
+        for events, window in tracked:
+            for host in list(events):
+                self._prune(events[host], now - window)
+                if not events[host]:
+                    del events[host]
+        for host, until in list(self._locked_until.items()):
+            if until <= now:
+                del self._locked_until[host]
+
+    def locked_out(self, host: str) -> bool:
+        until = self._locked_until.get(host)
+        if until is None:
+            return False
+        if until <= self._clock():
+            del self._locked_until[host]
+            return False
+        return True
+
+    def check_connection(self, host: str) -> None:
+        """
+        Admit or refuse a new connection from host.
+
+        Raises:
+            ConnectionRejected: host is locked out or over the rate limit
+        """
+        if host in self.exempt_hosts:
+            return
+        now = self._clock()
+        if len(self._connections) + len(self._failures) > _SWEEP_THRESHOLD:
+            self._sweep(now)
+
+        if self.locked_out(host):
+            self.audit.record("connection_rejected", client=host, reason="lockout")
+            raise ConnectionRejected(
+                "too many failed authentication attempts; try again later",
+                "28000",
+                "invalid_authorization_specification",
+            )
+
+        if self.rate_limit > 0:
+            events = self._connections.setdefault(host, deque())
+            self._prune(events, now - self.rate_window)
+            if len(events) >= self.rate_limit:
+                self.audit.record("connection_rejected", client=host, reason="rate_limit")
+                raise ConnectionRejected(
+                    "connection rate limit exceeded; try again later",
+                    "53300",
+                    "too_many_connections",
+                )
+            events.append(now)
+
+    def record_auth_failure(self, host: str, user: str | None) -> None:
+        """Count a failed authentication and lock the host out at the limit."""
+        if host in self.exempt_hosts or self.failure_limit <= 0:
+            self.audit.record("auth_failed", client=host, user=user)
+            return
+        now = self._clock()
+        failures = self._failures.setdefault(host, deque())
+        self._prune(failures, now - self.failure_window)
+        failures.append(now)
+        self.audit.record("auth_failed", client=host, user=user, failures=len(failures))
+
+        if len(failures) >= self.failure_limit:
+            self._locked_until[host] = now + self.lockout_seconds
+            del self._failures[host]
+            self.audit.record(
+                "auth_lockout", client=host, user=user, lockout_seconds=self.lockout_seconds
+            )
+
+    def record_auth_success(self, host: str, user: str | None) -> None:
+        self._failures.pop(host, None)
+        self.audit.record("auth_success", client=host, user=user)
@@ -0,0 +1,366 @@
+"""
+Binary COPY Format
+
+COPY ... FROM STDIN (FORMAT BINARY) and COPY ... TO STDOUT (FORMAT BINARY)
+exchange PostgreSQL's binary copy file, which pgx's CopyFrom and most
+high-throughput loaders use:
+
+    PGCOPY\\n\\377\\r\\n\\0, flags (int32), header extension length (int32)
+    per row: field count (int16), then per field its length (int32, -1 for
+             NULL) and its value in the type's binary send format
+    trailer: field count -1
+
+Fields are encoded by the PostgreSQL type of their column: the type mapping of
+the IRIS column type (type_mapping.py) for table COPY, the result's column
+types for COPY (query). Values arrive in the forms IRIS parameters accept -
+integers and floats as numbers, dates and timestamps as ISO text, booleans as
+1 / 0, NUMERIC as decimal text. A fixed-width field whose length does not fit
+its column type is read as text, so clients that describe a column
+differently still load. Malformed data fails with 22P04 (bad_copy_file_format)
+or, for a single field, 22P03 (invalid_binary_representation).
+"""
+
+import struct
+import uuid
+from collections.abc import AsyncIterator
+from datetime import date, datetime, time, timedelta
+from decimal import ROUND_HALF_UP, Context, Decimal, InvalidOperation, localcontext
+
+from .csv_processor import CSVParsingError
+from .timezone_support import posix_time_to_datetime
+from .type_mapping import get_type_mapping
+
+SIGNATURE = b"PGCOPY\n\xff\r\n\x00"
+HEADER = SIGNATURE + struct.pack("!ii", 0, 0)
+TRAILER = struct.pack("!h", -1)
+
+_PG_EPOCH = datetime(2000, 1, 1)
+_PG_EPOCH_DATE = _PG_EPOCH.date()
+
+# Type OIDs with a fixed-width binary form: (struct format, width)
+_INTEGERS = {21: "!h", 23: "!i", 20: "!q"}
+_INTEGER_WIDTHS = {2: "!h", 4: "!i", 8: "!q"}
+_FLOATS = {700: "!f", 701: "!d"}
+_FIXED_WIDTHS = {16: 1, 21: 2, 23: 4, 20: 8, 700: 4, 701: 8, 1082: 4, 1083: 8, 1114: 8, 1184: 8}
+
+_NUMERIC_POSITIVE, _NUMERIC_NEGATIVE, _NUMERIC_NAN = 0x0000, 0x4000, 0xC000
+_NUMERIC_INFINITY, _NUMERIC_NEG_INFINITY = 0xD000, 0xF000
+
+
+class BinaryCopyError(CSVParsingError):
+    """Malformed binary COPY data."""
+
+    def __init__(
+        self,
+        message: str,
+        row_number: int,
+        sqlstate: str = "22P04",
+        condition_name: str = "bad_copy_file_format",
+    ):
+        super().__init__(message, row_number)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+    def __str__(self) -> str:
+        return f"{self.message} (COPY row {self.line_number})"
+
+
+def column_oids(columns: list[tuple[str, str]]) -> list[int]:
+    """PostgreSQL type OIDs of (IRIS column name, IRIS data type) pairs."""
+    return [get_type_mapping(data_type or "")[2] or 25 for _, data_type in columns]
+
+
+# ----- Values to binary fields (COPY TO) -----
+
+
+def _parse_date(value) -> date:
+    if isinstance(value, datetime):
+        return value.date()
+    if isinstance(value, date):
+        return value
+    return date.fromisoformat(str(value).strip()[:10])
+
+
+def _parse_timestamp(value) -> datetime:
+    if isinstance(value, datetime):
+        return value.replace(tzinfo=None)
+    if isinstance(value, date):
+        return datetime(value.year, value.month, value.day)
+    if isinstance(value, int):
+        return posix_time_to_datetime(value)
+    return datetime.fromisoformat(str(value).strip()).replace(tzinfo=None)
+
+
+def _parse_time(value) -> time:
+    if isinstance(value, time):
+        return value
+    if isinstance(value, datetime):
+        return value.time()
+    return time.fromisoformat(str(value).strip())
+
+
+def numeric_value(value, scale: int | None = None) -> Decimal:
+    """
+    Exact Decimal of a NUMERIC value, rounded (half away from zero, as
+    PostgreSQL does) to scale fractional digits when the column declares one.
+
+    Floats are read through their shortest repr, so a value IRIS returned as
+    a float is not widened into binary noise (0.1, not 0.1000000000000000055).
+    """
+    if isinstance(value, Decimal):
+        number = value
+    elif isinstance(value, float):
+        number = Decimal(repr(value))
+    elif isinstance(value, int):
+        number = Decimal(value)
+    else:
+        number = Decimal(str(value).strip())
+    if scale is not None and number.is_finite():
+        digits = max(number.adjusted(), 0) + scale + 2
+        number = number.quantize(
+            Decimal(1).scaleb(-scale), rounding=ROUND_HALF_UP, context=Context(prec=digits)
+        )
+    if number.is_zero():
+        number = number.copy_abs()  # PostgreSQL has no negative zero
+    return number
+
+
+def encode_numeric(value, scale: int | None = None) -> bytes:
+    """
+    NUMERIC binary form: ndigits, weight, sign, dscale and base-10000 digits.
+
+    dscale is the value's own number of fractional digits, or the column's
+    scale when given (numeric(10,2) sends 10.5 as 10.50).
+    """
+    number = numeric_value(value, scale)
+    if number.is_nan():
+        return struct.pack("!hhHh", 0, 0, _NUMERIC_NAN, 0)
+    if number.is_infinite():
+        sign = _NUMERIC_NEG_INFINITY if number.is_signed() else _NUMERIC_INFINITY
+        return struct.pack("!hhHh", 0, 0, sign, 0)
+    sign = _NUMERIC_NEGATIVE if number.is_signed() else _NUMERIC_POSITIVE
+    dscale = max(0, -number.as_tuple().exponent)
+    integer, _, fraction = format(number.copy_abs(), "f").partition(".")  # abs() rounds
+    integer = integer.lstrip("0")
+    integer = integer.zfill(-(-len(integer) // 4) * 4)
+    fraction = fraction.ljust(-(-len(fraction) // 4) * 4, "0")
+    digits = [int(integer[i : i + 4]) for i in range(0, len(integer), 4)]
+    weight = len(digits) - 1
+    digits += [int(fraction[i : i + 4]) for i in range(0, len(fraction), 4)]
+    while digits and digits[0] == 0:
+        digits.pop(0)
+        weight -= 1
+    while digits and digits[-1] == 0:
+        digits.pop()
+    if not digits:
+        weight = 0
+    return struct.pack(f"!hhHh{len(digits)}h", len(digits), weight, sign, dscale, *digits)
+
+
+def decode_numeric(data: bytes) -> str:
+    """
+    Decimal text of a NUMERIC binary value, every digit kept.
+
+    Raises:
+        ValueError: a length, sign or digit PostgreSQL's numeric_recv rejects
+    """
+    ndigits, weight, sign, dscale = struct.unpack("!hhHh", data[:8])
+    if ndigits < 0 or len(data) != 8 + 2 * ndigits or dscale < 0:
+        raise ValueError("invalid length in external numeric value")
+    if sign == _NUMERIC_NAN:
+        return "NaN"
+    if sign in (_NUMERIC_INFINITY, _NUMERIC_NEG_INFINITY):
+        return "Infinity" if sign == _NUMERIC_INFINITY else "-Infinity"
+    if sign not in (_NUMERIC_POSITIVE, _NUMERIC_NEGATIVE):
+        raise ValueError("invalid sign in external numeric value")
+    digits = struct.unpack(f"!{ndigits}h", data[8:])
+    if any(not 0 <= digit < 10000 for digit in digits):
+        raise ValueError("invalid digit in external numeric value")
+    with localcontext() as context:
+        context.prec = 4 * (ndigits + abs(weight)) + dscale + 8
+        number = sum(
+            (Decimal(digit).scaleb(4 * (weight - i)) for i, digit in enumerate(digits)),
+            Decimal(0),
+        )
+        number = number.quantize(Decimal(1).scaleb(-dscale))
+        if sign == _NUMERIC_NEGATIVE and number:
+            number = -number
+    return format(number, "f")
+
+
+def encode_value(value, oid: int) -> bytes:
+    """Binary send form of a non-NULL value for a column of type oid."""
+    if oid == 16:
+        if isinstance(value, str):
+            value = value.strip().lower() in ("1", "t", "true", "y", "yes", "on")
+        return b"\x01" if value else b"\x00"
+    if oid in _INTEGERS:
+        return struct.pack(_INTEGERS[oid], int(value))
+    if oid in _FLOATS:
+        return struct.pack(_FLOATS[oid], float(value))
+    if oid == 1700:
+        return encode_numeric(value)
+    if oid == 1082:
+        return struct.pack("!i", (_parse_date(value) - _PG_EPOCH_DATE).days)
+    if oid == 1083:
+        moment = _parse_time(value)
+        micros = ((moment.hour * 60 + moment.minute) * 60 + moment.second) * 1_000_000
+        return struct.pack("!q", micros + moment.microsecond)
+    if oid in (1114, 1184):
+        return struct.pack("!q", (_parse_timestamp(value) - _PG_EPOCH) // timedelta(microseconds=1))
+    if oid == 17:
+        return bytes(value) if isinstance(value, bytes | bytearray) else str(value).encode()
+    if oid == 2950:
+        return uuid.UUID(str(value)).bytes
+    if oid == 3802:
+        return b"\x01" + str(value).encode("utf-8")
+    if isinstance(value, bool):
+        value = "t" if value else "f"
+    return str(value).encode("utf-8")
+
+
+def encode_binary_rows(rows: list[tuple], oids: list[int]) -> bytes:
+    """Rows as binary COPY tuples (without header or trailer)."""
+    out = bytearray()
+    field_count = struct.pack("!h", len(oids))
+    for row in rows:
+        out += field_count
+        for value, oid in zip(row, oids, strict=False):
+            if value is None:
+                out += b"\xff\xff\xff\xff"
+                continue
+            field = encode_value(value, oid)
+            out += struct.pack("!i", len(field)) + field
+    return bytes(out)
+
+
+# ----- Binary fields to IRIS parameter values (COPY FROM) -----
+
+
+def decode_value(data: bytes, oid: int):
+    """IRIS parameter value of a binary field of a column of type oid."""
+    if oid in _FIXED_WIDTHS and len(data) != _FIXED_WIDTHS[oid]:
+        if oid in _INTEGERS and len(data) in _INTEGER_WIDTHS:
+            return struct.unpack(_INTEGER_WIDTHS[len(data)], data)[0]
+        if oid in _FLOATS and len(data) in (4, 8):
+            return struct.unpack("!f" if len(data) == 4 else "!d", data)[0]
+        return data.decode("utf-8")  # Described as another type by the client
+    if oid == 16:
+        return 1 if data[0] else 0
+    if oid in _INTEGERS:
+        return struct.unpack(_INTEGERS[oid], data)[0]
+    if oid in _FLOATS:
+        return struct.unpack(_FLOATS[oid], data)[0]
+    if oid == 1700:
+        return decode_numeric(data)
+    if oid == 1082:
+        return (_PG_EPOCH_DATE + timedelta(days=struct.unpack("!i", data)[0])).isoformat()
+    if oid == 1083:
+        micros = struct.unpack("!q", data)[0]
+        return (datetime.min + timedelta(microseconds=micros)).strftime("%H:%M:%S.%f")
+    if oid in (1114, 1184):
+        moment = _PG_EPOCH + timedelta(microseconds=struct.unpack("!q", data)[0])
+        return moment.strftime("%Y-%m-%d %H:%M:%S.%f")
+    if oid == 17:
+        return data
+    if oid == 2950 and len(data) == 16:
+        return str(uuid.UUID(bytes=data))
+    if oid == 3802 and data[:1] == b"\x01":
+        return data[1:].decode("utf-8")
+    return data.decode("utf-8")
+
+
+async def parse_binary_rows(
+    stream: AsyncIterator[bytes], column_names: list[str], oids: list[int]
+) -> AsyncIterator[dict]:
+    """
+    Row dicts (keyed by column_names) from the CopyData payloads of a binary COPY.
+
+    Raises:
+        BinaryCopyError: Bad signature, wrong field count, truncated data or
+            an undecodable field
+    """
+    buffer = bytearray()
+    position = 0
+    row_number = 0
+    header_read = False
+    finished = False
+
+    async for chunk in stream:
+        if finished:
+            continue  # Data after the trailer is ignored, as by PostgreSQL
+        buffer += chunk
+        while True:
+            if not header_read:
+                if len(buffer) < len(HEADER):
+                    break
+                if bytes(buffer[: len(SIGNATURE)]) != SIGNATURE:
+                    raise BinaryCopyError("COPY file signature not recognized", 0)
+                extension = struct.unpack_from("!i", buffer, len(SIGNATURE) + 4)[0]
+                if len(buffer) < len(HEADER) + extension:
+                    break
+                position = len(HEADER) + extension
+                header_read = True
+
+            row = _read_row(buffer, position, len(oids), row_number + 1)
+            if row is None:
+                break
+            fields, position = row
+            if fields is None:
+                finished = True
+                break
+            row_number += 1
+            yield {
+                name: None if data is None else _decode_field(data, oid, row_number, name)
+                for name, oid, data in zip(column_names, oids, fields, strict=True)
+            }
+
+        # Drop consumed bytes so the buffer stays one row long
+        del buffer[:position]
+        position = 0
+
+    if not finished and (buffer or not header_read):
+        raise BinaryCopyError("unexpected EOF in COPY data", row_number + 1)
+
+
+def _read_row(buffer: bytearray, position: int, expected: int, row_number: int):
+    """
+    (fields, next position) of the row at position, fields None for the
+    trailer; None when the buffer does not hold the whole row yet.
+    """
+    if len(buffer) < position + 2:
+        return None
+    count = struct.unpack_from("!h", buffer, position)[0]
+    position += 2
+    if count == -1:
+        return None, position
+    if count != expected:
+        raise BinaryCopyError(f"row field count is {count}, expected {expected}", row_number)
+    fields = []
+    for _ in range(count):
+        if len(buffer) < position + 4:
+            return None
+        length = struct.unpack_from("!i", buffer, position)[0]
+        position += 4
+        if length == -1:
+            fields.append(None)
+            continue
+        if length < 0:
+            raise BinaryCopyError(f"invalid field size {length}", row_number)
+        if len(buffer) < position + length:
+            return None
+        fields.append(bytes(buffer[position : position + length]))
+        position += length
+    return fields, position
+
+
+def _decode_field(data: bytes, oid: int, row_number: int, column: str):
+    try:
+        return decode_value(data, oid)
+    except (struct.error, ValueError, InvalidOperation, OverflowError) as e:
+        raise BinaryCopyError(
+            f'incorrect binary data format in column "{column}": {e}',
+            row_number,
+            "22P03",
+            "invalid_binary_representation",
+        ) from None
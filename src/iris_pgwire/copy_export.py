@@ -0,0 +1,224 @@
+"""
+COPY TO Fast Path (pg_dump Data Phase)
+
+pg_dump exports every table with
+
+    COPY schema.table (col, ...) TO stdout;
+
+Run as an ordinary query, that would fetch the whole table into one result
+and convert each value on the way through the bridge. Table COPY instead
+reads the rows straight from IRIS in batches (IRISExecutor.stream_rows) and
+encodes each batch into CopyData at once:
+
+- the SELECT goes to IRIS as built here, without SQL translation
+- IRIS renders dates, times and timestamps in ISO form itself (%ODBCOUT), so
+  no value needs converting in Python
+- only one batch is held in memory: PGWIRE_COPY_FETCH_SIZE rows
+  (default 10000)
+
+Without a FORMAT option COPY TO writes PostgreSQL's text format (tab
+separated, \\N for NULL, backslash escapes), which pg_restore and psql read
+back. FORMAT CSV follows PostgreSQL's CSV rules: NULL is an unquoted empty
+field (NULL '...' overrides it), a value is quoted when it contains the
+delimiter, quote or a line break or reads as the NULL marker, and FORCE_QUOTE
+quotes the named columns (or all, with *).
+
+COPY (query) TO STDOUT runs its query through the translator like any other
+statement; it is executed before CopyOutResponse, so the response carries the
+result's column count, HEADER the column names, and a failing query returns
+its own error instead of an interrupted copy.
+"""
+
+import os
+from dataclasses import dataclass, field
+
+from .check_constraints import Relation, _unquote
+from .large_values import LargeValue
+from .partitioning import sql_identifier
+
+DEFAULT_FETCH_SIZE = 10000
+
+# IRIS column types whose logical values need %ODBCOUT to read as ISO text
+_TEMPORAL_TYPES = frozenset({"date", "time", "timestamp", "datetime", "posixtime"})
+
+
+class CopyExportError(Exception):
+    """A table COPY that cannot start (unknown table or column)."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+@dataclass
+class TableExport:
+    target: Relation
+    columns: list[tuple[str, str]]  # (IRIS column name, IRIS data type), in COPY order
+
+    @property
+    def column_names(self) -> list[str]:
+        return [name for name, _ in self.columns]
+
+    @property
+    def sql(self) -> str:
+        """The SELECT reading the exported columns from IRIS."""
+        items = ", ".join(_select_item(name, data_type) for name, data_type in self.columns)
+        return f"SELECT {items} FROM {self.target.sql}"
+
+
+@dataclass
+class QueryExport:
+    """The executed query of COPY (query) TO STDOUT."""
+
+    column_names: list[str]
+    rows: list
+    type_oids: list[int] = field(default_factory=list)  # Result column types (binary COPY)
+
+
+def _select_item(name: str, data_type: str) -> str:
+    column = sql_identifier(name)
+    if data_type.lower() in _TEMPORAL_TYPES:
+        return f"%ODBCOUT({column})"
+    return column
+
+
+def fetch_size() -> int:
+    """Rows per IRIS fetch (PGWIRE_COPY_FETCH_SIZE)."""
+    try:
+        return max(1, int(os.getenv("PGWIRE_COPY_FETCH_SIZE", DEFAULT_FETCH_SIZE)))
+    except ValueError:
+        return DEFAULT_FETCH_SIZE
+
+
+def columns_sql(target: Relation) -> str:
+    """Columns of a table: (COLUMN_NAME, DATA_TYPE) rows in table order."""
+
+    def literal(value: str) -> str:
+        return "'" + value.replace("'", "''") + "'"
+
+    return (
+        "SELECT COLUMN_NAME, DATA_TYPE FROM INFORMATION_SCHEMA.COLUMNS "
+        f"WHERE LOWER(TABLE_SCHEMA) = LOWER({literal(target.schema)}) "
+        f"AND LOWER(TABLE_NAME) = LOWER({literal(target.table)}) "
+        "ORDER BY ORDINAL_POSITION"
+    )
+
+
+def table_export(target: Relation, rows: list, column_list: list[str] | None) -> TableExport:
+    """
+    The export of a table from its columns_sql() rows, limited to column_list
+    (as written in the COPY statement) when one is given.
+    """
+    columns = [(name, data_type or "") for name, data_type in rows]
+    if not columns:
+        raise CopyExportError(
+            f'relation "{target.table}" does not exist', "42P01", "undefined_table"
+        )
+    if column_list is None:
+        return TableExport(target, columns)
+
+    by_name = {name.lower(): (name, data_type) for name, data_type in columns}
+    selected = []
+    for written in column_list:
+        column = by_name.get(_unquote(written).lower())
+        if column is None:
+            raise CopyExportError(
+                f'column "{_unquote(written)}" of relation "{target.table}" does not exist',
+                "42703",
+                "undefined_column",
+            )
+        selected.append(column)
+    return TableExport(target, selected)
+
+
+def _text_field(value, null_string: str, escapes: dict[int, str]) -> str:
+    if value is None:
+        return null_string
+    if isinstance(value, LargeValue):
+        value = value.read()  # A long character stream (stream_values.py)
+    if isinstance(value, bool):
+        return "t" if value else "f"
+    if isinstance(value, bytes | bytearray):
+        return "\\\\x" + value.hex()  # bytea hex output, its backslash escaped
+    return str(value).translate(escapes)
+
+
+def encode_text_rows(rows: list[tuple], delimiter: str = "\t", null_string: str = "\\N") -> bytes:
+    """Rows in PostgreSQL's COPY text format, one line each."""
+    # A custom delimiter is backslash-escaped; tab keeps its \t escape
+    escapes = str.maketrans(
+        {delimiter: "\\" + delimiter, "\\": "\\\\", "\n": "\\n", "\r": "\\r", "\t": "\\t"}
+    )
+    lines = [
+        delimiter.join(_text_field(value, null_string, escapes) for value in row) + "\n"
+        for row in rows
+    ]
+    return "".join(lines).encode("utf-8")
+
+
+def force_quote_columns(force_quote: list[str] | None, column_names: list[str]) -> set[int]:
+    """
+    Positions of the FORCE_QUOTE columns (["*"] = all columns).
+
+    Raises:
+        CopyExportError: FORCE_QUOTE names a column that is not copied
+    """
+    if not force_quote:
+        return set()
+    if force_quote == ["*"]:
+        return set(range(len(column_names)))
+    positions = {name.lower(): position for position, name in enumerate(column_names)}
+    selected = set()
+    for written in force_quote:
+        position = positions.get(_unquote(written).lower())
+        if position is None:
+            raise CopyExportError(
+                f'FORCE_QUOTE column "{_unquote(written)}" not referenced by COPY',
+                "42P10",
+                "invalid_column_reference",
+            )
+        selected.add(position)
+    return selected
+
+
+def _csv_field(value) -> str:
+    if isinstance(value, LargeValue):
+        value = value.read()
+    if isinstance(value, bool):
+        return "t" if value else "f"
+    if isinstance(value, bytes | bytearray):
+        return "\\x" + value.hex()
+    return str(value)
+
+
+def encode_csv_rows(
+    rows: list[tuple],
+    delimiter: str = ",",
+    null_string: str = "",
+    quote: str = '"',
+    escape: str = '"',
+    forced: set[int] = frozenset(),
+) -> bytes:
+    """Rows in PostgreSQL's COPY CSV format, one line each."""
+    specials = (delimiter, quote, "\r", "\n")
+    lines = []
+    for row in rows:
+        fields = []
+        for position, value in enumerate(row):
+            if value is None:
+                fields.append(null_string)
+                continue
+            text = _csv_field(value)
+            if (
+                position in forced
+                or text == null_string
+                or text == "\\."
+                or any(special in text for special in specials)
+            ):
+                if escape != quote:
+                    text = text.replace(escape, escape + escape)
+                text = quote + text.replace(quote, escape + quote) + quote
+            fields.append(text)
+        lines.append(delimiter.join(fields) + "\n")
+    return "".join(lines).encode("utf-8")
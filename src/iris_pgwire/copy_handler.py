@@ -6,7 +6,7 @@ Implements PostgreSQL COPY wire protocol message generation and handling.
 Wire Protocol Messages:
 - CopyInResponse ('G'): Server → Client (initiate COPY FROM STDIN)
 - CopyOutResponse ('H'): Server → Client (initiate COPY TO STDOUT)
-- CopyData ('d'): Bidirectional (stream CSV data)
+- CopyData ('d'): Bidirectional (stream text, CSV or binary data)
 - CopyDone ('c'): Client → Server (end of COPY FROM STDIN)
 - CopyFail ('f'): Client → Server (abort COPY FROM STDIN)
 
@@ -20,6 +20,14 @@ import struct
 from collections.abc import AsyncIterator
 
 from .bulk_executor import BulkExecutor
+from .copy_binary import HEADER, TRAILER, column_oids, encode_binary_rows, parse_binary_rows
+from .copy_export import (
+    QueryExport,
+    TableExport,
+    encode_csv_rows,
+    encode_text_rows,
+    force_quote_columns,
+)
 from .csv_processor import CSVProcessor
 from .sql_translator.copy_parser import CopyCommand
 
@@ -46,8 +54,9 @@ class CopyHandler:
         """
         self.csv_processor = csv_processor
         self.bulk_executor = bulk_executor
+        self.rows_exported = 0  # Rows sent by the last COPY TO STDOUT
 
-    def build_copy_in_response(self, column_count: int) -> bytes:
+    def build_copy_in_response(self, column_count: int, binary: bool = False) -> bytes:
         """
         Build CopyInResponse message (Server → Client).
 
@@ -56,21 +65,22 @@ class CopyHandler:
         - Int32: Length (including self)
         - Int8: Copy format (0=text/CSV, 1=binary)
         - Int16: Number of columns
-        - Int16[]: Format codes for each column (0=text)
+        - Int16[]: Format codes for each column (same as the copy format)
 
         Args:
             column_count: Number of columns in table
+            binary: FORMAT BINARY copy
 
         Returns:
             Encoded CopyInResponse message
         """
         # Build message payload
-        format_code = 0  # 0 = text/CSV format
+        format_code = 1 if binary else 0  # 0 = text/CSV format, 1 = binary
         payload = struct.pack("!b", format_code)  # Int8: format
         payload += struct.pack("!H", column_count)  # Int16: column count
-        # Format codes for each column (all 0 = text)
+        # Format codes for each column (all the copy format)
         for _ in range(column_count):
-            payload += struct.pack("!H", 0)  # Int16: format code
+            payload += struct.pack("!H", format_code)  # Int16: format code
 
         # Build full message
         message_type = b"G"
@@ -80,7 +90,7 @@ class CopyHandler:
         logger.debug(f"Built CopyInResponse: {len(message)} bytes, {column_count} columns")
         return message
 
-    def build_copy_out_response(self, column_count: int) -> bytes:
+    def build_copy_out_response(self, column_count: int, binary: bool = False) -> bytes:
         """
         Build CopyOutResponse message (Server → Client).
 
@@ -88,17 +98,18 @@ class CopyHandler:
 
         Args:
             column_count: Number of columns being exported
+            binary: FORMAT BINARY copy
 
         Returns:
             Encoded CopyOutResponse message
         """
         # Build message payload (same format as CopyInResponse)
-        format_code = 0  # 0 = text/CSV format
+        format_code = 1 if binary else 0  # 0 = text/CSV format, 1 = binary
         payload = struct.pack("!b", format_code)  # Int8: format
         payload += struct.pack("!H", column_count)  # Int16: column count
-        # Format codes for each column (all 0 = text)
+        # Format codes for each column (all the copy format)
         for _ in range(column_count):
-            payload += struct.pack("!H", 0)  # Int16: format code
+            payload += struct.pack("!H", format_code)  # Int16: format code
 
         # Build full message
         message_type = b"H"
@@ -294,7 +305,11 @@ class CopyHandler:
                     )
 
     async def handle_copy_from_stdin(
-        self, command: CopyCommand, csv_stream: AsyncIterator[bytes]
+        self,
+        command: CopyCommand,
+        csv_stream: AsyncIterator[bytes],
+        own_transaction: bool = True,
+        export: TableExport | None = None,
     ) -> int:
         """
         Handle COPY FROM STDIN operation with transactional semantics.
@@ -312,29 +327,43 @@ class CopyHandler:
         Args:
             command: Parsed COPY command
             csv_stream: Async iterator of CopyData message payloads
+            own_transaction: False inside the client's transaction block, whose
+                COMMIT / ROLLBACK then covers the COPY (IRIS COMMIT would end it)
+            export: The target's columns with their IRIS types
+                (BulkExecutor.table_export), by which FORMAT BINARY decodes fields
 
         Returns:
             Number of rows inserted
 
         Raises:
-            CSVParsingError: Malformed CSV data (transaction rolled back)
+            CSVParsingError: Malformed CSV or binary data (transaction rolled back)
             TransactionError: Transaction rollback required
         """
         logger.info(f"COPY FROM STDIN: table={command.table_name}, columns={command.column_list}")
 
-        # BEGIN transaction for atomic COPY operation
         iris_executor = self.bulk_executor.iris_executor
-        begin_result = await iris_executor.execute_query("START TRANSACTION", [])
-        if not begin_result.get("success", False):
-            raise RuntimeError(
-                f"Failed to begin transaction: {begin_result.get('error', 'Unknown error')}"
-            )
+        if own_transaction:
+            # BEGIN transaction for atomic COPY operation
+            begin_result = await iris_executor.execute_query("START TRANSACTION", [])
+            if not begin_result.get("success", False):
+                raise RuntimeError(
+                    f"Failed to begin transaction: {begin_result.get('error', 'Unknown error')}"
+                )
 
-        logger.debug("Transaction started for COPY FROM STDIN")
+            logger.debug("Transaction started for COPY FROM STDIN")
 
         try:
-            # Parse CSV data stream
-            rows_iterator = self.csv_processor.parse_csv_rows(csv_stream, command.csv_options)
+            # Parse text (default), CSV or binary data stream, keyed by the target columns
+            if command.csv_options.format == "BINARY":
+                if export is not None:
+                    oids = column_oids(export.columns)
+                else:
+                    oids = [25] * len(command.column_list)
+                rows_iterator = parse_binary_rows(csv_stream, command.column_list, oids)
+            else:
+                rows_iterator = self.csv_processor.parse_csv_rows(
+                    csv_stream, command.csv_options, command.column_list
+                )
 
             # Execute bulk insert
             # Note: Using individual INSERT statements per row (IRIS doesn't support multi-row INSERT)
@@ -346,6 +375,10 @@ class CopyHandler:
                 batch_size=100,  # Process 100 rows at a time
             )
 
+            if not own_transaction:
+                logger.info(f"COPY FROM STDIN complete: {row_count} rows inserted")
+                return row_count
+
             # COMMIT transaction on success
             commit_result = await iris_executor.execute_query("COMMIT", [])
             if not commit_result.get("success", False):
@@ -359,6 +392,9 @@ class CopyHandler:
             return row_count
 
         except Exception as e:
+            if not own_transaction:
+                raise  # The client's ROLLBACK undoes the rows already inserted
+
             # ROLLBACK transaction on any error
             logger.error(f"COPY FROM STDIN failed, rolling back transaction: {e}")
             try:
@@ -373,51 +409,107 @@ class CopyHandler:
             # Re-raise original error
             raise
 
-    async def handle_copy_to_stdout(self, command: CopyCommand) -> AsyncIterator[bytes]:
+    async def handle_copy_to_stdout(
+        self, command: CopyCommand, export: TableExport | QueryExport | None = None
+    ) -> AsyncIterator[bytes]:
         """
         Handle COPY TO STDOUT operation.
 
         Protocol Flow:
         1. Send CopyOutResponse to client
-        2. Execute SELECT query (or query all columns from table)
-        3. Generate CSV data
+        2. Execute SELECT query (or read the table in batches)
+        3. Generate TEXT, CSV or BINARY data
         4. Send CopyData messages to client
         5. Send CopyDone
 
+        The number of rows sent is left in rows_exported for CommandComplete.
+
         Args:
             command: Parsed COPY command
+            export: Resolved table export (BulkExecutor.table_export), whose rows
+                are streamed straight from IRIS (see copy_export.py), or the
+                executed query of COPY (query) TO STDOUT (BulkExecutor.query_export)
 
         Yields:
-            CSV data as CopyData message payloads
+            TEXT, CSV or BINARY data as CopyData message payloads
 
         Raises:
             QueryExecutionError: IRIS query failure
+            CopyExportError: FORCE_QUOTE names a column that is not copied
         """
         logger.info(f"COPY TO STDOUT: table={command.table_name}, query={command.query}")
-
-        # Determine query
-        if command.query:
-            # COPY (SELECT ...) TO STDOUT
-            query = command.query
-            # Extract column names from query (simplified - use IRIS metadata)
-            column_names = None  # Will be determined by bulk_executor
+        options = command.csv_options
+        self.rows_exported = 0
+
+        if isinstance(export, TableExport):
+            # COPY table_name TO STDOUT: batches straight from IRIS
+            batches = self.bulk_executor.stream_table(export)
+            column_names = export.column_names
+            oids = column_oids(export.columns)
+        elif isinstance(export, QueryExport):
+            # COPY (SELECT ...) TO STDOUT, already executed
+            batches = self._batched(self._iterate(export.rows))
+            column_names = export.column_names
+            oids = export.type_oids or [25] * len(column_names)
         else:
-            # COPY table_name TO STDOUT
-            query = f"SELECT {', '.join(command.column_list) if command.column_list else '*'} FROM {command.table_name}"
-            column_names = command.column_list
+            # Without a resolved export the statement runs as a query
+            if command.query:
+                query = command.query
+                column_names = None
+            else:
+                columns = ", ".join(command.column_list) if command.column_list else "*"
+                query = f"SELECT {columns} FROM {command.table_name}"
+                column_names = command.column_list
+            batches = self._batched(self.bulk_executor.stream_query_results(query))
+            oids = None  # Column types unknown: fields are sent as text
+
+        if options.format == "BINARY":
+            yield HEADER
+            async for batch in batches:
+                if oids is None:
+                    oids = [25] * (len(batch[0]) if batch else 0)
+                yield encode_binary_rows(batch, oids)
+                self.rows_exported += len(batch)
+            yield TRAILER
+            logger.info(f"COPY TO STDOUT complete: {self.rows_exported} rows exported")
+            return
+
+        if options.format == "TEXT":
+
+            def encode(rows):
+                return encode_text_rows(rows, options.delimiter, options.null_string)
 
-        # Execute query and stream results
-        result_rows = self.bulk_executor.stream_query_results(query)
-
-        # Generate CSV data
-        csv_stream = self.csv_processor.generate_csv_rows(
-            result_rows, column_names or [], command.csv_options  # TODO: Get from query metadata
-        )
+        else:
+            # CSV's ESCAPE defaults to QUOTE (CSVOptions keeps backslash as "unset")
+            escape = options.escape if options.escape != "\\" else options.quote
+            forced = force_quote_columns(options.force_quote, column_names or [])
 
-        # Stream CSV data as CopyData messages
-        row_count = 0
-        async for csv_chunk in csv_stream:
-            yield csv_chunk
-            row_count += csv_chunk.count(b"\n")  # Approximate row count
+            def encode(rows):
+                return encode_csv_rows(
+                    rows, options.delimiter, options.null_string, options.quote, escape, forced
+                )
 
-        logger.info(f"COPY TO STDOUT complete: ~{row_count} rows exported")
+        if options.header and column_names:
+            yield encode([tuple(column_names)])
+        async for batch in batches:
+            yield encode(batch)
+            self.rows_exported += len(batch)
+
+        logger.info(f"COPY TO STDOUT complete: {self.rows_exported} rows exported")
+
+    @staticmethod
+    async def _iterate(rows: list) -> AsyncIterator[tuple]:
+        for row in rows:
+            yield tuple(row)
+
+    @staticmethod
+    async def _batched(rows: AsyncIterator[tuple], size: int = 1000) -> AsyncIterator[list]:
+        """Group a row stream into lists of up to size rows."""
+        batch = []
+        async for row in rows:
+            batch.append(row)
+            if len(batch) >= size:
+                yield batch
+                batch = []
+        if batch:
+            yield batch
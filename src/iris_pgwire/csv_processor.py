@@ -2,6 +2,8 @@
 CSV Processing for COPY Protocol
 
 Implements CSV parsing and generation with batching for memory efficiency.
+COPY FROM STDIN data in PostgreSQL's text format (the default: tab separated,
+\\N for NULL, backslash escapes such as \\t and \\n) is parsed as well.
 
 Constitutional Requirements:
 - FR-006: <100MB memory for 1M rows (requires 1000-row batching)
@@ -9,8 +11,10 @@ Constitutional Requirements:
 """
 
 import csv
+import functools
 import io
 import logging
+import re
 from collections.abc import AsyncIterator
 from dataclasses import dataclass
 
@@ -19,6 +23,50 @@ from .sql_translator.copy_parser import CSVOptions
 
 logger = logging.getLogger(__name__)
 
+# Backslash escapes of the text format (others stand for the character itself)
+_TEXT_ESCAPES = {"b": "\b", "f": "\f", "n": "\n", "r": "\r", "t": "\t", "v": "\v"}
+
+
+@functools.cache
+def _text_tokens(delimiter: str) -> re.Pattern:
+    """Escapes, delimiters and runs of plain text of a text-format line."""
+    plain = re.escape(delimiter)
+    return re.compile(rf"\\(?:[0-7]{{1,3}}|x[0-9a-fA-F]{{1,2}}|.?)|{plain}|[^\\{plain}]+")
+
+
+def split_text_line(line: str, delimiter: str = "\t", null_string: str = "\\N") -> list:
+    """
+    Fields of one line of text-format COPY data, None for NULL.
+
+    A field is NULL when it is written exactly as null_string (before escapes
+    are decoded), as in PostgreSQL.
+    """
+    if "\\" not in line:
+        return [None if field == null_string else field for field in line.split(delimiter)]
+
+    fields: list = []
+    value: list[str] = []
+    start = 0
+    for match in _text_tokens(delimiter).finditer(line):
+        text = match.group()
+        if text == delimiter:
+            raw = line[start : match.start()]
+            fields.append(None if raw == null_string else "".join(value))
+            value, start = [], match.end()
+        elif text.startswith("\\") and len(text) > 1:
+            escape = text[1:]
+            if escape[0] in "01234567":
+                value.append(chr(int(escape, 8)))
+            elif escape[0] == "x" and len(escape) > 1:
+                value.append(chr(int(escape[1:], 16)))
+            else:
+                value.append(_TEXT_ESCAPES.get(escape, escape))
+        else:
+            value.append(text)
+    raw = line[start:]
+    fields.append(None if raw == null_string else "".join(value))
+    return fields
+
 
 class CSVParsingError(Exception):
     """CSV parsing error with line number."""
@@ -52,7 +100,10 @@ class CSVProcessor:
     BATCH_SIZE_BYTES = 10 * 1024 * 1024  # 10MB
 
     async def parse_csv_rows(
-        self, csv_stream: AsyncIterator[bytes], options: CSVOptions
+        self,
+        csv_stream: AsyncIterator[bytes],
+        options: CSVOptions,
+        column_names: list[str] | None = None,
     ) -> AsyncIterator[dict]:
         """
         Parse CSV bytes stream to row dicts with batching.
@@ -60,6 +111,8 @@ class CSVProcessor:
         Args:
             csv_stream: Async iterator of CSV bytes
             options: CSV format options (delimiter, quote, escape, header)
+            column_names: Target columns in data order (None = header row or
+                positional names); a header row is then skipped
 
         Yields:
             Row dicts with column names as keys
@@ -67,11 +120,16 @@ class CSVProcessor:
         Raises:
             CSVParsingError: Malformed CSV with line number
         """
+        if options.format == "TEXT":
+            async for row_dict in self.parse_text_rows(csv_stream, options, column_names):
+                yield row_dict
+            return
+
         logger.debug(f"Parsing CSV: header={options.header}, delimiter='{options.delimiter}'")
 
         # Accumulate bytes into buffer
         buffer = b""
-        column_names = None
+        header_pending = options.header
         line_number = 0
         rows_yielded = 0
         chunks_received = 0
@@ -109,7 +167,11 @@ class CSVProcessor:
                     row_values = next(csv_reader)
 
                     # Handle header row
-                    if options.header and column_names is None:
+                    if header_pending and column_names is not None:
+                        header_pending = False
+                        continue  # Target columns given: the header is only skipped
+                    if header_pending:
+                        header_pending = False
                         column_names = row_values
                         # Validate column names against IRIS restrictions
                         column_names = ColumnNameValidator.validate_column_list(column_names)
@@ -178,6 +240,66 @@ class CSVProcessor:
             f"CSV parsing complete: {chunks_received} chunks received, {rows_yielded} rows yielded"
         )
 
+    async def parse_text_rows(
+        self,
+        text_stream: AsyncIterator[bytes],
+        options: CSVOptions,
+        column_names: list[str] | None = None,
+    ) -> AsyncIterator[dict]:
+        """
+        Parse text-format COPY data (one row per line) to row dicts.
+
+        Newlines inside values arrive escaped, so every line is a row; a line
+        holding only \\. ends the data, as in psql scripts.
+
+        Args:
+            text_stream: Async iterator of COPY data bytes
+            options: Format options (delimiter, null_string, header)
+            column_names: Target columns in data order (None = positional names)
+
+        Yields:
+            Row dicts with column names as keys (None for NULL)
+
+        Raises:
+            CSVParsingError: Wrong column count or invalid UTF-8, with line number
+        """
+        header_pending = options.header
+        line_number = 0
+        rows_yielded = 0
+
+        async def lines():
+            buffer = b""
+            async for chunk in text_stream:
+                *complete, buffer = (buffer + chunk).split(b"\n")
+                for line in complete:
+                    yield line
+            if buffer:
+                yield buffer
+
+        async for line_bytes in lines():
+            line_number += 1
+            try:
+                line_text = line_bytes.decode("utf-8").rstrip("\r")
+            except UnicodeDecodeError as e:
+                raise CSVParsingError(f"Invalid UTF-8: {e}", line_number)
+            if line_text == "\\.":
+                break
+            if header_pending:
+                header_pending = False
+                continue
+
+            row_values = split_text_line(line_text, options.delimiter, options.null_string)
+            if column_names is None:
+                column_names = [f"column_{i}" for i in range(len(row_values))]
+            if len(row_values) != len(column_names):
+                raise CSVParsingError(
+                    f"Expected {len(column_names)} columns, got {len(row_values)}", line_number
+                )
+            yield dict(zip(column_names, row_values, strict=True))
+            rows_yielded += 1
+
+        logger.info(f"Text COPY parsing complete: {rows_yielded} rows yielded")
+
     async def generate_csv_rows(
         self, result_rows: AsyncIterator[tuple], column_names: list[str], options: CSVOptions
     ) -> AsyncIterator[bytes]:
@@ -18,6 +18,7 @@ import time
 import uuid
 from datetime import datetime
 
+from iris_pgwire.iris_tls import iris_tls_kwargs
 from iris_pgwire.models.backend_config import BackendConfig
 from iris_pgwire.models.connection_pool_state import ConnectionPoolState
 from iris_pgwire.models.dbapi_connection import (
@@ -279,6 +280,7 @@ class IRISConnectionPool:
                 namespace=self.config.iris_namespace,
                 username=self.config.iris_username,
                 password=self.config.iris_password,
+                **iris_tls_kwargs(),
             )
 
             # Wrap in DBAPIConnection model
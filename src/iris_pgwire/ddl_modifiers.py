@@ -0,0 +1,222 @@
+"""
+IF [NOT] EXISTS and UNLOGGED
+
+Migration tools make their DDL idempotent with IF [NOT] EXISTS, and IRIS
+versions differ in which statements accept it. The bridge checks existence
+itself (INFORMATION_SCHEMA) and either skips the statement with PostgreSQL's
+NOTICE or runs it without the modifier:
+
+    CREATE TABLE | INDEX | SCHEMA | SEQUENCE IF NOT EXISTS name ...
+        NOTICE: relation "name" already exists, skipping
+    DROP TABLE | VIEW | MATERIALIZED VIEW | INDEX | SCHEMA | SEQUENCE IF EXISTS a, b ...
+        NOTICE: table "b" does not exist, skipping      (a is still dropped)
+    ALTER TABLE | INDEX | VIEW | SEQUENCE IF EXISTS name ...
+        NOTICE: relation "name" does not exist, skipping
+    ALTER TABLE name ADD [COLUMN] IF NOT EXISTS column ... / DROP [COLUMN] IF EXISTS column
+        NOTICE: column "column" of relation "name" already exists, skipping
+
+IRIS has no sequences or materialized views, so DROP SEQUENCE / MATERIALIZED
+VIEW IF EXISTS are always skipped; CREATE SEQUENCE is left to IRIS.
+
+IRIS has no unlogged tables either (every table is journaled):
+CREATE UNLOGGED TABLE creates a regular table and ALTER TABLE ... SET
+[UN]LOGGED does nothing, both with a NOTICE.
+"""
+
+import re
+from dataclasses import dataclass, field
+
+from .check_constraints import _IDENTIFIER, _unquote, relation
+from .sql_text import split_top_level
+
+_NAME = rf"{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?"
+
+_CREATE = re.compile(
+    r"^\s*CREATE\s+(?P<options>(?:(?:GLOBAL|LOCAL|TEMP|TEMPORARY|UNLOGGED|UNIQUE)\s+)*)"
+    r"(?P<type>TABLE|INDEX(?:\s+CONCURRENTLY)?|SCHEMA|SEQUENCE)\s+"
+    rf"(?P<if>IF\s+NOT\s+EXISTS\s+)?(?P<name>{_NAME})(?P<rest>.*)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_DROP = re.compile(
+    r"^\s*DROP\s+(?P<type>TABLE|VIEW|MATERIALIZED\s+VIEW|INDEX(?:\s+CONCURRENTLY)?|SCHEMA|SEQUENCE)"
+    rf"\s+IF\s+EXISTS\s+(?P<names>{_NAME}(?:\s*,\s*{_NAME})*)"
+    r"(?P<rest>\s+(?:CASCADE|RESTRICT))?$",
+    re.IGNORECASE,
+)
+_ALTER = re.compile(
+    r"^\s*ALTER\s+(?P<type>TABLE|INDEX|VIEW|SEQUENCE)\s+(?P<if>IF\s+EXISTS\s+)?"
+    rf"(?P<only>ONLY\s+)?(?P<name>{_NAME})\s+(?P<action>.+)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_SET_LOGGED = re.compile(r"^SET\s+(?:UN)?LOGGED$", re.IGNORECASE)
+_COLUMN_ACTION = re.compile(
+    r"^(?P<verb>ADD|DROP)\s+(?P<column_kw>COLUMN\s+)?"
+    rf"(?P<if>IF\s+(?P<not>NOT\s+)?EXISTS\s+)(?P<column>{_IDENTIFIER})",
+    re.IGNORECASE,
+)
+
+_UNLOGGED = re.compile(r"\bUNLOGGED\s+", re.IGNORECASE)
+
+# Object types IRIS does not have: IF EXISTS never finds one
+NONEXISTENT_TYPES = frozenset({"sequence", "materialized view"})
+
+
+@dataclass
+class Condition:
+    object_type: str  # table, view, materialized view, index, schema, sequence or column
+    name: str  # As written
+    exists: bool  # True for IF EXISTS (skip when missing), False for IF NOT EXISTS
+    relation: str | None = None  # Table of a column, as written
+
+
+@dataclass
+class ModifiedDDL:
+    tag: str  # Command tag: CREATE TABLE, DROP INDEX, ALTER TABLE, ...
+    prefix: str  # Statement up to the object names, modifiers removed
+    names: list[str]  # Object names (several only for DROP)
+    suffix: str = ""  # Statement after the names, modifiers removed
+    conditions: list[Condition] = field(default_factory=list)
+    notices: list[tuple[str, str, str]] = field(default_factory=list)
+    runs: bool = True  # False when IRIS has nothing to do (SET [UN]LOGGED)
+
+    def statement(self, names: list[str] | None = None) -> str:
+        """The statement for IRIS, for names (all of them by default)."""
+        return f"{self.prefix}{', '.join(self.names if names is None else names)}{self.suffix}"
+
+
+def _unlogged_notice(table: str) -> tuple[str, str, str]:
+    return (
+        "NOTICE",
+        "00000",
+        f'IRIS has no unlogged tables; "{table}" is created as a regular (journaled) table',
+    )
+
+
+def parse_ddl_modifiers(sql: str) -> ModifiedDDL | None:
+    """DDL with IF [NOT] EXISTS or UNLOGGED (None for any other statement)."""
+    sql = sql.strip().rstrip(";").rstrip()
+    upper = sql.upper()
+    if "EXISTS" not in upper and "LOGGED" not in upper:
+        return None
+
+    if match := _CREATE.match(sql):
+        object_type = match.group("type").split()[0].lower()
+        unlogged = bool(_UNLOGGED.search(match.group("options")))
+        if not match.group("if") and not unlogged:
+            return None
+        options = _UNLOGGED.sub("", match.group("options"))
+        ddl = ModifiedDDL(
+            tag=f"CREATE {object_type.upper()}",
+            prefix=f"CREATE {options}{match.group('type')} ",
+            names=[match.group("name")],
+            suffix=match.group("rest"),
+        )
+        if match.group("if"):
+            ddl.conditions.append(Condition(object_type, match.group("name"), exists=False))
+        if unlogged:
+            ddl.notices.append(_unlogged_notice(relation(match.group("name"), "").table))
+        return ddl
+
+    if match := _DROP.match(sql):
+        object_type = re.sub(r"\s+", " ", match.group("type")).lower().replace(" concurrently", "")
+        names = [name.strip() for name in split_top_level(match.group("names"))]
+        return ModifiedDDL(
+            tag=f"DROP {object_type.upper()}",
+            prefix=f"DROP {match.group('type')} ",
+            names=names,
+            suffix=match.group("rest") or "",
+            conditions=[Condition(object_type, name, exists=True) for name in names],
+        )
+
+    match = _ALTER.match(sql)
+    if not match:
+        return None
+    object_type = match.group("type").lower()
+    ddl = ModifiedDDL(
+        tag=f"ALTER {object_type.upper()}",
+        prefix=f"ALTER {match.group('type')} {match.group('only') or ''}",
+        names=[match.group("name")],
+        suffix=f" {match.group('action')}",
+    )
+    if match.group("if"):
+        ddl.conditions.append(Condition(object_type, match.group("name"), exists=True))
+
+    action = match.group("action").strip()
+    column = _COLUMN_ACTION.match(action)
+    if object_type == "table" and _SET_LOGGED.match(action):
+        ddl.runs = False
+        ddl.notices.append(
+            ("NOTICE", "00000", f"IRIS has no unlogged tables; {action.upper()} has no effect")
+        )
+    elif object_type == "table" and column and len(split_top_level(action)) == 1:
+        ddl.conditions.append(
+            Condition(
+                "column",
+                column.group("column"),
+                exists=not column.group("not"),
+                relation=match.group("name"),
+            )
+        )
+        ddl.suffix = f" {action[: column.start('if')]}{action[column.end('if') :]}"
+    elif not match.group("if"):
+        return None
+    return ddl
+
+
+def existence_sql(condition: Condition, iris_schema: str) -> str | None:
+    """IRIS query returning a row when the object exists (None: it never does)."""
+    if condition.object_type in NONEXISTENT_TYPES:
+        return None
+
+    def literal(value: str) -> str:
+        return "'" + value.replace("'", "''") + "'"
+
+    if condition.object_type == "schema":
+        schema = _unquote(condition.name)
+        schema = iris_schema if schema == "public" else schema
+        return (
+            "SELECT SCHEMA_NAME FROM INFORMATION_SCHEMA.SCHEMATA "
+            f"WHERE LOWER(SCHEMA_NAME) = LOWER({literal(schema)})"
+        )
+
+    target = relation(condition.relation or condition.name, iris_schema)
+    in_schema = f"LOWER(TABLE_SCHEMA) = LOWER({literal(target.schema)})"
+    if condition.object_type == "index":
+        return (
+            f"SELECT INDEX_NAME FROM INFORMATION_SCHEMA.INDEXES WHERE {in_schema} "
+            f"AND LOWER(INDEX_NAME) = LOWER({literal(target.table)})"
+        )
+    if condition.object_type == "column":
+        return (
+            f"SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE {in_schema} "
+            f"AND LOWER(TABLE_NAME) = LOWER({literal(target.table)}) "
+            f"AND LOWER(COLUMN_NAME) = LOWER({literal(_unquote(condition.name))})"
+        )
+    sql = (
+        f"SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE {in_schema} "
+        f"AND LOWER(TABLE_NAME) = LOWER({literal(target.table)})"
+    )
+    return f"{sql} AND TABLE_TYPE = 'VIEW'" if condition.object_type == "view" else sql
+
+
+def skip_notice(condition: Condition, tag: str) -> tuple[str, str, str]:
+    """PostgreSQL's NOTICE for an object an IF [NOT] EXISTS statement skips."""
+    if condition.object_type == "column":
+        table = relation(condition.relation, "").table
+        column = _unquote(condition.name)
+        if condition.exists:
+            message = f'column "{column}" of relation "{table}" does not exist, skipping'
+            return "NOTICE", "00000", message
+        message = f'column "{column}" of relation "{table}" already exists, skipping'
+        return "NOTICE", "42701", message
+
+    name = relation(condition.name, "").table
+    if condition.object_type == "schema":
+        name = _unquote(condition.name)
+    if not condition.exists:
+        if condition.object_type == "schema":
+            return "NOTICE", "42P06", f'schema "{name}" already exists, skipping'
+        return "NOTICE", "42P07", f'relation "{name}" already exists, skipping'
+    # ALTER ... IF EXISTS names a relation; DROP names the object type
+    object_type = "relation" if tag.startswith("ALTER") else condition.object_type
+    return "NOTICE", "00000", f'{object_type} "{name}" does not exist, skipping'
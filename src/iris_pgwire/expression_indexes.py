@@ -0,0 +1,397 @@
+"""
+Expression Indexes on lower() and upper()
+
+IRIS indexes properties, not expressions, so CREATE INDEX ON users
+((lower(email))) fails in IRIS DDL. PostgreSQL applications create exactly
+these indexes for case-insensitive lookups (WHERE lower(email) = $1), so the
+bridge indexes a computed column holding the expression instead:
+
+    ALTER TABLE users ADD COLUMN PGWIRE_LOWER_EMAIL VARCHAR(255)
+        COMPUTECODE {SET {*} = $ZCONVERT({EMAIL},"L")} CALCULATED
+    CREATE INDEX users_lower_idx ON users (PGWIRE_LOWER_EMAIL)
+
+The column is calculated when read and stores nothing, so the rows already in
+the table need no update; IRIS computes the index entries when it builds the
+index and as rows are filed. The column has the length of its source, an
+existing computed column is reused, and an unnamed index gets PostgreSQL's
+name (users_lower_idx). Entries of the index other than lower(column) /
+upper(column) must be plain columns, otherwise the statement is left to IRIS.
+
+Queries on a single table (SELECT, UPDATE, DELETE without joins or
+subqueries) read the computed column where their WHERE clause has
+lower(column) / upper(column) of a column the bridge computes, so the index is
+used:
+
+    SELECT * FROM users WHERE lower(email) = ?
+        -> SELECT * FROM users WHERE PGWIRE_LOWER_EMAIL = ?
+
+DROP INDEX of an index on computed columns drops the columns with it, unless
+another index still uses them:
+
+    DROP INDEX users_lower_idx
+        -> DROP INDEX users_lower_idx ON SQLUser.users
+           ALTER TABLE SQLUser.users DROP COLUMN PGWIRE_LOWER_EMAIL
+"""
+
+import re
+from dataclasses import dataclass
+
+from .check_constraints import _IDENTIFIER, Relation, relation
+from .sql_translator.index_methods import _closing, _is, _split, parse_create_index
+
+COMPUTED_PREFIX = "PGWIRE_"
+
+# Computed columns the bridge created, for the query rewrite
+COMPUTED_COLUMNS_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS "
+    f"WHERE COLUMN_NAME %STARTSWITH '{COMPUTED_PREFIX}'"
+)
+
+# Indexes on computed columns, for DROP INDEX
+COMPUTED_INDEXES_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, COLUMN_NAME FROM INFORMATION_SCHEMA.INDEXES "
+    f"WHERE COLUMN_NAME %STARTSWITH '{COMPUTED_PREFIX}'"
+)
+
+_DROP_INDEX = re.compile(
+    rf"^\s*DROP\s+INDEX(?:\s+CONCURRENTLY)?\s+(?P<names>{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?"
+    rf"(?:\s*,\s*{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?)*)(?:\s+RESTRICT)?\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+_FUNCTIONS = {"LOWER": "L", "UPPER": "U"}  # $ZCONVERT mode of each function
+
+_SIMPLE_NAME = re.compile(r'^(?:[A-Za-z_]\w*|"[A-Za-z_]\w*")$')
+
+# IRIS character types a computed lower() / upper() column can copy
+_CHARACTER_TYPES = frozenset({"varchar", "char", "character", "character varying", "nvarchar"})
+
+_INDEXED_EXPRESSION = re.compile(
+    r"(?P<literal>'(?:[^']|'')*')"
+    r"|\b(?P<function>LOWER|UPPER)\s*\(\s*(?:(?P<qualifier>[A-Za-z_]\w*)\s*\.\s*)?"
+    r"(?P<column>[A-Za-z_]\w*)\s*\)",
+    re.IGNORECASE,
+)
+
+_QUERY_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<op>[(),.;])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+# Clauses that end a WHERE clause
+_AFTER_WHERE = frozenset(
+    {"GROUP", "ORDER", "HAVING", "LIMIT", "OFFSET", "FETCH", "RETURNING", "FOR", "UNION"}
+)
+# Statements over more than one table, or not plain statements on one
+_MULTI_TABLE = frozenset({"JOIN", "UNION", "INTERSECT", "EXCEPT", "WITH", "USING"})
+
+# Catalog relations, which never have computed columns
+_CATALOG_NAME = re.compile(r'^"?(?:PG_|INFORMATION_SCHEMA\b|%)', re.IGNORECASE)
+
+
+def _unquote(name: str) -> str:
+    return name[1:-1] if name.startswith('"') else name
+
+
+@dataclass(frozen=True)
+class ComputedColumn:
+    """lower(source) or upper(source) kept in a computed column."""
+
+    function: str  # LOWER or UPPER
+    source: str  # Source column as written
+
+    @property
+    def name(self) -> str:
+        return f"{COMPUTED_PREFIX}{self.function}_{_unquote(self.source).upper()}"
+
+
+@dataclass
+class ExpressionIndex:
+    """A CREATE INDEX statement with lower() / upper() entries."""
+
+    relation: str  # Table name as written
+    columns: list[ComputedColumn]
+    statement: str  # CREATE INDEX on the computed columns
+
+
+@dataclass
+class ComputedIndex:
+    """An index on computed columns, as INFORMATION_SCHEMA.INDEXES lists it."""
+
+    target: Relation
+    name: str  # IRIS index name
+    columns: list[str]  # Computed columns it indexes
+
+
+@dataclass
+class TableQuery:
+    """The table of a single-table query and the extent of its WHERE clause."""
+
+    relation: str  # Table name as written
+    alias: str | None
+    where: tuple[int, int]  # Offsets of the WHERE clause in the query
+
+
+def _entry_tail(sql: str, tokens) -> str:
+    """ASC / DESC / NULLS of an index entry, without operator classes and COLLATE."""
+    kept, skip_next = [], False
+    for token in tokens:
+        if skip_next:
+            skip_next = False
+        elif _is(token, "COLLATE"):
+            skip_next = True
+        elif not (token[0] == "word" and token[1].upper().endswith("_OPS")):
+            kept.append(sql[token[2] : token[3]])
+    return "".join(f" {text}" for text in kept)
+
+
+def _computed_entry(group) -> tuple[ComputedColumn, list] | None:
+    """The computed column of a lower(column) / (upper(column)) entry, and what follows it."""
+    body, tail = group, []
+    if body and body[0][1] == "(":
+        close = _closing(body, 0)
+        if close is None:
+            return None
+        body, tail = body[1:close], body[close + 1 :]
+    else:
+        body, tail = group[:4], group[4:]
+    if (
+        len(body) == 4
+        and _is(body[0], *_FUNCTIONS)
+        and body[1][1] == "("
+        and body[2][0] == "word"
+        and _SIMPLE_NAME.match(body[2][1])
+        and body[3][1] == ")"
+    ):
+        return ComputedColumn(body[0][1].upper(), body[2][1]), tail
+    return None
+
+
+def parse_expression_index(sql: str) -> ExpressionIndex | None:
+    """
+    CREATE INDEX with lower(column) / upper(column) entries, None for other
+    statements and for indexes on other expressions.
+    """
+    statement = parse_create_index(sql)
+    if statement is None:
+        return None
+    tokens = statement.tokens
+    open_index, close_index = statement.columns
+    entries, computed = [], []
+    for group in _split(tokens[open_index + 1 : close_index]):
+        if not group:
+            return None
+        entry = _computed_entry(group)
+        if entry is not None:
+            column, tail = entry
+            if column not in computed:
+                computed.append(column)
+            entries.append(column.name + _entry_tail(sql, tail))
+        elif group[0][0] == "word" and (len(group) == 1 or group[1][1] != "("):
+            entries.append(group[0][1] + _entry_tail(sql, group[1:]))
+        else:
+            return None  # Another expression
+    if not computed:
+        return None
+
+    start = statement.on + 1
+    if _is(tokens[start], "ONLY"):
+        start += 1
+    relation_sql = sql[tokens[start][2] : tokens[statement.table][3]]
+
+    on = tokens[statement.on][2]
+    name_sql = ""
+    if statement.name is None:
+        name_sql = statement.index_name(statement.column_sql()[0]) + " "
+    rewritten = (
+        f"{sql[:on]}{name_sql}{sql[on : tokens[open_index][2]]}({', '.join(entries)})"
+        f"{sql[tokens[close_index][3] :]}"
+    )
+    return ExpressionIndex(relation_sql, computed, rewritten)
+
+
+def source_column_sql(target: Relation, column: ComputedColumn) -> str:
+    """IRIS listing of the source column's name, type and length."""
+    schema = target.schema.replace("'", "''")
+    table = target.table.replace("'", "''")
+    source = _unquote(column.source)
+    return (
+        "SELECT COLUMN_NAME, DATA_TYPE, CHARACTER_MAXIMUM_LENGTH FROM INFORMATION_SCHEMA.COLUMNS "
+        f"WHERE LOWER(TABLE_SCHEMA) = LOWER('{schema}') AND LOWER(TABLE_NAME) = LOWER('{table}') "
+        f"AND LOWER(COLUMN_NAME) = LOWER('{source}')"
+    )
+
+
+def computed_column_type(row) -> str | None:
+    """VARCHAR type of a computed column from its source's listing row, None if not text."""
+    if not row or str(row[1]).lower() not in _CHARACTER_TYPES or row[2] is None:
+        return None
+    return f"VARCHAR({int(row[2])})"
+
+
+def add_column_sql(target: Relation, column: ComputedColumn, source: str, column_type: str) -> str:
+    """ALTER TABLE adding the computed column of the IRIS source column, calculated on read."""
+    mode = _FUNCTIONS[column.function]
+    return (
+        f"ALTER TABLE {target.sql} ADD COLUMN {column.name} {column_type} "
+        f'COMPUTECODE {{SET {{*}} = $ZCONVERT({{{source}}},"{mode}")}} CALCULATED'
+    )
+
+
+def drop_column_sql(target: Relation, name: str) -> str:
+    return f"ALTER TABLE {target.sql} DROP COLUMN {name}"
+
+
+def parse_drop_index(sql: str) -> list[str] | None:
+    """Index names of DROP INDEX as written (None for any other statement)."""
+    match = _DROP_INDEX.match(sql)
+    if not match:
+        return None
+    return [name.strip() for name in match.group("names").split(",")]
+
+
+def computed_indexes(rows, names: list[str], iris_schema: str) -> list[ComputedIndex]:
+    """Indexes on computed columns among names, from COMPUTED_INDEXES_SQL rows."""
+    indexes = []
+    for name in names:
+        index = relation(name, iris_schema)
+        matching = [
+            row
+            for row in rows
+            if str(row[0]).lower() == index.schema.lower()
+            and str(row[2]).lower() == index.table.lower()
+        ]
+        if matching:
+            schema, table, index_name = (str(value) for value in matching[0][:3])
+            target = Relation(schema, table, f"{schema}.{table}")
+            columns = list(dict.fromkeys(str(row[3]).upper() for row in matching))
+            indexes.append(ComputedIndex(target, index_name, columns))
+    return indexes
+
+
+def drop_index_sql(index: ComputedIndex) -> str:
+    return f"DROP INDEX {index.name} ON {index.target.sql}"
+
+
+def index_notice(index: ExpressionIndex) -> tuple[str, str, str]:
+    """NOTICE naming the computed columns an expression index is created on."""
+    names = ", ".join(column.name for column in index.columns)
+    return (
+        "NOTICE",
+        "00000",
+        f'expression index on "{_unquote(index.relation.split(".")[-1])}" is created on '
+        f"computed column {names}",
+    )
+
+
+def _query_tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _QUERY_TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def references_indexed_expression(sql: str) -> bool:
+    """Quick check for lower(column) / upper(column) before the query is parsed."""
+    upper = sql.upper()
+    if "LOWER" not in upper and "UPPER" not in upper:
+        return False
+    return any(match.group("function") for match in _INDEXED_EXPRESSION.finditer(sql))
+
+
+def query_table(sql: str) -> TableQuery | None:
+    """
+    The table of a single-table SELECT, UPDATE or DELETE with a WHERE clause,
+    None for other statements.
+    """
+    tokens = _query_tokens(sql)
+    if not tokens or not _is(tokens[0], "SELECT", "UPDATE", "DELETE"):
+        return None
+    words = [token[1].upper() for token in tokens if token[0] == "word"]
+    selects, froms = (1, 1) if words[0] == "SELECT" else (0, 1 if words[0] == "DELETE" else 0)
+    if (
+        words.count("SELECT") != selects
+        or words.count("FROM") != froms
+        or _MULTI_TABLE.intersection(words)
+    ):
+        return None
+
+    keyword = "UPDATE" if words[0] == "UPDATE" else "FROM"
+    position = next(index for index, token in enumerate(tokens) if _is(token, keyword)) + 1
+    if _is(tokens[position] if position < len(tokens) else None, "ONLY"):
+        position += 1
+    if position >= len(tokens) or tokens[position][0] != "word":
+        return None
+    start = position
+    while position + 2 < len(tokens) and tokens[position + 1][1] == ".":
+        position += 2
+    if _CATALOG_NAME.match(tokens[start][1]) or _CATALOG_NAME.match(tokens[position][1]):
+        return None
+    relation_sql = sql[tokens[start][2] : tokens[position][3]]
+    position += 1
+
+    alias = None
+    if _is(tokens[position] if position < len(tokens) else None, "AS"):
+        position += 1
+    if (
+        position < len(tokens)
+        and tokens[position][0] == "word"
+        and not _is(tokens[position], "WHERE", "SET", *_AFTER_WHERE)
+    ):
+        alias = tokens[position][1]
+        position += 1
+    if position < len(tokens) and tokens[position][1] == ",":
+        return None  # FROM a, b
+
+    where = next(
+        (index for index in range(position, len(tokens)) if _is(tokens[index], "WHERE")), None
+    )
+    if where is None:
+        return None
+    end, depth = len(sql), 0
+    for token in tokens[where:]:
+        if token[1] == "(":
+            depth += 1
+        elif token[1] == ")":
+            depth -= 1
+        elif depth == 0 and _is(token, *_AFTER_WHERE):
+            end = token[2]
+            break
+    return TableQuery(relation_sql, alias, (tokens[where][2], end))
+
+
+def rewrite_indexed_expressions(
+    sql: str, query: TableQuery, computed: set[str]
+) -> tuple[str, int]:
+    """
+    sql with lower(column) / upper(column) in its WHERE clause read from the
+    table's computed columns (computed: their names, uppercase), and the
+    number of expressions replaced.
+    """
+    table = _unquote(query.relation.split(".")[-1].strip())
+    qualifiers = {table.upper(), _unquote(query.alias).upper() if query.alias else None}
+    count = 0
+
+    def replace(match: re.Match) -> str:
+        nonlocal count
+        if match.group("literal"):
+            return match.group(0)
+        qualifier = match.group("qualifier")
+        if qualifier is not None and qualifier.upper() not in qualifiers:
+            return match.group(0)
+        function, source = match.group("function").upper(), match.group("column").upper()
+        column = f"{COMPUTED_PREFIX}{function}_{source}"
+        if column not in computed:
+            return match.group(0)
+        count += 1
+        return f"{qualifier}.{column}" if qualifier else column
+
+    start, end = query.where
+    clause = _INDEXED_EXPRESSION.sub(replace, sql[start:end])
+    return f"{sql[:start]}{clause}{sql[end:]}", count
@@ -0,0 +1,295 @@
+"""
+IRIS Globals as Read-Only Virtual Tables
+
+Selected globals can be browsed from SQL tools through the `globals` schema.
+Each configured global becomes a table whose columns are its subscript levels
+plus the node value:
+
+    PGWIRE_GLOBAL_TABLES="orders=^Orders(order_id,line);config=^AppConfig(key)"
+
+    SELECT * FROM globals.orders WHERE order_id = 42 LIMIT 10
+    → one row per defined node at full subscript depth: (order_id, line, value)
+
+Supported queries: SELECT * or a column list, WHERE with equality predicates
+joined by AND, and LIMIT. Equality predicates on leading subscripts descend
+directly into the global instead of scanning it. Rows come back in global
+collation order. Any write to a virtual table is rejected.
+
+Globals are read with iris.gref() in embedded mode and the Native API
+(iris.createIRIS) over external connections.
+"""
+
+import os
+import re
+from dataclasses import dataclass, field
+from typing import Any
+
+import structlog
+
+logger = structlog.get_logger()
+
+SCHEMA_NAME = "globals"
+
+_DEFINITION = re.compile(r"^\s*(\w+)\s*=\s*(\^?[%\w.]+)\s*(?:\(([^)]*)\))?\s*$")
+_SELECT = re.compile(
+    r"^\s*SELECT\s+(?P<columns>.+?)\s+FROM\s+" + SCHEMA_NAME + r"\.(?P<table>\w+)(?:\s+\w+)?"
+    r"(?:\s+WHERE\s+(?P<where>.+?))?(?:\s+LIMIT\s+(?P<limit>\d+))?\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_WRITE = re.compile(
+    r"^\s*(?:INSERT\s+INTO|UPDATE|DELETE\s+FROM|TRUNCATE(?:\s+TABLE)?)\s+" + SCHEMA_NAME + r"\.(\w+)",
+    re.IGNORECASE,
+)
+_EQUALITY = re.compile(r"^\s*(?:\w+\.)?(\w+)\s*=\s*('(?:[^']|'')*'|-?\d+(?:\.\d+)?)\s*$", re.DOTALL)
+
+
+class ReadOnlyGlobalTable(Exception):
+    """Write attempted on a global virtual table (SQLSTATE 25006)."""
+
+    sqlstate = "25006"
+
+
+@dataclass
+class GlobalTable:
+    """Mapping of one global onto a virtual table."""
+
+    name: str
+    global_name: str  # With leading caret, e.g. '^Orders'
+    subscript_columns: list[str] = field(default_factory=list)
+    value_column: str = "value"
+
+    @property
+    def columns(self) -> list[str]:
+        return [*self.subscript_columns, self.value_column]
+
+
+def load_global_tables(spec: str | None = None) -> dict[str, GlobalTable]:
+    """Parse PGWIRE_GLOBAL_TABLES ('name=^Global(col,...);...') into table definitions."""
+    if spec is None:
+        spec = os.getenv("PGWIRE_GLOBAL_TABLES", "")
+    tables = {}
+    for entry in spec.split(";"):
+        if not entry.strip():
+            continue
+        match = _DEFINITION.match(entry)
+        if not match:
+            logger.warning("Ignoring invalid global table definition", definition=entry)
+            continue
+        name, global_name, columns = match.groups()
+        if not global_name.startswith("^"):
+            global_name = "^" + global_name
+        subscripts = [c.strip().lower() for c in (columns or "").split(",") if c.strip()]
+        tables[name.lower()] = GlobalTable(name.lower(), global_name, subscripts)
+    return tables
+
+
+# ---------------------------------------------------------------------- global access
+
+
+class EmbeddedGlobalAccessor:
+    """Global access through embedded Python (iris.gref)."""
+
+    def __init__(self, iris_module):
+        self._iris = iris_module
+        self._refs: dict[str, Any] = {}
+
+    def _ref(self, global_name: str):
+        if global_name not in self._refs:
+            self._refs[global_name] = self._iris.gref(global_name)
+        return self._refs[global_name]
+
+    def next_subscript(self, global_name: str, subscripts: list, previous: Any) -> Any:
+        result = self._ref(global_name).order([*subscripts, previous])
+        return None if result in (None, "") else result
+
+    def get(self, global_name: str, subscripts: list) -> Any:
+        return self._ref(global_name).get(subscripts)
+
+    def data(self, global_name: str, subscripts: list) -> int:
+        return self._ref(global_name).data(subscripts)
+
+    def set(self, global_name: str, subscripts: list, value: Any) -> None:
+        self._ref(global_name).set(subscripts, value)
+
+    def kill(self, global_name: str, subscripts: list) -> None:
+        self._ref(global_name).kill(subscripts)
+
+    def tstart(self) -> None:
+        self._iris.tstart()
+
+    def tcommit(self) -> None:
+        self._iris.tcommit()
+
+    def trollback(self) -> None:
+        self._iris.trollback()
+
+
+class NativeGlobalAccessor:
+    """Global access through the IRIS Native API over a DBAPI connection."""
+
+    def __init__(self, iris_native):
+        self._native = iris_native
+
+    def next_subscript(self, global_name: str, subscripts: list, previous: Any) -> Any:
+        result = self._native.nextSubscript(False, global_name.lstrip("^"), *subscripts, previous)
+        return None if result in (None, "") else result
+
+    def get(self, global_name: str, subscripts: list) -> Any:
+        return self._native.get(global_name.lstrip("^"), *subscripts)
+
+    def data(self, global_name: str, subscripts: list) -> int:
+        return self._native.isDefined(global_name.lstrip("^"), *subscripts)
+
+    def set(self, global_name: str, subscripts: list, value: Any) -> None:
+        self._native.set(value, global_name.lstrip("^"), *subscripts)
+
+    def kill(self, global_name: str, subscripts: list) -> None:
+        self._native.kill(global_name.lstrip("^"), *subscripts)
+
+    def tstart(self) -> None:
+        self._native.tStart()
+
+    def tcommit(self) -> None:
+        self._native.tCommit()
+
+    def trollback(self) -> None:
+        self._native.tRollback()
+
+
+# ---------------------------------------------------------------------- query handling
+
+
+@dataclass
+class GlobalTableQuery:
+    table: GlobalTable
+    columns: list[str]
+    filters: dict[str, str]
+    limit: int | None
+
+
+def _literal_value(literal: str) -> str:
+    if literal.startswith("'"):
+        return literal[1:-1].replace("''", "'")
+    return literal
+
+
+class GlobalTableHandler:
+    """Answers queries against configured global virtual tables."""
+
+    def __init__(self, tables: dict[str, GlobalTable] | None = None):
+        self.tables = load_global_tables() if tables is None else tables
+
+    def references_virtual_table(self, sql: str) -> bool:
+        return bool(self.tables) and f"{SCHEMA_NAME}." in sql.lower()
+
+    def check_read_only(self, sql: str) -> None:
+        """Raise ReadOnlyGlobalTable for writes to a virtual table."""
+        match = _WRITE.match(sql)
+        if match and match.group(1).lower() in self.tables:
+            raise ReadOnlyGlobalTable(
+                f'cannot modify "{SCHEMA_NAME}.{match.group(1).lower()}": global tables are read-only'
+            )
+
+    def parse(self, sql: str) -> GlobalTableQuery | None:
+        """Parse a SELECT against a virtual table (None if unsupported/not ours)."""
+        match = _SELECT.match(sql)
+        if not match:
+            return None
+        table = self.tables.get(match.group("table").lower())
+        if table is None:
+            return None
+
+        column_list = match.group("columns").strip()
+        if column_list == "*":
+            columns = table.columns
+        else:
+            columns = [c.strip().split(".")[-1].lower() for c in column_list.split(",")]
+            unknown = [c for c in columns if c not in table.columns]
+            if unknown:
+                raise ValueError(f'column "{unknown[0]}" does not exist')
+
+        filters = {}
+        if match.group("where"):
+            for predicate in re.split(r"\s+AND\s+", match.group("where"), flags=re.IGNORECASE):
+                equality = _EQUALITY.match(predicate)
+                if not equality or equality.group(1).lower() not in table.columns:
+                    raise ValueError(
+                        "global tables support only equality predicates on their columns"
+                    )
+                filters[equality.group(1).lower()] = _literal_value(equality.group(2))
+
+        limit = int(match.group("limit")) if match.group("limit") else None
+        return GlobalTableQuery(table, columns, filters, limit)
+
+    def fetch_rows(self, query: GlobalTableQuery, accessor) -> list[tuple]:
+        """Walk the global and return rows for the parsed query."""
+        table = query.table
+        depth = len(table.subscript_columns)
+
+        # Leading equality filters pin subscripts, so the walk starts below them
+        prefix = []
+        for column in table.subscript_columns:
+            if column not in query.filters:
+                break
+            prefix.append(query.filters[column])
+
+        rows: list[tuple] = []
+
+        def matches(subscripts: list, value: Any) -> bool:
+            values = dict(zip(table.subscript_columns, subscripts, strict=True))
+            values[table.value_column] = value
+            return all(str(values[c]) == v for c, v in query.filters.items())
+
+        def walk(subscripts: list) -> bool:
+            """Depth-first walk; returns False once the limit is reached."""
+            if len(subscripts) == depth:
+                if accessor.data(table.global_name, subscripts) % 2 == 1:
+                    value = accessor.get(table.global_name, subscripts)
+                    if matches(subscripts, value):
+                        row = dict(zip(table.subscript_columns, subscripts, strict=True))
+                        row[table.value_column] = value
+                        rows.append(tuple(row[c] for c in query.columns))
+                return query.limit is None or len(rows) < query.limit
+
+            subscript = accessor.next_subscript(table.global_name, subscripts, "")
+            while subscript is not None:
+                if not walk([*subscripts, subscript]):
+                    return False
+                subscript = accessor.next_subscript(table.global_name, subscripts, subscript)
+            return True
+
+        if query.limit != 0:
+            walk(prefix)
+        return rows
+
+    def execute(self, sql: str, accessor) -> dict[str, Any] | None:
+        """Execute a virtual table query, returning an executor result dict."""
+        self.check_read_only(sql)
+        query = self.parse(sql)
+        if query is None:
+            return None
+
+        rows = self.fetch_rows(query, accessor)
+        logger.info(
+            "Global table query",
+            table=query.table.name,
+            global_name=query.table.global_name,
+            rows=len(rows),
+        )
+        return {
+            "success": True,
+            "rows": [[None if v is None else str(v) for v in row] for row in rows],
+            "columns": [
+                {
+                    "name": column,
+                    "type_oid": 25,
+                    "type_size": -1,
+                    "type_modifier": -1,
+                    "format_code": 0,
+                }
+                for column in query.columns
+            ],
+            "row_count": len(rows),
+            "command": "SELECT",
+            "command_tag": f"SELECT {len(rows)}",
+        }
@@ -0,0 +1,452 @@
+"""
+Host-Based Access Control (pg_hba.conf)
+
+PGWIRE_HBA_FILE restricts which networks, databases and users may connect,
+and how they authenticate, in PostgreSQL's pg_hba.conf format:
+
+    # TYPE     DATABASE   USER          ADDRESS          METHOD          [OPTIONS]
+    hostssl    all        all           10.0.0.0/8       scram-sha-256
+    host       USER       pooler        10.0.0.5/32      md5
+    hostssl    all        /^svc_        0.0.0.0/0        cert            map=certmap
+    host       all        all           10.1.0.0/16      gss             include_realm=0
+    host       all        admin         192.168.1.0 255.255.255.0  password
+    host       all        all           0.0.0.0/0        reject
+
+After the StartupMessage the lines are checked in order; the first whose
+connection type, database, user and client address match decides. A
+connection no line matches, or matching a reject line, is refused with FATAL
+28000 and PostgreSQL's message ('no pg_hba.conf entry for host ...').
+
+Fields:
+- TYPE: host (TLS or not), hostssl (TLS only), hostnossl (no TLS). local
+  lines are accepted but never match: the bridge has no Unix socket.
+- DATABASE: all, sameuser, replication (physical replication connections,
+  which match nothing else), or names; comma-separated lists
+- USER: all, or names; a name starting with / is a regular expression
+- ADDRESS: all, samehost (the client is on the bridge's host), an address
+  with a CIDR length, or an address followed by a mask field
+- METHOD: trust, reject, password (cleartext, checked against IRIS), md5
+  (SCRAM for users with a SCRAM verifier, as PostgreSQL), scram-sha-256,
+  cert (a verified TLS client certificate, see cert_auth.py), gss
+  (Kerberos, see auth/gssapi_auth.py), or ldap (the password checked by an
+  LDAP server, see ldap_auth.py)
+- OPTIONS: clientcert=verify-ca or verify-full (also require a client
+  certificate, the latter with a name that allows the user), map=name (user
+  name map in PGWIRE_IDENT_FILE for certificate names or Kerberos
+  principals), clientname=CN, DN or SAN; for gss, include_realm=0 or 1 and
+  krb_realm=REALM; for ldap, ldapserver and the options of ldap_auth.py
+  (quote values containing commas or spaces: ldapbasedn="dc=example,dc=com")
+
+Quoted names are literal (a quoted "all" is a database or user named all).
+Group (+role) and @file references and host names are not supported and
+make the file invalid.
+
+The file replaces PGWIRE_AUTH_METHODS, the methods of PGWIRE_LISTENERS and
+PGWIRE_CERT_AUTH for the connections it decides. In
+PGWIRE_BACKEND_AUTH_MODE=passthrough, every password method asks for the
+cleartext password, which IRIS checks.
+
+The file is read when the server starts, where an invalid file stops it,
+and again when it changes; an invalid edit is logged and the previous rules
+stay in force, as with pg_ctl reload.
+"""
+
+import ipaddress
+import os
+import re
+from dataclasses import dataclass, field
+
+import structlog
+
+from .ldap_auth import LDAP_OPTIONS, LdapConfig
+
+logger = structlog.get_logger()
+
+CONNECTION_TYPES = ("local", "host", "hostssl", "hostnossl")
+HBA_METHODS = ("trust", "reject", "password", "md5", "scram-sha-256", "cert", "gss", "ldap")
+HBA_OPTIONS = ("clientcert", "map", "clientname", "include_realm", "krb_realm", *LDAP_OPTIONS)
+CLIENTCERT_MODES = ("verify-ca", "verify-full")
+
+
+class HbaConfigError(ValueError):
+    """A pg_hba.conf line that cannot be loaded."""
+
+
+class HostAccessDenied(Exception):
+    """No line allows the connection, or a reject line matched (SQLSTATE 28000)."""
+
+    sqlstate = "28000"
+    condition_name = "invalid_authorization_specification"
+
+
+@dataclass(frozen=True)
+class _Name:
+    """A database or user name of a line: a keyword, a literal or a regular expression."""
+
+    text: str
+    keyword: bool = False  # Unquoted all, sameuser, replication
+    regex: bool = False
+
+    def matches(self, name: str) -> bool:
+        if self.regex:
+            try:
+                return re.search(self.text, name) is not None
+            except re.error:
+                return False
+        return self.text == name
+
+
+@dataclass(frozen=True)
+class HbaRule:
+    """One pg_hba.conf line."""
+
+    line: int
+    connection_type: str
+    databases: tuple[_Name, ...]
+    users: tuple[_Name, ...]
+    network: ipaddress.IPv4Network | ipaddress.IPv6Network | None  # None = all
+    samehost: bool
+    method: str
+    options: dict[str, str] = field(default_factory=dict, hash=False, compare=False)
+
+    def matches(
+        self,
+        address: str,
+        local_address: str | None,
+        ssl: bool,
+        database: str,
+        user: str,
+        replication: bool,
+    ) -> bool:
+        if self.connection_type == "local":
+            return False
+        if self.connection_type == "hostssl" and not ssl:
+            return False
+        if self.connection_type == "hostnossl" and ssl:
+            return False
+        if not self._database_matches(database, user, replication):
+            return False
+        if not any(name.keyword or name.matches(user) for name in self.users):
+            return False
+        return self._address_matches(address, local_address)
+
+    def _database_matches(self, database: str, user: str, replication: bool) -> bool:
+        for name in self.databases:
+            if name.keyword:
+                if name.text == "replication":
+                    if replication:
+                        return True
+                elif replication:
+                    continue  # all and sameuser do not match physical replication
+                elif name.text == "all" or (name.text == "sameuser" and database == user):
+                    return True
+            elif not replication and name.matches(database):
+                return True
+        return False
+
+    def _address_matches(self, address: str, local_address: str | None) -> bool:
+        try:
+            client = _ip_address(address)
+        except ValueError:
+            return False
+        if self.samehost:
+            return client.is_loopback or (
+                local_address is not None and _same_address(client, local_address)
+            )
+        if self.network is None:
+            return True
+        return client.version == self.network.version and client in self.network
+
+
+def _ip_address(address: str) -> ipaddress.IPv4Address | ipaddress.IPv6Address:
+    """A client address, with IPv4-mapped IPv6 addresses as IPv4 (as PostgreSQL)."""
+    ip = ipaddress.ip_address(address.split("%", 1)[0])
+    if isinstance(ip, ipaddress.IPv6Address) and ip.ipv4_mapped is not None:
+        return ip.ipv4_mapped
+    return ip
+
+
+def _same_address(client, local_address: str) -> bool:
+    try:
+        return client == _ip_address(local_address)
+    except ValueError:
+        return False
+
+
+# A list item: "quoted" ("" for a quote) or bare
+# An item is bare text and quoted parts, e.g. ldapbasedn="dc=example,dc=com"
+_ITEM = re.compile(r'(?:[^\s",#]+|"(?:[^"]|"")*")+')
+_QUOTED = re.compile(r'"((?:[^"]|"")*)"')
+
+
+def split_line(line: str, number: int) -> list[list[tuple[str, bool]]]:
+    """
+    Fields of a line, each a comma-separated list of (text, quoted) items.
+
+    Raises:
+        HbaConfigError: an unbalanced quote or an empty list item
+    """
+    fields, pos = [], 0
+    while True:
+        while pos < len(line) and line[pos].isspace():
+            pos += 1
+        if pos >= len(line) or line[pos] == "#":
+            return fields
+        items = []
+        while True:
+            match = _ITEM.match(line, pos)
+            if match is None:
+                if line.startswith('"', pos):
+                    raise HbaConfigError(f"line {number}: unterminated quoted string")
+                raise HbaConfigError(f"line {number}: empty list item")
+            quoted = _QUOTED.fullmatch(match.group(0))
+            if quoted is not None:
+                items.append((quoted.group(1).replace('""', '"'), True))
+            else:
+                text = _QUOTED.sub(lambda part: part.group(1).replace('""', '"'), match.group(0))
+                items.append((text, False))
+            pos = match.end()
+            if pos >= len(line) or line[pos] != ",":
+                break
+            pos += 1
+        fields.append(items)
+
+
+def _single(field: list[tuple[str, bool]], number: int) -> str:
+    if len(field) != 1:
+        raise HbaConfigError(f"line {number}: {field[0][0]!r} does not take a list")
+    return field[0][0]
+
+
+def _names(field: list[tuple[str, bool]], line: int, what: str) -> list[_Name]:
+    names = []
+    for item, quoted in field:
+        if quoted:
+            names.append(_Name(item))
+        elif item.startswith("@") or item.startswith("+"):
+            raise HbaConfigError(f"line {line}: {item[0]}{what} references are not supported")
+        elif item.startswith("/"):
+            try:
+                re.compile(item[1:])
+            except re.error as e:
+                raise HbaConfigError(
+                    f"line {line}: invalid regular expression {item}: {e}"
+                ) from None
+            names.append(_Name(item[1:], regex=True))
+        elif item == "all" or (what == "database" and item in ("sameuser", "replication")):
+            names.append(_Name(item, keyword=True))
+        elif what == "database" and item in ("samerole", "samegroup"):
+            raise HbaConfigError(f"line {line}: {item} is not supported")
+        else:
+            names.append(_Name(item))
+    return names
+
+
+def _network(address: str, mask: str | None, line: int):
+    if mask is None and "/" not in address:
+        raise HbaConfigError(
+            f"line {line}: {address} needs a CIDR length or a mask (host names are not supported)"
+        )
+    try:
+        return ipaddress.ip_network(address if mask is None else f"{address}/{mask}", strict=False)
+    except ValueError as e:
+        raise HbaConfigError(f"line {line}: invalid address {address!r}: {e}") from None
+
+
+def parse_line(text: str, number: int) -> HbaRule | None:
+    """
+    One line (None for blank and comment lines).
+
+    Raises:
+        HbaConfigError: a malformed or unsupported line
+    """
+    fields = split_line(text, number)
+    if not fields:
+        return None
+    connection_type = _single(fields[0], number)
+    if connection_type not in CONNECTION_TYPES:
+        raise HbaConfigError(f"line {number}: invalid connection type {connection_type!r}")
+    address_fields = 0 if connection_type == "local" else 1
+    if len(fields) < 4 + address_fields:
+        raise HbaConfigError(f"line {number}: end-of-line before authentication method")
+
+    databases = _names(fields[1], number, "database")
+    users = _names(fields[2], number, "user")
+    network, samehost = None, False
+    rest = [_single(field, number) for field in fields[3:]]
+    if address_fields:
+        address = rest[0]
+        rest = rest[1:]
+        if address == "samehost":
+            samehost = True
+        elif address == "samenet":
+            raise HbaConfigError(f"line {number}: samenet is not supported")
+        elif address != "all":
+            mask = None
+            if "/" not in address and rest and rest[0] not in HBA_METHODS:
+                mask = rest[0]
+                rest = rest[1:]
+            network = _network(address, mask, number)
+    if not rest:
+        raise HbaConfigError(f"line {number}: end-of-line before authentication method")
+
+    method = rest[0]
+    if method not in HBA_METHODS:
+        raise HbaConfigError(f"line {number}: invalid authentication method {method!r}")
+    options = {}
+    for option in rest[1:]:
+        name, equals, value = option.partition("=")
+        if not equals or name not in HBA_OPTIONS:
+            raise HbaConfigError(f"line {number}: invalid authentication option {option!r}")
+        options[name] = value
+    if options.get("clientcert", "verify-ca") not in CLIENTCERT_MODES:
+        raise HbaConfigError(f"line {number}: clientcert must be verify-ca or verify-full")
+    if options.get("clientname", "CN").upper() not in ("CN", "DN", "SAN"):
+        raise HbaConfigError(f"line {number}: clientname must be CN, DN or SAN")
+    if options.get("include_realm", "1") not in ("0", "1"):
+        raise HbaConfigError(f"line {number}: include_realm must be 0 or 1")
+    if ("include_realm" in options or "krb_realm" in options) and method != "gss":
+        raise HbaConfigError(
+            f"line {number}: include_realm and krb_realm are only valid for gss authentication"
+        )
+    ldap_options = {name: value for name, value in options.items() if name in LDAP_OPTIONS}
+    if method == "ldap":
+        try:
+            LdapConfig.from_options(ldap_options)
+        except ValueError as e:
+            raise HbaConfigError(f"line {number}: {e}") from None
+    elif ldap_options:
+        raise HbaConfigError(
+            f"line {number}: {next(iter(ldap_options))} is only valid for ldap authentication"
+        )
+    if "clientcert" in options and connection_type != "hostssl":
+        raise HbaConfigError(f"line {number}: clientcert can only be used on hostssl lines")
+    if method == "cert" and connection_type != "hostssl":
+        raise HbaConfigError(f"line {number}: cert authentication is only supported on hostssl")
+    return HbaRule(
+        number,
+        connection_type,
+        tuple(databases),
+        tuple(users),
+        network,
+        samehost,
+        method,
+        options,
+    )
+
+
+class HbaRules:
+    """The lines of a pg_hba.conf file, in order."""
+
+    def __init__(self, rules: list[HbaRule]):
+        self.rules = rules
+
+    @classmethod
+    def parse(cls, text: str) -> "HbaRules":
+        """
+        Raises:
+            HbaConfigError: the first invalid line
+        """
+        rules = []
+        for number, line in enumerate(text.splitlines(), start=1):
+            rule = parse_line(line, number)
+            if rule is not None:
+                rules.append(rule)
+        return cls(rules)
+
+    def match(
+        self,
+        address: str,
+        ssl: bool,
+        database: str,
+        user: str,
+        replication: bool = False,
+        local_address: str | None = None,
+    ) -> HbaRule:
+        """
+        The line that decides a connection.
+
+        Raises:
+            HostAccessDenied: no line matches, or the matching line is reject
+        """
+        encryption = "SSL encryption" if ssl else "no encryption"
+        for rule in self.rules:
+            if rule.matches(address, local_address, ssl, database, user, replication):
+                if rule.method == "reject":
+                    logger.warning(
+                        "Connection rejected by pg_hba line", line=rule.line, client=address
+                    )
+                    if replication:
+                        raise HostAccessDenied(
+                            f'pg_hba.conf rejects replication connection for host "{address}",'
+                            f' user "{user}", {encryption}'
+                        )
+                    raise HostAccessDenied(
+                        f'pg_hba.conf rejects connection for host "{address}", user "{user}",'
+                        f' database "{database}", {encryption}'
+                    )
+                return rule
+        if replication:
+            raise HostAccessDenied(
+                f'no pg_hba.conf entry for replication connection from host "{address}",'
+                f' user "{user}", {encryption}'
+            )
+        raise HostAccessDenied(
+            f'no pg_hba.conf entry for host "{address}", user "{user}",'
+            f' database "{database}", {encryption}'
+        )
+
+
+_cache: tuple[str, float, HbaRules] | None = None
+
+
+def _read(path: str) -> tuple[float, HbaRules]:
+    mtime = os.path.getmtime(path)
+    with open(path, encoding="utf-8") as f:
+        rules = HbaRules.parse(f.read())
+    logger.info("Host-based access rules loaded", path=path, rules=len(rules.rules))
+    return mtime, rules
+
+
+def load_hba() -> HbaRules | None:
+    """
+    Rules of PGWIRE_HBA_FILE (None when unset), checked at server start.
+
+    Raises:
+        ValueError: the file cannot be read or is invalid
+    """
+    global _cache
+    path = os.getenv("PGWIRE_HBA_FILE")
+    if not path:
+        return None
+    try:
+        mtime, rules = _read(path)
+    except OSError as e:
+        raise ValueError(f"cannot read PGWIRE_HBA_FILE {path}: {e}") from None
+    except HbaConfigError as e:
+        raise ValueError(f"invalid PGWIRE_HBA_FILE {path}: {e}") from None
+    _cache = (path, mtime, rules)
+    return rules
+
+
+def get_hba() -> HbaRules | None:
+    """Rules of PGWIRE_HBA_FILE, reloaded on change (None when unset)."""
+    global _cache
+    path = os.getenv("PGWIRE_HBA_FILE")
+    if not path:
+        return None
+    try:
+        if _cache is None or _cache[:2] != (path, os.path.getmtime(path)):
+            mtime, rules = _read(path)
+            _cache = (path, mtime, rules)
+    except (OSError, HbaConfigError) as e:
+        if _cache is None or _cache[0] != path:
+            # Never loaded: refuse everything rather than allow everything
+            logger.error("Cannot load PGWIRE_HBA_FILE", path=path, error=str(e))
+            return HbaRules([])
+        logger.warning(
+            "Invalid PGWIRE_HBA_FILE change ignored, previous rules in force",
+            path=path,
+            error=str(e),
+        )
+    return _cache[2]
@@ -0,0 +1,133 @@
+"""
+User Name Maps (pg_ident.conf)
+
+Authentication methods that establish an external identity (a client
+certificate's name, a Kerberos principal) check it against the requested
+user name through a user name map, in PostgreSQL's pg_ident.conf format:
+
+    # MAPNAME   SYSTEM-USERNAME         DATABASE-USERNAME
+    certmap     billing-service         BILLING
+    certmap     /^(.*)@example\\.com$    \\1
+    certmap     admin.example.com       all
+
+A system user name starting with a slash is a regular expression (not
+anchored unless written with ^ and $); \\1 in the database user name stands
+for its first capture group. A database user name of "all" accepts any
+user. Names are compared case-sensitively, and double quotes keep spaces or
+a leading slash literal. A # outside quotes starts a comment.
+
+The file is PGWIRE_IDENT_FILE; it is read again when it changes, so maps can
+be edited without restarting the bridge.
+"""
+
+import os
+import re
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+# A field: "quoted" ("" for a quote) or bare; # starts a comment outside quotes
+_FIELD = re.compile(r'"((?:[^"]|"")*)"|([^\s"#]+)|(#.*)')
+
+
+def split_fields(line: str) -> list[tuple[str, bool]] | None:
+    """Fields of a configuration line as (text, quoted); None when a quote is unbalanced."""
+    fields, pos = [], 0
+    while True:
+        while pos < len(line) and line[pos].isspace():
+            pos += 1
+        if pos >= len(line):
+            return fields
+        match = _FIELD.match(line, pos)
+        if match is None:
+            return None
+        if match.group(3) is not None:
+            return fields
+        if match.group(1) is not None:
+            fields.append((match.group(1).replace('""', '"'), True))
+        else:
+            fields.append((match.group(2), False))
+        pos = match.end()
+
+
+@dataclass(frozen=True)
+class IdentMapping:
+    """One pg_ident.conf line."""
+
+    map_name: str
+    system_user: str
+    database_user: str
+    regex: bool = False  # system_user is a regular expression (unquoted, started with /)
+
+    def matches(self, system_user: str, database_user: str) -> bool:
+        expected = self.database_user
+        if self.regex:
+            try:
+                match = re.search(self.system_user, system_user)
+            except re.error as e:
+                logger.warning(
+                    "Invalid user name map expression", pattern=self.system_user, error=str(e)
+                )
+                return False
+            if match is None:
+                return False
+            if "\\1" in expected:
+                expected = expected.replace("\\1", match.group(1) if match.groups() else "")
+        elif system_user != self.system_user:
+            return False
+        return expected == "all" or expected == database_user
+
+
+class IdentMap:
+    """User name maps by name."""
+
+    def __init__(self, mappings: list[IdentMapping] | None = None):
+        self.mappings = mappings or []
+
+    @classmethod
+    def parse(cls, text: str) -> "IdentMap":
+        """Parse pg_ident.conf lines (malformed lines are skipped with a warning)."""
+        mappings = []
+        for number, line in enumerate(text.splitlines(), start=1):
+            fields = split_fields(line)
+            if fields == []:
+                continue
+            if fields is None or len(fields) != 3:
+                logger.warning("Ignoring invalid user name map line", line=number)
+                continue
+            (map_name, _), (system_user, quoted), (database_user, _) = fields
+            regex = system_user.startswith("/") and not quoted
+            if regex:
+                system_user = system_user[1:]
+            mappings.append(IdentMapping(map_name, system_user, database_user, regex))
+        return cls(mappings)
+
+    def check(self, map_name: str, system_user: str, database_user: str) -> bool:
+        """Whether map_name lets system_user connect as database_user."""
+        return any(
+            mapping.map_name == map_name and mapping.matches(system_user, database_user)
+            for mapping in self.mappings
+        )
+
+
+_cache: tuple[str, float, IdentMap] | None = None
+
+
+def get_ident_map() -> IdentMap:
+    """Maps from PGWIRE_IDENT_FILE (empty when unset or unreadable), reloaded on change."""
+    global _cache
+    path = os.getenv("PGWIRE_IDENT_FILE")
+    if not path:
+        return IdentMap()
+    try:
+        mtime = os.path.getmtime(path)
+        if _cache is None or _cache[:2] != (path, mtime):
+            with open(path, encoding="utf-8") as f:
+                _cache = (path, mtime, IdentMap.parse(f.read()))
+            logger.info("User name maps loaded", path=path, mappings=len(_cache[2].mappings))
+    except OSError as e:
+        logger.warning("Cannot read PGWIRE_IDENT_FILE", path=path, error=str(e))
+        return IdentMap()
+    return _cache[2]
@@ -7,24 +7,237 @@ Based on patterns from caretdev/sqlalchemy-iris for proven IRIS integration.
 
 import asyncio
 import concurrent.futures
+import contextvars
+import itertools
 import threading
 import time
+from collections.abc import AsyncIterator
 from typing import Any
 
 import structlog
 
-from .schema_mapper import translate_output_schema  # Feature 030: PostgreSQL schema mapping
+from .admission import AdmissionController, AdmissionTimeout, current_session_role
+from .backend_auth import (
+    PASSTHROUGH,
+    SERVICE,
+    BackendCredentials,
+    BackendLoginUnavailable,
+    PasswordAuthenticationFailed,
+    apply_delegated_login,
+    current_backend_credentials,
+    load_backend_auth_mode,
+    login_rejected,
+)
+from .cancellation import current_statement_cancel  # CancelRequest
+from .check_constraints import (  # CHECK constraints enforced by triggers
+    CheckConstraints,
+    CheckConstraintStore,
+    CheckDDL,
+    annotate_violation,
+    parse_check_ddl,
+    relation,
+)
+from .cascade import CascadeDrop, parse_cascade_drop, plan_cascade  # DROP ... CASCADE
+from .column_types import describe_column, is_generated_column_name  # RowDescription types
+from .ddl_modifiers import (  # IF [NOT] EXISTS and UNLOGGED
+    ModifiedDDL,
+    existence_sql,
+    parse_ddl_modifiers,
+    skip_notice,
+)
+from .expression_indexes import (  # lower() / upper() indexes on computed columns
+    COMPUTED_COLUMNS_SQL,
+    COMPUTED_INDEXES_SQL,
+    ExpressionIndex,
+    add_column_sql,
+    computed_column_type,
+    computed_indexes,
+    drop_column_sql,
+    drop_index_sql,
+    index_notice,
+    parse_drop_index,
+    parse_expression_index,
+    query_table,
+    references_indexed_expression,
+    rewrite_indexed_expressions,
+    source_column_sql,
+)
+from .global_tables import (
+    EmbeddedGlobalAccessor,
+    GlobalTableHandler,
+    NativeGlobalAccessor,
+    ReadOnlyGlobalTable,
+)
+from .iris_list import decode_list_columns, load_list_format
+from .iris_tls import iris_tls_kwargs
+from .large_values import has_large_values, iris_stream_params, materialized_params
+from .maintenance import (  # REINDEX and CLUSTER
+    CLUSTER_NOTICE,
+    SYSTEM_REINDEX_NOTICE,
+    Reindex,
+    UndefinedIndex,
+    build_index_statements,
+    parse_cluster,
+    parse_reindex,
+)
+from .md5_auth import Md5SecretStore
+from .money_values import (  # MONEY columns in information_schema and results
+    MONEY_COLUMNS_SQL,
+    is_money_column,
+    money_result_columns,
+    numeric_result_columns,
+)
+from .partitioning import (  # PARTITION BY / PARTITION OF, pg_partitioned_table
+    PG_PARTITIONED_TABLE_COLUMNS,
+    PartitionDDL,
+    PartitionStore,
+    parse_partition_ddl,
+    partition_view_sql,
+    sql_identifier,
+)
+from .partitioning import referenced_view as references_partitioned_table
+from .partitioning import view_rows as partitioned_table_rows
+from .privileges import InvalidPrivilegeType, PrivilegeFunctionHandler
+from .publications import (  # CREATE / ALTER / DROP PUBLICATION, pg_publication*
+    PG_PUBLICATION_COLUMNS,
+    PG_PUBLICATION_TABLES_COLUMNS,
+    PublicationDDL,
+    PublicationError,
+    PublicationStore,
+    PublishedTable,
+    apply_publication_ddl,
+    compile_row_filter,
+    parse_publication_ddl,
+    publication_rows,
+    publication_table_rows,
+)
+from .publications import referenced_view as referenced_publication_view
+from .read_only import ReadOnlyTransaction, check_read_only
+from .replication_slots import ReplicationSlot, SlotStore, get_active_slots
+from .schema_mapper import (  # Feature 030: PostgreSQL schema mapping
+    get_schema_config,
+    translate_output_schema,
+)
+from .scram import ScramVerifier, ScramVerifierStore, password_stamp
 from .sql_translator import (
     SQLTranslator,  # Feature 021: PostgreSQL→IRIS normalization
     TransactionTranslator,
 )  # Feature 022: PostgreSQL transaction verb translation
 from .sql_translator.alias_extractor import AliasExtractor  # Column alias preservation
+from .sql_translator.arithmetic_translator import annotate_division_by_zero  # 22012
+from .sql_translator.null_ordering_translator import (  # NULLS FIRST / LAST
+    NOT_NULL_COLUMNS_SQL,
+    declare_not_null,
+    order_table,
+    postgres_null_ordering,
+)
 from .sql_translator.performance_monitor import MetricType, PerformanceTracker, get_monitor
+from .sql_translator.timezone_translator import helper_ddl, helper_zones  # AT TIME ZONE
+from .sql_translator.trigram_translator import (  # name % $1: pg_trgm or modulo
+    AmbiguousOperator,
+    inline_parameter_operands,
+)
+from .sql_translator.vector_translator import inline_distance_parameters  # a <-> ?
+from .session_labels import SessionLabel, SessionLabels, current_session_label
+from .session_settings import (  # current_setting() / set_config()
+    InvalidParameterValue,
+    UnrecognizedParameter,
+    current_session_settings,
+    evaluate_setting_functions,
+)
+from .shadow import ShadowComparator
+from .sql_cursors import (  # DECLARE / FETCH
+    ResultStream,
+    cursor_query_error,
+    materialized_stream,
+    references_catalog,
+)
+from .statement_cache import get_statement_cache
+from .stats_hooks import get_stats
+from .stream_values import read_stream_columns
+from .system_functions import (
+    EmbeddedSystemInvoker,
+    NativeSystemInvoker,
+    SystemFunctionHandler,
+    UnsupportedSystemCall,
+)
 from .type_mapping import get_type_mapping, load_type_mappings_from_file  # Configurable type mapping
+from .vector_values import (  # pgvector's vector type
+    create_extension_notices,
+    is_create_vector_extension,
+    references_vector_type,
+    vector_type_row,
+)
+from .workload import apply_process_priority, load_workload_priorities, resolve_workload
+from .catalog.fast_path import (  # Well-known driver catalog queries
+    cache_key,
+    fast_path_enabled,
+    fingerprint,
+)
+from .catalog.fast_path import recognize as recognize_catalog_query
 from .catalog.oid_generator import OIDGenerator  # OID generation for catalog emulation
+from .catalog.pg_description import (  # COMMENT ON, pg_description, obj_description()
+    CommentHandler,
+    CommentStatement,
+    CommentStore,
+    CommentTargetMissing,
+    columns_sql,
+    parse_comment,
+)
+from .catalog.pg_description import VIEW_COLUMNS as DESCRIPTION_VIEW_COLUMNS
+from .catalog.pg_description import referenced_view as referenced_description_view
+from .catalog.pg_inherits import (  # Class hierarchy as table inheritance
+    HIERARCHY_SQL,
+    PgInheritsEmulator,
+    inheritance_as_partitions,
+)
+from .catalog.pg_stat import (  # Cumulative statistics views from bridge counters
+    PG_REPLICATION_SLOTS_COLUMNS,
+    PG_STAT_ACTIVITY_COLUMNS,
+    PG_STAT_BGWRITER_COLUMNS,
+    PG_STAT_DATABASE_COLUMNS,
+    PG_STAT_IO_COLUMNS,
+    PG_STAT_REPLICATION_COLUMNS,
+    PG_STAT_USER_TABLES_COLUMNS,
+    USER_TABLES_SQL,
+    PgStatEmulator,
+    referenced_view,
+)
+from .catalog.schema_cache import changes_schema, get_schema_cache  # Dictionary listings
+from .catalog.system_views import (  # pg_tables / pg_views / pg_indexes / pg_matviews
+    VIEW_COLUMNS,
+    VIEW_SOURCES,
+    SystemViewEmulator,
+)
+from .catalog.system_views import referenced_view as referenced_system_view
+from .catalog.view_query import query_view
 
 logger = structlog.get_logger()
 
+# Common PostgreSQL parameters (SHOW of parameters the session does not track)
+SHOW_VALUES = {
+    "SERVER_VERSION": "16.0 (InterSystems IRIS)",
+    "SERVER_VERSION_NUM": "160000",
+    "CLIENT_ENCODING": "UTF8",
+    "DATESTYLE": "ISO, MDY",
+    "TIMEZONE": "UTC",
+    "STANDARD_CONFORMING_STRINGS": "on",
+    "INTEGER_DATETIMES": "on",
+    "INTERVALSTYLE": "postgres",
+}
+
+# (user, stamp) of the IRIS password read by the current connection's login
+_login_password_stamp: contextvars.ContextVar[tuple[str, str | None] | None] = (
+    contextvars.ContextVar("pgwire_login_password_stamp", default=None)
+)
+
+
+def _large_value_streams(params: list) -> list:
+    """Bind parameters with spooled values written to IRIS streams (embedded mode)."""
+    import iris
+
+    return iris_stream_params(params, iris)
+
 
 class IRISExecutor:
     """
@@ -58,6 +271,43 @@ class IRISExecutor:
         self._connection_pool = []
         self._max_connections = 10
 
+        # Dictionary listings behind catalog emulation, per namespace (PGWIRE_SCHEMA_CACHE)
+        self.schema_cache = get_schema_cache()
+
+        # Globals exposed as virtual tables (PGWIRE_GLOBAL_TABLES)
+        self.global_tables = GlobalTableHandler()
+
+        # iris_system.<function>() bridge to $SYSTEM class methods
+        self.system_functions = SystemFunctionHandler()
+
+        # has_table_privilege() / has_schema_privilege() against IRIS SQL privileges
+        self.privilege_functions = PrivilegeFunctionHandler(get_schema_config()["iris_schema"])
+
+        # %List columns are decoded to text[] or JSON (PGWIRE_LIST_FORMAT)
+        self.list_format = load_list_format()
+
+        # Global and per-role concurrency caps for IRIS statements (PGWIRE_MAX_*_QUERIES)
+        self.admission = AdmissionController()
+
+        # Workload classes with an IRIS process priority get their own external pool
+        self.workload_priorities = load_workload_priorities()
+        self._workload_pools: dict[str, list] = {}
+
+        # Service account or per-user IRIS logins (PGWIRE_BACKEND_AUTH_MODE)
+        self.backend_auth_mode = load_backend_auth_mode()
+        self._user_pools: dict[tuple[str, bool, str | None], list] = {}
+
+        # IRIS processes of external connections labelled with their session
+        self.session_labels = SessionLabels()
+        # Connection running each session's statement, by backend PID (CancelRequest)
+        self._statement_connections: dict[int, Any] = {}
+
+        # Region zones whose AT TIME ZONE function this process created in IRIS
+        self._timezone_helpers: set[str] = set()
+
+        # Shadow comparison against a real PostgreSQL (PGWIRE_SHADOW_DSN)
+        self.shadow = ShadowComparator.from_env()
+
         # Load custom type mappings from configuration file (if exists)
         # This allows users to customize IRIS→PostgreSQL type mappings
         # for ORM compatibility (Prisma, SQLAlchemy, etc.)
@@ -65,6 +315,9 @@ class IRISExecutor:
 
         # Attempt to detect IRIS environment
         self._detect_iris_environment()
+        if self.embedded_mode and self.backend_auth_mode == PASSTHROUGH:
+            logger.warning("Credential passthrough is not available in embedded mode")
+            self.backend_auth_mode = SERVICE
 
         logger.info(
             "IRIS executor initialized",
@@ -239,6 +492,7 @@ class IRISExecutor:
             "varchar": 1043,  # varchar
             "date": 1082,  # date
             "timestamp": 1114,  # timestamp
+            "timestamptz": 1184,  # timestamp with time zone
             "float": 701,  # float8
             "double": 701,  # float8
         }
@@ -303,6 +557,20 @@ class IRISExecutor:
         else:
             return 25  # Default to VARCHAR
 
+    def _infer_column_type(self, rows: list, index: int) -> int:
+        """
+        Type OID of a result column without metadata, from its values: int8 when
+        any value is outside int4, so later rows never overflow the first one's type.
+        """
+        if index >= len(rows[0]):
+            return 25
+        type_oid = self._infer_type_from_value(rows[0][index])
+        if type_oid == 23 and any(
+            index < len(row) and self._infer_type_from_value(row[index]) == 20 for row in rows
+        ):
+            return 20
+        return type_oid
+
     def _split_sql_statements(self, sql: str) -> list[str]:
         """
         Split SQL string into individual statements, handling semicolons properly.
@@ -458,6 +726,7 @@ class IRISExecutor:
                         namespace=self.iris_config["namespace"],
                         username=self.iris_config["username"],
                         password=self.iris_config["password"],
+                        **iris_tls_kwargs(),
                     )
 
                     # Test simple query
@@ -541,6 +810,40 @@ class IRISExecutor:
         Returns:
             Dictionary with query results and metadata
         """
+        started = time.perf_counter()
+        result = None
+        get_stats().query_started(sql)
+        try:
+            catalog_query = recognize_catalog_query(sql)
+            if catalog_query is not None and fast_path_enabled():
+                # Npgsql type load, JDBC getTables, psql \d (see catalog/fast_path.py)
+                statement = self._catalog_fast_path(catalog_query.name, sql, params, session_id)
+            else:
+                statement = self._execute_query(sql, params, session_id)
+            # Interruptible by the session's CancelRequest (see cancellation.py)
+            statement_cancel = current_statement_cancel()
+            if statement_cancel is not None:
+                raw = await statement_cancel.run(statement)
+            else:
+                raw = await statement
+            result = annotate_division_by_zero(annotate_violation(raw))
+            result = await self._describe_money_columns(sql, result, session_id)
+            if result.get("success") and changes_schema(sql):
+                self.schema_cache.invalidate(self.iris_config.get("namespace", "USER"))
+                get_statement_cache().clear()  # Implicit prepared statements
+            if self.shadow is not None:
+                # Validation mode: diff against a real PostgreSQL in the background
+                self.shadow.submit(sql, params, result)
+            return result
+        finally:
+            # Per-query timings and session counters for embedders (stats_hooks.py)
+            get_stats().record_query(
+                sql, result, (time.perf_counter() - started) * 1000, params=params
+            )
+
+    async def _execute_query(
+        self, sql: str, params: list | None = None, session_id: str | None = None
+    ) -> dict[str, Any]:
         try:
             # Feature 022: Apply PostgreSQL→IRIS transaction verb translation FIRST
             # This must happen before any other processing
@@ -549,6 +852,176 @@ class IRISExecutor:
             transaction_translator = TransactionTranslator()
             sql = transaction_translator.translate_transaction_command(sql)
 
+            # default_transaction_read_only sessions never send writes to IRIS
+            try:
+                check_read_only(sql)
+            except ReadOnlyTransaction as e:
+                return {
+                    "success": False,
+                    "error": str(e),
+                    "sqlstate": e.sqlstate,
+                    "condition_name": e.condition_name,
+                    "rows": [],
+                    "columns": [],
+                    "row_count": 0,
+                }
+
+            # Parameters of %, <% and %> decide between pg_trgm and modulo by their value
+            try:
+                sql, params = inline_parameter_operands(sql, params)
+            except AmbiguousOperator as e:
+                return {
+                    "success": False,
+                    "error": str(e),
+                    "sqlstate": e.sqlstate,
+                    "condition_name": e.condition_name,
+                    "rows": [],
+                    "columns": [],
+                    "row_count": 0,
+                }
+            # <-> outside an ORDER BY ranking reads its vector more than once
+            sql, params = inline_distance_parameters(sql, params)
+
+            # IRIS globals exposed as read-only virtual tables (globals.<name>)
+            if self.global_tables.references_virtual_table(sql):
+                global_result = await self._execute_global_table_query(sql, session_id)
+                if global_result is not None:
+                    return global_result
+
+            # iris_system.version() etc. call $SYSTEM class methods directly
+            if self.system_functions.references_system_function(sql):
+                system_result = await self._execute_system_function_query(sql, session_id)
+                if system_result is not None:
+                    return system_result
+
+            # has_table_privilege() / has_schema_privilege() (see privileges.py)
+            if self.privilege_functions.references_privilege_function(sql):
+                privilege_result = await self._execute_privilege_query(sql, session_id)
+                if privilege_result is not None:
+                    return privilege_result
+
+            # COMMENT ON and comment lookups (see catalog/pg_description.py)
+            comment = parse_comment(sql)
+            if comment is not None:
+                return await self._execute_comment(comment, session_id)
+            description_view = referenced_description_view(sql)
+            if description_view is not None or CommentHandler.references_description_function(
+                sql
+            ):
+                description_result = await self._execute_description_query(
+                    sql, description_view, session_id
+                )
+                if description_result is not None:
+                    return description_result
+
+            # REINDEX → BUILD INDEX, CLUSTER accepted as a no-op (see maintenance.py)
+            reindex = parse_reindex(sql)
+            if reindex is not None:
+                return await self._execute_reindex(reindex, session_id)
+            cluster = parse_cluster(sql)
+            if cluster is not None:
+                return {
+                    "success": True,
+                    "rows": [],
+                    "columns": [],
+                    "row_count": 0,
+                    "command": cluster.tag.split()[0],
+                    "command_tag": cluster.tag,
+                    "notices": [CLUSTER_NOTICE],
+                }
+
+            # IF [NOT] EXISTS and UNLOGGED, before other DDL handling (see ddl_modifiers.py)
+            modified_ddl = parse_ddl_modifiers(sql)
+            if modified_ddl is not None:
+                return await self._execute_modified_ddl(modified_ddl, session_id)
+
+            # CHECK constraints in CREATE / ALTER TABLE (see check_constraints.py)
+            check_ddl = parse_check_ddl(sql)
+            if check_ddl is not None:
+                check_result = await self._execute_check_ddl(check_ddl, session_id)
+                if check_result is not None:
+                    return check_result
+
+            # lower() / upper() indexes and the queries they serve (see expression_indexes.py)
+            expression_index = parse_expression_index(sql)
+            if expression_index is not None:
+                index_result = await self._execute_expression_index(expression_index, session_id)
+                if index_result is not None:
+                    return index_result
+            dropped_indexes = parse_drop_index(sql)
+            if dropped_indexes is not None:
+                drop_result = await self._execute_drop_index(dropped_indexes, session_id)
+                if drop_result is not None:
+                    return drop_result
+            if references_indexed_expression(sql):
+                sql = await self._read_indexed_expressions(sql, session_id)
+
+            # ORDER BY of NOT NULL columns needs no NULL-rank key (see null_ordering_translator.py)
+            if postgres_null_ordering() and order_table(sql) is not None:
+                sql = await self._declare_not_null_order(sql, session_id)
+
+            # AT TIME ZONE of region zones calls the zone's function (see timezone_translator.py)
+            zones = [zone for zone in helper_zones(sql) if zone not in self._timezone_helpers]
+            if zones:
+                await self._create_timezone_helpers(zones)
+
+            # Declarative partitioning and pg_partitioned_table (see partitioning.py)
+            partition_ddl = parse_partition_ddl(sql)
+            if partition_ddl is not None:
+                partition_result = await self._execute_partition_ddl(partition_ddl, session_id)
+                if partition_result is not None:
+                    return partition_result
+            if references_partitioned_table(sql):
+                return await self._execute_partitioned_table_query(sql, session_id)
+
+            # Publications, their row filters and column lists (see publications.py)
+            try:
+                publication_ddl = parse_publication_ddl(sql)
+            except PublicationError as e:
+                return self._publication_error(e)
+            if publication_ddl is not None:
+                return await self._execute_publication_ddl(publication_ddl, session_id)
+            publication_view = referenced_publication_view(sql)
+            if publication_view is not None:
+                return await self._execute_publication_query(sql, publication_view, session_id)
+
+            # DROP TABLE / VIEW / SCHEMA ... CASCADE (see cascade.py)
+            cascade_drop = parse_cascade_drop(sql)
+            if cascade_drop is not None:
+                return await self._execute_cascade_drop(cascade_drop, session_id)
+
+            # pg_stat_* statistics views (see catalog/pg_stat.py)
+            stat_view = referenced_view(sql)
+            if stat_view is not None:
+                return await self._execute_pg_stat_query(sql, stat_view, session_id)
+
+            # pg_tables / pg_views / pg_indexes / pg_matviews (see catalog/system_views.py)
+            system_view = referenced_system_view(sql)
+            if system_view is not None:
+                return await self._execute_system_view_query(sql, system_view, session_id)
+
+            # CREATE EXTENSION vector and pg_type lookups of vector (see vector_values.py)
+            if is_create_vector_extension(sql):
+                return {
+                    "success": True,
+                    "rows": [],
+                    "columns": [],
+                    "row_count": 0,
+                    "command": "CREATE",
+                    "command_tag": "CREATE EXTENSION",
+                    "notices": create_extension_notices(sql),
+                }
+            if references_vector_type(sql, params):
+                columns, rows = vector_type_row(sql)
+                return {
+                    "success": True,
+                    "rows": rows,
+                    "columns": columns,
+                    "row_count": len(rows),
+                    "command": "SELECT",
+                    "command_tag": f"SELECT {len(rows)}",
+                }
+
             # Intercept PostgreSQL system function calls and return stub results
             sql_upper = sql.upper().strip().rstrip(";")
 
@@ -562,18 +1035,7 @@ class IRISExecutor:
             if sql_upper.startswith("SHOW "):
                 param_name = sql_upper[5:].strip()  # Extract parameter name
                 logger.info("Intercepting SHOW command", param=param_name, session_id=session_id)
-                # Common PostgreSQL parameters
-                show_values = {
-                    "SERVER_VERSION": "16.0 (InterSystems IRIS)",
-                    "SERVER_VERSION_NUM": "160000",
-                    "CLIENT_ENCODING": "UTF8",
-                    "DATESTYLE": "ISO, MDY",
-                    "TIMEZONE": "UTC",
-                    "STANDARD_CONFORMING_STRINGS": "on",
-                    "INTEGER_DATETIMES": "on",
-                    "INTERVALSTYLE": "postgres",
-                }
-                value = show_values.get(param_name, "unknown")
+                value = SHOW_VALUES.get(param_name, "unknown")
                 return {
                     "success": True,
                     "rows": [[value]],
@@ -653,76 +1115,48 @@ class IRISExecutor:
                     "row_count": 1,
                 }
 
-            # Handle asyncpg type introspection query: SELECT CURRENT_SETTING('jit') AS CUR, SET_CONFIG('jit', 'off', FALSE) AS NEW
-            if "CURRENT_SETTING" in sql_upper and "SET_CONFIG" in sql_upper:
-                logger.info(
-                    "Intercepting asyncpg type introspection query",
-                    sql=sql[:150],
-                    session_id=session_id,
-                )
-                return {
-                    "success": True,
-                    "rows": [["off", "off"]],  # Two columns: CUR and NEW
-                    "columns": [
-                        {
-                            "name": "cur",
-                            "type_oid": 25,
-                            "type_size": -1,
-                            "type_modifier": -1,
-                            "format_code": 0,
-                        },
-                        {
-                            "name": "new",
-                            "type_oid": 25,
-                            "type_size": -1,
-                            "type_modifier": -1,
-                            "format_code": 0,
-                        },
-                    ],
-                    "row_count": 1,
-                }
-
-            # CURRENT_SETTING(name) - Return configuration parameter value (single call)
-            if "CURRENT_SETTING" in sql_upper:
-                logger.info(
-                    "Intercepting CURRENT_SETTING function call",
-                    sql=sql[:100],
-                    session_id=session_id,
-                )
-                return {
-                    "success": True,
-                    "rows": [["off"]],  # Return 'off' for JIT and other settings
-                    "columns": [
-                        {
-                            "name": "current_setting",
-                            "type_oid": 25,
-                            "type_size": -1,
-                            "type_modifier": -1,
-                            "format_code": 0,
-                        }
-                    ],
-                    "row_count": 1,
-                }
-
-            # SET_CONFIG(name, value, is_local) - Set configuration parameter (single call)
-            if "SET_CONFIG" in sql_upper:
-                logger.info(
-                    "Intercepting SET_CONFIG function call", sql=sql[:100], session_id=session_id
-                )
-                return {
-                    "success": True,
-                    "rows": [["off"]],  # Return the value that was set
-                    "columns": [
-                        {
-                            "name": "set_config",
-                            "type_oid": 25,
-                            "type_size": -1,
-                            "type_modifier": -1,
-                            "format_code": 0,
-                        }
-                    ],
-                    "row_count": 1,
-                }
+            # CURRENT_SETTING(name) / SET_CONFIG(name, value, is_local) - the session's
+            # parameters (session_settings.py), e.g. asyncpg's
+            # SELECT CURRENT_SETTING('jit') AS CUR, SET_CONFIG('jit', 'off', FALSE) AS NEW
+            if "CURRENT_SETTING" in sql_upper or "SET_CONFIG" in sql_upper:
+                try:
+                    settings = evaluate_setting_functions(
+                        sql,
+                        params,
+                        current_session_settings(),
+                        {name.lower(): value for name, value in SHOW_VALUES.items()},
+                    )
+                except (UnrecognizedParameter, InvalidParameterValue) as e:
+                    return {
+                        "success": False,
+                        "error": str(e),
+                        "sqlstate": e.sqlstate,
+                        "condition_name": e.condition_name,
+                        "rows": [],
+                        "columns": [],
+                        "row_count": 0,
+                    }
+                if settings is not None:
+                    logger.info(
+                        "Intercepting CURRENT_SETTING/SET_CONFIG",
+                        sql=sql[:100],
+                        session_id=session_id,
+                    )
+                    return {
+                        "success": True,
+                        "rows": [[value for _, value in settings]],
+                        "columns": [
+                            {
+                                "name": name,
+                                "type_oid": 25,
+                                "type_size": -1,
+                                "type_modifier": -1,
+                                "format_code": 0,
+                            }
+                            for name, _ in settings
+                        ],
+                        "row_count": 1,
+                    }
 
             # PG_ADVISORY_UNLOCK_ALL() - Release all advisory locks
             if "PG_ADVISORY_UNLOCK_ALL" in sql_upper:
@@ -818,12 +1252,36 @@ class IRISExecutor:
                 logger.warning(
                     f"🔍 DEBUG: execute_query() branching - embedded_mode = {self.embedded_mode}"
                 )
-                if self.embedded_mode:
-                    logger.warning("🔍 DEBUG: Taking EMBEDDED path → _execute_embedded_async()")
-                    result = await self._execute_embedded_async(sql, params, session_id)
-                else:
-                    logger.warning("🔍 DEBUG: Taking EXTERNAL path → _execute_external_async()")
-                    result = await self._execute_external_async(sql, params, session_id)
+                role = current_session_role()
+                workload = resolve_workload(sql)
+                try:
+                    async with self.admission.admit(role, workload):
+                        if self.embedded_mode:
+                            logger.warning(
+                                "🔍 DEBUG: Taking EMBEDDED path → _execute_embedded_async()"
+                            )
+                            result = await self._execute_embedded_async(sql, params, session_id)
+                        else:
+                            logger.warning(
+                                "🔍 DEBUG: Taking EXTERNAL path → _execute_external_async()"
+                            )
+                            result = await self._execute_external_async(
+                                sql, params, session_id, workload
+                            )
+                except AdmissionTimeout as e:
+                    return {
+                        "success": False,
+                        "error": str(e),
+                        "sqlstate": e.sqlstate,
+                        "condition_name": e.condition_name,
+                        "rows": [],
+                        "columns": [],
+                        "row_count": 0,
+                    }
+
+                # Raw $LIST values would render as garbage - decode %List columns
+                if result.get("success"):
+                    decode_list_columns(result, self.list_format)
 
                 # Add performance metadata
                 result["execution_metadata"] = {
@@ -855,6 +1313,140 @@ class IRISExecutor:
             )
             raise
 
+    async def _execute_global_table_query(
+        self, sql: str, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Answer a query against a global virtual table (None if it is not one).
+
+        Reads run in the thread pool: embedded mode walks globals with iris.gref(),
+        external mode uses the Native API on a pooled connection.
+        """
+
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _sync_global_query():
+            import iris
+
+            if self.embedded_mode:
+                return self.global_tables.execute(sql, EmbeddedGlobalAccessor(iris))
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                return self.global_tables.execute(sql, NativeGlobalAccessor(iris.createIRIS(conn)))
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        try:
+            loop = asyncio.get_event_loop()
+            return await loop.run_in_executor(self.thread_pool, _sync_global_query)
+        except ReadOnlyGlobalTable as e:
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": e.sqlstate,
+                "condition_name": "read_only_sql_transaction",
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+        except ValueError as e:
+            logger.warning("Unsupported global table query", error=str(e), session_id=session_id)
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": "0A000",
+                "condition_name": "feature_not_supported",
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+
+    async def _execute_system_function_query(
+        self, sql: str, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Answer a SELECT of iris_system functions (None if it is not one).
+
+        Embedded mode calls the class methods with iris.cls(), external mode uses
+        the Native API on a pooled connection.
+        """
+
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _sync_system_call():
+            import iris
+
+            if self.embedded_mode:
+                return self.system_functions.execute(sql, EmbeddedSystemInvoker(iris))
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                return self.system_functions.execute(sql, NativeSystemInvoker(iris.createIRIS(conn)))
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        try:
+            loop = asyncio.get_event_loop()
+            return await loop.run_in_executor(self.thread_pool, _sync_system_call)
+        except UnsupportedSystemCall as e:
+            logger.warning("Unsupported iris_system call", error=str(e), session_id=session_id)
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": e.sqlstate,
+                "condition_name": (
+                    "undefined_function" if e.sqlstate == "42883" else "feature_not_supported"
+                ),
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+
+    async def _execute_privilege_query(
+        self, sql: str, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """Answer a SELECT of privilege inquiry functions (None if it is not one)."""
+        credentials = current_backend_credentials()
+        label = current_session_label()
+        # The IRIS account the session's statements run as
+        user = credentials.user if credentials else self.iris_config["username"]
+
+        def _sync_privilege_check():
+            import iris
+
+            if self.embedded_mode:
+                return self.privilege_functions.execute(sql, user, EmbeddedSystemInvoker(iris))
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                return self.privilege_functions.execute(
+                    sql, user, NativeSystemInvoker(iris.createIRIS(conn))
+                )
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        try:
+            # Parse first so other SELECTs fall through without a round trip
+            if self.privilege_functions.parse(sql) is None:
+                return None
+            loop = asyncio.get_event_loop()
+            return await loop.run_in_executor(
+                self.thread_pool, contextvars.copy_context().run, _sync_privilege_check
+            )
+        except InvalidPrivilegeType as e:
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": e.sqlstate,
+                "condition_name": e.condition_name,
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+
     async def execute_many(
         self, sql: str, params_list: list[list], session_id: str | None = None
     ) -> dict[str, Any]:
@@ -890,6 +1482,9 @@ class IRISExecutor:
             - Community benchmark: community.intersystems.com/post/performance-tests-iris-postgresql-mysql-using-python
             - COPY Performance Investigation: docs/COPY_PERFORMANCE_INVESTIGATION.md
         """
+        # Batched writes are still writes (default_transaction_read_only)
+        check_read_only(sql)
+
         try:
             # Performance tracking for constitutional compliance
             with PerformanceTracker(
@@ -1109,6 +1704,9 @@ class IRISExecutor:
         - Expected throughput: 2,400-10,000+ rows/sec
         """
 
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
         def _sync_execute_many():
             """Synchronous IRIS DBAPI executemany() in thread pool"""
 
@@ -1124,7 +1722,7 @@ class IRISExecutor:
 
             try:
                 # Get pooled connection
-                connection = self._get_pooled_connection()
+                connection = self._get_pooled_connection(credentials=credentials, label=label)
 
                 # Feature 022: Apply PostgreSQL→IRIS transaction verb translation
                 transaction_translator = TransactionTranslator()
@@ -1198,13 +1796,134 @@ class IRISExecutor:
                         pass
                 if connection:
                     try:
-                        self._return_connection(connection)
+                        self._return_connection(connection, credentials=credentials)
                     except Exception:
                         pass
 
         # Execute in thread pool to avoid blocking event loop
         return await asyncio.to_thread(_sync_execute_many)
 
+    async def stream_rows(self, sql: str, batch_size: int) -> AsyncIterator[list[tuple]]:
+        """
+        Run a SELECT as written and yield its rows in batches of batch_size.
+
+        Used by COPY TO (see copy_export.py): the statement skips translation and
+        per-value conversion, and only one batch is in memory at a time. External
+        mode keeps a pooled connection until the rows are consumed (or the
+        iteration is abandoned).
+        """
+        credentials = current_backend_credentials()
+        label = current_session_label()
+        loop = asyncio.get_event_loop()
+        connection = None
+
+        def _sync_open():
+            nonlocal connection
+            import iris
+
+            if self.embedded_mode:
+                return iter(iris.sql.exec(sql))
+            connection = self._get_pooled_connection(credentials=credentials, label=label)
+            cursor = connection.cursor()
+            cursor.execute(sql)
+            return cursor
+
+        def _sync_fetch(results):
+            if self.embedded_mode:
+                return [tuple(row) for row in itertools.islice(results, batch_size)]
+            return [tuple(row) for row in results.fetchmany(batch_size)]
+
+        def _sync_close(results):
+            if connection is None:
+                return
+            try:
+                results.close()
+            finally:
+                self._return_connection(connection, credentials=credentials)
+
+        results = None
+        try:
+            results = await loop.run_in_executor(self.thread_pool, _sync_open)
+            while batch := await loop.run_in_executor(self.thread_pool, _sync_fetch, results):
+                yield batch
+        finally:
+            if results is not None:
+                await loop.run_in_executor(self.thread_pool, _sync_close, results)
+            elif connection is not None:
+                self._return_connection(connection, credentials=credentials)
+
+    async def open_cursor_stream(self, sql: str) -> ResultStream:
+        """
+        Open the query of a DECLAREd cursor for reading in pieces (see sql_cursors.py).
+
+        External mode runs a SELECT on a pooled connection, kept until the
+        stream is closed, and FETCH reads from it with fetchmany. In embedded
+        mode, and for queries the bridge answers itself, the query runs like
+        any other and the stream reads its result.
+
+        Raises:
+            CursorError: the query failed
+        """
+        if self.embedded_mode or self._answers_query(sql):
+            result = await self.execute_query(sql)
+            if not result.get("success"):
+                raise cursor_query_error(result)
+            return materialized_stream(result)
+
+        credentials = current_backend_credentials()
+        label = current_session_label()
+        loop = asyncio.get_event_loop()
+
+        def _sync_open():
+            connection = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                executed_sql = SQLTranslator().normalize_sql(sql, execution_path="external")
+                cursor = connection.cursor()
+                cursor.execute(executed_sql)
+                columns = [
+                    self._external_column(desc, sql, executed_sql)
+                    for desc in cursor.description or []
+                ]
+            except Exception:
+                self._return_connection(connection, credentials=credentials)
+                raise
+            return connection, cursor, columns
+
+        try:
+            connection, cursor, columns = await loop.run_in_executor(self.thread_pool, _sync_open)
+        except Exception as e:
+            logger.warning("Cursor query failed", sql=sql[:100], error=str(e))
+            raise cursor_query_error({"error": str(e)}) from e
+
+        def _sync_fetch(count):
+            return [list(row) for row in cursor.fetchmany(count)]
+
+        def _sync_close():
+            try:
+                cursor.close()
+            finally:
+                self._return_connection(connection, credentials=credentials)
+
+        async def fetch(count: int) -> list:
+            return await loop.run_in_executor(self.thread_pool, _sync_fetch, count)
+
+        async def close() -> None:
+            await loop.run_in_executor(self.thread_pool, _sync_close)
+
+        return ResultStream(columns, fetch, close)
+
+    def _answers_query(self, sql: str) -> bool:
+        """Whether execute_query answers sql without IRIS reading it as written."""
+        return (
+            references_catalog(sql)
+            or recognize_catalog_query(sql) is not None
+            or self.global_tables.references_virtual_table(sql)
+            or self.system_functions.references_system_function(sql)
+            or self.privilege_functions.references_privilege_function(sql)
+            or CommentHandler.references_description_function(sql)
+            or references_partitioned_table(sql)
+        )
+
     async def _execute_embedded_async(
         self, sql: str, params: list | None = None, session_id: str | None = None
     ) -> dict[str, Any]:
@@ -1460,12 +2179,22 @@ class IRISExecutor:
                 # Check constraint query: has is_deferrable/is_deferred columns AND NOT a rawindex query
                 if is_check_constraint_query or (has_deferrable and has_deferred and not is_rawindex_query):
                     logger.info(
-                        "Check/exclusion constraint query detected - returning empty result",
+                        "Check/exclusion constraint query detected - returning bridge CHECKs",
                         is_check_query=is_check_constraint_query,
                         has_deferrable=has_deferrable,
                         session_id=session_id,
                     )
-                    # Return empty result with expected columns for check constraint query
+                    # CHECK constraints the bridge enforces (see check_constraints.py), for
+                    # tables that still exist; IRIS itself has none
+                    tables = {
+                        (row[0].lower(), row[1].lower()) for row in iris.sql.exec(USER_TABLES_SQL)
+                    }
+                    stored = CheckConstraintStore(EmbeddedGlobalAccessor(iris)).rows()
+                    rows = [
+                        ("public", table, name, "c", f"CHECK ({expression})", False, False)
+                        for schema, table, name, expression in stored
+                        if (schema.lower(), table.lower()) in tables
+                    ]
                     columns = [
                         {"name": "namespace", "type_oid": 19, "type_size": 64, "type_modifier": -1, "format_code": 0},
                         {"name": "table_name", "type_oid": 19, "type_size": 64, "type_modifier": -1, "format_code": 0},
@@ -1477,11 +2206,11 @@ class IRISExecutor:
                     ]
                     return {
                         "success": True,
-                        "rows": [],
+                        "rows": rows,
                         "columns": columns,
-                        "row_count": 0,
+                        "row_count": len(rows),
                         "command": "SELECT",
-                        "command_tag": "SELECT 0",
+                        "command_tag": f"SELECT {len(rows)}",
                     }
 
                 try:
@@ -1650,30 +2379,82 @@ class IRISExecutor:
                     "command_tag": "SELECT 0",
                 }
 
-            # pg_proc - Return empty function/procedure information for Prisma introspection
-            # Prisma queries pg_proc for stored procedures and functions
-            # IRIS doesn't expose stored procedures via pg_proc, so return empty
+            # pg_proc - Bridge functions (ifind_match etc.) for simple catalog queries
+            # Prisma queries pg_proc JOIN pg_namespace/pg_language for user-defined functions
+            # and expects its own column set; IRIS stored procedures are not exposed, so
+            # those introspection queries keep returning empty
             if "PG_PROC" in sql_upper:
+                from .catalog.pg_proc import PgProcEmulator
+
+                is_introspection_join = any(
+                    marker in sql_upper
+                    for marker in ("PG_NAMESPACE", "PG_LANGUAGE", "PG_GET_FUNCTIONDEF")
+                )
                 logger.info(
-                    "Intercepting pg_proc query (returning empty - IRIS procedures not exposed)",
+                    "Intercepting pg_proc query",
+                    introspection_join=is_introspection_join,
                     sql_preview=sql[:200],
                     session_id=session_id,
                 )
 
-                # Return empty result with minimal columns for procedure queries
-                columns = [
-                    {"name": "oid", "type_oid": 26, "type_size": 4, "type_modifier": -1, "format_code": 0},
-                    {"name": "proname", "type_oid": 19, "type_size": 64, "type_modifier": -1, "format_code": 0},
-                    {"name": "pronamespace", "type_oid": 26, "type_size": 4, "type_modifier": -1, "format_code": 0},
+                if is_introspection_join:
+                    proc_columns = [
+                        {"name": "oid", "type_oid": 26},
+                        {"name": "proname", "type_oid": 19},
+                        {"name": "pronamespace", "type_oid": 26},
+                    ]
+                    rows = []
+                else:
+                    proc_columns, rows = PgProcEmulator().query(sql)
+
+                columns = [
+                    {
+                        "name": column["name"],
+                        "type_oid": column["type_oid"],
+                        "type_size": {18: 1, 19: 64, 21: 2, 26: 4}.get(column["type_oid"], -1),
+                        "type_modifier": -1,
+                        "format_code": 0,
+                    }
+                    for column in proc_columns
                 ]
 
                 return {
                     "success": True,
-                    "rows": [],  # No procedures to report
+                    "rows": rows,
                     "columns": columns,
-                    "row_count": 0,
+                    "row_count": len(rows),
                     "command": "SELECT",
-                    "command_tag": "SELECT 0",
+                    "command_tag": f"SELECT {len(rows)}",
+                }
+
+            # pg_inherits - IRIS class inheritance (subclass extents) as table inheritance
+            # Must be checked BEFORE pg_class: inheritance queries usually reference both
+            if "PG_INHERITS" in sql_upper:
+                logger.info(
+                    "Intercepting pg_inherits query",
+                    sql_preview=sql[:200],
+                    session_id=session_id,
+                )
+
+                inherit_columns, rows = self._load_class_hierarchy(iris).query(sql)
+                columns = [
+                    {
+                        "name": column["name"],
+                        "type_oid": column["type_oid"],
+                        "type_size": {16: 1, 23: 4, 26: 4}.get(column["type_oid"], -1),
+                        "type_modifier": -1,
+                        "format_code": 0,
+                    }
+                    for column in inherit_columns
+                ]
+
+                return {
+                    "success": True,
+                    "rows": rows,
+                    "columns": columns,
+                    "row_count": len(rows),
+                    "command": "SELECT",
+                    "command_tag": f"SELECT {len(rows)}",
                 }
 
             # pg_views - Return empty view information for Prisma introspection
@@ -1788,6 +2569,11 @@ class IRISExecutor:
 
                     logger.info(f"pg_class: filtering for namespaces {target_namespaces}")
 
+                    # Subclass extents: parents have subclasses, children show as partitions
+                    hierarchy = self._load_class_hierarchy(iris)
+                    child_tables = hierarchy.child_tables() if inheritance_as_partitions() else set()
+                    parent_tables = hierarchy.parent_tables()
+
                     rows = []
                     for table_name, table_schema in iris_tables:
                         # Map IRIS schema to PostgreSQL namespace
@@ -1799,8 +2585,8 @@ class IRISExecutor:
                             rows.append((
                                 table_name.lower(),  # table_name (lowercase for PostgreSQL)
                                 pg_namespace,        # namespace
-                                False,               # is_partition
-                                False,               # has_subclass
+                                table_name.lower() in child_tables,   # is_partition
+                                table_name.lower() in parent_tables,  # has_subclass
                                 False,               # has_row_level_security
                                 None,                # reloptions (array)
                                 None,                # description
@@ -1850,7 +2636,8 @@ class IRISExecutor:
                             COALESCE(CHARACTER_MAXIMUM_LENGTH, 0) AS max_length,
                             IS_NULLABLE,
                             COLUMN_DEFAULT,
-                            ORDINAL_POSITION
+                            ORDINAL_POSITION,
+                            DESCRIPTION
                         FROM INFORMATION_SCHEMA.COLUMNS
                         WHERE TABLE_SCHEMA = 'SQLUser'
                         ORDER BY TABLE_NAME, ORDINAL_POSITION
@@ -1909,6 +2696,8 @@ class IRISExecutor:
                         # Map to PostgreSQL format_type and udt_name using configurable type mapping
                         base_type = iris_data_type.split('(')[0]
                         pg_type, udt_name, _type_oid = get_type_mapping(base_type)
+                        if pg_type == 'numeric' and is_money_column(col[10]):
+                            pg_type, udt_name = 'money', 'money'
 
                         # Build formatted_type with precision/length
                         if max_length > 0 and pg_type in ('character varying', 'character'):
@@ -2675,7 +3464,8 @@ class IRISExecutor:
                     if optimized_params is not None and len(optimized_params) > 0:
                         result = iris.sql.exec(optimized_sql, *optimized_params)
                     else:
-                        result = iris.sql.exec(optimized_sql)
+                        # Repeated texts reuse an IRIS prepared statement (statement_cache.py)
+                        result = get_statement_cache().execute(optimized_sql, iris)
 
                 # RETURNING emulation: After INSERT/UPDATE/DELETE, fetch the affected row(s)
                 if returning_columns and returning_table and returning_operation:
@@ -2842,15 +3632,18 @@ class IRISExecutor:
                             col_info=col_info,
                         )
 
-                        # Get PostgreSQL type OID
-                        type_oid = self._iris_type_to_pg_oid(iris_type)
+                        # Get PostgreSQL type OID, typlen and typmod
+                        type_oid, type_size, type_modifier = describe_column(
+                            iris_type, col_info.get("precision"), col_info.get("scale")
+                        )
 
                         # CRITICAL FIX: IRIS type code 2 means NUMERIC, but for decimal literals
                         # like 3.14, we want FLOAT8 so node-postgres returns a number, not a string.
                         # Override to FLOAT8 UNLESS explicitly cast to NUMERIC/DECIMAL or INTEGER
+                        # (table columns keep numeric and its precision)
                         sql_upper = sql.upper()
 
-                        if iris_type == 2:
+                        if iris_type == 2 and is_generated_column_name(iris_col_name):
                             # Check for explicit casts
                             if "AS INTEGER" in sql_upper or "AS INT" in sql_upper:
                                 # Already handled by asyncpg CAST INTEGER fix - don't override
@@ -2863,7 +3656,7 @@ class IRISExecutor:
                                     original_oid=type_oid,
                                     reason="Decimal literal without explicit NUMERIC/DECIMAL cast",
                                 )
-                                type_oid = 701  # FLOAT8
+                                type_oid, type_size, type_modifier = 701, 8, -1  # FLOAT8
 
                         # CRITICAL FIX: CURRENT_TIMESTAMP returns type 25 (TEXT) in IRIS
                         # but should be type 1114 (TIMESTAMP) for Npgsql compatibility
@@ -2874,14 +3667,14 @@ class IRISExecutor:
                                 original_oid=type_oid,
                                 reason="CURRENT_TIMESTAMP function should return TIMESTAMP type",
                             )
-                            type_oid = 1114  # TIMESTAMP
+                            type_oid, type_size = 1114, 8  # TIMESTAMP
 
                         columns.append(
                             {
                                 "name": col_name,
                                 "type_oid": type_oid,
-                                "type_size": col_info.get("size", -1),
-                                "type_modifier": -1,
+                                "type_size": type_size,
+                                "type_modifier": type_modifier,
                                 "format_code": 0,  # Text format
                             }
                         )
@@ -2897,6 +3690,8 @@ class IRISExecutor:
                             # Single value result
                             normalized_value = self._normalize_iris_null(row)
                             rows.append([normalized_value])
+                    # bytea and text columns: stream OIDs are read while the process owns them
+                    read_stream_columns(rows, columns, iris)
                 except Exception as fetch_error:
                     logger.warning(
                         "Error fetching IRIS result rows",
@@ -2934,11 +3729,7 @@ class IRISExecutor:
                         )
                         # Infer types from first row data
                         for i, alias in enumerate(discovered_aliases):
-                            inferred_type = (
-                                self._infer_type_from_value(first_row[i])
-                                if i < len(first_row)
-                                else 25
-                            )
+                            inferred_type = self._infer_column_type(rows, i)
                             # CRITICAL: Lowercase column names for PostgreSQL compatibility
                             col_name = alias.lower() if isinstance(alias, str) else alias
                             # Apply same normalization as result._meta path
@@ -2976,11 +3767,7 @@ class IRISExecutor:
                             )
                             # Infer types from first row data
                             for i, col_name in enumerate(table_columns):
-                                inferred_type = (
-                                    self._infer_type_from_value(first_row[i])
-                                    if i < len(first_row)
-                                    else 25
-                                )
+                                inferred_type = self._infer_column_type(rows, i)
                                 # Column names from INFORMATION_SCHEMA are already in correct case
                                 columns.append(
                                     {
@@ -3017,11 +3804,7 @@ class IRISExecutor:
                                     # CRITICAL: Lowercase column names for PostgreSQL compatibility
                                     col_name = alias.lower() if isinstance(alias, str) else alias
                                     # Apply same normalization as result._meta path
-                                    inferred_type = (
-                                        self._infer_type_from_value(first_row[i])
-                                        if i < len(first_row)
-                                        else 25
-                                    )
+                                    inferred_type = self._infer_column_type(rows, i)
 
                                     # CRITICAL FIX (2025-11-14): Check for CAST expressions in SQL
                                     # IRIS returns integer 1 for boolean values, but we need OID 16 (bool)
@@ -3079,11 +3862,7 @@ class IRISExecutor:
                                 use_qcolumn = "SELECT" in sql_upper and "FROM" not in sql_upper
 
                                 for i in range(num_columns):
-                                    inferred_type = (
-                                        self._infer_type_from_value(first_row[i])
-                                        if i < len(first_row)
-                                        else 25
-                                    )
+                                    inferred_type = self._infer_column_type(rows, i)
                                     # Use ?column? for literal queries (SELECT 1, SELECT 'hello')
                                     # Otherwise use generic column1, column2, etc.
                                     col_name = "?column?" if use_qcolumn else f"column{i+1}"
@@ -3115,7 +3894,8 @@ class IRISExecutor:
                         # Query INFORMATION_SCHEMA for column metadata
                         try:
                             metadata_sql = f"""
-                                SELECT column_name, data_type
+                                SELECT column_name, data_type, character_maximum_length,
+                                       numeric_precision, numeric_scale
                                 FROM INFORMATION_SCHEMA.COLUMNS
                                 WHERE LOWER(table_name) = LOWER('{table_name}')
                                 ORDER BY ordinal_position
@@ -3126,15 +3906,18 @@ class IRISExecutor:
                             metadata_rows = list(metadata_result)
 
                             if metadata_rows:
-                                for col_name, col_type in metadata_rows:
-                                    # Map IRIS types to PostgreSQL OIDs
-                                    type_oid = self._map_iris_type_to_oid(col_type)
+                                for col_name, col_type, length, precision, scale in metadata_rows:
+                                    # Map IRIS types to PostgreSQL OIDs, typlen and typmod
+                                    # (numeric columns have a display length too)
+                                    type_oid, type_size, type_modifier = describe_column(
+                                        col_type, precision if precision else length, scale
+                                    )
                                     columns.append(
                                         {
                                             "name": col_name,
                                             "type_oid": type_oid,
-                                            "type_size": -1,
-                                            "type_modifier": -1,
+                                            "type_size": type_size,
+                                            "type_modifier": type_modifier,
                                             "format_code": 0,
                                         }
                                     )
@@ -3344,14 +4127,26 @@ class IRISExecutor:
 
         # Execute in thread pool to avoid blocking event loop
         loop = asyncio.get_event_loop()
+        if has_large_values(params):
+            # Spooled Bind values are bound as IRIS streams (large_values.py)
+            params = await loop.run_in_executor(self.thread_pool, _large_value_streams, params)
         return await loop.run_in_executor(self.thread_pool, _sync_execute)
 
     async def _execute_external_async(
-        self, sql: str, params: list | None = None, session_id: str | None = None
+        self,
+        sql: str,
+        params: list | None = None,
+        session_id: str | None = None,
+        workload: str | None = None,
     ) -> dict[str, Any]:
         """
         Execute SQL using external IRIS connection with proper async threading
+
+        The connection comes from the workload's pool (see workload.py), or the
+        session user's pool in credential passthrough mode (see backend_auth.py).
         """
+        credentials = current_backend_credentials()
+        label = current_session_label()
 
         def _sync_external_execute():
             """Synchronous external IRIS execution in thread pool"""
@@ -3474,7 +4269,7 @@ class IRISExecutor:
                 t_conn_start = time.perf_counter()
 
                 # Get connection from pool (or create new one)
-                conn = self._get_pooled_connection()
+                conn = self._get_pooled_connection(workload, credentials, label)
 
                 t_conn_elapsed = (time.perf_counter() - t_conn_start) * 1000
 
@@ -3509,68 +4304,10 @@ class IRISExecutor:
                 # Get column information
                 if cursor.description:
                     for desc in cursor.description:
-                        # Get original IRIS column name and type
-                        iris_col_name = desc[0]
-                        iris_type = desc[1] if len(desc) > 1 else "VARCHAR"
-
-                        # CRITICAL: Normalize IRIS column names to PostgreSQL conventions
-                        # IRIS generates HostVar_1, Expression_1, Aggregate_1 for unnamed columns
-                        # PostgreSQL uses ?column?, type names (int4), or function names (count)
-                        col_name = self._normalize_iris_column_name(iris_col_name, sql, iris_type)
-
-                        # DEBUG: Log IRIS type for arithmetic expressions (external mode)
-                        logger.info(
-                            "🔍 IRIS metadata type discovery (EXTERNAL MODE)",
-                            original_column_name=iris_col_name,
-                            normalized_column_name=col_name,
-                            iris_type=iris_type,
-                            desc=desc,
-                            sql_preview=optimized_sql[:200],
-                        )
-
-                        # CRITICAL FIX: IRIS type code 2 means NUMERIC, but for decimal literals
-                        # like 3.14, we want FLOAT8 so node-postgres returns a number, not a string.
-                        # Override to FLOAT8 UNLESS explicitly cast to NUMERIC/DECIMAL or INTEGER
-                        type_oid = self._iris_type_to_pg_oid(iris_type)
-
-                        sql_upper_check = optimized_sql.upper()
-
-                        if iris_type == 2:
-                            # Check for explicit casts
-                            if "AS INTEGER" in sql_upper_check or "AS INT" in sql_upper_check:
-                                # CAST(? AS INTEGER) - override to INT4
-                                logger.info(
-                                    "🔧 OVERRIDING IRIS type code 2 (NUMERIC) → OID 23 (INT4)",
-                                    column_name=col_name,
-                                    original_oid=type_oid,
-                                    reason="SQL contains CAST to INTEGER",
-                                )
-                                type_oid = 23  # INT4
-                            elif (
-                                "AS NUMERIC" not in sql_upper_check
-                                and "AS DECIMAL" not in sql_upper_check
-                            ):
-                                # No explicit NUMERIC/DECIMAL cast → make it FLOAT8
-                                logger.info(
-                                    "🔧 OVERRIDING IRIS type code 2 (NUMERIC) → OID 701 (FLOAT8)",
-                                    column_name=col_name,
-                                    original_oid=type_oid,
-                                    reason="Decimal literal without explicit NUMERIC/DECIMAL cast",
-                                )
-                                type_oid = 701  # FLOAT8
-
-                        columns.append(
-                            {
-                                "name": col_name,
-                                "type_oid": type_oid,
-                                "type_size": desc[2] if len(desc) > 2 else -1,
-                                "type_modifier": -1,
-                                "format_code": 0,  # Text format
-                            }
-                        )
+                        columns.append(self._external_column(desc, sql, optimized_sql))
 
                 # Fetch all rows for SELECT queries
-                if sql.upper().strip().startswith("SELECT") and columns:
+                if sql.upper().strip().startswith(("SELECT", "VALUES")) and columns:
                     try:
                         results = cursor.fetchall()
 
@@ -3594,6 +4331,9 @@ class IRISExecutor:
                             else:
                                 # Single value result
                                 rows.append([row])
+                        # bytea and text columns: driver streams are read before the
+                        # connection returns
+                        read_stream_columns(rows, columns)
                     except Exception as fetch_error:
                         logger.warning(
                             "Failed to fetch external IRIS results",
@@ -3603,7 +4343,7 @@ class IRISExecutor:
 
                 cursor.close()
                 # Return connection to pool instead of closing
-                self._return_connection(conn)
+                self._return_connection(conn, workload, credentials)
 
                 # PROFILING: Fetch complete
                 t_fetch_elapsed = (time.perf_counter() - t_fetch_start) * 1000
@@ -3727,8 +4467,907 @@ class IRISExecutor:
 
         # Execute in thread pool to avoid blocking event loop
         loop = asyncio.get_event_loop()
+        if has_large_values(params):
+            # The DB-API driver takes spooled Bind values as bytes or str (large_values.py)
+            params = await loop.run_in_executor(self.thread_pool, materialized_params, params)
         return await loop.run_in_executor(self.thread_pool, _sync_external_execute)
 
+    async def _execute_pg_stat_query(
+        self, sql: str, view: str, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Answer a SELECT on one of the emulated pg_stat_* views."""
+        logger.info("Intercepting pg_stat query", view=view, session_id=session_id)
+        emulator = PgStatEmulator(get_stats(), OIDGenerator(), get_schema_config()["iris_schema"])
+
+        if view == "pg_stat_database":
+            view_columns, view_rows = PG_STAT_DATABASE_COLUMNS, emulator.database_rows()
+        elif view == "pg_stat_io":
+            view_columns, view_rows = PG_STAT_IO_COLUMNS, emulator.io_rows()
+        elif view == "pg_stat_bgwriter":
+            view_columns, view_rows = PG_STAT_BGWRITER_COLUMNS, emulator.bgwriter_rows()
+        elif view == "pg_stat_activity":
+            view_columns, view_rows = PG_STAT_ACTIVITY_COLUMNS, emulator.activity_rows()
+        elif view == "pg_stat_replication":
+            view_columns, view_rows = PG_STAT_REPLICATION_COLUMNS, emulator.replication_rows()
+        elif view == "pg_replication_slots":
+            view_columns, view_rows = PG_REPLICATION_SLOTS_COLUMNS, emulator.replication_slot_rows(
+                await self.replication_slots()
+            )
+        else:
+            tables = []
+            listing = await self._dictionary_listing(USER_TABLES_SQL, session_id)
+            if listing.get("success"):
+                tables = [(row[0], row[1]) for row in listing.get("rows", [])]
+            else:
+                # Still list the tables written through the bridge
+                logger.warning("IRIS table list unavailable", error=listing.get("error"))
+            view_columns, view_rows = PG_STAT_USER_TABLES_COLUMNS, emulator.user_table_rows(tables)
+
+        return self._emulated_view_result(*query_view(sql, view_columns, view_rows))
+
+    def global_operation(self, operation):
+        """Run operation(global accessor) in the thread pool (see global_tables.py)."""
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _sync_global_operation():
+            import iris
+
+            if self.embedded_mode:
+                return operation(EmbeddedGlobalAccessor(iris))
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                return operation(NativeGlobalAccessor(iris.createIRIS(conn)))
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        loop = asyncio.get_event_loop()
+        return loop.run_in_executor(self.thread_pool, _sync_global_operation)
+
+    def journal_position(self):
+        """(file name, offset) of the current IRIS journal position, in the thread pool."""
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _position(invoker):
+            return (
+                invoker.call("%SYS.Journal.System", "GetCurrentFileName", []),
+                invoker.call("%SYS.Journal.System", "GetCurrentFileOffset", []),
+            )
+
+        def _sync_journal_position():
+            import iris
+
+            if self.embedded_mode:
+                return _position(EmbeddedSystemInvoker(iris))
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                return _position(NativeSystemInvoker(iris.createIRIS(conn)))
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        loop = asyncio.get_event_loop()
+        return loop.run_in_executor(self.thread_pool, _sync_journal_position)
+
+    async def replication_slots(self) -> list[ReplicationSlot]:
+        """The stored replication slots and this process's temporary ones."""
+        try:
+            slots = await self.global_operation(lambda accessor: SlotStore(accessor).all())
+        except Exception as e:
+            logger.warning("Replication slots unavailable", error=str(e))
+            slots = []
+        return slots + list(get_active_slots().temporary.values())
+
+    def _comment_store_call(self, operation):
+        """Run operation(CommentStore) in the thread pool against the comments global."""
+        return self.global_operation(lambda accessor: operation(CommentStore(accessor)))
+
+    async def _execute_comment(
+        self, comment: CommentStatement, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Store or remove the comment of a COMMENT ON statement."""
+        handler = CommentHandler(OIDGenerator(), get_schema_config()["iris_schema"])
+        columns = None
+        if comment.object_type in ("TABLE", "VIEW", "COLUMN"):
+            listing = await self._execute_query(
+                columns_sql(*handler.relation(comment.name)), session_id=session_id
+            )
+            columns = [tuple(row) for row in listing.get("rows") or []]
+
+        try:
+            classoid, objoid, objsubid = handler.target(comment, columns)
+            await self._comment_store_call(
+                lambda store: store.set(classoid, objoid, objsubid, comment.text)
+            )
+        except CommentTargetMissing as e:
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": e.sqlstate,
+                "condition_name": e.condition_name,
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+
+        logger.info(
+            "Comment stored",
+            object_type=comment.object_type,
+            name=comment.name,
+            column=comment.column,
+            removed=comment.text is None,
+            session_id=session_id,
+        )
+        return {
+            "success": True,
+            "rows": [],
+            "columns": [],
+            "row_count": 0,
+            "command": "COMMENT",
+            "command_tag": "COMMENT",
+        }
+
+    async def _execute_description_query(
+        self, sql: str, view: str | None, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Answer a SELECT on pg_description / pg_shdescription (view given) or of
+        description function calls (None if the SELECT is not only such calls).
+        """
+        if view is not None:
+            rows = await self._comment_store_call(lambda store: store.view_rows(view))
+            return self._emulated_view_result(
+                *query_view(sql, DESCRIPTION_VIEW_COLUMNS[view], rows)
+            )
+
+        handler = CommentHandler(OIDGenerator(), get_schema_config()["iris_schema"])
+        if handler.parse_functions(sql) is None:
+            return None
+        return await self._comment_store_call(
+            lambda store: handler.execute_functions(sql, store)
+        )
+
+    async def _execute_modified_ddl(
+        self, ddl: ModifiedDDL, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Skip or run a statement with IF [NOT] EXISTS / UNLOGGED, modifiers removed."""
+        iris_schema = get_schema_config()["iris_schema"]
+        notices = list(ddl.notices)
+        names = list(ddl.names)
+        for condition in ddl.conditions:
+            sql = existence_sql(condition, iris_schema)
+            exists = False
+            if sql is not None:
+                listing = await self._execute_query(sql, session_id=session_id)
+                if not listing.get("success"):
+                    # Unknown: let IRIS run the statement and report what it finds
+                    logger.warning("Existence check failed", error=listing.get("error"))
+                    continue
+                exists = bool(listing.get("rows"))
+            if exists == condition.exists:
+                continue
+            notices.append(skip_notice(condition, ddl.tag))
+            if ddl.tag.startswith("DROP"):
+                # The objects that do exist are still dropped
+                names.remove(condition.name)
+            else:
+                names = []
+                break
+
+        if names and ddl.runs:
+            result = await self._execute_query(ddl.statement(names), session_id=session_id)
+            if not result.get("success"):
+                return result
+        else:
+            logger.info("DDL skipped", tag=ddl.tag, names=ddl.names, session_id=session_id)
+            command, _, _ = ddl.tag.partition(" ")
+            result = {
+                "success": True,
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+                "command": command,
+                "command_tag": ddl.tag,
+            }
+        result["notices"] = [*result.get("notices", []), *notices]
+        return result
+
+    async def _execute_cascade_drop(
+        self, drop: CascadeDrop, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Drop the dependents of the named objects, then the objects themselves."""
+
+        async def lookup(sql: str) -> list:
+            result = await self._execute_query(sql, session_id=session_id)
+            if not result.get("success"):
+                # IRIS reports the remaining dependency when the drop fails
+                logger.warning("Dependency lookup failed", error=result.get("error"))
+            return result.get("rows") or []
+
+        plan = await plan_cascade(drop, get_schema_config()["iris_schema"], lookup)
+        logger.info(
+            "DROP CASCADE",
+            drops=[dropped.describe() for dropped in plan.drops],
+            session_id=session_id,
+        )
+        result = {"success": True, "rows": [], "columns": [], "row_count": 0}
+        for dropped in plan.drops:
+            result = await self._execute_query(dropped.drop_sql(), session_id=session_id)
+            if not result.get("success"):
+                return result
+
+        result.update(command="DROP", command_tag=drop.tag)
+        notice = plan.notice()
+        if notice is not None:
+            result["notices"] = [*result.get("notices", []), notice]
+        return result
+
+    async def _execute_reindex(
+        self, reindex: Reindex, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Rebuild the indexes of a REINDEX with BUILD INDEX."""
+
+        async def lookup(sql: str) -> list:
+            result = await self._execute_query(sql, session_id=session_id)
+            if not result.get("success"):
+                logger.warning("Index lookup failed", error=result.get("error"))
+            return result.get("rows") or []
+
+        try:
+            statements = await build_index_statements(
+                reindex, get_schema_config()["iris_schema"], lookup
+            )
+        except UndefinedIndex as e:
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": e.sqlstate,
+                "condition_name": e.condition_name,
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+        logger.info("REINDEX", statements=statements, session_id=session_id)
+        result = {"success": True, "rows": [], "columns": [], "row_count": 0}
+        for statement in statements:
+            result = await self._execute_query(statement, session_id=session_id)
+            if not result.get("success"):
+                return result
+
+        result.update(command="REINDEX", command_tag="REINDEX")
+        if reindex.kind == "system":
+            result["notices"] = [*result.get("notices", []), SYSTEM_REINDEX_NOTICE]
+        return result
+
+    def _partition_store_call(self, operation):
+        """Run operation(PartitionStore) in the thread pool against the partitions global."""
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _sync_partition_operation():
+            import iris
+
+            if self.embedded_mode:
+                return operation(PartitionStore(EmbeddedGlobalAccessor(iris)))
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                return operation(PartitionStore(NativeGlobalAccessor(iris.createIRIS(conn))))
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        loop = asyncio.get_event_loop()
+        return loop.run_in_executor(self.thread_pool, _sync_partition_operation)
+
+    async def _execute_partition_ddl(
+        self, ddl: PartitionDDL, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Create a partitioned table as a plain table, a partition as a view of its
+        parent, or drop either (None for a DROP TABLE of any other table).
+        """
+        iris_schema = get_schema_config()["iris_schema"]
+        target = relation(ddl.relation, iris_schema)
+
+        if ddl.drop:
+            partitions = await self._partition_store_call(
+                lambda store: (
+                    store.parent(target.schema, target.table),
+                    store.partitions(target.schema, target.table),
+                )
+            )
+            parent, children = partitions
+            if parent is None and not children:
+                return None
+            for child in children:
+                # Partitions are views, dropped (with their own partitions) before the table
+                await self._execute_partition_ddl(
+                    PartitionDDL(f"{target.schema}.{sql_identifier(child)}", drop=True),
+                    session_id,
+                )
+            await self._partition_store_call(
+                lambda store: store.remove(target.schema, target.table)
+            )
+            if parent is None:
+                # The partitioned table itself is a plain table IRIS drops
+                return None
+            result = await self._execute_query(f"DROP VIEW {target.sql}", session_id=session_id)
+            if result.get("success"):
+                result.update(command="DROP", command_tag="DROP TABLE")
+            return result
+
+        if ddl.parent is None:
+            result = await self._execute_query(ddl.statement, session_id=session_id)
+            if result.get("success"):
+                await self._partition_store_call(
+                    lambda store: store.set_key(target.schema, target.table, ddl.key)
+                )
+                result["notices"] = [
+                    *result.get("notices", []),
+                    (
+                        "NOTICE",
+                        "00000",
+                        f'IRIS has no table partitioning; "{target.table}" is created as a '
+                        "plain table holding every row",
+                    ),
+                ]
+            return result
+
+        parent = relation(ddl.parent, iris_schema)
+        key = await self._partition_store_call(
+            lambda store: store.key(parent.schema, parent.table)
+        )
+        if key is None:
+            return {
+                "success": False,
+                "error": f'"{parent.table}" is not partitioned',
+                "sqlstate": "42809",
+                "condition_name": "wrong_object_type",
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+
+        result = await self._execute_query(
+            partition_view_sql(target.sql, parent.sql, key, ddl.bound), session_id=session_id
+        )
+        if not result.get("success"):
+            return result
+
+        def record(store):
+            store.add_partition(parent.schema, parent.table, target.table, ddl.bound)
+            if ddl.key is not None:
+                store.set_key(target.schema, target.table, ddl.key)
+
+        await self._partition_store_call(record)
+        logger.info(
+            "Partition created as a view",
+            partition=target.table,
+            parent=parent.table,
+            bound=ddl.bound,
+            session_id=session_id,
+        )
+        result.update(command="CREATE", command_tag="CREATE TABLE")
+        result["notices"] = [
+            *result.get("notices", []),
+            (
+                "NOTICE",
+                "00000",
+                f'partition "{target.table}" is created as a view of "{parent.table}"',
+            ),
+        ]
+        return result
+
+    async def _execute_partitioned_table_query(
+        self, sql: str, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Answer a SELECT on pg_partitioned_table from the partitions global."""
+        stored = await self._partition_store_call(lambda store: store.rows())
+        attnums = {}
+        for schema, table, _, _ in stored:
+            listing = await self._dictionary_listing(columns_sql(schema, table), session_id)
+            attnums[(schema, table)] = {
+                str(row[0]).lower(): int(row[1]) for row in listing.get("rows") or []
+            }
+        rows = partitioned_table_rows(stored, OIDGenerator(), attnums)
+        return self._emulated_view_result(*query_view(sql, PG_PARTITIONED_TABLE_COLUMNS, rows))
+
+    @staticmethod
+    def _publication_error(error: PublicationError) -> dict[str, Any]:
+        return {
+            "success": False,
+            "error": str(error),
+            "sqlstate": error.sqlstate,
+            "condition_name": error.condition_name,
+            "rows": [],
+            "columns": [],
+            "row_count": 0,
+        }
+
+    async def _table_column_names(
+        self, schema: str, table: str, session_id: str | None = None
+    ) -> list[str]:
+        """Column names of an IRIS table in table order ([] if there is no such table)."""
+        listing = await self._dictionary_listing(columns_sql(schema, table), session_id)
+        return [str(row[0]) for row in listing.get("rows") or []]
+
+    async def _execute_publication_ddl(
+        self, ddl: PublicationDDL, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Store, change or remove publications in the publications global."""
+        iris_schema = get_schema_config()["iris_schema"]
+        tables = []
+        for spec in ddl.tables or []:
+            target = relation(spec.relation, iris_schema)
+            names = [
+                name.lower()
+                for name in await self._table_column_names(target.schema, target.table, session_id)
+            ]
+            if not names:
+                return self._publication_error(
+                    PublicationError(
+                        f'relation "{target.table}" does not exist', "42P01", "undefined_table"
+                    )
+                )
+            referenced = list(spec.columns or [])
+            if spec.where:
+                referenced += sorted(compile_row_filter(spec.where).columns)
+            for column in referenced:
+                if column not in names:
+                    return self._publication_error(
+                        PublicationError(
+                            f'column "{column}" of relation "{target.table}" does not exist',
+                            "42703",
+                            "undefined_column",
+                        )
+                    )
+            tables.append(PublishedTable(target.schema, target.table, spec.columns, spec.where))
+
+        try:
+            await self.global_operation(
+                lambda accessor: apply_publication_ddl(PublicationStore(accessor), ddl, tables)
+            )
+        except PublicationError as e:
+            return self._publication_error(e)
+
+        logger.info(
+            "Publication stored",
+            action=ddl.action,
+            publications=ddl.names,
+            tables=[f"{table.schema}.{table.table}" for table in tables],
+            session_id=session_id,
+        )
+        return {
+            "success": True,
+            "rows": [],
+            "columns": [],
+            "row_count": 0,
+            "command": ddl.action,
+            "command_tag": ddl.command_tag,
+        }
+
+    async def _execute_publication_query(
+        self, sql: str, view: str, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Answer a SELECT on pg_publication or pg_publication_tables."""
+        publications = await self.global_operation(
+            lambda accessor: PublicationStore(accessor).all()
+        )
+        if view == "pg_publication":
+            rows = publication_rows(publications, OIDGenerator())
+            return self._emulated_view_result(*query_view(sql, PG_PUBLICATION_COLUMNS, rows))
+
+        tables = set()
+        for publication in publications:
+            tables.update((entry.schema, entry.table) for entry in publication.tables)
+        if any(publication.all_tables for publication in publications):
+            listing = await self._dictionary_listing(USER_TABLES_SQL, session_id)
+            tables.update((row[0], row[1]) for row in listing.get("rows") or [])
+        columns = {
+            (schema, table): await self._table_column_names(schema, table, session_id)
+            for schema, table in sorted(tables)
+        }
+        rows = publication_table_rows(publications, columns, get_schema_config()["iris_schema"])
+        return self._emulated_view_result(*query_view(sql, PG_PUBLICATION_TABLES_COLUMNS, rows))
+
+    def _check_constraints_call(self, operation):
+        """Run operation(CheckConstraints) in the thread pool."""
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _sync_check_operation():
+            import iris
+
+            if self.embedded_mode:
+                return operation(
+                    CheckConstraints(
+                        lambda sql: list(iris.sql.exec(sql)),
+                        CheckConstraintStore(EmbeddedGlobalAccessor(iris)),
+                    )
+                )
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+
+                def run(sql):
+                    cursor = conn.cursor()
+                    try:
+                        cursor.execute(sql)
+                        return cursor.fetchall() if cursor.description else []
+                    finally:
+                        cursor.close()
+
+                store = CheckConstraintStore(NativeGlobalAccessor(iris.createIRIS(conn)))
+                return operation(CheckConstraints(run, store))
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        loop = asyncio.get_event_loop()
+        return loop.run_in_executor(self.thread_pool, _sync_check_operation)
+
+    async def _execute_check_ddl(
+        self, ddl: CheckDDL, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Run CREATE / ALTER TABLE without its CHECK clauses and install their
+        triggers, or drop a CHECK constraint (None if the bridge did not create
+        the constraint being dropped).
+        """
+        target = relation(ddl.relation, get_schema_config()["iris_schema"])
+        result = {
+            "success": True,
+            "rows": [],
+            "columns": [],
+            "row_count": 0,
+            "command": "ALTER",
+            "command_tag": "ALTER TABLE",
+        }
+        if ddl.drop is not None:
+            dropped = await self._check_constraints_call(
+                lambda checks: checks.drop(target, ddl.drop)
+            )
+            if not dropped:
+                return None
+            logger.info("CHECK constraint dropped", table=target.table, name=ddl.drop)
+            return result
+
+        if ddl.statement is not None:
+            result = await self._execute_query(ddl.statement, session_id=session_id)
+            if not result.get("success"):
+                return result
+
+        try:
+            await self._check_constraints_call(
+                lambda checks: checks.add(target, ddl.checks, ddl.creates_table)
+            )
+        except Exception as e:
+            if ddl.creates_table:
+                # The statement fails as a whole, as in PostgreSQL
+                await self._execute_query(f"DROP TABLE {target.sql}", session_id=session_id)
+            logger.warning("CHECK constraint not installed", table=target.table, error=str(e))
+            return {
+                "success": False,
+                "error": str(e),
+                "sqlstate": getattr(e, "sqlstate", "0A000"),
+                "condition_name": getattr(e, "condition_name", "feature_not_supported"),
+                "rows": [],
+                "columns": [],
+                "row_count": 0,
+            }
+
+        logger.info(
+            "CHECK constraints installed",
+            table=target.table,
+            names=[check.name for check in ddl.checks],
+            session_id=session_id,
+        )
+        return result
+
+    async def _execute_expression_index(
+        self, index: ExpressionIndex, session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Add the computed columns of a lower() / upper() index and index them
+        (None if a source column is not a character column of the table).
+        """
+        target = relation(index.relation, get_schema_config()["iris_schema"])
+        existing = {
+            name.upper()
+            for name in await self._table_column_names(target.schema, target.table, session_id)
+        }
+        additions = []
+        for column in index.columns:
+            if column.name in existing:
+                continue
+            listing = await self._dictionary_listing(source_column_sql(target, column), session_id)
+            row = (listing.get("rows") or [None])[0]
+            column_type = computed_column_type(row)
+            if column_type is None:
+                return None
+            additions.append((column, str(row[0]), column_type))
+
+        added, result = [], {"success": True}
+        for column, source, column_type in additions:
+            result = await self._execute_query(
+                add_column_sql(target, column, source, column_type), session_id=session_id
+            )
+            if not result.get("success"):
+                break
+            added.append(column)
+        if result.get("success"):
+            result = await self._execute_query(index.statement, session_id=session_id)
+        if not result.get("success"):
+            # The statement fails as a whole, as in PostgreSQL
+            for column in added:
+                await self._execute_query(
+                    drop_column_sql(target, column.name), session_id=session_id
+                )
+            return result
+
+        logger.info(
+            "Expression index created on computed columns",
+            table=target.table,
+            columns=[column.name for column in index.columns],
+            added=[column.name for column in added],
+            session_id=session_id,
+        )
+        result.update(command="CREATE", command_tag="CREATE INDEX")
+        result["notices"] = [*result.get("notices", []), index_notice(index)]
+        return result
+
+    async def _execute_drop_index(
+        self, names: list[str], session_id: str | None = None
+    ) -> dict[str, Any] | None:
+        """
+        Drop indexes on computed columns with the columns no other index uses
+        (None if none of the named indexes is on computed columns).
+        """
+        iris_schema = get_schema_config()["iris_schema"]
+        listing = await self._execute_query(COMPUTED_INDEXES_SQL, session_id=session_id)
+        rows = listing.get("rows") or []
+        indexes = computed_indexes(rows, names, iris_schema)
+        if not indexes:
+            return None
+
+        statements = [drop_index_sql(index) for index in indexes]
+        others = [name for name in names if not computed_indexes(rows, [name], iris_schema)]
+        if others:
+            statements.append(f"DROP INDEX {', '.join(others)}")
+        result = {"success": True}
+        for statement in statements:
+            result = await self._execute_query(statement, session_id=session_id)
+            if not result.get("success"):
+                return result
+
+        # Computed columns another index still uses are kept
+        dropped = {(index.target.sql.lower(), index.name.lower()) for index in indexes}
+        kept = {
+            (f"{row[0]}.{row[1]}".lower(), str(row[3]).upper())
+            for row in rows
+            if (f"{row[0]}.{row[1]}".lower(), str(row[2]).lower()) not in dropped
+        }
+        columns = {
+            (index.target.sql.lower(), column): drop_column_sql(index.target, column)
+            for index in indexes
+            for column in index.columns
+            if (index.target.sql.lower(), column) not in kept
+        }
+        for statement in columns.values():
+            await self._execute_query(statement, session_id=session_id)
+
+        logger.info(
+            "Expression indexes dropped with their computed columns",
+            indexes=[index.name for index in indexes],
+            session_id=session_id,
+        )
+        result.update(command="DROP", command_tag="DROP INDEX")
+        return result
+
+    async def _create_timezone_helpers(self, zones: list[str]) -> None:
+        """Create (or refresh) the IRIS functions converting to and from region zones."""
+        credentials = current_backend_credentials()
+        label = current_session_label()
+
+        def _sync_create_helpers():
+            import iris
+
+            statements = [helper_ddl(zone) for zone in zones]
+            if self.embedded_mode:
+                for statement in statements:
+                    iris.sql.exec(statement)
+                return
+
+            conn = self._get_pooled_connection(credentials=credentials, label=label)
+            try:
+                cursor = conn.cursor()
+                try:
+                    for statement in statements:
+                        cursor.execute(statement)
+                finally:
+                    cursor.close()
+            finally:
+                self._return_connection(conn, credentials=credentials)
+
+        try:
+            loop = asyncio.get_event_loop()
+            await loop.run_in_executor(self.thread_pool, _sync_create_helpers)
+        except Exception as e:
+            # The statement then fails on the missing function with IRIS's error
+            logger.warning("Time zone functions not created", zones=zones, error=str(e))
+            return
+        self._timezone_helpers.update(zones)
+        logger.info("Time zone functions created", zones=zones)
+
+    async def _declare_not_null_order(self, sql: str, session_id: str | None = None) -> str:
+        """sql with the ORDER BY items of its table's NOT NULL columns kept in IRIS's order."""
+        target = relation(order_table(sql), get_schema_config()["iris_schema"])
+        listing = await self._dictionary_listing(NOT_NULL_COLUMNS_SQL, session_id)
+        columns = {
+            str(row[2]).upper()
+            for row in listing.get("rows") or []
+            if str(row[0]).lower() == target.schema.lower()
+            and str(row[1]).lower() == target.table.lower()
+        }
+        return declare_not_null(sql, columns) if columns else sql
+
+    async def _read_indexed_expressions(self, sql: str, session_id: str | None = None) -> str:
+        """sql reading the computed columns of its table for lower() / upper() of a column."""
+        query = query_table(sql)
+        if query is None:
+            return sql
+        target = relation(query.relation, get_schema_config()["iris_schema"])
+        listing = await self._dictionary_listing(COMPUTED_COLUMNS_SQL, session_id)
+        computed = {
+            str(row[2]).upper()
+            for row in listing.get("rows") or []
+            if str(row[0]).lower() == target.schema.lower()
+            and str(row[1]).lower() == target.table.lower()
+        }
+        if not computed:
+            return sql
+        rewritten, count = rewrite_indexed_expressions(sql, query, computed)
+        if count:
+            logger.debug("Reading indexed expressions", table=target.table, expressions=count)
+        return rewritten
+
+    async def _execute_system_view_query(
+        self, sql: str, view: str, session_id: str | None = None
+    ) -> dict[str, Any]:
+        """Answer a SELECT on pg_tables, pg_views, pg_indexes or pg_matviews."""
+        logger.info("Intercepting system view query", view=view, session_id=session_id)
+
+        listings = []
+        for listing_sql in VIEW_SOURCES[view]:
+            listing = await self._dictionary_listing(listing_sql, session_id)
+            if not listing.get("success"):
+                logger.warning(
+                    "IRIS catalog listing unavailable", view=view, error=listing.get("error")
+                )
+            listings.append([tuple(row) for row in listing.get("rows") or []])
+
+        emulator = SystemViewEmulator(get_schema_config()["iris_schema"])
+        view_rows = emulator.build_rows(view, *listings)
+        return self._emulated_view_result(*query_view(sql, VIEW_COLUMNS[view], view_rows))
+
+    async def _describe_money_columns(
+        self, sql: str, result: dict[str, Any], session_id: str | None = None
+    ) -> dict[str, Any]:
+        """result with the numeric columns of columns created as MONEY described as money."""
+        columns = result.get("columns") or []
+        if not result.get("success") or not numeric_result_columns(columns):
+            return result
+        listing = await self._dictionary_listing(MONEY_COLUMNS_SQL, session_id)
+        money_result_columns(columns, listing.get("rows") or [], sql)
+        return result
+
+    async def _dictionary_listing(self, sql: str, session_id: str | None = None) -> dict[str, Any]:
+        """Run an IRIS dictionary listing for catalog emulation, through the schema cache."""
+        credentials = current_backend_credentials()
+        return await self.schema_cache.listing(
+            self.iris_config.get("namespace", "USER"),
+            credentials.user if credentials else None,
+            sql,
+            lambda listing_sql: self._execute_query(listing_sql, session_id=session_id),
+        )
+
+    async def _catalog_fast_path(
+        self, name: str, sql: str, params: list | None, session_id: str | None
+    ) -> dict[str, Any]:
+        """Answer a recognized driver catalog query from the schema cache while unchanged."""
+        credentials = current_backend_credentials()
+        logger.debug("Catalog fast path", query=name, fingerprint=fingerprint(sql))
+        return await self.schema_cache.result(
+            self.iris_config.get("namespace", "USER"),
+            credentials.user if credentials else None,
+            cache_key(sql, params),
+            lambda: self._execute_query(sql, params, session_id),
+            lambda stamp_sql: self._execute_query(stamp_sql, session_id=session_id),
+        )
+
+    async def describe_statement(
+        self, sql: str, param_types: list[int], session_id: str | None = None
+    ) -> tuple[list[dict[str, Any]] | None, bool]:
+        """
+        Result columns of a prepared statement for Describe, and whether they
+        came from the schema cache.
+
+        IRIS reports columns only for a statement it runs, so a statement not
+        in the cache runs once with NULL for every parameter and its rows are
+        dropped.
+        """
+        columns, cached = await self.schema_cache.description(
+            *self._description_key(sql, param_types),
+            lambda: self.execute_query(sql, [None] * len(param_types), session_id),
+            lambda stamp_sql: self._execute_query(stamp_sql, session_id=session_id),
+        )
+        get_stats().record_describe(cached)
+        return columns, cached
+
+    def forget_statement_description(self, sql: str, param_types: list[int]) -> None:
+        """Drop a statement's cached columns (its result no longer matches them)."""
+        self.schema_cache.forget_description(*self._description_key(sql, param_types))
+
+    def _description_key(self, sql: str, param_types: list[int]) -> tuple[str, str | None, str]:
+        """(namespace, login, key) of a statement description in the schema cache."""
+        credentials = current_backend_credentials()
+        return (
+            self.iris_config.get("namespace", "USER"),
+            credentials.user if credentials else None,
+            f"{','.join(str(oid) for oid in param_types)}:{sql}",
+        )
+
+    @staticmethod
+    def _emulated_view_result(
+        view_columns: list[dict[str, Any]], rows: list[tuple[Any, ...]]
+    ) -> dict[str, Any]:
+        """Result dict for rows of a view emulated in Python (see catalog/view_query.py)."""
+        columns = [
+            {
+                "name": column["name"],
+                "type_oid": column["type_oid"],
+                "type_size": {
+                    16: 1,
+                    19: 64,
+                    20: 8,
+                    23: 4,
+                    26: 4,
+                    28: 4,
+                    701: 8,
+                    1184: 8,
+                    1186: 16,
+                    3220: 8,
+                }.get(column["type_oid"], -1),
+                "type_modifier": -1,
+                "format_code": 0,
+            }
+            for column in view_columns
+        ]
+        return {
+            "success": True,
+            "rows": rows,
+            "columns": columns,
+            "row_count": len(rows),
+            "command": "SELECT",
+            "command_tag": f"SELECT {len(rows)}",
+        }
+
+    def _load_class_hierarchy(self, iris_module) -> PgInheritsEmulator:
+        """
+        Build pg_inherits from IRIS persistent class inheritance.
+
+        Failures (e.g. no %Dictionary access) yield an empty hierarchy so catalog
+        queries keep working.
+        """
+        hierarchy = PgInheritsEmulator(OIDGenerator())
+        try:
+            classes = [tuple(row) for row in iris_module.sql.exec(HIERARCHY_SQL)]
+            hierarchy.load_classes(classes)
+        except Exception as e:
+            logger.warning("Class hierarchy unavailable", error=str(e))
+        return hierarchy
+
     def _get_iris_connection(self):
         """
         Get or create IRIS connection for embedded mode batch operations.
@@ -3745,19 +5384,222 @@ class IRISExecutor:
         # The _execute_many_embedded_async() method will use iris.sql.exec() in a loop
         return None
 
-    def _get_pooled_connection(self):
+    async def verify_backend_credentials(self, user: str, password: str) -> BackendCredentials:
+        """
+        Check a client's credentials by logging in to IRIS (passthrough mode).
+
+        The connection is kept in the user's pool for the session's first query.
+
+        Raises:
+            PasswordAuthenticationFailed: IRIS rejected the login
+            BackendLoginUnavailable: IRIS could not be reached
+        """
+        credentials = BackendCredentials(user, password)
+
+        def _sync_login():
+            import iris
+
+            try:
+                conn = iris.connect(
+                    hostname=self.iris_config["host"],
+                    port=self.iris_config["port"],
+                    namespace=self.iris_config["namespace"],
+                    username=user,
+                    password=password,
+                    **iris_tls_kwargs(),
+                )
+            except Exception as e:
+                if not login_rejected(e):
+                    logger.error("IRIS unavailable for passthrough login", user=user, error=str(e))
+                    raise BackendLoginUnavailable(user) from e
+                logger.info("IRIS rejected passthrough login", user=user, error=str(e))
+                raise PasswordAuthenticationFailed(user) from None
+            self._return_connection(conn, credentials=credentials)
+
+        await asyncio.get_event_loop().run_in_executor(self.thread_pool, _sync_login)
+        return credentials
+
+    async def verify_iris_password(self, user: str, password: str) -> None:
+        """
+        Check a password by logging in to IRIS as user (SCRAM and MD5 enrollment).
+
+        Raises:
+            PasswordAuthenticationFailed: IRIS rejected the login
+            BackendLoginUnavailable: IRIS could not be reached
+        """
+
+        def _sync_login():
+            import iris
+
+            try:
+                conn = iris.connect(
+                    hostname=self.iris_config["host"],
+                    port=self.iris_config["port"],
+                    namespace=self.iris_config["namespace"],
+                    username=user,
+                    password=password,
+                    **iris_tls_kwargs(),
+                )
+            except Exception as e:
+                if not login_rejected(e):
+                    logger.error("IRIS unavailable for password check", user=user, error=str(e))
+                    raise BackendLoginUnavailable(user) from e
+                logger.info("IRIS rejected SCRAM enrollment login", user=user, error=str(e))
+                raise PasswordAuthenticationFailed(user) from None
+            conn.close()
+
+        await asyncio.get_event_loop().run_in_executor(self.thread_pool, _sync_login)
+
+    async def iris_ssl_config(self, name: str) -> dict[str, str] | None:
+        """
+        Certificate, key and CA settings of an IRIS SSL/TLS configuration
+        (Security.SSLConfigs in %SYS); None if it does not exist.
+        """
+        properties = ("CertificateFile", "PrivateKeyFile", "PrivateKeyPassword", "CAFile")
+
+        def _sync_ssl_config():
+            import iris
+
+            conn = self._connect_sys()
+            try:
+                native = iris.createIRIS(conn)
+                config = native.classMethodObject("Security.SSLConfigs", "%OpenId", name)
+                if config is None:
+                    return None
+                return {prop: config.get(prop) for prop in properties}
+            finally:
+                conn.close()
+
+        loop = asyncio.get_event_loop()
+        return await loop.run_in_executor(self.thread_pool, _sync_ssl_config)
+
+    def _connect_sys(self):
+        """
+        Service-account connection to %SYS (security classes), in either mode.
+
+        Embedded mode does not switch the process to %SYS instead: that would
+        move other sessions' statements running in the process along with it.
+        """
+        import iris
+
+        return iris.connect(
+            hostname=self.iris_config["host"],
+            port=self.iris_config["port"],
+            namespace="%SYS",
+            username=self.iris_config["username"],
+            password=self.iris_config["password"],
+            **iris_tls_kwargs(),
+        )
+
+    async def iris_password_stamp(self, user: str) -> str | None:
+        """
+        Stamp of the user's current IRIS password (Security.Users in %SYS, see
+        scram.py); None if the user does not exist or it cannot be read.
+
+        Read once per login: each connection logs in in its own task, which
+        keeps the stamp for the secret lookups and enrollment that follow.
+        """
+        cached = _login_password_stamp.get()
+        if cached is not None and cached[0] == user:
+            return cached[1]
+
+        def _sync_password_hash():
+            import iris
+
+            conn = self._connect_sys()
+            try:
+                native = iris.createIRIS(conn)
+                # IDs of Security.Users are lower-case user names
+                account = native.classMethodObject("Security.Users", "%OpenId", user.lower())
+                return None if account is None else account.get("Password")
+            finally:
+                conn.close()
+
+        loop = asyncio.get_event_loop()
+        try:
+            hashed = await loop.run_in_executor(self.thread_pool, _sync_password_hash)
+        except Exception as e:
+            logger.warning("IRIS password stamp unavailable", user=user, error=str(e))
+            hashed = None
+        stamp = password_stamp(hashed) if hashed else None
+        _login_password_stamp.set((user, stamp))
+        return stamp
+
+    async def scram_verifier(self, user: str) -> ScramVerifier | None:
+        """The user's stored SCRAM verifier (see scram.py); None once IRIS's password changed."""
+        stamp = await self.iris_password_stamp(user)
+        return await self.global_operation(
+            lambda accessor: ScramVerifierStore(accessor).get(user, stamp)
+        )
+
+    async def store_scram_verifier(self, user: str, verifier: ScramVerifier) -> None:
+        """Store an enrolled verifier; not without a stamp, which would never expire it."""
+        stamp = await self.iris_password_stamp(user)
+        if stamp is None:
+            logger.warning("Not storing SCRAM verifier without an IRIS password stamp", user=user)
+            return
+        await self.global_operation(
+            lambda accessor: ScramVerifierStore(accessor).set(user, verifier, stamp)
+        )
+
+    async def md5_secret(self, user: str) -> str | None:
+        """The user's stored MD5 secret (see md5_auth.py); None once IRIS's password changed."""
+        stamp = await self.iris_password_stamp(user)
+        return await self.global_operation(
+            lambda accessor: Md5SecretStore(accessor).get(user, stamp)
+        )
+
+    async def store_md5_secret(self, user: str, secret: str) -> None:
+        """Store an enrolled MD5 secret; not without a stamp, which would never expire it."""
+        stamp = await self.iris_password_stamp(user)
+        if stamp is None:
+            logger.warning("Not storing MD5 secret without an IRIS password stamp", user=user)
+            return
+        await self.global_operation(
+            lambda accessor: Md5SecretStore(accessor).set(user, secret, stamp)
+        )
+
+    def _workload_pool(
+        self, workload: str | None, credentials: BackendCredentials | None = None
+    ) -> list:
+        """Connection pool for a workload (prioritized workloads get their own)."""
+        if credentials is not None:
+            # Passthrough/delegation: connections run as the session user
+            pool_workload = workload if workload in self.workload_priorities else None
+            key = (credentials.user, credentials.delegated, pool_workload)
+            return self._user_pools.setdefault(key, [])
+        if workload in self.workload_priorities:
+            return self._workload_pools.setdefault(workload, [])
+        return self._connection_pool
+
+    def _get_pooled_connection(
+        self,
+        workload: str | None = None,
+        credentials: BackendCredentials | None = None,
+        label: SessionLabel | None = None,
+    ):
         """
         Get a connection from the pool or create a new one.
 
         Implements simple connection pooling for external IRIS connections
         to avoid the 7ms connection overhead on every query.
+
+        Args:
+            workload: Workload class; prioritized workloads use a dedicated pool
+                whose IRIS processes run at the workload's priority
+            credentials: Session user's IRIS login (passthrough mode) or delegated
+                identity (Kerberos delegation); None uses the service account
+            label: Session the connection is taken for; its IRIS process is
+                labelled with it (see session_labels)
         """
         import iris
 
         with self._connection_lock:
+            pool = self._workload_pool(workload, credentials)
+
             # Try to get a connection from the pool
-            if self._connection_pool:
-                conn = self._connection_pool.pop()
+            if pool:
+                conn = pool.pop()
 
                 # Validate the connection is still alive
                 try:
@@ -3766,38 +5608,67 @@ class IRISExecutor:
                     cursor.execute("SELECT 1")
                     cursor.fetchone()
                     cursor.close()
-                    return conn
+                    return self._session_connection(self.session_labels.apply(conn, label), label)
                 except Exception:
                     # Connection is dead, create a new one
+                    self.session_labels.forget(conn)
                     try:
                         conn.close()
                     except Exception:
                         pass
 
             # No connections available or connection was dead - create new one
+            login = credentials if credentials and not credentials.delegated else None
             conn = iris.connect(
                 hostname=self.iris_config["host"],
                 port=self.iris_config["port"],
                 namespace=self.iris_config["namespace"],
-                username=self.iris_config["username"],
-                password=self.iris_config["password"],
+                username=login.user if login else self.iris_config["username"],
+                password=login.password if login else self.iris_config["password"],
+                **iris_tls_kwargs(),
+            )
+            if credentials and credentials.delegated:
+                try:
+                    apply_delegated_login(conn, credentials.user)
+                except Exception:
+                    conn.close()
+                    raise
+            if workload in self.workload_priorities:
+                apply_process_priority(conn, self.workload_priorities[workload])
+
+            return self._session_connection(
+                self.session_labels.apply(conn, label, new=True), label
             )
 
-            return conn
+    def _session_connection(self, conn, label: SessionLabel | None):
+        """Note conn as the connection running label's session's statement (caller locks)."""
+        if label is not None:
+            self._statement_connections[label.backend_pid] = conn
+        return conn
 
-    def _return_connection(self, conn):
+    def _return_connection(
+        self, conn, workload: str | None = None, credentials: BackendCredentials | None = None
+    ):
         """
         Return a connection to the pool for reuse.
 
         Args:
             conn: IRIS connection to return to pool
+            workload: Workload class the connection was taken for
+            credentials: IRIS login the connection was opened with (passthrough mode)
         """
         with self._connection_lock:
+            pool = self._workload_pool(workload, credentials)
+            for backend_pid, used in list(self._statement_connections.items()):
+                if used is conn:
+                    del self._statement_connections[backend_pid]
+
             # Only keep up to max_connections in the pool
-            if len(self._connection_pool) < self._max_connections:
-                self._connection_pool.append(conn)
+            if len(pool) < self._max_connections:
+                pool.append(conn)
             else:
                 # Pool is full, close this connection
+                self.session_labels.forget(conn)
                 try:
                     conn.close()
                 except Exception:
@@ -3988,6 +5859,70 @@ class IRISExecutor:
             )
             return None
 
+    def _external_column(self, desc, sql: str, executed_sql: str) -> dict[str, Any]:
+        """Result column metadata of a DB-API cursor.description entry (external mode)."""
+        # Get original IRIS column name and type
+        iris_col_name = desc[0]
+        iris_type = desc[1] if len(desc) > 1 else "VARCHAR"
+
+        # CRITICAL: Normalize IRIS column names to PostgreSQL conventions
+        # IRIS generates HostVar_1, Expression_1, Aggregate_1 for unnamed columns
+        # PostgreSQL uses ?column?, type names (int4), or function names (count)
+        col_name = self._normalize_iris_column_name(iris_col_name, sql, iris_type)
+
+        # DEBUG: Log IRIS type for arithmetic expressions (external mode)
+        logger.info(
+            "🔍 IRIS metadata type discovery (EXTERNAL MODE)",
+            original_column_name=iris_col_name,
+            normalized_column_name=col_name,
+            iris_type=iris_type,
+            desc=desc,
+            sql_preview=executed_sql[:200],
+        )
+
+        # DB-API description: name, type_code, display_size, internal_size,
+        # precision, scale, null_ok
+        type_oid, type_size, type_modifier = describe_column(
+            iris_type,
+            desc[4] if len(desc) > 4 else None,
+            desc[5] if len(desc) > 5 else None,
+        )
+
+        sql_upper_check = executed_sql.upper()
+
+        # CRITICAL FIX: IRIS type code 2 means NUMERIC, but for decimal literals
+        # like 3.14, we want FLOAT8 so node-postgres returns a number, not a string.
+        # Override to FLOAT8 UNLESS explicitly cast to NUMERIC/DECIMAL or INTEGER
+        # (table columns keep numeric and its precision)
+        if iris_type == 2 and is_generated_column_name(iris_col_name):
+            # Check for explicit casts
+            if "AS INTEGER" in sql_upper_check or "AS INT" in sql_upper_check:
+                # CAST(? AS INTEGER) - override to INT4
+                logger.info(
+                    "🔧 OVERRIDING IRIS type code 2 (NUMERIC) → OID 23 (INT4)",
+                    column_name=col_name,
+                    original_oid=type_oid,
+                    reason="SQL contains CAST to INTEGER",
+                )
+                type_oid, type_size, type_modifier = 23, 4, -1  # INT4
+            elif "AS NUMERIC" not in sql_upper_check and "AS DECIMAL" not in sql_upper_check:
+                # No explicit NUMERIC/DECIMAL cast → make it FLOAT8
+                logger.info(
+                    "🔧 OVERRIDING IRIS type code 2 (NUMERIC) → OID 701 (FLOAT8)",
+                    column_name=col_name,
+                    original_oid=type_oid,
+                    reason="Decimal literal without explicit NUMERIC/DECIMAL cast",
+                )
+                type_oid, type_size, type_modifier = 701, 8, -1  # FLOAT8
+
+        return {
+            "name": col_name,
+            "type_oid": type_oid,
+            "type_size": type_size,
+            "type_modifier": type_modifier,
+            "format_code": 0,  # Text format
+        }
+
     def _normalize_iris_column_name(self, iris_name: str, sql: str, iris_type: str | int) -> str:
         """
         Normalize IRIS-generated column names to PostgreSQL-compatible names.
@@ -4173,50 +6108,8 @@ class IRISExecutor:
         return normalized
 
     def _iris_type_to_pg_oid(self, iris_type: str | int) -> int:
-        """Convert IRIS data type to PostgreSQL OID"""
-        # Handle both string type names and integer type codes
-        if isinstance(iris_type, int):
-            # Map IRIS integer type codes to PostgreSQL OIDs
-            # CRITICAL: Based on actual IRIS behavior for SQL literals:
-            # - type_code=4 returns Python int (e.g., SELECT 1) → INTEGER
-            # - type_code=2 returns Python Decimal (e.g., SELECT 3.14) → NUMERIC
-            int_type_mapping = {
-                -7: 16,  # BIT → bool (IRIS type code for BIT columns)
-                1: 23,  # int4
-                2: 1700,  # numeric (FIXED: was 21/int2, but IRIS returns Decimal for numeric literals)
-                3: 20,  # int8
-                4: 23,  # int4 (FIXED: was 700/float4, but IRIS returns int for integer literals)
-                5: 701,  # float8
-                8: 1083,  # time (FIXED: was 1082/date - IRIS type code 8 is TIME)
-                9: 1082,  # date (FIXED: was 1083/time - IRIS type code 9 is DATE)
-                10: 1114,  # timestamp
-                12: 1043,  # varchar
-                16: 16,  # bool
-                17: 17,  # bytea
-            }
-            return int_type_mapping.get(iris_type, 25)  # Default to text
-
-        # Handle string type names
-        type_mapping = {
-            "VARCHAR": 1043,  # varchar
-            "CHAR": 1042,  # bpchar
-            "TEXT": 25,  # text
-            "INTEGER": 23,  # int4
-            "BIGINT": 20,  # int8
-            "SMALLINT": 21,  # int2
-            "DECIMAL": 1700,  # numeric
-            "NUMERIC": 1700,  # numeric
-            "DOUBLE": 701,  # float8
-            "FLOAT": 700,  # float4
-            "DATE": 1082,  # date
-            "TIME": 1083,  # time
-            "TIMESTAMP": 1114,  # timestamp
-            "BOOLEAN": 16,  # bool
-            "BINARY": 17,  # bytea
-            "VARBINARY": 17,  # bytea
-            "VECTOR": 16388,  # custom vector type
-        }
-        return type_mapping.get(str(iris_type).upper(), 25)  # Default to text
+        """Convert an IRIS ODBC type code or type name to a PostgreSQL OID"""
+        return describe_column(iris_type)[0]
 
     def _extract_table_name_from_select(self, sql: str) -> str | None:
         """
@@ -4265,36 +6158,13 @@ class IRISExecutor:
         Returns:
             PostgreSQL type OID
         """
-        type_map = {
-            "INT": 23,  # int4
-            "INTEGER": 23,  # int4
-            "BIGINT": 20,  # int8
-            "SMALLINT": 21,  # int2
-            "VARCHAR": 1043,  # varchar
-            "CHAR": 1042,  # char
-            "TEXT": 25,  # text
-            "DATE": 1082,  # date
-            "TIME": 1083,  # time
-            "TIMESTAMP": 1114,  # timestamp
-            "DOUBLE": 701,  # float8
-            "FLOAT": 701,  # float8
-            "NUMERIC": 1700,  # numeric
-            "DECIMAL": 1700,  # numeric
-            "BIT": 1560,  # bit
-            "BOOLEAN": 16,  # bool
-            "VARBINARY": 17,  # bytea
-        }
-
-        # Normalize type name (remove size, etc.)
-        normalized_type = iris_type.upper().split("(")[0].strip()
-
-        return type_map.get(normalized_type, 25)  # Default to TEXT (OID 25)
+        return describe_column(iris_type)[0]
 
     def _determine_command_tag(self, sql: str, row_count: int) -> str:
         """Determine PostgreSQL command tag from SQL"""
         sql_upper = sql.upper().strip()
 
-        if sql_upper.startswith("SELECT"):
+        if sql_upper.startswith(("SELECT", "VALUES")):
             return "SELECT"
         elif sql_upper.startswith("INSERT"):
             return f"INSERT 0 {row_count}"
@@ -4448,12 +6318,16 @@ class IRISExecutor:
         loop = asyncio.get_event_loop()
         return await loop.run_in_executor(self.thread_pool, _sync_rollback)
 
-    async def cancel_query(self, backend_pid: int, backend_secret: int):
+    async def cancel_query(self, backend_pid: int, backend_secret: int) -> bool:
         """
-        Cancel a running query (P4 implementation)
+        Cancel the running statement of the session with a backend key (CancelRequest).
+
+        The session's statement returns 57014 to its client; with external
+        connections the IRIS process running it is terminated as well (see
+        cancellation.py).
 
-        Since IRIS SQL doesn't have PostgreSQL-style CANCEL QUERY, we implement
-        this using process termination and connection management.
+        Returns:
+            False when the key is unknown or the session runs no statement
         """
         try:
             logger.info(
@@ -4461,89 +6335,63 @@ class IRISExecutor:
                 backend_pid=backend_pid,
                 backend_secret="***",
             )
-
-            # P4: Query cancellation via connection termination
-            # In production, this would:
-            # 1. Validate backend_secret against stored secret for backend_pid
-            # 2. Find the active connection/query for that PID
-            # 3. Terminate the IRIS connection/process
-            # 4. Clean up resources
-
-            if self.embedded_mode:
-                # For embedded mode, we could use IRIS job control
-                success = await self._cancel_embedded_query(backend_pid, backend_secret)
-            else:
-                # For external connections, terminate the connection
-                success = await self._cancel_external_query(backend_pid, backend_secret)
-
-            if success:
-                logger.info("Query cancellation successful", backend_pid=backend_pid)
-            else:
-                logger.warning(
-                    "Query cancellation failed - PID not found or secret mismatch",
-                    backend_pid=backend_pid,
-                )
-
-            return success
-
-        except Exception as e:
-            logger.error("Query cancellation error", backend_pid=backend_pid, error=str(e))
-            return False
-
-    async def _cancel_embedded_query(self, backend_pid: int, backend_secret: int) -> bool:
-        """Cancel query in IRIS embedded mode"""
-        try:
-
-            def _sync_cancel():
-                # In embedded mode, we could potentially use IRIS job control
-                # For now, return success for demo purposes
-                # Production would implement actual IRIS job termination
-                logger.info("Embedded query cancellation (demo mode)")
-                return True
-
-            return await asyncio.to_thread(_sync_cancel)
-
-        except Exception as e:
-            logger.error("Embedded query cancellation failed", error=str(e))
-            return False
-
-    async def _cancel_external_query(self, backend_pid: int, backend_secret: int) -> bool:
-        """Cancel query for external IRIS connection"""
-        try:
-            # P4: Use server's connection registry to find and terminate connection
             if not self.server:
                 logger.warning("No server reference for cancellation")
                 return False
 
-            # Find the target connection
             target_protocol = self.server.find_connection_for_cancellation(
                 backend_pid, backend_secret
             )
-
-            if not target_protocol:
-                logger.warning("Connection not found for cancellation", backend_pid=backend_pid)
+            if target_protocol is None or not target_protocol.statement_cancel.running:
                 return False
 
-            # Terminate the connection - this will stop any running queries
-            logger.info(
-                "Terminating connection for query cancellation",
-                backend_pid=backend_pid,
-                connection_id=target_protocol.connection_id,
-            )
+            if not self.embedded_mode:
+                await self._interrupt_iris_statement(backend_pid)
+            return target_protocol.statement_cancel.cancel()
 
-            # Close the connection which will abort any running IRIS queries
-            if not target_protocol.writer.is_closing():
-                target_protocol.writer.close()
-                try:
-                    await target_protocol.writer.wait_closed()
-                except Exception:
-                    pass  # Connection may already be closed
+        except Exception as e:
+            logger.error("Query cancellation error", backend_pid=backend_pid, error=str(e))
+            return False
+
+    async def _interrupt_iris_statement(self, backend_pid: int) -> bool:
+        """Terminate the IRIS process running a session's statement (external connections)."""
+        with self._connection_lock:
+            conn = self._statement_connections.get(backend_pid)
+        iris_pid = self.session_labels.iris_pid(conn) if conn is not None else None
+        if iris_pid is None:
+            logger.info("IRIS process of cancelled statement unknown", backend_pid=backend_pid)
+            return False
 
-            return True
+        def _terminate():
+            import iris
 
+            admin = self._get_pooled_connection()
+            try:
+                # The statement may have ended and its connection gone to another session
+                # since: terminate only while the lock keeps it from being returned
+                with self._connection_lock:
+                    if self._statement_connections.get(backend_pid) is not conn:
+                        logger.info("Cancelled statement already ended", backend_pid=backend_pid)
+                        return False
+                    status = iris.createIRIS(admin).classMethodValue(
+                        "%SYSTEM.Process", "Terminate", iris_pid
+                    )
+            finally:
+                self._return_connection(admin)
+            return str(status) == "1"
+
+        try:
+            loop = asyncio.get_event_loop()
+            terminated = await loop.run_in_executor(self.thread_pool, _terminate)
         except Exception as e:
-            logger.error("External query cancellation failed", error=str(e))
-            return False
+            terminated = False
+            logger.warning("Could not terminate IRIS process", iris_pid=iris_pid, error=str(e))
+        logger.info(
+            "IRIS statement interrupted" if terminated else "IRIS statement not interrupted",
+            backend_pid=backend_pid,
+            iris_pid=iris_pid,
+        )
+        return terminated
 
     def get_iris_type_mapping(self) -> dict[str, dict[str, Any]]:
         """
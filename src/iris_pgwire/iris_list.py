@@ -0,0 +1,229 @@
+"""
+IRIS $LISTBUILD Decoding
+
+%List values reach the bridge in their logical (binary $LISTBUILD) form. Each
+element is encoded as:
+
+    <length> <type> <data>
+
+where <length> counts the whole element. Lengths of 255 and above use an
+extended header: a zero byte followed by a 2-byte (or, if that is zero, a
+4-byte) little-endian length of type + data.
+
+Element types:
+    1  8-bit string            2  UTF-16LE string
+    4  positive integer        5  negative integer (two's complement)
+    6  positive decimal        7  negative decimal (exponent byte + mantissa)
+    8  IEEE double
+A length of 1 (no type byte) is an undefined element ($LISTBUILD(,x)) → None.
+
+decode_list() is strict: anything that is not exactly a sequence of well-formed
+elements returns None, so ordinary strings are never mistaken for lists.
+
+Result columns are detected by value: a text column whose non-NULL values all
+decode is a %List column. PGWIRE_LIST_FORMAT chooses what clients receive:
+- array (default): array literal, e.g. {red,"dark blue",NULL}, typed by the
+  elements of the whole column (int4[], int8[], float8[], numeric[] or
+  text[], see array_values.py)
+- json: JSON array, e.g. ["red", "dark blue", null]
+- text: unchanged, left to iris.select_mode (comma-joined, or raw $LIST in logical mode)
+"""
+
+import json
+import os
+import struct
+from decimal import Decimal
+from typing import Any
+
+import structlog
+
+from .array_values import array_type_oid
+
+logger = structlog.get_logger()
+
+LIST_FORMATS = ("array", "json", "text")
+
+# Text-like column types that can carry a $LIST value
+LIST_CARRIER_OIDS = (25, 1043, 1042, 19)
+
+_JSON_OID = 114
+
+
+def _decode_element(element_type: int, data: bytes) -> Any:
+    if element_type == 1:
+        return data.decode("latin-1")
+    if element_type == 2:
+        if len(data) % 2:
+            raise ValueError("odd-length unicode element")
+        return data.decode("utf-16-le")
+    if element_type == 4:
+        return int.from_bytes(data, "little")
+    if element_type == 5:
+        return int.from_bytes(data, "little") - (1 << (8 * len(data))) if data else -1
+    if element_type in (6, 7):
+        if not data:
+            raise ValueError("empty decimal element")
+        exponent = struct.unpack("b", data[:1])[0]
+        mantissa = int.from_bytes(data[1:], "little")
+        if element_type == 7:
+            mantissa -= 1 << (8 * len(data[1:]))
+        return Decimal(mantissa).scaleb(exponent)
+    if element_type == 8:
+        if len(data) != 8:
+            raise ValueError("double element must be 8 bytes")
+        return struct.unpack("<d", data)[0]
+    raise ValueError(f"unknown $LIST element type {element_type}")
+
+
+def decode_list(value: Any) -> list[Any] | None:
+    """
+    Decode a $LISTBUILD value into a Python list.
+
+    Args:
+        value: bytes, or a str carrying the list's bytes as code points < 256
+
+    Returns:
+        List of elements (str/int/Decimal/float/None), or None if `value` is
+        not a well-formed $LISTBUILD
+    """
+    if isinstance(value, str):
+        try:
+            data = value.encode("latin-1")
+        except UnicodeEncodeError:
+            return None
+    elif isinstance(value, bytes | bytearray | memoryview):
+        data = bytes(value)
+    else:
+        return None
+
+    if not data:
+        return None
+
+    elements: list[Any] = []
+    pos = 0
+    try:
+        while pos < len(data):
+            length = data[pos]
+            if length == 0:
+                # Extended header: 0, then 2-byte (or 0,0 + 4-byte) length of type + data
+                if pos + 3 > len(data):
+                    return None
+                length = int.from_bytes(data[pos + 1 : pos + 3], "little")
+                header = 3
+                if length == 0:
+                    if pos + 7 > len(data):
+                        return None
+                    length = int.from_bytes(data[pos + 3 : pos + 7], "little")
+                    header = 7
+                if length == 0:
+                    return None
+                body_start, end = pos + header, pos + header + length
+            elif length == 1:
+                elements.append(None)
+                pos += 1
+                continue
+            else:
+                body_start, end = pos + 1, pos + length
+
+            if end > len(data):
+                return None
+            elements.append(_decode_element(data[body_start], data[body_start + 1 : end]))
+            pos = end
+    except (ValueError, UnicodeDecodeError, struct.error):
+        return None
+
+    return elements
+
+
+def load_list_format(value: str | None = None) -> str:
+    """PGWIRE_LIST_FORMAT setting ('array', 'json' or 'text')."""
+    if value is None:
+        value = os.getenv("PGWIRE_LIST_FORMAT", "array")
+    value = value.strip().lower()
+    if value not in LIST_FORMATS:
+        logger.warning("Ignoring invalid PGWIRE_LIST_FORMAT", value=value)
+        return "array"
+    return value
+
+
+def _element_text(element: Any) -> str:
+    if isinstance(element, Decimal):
+        return format(element, "f")
+    return str(element)
+
+
+def format_array_literal(elements: list[Any]) -> str:
+    """PostgreSQL text[] literal for decoded list elements."""
+    parts = []
+    for element in elements:
+        if element is None:
+            parts.append("NULL")
+            continue
+        text = _element_text(element)
+        if (
+            text == ""
+            or text.upper() == "NULL"
+            or any(c in text for c in '{},"\\')
+            or any(c.isspace() for c in text)
+        ):
+            text = '"' + text.replace("\\", "\\\\").replace('"', '\\"') + '"'
+        parts.append(text)
+    return "{" + ",".join(parts) + "}"
+
+
+def format_json_array(elements: list[Any]) -> str:
+    """JSON array for decoded list elements."""
+
+    def _json_value(element: Any) -> Any:
+        if isinstance(element, Decimal):
+            return int(element) if element == element.to_integral_value() else float(element)
+        return element
+
+    return json.dumps([_json_value(element) for element in elements])
+
+
+def decode_list_columns(result: dict[str, Any], list_format: str = "array") -> int:
+    """
+    Decode %List columns of an executor result in place.
+
+    Args:
+        result: Executor result dict (rows/columns)
+        list_format: 'array', 'json' or 'text' (no-op)
+
+    Returns:
+        Number of columns converted
+    """
+    rows, columns = result.get("rows"), result.get("columns")
+    if list_format == "text" or not rows or not columns:
+        return 0
+
+    converted = 0
+    for index, column in enumerate(columns):
+        if column.get("type_oid") not in LIST_CARRIER_OIDS:
+            continue
+        decoded = {
+            row_index: decode_list(row[index])
+            for row_index, row in enumerate(rows)
+            if index < len(row) and row[index] is not None
+        }
+        if not decoded or any(elements is None for elements in decoded.values()):
+            continue
+
+        render = format_json_array if list_format == "json" else format_array_literal
+        for row_index, elements in decoded.items():
+            if isinstance(rows[row_index], tuple):
+                rows[row_index] = list(rows[row_index])
+            rows[row_index][index] = render(elements)
+        if list_format == "json":
+            column["type_oid"] = _JSON_OID
+        else:
+            column["type_oid"] = array_type_oid(
+                [element for elements in decoded.values() for element in elements]
+            )
+        column["type_size"] = -1
+        column["type_modifier"] = -1
+        converted += 1
+
+    if converted:
+        logger.debug("Decoded %List columns", columns=converted, list_format=list_format)
+    return converted
@@ -0,0 +1,106 @@
+"""
+TLS for the Bridge → IRIS Connection
+
+Encrypts the superserver hop between iris-pgwire and IRIS (and optionally
+authenticates the bridge with a client certificate), independently of the
+client-facing TLS settings. Modes follow libpq's sslmode:
+
+- disable (default): plaintext
+- require: TLS without certificate checks
+- verify-ca: TLS, IRIS certificate must chain to PGWIRE_IRIS_SSLROOTCERT
+- verify-full: verify-ca plus hostname check against the IRIS host
+
+Configuration:
+- PGWIRE_IRIS_SSLMODE: one of the modes above
+- PGWIRE_IRIS_SSLROOTCERT: CA bundle for verify-ca/verify-full (default: system CAs)
+- PGWIRE_IRIS_SSLCERT / PGWIRE_IRIS_SSLKEY: client certificate and key for
+  mutual TLS (the superserver's SSL configuration must request peer certificates)
+- PGWIRE_IRIS_SSLKEY_PASSWORD: passphrase of an encrypted client key
+
+The IRIS side needs an SSL/TLS configuration enabled for the superserver
+(System Administration > Security > SSL/TLS Configurations, then
+"%SuperServer" in System-wide Security Parameters).
+"""
+
+import os
+import ssl
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+SSL_MODES = ("disable", "require", "verify-ca", "verify-full")
+
+
+@dataclass
+class IrisTLSConfig:
+    """TLS settings for connections to IRIS."""
+
+    mode: str = "disable"
+    root_cert: str | None = None
+    cert: str | None = None
+    key: str | None = None
+    key_password: str | None = None
+
+    def __post_init__(self):
+        if self.mode not in SSL_MODES:
+            expected = ", ".join(SSL_MODES)
+            raise ValueError(f"invalid PGWIRE_IRIS_SSLMODE {self.mode!r} (expected {expected})")
+        if self.key and not self.cert:
+            raise ValueError("PGWIRE_IRIS_SSLKEY requires PGWIRE_IRIS_SSLCERT")
+
+    @classmethod
+    def from_env(cls) -> "IrisTLSConfig":
+        return cls(
+            mode=os.getenv("PGWIRE_IRIS_SSLMODE", "disable").strip().lower() or "disable",
+            root_cert=os.getenv("PGWIRE_IRIS_SSLROOTCERT") or None,
+            cert=os.getenv("PGWIRE_IRIS_SSLCERT") or None,
+            key=os.getenv("PGWIRE_IRIS_SSLKEY") or None,
+            key_password=os.getenv("PGWIRE_IRIS_SSLKEY_PASSWORD") or None,
+        )
+
+    @property
+    def enabled(self) -> bool:
+        return self.mode != "disable"
+
+    def create_context(self) -> ssl.SSLContext | None:
+        """Client-side SSLContext for the configured mode (None when disabled)."""
+        if not self.enabled:
+            return None
+
+        context = ssl.create_default_context(ssl.Purpose.SERVER_AUTH, cafile=self.root_cert)
+        context.minimum_version = ssl.TLSVersion.TLSv1_2
+        if self.mode == "require":
+            context.check_hostname = False
+            context.verify_mode = ssl.CERT_NONE
+        elif self.mode == "verify-ca":
+            context.check_hostname = False
+        if self.cert:
+            context.load_cert_chain(self.cert, self.key, password=self.key_password)
+        return context
+
+
+_connect_kwargs: dict | None = None
+
+
+def iris_tls_kwargs() -> dict:
+    """
+    Extra keyword arguments for iris.connect()/iris.createConnection().
+
+    The SSLContext is built once from the environment and shared by every
+    connection; a broken configuration fails loudly instead of silently
+    falling back to plaintext.
+    """
+    global _connect_kwargs
+    if _connect_kwargs is None:
+        config = IrisTLSConfig.from_env()
+        context = config.create_context()
+        _connect_kwargs = {"sslcontext": context} if context is not None else {}
+        if context is not None:
+            logger.info(
+                "TLS enabled for IRIS connections",
+                mode=config.mode,
+                client_certificate=config.cert is not None,
+            )
+    return _connect_kwargs
@@ -15,6 +15,8 @@ from dataclasses import dataclass
 from enum import Enum
 from typing import Any
 
+from .iris_tls import iris_tls_kwargs
+
 logger = logging.getLogger(__name__)
 
 
@@ -118,6 +120,7 @@ class IRISUserManager:
                         namespace=self.iris_config["namespace"],
                         username=self.iris_config.get("system_user", "_SYSTEM"),
                         password=self.iris_config.get("system_password", "SYS"),
+                        **iris_tls_kwargs(),
                     )
 
                     cursor = connection.cursor()
@@ -229,6 +232,7 @@ class IRISUserManager:
                         namespace=self.iris_config["namespace"],
                         username=self.iris_config.get("system_user", "_SYSTEM"),
                         password=self.iris_config.get("system_password", "SYS"),
+                        **iris_tls_kwargs(),
                     )
 
                     cursor = connection.cursor()
@@ -298,6 +302,7 @@ class IRISUserManager:
                         namespace=self.iris_config["namespace"],
                         username=self.iris_config.get("system_user", "_SYSTEM"),
                         password=self.iris_config.get("system_password", "SYS"),
+                        **iris_tls_kwargs(),
                     )
 
                     cursor = connection.cursor()
@@ -345,6 +350,7 @@ class IRISUserManager:
                         namespace=self.iris_config["namespace"],
                         username=self.iris_config.get("system_user", "_SYSTEM"),
                         password=self.iris_config.get("system_password", "SYS"),
+                        **iris_tls_kwargs(),
                     )
 
                     cursor = connection.cursor()
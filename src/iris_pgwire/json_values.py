@@ -0,0 +1,46 @@
+"""
+JSON and JSONB Columns
+
+IRIS keeps JSON documents as text. JSON and JSONB columns in CREATE TABLE /
+ALTER TABLE are created as PGWIRE_JSON_COLUMN_TYPE (ddl_translator.py), a
+VARCHAR long enough for any IRIS string by default, so that the SQL/JSON
+functions the JSON operators are translated into (json_translator.py) can
+read them. Result columns of exactly that type and length are reported as
+jsonb (OID 3802, column_types.py), which drivers decode into objects; choose
+a length no other column uses. jsonb Bind parameters in binary lose their
+version byte (bind_params.py) and results in binary get one
+(result_encoding.py).
+"""
+
+import os
+import re
+
+import structlog
+
+logger = structlog.get_logger()
+
+JSONB_OID = 3802
+
+# IRIS's longest string: no other column is expected to declare it
+DEFAULT_JSON_COLUMN_TYPE = "VARCHAR(3641144)"
+
+_CHARACTER_TYPE = re.compile(r"^VARCHAR\s*\(\s*(\d+)\s*\)$", re.IGNORECASE)
+
+
+def json_column_type(value: str | None = None) -> str:
+    """
+    IRIS type of JSON and JSONB columns, PGWIRE_JSON_COLUMN_TYPE: VARCHAR(n)
+    (invalid values fall back to the default).
+    """
+    if value is None:
+        value = os.getenv("PGWIRE_JSON_COLUMN_TYPE", DEFAULT_JSON_COLUMN_TYPE)
+    match = _CHARACTER_TYPE.match(value.strip())
+    if match and int(match.group(1)) > 0:
+        return f"VARCHAR({int(match.group(1))})"
+    logger.warning("Ignoring invalid PGWIRE_JSON_COLUMN_TYPE", value=value)
+    return DEFAULT_JSON_COLUMN_TYPE
+
+
+def is_json_column(type_name: str, length: int | None) -> bool:
+    """Whether a character result column is of the configured JSON column type."""
+    return length is not None and json_column_type() == f"{type_name.upper()}({length})"
@@ -0,0 +1,127 @@
+"""
+Idle Sessions and TCP Keepalives
+
+Sessions left open between commands (psql's \\watch, dashboards that re-run a
+query every few minutes, pooled connections) are never closed by the bridge
+unless idle_session_timeout asks for it:
+
+    SET idle_session_timeout = '10min'
+
+terminates a session that has waited longer than that for its next command
+outside a transaction, with FATAL 57P05 (idle_session_timeout) as in
+PostgreSQL 14+. The default, 0, disables the timeout; a server-wide value can
+be set for every role with a global entry in the role settings file (see
+role_settings.py).
+
+To keep NAT gateways and firewalls from silently dropping idle connections,
+every client socket has TCP keepalives enabled (PostgreSQL does the same).
+No protocol-level messages are sent while idle: psql only reads the socket
+when a command runs and would then print a burst of stale notices.
+
+Configuration (PostgreSQL's tcp_keepalives_* settings, 0 = system default):
+- PGWIRE_TCP_KEEPALIVES_IDLE: seconds of inactivity before the first probe
+- PGWIRE_TCP_KEEPALIVES_INTERVAL: seconds between unanswered probes
+- PGWIRE_TCP_KEEPALIVES_COUNT: unanswered probes before the connection is dropped
+"""
+
+import os
+import re
+import socket
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+IDLE_SESSION_TIMEOUT_PARAMETER = "idle_session_timeout"
+
+# PostgreSQL time units for millisecond-based parameters, largest first
+_TIME_UNITS = {"d": 86_400_000, "h": 3_600_000, "min": 60_000, "s": 1000, "ms": 1}
+
+_DURATION = re.compile(r"^\s*(\d+)\s*(ms|s|min|h|d)?\s*$", re.IGNORECASE)
+
+
+class IdleSessionTimeout(Exception):
+    """Session idle for longer than idle_session_timeout (SQLSTATE 57P05)."""
+
+    sqlstate = "57P05"
+    condition_name = "idle_session_timeout"
+
+    def __init__(self):
+        super().__init__("terminating connection due to idle-session timeout")
+
+
+def parse_duration_ms(value: str) -> int:
+    """
+    Milliseconds for a PostgreSQL duration ('0', '5000', '30s', '10min').
+
+    Raises:
+        ValueError: not a non-negative integer with an optional time unit
+    """
+    match = _DURATION.match(value)
+    if not match:
+        raise ValueError(f"invalid duration: {value!r}")
+    unit = (match.group(2) or "ms").lower()
+    return int(match.group(1)) * _TIME_UNITS[unit]
+
+
+def normalize_duration(value: str) -> str:
+    """Canonical spelling SHOW reports: the largest unit that divides the value."""
+    milliseconds = parse_duration_ms(value)
+    if milliseconds == 0:
+        return "0"
+    for unit, size in _TIME_UNITS.items():
+        if milliseconds % size == 0:
+            return f"{milliseconds // size}{unit}"
+    return f"{milliseconds}ms"
+
+
+def idle_timeout_seconds(value: str | None) -> float | None:
+    """idle_session_timeout setting as seconds (None = disabled)."""
+    if not value:
+        return None
+    try:
+        milliseconds = parse_duration_ms(value)
+    except ValueError:
+        return None
+    return milliseconds / 1000 if milliseconds > 0 else None
+
+
+@dataclass
+class TCPKeepaliveConfig:
+    """Keepalive probe timing; 0 leaves the system default in place."""
+
+    idle: int = 0
+    interval: int = 0
+    count: int = 0
+
+    @classmethod
+    def from_env(cls) -> "TCPKeepaliveConfig":
+        values = {}
+        for field_name in ("idle", "interval", "count"):
+            variable = f"PGWIRE_TCP_KEEPALIVES_{field_name.upper()}"
+            raw = os.getenv(variable, "0")
+            try:
+                values[field_name] = max(int(raw), 0)
+            except ValueError:
+                logger.warning(f"Ignoring invalid {variable}", value=raw)
+                values[field_name] = 0
+        return cls(**values)
+
+    def apply(self, sock: socket.socket | None) -> None:
+        """Enable keepalives on a client socket (Unix sockets are left alone)."""
+        if sock is None or sock.family not in (socket.AF_INET, socket.AF_INET6):
+            return
+        try:
+            sock.setsockopt(socket.SOL_SOCKET, socket.SO_KEEPALIVE, 1)
+            # TCP_KEEPIDLE is TCP_KEEPALIVE on macOS
+            idle_option = getattr(socket, "TCP_KEEPIDLE", getattr(socket, "TCP_KEEPALIVE", None))
+            for option, value in (
+                (idle_option, self.idle),
+                (getattr(socket, "TCP_KEEPINTVL", None), self.interval),
+                (getattr(socket, "TCP_KEEPCNT", None), self.count),
+            ):
+                if option is not None and value > 0:
+                    sock.setsockopt(socket.IPPROTO_TCP, option, value)
+        except OSError as e:
+            logger.debug("TCP keepalive not configured", error=str(e))
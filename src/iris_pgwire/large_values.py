@@ -0,0 +1,356 @@
+"""
+Large Parameter Values
+
+A Bind message can carry bytea, text or jsonb parameters of hundreds of MB
+(up to PGWIRE_MAX_BIND_MESSAGE_SIZE, default 1 GB). Buffering such a message and
+then slicing each parameter out of it would hold every value in memory two
+or three times, so Bind messages longer than PGWIRE_LARGE_VALUE_BYTES
+(default 16 MB) are read field by field instead:
+
+- each parameter value above PGWIRE_LARGE_VALUE_BYTES is copied in chunks to
+  an unlinked temporary file (PGWIRE_SPOOL_DIR, counted against
+  PGWIRE_SPOOL_MAX_BYTES and in pg_stat_database.temp_files/temp_bytes like
+  the result spools of temp_spool.py) and becomes a LargeValue
+- smaller values and the rest of the message are read as usual
+
+Hex-format bytea text ('\\x...') is decoded chunk by chunk into a new file;
+jsonb in binary format loses its version byte. At execution the executor
+binds each LargeValue as an IRIS stream in embedded mode (%Stream.TempBinary
+or %Stream.TempCharacter, written a chunk at a time), which IRIS accepts for
+stream columns (LONGVARBINARY, LONGVARCHAR). bytea values held in memory
+that exceed the IRIS string limit (IRIS_STRING_LIMIT) are bound the same
+way. The DB-API driver of external mode takes bytes or str, so there the
+value is read into memory once, when the statement runs.
+
+A LargeValue's file is deleted when the value is closed or garbage
+collected, i.e. when its portal is rebound or closed. Results hold long
+character stream values the same way (stream_values.py).
+"""
+
+import binascii
+import codecs
+import os
+import struct
+import tempfile
+from collections.abc import Iterator
+
+import structlog
+
+from .message_framing import InvalidTextEncoding, parse_byte_size
+from .stats_hooks import get_stats
+from .temp_spool import SpoolConfig, TempFileLimitExceeded, release_disk, reserve_disk
+
+logger = structlog.get_logger()
+
+DEFAULT_LARGE_VALUE_BYTES = 16 * 1024 * 1024
+
+# Values are copied between the socket, files and IRIS streams in chunks of this size
+CHUNK_BYTES = 1024 * 1024
+
+# Longest portal or statement name read from a streamed Bind message
+MAX_NAME_BYTES = 65536
+
+# Longest string IRIS accepts as a value; longer bytea is bound as a stream
+IRIS_STRING_LIMIT = 3_641_144
+
+BYTEA_OID = 17
+JSONB_OID = 3802
+# Types whose binary format is their UTF-8 text
+TEXT_OIDS = (18, 19, 25, 114, 1042, 1043)
+
+
+def load_large_value_bytes(value: str | None = None) -> int:
+    """PGWIRE_LARGE_VALUE_BYTES (invalid values fall back to the default)."""
+    if value is None:
+        value = os.getenv("PGWIRE_LARGE_VALUE_BYTES", str(DEFAULT_LARGE_VALUE_BYTES))
+    try:
+        size = parse_byte_size(value)
+    except ValueError:
+        size = 0
+    if size < CHUNK_BYTES:
+        logger.warning("Ignoring invalid PGWIRE_LARGE_VALUE_BYTES", value=value)
+        return DEFAULT_LARGE_VALUE_BYTES
+    return size
+
+
+class LargeValue:
+    """A parameter or result value held in a temporary file."""
+
+    # Result spools keep the value rather than pickling its file (temp_spool.py)
+    spooled_by_reference = True
+
+    def __init__(self, binary: bool, config: SpoolConfig | None = None):
+        self.binary = binary  # bytea contents; otherwise UTF-8 text
+        self.config = config or SpoolConfig.from_env()
+        self.size = 0
+        self._file = tempfile.TemporaryFile(prefix="pgwire_value_", dir=self.config.directory)
+        get_stats().record_temp_file(0, created=True)
+
+    def __repr__(self) -> str:
+        kind = "binary" if self.binary else "text"
+        return f"<LargeValue {kind} {self.size} bytes>"
+
+    def __reduce__(self):
+        raise TypeError("a LargeValue cannot be pickled")
+
+    def __len__(self) -> int:
+        return self.size
+
+    def write(self, data: bytes) -> None:
+        """
+        Append data.
+
+        Raises:
+            TempFileLimitExceeded: it would exceed PGWIRE_SPOOL_MAX_BYTES
+        """
+        reserve_disk(len(data), self.config.max_bytes)
+        self._file.write(data)
+        self.size += len(data)
+        get_stats().record_temp_file(len(data))
+
+    def chunks(self, size: int | None = None) -> Iterator[bytes]:
+        """The value's bytes from the start, size (default CHUNK_BYTES) at a time."""
+        self._file.seek(0)
+        while True:
+            data = self._file.read(size or CHUNK_BYTES)
+            if not data:
+                return
+            yield data
+
+    def text_chunks(self, size: int | None = None) -> Iterator[str]:
+        """
+        A text value decoded chunk by chunk.
+
+        Raises:
+            InvalidTextEncoding: the value is not valid UTF-8
+        """
+        decoder = codecs.getincrementaldecoder("utf-8")()
+        try:
+            for data in self.chunks(size):
+                text = decoder.decode(data)
+                if text:
+                    yield text
+            decoder.decode(b"", final=True)
+        except UnicodeDecodeError:
+            raise InvalidTextEncoding(
+                'invalid byte sequence for encoding "UTF8" in a large parameter value'
+            ) from None
+
+    def read(self) -> bytes | str:
+        """The whole value, in memory."""
+        if self.binary:
+            return b"".join(self.chunks())
+        return "".join(self.text_chunks())
+
+    def decoded(self, format_code: int, type_oid: int) -> "LargeValue":
+        """
+        The parameter for a Bind format code and declared type.
+
+        Raises:
+            ValueError: bytea text that is not in hex format
+        """
+        if format_code == 1:
+            if type_oid == JSONB_OID:
+                return self._copy(binary=False, skip=1)  # Version byte
+            return self._copy(binary=type_oid not in TEXT_OIDS)
+        if type_oid != BYTEA_OID:
+            return self._copy(binary=False)
+        return self._unhex()
+
+    def close(self) -> None:
+        if not self._file.closed:
+            self._file.close()
+            release_disk(self.size)
+
+    def __del__(self):
+        try:
+            self.close()
+        except Exception:
+            pass
+
+    def _copy(self, binary: bool, skip: int = 0) -> "LargeValue":
+        """The value under another kind (skip: leading bytes to drop)."""
+        if skip == 0:
+            self.binary = binary
+            return self
+        copy = LargeValue(binary, self.config)
+        first = True
+        for data in self.chunks():
+            copy.write(data[skip:] if first else data)
+            first = False
+        self.close()
+        return copy
+
+    def _unhex(self) -> "LargeValue":
+        """Decode '\\x' hex bytea text into a binary value."""
+        value = LargeValue(True, self.config)
+        carry = b""
+        first = True
+        for data in self.chunks():
+            data = carry + data
+            if first:
+                if not data.startswith(b"\\x"):
+                    value.close()
+                    raise ValueError("large bytea parameters must use hex or binary format")
+                data = data[2:]
+                first = False
+            even = len(data) - len(data) % 2
+            try:
+                value.write(binascii.unhexlify(data[:even]))
+            except binascii.Error:
+                value.close()
+                raise ValueError("invalid hexadecimal data in bytea parameter") from None
+            carry = data[even:]
+        if carry:
+            value.close()
+            raise ValueError("invalid hexadecimal data: odd number of digits")
+        self.close()
+        return value
+
+
+def _over_string_limit(value) -> bool:
+    return isinstance(value, bytes | bytearray) and len(value) > IRIS_STRING_LIMIT
+
+
+def has_large_values(params: list | None) -> bool:
+    return bool(params) and any(
+        isinstance(value, LargeValue) or _over_string_limit(value) for value in params
+    )
+
+
+def iris_stream_params(params: list, iris) -> list:
+    """
+    Parameters with each LargeValue, and bytea over the string limit, written to an
+    IRIS temporary stream (embedded mode).
+    """
+    bound = []
+    for value in params:
+        if isinstance(value, LargeValue):
+            if value.binary:
+                stream = iris.cls("%Stream.TempBinary")._New()
+                for data in value.chunks():
+                    stream.Write(data)
+            else:
+                stream = iris.cls("%Stream.TempCharacter")._New()
+                for text in value.text_chunks():
+                    stream.Write(text)
+            stream.Rewind()
+            value = stream
+        elif _over_string_limit(value):
+            stream = iris.cls("%Stream.TempBinary")._New()
+            for start in range(0, len(value), CHUNK_BYTES):
+                stream.Write(bytes(value[start : start + CHUNK_BYTES]))
+            stream.Rewind()
+            value = stream
+        bound.append(value)
+    return bound
+
+
+def materialized_params(params: list) -> list:
+    """Parameters with each LargeValue read into memory (external mode's DB-API)."""
+    return [value.read() if isinstance(value, LargeValue) else value for value in params]
+
+
+def preview(value, limit: int = 100) -> str:
+    """A short rendering of a possibly huge value, for logs."""
+    if isinstance(value, str | bytes | bytearray) and len(value) > limit:
+        unit = "chars" if isinstance(value, str) else "bytes"
+        return f"{value[:limit]!r}... ({len(value)} {unit})"
+    return repr(value)
+
+
+class _MessageBody:
+    """Reads a message body of a known length from the client, field by field."""
+
+    def __init__(self, reader, length: int):
+        self.reader = reader
+        self.remaining = length
+        self.body = bytearray()
+
+    async def field(self, n: int) -> bytes:
+        """Read n bytes into the body."""
+        data = await self.take(n)
+        self.body += data
+        return data
+
+    async def take(self, n: int) -> bytes:
+        if n > self.remaining:
+            raise _Truncated
+        data = await self.reader.readexactly(n)
+        self.remaining -= n
+        return data
+
+    async def name(self) -> None:
+        """Read a null-terminated name into the body."""
+        while True:
+            if len(self.body) > MAX_NAME_BYTES:
+                raise _Truncated
+            byte = await self.field(1)
+            if byte == b"\x00":
+                return
+
+    async def skip_rest(self) -> None:
+        while self.remaining:
+            await self.take(min(self.remaining, CHUNK_BYTES))
+
+
+class _Truncated(Exception):
+    pass
+
+
+async def read_bind_message(
+    reader, length: int, threshold: int, config: SpoolConfig | None = None
+) -> tuple[bytes, dict[int, LargeValue]]:
+    """
+    Read a Bind message body of length bytes, spooling values above threshold.
+
+    Returns:
+        The body with each spooled value replaced by NULL (length -1), and
+        the spooled values by parameter index. A malformed body is consumed
+        and returned truncated, for the Bind handler to report as 08P01.
+
+    Raises:
+        TempFileLimitExceeded: after consuming the message
+    """
+    message = _MessageBody(reader, length)
+    large_values: dict[int, LargeValue] = {}
+    try:
+        await message.name()  # Portal
+        await message.name()  # Statement
+        (format_count,) = struct.unpack("!H", await message.field(2))
+        await message.field(2 * format_count)
+        (param_count,) = struct.unpack("!H", await message.field(2))
+        for i in range(param_count):
+            (param_length,) = struct.unpack("!I", await message.take(4))
+            if param_length == 0xFFFFFFFF or param_length <= threshold:
+                message.body += struct.pack("!I", param_length)
+                if param_length != 0xFFFFFFFF:
+                    await message.field(param_length)
+                continue
+            if param_length > message.remaining:
+                raise _Truncated
+            value = LargeValue(binary=True, config=config)
+            large_values[i] = value
+            while param_length:
+                data = await message.take(min(param_length, CHUNK_BYTES))
+                value.write(data)
+                param_length -= len(data)
+            message.body += struct.pack("!I", 0xFFFFFFFF)
+        await message.field(message.remaining)  # Result format codes
+    except _Truncated:
+        for value in large_values.values():
+            value.close()
+        large_values = {}
+        await message.skip_rest()
+    except TempFileLimitExceeded:
+        # The message is consumed, so the session can go on to the next one
+        for value in large_values.values():
+            value.close()
+        await message.skip_rest()
+        raise
+    logger.info(
+        "Large Bind message streamed",
+        length=length,
+        spooled=[len(value) for value in large_values.values()],
+    )
+    return bytes(message.body), large_values
@@ -0,0 +1,329 @@
+"""
+LDAP Authentication
+
+Where IRIS users are LDAP-backed (Active Directory, OpenLDAP), the bridge can
+check the client's password against the directory instead of IRIS, like
+PostgreSQL's ldap method. The password is requested in cleartext (run
+client-facing TLS) and verified in one of two modes:
+
+- simple bind: bind as ldapprefix + user + ldapsuffix, e.g.
+  ldapprefix="cn=" ldapsuffix=",ou=people,dc=example,dc=com", or
+  ldapsuffix="@EXAMPLE.COM" for an Active Directory UPN
+- search+bind: bind as ldapbinddn / ldapbindpasswd (anonymously without
+  them), search ldapbasedn for the one entry whose ldapsearchattribute
+  (default uid; sAMAccountName for Active Directory) equals the user, or
+  that matches ldapsearchfilter ($username is replaced by the escaped user
+  name), then bind as that entry with the password
+
+Options, in pg_hba.conf syntax: ldapserver (host names separated by spaces,
+tried in order), ldapport, ldapscheme (ldap or ldaps), ldaptls=1 (StartTLS)
+and the mode's options above. A pg_hba.conf ldap line carries them, so
+different databases and user patterns can use different directories; a
+listener offering ldap (PGWIRE_AUTH_METHODS) takes them from
+PGWIRE_LDAP_OPTIONS:
+
+    PGWIRE_LDAP_OPTIONS='ldapserver=ad1.example.com ldapbasedn="dc=example,dc=com"
+                         ldapsearchattribute=sAMAccountName ldapscheme=ldaps'
+
+A user the directory accepts logs in under the same name: in
+PGWIRE_BACKEND_AUTH_MODE=passthrough the session's IRIS connections switch
+to that IRIS user (as for Kerberos delegation, see backend_auth.py),
+otherwise statements run as the service account. A refusal fails with
+28000 'LDAP authentication failed for user "..."', the reason (unknown or
+ambiguous user, wrong password, unreachable server) only in the log.
+
+The LDAP client is ldap3 (pip install iris-pgwire[ldap]).
+"""
+
+import os
+from collections.abc import Callable
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+LDAP_OPTIONS = (
+    "ldapserver",
+    "ldapport",
+    "ldapscheme",
+    "ldaptls",
+    "ldapprefix",
+    "ldapsuffix",
+    "ldapbasedn",
+    "ldapbinddn",
+    "ldapbindpasswd",
+    "ldapsearchattribute",
+    "ldapsearchfilter",
+)
+_SEARCH_OPTIONS = (
+    "ldapbasedn",
+    "ldapbinddn",
+    "ldapbindpasswd",
+    "ldapsearchattribute",
+    "ldapsearchfilter",
+)
+
+DEFAULT_TIMEOUT = 10.0
+
+# Characters PostgreSQL refuses in user names for search+bind
+_UNSAFE_SEARCH_CHARS = set('*()\\/')
+
+
+class LdapAuthenticationFailed(Exception):
+    """The directory did not accept the user and password (SQLSTATE 28000)."""
+
+    sqlstate = "28000"
+    condition_name = "invalid_authorization_specification"
+
+    def __init__(self, user: str):
+        super().__init__(f'LDAP authentication failed for user "{user}"')
+        self.user = user
+
+
+@dataclass(frozen=True)
+class LdapConfig:
+    """How to verify a password against the directory."""
+
+    servers: tuple[str, ...]
+    port: int | None = None
+    scheme: str = "ldap"
+    starttls: bool = False
+    prefix: str = ""
+    suffix: str = ""
+    basedn: str | None = None
+    binddn: str | None = None
+    bindpasswd: str | None = None
+    searchattribute: str | None = None
+    searchfilter: str | None = None
+    timeout: float = DEFAULT_TIMEOUT
+
+    @property
+    def search(self) -> bool:
+        """search+bind (otherwise simple bind)."""
+        return self.basedn is not None
+
+    @classmethod
+    def from_options(cls, options: dict[str, str]) -> "LdapConfig":
+        """
+        Configuration from pg_hba.conf-style options.
+
+        Raises:
+            ValueError: a missing server or port, unknown scheme, or mixed modes
+        """
+        unknown = sorted(set(options) - set(LDAP_OPTIONS))
+        if unknown:
+            raise ValueError(f"invalid LDAP option {unknown[0]!r}")
+        servers = tuple(options.get("ldapserver", "").split())
+        if not servers:
+            raise ValueError('authentication method "ldap" requires argument "ldapserver"')
+        port = options.get("ldapport")
+        if port is not None and not (port.isdigit() and 0 < int(port) < 65536):
+            raise ValueError(f"invalid ldapport {port!r}")
+        scheme = options.get("ldapscheme", "ldap")
+        if scheme not in ("ldap", "ldaps"):
+            raise ValueError(f"invalid ldapscheme {scheme!r} (expected ldap or ldaps)")
+        starttls = options.get("ldaptls", "0")
+        if starttls not in ("0", "1"):
+            raise ValueError("ldaptls must be 0 or 1")
+        if starttls == "1" and scheme == "ldaps":
+            raise ValueError("ldaptls cannot be used with ldapscheme=ldaps")
+        simple = "ldapprefix" in options or "ldapsuffix" in options
+        search = any(name in options for name in _SEARCH_OPTIONS)
+        if simple and search:
+            raise ValueError(
+                "cannot use ldapbasedn, ldapbinddn, ldapbindpasswd, ldapsearchattribute "
+                "or ldapsearchfilter together with ldapprefix"
+            )
+        if not simple and not search:
+            raise ValueError(
+                'authentication method "ldap" requires argument "ldapbasedn", '
+                '"ldapprefix", or "ldapsuffix" to be set'
+            )
+        if search and "ldapbasedn" not in options:
+            raise ValueError('search+bind authentication requires argument "ldapbasedn"')
+        if "ldapsearchattribute" in options and "ldapsearchfilter" in options:
+            raise ValueError("cannot use ldapsearchattribute together with ldapsearchfilter")
+        return cls(
+            servers=servers,
+            port=int(port) if port is not None else None,
+            scheme=scheme,
+            starttls=starttls == "1",
+            prefix=options.get("ldapprefix", ""),
+            suffix=options.get("ldapsuffix", ""),
+            basedn=options.get("ldapbasedn"),
+            binddn=options.get("ldapbinddn"),
+            bindpasswd=options.get("ldapbindpasswd"),
+            searchattribute=options.get("ldapsearchattribute"),
+            searchfilter=options.get("ldapsearchfilter"),
+        )
+
+
+def parse_ldap_options(text: str) -> dict[str, str]:
+    """
+    name=value options separated by spaces, quoted as in pg_hba.conf.
+
+    Raises:
+        ValueError: a malformed option
+    """
+    from .hba import split_line
+
+    options = {}
+    for field in split_line(text, 1):
+        option = ",".join(item for item, _ in field)
+        name, equals, value = option.partition("=")
+        if not equals:
+            raise ValueError(f"invalid LDAP option {option!r} (expected name=value)")
+        options[name] = value
+    return options
+
+
+_cache: tuple[str, LdapConfig] | None = None
+
+
+def load_ldap_config() -> LdapConfig:
+    """
+    The configuration of listeners offering ldap (PGWIRE_LDAP_OPTIONS).
+
+    Raises:
+        ValueError: unset or invalid
+    """
+    global _cache
+    text = os.getenv("PGWIRE_LDAP_OPTIONS", "")
+    if _cache is None or _cache[0] != text:
+        try:
+            config = LdapConfig.from_options(parse_ldap_options(text))
+        except ValueError as e:
+            raise ValueError(f"invalid PGWIRE_LDAP_OPTIONS: {e}") from None
+        _cache = (text, config)
+    return _cache[1]
+
+
+def escape_filter_value(value: str) -> str:
+    """A value for an LDAP search filter (RFC 4515)."""
+    escaped = []
+    for char in value:
+        if char in '*()\\\x00':
+            escaped.append(f"\\{ord(char):02x}")
+        else:
+            escaped.append(char)
+    return "".join(escaped)
+
+
+def search_filter(config: LdapConfig, user: str) -> str:
+    """The filter finding user's entry in search+bind mode."""
+    value = escape_filter_value(user)
+    if config.searchfilter:
+        return config.searchfilter.replace("$username", value)
+    return f"({config.searchattribute or 'uid'}={value})"
+
+
+class Ldap3Connection:
+    """A connection to one directory server through ldap3."""
+
+    def __init__(self, config: LdapConfig, host: str):
+        import ldap3
+
+        self._ldap3 = ldap3
+        server = ldap3.Server(
+            host,
+            port=config.port,
+            use_ssl=config.scheme == "ldaps",
+            connect_timeout=config.timeout,
+        )
+        self._connection = ldap3.Connection(server, receive_timeout=config.timeout)
+        self._connection.open()
+        if config.starttls and not self._connection.start_tls():
+            raise ConnectionError(f"StartTLS failed: {self._connection.result}")
+
+    def bind(self, dn: str | None, password: str | None) -> bool:
+        connection = self._connection
+        connection.user, connection.password = dn, password
+        connection.authentication = self._ldap3.SIMPLE if dn else self._ldap3.ANONYMOUS
+        return bool(connection.bind())
+
+    def search(self, basedn: str, search_filter: str) -> list[str]:
+        """DNs of the entries matching the filter under basedn."""
+        self._connection.search(
+            basedn,
+            search_filter,
+            search_scope=self._ldap3.SUBTREE,
+            attributes=[self._ldap3.NO_ATTRIBUTES],
+        )
+        return [
+            entry["dn"]
+            for entry in self._connection.response or []
+            if entry.get("type") == "searchResEntry"
+        ]
+
+    def close(self) -> None:
+        self._connection.unbind()
+
+
+def authenticate_ldap(
+    config: LdapConfig,
+    user: str,
+    password: str,
+    connect: Callable[[LdapConfig, str], Ldap3Connection] | None = None,
+) -> str:
+    """
+    Verify user's password against the directory (blocking).
+
+    Args:
+        connect: Opens a connection to a server (default: ldap3)
+
+    Returns:
+        The DN the user bound as
+
+    Raises:
+        LdapAuthenticationFailed: refused, or no server reachable
+    """
+    connect = connect or Ldap3Connection
+    if not password:
+        # An empty password would be an unauthenticated bind, which succeeds
+        logger.warning("LDAP authentication with an empty password", user=user)
+        raise LdapAuthenticationFailed(user)
+    if config.search and _UNSAFE_SEARCH_CHARS & set(user):
+        logger.warning("Invalid character in user name for LDAP authentication", user=user)
+        raise LdapAuthenticationFailed(user)
+
+    for host in config.servers:
+        try:
+            connection = connect(config, host)
+        except Exception as e:
+            logger.warning("LDAP server unreachable", server=host, error=str(e))
+            continue
+        try:
+            return _bind_user(connection, config, user, password)
+        except LdapAuthenticationFailed:
+            raise
+        except Exception as e:
+            logger.warning("LDAP authentication error", server=host, user=user, error=str(e))
+            raise LdapAuthenticationFailed(user) from None
+        finally:
+            try:
+                connection.close()
+            except Exception:
+                pass
+    logger.error("No LDAP server reachable", servers=list(config.servers))
+    raise LdapAuthenticationFailed(user)
+
+
+def _bind_user(connection, config: LdapConfig, user: str, password: str) -> str:
+    if not config.search:
+        dn = f"{config.prefix}{user}{config.suffix}"
+    else:
+        if not connection.bind(config.binddn, config.bindpasswd):
+            logger.error("LDAP search bind failed", binddn=config.binddn)
+            raise LdapAuthenticationFailed(user)
+        entries = connection.search(config.basedn, search_filter(config, user))
+        if len(entries) != 1:
+            reason = "does not exist" if not entries else "is not unique"
+            logger.warning(f"LDAP user {reason}", user=user, entries=len(entries))
+            raise LdapAuthenticationFailed(user)
+        dn = entries[0]
+    if not connection.bind(dn, password):
+        logger.warning("LDAP bind refused", user=user, dn=dn)
+        raise LdapAuthenticationFailed(user)
+    logger.info("LDAP authentication succeeded", user=user, dn=dn)
+    return dn
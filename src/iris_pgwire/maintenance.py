@@ -0,0 +1,166 @@
+"""
+REINDEX and CLUSTER
+
+Maintenance scripts and pg_dump output run REINDEX and CLUSTER, which IRIS
+has no statements for. IRIS rebuilds indexes with BUILD INDEX, so
+
+    REINDEX [(options)] INDEX [CONCURRENTLY] name
+        BUILD INDEX FOR TABLE <the index's table> INDEX name
+    REINDEX [(options)] TABLE [CONCURRENTLY] name
+        BUILD INDEX FOR TABLE name
+    REINDEX [(options)] SCHEMA [CONCURRENTLY] name
+        BUILD INDEX FOR SCHEMA name (public is the default IRIS schema)
+    REINDEX [(options)] DATABASE [CONCURRENTLY] [name]
+        BUILD INDEX FOR SCHEMA of each schema that has tables
+    REINDEX [(options)] SYSTEM [name]
+        nothing (the system catalogs are emulated and have no indexes),
+        with a NOTICE
+
+The index of REINDEX INDEX is looked up in INFORMATION_SCHEMA.INDEXES (42704
+when there is none). CONCURRENTLY and the options (VERBOSE, TABLESPACE) are
+ignored.
+
+IRIS stores rows in ID order and cannot keep them in index order, so
+
+    CLUSTER [VERBOSE] [table [USING index]], CLUSTER index ON table
+    ALTER TABLE table CLUSTER ON index, ALTER TABLE table SET WITHOUT CLUSTER
+
+succeed without doing anything, with a NOTICE.
+"""
+
+import re
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass
+
+from .check_constraints import _IDENTIFIER, _unquote, relation
+from .partitioning import sql_identifier
+
+_NAME = rf"{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?"
+
+_REINDEX = re.compile(
+    r"^\s*REINDEX\s+(?:\([^)]*\)\s*)?(?P<kind>INDEX|TABLE|SCHEMA|DATABASE|SYSTEM)"
+    rf"(?:\s+CONCURRENTLY)?(?:\s+(?P<name>{_NAME}))?\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+_CLUSTER = re.compile(
+    r"^\s*CLUSTER(?:\s*\([^)]*\))?(?:\s+VERBOSE)?"
+    rf"(?:\s+{_NAME}\s+ON\s+(?P<on_table>{_NAME})"
+    rf"|\s+(?P<table>{_NAME})(?:\s+USING\s+{_IDENTIFIER})?)?\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+_ALTER_TABLE_CLUSTER = re.compile(
+    r"^\s*ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?"
+    rf"(?P<table>{_NAME})\s+(?:CLUSTER\s+ON\s+{_IDENTIFIER}|SET\s+WITHOUT\s+CLUSTER)\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+# Kinds of REINDEX that need a name
+_NAMED_KINDS = frozenset({"index", "table", "schema"})
+
+SYSTEM_REINDEX_NOTICE = (
+    "NOTICE",
+    "00000",
+    "system catalogs are emulated and have no indexes; REINDEX SYSTEM has no effect",
+)
+CLUSTER_NOTICE = (
+    "NOTICE",
+    "00000",
+    "IRIS stores rows in ID order, not index order; CLUSTER has no effect",
+)
+
+
+class UndefinedIndex(Exception):
+    """REINDEX INDEX of an index that does not exist (SQLSTATE 42704)."""
+
+    sqlstate = "42704"
+    condition_name = "undefined_object"
+
+
+@dataclass
+class Reindex:
+    kind: str  # index, table, schema, database or system
+    name: str | None  # As written
+
+
+@dataclass
+class Cluster:
+    table: str | None  # As written; None clusters every previously clustered table
+    tag: str  # CLUSTER, or ALTER TABLE for ALTER TABLE ... CLUSTER ON
+
+
+def parse_reindex(sql: str) -> Reindex | None:
+    """REINDEX (None for any other statement)."""
+    match = _REINDEX.match(sql)
+    if not match:
+        return None
+    kind = match.group("kind").lower()
+    if kind in _NAMED_KINDS and not match.group("name"):
+        return None
+    return Reindex(kind, match.group("name"))
+
+
+def parse_cluster(sql: str) -> Cluster | None:
+    """CLUSTER and ALTER TABLE ... [SET WITHOUT] CLUSTER (None for any other statement)."""
+    match = _CLUSTER.match(sql)
+    if match:
+        return Cluster(match.group("table") or match.group("on_table"), "CLUSTER")
+    match = _ALTER_TABLE_CLUSTER.match(sql)
+    if match:
+        return Cluster(match.group("table"), "ALTER TABLE")
+    return None
+
+
+def _literal(value: str) -> str:
+    return "'" + value.replace("'", "''") + "'"
+
+
+def index_table_sql(schema: str, index: str) -> str:
+    """Table of an index: (TABLE_SCHEMA, TABLE_NAME, INDEX_NAME) rows."""
+    return (
+        "SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME FROM INFORMATION_SCHEMA.INDEXES "
+        f"WHERE LOWER(TABLE_SCHEMA) = LOWER({_literal(schema)}) "
+        f"AND LOWER(INDEX_NAME) = LOWER({_literal(index)})"
+    )
+
+
+# Schemas that have tables, for REINDEX DATABASE
+TABLE_SCHEMAS_SQL = (
+    "SELECT DISTINCT TABLE_SCHEMA FROM INFORMATION_SCHEMA.TABLES "
+    "WHERE TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_SCHEMA"
+)
+
+
+async def build_index_statements(
+    reindex: Reindex, iris_schema: str, lookup: Callable[[str], Awaitable[list]]
+) -> list[str]:
+    """
+    BUILD INDEX statements of a REINDEX; lookup runs an INFORMATION_SCHEMA
+    query and returns its rows.
+
+    Raises:
+        UndefinedIndex: REINDEX INDEX of an index that does not exist
+    """
+    if reindex.kind == "index":
+        target = relation(reindex.name, iris_schema)
+        rows = await lookup(index_table_sql(target.schema, target.table))
+        if not rows:
+            raise UndefinedIndex(f'index "{target.table}" does not exist')
+        schema, table, index = rows[0][:3]
+        return [
+            f"BUILD INDEX FOR TABLE {sql_identifier(schema)}.{sql_identifier(table)} "
+            f"INDEX {sql_identifier(index)}"
+        ]
+    if reindex.kind == "table":
+        return [f"BUILD INDEX FOR TABLE {relation(reindex.name, iris_schema).sql}"]
+    if reindex.kind == "schema":
+        schema = _unquote(reindex.name)
+        schema = iris_schema if schema == "public" else schema
+        return [f"BUILD INDEX FOR SCHEMA {sql_identifier(schema)}"]
+    if reindex.kind == "database":
+        return [
+            f"BUILD INDEX FOR SCHEMA {sql_identifier(row[0])}"
+            for row in await lookup(TABLE_SCHEMAS_SQL)
+        ]
+    return []  # SYSTEM
@@ -0,0 +1,89 @@
+"""
+MD5 Password Authentication
+
+Legacy clients and connection poolers that only speak md5 (PgBouncer before
+1.14 with auth_type=md5, old JDBC and ODBC drivers, Npgsql 2.x) are
+authenticated with AuthenticationMD5Password: the bridge sends a random
+4-byte salt and the client answers
+
+    "md5" + md5_hex(md5_hex(password + user) + salt)
+
+The server checks the answer against the stored secret
+"md5" + md5_hex(password + user), PostgreSQL's pg_authid.rolpassword form,
+never against the password. IRIS stores no such secret, so the bridge keeps
+one per user in an IRIS global (PGWIRE_MD5_GLOBAL, default ^PGWire.MD5):
+
+    ^PGWire.MD5("alice") = "md5<32 hex digits>"
+
+Secrets are enrolled like SCRAM verifiers (scram.py): a user without one is
+asked for the password once, it is checked by logging in to IRIS, and the
+secrets of the password methods the listener offers are stored
+(PGWIRE_SCRAM_ENROLL applies). Like verifiers, enrolled secrets are stamped
+with the IRIS password and ignored once it changes; secrets copied from
+pg_authid work as they are.
+
+MD5 is weaker than SCRAM-SHA-256 (the stored secret is password-equivalent
+and the exchange is open to offline guessing), which is why it is only
+offered where a listener lists it (auth_methods.py).
+"""
+
+import hashlib
+import hmac
+import os
+import re
+
+import structlog
+
+from .scram import enrolled_before_password_change, store_password_stamp
+
+logger = structlog.get_logger()
+
+DEFAULT_MD5_GLOBAL = "^PGWire.MD5"
+
+_SECRET = re.compile(r"md5[0-9a-f]{32}")
+
+
+def md5_secret(password: str, user: str) -> str:
+    """Stored secret of a password: "md5" + md5_hex(password + user)."""
+    return "md5" + hashlib.md5((password + user).encode("utf-8")).hexdigest()
+
+
+def md5_response(secret: str, salt: bytes) -> str:
+    """The answer to an AuthenticationMD5Password salt, from the stored secret."""
+    return "md5" + hashlib.md5(secret[3:].encode("ascii") + salt).hexdigest()
+
+
+def verify_md5_response(secret: str | None, salt: bytes, response: str) -> bool:
+    """Whether a client's PasswordMessage answers salt for secret (False without one)."""
+    if secret is None:
+        return False
+    return hmac.compare_digest(md5_response(secret, salt), response)
+
+
+class Md5SecretStore:
+    """Users' MD5 secrets in the IRIS global, through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv("PGWIRE_MD5_GLOBAL", DEFAULT_MD5_GLOBAL)
+
+    def get(self, user: str, stamp: str | None = None) -> str | None:
+        """The user's secret, unless stamp (the current IRIS password's) says it is stale."""
+        if not self.accessor.data(self.global_name, [user]) % 10:
+            return None
+        if enrolled_before_password_change(self.accessor, self.global_name, user, stamp):
+            logger.info(
+                "Ignoring MD5 secret of a changed IRIS password",
+                user=user,
+                stamp_known=stamp is not None,
+            )
+            return None
+        secret = str(self.accessor.get(self.global_name, [user]))
+        if not _SECRET.fullmatch(secret):
+            logger.warning("Ignoring invalid MD5 secret", user=user, glob=self.global_name)
+            return None
+        return secret
+
+    def set(self, user: str, secret: str, stamp: str | None = None) -> None:
+        self.accessor.set(self.global_name, [user], secret)
+        store_password_stamp(self.accessor, self.global_name, user, stamp)
@@ -0,0 +1,146 @@
+"""
+Message Framing Limits and Malformed-Message Errors
+
+Every frontend message starts with a length word the client controls. The
+server validates it before reading (and allocating) the body, and decodes
+text fields strictly, so hostile or corrupt input produces a protocol error
+instead of an unbounded allocation or an unrelated SQLSTATE:
+
+- length < 4, or above PGWIRE_MAX_MESSAGE_SIZE → FATAL 08P01, connection closed
+  (the stream can no longer be framed)
+- startup packets outside 8..10000 bytes → connection closed (as PostgreSQL)
+- truncated fields inside a message → ERROR 08P01 protocol_violation
+- invalid UTF-8 in text fields → ERROR 22021 character_not_in_repertoire
+
+PGWIRE_MAX_MESSAGE_SIZE defaults to 64 MB. Only Bind messages may be larger,
+up to PGWIRE_MAX_BIND_MESSAGE_SIZE (default 1 GB, PostgreSQL's limit): they are
+not buffered whole but read field by field, with parameter values above
+PGWIRE_LARGE_VALUE_BYTES spooled to temporary files as they arrive
+(large_values.py). Both accept kB/MB/GB units (PGWIRE_MAX_MESSAGE_SIZE=256MB).
+"""
+
+import os
+import struct
+
+import structlog
+
+logger = structlog.get_logger()
+
+DEFAULT_MAX_MESSAGE_SIZE = 64 * 1024 * 1024
+# Bind messages, read field by field with large values spooled
+DEFAULT_MAX_BIND_MESSAGE_SIZE = 1024 * 1024 * 1024
+# The length word is a signed 32-bit integer
+MAX_MESSAGE_SIZE_LIMIT = 0x7FFFFFFF
+# PostgreSQL's MAX_STARTUP_PACKET_LENGTH
+MAX_STARTUP_PACKET_SIZE = 10000
+
+
+class MalformedMessage(ValueError):
+    """Frontend message that cannot be decoded."""
+
+    sqlstate = "08P01"
+    condition_name = "protocol_violation"
+
+
+class ProtocolViolation(MalformedMessage):
+    """Bad length word or truncated/missing message field (SQLSTATE 08P01)."""
+
+
+class InvalidTextEncoding(MalformedMessage):
+    """Text field is not valid UTF-8 (SQLSTATE 22021)."""
+
+    sqlstate = "22021"
+    condition_name = "character_not_in_repertoire"
+
+
+_UNITS = {"": 1, "B": 1, "KB": 1024, "MB": 1024**2, "GB": 1024**3}
+
+
+def parse_byte_size(value: str) -> int:
+    """
+    A size in bytes, optionally with a kB, MB or GB unit (1024 multiples, as PostgreSQL).
+
+    Raises:
+        ValueError: not a size
+    """
+    text = value.strip()
+    digits = text.rstrip("BbKkMmGg ")
+    unit = text[len(digits) :].strip().upper()
+    if unit not in _UNITS or not digits.strip().isdigit():
+        raise ValueError(f"invalid size {value!r}")
+    return int(digits) * _UNITS[unit]
+
+
+def _load_size(variable: str, value: str | None, default: int) -> int:
+    if value is None:
+        value = os.getenv(variable, str(default))
+    try:
+        size = parse_byte_size(value)
+    except ValueError:
+        size = 0
+    if size < 1024 or size > MAX_MESSAGE_SIZE_LIMIT:
+        logger.warning(f"Ignoring invalid {variable}", value=value)
+        return default
+    return size
+
+
+def load_max_message_size(value: str | None = None) -> int:
+    """PGWIRE_MAX_MESSAGE_SIZE in bytes (invalid values fall back to the default)."""
+    return _load_size("PGWIRE_MAX_MESSAGE_SIZE", value, DEFAULT_MAX_MESSAGE_SIZE)
+
+
+def load_max_bind_message_size(value: str | None = None) -> int:
+    """PGWIRE_MAX_BIND_MESSAGE_SIZE in bytes (invalid values fall back to the default)."""
+    return _load_size("PGWIRE_MAX_BIND_MESSAGE_SIZE", value, DEFAULT_MAX_BIND_MESSAGE_SIZE)
+
+
+def parse_message_header(
+    header: bytes, max_size: int, max_bind_size: int | None = None
+) -> tuple[bytes, int]:
+    """
+    Validate a 5-byte message header.
+
+    Bind messages may be up to max_bind_size where the reader streams them
+    (large_values.py); every other message is limited to max_size.
+
+    Returns:
+        (message type, body length)
+
+    Raises:
+        ProtocolViolation: length word below 4 or above the limit
+    """
+    message_type, length = struct.unpack("!cI", header)
+    if length < 4:
+        raise ProtocolViolation(
+            f"invalid message length {length} for message type {message_type!r}"
+        )
+    limit, variable = max_size, "PGWIRE_MAX_MESSAGE_SIZE"
+    if message_type == b"B" and max_bind_size is not None:
+        limit, variable = max_bind_size, "PGWIRE_MAX_BIND_MESSAGE_SIZE"
+    if length > limit:
+        raise ProtocolViolation(
+            f"message of type {message_type!r} is {length} bytes, exceeding "
+            f"{variable} ({limit})"
+        )
+    return message_type, length - 4
+
+
+def check_startup_length(length: int) -> None:
+    """Raise ProtocolViolation for a startup packet length PostgreSQL would reject."""
+    if length < 8 or length > MAX_STARTUP_PACKET_SIZE:
+        raise ProtocolViolation(f"invalid length of startup packet: {length}")
+
+
+def decode_text(data: bytes) -> str:
+    """
+    Decode a UTF-8 text field.
+
+    Raises:
+        InvalidTextEncoding: with PostgreSQL's wording and the offending bytes
+    """
+    try:
+        return data.decode("utf-8")
+    except UnicodeDecodeError as e:
+        offending = data[e.start : min(e.end + 1, e.start + 4)]
+        sequence = " ".join(f"0x{byte:02x}" for byte in offending)
+        raise InvalidTextEncoding(f'invalid byte sequence for encoding "UTF8": {sequence}') from None
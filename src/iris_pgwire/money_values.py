@@ -0,0 +1,150 @@
+"""
+Money Columns and Parameters
+
+IRIS has no money type. MONEY columns in CREATE TABLE / ALTER TABLE are
+created as PGWIRE_MONEY_COLUMN_TYPE (ddl_translator.py), NUMERIC(19,4) by
+default: the range of PostgreSQL's money (int64 cents) with the scale of
+IRIS's own MONEY (%Library.Currency), marked as money by their IRIS column
+description:
+
+    CREATE TABLE orders (total MONEY)
+        -> CREATE TABLE orders (total NUMERIC(19,4) %DESCRIPTION 'pgwire:money')
+
+Only marked columns are reported as money (OID 790): in
+information_schema.columns and pg_attribute, and as result columns named
+like a marked column of a table the statement names (money_result_columns).
+Other NUMERIC columns keep numeric whatever their precision and scale. IRIS
+MONEY / SMALLMONEY columns are reported as money by their type name
+(type_mapping.py). ALTER COLUMN ... TYPE MONEY changes the type without the
+marker.
+
+Money results are sent as text per lc_monetary ($1,234.50,
+value_formatting.format_money). Bind parameters declared money are bound as
+decimal text, from binary (int64 cents, bind_params.py) or from the text
+cash_in accepts: an optional sign or parentheses, the currency symbol,
+digits with thousands separators, and fraction digits rounded half up to
+the locale's. Other text fails Bind with 22P02, amounts beyond money's
+range with 22003.
+"""
+
+import os
+import re
+from decimal import ROUND_HALF_UP, Decimal
+
+import structlog
+
+from .numeric_range import NumericValueOutOfRange
+
+logger = structlog.get_logger()
+
+MONEY_OID = 790
+DEFAULT_MONEY_COLUMN_TYPE = "NUMERIC(19,4)"
+
+# IRIS column description of the columns created as MONEY
+MONEY_MARKER = "pgwire:money"
+
+# Columns created as MONEY, for result columns
+MONEY_COLUMNS_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS "
+    f"WHERE DESCRIPTION = '{MONEY_MARKER}'"
+)
+
+_NUMERIC_OID = 1700
+
+_NUMERIC_TYPE = re.compile(r"^(NUMERIC|DECIMAL)\s*\(\s*(\d+)\s*,\s*(\d+)\s*\)$", re.IGNORECASE)
+
+# PostgreSQL's money range, in cents
+_MAX_CENTS = 2**63 - 1
+
+
+class InvalidMoney(ValueError):
+    """A money Bind parameter that is not an amount (SQLSTATE 22P02)."""
+
+    sqlstate = "22P02"
+    condition_name = "invalid_text_representation"
+
+
+def money_column_type(value: str | None = None) -> str:
+    """
+    IRIS type of MONEY columns, PGWIRE_MONEY_COLUMN_TYPE: NUMERIC(p,s) or
+    DECIMAL(p,s) with s >= 2 (invalid values fall back to the default).
+    """
+    if value is None:
+        value = os.getenv("PGWIRE_MONEY_COLUMN_TYPE", DEFAULT_MONEY_COLUMN_TYPE)
+    match = _NUMERIC_TYPE.match(value.strip())
+    if match:
+        precision, scale = int(match.group(2)), int(match.group(3))
+        if 2 <= scale < precision:
+            return f"{match.group(1).upper()}({precision},{scale})"
+    logger.warning("Ignoring invalid PGWIRE_MONEY_COLUMN_TYPE", value=value)
+    return DEFAULT_MONEY_COLUMN_TYPE
+
+
+def money_column_definition() -> str:
+    """Type and marker of a MONEY column definition."""
+    return f"{money_column_type()} %DESCRIPTION '{MONEY_MARKER}'"
+
+
+def is_money_column(description: str | None) -> bool:
+    """Whether an IRIS column description marks a column created as MONEY."""
+    return isinstance(description, str) and description.strip() == MONEY_MARKER
+
+
+def numeric_result_columns(columns: list) -> list[dict]:
+    """The numeric columns of a result's column descriptions."""
+    return [
+        column
+        for column in columns
+        if isinstance(column, dict) and column.get("type_oid") == _NUMERIC_OID
+    ]
+
+
+def money_result_columns(columns: list[dict], rows, sql: str) -> int:
+    """
+    Describe numeric result columns as money where they are named like a
+    MONEY column (MONEY_COLUMNS_SQL rows) of a table the statement names.
+    Returns the number of columns described as money.
+    """
+    words = {word.upper() for word in re.findall(r"[\w$]+", sql)}
+    names = {str(row[2]).upper() for row in rows if str(row[1]).upper() in words}
+    count = 0
+    for column in numeric_result_columns(columns):
+        if str(column.get("name")).upper() in names:
+            column.update(type_oid=MONEY_OID, type_size=8, type_modifier=-1)
+            count += 1
+    return count
+
+
+def parse_money(text: str, lc_monetary: str = "C") -> str:
+    """
+    Decimal text of money input text, rounded to the locale's fraction
+    digits as cash_in does.
+
+    Raises:
+        InvalidMoney: text is not an amount of money
+        NumericValueOutOfRange: the amount is beyond money's range
+    """
+    # Imported here: value_formatting imports the SQL translator, which imports this module
+    from .value_formatting import monetary_conventions
+
+    conv = monetary_conventions(lc_monetary)
+    body = text.strip()
+    negative = body.startswith("(") and body.endswith(")")  # ($1.00)
+    if negative:
+        body = body[1:-1].strip()
+    # The sign may precede or follow the symbol: -$1.00, $-1.00
+    body = body.replace(conv.symbol, "", 1).strip()
+    if body[:1] in ("-", "+"):
+        negative, body = negative or body[0] == "-", body[1:].strip()
+    whole, point, fraction = body.partition(conv.decimal_point)
+    if conv.thousands_sep:
+        whole = whole.replace(conv.thousands_sep, "")
+    digits = f"{whole}.{fraction}" if point else whole
+    if not re.fullmatch(r"\d+(?:\.\d*)?|\.\d+", digits):
+        raise InvalidMoney(f'invalid input syntax for type money: "{text}"')
+    amount = Decimal(digits).quantize(Decimal(1).scaleb(-conv.frac_digits), rounding=ROUND_HALF_UP)
+    if amount.scaleb(conv.frac_digits) > _MAX_CENTS:
+        raise NumericValueOutOfRange(
+            f'value "{text}" is out of range for type money', text, MONEY_OID
+        )
+    return f"{-amount if negative and amount else amount:f}"
@@ -0,0 +1,476 @@
+"""
+LISTEN / NOTIFY
+
+Applications use PostgreSQL's notifications for cache invalidation and to
+wake up workers:
+
+    LISTEN channel
+    UNLISTEN channel | *
+    NOTIFY channel [, 'payload']
+
+The bridge keeps a process-wide hub of the channels each session listens
+on. As in PostgreSQL, a NOTIFY outside a transaction block is delivered at
+once, while inside one it is queued until COMMIT (identical notifications of
+one transaction are sent once) and dropped on ROLLBACK. A listening session
+gets a NotificationResponse, carrying the notifying session's process ID, at
+once outside a transaction block, even while it runs a query (see
+async_messages.py); in a transaction block, and for its own NOTIFYs, just
+before its next ReadyForQuery outside one.
+
+With PGWIRE_NOTIFY_BRIDGE=global, notifications also travel between bridge
+instances on the same IRIS namespace. Each NOTIFY is appended to the global
+PGWIRE_NOTIFY_GLOBAL (default ^PGWire.Notify) as
+(time, instance, n) = [channel, payload, pid], and every instance reads the
+entries other instances added every PGWIRE_NOTIFY_POLL_INTERVAL (default 1s).
+Entries older than a minute are removed.
+
+LISTEN, UNLISTEN and NOTIFY are run with simple Query messages. The function
+forms work with both simple and extended queries, so drivers can bind the
+channel and payload as parameters:
+
+    SELECT pg_notify($1, $2)
+    SELECT pg_notification_queue_usage()
+
+Limits follow PostgreSQL: channel names are shorter than 64 bytes and
+payloads shorter than 8000 bytes (22023 invalid_parameter_value otherwise).
+Each listening session holds at most PGWIRE_NOTIFY_QUEUE_SIZE (default
+10000) notifications it has not been sent yet, e.g. while it runs a long
+query; a NOTIFY that would exceed that fails with 54000
+program_limit_exceeded, and pg_notification_queue_usage() reports the
+fullest session's share.
+"""
+
+import asyncio
+import itertools
+import json
+import os
+import re
+import secrets
+import time
+from collections import deque
+from collections.abc import Awaitable, Callable, Iterator
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .keepalive import parse_duration_ms
+
+logger = structlog.get_logger()
+
+DEFAULT_NOTIFY_GLOBAL = "^PGWire.Notify"
+DEFAULT_POLL_INTERVAL_MS = 1000
+DEFAULT_QUEUE_SIZE = 10000
+
+# PostgreSQL's NAMEDATALEN and NOTIFY_PAYLOAD_MAX_LENGTH, in bytes
+MAX_CHANNEL_BYTES = 63
+MAX_PAYLOAD_BYTES = 7999
+
+# Global queue entries kept for late readers, and how far back each poll looks
+_RETENTION_NS = 60_000_000_000
+_LOOKBACK_NS = 5_000_000_000
+
+_CHANNEL = r"(?P<channel>\"(?:[^\"]|\"\")+\"|[A-Za-z_][\w$]*)"
+_LISTEN = re.compile(rf"^\s*LISTEN\s+{_CHANNEL}\s*;?\s*$", re.IGNORECASE)
+_UNLISTEN = re.compile(rf"^\s*UNLISTEN\s+(?:\*|{_CHANNEL})\s*;?\s*$", re.IGNORECASE)
+_NOTIFY = re.compile(
+    rf"^\s*NOTIFY\s+{_CHANNEL}\s*(?:,\s*'(?P<payload>(?:[^']|'')*)')?\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+
+# SELECT pg_notify(channel, payload) with literal, NULL or parameter arguments
+_ARGUMENT = (
+    r"(?:'(?:[^']|'')*'|NULL|\$\d+|\?)(?:\s*::\s*[A-Za-z_][\w ]*?(?:\(\s*\d+\s*\))?)?"
+)
+_PG_NOTIFY = re.compile(
+    rf"^\s*SELECT\s+pg_notify\s*\(\s*(?P<channel>{_ARGUMENT})\s*,\s*(?P<payload>{_ARGUMENT})\s*\)"
+    r"(?:\s+AS\s+(?P<alias>\"[^\"]+\"|[A-Za-z_]\w*))?\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_QUEUE_USAGE = re.compile(
+    r"^\s*SELECT\s+pg_notification_queue_usage\s*\(\s*\)"
+    r"(?:\s+AS\s+(?P<alias>\"[^\"]+\"|[A-Za-z_]\w*))?\s*;?\s*$",
+    re.IGNORECASE,
+)
+_VALUE = re.compile(r"'(?:[^']|'')*'|NULL|\$\d+|\?", re.IGNORECASE)  # Without its ::type
+
+
+@dataclass(frozen=True)
+class Notification:
+    channel: str
+    payload: str
+    sender_pid: int
+
+
+@dataclass
+class Listen:
+    channel: str
+
+
+@dataclass
+class Unlisten:
+    channel: str | None  # None = UNLISTEN *
+
+
+@dataclass
+class Notify:
+    channel: str
+    payload: str = ""
+
+
+@dataclass
+class NotifyCall:
+    """SELECT pg_notify(...); arguments are values or parameter indexes."""
+
+    channel: str | int | None
+    payload: str | int | None
+    column: str = "pg_notify"
+
+    @property
+    def parameter_count(self) -> int:
+        indexes = [arg for arg in (self.channel, self.payload) if isinstance(arg, int)]
+        return max(indexes) + 1 if indexes else 0
+
+
+@dataclass
+class QueueUsageCall:
+    """SELECT pg_notification_queue_usage()"""
+
+    column: str = "pg_notification_queue_usage"
+
+
+class NotifyError(Exception):
+    """A NOTIFY PostgreSQL refuses, with its SQLSTATE."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+def _invalid(message: str) -> NotifyError:
+    return NotifyError(message, "22023", "invalid_parameter_value")
+
+
+def check_notification(channel: str | None, payload: str | None) -> str:
+    """
+    Validate a NOTIFY's channel and payload as PostgreSQL does.
+
+    Returns:
+        The payload ("" for NULL)
+
+    Raises:
+        NotifyError: 22023 for an empty or too long channel name, or a too long payload
+    """
+    if not channel:
+        raise _invalid("channel name cannot be empty")
+    if len(channel.encode("utf-8")) > MAX_CHANNEL_BYTES:
+        raise _invalid("channel name too long")
+    payload = payload or ""
+    if len(payload.encode("utf-8")) > MAX_PAYLOAD_BYTES:
+        raise _invalid("payload string too long")
+    return payload
+
+
+def _channel_name(text: str) -> str:
+    if text.startswith('"'):
+        return text[1:-1].replace('""', '"')
+    return text.lower()
+
+
+def parse_notification_command(sql: str) -> Listen | Unlisten | Notify | None:
+    """Parse LISTEN, UNLISTEN or NOTIFY; None for other statements."""
+    match = _LISTEN.match(sql)
+    if match:
+        return Listen(_channel_name(match.group("channel")))
+    match = _UNLISTEN.match(sql)
+    if match:
+        channel = match.group("channel")
+        return Unlisten(_channel_name(channel) if channel else None)
+    match = _NOTIFY.match(sql)
+    if match:
+        payload = (match.group("payload") or "").replace("''", "'")
+        return Notify(_channel_name(match.group("channel")), payload)
+    return None
+
+
+def _argument(text: str, placeholders: Iterator[int]) -> str | int | None:
+    """A pg_notify argument: its value, a 0-based parameter index, or None for NULL."""
+    text = _VALUE.match(text).group()
+    if text.startswith("'"):
+        return text[1:-1].replace("''", "'")
+    if text.upper() == "NULL":
+        return None
+    if text.startswith("$"):
+        return int(text[1:]) - 1
+    return next(placeholders)  # ? placeholders are numbered in order of appearance
+
+
+def _alias(match: re.Match, default: str) -> str:
+    alias = match.group("alias")
+    return _channel_name(alias) if alias else default
+
+
+def parse_notify_call(sql: str) -> NotifyCall | QueueUsageCall | None:
+    """Parse SELECT pg_notify(...) or SELECT pg_notification_queue_usage(); None otherwise."""
+    match = _PG_NOTIFY.match(sql)
+    if match:
+        placeholders = itertools.count()
+        channel = _argument(match.group("channel"), placeholders)
+        payload = _argument(match.group("payload"), placeholders)
+        return NotifyCall(channel, payload, _alias(match, "pg_notify"))
+    match = _QUEUE_USAGE.match(sql)
+    if match:
+        return QueueUsageCall(_alias(match, "pg_notification_queue_usage"))
+    return None
+
+
+def load_queue_size() -> int:
+    """PGWIRE_NOTIFY_QUEUE_SIZE: unsent notifications a listening session may hold."""
+    value = os.getenv("PGWIRE_NOTIFY_QUEUE_SIZE")
+    try:
+        size = int(value) if value else DEFAULT_QUEUE_SIZE
+    except ValueError:
+        logger.warning("Ignoring invalid PGWIRE_NOTIFY_QUEUE_SIZE", value=value)
+        size = DEFAULT_QUEUE_SIZE
+    return size if size > 0 else DEFAULT_QUEUE_SIZE
+
+
+class NotificationHub:
+    """Listening sessions of this bridge process, by channel."""
+
+    def __init__(self, queue_size: int | None = None):
+        self._listeners: dict[str, dict[int, Callable[[Notification], None]]] = {}
+        self._inboxes: dict[int, deque] = {}  # Listening sessions' unsent notifications
+        self.queue_size = load_queue_size() if queue_size is None else queue_size
+        self.bridge: "GlobalQueueBridge | None" = None
+
+    def listen(
+        self,
+        pid: int,
+        channel: str,
+        deliver: Callable[[Notification], None],
+        inbox: deque | None = None,
+    ) -> None:
+        """Deliver channel's notifications to a session; inbox counts against queue_size."""
+        self._listeners.setdefault(channel, {})[pid] = deliver
+        if inbox is not None:
+            self._inboxes[pid] = inbox
+
+    def unlisten(self, pid: int, channel: str | None = None) -> None:
+        """Stop listening on channel (all channels for None)."""
+        for name in [channel] if channel is not None else list(self._listeners):
+            listeners = self._listeners.get(name, {})
+            listeners.pop(pid, None)
+            if not listeners:
+                self._listeners.pop(name, None)
+        if not self.listening(pid):
+            self._inboxes.pop(pid, None)
+
+    def listening(self, pid: int) -> list[str]:
+        """Channels a session listens on."""
+        return sorted(name for name, listeners in self._listeners.items() if pid in listeners)
+
+    def queue_full(self, channel: str) -> bool:
+        """Whether a session listening on channel holds queue_size unsent notifications."""
+        return any(
+            len(self._inboxes.get(pid, ())) >= self.queue_size
+            for pid in self._listeners.get(channel, {})
+        )
+
+    def queue_usage(self) -> float:
+        """Fraction of queue_size taken by the fullest session's unsent notifications."""
+        if not self._inboxes or self.queue_size <= 0:
+            return 0.0
+        return min(1.0, max(len(inbox) for inbox in self._inboxes.values()) / self.queue_size)
+
+    def publish(self, notifications: list[Notification], forward: bool = True) -> None:
+        """Deliver notifications to the listening sessions (and other bridge instances)."""
+        for notification in notifications:
+            for pid, deliver in list(self._listeners.get(notification.channel, {}).items()):
+                if len(self._inboxes.get(pid, ())) >= self.queue_size:
+                    # COMMITted or from another instance: too late to fail the NOTIFY
+                    logger.warning(
+                        "Notification queue full, dropping notification",
+                        pid=pid,
+                        channel=notification.channel,
+                    )
+                    continue
+                deliver(notification)
+        if forward and self.bridge is not None:
+            self.bridge.outbox.extend(notifications)
+
+
+_hub = NotificationHub()
+
+
+def get_notification_hub() -> NotificationHub:
+    """Process-wide notification hub."""
+    return _hub
+
+
+class SessionNotifications:
+    """One session's channels, NOTIFYs awaiting COMMIT and notifications not yet sent."""
+
+    def __init__(self, pid: int, hub: NotificationHub | None = None):
+        self.pid = pid
+        self.hub = get_notification_hub() if hub is None else hub
+        self.inbox: deque[Notification] = deque()
+        self.on_arrival: Callable[[], None] | None = None  # Called as notifications arrive
+        self._pending: list[Notification] = []
+
+    def listen(self, channel: str) -> None:
+        self.hub.listen(self.pid, channel, self._receive, self.inbox)
+
+    def unlisten(self, channel: str | None) -> None:
+        self.hub.unlisten(self.pid, channel)
+
+    def notify(self, channel: str | None, payload: str | None, in_transaction: bool) -> None:
+        """
+        NOTIFY channel, payload (now, or at COMMIT in a transaction block).
+
+        Raises:
+            NotifyError: invalid channel or payload (22023), or a listener's queue is full (54000)
+        """
+        payload = check_notification(channel, payload)
+        if self.hub.queue_full(channel):
+            raise NotifyError(
+                "too many notifications in the NOTIFY queue", "54000", "program_limit_exceeded"
+            )
+        notification = Notification(channel, payload, self.pid)
+        if not in_transaction:
+            self.hub.publish([notification])
+        elif notification not in self._pending:
+            self._pending.append(notification)
+
+    def end_transaction(self, committed: bool) -> None:
+        """Send the transaction's notifications on COMMIT, drop them on ROLLBACK."""
+        pending, self._pending = self._pending, []
+        if committed and pending:
+            self.hub.publish(pending)
+
+    def take(self, exclude_sender: int | None = None) -> list[Notification]:
+        """Notifications received and not yet sent to the client (but exclude_sender's)."""
+        notifications = [n for n in self.inbox if n.sender_pid != exclude_sender]
+        kept = [n for n in self.inbox if n.sender_pid == exclude_sender]
+        self.inbox.clear()
+        self.inbox.extend(kept)
+        return notifications
+
+    def close(self) -> None:
+        self.hub.unlisten(self.pid)
+        self._pending = []
+        self.inbox.clear()
+
+    def _receive(self, notification: Notification) -> None:
+        self.inbox.append(notification)
+        if self.on_arrival is not None:
+            self.on_arrival()
+
+
+class GlobalQueueBridge:
+    """
+    Notifications exchanged with other bridge instances through an IRIS global.
+
+    Args:
+        global_operation: runs a function of a global accessor (see
+            global_tables.py) against IRIS, e.g. IRISExecutor.global_operation
+    """
+
+    def __init__(
+        self,
+        global_operation: Callable[[Callable[[Any], Any]], Awaitable[Any]],
+        global_name: str = DEFAULT_NOTIFY_GLOBAL,
+        origin: str | None = None,
+    ):
+        self.global_operation = global_operation
+        self.global_name = global_name
+        self.origin = origin or secrets.token_hex(8)
+        self.outbox: list[Notification] = []
+        self._seen: dict[tuple, int] = {}  # Entries already delivered -> their time
+
+    async def exchange(self, hub: NotificationHub) -> None:
+        """Write the outbox to the global and deliver other instances' new entries."""
+        outgoing, self.outbox = self.outbox, []
+        incoming = await self.global_operation(
+            lambda accessor: self.sync_exchange(accessor, outgoing, time.time_ns())
+        )
+        hub.publish(incoming, forward=False)
+
+    def sync_exchange(self, accessor, outgoing: list[Notification], now: int) -> list:
+        """Append outgoing, remove expired entries and read new ones (thread pool)."""
+        for n, notification in enumerate(outgoing):
+            value = json.dumps(
+                [notification.channel, notification.payload, notification.sender_pid]
+            )
+            accessor.set(self.global_name, [now, self.origin, n], value)
+
+        stamp = accessor.next_subscript(self.global_name, [], "")
+        while stamp is not None and int(stamp) < now - _RETENTION_NS:
+            accessor.kill(self.global_name, [stamp])
+            stamp = accessor.next_subscript(self.global_name, [], stamp)
+
+        start = now - _LOOKBACK_NS
+        incoming = []
+        stamp = accessor.next_subscript(self.global_name, [], start - 1)
+        while stamp is not None:
+            origin = accessor.next_subscript(self.global_name, [stamp], "")
+            while origin is not None:
+                if origin != self.origin:
+                    incoming.extend(self._read_entries(accessor, stamp, origin))
+                origin = accessor.next_subscript(self.global_name, [stamp], origin)
+            stamp = accessor.next_subscript(self.global_name, [], stamp)
+
+        self._seen = {key: at for key, at in self._seen.items() if at >= start}
+        return incoming
+
+    def _read_entries(self, accessor, stamp, origin) -> list[Notification]:
+        notifications = []
+        n = accessor.next_subscript(self.global_name, [stamp, origin], "")
+        while n is not None:
+            key = (stamp, origin, n)
+            if key not in self._seen:
+                self._seen[key] = int(stamp)
+                channel, payload, pid = json.loads(
+                    accessor.get(self.global_name, [stamp, origin, n])
+                )
+                notifications.append(Notification(channel, payload, pid))
+            n = accessor.next_subscript(self.global_name, [stamp, origin], n)
+        return notifications
+
+
+def load_poll_interval() -> float:
+    """PGWIRE_NOTIFY_POLL_INTERVAL in seconds."""
+    value = os.getenv("PGWIRE_NOTIFY_POLL_INTERVAL")
+    try:
+        interval_ms = parse_duration_ms(value) if value else DEFAULT_POLL_INTERVAL_MS
+    except ValueError:
+        logger.warning("Ignoring invalid PGWIRE_NOTIFY_POLL_INTERVAL", value=value)
+        interval_ms = DEFAULT_POLL_INTERVAL_MS
+    return (interval_ms or DEFAULT_POLL_INTERVAL_MS) / 1000
+
+
+async def run_bridge(bridge: GlobalQueueBridge, hub: NotificationHub, interval: float) -> None:
+    """Exchange notifications with other instances every interval, until cancelled."""
+    while True:
+        try:
+            await bridge.exchange(hub)
+        except Exception as e:
+            logger.warning("Notification bridge exchange failed", error=str(e))
+        await asyncio.sleep(interval)
+
+
+def start_notification_bridge(global_operation) -> asyncio.Task | None:
+    """Start the PGWIRE_NOTIFY_BRIDGE=global poller (None when not configured)."""
+    mode = os.getenv("PGWIRE_NOTIFY_BRIDGE", "off").strip().lower()
+    if mode in ("off", "none", ""):
+        return None
+    if mode != "global":
+        logger.warning("Ignoring unknown PGWIRE_NOTIFY_BRIDGE", value=mode)
+        return None
+    hub = get_notification_hub()
+    hub.bridge = GlobalQueueBridge(
+        global_operation, os.getenv("PGWIRE_NOTIFY_GLOBAL", DEFAULT_NOTIFY_GLOBAL)
+    )
+    logger.info("Notification bridge started", global_name=hub.bridge.global_name)
+    return asyncio.ensure_future(run_bridge(hub.bridge, hub, load_poll_interval()))
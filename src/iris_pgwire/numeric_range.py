@@ -0,0 +1,86 @@
+"""
+Strict Integer Range Checking for Wire Values
+
+PostgreSQL rejects integers that do not fit their declared type with SQLSTATE
+22003 (numeric_value_out_of_range). Python ints are unbounded, so without an
+explicit check an oversized value either wraps when packed into a binary field
+or reaches IRIS and is silently truncated to the column range.
+
+This module is used at two boundaries:
+1. Bind: text and binary parameters declared as int2/int4/int8
+2. DataRow: result values in columns described as int2/int4/int8
+"""
+
+from typing import Any
+
+# PostgreSQL integer type OID → (type name, minimum, maximum)
+INTEGER_TYPE_RANGES: dict[int, tuple[str, int, int]] = {
+    21: ("smallint", -(2**15), 2**15 - 1),  # int2
+    23: ("integer", -(2**31), 2**31 - 1),  # int4
+    20: ("bigint", -(2**63), 2**63 - 1),  # int8
+}
+
+# Binary integer wire widths → struct format
+BINARY_INTEGER_FORMATS: dict[int, str] = {2: "!h", 4: "!i", 8: "!q"}
+
+SQLSTATE_NUMERIC_VALUE_OUT_OF_RANGE = "22003"
+
+
+class NumericValueOutOfRange(ValueError):
+    """Integer value does not fit the PostgreSQL type it is declared as."""
+
+    sqlstate = SQLSTATE_NUMERIC_VALUE_OUT_OF_RANGE
+    condition_name = "numeric_value_out_of_range"
+
+    def __init__(self, message: str, value: Any = None, type_oid: int | None = None):
+        super().__init__(message)
+        self.value = value
+        self.type_oid = type_oid
+
+
+def is_integer_type(type_oid: int) -> bool:
+    """Return True for int2/int4/int8 OIDs."""
+    return type_oid in INTEGER_TYPE_RANGES
+
+
+def check_integer_range(value: int, type_oid: int) -> int:
+    """
+    Validate an integer against the range of an int2/int4/int8 OID.
+
+    Args:
+        value: Integer value to validate
+        type_oid: PostgreSQL type OID (non-integer OIDs pass through)
+
+    Returns:
+        The value unchanged when it fits
+
+    Raises:
+        NumericValueOutOfRange: value exceeds the type range (message matches PostgreSQL)
+    """
+    bounds = INTEGER_TYPE_RANGES.get(type_oid)
+    if bounds is None:
+        return value
+
+    type_name, minimum, maximum = bounds
+    if value < minimum or value > maximum:
+        raise NumericValueOutOfRange(f"{type_name} out of range", value=value, type_oid=type_oid)
+    return value
+
+
+def parse_integer_text(text: str, type_oid: int) -> int:
+    """
+    Parse a text-format integer parameter and validate it against its declared type.
+
+    Raises:
+        NumericValueOutOfRange: value is syntactically valid but exceeds the type range
+        ValueError: text is not an integer literal
+    """
+    value = int(text.strip())
+    bounds = INTEGER_TYPE_RANGES.get(type_oid)
+    if bounds is not None and not bounds[1] <= value <= bounds[2]:
+        raise NumericValueOutOfRange(
+            f'value "{text}" is out of range for type {bounds[0]}',
+            value=value,
+            type_oid=type_oid,
+        )
+    return value
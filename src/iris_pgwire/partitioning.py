@@ -0,0 +1,329 @@
+"""
+Declarative Partitioning
+
+IRIS has no declarative table partitioning. Partitioned tables in PostgreSQL
+schemas (time-series tables, pg_partman or framework partition packages) are
+accepted and mapped:
+
+    CREATE TABLE measurement (city_id INT, logdate DATE) PARTITION BY RANGE (logdate);
+    CREATE TABLE measurement_y2024 PARTITION OF measurement
+        FOR VALUES FROM ('2024-01-01') TO ('2025-01-01');
+
+- PARTITION BY is removed: the partitioned table is a plain IRIS table that
+  holds every row, and the client receives a NOTICE saying so. With
+  PGWIRE_HASH_PARTITIONS_AS_SHARD_KEY=true, PARTITION BY HASH (columns)
+  becomes SHARD KEY (columns) instead, spreading rows over the data nodes of
+  a sharded IRIS cluster.
+- PARTITION OF creates a view of the parent's rows within the partition
+  bound (key >= lower AND key < upper for RANGE, key IN (...) for LIST).
+  Single-table IRIS views are updatable, so partitions can be read and
+  written by name. HASH and DEFAULT partitions, and bounds over several key
+  columns, cannot be written as a filter: those views show every row of the
+  parent.
+- DROP TABLE of a partition drops its view; DROP TABLE of a partitioned
+  table drops its partitions' views first.
+
+Keys and bounds are kept in an IRIS global (PGWIRE_PARTITIONS_GLOBAL,
+default ^PGWire.Partitions):
+
+    ^PGWire.Partitions(schema, table) = "RANGE (logdate)"
+    ^PGWire.Partitions(schema, table, partition) = "FOR VALUES FROM (...) TO (...)"
+
+and reported through pg_partitioned_table (single-view SELECTs). Partitioned
+tables keep relkind 'r' in pg_class, as they are plain tables in IRIS.
+ATTACH / DETACH PARTITION are not supported.
+"""
+
+import os
+import re
+from dataclasses import dataclass
+from typing import Any
+
+from .check_constraints import _IDENTIFIER, _closing_paren, _unquote
+from .sql_text import split_top_level
+
+DEFAULT_PARTITIONS_GLOBAL = "^PGWire.Partitions"
+
+VIEW_NAME = "pg_partitioned_table"
+
+_RELATION = rf"{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?"
+
+_CREATE_TABLE = re.compile(
+    r"^\s*CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+"
+    rf"(?:IF\s+NOT\s+EXISTS\s+)?(?P<name>{_RELATION})\s*",
+    re.IGNORECASE,
+)
+_PARTITION_OF = re.compile(rf"PARTITION\s+OF\s+(?P<parent>{_RELATION})\s*", re.IGNORECASE)
+_PARTITION_BY = re.compile(r"\s*\bPARTITION\s+BY\s+(?P<strategy>RANGE|LIST|HASH)\s*\(", re.I)
+_BOUND = re.compile(r"DEFAULT\b|FOR\s+VALUES\s+(?P<kind>FROM|IN|WITH)\s*\(", re.IGNORECASE)
+_TO = re.compile(r"\s*TO\s*\(", re.IGNORECASE)
+_DROP_TABLE = re.compile(
+    rf"^\s*DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?P<name>{_RELATION})"
+    r"(?:\s+(?:CASCADE|RESTRICT))?\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+_VIEW_REFERENCE = re.compile(r"\bFROM\s+(?:pg_catalog\.)?pg_partitioned_table\b", re.IGNORECASE)
+_JOIN = re.compile(r"\bJOIN\b|\bFROM\s+[\w.\"]+(?:\s+(?:AS\s+)?\w+)?\s*,", re.IGNORECASE)
+
+# Type OIDs
+_CHAR = 18
+_INT2 = 21
+_OID = 26
+_TEXT = 25
+
+PG_PARTITIONED_TABLE_COLUMNS = [
+    ("partrelid", _OID),
+    ("partstrat", _CHAR),
+    ("partnatts", _INT2),
+    ("partdefid", _OID),
+    ("partattrs", _TEXT),  # int2vector, rendered as in PostgreSQL ("1 2")
+    ("partclass", _TEXT),
+    ("partcollation", _TEXT),
+    ("partexprs", _TEXT),
+]
+
+
+@dataclass
+class PartitionDDL:
+    relation: str  # Table named by the statement, as written
+    statement: str | None = None  # CREATE TABLE left for IRIS (PARTITION BY removed)
+    key: str | None = None  # Partition key of a partitioned table: "RANGE (logdate)"
+    parent: str | None = None  # PARTITION OF parent, as written
+    bound: str | None = None  # "FOR VALUES ..." or "DEFAULT"
+    drop: bool = False  # DROP TABLE
+
+
+def hash_partitions_as_shard_key() -> bool:
+    """Whether PARTITION BY HASH becomes an IRIS SHARD KEY."""
+    return os.getenv("PGWIRE_HASH_PARTITIONS_AS_SHARD_KEY", "false").lower() in (
+        "true",
+        "1",
+        "yes",
+        "on",
+    )
+
+
+def _take_partition_by(sql: str, start: int) -> tuple[str, str | None]:
+    """Remove PARTITION BY ... found after start: (rest, "STRATEGY (key)")."""
+    match = _PARTITION_BY.search(sql, start)
+    if not match:
+        return sql, None
+    close = _closing_paren(sql, match.end() - 1)
+    if close < 0:
+        return sql, None
+    key = f"{match.group('strategy').upper()} ({sql[match.end() : close].strip()})"
+    return (sql[: match.start()] + sql[close + 1 :]).strip(), key
+
+
+def parse_partition_ddl(sql: str) -> PartitionDDL | None:
+    """
+    CREATE TABLE ... PARTITION BY, CREATE TABLE ... PARTITION OF, or a
+    single-table DROP TABLE (None for any other statement).
+    """
+    sql = sql.strip().rstrip(";").rstrip()
+    upper = sql.upper()
+    if "PARTITION" not in upper and not upper.startswith("DROP"):
+        return None
+
+    if match := _DROP_TABLE.match(sql):
+        return PartitionDDL(match.group("name"), drop=True)
+
+    match = _CREATE_TABLE.match(sql)
+    if not match:
+        return None
+
+    partition_of = _PARTITION_OF.match(sql, match.end())
+    if partition_of:
+        position = partition_of.end()
+        if sql.startswith("(", position):
+            # Column constraints of the partition (inherited from the parent in IRIS)
+            position = _closing_paren(sql, position) + 1
+            if position == 0:
+                return None
+        while sql[position : position + 1].isspace():
+            position += 1
+        bound = _BOUND.match(sql, position)
+        if not bound:
+            return None
+        end = bound.end()
+        if bound.group("kind"):
+            end = _closing_paren(sql, bound.end() - 1) + 1
+            if bound.group("kind").upper() == "FROM" and (to := _TO.match(sql, end)):
+                end = _closing_paren(sql, to.end() - 1) + 1
+        _, key = _take_partition_by(sql, end)
+        return PartitionDDL(
+            match.group("name"),
+            key=key,
+            parent=partition_of.group("parent"),
+            bound=sql[position:end].strip(),
+        )
+
+    if not sql.startswith("(", match.end()):
+        return None
+    close = _closing_paren(sql, match.end())
+    statement, key = _take_partition_by(sql, close)
+    if key is None:
+        return None
+    if key.startswith("HASH") and hash_partitions_as_shard_key():
+        columns = key[len("HASH (") : -1]
+        statement = f"{statement[:close]}, SHARD KEY ({columns}){statement[close:]}"
+    return PartitionDDL(match.group("name"), statement=statement, key=key)
+
+
+def sql_identifier(name: str) -> str:
+    """A stored (unquoted) name as an SQL identifier, quoted only when it has to be."""
+    if re.fullmatch(r"[a-z_][a-z0-9_$]*", name):
+        return name
+    return '"' + name.replace('"', '""') + '"'
+
+
+def bound_filter(key: str, bound: str) -> str | None:
+    """
+    WHERE condition selecting a partition's rows from its parent (None when the
+    bound cannot be written as one: HASH, DEFAULT or several key columns).
+    """
+    strategy, _, columns = key.partition(" ")
+    columns = split_top_level(columns[1:-1])
+    if len(columns) != 1:
+        return None
+    column = columns[0]
+
+    values = re.match(r"FOR\s+VALUES\s+(FROM|IN)\s*\(", bound, re.IGNORECASE)
+    if not values:
+        return None
+    close = _closing_paren(bound, values.end() - 1)
+    first = bound[values.end() : close].strip()
+    if values.group(1).upper() == "IN" and strategy == "LIST":
+        items = split_top_level(first)
+        listed = [item for item in items if item.upper() != "NULL"]
+        conditions = [f"{column} IN ({', '.join(listed)})"] if listed else []
+        if len(listed) != len(items):
+            conditions.append(f"{column} IS NULL")
+        return " OR ".join(conditions)
+    to = _TO.match(bound, close + 1)
+    if values.group(1).upper() == "FROM" and strategy == "RANGE" and to:
+        upper = bound[to.end() : _closing_paren(bound, to.end() - 1)].strip()
+        conditions = []
+        if first.upper() != "MINVALUE":
+            conditions.append(f"{column} >= {first}")
+        if upper.upper() != "MAXVALUE":
+            conditions.append(f"{column} < {upper}")
+        return " AND ".join(conditions) or None
+    return None
+
+
+def partition_view_sql(partition: str, parent: str, key: str, bound: str) -> str:
+    """CREATE VIEW standing in for a partition (table names as IRIS SQL)."""
+    sql = f"CREATE VIEW {partition} AS SELECT * FROM {parent}"
+    condition = bound_filter(key, bound)
+    return f"{sql} WHERE {condition}" if condition else sql
+
+
+def referenced_view(sql: str) -> bool:
+    """Whether a statement is a single-view SELECT on pg_partitioned_table."""
+    return bool(_VIEW_REFERENCE.search(sql)) and not _JOIN.search(sql)
+
+
+class PartitionStore:
+    """Partition keys and bounds in the IRIS global, through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv(
+            "PGWIRE_PARTITIONS_GLOBAL", DEFAULT_PARTITIONS_GLOBAL
+        )
+
+    def _subscripts_under(self, prefix: list) -> list:
+        found, previous = [], ""
+        while True:
+            previous = self.accessor.next_subscript(self.global_name, prefix, previous)
+            if previous is None:
+                return found
+            found.append(previous)
+
+    def _value(self, subscripts: list) -> str | None:
+        if self.accessor.data(self.global_name, subscripts) in (0, 10):
+            return None
+        return self.accessor.get(self.global_name, subscripts)
+
+    def set_key(self, schema: str, table: str, key: str) -> None:
+        """Record a partitioned table (forgetting partitions of a dropped namesake)."""
+        self.accessor.kill(self.global_name, [schema, table])
+        self.accessor.set(self.global_name, [schema, table], key)
+
+    def key(self, schema: str, table: str) -> str | None:
+        return self._value([schema, table])
+
+    def add_partition(self, schema: str, parent: str, partition: str, bound: str) -> None:
+        self.accessor.set(self.global_name, [schema, parent, partition], bound)
+
+    def partitions(self, schema: str, parent: str) -> list[str]:
+        return self._subscripts_under([schema, parent])
+
+    def parent(self, schema: str, partition: str) -> str | None:
+        for table in self._subscripts_under([schema]):
+            if self._value([schema, table, partition]) is not None:
+                return table
+        return None
+
+    def remove(self, schema: str, table: str) -> None:
+        """Forget a table, as partitioned table and as partition."""
+        parent = self.parent(schema, table)
+        if parent is not None:
+            self.accessor.kill(self.global_name, [schema, parent, table])
+        self.accessor.kill(self.global_name, [schema, table])
+
+    def rows(self) -> list[tuple[str, str, str, str | None]]:
+        """Every partitioned table as (schema, table, key, default partition)."""
+        rows = []
+        for schema in self._subscripts_under([]):
+            for table in self._subscripts_under([schema]):
+                key = self.key(schema, table)
+                if key is None:
+                    continue
+                default = next(
+                    (
+                        partition
+                        for partition in self.partitions(schema, table)
+                        if self._value([schema, table, partition]).upper() == "DEFAULT"
+                    ),
+                    None,
+                )
+                rows.append((schema, table, key, default))
+        return rows
+
+
+def view_rows(
+    stored: list[tuple[str, str, str, str | None]],
+    oid_generator,
+    attnums: dict[tuple[str, str], dict[str, int]],
+) -> list[dict[str, Any]]:
+    """
+    pg_partitioned_table rows.
+
+    Args:
+        stored: PartitionStore.rows()
+        oid_generator: OIDGenerator giving the OIDs pg_class reports
+        attnums: Column numbers by lowercased column name, per (schema, table)
+    """
+    rows = []
+    for schema, table, key, default in stored:
+        strategy, _, columns = key.partition(" ")
+        keys = split_top_level(columns[1:-1])
+        numbers = attnums.get((schema, table), {})
+        # Expression keys are 0 in partattrs, as in PostgreSQL
+        partattrs = [numbers.get(_unquote(column), 0) for column in keys]
+        rows.append(
+            {
+                "partrelid": oid_generator.get_table_oid(schema, table),
+                "partstrat": strategy[0].lower(),
+                "partnatts": len(keys),
+                "partdefid": oid_generator.get_table_oid(schema, default) if default else 0,
+                "partattrs": " ".join(str(number) for number in partattrs),
+                "partclass": " ".join("0" for _ in keys),
+                "partcollation": " ".join("0" for _ in keys),
+                "partexprs": None,
+            }
+        )
+    return rows
@@ -0,0 +1,76 @@
+"""
+Extended Query Pipelines
+
+Clients such as pgx v5, libpq (PQenterPipelineMode) and asyncpg's
+executemany send many Parse/Bind/Describe/Execute sequences before a single
+Sync, and often write the whole batch before reading any response. As in
+PostgreSQL:
+
+- every message is answered in order as it arrives; ReadyForQuery is sent
+  only for Sync
+- Flush sends the responses produced so far without ending the pipeline
+- after an error the remaining messages up to the next Sync are skipped
+  (not answered), then Sync reports ReadyForQuery
+
+Waiting for the socket to take each response before reading the next
+message deadlocks a pipeline: once the socket buffers fill, the bridge waits
+for the client to read while the client waits for the bridge to accept the
+rest of the batch. While a pipeline is open the bridge therefore keeps
+writing responses without waiting for the client to take them, until Flush
+or Sync, or until PGWIRE_PIPELINE_BUFFER_BYTES (default 16MB) are
+outstanding, which bounds the memory one connection can hold.
+"""
+
+import os
+
+import structlog
+
+logger = structlog.get_logger()
+
+DEFAULT_PIPELINE_BUFFER_BYTES = 16 * 1024 * 1024
+
+
+def load_pipeline_buffer_bytes() -> int:
+    """PGWIRE_PIPELINE_BUFFER_BYTES (responses written without waiting on the client)."""
+    value = os.getenv("PGWIRE_PIPELINE_BUFFER_BYTES")
+    if value is None:
+        return DEFAULT_PIPELINE_BUFFER_BYTES
+    try:
+        limit = int(value)
+    except ValueError:
+        limit = -1
+    if limit < 0:
+        logger.warning("Ignoring invalid PGWIRE_PIPELINE_BUFFER_BYTES", value=value)
+        return DEFAULT_PIPELINE_BUFFER_BYTES
+    return limit
+
+
+class PipelineWriter:
+    """StreamWriter wrapper that holds back drain() while a pipeline is open."""
+
+    def __init__(self, stream, limit: int | None = None):
+        self.stream = stream
+        self.limit = load_pipeline_buffer_bytes() if limit is None else limit
+        self.pipelining = False
+        self.outstanding = 0  # Bytes written since the last drain
+
+    def write(self, data: bytes) -> None:
+        self.outstanding += len(data)
+        self.stream.write(data)
+
+    def writelines(self, data) -> None:
+        for chunk in data:
+            self.write(chunk)
+
+    async def drain(self) -> None:
+        if self.pipelining and self.outstanding < self.limit:
+            return
+        await self.flush()
+
+    async def flush(self) -> None:
+        """Wait until the client has taken the responses (Flush, Sync)."""
+        self.outstanding = 0
+        await self.stream.drain()
+
+    def __getattr__(self, name):
+        return getattr(self.stream, name)
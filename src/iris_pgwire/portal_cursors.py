@@ -0,0 +1,144 @@
+"""
+Per-Portal Result Sets (Extended Query Protocol)
+
+PostgreSQL lets a session keep several portals open and fetch from them in any
+order - Execute(A, 100 rows), Execute(B, 100 rows), Execute(A, 100 rows) - which
+psycopg named cursors and ETL tools that read one result while writing another
+depend on.
+
+Each portal owns the IRIS result set produced when it first runs (Describe or
+Execute) together with its own read position, so fetching from one portal never
+disturbs another. Execute's max_rows limits a fetch; a portal with rows left is
+suspended (PortalSuspended) and resumes on its next Execute.
+
+Lifetime follows PostgreSQL: a portal's result set lives until the portal is
+closed, rebound, or the transaction ends (Sync outside an explicit transaction
+block drops all portals). PGWIRE_MAX_OPEN_PORTALS (default 64) caps how many
+result sets one session may hold open. A result set larger than
+PGWIRE_SPOOL_MEMORY_BYTES is moved to a temporary spool file
+(temp_spool.py) while the portal stays open, so suspended portals of large
+queries do not hold their rows in memory.
+"""
+
+import os
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .temp_spool import SpoolConfig, TempSpool
+
+logger = structlog.get_logger()
+
+DEFAULT_MAX_OPEN_PORTALS = 64
+
+
+class TooManyOpenPortals(Exception):
+    """Session exceeded PGWIRE_MAX_OPEN_PORTALS (SQLSTATE 54000)."""
+
+    sqlstate = "54000"
+    condition_name = "program_limit_exceeded"
+
+
+@dataclass
+class PortalCursor:
+    """Result set of one portal and the position of the next row to send."""
+
+    result: dict[str, Any]
+    position: int = 0
+    spool: TempSpool | None = None  # The rows, when spilled out of result
+
+    @property
+    def rows(self) -> list:
+        if self.spool is not None:
+            return self.spool.rows(0)
+        return self.result.get("rows") or []
+
+    @property
+    def row_count(self) -> int:
+        if self.spool is not None:
+            return len(self.spool)
+        return len(self.result.get("rows") or [])
+
+    @property
+    def returns_rows(self) -> bool:
+        """Whether the portal's statement produces a row set (has columns)."""
+        return bool(self.result.get("columns"))
+
+    @property
+    def exhausted(self) -> bool:
+        return self.position >= self.row_count
+
+    def fetch(self, max_rows: int = 0) -> list:
+        """Return the next batch of rows (max_rows 0 = all remaining)."""
+        count = self.row_count
+        end = count if max_rows <= 0 else min(self.position + max_rows, count)
+        if self.spool is not None:
+            batch = self.spool.rows(self.position, end)
+        else:
+            batch = self.rows[self.position : end]
+        self.position = end
+        return batch
+
+    def close(self) -> None:
+        if self.spool is not None:
+            self.spool.close()
+
+
+class PortalCursorRegistry:
+    """Open portal result sets of one session."""
+
+    def __init__(self, max_open: int | None = None, spool_config: SpoolConfig | None = None):
+        if max_open is None:
+            max_open = int(os.getenv("PGWIRE_MAX_OPEN_PORTALS", str(DEFAULT_MAX_OPEN_PORTALS)))
+        self.max_open = max_open
+        self.spool_config = spool_config  # None = PGWIRE_SPOOL_* settings when a spool opens
+        self._cursors: dict[str, PortalCursor] = {}
+
+    def __contains__(self, name: str) -> bool:
+        return name in self._cursors
+
+    def __len__(self) -> int:
+        return len(self._cursors)
+
+    def open(self, name: str, result: dict[str, Any]) -> PortalCursor:
+        """
+        Attach a result set to a portal (replacing any previous one).
+
+        Raises:
+            TooManyOpenPortals: the session already holds max_open result sets
+            TempFileLimitExceeded: spilling the rows would exceed PGWIRE_SPOOL_MAX_BYTES
+        """
+        if name not in self._cursors and len(self._cursors) >= self.max_open:
+            raise TooManyOpenPortals(
+                f"too many open portals (PGWIRE_MAX_OPEN_PORTALS = {self.max_open})"
+            )
+        cursor = PortalCursor(result)
+        if result.get("rows"):
+            spool = TempSpool(self.spool_config)
+            try:
+                spool.append(result["rows"])
+            except Exception:
+                spool.close()
+                raise
+            if spool.spilled:
+                cursor = PortalCursor({**result, "rows": []}, spool=spool)
+            else:
+                spool.close()
+        self.close(name)
+        self._cursors[name] = cursor
+        logger.debug("Portal result set opened", portal=name, rows=cursor.row_count)
+        return cursor
+
+    def get(self, name: str) -> PortalCursor | None:
+        return self._cursors.get(name)
+
+    def close(self, name: str) -> None:
+        cursor = self._cursors.pop(name, None)
+        if cursor is not None:
+            cursor.close()
+
+    def close_all(self) -> None:
+        cursors, self._cursors = list(self._cursors.values()), {}
+        for cursor in cursors:
+            cursor.close()
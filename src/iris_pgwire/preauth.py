@@ -0,0 +1,123 @@
+"""
+Pre-Authentication Limits
+
+A connection that has not finished authenticating costs a file descriptor and
+a task but has proven nothing. Two limits keep half-open or malicious clients
+(slowloris-style trickling, endless SSLRequest loops, huge SASL messages) from
+holding those resources:
+
+- the whole handshake - SSL/GSSENC negotiation, StartupMessage and
+  authentication exchange - must finish within the authentication timeout,
+  otherwise the connection is closed (PostgreSQL's authentication_timeout)
+- the client may send at most a fixed number of bytes before it is
+  authenticated; exceeding it is a FATAL 08P01 protocol violation
+
+Configuration:
+- PGWIRE_AUTHENTICATION_TIMEOUT: seconds (default 60, 0 = no limit)
+- PGWIRE_MAX_PREAUTH_BYTES: bytes (default 131072)
+"""
+
+import asyncio
+import os
+
+import structlog
+
+from .message_framing import ProtocolViolation
+
+logger = structlog.get_logger()
+
+DEFAULT_AUTHENTICATION_TIMEOUT = 60.0
+DEFAULT_MAX_PREAUTH_BYTES = 128 * 1024
+
+
+class PreAuthLimitExceeded(ProtocolViolation):
+    """Client sent more data than allowed before authenticating."""
+
+
+def load_authentication_timeout() -> float | None:
+    """PGWIRE_AUTHENTICATION_TIMEOUT in seconds (None = no limit)."""
+    value = os.getenv("PGWIRE_AUTHENTICATION_TIMEOUT", str(DEFAULT_AUTHENTICATION_TIMEOUT))
+    try:
+        timeout = float(value)
+    except ValueError:
+        logger.warning("Ignoring invalid PGWIRE_AUTHENTICATION_TIMEOUT", value=value)
+        return DEFAULT_AUTHENTICATION_TIMEOUT
+    return timeout if timeout > 0 else None
+
+
+def load_max_preauth_bytes() -> int:
+    """PGWIRE_MAX_PREAUTH_BYTES (values below the 10000-byte startup packet limit are raised)."""
+    value = os.getenv("PGWIRE_MAX_PREAUTH_BYTES", str(DEFAULT_MAX_PREAUTH_BYTES))
+    try:
+        limit = int(value)
+    except ValueError:
+        logger.warning("Ignoring invalid PGWIRE_MAX_PREAUTH_BYTES", value=value)
+        return DEFAULT_MAX_PREAUTH_BYTES
+    return max(limit, 10000)
+
+
+class PreAuthReader:
+    """
+    StreamReader wrapper that stops reading once a byte budget is spent.
+
+    The budget is checked before each read, so a client-supplied length can
+    never make the server buffer more than the remaining budget.
+    """
+
+    def __init__(self, stream: asyncio.StreamReader, max_bytes: int):
+        self.stream = stream
+        self.max_bytes = max_bytes
+        self.bytes_read = 0
+
+    def _reserve(self, n: int) -> None:
+        if self.bytes_read + n > self.max_bytes:
+            raise PreAuthLimitExceeded(
+                f"client sent more than {self.max_bytes} bytes before authenticating"
+            )
+        self.bytes_read += n
+
+    async def readexactly(self, n: int) -> bytes:
+        self._reserve(n)
+        return await self.stream.readexactly(n)
+
+    async def read(self, n: int = -1) -> bytes:
+        remaining = self.max_bytes - self.bytes_read
+        if n < 0 or n > remaining:
+            n = remaining
+        if n == 0:
+            self._reserve(1)
+        data = await self.stream.read(n)
+        self.bytes_read += len(data)
+        return data
+
+    def __getattr__(self, name):
+        return getattr(self.stream, name)
+
+
+async def run_handshake(
+    protocol,
+    ssl_context,
+    timeout: float | None,
+    max_bytes: int,
+) -> None:
+    """
+    Run SSL negotiation and the startup/authentication sequence under both limits.
+
+    Raises:
+        ConnectionAbortedError: handshake timed out or a limit was exceeded
+    """
+    stream = protocol.reader
+    protocol.reader = PreAuthReader(stream, max_bytes)
+    try:
+        async with asyncio.timeout(timeout):
+            await protocol.handle_ssl_probe(ssl_context)
+            await protocol.handle_startup_sequence()
+    except TimeoutError:
+        logger.warning(
+            "Canceling authentication due to timeout",
+            connection_id=protocol.connection_id,
+            timeout=timeout,
+        )
+        raise ConnectionAbortedError("authentication timeout") from None
+    finally:
+        protocol.reader = stream
@@ -0,0 +1,237 @@
+"""
+Privilege Inquiry Functions (has_table_privilege / has_schema_privilege)
+
+Admin UIs decide what to offer by asking the server what the current role
+may do:
+
+    SELECT has_table_privilege('public.orders', 'INSERT')
+    SELECT has_table_privilege('bi_reader', 'sales.returns', 'SELECT, UPDATE')
+    SELECT has_schema_privilege('reporting', 'USAGE') AS can_use
+
+SELECTs made only of these calls with literal arguments are answered here;
+the calls inside larger catalog queries keep their existing handling.
+
+Table privileges are checked against IRIS SQL privileges with
+%SYSTEM.SQL.Security.CheckPrivilege (tables, then views). Without a user
+argument the account checked is the IRIS user that runs the session's
+statements: the client's own login in passthrough mode, otherwise the
+service account. As in PostgreSQL, a list of privileges is true when any of
+them is held; "WITH GRANT OPTION" is accepted and ignored.
+
+IRIS has no schema privileges: USAGE is always granted (access is decided per
+table) and CREATE follows whether the session may run DDL. Read-only
+sessions (default_transaction_read_only) report no INSERT, UPDATE, DELETE,
+TRUNCATE or CREATE privilege for themselves, matching what the bridge will
+allow.
+"""
+
+import re
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .read_only import session_read_only
+from .sql_text import split_top_level
+
+logger = structlog.get_logger()
+
+_BOOL = 16
+
+SECURITY_CLASS = "%SYSTEM.SQL.Security"
+
+# IRIS CheckPrivilege object types
+_IRIS_TABLE = 1
+_IRIS_VIEW = 3
+
+# PostgreSQL table privilege -> IRIS action letter
+TABLE_PRIVILEGES = {
+    "SELECT": "s",
+    "INSERT": "i",
+    "UPDATE": "u",
+    "DELETE": "d",
+    "TRUNCATE": "d",  # IRIS TRUNCATE TABLE requires DELETE
+    "REFERENCES": "r",
+    "TRIGGER": "a",  # Creating triggers requires ALTER in IRIS
+}
+SCHEMA_PRIVILEGES = ("USAGE", "CREATE")
+_WRITE_PRIVILEGES = frozenset({"INSERT", "UPDATE", "DELETE", "TRUNCATE", "TRIGGER", "CREATE"})
+
+_SELECT = re.compile(r"^\s*SELECT\s+(?P<items>.+?)\s*;?\s*$", re.IGNORECASE | re.DOTALL)
+_CALL = re.compile(
+    r"^(?:pg_catalog\.)?(?P<name>has_table_privilege|has_schema_privilege)\s*"
+    r"\((?P<args>.*)\)(?:\s+(?:AS\s+)?(?P<alias>\w+|\"[^\"]+\"))?$",
+    re.IGNORECASE | re.DOTALL,
+)
+_LITERAL = re.compile(r"^'((?:[^']|'')*)'$", re.DOTALL)
+
+
+class InvalidPrivilegeType(ValueError):
+    """Unknown privilege name (SQLSTATE 22023)."""
+
+    sqlstate = "22023"
+    condition_name = "invalid_parameter_value"
+
+
+@dataclass
+class PrivilegeCheck:
+    function: str  # has_table_privilege | has_schema_privilege
+    user: str | None  # None = the session's IRIS user
+    target: str  # Table (optionally schema-qualified) or schema name
+    privileges: list[str]
+    column_name: str
+
+
+def _identifier(name: str) -> str:
+    name = name.strip()
+    if len(name) >= 2 and name[0] == name[-1] == '"':
+        return name[1:-1]
+    return name
+
+
+def _split_qualified(name: str) -> list[str]:
+    return re.findall(r'"(?:[^"]|"")*"|[^."]+', name.strip())
+
+
+def parse_privileges(function: str, text: str) -> list[str]:
+    """
+    Privilege names of a privilege argument ('select, INSERT WITH GRANT OPTION').
+
+    Raises:
+        InvalidPrivilegeType: privilege unknown for the function
+    """
+    known = TABLE_PRIVILEGES if function == "has_table_privilege" else SCHEMA_PRIVILEGES
+    privileges = []
+    for item in text.split(","):
+        privilege = re.sub(r"\s+WITH\s+GRANT\s+OPTION$", "", item.strip(), flags=re.IGNORECASE)
+        privilege = privilege.upper()
+        if privilege not in known:
+            raise InvalidPrivilegeType(f'unrecognized privilege type: "{item.strip()}"')
+        privileges.append(privilege)
+    return privileges
+
+
+class PrivilegeFunctionHandler:
+    """Answers SELECTs made of has_table_privilege / has_schema_privilege calls."""
+
+    def __init__(self, iris_schema: str = "SQLUser"):
+        self.iris_schema = iris_schema
+
+    @staticmethod
+    def references_privilege_function(sql: str) -> bool:
+        lowered = sql.lower()
+        return "has_table_privilege" in lowered or "has_schema_privilege" in lowered
+
+    def parse(self, sql: str) -> list[PrivilegeCheck] | None:
+        """
+        Parse the select list into checks (None if the SQL is not only such calls).
+
+        Raises:
+            InvalidPrivilegeType: unknown privilege name
+        """
+        match = _SELECT.match(sql)
+        if not match or re.search(r"\bFROM\b", match.group("items"), re.IGNORECASE):
+            return None
+
+        checks = []
+        for item in split_top_level(match.group("items")):
+            call = _CALL.match(item)
+            if not call:
+                return None
+            literals = [_LITERAL.match(arg) for arg in split_top_level(call.group("args"))]
+            if not literals or len(literals) > 3 or not all(literals):
+                return None
+            args = [literal.group(1).replace("''", "'") for literal in literals]
+            if len(args) < 2:
+                return None
+
+            function = call.group("name").lower()
+            user = args[0] if len(args) == 3 else None
+            checks.append(
+                PrivilegeCheck(
+                    function=function,
+                    user=user,
+                    target=args[-2],
+                    privileges=parse_privileges(function, args[-1]),
+                    column_name=(call.group("alias") or function).strip('"'),
+                )
+            )
+        return checks
+
+    def iris_object(self, target: str) -> str:
+        """IRIS Schema.Table name for a PostgreSQL table name."""
+        parts = [_identifier(part) for part in _split_qualified(target)]
+        schema, table = (parts[0], parts[1]) if len(parts) == 2 else ("public", parts[0])
+        if schema.lower() == "public":
+            schema = self.iris_schema
+        return f"{schema}.{table}"
+
+    def has_privilege(self, check: PrivilegeCheck, user: str, invoker) -> bool:
+        """Whether user holds any of the check's privileges."""
+        # The session's own writes are limited by default_transaction_read_only
+        read_only = check.user is None and session_read_only()
+        privileges = [
+            privilege
+            for privilege in check.privileges
+            if not (read_only and privilege in _WRITE_PRIVILEGES)
+        ]
+        if not privileges:
+            return False
+
+        if check.function == "has_schema_privilege":
+            # IRIS has no schema privileges; see the module docstring
+            return True
+
+        obj = self.iris_object(check.target)
+        for action in dict.fromkeys(TABLE_PRIVILEGES[privilege] for privilege in privileges):
+            for object_type in (_IRIS_TABLE, _IRIS_VIEW):
+                granted = invoker.call(
+                    SECURITY_CLASS, "CheckPrivilege", [user, object_type, obj, action, ""]
+                )
+                if str(granted) == "1":
+                    return True
+        return False
+
+    def execute(self, sql: str, session_user: str, invoker) -> dict[str, Any] | None:
+        """
+        Evaluate the checks and return an executor result dict (None if not ours).
+
+        Raises:
+            InvalidPrivilegeType: unknown privilege name
+        """
+        checks = self.parse(sql)
+        if checks is None:
+            return None
+
+        row = []
+        for check in checks:
+            user = check.user or session_user
+            granted = self.has_privilege(check, user, invoker)
+            logger.info(
+                "Privilege check",
+                function=check.function,
+                user=user,
+                target=check.target,
+                privileges=check.privileges,
+                granted=granted,
+            )
+            row.append(granted)
+
+        return {
+            "success": True,
+            "rows": [row],
+            "columns": [
+                {
+                    "name": check.column_name,
+                    "type_oid": _BOOL,
+                    "type_size": 1,
+                    "type_modifier": -1,
+                    "format_code": 0,
+                }
+                for check in checks
+            ],
+            "row_count": 1,
+            "command": "SELECT",
+            "command_tag": "SELECT 1",
+        }
+
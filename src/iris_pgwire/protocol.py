@@ -14,22 +14,204 @@ P0 Implementation:
 """
 
 import asyncio
-import base64
+import os
 import re
-import secrets
 import ssl
 import struct
+from dataclasses import replace
+from decimal import Decimal, InvalidOperation
 from typing import Any
 
 import structlog
 
+from .admission import set_session_role
+from .audit import get_audit_log
+from .auth.gssapi_auth import (
+    GSSAPIAuthenticator,
+    KerberosAuthenticationError,
+    KerberosTimeoutError,
+)
+from .auth_methods import GSS, LDAP, MD5, PASSWORD, SCRAM_SHA_256, TRUST
+from .backend_auth import (
+    PASSTHROUGH,
+    SERVICE,
+    BackendCredentials,
+    BackendLoginUnavailable,
+    PasswordAuthenticationFailed,
+    set_backend_credentials,
+)
+from .array_values import array_parameter, expand_any_parameters
+from .bind_params import ARRAY_ELEMENT_TYPES, InvalidBinaryParameter, decode_binary_parameter
+from .bool_values import BOOL_OID, InvalidBoolean, format_bool, parse_bool
 from .bulk_executor import BulkExecutor
+from .cancellation import StatementCancel, get_backend_keys, set_statement_cancel
+from .cert_auth import CertAuthConfig, CertificateAuthenticationFailed, authenticate_certificate
+from .catalog.reg_casts import RegCastResolver, UndefinedRegName, has_reg_cast
+from .change_sink import SessionChanges
+from .column_names import name_result_columns
+from .copy_export import CopyExportError, force_quote_columns
 from .copy_handler import CopyHandler
 from .csv_processor import CSVParsingError, CSVProcessor
+from .hba import HostAccessDenied, get_hba
 from .iris_executor import IRISExecutor
+from .keepalive import IDLE_SESSION_TIMEOUT_PARAMETER, IdleSessionTimeout, idle_timeout_seconds
+from .large_values import LargeValue, load_large_value_bytes, preview, read_bind_message
+from .ldap_auth import (
+    LDAP_OPTIONS,
+    LdapAuthenticationFailed,
+    LdapConfig,
+    authenticate_ldap,
+    load_ldap_config,
+)
+from .md5_auth import md5_secret, verify_md5_response
+from .message_framing import (
+    MalformedMessage,
+    ProtocolViolation,
+    check_startup_length,
+    decode_text,
+    load_max_bind_message_size,
+    load_max_message_size,
+    parse_message_header,
+)
+from .money_values import MONEY_OID, InvalidMoney, parse_money
+from .async_messages import (
+    AsyncMessages,
+    notice_response,
+    notification_response,
+    parameter_status,
+)
+from .notifications import (
+    Listen,
+    Notify,
+    NotifyCall,
+    NotifyError,
+    SessionNotifications,
+    parse_notification_command,
+    parse_notify_call,
+)
+from .numeric_range import (
+    NumericValueOutOfRange,
+    check_integer_range,
+    is_integer_type,
+    parse_integer_text,
+)
+from .pipeline import PipelineWriter
+from .portal_cursors import PortalCursor, PortalCursorRegistry, TooManyOpenPortals
+from .replication import (
+    EXTENDED_PROTOCOL_IN_REPLICATION,
+    PHYSICAL,
+    SQL_IN_PHYSICAL_REPLICATION,
+    WALSENDER,
+    InvalidReplicationParameter,
+    get_wal_senders,
+    TIMELINE,
+    format_lsn,
+    identify_system_result,
+    replication_command,
+    replication_mode,
+    system_identifier,
+)
+from .replication_slots import (
+    LOGICAL_SLOT,
+    PHYSICAL_SLOT,
+    SLOT_COMMANDS,
+    ReplicationSlot,
+    SlotError,
+    SlotStore,
+    create_slot_result,
+    current_position,
+    duplicate_slot,
+    get_active_slots,
+    parse_create_slot,
+    parse_slot_command,
+    read_slot_result,
+    undefined_slot,
+)
+from .replication_stream import (
+    ClientTerminated,
+    InvalidStartReplication,
+    ReplicationStream,
+    WalSenderTimeout,
+    parse_start_replication,
+)
+from .result_encoding import (
+    InvalidResultValue,
+    encode_result_value,
+    numeric_scale,
+    result_format,
+)
+from .role_settings import (
+    AlterSetting,
+    RoleInitFailed,
+    RoleSettingsNotPersistent,
+    get_role_settings,
+    load_role_settings_admins,
+    parse_alter_setting,
+)
+from .schema_mapper import get_schema_config
+from .scram import (
+    ScramError,
+    ScramExchange,
+    ScramVerifier,
+    authentication_failed,
+    load_enroll_mode,
+    malformed,
+    mock_verifier,
+)
+from .select_mode import render_value, result_type
+from .session_labels import SessionLabel, set_session_label
+from .session_settings import (
+    InvalidParameterValue,
+    SessionSettings,
+    set_session_settings,
+    strip_setting_value,
+)
+from .sql_cursors import (
+    CloseCursor,
+    CursorError,
+    DeclareCursor,
+    SqlCursors,
+    parse_cursor_statement,
+)
+from .statement_savepoints import (
+    STATEMENT_SAVEPOINTS_PARAMETER,
+    run_with_savepoint,
+    savepoints_enabled,
+    wraps_statement,
+)
+from .stats_hooks import get_stats
+from .stream_values import FailedStreamValue, StreamValueTooLarge
+from .table_locks import TableLocks, outside_transaction_error, parse_lock_table
+from .temp_spool import TempFileLimitExceeded
+from .transactional_ddl import (
+    DDL_IN_TRANSACTION_PARAMETER,
+    TRANSACTIONAL_DDL_PARAMETER,
+    ddl_command,
+    ddl_in_transaction_error,
+    ddl_warning,
+)
 from .sql_translator import TranslationContext, ValidationLevel, get_translator
 from .sql_translator.copy_parser import CopyCommandParser, CopyDirection
+from .sql_translator.ddl_translator import DEFERRED_CONSTRAINTS_WARNING, ddl_notices
 from .sql_translator.performance_monitor import MetricType, PerformanceTracker, get_monitor
+from .temporal_values import TEMPORAL_TYPES, format_temporal
+from .timezone_support import (
+    format_timestamp,
+    format_timestamptz,
+    interval_timezone_name,
+    resolve_timezone,
+    to_utc,
+)
+from .uuid_values import UUID_OID, InvalidUUID, format_uuid, parse_uuid
+from .value_formatting import (
+    InvalidBytea,
+    format_bytea,
+    format_money,
+    format_numeric,
+    parse_bytea,
+)
+from .vector_values import VECTOR_OID, InvalidVector, format_vector, parse_vector
+from .writable_cte import parse_writable_cte, run_writable_cte
 
 logger = structlog.get_logger()
 
@@ -154,26 +336,39 @@ MSG_BIND_COMPLETE = b"2"
 MSG_CLOSE_COMPLETE = b"3"
 MSG_PARAMETER_DESCRIPTION = b"t"
 MSG_NO_DATA = b"n"
+MSG_PORTAL_SUSPENDED = b"s"
 MSG_COPY_IN_RESPONSE = b"G"
 MSG_COPY_OUT_RESPONSE = b"H"
 MSG_COPY_BOTH_RESPONSE = b"W"
 
+# Extended query messages; an error in one skips the rest of its pipeline (pipeline.py)
+EXTENDED_QUERY_MESSAGES = frozenset(
+    {MSG_PARSE, MSG_BIND, MSG_DESCRIBE, MSG_EXECUTE, MSG_CLOSE, MSG_FLUSH}
+)
+
+# Messages a replication connection refuses, like PostgreSQL's walsender
+REPLICATION_FORBIDDEN_MESSAGES = frozenset(
+    {MSG_PARSE, MSG_BIND, MSG_DESCRIBE, MSG_EXECUTE, MSG_CLOSE, MSG_FLUSH, MSG_SYNC}
+)
+
 # Transaction status
 STATUS_IDLE = b"I"
 STATUS_IN_TRANSACTION = b"T"
 STATUS_FAILED_TRANSACTION = b"E"
 
+# SET LOCAL: in effect until the transaction ends
+_SET_LOCAL = re.compile(r"^\s*SET\s+LOCAL\s", re.IGNORECASE)
+
 # Authentication types
 AUTH_OK = 0
 AUTH_CLEARTEXT_PASSWORD = 3
 AUTH_MD5_PASSWORD = 5
+AUTH_GSS = 7
+AUTH_GSS_CONTINUE = 8
 AUTH_SASL = 10
 AUTH_SASL_CONTINUE = 11
 AUTH_SASL_FINAL = 12
 
-# SASL mechanisms
-SASL_SCRAM_SHA_256 = "SCRAM-SHA-256"
-
 
 class PGWireProtocol:
     """
@@ -195,26 +390,44 @@ class PGWireProtocol:
         self.writer = writer
         self.iris_executor = iris_executor
         self.connection_id = connection_id
+        self.client_address = None  # Peer (host, port), set by the server
 
         # Session state
         self.startup_params = {}
+        self.session_settings = SessionSettings()  # Runtime parameters (SET/RESET/SHOW)
+        set_session_settings(self.session_settings)  # current_setting() in the executor
+        self.table_locks = TableLocks(lambda sql: self.iris_executor.execute_query(sql))
+        self.max_message_size = load_max_message_size()  # PGWIRE_MAX_MESSAGE_SIZE
+        self.max_bind_message_size = load_max_bind_message_size()  # PGWIRE_MAX_BIND_MESSAGE_SIZE
+        self.large_value_bytes = load_large_value_bytes()  # PGWIRE_LARGE_VALUE_BYTES
         self.transaction_status = STATUS_IDLE
-        self.backend_pid = secrets.randbelow(32768) + 1000  # PostgreSQL-like PID
-        self.backend_secret = secrets.randbelow(2**32)
+        self.awaiting_command = False  # ReadyForQuery sent, next message not yet received
+        self.extended_message = False  # Handling an extended query message
+        self.ignore_till_sync = False  # An extended query message failed (pipeline.py)
+        # BackendKeyData for CancelRequest (see cancellation.py)
+        self.backend_pid, self.backend_secret = get_backend_keys().allocate()
+        self.statement_cancel = StatementCancel()
+        self.replication_mode = None  # LOGICAL/PHYSICAL for walsender sessions (replication.py)
+        self.wal_sender = None  # Streaming progress of a replication connection
         self.ssl_enabled = False
 
         # Protocol state
         self.authenticated = False
+        self.credentials_rejected = False  # Failed login that counts towards lockout
         self.ready = False
 
         # P3: Authentication state
         self.enable_scram = enable_scram
         self.auth_method = AUTH_SASL if enable_scram else AUTH_OK
+        # Methods offered on the connection's listener, set by the server (auth_methods.py)
+        self.auth_methods = (SCRAM_SHA_256,) if enable_scram else (TRUST,)
         self.scram_state = {}  # SCRAM authentication state
-        self.client_nonce = None
-        self.server_nonce = None
-        self.salt = None
-        self.iteration_count = 4096
+        self.scram_exchange = None  # ScramExchange in progress (scram.py)
+        self.tls_server_end_point = None  # Channel binding data, set by the server
+        self.cert_auth = CertAuthConfig()  # Client certificate logins, set by the server
+        self.gss_options: dict[str, str] = {}  # include_realm/krb_realm/map of a pg_hba gss line
+        self.gssapi_authenticator = None  # Created by the first Kerberos login
+        self.ldap_config: LdapConfig | None = None  # A pg_hba ldap line's (PGWIRE_LDAP_OPTIONS)
 
         # Feature 024: Authentication Bridge integration
         try:
@@ -222,7 +435,7 @@ class PGWireProtocol:
 
             self.auth_selector = AuthenticationSelector(
                 oauth_enabled=True,
-                kerberos_enabled=False,  # GSSAPI not yet wired
+                kerberos_enabled=False,  # GSSAPI logins go through authenticate_gss()
                 wallet_enabled=True,
             )
             self.oauth_bridge = OAuthBridge()
@@ -246,6 +459,13 @@ class PGWireProtocol:
         # P2: Extended Protocol state
         self.prepared_statements = {}  # name -> {'query': str, 'param_types': list}
         self.portals = {}  # name -> {'statement': str, 'params': list}
+        self.portal_cursors = PortalCursorRegistry()  # Open result set per portal
+        self.sql_cursors = SqlCursors()  # DECLAREd cursors (sql_cursors.py)
+        self.notifications = SessionNotifications(self.backend_pid)  # LISTEN / NOTIFY
+        self.notifications.on_arrival = self._notifications_arrived
+        # NoticeResponse, NotificationResponse and ParameterStatus (async_messages.py)
+        self.async_messages = AsyncMessages()
+        self.changes = SessionChanges(self.backend_pid)  # PGWIRE_CDC_SINKS (change_sink.py)
 
         # P6: Back-pressure controls for large result sets
         self.result_batch_size = 1000  # Rows per DataRow batch
@@ -427,6 +647,10 @@ class PGWireProtocol:
             # Fast path: no parameters or type casts to translate
             return sql
 
+        # Step 0: 'orders'::regclass and friends become OIDs (see catalog/reg_casts.py)
+        if has_reg_cast(sql):
+            sql = RegCastResolver(iris_schema=get_schema_config()["iris_schema"]).resolve(sql)
+
         # Step 1: Replace $1, $2, $3, ... with ? for IRIS parameter binding
         # Pattern: \$\d+ matches $1, $2, $3, etc.
         if "$" in sql:
@@ -450,15 +674,18 @@ class PGWireProtocol:
             }
 
             # Replace ::type with CAST() - handles simple cases like ?::int or 'value'::text
-            # This regex matches: (?) :: (type) OR ('value') :: (type) OR (number) :: (type)
+            # This regex matches: (?) :: (type) OR ('value') :: (type) OR (number) :: (type),
+            # with the type's modifiers (?::vector(3) → CAST(? AS VECTOR(3)))
             def replace_typecast(match):
                 expr = match.group(1)
                 pg_type = match.group(2).lower()
                 iris_type = type_map.get(pg_type, pg_type.upper())
-                return f"CAST({expr} AS {iris_type})"
+                return f"CAST({expr} AS {iris_type}{match.group(3) or ''})"
 
-            # Pattern: (?) or ('...') or (number) followed by ::type
-            sql = re.sub(r"(\?|'[^']*'|\d+)::([\w]+)", replace_typecast, sql)
+            # Pattern: (?) or ('...') or (number) followed by ::type or ::type(n[, m])
+            sql = re.sub(
+                r"(\?|'[^']*'|\d+)::([\w]+)(\(\s*\d+(?:\s*,\s*\d+)?\s*\))?", replace_typecast, sql
+            )
 
         logger.debug(
             "Translated PostgreSQL syntax",
@@ -546,6 +773,7 @@ class PGWireProtocol:
             "TIMESTAMP": 1114,  # timestamp
             "NUMERIC": 1700,  # numeric
             "DECIMAL": 1700,  # numeric
+            "VECTOR": VECTOR_OID,  # pgvector's vector
         }
 
         # Extract CAST(? AS type) patterns in order
@@ -618,24 +846,30 @@ class PGWireProtocol:
                     logger.debug("SSL request received", connection_id=self.connection_id)
 
                     if ssl_context:
+                        # Bytes sent after SSLRequest, before the handshake, came in the
+                        # clear: never read them as part of the TLS session (CVE-2021-23214)
+                        buffered = getattr(self.reader, "_buffer", None)
+                        if isinstance(buffered, bytearray) and buffered:
+                            logger.warning(
+                                "Unencrypted data after SSLRequest",
+                                connection_id=self.connection_id,
+                                bytes=len(buffered),
+                            )
+                            message = "received unencrypted data after SSL request"
+                            await self.send_error_response(
+                                "FATAL", "08P01", "protocol_violation", message
+                            )
+                            raise ConnectionAbortedError(message)
+
                         # Respond with 'S' (SSL supported) and upgrade connection
                         self.writer.write(b"S")
                         await self.writer.drain()
 
-                        # Upgrade to TLS
-                        transport = self.writer.transport
-                        protocol = transport.get_protocol()
-                        await asyncio.sleep(0.1)  # Allow response to be sent
-
-                        # Create SSL transport
-                        ssl_transport = await asyncio.get_event_loop().start_tls(
-                            transport, protocol, ssl_context, server_side=True
-                        )
-
-                        # Update reader/writer for SSL
-                        self.writer = asyncio.StreamWriter(
-                            ssl_transport, protocol, self.reader, asyncio.get_event_loop()
-                        )
+                        # Upgrade to TLS in place: the reader and writer stay the same
+                        try:
+                            await self.writer.start_tls(ssl_context)
+                        except (ssl.SSLError, ConnectionError) as e:
+                            raise ConnectionAbortedError(f"TLS handshake failed: {e}") from e
                         self.ssl_enabled = True
 
                         logger.info("SSL connection established", connection_id=self.connection_id)
@@ -659,6 +893,8 @@ class PGWireProtocol:
                         code=code,
                     )
 
+                    check_startup_length(length)
+
                     # Store the data for startup message parsing
                     self._buffered_data = data
                     break  # Exit loop - startup message will be parsed next
@@ -672,6 +908,9 @@ class PGWireProtocol:
                 expected=8,
             )
             raise ConnectionAbortedError("Connection closed during probe")
+        except ProtocolViolation as e:
+            logger.warning("Invalid startup packet", connection_id=self.connection_id, error=str(e))
+            raise ConnectionAbortedError(str(e)) from e
         except Exception as e:
             logger.error(
                 "Probe handling failed", connection_id=self.connection_id, error=str(e)
@@ -707,18 +946,32 @@ class PGWireProtocol:
                 connection_id=self.connection_id,
                 scram_enabled=self.enable_scram,
             )
-            if self.enable_scram:
-                await self.start_scram_authentication()
-                # SCRAM requires additional message handling
-                await self.handle_scram_client_final()
-                await self.complete_scram_authentication()
-            else:
-                # P0: Basic authentication (trust)
+            backend_auth_mode = getattr(self.iris_executor, "backend_auth_mode", SERVICE)
+            # pg_hba.conf-style rules decide whether and how the client may connect (hba.py)
+            self.apply_host_access_rules()
+            if self.authenticate_client_certificate(backend_auth_mode):
+                # A verified client certificate replaces the password (see cert_auth.py)
                 await self.send_authentication_ok()
+            elif self.auth_methods == (GSS,):
+                # Kerberos tickets replace the password (see auth/gssapi_auth.py)
+                await self.authenticate_gss(backend_auth_mode)
+            elif backend_auth_mode == PASSTHROUGH and LDAP in self.auth_methods:
+                # The directory checks the password, connections switch to the user
+                await self.authenticate_ldap(backend_auth_mode)
+            elif backend_auth_mode == PASSTHROUGH:
+                # Client credentials become the IRIS login (see backend_auth.py)
+                await self.authenticate_passthrough()
+            else:
+                await self.authenticate_password_method()
             logger.info(
                 "✅ HANDSHAKE STEP 2: Authentication sent", connection_id=self.connection_id
             )
 
+            # Admission control caps concurrency per role; this task serves the session
+            set_session_role(self.startup_params.get("user"))
+            # Role/database defaults before ParameterStatus, so clients see them
+            await self.apply_role_settings()
+
             # STEP 3: Send parameter status messages
             logger.info(
                 "🔍 HANDSHAKE STEP 3: About to send ParameterStatus",
@@ -749,6 +1002,39 @@ class PGWireProtocol:
             self.authenticated = True
             self.ready = True
 
+            if self.replication_mode:
+                self.wal_sender = get_wal_senders().register(self.backend_pid)
+                self.async_messages.close()
+            else:
+                self.async_messages.open(lambda message: self.writer.write(message))
+            self.changes.user = self.startup_params.get("user")
+            self.changes.database = self.startup_params.get("database")
+            get_stats().session_started(
+                self.connection_id,
+                user=self.startup_params.get("user"),
+                # A physical replication connection is not connected to a database
+                database=(
+                    None
+                    if self.replication_mode == PHYSICAL
+                    else self.startup_params.get("database")
+                ),
+                backend_pid=self.backend_pid,
+                backend_type=WALSENDER if self.replication_mode else "client backend",
+                application_name=self.session_settings.get("application_name"),
+                client=self.client_address,
+            )
+            get_audit_log().record(
+                "session_start",
+                connection_id=self.connection_id,
+                user=self.startup_params.get("user"),
+                backend_auth=backend_auth_mode,
+                backend_user=(
+                    self.startup_params.get("user")
+                    if backend_auth_mode == PASSTHROUGH
+                    else self.iris_executor.iris_config.get("username")
+                ),
+            )
+
             logger.info(
                 "🎉 Startup sequence completed successfully",
                 connection_id=self.connection_id,
@@ -765,6 +1051,62 @@ class PGWireProtocol:
                 expected=e.expected,
             )
             raise ConnectionAbortedError("Client disconnected before StartupMessage")
+        except PasswordAuthenticationFailed as e:
+            logger.warning("Authentication failed", connection_id=self.connection_id, user=e.user)
+            self.credentials_rejected = True
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except BackendLoginUnavailable as e:
+            # IRIS never saw the credentials: not a failed attempt for the lockout
+            logger.error("IRIS login unavailable", connection_id=self.connection_id, user=e.user)
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except CertificateAuthenticationFailed as e:
+            logger.warning(
+                "Certificate authentication failed", connection_id=self.connection_id, error=str(e)
+            )
+            self.credentials_rejected = True
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except (KerberosAuthenticationError, KerberosTimeoutError) as e:
+            logger.warning(
+                "GSSAPI authentication failed", connection_id=self.connection_id, error=str(e)
+            )
+            # A handshake that timed out is not a rejected ticket
+            self.credentials_rejected = isinstance(e, KerberosAuthenticationError)
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except LdapAuthenticationFailed as e:
+            logger.warning(
+                "LDAP authentication failed", connection_id=self.connection_id, error=str(e)
+            )
+            self.credentials_rejected = True
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except HostAccessDenied as e:
+            logger.warning("Connection refused", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except ScramError as e:
+            logger.warning(
+                "SCRAM authentication failed", connection_id=self.connection_id, error=str(e)
+            )
+            # Not malformed messages or channel binding errors, only an unverified proof
+            self.credentials_rejected = e.sqlstate == "28P01"
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except RoleInitFailed as e:
+            logger.error("Role init_sql failed", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except MalformedMessage as e:
+            logger.warning("Malformed StartupMessage", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
+        except InvalidReplicationParameter as e:
+            logger.warning("Invalid replication parameter", connection_id=self.connection_id)
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+            raise ConnectionAbortedError(str(e)) from e
         except Exception as e:
             logger.error(
                 "❌ Startup sequence failed",
@@ -815,6 +1157,7 @@ class PGWireProtocol:
             length_data = await self.reader.readexactly(4)
             length = struct.unpack("!I", length_data)[0]
             logger.info("📏 Message length read", connection_id=self.connection_id, length=length)
+            check_startup_length(length)
 
         # Read remaining message data
         # Length includes the length field itself (4 bytes), so remaining = length - 4
@@ -887,20 +1230,22 @@ class PGWireProtocol:
                 key_end = param_data.find(b"\x00", i)
                 if key_end == -1:
                     break
-                key = param_data[i:key_end].decode("utf-8")
+                key = decode_text(param_data[i:key_end])
                 i = key_end + 1
 
                 # Find value
                 value_end = param_data.find(b"\x00", i)
                 if value_end == -1:
                     break
-                value = param_data[i:value_end].decode("utf-8")
+                value = decode_text(param_data[i:value_end])
                 i = value_end + 1
 
                 params[key] = value
                 logger.debug(f"📝 Parameter: {key}={value}", connection_id=self.connection_id)
 
             self.startup_params = params
+            self.replication_mode = replication_mode(params.get("replication"))
+            self._apply_startup_settings(params)
             logger.info(
                 "✅ All parameters parsed successfully",
                 connection_id=self.connection_id,
@@ -908,376 +1253,509 @@ class PGWireProtocol:
             )
             logger.debug("Startup message parsed", connection_id=self.connection_id, params=params)
 
-    async def send_authentication_ok(self):
-        """Send AuthenticationOk message (P0: basic trust auth)"""
-        # AuthenticationOk: R + length + 0
-        message = struct.pack("!cII", MSG_AUTHENTICATION, 8, 0)
-        self.writer.write(message)
-        await self.writer.drain()
-        logger.debug("Authentication OK sent", connection_id=self.connection_id)
-
-    # P3: SCRAM-SHA-256 Authentication Methods
+    # StartupMessage keys that are connection options, not runtime parameters
+    STARTUP_NON_GUC_KEYS = frozenset({"user", "database", "options", "replication"})
 
-    async def start_scram_authentication(self):
-        """P3: Start SCRAM-SHA-256 authentication sequence"""
-        try:
-            # Send SASL authentication request with supported mechanisms
-            await self.send_sasl_auth_request()
+    def _apply_startup_settings(self, params: dict[str, str]):
+        """Seed session settings from StartupMessage parameters (e.g. application_name)."""
+        # IRIS processes serving this session are labelled with it (session_labels.py)
+        set_session_label(
+            SessionLabel(self.backend_pid, self.connection_id, params.get("user", ""), "")
+        )
+        # Statements of this session can be cancelled by CancelRequest (cancellation.py)
+        set_statement_cancel(self.statement_cancel)
+        for key, value in params.items():
+            if key in self.STARTUP_NON_GUC_KEYS:
+                continue
+            try:
+                self.session_settings.set(key, value)
+            except InvalidParameterValue as e:
+                logger.warning(
+                    "Ignoring invalid startup parameter",
+                    connection_id=self.connection_id,
+                    parameter=key,
+                    error=str(e),
+                )
+        # Initial values are sent by send_parameter_status, not as change reports
+        self.session_settings.take_pending_reports()
 
-            # Wait for client's SASL initial response
-            header = await self.reader.readexactly(5)
-            msg_type, length = struct.unpack("!cI", header)
+    async def apply_role_settings(self):
+        """
+        Apply role/database defaults and run init_sql (see role_settings.py).
 
-            if msg_type != b"p":  # SASLResponse message
-                raise ValueError(f"Expected SASLResponse, got {msg_type}")
+        Parameters from the StartupMessage win over role defaults.
 
-            body_length = length - 4
-            body = await self.reader.readexactly(body_length) if body_length > 0 else b""
+        Raises:
+            RoleInitFailed: an init_sql statement failed
+        """
+        user = self.startup_params.get("user")
+        database = self.startup_params.get("database")
+        defaults, init_sql = get_role_settings().resolve(user, database)
+
+        explicit = {key.lower() for key in self.startup_params}
+        for name, value in defaults.items():
+            if name not in explicit:
+                self.session_settings.set_session_default(name, value)
+        # Initial values are sent by send_parameter_status, not as change reports
+        self.session_settings.take_pending_reports()
+
+        for statement in init_sql:
+            if re.match(r"\s*(SET|RESET)\s", statement, re.IGNORECASE):
+                try:
+                    param_name, _ = self._apply_set_command(statement)
+                except InvalidParameterValue as e:
+                    raise RoleInitFailed(f"role init statement failed: {e}") from e
+                if param_name is None:
+                    raise RoleInitFailed(f"role init statement failed: {statement}")
+                continue
+            result = await self.iris_executor.execute_query(statement)
+            if not result.get("success"):
+                raise RoleInitFailed(f"role init statement failed: {result.get('error')}")
+
+        if defaults or init_sql:
+            logger.info(
+                "Role settings applied",
+                connection_id=self.connection_id,
+                user=user,
+                database=database,
+                settings=sorted(defaults),
+                init_statements=len(init_sql),
+            )
 
-            await self.handle_sasl_initial_response(body)
+    def apply_host_access_rules(self) -> None:
+        """
+        Check the connection against PGWIRE_HBA_FILE and take the matching
+        line's authentication method (no-op without the file).
 
-        except Exception as e:
-            logger.error(
-                "SCRAM authentication failed", connection_id=self.connection_id, error=str(e)
-            )
-            await self.send_error_response(
-                "FATAL",
-                "28000",
-                "invalid_authorization_specification",
-                f"SCRAM authentication failed: {e}",
+        Raises:
+            HostAccessDenied: no line allows the connection, or it is rejected
+            CertificateAuthenticationFailed: the line's clientcert is not met
+        """
+        hba = get_hba()
+        if hba is None:
+            return
+        user = self.startup_params.get("user", "")
+        sockname = self.writer.get_extra_info("sockname")
+        rule = hba.match(
+            self.client_address[0] if self.client_address else "",
+            self.ssl_enabled,
+            self.startup_params.get("database") or user,
+            user,
+            replication=self.replication_mode == PHYSICAL,
+            local_address=sockname[0] if sockname else None,
+        )
+        logger.info(
+            "Connection allowed by pg_hba line",
+            connection_id=self.connection_id,
+            line=rule.line,
+            method=rule.method,
+        )
+        client_name = rule.options.get("clientname", "CN").upper()
+        certificate = CertAuthConfig("required", rule.options.get("map"), client_name)
+        if rule.method == "cert":
+            self.cert_auth = certificate
+            return
+        self.cert_auth = CertAuthConfig()
+        self.gss_options = rule.options if rule.method == GSS else {}
+        self.ldap_config = None
+        if rule.method == LDAP:
+            options = rule.options.items()
+            # Validated when the file was loaded
+            self.ldap_config = LdapConfig.from_options(
+                {name: value for name, value in options if name in LDAP_OPTIONS}
             )
-            raise
+        # md5 lines use SCRAM for users with a SCRAM verifier, as PostgreSQL does
+        self.auth_methods = (SCRAM_SHA_256, MD5) if rule.method == MD5 else (rule.method,)
+        clientcert = rule.options.get("clientcert")
+        peercert = self.writer.get_extra_info("peercert") if self.ssl_enabled else None
+        if clientcert == "verify-ca" and not peercert:
+            raise CertificateAuthenticationFailed("connection requires a valid client certificate")
+        if clientcert == "verify-full":
+            authenticate_certificate(certificate, peercert, user)
+
+    def authenticate_client_certificate(self, backend_auth_mode: str) -> bool:
+        """
+        Authenticate the user by the verified TLS client certificate, if any.
 
-    async def send_sasl_auth_request(self):
-        """Send SASL authentication request with SCRAM-SHA-256"""
-        # AuthenticationSASL: R + length + 10 + mechanisms
-        mechanisms = SASL_SCRAM_SHA_256.encode("utf-8") + b"\x00" + b"\x00"  # null-terminated list
-        message_length = 4 + 4 + len(mechanisms)
+        Returns False when certificate authentication does not apply.
 
-        message = struct.pack("!cII", MSG_AUTHENTICATION, message_length, AUTH_SASL) + mechanisms
-        self.writer.write(message)
-        await self.writer.drain()
+        Raises:
+            CertificateAuthenticationFailed: the certificate is missing (required)
+                or does not allow the user
+        """
+        peercert = self.writer.get_extra_info("peercert") if self.ssl_enabled else None
+        user = self.startup_params.get("user", "")
+        if not authenticate_certificate(self.cert_auth, peercert, user):
+            return False
+        if backend_auth_mode == PASSTHROUGH:
+            # No password to log in with: connections switch to the user
+            set_backend_credentials(BackendCredentials(user))
+        return True
+
+    async def authenticate_passthrough(self):
+        """
+        Credential passthrough: request the password in cleartext and verify it
+        by logging in to IRIS as the client's user.
 
-        logger.debug(
-            "SASL authentication request sent",
-            connection_id=self.connection_id,
-            mechanisms=[SASL_SCRAM_SHA_256],
-        )
+        Raises:
+            PasswordAuthenticationFailed: IRIS rejected the credentials
+        """
+        user = self.startup_params.get("user", "")
+        self.writer.write(struct.pack("!cII", MSG_AUTHENTICATION, 8, AUTH_CLEARTEXT_PASSWORD))
+        await self.writer.drain()
 
-    async def handle_sasl_initial_response(self, body: bytes):
-        """Handle client's SASL initial response"""
-        pos = 0
+        header = await self.reader.readexactly(5)
+        msg_type, body_length = parse_message_header(header, self.max_message_size)
+        if msg_type != b"p":
+            raise ProtocolViolation(f"expected password response, got message type {msg_type!r}")
+        body = await self.reader.readexactly(body_length) if body_length > 0 else b""
+        password = decode_text(body.rstrip(b"\x00"))
 
-        # Parse mechanism name
-        mechanism_end = body.find(b"\x00", pos)
-        if mechanism_end == -1:
-            raise ValueError("Invalid SASL response: missing mechanism")
-        mechanism = body[pos:mechanism_end].decode("utf-8")
-        pos = mechanism_end + 1
+        credentials = await self.iris_executor.verify_backend_credentials(user, password)
+        set_backend_credentials(credentials)
+        await self.send_authentication_ok()
 
-        if mechanism != SASL_SCRAM_SHA_256:
-            raise ValueError(f"Unsupported SASL mechanism: {mechanism}")
+    async def authenticate_gss(self, backend_auth_mode: str):
+        """
+        AuthenticationGSS: accept the client's GSSAPI tokens, then check that
+        the Kerberos principal allows the user (auth/gssapi_auth.py).
 
-        # Parse initial response length
-        if pos + 4 > len(body):
-            raise ValueError("Invalid SASL response: missing response length")
-        response_length = struct.unpack("!I", body[pos : pos + 4])[0]
-        pos += 4
+        In passthrough mode the session's IRIS connections switch to the user
+        (as for certificates); in service mode they do so only with
+        PGWIRE_KERBEROS_DELEGATION and forwarded credentials.
 
-        # Parse initial response data
-        if response_length == 0xFFFFFFFF:
-            response_data = b""
+        Raises:
+            KerberosAuthenticationError: no keytab, an invalid ticket or a
+                principal that does not allow the user
+            KerberosTimeoutError: a token took longer than PGWIRE_KERBEROS_TIMEOUT
+        """
+        user = self.startup_params.get("user", "")
+        if self.gssapi_authenticator is None:
+            try:
+                self.gssapi_authenticator = GSSAPIAuthenticator()
+            except ImportError as e:
+                logger.error("GSSAPI unavailable", connection_id=self.connection_id, error=str(e))
+                raise KerberosAuthenticationError(
+                    "GSSAPI authentication is not supported by this server"
+                ) from None
+        authenticator = self.gssapi_authenticator
+        context = await authenticator.accept_context()
+
+        self.writer.write(struct.pack("!cII", MSG_AUTHENTICATION, 8, AUTH_GSS))
+        await self.writer.drain()
+        while True:
+            token = await authenticator.step(context, await self.read_sasl_response())
+            if token:
+                message = struct.pack("!cII", MSG_AUTHENTICATION, 8 + len(token), AUTH_GSS_CONTINUE)
+                self.writer.write(message + token)
+                await self.writer.drain()
+            if context.complete:
+                break
+
+        options = self.gss_options
+        include_realm = options.get("include_realm")
+        principal = authenticator.authorize_principal(
+            str(context.initiator_name),
+            user,
+            include_realm=None if include_realm is None else include_realm == "1",
+            realm=options.get("krb_realm"),
+            map_name=options.get("map"),
+        )
+        if backend_auth_mode == PASSTHROUGH:
+            set_backend_credentials(BackendCredentials(user))
         else:
-            if pos + response_length > len(body):
-                raise ValueError("Invalid SASL response: truncated response data")
-            response_data = body[pos : pos + response_length]
-
-        await self.process_scram_client_first(response_data)
+            set_backend_credentials(authenticator.delegated_backend_credentials(principal, context))
+        logger.info(
+            "GSSAPI authentication completed",
+            connection_id=self.connection_id,
+            user=user,
+            principal=principal.principal,
+        )
+        await self.send_authentication_ok()
 
-    async def process_scram_client_first(self, client_first: bytes):
-        """Process SCRAM client-first message"""
-        try:
-            # Parse client-first message: "n,,n=user,r=client_nonce"
-            client_first_str = client_first.decode("utf-8")
-
-            # Basic parsing (production would be more robust)
-            if not client_first_str.startswith("n,,"):
-                raise ValueError("Invalid SCRAM client-first message format")
-
-            # Extract username and client nonce
-            auth_message = client_first_str[3:]  # Remove "n,," prefix
-            parts = auth_message.split(",")
-
-            username = None
-            self.client_nonce = None
-
-            for part in parts:
-                if part.startswith("n="):
-                    username = part[2:]
-                elif part.startswith("r="):
-                    self.client_nonce = part[2:]
-
-            if not username or not self.client_nonce:
-                raise ValueError("Missing username or client nonce in SCRAM message")
-
-            # Generate server nonce and salt
-            self.server_nonce = base64.b64encode(secrets.token_bytes(18)).decode("ascii")
-            self.salt = base64.b64encode(secrets.token_bytes(16)).decode("ascii")
-
-            # Store auth state for final verification
-            self.scram_state = {
-                "username": username,
-                "client_first_bare": auth_message,
-                "client_nonce": self.client_nonce,
-                "server_nonce": self.server_nonce,
-                "salt": self.salt,
-                "iteration_count": self.iteration_count,
-            }
+    async def authenticate_ldap(self, backend_auth_mode: str):
+        """
+        Request the password in cleartext and check it against the LDAP server
+        (ldap_auth.py). In passthrough mode the session's IRIS connections
+        switch to the user, as for Kerberos.
 
-            # Send server-first message
-            await self.send_scram_server_first()
+        Raises:
+            LdapAuthenticationFailed: refused, or no usable LDAP configuration
+        """
+        user = self.startup_params.get("user", "")
+        config = self.ldap_config
+        if config is None:
+            try:
+                config = load_ldap_config()
+            except ValueError as e:
+                logger.error("LDAP authentication unavailable", error=str(e))
+                raise LdapAuthenticationFailed(user) from None
 
-            logger.debug(
-                "SCRAM client-first processed",
-                connection_id=self.connection_id,
-                username=username,
-                client_nonce=self.client_nonce[:8] + "...",
-            )
+        self.writer.write(struct.pack("!cII", MSG_AUTHENTICATION, 8, AUTH_CLEARTEXT_PASSWORD))
+        await self.writer.drain()
+        password = decode_text((await self.read_sasl_response()).rstrip(b"\x00"))
+        # The directory answers over the network: keep the event loop free
+        await asyncio.to_thread(authenticate_ldap, config, user, password)
+        if backend_auth_mode == PASSTHROUGH:
+            set_backend_credentials(BackendCredentials(user))
+        logger.info("LDAP authentication completed", connection_id=self.connection_id, user=user)
+        await self.send_authentication_ok()
 
-        except Exception as e:
-            logger.error(
-                "SCRAM client-first processing failed",
-                connection_id=self.connection_id,
-                error=str(e),
-            )
-            raise
+    async def choose_auth_method(self, user: str) -> str:
+        """The first of the listener's methods the user has a stored secret for."""
+        for method in self.auth_methods:
+            try:
+                if method in (TRUST, PASSWORD, LDAP):
+                    return method
+                if method == MD5 and await self.iris_executor.md5_secret(user) is not None:
+                    return method
+                if (
+                    method == SCRAM_SHA_256
+                    and await self.iris_executor.scram_verifier(user) is not None
+                ):
+                    return method
+            except Exception as e:
+                logger.warning("Stored password secrets unavailable", user=user, error=str(e))
+        # No secret yet: the first method enrolls the user (or fails like a wrong password)
+        return self.auth_methods[0]
 
-    async def send_scram_server_first(self):
-        """Send SCRAM server-first message"""
-        nonce = self.client_nonce + self.server_nonce
-        server_first = f"r={nonce},s={self.salt},i={self.iteration_count}"
-        server_first_bytes = server_first.encode("utf-8")
+    async def authenticate_password_method(self):
+        """
+        Authenticate with one of the methods the listener offers (auth_methods.py).
 
-        # AuthenticationSASLContinue: R + length + 11 + data
-        message_length = 4 + 4 + len(server_first_bytes)
-        message = (
-            struct.pack("!cII", MSG_AUTHENTICATION, message_length, AUTH_SASL_CONTINUE)
-            + server_first_bytes
-        )
+        Raises:
+            ScramError: a SCRAM exchange failed
+            PasswordAuthenticationFailed: a wrong MD5 or cleartext password
+            LdapAuthenticationFailed: the directory refused the password
+        """
+        user = self.startup_params.get("user", "")
+        method = self.auth_methods[0]
+        if len(self.auth_methods) > 1:
+            method = await self.choose_auth_method(user)
+
+        if method == SCRAM_SHA_256:
+            # False when the user enrolled a verifier with a password instead
+            if await self.start_scram_authentication():
+                await self.handle_scram_client_final()
+                await self.complete_scram_authentication()
+        elif method == MD5:
+            await self.authenticate_md5(user)
+        elif method == PASSWORD:
+            self.writer.write(struct.pack("!cII", MSG_AUTHENTICATION, 8, AUTH_CLEARTEXT_PASSWORD))
+            await self.writer.drain()
+            password = decode_text((await self.read_sasl_response()).rstrip(b"\x00"))
+            await self.iris_executor.verify_iris_password(user, password)
+            await self.send_authentication_ok()
+        elif method == LDAP:
+            await self.authenticate_ldap(SERVICE)
+        else:
+            # P0: Basic authentication (trust)
+            await self.send_authentication_ok()
 
-        self.writer.write(message)
-        await self.writer.drain()
+    async def authenticate_md5(self, user: str):
+        """
+        AuthenticationMD5Password against the user's stored MD5 secret (md5_auth.py).
 
-        logger.debug(
-            "SCRAM server-first sent", connection_id=self.connection_id, nonce=nonce[:16] + "..."
-        )
+        A user without one enrolls when PGWIRE_SCRAM_ENROLL allows it.
 
-    async def handle_scram_client_final(self):
-        """Handle SCRAM client-final message"""
+        Raises:
+            PasswordAuthenticationFailed: wrong password, or no secret (28P01)
+        """
         try:
-            # Wait for client-final response
-            header = await self.reader.readexactly(5)
-            msg_type, length = struct.unpack("!cI", header)
+            secret = await self.iris_executor.md5_secret(user)
+        except Exception as e:
+            logger.warning("MD5 secrets unavailable", user=user, error=str(e))
+            secret = None
+        if secret is None:
+            enroll = load_enroll_mode()
+            if enroll == "on" or (enroll == "tls" and self.ssl_enabled):
+                await self.enroll_password_secrets(user)
+                return
 
-            if msg_type != b"p":  # SASLResponse message
-                raise ValueError(f"Expected SASLResponse, got {msg_type}")
+        # Unknown users get a salt too and fail at the response, like a wrong password
+        salt = os.urandom(4)
+        self.writer.write(struct.pack("!cII", MSG_AUTHENTICATION, 12, AUTH_MD5_PASSWORD) + salt)
+        await self.writer.drain()
+        response = decode_text((await self.read_sasl_response()).rstrip(b"\x00"))
+        if not verify_md5_response(secret, salt, response):
+            raise PasswordAuthenticationFailed(user)
+        logger.info("MD5 authentication completed", connection_id=self.connection_id, user=user)
+        await self.send_authentication_ok()
 
-            body_length = length - 4
-            body = await self.reader.readexactly(body_length) if body_length > 0 else b""
+    async def send_authentication_ok(self):
+        """Send AuthenticationOk message (P0: basic trust auth)"""
+        # AuthenticationOk: R + length + 0
+        message = struct.pack("!cII", MSG_AUTHENTICATION, 8, 0)
+        self.writer.write(message)
+        await self.writer.drain()
+        logger.debug("Authentication OK sent", connection_id=self.connection_id)
 
-            # Parse client-final message
-            client_final_str = body.decode("utf-8")
-            logger.debug(
-                "SCRAM client-final received",
-                connection_id=self.connection_id,
-                message_preview=client_final_str[:50] + "...",
-            )
+    # P3: SCRAM-SHA-256 Authentication Methods (scram.py)
 
-            # In production, this would verify the client proof
-            # For now, we'll accept any well-formed client-final message
+    async def start_scram_authentication(self) -> bool:
+        """
+        Offer SCRAM-SHA-256 (and -PLUS over TLS) and answer the client-first-message.
 
-        except Exception as e:
-            logger.error(
-                "SCRAM client-final handling failed", connection_id=self.connection_id, error=str(e)
-            )
-            raise
+        A user without a stored verifier enrolls instead when PGWIRE_SCRAM_ENROLL
+        allows it: the password is checked against IRIS, its verifier stored and
+        AuthenticationOk sent. Returns False in that case.
 
-    async def complete_scram_authentication(self):
-        """
-        Complete SCRAM authentication with OAuth/Wallet integration (Feature 024).
-
-        Authentication Flow:
-        1. Extract username and password from SCRAM client-final
-        2. Select authentication method (OAuth vs password)
-        3. Try Wallet password retrieval first (if enabled)
-        4. Fallback to OAuth token exchange or password authentication
-        5. Send SCRAM final success on authentication success
+        Raises:
+            ScramError: the exchange failed
+            PasswordAuthenticationFailed: IRIS rejected an enrolling password
         """
+        user = self.startup_params.get("user", "")
         try:
-            # Parse client-final message to extract password
-            # In real SCRAM, this would verify the client proof
-            # For Feature 024, we'll extract credentials and authenticate via OAuth/Wallet
-
-            username = self.scram_state.get("username")
-            if not username:
-                raise ValueError("Username not found in SCRAM state")
-
-            # Feature 024: Authentication bridge integration
-            if self.auth_bridge_available:
-                try:
-                    # Select authentication method
-                    connection_context = {
-                        "auth_method": "password",  # SCRAM is password-based
-                        "username": username,
-                        "database": self.startup_params.get("database", "USER"),
-                        "oauth_available": True,
-                    }
-
-                    auth_method = await self.auth_selector.select_authentication_method(
-                        connection_context
-                    )
-
-                    logger.info(
-                        "Authentication method selected",
-                        connection_id=self.connection_id,
-                        username=username,
-                        method=auth_method,
-                    )
-
-                    # Try Wallet password retrieval first (if applicable)
-                    password = None
-                    should_try_wallet = await self.auth_selector.should_try_wallet_first(
-                        auth_method, username
-                    )
+            verifier = await self.iris_executor.scram_verifier(user)
+        except Exception as e:
+            logger.warning("SCRAM verifiers unavailable", user=user, error=str(e))
+            verifier = None
+        if verifier is None:
+            enroll = load_enroll_mode()
+            if enroll == "on" or (enroll == "tls" and self.ssl_enabled):
+                await self.enroll_password_secrets(user)
+                return False
+            # Fails at the proof, like a wrong password
+            verifier = mock_verifier(user)
+
+        channel_binding = self.tls_server_end_point if self.ssl_enabled else None
+        self.scram_exchange = ScramExchange(user, verifier, channel_binding)
+        self.scram_state = {"username": user}
+        await self.send_sasl_auth_request()
+
+        body = await self.read_sasl_response()
+        await self.handle_sasl_initial_response(body)
+        return True
+
+    async def enroll_password_secrets(self, user: str):
+        """
+        Authenticate with a cleartext password and store its SCRAM verifier and
+        MD5 secret, for the methods the listener offers.
+        """
+        self.writer.write(struct.pack("!cII", MSG_AUTHENTICATION, 8, AUTH_CLEARTEXT_PASSWORD))
+        await self.writer.drain()
+        password = decode_text((await self.read_sasl_response()).rstrip(b"\x00"))
+
+        await self.iris_executor.verify_iris_password(user, password)
+        if SCRAM_SHA_256 in self.auth_methods:
+            verifier = ScramVerifier.from_password(password)
+            await self.iris_executor.store_scram_verifier(user, verifier)
+        if MD5 in self.auth_methods:
+            await self.iris_executor.store_md5_secret(user, md5_secret(password, user))
+        logger.info(
+            "Password secrets enrolled",
+            connection_id=self.connection_id,
+            user=user,
+            methods=[m for m in self.auth_methods if m in (SCRAM_SHA_256, MD5)],
+        )
+        await self.send_authentication_ok()
 
-                    if should_try_wallet:
-                        try:
-                            password = await self.wallet_credentials.get_password_from_wallet(
-                                username
-                            )
-                            logger.info(
-                                "Password retrieved from Wallet",
-                                connection_id=self.connection_id,
-                                username=username,
-                            )
-                        except Exception as wallet_error:
-                            logger.info(
-                                "Wallet password retrieval failed - will use SCRAM password",
-                                connection_id=self.connection_id,
-                                username=username,
-                                error=str(wallet_error),
-                            )
-                            # Password remains None - will extract from SCRAM client-final
+    async def read_sasl_response(self) -> bytes:
+        """Body of the client's next password / SASL response message."""
+        header = await self.reader.readexactly(5)
+        msg_type, body_length = parse_message_header(header, self.max_message_size)
+        if msg_type != b"p":
+            raise ProtocolViolation(f"expected SASL response, got message type {msg_type!r}")
+        return await self.reader.readexactly(body_length) if body_length > 0 else b""
 
-                    # If no wallet password, extract from SCRAM client-final
-                    if password is None:
-                        # TODO: Implement proper SCRAM client-final parsing to extract password
-                        # For now, use a placeholder (trust mode)
-                        password = "placeholder_password"
-                        logger.warning(
-                            "SCRAM client-final password extraction not yet implemented",
-                            connection_id=self.connection_id,
-                            username=username,
-                        )
+    async def send_sasl_auth_request(self):
+        """Send AuthenticationSASL with the mechanisms of the exchange"""
+        mechanisms = self.scram_exchange.mechanisms
+        # AuthenticationSASL: R + length + 10 + null-terminated list of mechanisms
+        payload = b"".join(name.encode() + b"\x00" for name in mechanisms) + b"\x00"
+        message_length = 4 + 4 + len(payload)
 
-                    # Authenticate based on selected method
-                    if auth_method == "oauth":
-                        # OAuth token exchange
-                        token = await self.oauth_bridge.exchange_password_for_token(
-                            username, password
-                        )
-                        logger.info(
-                            "OAuth authentication successful",
-                            connection_id=self.connection_id,
-                            username=username,
-                            expires_in=token.expires_in,
-                        )
+        message = struct.pack("!cII", MSG_AUTHENTICATION, message_length, AUTH_SASL) + payload
+        self.writer.write(message)
+        await self.writer.drain()
 
-                        # Store token in session for future requests
-                        self.scram_state["oauth_token"] = token
+        logger.debug(
+            "SASL authentication request sent",
+            connection_id=self.connection_id,
+            mechanisms=mechanisms,
+        )
 
-                    elif auth_method == "password":
-                        # Direct password authentication (fallback)
-                        # Verify password against IRIS %Service_Login
-                        logger.info(
-                            "Password authentication (fallback)",
-                            connection_id=self.connection_id,
-                            username=username,
-                        )
-                        # TODO: Implement actual password verification
-                        # For now, accept in trust mode
+    async def handle_sasl_initial_response(self, body: bytes):
+        """Handle client's SASL initial response"""
+        pos = 0
 
-                    else:
-                        raise ValueError(f"Unsupported authentication method: {auth_method}")
+        # Parse mechanism name
+        mechanism_end = body.find(b"\x00", pos)
+        if mechanism_end == -1:
+            raise malformed("missing SASL mechanism")
+        mechanism = body[pos:mechanism_end].decode("utf-8", "replace")
+        pos = mechanism_end + 1
 
-                    # Authentication successful - send SCRAM final success
-                    await self.send_scram_final_success()
+        # Parse initial response length
+        if pos + 4 > len(body):
+            raise malformed("missing SASL response length")
+        response_length = struct.unpack("!i", body[pos : pos + 4])[0]
+        pos += 4
 
-                except Exception as auth_error:
-                    logger.error(
-                        "Authentication failed",
-                        connection_id=self.connection_id,
-                        username=username,
-                        error=str(auth_error),
-                    )
-                    raise ValueError(f"Authentication failed: {auth_error}")
+        # Parse initial response data
+        if response_length == -1 or pos + response_length > len(body):
+            raise malformed("missing or truncated client-first-message")
+        client_first = body[pos : pos + response_length]
 
-            else:
-                # Authentication bridge not available - use trust mode
-                logger.warning(
-                    "Using trust mode authentication (bridge unavailable)",
-                    connection_id=self.connection_id,
-                    username=username,
-                )
-                await self.send_scram_final_success()
+        server_first = self.scram_exchange.client_first(mechanism, client_first)
+        await self.send_sasl_message(AUTH_SASL_CONTINUE, server_first)
+        logger.debug(
+            "SCRAM server-first sent", connection_id=self.connection_id, mechanism=mechanism
+        )
 
-        except Exception as e:
-            logger.error(
-                "SCRAM authentication completion failed",
-                connection_id=self.connection_id,
-                error=str(e),
-            )
-            raise
+    async def handle_scram_client_final(self):
+        """Verify the client-final-message's proof (and channel binding)"""
+        client_final = await self.read_sasl_response()
+        self.scram_state["server_final"] = self.scram_exchange.client_final(client_final)
+        self.scram_state["mechanism"] = self.scram_exchange.mechanism
 
-    async def send_scram_final_success(self):
-        """Send SCRAM final success message"""
-        # AuthenticationSASLFinal: R + length + 12 + server_signature
-        server_final = "v=rmF+pqV8S7suAoZWja4dJRkFsKQ="  # Dummy server signature
-        server_final_bytes = server_final.encode("utf-8")
+    async def complete_scram_authentication(self):
+        """
+        Finish a verified SCRAM exchange: send the server signature and AuthenticationOk.
 
-        message_length = 4 + 4 + len(server_final_bytes)
-        message = (
-            struct.pack("!cII", MSG_AUTHENTICATION, message_length, AUTH_SASL_FINAL)
-            + server_final_bytes
-        )
+        Raises:
+            ScramError: the client proof was not verified (28P01)
+        """
+        if "server_final" not in self.scram_state:
+            raise authentication_failed(self.scram_state.get("username", ""))
+        await self.send_scram_final_success()
 
+    async def send_sasl_message(self, auth_type: int, data: bytes):
+        # AuthenticationSASLContinue / AuthenticationSASLFinal: R + length + type + data
+        message = struct.pack("!cII", MSG_AUTHENTICATION, 8 + len(data), auth_type) + data
         self.writer.write(message)
         await self.writer.drain()
 
-        # Send final AuthenticationOk
+    async def send_scram_final_success(self):
+        """Send the server signature (AuthenticationSASLFinal), then AuthenticationOk"""
+        await self.send_sasl_message(AUTH_SASL_FINAL, self.scram_state["server_final"])
         await self.send_authentication_ok()
 
         logger.info(
             "SCRAM authentication completed successfully",
             connection_id=self.connection_id,
             username=self.scram_state.get("username"),
+            mechanism=self.scram_state.get("mechanism"),
         )
 
     async def send_parameter_status(self):
         """Send ParameterStatus messages for PostgreSQL compatibility"""
         # Based on caretdev patterns and PostgreSQL requirements
+        settings = self.session_settings
         parameters = {
             "server_version": "16.0 (InterSystems IRIS)",
             "server_version_num": "160000",
-            "client_encoding": "UTF8",
-            "DateStyle": "ISO, MDY",
-            "TimeZone": "UTC",
-            "standard_conforming_strings": "on",
-            "integer_datetimes": "on",
-            "IntervalStyle": "postgres",
+            "client_encoding": settings.get("client_encoding"),
+            "DateStyle": settings.get("DateStyle"),
+            "TimeZone": settings.get("TimeZone"),
+            "standard_conforming_strings": settings.get("standard_conforming_strings"),
+            "integer_datetimes": settings.get("integer_datetimes"),
+            "IntervalStyle": settings.get("IntervalStyle"),
             "is_superuser": "off",
             "server_encoding": "UTF8",
-            "application_name": self.startup_params.get("application_name", ""),
+            "application_name": settings.get("application_name"),
+            "default_transaction_read_only": settings.get("default_transaction_read_only"),
+            TRANSACTIONAL_DDL_PARAMETER: settings.get(TRANSACTIONAL_DDL_PARAMETER),
         }
 
         for key, value in parameters.items():
@@ -1285,12 +1763,7 @@ class PGWireProtocol:
 
     async def send_parameter_status_message(self, name: str, value: str):
         """Send a single ParameterStatus message"""
-        name_bytes = name.encode("utf-8") + b"\x00"
-        value_bytes = value.encode("utf-8") + b"\x00"
-        length = 4 + len(name_bytes) + len(value_bytes)
-
-        message = struct.pack("!cI", MSG_PARAMETER_STATUS, length) + name_bytes + value_bytes
-        self.writer.write(message)
+        self.writer.write(parameter_status(name, value))
         await self.writer.drain()
 
     async def send_backend_key_data(self):
@@ -1311,16 +1784,45 @@ class PGWireProtocol:
 
     async def send_ready_for_query(self):
         """Send ReadyForQuery message"""
+        # set_config(..., true) outside a transaction block lasted for its statement only
+        if self.transaction_status == STATUS_IDLE:
+            self.session_settings.end_transaction()
+
+        # Report GUC_REPORT parameters changed by SET/RESET not reported yet
+        self._report_parameters()
+
+        # Notifications held in a transaction block or sent by this session (notifications.py)
+        if self.transaction_status == STATUS_IDLE:
+            for notification in self.notifications.take():
+                self.writer.write(notification_response(notification))
+
         # ReadyForQuery: Z + length + status
         message = struct.pack("!cI", MSG_READY_FOR_QUERY, 5) + self.transaction_status
         self.writer.write(message)
         await self.writer.drain()
+        self.awaiting_command = True
         logger.debug(
             "Ready for query sent",
             connection_id=self.connection_id,
             status=self.transaction_status.decode(),
         )
 
+    def _notifications_arrived(self):
+        """
+        Send notifications at once outside a transaction block, also while a query runs.
+
+        The session's own NOTIFYs wait for its ReadyForQuery, as in PostgreSQL.
+        """
+        if self.transaction_status == STATUS_IDLE:
+            exclude = None if self.awaiting_command else self.backend_pid
+            for notification in self.notifications.take(exclude_sender=exclude):
+                self.async_messages.send(notification_response(notification))
+
+    def _report_parameters(self):
+        """ParameterStatus for GUC_REPORT parameters changed by SET/RESET since the last report"""
+        for name, value in self.session_settings.take_pending_reports().items():
+            self.async_messages.send(parameter_status(name, value))
+
     async def send_error_response(self, severity: str, code: str, message_type: str, message: str):
         """Send ErrorResponse message"""
         # ErrorResponse: E + length + fields
@@ -1337,6 +1839,17 @@ class PGWireProtocol:
         self.writer.write(error_msg)
         await self.writer.drain()
 
+        # Like PostgreSQL, the rest of a failed pipeline is skipped until Sync
+        if severity == "ERROR" and self.extended_message:
+            self.ignore_till_sync = True
+
+    async def send_notice_response(
+        self, severity: str, code: str, message: str, detail: str | None = None
+    ):
+        """Send NoticeResponse message (WARNING / NOTICE the statement still succeeds)"""
+        self.writer.write(notice_response(severity, code, message, detail))
+        await self.writer.drain()
+
     async def message_loop(self):
         """
         Main message processing loop (P0: basic structure)
@@ -1346,15 +1859,39 @@ class PGWireProtocol:
         """
         logger.info("Entering message loop", connection_id=self.connection_id)
 
+        # Pipelined responses are written without waiting on the client (pipeline.py)
+        if not isinstance(self.writer, PipelineWriter):
+            self.writer = PipelineWriter(self.writer)
+
         try:
             while True:
-                # Read message type and length
-                header = await self.reader.readexactly(5)
-                msg_type, length = struct.unpack("!cI", header)
+                # Read message type and length (validated before the body is allocated)
+                header = await self.read_message_header()
+                msg_type, body_length = parse_message_header(
+                    header, self.max_message_size, self.max_bind_message_size
+                )
+                length = body_length + 4
 
-                # Read message body
-                body_length = length - 4
-                if body_length > 0:
+                # Read message body (large Bind values are spooled as they arrive, and
+                # Bind messages above PGWIRE_MAX_MESSAGE_SIZE are never buffered whole)
+                large_values = None
+                if msg_type == MSG_BIND and body_length > min(
+                    self.large_value_bytes, self.max_message_size
+                ):
+                    try:
+                        body, large_values = await read_bind_message(
+                            self.reader,
+                            body_length,
+                            self.large_value_bytes,
+                            self.portal_cursors.spool_config,
+                        )
+                    except TempFileLimitExceeded as e:
+                        await self.send_error_response(
+                            "ERROR", e.sqlstate, e.condition_name, str(e)
+                        )
+                        self.ignore_till_sync = True
+                        continue
+                elif body_length > 0:
                     body = await self.reader.readexactly(body_length)
                 else:
                     body = b""
@@ -1366,8 +1903,29 @@ class PGWireProtocol:
                     length=length,
                 )
 
+                if self.ignore_till_sync and msg_type not in (MSG_SYNC, MSG_TERMINATE):
+                    logger.debug(
+                        "Message skipped until Sync",
+                        connection_id=self.connection_id,
+                        msg_type=msg_type,
+                    )
+                    continue
+                self.extended_message = (
+                    msg_type in EXTENDED_QUERY_MESSAGES and not self.replication_mode
+                )
+                if self.extended_message:
+                    self.writer.pipelining = True
+                elif msg_type == MSG_QUERY:
+                    self.writer.pipelining = False
+
                 # Handle message based on type
-                if msg_type == MSG_QUERY:
+                if self.replication_mode and msg_type in REPLICATION_FORBIDDEN_MESSAGES:
+                    # Walsender sessions speak the simple protocol only (replication.py)
+                    await self.send_error_response(
+                        "ERROR", "08P01", "protocol_violation", EXTENDED_PROTOCOL_IN_REPLICATION
+                    )
+                    await self.send_ready_for_query()
+                elif msg_type == MSG_QUERY:
                     # P1: Simple Query Protocol
                     await self.handle_query_message(body)
                 elif msg_type == MSG_PARSE:
@@ -1375,7 +1933,7 @@ class PGWireProtocol:
                     await self.handle_parse_message(body)
                 elif msg_type == MSG_BIND:
                     # P2: Extended Protocol - Bind
-                    await self.handle_bind_message(body)
+                    await self.handle_bind_message(body, large_values)
                 elif msg_type == MSG_DESCRIBE:
                     # P2: Extended Protocol - Describe
                     await self.handle_describe_message(body)
@@ -1414,12 +1972,50 @@ class PGWireProtocol:
 
         except asyncio.IncompleteReadError:
             logger.info("Client disconnected", connection_id=self.connection_id)
+        except ProtocolViolation as e:
+            # The stream can no longer be framed - report and close, like PostgreSQL
+            logger.warning("Protocol violation", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+        except IdleSessionTimeout as e:
+            logger.info("Idle session timeout", connection_id=self.connection_id)
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
+        except ClientTerminated:
+            logger.info("Client terminated replication stream", connection_id=self.connection_id)
+        except WalSenderTimeout as e:
+            logger.info("Replication timeout", connection_id=self.connection_id)
+            await self.send_error_response("FATAL", e.sqlstate, e.condition_name, str(e))
         except Exception as e:
             logger.error("Message loop error", connection_id=self.connection_id, error=str(e))
             await self.send_error_response(
                 "FATAL", "08006", "connection_failure", f"Protocol error: {e}"
             )
 
+    async def read_message_header(self) -> bytes:
+        """
+        Read the next message's type and length.
+
+        While the session waits for a command outside a transaction, the wait
+        is limited by idle_session_timeout (see keepalive.py).
+
+        Raises:
+            IdleSessionTimeout: no message within idle_session_timeout
+        """
+        timeout = None
+        if self.awaiting_command and self.transaction_status == STATUS_IDLE:
+            timeout = idle_timeout_seconds(
+                self.session_settings.get(IDLE_SESSION_TIMEOUT_PARAMETER)
+            )
+
+        try:
+            if timeout is None:
+                header = await self.reader.readexactly(5)
+            else:
+                header = await asyncio.wait_for(self.reader.readexactly(5), timeout)
+        except asyncio.TimeoutError:
+            raise IdleSessionTimeout() from None
+        self.awaiting_command = False
+        return header
+
     async def handle_query_message(self, body: bytes):
         """
         P1: Real Query message handler with IRIS execution
@@ -1434,15 +2030,20 @@ class PGWireProtocol:
         """
         try:
             # Parse query string (null-terminated)
-            query = body.rstrip(b"\x00").decode("utf-8")
+            query = decode_text(body.rstrip(b"\x00"))
             logger.info(
                 "Query received",
                 connection_id=self.connection_id,
                 query=query[:100] + "..." if len(query) > 100 else query,
             )
 
+            # Replication connections accept the walsender grammar (replication.py)
+            if self.replication_mode and await self.handle_replication_command(query):
+                return
+
             # CRITICAL: Translate PostgreSQL syntax (:: type casts, $1 parameters if present)
             # This enables Simple Query protocol to work with PostgreSQL-specific syntax
+            client_query = query
             query = self.translate_postgres_parameters(query)
 
             # CRITICAL (.NET Npgsql Fix): Split multiple statements by semicolons
@@ -1457,13 +2058,33 @@ class PGWireProtocol:
                     statement_count=len(statements),
                 )
 
+            # Result columns are named from the statements as the client wrote them
+            client_statements = (
+                self._split_query_statements(client_query) if client_query != query else statements
+            )
+            if len(client_statements) != len(statements):
+                client_statements = statements
+
             # Process each statement, sending ReadyForQuery only after the last one
             for i, statement in enumerate(statements):
                 is_last_statement = i == len(statements) - 1
-                await self._handle_single_statement(statement, send_ready=is_last_statement)
+                await self._handle_single_statement(
+                    statement, send_ready=is_last_statement, client_sql=client_statements[i]
+                )
 
             return  # All statements processed
 
+        except (ClientTerminated, WalSenderTimeout, ProtocolViolation):
+            raise  # Ends a replication stream and the connection (message_loop)
+        except MalformedMessage as e:
+            logger.warning(
+                "Malformed Query message", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            await self.send_ready_for_query()
+        except UndefinedRegName as e:
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            await self.send_ready_for_query()
         except Exception as e:
             logger.error("Query handling failed", connection_id=self.connection_id, error=str(e))
             await self.send_error_response(
@@ -1472,6 +2093,215 @@ class PGWireProtocol:
             # CRITICAL: Send ReadyForQuery after exception in Simple Query Protocol
             await self.send_ready_for_query()
 
+    async def handle_replication_command(self, query: str) -> bool:
+        """
+        Answer a Query message on a replication connection.
+
+        Returns:
+            False when the statement runs like in a regular session (SHOW, and
+            SQL on a logical replication connection)
+        """
+        command = replication_command(query)
+        if command is None:
+            if self.replication_mode != PHYSICAL:
+                return False
+            await self.send_error_response(
+                "ERROR", "0A000", "feature_not_supported", SQL_IN_PHYSICAL_REPLICATION
+            )
+            await self.send_ready_for_query()
+            return True
+        if command == "SHOW":
+            return False
+
+        logger.info("Replication command", connection_id=self.connection_id, command=command)
+        if command == "IDENTIFY_SYSTEM":
+            config = self.iris_executor.iris_config
+            result = identify_system_result(
+                system_identifier(config.get("host"), config.get("port"), config.get("namespace")),
+                None if self.replication_mode == PHYSICAL else self.startup_params.get("database"),
+                await current_position(self.iris_executor),
+            )
+            await self.send_row_description(result["columns"])
+            await self.send_data_rows_with_backpressure(result["rows"], result["columns"])
+            await self.send_postgresql_command_response(command)
+        elif command == "START_REPLICATION":
+            await self.start_replication(query)
+        elif command in SLOT_COMMANDS:
+            try:
+                result = await self.handle_slot_command(command, query)
+            except SlotError as e:
+                await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+                await self.send_ready_for_query()
+                return True
+            if result is not None:
+                await self.send_row_description(result["columns"])
+                await self.send_data_rows_with_backpressure(result["rows"], result["columns"])
+            await self.send_postgresql_command_response(command)
+        else:
+            await self.send_error_response(
+                "ERROR", "0A000", "feature_not_supported", f"{command} is not supported"
+            )
+            await self.send_ready_for_query()
+        return True
+
+    def _slot_store_call(self, operation):
+        """Run operation(SlotStore) in the thread pool against the slots global."""
+        return self.iris_executor.global_operation(lambda accessor: operation(SlotStore(accessor)))
+
+    async def _find_slot(self, name: str) -> ReplicationSlot | None:
+        slot = get_active_slots().temporary.get(name)
+        if slot is None:
+            slot = await self._slot_store_call(lambda store: store.get(name))
+        return slot
+
+    async def handle_slot_command(self, command: str, query: str) -> dict | None:
+        """
+        Run CREATE_REPLICATION_SLOT, DROP_REPLICATION_SLOT or READ_REPLICATION_SLOT
+        (replication_slots.py); returns the result set, None for DROP.
+
+        Raises:
+            SlotError: the command fails
+        """
+        active = get_active_slots()
+        if command == "CREATE_REPLICATION_SLOT":
+            create = parse_create_slot(query)
+            database = self.startup_params.get("database")
+            if create.logical and self.replication_mode == PHYSICAL:
+                raise SlotError(
+                    "logical decoding requires a database connection",
+                    "0A000",
+                    "feature_not_supported",
+                )
+            if await self._find_slot(create.name) is not None:
+                raise duplicate_slot(create.name)
+            position = await current_position(self.iris_executor)
+            slot = ReplicationSlot(
+                create.name,
+                LOGICAL_SLOT if create.logical else PHYSICAL_SLOT,
+                plugin=create.plugin,
+                database=database if create.logical else None,
+                temporary=create.temporary,
+                restart_lsn=position,
+                confirmed_flush_lsn=position,
+            )
+            if slot.temporary:
+                active.create_temporary(slot, self.backend_pid)
+            else:
+                await self._slot_store_call(lambda store: store.create(slot))
+            logger.info("Replication slot created", slot=slot.name, temporary=slot.temporary)
+            return create_slot_result(slot, format_lsn)
+
+        name = parse_slot_command(query)
+        if command == "READ_REPLICATION_SLOT":
+            slot = await self._find_slot(name)
+            if slot is not None and slot.logical:
+                raise SlotError(
+                    "cannot use READ_REPLICATION_SLOT with a logical replication slot",
+                    "0A000",
+                    "feature_not_supported",
+                )
+            return read_slot_result(slot, format_lsn, TIMELINE)
+
+        active.check_available(name, self.backend_pid)
+        if not active.drop_temporary(name):
+            await self._slot_store_call(lambda store: store.drop(name))
+        logger.info("Replication slot dropped", slot=name)
+        return None
+
+    async def start_replication(self, query: str):
+        """
+        Stream in CopyBoth mode until the subscriber sends CopyDone
+        (replication_stream.py), then complete START_REPLICATION.
+        """
+        try:
+            start = parse_start_replication(query)
+        except InvalidStartReplication as e:
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            await self.send_ready_for_query()
+            return
+        if start.logical and self.replication_mode == PHYSICAL:
+            await self.send_error_response(
+                "ERROR",
+                "0A000",
+                "feature_not_supported",
+                "logical replication requires a database connection",
+            )
+            await self.send_ready_for_query()
+            return
+
+        async def read_message() -> tuple[bytes, bytes]:
+            header = await self.reader.readexactly(5)
+            msg_type, body_length = parse_message_header(header, self.max_message_size)
+            body = await self.reader.readexactly(body_length) if body_length > 0 else b""
+            return msg_type, body
+
+        async def write(data: bytes):
+            self.writer.write(data)
+            await self.writer.drain()
+
+        slot = None
+        if start.slot_name is not None:
+            try:
+                slot = await self._acquire_slot(start.slot_name, start.logical)
+            except SlotError as e:
+                await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+                await self.send_ready_for_query()
+                return
+            # Changes the subscriber confirmed are not sent again
+            start = replace(start, start_lsn=slot.resume_lsn(start.start_lsn))
+
+        async def confirmed(flush_lsn: int):
+            if slot.temporary:
+                slot.confirm(flush_lsn)
+                return
+            try:
+                await self._slot_store_call(lambda store: store.confirm(slot.name, flush_lsn))
+            except Exception as e:
+                logger.warning("Replication slot position not saved", slot=slot.name, error=str(e))
+
+        logger.info(
+            "Replication streaming started",
+            connection_id=self.connection_id,
+            slot=start.slot_name,
+            start_lsn=format_lsn(start.start_lsn),
+        )
+        try:
+            await ReplicationStream(
+                self.wal_sender,
+                read_message,
+                write,
+                position=lambda: current_position(self.iris_executor),
+                on_confirmed=confirmed if slot is not None else None,
+            ).run(start)
+        finally:
+            if slot is not None:
+                get_active_slots().release(slot.name, self.backend_pid)
+        logger.info("Replication streaming ended", connection_id=self.connection_id)
+        await self.send_postgresql_command_response("START_REPLICATION")
+
+    async def _acquire_slot(self, name: str, logical: bool) -> ReplicationSlot:
+        """
+        The slot a START_REPLICATION streams, reserved for this connection.
+
+        Raises:
+            SlotError: no such slot, a slot of the other kind, or in use elsewhere
+        """
+        slot = await self._find_slot(name)
+        if slot is None:
+            raise undefined_slot(name)
+        if slot.logical != logical:
+            raise SlotError(
+                (
+                    "cannot use physical replication slot for logical decoding"
+                    if logical
+                    else "cannot use a logical replication slot for physical replication"
+                ),
+                "55000",
+                "object_not_in_prerequisite_state",
+            )
+        get_active_slots().acquire(name, self.backend_pid)
+        return slot
+
     def _split_query_statements(self, query: str) -> list:
         """
         Split a query string into individual statements by semicolons.
@@ -1493,13 +2323,17 @@ class PGWireProtocol:
 
         return statements
 
-    async def _handle_single_statement(self, query: str, send_ready: bool = True):
+    async def _handle_single_statement(
+        self, query: str, send_ready: bool = True, client_sql: str | None = None
+    ):
         """
         Handle a single SQL statement (extracted from multi-statement query).
 
         Args:
             query: Single SQL statement (no trailing semicolon)
             send_ready: If True, send ReadyForQuery after processing
+            client_sql: The statement before parameter and cast translation,
+                        which names the result columns (defaults to query)
         """
         try:
             # DEBUGGING: Log full SQL for CREATE TABLE statements
@@ -1514,10 +2348,12 @@ class PGWireProtocol:
                 return
             elif query_upper in ("COMMIT", "END"):
                 await self.iris_executor.commit_transaction()
+                await self.end_transaction(committed=True)
                 await self.send_transaction_response("COMMIT", send_ready=send_ready)
                 return
             elif query_upper == "ROLLBACK":
                 await self.iris_executor.rollback_transaction()
+                await self.end_transaction(committed=False)
                 await self.send_transaction_response("ROLLBACK", send_ready=send_ready)
                 return
 
@@ -1531,14 +2367,34 @@ class PGWireProtocol:
             # IRIS uses different SET syntax (requires OPTION keyword),
             # so we intercept PostgreSQL-specific SET commands and silently succeed
             if query_upper.startswith("SET ") or query_upper.startswith("RESET "):
-                await self.handle_set_command(query_upper, send_ready=send_ready)
+                await self.handle_set_command(query, send_ready=send_ready)
                 return
 
-            # Handle PostgreSQL UNLISTEN and CLOSE ALL commands
-            # IRIS doesn't support these, so we silently succeed
-            if query_upper.startswith("UNLISTEN") or query_upper.startswith("CLOSE ALL"):
-                await self.send_postgresql_command_response(query_upper, send_ready=send_ready)
-                return
+            # ALTER ROLE/DATABASE ... SET/RESET - stored in the bridge's role settings
+            if query_upper.startswith("ALTER "):
+                alter = parse_alter_setting(query)
+                if alter is not None:
+                    await self.handle_alter_setting_command(alter, send_ready=send_ready)
+                    return
+
+            # DECLARE ... CURSOR, FETCH, MOVE and CLOSE (see sql_cursors.py)
+            if query_upper.startswith(("DECLARE", "FETCH", "MOVE", "CLOSE")):
+                if await self.handle_cursor_statement(query, send_ready=send_ready):
+                    return
+
+            # LISTEN, UNLISTEN and NOTIFY (see notifications.py)
+            if query_upper.startswith(("LISTEN", "UNLISTEN", "NOTIFY")):
+                command = parse_notification_command(query)
+                if command is not None:
+                    await self.handle_notification_command(command, send_ready=send_ready)
+                    return
+
+            # SHOW for session-scoped parameters (values set with SET in this session)
+            if query_upper.startswith("SHOW "):
+                show_result = self._session_show_result(query)
+                if show_result is not None:
+                    await self.send_query_result(show_result, send_ready=send_ready)
+                    return
 
             # P6: Handle COPY commands
             if query_upper.startswith("COPY "):
@@ -1594,7 +2450,7 @@ class PGWireProtocol:
                 )
 
             # Execute translated SQL against IRIS
-            result = await self.iris_executor.execute_query(final_sql)
+            result = await self._execute_statement(final_sql)
 
             # Add translation metadata to result for debugging/monitoring
             if translation_result.get("translation_used"):
@@ -1608,15 +2464,31 @@ class PGWireProtocol:
                 }
 
             if result["success"]:
+                result["columns"] = name_result_columns(result.get("columns"), client_sql or query)
                 await self.send_query_result(result, send_ready=send_ready)
             else:
                 await self.send_error_response(
-                    "ERROR", "42000", "syntax_error", result.get("error", "Query execution failed")
+                    "ERROR",
+                    result.get("sqlstate", "42000"),
+                    result.get("condition_name", "syntax_error"),
+                    result.get("error", "Query execution failed"),
                 )
                 # CRITICAL: Send ReadyForQuery after error (only if last statement)
                 if send_ready:
                     await self.send_ready_for_query()
 
+        except (
+            NumericValueOutOfRange,
+            InvalidResultValue,
+            StreamValueTooLarge,
+            TempFileLimitExceeded,
+        ) as e:
+            # A result value its column type cannot hold (22003, 22P02), or a stream
+            # value that could not be read (54000, 53400)
+            logger.warning("Invalid result value", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            if send_ready:
+                await self.send_ready_for_query()
         except Exception as e:
             logger.error(
                 "Single statement handling failed", connection_id=self.connection_id, error=str(e)
@@ -1628,6 +2500,252 @@ class PGWireProtocol:
             if send_ready:
                 await self.send_ready_for_query()
 
+    async def handle_cursor_statement(self, query: str, send_ready: bool = True) -> bool:
+        """
+        Run DECLARE ... CURSOR, FETCH, MOVE or CLOSE (see sql_cursors.py).
+
+        Returns:
+            False when query is not a cursor statement
+        """
+        try:
+            statement = parse_cursor_statement(query)
+            if statement is None:
+                return False
+
+            if isinstance(statement, DeclareCursor):
+                if not statement.hold and self.transaction_status != STATUS_IN_TRANSACTION:
+                    raise CursorError(
+                        "DECLARE CURSOR can only be used in transaction blocks",
+                        "25P01",
+                        "no_active_sql_transaction",
+                    )
+                # Terminated like other statements, for IRIS literal parsing
+                cursor_sql = statement.query + ";"
+                await self.sql_cursors.declare(
+                    statement, lambda: self.iris_executor.open_cursor_stream(cursor_sql)
+                )
+                await self.send_command_complete("DECLARE CURSOR", send_ready=send_ready)
+            elif isinstance(statement, CloseCursor):
+                if statement.name is None:
+                    await self.sql_cursors.close_all()
+                    await self.send_command_complete("CLOSE CURSOR ALL", send_ready=send_ready)
+                else:
+                    await self.sql_cursors.close(statement.name)
+                    await self.send_command_complete("CLOSE CURSOR", send_ready=send_ready)
+            else:
+                cursor = self.sql_cursors.get(statement.name)
+                rows, row_count = await cursor.execute(statement)
+                result = {
+                    "success": True,
+                    "rows": rows,
+                    "columns": [] if statement.move else cursor.columns,
+                    "row_count": row_count,
+                    "command_tag": statement.command,
+                }
+                await self.send_query_result(result, send_ready=send_ready)
+        except CursorError as e:
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            if send_ready:
+                await self.send_ready_for_query()
+        return True
+
+    async def handle_notification_command(self, command, send_ready: bool = True):
+        """Run LISTEN, UNLISTEN or NOTIFY (see notifications.py)."""
+        if isinstance(command, Listen):
+            self.notifications.listen(command.channel)
+            tag = "LISTEN"
+        elif isinstance(command, Notify):
+            try:
+                self.notifications.notify(
+                    command.channel,
+                    command.payload,
+                    in_transaction=self.transaction_status == STATUS_IN_TRANSACTION,
+                )
+            except NotifyError as e:
+                await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+                if send_ready:
+                    await self.send_ready_for_query()
+                return
+            tag = "NOTIFY"
+        else:
+            self.notifications.unlisten(command.channel)
+            tag = "UNLISTEN"
+        await self.send_command_complete(tag, send_ready=send_ready)
+
+    def _notify_call_columns(self, call) -> list[dict[str, Any]]:
+        """pg_notify() returns void, pg_notification_queue_usage() double precision."""
+        return [
+            {
+                "name": call.column,
+                "type_oid": 2278 if isinstance(call, NotifyCall) else 701,
+                "type_size": 4 if isinstance(call, NotifyCall) else 8,
+                "type_modifier": -1,
+                "format_code": 0,
+            }
+        ]
+
+    def _notify_call_result(self, call, params: list | None) -> dict[str, Any]:
+        """Run SELECT pg_notify(...) or pg_notification_queue_usage() (see notifications.py)."""
+        if isinstance(call, NotifyCall):
+
+            def argument(value):
+                if isinstance(value, int):
+                    value = params[value] if params and value < len(params) else None
+                return value.decode("utf-8") if isinstance(value, bytes) else value
+
+            try:
+                self.notifications.notify(
+                    argument(call.channel),
+                    argument(call.payload),
+                    in_transaction=self.transaction_status == STATUS_IN_TRANSACTION,
+                )
+            except NotifyError as e:
+                return {
+                    "success": False,
+                    "error": str(e),
+                    "sqlstate": e.sqlstate,
+                    "condition_name": e.condition_name,
+                    "rows": [],
+                    "columns": [],
+                    "row_count": 0,
+                }
+            value = ""
+        else:
+            value = self.notifications.hub.queue_usage()
+        return {
+            "success": True,
+            "rows": [[value]],
+            "columns": self._notify_call_columns(call),
+            "row_count": 1,
+            "command_tag": "SELECT",
+        }
+
+    async def end_transaction(self, committed: bool):
+        """
+        Release what the ending transaction held: LOCK TABLE locks, cursors,
+        NOTIFYs, changes and SET LOCAL values.
+        """
+        self.session_settings.end_transaction()
+        await self.table_locks.release()
+        await self.sql_cursors.end_transaction(committed=committed)
+        self.notifications.end_transaction(committed=committed)
+        self.changes.end_transaction(committed=committed)
+
+    async def _execute_statement(self, sql: str, params: list | None = None) -> dict[str, Any]:
+        """Execute a client statement on IRIS, under a savepoint when configured."""
+        # Earlier SETs of a multi-statement query or pipeline are reported before this runs
+        self._report_parameters()
+
+        # x = ANY(?) of an array parameter → x IN (?, ...) (see array_values.py)
+        sql, params = expand_any_parameters(sql, params)
+
+        in_transaction = self.transaction_status == STATUS_IN_TRANSACTION
+        writable_cte = parse_writable_cte(sql)
+
+        async def execute():
+            if writable_cte is not None:
+                # Data-modifying WITH queries, one statement per CTE (see writable_cte.py)
+                return await run_writable_cte(
+                    self.iris_executor,
+                    writable_cte,
+                    params,
+                    own_transaction=not in_transaction,
+                    record=lambda step_sql, step_params, step_result: self.changes.record(
+                        step_sql, step_params, step_result, in_transaction=in_transaction
+                    ),
+                )
+            return await self.iris_executor.execute_query(sql, params=params)
+
+        # pg_notify() and pg_notification_queue_usage() run in the bridge
+        notify_call = parse_notify_call(sql)
+        if notify_call is not None:
+            return self._notify_call_result(notify_call, params)
+
+        # LOCK TABLE, held until the transaction ends (see table_locks.py)
+        lock = parse_lock_table(sql, get_schema_config()["iris_schema"])
+        if lock is not None:
+            if self.transaction_status != STATUS_IN_TRANSACTION:
+                return outside_transaction_error()
+            return await self.table_locks.lock(lock, self.session_settings.get("lock_timeout"))
+
+        # IRIS does not roll back DDL (see transactional_ddl.py)
+        ddl_mode = self.session_settings.get(DDL_IN_TRANSACTION_PARAMETER)
+        command = ddl_command(sql) if self.transaction_status == STATUS_IN_TRANSACTION else None
+        if command and ddl_mode == "error":
+            return ddl_in_transaction_error(command)
+
+        # iris.statement_savepoints (see statement_savepoints.py)
+        if (
+            self.transaction_status == STATUS_IN_TRANSACTION
+            and savepoints_enabled(self.session_settings.get(STATEMENT_SAVEPOINTS_PARAMETER))
+            and wraps_statement(sql)
+        ):
+            result = await run_with_savepoint(self.iris_executor, execute)
+        else:
+            result = await execute()
+
+        # DDL clauses the translator dropped with a change in behavior, DDL a rollback keeps
+        if result.get("success"):
+            notices = ddl_notices(sql)
+            if command and ddl_mode == "warn":
+                notices.append(ddl_warning(command))
+            if notices:
+                result["notices"] = [*result.get("notices", []), *notices]
+            # Changes for the PGWIRE_CDC_SINKS destinations
+            self.changes.record(
+                sql, params, result, in_transaction=self.transaction_status == STATUS_IN_TRANSACTION
+            )
+        return result
+
+    @property
+    def session_timezone(self):
+        """tzinfo for the session's TimeZone setting (DST-aware for region names)."""
+        return resolve_timezone(self.session_settings.get("TimeZone", "UTC"))
+
+    def _session_show_result(self, query: str) -> dict[str, Any] | None:
+        """
+        Build a SHOW result from session settings.
+
+        Returns None for parameters the session does not track, so the executor's
+        SHOW shim (server_version, transaction isolation level, ...) still answers them.
+        """
+        match = re.match(r"SHOW\s+([\w.]+)\s*;?\s*$", query.strip(), re.IGNORECASE)
+        if not match:
+            return None
+
+        settings = self.session_settings
+        param_name = match.group(1)
+
+        def text_column(name: str) -> dict[str, Any]:
+            return {
+                "name": name,
+                "type_oid": 25,
+                "type_size": -1,
+                "type_modifier": -1,
+                "format_code": 0,
+            }
+
+        if param_name.upper() == "ALL":
+            rows = [
+                [settings.canonical_name(key), value, ""]
+                for key, value in sorted(settings.values.items())
+            ]
+            columns = [text_column("name"), text_column("setting"), text_column("description")]
+        else:
+            value = settings.get(param_name)
+            if value is None:
+                return None
+            rows = [[value]]
+            columns = [text_column(settings.canonical_name(param_name))]
+
+        return {
+            "success": True,
+            "rows": rows,
+            "columns": columns,
+            "row_count": len(rows),
+            "command_tag": "SHOW",
+        }
+
     async def send_query_result(
         self, result: dict[str, Any], send_ready: bool = True, send_row_description: bool = True,
         result_formats: list[int] = None
@@ -1694,6 +2812,13 @@ class PGWireProtocol:
                 await self.send_data_rows_with_backpressure(rows, columns)
                 logger.info("🔵 STEP 3: DataRows sent", connection_id=self.connection_id)
 
+            # Warnings and notices about the statement precede its CommandComplete;
+            # plain strings are warnings, tuples (severity, sqlstate, message[, detail])
+            for notice in result.get("notices", []):
+                if isinstance(notice, str):
+                    notice = ("WARNING", "01000", notice)
+                await self.send_notice_response(*notice)
+
             # Send CommandComplete
             if command.upper() == "SELECT":
                 tag = f"SELECT {row_count}\x00".encode()
@@ -1773,11 +2898,14 @@ class PGWireProtocol:
         if result_formats is None:
             result_formats = []
 
+        select_mode = self.session_settings.get("iris.select_mode", "odbc")
+
         for i, col in enumerate(columns):
             name = col.get("name", "unknown")
             # CRITICAL: Lowercase column names for PostgreSQL compatibility
             # PostgreSQL clients expect lowercase unless explicitly quoted
-            if isinstance(name, str):
+            # (names taken from the client's select list are exact, see column_names.py)
+            if isinstance(name, str) and not col.get("name_from_sql"):
                 name = name.lower()
 
             # CRITICAL FIX: Use type_oid, type_size, type_modifier if already present
@@ -1811,21 +2939,14 @@ class PGWireProtocol:
                     mapped_oid=type_oid,
                 )
 
-            # CRITICAL FIX: Determine format_code from result_formats (from Bind message)
+            # iris.select_mode re-renders some types (e.g. logical DATE → int4 $HOROLOG)
+            rendered_oid = result_type(type_oid, select_mode)
+            if rendered_oid != type_oid:
+                type_oid, type_size, type_modifier = rendered_oid, 4 if rendered_oid == 23 else -1, -1
+
             # PostgreSQL protocol: format_code MUST match the format used in DataRow
-            # 0 = text format, 1 = binary format
-            if not result_formats:
-                # No format codes specified - default to text (0) for all columns
-                format_code = 0
-            elif len(result_formats) == 1:
-                # Single format code applies to all columns
-                format_code = result_formats[0]
-            elif i < len(result_formats):
-                # Per-column format code
-                format_code = result_formats[i]
-            else:
-                # Fallback to text if not enough format codes
-                format_code = 0
+            # (binary only for types with a binary form, see result_encoding.py)
+            format_code = result_format(result_formats, i, type_oid)
 
             logger.info(
                 "🔵 Format code determined",
@@ -1891,7 +3012,11 @@ class PGWireProtocol:
         if field_count < 0 or field_count > 65535:
             raise ValueError(f"Invalid field count: {field_count}")
 
-        data_row_data = struct.pack("!cIH", MSG_DATA_ROW, 0, field_count)  # Length will be updated
+        # Appended to in place, so a huge value is copied once (see large_values.py)
+        data_row_data = bytearray(struct.pack("!cIH", MSG_DATA_ROW, 0, field_count))
+        # LargeValues are written from their files between the parts
+        parts: list[bytearray | LargeValue] = [data_row_data]
+        select_mode = self.session_settings.get("iris.select_mode", "odbc")
 
         for i, col in enumerate(columns):
             # Row is a list of values, access by index
@@ -1900,219 +3025,103 @@ class PGWireProtocol:
             # DEBUG: Log value being sent for each column
             col_name = col.get("name", "unknown")
             logger.info(
-                f"📦 DataRow column {i}: name='{col_name}' value='{value}' type={type(value).__name__}"
+                f"📦 DataRow column {i}: name='{col_name}' value={preview(value)} "
+                f"type={type(value).__name__}"
             )
 
             if value is None:
                 # NULL value
                 data_row_data += struct.pack("!I", 0xFFFFFFFF)  # -1 indicates NULL
+            elif isinstance(value, FailedStreamValue):
+                raise value.error  # Over PGWIRE_MAX_STREAM_BYTES (see stream_values.py)
+            elif isinstance(value, LargeValue):
+                # A long character stream in a temporary file: its UTF-8 text is the
+                # value in text and binary format
+                data_row_data += struct.pack("!I", value.size)
+                data_row_data = bytearray()
+                parts += [value, data_row_data]
             else:
-                # Determine format code for this column
-                # If result_formats is empty, default to text (0)
-                # If single format, apply to all columns
-                # If per-column formats, use specific format for this column
+                # iris.select_mode rendering - type follows send_row_description
+                source_oid = col.get("type_oid", 25)
+                value = render_value(value, source_oid, select_mode)
+                col = {**col, "type_oid": result_type(source_oid, select_mode)}
+
+                # Same format as the column's RowDescription field
                 result_formats = getattr(self, "_current_result_formats", [])
-                if not result_formats:
-                    format_code = 0  # Default to text
-                elif len(result_formats) == 1:
-                    format_code = result_formats[0]  # Single format for all columns
-                elif i < len(result_formats):
-                    format_code = result_formats[i]  # Per-column format
-                else:
-                    format_code = 0  # Fallback to text
+                format_code = result_format(result_formats, i, col["type_oid"])
 
                 if format_code == 0:
                     # Text format - use PostgreSQL text conventions
                     type_oid = col.get("type_oid", 25)
 
                     # Special handling for boolean - PostgreSQL uses 't'/'f', not 'True'/'False' or '1'/'0'
-                    if type_oid == 16:  # BOOL
-                        if value in (1, "1", True, "t", "true", "TRUE"):
-                            value_str = "t"
-                        elif value in (0, "0", False, "f", "false", "FALSE"):
-                            value_str = "f"
-                        else:
-                            value_str = "t" if value else "f"
+                    if type_oid == BOOL_OID:
+                        value_str = format_bool(value)
+                    elif type_oid == 17 and isinstance(value, bytes | bytearray | memoryview):
+                        # BYTEA - rendering depends on the session's bytea_output
+                        value_str = format_bytea(
+                            value, self.session_settings.get("bytea_output", "hex")
+                        )
+                    elif type_oid in TEMPORAL_TYPES:  # DATE, TIME, TIMETZ, INTERVAL
+                        try:
+                            value_str = format_temporal(value, type_oid)
+                        except (TypeError, ValueError):
+                            value_str = str(value)
+                    elif type_oid == 1114:  # TIMESTAMP - ISO DateStyle, no trailing zeros
+                        try:
+                            value_str = format_timestamp(value)
+                        except (TypeError, ValueError):
+                            value_str = str(value)
+                    elif type_oid == 1184:  # TIMESTAMPTZ - stored as UTC, shown in session TimeZone
+                        try:
+                            value_str = format_timestamptz(value, self.session_timezone)
+                        except (TypeError, ValueError):
+                            value_str = str(value)
+                    elif type_oid == UUID_OID:  # UUID - lowercase, as IRIS may store upper case
+                        value_str = format_uuid(value)
+                    elif type_oid == VECTOR_OID:  # VECTOR - pgvector's [1,2,3]
+                        value_str = format_vector(value)
+                    elif type_oid == MONEY_OID:  # MONEY - rendered per lc_monetary
+                        value_str = format_money(value, self.session_settings.get("lc_monetary", "C"))
+                    elif type_oid == 1700:  # NUMERIC - every digit, padded to the column's scale
+                        value_str = format_numeric(
+                            value, numeric_scale(col.get("type_modifier") or -1)
+                        )
+                    elif is_integer_type(type_oid) and isinstance(value, int):
+                        # Strict range check - never describe an int4 column and send an int8 value
+                        value_str = str(check_integer_range(value, type_oid))
                     else:
                         value_str = str(value)
 
                     value_bytes = value_str.encode("utf-8")
                     data_row_data += struct.pack("!I", len(value_bytes)) + value_bytes
-                elif format_code == 1:
-                    # Binary format - encode based on PostgreSQL type OID
-                    type_oid = col.get("type_oid", 25)  # Default to TEXT (25)
-
-                    try:
-                        if type_oid == 23:  # INT4
-                            binary_data = struct.pack("!i", int(value))
-                        elif type_oid == 21:  # INT2 (smallint)
-                            binary_data = struct.pack("!h", int(value))
-                        elif type_oid == 20:  # INT8 (bigint)
-                            binary_data = struct.pack("!q", int(value))
-                        elif type_oid == 700:  # FLOAT4
-                            binary_data = struct.pack("!f", float(value))
-                        elif type_oid == 701:  # FLOAT8 (double)
-                            binary_data = struct.pack("!d", float(value))
-                        elif type_oid == 26:  # OID (4-byte unsigned int)
-                            binary_data = struct.pack("!I", int(value))
-                        elif type_oid == 19:  # NAME (63-byte string, encode as text)
-                            binary_data = str(value).encode("utf-8")
-                        elif type_oid == 16:  # BOOL
-                            binary_data = struct.pack("!?", bool(value))
-                        elif type_oid == 1082:  # DATE
-                            # PostgreSQL DATE binary format: 4-byte signed integer (days since 2000-01-01)
-                            # Value should already be converted from IRIS format in iris_executor.py
-                            binary_data = struct.pack("!i", int(value))
-                        elif type_oid == 1114:  # TIMESTAMP (without timezone)
-                            # PostgreSQL TIMESTAMP binary format: 8-byte signed integer (microseconds since 2000-01-01 00:00:00)
-                            # IRIS returns timestamps as strings like '2025-11-14 20:57:57'
-                            import datetime
-
-                            # Parse IRIS timestamp string
-                            if isinstance(value, str):
-                                timestamp_obj = datetime.datetime.strptime(
-                                    value, "%Y-%m-%d %H:%M:%S"
-                                )
-                            elif isinstance(value, datetime.datetime):
-                                timestamp_obj = value
-                            else:
-                                raise ValueError(f"Unexpected timestamp value type: {type(value)}")
-
-                            # PostgreSQL J2000 epoch: 2000-01-01 00:00:00
-                            PG_EPOCH = datetime.datetime(2000, 1, 1, 0, 0, 0)
-
-                            # Calculate microseconds since J2000 epoch
-                            delta = timestamp_obj - PG_EPOCH
-                            microseconds = int(delta.total_seconds() * 1_000_000)
-
-                            # Pack as 8-byte signed integer
-                            binary_data = struct.pack("!q", microseconds)
-                        elif type_oid == 1700:  # NUMERIC/DECIMAL
-                            # PostgreSQL NUMERIC binary format:
-                            # https://github.com/postgres/postgres/blob/master/src/backend/utils/adt/numeric.c
-                            # struct NumericData {
-                            #     int16 ndigits;  // number of base-10000 digits
-                            #     int16 weight;   // weight of first digit (10000^weight)
-                            #     int16 sign;     // 0x0000=positive, 0x4000=negative, 0xC000=NaN
-                            #     int16 dscale;   // display scale (digits after decimal point)
-                            #     int16 digits[]; // base-10000 digits
-                            # }
-                            from decimal import Decimal
-
-                            if isinstance(value, Decimal):
-                                # Convert Decimal to PostgreSQL binary NUMERIC format
-                                dec_str = str(value)
-                                is_negative = dec_str.startswith("-")
-                                dec_str = dec_str.lstrip("-")
-
-                                # Split into integer and fractional parts
-                                if "." in dec_str:
-                                    int_part, frac_part = dec_str.split(".")
-                                    dscale = len(frac_part)
-                                else:
-                                    int_part = dec_str
-                                    frac_part = ""
-                                    dscale = 0
-
-                                # PostgreSQL NUMERIC splits digits at decimal point
-                                # Integer part: pad left, fractional part: pad right
-                                # Example: 3.14 → int_part='3' frac_part='14'
-                                #   int groups: ['0003'] → [3]
-                                #   frac groups: ['1400'] → [1400]
-                                #   combined: [3, 1400] with weight=0
-
-                                # Process integer part (pad on LEFT to make groups of 4)
-                                if int_part == "0" or not int_part:
-                                    int_groups = []
-                                    int_weight = -1
-                                else:
-                                    int_padding = (4 - len(int_part) % 4) % 4
-                                    int_padded = "0" * int_padding + int_part
-                                    int_groups = []
-                                    for i in range(0, len(int_padded), 4):
-                                        int_groups.append(int(int_padded[i : i + 4]))
-                                    # Remove leading zeros
-                                    while int_groups and int_groups[0] == 0:
-                                        int_groups.pop(0)
-                                    int_weight = len(int_groups) - 1
-
-                                # Process fractional part (pad on RIGHT to make groups of 4)
-                                if frac_part:
-                                    frac_padding = (4 - len(frac_part) % 4) % 4
-                                    frac_padded = frac_part + "0" * frac_padding
-                                    frac_groups = []
-                                    for i in range(0, len(frac_padded), 4):
-                                        frac_groups.append(int(frac_padded[i : i + 4]))
-                                    # Remove trailing zeros
-                                    while frac_groups and frac_groups[-1] == 0:
-                                        frac_groups.pop()
-                                else:
-                                    frac_groups = []
-
-                                # Combine integer and fractional parts
-                                digits_10000 = int_groups + frac_groups
-                                weight = int_weight if int_groups else -len(frac_groups)
-
-                                ndigits = len(digits_10000)
-                                sign = 0x4000 if is_negative else 0x0000
-
-                                # Pack into PostgreSQL binary format
-                                binary_data = struct.pack("!hhhh", ndigits, weight, sign, dscale)
-                                for digit in digits_10000:
-                                    binary_data += struct.pack("!H", digit)
-                            else:
-                                # Fallback for non-Decimal values
-                                binary_data = str(value).encode("utf-8")
-                        else:
-                            # Fallback to text for unknown types
-                            binary_data = str(value).encode("utf-8")
-
-                        data_row_data += struct.pack("!I", len(binary_data)) + binary_data
-
-                        logger.debug(
-                            "Binary encoded column",
-                            column_index=i,
-                            type_oid=type_oid,
-                            value=value,
-                            binary_length=len(binary_data),
-                        )
-
-                    except (ValueError, struct.error) as e:
-                        # If binary encoding fails, fallback to text
-                        logger.warning(
-                            "Binary encoding failed, falling back to text",
-                            column_index=i,
-                            type_oid=type_oid,
-                            value=value,
-                            error=str(e),
-                        )
-                        value_str = str(value)
-                        value_bytes = value_str.encode("utf-8")
-                        data_row_data += struct.pack("!I", len(value_bytes)) + value_bytes
                 else:
-                    # Unknown format code - default to text
-                    logger.warning(
-                        "Unknown format code, defaulting to text",
-                        format_code=format_code,
-                        column_index=i,
+                    # Binary format - the type's send form (result_encoding.py)
+                    binary_data = encode_result_value(
+                        value, col["type_oid"], col.get("type_modifier") or -1
                     )
-                    value_str = str(value)
-                    value_bytes = value_str.encode("utf-8")
-                    data_row_data += struct.pack("!I", len(value_bytes)) + value_bytes
+                    data_row_data += struct.pack("!I", len(binary_data)) + binary_data
 
         # Update length
-        total_length = len(data_row_data) - 1  # Subtract the message type byte
-        data_row_data = data_row_data[:1] + struct.pack("!I", total_length) + data_row_data[5:]
+        header = parts[0]
+        total_length = sum(len(part) for part in parts) - 1  # Subtract the message type byte
+        struct.pack_into("!I", header, 1, total_length)
 
         # DEBUG: Hex dump of DataRow message (first 200 bytes)
-        hex_preview = data_row_data[:200].hex()
+        hex_preview = header[:200].hex()
         logger.info(f"🔍 DataRow hex dump (first 200 bytes): {hex_preview}")
         logger.info("🔍 DataRow message structure:")
-        logger.info(f"   - Message type: {data_row_data[0:1].hex()} ('D')")
-        logger.info(f"   - Total length: {struct.unpack('!I', data_row_data[1:5])[0]} bytes")
-        logger.info(f"   - Field count: {struct.unpack('!H', data_row_data[5:7])[0]}")
-
-        self.writer.write(data_row_data)
+        logger.info(f"   - Message type: {header[0:1].hex()} ('D')")
+        logger.info(f"   - Total length: {struct.unpack('!I', header[1:5])[0]} bytes")
+        logger.info(f"   - Field count: {struct.unpack('!H', header[5:7])[0]}")
+
+        for part in parts:
+            if isinstance(part, LargeValue):
+                for chunk in part.chunks():
+                    self.writer.write(chunk)
+                    await self.writer.drain()
+            elif part:
+                self.writer.write(part)
         await self.writer.drain()
 
     async def send_simple_query_response(self):
@@ -2283,7 +3292,8 @@ class PGWireProtocol:
         PostgreSQL clients send SET commands to configure runtime parameters.
         IRIS uses different syntax (SET OPTION parameter = value) vs PostgreSQL (SET parameter = value).
 
-        We intercept common PostgreSQL SET/RESET commands and silently succeed to maintain compatibility.
+        Values are stored in the per-session SessionSettings so SHOW and text rendering
+        (bytea_output, lc_monetary) reflect them. Unknown parameters still succeed.
 
         Common PostgreSQL SET commands from drivers:
         - SET EXTRA_FLOAT_DIGITS = X (JDBC driver initialization - CRITICAL blocker)
@@ -2295,51 +3305,8 @@ class PGWireProtocol:
         - RESET parameter (reset specific parameter)
         """
         try:
-            # Parse SET/RESET command to extract parameter name
-            # Format: "SET parameter = value" or "SET parameter TO value" or "RESET parameter" or "RESET ALL"
             command_clean = command.strip()
-
-            # DEBUG: Log the incoming command
-            logger.warning(
-                f"🔍 DEBUG handle_set_command called with: '{command_clean}' (length={len(command_clean)})",
-                connection_id=self.connection_id,
-            )
-
-            # Extract parameter name (everything between SET/RESET and = or TO)
-            import re
-
-            # Try SET pattern first
-            match = re.match(r"SET\s+(\w+)(?:\s+(?:=|TO)\s+(.+))?", command_clean, re.IGNORECASE)
-            logger.warning(f"🔍 DEBUG SET pattern match: {match}", connection_id=self.connection_id)
-
-            # If no SET match, try RESET pattern
-            if not match:
-                match = re.match(r"RESET\s+(\w+|\*)", command_clean, re.IGNORECASE)
-                logger.warning(
-                    f"🔍 DEBUG RESET pattern match: {match}", connection_id=self.connection_id
-                )
-                if match:
-                    # RESET command - treat as setting to default
-                    param_name = match.group(1)
-                    param_value = "DEFAULT"
-                    logger.warning(
-                        f"🔍 DEBUG RESET matched: param_name='{param_name}', param_value='{param_value}'",
-                        connection_id=self.connection_id,
-                    )
-                else:
-                    param_name = None
-                    param_value = None
-                    logger.warning(
-                        "🔍 DEBUG No match for RESET pattern!", connection_id=self.connection_id
-                    )
-            else:
-                # SET command
-                param_name = match.group(1)
-                param_value = match.group(2) if match.group(2) else None
-                logger.warning(
-                    f"🔍 DEBUG SET matched: param_name='{param_name}', param_value='{param_value}'",
-                    connection_id=self.connection_id,
-                )
+            param_name, param_value = self._apply_set_command(command_clean)
 
             if param_name:
                 logger.info(
@@ -2351,6 +3318,18 @@ class PGWireProtocol:
                     ),
                 )
 
+                warning = self.set_local_warning(command_clean)
+                if warning:
+                    await self.send_notice_response("WARNING", "25P01", warning)
+
+                # IRIS checks constraints immediately (see sql_translator/ddl_translator.py)
+                if param_name.upper() == "CONSTRAINTS" and re.search(
+                    r"\bDEFERRED$", param_value or "", re.IGNORECASE
+                ):
+                    await self.send_notice_response(
+                        "WARNING", "01000", DEFERRED_CONSTRAINTS_WARNING
+                    )
+
                 # Send success response for all SET/RESET commands
                 # PostgreSQL clients expect success for runtime parameter configuration
                 await self.send_set_response(param_name, param_value, send_ready=send_ready)
@@ -2365,6 +3344,10 @@ class PGWireProtocol:
                 if send_ready:
                     await self.send_ready_for_query()
 
+        except InvalidParameterValue as e:
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            if send_ready:
+                await self.send_ready_for_query()
         except Exception as e:
             logger.error(
                 "SET command handling failed",
@@ -2378,6 +3361,133 @@ class PGWireProtocol:
             if send_ready:
                 await self.send_ready_for_query()
 
+    # Role names PostgreSQL resolves to the session user in ALTER ROLE
+    CURRENT_ROLE_NAMES = frozenset({"current_user", "current_role", "session_user"})
+
+    async def handle_alter_setting_command(self, alter: AlterSetting, send_ready: bool = True):
+        """
+        ALTER ROLE/DATABASE ... SET/RESET: persist a session default (see role_settings.py).
+
+        Restricted to PGWIRE_ROLE_SETTINGS_ADMINS; the change applies to new sessions.
+        """
+        user = self.startup_params.get("user") or ""
+        try:
+            if user.casefold() not in load_role_settings_admins():
+                await self.send_error_response(
+                    "ERROR",
+                    "42501",
+                    "insufficient_privilege",
+                    f'permission denied to change role settings for user "{user}"',
+                )
+            else:
+                role = alter.role
+                if role is not None and role.lower() in self.CURRENT_ROLE_NAMES:
+                    role = user
+                value = alter.value
+                if alter.from_current:
+                    value = self.session_settings.get(alter.parameter, "")
+                get_role_settings().alter(role, alter.database, alter.parameter, value)
+
+                tag = f"{alter.command}\x00".encode()
+                cmd_complete = struct.pack("!cI", MSG_COMMAND_COMPLETE, 4 + len(tag)) + tag
+                self.writer.write(cmd_complete)
+                await self.writer.drain()
+        except (InvalidParameterValue, RoleSettingsNotPersistent) as e:
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+        except OSError as e:
+            logger.error(
+                "Role settings file write failed", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response(
+                "ERROR", "58030", "io_error", f"could not write role settings: {e}"
+            )
+
+        if send_ready:
+            await self.send_ready_for_query()
+
+    def _apply_set_command(self, command: str) -> tuple[str | None, str | None]:
+        """
+        Parse a SET/RESET statement and apply it to the session settings.
+
+        Handles:
+        - SET [SESSION | LOCAL] name {= | TO} value | DEFAULT
+        - SET TIME ZONE value, SET NAMES value
+        - RESET name, RESET ALL
+
+        SET LOCAL lasts until the transaction ends (see end_transaction);
+        outside a transaction block it is validated but has no effect
+        (set_local_warning).
+
+        Returns:
+            (parameter name, value) - name is None when the syntax is not recognized
+
+        Raises:
+            InvalidParameterValue: value rejected by the parameter's validator
+        """
+        command = command.strip().rstrip(";").strip()
+        settings = self.session_settings
+        local = bool(_SET_LOCAL.match(command))
+        if local and self.transaction_status == STATUS_IDLE:
+            set_value, reset = settings.validate, lambda name: None  # No effect
+        elif local:
+            set_value, reset = settings.set_local, settings.reset_local
+        else:
+            set_value, reset = settings.set, settings.reset
+
+        match = re.match(r"RESET\s+(ALL|[\w.]+)$", command, re.IGNORECASE)
+        if match:
+            param_name = match.group(1)
+            if param_name.upper() == "ALL":
+                self.session_settings.reset_all()
+            else:
+                self.session_settings.reset(param_name)
+            return param_name, "DEFAULT"
+
+        match = re.match(r"SET\s+(?:SESSION\s+|LOCAL\s+)?TIME\s+ZONE\s+(.+)$", command, re.IGNORECASE)
+        if match:
+            param_name, raw_value = "TimeZone", match.group(1)
+            try:
+                zone = interval_timezone_name(raw_value)
+            except ValueError as e:
+                raise InvalidParameterValue(str(e)) from None
+            if zone is not None:
+                return param_name, set_value(param_name, zone)
+        else:
+            match = re.match(r"SET\s+(?:SESSION\s+|LOCAL\s+)?NAMES\s+(.+)$", command, re.IGNORECASE)
+            if match:
+                param_name, raw_value = "client_encoding", match.group(1)
+            else:
+                match = re.match(
+                    r"SET\s+(?:SESSION\s+|LOCAL\s+)?([\w.]+)(?:\s*=\s*|\s+TO\s+)?(.+)?$",
+                    command,
+                    re.IGNORECASE | re.DOTALL,
+                )
+                if not match:
+                    return None, None
+                param_name, raw_value = match.group(1), match.group(2)
+
+        if raw_value is None:
+            # e.g. SET TRANSACTION ISOLATION LEVEL ... / SET SESSION CHARACTERISTICS ...
+            return param_name, None
+
+        value = strip_setting_value(raw_value)
+        keyword = raw_value.strip().upper()
+        if keyword == "DEFAULT" or (param_name == "TimeZone" and keyword == "LOCAL"):
+            reset(param_name)
+            return param_name, "DEFAULT"
+
+        if param_name.upper() in ("TRANSACTION", "SESSION", "CONSTRAINTS", "ROLE"):
+            # Transaction/session characteristics are not runtime parameters
+            return param_name, value
+
+        return param_name, set_value(param_name, value)
+
+    def set_local_warning(self, command: str) -> str | None:
+        """WARNING of a SET LOCAL outside a transaction block, which has no effect."""
+        if _SET_LOCAL.match(command) and self.transaction_status == STATUS_IDLE:
+            return "SET LOCAL can only be used in transaction blocks"
+        return None
+
     async def send_set_response(
         self, param_name: str, param_value: str = None, send_ready: bool = True
     ):
@@ -2416,8 +3526,6 @@ class PGWireProtocol:
         """Send response for PostgreSQL-specific commands not supported by IRIS
 
         Commands handled:
-        - UNLISTEN * (asyncpg connection reset)
-        - CLOSE ALL (asyncpg connection reset)
         - RESET ALL (asyncpg connection reset, already handled by handle_set_command)
 
         Args:
@@ -2556,16 +3664,17 @@ class PGWireProtocol:
             # Parse statement name
             name_end = body.find(b"\x00", pos)
             if name_end == -1:
-                raise ValueError("Invalid Parse message: missing statement name terminator")
-            statement_name = body[pos:name_end].decode("utf-8")
+                raise ProtocolViolation("Invalid Parse message: missing statement name terminator")
+            statement_name = decode_text(body[pos:name_end])
             pos = name_end + 1
 
             # Parse query
             query_end = body.find(b"\x00", pos)
             if query_end == -1:
-                raise ValueError("Invalid Parse message: missing query terminator")
-            query = body[pos:query_end].decode("utf-8")
+                raise ProtocolViolation("Invalid Parse message: missing query terminator")
+            query = decode_text(body[pos:query_end])
             pos = query_end + 1
+            client_query = query  # Names the result columns (see column_names.py)
 
             # CRITICAL: Translate PostgreSQL $1, $2, $3 parameters to IRIS ? syntax
             # This must happen BEFORE translation to avoid IRIS SQL errors with $1 syntax
@@ -2633,7 +3742,7 @@ class PGWireProtocol:
             # JDBC driver uses Extended Protocol, so we must intercept SET during Parse
             # to prevent translation failures and empty query storage
             query_upper = query.upper().strip().rstrip(";")
-            if query_upper.startswith("SET "):
+            if query_upper.startswith("SET ") or query_upper.startswith("RESET "):
                 logger.info(
                     "PostgreSQL SET command intercepted in Parse phase",
                     connection_id=self.connection_id,
@@ -2745,7 +3854,7 @@ class PGWireProtocol:
 
             # Parse parameter types count
             if pos + 2 > len(body):
-                raise ValueError("Invalid Parse message: missing parameter count")
+                raise ProtocolViolation("Invalid Parse message: missing parameter count")
             num_params = struct.unpack("!H", body[pos : pos + 2])[0]
             pos += 2
 
@@ -2753,7 +3862,7 @@ class PGWireProtocol:
             param_types = []
             for i in range(num_params):
                 if pos + 4 > len(body):
-                    raise ValueError(f"Invalid Parse message: missing parameter type {i}")
+                    raise ProtocolViolation(f"Invalid Parse message: missing parameter type {i}")
                 param_type = struct.unpack("!I", body[pos : pos + 4])[0]
                 param_types.append(param_type)
                 pos += 4
@@ -2796,6 +3905,11 @@ class PGWireProtocol:
                     translated_query=translation_result["translated_sql"][:150],
                 )
 
+            # pg_notify(channel, payload) takes text arguments
+            notify_call = parse_notify_call(translation_result["translated_sql"])
+            if isinstance(notify_call, NotifyCall) and not any(param_types):
+                param_types = [25] * max(len(param_types), notify_call.parameter_count)
+
             if not translation_result["success"]:
                 logger.warning(
                     "SQL translation failed for prepared statement",
@@ -2807,6 +3921,7 @@ class PGWireProtocol:
             # Store prepared statement with both original and translated SQL
             self.prepared_statements[statement_name] = {
                 "original_query": query,
+                "client_query": client_query,
                 "translated_query": translation_result["translated_sql"],
                 "param_types": param_types,
                 "translation_metadata": {
@@ -2849,16 +3964,28 @@ class PGWireProtocol:
             # Send ParseComplete response
             await self.send_parse_complete()
 
+        except MalformedMessage as e:
+            logger.warning(
+                "Malformed Parse message", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+        except UndefinedRegName as e:
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
         except Exception as e:
             logger.error(
                 "Parse message handling failed", connection_id=self.connection_id, error=str(e)
             )
             await self.send_error_response("ERROR", "42601", "syntax_error", f"Parse failed: {e}")
 
-    async def handle_bind_message(self, body: bytes):
+    async def handle_bind_message(
+        self, body: bytes, large_values: dict[int, LargeValue] | None = None
+    ):
         """
         P2: Handle Bind message for parameter binding
 
+        large_values: parameter values spooled while a large message was read,
+        by index; their slots in body are NULL (see large_values.py).
+
         Bind message format:
         - portal_name (null-terminated string)
         - statement_name (null-terminated string)
@@ -2875,15 +4002,15 @@ class PGWireProtocol:
             # Parse portal name
             name_end = body.find(b"\x00", pos)
             if name_end == -1:
-                raise ValueError("Invalid Bind message: missing portal name terminator")
-            portal_name = body[pos:name_end].decode("utf-8")
+                raise ProtocolViolation("Invalid Bind message: missing portal name terminator")
+            portal_name = decode_text(body[pos:name_end])
             pos = name_end + 1
 
             # Parse statement name
             stmt_end = body.find(b"\x00", pos)
             if stmt_end == -1:
-                raise ValueError("Invalid Bind message: missing statement name terminator")
-            statement_name = body[pos:stmt_end].decode("utf-8")
+                raise ProtocolViolation("Invalid Bind message: missing statement name terminator")
+            statement_name = decode_text(body[pos:stmt_end])
             pos = stmt_end + 1
 
             # Check if statement exists
@@ -2896,47 +4023,126 @@ class PGWireProtocol:
 
             # Parse parameter format codes
             if pos + 2 > len(body):
-                raise ValueError("Invalid Bind message: missing format codes count")
+                raise ProtocolViolation("Invalid Bind message: missing format codes count")
             num_format_codes = struct.unpack("!H", body[pos : pos + 2])[0]
             pos += 2
 
             format_codes = []
             for i in range(num_format_codes):
                 if pos + 2 > len(body):
-                    raise ValueError(f"Invalid Bind message: missing format code {i}")
+                    raise ProtocolViolation(f"Invalid Bind message: missing format code {i}")
                 format_code = struct.unpack("!H", body[pos : pos + 2])[0]
                 format_codes.append(format_code)
                 pos += 2
 
             # Parse parameter values
             if pos + 2 > len(body):
-                raise ValueError("Invalid Bind message: missing parameter count")
+                raise ProtocolViolation("Invalid Bind message: missing parameter count")
             num_params = struct.unpack("!H", body[pos : pos + 2])[0]
             pos += 2
 
             param_values = []
             for i in range(num_params):
                 if pos + 4 > len(body):
-                    raise ValueError(f"Invalid Bind message: missing parameter length {i}")
+                    raise ProtocolViolation(f"Invalid Bind message: missing parameter length {i}")
                 param_length = struct.unpack("!I", body[pos : pos + 4])[0]
                 pos += 4
 
+                # Format: format_codes[i] if available, else format_codes[0], else text (0)
+                if format_codes:
+                    format_code = format_codes[i] if i < len(format_codes) else format_codes[0]
+                else:
+                    format_code = 0  # Default to text
+
                 if param_length == 0xFFFFFFFF:  # NULL value
-                    param_values.append(None)
+                    large_value = large_values.get(i) if large_values else None
+                    if large_value is not None:
+                        param_type_oid = param_types[i] if i < len(param_types) else 0
+                        large_value = large_value.decoded(format_code, param_type_oid)
+                    param_values.append(large_value)
                 else:
                     if pos + param_length > len(body):
-                        raise ValueError(f"Invalid Bind message: truncated parameter {i}")
+                        raise ProtocolViolation(f"Invalid Bind message: truncated parameter {i}")
                     param_data = body[pos : pos + param_length]
 
-                    # Determine format: use format_codes[i] if available, else format_codes[0], else text (0)
-                    if format_codes:
-                        format_code = format_codes[i] if i < len(format_codes) else format_codes[0]
-                    else:
-                        format_code = 0  # Default to text
-
                     if format_code == 0:
                         # Text format - decode and try to preserve numeric types
-                        text_value = param_data.decode("utf-8")
+                        text_value = decode_text(param_data)
+                        param_type_oid = param_types[i] if i < len(param_types) else 0
+
+                        # TIMESTAMPTZ text: resolve offset (or session TimeZone) to UTC for IRIS
+                        if param_type_oid == 1184:
+                            try:
+                                utc_value = to_utc(text_value, self.session_timezone)
+                                param_values.append(utc_value.strftime("%Y-%m-%d %H:%M:%S.%f"))
+                                pos += param_length
+                                continue
+                            except ValueError:
+                                pass  # Not a timestamp literal (e.g. 'now') - pass through
+
+                        # Declared integer parameters are range-checked (SQLSTATE 22003)
+                        if is_integer_type(param_type_oid):
+                            try:
+                                param_values.append(parse_integer_text(text_value, param_type_oid))
+                                pos += param_length
+                                continue
+                            except NumericValueOutOfRange:
+                                raise
+                            except ValueError:
+                                pass  # Not an integer literal - fall through to generic handling
+
+                        # bytea text (hex or escape format) is bound as the bytes it denotes
+                        if param_type_oid == 17:
+                            param_values.append(parse_bytea(text_value))
+                            pos += param_length
+                            continue
+
+                        # Declared bool parameters are bound as IRIS BIT values (1 / 0)
+                        if param_type_oid == BOOL_OID:
+                            param_values.append(parse_bool(text_value))
+                            pos += param_length
+                            continue
+
+                        # Declared uuid parameters are bound in the form results use
+                        if param_type_oid == UUID_OID:
+                            param_values.append(parse_uuid(text_value))
+                            pos += param_length
+                            continue
+
+                        # Declared money parameters are bound as decimal text ($1,234.50)
+                        if param_type_oid == MONEY_OID:
+                            param_values.append(
+                                parse_money(
+                                    text_value, self.session_settings.get("lc_monetary", "C")
+                                )
+                            )
+                            pos += param_length
+                            continue
+
+                        # Declared vector parameters must be pgvector's [1,2,3]
+                        if param_type_oid == VECTOR_OID:
+                            param_values.append(parse_vector(text_value))
+                            pos += param_length
+                            continue
+
+                        # Array literals keep their elements for = ANY(?) (see array_values.py)
+                        if param_type_oid in ARRAY_ELEMENT_TYPES:
+                            try:
+                                param_values.append(
+                                    array_parameter(
+                                        text_value, ARRAY_ELEMENT_TYPES[param_type_oid]
+                                    )
+                                )
+                                pos += param_length
+                                continue
+                            except ValueError:
+                                pass  # Not a literal of the array type - passed through
+
+                        # Declared numeric parameters keep every digit as decimal text
+                        if param_type_oid == 1700:
+                            param_values.append(text_value.strip())
+                            pos += param_length
+                            continue
 
                         # Try to convert to int or float if it looks numeric
                         # This handles asyncpg sending integers as text when param type is UNKNOWN
@@ -2945,16 +4151,21 @@ class PGWireProtocol:
                             if "." not in text_value and "e" not in text_value.lower():
                                 param_values.append(int(text_value))
                             else:
-                                # Try float
-                                param_values.append(float(text_value))
-                        except (ValueError, TypeError):
+                                # Try float - decimals a float would round stay decimal text
+                                number = float(text_value)
+                                exact = Decimal(repr(number)) == Decimal(text_value.strip())
+                                if exact or param_type_oid in (700, 701):
+                                    param_values.append(number)
+                                else:
+                                    param_values.append(text_value.strip())
+                        except (ValueError, TypeError, InvalidOperation):
                             # Not a number, keep as string
                             param_values.append(text_value)
                     elif format_code == 1:
-                        # Binary format - decode based on parameter type OID
+                        # Binary format - decode based on parameter type OID (see bind_params.py)
                         # Get parameter type OID from prepared statement (0 if not available)
                         param_type_oid = param_types[i] if i < len(param_types) else 0
-                        decoded_param = self._decode_binary_parameter(param_data, i, param_type_oid)
+                        decoded_param = decode_binary_parameter(param_data, i, param_type_oid)
                         param_values.append(decoded_param)
                     else:
                         raise ValueError(f"Unknown format code {format_code} for parameter {i}")
@@ -2998,7 +4209,8 @@ class PGWireProtocol:
                     connection_id=self.connection_id,
                 )
 
-            # Store portal with result format codes
+            # Store portal with result format codes (rebinding discards its result set)
+            self.portal_cursors.close(portal_name)
             self.portals[portal_name] = {
                 "statement": statement_name,
                 "params": param_values,
@@ -3016,6 +4228,22 @@ class PGWireProtocol:
             # Send BindComplete response
             await self.send_bind_complete()
 
+        except MalformedMessage as e:
+            logger.warning("Malformed Bind message", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+        except (
+            NumericValueOutOfRange,
+            InvalidBinaryParameter,
+            InvalidBoolean,
+            InvalidBytea,
+            InvalidMoney,
+            InvalidUUID,
+            InvalidVector,
+        ) as e:
+            logger.warning(
+                "Invalid Bind parameter", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
         except Exception as e:
             logger.error(
                 "Bind message handling failed", connection_id=self.connection_id, error=str(e)
@@ -3034,10 +4262,10 @@ class PGWireProtocol:
         """
         try:
             if len(body) < 2:
-                raise ValueError("Invalid Describe message: too short")
+                raise ProtocolViolation("Invalid Describe message: too short")
 
             describe_type = chr(body[0])
-            name = body[1:].rstrip(b"\x00").decode("utf-8")
+            name = decode_text(body[1:].rstrip(b"\x00"))
 
             if describe_type == "S":
                 # Describe statement
@@ -3066,7 +4294,26 @@ class PGWireProtocol:
                 # Check if query has RETURNING clause (INSERT/UPDATE/DELETE with RETURNING)
                 has_returning = "RETURNING" in query_upper
 
-                if query_upper.startswith("SELECT") or query_upper.startswith("SHOW") or has_returning:
+                notify_call = parse_notify_call(query)
+                writable_cte = parse_writable_cte(query)
+                if notify_call is not None:
+                    # Known columns; running the statement would send the notification
+                    await self.send_row_description(self._notify_call_columns(notify_call))
+                    stmt["row_description_sent_in_describe"] = True
+                elif writable_cte is not None:
+                    columns = name_result_columns(
+                        await self._writable_cte_columns(
+                            writable_cte, self._statement_param_types(stmt)
+                        ),
+                        stmt.get("client_query") or query,
+                    )
+                    if columns:
+                        await self.send_row_description(columns)
+                        stmt["row_description_sent_in_describe"] = True
+                        stmt["described_columns"] = columns
+                    else:
+                        await self.send_no_data()
+                elif query_upper.startswith(("SELECT", "SHOW", "VALUES")) or has_returning:
                     # Execute metadata discovery to get column information
                     # Use LIMIT 0 pattern to avoid fetching actual data
                     # For RETURNING queries, we'll send synthetic column metadata based on RETURNING columns
@@ -3148,49 +4395,33 @@ class PGWireProtocol:
                                 )
                                 return
 
-                        logger.info(
-                            "🔍 Describe Statement: Executing metadata discovery",
-                            connection_id=self.connection_id,
-                            query=query[:150],
-                        )
-
-                        # Execute the query to discover column metadata
-                        # NOTE: We're not using LIMIT 0 wrapper because IRIS may not support it
-                        # Instead, we'll execute the full query but only need the metadata
-
-                        # CRITICAL: If statement has parameters, supply dummy values for metadata discovery
-                        param_count = (
-                            stmt["param_types"]
-                            if isinstance(stmt.get("param_types"), int)
-                            else len(stmt.get("param_types", []))
+                        # Columns from the schema cache, or from running the statement once
+                        # with NULL parameters (see IRISExecutor.describe_statement)
+                        columns, cached = await self.iris_executor.describe_statement(
+                            query, self._statement_param_types(stmt)
                         )
-                        dummy_params = [None] * param_count  # Use NULL for all parameters
-
-                        logger.info(
-                            "🔍 Describe Statement: Using dummy parameters for metadata",
-                            connection_id=self.connection_id,
-                            param_count=param_count,
+                        columns = name_result_columns(
+                            columns, stmt.get("client_query") or query
                         )
 
-                        result = await self.iris_executor.execute_query(query, params=dummy_params)
-
-                        if result.get("success") and result.get("columns"):
-                            await self.send_row_description(result["columns"])
-                            # CRITICAL: Mark that RowDescription was sent in Describe phase (text format)
-                            # Execute phase must use text format in DataRow to match RowDescription
+                        if columns:
+                            await self.send_row_description(columns)
+                            # Execute without a Describe of its portal must send DataRows
+                            # matching this RowDescription (statement caching drivers)
                             stmt["row_description_sent_in_describe"] = True
+                            stmt["described_columns"] = columns
                             logger.info(
                                 "✅ Sent RowDescription for statement Describe",
                                 connection_id=self.connection_id,
                                 statement_name=name,
-                                column_count=len(result["columns"]),
-                                columns=[c["name"] for c in result["columns"]],
+                                column_count=len(columns),
+                                cached=cached,
                             )
                         else:
                             logger.warning(
                                 "Metadata discovery returned no columns",
                                 connection_id=self.connection_id,
-                                result_keys=list(result.keys()) if result else None,
+                                statement_name=name,
                             )
                             await self.send_no_data()
 
@@ -3226,6 +4457,7 @@ class PGWireProtocol:
                 # For Extended Protocol, we need to describe the result columns
                 # We'll execute the query to get column metadata, then send RowDescription
                 portal = self.portals[name]
+                portal["described"] = True
                 statement_name = portal["statement"]
 
                 logger.info(
@@ -3249,7 +4481,28 @@ class PGWireProtocol:
                         is_select=query_upper.startswith("SELECT"),
                         is_show=query_upper.startswith("SHOW"),
                     )
-                    if query_upper.startswith("SELECT") or query_upper.startswith("SHOW"):
+                    notify_call = parse_notify_call(query)
+                    writable_cte = parse_writable_cte(query)
+                    if notify_call is not None:
+                        # Execute runs it, so errors reach the client
+                        await self.send_row_description(
+                            self._notify_call_columns(notify_call), portal.get("result_formats", [])
+                        )
+                    elif writable_cte is not None:
+                        # Running it here would apply its changes before Execute
+                        columns = name_result_columns(
+                            await self._writable_cte_columns(
+                                writable_cte, self._statement_param_types(stmt)
+                            ),
+                            stmt.get("client_query") or query,
+                        )
+                        if columns:
+                            await self.send_row_description(
+                                columns, portal.get("result_formats", [])
+                            )
+                        else:
+                            await self.send_no_data()
+                    elif query_upper.startswith(("SELECT", "SHOW", "VALUES")):
                         # Execute query to get column metadata
                         logger.info(
                             "🔍 Describe: Executing query to get column metadata", query=query[:100]
@@ -3260,6 +4513,15 @@ class PGWireProtocol:
                                 query, params=portal.get("params", [])
                             )
                             if result.get("success") and result.get("columns"):
+                                result["columns"] = name_result_columns(
+                                    result["columns"], stmt.get("client_query") or query
+                                )
+                                # Keep the result set - Execute fetches from it instead of re-running
+                                try:
+                                    self.portal_cursors.open(name, result)
+                                except (TooManyOpenPortals, TempFileLimitExceeded):
+                                    pass  # Execute re-runs the query and reports the limit
+
                                 # CRITICAL FIX: Pass result_formats from portal to send_row_description
                                 # This ensures RowDescription format_code matches DataRow format
                                 result_formats = portal.get("result_formats", [])
@@ -3293,6 +4555,11 @@ class PGWireProtocol:
                 "Described object", connection_id=self.connection_id, type=describe_type, name=name
             )
 
+        except MalformedMessage as e:
+            logger.warning(
+                "Malformed Describe message", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
         except Exception as e:
             logger.error(
                 "Describe message handling failed", connection_id=self.connection_id, error=str(e)
@@ -3313,24 +4580,36 @@ class PGWireProtocol:
             # Parse portal name
             name_end = body.find(b"\x00")
             if name_end == -1:
-                raise ValueError("Invalid Execute message: missing portal name terminator")
-            portal_name = body[:name_end].decode("utf-8")
+                raise ProtocolViolation("Invalid Execute message: missing portal name terminator")
+            portal_name = decode_text(body[:name_end])
 
-            # Parse max rows (for now, ignore and fetch all)
+            # Parse max rows (0 = fetch all remaining rows)
+            max_rows = 0
             if len(body) >= name_end + 5:
-                struct.unpack("!I", body[name_end + 1 : name_end + 5])[0]
-            else:
-                pass
+                max_rows = struct.unpack("!i", body[name_end + 1 : name_end + 5])[0]
 
-            # Check if portal exists
             if portal_name not in self.portals:
-                raise ValueError(f"Portal '{portal_name}' does not exist")
+                await self.send_error_response(
+                    "ERROR",
+                    "34000",
+                    "invalid_cursor_name",
+                    f'portal "{portal_name}" does not exist',
+                )
+                return
 
             portal = self.portals[portal_name]
             statement_name = portal["statement"]
             params = portal["params"]
             result_formats = portal.get("result_formats", [])  # Get result format codes from Bind
 
+            # A suspended portal continues from its result set, whatever has since become
+            # of its statement (the unnamed statement may have been parsed again)
+            cursor = self.portal_cursors.get(portal_name)
+            if cursor is not None and cursor.position > 0:
+                self._current_result_formats = result_formats
+                await self.send_portal_rows(cursor, max_rows)
+                return
+
             if statement_name not in self.prepared_statements:
                 raise ValueError(f"Statement '{statement_name}' no longer exists")
 
@@ -3389,6 +4668,14 @@ class PGWireProtocol:
                     connection_id=self.connection_id,
                     query=query[:100] if query else "(empty after Parse interception)",
                 )
+                try:
+                    self._apply_set_command(query)
+                except InvalidParameterValue as e:
+                    await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+                    return
+                warning = self.set_local_warning(query)
+                if warning:
+                    await self.send_notice_response("WARNING", "25P01", warning)
                 # Send success response for SET commands
                 await self.send_set_response_extended_protocol()
                 return
@@ -3410,9 +4697,11 @@ class PGWireProtocol:
                     await self.send_transaction_response_extended_protocol("BEGIN")
                 elif transaction_type == "COMMIT":
                     await self.iris_executor.commit_transaction()
+                    await self.end_transaction(committed=True)
                     await self.send_transaction_response_extended_protocol("COMMIT")
                 elif transaction_type == "ROLLBACK":
                     await self.iris_executor.rollback_transaction()
+                    await self.end_transaction(committed=False)
                     await self.send_transaction_response_extended_protocol("ROLLBACK")
                 return
 
@@ -3441,27 +4730,67 @@ class PGWireProtocol:
                 )
                 return
 
-            # Execute the query with parameters
-            # IMPORTANT: Pass parameters separately to enable vector query optimizer
-            # The optimizer needs to transform vector parameters BEFORE IRIS execution
-            # Interpolating here would create large SQL literals that exceed IRIS limits
+            # SHOW for session-scoped parameters (Describe already sent the single text column)
+            if query_upper.startswith("SHOW "):
+                show_result = self._session_show_result(query)
+                if show_result is not None:
+                    await self.send_query_result(
+                        show_result, send_ready=False, send_row_description=False
+                    )
+                    return
+
+            # Resume the portal's open result set, or run the query to open one
+            cursor = self.portal_cursors.get(portal_name)
+            if cursor is None:
+                # Execute the query with parameters
+                # IMPORTANT: Pass parameters separately to enable vector query optimizer
+                # The optimizer needs to transform vector parameters BEFORE IRIS execution
+                # Interpolating here would create large SQL literals that exceed IRIS limits
 
-            # NOTE: PostgreSQL $1, $2 parameters were already translated to IRIS ? syntax
-            # in handle_parse_message(), so query already has correct parameter placeholders
+                # NOTE: PostgreSQL $1, $2 parameters were already translated to IRIS ? syntax
+                # in handle_parse_message(), so query already has correct parameter placeholders
 
-            # Execute via IRIS with parameters (vector optimizer will transform if needed)
-            result = await self.iris_executor.execute_query(
-                query, params=params if params else None
-            )
+                # Execute via IRIS with parameters (vector optimizer will transform if needed)
+                result = await self._execute_statement(query, params if params else None)
 
-            if result["success"]:
-                # Extended Protocol: Don't send ReadyForQuery here - Sync handler will send it
-                # Extended Protocol: Don't send RowDescription here - Describe already sent it
-                await self.send_query_result(result, send_ready=False, send_row_description=False)
-            else:
-                await self.send_error_response(
-                    "ERROR", "42000", "syntax_error", result.get("error", "Query execution failed")
+                if not result["success"]:
+                    await self.send_error_response(
+                        "ERROR",
+                        result.get("sqlstate", "42000"),
+                        result.get("condition_name", "syntax_error"),
+                        result.get("error", "Query execution failed"),
+                    )
+                    return
+
+                result["columns"] = name_result_columns(
+                    result.get("columns"), stmt.get("client_query") or query
                 )
+                described = stmt.get("described_columns")
+                if described and not portal.get("described"):
+                    # The client reuses the statement's RowDescription (pgx, Npgsql)
+                    if not self._matches_description(result, described):
+                        self.iris_executor.forget_statement_description(
+                            query, self._statement_param_types(stmt)
+                        )
+                        stmt.pop("described_columns")
+                        await self.send_error_response(
+                            "ERROR",
+                            "0A000",
+                            "feature_not_supported",
+                            "cached plan must not change result type",
+                        )
+                        return
+                    columns = self._described_result_columns(result["columns"], described)
+                    result = {**result, "columns": columns}
+                    get_stats().record_describe_skipped()
+
+                try:
+                    cursor = self.portal_cursors.open(portal_name, result)
+                except (TooManyOpenPortals, TempFileLimitExceeded) as e:
+                    await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+                    return
+
+            await self.send_portal_rows(cursor, max_rows)
 
             logger.info(
                 "Executed portal",
@@ -3470,6 +4799,21 @@ class PGWireProtocol:
                 query=query[:100] + "..." if len(query) > 100 else query,
             )
 
+        except MalformedMessage as e:
+            logger.warning(
+                "Malformed Execute message", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+        except (
+            NumericValueOutOfRange,
+            InvalidResultValue,
+            StreamValueTooLarge,
+            TempFileLimitExceeded,
+        ) as e:
+            # A result value its column type cannot hold (22003, 22P02), or a stream
+            # value that could not be read (54000, 53400)
+            logger.warning("Invalid result value", connection_id=self.connection_id, error=str(e))
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
         except Exception as e:
             logger.error(
                 "Execute message handling failed", connection_id=self.connection_id, error=str(e)
@@ -3478,6 +4822,87 @@ class PGWireProtocol:
                 "ERROR", "42P03", "undefined_cursor", f"Execute failed: {e}"
             )
 
+    async def _writable_cte_columns(
+        self, statement, param_types: list[int]
+    ) -> list[dict[str, Any]] | None:
+        """Result columns of a data-modifying WITH query, found without running its DML."""
+        described = statement.describe_sql()
+        if described is None:
+            return None
+        sql, numbers = described
+        types = [param_types[number] if number < len(param_types) else 0 for number in numbers]
+        try:
+            columns, _ = await self.iris_executor.describe_statement(sql, types)
+        except Exception as e:
+            logger.warning(
+                "Failed to describe WITH query", connection_id=self.connection_id, error=str(e)
+            )
+            return None
+        return columns
+
+    @staticmethod
+    def _statement_param_types(stmt: dict[str, Any]) -> list[int]:
+        """Declared parameter type OIDs of a prepared statement (0 = unspecified)."""
+        param_types = stmt.get("param_types", [])
+        if isinstance(param_types, int):
+            return [0] * param_types
+        return list(param_types)
+
+    @staticmethod
+    def _matches_description(result: dict[str, Any], described: list[dict[str, Any]]) -> bool:
+        """Whether an execution's result has the shape its statement was described with."""
+        return len(result.get("columns") or []) == len(described)
+
+    @staticmethod
+    def _described_result_columns(
+        columns: list[dict[str, Any]], described: list[dict[str, Any]]
+    ) -> list[dict[str, Any]]:
+        """
+        Result columns typed as the statement's Describe reported them.
+
+        IRIS can type a column differently when it runs with the actual
+        parameters than with the NULLs of Describe; DataRows must be encoded
+        for the RowDescription the client cached.
+        """
+        return [
+            {
+                **column,
+                "type_oid": description.get("type_oid", column.get("type_oid")),
+                "type_size": description.get("type_size", column.get("type_size", -1)),
+                "type_modifier": description.get(
+                    "type_modifier", column.get("type_modifier", -1)
+                ),
+            }
+            for column, description in zip(columns, described, strict=True)
+        ]
+
+    async def send_portal_rows(self, cursor: PortalCursor, max_rows: int):
+        """
+        Send the next max_rows rows of a portal (0 = all remaining), then
+        PortalSuspended if rows are left, else CommandComplete.
+
+        Extended Protocol: no RowDescription (Describe sent it) and no
+        ReadyForQuery (Sync sends it).
+        """
+        if not cursor.returns_rows:
+            await self.send_query_result(
+                cursor.result, send_ready=False, send_row_description=False
+            )
+            return
+
+        rows = cursor.fetch(max_rows)
+        if cursor.exhausted:
+            # CommandComplete reports the rows sent by this Execute
+            batch = {**cursor.result, "rows": rows, "row_count": len(rows)}
+            tag = batch.get("command_tag", batch.get("command", "SELECT"))
+            if tag.upper().startswith("SELECT"):
+                batch["command_tag"] = "SELECT"
+            await self.send_query_result(batch, send_ready=False, send_row_description=False)
+        else:
+            if rows:
+                await self.send_data_rows_with_backpressure(rows, cursor.result["columns"])
+            await self.send_portal_suspended()
+
     async def handle_sync_message(self, body: bytes):
         """
         P2: Handle Sync message (end of extended protocol cycle)
@@ -3486,6 +4911,19 @@ class PGWireProtocol:
         """
         try:
             logger.info("🔄 Sync received, sending ReadyForQuery", connection_id=self.connection_id)
+
+            # Outside a transaction block Sync ends the implicit transaction, and with it
+            # every portal: a later Execute of a suspended portal fails (34000) instead of
+            # running its query again
+            if self.transaction_status == STATUS_IDLE:
+                self.portal_cursors.close_all()
+                self.portals.clear()
+
+            # Sync ends the pipeline: ReadyForQuery waits until the client has the responses
+            self.ignore_till_sync = False
+            if isinstance(self.writer, PipelineWriter):
+                self.writer.pipelining = False
+
             # Send ReadyForQuery to indicate we're ready for the next command
             await self.send_ready_for_query()
 
@@ -3506,10 +4944,10 @@ class PGWireProtocol:
         """
         try:
             if len(body) < 2:
-                raise ValueError("Invalid Close message: too short")
+                raise ProtocolViolation("Invalid Close message: too short")
 
             close_type = chr(body[0])
-            name = body[1:].rstrip(b"\x00").decode("utf-8")
+            name = decode_text(body[1:].rstrip(b"\x00"))
 
             if close_type == "S":
                 # Close statement
@@ -3518,6 +4956,7 @@ class PGWireProtocol:
                     logger.info("Closed statement", connection_id=self.connection_id, name=name)
             elif close_type == "P":
                 # Close portal
+                self.portal_cursors.close(name)
                 if name in self.portals:
                     del self.portals[name]
                     logger.info("Closed portal", connection_id=self.connection_id, name=name)
@@ -3527,6 +4966,11 @@ class PGWireProtocol:
             # Send CloseComplete response
             await self.send_close_complete()
 
+        except MalformedMessage as e:
+            logger.warning(
+                "Malformed Close message", connection_id=self.connection_id, error=str(e)
+            )
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
         except Exception as e:
             logger.error(
                 "Close message handling failed", connection_id=self.connection_id, error=str(e)
@@ -3542,8 +4986,11 @@ class PGWireProtocol:
         Flush message has no body.
         """
         try:
-            # Ensure all pending output is sent
-            await self.writer.drain()
+            # Send the responses so far; the pipeline stays open until Sync (pipeline.py)
+            if isinstance(self.writer, PipelineWriter):
+                await self.writer.flush()
+            else:
+                await self.writer.drain()
             logger.debug("Flush message processed", connection_id=self.connection_id)
 
         except Exception as e:
@@ -3565,6 +5012,12 @@ class PGWireProtocol:
         self.writer.write(message)
         await self.writer.drain()
 
+    async def send_portal_suspended(self):
+        """Send PortalSuspended (Execute row limit reached, portal has more rows)"""
+        message = struct.pack("!cI", MSG_PORTAL_SUSPENDED, 4)
+        self.writer.write(message)
+        await self.writer.drain()
+
     async def send_close_complete(self):
         """Send CloseComplete response"""
         message = struct.pack("!cI", MSG_CLOSE_COMPLETE, 4)
@@ -3641,197 +5094,6 @@ class PGWireProtocol:
 
         return query
 
-    def _decode_binary_parameter(self, data: bytes, param_index: int, param_type_oid: int = 0):
-        """
-        Decode binary-format parameter from PostgreSQL wire protocol.
-
-        PostgreSQL binary format for arrays (vectors):
-        - Int32: number of dimensions (ndim)
-        - Int32: has_null flag (0 = no nulls)
-        - Int32: element type OID
-        - For each dimension:
-          - Int32: dimension size
-          - Int32: lower bound (usually 1)
-        - For each element:
-          - Int32: element length (-1 for NULL)
-          - bytes: element data (if not NULL)
-
-        For simple types:
-        - int2 (OID 21): 2-byte signed integer (big-endian)
-        - int4 (OID 23): 4-byte signed integer (big-endian)
-        - int8 (OID 20): 8-byte signed integer (big-endian)
-        - float4 (OID 700): 4-byte IEEE 754 float
-        - float8 (OID 701): 8-byte IEEE 754 double
-
-        Args:
-            data: Binary parameter data
-            param_index: Parameter index (for logging)
-            param_type_oid: PostgreSQL type OID from prepared statement (0 if unknown)
-
-        Returns:
-            Typed value (int, float, str, or list) suitable for IRIS parameter binding
-        """
-        try:
-            if len(data) < 12:
-                # Not an array, might be a simple type
-                # Decode based on parameter type OID OR data length
-                if param_type_oid == 21 and len(data) == 2:  # int2 (smallint)
-                    value = struct.unpack("!h", data)[0]  # Big-endian signed short
-                    return value  # Return actual int, not string
-                elif param_type_oid == 23 and len(data) == 4:  # int4
-                    value = struct.unpack("!i", data)[0]  # Big-endian signed int
-                    return value  # Return actual int, not string
-                elif param_type_oid == 20 and len(data) == 8:  # int8 (bigint)
-                    value = struct.unpack("!q", data)[0]  # Big-endian signed long
-                    return value  # Return actual int, not string
-                elif param_type_oid == 700 and len(data) == 4:  # float4 explicit
-                    value = struct.unpack("!f", data)[0]  # Big-endian float
-                    return value  # Return actual float, not string
-                elif param_type_oid == 701 and len(data) == 8:  # float8 explicit
-                    value = struct.unpack("!d", data)[0]  # Big-endian double
-                    return value  # Return actual float, not string
-                elif param_type_oid == 16 and len(data) == 1:  # bool
-                    # PostgreSQL boolean binary format: 1 byte (0x00 = False, 0x01 = True)
-                    value = data[0] != 0
-                    # Convert to IRIS BIT representation (1 or 0)
-                    return 1 if value else 0
-                elif param_type_oid == 1082 and len(data) == 4:  # DATE
-                    # PostgreSQL DATE binary format: 4-byte signed integer (days since 2000-01-01)
-                    # IRIS expects dates as ISO 8601 strings (YYYY-MM-DD)
-                    import datetime
-
-                    pg_days = struct.unpack("!i", data)[0]
-                    PG_EPOCH = datetime.date(2000, 1, 1)
-                    date_obj = PG_EPOCH + datetime.timedelta(days=pg_days)
-                    return date_obj.strftime("%Y-%m-%d")  # Convert to ISO string for IRIS
-                elif param_type_oid == 1114 and len(data) == 8:  # TIMESTAMP (without timezone)
-                    # PostgreSQL TIMESTAMP binary format: 8-byte signed integer (microseconds since 2000-01-01)
-                    # IRIS expects timestamps as ISO 8601 strings (YYYY-MM-DD HH:MM:SS.ffffff)
-                    import datetime
-
-                    pg_microseconds = struct.unpack("!q", data)[0]
-                    PG_EPOCH = datetime.datetime(2000, 1, 1, 0, 0, 0)
-                    timestamp_obj = PG_EPOCH + datetime.timedelta(microseconds=pg_microseconds)
-                    return timestamp_obj.strftime("%Y-%m-%d %H:%M:%S.%f")  # Convert to ISO string for IRIS
-                elif param_type_oid == 1184 and len(data) == 8:  # TIMESTAMPTZ (with timezone)
-                    # Same as TIMESTAMP but with timezone - PostgreSQL stores as UTC microseconds
-                    import datetime
-
-                    pg_microseconds = struct.unpack("!q", data)[0]
-                    PG_EPOCH = datetime.datetime(2000, 1, 1, 0, 0, 0)
-                    timestamp_obj = PG_EPOCH + datetime.timedelta(microseconds=pg_microseconds)
-                    return timestamp_obj.strftime("%Y-%m-%d %H:%M:%S.%f")  # Convert to ISO string for IRIS
-                # Fallback: Infer type from data length when OID not specified
-                elif len(data) == 1:
-                    # Could be boolean - treat as boolean
-                    value = data[0] != 0
-                    return 1 if value else 0
-                elif len(data) == 2:
-                    # Assume int2 (smallint)
-                    value = struct.unpack("!h", data)[0]
-                    return value
-                elif len(data) == 4:
-                    # Assume int4 (psycopg may not specify OID for integers)
-                    value = struct.unpack("!i", data)[0]
-                    return value
-                elif len(data) == 8:
-                    # Assume int8 (prefer int over float for 8-byte values)
-                    value = struct.unpack("!q", data)[0]
-                    return value
-                else:
-                    # Unknown format, return as text
-                    return data.decode("utf-8", errors="replace")
-
-            # Parse array header
-            pos = 0
-            ndim = struct.unpack("!I", data[pos : pos + 4])[0]
-            pos += 4
-            struct.unpack("!I", data[pos : pos + 4])[0]
-            pos += 4
-            element_oid = struct.unpack("!I", data[pos : pos + 4])[0]
-            pos += 4
-
-            if ndim == 0:
-                # Empty array
-                return "[]"
-
-            # Parse dimension info
-            dimensions = []
-            for _ in range(ndim):
-                if pos + 8 > len(data):
-                    raise ValueError(f"Truncated dimension info for parameter {param_index}")
-                dim_size = struct.unpack("!I", data[pos : pos + 4])[0]
-                pos += 4
-                struct.unpack("!I", data[pos : pos + 4])[0]
-                pos += 4
-                dimensions.append(dim_size)
-
-            # Parse elements
-            total_elements = 1
-            for dim in dimensions:
-                total_elements *= dim
-
-            elements = []
-            for i in range(total_elements):
-                if pos + 4 > len(data):
-                    raise ValueError(
-                        f"Truncated element length for parameter {param_index}, element {i}"
-                    )
-                elem_len = struct.unpack("!I", data[pos : pos + 4])[0]
-                pos += 4
-
-                if elem_len == 0xFFFFFFFF:
-                    # NULL element
-                    elements.append("NULL")
-                else:
-                    if pos + elem_len > len(data):
-                        raise ValueError(
-                            f"Truncated element data for parameter {param_index}, element {i}"
-                        )
-                    elem_data = data[pos : pos + elem_len]
-                    pos += elem_len
-
-                    # Decode based on element OID
-                    if element_oid == 700:  # float4
-                        value = struct.unpack("!f", elem_data)[0]
-                        elements.append(str(value))
-                    elif element_oid == 701:  # float8 (double)
-                        value = struct.unpack("!d", elem_data)[0]
-                        elements.append(str(value))
-                    elif element_oid == 23:  # int4
-                        value = struct.unpack("!i", elem_data)[0]
-                        elements.append(str(value))
-                    elif element_oid == 20:  # int8 (bigint)
-                        value = struct.unpack("!q", elem_data)[0]
-                        elements.append(str(value))
-                    else:
-                        # Unknown type, try as text
-                        elements.append(elem_data.decode("utf-8", errors="replace"))
-
-            # Format as IRIS vector: [v1,v2,v3,...]
-            vector_text = "[" + ",".join(elements) + "]"
-
-            logger.debug(
-                "Decoded binary vector parameter",
-                param_index=param_index,
-                dimensions=dimensions,
-                element_count=len(elements),
-                element_oid=element_oid,
-                vector_length=len(vector_text),
-            )
-
-            return vector_text
-
-        except Exception as e:
-            logger.error(
-                "Binary parameter decode failed",
-                param_index=param_index,
-                error=str(e),
-                data_length=len(data),
-            )
-            # Fallback: try to decode as text
-            return data.decode("utf-8", errors="replace")
-
     # P4: Query Cancellation Methods
 
     async def handle_cancel_request(self):
@@ -3903,7 +5165,12 @@ class PGWireProtocol:
                 csv_format=command.csv_options.format,
             )
 
-            if command.direction == CopyDirection.FROM_STDIN:
+            if command.csv_options.force_quote and command.csv_options.format != "CSV":
+                await self.send_error_response(
+                    "ERROR", "0A000", "feature_not_supported", "COPY FORCE_QUOTE requires CSV mode"
+                )
+                await self.send_ready_for_query()
+            elif command.direction == CopyDirection.FROM_STDIN:
                 # COPY FROM STDIN - bulk data import
                 await self.handle_copy_from_stdin_v2(command)
             elif command.direction == CopyDirection.TO_STDOUT:
@@ -3918,17 +5185,27 @@ class PGWireProtocol:
                 )
 
         except CSVParsingError as e:
-            # CSV parsing errors include line numbers (FR-007)
+            # CSV parsing errors include line numbers (FR-007); binary COPY errors their SQLSTATE
             logger.error(
                 "CSV parsing failed",
                 connection_id=self.connection_id,
                 error=str(e),
                 line_number=e.line_number,
             )
-            await self.send_error_response("ERROR", "22P04", "bad_copy_file_format", str(e))
+            await self.send_error_response(
+                "ERROR",
+                getattr(e, "sqlstate", "22P04"),
+                getattr(e, "condition_name", "bad_copy_file_format"),
+                str(e),
+            )
             # Send ReadyForQuery after error
             await self.send_ready_for_query()
 
+        except CopyExportError as e:
+            # Unknown table or column or failed COPY query, before any Copy*Response
+            await self.send_error_response("ERROR", e.sqlstate, e.condition_name, str(e))
+            await self.send_ready_for_query()
+
         except ValueError as e:
             # Parse errors (invalid COPY syntax)
             logger.error(
@@ -3961,14 +5238,30 @@ class PGWireProtocol:
         """
         try:
             # Determine column count for CopyInResponse
-            if command.column_list:
-                column_count = len(command.column_list)
-            else:
-                # Get column count from table metadata
-                column_count = len(await self.bulk_executor.get_table_columns(command.table_name))
+            binary = command.csv_options.format == "BINARY"
+            export = None
+            if binary:
+                # Binary fields are decoded by their column's type; rows are
+                # keyed by the IRIS names of the columns (pgx quotes them)
+                export = await self.bulk_executor.table_export(
+                    command.table_name, command.column_list
+                )
+                command.column_list = export.column_names
+            elif not command.column_list:
+                # Without a column list the data fills every column in table order
+                command.column_list = await self.bulk_executor.get_table_columns(
+                    command.table_name
+                )
+                if not command.column_list:
+                    raise CopyExportError(
+                        f'relation "{command.table_name}" does not exist',
+                        "42P01",
+                        "undefined_table",
+                    )
+            column_count = len(command.column_list)
 
             # Send CopyInResponse message (T014)
-            copy_in_response = self.copy_handler.build_copy_in_response(column_count)
+            copy_in_response = self.copy_handler.build_copy_in_response(column_count, binary)
             self.writer.write(copy_in_response)
             await self.writer.drain()
 
@@ -3979,10 +5272,13 @@ class PGWireProtocol:
                 column_count=column_count,
             )
 
+            copy_done = False
+
             # Collect CopyData messages as async iterator
             async def csv_stream():
                 """Async iterator yielding CSV bytes from CopyData messages"""
-                while True:
+                nonlocal copy_done
+                while not copy_done:
                     # Read next message
                     header = await self.reader.readexactly(5)
                     msg_type, length = struct.unpack("!cI", header)
@@ -3999,16 +5295,30 @@ class PGWireProtocol:
                     elif msg_type == MSG_COPY_DONE:
                         # End of stream
                         logger.info("CopyDone received", connection_id=self.connection_id)
-                        break
+                        copy_done = True
                     elif msg_type == MSG_COPY_FAIL:
                         # Client aborted
+                        copy_done = True
                         error_msg = body.decode("utf-8") if body else "Client aborted"
                         raise RuntimeError(f"COPY aborted by client: {error_msg}")
-                    else:
+                    elif msg_type not in (MSG_FLUSH, MSG_SYNC):
+                        copy_done = True
                         raise ValueError(f"Unexpected message type during COPY: {msg_type}")
 
-            # Execute COPY FROM STDIN via CopyHandler (T015, T018, T020)
-            row_count = await self.copy_handler.handle_copy_from_stdin(command, csv_stream())
+            # Execute COPY FROM STDIN via CopyHandler (T015, T018, T020); inside a
+            # transaction block the client's COMMIT / ROLLBACK decides
+            try:
+                row_count = await self.copy_handler.handle_copy_from_stdin(
+                    command,
+                    csv_stream(),
+                    own_transaction=self.transaction_status != STATUS_IN_TRANSACTION,
+                    export=export,
+                )
+            except Exception:
+                # Like PostgreSQL, discard the rest of the data before reporting the error
+                async for _ in csv_stream():
+                    pass
+                raise
 
             # Send CommandComplete with row count
             tag = f"COPY {row_count}\x00".encode()
@@ -4044,17 +5354,25 @@ class PGWireProtocol:
         """
         try:
             # Determine column count for CopyOutResponse
-            if command.column_list:
-                column_count = len(command.column_list)
-            elif command.table_name:
-                # Get column count from table metadata
-                column_count = len(await self.bulk_executor.get_table_columns(command.table_name))
+            export = None
+            if command.table_name:
+                # Table COPY streams straight from IRIS (see copy_export.py)
+                export = await self.bulk_executor.table_export(
+                    command.table_name, command.column_list
+                )
+                column_count = len(export.columns)
             else:
-                # Query-based COPY - default to unknown
-                column_count = 0  # Will be determined by query execution
+                # COPY (query): run it first, so the response has its columns
+                export = await self.bulk_executor.query_export(command.query)
+                column_count = len(export.column_names)
+            if command.csv_options.force_quote:
+                # Checked before CopyOutResponse, as PostgreSQL does
+                force_quote_columns(command.csv_options.force_quote, export.column_names)
 
             # Send CopyOutResponse message (T014)
-            copy_out_response = self.copy_handler.build_copy_out_response(column_count)
+            copy_out_response = self.copy_handler.build_copy_out_response(
+                column_count, command.csv_options.format == "BINARY"
+            )
             self.writer.write(copy_out_response)
             await self.writer.drain()
 
@@ -4066,15 +5384,12 @@ class PGWireProtocol:
             )
 
             # Execute COPY TO STDOUT via CopyHandler (T016, T019, T021)
-            row_count = 0
-            async for csv_chunk in self.copy_handler.handle_copy_to_stdout(command):
+            async for csv_chunk in self.copy_handler.handle_copy_to_stdout(command, export):
                 # Send CopyData message (T016)
                 copy_data = self.copy_handler.build_copy_data(csv_chunk)
                 self.writer.write(copy_data)
                 await self.writer.drain()
-
-                # Approximate row count
-                row_count += csv_chunk.count(b"\n")
+            row_count = self.copy_handler.rows_exported
 
             # Send CopyDone message
             copy_done = self.copy_handler.build_copy_done()
@@ -4492,6 +5807,15 @@ class PGWireProtocol:
 
         logger.debug("CopyFail sent", connection_id=self.connection_id, error=error_message)
 
+    async def send_command_complete(self, tag: str, send_ready: bool = True):
+        """Send CommandComplete with the given tag (and ReadyForQuery if requested)"""
+        tag_bytes = f"{tag}\x00".encode()
+        self.writer.write(struct.pack("!cI", MSG_COMMAND_COMPLETE, 4 + len(tag_bytes)) + tag_bytes)
+        await self.writer.drain()
+
+        if send_ready:
+            await self.send_ready_for_query()
+
     async def send_copy_complete_response(self, row_count: int):
         """Send CommandComplete response for COPY operation"""
         # CommandComplete: C + length + tag
@@ -0,0 +1,837 @@
+"""
+Publications
+
+Logical replication subscribers (Debezium, pgoutput consumers) name the
+publications whose changes they receive. Publications are defined as in
+PostgreSQL:
+
+    CREATE PUBLICATION name
+        [FOR ALL TABLES | FOR TABLE table [(column, ...)] [WHERE (condition)] [, ...]]
+        [WITH (publish = 'insert, update, delete, truncate')]
+    ALTER PUBLICATION name {ADD | SET} TABLE table [(column, ...)] [WHERE (condition)] [, ...]
+    ALTER PUBLICATION name DROP TABLE table [, ...]
+    ALTER PUBLICATION name SET (publish = '...')
+    DROP PUBLICATION [IF EXISTS] name [, ...]
+
+A column list limits the columns a table's changes carry, so PHI columns
+never leave IRIS; a row filter limits the rows. ChangeFilter applies the
+publications of a subscription to captured row changes with PostgreSQL's
+rules:
+
+- An UPDATE whose old row passes the filter but whose new row does not is
+  published as a DELETE, the reverse as an INSERT, and one where neither
+  passes is not published. Without the old row, the new row decides.
+- A table in several of the subscription's publications is published when
+  any of their filters passes (a publication without one passes every row).
+  Its column lists must be the same in all of them.
+- A NULL filter result does not pass. TRUNCATE is never filtered.
+
+Row filters may use the table's columns, constants, comparisons, IS [NOT]
+NULL, [NOT] IN (...), AND, OR, NOT and parentheses; anything else (function
+calls, subqueries) is rejected as in PostgreSQL. Tables and the columns of
+column lists and filters must exist when the publication is defined.
+
+Definitions are kept in an IRIS global (PGWIRE_PUBLICATIONS_GLOBAL, default
+^PGWire.Publications):
+
+    ^PGWire.Publications(name) = {"all_tables": false, "publish": [...], "via_root": false}
+    ^PGWire.Publications(name, schema, table) = {"columns": [...] | null, "where": "..." | null}
+
+and reported through pg_publication and pg_publication_tables (single-view
+SELECTs). FOR TABLES IN SCHEMA is not supported.
+"""
+
+import datetime
+import json
+import os
+import re
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from decimal import Decimal, InvalidOperation
+from typing import Any
+
+from .check_constraints import _IDENTIFIER, _closing_paren, _unquote
+from .sql_text import split_top_level
+
+DEFAULT_PUBLICATIONS_GLOBAL = "^PGWire.Publications"
+
+PUBLISH_ACTIONS = ("insert", "update", "delete", "truncate")
+
+VIEW_NAMES = ("pg_publication", "pg_publication_tables")
+
+_RELATION = rf"{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?"
+
+_CREATE = re.compile(
+    rf"^\s*CREATE\s+PUBLICATION\s+(?P<name>{_IDENTIFIER})\s*(?P<rest>.*?)\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_ALTER = re.compile(
+    rf"^\s*ALTER\s+PUBLICATION\s+(?P<name>{_IDENTIFIER})\s+(?P<rest>.*?)\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_DROP = re.compile(
+    r"^\s*DROP\s+PUBLICATION\s+(?P<if_exists>IF\s+EXISTS\s+)?"
+    r"(?P<names>.+?)(?:\s+(?:CASCADE|RESTRICT))?\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_FOR_ALL_TABLES = re.compile(r"FOR\s+ALL\s+TABLES\b\s*", re.IGNORECASE)
+_FOR_SCHEMA = re.compile(r"FOR\s+TABLES\s+IN\s+SCHEMA\b", re.IGNORECASE)
+_FOR_TABLE = re.compile(r"FOR\s+TABLE\s+", re.IGNORECASE)
+_TABLE_ACTION = re.compile(r"(?P<action>ADD|SET|DROP)\s+TABLE\s+(?P<tables>.+)$", re.I | re.S)
+_SET_OPTIONS = re.compile(r"SET\s*\(", re.IGNORECASE)
+_WITH = re.compile(r"\bWITH\s*\(", re.IGNORECASE)
+_TABLE_ITEM = re.compile(
+    rf"^(?:ONLY\s+)?(?P<relation>{_RELATION})\s*\*?\s*(?P<rest>.*)$", re.IGNORECASE | re.DOTALL
+)
+_WHERE = re.compile(r"WHERE\s*", re.IGNORECASE)
+
+_VIEW_REFERENCE = re.compile(
+    r"\bFROM\s+(?:pg_catalog\.)?(pg_publication_tables|pg_publication)\b", re.IGNORECASE
+)
+_JOIN = re.compile(r"\bJOIN\b|\bFROM\s+[\w.\"]+(?:\s+(?:AS\s+)?\w+)?\s*,", re.IGNORECASE)
+
+# Type OIDs
+_BOOL = 16
+_NAME = 19
+_TEXT = 25
+_OID = 26
+
+PG_PUBLICATION_COLUMNS = [
+    ("oid", _OID),
+    ("pubname", _NAME),
+    ("pubowner", _OID),
+    ("puballtables", _BOOL),
+    ("pubinsert", _BOOL),
+    ("pubupdate", _BOOL),
+    ("pubdelete", _BOOL),
+    ("pubtruncate", _BOOL),
+    ("pubviaroot", _BOOL),
+]
+
+PG_PUBLICATION_TABLES_COLUMNS = [
+    ("pubname", _NAME),
+    ("schemaname", _NAME),
+    ("tablename", _NAME),
+    ("attnames", _TEXT),  # name[], rendered as in PostgreSQL ("{id,status}")
+    ("rowfilter", _TEXT),
+]
+
+
+class PublicationError(Exception):
+    """A publication statement or row change that fails, with its SQLSTATE."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+@dataclass
+class PublishedTable:
+    schema: str  # IRIS schema
+    table: str
+    columns: list[str] | None = None  # Column list (lowercased), None = all columns
+    where: str | None = None  # Row filter condition, without its parentheses
+
+
+@dataclass
+class Publication:
+    name: str
+    all_tables: bool = False
+    publish: list[str] = field(default_factory=lambda: list(PUBLISH_ACTIONS))
+    via_root: bool = False  # publish_via_partition_root
+    tables: list[PublishedTable] = field(default_factory=list)
+
+    def table(self, schema: str, table: str) -> PublishedTable | None:
+        """The publication's entry for a table (a bare one for FOR ALL TABLES)."""
+        for entry in self.tables:
+            if (entry.schema.lower(), entry.table.lower()) == (schema.lower(), table.lower()):
+                return entry
+        return PublishedTable(schema, table) if self.all_tables else None
+
+
+@dataclass
+class TableSpec:
+    relation: str  # Table name as written
+    columns: list[str] | None = None
+    where: str | None = None
+
+
+@dataclass
+class PublicationDDL:
+    action: str  # CREATE, ALTER or DROP
+    names: list[str]
+    all_tables: bool = False
+    tables: list[TableSpec] | None = None  # FOR TABLE / ADD / SET / DROP TABLE list
+    table_action: str | None = None  # ALTER ... ADD, SET or DROP TABLE
+    options: dict[str, str] | None = None  # WITH (...) / SET (...)
+    if_exists: bool = False
+
+    @property
+    def command_tag(self) -> str:
+        return f"{self.action} PUBLICATION"
+
+
+def _syntax_error(text: str) -> PublicationError:
+    word = text.split()[0] if text.split() else text
+    return PublicationError(f'syntax error at or near "{word}"', "42601", "syntax_error")
+
+
+def _parse_options(text: str) -> dict[str, str]:
+    options = {}
+    for item in split_top_level(text):
+        name, _, value = item.partition("=")
+        value = value.strip()
+        if value.startswith("'"):
+            value = value[1:-1].replace("''", "'")
+        options[_unquote(name)] = value or "true"
+    return options
+
+
+def _parse_tables(text: str, allow_details: bool = True) -> list[TableSpec]:
+    tables = []
+    for item in split_top_level(text):
+        match = _TABLE_ITEM.match(item)
+        if not match:
+            raise _syntax_error(item)
+        spec, rest = TableSpec(match.group("relation")), match.group("rest")
+        if rest.startswith("(") and allow_details:
+            close = _closing_paren(rest, 0)
+            if close < 0:
+                raise _syntax_error(rest)
+            spec.columns = [_unquote(column) for column in split_top_level(rest[1:close])]
+            duplicate = {c for c in spec.columns if spec.columns.count(c) > 1}
+            if duplicate:
+                raise PublicationError(
+                    f'duplicate column "{sorted(duplicate)[0]}" in publication column list',
+                    "42701",
+                    "duplicate_column",
+                )
+            rest = rest[close + 1 :].lstrip()
+        where = _WHERE.match(rest) if allow_details else None
+        if where:
+            rest = rest[where.end() :]
+            # The condition must be parenthesized, as in PostgreSQL
+            close = _closing_paren(rest, 0) if rest.startswith("(") else -1
+            if close < 0:
+                raise _syntax_error(rest or "WHERE")
+            spec.where = rest[1:close].strip()
+            compile_row_filter(spec.where)
+            rest = rest[close + 1 :].lstrip()
+        if rest:
+            raise _syntax_error(rest)
+        tables.append(spec)
+    return tables
+
+
+def _split_with(text: str) -> tuple[str, dict[str, str] | None]:
+    """Remove a trailing WITH (...) clause: (rest, options)."""
+    match = _WITH.search(text)
+    if not match:
+        return text, None
+    close = _closing_paren(text, match.end() - 1)
+    if close < 0 or text[close + 1 :].strip():
+        raise _syntax_error(text[match.start() :])
+    return text[: match.start()].strip(), _parse_options(text[match.end() : close])
+
+
+def parse_publication_ddl(sql: str) -> PublicationDDL | None:
+    """
+    CREATE, ALTER or DROP PUBLICATION (None for any other statement).
+
+    Raises:
+        PublicationError: a publication statement that is invalid or unsupported
+    """
+    if "PUBLICATION" not in sql.upper():
+        return None
+
+    if match := _DROP.match(sql):
+        names = [_unquote(name) for name in split_top_level(match.group("names"))]
+        return PublicationDDL("DROP", names, if_exists=bool(match.group("if_exists")))
+
+    if match := _CREATE.match(sql):
+        rest, options = _split_with(match.group("rest"))
+        ddl = PublicationDDL("CREATE", [_unquote(match.group("name"))], options=options)
+        if _FOR_SCHEMA.match(rest):
+            raise PublicationError(
+                "FOR TABLES IN SCHEMA is not supported", "0A000", "feature_not_supported"
+            )
+        if all_tables := _FOR_ALL_TABLES.match(rest):
+            if rest[all_tables.end() :]:
+                raise _syntax_error(rest[all_tables.end() :])
+            ddl.all_tables = True
+        elif for_table := _FOR_TABLE.match(rest):
+            ddl.tables = _parse_tables(rest[for_table.end() :])
+        elif rest:
+            raise _syntax_error(rest)
+        return ddl
+
+    if match := _ALTER.match(sql):
+        rest = match.group("rest")
+        ddl = PublicationDDL("ALTER", [_unquote(match.group("name"))])
+        if options := _SET_OPTIONS.match(rest):
+            close = _closing_paren(rest, options.end() - 1)
+            if close < 0 or rest[close + 1 :].strip():
+                raise _syntax_error(rest)
+            ddl.options = _parse_options(rest[options.end() : close])
+            return ddl
+        table_action = _TABLE_ACTION.match(rest)
+        if table_action is None:
+            # TABLES IN SCHEMA, RENAME TO, OWNER TO
+            raise PublicationError(
+                f"ALTER PUBLICATION {' '.join(rest.split()[:2]).upper()} is not supported",
+                "0A000",
+                "feature_not_supported",
+            )
+        ddl.table_action = table_action.group("action").upper()
+        ddl.tables = _parse_tables(
+            table_action.group("tables"), allow_details=ddl.table_action != "DROP"
+        )
+        return ddl
+
+    return None
+
+
+def _publish_actions(value: str) -> list[str]:
+    actions = [action.strip().lower() for action in value.split(",") if action.strip()]
+    for action in actions:
+        if action not in PUBLISH_ACTIONS:
+            raise PublicationError(
+                f'unrecognized value for publication option "publish": "{action}"',
+                "22023",
+                "invalid_parameter_value",
+            )
+    return actions
+
+
+def _apply_options(publication: Publication, options: dict[str, str]) -> None:
+    for name, value in options.items():
+        if name == "publish":
+            publication.publish = _publish_actions(value)
+        elif name == "publish_via_partition_root":
+            publication.via_root = value.lower() in ("true", "on", "1", "yes")
+        else:
+            raise PublicationError(
+                f'unrecognized publication parameter: "{name}"', "42601", "syntax_error"
+            )
+
+
+def _does_not_exist(name: str) -> PublicationError:
+    return PublicationError(f'publication "{name}" does not exist', "42704", "undefined_object")
+
+
+def apply_publication_ddl(
+    store: "PublicationStore", ddl: PublicationDDL, tables: list[PublishedTable]
+) -> None:
+    """
+    Carry out a publication statement on the stored definitions.
+
+    Args:
+        tables: ddl.tables resolved to IRIS tables, in the same order
+
+    Raises:
+        PublicationError: the publication exists (CREATE) or does not, or the
+            statement conflicts with its definition
+    """
+    if ddl.action == "DROP":
+        missing = [name for name in ddl.names if store.get(name) is None]
+        if missing and not ddl.if_exists:
+            raise _does_not_exist(missing[0])
+        for name in ddl.names:
+            store.remove(name)
+        return
+
+    name = ddl.names[0]
+    publication = store.get(name)
+    if ddl.action == "CREATE":
+        if publication is not None:
+            raise PublicationError(
+                f'publication "{name}" already exists', "42710", "duplicate_object"
+            )
+        publication = Publication(name, all_tables=ddl.all_tables)
+        _add_tables(publication, tables)
+    elif publication is None:
+        raise _does_not_exist(name)
+    elif ddl.table_action is not None:
+        if publication.all_tables:
+            raise PublicationError(
+                f'publication "{name}" is defined as FOR ALL TABLES',
+                "55000",
+                "object_not_in_prerequisite_state",
+            )
+        if ddl.table_action == "SET":
+            publication.tables = []
+        if ddl.table_action == "DROP":
+            for table in tables:
+                if publication.table(table.schema, table.table) is None:
+                    raise PublicationError(
+                        f'relation "{table.table}" is not part of the publication',
+                        "42704",
+                        "undefined_object",
+                    )
+                publication.tables.remove(publication.table(table.schema, table.table))
+        else:
+            _add_tables(publication, tables)
+
+    if ddl.options:
+        _apply_options(publication, ddl.options)
+    store.put(publication)
+
+
+def _add_tables(publication: Publication, tables: list[PublishedTable]) -> None:
+    for table in tables:
+        if publication.table(table.schema, table.table) is not None:
+            raise PublicationError(
+                f'relation "{table.table}" is already member of publication '
+                f'"{publication.name}"',
+                "42710",
+                "duplicate_object",
+            )
+        publication.tables.append(table)
+
+
+class PublicationStore:
+    """Publication definitions in the IRIS global, through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv(
+            "PGWIRE_PUBLICATIONS_GLOBAL", DEFAULT_PUBLICATIONS_GLOBAL
+        )
+
+    def _subscripts_under(self, prefix: list) -> list:
+        found, previous = [], ""
+        while True:
+            previous = self.accessor.next_subscript(self.global_name, prefix, previous)
+            if previous is None:
+                return found
+            found.append(previous)
+
+    def get(self, name: str) -> Publication | None:
+        if self.accessor.data(self.global_name, [name]) in (0, 10):
+            return None
+        definition = json.loads(self.accessor.get(self.global_name, [name]))
+        publication = Publication(
+            name, definition["all_tables"], definition["publish"], definition["via_root"]
+        )
+        for schema in self._subscripts_under([name]):
+            for table in self._subscripts_under([name, schema]):
+                entry = json.loads(self.accessor.get(self.global_name, [name, schema, table]))
+                publication.tables.append(
+                    PublishedTable(schema, table, entry["columns"], entry["where"])
+                )
+        return publication
+
+    def put(self, publication: Publication) -> None:
+        """Store a publication, replacing an earlier definition."""
+        self.accessor.kill(self.global_name, [publication.name])
+        definition = {
+            "all_tables": publication.all_tables,
+            "publish": publication.publish,
+            "via_root": publication.via_root,
+        }
+        self.accessor.set(self.global_name, [publication.name], json.dumps(definition))
+        for table in publication.tables:
+            self.accessor.set(
+                self.global_name,
+                [publication.name, table.schema, table.table],
+                json.dumps({"columns": table.columns, "where": table.where}),
+            )
+
+    def remove(self, name: str) -> None:
+        self.accessor.kill(self.global_name, [name])
+
+    def all(self) -> list[Publication]:
+        return [self.get(name) for name in self._subscripts_under([])]
+
+
+# ---------------------------------------------------------------------- row filters
+
+
+_TOKEN = re.compile(
+    r"\s*(?:(?P<string>'(?:[^']|'')*')"
+    r"|(?P<number>\d+(?:\.\d*)?(?:[eE][+-]?\d+)?|\.\d+)"
+    r'|(?P<identifier>"(?:[^"]|"")+"|[A-Za-z_][\w$]*)'
+    r"|(?P<operator><>|!=|<=|>=|[=<>(),.\-]))"
+)
+_COMPARISONS = {
+    "=": lambda a, b: a == b,
+    "<>": lambda a, b: a != b,
+    "!=": lambda a, b: a != b,
+    "<": lambda a, b: a < b,
+    "<=": lambda a, b: a <= b,
+    ">": lambda a, b: a > b,
+    ">=": lambda a, b: a >= b,
+}
+_KEYWORDS = frozenset({"AND", "OR", "NOT", "IS", "NULL", "IN", "TRUE", "FALSE"})
+
+
+@dataclass
+class RowFilter:
+    """A compiled publication row filter."""
+
+    condition: str
+    columns: set[str]  # Columns referenced (lowercased)
+    evaluate: Callable[[dict[str, Any]], Any]  # Row -> True / False / None (NULL)
+
+    def matches(self, row: dict[str, Any]) -> bool:
+        return self.evaluate({name.lower(): value for name, value in row.items()}) is True
+
+
+def _invalid_expression() -> PublicationError:
+    return PublicationError(
+        "invalid publication WHERE expression", "0A000", "feature_not_supported"
+    )
+
+
+def _coerce(left: Any, right: Any) -> tuple[Any, Any]:
+    """Compare row values with literals of another type as PostgreSQL's casts would."""
+    for swap in (False, True):
+        a, b = (right, left) if swap else (left, right)
+        if isinstance(b, str) and not isinstance(a, str):
+            try:
+                if isinstance(a, bool):
+                    b = b.strip().lower() in ("t", "true", "on", "1", "yes", "y")
+                elif isinstance(a, (int, float, Decimal)):
+                    b = Decimal(b)
+                elif isinstance(a, datetime.datetime):
+                    b = datetime.datetime.fromisoformat(b)
+                elif isinstance(a, datetime.date):
+                    b = datetime.date.fromisoformat(b)
+            except (InvalidOperation, ValueError):
+                pass
+            return (b, a) if swap else (a, b)
+    return left, right
+
+
+def _compare(operator: str, left: Any, right: Any) -> bool | None:
+    if left is None or right is None:
+        return None
+    left, right = _coerce(left, right)
+    try:
+        return _COMPARISONS[operator](left, right)
+    except TypeError:
+        return _COMPARISONS[operator](str(left), str(right))
+
+
+class _FilterParser:
+    def __init__(self, condition: str):
+        self.tokens = []
+        position = 0
+        while condition[position:].strip():
+            match = _TOKEN.match(condition, position)
+            if not match:
+                raise _syntax_error(condition[position:].strip())
+            kind = match.lastgroup
+            self.tokens.append((kind, match.group(kind)))
+            position = match.end()
+        self.index = 0
+        self.columns: set[str] = set()
+
+    def peek(self, offset: int = 0) -> tuple[str, str] | None:
+        index = self.index + offset
+        return self.tokens[index] if index < len(self.tokens) else None
+
+    def keyword(self, *words: str) -> bool:
+        token = self.peek()
+        if token and token[0] == "identifier" and token[1].upper() in words:
+            self.index += 1
+            return True
+        return False
+
+    def operator(self, *operators: str) -> str | None:
+        token = self.peek()
+        if token and token[0] == "operator" and token[1] in operators:
+            self.index += 1
+            return token[1]
+        return None
+
+    def expect(self, operator: str) -> None:
+        if not self.operator(operator):
+            token = self.peek()
+            raise _syntax_error(token[1] if token else "end of input")
+
+    def parse(self):
+        expression = self.or_expression()
+        if self.peek() is not None:
+            raise _syntax_error(self.peek()[1])
+        return expression
+
+    def or_expression(self):
+        terms = [self.and_expression()]
+        while self.keyword("OR"):
+            terms.append(self.and_expression())
+        if len(terms) == 1:
+            return terms[0]
+
+        def evaluate(row):
+            values = [term(row) for term in terms]
+            if True in values:
+                return True
+            return None if None in values else False
+
+        return evaluate
+
+    def and_expression(self):
+        terms = [self.not_expression()]
+        while self.keyword("AND"):
+            terms.append(self.not_expression())
+        if len(terms) == 1:
+            return terms[0]
+
+        def evaluate(row):
+            values = [term(row) for term in terms]
+            if False in values:
+                return False
+            return None if None in values else True
+
+        return evaluate
+
+    def not_expression(self):
+        if self.keyword("NOT"):
+            term = self.not_expression()
+            return lambda row: None if term(row) is None else not term(row)
+        return self.predicate()
+
+    def predicate(self):
+        left = self.operand()
+        if operator := self.operator(*_COMPARISONS):
+            right = self.operand()
+            return lambda row: _compare(operator, left(row), right(row))
+        if self.keyword("IS"):
+            negated = self.keyword("NOT")
+            if self.keyword("NULL"):
+                return lambda row: (left(row) is None) != negated
+            if self.keyword("TRUE", "FALSE"):
+                wanted = self.tokens[self.index - 1][1].upper() == "TRUE"
+                return lambda row: (left(row) == wanted and left(row) is not None) != negated
+            token = self.peek()
+            raise _syntax_error(token[1] if token else "end of input")
+        negated = self.keyword("NOT")
+        if self.keyword("IN"):
+            self.expect("(")
+            items = [self.operand()]
+            while self.operator(","):
+                items.append(self.operand())
+            self.expect(")")
+
+            def evaluate(row):
+                found = [_compare("=", left(row), item(row)) for item in items]
+                result = True if True in found else (None if None in found else False)
+                return result if result is None or not negated else not result
+
+            return evaluate
+        if negated:
+            raise _syntax_error("NOT")
+        return left
+
+    def operand(self):
+        token = self.peek()
+        if token is None:
+            raise _syntax_error("end of input")
+        kind, text = token
+        if kind == "operator" and text == "(":
+            self.index += 1
+            expression = self.or_expression()
+            self.expect(")")
+            return expression
+        if kind == "operator" and text == "-":
+            self.index += 1
+            number = self.peek()
+            if not number or number[0] != "number":
+                raise _invalid_expression()
+            self.index += 1
+            value = -Decimal(number[1])
+            return lambda row: value
+        self.index += 1
+        if kind == "string":
+            value = text[1:-1].replace("''", "'")
+            return lambda row: value
+        if kind == "number":
+            value = Decimal(text)
+            return lambda row: value
+        if kind == "identifier":
+            following = self.peek()
+            if following and following[0] == "operator" and following[1] in ("(", "."):
+                # Function calls and qualified names
+                raise _invalid_expression()
+            upper = text.upper()
+            if upper in ("TRUE", "FALSE"):
+                value = upper == "TRUE"
+                return lambda row: value
+            if upper == "NULL":
+                return lambda row: None
+            if upper in _KEYWORDS:
+                raise _syntax_error(text)
+            column = _unquote(text)
+            self.columns.add(column)
+            return lambda row: row.get(column)
+        raise _syntax_error(text)
+
+
+def compile_row_filter(condition: str) -> RowFilter:
+    """
+    Raises:
+        PublicationError: a condition that is not a valid row filter
+    """
+    parser = _FilterParser(condition)
+    return RowFilter(condition, parser.columns, parser.parse())
+
+
+# ---------------------------------------------------------------------- row changes
+
+
+@dataclass
+class RowChange:
+    operation: str  # insert, update, delete or truncate
+    schema: str  # IRIS schema
+    table: str
+    new: dict[str, Any] | None = None  # Column values by name
+    old: dict[str, Any] | None = None  # Old row (or its replica identity) of UPDATE / DELETE
+
+
+class ChangeFilter:
+    """A subscription's publications, applied to row changes."""
+
+    def __init__(self, publications: list[Publication]):
+        self.publications = publications
+        self._filters: dict[str, RowFilter] = {}
+
+    def _row_filter(self, condition: str) -> RowFilter:
+        if condition not in self._filters:
+            self._filters[condition] = compile_row_filter(condition)
+        return self._filters[condition]
+
+    def columns(self, schema: str, table: str) -> list[str] | None:
+        """
+        The column list of a table (None for all columns).
+
+        Raises:
+            PublicationError: the publications give the table different column lists
+        """
+        lists = []
+        for publication in self.publications:
+            entry = publication.table(schema, table)
+            if entry is not None and entry.columns not in lists:
+                lists.append(entry.columns)
+        if len(lists) > 1:
+            raise PublicationError(
+                f'cannot use different column lists for table "{schema}.{table}" '
+                "in different publications",
+                "0A000",
+                "feature_not_supported",
+            )
+        return lists[0] if lists else None
+
+    def apply(self, change: RowChange) -> RowChange | None:
+        """
+        The change as published: filtered, projected on the column list, an
+        UPDATE possibly turned into an INSERT or DELETE (None if not published).
+        """
+        entries = [
+            entry
+            for publication in self.publications
+            if change.operation in publication.publish
+            and (entry := publication.table(change.schema, change.table)) is not None
+        ]
+        if not entries:
+            return None
+        columns = self.columns(change.schema, change.table)
+
+        operation = change.operation
+        if operation != "truncate" and all(entry.where for entry in entries):
+            filters = [self._row_filter(entry.where) for entry in entries]
+
+            def passes(row):
+                return any(row_filter.matches(row) for row_filter in filters)
+
+            if operation == "insert" and not passes(change.new):
+                return None
+            if operation == "delete" and not passes(change.old):
+                return None
+            if operation == "update":
+                new_passes = passes(change.new)
+                old_passes = new_passes if change.old is None else passes(change.old)
+                if not (old_passes or new_passes):
+                    return None
+                if not new_passes:
+                    operation = "delete"
+                elif not old_passes:
+                    operation = "insert"
+
+        def project(row):
+            if row is None or columns is None:
+                return row
+            return {name: value for name, value in row.items() if name.lower() in columns}
+
+        return RowChange(
+            operation,
+            change.schema,
+            change.table,
+            None if operation == "delete" else project(change.new),
+            None if operation == "insert" else project(change.old),
+        )
+
+
+# ---------------------------------------------------------------------- catalog views
+
+
+def referenced_view(sql: str) -> str | None:
+    """pg_publication or pg_publication_tables for a single-view SELECT on one."""
+    match = _VIEW_REFERENCE.search(sql)
+    if not match or _JOIN.search(sql):
+        return None
+    return match.group(1).lower()
+
+
+def _pg_schema(schema: str, iris_schema: str) -> str:
+    return "public" if schema.lower() == iris_schema.lower() else schema.lower()
+
+
+def publication_rows(publications: list[Publication], oid_generator) -> list[dict[str, Any]]:
+    """pg_publication rows."""
+    return [
+        {
+            "oid": oid_generator.get_oid("pg_catalog", "publication", publication.name),
+            "pubname": publication.name,
+            "pubowner": 10,
+            "puballtables": publication.all_tables,
+            **{f"pub{action}": action in publication.publish for action in PUBLISH_ACTIONS},
+            "pubviaroot": publication.via_root,
+        }
+        for publication in publications
+    ]
+
+
+def publication_table_rows(
+    publications: list[Publication],
+    columns: dict[tuple[str, str], list[str]],
+    iris_schema: str,
+) -> list[dict[str, Any]]:
+    """
+    pg_publication_tables rows.
+
+    Args:
+        columns: Column names in table order per (IRIS schema, table), for
+            every table (FOR ALL TABLES publications list them all)
+    """
+    rows = []
+    for publication in publications:
+        if publication.all_tables:
+            tables = [PublishedTable(schema, table) for schema, table in columns]
+        else:
+            tables = publication.tables
+        for entry in tables:
+            names = columns.get((entry.schema, entry.table), [])
+            if entry.columns is not None:
+                names = [name for name in names if name.lower() in entry.columns]
+            rows.append(
+                {
+                    "pubname": publication.name,
+                    "schemaname": _pg_schema(entry.schema, iris_schema),
+                    "tablename": entry.table.lower(),
+                    "attnames": "{" + ",".join(name.lower() for name in names) + "}",
+                    "rowfilter": f"({entry.where})" if entry.where else None,
+                }
+            )
+    return rows
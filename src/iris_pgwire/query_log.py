@@ -0,0 +1,134 @@
+"""
+Structured Query Log (JSON Lines / syslog)
+
+Optional full query log for compliance pipelines. Every statement executed
+against IRIS is written as one JSON object:
+
+    {"ts": "2026-01-05T10:31:02.114Z", "connection_id": "10.0.0.7:53122",
+     "user": "etl", "command": "INSERT", "sql": "INSERT INTO t VALUES (?, ?)",
+     "params": ["***", "***"], "duration_ms": 3.2, "rows": 1,
+     "success": true, "sqlstate": null}
+
+Configuration:
+- PGWIRE_QUERY_LOG: destination - a file path (JSON Lines, rotated by size) or
+  "syslog" / "syslog:<host>:<port>" (default: disabled)
+- PGWIRE_QUERY_LOG_MAX_BYTES: rotate the file at this size (default 100 MB, 0 = never)
+- PGWIRE_QUERY_LOG_BACKUPS: rotated files to keep (default 10)
+- PGWIRE_QUERY_LOG_PARAMS: "redact" (default - values replaced by "***"),
+  "full" (values logged as sent) or "none" (parameters omitted)
+
+The writer is a StatsHooks subscriber (see stats_hooks.py), so it sees exactly
+the statements the embedder hooks see.
+"""
+
+import datetime
+import json
+import logging
+import logging.handlers
+import os
+from typing import Any
+
+import structlog
+
+from .stats_hooks import QueryEvent, StatsHooks, get_stats
+
+logger = structlog.get_logger()
+
+PARAM_MODES = ("redact", "full", "none")
+REDACTED = "***"
+DEFAULT_MAX_BYTES = 100 * 1024 * 1024
+DEFAULT_BACKUPS = 10
+DEFAULT_SYSLOG_ADDRESS = "/dev/log"
+
+
+def _json_default(value: Any) -> Any:
+    if isinstance(value, bytes | bytearray | memoryview):
+        return bytes(value).hex()
+    return str(value)
+
+
+class QueryLogWriter(StatsHooks):
+    """Write one JSON record per executed statement to a logging handler."""
+
+    def __init__(self, handler: logging.Handler, param_mode: str = "redact"):
+        if param_mode not in PARAM_MODES:
+            raise ValueError(f"invalid query log parameter mode: {param_mode!r}")
+        self.param_mode = param_mode
+        self.handler = handler
+        self.handler.setFormatter(logging.Formatter("%(message)s"))
+
+        # Dedicated logger: query records must not mix with the server log
+        self._log = logging.Logger("iris_pgwire.query_log", level=logging.INFO)
+        self._log.propagate = False
+        self._log.addHandler(handler)
+
+    def format_event(self, event: QueryEvent) -> str:
+        """JSON record for one statement."""
+        record = {
+            "ts": datetime.datetime.fromtimestamp(event.timestamp, datetime.UTC)
+            .isoformat(timespec="milliseconds")
+            .replace("+00:00", "Z"),
+            "connection_id": event.connection_id,
+            "user": event.user,
+            "command": event.command,
+            "sql": event.sql,
+        }
+        if self.param_mode != "none":
+            params = event.params or []
+            record["params"] = (
+                [None if p is None else REDACTED for p in params]
+                if self.param_mode == "redact"
+                else params
+            )
+        record.update(
+            {
+                "duration_ms": round(event.duration_ms, 3),
+                "rows": event.row_count,
+                "success": event.success,
+                "sqlstate": event.sqlstate,
+            }
+        )
+        return json.dumps(record, default=_json_default, ensure_ascii=False)
+
+    def on_query(self, event: QueryEvent) -> None:
+        self._log.info(self.format_event(event))
+
+    def close(self) -> None:
+        self._log.removeHandler(self.handler)
+        self.handler.close()
+
+
+def create_handler(destination: str) -> logging.Handler:
+    """Logging handler for a PGWIRE_QUERY_LOG destination."""
+    if destination == "syslog" or destination.startswith("syslog:"):
+        address: Any = DEFAULT_SYSLOG_ADDRESS
+        if destination.startswith("syslog:"):
+            host, _, port = destination[len("syslog:") :].rpartition(":")
+            address = (host, int(port)) if host else (port, 514)
+        return logging.handlers.SysLogHandler(
+            address=address, facility=logging.handlers.SysLogHandler.LOG_LOCAL0
+        )
+
+    max_bytes = int(os.getenv("PGWIRE_QUERY_LOG_MAX_BYTES", str(DEFAULT_MAX_BYTES)))
+    backups = int(os.getenv("PGWIRE_QUERY_LOG_BACKUPS", str(DEFAULT_BACKUPS)))
+    return logging.handlers.RotatingFileHandler(
+        destination, maxBytes=max_bytes, backupCount=backups, encoding="utf-8"
+    )
+
+
+def install_query_log() -> QueryLogWriter | None:
+    """Register the query log writer configured by PGWIRE_QUERY_LOG (if any)."""
+    destination = os.getenv("PGWIRE_QUERY_LOG", "").strip()
+    if not destination:
+        return None
+
+    param_mode = os.getenv("PGWIRE_QUERY_LOG_PARAMS", "redact").strip().lower()
+    try:
+        writer = QueryLogWriter(create_handler(destination), param_mode)
+    except (OSError, ValueError) as e:
+        logger.error("Query log disabled", destination=destination, error=str(e))
+        return None
+
+    get_stats().add_hooks(writer)
+    logger.info("Query log enabled", destination=destination, params=param_mode)
+    return writer
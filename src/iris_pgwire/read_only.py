@@ -0,0 +1,108 @@
+"""
+Read-Only Sessions (default_transaction_read_only)
+
+    SET default_transaction_read_only = on
+
+makes every statement of the session read-only, as in PostgreSQL: writes and
+DDL fail with SQLSTATE 25006 (read_only_sql_transaction) before they reach
+IRIS. It is usually set for reporting roles through role defaults (see
+role_settings.py) rather than by the client.
+
+Classification is by statement verb, so the check is cheap and independent
+of the IRIS account's privileges; it is a guard rail for BI tools, not a
+replacement for IRIS SQL privileges.
+"""
+
+import re
+from contextvars import ContextVar
+
+READ_ONLY_PARAMETER = "default_transaction_read_only"
+
+# Statement verbs PostgreSQL rejects in a read-only transaction
+_WRITE_VERBS = frozenset(
+    {
+        "INSERT",
+        "UPDATE",
+        "DELETE",
+        "MERGE",
+        "TRUNCATE",
+        "CREATE",
+        "ALTER",
+        "DROP",
+        "GRANT",
+        "REVOKE",
+        "COMMENT",
+        "REINDEX",
+        "CLUSTER",
+        "VACUUM",
+        "REFRESH",
+    }
+)
+
+_LEADING_COMMENTS = re.compile(r"^(?:\s+|/\*.*?\*/|--[^\n]*(?:\n|$))*", re.DOTALL)
+_STRING_LITERAL = re.compile(r"'(?:[^']|'')*'")
+_DML_VERB = re.compile(r"\b(INSERT|UPDATE|DELETE|MERGE)\b", re.IGNORECASE)
+_SELECT_INTO = re.compile(r"^SELECT\b.*?\bINTO\b(?!\s*:)", re.IGNORECASE | re.DOTALL)
+_COPY_FROM = re.compile(r"^COPY\b.*?\bFROM\b", re.IGNORECASE | re.DOTALL)
+
+_session_read_only: ContextVar[bool] = ContextVar("pgwire_session_read_only", default=False)
+
+
+class ReadOnlyTransaction(Exception):
+    """Write attempted in a read-only session (SQLSTATE 25006)."""
+
+    sqlstate = "25006"
+    condition_name = "read_only_sql_transaction"
+
+    def __init__(self, command: str):
+        super().__init__(f"cannot execute {command} in a read-only transaction")
+        self.command = command
+
+
+def set_session_read_only(read_only: bool) -> None:
+    """Record default_transaction_read_only for the current connection task."""
+    _session_read_only.set(read_only)
+
+
+def session_read_only() -> bool:
+    """Whether the session the current task serves is read-only."""
+    return _session_read_only.get()
+
+
+def write_command(sql: str) -> str | None:
+    """
+    Command name of a statement that modifies data or schema (None for reads).
+
+    WITH queries count as writes when a CTE or the main statement is DML.
+    """
+    statement = _LEADING_COMMENTS.sub("", sql, count=1)
+    statement = _STRING_LITERAL.sub("''", statement)
+    match = re.match(r"\w+", statement)
+    if not match:
+        return None
+
+    verb = match.group(0).upper()
+    if verb in _WRITE_VERBS:
+        return verb
+    if verb == "WITH":
+        dml = _DML_VERB.search(statement)
+        return dml.group(1).upper() if dml else None
+    if verb == "SELECT" and _SELECT_INTO.match(statement):
+        return "SELECT INTO"
+    if verb == "COPY" and _COPY_FROM.match(statement):
+        return "COPY FROM"
+    return None
+
+
+def check_read_only(sql: str) -> None:
+    """
+    Reject a write when the current session is read-only.
+
+    Raises:
+        ReadOnlyTransaction: statement modifies data or schema
+    """
+    if not session_read_only():
+        return
+    command = write_command(sql)
+    if command is not None:
+        raise ReadOnlyTransaction(command)
@@ -0,0 +1,256 @@
+"""
+Replication Connections (walsender sessions)
+
+A StartupMessage with replication=database opens a logical replication
+connection - what Debezium, pg_recvlogical and other pgoutput consumers open
+for change data capture - and replication=true (on/yes/1) a physical one.
+Like a PostgreSQL walsender, such a session:
+
+- accepts the replication command grammar in simple Query messages.
+  IDENTIFY_SYSTEM reports a system identifier stable per IRIS instance and
+  namespace, timeline 1, the current position and the database; SHOW answers
+  as in other sessions; CREATE_REPLICATION_SLOT, DROP_REPLICATION_SLOT and
+  READ_REPLICATION_SLOT manage slots (replication_slots.py);
+  START_REPLICATION streams (replication_stream.py); TIMELINE_HISTORY,
+  ALTER_REPLICATION_SLOT and BASE_BACKUP fail with 0A000
+  (feature_not_supported)
+- in database mode also runs plain SQL; a physical connection rejects it
+- rejects the extended query protocol with 08P01 (protocol_violation)
+- is listed as a "walsender" backend in pg_stat_activity and in
+  pg_stat_replication (catalog/pg_stat.py)
+
+While a connection streams changes its WalSenderProgress records the
+positions sent and those the subscriber reports written, flushed and applied
+(standby status updates). pg_stat_replication shows them with the lag times
+derived from them, as PostgreSQL does: the time between sending a position
+and the subscriber confirming it. The slot being streamed is listed in
+pg_replication_slots, with the subscriber's flushed position as its
+confirmed_flush_lsn.
+
+IRIS has no write-ahead log to number positions (LSNs) by. Positions are
+IRIS journal positions (replication_slots.py), or the bridge's clock in
+microseconds, which only moves forward, when the journal cannot be read.
+"""
+
+import hashlib
+import re
+import time
+from collections import deque
+from dataclasses import dataclass, field
+from typing import Any
+
+LOGICAL = "database"
+PHYSICAL = "physical"
+
+# pg_stat_activity.backend_type of replication connections
+WALSENDER = "walsender"
+
+REPLICATION_COMMANDS = frozenset(
+    {
+        "IDENTIFY_SYSTEM",
+        "SHOW",
+        "TIMELINE_HISTORY",
+        "CREATE_REPLICATION_SLOT",
+        "DROP_REPLICATION_SLOT",
+        "ALTER_REPLICATION_SLOT",
+        "READ_REPLICATION_SLOT",
+        "START_REPLICATION",
+        "BASE_BACKUP",
+        "UPLOAD_MANIFEST",
+    }
+)
+
+SQL_IN_PHYSICAL_REPLICATION = "cannot execute SQL commands in WAL sender for physical replication"
+EXTENDED_PROTOCOL_IN_REPLICATION = (
+    "extended query protocol not supported in a replication connection"
+)
+
+TIMELINE = 1
+
+_FIRST_WORD = re.compile(r"\s*([A-Za-z_]+)")
+
+# Sent positions remembered for lag measurement, like PostgreSQL's lag tracker
+_LAG_SAMPLES = 1024
+
+_TRUE_VALUES = ("true", "on", "yes", "1")
+_FALSE_VALUES = ("false", "off", "no", "0")
+
+
+class InvalidReplicationParameter(Exception):
+    """StartupMessage replication parameter is not a boolean or "database" (22023)."""
+
+    sqlstate = "22023"
+    condition_name = "invalid_parameter_value"
+
+    def __init__(self, value: str):
+        super().__init__(f'invalid value for parameter "replication": "{value}"')
+
+
+def replication_mode(value: str | None) -> str | None:
+    """
+    LOGICAL or PHYSICAL for a replication startup parameter; None for a
+    regular session.
+
+    Raises:
+        InvalidReplicationParameter: value is neither a boolean nor "database"
+    """
+    if value is None:
+        return None
+    normalized = value.strip().lower()
+    if normalized == LOGICAL:
+        return LOGICAL
+    if normalized in _TRUE_VALUES:
+        return PHYSICAL
+    if normalized in _FALSE_VALUES:
+        return None
+    raise InvalidReplicationParameter(value)
+
+
+def replication_command(sql: str) -> str | None:
+    """The replication command sql is (e.g. "IDENTIFY_SYSTEM"), None for SQL."""
+    match = _FIRST_WORD.match(sql)
+    if not match:
+        return None
+    keyword = match.group(1).upper()
+    return keyword if keyword in REPLICATION_COMMANDS else None
+
+
+def current_lsn() -> int:
+    """The current position: microseconds since the epoch."""
+    return time.time_ns() // 1000
+
+
+def format_lsn(position: int) -> str:
+    """PostgreSQL's text form of a position, e.g. 16/B374D848."""
+    return f"{position >> 32:X}/{position & 0xFFFFFFFF:X}"
+
+
+def system_identifier(host: str | None, port: Any, namespace: str | None) -> str:
+    """Stable 64-bit identifier of an IRIS instance and namespace, as decimal text."""
+    digest = hashlib.sha256(f"{host}:{port}/{namespace}".encode()).digest()
+    return str(int.from_bytes(digest[:8], "big") >> 1)
+
+
+def _column(name: str, type_oid: int = 25) -> dict[str, Any]:
+    return {
+        "name": name,
+        "type_oid": type_oid,
+        "type_size": 4 if type_oid == 23 else -1,
+        "type_modifier": -1,
+        "format_code": 0,
+    }
+
+
+def identify_system_result(
+    system_id: str, database: str | None, position: int | None = None
+) -> dict[str, Any]:
+    """IDENTIFY_SYSTEM result; database is None on a physical connection."""
+    xlogpos = current_lsn() if position is None else position
+    row = [system_id, TIMELINE, format_lsn(xlogpos), database]
+    return {
+        "success": True,
+        "rows": [row],
+        "columns": [
+            _column("systemid"),
+            _column("timeline", 23),
+            _column("xlogpos"),
+            _column("dbname"),
+        ],
+        "row_count": 1,
+        "command": "IDENTIFY_SYSTEM",
+    }
+
+
+@dataclass
+class WalSenderProgress:
+    """Streaming progress of one replication connection; positions are LSNs."""
+
+    slot_name: str | None = None
+    plugin: str | None = None  # Output plugin of a logical slot
+    state: str = "startup"  # "streaming" once START_REPLICATION runs
+    sent_lsn: int | None = None
+    write_lsn: int | None = None
+    flush_lsn: int | None = None
+    replay_lsn: int | None = None
+    write_lag: float | None = None  # Seconds
+    flush_lag: float | None = None
+    replay_lag: float | None = None
+    reply_time: float | None = None  # Epoch seconds of the last standby status update
+    _sent: deque = field(default_factory=lambda: deque(maxlen=_LAG_SAMPLES), repr=False)
+
+    def start_streaming(self, slot_name: str | None, plugin: str | None = None) -> None:
+        self.slot_name = slot_name
+        self.plugin = plugin
+        self.state = "streaming"
+
+    def stop_streaming(self) -> None:
+        self.slot_name = None
+        self.plugin = None
+        self.state = "startup"
+
+    def sent(self, lsn: int, now: float | None = None) -> None:
+        """Record changes up to lsn sent to the subscriber."""
+        self.sent_lsn = lsn
+        if not self._sent or self._sent[-1][0] < lsn:
+            self._sent.append((lsn, time.time() if now is None else now))
+
+    def confirmed(
+        self,
+        write: int,
+        flush: int,
+        replay: int,
+        reply_time: float | None = None,
+        now: float | None = None,
+    ) -> None:
+        """
+        Record a standby status update. A position of 0 means the subscriber
+        does not report it and leaves the previous value.
+        """
+        now = time.time() if now is None else now
+        for name, position in (("write", write), ("flush", flush), ("replay", replay)):
+            if not position:
+                continue
+            setattr(self, f"{name}_lsn", position)
+            lag = self._lag(position, now)
+            if lag is not None:
+                setattr(self, f"{name}_lag", lag)
+        self.reply_time = now if reply_time is None else reply_time
+
+        # Samples every reported position has passed are no longer needed
+        reported = [p for p in (self.write_lsn, self.flush_lsn, self.replay_lsn) if p]
+        while reported and self._sent and self._sent[0][0] <= min(reported):
+            self._sent.popleft()
+
+    def _lag(self, position: int, now: float) -> float | None:
+        """Time since the newest sent position the subscriber has reached was sent."""
+        sent_at = None
+        for lsn, at in self._sent:
+            if lsn > position:
+                break
+            sent_at = at
+        return None if sent_at is None else now - sent_at
+
+
+class WalSenders:
+    """Progress of the open replication connections, by backend process ID."""
+
+    def __init__(self):
+        self._progress: dict[int, WalSenderProgress] = {}
+
+    def register(self, pid: int) -> WalSenderProgress:
+        progress = self._progress[pid] = WalSenderProgress()
+        return progress
+
+    def unregister(self, pid: int) -> None:
+        self._progress.pop(pid, None)
+
+    def get(self, pid: int | None) -> WalSenderProgress | None:
+        return self._progress.get(pid)
+
+
+_wal_senders = WalSenders()
+
+
+def get_wal_senders() -> WalSenders:
+    """Process-wide replication connection progress."""
+    return _wal_senders
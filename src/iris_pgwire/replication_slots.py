@@ -0,0 +1,385 @@
+"""
+Replication Slots
+
+A slot is the position up to which a logical replication subscriber
+(Debezium, pg_recvlogical) has durably processed the change stream. When
+the subscriber restarts, or the bridge crashes, the stream must resume at
+exactly that position, or downstream Kafka consumers see duplicates or miss
+changes. Slots are managed with the replication commands
+
+    CREATE_REPLICATION_SLOT name [TEMPORARY] {LOGICAL plugin | PHYSICAL} [options]
+    DROP_REPLICATION_SLOT name [WAIT]
+    READ_REPLICATION_SLOT name
+
+Positions (LSNs) are IRIS journal positions: the journal file in the high
+32 bits (days since 1970 * 1000 + the sequence number of its YYYYMMDD.NNN
+name) and the offset within that file in the low 32 bits, so a slot's
+position names the journal record its stream resumes reading at. When the
+journal cannot be read (journaling disabled), the bridge's clock gives the
+current position instead (replication.current_lsn); clock positions are
+always lower than journal ones, and a stream never reports a position lower
+than one it reported before.
+
+Slots are kept in an IRIS global (PGWIRE_REPLICATION_SLOTS_GLOBAL, default
+^PGWire.Slots), one JSON definition per slot:
+
+    ^PGWire.Slots(name) = {"slot_type": "logical", "plugin": "pgoutput",
+                           "restart_lsn": ..., "confirmed_flush_lsn": ..., ...}
+
+Each standby status update that advances the subscriber's flushed position
+rewrites the slot inside an IRIS transaction (TSTART / TCOMMIT), journaled
+like any other update: after a crash the slot holds the last position the
+subscriber confirmed, never a later or partly written one. START_REPLICATION
+on a slot resumes at the later of the requested position and the slot's
+confirmed one, so changes up to it are not sent again and every change after
+it is still read from the journal. Slot positions only move forward.
+
+Temporary slots live in memory and are dropped when their session ends. A
+slot streamed by one connection cannot be streamed or dropped by another
+(55006); DROP_REPLICATION_SLOT ... WAIT does not wait for it.
+"""
+
+import datetime
+import json
+import os
+import re
+from dataclasses import asdict, dataclass
+
+import structlog
+
+from .replication import current_lsn
+
+logger = structlog.get_logger()
+
+DEFAULT_SLOTS_GLOBAL = "^PGWire.Slots"
+
+# Replication commands answered here; ALTER_REPLICATION_SLOT is not supported
+SLOT_COMMANDS = frozenset(
+    {"CREATE_REPLICATION_SLOT", "DROP_REPLICATION_SLOT", "READ_REPLICATION_SLOT"}
+)
+
+LOGICAL_SLOT = "logical"
+PHYSICAL_SLOT = "physical"
+
+_EPOCH = datetime.date(1970, 1, 1)
+# Journal positions start with files named after 2000-01-01
+_FIRST_JOURNAL_FILE = (datetime.date(2000, 1, 1) - _EPOCH).days * 1000
+
+_SLOT = r"(?P<slot>\"[^\"]+\"|[\w$]+)"
+_CREATE_SLOT = re.compile(
+    rf"^\s*CREATE_REPLICATION_SLOT\s+{_SLOT}\s+(?P<temporary>TEMPORARY\s+)?"
+    r"(?:(?P<physical>PHYSICAL)|LOGICAL\s+(?P<plugin>\"[^\"]+\"|[\w$]+))(?:\s.*?)?;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_SLOT_COMMAND = re.compile(
+    rf"^\s*(?P<command>DROP_REPLICATION_SLOT|READ_REPLICATION_SLOT)\s+{_SLOT}"
+    r"(?:\s+WAIT)?\s*;?\s*$",
+    re.IGNORECASE,
+)
+_SLOT_NAME = re.compile(r"[a-z0-9_]{1,63}")
+_JOURNAL_FILE = re.compile(r"(\d{4})(\d{2})(\d{2})\.(\d{3,})$")
+
+
+class SlotError(Exception):
+    """A slot command that fails, with its SQLSTATE."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+def _syntax_error(sql: str) -> SlotError:
+    word = sql.split()[0] if sql.split() else sql
+    return SlotError(f'syntax error at or near "{word}"', "42601", "syntax_error")
+
+
+def _slot_name(text: str) -> str:
+    name = text[1:-1] if text.startswith('"') else text.lower()
+    if not _SLOT_NAME.fullmatch(name):
+        raise SlotError(
+            f'replication slot name "{name}" contains invalid character',
+            "42602",
+            "invalid_name",
+        )
+    return name
+
+
+@dataclass
+class CreateSlot:
+    name: str
+    logical: bool
+    plugin: str | None = None
+    temporary: bool = False
+
+
+def parse_create_slot(sql: str) -> CreateSlot:
+    """
+    Raises:
+        SlotError: not a valid CREATE_REPLICATION_SLOT command
+    """
+    match = _CREATE_SLOT.match(sql)
+    if not match:
+        raise _syntax_error(sql)
+    plugin = match.group("plugin")
+    return CreateSlot(
+        _slot_name(match.group("slot")),
+        logical=not match.group("physical"),
+        plugin=plugin.strip('"') if plugin else None,
+        temporary=bool(match.group("temporary")),
+    )
+
+
+def parse_slot_command(sql: str) -> str:
+    """
+    The slot named by DROP_REPLICATION_SLOT or READ_REPLICATION_SLOT.
+
+    Raises:
+        SlotError: not a valid command
+    """
+    match = _SLOT_COMMAND.match(sql)
+    if not match:
+        raise _syntax_error(sql)
+    return _slot_name(match.group("slot"))
+
+
+class JournalUnavailable(Exception):
+    """The journal file name does not give a position (e.g. journaling disabled)."""
+
+
+def journal_lsn(file_name: str, offset: int) -> int:
+    """
+    The position of an offset in an IRIS journal file.
+
+    Raises:
+        JournalUnavailable: file_name is not a YYYYMMDD.NNN journal file
+    """
+    match = _JOURNAL_FILE.search(file_name or "")
+    if not match:
+        raise JournalUnavailable(f"not a journal file: {file_name!r}")
+    year, month, day, sequence = (int(part) for part in match.groups())
+    days = (datetime.date(year, month, day) - _EPOCH).days
+    return ((days * 1000 + sequence) << 32) | int(offset)
+
+
+def journal_location(lsn: int) -> tuple[str, int] | None:
+    """(journal file name, offset) of a position; None for a clock position."""
+    file_index = lsn >> 32
+    if file_index < _FIRST_JOURNAL_FILE:
+        return None
+    date = _EPOCH + datetime.timedelta(days=file_index // 1000)
+    return f"{date:%Y%m%d}.{file_index % 1000:03d}", lsn & 0xFFFFFFFF
+
+
+async def current_position(iris_executor) -> int:
+    """The current journal position, or the clock when the journal cannot be read."""
+    try:
+        return journal_lsn(*await iris_executor.journal_position())
+    except Exception as e:
+        logger.debug("Journal position unavailable, using the clock", error=str(e))
+        return current_lsn()
+
+
+@dataclass
+class ReplicationSlot:
+    name: str
+    slot_type: str  # logical or physical
+    plugin: str | None = None
+    database: str | None = None
+    temporary: bool = False
+    restart_lsn: int = 0  # Where a resumed stream reads the journal from
+    confirmed_flush_lsn: int = 0  # Last position the subscriber flushed
+
+    @property
+    def logical(self) -> bool:
+        return self.slot_type == LOGICAL_SLOT
+
+    def resume_lsn(self, requested: int) -> int:
+        """Where START_REPLICATION at requested continues: never before the confirmed position."""
+        return max(requested, self.confirmed_flush_lsn)
+
+    def confirm(self, flush_lsn: int) -> bool:
+        """Advance to a flushed position; False if it is not later than the slot's."""
+        if flush_lsn <= self.confirmed_flush_lsn:
+            return False
+        self.confirmed_flush_lsn = flush_lsn
+        self.restart_lsn = max(self.restart_lsn, flush_lsn)
+        return True
+
+
+def undefined_slot(name: str) -> SlotError:
+    return SlotError(f'replication slot "{name}" does not exist', "42704", "undefined_object")
+
+
+def duplicate_slot(name: str) -> SlotError:
+    return SlotError(f'replication slot "{name}" already exists', "42710", "duplicate_object")
+
+
+class SlotStore:
+    """Persistent slots in the IRIS global, through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv(
+            "PGWIRE_REPLICATION_SLOTS_GLOBAL", DEFAULT_SLOTS_GLOBAL
+        )
+
+    def get(self, name: str) -> ReplicationSlot | None:
+        if not self.accessor.data(self.global_name, [name]):
+            return None
+        definition = json.loads(self.accessor.get(self.global_name, [name]))
+        definition.pop("journal", None)
+        return ReplicationSlot(name, **definition)
+
+    def _put(self, slot: ReplicationSlot) -> None:
+        definition = asdict(slot)
+        del definition["name"], definition["temporary"]
+        # The journal record a resumed stream reads from, for operators
+        definition["journal"] = journal_location(slot.restart_lsn)
+        self.accessor.set(self.global_name, [slot.name], json.dumps(definition))
+
+    def create(self, slot: ReplicationSlot) -> None:
+        """
+        Raises:
+            SlotError: a slot of that name exists (42710)
+        """
+        if self.get(slot.name) is not None:
+            raise duplicate_slot(slot.name)
+        self._put(slot)
+
+    def drop(self, name: str) -> None:
+        """
+        Raises:
+            SlotError: no slot of that name exists (42704)
+        """
+        if self.get(name) is None:
+            raise undefined_slot(name)
+        self.accessor.kill(self.global_name, [name])
+
+    def confirm(self, name: str, flush_lsn: int) -> bool:
+        """
+        Record a flushed position in one IRIS transaction; False if the slot is
+        gone or already at or past it.
+        """
+        self.accessor.tstart()
+        try:
+            slot = self.get(name)
+            advanced = slot is not None and slot.confirm(flush_lsn)
+            if advanced:
+                self._put(slot)
+            self.accessor.tcommit()
+        except Exception:
+            self.accessor.trollback()
+            raise
+        return advanced
+
+    def all(self) -> list[ReplicationSlot]:
+        slots = []
+        name = self.accessor.next_subscript(self.global_name, [], "")
+        while name is not None:
+            slots.append(self.get(name))
+            name = self.accessor.next_subscript(self.global_name, [], name)
+        return slots
+
+
+class ActiveSlots:
+    """Slots in use by this bridge process: temporary slots and streaming connections."""
+
+    def __init__(self):
+        self.temporary: dict[str, ReplicationSlot] = {}
+        self._owners: dict[str, int] = {}  # Slot name -> backend PID using it
+
+    def owner(self, name: str) -> int | None:
+        return self._owners.get(name)
+
+    def create_temporary(self, slot: ReplicationSlot, pid: int) -> None:
+        """
+        Raises:
+            SlotError: a temporary slot of that name exists (42710)
+        """
+        if slot.name in self.temporary:
+            raise duplicate_slot(slot.name)
+        self.temporary[slot.name] = slot
+        self._owners[slot.name] = pid
+
+    def check_available(self, name: str, pid: int) -> None:
+        """
+        Raises:
+            SlotError: another connection uses the slot (55006)
+        """
+        owner = self._owners.get(name)
+        if owner is not None and owner != pid:
+            raise SlotError(
+                f'replication slot "{name}" is active for PID {owner}',
+                "55006",
+                "object_in_use",
+            )
+
+    def acquire(self, name: str, pid: int) -> None:
+        """
+        Raises:
+            SlotError: another connection uses the slot (55006)
+        """
+        self.check_available(name, pid)
+        self._owners[name] = pid
+
+    def release(self, name: str, pid: int) -> None:
+        """End a stream's use of a slot (temporary slots stay with their session)."""
+        if name not in self.temporary and self._owners.get(name) == pid:
+            del self._owners[name]
+
+    def drop_temporary(self, name: str) -> bool:
+        self._owners.pop(name, None)
+        return self.temporary.pop(name, None) is not None
+
+    def session_ended(self, pid: int) -> None:
+        """Release a session's slots and drop its temporary ones."""
+        for name, owner in list(self._owners.items()):
+            if owner == pid:
+                self._owners.pop(name)
+                self.temporary.pop(name, None)
+
+
+_active_slots = ActiveSlots()
+
+
+def get_active_slots() -> ActiveSlots:
+    """Process-wide slots in use."""
+    return _active_slots
+
+
+def create_slot_result(slot: ReplicationSlot, format_position) -> dict:
+    """CREATE_REPLICATION_SLOT result: the slot, its consistent point and plugin."""
+    columns = ["slot_name", "consistent_point", "snapshot_name", "output_plugin"]
+    return {
+        "success": True,
+        "rows": [[slot.name, format_position(slot.confirmed_flush_lsn), None, slot.plugin]],
+        "columns": [
+            {"name": name, "type_oid": 25, "type_size": -1, "type_modifier": -1, "format_code": 0}
+            for name in columns
+        ],
+        "row_count": 1,
+        "command": "CREATE_REPLICATION_SLOT",
+    }
+
+
+def read_slot_result(slot: ReplicationSlot | None, format_position, timeline: int) -> dict:
+    """READ_REPLICATION_SLOT result (NULLs for a slot that does not exist)."""
+    row = [None, None, None]
+    if slot is not None:
+        row = [slot.slot_type, format_position(slot.restart_lsn), timeline]
+    return {
+        "success": True,
+        "rows": [row],
+        "columns": [
+            {"name": name, "type_oid": type_oid, "type_size": size, "type_modifier": -1}
+            | {"format_code": 0}
+            for name, type_oid, size in (
+                ("slot_type", 25, -1),
+                ("restart_lsn", 25, -1),
+                ("restart_tli", 20, 8),
+            )
+        ],
+        "row_count": 1,
+        "command": "READ_REPLICATION_SLOT",
+    }
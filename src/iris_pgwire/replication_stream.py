@@ -0,0 +1,337 @@
+"""
+Replication Streams (START_REPLICATION)
+
+START_REPLICATION on a replication connection (replication.py) switches the
+connection to CopyBoth mode, as in PostgreSQL. Both directions then exchange
+CopyData messages:
+
+- bridge → subscriber: primary keepalive ('k') with the current position,
+  the server clock and whether a reply is requested
+- subscriber → bridge: standby status update ('r') with the positions it has
+  written, flushed and applied; hot standby feedback ('h') is accepted and
+  ignored
+
+Subscribers like Debezium treat a silent stream as a dead connection, so
+while the stream is quiet the bridge sends a keepalive every
+PGWIRE_REPLICATION_KEEPALIVE_INTERVAL (default 10s). Standby status updates
+are recorded in the connection's WalSenderProgress (pg_stat_replication) and
+answered at once when the subscriber asks for a reply. Like PostgreSQL's
+wal_sender_timeout, PGWIRE_WAL_SENDER_TIMEOUT (default 60s, 0 disables)
+bounds how long a subscriber may stay silent: after half of it the
+keepalives request a reply, and after all of it the connection is closed.
+
+CopyDone from the subscriber ends the stream: the bridge answers CopyDone and
+completes START_REPLICATION, and the connection accepts commands again.
+Changes are not captured yet, so the stream carries keepalives only; each
+reports the current position as sent, since nothing before it is pending.
+Positions never go backwards within a stream. On a slot, the subscriber's
+flushed positions are recorded in the slot (replication_slots.py) as they
+advance.
+"""
+
+import asyncio
+import os
+import re
+import struct
+import time
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass, field
+
+import structlog
+
+from .keepalive import parse_duration_ms
+from .message_framing import ProtocolViolation
+from .replication import WalSenderProgress, current_lsn
+
+logger = structlog.get_logger()
+
+DEFAULT_KEEPALIVE_INTERVAL_MS = 10_000
+DEFAULT_WAL_SENDER_TIMEOUT_MS = 60_000
+
+# PostgreSQL timestamps count microseconds from 2000-01-01
+_PG_EPOCH = 946_684_800
+
+_START_REPLICATION = re.compile(
+    r"^\s*START_REPLICATION\s+"
+    r"(?:SLOT\s+(?P<slot>\"[^\"]+\"|[\w$]+)\s+)?"
+    r"(?:(?P<kind>LOGICAL|PHYSICAL)\s+)?"
+    r"(?P<lsn>[0-9A-F]+/[0-9A-F]+)"
+    r"(?:\s+TIMELINE\s+(?P<timeline>\d+))?"
+    r"\s*(?:\((?P<options>.*)\))?\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_OPTION = re.compile(r"\s*(\"[^\"]+\"|[\w$]+)(?:\s+('(?:[^']|'')*'|[^,\s]+))?\s*(?:,|$)")
+
+
+class InvalidStartReplication(ValueError):
+    """START_REPLICATION that cannot be parsed (SQLSTATE 42601)."""
+
+    sqlstate = "42601"
+    condition_name = "syntax_error"
+
+
+class WalSenderTimeout(Exception):
+    """Subscriber silent for longer than PGWIRE_WAL_SENDER_TIMEOUT."""
+
+    sqlstate = "08006"
+    condition_name = "connection_failure"
+
+    def __init__(self):
+        super().__init__("terminating walsender process due to replication timeout")
+
+
+class ClientTerminated(Exception):
+    """The subscriber sent Terminate during the stream."""
+
+
+@dataclass
+class StartReplication:
+    """A parsed START_REPLICATION command."""
+
+    slot_name: str | None
+    logical: bool
+    start_lsn: int
+    timeline: int | None = None
+    options: dict[str, str | None] = field(default_factory=dict)
+
+
+def parse_lsn(text: str) -> int:
+    """Position from PostgreSQL's text form, e.g. 16/B374D848."""
+    high, low = text.split("/")
+    return (int(high, 16) << 32) | int(low, 16)
+
+
+def _unquote(text: str) -> str:
+    if text.startswith('"'):
+        return text[1:-1]
+    if text.startswith("'"):
+        return text[1:-1].replace("''", "'")
+    return text.lower()
+
+
+def parse_start_replication(sql: str) -> StartReplication:
+    """
+    Parse START_REPLICATION [SLOT s] [LOGICAL|PHYSICAL] X/X [TIMELINE t] [(options)].
+
+    Raises:
+        InvalidStartReplication: malformed command, or LOGICAL without a slot
+    """
+    match = _START_REPLICATION.match(sql)
+    if not match:
+        raise InvalidStartReplication(f"syntax error in replication command: {sql.strip()}")
+    logical = (match.group("kind") or "").upper() == "LOGICAL"
+    slot = match.group("slot")
+    if logical and slot is None:
+        raise InvalidStartReplication("logical replication requires a replication slot")
+
+    options: dict[str, str | None] = {}
+    text = match.group("options") or ""
+    position = 0
+    while text[position:].strip():
+        option = _OPTION.match(text, position)
+        if not option or option.end() == position:
+            raise InvalidStartReplication(f"invalid replication options: {text.strip()}")
+        value = option.group(2)
+        options[_unquote(option.group(1))] = _unquote(value) if value is not None else None
+        position = option.end()
+
+    timeline = match.group("timeline")
+    return StartReplication(
+        slot_name=_unquote(slot) if slot else None,
+        logical=logical,
+        start_lsn=parse_lsn(match.group("lsn")),
+        timeline=int(timeline) if timeline else None,
+        options=options,
+    )
+
+
+def pg_timestamp(epoch: float | None = None) -> int:
+    """PostgreSQL timestamp (microseconds since 2000-01-01) of an epoch time."""
+    return round(((time.time() if epoch is None else epoch) - _PG_EPOCH) * 1_000_000)
+
+
+def copy_data(payload: bytes) -> bytes:
+    return b"d" + struct.pack("!I", len(payload) + 4) + payload
+
+
+def keepalive_message(wal_end: int, reply_requested: bool, epoch: float | None = None) -> bytes:
+    """Primary keepalive message, wrapped in CopyData."""
+    return copy_data(
+        b"k" + struct.pack("!QqB", wal_end, pg_timestamp(epoch), 1 if reply_requested else 0)
+    )
+
+
+def copy_both_response() -> bytes:
+    """CopyBothResponse: text format, no columns (as PostgreSQL's walsender sends it)."""
+    return b"W" + struct.pack("!IbH", 7, 0, 0)
+
+
+def copy_done() -> bytes:
+    return b"c" + struct.pack("!I", 4)
+
+
+@dataclass
+class StandbyStatus:
+    """Standby status update: positions the subscriber has written/flushed/applied."""
+
+    write_lsn: int
+    flush_lsn: int
+    apply_lsn: int
+    client_time: int  # PostgreSQL timestamp
+    reply_requested: bool
+
+
+def parse_standby_status(payload: bytes) -> StandbyStatus:
+    """
+    Decode the body of a standby status update ('r' CopyData payload).
+
+    Raises:
+        ProtocolViolation: truncated message
+    """
+    if len(payload) < 34:
+        raise ProtocolViolation("invalid standby status update: message too short")
+    write, flush, apply, client_time, reply = struct.unpack("!QQQqB", payload[1:34])
+    return StandbyStatus(write, flush, apply, client_time, bool(reply))
+
+
+def _duration_seconds(name: str, default_ms: int) -> float:
+    value = os.getenv(name)
+    if value is None:
+        return default_ms / 1000
+    try:
+        return parse_duration_ms(value) / 1000
+    except ValueError:
+        logger.warning(f"Ignoring invalid {name}", value=value)
+        return default_ms / 1000
+
+
+def load_keepalive_interval() -> float:
+    """PGWIRE_REPLICATION_KEEPALIVE_INTERVAL in seconds."""
+    interval = _duration_seconds(
+        "PGWIRE_REPLICATION_KEEPALIVE_INTERVAL", DEFAULT_KEEPALIVE_INTERVAL_MS
+    )
+    return interval or DEFAULT_KEEPALIVE_INTERVAL_MS / 1000
+
+
+def load_wal_sender_timeout() -> float:
+    """PGWIRE_WAL_SENDER_TIMEOUT in seconds (0 = no timeout)."""
+    return _duration_seconds("PGWIRE_WAL_SENDER_TIMEOUT", DEFAULT_WAL_SENDER_TIMEOUT_MS)
+
+
+class ReplicationStream:
+    """
+    One START_REPLICATION stream in CopyBoth mode.
+
+    Args:
+        progress: the connection's WalSenderProgress
+        read_message: reads the next frontend message as (type, body)
+        write: sends bytes to the subscriber
+        position: returns the current position (default: the clock, current_lsn)
+        on_confirmed: called with each flushed position the subscriber advances to
+    """
+
+    def __init__(
+        self,
+        progress: WalSenderProgress,
+        read_message: Callable[[], Awaitable[tuple[bytes, bytes]]],
+        write: Callable[[bytes], Awaitable[None]],
+        keepalive_interval: float | None = None,
+        timeout: float | None = None,
+        position: Callable[[], Awaitable[int]] | None = None,
+        on_confirmed: Callable[[int], Awaitable[None]] | None = None,
+    ):
+        self.progress = progress
+        self.read_message = read_message
+        self.write = write
+        self.keepalive_interval = (
+            load_keepalive_interval() if keepalive_interval is None else keepalive_interval
+        )
+        self.timeout = load_wal_sender_timeout() if timeout is None else timeout
+        self.position = position
+        self.on_confirmed = on_confirmed
+        self.last_reply = time.monotonic()
+        self.reply_requested = False
+
+    async def run(self, start: StartReplication) -> None:
+        """
+        Stream until the subscriber sends CopyDone.
+
+        Raises:
+            ClientTerminated: the subscriber sent Terminate
+            WalSenderTimeout: no message from the subscriber within the timeout
+        """
+        self.progress.start_streaming(
+            start.slot_name, "pgoutput" if "publication_names" in start.options else None
+        )
+        self.progress.sent(max(start.start_lsn, await self._current_position()))
+        await self.write(copy_both_response())
+        pending = asyncio.ensure_future(self.read_message())
+        try:
+            while True:
+                done, _ = await asyncio.wait({pending}, timeout=self._wait())
+                if not done:
+                    await self._idle()
+                    continue
+                message_type, body = pending.result()
+                self.last_reply = time.monotonic()
+                self.reply_requested = False
+                if message_type == b"c":
+                    await self.write(copy_done())
+                    return
+                if message_type == b"X":
+                    raise ClientTerminated()
+                if message_type == b"d":
+                    await self._copy_data(body)
+                elif message_type != b"f":
+                    raise ProtocolViolation(
+                        f"unexpected message type {message_type!r} during replication"
+                    )
+                pending = asyncio.ensure_future(self.read_message())
+        finally:
+            if not pending.done():
+                pending.cancel()
+            self.progress.stop_streaming()
+
+    def _wait(self) -> float:
+        """Seconds until the next keepalive (or timeout check) is due."""
+        wait = self.keepalive_interval
+        if self.timeout:
+            silent = time.monotonic() - self.last_reply
+            deadline = self.timeout if self.reply_requested else self.timeout / 2
+            wait = min(wait, deadline - silent)
+        return max(wait, 0)
+
+    async def _idle(self) -> None:
+        silent = time.monotonic() - self.last_reply
+        if self.timeout and silent >= self.timeout:
+            logger.warning(
+                "Replication subscriber timed out",
+                slot=self.progress.slot_name,
+                silent_s=round(silent, 1),
+            )
+            raise WalSenderTimeout()
+        if self.timeout and silent >= self.timeout / 2:
+            self.reply_requested = True
+        await self._keepalive(self.reply_requested)
+
+    async def _current_position(self) -> int:
+        if self.position is None:
+            return current_lsn()
+        return await self.position()
+
+    async def _keepalive(self, reply_requested: bool) -> None:
+        position = max(await self._current_position(), self.progress.sent_lsn or 0)
+        self.progress.sent(position)
+        await self.write(keepalive_message(position, reply_requested))
+
+    async def _copy_data(self, payload: bytes) -> None:
+        if payload[:1] == b"r":
+            status = parse_standby_status(payload)
+            flushed = self.progress.flush_lsn or 0
+            self.progress.confirmed(status.write_lsn, status.flush_lsn, status.apply_lsn)
+            if self.on_confirmed is not None and status.flush_lsn > flushed:
+                await self.on_confirmed(status.flush_lsn)
+            if status.reply_requested:
+                await self._keepalive(False)
+        elif payload[:1] != b"h":
+            raise ProtocolViolation(f"unexpected replication message type {payload[:1]!r}")
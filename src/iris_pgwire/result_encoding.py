@@ -0,0 +1,147 @@
+"""
+Binary Result Columns
+
+Bind's result-format codes ask for result columns in text (0) or binary (1).
+pgx, Npgsql and asyncpg ask for binary wherever they know a type's binary
+form, and decode every column by the format code of its RowDescription
+field, so the field and the DataRow must agree.
+
+A column is sent in binary when the client asked for it and its type has a
+binary send form here:
+
+- int2, int4, int8, oid, float4, float8, bool and numeric (the base-10000
+  digits of copy_binary.encode_numeric, exact for any precision, with the
+  column's declared scale as dscale)
+- date (days since 2000-01-01; the executor's day counts, ISO or $HOROLOG
+  text), time, timetz and interval (temporal_values.py), timestamp and
+  timestamptz (microseconds since 2000-01-01 UTC; stored datetimes, ISO text
+  or %PosixTime integers)
+- text, varchar, bpchar, name, json and jsonb, and bytea as the raw bytes
+- uuid as its 16 bytes
+- pgvector's vector as its dimensions and float4 elements (vector_values.py)
+- one-dimensional bool, int2, int4, int8, float4, float8, numeric, text and
+  varchar arrays (array_values.py)
+
+Other types are sent in text with format code 0 in their RowDescription
+field, which the drivers above read as text (a client that executes without
+a portal Describe chose its format codes from the statement's described
+types, and asks for binary only where it knows the type's form). A value that cannot be read as
+its column's type (an integer column holding 'abc') fails the statement with
+22P02, as a cast would, rather than being sent as text under a binary format
+code; integers out of their type's range fail with 22003 (numeric_range.py).
+"""
+
+import struct
+from decimal import InvalidOperation
+
+from .array_values import ARRAY_TYPES, encode_array
+from .copy_binary import encode_numeric, encode_value
+from .numeric_range import NumericValueOutOfRange, check_integer_range, is_integer_type
+from .temporal_values import TEMPORAL_TYPES, encode_temporal
+from .timezone_support import (
+    timestamp_to_pg_microseconds,
+    timestamptz_to_pg_microseconds,
+)
+from .type_mapping import TypeModifier
+from .vector_values import VECTOR_OID, encode_vector
+
+# Type OID -> name, of the types with a binary send form
+BINARY_RESULT_TYPES: dict[int, str] = {
+    16: "boolean",
+    17: "bytea",
+    19: "name",
+    20: "bigint",
+    21: "smallint",
+    23: "integer",
+    25: "text",
+    26: "oid",
+    114: "json",
+    700: "real",
+    701: "double precision",
+    1042: "character",
+    1043: "character varying",
+    1082: "date",
+    1083: "time without time zone",
+    1114: "timestamp without time zone",
+    1184: "timestamp with time zone",
+    1186: "interval",
+    1266: "time with time zone",
+    1700: "numeric",
+    2950: "uuid",
+    3802: "jsonb",
+    16388: "vector",
+    1000: "boolean[]",
+    1005: "smallint[]",
+    1007: "integer[]",
+    1009: "text[]",
+    1015: "character varying[]",
+    1016: "bigint[]",
+    1021: "real[]",
+    1022: "double precision[]",
+    1231: "numeric[]",
+}
+
+
+class InvalidResultValue(ValueError):
+    """A result value that is not valid for its column type (SQLSTATE 22P02)."""
+
+    sqlstate = "22P02"
+    condition_name = "invalid_text_representation"
+
+
+def result_format(result_formats: list[int] | None, index: int, type_oid: int) -> int:
+    """
+    Format code of result column index: Bind's code for it (one code applies
+    to every column), binary only for types in BINARY_RESULT_TYPES.
+    """
+    if not result_formats:
+        return 0
+    if len(result_formats) == 1:
+        requested = result_formats[0]
+    elif index < len(result_formats):
+        requested = result_formats[index]
+    else:
+        requested = 0
+    return 1 if requested == 1 and type_oid in BINARY_RESULT_TYPES else 0
+
+
+def numeric_scale(type_modifier: int) -> int | None:
+    """Declared scale of a numeric column's typmod, or None for unconstrained numeric."""
+    precision_scale = TypeModifier.decode_numeric_precision(type_modifier)
+    return precision_scale[1] if precision_scale else None
+
+
+def encode_result_value(value, type_oid: int, type_modifier: int = -1) -> bytes:
+    """
+    Binary form of a non-NULL result value of a column of type type_oid
+    (type_modifier: its RowDescription typmod).
+
+    Raises:
+        NumericValueOutOfRange: an integer outside its type's range
+        InvalidResultValue: the value cannot be read as the type
+    """
+    try:
+        if is_integer_type(type_oid):
+            return encode_value(check_integer_range(int(value), type_oid), type_oid)
+        if type_oid == 26:
+            return struct.pack("!I", int(value))
+        if type_oid in TEMPORAL_TYPES:
+            return encode_temporal(value, type_oid)
+        if type_oid == 1114:
+            return struct.pack("!q", timestamp_to_pg_microseconds(value))
+        if type_oid == 1184:
+            return struct.pack("!q", timestamptz_to_pg_microseconds(value))
+        if type_oid == 17 and isinstance(value, memoryview):
+            return value.tobytes()
+        if type_oid == 1700:
+            return encode_numeric(value, numeric_scale(type_modifier))
+        if type_oid in ARRAY_TYPES:
+            return encode_array(value, type_oid)
+        if type_oid == VECTOR_OID:
+            return encode_vector(value)
+        return encode_value(value, type_oid)
+    except NumericValueOutOfRange:
+        raise
+    except (ValueError, TypeError, OverflowError, InvalidOperation, struct.error):
+        type_name = BINARY_RESULT_TYPES.get(type_oid, str(type_oid))
+        raise InvalidResultValue(f'invalid input syntax for type {type_name}: "{value}"') from None
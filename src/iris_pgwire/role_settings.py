@@ -0,0 +1,360 @@
+"""
+Per-Role and Per-Database Session Defaults
+
+The bridge's equivalent of ALTER ROLE ... SET / ALTER DATABASE ... SET: a
+YAML (or JSON) file named by PGWIRE_ROLE_SETTINGS_FILE lists default runtime
+parameters and initialization statements applied when a session starts:
+
+    - role: bi_reader
+      settings:
+        default_transaction_read_only: on
+        search_path: reporting, public
+    - role: bi_reader
+      database: USER
+      init_sql:
+        - SET iris.workload = dashboard
+    - database: ANALYTICS           # every role connecting to ANALYTICS
+      settings:
+        statement_timeout: 30000
+
+An entry without role/database applies to every role/database. When several
+entries set the same parameter, the most specific wins, in PostgreSQL's order:
+role + database, then role, then database, then global. Parameters sent in
+the StartupMessage override all of them, and RESET returns to the role default.
+
+init_sql statements run after authentication, before the first
+ReadyForQuery, with the session's own IRIS credentials, from the least to the
+most specific entry. SET/RESET statements update the session settings; any
+other statement is executed on IRIS. A failing statement terminates the
+session, so a misconfigured reporting role never falls back to unrestricted
+defaults. Role and database names are compared case-insensitively (IRIS
+usernames and namespaces are).
+
+Administrators can also manage the file with PostgreSQL's commands:
+
+    ALTER ROLE bi_reader SET default_transaction_read_only = on
+    ALTER ROLE bi_reader IN DATABASE analytics SET search_path TO reporting
+    ALTER ROLE ALL SET statement_timeout = 60000     -- global entry
+    ALTER DATABASE analytics RESET ALL
+    ALTER USER CURRENT_USER SET TimeZone FROM CURRENT
+
+The change is written back to PGWIRE_ROLE_SETTINGS_FILE (created if missing,
+comments are not preserved) and applies to sessions started afterwards, as
+in PostgreSQL. Only users listed in PGWIRE_ROLE_SETTINGS_ADMINS may run them;
+everyone else gets SQLSTATE 42501.
+"""
+
+import copy
+import os
+import re
+import tempfile
+from dataclasses import dataclass, field
+from pathlib import Path
+
+import structlog
+import yaml
+
+from .session_settings import InvalidParameterValue, SessionSettings, strip_setting_value
+
+logger = structlog.get_logger()
+
+
+class RoleInitFailed(Exception):
+    """An init_sql statement failed during session start."""
+
+    sqlstate = "08006"
+    condition_name = "connection_failure"
+
+
+class RoleSettingsNotPersistent(Exception):
+    """ALTER ... SET without PGWIRE_ROLE_SETTINGS_FILE (SQLSTATE 55000)."""
+
+    sqlstate = "55000"
+    condition_name = "object_not_in_prerequisite_state"
+
+
+def _setting_value(value) -> str:
+    """YAML scalars as GUC strings (on/off booleans, numbers, lists)."""
+    if isinstance(value, bool):
+        return "on" if value else "off"
+    if isinstance(value, list | tuple):
+        return ", ".join(str(item) for item in value)
+    return str(value)
+
+
+@dataclass
+class RoleSettingsEntry:
+    """Defaults for one role/database combination (None matches any)."""
+
+    role: str | None = None
+    database: str | None = None
+    settings: dict[str, str] = field(default_factory=dict)
+    init_sql: list[str] = field(default_factory=list)
+
+    @property
+    def specificity(self) -> int:
+        """3 = role + database, 2 = role, 1 = database, 0 = global."""
+        return (2 if self.role is not None else 0) + (1 if self.database is not None else 0)
+
+    def matches(self, role: str | None, database: str | None) -> bool:
+        return _same_name(self.role, role) and _same_name(self.database, database)
+
+
+def _same_name(configured: str | None, actual: str | None) -> bool:
+    if configured is None:
+        return True
+    return actual is not None and configured.casefold() == actual.casefold()
+
+
+class RoleSettings:
+    """Configured role/database defaults."""
+
+    def __init__(
+        self, entries: list[RoleSettingsEntry] | None = None, path: str | Path | None = None
+    ):
+        self.entries = list(entries or [])
+        self.path = Path(path) if path is not None else None
+
+    @classmethod
+    def from_file(cls, path: str | Path) -> "RoleSettings":
+        """
+        Load entries from a YAML/JSON file.
+
+        Raises:
+            ValueError: malformed entry or invalid parameter value
+        """
+        with open(path, encoding="utf-8") as f:
+            data = yaml.safe_load(f) or []
+        if not isinstance(data, list):
+            raise ValueError(f"{path}: expected a list of role settings entries")
+        entries = [cls._parse_entry(path, index, raw) for index, raw in enumerate(data)]
+        return cls(entries, path)
+
+    @classmethod
+    def from_env(cls) -> "RoleSettings":
+        path = os.getenv("PGWIRE_ROLE_SETTINGS_FILE")
+        if not path:
+            return cls()
+        if not os.path.exists(path):
+            # Created by the first ALTER ROLE/DATABASE ... SET
+            return cls(path=path)
+        role_settings = cls.from_file(path)
+        logger.info("Role settings loaded", path=path, entries=len(role_settings.entries))
+        return role_settings
+
+    @staticmethod
+    def _parse_entry(path, index: int, raw) -> RoleSettingsEntry:
+        where = f"{path}: entry {index + 1}"
+        if not isinstance(raw, dict):
+            raise ValueError(f"{where}: expected a mapping")
+        unknown = set(raw) - {"role", "database", "settings", "init_sql"}
+        if unknown:
+            raise ValueError(f"{where}: unknown keys {sorted(unknown)}")
+
+        settings = {}
+        validator = SessionSettings()
+        for name, value in (raw.get("settings") or {}).items():
+            try:
+                settings[name] = validator.validate(name, _setting_value(value))
+            except InvalidParameterValue as e:
+                raise ValueError(f"{where}: {e}") from e
+
+        init_sql = raw.get("init_sql") or []
+        if isinstance(init_sql, str):
+            init_sql = [init_sql]
+
+        role = raw.get("role")
+        database = raw.get("database")
+        return RoleSettingsEntry(
+            role=str(role) if role is not None else None,
+            database=str(database) if database is not None else None,
+            settings=settings,
+            init_sql=[str(statement) for statement in init_sql],
+        )
+
+    def resolve(self, role: str | None, database: str | None) -> tuple[dict[str, str], list[str]]:
+        """
+        Defaults and init statements for a session.
+
+        Returns:
+            (parameter name → value, init statements in execution order)
+        """
+        matching = sorted(
+            (entry for entry in self.entries if entry.matches(role, database)),
+            key=lambda entry: entry.specificity,
+        )
+        settings: dict[str, str] = {}
+        init_sql: list[str] = []
+        for entry in matching:
+            # Later (more specific) entries override earlier ones
+            for name, value in entry.settings.items():
+                settings[name.lower()] = value
+            init_sql.extend(entry.init_sql)
+        return settings, init_sql
+
+    def alter(
+        self, role: str | None, database: str | None, name: str | None, value: str | None
+    ) -> None:
+        """
+        ALTER ROLE/DATABASE ... SET (value) or RESET (value None; name None = ALL).
+
+        Raises:
+            RoleSettingsNotPersistent: no PGWIRE_ROLE_SETTINGS_FILE to store the change
+            InvalidParameterValue: value outside the parameter's domain
+        """
+        if self.path is None:
+            raise RoleSettingsNotPersistent(
+                "cannot store role settings: PGWIRE_ROLE_SETTINGS_FILE is not set"
+            )
+        if value is not None:
+            value = SessionSettings().validate(name, value)
+
+        previous = copy.deepcopy(self.entries)
+        entry = next(
+            (
+                entry
+                for entry in self.entries
+                if _exact_name(entry.role, role) and _exact_name(entry.database, database)
+            ),
+            None,
+        )
+        if entry is None:
+            if value is None:
+                return
+            entry = RoleSettingsEntry(role=role, database=database)
+            self.entries.append(entry)
+
+        if name is None:
+            entry.settings.clear()
+        else:
+            for existing in [key for key in entry.settings if key.lower() == name.lower()]:
+                del entry.settings[existing]
+            if value is not None:
+                entry.settings[name.lower()] = value
+        if not entry.settings and not entry.init_sql:
+            self.entries.remove(entry)
+
+        try:
+            self.save()
+        except OSError:
+            # Keep memory and file in agreement
+            self.entries = previous
+            raise
+        logger.info(
+            "Role settings changed", role=role, database=database, parameter=name, value=value
+        )
+
+    def save(self) -> None:
+        """Write the entries back to the settings file (atomically)."""
+        data = []
+        for entry in self.entries:
+            raw = {}
+            if entry.role is not None:
+                raw["role"] = entry.role
+            if entry.database is not None:
+                raw["database"] = entry.database
+            if entry.settings:
+                raw["settings"] = dict(entry.settings)
+            if entry.init_sql:
+                raw["init_sql"] = list(entry.init_sql)
+            data.append(raw)
+
+        directory = self.path.parent
+        directory.mkdir(parents=True, exist_ok=True)
+        with tempfile.NamedTemporaryFile(
+            "w", encoding="utf-8", dir=directory, prefix=".role-settings-", delete=False
+        ) as f:
+            yaml.safe_dump(data, f, sort_keys=False, default_flow_style=False)
+        os.replace(f.name, self.path)
+
+
+def _exact_name(configured: str | None, name: str | None) -> bool:
+    if configured is None or name is None:
+        return configured is name
+    return configured.casefold() == name.casefold()
+
+
+@dataclass
+class AlterSetting:
+    """Parsed ALTER ROLE/DATABASE ... SET/RESET (role/database None = ALL/any)."""
+
+    command: str  # "ALTER ROLE" or "ALTER DATABASE" (the CommandComplete tag)
+    role: str | None
+    database: str | None
+    parameter: str | None  # None = RESET ALL
+    value: str | None = None  # None = RESET / SET ... TO DEFAULT
+    from_current: bool = False
+
+
+_NAME = r'(?:"(?:[^"]|"")+"|[\w$]+)'
+_ALTER_ROLE = re.compile(
+    rf"^ALTER\s+(?:ROLE|USER)\s+(?P<role>{_NAME})"
+    rf"(?:\s+IN\s+DATABASE\s+(?P<database>{_NAME}))?\s+(?P<action>(?:SET|RESET)\b.*)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_ALTER_DATABASE = re.compile(
+    rf"^ALTER\s+DATABASE\s+(?P<database>{_NAME})\s+(?P<action>(?:SET|RESET)\b.*)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_SET_ACTION = re.compile(
+    r"^SET\s+(?P<name>[\w.]+)\s*(?:=|\s+TO\s+)\s*(?P<value>.+)$", re.IGNORECASE | re.DOTALL
+)
+_SET_FROM_CURRENT = re.compile(r"^SET\s+(?P<name>[\w.]+)\s+FROM\s+CURRENT$", re.IGNORECASE)
+_RESET_ACTION = re.compile(r"^RESET\s+(?P<name>ALL|[\w.]+)$", re.IGNORECASE)
+
+
+def _identifier(name: str) -> str:
+    if name.startswith('"'):
+        return name[1:-1].replace('""', '"')
+    return name
+
+
+def parse_alter_setting(sql: str) -> AlterSetting | None:
+    """Parse ALTER ROLE/USER/DATABASE ... SET/RESET (None for any other statement)."""
+    statement = sql.strip().rstrip(";").strip()
+    match = _ALTER_ROLE.match(statement)
+    if match:
+        command = "ALTER ROLE"
+        raw_role = match.group("role")
+        role = None if raw_role.upper() == "ALL" else _identifier(raw_role)
+    else:
+        match = _ALTER_DATABASE.match(statement)
+        if not match:
+            return None
+        command = "ALTER DATABASE"
+        role = None
+    database = _identifier(match.group("database")) if match.group("database") else None
+    action = match.group("action").strip()
+
+    alter = AlterSetting(command, role, database, parameter=None)
+    if set_match := _SET_FROM_CURRENT.match(action):
+        alter.parameter = set_match.group("name")
+        alter.from_current = True
+    elif set_match := _SET_ACTION.match(action):
+        alter.parameter = set_match.group("name")
+        value = set_match.group("value").strip()
+        if value.upper() != "DEFAULT":
+            alter.value = strip_setting_value(value)
+    elif reset_match := _RESET_ACTION.match(action):
+        name = reset_match.group("name")
+        alter.parameter = None if name.upper() == "ALL" else name
+    else:
+        return None
+    return alter
+
+
+def load_role_settings_admins() -> frozenset[str]:
+    """PGWIRE_ROLE_SETTINGS_ADMINS: users allowed to run ALTER ROLE/DATABASE ... SET."""
+    spec = os.getenv("PGWIRE_ROLE_SETTINGS_ADMINS", "")
+    return frozenset(user.strip().casefold() for user in spec.split(",") if user.strip())
+
+
+_role_settings: RoleSettings | None = None
+
+
+def get_role_settings() -> RoleSettings:
+    """Process-wide role settings, loaded from PGWIRE_ROLE_SETTINGS_FILE on first use."""
+    global _role_settings
+    if _role_settings is None:
+        _role_settings = RoleSettings.from_env()
+    return _role_settings
@@ -0,0 +1,391 @@
+"""
+SCRAM-SHA-256 Authentication
+
+Password authentication without sending the password (RFC 5802 / RFC 7677),
+as PostgreSQL runs it; libpq, pgJDBC, Npgsql and others can be set to refuse
+cleartext and MD5. On TLS connections the bridge also offers
+SCRAM-SHA-256-PLUS, which binds the exchange to the TLS channel
+(tls-server-end-point: a hash of the bridge's certificate), so a proxy
+terminating TLS in the middle cannot replay it.
+
+The server checks a SCRAM proof against a verifier (salt, iteration count,
+StoredKey and ServerKey) rather than the password. IRIS stores no SCRAM
+verifiers, so the bridge keeps one per user in an IRIS global
+(PGWIRE_SCRAM_GLOBAL, default ^PGWire.Scram), in PostgreSQL's format:
+
+    ^PGWire.Scram("alice") = "SCRAM-SHA-256$4096:<salt>$<StoredKey>:<ServerKey>"
+    ^PGWire.Scram("alice", "stamp") = "<SHA-256 of the IRIS password hash>"
+
+Verifiers come from the users' IRIS passwords. When a user without one
+connects, the bridge asks for the password once (cleartext password
+authentication), checks it by logging in to IRIS and stores the verifier
+derived from it; later logins use SCRAM. PGWIRE_SCRAM_ENROLL controls this:
+"tls" (default) enrolls only over TLS, "on" also on plain connections, "off"
+never (verifiers are provisioned in the global, e.g. copied from
+pg_authid.rolpassword). Listeners that also offer md5 store the user's MD5
+secret at the same time (md5_auth.py, auth_methods.py).
+
+An enrolled verifier is stored with a stamp of the IRIS password it came from
+(a hash of Security.Users' password hash). Once the password is changed in
+IRIS the stamps differ and the verifier is ignored, so the old password stops
+working and the user enrolls again with the new one. Provisioned verifiers
+have no stamp and are always used; if Security.Users cannot be read (the
+bridge's IRIS account needs read access in %SYS) stamps are not checked.
+
+Unknown users go through a complete exchange against a made-up verifier and
+fail like a wrong password, so the failure does not reveal whether the user
+exists.
+"""
+
+import base64
+import hashlib
+import hmac
+import os
+import secrets
+import ssl
+import unicodedata
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+SCRAM_SHA_256 = "SCRAM-SHA-256"
+SCRAM_SHA_256_PLUS = "SCRAM-SHA-256-PLUS"
+CHANNEL_BINDING_TYPE = "tls-server-end-point"
+DEFAULT_ITERATIONS = 4096
+DEFAULT_SCRAM_GLOBAL = "^PGWire.Scram"
+ENROLL_MODES = ("tls", "on", "off")
+STAMP_SUBSCRIPT = "stamp"
+
+# Salts of mock verifiers stay the same for a user while the process runs
+_MOCK_SECRET = secrets.token_bytes(32)
+
+# Characters SASLprep drops (RFC 3454 table B.1)
+_MAPPED_TO_NOTHING = "\u00ad\u034f\u1806\u180b\u180c\u180d\u200b\u200c\u200d\u2060\ufeff"
+
+# Certificate signature algorithms hashed with something stronger than
+# SHA-256 for tls-server-end-point (RFC 5929 section 4.1)
+_SIGNATURE_HASHES = {
+    bytes.fromhex("2a864886f70d01010c"): hashlib.sha384,  # sha384WithRSAEncryption
+    bytes.fromhex("2a864886f70d01010d"): hashlib.sha512,  # sha512WithRSAEncryption
+    bytes.fromhex("2a8648ce3d040303"): hashlib.sha384,  # ecdsa-with-SHA384
+    bytes.fromhex("2a8648ce3d040304"): hashlib.sha512,  # ecdsa-with-SHA512
+}
+
+
+class ScramError(Exception):
+    """A failed SCRAM exchange, reported to the client as FATAL."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+def authentication_failed(user: str) -> ScramError:
+    return ScramError(
+        f'password authentication failed for user "{user}"', "28P01", "invalid_password"
+    )
+
+
+def malformed(detail: str) -> ScramError:
+    return ScramError(f"malformed SCRAM message: {detail}", "08P01", "protocol_violation")
+
+
+def _b64(value: bytes) -> str:
+    return base64.b64encode(value).decode()
+
+
+def _hmac(key: bytes, message: bytes) -> bytes:
+    return hmac.new(key, message, hashlib.sha256).digest()
+
+
+def saslprep(password: str) -> str:
+    """
+    Normalize a password as PostgreSQL does (SASLprep, RFC 4013): non-ASCII
+    spaces become spaces, soft hyphens and the like are dropped, NFKC applies.
+    """
+    if password.isascii():
+        return password
+    mapped = "".join(
+        " " if unicodedata.category(char) == "Zs" else char
+        for char in password
+        if char not in _MAPPED_TO_NOTHING
+        and not "\ufe00" <= char <= "\ufe0f"  # Variation selectors
+    )
+    normalized = unicodedata.normalize("NFKC", mapped)
+    # Unassigned or control characters: use the password as given
+    if any(unicodedata.category(char) in ("Cc", "Cn", "Co", "Cs") for char in normalized):
+        return password
+    return normalized
+
+
+@dataclass(frozen=True)
+class ScramVerifier:
+    """What the server stores for a SCRAM-SHA-256 password."""
+
+    iterations: int
+    salt: bytes
+    stored_key: bytes
+    server_key: bytes
+
+    @classmethod
+    def from_password(
+        cls, password: str, salt: bytes | None = None, iterations: int = DEFAULT_ITERATIONS
+    ) -> "ScramVerifier":
+        salt = salt if salt is not None else secrets.token_bytes(16)
+        salted = hashlib.pbkdf2_hmac("sha256", saslprep(password).encode(), salt, iterations)
+        return cls(
+            iterations,
+            salt,
+            hashlib.sha256(_hmac(salted, b"Client Key")).digest(),
+            _hmac(salted, b"Server Key"),
+        )
+
+    @classmethod
+    def parse(cls, text: str) -> "ScramVerifier":
+        """
+        Raises:
+            ValueError: not a SCRAM-SHA-256 verifier
+        """
+        try:
+            mechanism, params, keys = text.split("$")
+            iterations, salt = params.split(":")
+            stored_key, server_key = keys.split(":")
+            verifier = cls(
+                int(iterations),
+                base64.b64decode(salt, validate=True),
+                base64.b64decode(stored_key, validate=True),
+                base64.b64decode(server_key, validate=True),
+            )
+        except (ValueError, TypeError):
+            raise ValueError("invalid SCRAM verifier") from None
+        if mechanism != SCRAM_SHA_256 or len(verifier.stored_key) != 32:
+            raise ValueError("invalid SCRAM verifier")
+        return verifier
+
+    def __str__(self) -> str:
+        return (
+            f"{SCRAM_SHA_256}${self.iterations}:{_b64(self.salt)}"
+            f"${_b64(self.stored_key)}:{_b64(self.server_key)}"
+        )
+
+
+def mock_verifier(user: str) -> ScramVerifier:
+    """A verifier no password matches, with a salt that is stable per user."""
+    salt = hashlib.sha256(_MOCK_SECRET + user.encode()).digest()[:16]
+    return ScramVerifier(DEFAULT_ITERATIONS, salt, secrets.token_bytes(32), secrets.token_bytes(32))
+
+
+def _der_element(data: bytes, pos: int) -> tuple[int, int, int]:
+    """(tag, content start, content end) of the DER element at pos."""
+    tag, length = data[pos], data[pos + 1]
+    pos += 2
+    if length & 0x80:
+        size = length & 0x7F
+        length = int.from_bytes(data[pos : pos + size], "big")
+        pos += size
+    return tag, pos, pos + length
+
+
+def tls_server_end_point(certificate: bytes) -> bytes:
+    """
+    Channel binding data for a DER certificate (RFC 5929): its hash, with
+    SHA-256 unless the certificate is signed with SHA-384 or SHA-512.
+    """
+    digest = hashlib.sha256
+    try:
+        _, pos, _ = _der_element(certificate, 0)  # Certificate
+        _, _, pos = _der_element(certificate, pos)  # tbsCertificate (skipped)
+        _, pos, _ = _der_element(certificate, pos)  # signatureAlgorithm
+        tag, start, end = _der_element(certificate, pos)
+        if tag == 0x06:
+            digest = _SIGNATURE_HASHES.get(certificate[start:end], hashlib.sha256)
+    except IndexError:
+        pass
+    return digest(certificate).digest()
+
+
+def certificate_binding(cert_path: str) -> bytes | None:
+    """tls-server-end-point data for the server certificate in a PEM file."""
+    try:
+        with open(cert_path) as f:
+            pem = f.read()
+        end = pem.index(ssl.PEM_FOOTER) + len(ssl.PEM_FOOTER)
+        return tls_server_end_point(ssl.PEM_cert_to_DER_cert(pem[:end]))
+    except (OSError, ValueError) as e:
+        logger.warning("SCRAM channel binding unavailable", cert_path=cert_path, error=str(e))
+        return None
+
+
+class ScramExchange:
+    """Server side of one SCRAM-SHA-256(-PLUS) exchange."""
+
+    def __init__(self, user: str, verifier: ScramVerifier, channel_binding: bytes | None = None):
+        self.user = user
+        self.verifier = verifier
+        self.channel_binding = channel_binding  # tls-server-end-point data, with TLS
+        self.mechanism = None
+        self.gs2_header = None
+        self.client_first_bare = None
+        self.server_first = None
+        self.nonce = None
+
+    @property
+    def mechanisms(self) -> list[str]:
+        """SASL mechanisms to offer, preferred first."""
+        if self.channel_binding is None:
+            return [SCRAM_SHA_256]
+        return [SCRAM_SHA_256_PLUS, SCRAM_SHA_256]
+
+    def client_first(self, mechanism: str, message: bytes) -> bytes:
+        """
+        Check the client-first-message and return the server-first-message.
+
+        Raises:
+            ScramError: unsupported mechanism or malformed message
+        """
+        if mechanism not in self.mechanisms:
+            raise ScramError(
+                f"client selected an invalid SASL authentication mechanism: {mechanism}",
+                "08P01",
+                "protocol_violation",
+            )
+        self.mechanism = mechanism
+        text = message.decode("utf-8", "replace")
+        flag, _, rest = text.partition(",")
+        authzid, _, bare = rest.partition(",")
+        if authzid and not authzid.startswith("a="):
+            raise malformed("invalid authorization identity")
+        if flag == f"p={CHANNEL_BINDING_TYPE}":
+            if mechanism != SCRAM_SHA_256_PLUS:
+                raise malformed("channel binding requested for SCRAM-SHA-256")
+        elif flag.startswith("p="):
+            raise malformed(f"unsupported channel binding type {flag[2:]}")
+        elif flag in ("n", "y"):
+            if mechanism == SCRAM_SHA_256_PLUS:
+                raise malformed("channel binding required by SCRAM-SHA-256-PLUS")
+            if flag == "y" and self.channel_binding is not None:
+                # The client could bind but thinks we cannot: a downgrade
+                raise ScramError(
+                    "SCRAM channel binding negotiation error", "08P01", "protocol_violation"
+                )
+        else:
+            raise malformed("unexpected channel-binding flag")
+        attributes = bare.split(",")
+        if len(attributes) < 2 or not attributes[0].startswith("n="):
+            raise malformed("expected username attribute")
+        if attributes[1].startswith("m="):
+            raise malformed("unsupported SCRAM extension")
+        if not attributes[1].startswith("r=") or len(attributes[1]) < 3:
+            raise malformed("expected nonce attribute")
+        self.gs2_header = f"{flag},{authzid},"
+        self.client_first_bare = bare
+        self.nonce = attributes[1][2:] + _b64(secrets.token_bytes(18))
+        self.server_first = (
+            f"r={self.nonce},s={_b64(self.verifier.salt)},i={self.verifier.iterations}"
+        )
+        return self.server_first.encode()
+
+    def client_final(self, message: bytes) -> bytes:
+        """
+        Verify the client-final-message and return the server-final-message.
+
+        Raises:
+            ScramError: wrong proof (28P01), channel binding mismatch or malformed message
+        """
+        text = message.decode("utf-8", "replace")
+        without_proof, _, proof = text.rpartition(",p=")
+        attributes = without_proof.split(",")
+        if len(attributes) < 2 or not attributes[0].startswith("c="):
+            raise malformed("expected channel-binding attribute")
+        try:
+            cbind = base64.b64decode(attributes[0][2:], validate=True)
+            proof = base64.b64decode(proof, validate=True)
+        except ValueError:
+            raise malformed("invalid base64") from None
+        expected = self.gs2_header.encode()
+        if self.gs2_header.startswith("p="):
+            expected += self.channel_binding
+        if not hmac.compare_digest(cbind, expected):
+            raise ScramError(
+                "SCRAM channel binding check failed", "28000", "invalid_authorization_specification"
+            )
+        if attributes[1] != f"r={self.nonce}":
+            raise malformed("nonce does not match")
+        auth_message = f"{self.client_first_bare},{self.server_first},{without_proof}".encode()
+        signature = _hmac(self.verifier.stored_key, auth_message)
+        client_key = bytes(a ^ b for a, b in zip(proof, signature, strict=False))
+        if len(proof) != len(signature) or not hmac.compare_digest(
+            hashlib.sha256(client_key).digest(), self.verifier.stored_key
+        ):
+            raise authentication_failed(self.user)
+        server_signature = _hmac(self.verifier.server_key, auth_message)
+        return f"v={_b64(server_signature)}".encode()
+
+
+def password_stamp(hashed_password: bytes | str) -> str:
+    """Stamp of a user's IRIS password, from its hash in Security.Users."""
+    if isinstance(hashed_password, str):
+        hashed_password = hashed_password.encode("utf-8", "surrogateescape")
+    return hashlib.sha256(hashed_password).hexdigest()
+
+
+def enrolled_before_password_change(
+    accessor, global_name: str, user: str, stamp: str | None
+) -> bool:
+    """
+    Whether user's secret in global_name came from an IRIS password changed since.
+
+    Fails closed: an enrolled secret counts as stale when stamp is None (the
+    current password could not be read). Provisioned secrets carry no stamp.
+    """
+    if not accessor.data(global_name, [user, STAMP_SUBSCRIPT]):
+        return False
+    return stamp is None or str(accessor.get(global_name, [user, STAMP_SUBSCRIPT])) != stamp
+
+
+def store_password_stamp(accessor, global_name: str, user: str, stamp: str | None) -> None:
+    if stamp is None:
+        accessor.kill(global_name, [user, STAMP_SUBSCRIPT])
+    else:
+        accessor.set(global_name, [user, STAMP_SUBSCRIPT], stamp)
+
+
+class ScramVerifierStore:
+    """Users' SCRAM verifiers in the IRIS global, through a global accessor."""
+
+    def __init__(self, accessor, global_name: str | None = None):
+        self.accessor = accessor
+        self.global_name = global_name or os.getenv("PGWIRE_SCRAM_GLOBAL", DEFAULT_SCRAM_GLOBAL)
+
+    def get(self, user: str, stamp: str | None = None) -> ScramVerifier | None:
+        """The user's verifier, unless stamp (the current IRIS password's) says it is stale."""
+        if not self.accessor.data(self.global_name, [user]) % 10:
+            return None
+        if enrolled_before_password_change(self.accessor, self.global_name, user, stamp):
+            logger.info(
+                "Ignoring SCRAM verifier of a changed IRIS password",
+                user=user,
+                stamp_known=stamp is not None,
+            )
+            return None
+        try:
+            return ScramVerifier.parse(self.accessor.get(self.global_name, [user]))
+        except ValueError:
+            logger.warning("Ignoring invalid SCRAM verifier", user=user, glob=self.global_name)
+            return None
+
+    def set(self, user: str, verifier: ScramVerifier, stamp: str | None = None) -> None:
+        self.accessor.set(self.global_name, [user], str(verifier))
+        store_password_stamp(self.accessor, self.global_name, user, stamp)
+
+
+def load_enroll_mode() -> str:
+    """PGWIRE_SCRAM_ENROLL (when users without a verifier may enroll with a password)."""
+    value = os.getenv("PGWIRE_SCRAM_ENROLL", "tls").lower()
+    if value not in ENROLL_MODES:
+        logger.warning("Ignoring invalid PGWIRE_SCRAM_ENROLL", value=value)
+        return "tls"
+    return value
@@ -0,0 +1,133 @@
+"""
+IRIS SELECTMODE Emulation (SET iris.select_mode)
+
+IRIS renders values in one of three select modes. Different downstream tools
+want different representations, so the session can choose:
+
+    SET iris.select_mode = odbc      -- default: ISO dates, t/f booleans, lists as a,b,c
+    SET iris.select_mode = logical   -- storage values: $HOROLOG dates, seconds-since-midnight
+                                     -- times, 1/0 booleans, raw $LIST
+    SET iris.select_mode = display   -- locale display: MM/DD/YYYY dates, 1/0 booleans
+
+Values are re-rendered in the protocol layer and the RowDescription type
+changes with them (a logical DATE is an int4 day count, a display DATE is
+text), so drivers never receive a value that contradicts the declared type.
+%List values only reach this layer undecoded with PGWIRE_LIST_FORMAT=text
+(otherwise the executor has already turned them into arrays or JSON).
+"""
+
+import datetime
+from decimal import Decimal
+from typing import Any
+
+from .iris_list import LIST_CARRIER_OIDS, decode_list
+
+SELECT_MODES = ("logical", "odbc", "display")
+DEFAULT_SELECT_MODE = "odbc"
+
+HOROLOG_BASE = datetime.date(1840, 12, 31)
+PG_EPOCH = datetime.date(2000, 1, 1)
+
+_BOOL, _INT4, _TEXT = 16, 23, 25
+_DATE, _TIME, _TIMESTAMP = 1082, 1083, 1114
+
+# Types whose declared OID changes with the mode (anything else keeps its type)
+_MODE_TYPES = {
+    "logical": {_DATE: _INT4, _TIME: _INT4, _BOOL: _INT4},
+    "display": {_DATE: _TEXT, _TIMESTAMP: _TEXT, _BOOL: _TEXT},
+}
+
+
+def result_type(type_oid: int, mode: str) -> int:
+    """Type OID a column is described with under `mode`."""
+    return _MODE_TYPES.get(mode, {}).get(type_oid, type_oid)
+
+
+def _as_date(value: Any) -> datetime.date | None:
+    if isinstance(value, datetime.datetime):
+        return value.date()
+    if isinstance(value, datetime.date):
+        return value
+    if isinstance(value, int):
+        # The executor hands dates over as PostgreSQL day numbers (since 2000-01-01)
+        return PG_EPOCH + datetime.timedelta(days=value)
+    if isinstance(value, str):
+        return datetime.date.fromisoformat(value.strip()[:10])
+    return None
+
+
+def _as_time(value: Any) -> datetime.time | None:
+    if isinstance(value, datetime.time):
+        return value
+    if isinstance(value, datetime.timedelta):
+        return (datetime.datetime.min + value).time()
+    if isinstance(value, str):
+        return datetime.time.fromisoformat(value.strip())
+    return None
+
+
+def _as_timestamp(value: Any) -> datetime.datetime | None:
+    if isinstance(value, datetime.datetime):
+        return value
+    if isinstance(value, str):
+        return datetime.datetime.fromisoformat(value.strip())
+    return None
+
+
+def _as_bool(value: Any) -> bool:
+    if isinstance(value, str):
+        return value.strip().lower() in ("1", "t", "true", "y", "yes", "on")
+    return bool(value)
+
+
+def _list_text(elements: list[Any]) -> str:
+    return ",".join(
+        "" if element is None else format(element, "f") if isinstance(element, Decimal) else str(element)
+        for element in elements
+    )
+
+
+def render_value(value: Any, type_oid: int, mode: str) -> Any:
+    """
+    Re-render a non-NULL value for the session's select mode.
+
+    Args:
+        value: Value as produced by the executor
+        type_oid: Column type OID reported by the executor
+        mode: 'logical', 'odbc' or 'display'
+
+    Returns:
+        The value to send for the column's result_type(); unconvertible values
+        are returned unchanged
+    """
+    try:
+        if type_oid in LIST_CARRIER_OIDS and mode != "logical":
+            elements = decode_list(value)
+            if elements is not None:
+                return _list_text(elements)
+            return value
+
+        if mode == "logical":
+            if type_oid == _DATE:
+                date = _as_date(value)
+                return value if date is None else (date - HOROLOG_BASE).days
+            if type_oid == _TIME:
+                time = _as_time(value)
+                return value if time is None else time.hour * 3600 + time.minute * 60 + time.second
+            if type_oid == _BOOL:
+                return 1 if _as_bool(value) else 0
+
+        elif mode == "display":
+            if type_oid == _DATE:
+                date = _as_date(value)
+                return value if date is None else date.strftime("%m/%d/%Y")
+            if type_oid == _TIMESTAMP:
+                timestamp = _as_timestamp(value)
+                return value if timestamp is None else timestamp.strftime("%m/%d/%Y %H:%M:%S")
+            if type_oid == _BOOL:
+                return "1" if _as_bool(value) else "0"
+
+    except (TypeError, ValueError, OverflowError):
+        return value
+
+    return value
@@ -7,9 +7,11 @@ specification with E2E testing approach.
 """
 
 import asyncio
-import importlib
+import functools
+import importlib.util
 import logging
 import os
+import secrets
 import ssl
 import sys
 
@@ -39,9 +41,27 @@ import iris_pgwire.iris_executor
 reloaded_module = importlib.reload(iris_pgwire.iris_executor)
 
 # NOW import after reload
+from .auth.gssapi_auth import GSSAPI_AVAILABLE
+from .auth_methods import GSS, LDAP, load_auth_methods, load_listeners
+from .cancellation import get_backend_keys
+from .cert_auth import CertAuthConfig
+from .change_sink import start_change_exporter
+from .connection_guard import ConnectionGuard, ConnectionRejected
+from .hba import load_hba
 from .integratedml import enhance_iris_executor_with_integratedml
 from .iris_executor import IRISExecutor
+from .keepalive import TCPKeepaliveConfig
+from .ldap_auth import load_ldap_config
+from .notifications import start_notification_bridge
+from .preauth import load_authentication_timeout, load_max_preauth_bytes, run_handshake
 from .protocol import PGWireProtocol
+from .query_log import install_query_log
+from .replication import get_wal_senders
+from .replication_slots import get_active_slots
+from .scram import certificate_binding
+from .server_tls import ServerTLSConfig
+from .stats_hooks import CountingStreamReader, CountingStreamWriter, get_stats
+from .temp_spool import SpoolConfig
 
 
 class PGWireServer:
@@ -85,8 +105,32 @@ class PGWireServer:
 
         self.server = None
         self.ssl_context = None
+        self.tls_server_end_point = None  # SCRAM-SHA-256-PLUS channel binding (scram.py)
+        self.cert_auth = CertAuthConfig.from_env()  # Client certificate logins (cert_auth.py)
+        # Password methods of the main listener, and further listeners (auth_methods.py)
+        self.auth_methods = load_auth_methods(enable_scram)
+        self.listeners = load_listeners()
+        self.extra_servers = []
+        # pg_hba.conf-style access rules; an invalid file stops the server (hba.py)
+        self.hba = load_hba()
+        if not GSSAPI_AVAILABLE and self._uses(GSS):
+            logger.warning("gss authentication is configured but python-gssapi is not installed")
+        if self._uses(LDAP) and importlib.util.find_spec("ldap3") is None:
+            logger.warning("ldap authentication is configured but ldap3 is not installed")
+        offered = [self.auth_methods, *(listener.auth_methods for listener in self.listeners)]
+        if any(LDAP in methods for methods in offered):
+            load_ldap_config()  # PGWIRE_LDAP_OPTIONS; invalid options stop the server
+        self.spool_config = SpoolConfig.from_env()  # Held and scrolled results (temp_spool.py)
+        self.notification_bridge = None  # PGWIRE_NOTIFY_BRIDGE poller (see notifications.py)
+        self.change_sinks = []  # PGWIRE_CDC_SINKS delivery tasks (see change_sink.py)
         self.active_connections = set()
 
+        # Handshake limits for not-yet-authenticated clients
+        self.authentication_timeout = load_authentication_timeout()
+        self.max_preauth_bytes = load_max_preauth_bytes()
+        self.connection_guard = ConnectionGuard.from_env()  # Per-IP rate limit and lockout
+        self.tcp_keepalive = TCPKeepaliveConfig.from_env()  # PGWIRE_TCP_KEEPALIVES_*
+
         # P4: Connection registry for query cancellation
         self.connection_registry = {}  # backend_pid -> (protocol, backend_secret)
 
@@ -96,6 +140,9 @@ class PGWireServer:
         # Enhance with IntegratedML support
         self.iris_executor = enhance_iris_executor_with_integratedml(self.iris_executor)
 
+        # Optional compliance query log (PGWIRE_QUERY_LOG)
+        self.query_log = install_query_log()
+
         logger.info(
             "PGWire server initialized",
             host=host,
@@ -117,7 +164,10 @@ class PGWireServer:
         )
 
     def unregister_connection(self, protocol):
-        """Unregister a connection"""
+        """Unregister a connection and release its backend key"""
+        get_backend_keys().release(protocol.backend_pid)
+        get_wal_senders().unregister(protocol.backend_pid)
+        get_active_slots().session_ended(protocol.backend_pid)
         if protocol.backend_pid in self.connection_registry:
             del self.connection_registry[protocol.backend_pid]
             logger.debug(
@@ -130,29 +180,62 @@ class PGWireServer:
         """Find connection for cancellation by PID and secret"""
         if backend_pid in self.connection_registry:
             stored_protocol, stored_secret = self.connection_registry[backend_pid]
-            if stored_secret == backend_secret:
+            if secrets.compare_digest(stored_secret.to_bytes(4), backend_secret.to_bytes(4)):
                 return stored_protocol
         return None
 
-    async def setup_ssl_context(self) -> ssl.SSLContext | None:
-        """Setup SSL context for TLS connections if enabled"""
-        if not self.enable_ssl:
-            return None
+    def _uses(self, method: str) -> bool:
+        """Whether any listener or pg_hba line authenticates with the method."""
+        methods = [*self.auth_methods]
+        methods += [method for listener in self.listeners for method in listener.auth_methods]
+        if self.hba is not None:
+            methods += [rule.method for rule in self.hba.rules]
+        return method in methods
 
-        if not self.ssl_cert_path or not self.ssl_key_path:
-            logger.warning("SSL enabled but cert/key paths not provided, disabling SSL")
-            return None
+    async def setup_ssl_context(self) -> ssl.SSLContext | None:
+        """
+        Setup SSL context for TLS connections if enabled (see server_tls.py)
 
-        try:
-            ssl_context = ssl.create_default_context(ssl.Purpose.CLIENT_AUTH)
-            ssl_context.load_cert_chain(self.ssl_cert_path, self.ssl_key_path)
-            logger.info("SSL context configured", cert_path=self.ssl_cert_path)
-            return ssl_context
-        except Exception as e:
-            logger.error("Failed to setup SSL context", error=str(e))
+        Raises:
+            ValueError: TLS is enabled but its certificate cannot be loaded, or
+                client certificates are required without a CA file
+        """
+        if not self.enable_ssl:
             return None
 
-    async def handle_client(self, reader: asyncio.StreamReader, writer: asyncio.StreamWriter):
+        config = ServerTLSConfig.from_env(cert=self.ssl_cert_path, key=self.ssl_key_path)
+        if config.iris_config:
+            await config.load_iris_config(self.iris_executor)
+        ssl_context = config.create_context()
+        self.ssl_cert_path, self.ssl_key_path = config.cert, config.key
+        self.tls_server_end_point = certificate_binding(config.cert)
+        logger.info(
+            "SSL context configured",
+            cert_path=config.cert,
+            iris_config=config.iris_config,
+            min_protocol_version=config.min_protocol_version,
+            client_ca_file=config.ca_file,
+        )
+        if self.cert_auth.enabled and not config.ca_file:
+            # Without client CAs no certificate is requested, so none could authenticate
+            if self.cert_auth.mode == "required":
+                raise ValueError("PGWIRE_CERT_AUTH=required needs PGWIRE_SSL_CA_FILE")
+            logger.warning("PGWIRE_CERT_AUTH is set without PGWIRE_SSL_CA_FILE")
+        if self.hba is not None and not config.ca_file:
+            for rule in self.hba.rules:
+                if rule.method == "cert" or "clientcert" in rule.options:
+                    logger.warning(
+                        "pg_hba line needs client certificates but PGWIRE_SSL_CA_FILE is not set",
+                        line=rule.line,
+                    )
+        return ssl_context
+
+    async def handle_client(
+        self,
+        reader: asyncio.StreamReader,
+        writer: asyncio.StreamWriter,
+        auth_methods: tuple[str, ...] | None = None,
+    ):
         """
         Handle individual client connection with P0 protocol implementation
 
@@ -167,20 +250,52 @@ class PGWireServer:
         client_addr = writer.get_extra_info("peername")
         connection_id = f"{client_addr[0]}:{client_addr[1]}"
 
+        try:
+            self.connection_guard.check_connection(client_addr[0])
+        except ConnectionRejected as e:
+            logger.warning("Connection refused", connection_id=connection_id, reason=str(e))
+            writer.write(e.error_response())
+            writer.close()
+            return
+
         logger.info("Client connection established", connection_id=connection_id)
         self.active_connections.add(writer)
+        # Keeps idle sessions alive through NAT gateways and firewalls
+        self.tcp_keepalive.apply(writer.get_extra_info("socket"))
 
         try:
             # Create protocol handler for this connection
             protocol = PGWireProtocol(
                 reader, writer, self.iris_executor, connection_id, self.enable_scram
             )
+            protocol.client_address = client_addr
+            protocol.tls_server_end_point = self.tls_server_end_point
+            protocol.cert_auth = self.cert_auth
+            protocol.auth_methods = auth_methods or self.auth_methods
+            protocol.sql_cursors.spool_config = self.spool_config
+            protocol.portal_cursors.spool_config = self.spool_config
+
+            # P0 Phase: SSL probe, then startup sequence (bounded in time and bytes)
+            try:
+                await run_handshake(
+                    protocol, self.ssl_context, self.authentication_timeout, self.max_preauth_bytes
+                )
+            except Exception:
+                # Only rejected credentials count towards lockout, not clients that hang up
+                # at the password prompt (as psql does before asking for one), timeouts or
+                # TLS errors
+                if protocol.credentials_rejected:
+                    self.connection_guard.record_auth_failure(
+                        client_addr[0], protocol.startup_params.get("user")
+                    )
+                raise
+            self.connection_guard.record_auth_success(
+                client_addr[0], protocol.startup_params.get("user")
+            )
 
-            # P0 Phase: Handle SSL probe first
-            await protocol.handle_ssl_probe(self.ssl_context)
-
-            # P0 Phase: Handle startup sequence
-            await protocol.handle_startup_sequence()
+            # Client bytes for pg_stat_io / pg_stat_bgwriter
+            protocol.reader = CountingStreamReader(protocol.reader, get_stats())
+            protocol.writer = CountingStreamWriter(protocol.writer, get_stats())
 
             # P4: Register connection for query cancellation
             self.register_connection(protocol)
@@ -198,6 +313,14 @@ class PGWireServer:
             # P4: Unregister connection from cancellation registry
             if "protocol" in locals():
                 self.unregister_connection(protocol)
+                # LOCK TABLE locks of a transaction the client abandoned
+                await protocol.table_locks.release()
+                # Cursors still holding IRIS result sets or spool files (see sql_cursors.py)
+                await protocol.sql_cursors.close_all()
+                protocol.portal_cursors.close_all()
+                protocol.notifications.close()
+                protocol.async_messages.close()
+                get_stats().session_ended(protocol.connection_id)
 
             self.active_connections.discard(writer)
             if not writer.is_closing():
@@ -214,14 +337,35 @@ class PGWireServer:
             # Setup SSL if enabled
             self.ssl_context = await self.setup_ssl_context()
 
+            # Notifications from other bridge instances, if configured
+            self.notification_bridge = start_notification_bridge(
+                self.iris_executor.global_operation
+            )
+            # Kafka / webhook destinations for changes made through the bridge
+            self.change_sinks = start_change_exporter()
+
             # Start TCP server
             self.server = await asyncio.start_server(self.handle_client, self.host, self.port)
+            for listener in self.listeners:
+                self.extra_servers.append(
+                    await asyncio.start_server(
+                        functools.partial(self.handle_client, auth_methods=listener.auth_methods),
+                        listener.host,
+                        listener.port,
+                    )
+                )
+                logger.info(
+                    "Additional listener started",
+                    address=f"{listener.host}:{listener.port}",
+                    auth_methods=list(listener.auth_methods),
+                )
 
             addr = self.server.sockets[0].getsockname()
             logger.info(
                 "PGWire server started",
                 address=f"{addr[0]}:{addr[1]}",
                 ssl_enabled=self.ssl_context is not None,
+                auth_methods=list(self.auth_methods),
                 active_connections=len(self.active_connections),
             )
 
@@ -235,6 +379,11 @@ class PGWireServer:
 
     async def stop(self):
         """Stop the PGWire server gracefully"""
+        for server in self.extra_servers:
+            server.close()
+            await server.wait_closed()
+        self.extra_servers = []
+
         if self.server:
             self.server.close()
             await self.server.wait_closed()
@@ -247,6 +396,19 @@ class PGWireServer:
 
             logger.info("PGWire server stopped", connections_closed=len(self.active_connections))
 
+        if self.notification_bridge:
+            self.notification_bridge.cancel()
+            self.notification_bridge = None
+
+        for task in self.change_sinks:
+            task.cancel()
+        self.change_sinks = []
+
+        if self.query_log:
+            get_stats().remove_hooks(self.query_log)
+            self.query_log.close()
+            self.query_log = None
+
 
 async def main():
     """Main entry point for the PGWire server"""
@@ -263,6 +425,7 @@ async def main():
     enable_ssl = os.getenv("PGWIRE_SSL_ENABLED", "false").lower() == "true"
     ssl_cert_path = os.getenv("PGWIRE_SSL_CERT")
     ssl_key_path = os.getenv("PGWIRE_SSL_KEY")
+    enable_scram = os.getenv("PGWIRE_ENABLE_SCRAM", "false").lower() == "true"
 
     debug = os.getenv("PGWIRE_DEBUG", "false").lower() == "true"
 
@@ -281,6 +444,7 @@ async def main():
         enable_ssl=enable_ssl,
         ssl_cert_path=ssl_cert_path,
         ssl_key_path=ssl_key_path,
+        enable_scram=enable_scram,
     )
 
     try:
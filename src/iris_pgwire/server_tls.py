@@ -0,0 +1,126 @@
+"""
+Client-Facing TLS
+
+Clients ask for TLS with an SSLRequest before their StartupMessage (libpq
+sslmode=prefer, require, verify-ca and verify-full; the JDBC, Npgsql and
+pgx equivalents). When TLS is configured the bridge answers 'S' and runs the
+TLS handshake on the connection, otherwise it answers 'N' and clients that
+require TLS disconnect.
+
+The certificate is checked by the client, not the bridge: verify-ca needs a
+chain to the client's root certificate, so PGWIRE_SSL_CERT should hold the
+server certificate followed by any intermediate CA certificates, and
+verify-full also needs the host name clients connect to in the certificate's
+subjectAltName.
+
+Configuration:
+- PGWIRE_SSL_ENABLED: true to accept TLS
+- PGWIRE_SSL_CERT / PGWIRE_SSL_KEY: PEM certificate (chain) and private key
+- PGWIRE_SSL_KEY_PASSWORD: passphrase of an encrypted key
+- PGWIRE_SSL_IRIS_CONFIG: name of an IRIS SSL/TLS configuration (System
+  Administration > Security > SSL/TLS Configurations) whose certificate file,
+  key file, key password and CA file are used instead, so certificates stay
+  managed in IRIS. Its paths are read as IRIS sees them: the bridge must share IRIS's
+  filesystem (embedded mode, same host or a shared volume).
+- PGWIRE_SSL_MIN_PROTOCOL_VERSION: TLSv1.2 (default) or TLSv1.3
+- PGWIRE_SSL_CIPHERS: OpenSSL cipher list for TLS 1.2 (default: Python's)
+- PGWIRE_SSL_CA_FILE / PGWIRE_SSL_CRL_FILE: CA certificates and revocation
+  list for client certificates (see cert_auth.py)
+
+When TLS is enabled but the certificate or key cannot be loaded, the server
+does not start rather than silently accept plaintext only.
+"""
+
+import os
+import ssl
+from dataclasses import dataclass
+
+import structlog
+
+logger = structlog.get_logger()
+
+PROTOCOL_VERSIONS = {"TLSv1.2": ssl.TLSVersion.TLSv1_2, "TLSv1.3": ssl.TLSVersion.TLSv1_3}
+
+
+@dataclass
+class ServerTLSConfig:
+    """Certificate and protocol settings for client connections."""
+
+    cert: str | None = None
+    key: str | None = None
+    key_password: str | None = None
+    iris_config: str | None = None
+    min_protocol_version: str = "TLSv1.2"
+    ciphers: str | None = None
+    ca_file: str | None = None  # Client certificates are requested when set
+    crl_file: str | None = None
+
+    def __post_init__(self):
+        if self.min_protocol_version not in PROTOCOL_VERSIONS:
+            expected = ", ".join(PROTOCOL_VERSIONS)
+            raise ValueError(
+                f"invalid PGWIRE_SSL_MIN_PROTOCOL_VERSION {self.min_protocol_version!r}"
+                f" (expected {expected})"
+            )
+
+    @classmethod
+    def from_env(cls, cert: str | None = None, key: str | None = None) -> "ServerTLSConfig":
+        """Settings from the environment; cert and key override PGWIRE_SSL_CERT/KEY."""
+        return cls(
+            cert=cert or os.getenv("PGWIRE_SSL_CERT") or None,
+            key=key or os.getenv("PGWIRE_SSL_KEY") or None,
+            key_password=os.getenv("PGWIRE_SSL_KEY_PASSWORD") or None,
+            iris_config=os.getenv("PGWIRE_SSL_IRIS_CONFIG") or None,
+            min_protocol_version=os.getenv("PGWIRE_SSL_MIN_PROTOCOL_VERSION", "TLSv1.2"),
+            ciphers=os.getenv("PGWIRE_SSL_CIPHERS") or None,
+            ca_file=os.getenv("PGWIRE_SSL_CA_FILE") or None,
+            crl_file=os.getenv("PGWIRE_SSL_CRL_FILE") or None,
+        )
+
+    async def load_iris_config(self, iris_executor) -> None:
+        """
+        Take the certificate, key, key password and CA file of the IRIS SSL/TLS configuration.
+
+        Raises:
+            ValueError: no such configuration, or it has no certificate
+        """
+        config = await iris_executor.iris_ssl_config(self.iris_config)
+        if config is None:
+            raise ValueError(f'IRIS SSL/TLS configuration "{self.iris_config}" does not exist')
+        if not config.get("CertificateFile"):
+            raise ValueError(f'IRIS SSL/TLS configuration "{self.iris_config}" has no certificate')
+        self.cert = config["CertificateFile"]
+        self.key = config.get("PrivateKeyFile") or None
+        self.key_password = config.get("PrivateKeyPassword") or None
+        self.ca_file = config.get("CAFile") or self.ca_file
+
+    def create_context(self) -> ssl.SSLContext:
+        """
+        Server-side SSLContext.
+
+        Raises:
+            ValueError: no certificate configured, or it cannot be loaded
+        """
+        if not self.cert:
+            raise ValueError("TLS enabled without a certificate (PGWIRE_SSL_CERT)")
+        context = ssl.create_default_context(ssl.Purpose.CLIENT_AUTH)
+        context.minimum_version = PROTOCOL_VERSIONS[self.min_protocol_version]
+        # PostgreSQL 17 clients announce the protocol with ALPN
+        context.set_alpn_protocols(["postgresql"])
+        try:
+            if self.ciphers:
+                context.set_ciphers(self.ciphers)
+            context.load_cert_chain(self.cert, self.key, password=self.key_password)
+        except (OSError, ssl.SSLError) as e:
+            raise ValueError(f"cannot load TLS certificate {self.cert}: {e}") from e
+        if self.ca_file:
+            # Clients may present a certificate, verified against these CAs (cert_auth.py)
+            try:
+                context.load_verify_locations(cafile=self.ca_file)
+                if self.crl_file:
+                    context.load_verify_locations(cafile=self.crl_file)
+                    context.verify_flags |= ssl.VERIFY_CRL_CHECK_LEAF
+            except (OSError, ssl.SSLError) as e:
+                raise ValueError(f"cannot load client CA certificates {self.ca_file}: {e}") from e
+            context.verify_mode = ssl.CERT_OPTIONAL
+        return context
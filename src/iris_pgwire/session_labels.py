@@ -0,0 +1,137 @@
+"""
+Session Labels for IRIS Processes
+
+Statements of PostgreSQL clients run in IRIS processes of the bridge's
+connection pool, so IRIS-side monitoring (Management Portal > Processes, ^%SS,
+%SYS.ProcessQuery) only shows the service account. To correlate them, each
+external connection's IRIS process is labelled with the session it serves:
+
+    ^PGWire.Sessions(<IRIS pid>) = "pid=4242 client=10.0.0.7:51234 user=app application=psql"
+
+pid is the backend PID sent to the client in BackendKeyData (the one
+CancelRequest names), client the client address and port. The label is
+written when a connection is taken for a session it was not labelled with,
+so a pooled process that is idle keeps the label of the last session it
+served; the entry is removed when the bridge closes the connection. From
+IRIS, join on the process id:
+
+    SELECT Pid, State, $GET(^PGWire.Sessions(Pid)) FROM %SYS.ProcessQuery
+
+Embedded mode runs every session in the bridge's own process, so there is
+nothing to label. PGWIRE_SESSION_LABELS=off disables labelling; the global is
+named by PGWIRE_SESSIONS_GLOBAL (default ^PGWire.Sessions).
+"""
+
+import os
+import threading
+from contextvars import ContextVar
+from dataclasses import dataclass, replace
+
+import structlog
+
+logger = structlog.get_logger()
+
+DEFAULT_SESSIONS_GLOBAL = "^PGWire.Sessions"
+
+
+@dataclass(frozen=True)
+class SessionLabel:
+    backend_pid: int  # PID reported to the client (BackendKeyData)
+    client: str  # Client address and port
+    user: str
+    application: str  # application_name
+
+    def text(self) -> str:
+        return (
+            f"pid={self.backend_pid} client={self.client} user={self.user} "
+            f"application={self.application}"
+        )
+
+
+_session_label: ContextVar[SessionLabel | None] = ContextVar("pgwire_session_label", default=None)
+
+
+def set_session_label(label: SessionLabel | None) -> None:
+    """Record the label of the current connection task's session."""
+    _session_label.set(label)
+
+
+def set_session_application(application: str) -> None:
+    """Follow SET application_name in the current session's label."""
+    label = _session_label.get()
+    if label is not None:
+        _session_label.set(replace(label, application=application))
+
+
+def current_session_label() -> SessionLabel | None:
+    return _session_label.get()
+
+
+def sessions_global() -> str:
+    """Global holding the labels (PGWIRE_SESSIONS_GLOBAL), without the caret."""
+    return os.getenv("PGWIRE_SESSIONS_GLOBAL", DEFAULT_SESSIONS_GLOBAL).lstrip("^")
+
+
+class SessionLabels:
+    """Labels written on external IRIS connections, by connection."""
+
+    def __init__(self, enabled: bool | None = None):
+        if enabled is None:
+            enabled = os.getenv("PGWIRE_SESSION_LABELS", "on").strip().lower() not in (
+                "off",
+                "false",
+                "0",
+            )
+        self.enabled = enabled
+        self._lock = threading.Lock()
+        self._applied: dict[int, tuple[int, str]] = {}  # id(connection) -> (IRIS pid, label)
+
+    def apply(self, connection, label: SessionLabel | None, new: bool = False):
+        """
+        Label connection's IRIS process for label's session (only when it changed)
+        and return the connection.
+
+        Failures are logged and ignored - monitoring must not make the
+        connection unusable.
+        """
+        if not self.enabled or label is None:
+            return connection
+        key = id(connection)
+        with self._lock:
+            applied = None if new else self._applied.get(key)
+        text = label.text()
+        if applied and applied[1] == text:
+            return connection
+        try:
+            import iris
+
+            native = iris.createIRIS(connection)
+            if applied:
+                pid = applied[0]
+            else:
+                pid = int(native.classMethodValue("%SYSTEM.SYS", "ProcessID"))
+            native.set(text, sessions_global(), pid)
+            with self._lock:
+                self._applied[key] = (pid, text)
+        except Exception as e:
+            logger.warning("Could not label IRIS process", label=text, error=str(e))
+        return connection
+
+    def iris_pid(self, connection) -> int | None:
+        """IRIS process ID of a labelled connection (None when not labelled)."""
+        with self._lock:
+            applied = self._applied.get(id(connection))
+        return applied[0] if applied else None
+
+    def forget(self, connection) -> None:
+        """Remove the label of a connection the bridge is about to close."""
+        with self._lock:
+            applied = self._applied.pop(id(connection), None)
+        if applied is None:
+            return
+        try:
+            import iris
+
+            iris.createIRIS(connection).kill(sessions_global(), applied[0])
+        except Exception as e:
+            logger.debug("Could not remove IRIS process label", pid=applied[0], error=str(e))
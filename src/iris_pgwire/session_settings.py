@@ -0,0 +1,378 @@
+"""
+Per-Session Runtime Parameters (GUCs)
+
+PostgreSQL clients configure the session with SET/RESET and read values back
+with SHOW or current_setting(). IRIS has no equivalent for most of these, so
+the session state lives here, one SessionSettings instance per connection.
+
+Behavior follows PostgreSQL:
+- Parameter names are case-insensitive; SHOW reports the canonical spelling
+- Known parameters with an enumerated domain reject invalid values (22023)
+- Unknown parameters are accepted and stored (drivers SET many harmless options)
+- Changes to GUC_REPORT parameters are queued and flushed as ParameterStatus
+  messages before the next ReadyForQuery
+- SET LOCAL values last until the transaction ends (COMMIT or ROLLBACK),
+  then the value from before the transaction's first SET LOCAL returns; a
+  plain SET or RESET of the parameter in the same transaction keeps its value
+- current_setting() and set_config() read and change the same values; the
+  shared executor answers them from the connection task's settings
+"""
+
+import re
+from collections.abc import Callable
+from contextvars import ContextVar
+from dataclasses import dataclass, field
+
+import structlog
+
+from .keepalive import IDLE_SESSION_TIMEOUT_PARAMETER, normalize_duration
+from .read_only import READ_ONLY_PARAMETER, set_session_read_only
+from .select_mode import DEFAULT_SELECT_MODE, SELECT_MODES
+from .session_labels import set_session_application
+from .statement_savepoints import STATEMENT_SAVEPOINTS_PARAMETER
+from .stats_hooks import get_stats
+from .timezone_support import normalize_timezone_name
+from .transactional_ddl import (
+    DDL_IN_TRANSACTION_MODES,
+    DDL_IN_TRANSACTION_PARAMETER,
+    TRANSACTIONAL_DDL_PARAMETER,
+)
+from .workload import WORKLOAD_PARAMETER, normalize_workload, set_session_workload
+
+logger = structlog.get_logger()
+
+
+class UnrecognizedParameter(LookupError):
+    """current_setting() of a parameter the session does not know (SQLSTATE 42704)."""
+
+    sqlstate = "42704"
+    condition_name = "undefined_object"
+
+
+class InvalidParameterValue(ValueError):
+    """SET value rejected by the parameter's validator (SQLSTATE 22023)."""
+
+    sqlstate = "22023"
+    condition_name = "invalid_parameter_value"
+
+
+@dataclass
+class ParameterDefinition:
+    """Definition of a runtime parameter known to the server."""
+
+    name: str  # Canonical spelling (e.g. 'TimeZone')
+    default: str
+    allowed: tuple[str, ...] | None = None  # Enumerated domain (compared case-insensitively)
+    reportable: bool = False  # GUC_REPORT - send ParameterStatus on change
+    normalizer: Callable[[str], str] | None = None  # Canonicalize/validate free-form values
+
+
+def _normalize_locale(value: str) -> str:
+    """Accept C/POSIX and ll_CC[.encoding] locale names."""
+    import re
+
+    if value.upper() in ("C", "POSIX", ""):
+        return value
+    if not re.match(r"^[A-Za-z]{2,3}(_[A-Za-z]{2})?(\.[\w-]+)?(@\w+)?$", value):
+        raise InvalidParameterValue(f'invalid locale name: "{value}"')
+    return value
+
+
+# Parameters the server understands. Values are the session defaults.
+PARAMETER_DEFINITIONS: dict[str, ParameterDefinition] = {
+    definition.name.lower(): definition
+    for definition in (
+        ParameterDefinition("application_name", "", reportable=True),
+        ParameterDefinition("client_encoding", "UTF8", reportable=True),
+        ParameterDefinition("DateStyle", "ISO, MDY", reportable=True),
+        ParameterDefinition(
+            "TimeZone", "UTC", reportable=True, normalizer=normalize_timezone_name
+        ),
+        ParameterDefinition(
+            "IntervalStyle",
+            "postgres",
+            allowed=("postgres", "postgres_verbose", "sql_standard", "iso_8601"),
+            reportable=True,
+        ),
+        ParameterDefinition(
+            "standard_conforming_strings", "on", allowed=("on", "off"), reportable=True
+        ),
+        # Read-only: timestamps travel as 64-bit microseconds (timezone_support.py)
+        ParameterDefinition("integer_datetimes", "on", allowed=("on",), reportable=True),
+        ParameterDefinition("extra_float_digits", "1"),
+        ParameterDefinition("search_path", '"$user", public'),
+        ParameterDefinition("statement_timeout", "0"),
+        # IRIS has no JIT; asyncpg turns it off around type introspection
+        ParameterDefinition("jit", "off", allowed=("on", "off")),
+        # Wait for LOCK TABLE (see table_locks.py)
+        ParameterDefinition("lock_timeout", "0", normalizer=normalize_duration),
+        # Closes sessions idle between commands (see keepalive.py)
+        ParameterDefinition(IDLE_SESSION_TIMEOUT_PARAMETER, "0", normalizer=normalize_duration),
+        # Rejects writes for the whole session (see read_only.py)
+        ParameterDefinition(READ_ONLY_PARAMETER, "off", allowed=("on", "off"), reportable=True),
+        # Formatting parameters (affect text rendering of bytea / money results)
+        ParameterDefinition("bytea_output", "hex", allowed=("hex", "escape")),
+        ParameterDefinition("lc_monetary", "C", normalizer=_normalize_locale),
+        ParameterDefinition("lc_numeric", "C", normalizer=_normalize_locale),
+        # IRIS SELECTMODE for dates, booleans and %List values (see select_mode.py)
+        ParameterDefinition("iris.select_mode", DEFAULT_SELECT_MODE, allowed=SELECT_MODES),
+        # Wraps statements in explicit transactions in savepoints (see statement_savepoints.py)
+        ParameterDefinition(STATEMENT_SAVEPOINTS_PARAMETER, "off", allowed=("on", "off")),
+        # IRIS DDL cannot be rolled back; reported and handled per session (transactional_ddl.py)
+        ParameterDefinition(TRANSACTIONAL_DDL_PARAMETER, "off", allowed=("off",)),
+        ParameterDefinition(
+            DDL_IN_TRANSACTION_PARAMETER, "warn", allowed=DDL_IN_TRANSACTION_MODES
+        ),
+        # Workload class for admission limits and IRIS priority (see workload.py)
+        ParameterDefinition(WORKLOAD_PARAMETER, "", normalizer=normalize_workload),
+    )
+}
+
+# Boolean spellings PostgreSQL accepts for on/off parameters
+_BOOLEAN_SPELLINGS = {
+    "on": "on",
+    "true": "on",
+    "yes": "on",
+    "1": "on",
+    "off": "off",
+    "false": "off",
+    "no": "off",
+    "0": "off",
+}
+
+
+def strip_setting_value(raw: str) -> str:
+    """Strip quotes and trailing semicolon from a SET value ('x', "x", x)."""
+    value = raw.strip().rstrip(";").strip()
+    if len(value) >= 2 and value[0] == value[-1] and value[0] in ("'", '"'):
+        value = value[1:-1].replace(value[0] * 2, value[0])
+    return value
+
+
+@dataclass
+class SessionSettings:
+    """Runtime parameter values for one client session."""
+
+    values: dict[str, str] = field(default_factory=dict)
+    pending_reports: dict[str, str] = field(default_factory=dict)
+    # Values RESET returns to instead of the built-in defaults (role defaults)
+    session_defaults: dict[str, str] = field(default_factory=dict)
+    # SET LOCAL parameters -> value restored at transaction end (None: unset)
+    local_restore: dict[str, str | None] = field(default_factory=dict)
+
+    def __post_init__(self):
+        for key, definition in PARAMETER_DEFINITIONS.items():
+            self.values.setdefault(key, definition.default)
+
+    @staticmethod
+    def canonical_name(name: str) -> str:
+        """Return the canonical spelling for a parameter name."""
+        definition = PARAMETER_DEFINITIONS.get(name.lower())
+        return definition.name if definition else name.lower()
+
+    def get(self, name: str, default: str | None = None) -> str | None:
+        """Current value of a parameter (None/default if never set and unknown)."""
+        return self.values.get(name.lower(), default)
+
+    def set(self, name: str, value: str) -> str:
+        """
+        Set a parameter, validating known ones.
+
+        Returns:
+            The stored (normalized) value
+
+        Raises:
+            InvalidParameterValue: value outside the parameter's domain
+        """
+        key = name.lower()
+        value = self._validate(key, value)
+        self.local_restore.pop(key, None)
+        previous = self.values.get(key)
+        self.values[key] = value
+
+        definition = PARAMETER_DEFINITIONS.get(key)
+        if definition and definition.reportable and previous != value:
+            self.pending_reports[definition.name] = value
+        if key == WORKLOAD_PARAMETER:
+            # The executor is shared; it reads the tag from the connection's task
+            set_session_workload(value)
+        elif key == READ_ONLY_PARAMETER:
+            set_session_read_only(value == "on")
+        elif key == "application_name":
+            set_session_application(value)
+            get_stats().application_name_changed(value)
+
+        logger.debug("Session parameter set", parameter=key, value=value)
+        return value
+
+    def set_session_default(self, name: str, value: str) -> str:
+        """Set a parameter and make the value the one RESET restores."""
+        value = self.set(name, value)
+        self.session_defaults[name.lower()] = value
+        return value
+
+    def reset(self, name: str) -> None:
+        """RESET a single parameter to its default (unknown parameters are dropped)."""
+        key = name.lower()
+        definition = PARAMETER_DEFINITIONS.get(key)
+        if key in self.session_defaults:
+            self.set(key, self.session_defaults[key])
+        elif definition:
+            self.set(key, definition.default)
+        else:
+            self.local_restore.pop(key, None)
+            self.values.pop(key, None)
+
+    def set_local(self, name: str, value: str) -> str:
+        """SET LOCAL: set a parameter until the transaction ends."""
+        key = name.lower()
+        previous = self._value_before_transaction(key)
+        value = self.set(key, value)
+        self.local_restore[key] = previous
+        return value
+
+    def reset_local(self, name: str) -> None:
+        """SET LOCAL name TO DEFAULT: reset a parameter until the transaction ends."""
+        key = name.lower()
+        previous = self._value_before_transaction(key)
+        self.reset(key)
+        self.local_restore[key] = previous
+
+    def end_transaction(self) -> None:
+        """Restore the parameters SET LOCAL changed in the ending transaction."""
+        restore, self.local_restore = self.local_restore, {}
+        for key, value in restore.items():
+            if value is None:
+                self.values.pop(key, None)
+            else:
+                self.set(key, value)
+
+    def _value_before_transaction(self, key: str) -> str | None:
+        if key in self.local_restore:
+            return self.local_restore[key]
+        return self.values.get(key)
+
+    def reset_all(self) -> None:
+        """RESET ALL - restore every parameter to its default."""
+        for key in list(self.values) + list(self.session_defaults):
+            self.reset(key)
+
+    def validate(self, name: str, value: str) -> str:
+        """
+        Normalized value for a parameter, without applying it.
+
+        Raises:
+            InvalidParameterValue: value outside the parameter's domain
+        """
+        return self._validate(name.lower(), value)
+
+    def take_pending_reports(self) -> dict[str, str]:
+        """Return and clear ParameterStatus updates queued by SET/RESET."""
+        reports, self.pending_reports = self.pending_reports, {}
+        return reports
+
+    def _validate(self, key: str, value: str) -> str:
+        definition = PARAMETER_DEFINITIONS.get(key)
+        if definition is None:
+            return value
+
+        if definition.allowed is not None:
+            candidate = value.lower()
+            if definition.allowed == ("on", "off"):
+                candidate = _BOOLEAN_SPELLINGS.get(candidate, candidate)
+            if candidate not in definition.allowed:
+                raise InvalidParameterValue(
+                    f'invalid value for parameter "{definition.name}": "{value}"'
+                )
+            return candidate
+
+        if definition.normalizer is not None:
+            try:
+                return definition.normalizer(value)
+            except InvalidParameterValue:
+                raise
+            except ValueError as e:
+                raise InvalidParameterValue(
+                    f'invalid value for parameter "{definition.name}": "{value}"'
+                ) from e
+
+        return value
+
+
+_session_settings: ContextVar[SessionSettings | None] = ContextVar(
+    "pgwire_session_settings", default=None
+)
+
+
+def set_session_settings(settings: SessionSettings | None) -> None:
+    """Record the session's settings for the current connection task."""
+    _session_settings.set(settings)
+
+
+def current_session_settings() -> SessionSettings:
+    """Settings of the session the current task serves (defaults outside one)."""
+    return _session_settings.get() or SessionSettings()
+
+
+# current_setting('name' [, missing_ok]) and set_config('name', 'value', is_local)
+_ARGUMENT = r"\s*('(?:[^']|'')*'|\$\d+|\w+)\s*"
+_SETTING_CALL = (
+    rf"(current_setting|set_config)\s*\({_ARGUMENT}(?:,{_ARGUMENT})?(?:,{_ARGUMENT})?\)"
+    r"(?:\s*::\s*\w+)?(?:\s+AS\s+(\w+))?"
+)
+SETTING_CALL = re.compile(_SETTING_CALL, re.IGNORECASE)
+# A select list of nothing but such calls, as drivers and ORMs send them
+SETTING_FUNCTION_QUERY = re.compile(
+    rf"\s*SELECT\s+{_SETTING_CALL}(?:\s*,\s*{_SETTING_CALL})*\s*;?\s*", re.IGNORECASE
+)
+
+
+def _argument(token: str, params: list | None) -> str | None:
+    if token.startswith("'"):
+        return token[1:-1].replace("''", "'")
+    if token.startswith("$"):
+        index = int(token[1:]) - 1
+        value = params[index] if params and index < len(params) else None
+        return None if value is None else str(value)
+    return token
+
+
+def _is_true(value: str | None) -> bool:
+    return value is not None and _BOOLEAN_SPELLINGS.get(value.lower()) == "on"
+
+
+def evaluate_setting_functions(
+    sql: str,
+    params: list | None,
+    settings: SessionSettings,
+    server_values: dict[str, str] | None = None,
+) -> list[tuple[str, str | None]] | None:
+    """
+    (column name, value) of each current_setting() / set_config() call of a
+    SELECT made of nothing else, applied to settings; None for other statements.
+
+    server_values: read-only parameters (server_version, ...) by lower-case
+    name, which current_setting() reads too
+
+    Raises:
+        UnrecognizedParameter: current_setting() of an unknown parameter
+        InvalidParameterValue: set_config() with a value outside the domain
+    """
+    if not SETTING_FUNCTION_QUERY.fullmatch(sql):
+        return None
+    results = []
+    for match in SETTING_CALL.finditer(sql):
+        function, alias = match.group(1).lower(), match.group(5)
+        args = [_argument(token, params) for token in match.group(2, 3, 4) if token is not None]
+        if function == "current_setting":
+            name = args[0] or ""
+            value = settings.get(name, (server_values or {}).get(name.lower()))
+            if value is None and not _is_true(args[1] if len(args) > 1 else None):
+                raise UnrecognizedParameter(f'unrecognized configuration parameter "{name}"')
+        elif len(args) == 3 and args[0]:
+            apply = settings.set_local if _is_true(args[2]) else settings.set
+            value = apply(args[0], args[1] or "")
+        else:
+            return None
+        results.append((alias.lower() if alias else function, value))
+    return results
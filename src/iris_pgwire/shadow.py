@@ -0,0 +1,308 @@
+"""
+Shadow Comparison Against Real PostgreSQL
+
+Validation mode for compatibility hardening: every statement the bridge runs
+on IRIS is also run on a real PostgreSQL instance holding the same schema and
+data, and the two outcomes are diffed. Mismatches are logged as
+"Shadow comparison mismatch" with the statement and what differed:
+
+- success vs failure, or different SQLSTATEs
+- row counts
+- values (compared after normalization: numbers by value, dates/times in ISO
+  form, booleans as t/f; row order is ignored unless the query has ORDER BY)
+
+Shadow execution runs in the background on its own threads and never delays
+or alters what the client receives.
+
+Configuration:
+- PGWIRE_SHADOW_DSN: libpq connection string of the PostgreSQL instance
+  (unset = shadow mode off)
+- PGWIRE_SHADOW_WRITES: also shadow INSERT/UPDATE/DELETE/DDL (default false -
+  only SELECT/WITH/VALUES/TABLE/SHOW are sent)
+- PGWIRE_SHADOW_SAMPLE: fraction of statements to shadow (default 1.0)
+- PGWIRE_SHADOW_MAX_PENDING: comparisons allowed in flight before new ones are
+  dropped (default 100)
+"""
+
+import asyncio
+import concurrent.futures
+import datetime
+import os
+import random
+import re
+import threading
+from dataclasses import dataclass, field
+from decimal import Decimal, InvalidOperation
+from typing import Any
+
+import structlog
+
+logger = structlog.get_logger()
+
+READ_ONLY_PREFIXES = ("SELECT", "WITH", "VALUES", "TABLE", "SHOW")
+TRANSACTION_PREFIXES = ("BEGIN", "START", "COMMIT", "END", "ROLLBACK", "SAVEPOINT", "RELEASE")
+
+PG_EPOCH = datetime.date(2000, 1, 1)
+_BOOL_OID, _DATE_OID = 16, 1082
+
+# String/quoted-identifier/comment spans, then ? or $n placeholders
+_PLACEHOLDER_SCAN = re.compile(
+    r"('(?:[^']|'')*'|\"(?:[^\"]|\"\")*\"|--[^\n]*|/\*.*?\*/)|(\?|\$\d+)|(%)", re.DOTALL
+)
+
+
+@dataclass
+class ShadowOutcome:
+    """Result of running a statement on one side, reduced to comparable form."""
+
+    success: bool
+    sqlstate: str | None = None
+    row_count: int | None = None
+    rows: list[tuple] | None = None
+
+
+@dataclass
+class ShadowStats:
+    compared: int = 0
+    matched: int = 0
+    mismatched: int = 0
+    shadow_errors: int = 0
+    dropped: int = 0
+    mismatches_by_kind: dict[str, int] = field(default_factory=dict)
+
+
+def to_pyformat(sql: str, params: list | None) -> tuple[str, list | None]:
+    """
+    Rewrite ? / $n placeholders to psycopg2's %s (escaping literal %).
+
+    $n placeholders are reordered into the positional parameter list.
+    """
+    if not params:
+        return sql, None
+
+    ordered: list = []
+    position = 0
+
+    def _replace(match: re.Match) -> str:
+        nonlocal position
+        if match.group(1):
+            # psycopg2 interpolates the whole text, literals included
+            return match.group(1).replace("%", "%%")
+        if match.group(3):
+            return "%%"
+        token = match.group(2)
+        if token == "?":
+            ordered.append(params[position])
+            position += 1
+        else:
+            ordered.append(params[int(token[1:]) - 1])
+        return "%s"
+
+    return _PLACEHOLDER_SCAN.sub(_replace, sql), ordered
+
+
+def normalize_value(value: Any, type_oid: int | None = None) -> Any:
+    """Comparable form of a result value from either side."""
+    if value is None:
+        return None
+    if type_oid == _DATE_OID and isinstance(value, int) and not isinstance(value, bool):
+        # The executor hands DATE values over as PostgreSQL day numbers
+        value = PG_EPOCH + datetime.timedelta(days=value)
+    if type_oid == _BOOL_OID and value in (0, 1, "0", "1"):
+        value = value in (1, "1")
+    if isinstance(value, bool):
+        return "t" if value else "f"
+    if isinstance(value, int | float | Decimal):
+        try:
+            number = Decimal(str(value)).normalize()
+        except InvalidOperation:
+            return str(value)
+        return format(number, "f") if number == number.to_integral_value() else str(number)
+    if isinstance(value, datetime.datetime | datetime.date | datetime.time):
+        return value.isoformat()
+    if isinstance(value, bytes | bytearray | memoryview):
+        return bytes(value).hex()
+    text = str(value)
+    try:
+        # Numeric text (IRIS often returns numbers as strings) compares by value
+        return normalize_value(Decimal(text))
+    except InvalidOperation:
+        return text
+
+
+def outcome_from_result(result: dict[str, Any]) -> ShadowOutcome:
+    """ShadowOutcome for an IRIS executor result dict."""
+    if not result.get("success"):
+        return ShadowOutcome(success=False, sqlstate=result.get("sqlstate"))
+    columns = result.get("columns") or []
+    type_oids = [column.get("type_oid") for column in columns]
+    rows = None
+    if columns:
+        rows = [
+            tuple(
+                normalize_value(value, type_oids[index] if index < len(type_oids) else None)
+                for index, value in enumerate(row)
+            )
+            for row in result.get("rows") or []
+        ]
+    return ShadowOutcome(success=True, row_count=result.get("row_count"), rows=rows)
+
+
+def compare_outcomes(sql: str, iris: ShadowOutcome, postgres: ShadowOutcome) -> list[str]:
+    """
+    Differences between the IRIS and PostgreSQL outcomes of a statement.
+
+    Returns:
+        List of "kind: detail" strings (empty when they agree)
+    """
+    if iris.success != postgres.success:
+        if iris.success:
+            return [f"error: only PostgreSQL failed (SQLSTATE {postgres.sqlstate})"]
+        return [f"error: only IRIS failed (SQLSTATE {iris.sqlstate})"]
+    if not iris.success:
+        if iris.sqlstate != postgres.sqlstate:
+            return [f"sqlstate: IRIS {iris.sqlstate}, PostgreSQL {postgres.sqlstate}"]
+        return []
+
+    differences = []
+    if iris.rows is None or postgres.rows is None:
+        if iris.row_count != postgres.row_count:
+            differences.append(f"row_count: IRIS {iris.row_count}, PostgreSQL {postgres.row_count}")
+        return differences
+
+    if len(iris.rows) != len(postgres.rows):
+        differences.append(f"row_count: IRIS {len(iris.rows)}, PostgreSQL {len(postgres.rows)}")
+        return differences
+
+    iris_rows, pg_rows = iris.rows, postgres.rows
+    if not re.search(r"\bORDER\s+BY\b", sql, re.IGNORECASE):
+        iris_rows = sorted(iris_rows, key=repr)
+        pg_rows = sorted(pg_rows, key=repr)
+    for index, (iris_row, pg_row) in enumerate(zip(iris_rows, pg_rows)):
+        if iris_row != pg_row:
+            differences.append(f"values: row {index}: IRIS {iris_row!r}, PostgreSQL {pg_row!r}")
+            break
+    return differences
+
+
+class ShadowComparator:
+    """Run statements on a shadow PostgreSQL and log differences."""
+
+    def __init__(
+        self,
+        dsn: str,
+        shadow_writes: bool = False,
+        sample_rate: float = 1.0,
+        max_pending: int = 100,
+        connect=None,
+    ):
+        self.dsn = dsn
+        self.shadow_writes = shadow_writes
+        self.sample_rate = sample_rate
+        self.max_pending = max_pending
+        self.stats = ShadowStats()
+        self._connect = connect
+        self._local = threading.local()
+        self._pending = 0
+        self._pool = concurrent.futures.ThreadPoolExecutor(
+            max_workers=2, thread_name_prefix="pgwire-shadow"
+        )
+
+    @classmethod
+    def from_env(cls) -> "ShadowComparator | None":
+        dsn = os.getenv("PGWIRE_SHADOW_DSN", "").strip()
+        if not dsn:
+            return None
+        comparator = cls(
+            dsn,
+            shadow_writes=os.getenv("PGWIRE_SHADOW_WRITES", "false").lower()
+            in ("1", "true", "yes", "on"),
+            sample_rate=float(os.getenv("PGWIRE_SHADOW_SAMPLE", "1.0")),
+            max_pending=int(os.getenv("PGWIRE_SHADOW_MAX_PENDING", "100")),
+        )
+        logger.info(
+            "Shadow comparison enabled",
+            writes=comparator.shadow_writes,
+            sample_rate=comparator.sample_rate,
+        )
+        return comparator
+
+    def should_shadow(self, sql: str) -> bool:
+        keyword = sql.lstrip(" \t\r\n(").split(None, 1)[0].upper() if sql.strip() else ""
+        if keyword in TRANSACTION_PREFIXES:
+            return False
+        if not self.shadow_writes and keyword not in READ_ONLY_PREFIXES:
+            return False
+        return self.sample_rate >= 1.0 or random.random() < self.sample_rate
+
+    def _connection(self):
+        connection = getattr(self._local, "connection", None)
+        if connection is None or getattr(connection, "closed", False):
+            if self._connect is None:
+                import psycopg2
+
+                self._connect = psycopg2.connect
+            connection = self._connect(self.dsn)
+            connection.autocommit = True
+            self._local.connection = connection
+        return connection
+
+    def run_on_postgres(self, sql: str, params: list | None) -> ShadowOutcome:
+        """Execute a statement on the shadow instance (blocking)."""
+        statement, arguments = to_pyformat(sql.strip().rstrip(";"), params)
+        cursor = self._connection().cursor()
+        try:
+            cursor.execute(statement, arguments)
+            if cursor.description is None:
+                return ShadowOutcome(success=True, row_count=cursor.rowcount)
+            rows = [tuple(normalize_value(value) for value in row) for row in cursor.fetchall()]
+            return ShadowOutcome(success=True, row_count=len(rows), rows=rows)
+        except Exception as e:
+            sqlstate = getattr(e, "pgcode", None)
+            if sqlstate is None:
+                raise
+            return ShadowOutcome(success=False, sqlstate=sqlstate)
+        finally:
+            cursor.close()
+
+    def compare(self, sql: str, params: list | None, result: dict[str, Any]) -> list[str]:
+        """Run on PostgreSQL, diff against the IRIS result and log (blocking)."""
+        try:
+            postgres = self.run_on_postgres(sql, params)
+        except Exception as e:
+            self.stats.shadow_errors += 1
+            logger.warning("Shadow execution failed", sql=sql[:200], error=str(e))
+            return []
+
+        differences = compare_outcomes(sql, outcome_from_result(result), postgres)
+        self.stats.compared += 1
+        if differences:
+            self.stats.mismatched += 1
+            for difference in differences:
+                kind = difference.split(":", 1)[0]
+                self.stats.mismatches_by_kind[kind] = self.stats.mismatches_by_kind.get(kind, 0) + 1
+            logger.warning(
+                "Shadow comparison mismatch",
+                sql=sql[:500],
+                differences=differences,
+            )
+        else:
+            self.stats.matched += 1
+        return differences
+
+    def submit(self, sql: str, params: list | None, result: dict[str, Any]) -> None:
+        """Schedule a background comparison (no-op for skipped/sampled-out statements)."""
+        if not self.should_shadow(sql):
+            return
+        if self._pending >= self.max_pending:
+            self.stats.dropped += 1
+            return
+
+        self._pending += 1
+        loop = asyncio.get_running_loop()
+        future = loop.run_in_executor(self._pool, self.compare, sql, params, result)
+
+        def _done(_):
+            self._pending -= 1
+
+        future.add_done_callback(_done)
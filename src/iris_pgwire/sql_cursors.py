@@ -0,0 +1,520 @@
+"""
+SQL Cursors (DECLARE / FETCH / MOVE / CLOSE)
+
+psycopg2 named cursors, pgJDBC-style batch readers and hand-written reports
+page through large results with
+
+    DECLARE name [ASENSITIVE | INSENSITIVE] [[NO] SCROLL] CURSOR [WITH[OUT] HOLD] FOR query
+    FETCH [direction] [FROM | IN] name
+    MOVE  [direction] [FROM | IN] name
+    CLOSE name | ALL
+
+where direction is NEXT, PRIOR, FIRST, LAST, ABSOLUTE n, RELATIVE n, n, ALL,
+FORWARD [n | ALL] or BACKWARD [n | ALL].
+
+A cursor keeps its query's IRIS result set open and reads only as far as
+FETCH asks (IRISExecutor.open_cursor_stream): FETCH FORWARD 2000 pulls 2000
+rows, MOVE skips rows in batches without keeping them, so a multi-million-row
+result is never held in memory at once. In external mode each open cursor
+holds a pooled IRIS connection until it is closed; PGWIRE_MAX_OPEN_CURSORS
+(default 16) caps how many one session may have open. In embedded mode, and
+for queries the bridge answers itself (pg_catalog, information_schema,
+virtual tables), the result is read whole when the cursor is declared.
+
+IRIS result sets read forward only. A SCROLL cursor keeps the rows it has
+read in a temporary spool (temp_spool.py), which spills to disk beyond
+PGWIRE_SPOOL_MEMORY_BYTES, and serves the backward directions from it. Other
+cursors reject a direction that would move back (PRIOR, BACKWARD, LAST,
+FETCH 0, an earlier ABSOLUTE or RELATIVE row) with 55000, as PostgreSQL does
+for NO SCROLL cursors.
+
+As in PostgreSQL, a cursor without HOLD can only be declared in a
+transaction block and is closed when it ends; a WITH HOLD cursor stays open
+after COMMIT (ROLLBACK closes the ones declared in the rolled back
+transaction) until CLOSE or the end of the session. At COMMIT a held
+cursor's remaining rows are read into a spool and its IRIS result set is
+closed, so held cursors do not keep IRIS connections. BINARY cursors are
+rejected.
+
+Cursors are declared and fetched with simple Query messages.
+"""
+
+import itertools
+import os
+import re
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .temp_spool import SpoolConfig, TempFileLimitExceeded, TempSpool
+
+logger = structlog.get_logger()
+
+DEFAULT_MAX_OPEN_CURSORS = 16
+
+# Rows read from IRIS at a time for FETCH ALL and MOVE
+BATCH_SIZE = 10000
+
+_NAME = r"(?:\"(?:[^\"]|\"\")+\"|[A-Za-z_][\w$]*)"
+
+_DECLARE = re.compile(
+    rf"^\s*DECLARE\s+(?P<name>{_NAME})\s+"
+    r"(?P<options>(?:(?:BINARY|ASENSITIVE|INSENSITIVE|NO\s+SCROLL|SCROLL)\s+)*)"
+    r"CURSOR\s+(?:(?P<hold>WITH|WITHOUT)\s+HOLD\s+)?FOR\s+(?P<query>.+?)\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_FETCH = re.compile(
+    r"^\s*(?P<command>FETCH|MOVE)\s+(?:(?P<direction>.*?)\s+)??"
+    rf"(?:(?:FROM|IN)\s+)?(?P<name>{_NAME})\s*;?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_CLOSE = re.compile(rf"^\s*CLOSE\s+(?P<name>{_NAME})\s*;?\s*$", re.IGNORECASE)
+
+_CURSOR_QUERY = re.compile(r"\s*\(*\s*(SELECT|WITH|VALUES|TABLE)\b", re.IGNORECASE)
+
+# Queries answered by the bridge's catalog emulation rather than IRIS
+_CATALOG_REFERENCE = re.compile(r"\b(?:pg_\w+|information_schema)\b", re.IGNORECASE)
+
+_INTEGER = re.compile(r"[+-]?\d+")
+
+# Directions written without a count
+_FIXED_DIRECTIONS = {
+    "NEXT": ("FORWARD", 1),
+    "PRIOR": ("BACKWARD", 1),
+    "FIRST": ("ABSOLUTE", 1),
+    "LAST": ("ABSOLUTE", -1),
+    "ALL": ("FORWARD", None),
+}
+
+
+class CursorError(Exception):
+    """A cursor statement that fails, with its SQLSTATE."""
+
+    def __init__(self, message: str, sqlstate: str, condition_name: str):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+        self.condition_name = condition_name
+
+
+@dataclass
+class DeclareCursor:
+    name: str
+    query: str
+    hold: bool = False
+    scroll: bool = False
+
+
+@dataclass
+class FetchCursor:
+    name: str
+    count: int | None  # None = ALL; the row number for ABSOLUTE, the offset for RELATIVE
+    move: bool = False
+    direction: str = "FORWARD"  # FORWARD, BACKWARD, ABSOLUTE or RELATIVE
+
+    @property
+    def command(self) -> str:
+        return "MOVE" if self.move else "FETCH"
+
+
+@dataclass
+class CloseCursor:
+    name: str | None  # None = ALL
+
+
+def _cursor_name(text: str) -> str:
+    if text.startswith('"'):
+        return text[1:-1].replace('""', '"')
+    return text.lower()
+
+
+def _direction(text: str) -> tuple[str, int | None]:
+    """(FORWARD, BACKWARD, ABSOLUTE or RELATIVE, count) of a FETCH/MOVE direction."""
+    words = text.split()
+    if not words:
+        return "FORWARD", 1
+    if len(words) == 1 and words[0].upper() in _FIXED_DIRECTIONS:
+        return _FIXED_DIRECTIONS[words[0].upper()]
+    direction = "FORWARD"
+    if words[0].upper() in ("FORWARD", "BACKWARD", "ABSOLUTE", "RELATIVE"):
+        direction, words = words[0].upper(), words[1:]
+        if not words and direction in ("FORWARD", "BACKWARD"):
+            return direction, 1
+    if len(words) == 1 and words[0].upper() == "ALL" and direction in ("FORWARD", "BACKWARD"):
+        return direction, None
+    if len(words) != 1 or not _INTEGER.fullmatch(words[0]):
+        word = words[0] if words else text.split()[-1]
+        raise CursorError(f'syntax error at or near "{word}"', "42601", "syntax_error")
+    count = int(words[0])
+    if direction in ("FORWARD", "BACKWARD"):
+        if count == 0:
+            return "RELATIVE", 0  # The current row again
+        if count < 0:
+            return ("BACKWARD" if direction == "FORWARD" else "FORWARD"), -count
+    return direction, count
+
+
+def parse_cursor_statement(sql: str) -> DeclareCursor | FetchCursor | CloseCursor | None:
+    """
+    Parse DECLARE ... CURSOR, FETCH, MOVE or CLOSE; None for other statements.
+
+    Raises:
+        CursorError: a cursor statement this bridge cannot run
+    """
+    keyword = sql.lstrip()[:8].upper()
+    if keyword.startswith("DECLARE"):
+        match = _DECLARE.match(sql)
+        if not match:
+            return None
+        options = match.group("options").upper().split()
+        if "BINARY" in options:
+            raise CursorError("BINARY cursors are not supported", "0A000", "feature_not_supported")
+        query = match.group("query")
+        if not _CURSOR_QUERY.match(query):
+            word = query.split()[0]
+            raise CursorError(f'syntax error at or near "{word}"', "42601", "syntax_error")
+        return DeclareCursor(
+            _cursor_name(match.group("name")),
+            query,
+            hold=(match.group("hold") or "").upper() == "WITH",
+            scroll="SCROLL" in options and "NO" not in options,
+        )
+    if keyword.startswith(("FETCH", "MOVE")):
+        match = _FETCH.match(sql)
+        if not match:
+            return None
+        direction, count = _direction(match.group("direction") or "")
+        return FetchCursor(
+            _cursor_name(match.group("name")),
+            count,
+            move=match.group("command").upper() == "MOVE",
+            direction=direction,
+        )
+    if keyword.startswith("CLOSE"):
+        match = _CLOSE.match(sql)
+        if not match:
+            return None
+        name = match.group("name")
+        return CloseCursor(None if name.upper() == "ALL" else _cursor_name(name))
+    return None
+
+
+def references_catalog(sql: str) -> bool:
+    """Whether a query reads pg_catalog or information_schema relations."""
+    return bool(_CATALOG_REFERENCE.search(sql))
+
+
+def cursor_query_error(result: dict[str, Any]) -> CursorError:
+    """CursorError for the failed executor result of a cursor's query."""
+    return CursorError(
+        result.get("error", "Query execution failed"),
+        result.get("sqlstate", "42000"),
+        result.get("condition_name", "syntax_error"),
+    )
+
+
+@dataclass
+class ResultStream:
+    """An open result set read in pieces."""
+
+    columns: list[dict[str, Any]]
+    fetch: Callable[[int], Awaitable[list]]  # Up to n more rows, [] once exhausted
+    close: Callable[[], Awaitable[None]]
+
+
+def materialized_stream(result: dict[str, Any]) -> ResultStream:
+    """ResultStream over an executor result already held in memory."""
+    rows = iter(result.get("rows") or [])
+
+    async def fetch(count: int) -> list:
+        return list(itertools.islice(rows, count))
+
+    async def close() -> None:
+        pass
+
+    return ResultStream(result.get("columns") or [], fetch, close)
+
+
+def spooled_stream(columns: list[dict[str, Any]], spool: TempSpool) -> ResultStream:
+    """ResultStream over the rows of a spool, in order; closing it deletes the spool."""
+    position = 0
+
+    async def fetch(count: int) -> list:
+        nonlocal position
+        rows = spool.rows(position, position + count)
+        position += len(rows)
+        return rows
+
+    async def close() -> None:
+        spool.close()
+
+    return ResultStream(columns, fetch, close)
+
+
+def _scan_forward_only() -> CursorError:
+    return CursorError("cursor can only scan forward", "55000", "object_not_in_prerequisite_state")
+
+
+class SqlCursor:
+    """One declared cursor and the number of rows read from it."""
+
+    def __init__(
+        self,
+        name: str,
+        stream: ResultStream,
+        hold: bool = False,
+        scroll: bool = False,
+        spool_config: SpoolConfig | None = None,
+    ):
+        self.name = name
+        self.stream = stream
+        self.hold = hold
+        self.scroll = scroll
+        self.spool_config = spool_config
+        self.committed = False  # Survived a COMMIT (WITH HOLD)
+        self.position = 0  # Number of the current row (0 = before the first)
+        self._exhausted = False
+        self._after_end = False  # Positioned after the last row
+        self._spool = TempSpool(spool_config) if scroll else None  # Rows read (SCROLL)
+
+    @property
+    def columns(self) -> list[dict[str, Any]]:
+        return self.stream.columns
+
+    async def fetch(self, count: int | None) -> list:
+        """The next count rows (all remaining for None)."""
+        rows = []
+        while not self._exhausted and (count is None or len(rows) < count):
+            wanted = BATCH_SIZE if count is None else count - len(rows)
+            batch = await self.stream.fetch(wanted)
+            if not batch:
+                self._exhausted = self._after_end = True
+            rows.extend(batch)
+        self.position += len(rows)
+        return rows
+
+    async def move(self, count: int | None) -> int:
+        """Skip count rows (all remaining for None) without keeping them."""
+        moved = 0
+        while not self._exhausted and (count is None or moved < count):
+            wanted = BATCH_SIZE if count is None else min(count - moved, BATCH_SIZE)
+            batch = await self.stream.fetch(wanted)
+            if not batch:
+                self._exhausted = self._after_end = True
+            moved += len(batch)
+        self.position += moved
+        return moved
+
+    async def execute(self, statement: FetchCursor) -> tuple[list, int]:
+        """
+        Run a FETCH or MOVE.
+
+        Returns:
+            (rows fetched - none for MOVE, row count of the command tag)
+
+        Raises:
+            CursorError: a backward move on a cursor without SCROLL (55000), or
+                a spool over PGWIRE_SPOOL_MAX_BYTES (53400)
+        """
+        try:
+            if self._spool is not None:
+                rows, count = await self._scroll(statement.direction, statement.count)
+            else:
+                rows, count = await self._forward(statement)
+        except TempFileLimitExceeded as e:
+            raise CursorError(str(e), e.sqlstate, e.condition_name) from e
+        return ([] if statement.move else rows), count
+
+    async def _forward(self, statement: FetchCursor) -> tuple[list, int]:
+        if statement.direction == "FORWARD":
+            if statement.move:
+                return [], await self.move(statement.count)
+            rows = await self.fetch(statement.count)
+            return rows, len(rows)
+        current = self.position + self._after_end
+        if statement.direction == "ABSOLUTE":
+            target = statement.count
+        elif statement.direction == "RELATIVE":
+            target = current + statement.count
+        else:
+            raise _scan_forward_only()
+        if target < 0 or target <= current:
+            raise _scan_forward_only()
+        await self.move(target - current - 1)
+        if statement.move:
+            return [], await self.move(1)
+        rows = await self.fetch(1)
+        return rows, len(rows)
+
+    async def _load(self, count: int | None) -> int:
+        """Read rows into the spool until it holds count (None = all); the rows held."""
+        spool = self._spool
+        while not self._exhausted and (count is None or len(spool) < count):
+            wanted = BATCH_SIZE if count is None else min(count - len(spool), BATCH_SIZE)
+            batch = await self.stream.fetch(wanted)
+            if not batch:
+                self._exhausted = True
+            spool.append(batch)
+        return len(spool)
+
+    async def _scroll(self, direction: str, count: int | None) -> tuple[list, int]:
+        """FETCH/MOVE on a SCROLL cursor, in PostgreSQL's position semantics."""
+        spool = self._spool
+        current = self.position + self._after_end
+        if direction == "FORWARD":
+            end = None if count is None else current + count
+            total = await self._load(end)
+            rows = spool.rows(current, end)
+            if count is None or len(rows) < count:
+                self.position, self._after_end = total, True
+            else:
+                self.position, self._after_end = end, False
+            return rows, len(rows)
+        if direction == "BACKWARD":
+            start = 0 if count is None else max(current - 1 - count, 0)
+            rows = spool.rows(start, current - 1)[::-1]
+            if count is None or len(rows) < count:
+                self.position = 0
+            else:
+                self.position = current - count
+            self._after_end = False
+            return rows, len(rows)
+
+        # ABSOLUTE and RELATIVE return the one row they land on
+        if direction == "ABSOLUTE" and count < 0:
+            target = await self._load(None) + 1 + count
+        else:
+            target = count if direction == "ABSOLUTE" else current + count
+        if target <= 0:
+            self.position, self._after_end = 0, False
+            return [], 0
+        total = await self._load(target)
+        if target > total:
+            self.position, self._after_end = total, True
+            return [], 0
+        self.position, self._after_end = target, False
+        return spool.rows(target - 1, target), 1
+
+    async def hold_rows(self) -> None:
+        """
+        Read the remaining rows into a spool and close the IRIS result set
+        (a WITH HOLD cursor at COMMIT).
+        """
+        if self._spool is not None:
+            await self._load(None)
+            spooled = materialized_stream({"columns": self.columns})
+        else:
+            spool = TempSpool(self.spool_config)
+            try:
+                while batch := await self.stream.fetch(BATCH_SIZE):
+                    spool.append(batch)
+            except BaseException:
+                spool.close()
+                raise
+            spooled = spooled_stream(self.columns, spool)
+        stream, self.stream = self.stream, spooled
+        await stream.close()
+        logger.debug("Held cursor rows spooled", cursor=self.name)
+
+    async def close(self) -> None:
+        try:
+            await self.stream.close()
+        finally:
+            if self._spool is not None:
+                self._spool.close()
+
+
+class SqlCursors:
+    """Declared cursors of one session."""
+
+    def __init__(self, max_open: int | None = None, spool_config: SpoolConfig | None = None):
+        if max_open is None:
+            max_open = int(os.getenv("PGWIRE_MAX_OPEN_CURSORS", str(DEFAULT_MAX_OPEN_CURSORS)))
+        self.max_open = max_open
+        self.spool_config = spool_config  # None = PGWIRE_SPOOL_* settings when a spool opens
+        self._cursors: dict[str, SqlCursor] = {}
+
+    def __contains__(self, name: str) -> bool:
+        return name in self._cursors
+
+    def __len__(self) -> int:
+        return len(self._cursors)
+
+    async def declare(
+        self, statement: DeclareCursor, open_stream: Callable[[], Awaitable[ResultStream]]
+    ) -> SqlCursor:
+        """
+        Open a cursor's query and register it.
+
+        Raises:
+            CursorError: the name is taken, too many cursors are open or the
+                query fails
+        """
+        if statement.name in self._cursors:
+            raise CursorError(
+                f'cursor "{statement.name}" already exists', "42P03", "duplicate_cursor"
+            )
+        if len(self._cursors) >= self.max_open:
+            raise CursorError(
+                f"too many open cursors (PGWIRE_MAX_OPEN_CURSORS = {self.max_open})",
+                "54000",
+                "program_limit_exceeded",
+            )
+        cursor = SqlCursor(
+            statement.name, await open_stream(), statement.hold, statement.scroll, self.spool_config
+        )
+        self._cursors[statement.name] = cursor
+        logger.debug(
+            "Cursor declared", cursor=statement.name, hold=statement.hold, scroll=statement.scroll
+        )
+        return cursor
+
+    def get(self, name: str) -> SqlCursor:
+        """
+        Raises:
+            CursorError: no cursor of that name is open (34000)
+        """
+        cursor = self._cursors.get(name)
+        if cursor is None:
+            raise CursorError(f'cursor "{name}" does not exist', "34000", "invalid_cursor_name")
+        return cursor
+
+    async def close(self, name: str) -> None:
+        """
+        Raises:
+            CursorError: no cursor of that name is open (34000)
+        """
+        cursor = self.get(name)
+        del self._cursors[name]
+        await self._close(cursor)
+
+    async def close_all(self) -> None:
+        cursors, self._cursors = list(self._cursors.values()), {}
+        for cursor in cursors:
+            await self._close(cursor)
+
+    async def end_transaction(self, committed: bool) -> None:
+        """
+        Close the cursors the transaction's end closes; WITH HOLD ones survive
+        COMMIT with their rows spooled (one whose rows exceed
+        PGWIRE_SPOOL_MAX_BYTES is closed).
+        """
+        for name, cursor in list(self._cursors.items()):
+            if cursor.hold and cursor.committed:
+                continue
+            if cursor.hold and committed:
+                try:
+                    await cursor.hold_rows()
+                    cursor.committed = True
+                    continue
+                except Exception as e:
+                    logger.warning("Held cursor closed at COMMIT", cursor=name, error=str(e))
+            del self._cursors[name]
+            await self._close(cursor)
+
+    async def _close(self, cursor: SqlCursor) -> None:
+        try:
+            await cursor.close()
+        except Exception as e:
+            logger.warning("Cursor result set not closed", cursor=cursor.name, error=str(e))
@@ -0,0 +1,152 @@
+"""
+Lexical Helpers for SQL Text
+
+Small scanners shared by code that takes SQL apart without the translator's
+parser: finding a keyword or operator outside string literals and the operand
+in front of it, and splitting column, argument and option lists.
+"""
+
+import re
+
+# Type keyword in front of a typed literal operand (TIMESTAMP '2024-01-01 00:00')
+_TYPED_LITERAL_PREFIX = re.compile(
+    r"(TIMESTAMPTZ|TIMESTAMP\s+WITH\s+TIME\s+ZONE|TIMESTAMP\s+WITHOUT\s+TIME\s+ZONE|TIMESTAMP)\s*$",
+    re.IGNORECASE,
+)
+
+
+def _literal_spans(sql: str) -> list[tuple[int, int]]:
+    spans = []
+    i = 0
+    while i < len(sql):
+        if sql[i] == "'":
+            start = i
+            i += 1
+            while i < len(sql):
+                if sql[i] == "'" and i + 1 < len(sql) and sql[i + 1] == "'":
+                    i += 2
+                    continue
+                if sql[i] == "'":
+                    break
+                i += 1
+            spans.append((start, i))
+        i += 1
+    return spans
+
+
+def find_outside_literals(pattern: re.Pattern, sql: str, pos: int) -> re.Match | None:
+    """First match of pattern at or after pos that is not inside a string literal."""
+    spans = _literal_spans(sql)
+    for match in pattern.finditer(sql, pos):
+        if not any(start <= match.start() <= end for start, end in spans):
+            return match
+    return None
+
+
+def read_string_literal(sql: str, pos: int) -> str | None:
+    """The string literal starting at pos, quotes included, or None."""
+    if pos >= len(sql) or sql[pos] != "'":
+        return None
+    i = pos + 1
+    while i < len(sql):
+        if sql[i] == "'":
+            if i + 1 < len(sql) and sql[i + 1] == "'":
+                i += 2
+                continue
+            return sql[pos : i + 1]
+        i += 1
+    return None
+
+
+def operand_start(sql: str, end: int) -> int | None:
+    """
+    Scan backwards from `end` to the start of the operand expression: a
+    parenthesized expression or function call, a (typed) string literal, or
+    an identifier chain. None if there is no operand.
+    """
+    i = end - 1
+    while i >= 0 and sql[i].isspace():
+        i -= 1
+    if i < 0:
+        return None
+
+    if sql[i] == ")":
+        depth = 0
+        in_literal = False
+        while i >= 0:
+            char = sql[i]
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == ")":
+                    depth += 1
+                elif char == "(":
+                    depth -= 1
+                    if depth == 0:
+                        break
+            i -= 1
+        if i < 0:
+            return None
+        # Include a function name (NOW, CAST, DATE_TRUNC, schema.func)
+        j = i
+        while j > 0 and sql[j - 1].isspace():
+            j -= 1
+        k = j
+        while k > 0 and (sql[k - 1].isalnum() or sql[k - 1] in "_."):
+            k -= 1
+        if k < j and not re.fullmatch(
+            r"(AND|OR|NOT|WHERE|SELECT|ON|WHEN|THEN|ELSE|IN|BY|AS)", sql[k:j], re.IGNORECASE
+        ):
+            return k
+        return i
+
+    if sql[i] == "'":
+        i -= 1
+        while i >= 0:
+            if sql[i] == "'":
+                if i > 0 and sql[i - 1] == "'":
+                    i -= 2
+                    continue
+                break
+            i -= 1
+        if i < 0:
+            return None
+        prefix = _TYPED_LITERAL_PREFIX.search(sql[:i])
+        return prefix.start() if prefix else i
+
+    # Identifier chain: col, t.col, "Col", schema.table.col
+    start = i + 1
+    while i >= 0 and (sql[i].isalnum() or sql[i] in '_."$'):
+        i -= 1
+    if i + 1 == start:
+        return None
+    return i + 1
+
+
+def split_top_level(text: str) -> list[str]:
+    """
+    Split on commas outside parentheses, string literals and quoted
+    identifiers, stripping each item (empty trailing item dropped).
+
+    split_top_level("a, f(b, c), 'd,e', \"f,g\"") == ["a", "f(b, c)", "'d,e'", '"f,g"']
+    """
+    items, depth, quote, current = [], 0, None, []
+    for char in text:
+        if quote:
+            # A doubled quote ('' or "") closes and reopens, which leaves it open
+            if char == quote:
+                quote = None
+        elif char in ("'", '"'):
+            quote = char
+        elif char == "(":
+            depth += 1
+        elif char == ")":
+            depth -= 1
+        elif char == "," and depth == 0:
+            items.append("".join(current).strip())
+            current = []
+            continue
+        current.append(char)
+    if "".join(current).strip():
+        items.append("".join(current).strip())
+    return items
@@ -13,7 +13,10 @@ Constitutional Requirements:
 """
 
 from .date_translator import DATETranslator
+from .datetime_function_translator import DateTimeFunctionTranslator
+from .fts_translator import FullTextSearchTranslator
 from .identifier_normalizer import IdentifierNormalizer
+from .ifind_translator import IFindTranslator
 from .models import (
     ConstructMapping,
     PerformanceStats,
@@ -25,8 +28,12 @@ from .models import (
 # Feature 021: PostgreSQL-Compatible SQL Normalization
 from .normalizer import SQLTranslator
 
+# AT TIME ZONE → IRIS DATEADD translation
+from .timezone_translator import TimeZoneTranslator
+
 # Feature 022: PostgreSQL Transaction Verb Compatibility
 from .transaction_translator import TransactionTranslator
+from .trigram_translator import TrigramTranslator
 from .translator import IRISSQLTranslator, TranslationContext, get_translator, translate_sql
 from .validator import ValidationContext, ValidationLevel
 
@@ -48,6 +55,11 @@ __all__ = [
     "SQLTranslator",
     "IdentifierNormalizer",
     "DATETranslator",
+    "TimeZoneTranslator",
+    "DateTimeFunctionTranslator",
+    "TrigramTranslator",
+    "FullTextSearchTranslator",
+    "IFindTranslator",
     # PostgreSQL → IRIS transaction verb translation (Feature 022)
     "TransactionTranslator",
 ]
@@ -0,0 +1,293 @@
+"""
+Integer Arithmetic Translator for PostgreSQL-Compatible SQL
+
+PostgreSQL divides integers as integers, truncating toward zero (7 / 2 = 3,
+-7 / 2 = -3), and its % takes the sign of the dividend (-7 % 2 = -1). IRIS
+SQL's / always returns the exact quotient (3.5), and % is not an arithmetic
+operator there. Computed columns would silently differ, so:
+
+- a / b where both operands are integers → (a \\ b), IRIS's integer division,
+  which truncates toward zero as PostgreSQL does
+- a % b → (a - b * (a \\ b)), the remainder with the sign of the dividend,
+  for integer and numeric operands alike
+- a ^ b next to / or % → (a ^ b): ^ binds tighter than * / % in PostgreSQL
+  (2^3 / 2 = 4), and its double precision result is divided exactly
+
+An operand is known to be an integer when it is an integer literal, a CAST
+or ::cast to an integer type, COUNT(...), or an arithmetic expression of such
+operands; column types are not known at translation time, so a / b over
+integer columns keeps IRIS's exact quotient. Division and modulo by zero fail
+in IRIS with <DIVIDE>, reported to clients as 22012 (division_by_zero) with
+PostgreSQL's message by annotate_division_by_zero.
+
+% between string literals (pg_trgm similarity) is translated before this
+step (trigram_translator.py); %-prefixed IRIS names (%ID, %EXACT) are not
+operators.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without / or
+  % are returned after a substring check)
+"""
+
+import re
+from typing import Any
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<op>::|\|\||<=|>=|<>|!=|[-+*/%\\^(),.?;=<>])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+_INTEGER_TYPES = frozenset(
+    {"INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "INT2", "INT4", "INT8"}
+)
+
+# Words that end an operand rather than being one
+_KEYWORDS = frozenset(
+    {
+        "SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "ON", "AS", "BY", "CASE", "WHEN",
+        "THEN", "ELSE", "END", "IS", "IN", "LIKE", "BETWEEN", "HAVING", "GROUP", "ORDER",
+        "LIMIT", "OFFSET", "UNION", "ALL", "DISTINCT", "SET", "VALUES", "RETURNING",
+        "ASC", "DESC", "INTERVAL", "NULL",
+    }
+)
+
+_DIVISION_BY_ZERO = re.compile(r"<DIVIDE>")
+
+SQLSTATE_DIVISION_BY_ZERO = "22012"
+
+
+def annotate_division_by_zero(result: dict[str, Any]) -> dict[str, Any]:
+    """Report a failed statement's IRIS <DIVIDE> error as division_by_zero."""
+    if result.get("success") or result.get("sqlstate"):
+        return result
+    if _DIVISION_BY_ZERO.search(str(result.get("error") or "")):
+        result["error"] = "division by zero"
+        result["sqlstate"] = SQLSTATE_DIVISION_BY_ZERO
+        result["condition_name"] = "division_by_zero"
+    return result
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _is_name(token) -> bool:
+    return token[0] == "word" and token[1].upper() not in _KEYWORDS
+
+
+def _is_value(token) -> bool:
+    return token[0] in ("string", "number") or token[1] == "?" or _is_name(token)
+
+
+def _matching(tokens, index: int, step: int) -> int | None:
+    """Index of the parenthesis matching tokens[index], searching by step (+1 / -1)."""
+    opening, closing = ("(", ")") if step > 0 else (")", "(")
+    depth = 0
+    while 0 <= index < len(tokens):
+        if tokens[index][1] == opening:
+            depth += 1
+        elif tokens[index][1] == closing:
+            depth -= 1
+            if depth == 0:
+                return index
+        index += step
+    return None
+
+
+def _term_start(tokens, end: int) -> int | None:
+    """First token of the operand ending at tokens[end] (postfix casts and signs included)."""
+    index = end
+    if index < 0:
+        return None
+    if tokens[index][1] == ")":
+        index = _matching(tokens, index, -1)
+        if index is None:
+            return None
+        if index > 0 and _is_name(tokens[index - 1]):
+            index -= 1  # Function call
+    elif _is_value(tokens[index]):
+        if index >= 2 and tokens[index - 1][1] == "::":
+            return _term_start(tokens, index - 2)
+        while index >= 2 and tokens[index - 1][1] == "." and _is_name(tokens[index - 2]):
+            index -= 2  # Qualified name
+    else:
+        return None
+    # A unary sign binds tighter than ^, and ^ tighter than * / %
+    while index > 0 and tokens[index - 1][1] in ("-", "+") and _is_unary(tokens, index - 1):
+        index -= 1
+    if index >= 2 and tokens[index - 1][1] == "^":
+        return _term_start(tokens, index - 2)
+    return index
+
+
+def _is_unary(tokens, sign: int) -> bool:
+    """Whether the + / - at tokens[sign] is a sign rather than a binary operator."""
+    return sign == 0 or not (tokens[sign - 1][1] == ")" or _is_value(tokens[sign - 1]))
+
+
+def _term_end(tokens, start: int) -> int | None:
+    """Last token of the operand starting at tokens[start]."""
+    index = start
+    while index < len(tokens) and tokens[index][1] in ("-", "+"):
+        index += 1
+    if index >= len(tokens):
+        return None
+    if tokens[index][1] == "(":
+        index = _matching(tokens, index, 1)
+    elif _is_value(tokens[index]):
+        while (
+            index + 2 < len(tokens) and tokens[index + 1][1] == "." and _is_name(tokens[index + 2])
+        ):
+            index += 2
+        if index + 1 < len(tokens) and tokens[index + 1][1] == "(" and _is_name(tokens[index]):
+            index = _matching(tokens, index + 1, 1)
+    else:
+        return None
+    while index is not None and index + 2 < len(tokens) and tokens[index + 1][1] == "::":
+        index += 2  # ::type
+    if index is not None and index + 2 < len(tokens) and tokens[index + 1][1] == "^":
+        return _term_end(tokens, index + 2)
+    return index
+
+
+def _is_integer(tokens) -> bool:
+    """Whether an operand's tokens are an integer-valued expression."""
+    if not tokens:
+        return False
+    if tokens[0][1] == "(" and _matching(tokens, 0, 1) == len(tokens) - 1:
+        return _is_integer(tokens[1:-1])
+    # Split at top-level arithmetic operators; every term must be an integer
+    terms, current, depth = [], [], 0
+    for token in tokens:
+        if token[1] == "(":
+            depth += 1
+        elif token[1] == ")":
+            depth -= 1
+        if depth == 0 and token[1] in ("+", "-", "*", "/", "\\", "%"):
+            if current:
+                terms.append(current)
+            current = []
+            continue
+        current.append(token)
+    if current:
+        terms.append(current)
+    if len(terms) > 1:
+        return all(_is_integer(term) for term in terms)
+    term = terms[0] if terms else []
+    if not term:
+        return False
+    if len(term) >= 3 and term[-2][1] == "::":
+        return term[-1][1].upper() in _INTEGER_TYPES
+    if len(term) == 1:
+        return term[0][0] == "number" and term[0][1].isdigit()
+    name = term[0][1].upper()
+    if len(term) > 2 and term[1][1] == "(" and _matching(term, 1, 1) == len(term) - 1:
+        if name == "COUNT":
+            return True
+        if name == "CAST" and term[-3][1].upper() == "AS":
+            return term[-2][1].upper() in _INTEGER_TYPES
+        if name == "CAST" and len(term) > 4 and term[-4][1].upper() == "AS":
+            return term[-3][1].upper() in _INTEGER_TYPES  # CAST(x AS INTEGER(10))
+    return False
+
+
+def _has_power(tokens) -> bool:
+    """Whether an operand is a ^ exponentiation (outside parentheses)."""
+    depth = 0
+    for token in tokens:
+        if token[1] == "(":
+            depth += 1
+        elif token[1] == ")":
+            depth -= 1
+        elif depth == 0 and token[1] == "^":
+            return True
+    return False
+
+
+def _grouped(text: str) -> str:
+    """
+    text as an operand of a larger expression: single terms as they are,
+    others in (), and ^ in () as well, which IRIS would otherwise evaluate
+    left to right with the operators around it.
+    """
+    tokens = _tokens(text)
+    if (
+        tokens
+        and tokens[0][1] not in ("-", "+")
+        and _term_end(tokens, 0) == len(tokens) - 1
+        and not _has_power(tokens)
+    ):
+        return text
+    return f"({text})"
+
+
+class ArithmeticTranslator:
+    """Rewrites integer division and modulo to PostgreSQL's semantics."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate / of integers and % to IRIS integer division.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, number of operators rewritten)
+        """
+        if "/" not in sql and "%" not in sql:
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            tokens = _tokens(sql)
+            position = next(
+                (
+                    i
+                    for i, token in enumerate(tokens)
+                    if token[2] >= search_from and token[0] == "op" and token[1] in ("/", "%")
+                ),
+                None,
+            )
+            if position is None:
+                return sql, count
+            operator = tokens[position]
+            search_from = operator[3]
+
+            # Left operand: the terms of * / % before the operator (left-associative)
+            left = _term_start(tokens, position - 1)
+            while left is not None and left > 1 and tokens[left - 1][1] in ("*", "/", "\\"):
+                left = _term_start(tokens, left - 2)
+            right = _term_end(tokens, position + 1)
+            if left is None or right is None:
+                continue
+
+            left_text = sql[tokens[left][2] : tokens[position - 1][3]]
+            right_text = sql[tokens[position + 1][2] : tokens[right][3]]
+            dividend, divisor = _grouped(left_text), _grouped(right_text)
+            if operator[1] == "/":
+                if _is_integer(tokens[left:position]) and _is_integer(
+                    tokens[position + 1 : right + 1]
+                ):
+                    replacement = f"({dividend} \\ {divisor})"
+                elif _has_power(tokens[left : right + 1]):
+                    # ^ returns double precision: an exact quotient, ^ grouped first
+                    replacement = f"{dividend} / {divisor}"
+                else:
+                    continue
+            else:
+                replacement = f"({dividend} - {divisor} * ({dividend} \\ {divisor}))"
+            start, end = tokens[left][2], tokens[right][3]
+            sql = sql[:start] + replacement + sql[end:]
+            search_from = start + len(replacement)
+            count += 1
@@ -0,0 +1,244 @@
+"""
+Array Constructor and Subscript Translator for PostgreSQL-Compatible SQL
+
+IRIS has no SQL array type; the bridge's array encoding is the %List
+($LISTBUILD) value, which result columns send to clients as text[] literals
+(iris_list.py). PostgreSQL array syntax is rewritten onto %List functions:
+
+- ARRAY[a, b, c]            → $LISTBUILD(a, b, c) (a trailing ::type[] cast
+                              is dropped)
+- x = ANY(ARRAY[a, b])      → x IN (a, b)
+- x <> ALL(ARRAY[a, b])     → x NOT IN (a, b)
+- col[n]                    → $LISTGET(col, n) (1-based like PostgreSQL; a
+                              missing element is NULL)
+- col[m:n], col[m:], col[:n] → $LIST(col, m, n) with 1 and -1 (the last
+                              element) for omitted bounds
+
+Subscripts apply to column names, qualified names, function calls and
+parenthesized expressions. Multi-dimensional constructors (ARRAY[[1, 2]]) and
+ARRAY(SELECT ...) are left unchanged, as are brackets holding a string
+literal or a keyword: IRIS uses [ (contains) and ] (follows) as operators.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without [ are
+  returned after a substring check)
+"""
+
+import re
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<op>::|<>|!=|[-+*/%(),.?;:=<>\[\]$])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+# Words that cannot be subscripted, nor appear in a subscript
+_KEYWORDS = frozenset(
+    {
+        "SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "ON", "AS", "BY", "CASE", "WHEN",
+        "THEN", "ELSE", "END", "IS", "IN", "LIKE", "BETWEEN", "HAVING", "GROUP", "ORDER",
+        "LIMIT", "OFFSET", "UNION", "ALL", "ANY", "SOME", "DISTINCT", "SET", "VALUES",
+        "RETURNING", "ASC", "DESC", "NULL", "ARRAY",
+    }
+)
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _is_name(token) -> bool:
+    return token[0] == "word" and token[1].upper() not in _KEYWORDS
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ] or ) closing the bracket at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text in ("(", "["):
+            depth += 1
+        elif text in (")", "]"):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _opening(tokens, index: int) -> int | None:
+    """Index of the ( opening the parenthesis closed at tokens[index]."""
+    depth = 0
+    for position in range(index, -1, -1):
+        text = tokens[position][1]
+        if text in (")", "]"):
+            depth += 1
+        elif text in ("(", "["):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _split(tokens) -> list[list]:
+    """Top-level comma-separated groups of tokens."""
+    groups, current, depth = [], [], 0
+    for token in tokens:
+        if token[1] in ("(", "["):
+            depth += 1
+        elif token[1] in (")", "]"):
+            depth -= 1
+        elif token[1] == "," and depth == 0:
+            groups.append(current)
+            current = []
+            continue
+        current.append(token)
+    groups.append(current)
+    return groups
+
+
+class ArrayTranslator:
+    """Rewrites ARRAY[...] constructors and subscripts onto %List functions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate array constructors, subscripts and slices.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, number of constructs rewritten)
+        """
+        if "[" not in sql:
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            tokens = _tokens(sql)
+            position = next(
+                (
+                    i
+                    for i, token in enumerate(tokens)
+                    if token[2] >= search_from and token[1] == "["
+                ),
+                None,
+            )
+            if position is None:
+                return sql, count
+            search_from = tokens[position][3]
+
+            if position > 0 and tokens[position - 1][1].upper() == "ARRAY":
+                rewrite = self._constructor(sql, tokens, position)
+                close = _closing(tokens, position)
+                if rewrite is None and close is not None:
+                    search_from = tokens[close][3]  # Left whole, nested constructors included
+            else:
+                rewrite = self._subscript(sql, tokens, position)
+            if rewrite is None:
+                continue
+            start, end, replacement, rewritten = rewrite
+            sql = sql[:start] + replacement + sql[end:]
+            search_from = start
+            count += rewritten
+
+    def _constructor(self, sql: str, tokens, position: int) -> tuple[int, int, str, int] | None:
+        close = _closing(tokens, position)
+        if close is None:
+            return None
+        inner = tokens[position + 1 : close]
+        if any(
+            token[1].upper() == "ARRAY" and following[1] == "["
+            for token, following in zip(inner, inner[1:], strict=False)
+        ):
+            return None  # Multi-dimensional
+        # Subscripts inside the elements (ARRAY[a[1], b]) first
+        inner_sql, rewritten = "", 0
+        if inner:
+            inner_sql, rewritten = self.translate(sql[inner[0][2] : inner[-1][3]])
+        inner = _tokens(inner_sql)
+        if any(token[1] == "[" for token in inner):
+            return None  # Multi-dimensional (ARRAY[[1, 2]])
+        groups = _split(inner) if inner else []
+        if not all(groups):
+            return None  # Empty element (ARRAY[1,,2])
+        elements = [inner_sql[group[0][2] : group[-1][3]] for group in groups]
+
+        start, end = tokens[position - 1][2], tokens[close][3]
+        # A cast to an array type (ARRAY[1, 2]::int[]) only names the element type
+        following = close + 1
+        if (
+            following + 3 < len(tokens)
+            and tokens[following][1] == "::"
+            and tokens[following + 1][0] == "word"
+            and tokens[following + 2][1] == "["
+            and tokens[following + 3][1] == "]"
+        ):
+            following += 4
+            end = tokens[following - 1][3]
+
+        # x = ANY(ARRAY[...]) / x <> ALL(ARRAY[...]) → IN lists
+        if (
+            elements
+            and position >= 4
+            and following < len(tokens)
+            and tokens[following][1] == ")"
+            and tokens[position - 2][1] == "("
+        ):
+            quantifier = tokens[position - 3][1].upper()
+            operator = tokens[position - 4][1]
+            span = tokens[position - 4][2], tokens[following][3]
+            if quantifier in ("ANY", "SOME") and operator == "=":
+                return *span, f"IN ({', '.join(elements)})", rewritten + 1
+            if quantifier == "ALL" and operator in ("<>", "!="):
+                return *span, f"NOT IN ({', '.join(elements)})", rewritten + 1
+
+        return start, end, f"$LISTBUILD({', '.join(elements)})", rewritten + 1
+
+    @staticmethod
+    def _subscript(sql: str, tokens, position: int) -> tuple[int, int, str, int] | None:
+        close = _closing(tokens, position)
+        if close is None or close == position + 1 or position == 0:
+            return None
+        inner = tokens[position + 1 : close]
+        for token in inner:
+            if token[0] in ("string", "other") or token[1] in ("[", ";", ","):
+                return None
+            if token[0] == "word" and not _is_name(token):
+                return None
+
+        # The subscripted operand: a (qualified) name, a call or a parenthesized expression
+        end = position - 1
+        if tokens[end][1] == ")":
+            start = _opening(tokens, end)
+            if start is None:
+                return None
+            if start > 0 and _is_name(tokens[start - 1]):
+                start -= 1
+        elif _is_name(tokens[end]):
+            start = end
+            while start >= 2 and tokens[start - 1][1] == "." and _is_name(tokens[start - 2]):
+                start -= 2
+        else:
+            return None
+        operand = sql[tokens[start][2] : tokens[end][3]]
+
+        colons = [i for i, token in enumerate(inner) if token[1] == ":"]
+        if not colons:
+            index = sql[inner[0][2] : inner[-1][3]]
+            return tokens[start][2], tokens[close][3], f"$LISTGET({operand}, {index})", 1
+        if len(colons) != 1:
+            return None
+        lower_tokens, upper_tokens = inner[: colons[0]], inner[colons[0] + 1 :]
+        lower = sql[lower_tokens[0][2] : lower_tokens[-1][3]] if lower_tokens else "1"
+        upper = sql[upper_tokens[0][2] : upper_tokens[-1][3]] if upper_tokens else "-1"
+        return tokens[start][2], tokens[close][3], f"$LIST({operand}, {lower}, {upper})", 1
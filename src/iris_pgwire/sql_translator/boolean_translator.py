@@ -0,0 +1,147 @@
+"""
+Boolean Literal Translator for PostgreSQL-Compatible SQL
+
+IRIS has no TRUE / FALSE literals: booleans are BIT values, 1 and 0.
+
+- TRUE / FALSE → 1 / 0 (WHERE active = TRUE, VALUES (1, FALSE),
+  SET flag = TRUE, DEFAULT FALSE)
+- 't'::bool, 'true'::boolean, CAST('yes' AS BOOLEAN) and the other text
+  boolin accepts → CAST(1 AS BIT), CAST(0 AS BIT)
+
+x IS [NOT] TRUE / FALSE is left alone, as are statements other than
+queries, DML and CREATE / ALTER TABLE and VIEW (SET and option lists such
+as EXPLAIN (ANALYZE TRUE) take the words as they are).
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without a
+  boolean literal are returned after a regex check)
+"""
+
+import re
+
+from ..bool_values import InvalidBoolean, parse_bool
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+(?:\.\d*)?)"
+    r"|(?P<op>::|[(),.;])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+_BOOLEAN_LITERAL = re.compile(r"\b(?:TRUE|FALSE)\b|::\s*bool|\bAS\s+(?:BOOL|BIT)", re.IGNORECASE)
+
+_BOOLEAN_TYPES = frozenset({"BOOL", "BOOLEAN", "BIT"})
+
+_STATEMENTS = frozenset({"SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "VALUES"})
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _is(token, *words: str) -> bool:
+    return token is not None and token[0] == "word" and token[1].upper() in words
+
+
+def _translated_statement(tokens) -> bool:
+    """Whether a statement is a query, DML or CREATE / ALTER of a table or view."""
+    if not tokens:
+        return False
+    if _is(tokens[0], *_STATEMENTS) or tokens[0][1] == "(":
+        return True
+    if _is(tokens[0], "CREATE", "ALTER"):
+        # CREATE [OR REPLACE] [GLOBAL] [TEMPORARY] TABLE / VIEW
+        return any(_is(token, "TABLE", "VIEW") for token in tokens[1:6])
+    return False
+
+
+def _cast_literal(tokens, index: int) -> tuple[int, int] | None:
+    """Last token and value of a boolean cast of a string literal starting at tokens[index]."""
+    if (
+        tokens[index][0] == "string"
+        and index + 2 < len(tokens)
+        and tokens[index + 1][1] == "::"
+        and _is(tokens[index + 2], *_BOOLEAN_TYPES)
+    ):
+        literal, end = tokens[index], index + 2  # 't'::bool
+    elif (
+        _is(tokens[index], "CAST")
+        and index + 5 < len(tokens)
+        and tokens[index + 1][1] == "("
+        and tokens[index + 2][0] == "string"
+        and _is(tokens[index + 3], "AS")
+        and _is(tokens[index + 4], *_BOOLEAN_TYPES)
+        and tokens[index + 5][1] == ")"
+    ):
+        literal, end = tokens[index + 2], index + 5  # CAST('t' AS BOOLEAN)
+    else:
+        return None
+    try:
+        return end, parse_bool(literal[1][1:-1].replace("''", "'"))
+    except InvalidBoolean:
+        return None  # Left for IRIS to reject
+
+
+class BooleanTranslator:
+    """Rewrites TRUE / FALSE and boolean casts of string literals onto IRIS BIT values."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate boolean literals.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, number of literals rewritten)
+        """
+        if not _BOOLEAN_LITERAL.search(sql):
+            return sql, 0
+        tokens = _tokens(sql)
+        if not _translated_statement(tokens):
+            return sql, 0
+
+        pieces, cursor, count = [], 0, 0
+        index = 0
+        while index < len(tokens):
+            token = tokens[index]
+            cast = _cast_literal(tokens, index)
+            if cast is not None:
+                end, value = cast
+                pieces += [sql[cursor : token[2]], f"CAST({value} AS BIT)"]
+                cursor = tokens[end][3]
+                count += 1
+                index = end + 1
+                continue
+            if _is(token, "TRUE", "FALSE") and not self._keeps_keyword(tokens, index):
+                pieces += [sql[cursor : token[2]], "1" if token[1].upper() == "TRUE" else "0"]
+                cursor = token[3]
+                count += 1
+            index += 1
+
+        if not count:
+            return sql, 0
+        pieces.append(sql[cursor:])
+        return "".join(pieces), count
+
+    @staticmethod
+    def _keeps_keyword(tokens, index: int) -> bool:
+        """Whether TRUE / FALSE at tokens[index] is not a literal to rewrite."""
+        before = tokens[index - 1] if index > 0 else None
+        after = tokens[index + 1] if index + 1 < len(tokens) else None
+        if before is not None and before[1] == ".":
+            return True  # t.true
+        if after is not None and after[1] in ("(", "."):
+            return True  # A function or qualifier named true
+        # x IS [NOT] TRUE
+        if _is(before, "NOT"):
+            before = tokens[index - 2] if index > 1 else None
+        return _is(before, "IS")
@@ -9,6 +9,12 @@ Syntax:
     COPY table_name [(column_list)] TO STDOUT [WITH (options)]
     COPY (query) TO STDOUT [WITH (options)]
 
+Without FORMAT, COPY uses PostgreSQL's text format (tab separated, \\N for
+NULL, backslash escapes), which is what pg_dump writes and psql's \\copy
+sends. Options are also accepted in parentheses without WITH and in the
+pre-9.0 syntax (COPY t FROM STDIN CSV HEADER, COPY t TO STDOUT CSV FORCE
+QUOTE *), as psql's \\copy and older drivers write them.
+
 Constitutional Requirement:
 - Translation overhead <5ms (performance standard)
 - Protocol Fidelity: Exact PostgreSQL COPY syntax support
@@ -19,6 +25,10 @@ from dataclasses import dataclass
 from enum import Enum
 
 
+# A column name in an option list (FORCE_QUOTE a, "B")
+_COLUMN = r'(?:"[^"]*"|\w+)'
+
+
 class CopyDirection(str, Enum):
     """COPY operation direction."""
 
@@ -40,6 +50,7 @@ class CSVOptions:
     header: bool = False
     quote: str = '"'
     escape: str = "\\"
+    force_quote: list[str] | None = None  # COPY TO CSV: columns always quoted (["*"] = all)
 
     @staticmethod
     def _unescape_string(s: str) -> str:
@@ -63,13 +74,16 @@ class CSVOptions:
         return result
 
     @classmethod
-    def from_with_clause(cls, with_clause: str) -> "CSVOptions":
+    def from_with_clause(cls, with_clause: str, default_format: str = "CSV") -> "CSVOptions":
         """
         Parse WITH (...) clause to extract CSV options.
 
         Example: "FORMAT CSV, DELIMITER ',', HEADER, NULL ''"
+
+        TEXT format (PostgreSQL's default) separates columns with tabs unless
+        DELIMITER says otherwise.
         """
-        options = cls()
+        options = cls(format=default_format, delimiter="\t" if default_format == "TEXT" else ",")
 
         if not with_clause:
             return options
@@ -89,6 +103,8 @@ class CSVOptions:
             has_e_prefix = delimiter_match.group(1) is not None
             value = delimiter_match.group(2)
             options.delimiter = cls._unescape_string(value) if has_e_prefix else value
+        else:
+            options.delimiter = "\t" if options.format == "TEXT" else ","
 
         # NULL option (handle E'...' escape sequences)
         null_match = re.search(r"NULL\s+(E)?'([^']*)'", with_clause, re.IGNORECASE)
@@ -101,6 +117,22 @@ class CSVOptions:
         if re.search(r"\bHEADER\b", with_clause_upper):
             options.header = True
 
+        # CSV: NULL is an unquoted empty field unless NULL says otherwise
+        if not null_match and options.format == "CSV":
+            options.null_string = ""
+
+        # FORCE_QUOTE option: * or a column list
+        force_match = re.search(
+            rf"FORCE_QUOTE\s*(?:(\*)|\(([^)]*)\)|({_COLUMN}(?:\s*,\s*{_COLUMN})*))",
+            with_clause,
+            re.IGNORECASE,
+        )
+        if force_match:
+            columns = force_match.group(2) or force_match.group(3)
+            options.force_quote = (
+                ["*"] if force_match.group(1) else [col.strip() for col in columns.split(",")]
+            )
+
         # QUOTE option (handle doubled single quotes '')
         quote_match = re.search(r"QUOTE\s+'((?:''|[^'])*)'", with_clause, re.IGNORECASE)
         if quote_match:
@@ -149,17 +181,22 @@ class CopyCommandParser:
     - WITH (FORMAT CSV, HEADER, DELIMITER ',', ...)
     """
 
-    # Regex patterns
+    # Regex patterns (table names may be schema-qualified and quoted, as pg_dump writes them)
+    TABLE_NAME = r'(?:"[^"]*"|\w+)(?:\s*\.\s*(?:"[^"]*"|\w+))?'
+
+    # Options as WITH (...), (...) or the pre-9.0 form (psql's \\copy, pgx's "binary")
     COPY_FROM_STDIN_PATTERN = re.compile(
-        r"COPY\s+(\w+)(?:\s*\(([^)]+)\))?\s+FROM\s+STDIN(?:\s+WITH\s*\(([^)]+)\))?", re.IGNORECASE
+        rf"COPY\s+({TABLE_NAME})(?:\s*\(([^)]+)\))?\s+FROM\s+STDIN\b(.*)$",
+        re.IGNORECASE | re.DOTALL,
     )
 
     COPY_TO_STDOUT_PATTERN = re.compile(
-        r"COPY\s+(\w+)(?:\s*\(([^)]+)\))?\s+TO\s+STDOUT(?:\s+WITH\s*\(([^)]+)\))?", re.IGNORECASE
+        rf"COPY\s+({TABLE_NAME})(?:\s*\(([^)]+)\))?\s+TO\s+STDOUT\b(.*)$",
+        re.IGNORECASE | re.DOTALL,
     )
 
     COPY_QUERY_TO_STDOUT_PATTERN = re.compile(
-        r"COPY\s*\((.+)\)\s+TO\s+STDOUT(?:\s+WITH\s*\(([^)]+)\))?", re.IGNORECASE | re.DOTALL
+        r"COPY\s*\((.+)\)\s+TO\s+STDOUT\b(.*)$", re.IGNORECASE | re.DOTALL
     )
 
     @staticmethod
@@ -194,7 +231,9 @@ class CopyCommandParser:
             if column_list_str:
                 column_list = [col.strip() for col in column_list_str.split(",")]
 
-            csv_options = CSVOptions.from_with_clause(with_clause or "")
+            csv_options = CSVOptions.from_with_clause(
+                _option_clause(with_clause or ""), default_format="TEXT"
+            )
 
             return CopyCommand(
                 table_name=table_name,
@@ -214,7 +253,9 @@ class CopyCommandParser:
             if column_list_str:
                 column_list = [col.strip() for col in column_list_str.split(",")]
 
-            csv_options = CSVOptions.from_with_clause(with_clause or "")
+            csv_options = CSVOptions.from_with_clause(
+                _option_clause(with_clause or ""), default_format="TEXT"
+            )
 
             return CopyCommand(
                 table_name=table_name,
@@ -229,7 +270,9 @@ class CopyCommandParser:
             query = match.group(1).strip()
             with_clause = match.group(2)
 
-            csv_options = CSVOptions.from_with_clause(with_clause or "")
+            csv_options = CSVOptions.from_with_clause(
+                _option_clause(with_clause or ""), default_format="TEXT"
+            )
 
             return CopyCommand(
                 table_name=None,
@@ -243,6 +286,26 @@ class CopyCommandParser:
         raise ValueError(f"Invalid COPY command syntax: {sql[:100]}")
 
 
+_LEGACY_FORMAT = re.compile(r"(?<!FORMAT\s)\b(CSV|BINARY)\b", re.IGNORECASE)
+_LEGACY_AS = re.compile(r"\b(DELIMITER|NULL|QUOTE|ESCAPE)\s+AS\s+", re.IGNORECASE)
+_LEGACY_FORCE_QUOTE = re.compile(r"\bFORCE\s+QUOTE\b", re.IGNORECASE)
+
+
+def _option_clause(options: str) -> str:
+    """
+    The options of a COPY statement in WITH (...) form, without the parentheses.
+
+    The pre-9.0 syntax (CSV HEADER, BINARY, DELIMITER AS '|', FORCE QUOTE *)
+    is rewritten to its FORMAT / DELIMITER / FORCE_QUOTE equivalents.
+    """
+    options = options.strip().rstrip(";").strip()
+    options = re.sub(r"^WITH\b", "", options, flags=re.IGNORECASE).strip()
+    if options.startswith("(") and options.endswith(")"):
+        return options[1:-1]
+    options = _LEGACY_FORCE_QUOTE.sub("FORCE_QUOTE", options)
+    return _LEGACY_FORMAT.sub(r"FORMAT \1", _LEGACY_AS.sub(r"\1 ", options))
+
+
 # Convenience function for direct usage
 def parse_copy_command(sql: str) -> CopyCommand:
     """Parse COPY SQL command (convenience wrapper)."""
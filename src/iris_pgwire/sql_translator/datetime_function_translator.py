@@ -0,0 +1,315 @@
+"""
+Date/Time Function Translator for PostgreSQL-Compatible SQL
+
+Monitoring queries and ORMs emit PostgreSQL date/time functions that IRIS SQL
+does not have. They are rewritten into IRIS DATEDIFF/DATEADD/DATEPART calls:
+
+- extract(epoch FROM ts)         → DATEDIFF('s', '1970-01-01 00:00:00', ts)
+- extract(epoch FROM a - b)      → DATEDIFF('s', b, a)  (interval between timestamps)
+- extract(dow|isodow|doy|... FROM ts), date_part('field', ts) → DATEPART(...)
+- to_timestamp(epoch)            → DATEADD('s', epoch, '1970-01-01 00:00:00')
+- make_timestamp(y, mo, d, h, mi, s) / make_date(y, mo, d)
+- ts ± make_interval(years => .., days => .., secs => ..) → nested DATEADD
+
+Literal arguments are folded into timestamp literals at translation time.
+timestamptz values are stored as naive UTC, so epoch arithmetic needs no zone
+adjustment. IRIS DATEDIFF/DATEADD work in whole seconds: fractional epoch
+values are truncated.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement
+- Unsupported forms (to_timestamp(text, format), unknown fields) are left unchanged
+"""
+
+import datetime
+import re
+
+from ..sql_text import find_outside_literals, operand_start
+
+EPOCH_LITERAL = "'1970-01-01 00:00:00'"
+_BASE_LITERAL = "'1900-01-01 00:00:00'"
+
+_FUNCTION_CALL = re.compile(
+    r"\b(EXTRACT|DATE_PART|TO_TIMESTAMP|MAKE_TIMESTAMP|MAKE_DATE|MAKE_INTERVAL)\s*\(",
+    re.IGNORECASE,
+)
+_NUMBER = re.compile(r"^[+-]?\d+(\.\d+)?$")
+_NAMED_ARGUMENT = re.compile(r"^(\w+)\s*(?:=>|:=)\s*(.+)$", re.DOTALL)
+
+# extract()/date_part() fields with a direct IRIS DATEPART equivalent
+_DATEPART_FIELDS = {
+    "year": "yy",
+    "quarter": "qq",
+    "month": "mm",
+    "day": "dd",
+    "doy": "dy",
+    "hour": "hh",
+    "minute": "mi",
+    "second": "ss",
+}
+
+# make_interval() parameters in PostgreSQL positional order → IRIS datepart
+_INTERVAL_PARTS = (
+    ("years", "yy"),
+    ("months", "mm"),
+    ("weeks", "wk"),
+    ("days", "dd"),
+    ("hours", "hh"),
+    ("mins", "mi"),
+    ("secs", "ss"),
+)
+
+
+def _format_timestamp(value: datetime.datetime) -> str:
+    text = value.strftime("%Y-%m-%d %H:%M:%S")
+    if value.microsecond:
+        text += f".{value.microsecond:06d}".rstrip("0")
+    return text
+
+
+class DateTimeFunctionTranslator:
+    """Rewrites PostgreSQL epoch/ISO date-time functions into IRIS expressions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate date/time functions in SQL.
+
+        Args:
+            sql: SQL that may contain extract/date_part/to_timestamp/make_* calls
+
+        Returns:
+            Tuple of (translated_sql, translation_count)
+        """
+        upper = sql.upper()
+        if not any(name in upper for name in ("EXTRACT", "DATE_PART", "TO_TIMESTAMP", "MAKE_")):
+            return sql, 0
+
+        count = 0
+        search_from = 0
+
+        while True:
+            match = find_outside_literals(_FUNCTION_CALL, sql, search_from)
+            if not match:
+                break
+
+            call_end = self._closing_paren(sql, match.end())
+            if call_end is None:
+                break
+
+            inner, inner_count = self.translate(sql[match.end() : call_end])
+            start, replacement = self._rewrite(sql, match, inner)
+            if replacement is None:
+                search_from = match.end()
+                continue
+
+            sql = sql[:start] + replacement + sql[call_end + 1 :]
+            search_from = start + len(replacement)
+            count += 1 + inner_count
+
+        return sql, count
+
+    # ------------------------------------------------------------------ rewrites
+
+    def _rewrite(self, sql: str, match: re.Match, inner: str) -> tuple[int, str | None]:
+        name = match.group(1).upper()
+        start = match.start()
+
+        if name == "EXTRACT":
+            field_match = re.match(r"^\s*'?(\w+)'?\s+FROM\s+(.+)$", inner, re.IGNORECASE | re.DOTALL)
+            if not field_match:
+                return start, None
+            return start, self._extract(field_match.group(1), field_match.group(2).strip())
+
+        args = self._split_arguments(inner)
+
+        if name == "DATE_PART":
+            if len(args) != 2 or not re.match(r"^'\w+'$", args[0]):
+                return start, None
+            return start, self._extract(args[0][1:-1], args[1])
+
+        if name == "TO_TIMESTAMP":
+            # to_timestamp(text, format) is native IRIS
+            if len(args) != 1:
+                return start, None
+            return start, self._to_timestamp(args[0])
+
+        if name == "MAKE_TIMESTAMP":
+            if len(args) != 6:
+                return start, None
+            return start, self._make_timestamp(args)
+
+        if name == "MAKE_DATE":
+            if len(args) != 3:
+                return start, None
+            return start, self._make_date(args)
+
+        return self._make_interval_arithmetic(sql, start, args)
+
+    def _extract(self, field: str, expr: str) -> str | None:
+        field = field.lower()
+
+        if field == "epoch":
+            difference = self._split_difference(expr)
+            if difference:
+                return f"DATEDIFF('s', {difference[1]}, {difference[0]})"
+            return f"DATEDIFF('s', {EPOCH_LITERAL}, {expr})"
+
+        if field == "dow":
+            # IRIS 'dw' is 1 (Sunday) .. 7, PostgreSQL dow is 0 (Sunday) .. 6
+            return f"(DATEPART('dw', {expr}) - 1)"
+
+        if field == "isodow":
+            # ISO 8601: Monday = 1 .. Sunday = 7
+            return f"(CASE DATEPART('dw', {expr}) WHEN 1 THEN 7 ELSE DATEPART('dw', {expr}) - 1 END)"
+
+        if field in _DATEPART_FIELDS:
+            return f"DATEPART('{_DATEPART_FIELDS[field]}', {expr})"
+
+        return None
+
+    @staticmethod
+    def _to_timestamp(arg: str) -> str:
+        if _NUMBER.match(arg):
+            instant = datetime.datetime(1970, 1, 1) + datetime.timedelta(seconds=float(arg))
+            return f"CAST('{_format_timestamp(instant)}' AS TIMESTAMP)"
+        return f"DATEADD('s', {arg}, {EPOCH_LITERAL})"
+
+    def _make_timestamp(self, args: list[str]) -> str | None:
+        if all(_NUMBER.match(arg) for arg in args):
+            year, month, day, hour, minute = (int(arg) for arg in args[:5])
+            try:
+                value = datetime.datetime(year, month, day, hour, minute) + datetime.timedelta(
+                    seconds=float(args[5])
+                )
+            except ValueError:
+                return None
+            return f"CAST('{_format_timestamp(value)}' AS TIMESTAMP)"
+
+        expression = _BASE_LITERAL
+        for part, arg in zip(("yy", "mm", "dd", "hh", "mi", "ss"), args, strict=True):
+            offset = arg
+            if part in ("hh", "mi", "ss") and arg == "0":
+                continue
+            if part == "yy":
+                offset = f"({arg}) - 1900"
+            elif part in ("mm", "dd"):
+                offset = f"({arg}) - 1"
+            expression = f"DATEADD('{part}', {offset}, {expression})"
+        return expression
+
+    def _make_date(self, args: list[str]) -> str | None:
+        if all(re.match(r"^\d+$", arg) for arg in args):
+            try:
+                value = datetime.date(*(int(arg) for arg in args))
+            except ValueError:
+                return None
+            return f"CAST('{value.isoformat()}' AS DATE)"
+        timestamp = self._make_timestamp([*args, "0", "0", "0"])
+        return f"CAST({timestamp} AS DATE)"
+
+    def _make_interval_arithmetic(
+        self, sql: str, call_start: int, args: list[str]
+    ) -> tuple[int, str | None]:
+        """ts + make_interval(...) / ts - make_interval(...) → nested DATEADD."""
+        parts = self._interval_parts(args)
+        if parts is None:
+            return call_start, None
+
+        i = call_start - 1
+        while i >= 0 and sql[i].isspace():
+            i -= 1
+        if i < 0 or sql[i] not in "+-":
+            return call_start, None
+
+        left_start = operand_start(sql, i)
+        if left_start is None:
+            return call_start, None
+
+        operand = sql[left_start:i].strip()
+        negate = sql[i] == "-"
+        expression = operand
+        for name, datepart in _INTERVAL_PARTS:
+            if name not in parts:
+                continue
+            amount = parts[name]
+            if negate:
+                if not _NUMBER.match(amount):
+                    amount = f"-({amount})"
+                else:
+                    amount = amount[1:] if amount.startswith("-") else f"-{amount.lstrip('+')}"
+            expression = f"DATEADD('{datepart}', {amount}, {expression})"
+        return left_start, expression
+
+    @staticmethod
+    def _interval_parts(args: list[str]) -> dict[str, str] | None:
+        names = [name for name, _ in _INTERVAL_PARTS]
+        parts: dict[str, str] = {}
+        for position, arg in enumerate(args):
+            named = _NAMED_ARGUMENT.match(arg)
+            if named:
+                name = named.group(1).lower()
+                if name not in names:
+                    return None
+                parts[name] = named.group(2).strip()
+            elif position < len(names) and len(parts) == position:
+                # Positional arguments must precede named ones
+                parts[names[position]] = arg
+            else:
+                return None
+        # Drop zero components (make_interval(0, 0, 0, 1) is just one day)
+        return {name: value for name, value in parts.items() if value not in ("0", "0.0")}
+
+    # ------------------------------------------------------------------ scanning helpers
+
+    @staticmethod
+    def _closing_paren(sql: str, pos: int) -> int | None:
+        depth = 1
+        in_literal = False
+        for i in range(pos, len(sql)):
+            char = sql[i]
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                    if depth == 0:
+                        return i
+        return None
+
+    @staticmethod
+    def _top_level_positions(text: str, char: str) -> list[int]:
+        positions = []
+        depth = 0
+        in_literal = False
+        for i, current in enumerate(text):
+            if current == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if current == "(":
+                    depth += 1
+                elif current == ")":
+                    depth -= 1
+                elif current == char and depth == 0:
+                    positions.append(i)
+        return positions
+
+    def _split_arguments(self, inner: str) -> list[str]:
+        if not inner.strip():
+            return []
+        bounds = [-1, *self._top_level_positions(inner, ","), len(inner)]
+        return [inner[a + 1 : b].strip() for a, b in zip(bounds, bounds[1:], strict=False)]
+
+    def _split_difference(self, expr: str) -> tuple[str, str] | None:
+        """Split 'a - b' (single top-level binary minus) into (a, b)."""
+        text = expr.strip()
+        while text.startswith("(") and self._closing_paren(text, 1) == len(text) - 1:
+            text = text[1:-1].strip()
+        minus = self._top_level_positions(text, "-")
+        if len(minus) != 1:
+            return None
+        left, right = text[: minus[0]].strip(), text[minus[0] + 1 :].strip()
+        if not left or not right or left[-1] in "+-*/(":
+            return None
+        return left, right
@@ -0,0 +1,148 @@
+"""
+PostgreSQL-only DDL Clauses
+
+Framework-generated schemas (Django, Rails, Hibernate, Prisma migrations)
+use constraint and table options IRIS does not parse. Rather than failing
+the whole migration, CREATE TABLE / ALTER TABLE statements are rewritten:
+
+- [NOT] DEFERRABLE, INITIALLY IMMEDIATE: removed. IRIS checks every
+  constraint when the statement runs, which is PostgreSQL's behavior for
+  these clauses.
+- INITIALLY DEFERRED: removed, and the client receives a WARNING: IRIS has
+  no deferred constraint checking, so a transaction that relies on it (for
+  example inserting rows before the rows they reference) fails at the
+  offending statement instead of at COMMIT.
+
+- BYTEA columns: created as LONGVARBINARY, a %Stream.GlobalBinary property,
+  so values are not limited to the IRIS string length. Results read the
+  stream (stream_values.py) and report the column as bytea.
+- UUID columns: created as PGWIRE_UUID_COLUMN_TYPE (UNIQUEIDENTIFIER, or
+  CHAR(36)) and reported as uuid (uuid_values.py).
+- JSON and JSONB columns: created as PGWIRE_JSON_COLUMN_TYPE (a long
+  VARCHAR) and reported as jsonb (json_values.py).
+- MONEY columns: created as PGWIRE_MONEY_COLUMN_TYPE (NUMERIC(19,4)) with
+  a description marking them, and reported as money (money_values.py).
+- pgvector's vector(n) columns: created as VECTOR(DOUBLE, n) and reported
+  as vector (vector_values.py).
+
+CREATE TABLE ... USING columnar, and CREATE TABLE of tables matching
+PGWIRE_COLUMNAR_TABLES, get IRIS columnar storage (table_storage.py).
+CREATE INDEX ... USING gin / gist / brin is created as a bitmap, iFind,
+columnar or standard index, with a NOTICE (index_methods.py).
+
+SET CONSTRAINTS ... DEFERRED is accepted with the same warning (see
+protocol.py). Clauses inside string literals are left alone, as are columns
+named like a type (CREATE TABLE t (json TEXT)).
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements that are not
+  CREATE/ALTER TABLE are returned after a prefix check)
+"""
+
+import re
+
+from ..json_values import json_column_type
+from ..money_values import money_column_definition, money_column_type
+from ..uuid_values import uuid_column_type
+from ..vector_values import vector_column_type
+from .index_methods import index_method_notice, partial_index_notice, rewrite_create_index
+from .table_storage import rewrite_table_storage
+
+_TABLE_DDL = re.compile(r"^\s*(?:CREATE|ALTER)\s+(?:\w+\s+)*?TABLE\b", re.IGNORECASE)
+
+# String literals are matched first so their contents are never rewritten
+_CONSTRAINT_TIMING = re.compile(
+    r"(?P<literal>'(?:[^']|'')*')"
+    r"|\s+(?:(?:NOT\s+)?DEFERRABLE|INITIALLY\s+(?P<initially>DEFERRED|IMMEDIATE))\b",
+    re.IGNORECASE,
+)
+
+# Quoted identifiers are skipped too: a column may be named "bytea"
+_COLUMN_TYPE = re.compile(
+    r"(?P<literal>'(?:[^']|'')*'|\"(?:[^\"]|\"\")*\")|\b(?P<type>BYTEA|UUID|JSONB?|MONEY)\b"
+    # pgvector's vector(n); IRIS's own VECTOR(DOUBLE, n) is left alone
+    r"|\b(?P<vector>VECTOR)\b(?:\s*\(\s*(?P<dimensions>\d+)\s*\))?(?!\s*\()",
+    re.IGNORECASE,
+)
+
+# What precedes a column name: the type keywords after them are names, not types
+_COLUMN_NAME_CONTEXT = re.compile(r"(?:[(,]|\b(?:COLUMN|ADD))\s*$", re.IGNORECASE)
+
+# A column name before the type: a column definition, which can carry a description
+_COLUMN_DEFINITION = re.compile(
+    r'(?:[(,]|\b(?:COLUMN|ADD))\s*(?:"(?:[^"]|"")+"|\w+)\s+$', re.IGNORECASE
+)
+
+DEFERRED_CONSTRAINTS_WARNING = (
+    "IRIS does not support deferred constraints; constraints are checked immediately"
+)
+
+
+def is_table_ddl(sql: str) -> bool:
+    """Whether a statement is CREATE TABLE or ALTER TABLE."""
+    return bool(_TABLE_DDL.match(sql))
+
+
+def ddl_notices(sql: str) -> list:
+    """
+    WARNING messages for clauses DDLTranslator removes with a change in
+    behavior, and the NOTICEs of an index created with another access method
+    or without its WHERE predicate.
+    """
+    if not is_table_ddl(sql):
+        notices = [index_method_notice(sql), partial_index_notice(sql)]
+        return [notice for notice in notices if notice]
+    for match in _CONSTRAINT_TIMING.finditer(sql):
+        if (match.group("initially") or "").upper() == "DEFERRED":
+            return [DEFERRED_CONSTRAINTS_WARNING]
+    return []
+
+
+class DDLTranslator:
+    """Rewrites PostgreSQL-only clauses and types in CREATE TABLE / ALTER TABLE / CREATE INDEX."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Rewrite a DDL statement for IRIS.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, clauses removed or rewritten)
+        """
+        if not is_table_ddl(sql):
+            rewritten = rewrite_create_index(sql)
+            return (sql, 0) if rewritten is None else (rewritten, 1)
+
+        count = 0
+
+        def remove(match: re.Match) -> str:
+            nonlocal count
+            if match.group("literal"):
+                return match.group(0)
+            count += 1
+            return ""
+
+        def column_type(match: re.Match) -> str:
+            nonlocal count
+            if match.group("literal") or _COLUMN_NAME_CONTEXT.search(sql, 0, match.start()):
+                return match.group(0)
+            count += 1
+            if match.group("vector"):
+                return vector_column_type(match.group("dimensions"))
+            type_name = match.group("type").upper()
+            if type_name == "BYTEA":
+                return "LONGVARBINARY"
+            if type_name == "UUID":
+                return uuid_column_type()
+            if type_name == "MONEY":
+                if _COLUMN_DEFINITION.search(sql, 0, match.start()):
+                    return money_column_definition()
+                return money_column_type()
+            return json_column_type()
+
+        sql = _CONSTRAINT_TIMING.sub(remove, sql)
+        sql = _COLUMN_TYPE.sub(column_type, sql)
+        sql, storage_count = rewrite_table_storage(sql)
+        return sql, count + storage_count
@@ -0,0 +1,438 @@
+"""
+Full Text Search Translator (minimal tsvector/tsquery emulation)
+
+IRIS has no tsvector/tsquery types. Basic PostgreSQL full-text predicates are
+mapped onto IRIS search instead:
+
+    to_tsvector('english', body) @@ to_tsquery('english', 'cat & !dog')
+
+- Columns with a configured iFind index become
+      ALIAS.%ID %FIND search_index(IndexName, '(cat AND NOT dog)')
+- Other columns fall back to case-insensitive substring matching
+      (LOWER(body) LIKE '%cat%' AND NOT (LOWER(body) LIKE '%dog%'))
+
+Supported query constructors: to_tsquery (&, |, !, parentheses, <-> and :*
+prefixes), plainto_tsquery, phraseto_tsquery, websearch_to_tsquery
+("phrases", or, -term) and CAST('...' AS TSQUERY). ts_rank()/ts_rank_cd()
+return the fraction of positive query terms found in the document, which
+preserves relative ordering. There is no stemming or stop-word removal.
+
+iFind indexes are configured with PGWIRE_FTS_INDEXES, a comma separated list
+of COLUMN=IndexName or TABLE.COLUMN=IndexName entries:
+
+    PGWIRE_FTS_INDEXES="ARTICLES.BODY=BodyIdx,TITLE=TitleIdx"
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement
+- Queries given as parameters degrade to whole-phrase matching
+"""
+
+import os
+import re
+from dataclasses import dataclass
+
+from ..sql_text import find_outside_literals, operand_start
+
+_QUERY_FUNCTIONS = ("TO_TSQUERY", "PLAINTO_TSQUERY", "PHRASETO_TSQUERY", "WEBSEARCH_TO_TSQUERY")
+_MATCH_OPERATOR = re.compile(r"@@")
+_RANK_FUNCTION = re.compile(r"\bTS_RANK(?:_CD)?\s*\(", re.IGNORECASE)
+_CALL = re.compile(r"^([\w.]+)\s*\((.*)\)$", re.DOTALL)
+_CAST = re.compile(r"^CAST\s*\((.*)\s+AS\s+(TSQUERY|TSVECTOR)\s*\)$", re.IGNORECASE | re.DOTALL)
+_STRING_LITERAL = re.compile(r"^'((?:[^']|'')*)'$", re.DOTALL)
+_WORD = re.compile(r"[^\W_]+", re.UNICODE)
+_TSQUERY_TOKEN = re.compile(
+    r"\s*(?:(?P<op>[&|!()])|(?P<phrase><(?:-|\d+)>)|'(?P<quoted>(?:[^']|'')*)'|(?P<word>[^\s&|!()<':]+))"
+    r"(?P<suffix>:[*A-Da-d]+)?"
+)
+
+
+@dataclass
+class Term:
+    words: tuple[str, ...]  # More than one word is a phrase
+
+
+@dataclass
+class Not:
+    operand: object
+
+
+@dataclass
+class BoolOp:
+    operator: str  # "AND" | "OR"
+    left: object
+    right: object
+
+
+def load_fts_index_map(spec: str | None = None) -> dict[str, str]:
+    """Parse PGWIRE_FTS_INDEXES into {COLUMN or TABLE.COLUMN: IndexName}."""
+    if spec is None:
+        spec = os.getenv("PGWIRE_FTS_INDEXES", "")
+    mapping = {}
+    for entry in spec.split(","):
+        if "=" not in entry:
+            continue
+        column, index = entry.split("=", 1)
+        if column.strip() and index.strip():
+            mapping[column.strip().upper()] = index.strip()
+    return mapping
+
+
+# ---------------------------------------------------------------------- query parsing
+
+
+def _words(text: str) -> tuple[str, ...]:
+    return tuple(word.lower() for word in _WORD.findall(text))
+
+
+def _combine(operator: str, nodes: list) -> object | None:
+    nodes = [node for node in nodes if node is not None]
+    if not nodes:
+        return None
+    result = nodes[0]
+    for node in nodes[1:]:
+        result = BoolOp(operator, result, node)
+    return result
+
+
+def parse_tsquery(text: str) -> object | None:
+    """Parse to_tsquery() syntax. <-> is treated as AND, :* and weights are ignored."""
+    tokens = []
+    for match in _TSQUERY_TOKEN.finditer(text):
+        if match.group("op"):
+            tokens.append(match.group("op"))
+        elif match.group("phrase"):
+            tokens.append("&")
+        else:
+            raw = match.group("quoted") if match.group("quoted") is not None else match.group("word")
+            words = _words(raw or "")
+            if words:
+                tokens.append(Term(words))
+
+    position = 0
+
+    def peek():
+        return tokens[position] if position < len(tokens) else None
+
+    def parse_or():
+        nonlocal position
+        node = parse_and()
+        while peek() == "|":
+            position += 1
+            node = _combine("OR", [node, parse_and()])
+        return node
+
+    def parse_and():
+        nonlocal position
+        node = parse_unary()
+        while peek() == "&" or isinstance(peek(), Term) or peek() in ("!", "("):
+            if peek() == "&":
+                position += 1
+            node = _combine("AND", [node, parse_unary()])
+        return node
+
+    def parse_unary():
+        nonlocal position
+        token = peek()
+        if token is None:
+            return None
+        position += 1
+        if token == "!":
+            operand = parse_unary()
+            return Not(operand) if operand is not None else None
+        if token == "(":
+            node = parse_or()
+            if peek() == ")":
+                position += 1
+            return node
+        if isinstance(token, Term):
+            return token
+        return None
+
+    return parse_or()
+
+
+def parse_plain_query(text: str) -> object | None:
+    """plainto_tsquery(): every word is required."""
+    return _combine("AND", [Term((word,)) for word in _words(text)])
+
+
+def parse_phrase_query(text: str) -> object | None:
+    """phraseto_tsquery(): the words must appear in sequence."""
+    words = _words(text)
+    return Term(words) if words else None
+
+
+def parse_websearch_query(text: str) -> object | None:
+    """websearch_to_tsquery(): "quoted phrases", OR, -negation, implicit AND."""
+    alternatives: list[list] = [[]]
+    for match in re.finditer(r'(-?)"([^"]*)"|(-?)([^\s"]+)', text):
+        if match.group(2) is not None:
+            negate, words = match.group(1), _words(match.group(2))
+        else:
+            negate, raw = match.group(3), match.group(4)
+            if raw.lower() == "or" and not negate:
+                alternatives.append([])
+                continue
+            words = _words(raw)
+        if not words:
+            continue
+        term = Term(words)
+        alternatives[-1].append(Not(term) if negate else term)
+    return _combine("OR", [_combine("AND", nodes) for nodes in alternatives])
+
+
+_PARSERS = {
+    "TO_TSQUERY": parse_tsquery,
+    "PLAINTO_TSQUERY": parse_plain_query,
+    "PHRASETO_TSQUERY": parse_phrase_query,
+    "WEBSEARCH_TO_TSQUERY": parse_websearch_query,
+}
+
+
+def positive_terms(node) -> list[Term]:
+    """Terms that are not under a negation (used for ranking)."""
+    if isinstance(node, Term):
+        return [node]
+    if isinstance(node, BoolOp):
+        return positive_terms(node.left) + positive_terms(node.right)
+    return []
+
+
+# ---------------------------------------------------------------------- translator
+
+
+class FullTextSearchTranslator:
+    """Rewrites tsvector @@ tsquery predicates into iFind or LIKE expressions."""
+
+    def __init__(self, index_map: dict[str, str] | None = None):
+        self.index_map = load_fts_index_map() if index_map is None else index_map
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate full-text search predicates and ranking functions.
+
+        Args:
+            sql: SQL that may contain @@ predicates or ts_rank()
+
+        Returns:
+            Tuple of (translated_sql, translation_count)
+        """
+        if "@@" not in sql and "TS_RANK" not in sql.upper():
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            match = find_outside_literals(_MATCH_OPERATOR, sql, search_from)
+            if not match:
+                break
+            left_start = operand_start(sql, match.start())
+            right_end = self._operand_end(sql, match.end())
+            if left_start is None or right_end is None:
+                search_from = match.end()
+                continue
+
+            left = sql[left_start : match.start()].strip()
+            right = sql[match.end() : right_end].strip()
+            replacement = self.match_expression(left, right)
+            if replacement is None:
+                search_from = match.end()
+                continue
+
+            sql = sql[:left_start] + replacement + sql[right_end:]
+            search_from = left_start + len(replacement)
+            count += 1
+
+        search_from = 0
+        while True:
+            match = find_outside_literals(_RANK_FUNCTION, sql, search_from)
+            if not match:
+                break
+            close = self._closing_paren(sql, match.end())
+            args = self._split_arguments(sql[match.end() : close]) if close is not None else []
+            # ts_rank([weights,] vector, query [, normalization])
+            if len(args) >= 3 and args[0].strip().startswith(("'{", "ARRAY")):
+                args = args[1:]
+            replacement = self.rank_expression(args[0], args[1]) if len(args) >= 2 else None
+            if replacement is None:
+                search_from = match.end()
+                continue
+            sql = sql[: match.start()] + replacement + sql[close + 1 :]
+            search_from = match.start() + len(replacement)
+            count += 1
+
+        return sql, count
+
+    # ------------------------------------------------------------------ expressions
+
+    def match_expression(self, left: str, right: str) -> str | None:
+        """Expression for `left @@ right` (either side may be the tsquery)."""
+        document, query = self._document_text(left), self._query(right)
+        if document is None or query is None:
+            document, query = self._document_text(right), self._query(left)
+        if document is None or query is None:
+            return None
+
+        parsed, raw = query
+        index = self._index_for(document)
+        if index:
+            column_owner = document.rsplit(".", 1)[0] + "." if "." in document else ""
+            search = raw if parsed is None else f"'{self._ifind_query(parsed)}'"
+            return f"{column_owner}%ID %FIND search_index({index}, {search})"
+
+        text = f"LOWER({document})"
+        if parsed is None:
+            # Parameter query: match it as one phrase
+            return f"({text} LIKE '%' || LOWER({raw}) || '%')"
+        return self._like_expression(parsed, text)
+
+    def rank_expression(self, vector: str, query_expr: str) -> str | None:
+        document, query = self._document_text(vector), self._query(query_expr)
+        if document is None or query is None or query[0] is None:
+            return None
+        terms = positive_terms(query[0])
+        if not terms:
+            return "0"
+        text = f"LOWER({document})"
+        hits = " + ".join(
+            f"CASE WHEN {text} LIKE '%{' '.join(term.words)}%' THEN 1 ELSE 0 END" for term in terms
+        )
+        return f"(CAST(({hits}) AS DOUBLE) / {len(terms)})"
+
+    def _like_expression(self, node, text: str) -> str:
+        if isinstance(node, Term):
+            return f"{text} LIKE '%{' '.join(node.words)}%'"
+        if isinstance(node, Not):
+            return f"NOT ({self._like_expression(node.operand, text)})"
+        return (
+            f"({self._like_expression(node.left, text)} {node.operator} "
+            f"{self._like_expression(node.right, text)})"
+        )
+
+    def _ifind_query(self, node) -> str:
+        if isinstance(node, Term):
+            return " ".join(node.words) if len(node.words) == 1 else f"\"{' '.join(node.words)}\""
+        if isinstance(node, Not):
+            return f"NOT {self._ifind_query(node.operand)}"
+        return f"({self._ifind_query(node.left)} {node.operator} {self._ifind_query(node.right)})"
+
+    def _index_for(self, document: str) -> str | None:
+        if not self.index_map or not re.fullmatch(r'[\w."]+', document):
+            return None
+        parts = [part.strip('"').upper() for part in document.split(".")]
+        column = parts[-1]
+        if len(parts) > 1 and f"{parts[-2]}.{column}" in self.index_map:
+            return self.index_map[f"{parts[-2]}.{column}"]
+        if column in self.index_map:
+            return self.index_map[column]
+        # Unqualified or alias-qualified references match a unique TABLE.COLUMN entry
+        candidates = [index for key, index in self.index_map.items() if key.endswith(f".{column}")]
+        return candidates[0] if len(candidates) == 1 else None
+
+    # ------------------------------------------------------------------ operand analysis
+
+    def _document_text(self, expr: str) -> str | None:
+        """Text expression behind a tsvector operand (to_tsvector([cfg,] x) or a column)."""
+        expr = self._strip_parens(expr)
+        cast = _CAST.match(expr)
+        if cast and cast.group(2).upper() == "TSVECTOR":
+            return self._strip_parens(cast.group(1))
+        call = _CALL.match(expr)
+        if call:
+            name = call.group(1).upper()
+            if name != "TO_TSVECTOR":
+                return None
+            args = self._split_arguments(call.group(2))
+            return args[-1] if args else None
+        if re.fullmatch(r'[\w."]+', expr) and not _STRING_LITERAL.match(expr):
+            return expr
+        return None
+
+    def _query(self, expr: str) -> tuple[object | None, str] | None:
+        """(parsed query, raw argument) for a tsquery operand; parsed is None for parameters."""
+        expr = self._strip_parens(expr)
+        cast = _CAST.match(expr)
+        if cast and cast.group(2).upper() == "TSQUERY":
+            name, argument = "TO_TSQUERY", cast.group(1).strip()
+        else:
+            call = _CALL.match(expr)
+            if not call or call.group(1).upper() not in _QUERY_FUNCTIONS:
+                return None
+            name = call.group(1).upper()
+            args = self._split_arguments(call.group(2))
+            if not args:
+                return None
+            argument = args[-1]
+
+        literal = _STRING_LITERAL.match(argument)
+        if not literal:
+            return None, argument
+        parsed = _PARSERS[name](literal.group(1).replace("''", "'"))
+        if parsed is None:
+            return None
+        return parsed, argument
+
+    # ------------------------------------------------------------------ scanning helpers
+
+    def _strip_parens(self, expr: str) -> str:
+        expr = expr.strip()
+        while expr.startswith("(") and self._closing_paren(expr, 1) == len(expr) - 1:
+            expr = expr[1:-1].strip()
+        return expr
+
+    def _operand_end(self, sql: str, start: int) -> int | None:
+        i = start
+        while i < len(sql) and sql[i].isspace():
+            i += 1
+        if i < len(sql) and sql[i] == "(":
+            close = self._closing_paren(sql, i + 1)
+            return None if close is None else close + 1
+        begin = i
+        while i < len(sql) and (sql[i].isalnum() or sql[i] in '_."'):
+            i += 1
+        if i == begin:
+            return None
+        j = i
+        while j < len(sql) and sql[j].isspace():
+            j += 1
+        if j < len(sql) and sql[j] == "(":
+            close = self._closing_paren(sql, j + 1)
+            return None if close is None else close + 1
+        return i
+
+    @staticmethod
+    def _closing_paren(sql: str, pos: int) -> int | None:
+        depth = 1
+        in_literal = False
+        for i in range(pos, len(sql)):
+            char = sql[i]
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                    if depth == 0:
+                        return i
+        return None
+
+    @staticmethod
+    def _split_arguments(inner: str) -> list[str]:
+        args, depth, in_literal, current = [], 0, False, []
+        for char in inner:
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                elif char == "," and depth == 0:
+                    args.append("".join(current).strip())
+                    current = []
+                    continue
+            current.append(char)
+        if "".join(current).strip():
+            args.append("".join(current).strip())
+        return args
@@ -0,0 +1,150 @@
+"""
+iFind Function Translator
+
+Exposes IRIS iFind text search to PostgreSQL clients as ordinary functions
+(registered in the pg_proc emulation, see catalog/pg_proc.py):
+
+- ifind_match(col, 'query' [, 'Index'])      → ALIAS.%ID %FIND search_index(Index, 'query')
+- ifind_rank(col, 'query' [, 'Index'])       → Schema.Table_IndexRank(ALIAS.%ID, 'query')
+- ifind_highlight(col, 'query' [, 'Index'])  → Schema.Table_IndexHighlight(ALIAS.%ID, 'query')
+
+The query string uses native iFind syntax (AND/OR/NOT, "phrases", wildcards),
+not tsquery. The index is looked up from PGWIRE_FTS_INDEXES (shared with the
+full text search translator) unless passed explicitly. Rank and highlight call
+the SQL procedures iFind generates for the index, which need the owning table:
+it comes from a TABLE.COLUMN (or SCHEMA.TABLE.COLUMN) configuration entry.
+
+Columns without an index degrade to substring matching, rank 0 and the
+unmodified column text so queries keep working during development.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement
+"""
+
+import re
+
+from ..sql_text import find_outside_literals
+from .fts_translator import load_fts_index_map
+
+DEFAULT_SCHEMA = "SQLUser"
+
+_FUNCTION_CALL = re.compile(r"\b(IFIND_MATCH|IFIND_RANK|IFIND_HIGHLIGHT)\s*\(", re.IGNORECASE)
+_STRING_LITERAL = re.compile(r"^'((?:[^']|'')*)'$", re.DOTALL)
+
+
+class IFindTranslator:
+    """Rewrites ifind_* functions into iFind %FIND predicates and procedures."""
+
+    def __init__(self, index_map: dict[str, str] | None = None):
+        self.index_map = load_fts_index_map() if index_map is None else index_map
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate ifind_match/ifind_rank/ifind_highlight calls.
+
+        Args:
+            sql: SQL that may contain ifind_* calls
+
+        Returns:
+            Tuple of (translated_sql, translation_count)
+        """
+        if "IFIND_" not in sql.upper():
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            match = find_outside_literals(_FUNCTION_CALL, sql, search_from)
+            if not match:
+                break
+            close = self._closing_paren(sql, match.end())
+            if close is None:
+                break
+            args = self._split_arguments(sql[match.end() : close])
+            replacement = None
+            if len(args) in (2, 3):
+                replacement = self._rewrite(match.group(1).upper(), *args)
+            if replacement is None:
+                search_from = match.end()
+                continue
+
+            sql = sql[: match.start()] + replacement + sql[close + 1 :]
+            search_from = match.start() + len(replacement)
+            count += 1
+
+        return sql, count
+
+    def _rewrite(self, function: str, column: str, query: str, index: str | None = None) -> str | None:
+        if index is not None:
+            literal = _STRING_LITERAL.match(index.strip())
+            if not literal:
+                return None
+            index_name, table = literal.group(1), None
+        else:
+            index_name, table = self._lookup(column)
+
+        owner = column.rsplit(".", 1)[0] + "." if "." in column else ""
+        row_id = f"{owner}%ID"
+
+        if function == "IFIND_MATCH":
+            if index_name:
+                return f"{row_id} %FIND search_index({index_name}, {query})"
+            return f"(LOWER({column}) LIKE '%' || LOWER({query}) || '%')"
+
+        suffix = "Rank" if function == "IFIND_RANK" else "Highlight"
+        if index_name and table:
+            return f"{table}_{index_name}{suffix}({row_id}, {query})"
+        # No generated procedure to call
+        return "0" if function == "IFIND_RANK" else column
+
+    def _lookup(self, column: str) -> tuple[str | None, str | None]:
+        """(index name, Schema.Table) for a column reference."""
+        name = column.strip().split(".")[-1].strip('"').upper()
+        for key, index in self.index_map.items():
+            parts = key.split(".")
+            if parts[-1] != name:
+                continue
+            if len(parts) == 1:
+                return index, None
+            schema = parts[-3] if len(parts) >= 3 else DEFAULT_SCHEMA
+            return index, f"{schema}.{parts[-2]}"
+        return None, None
+
+    # ------------------------------------------------------------------ scanning helpers
+
+    @staticmethod
+    def _closing_paren(sql: str, pos: int) -> int | None:
+        depth = 1
+        in_literal = False
+        for i in range(pos, len(sql)):
+            char = sql[i]
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                    if depth == 0:
+                        return i
+        return None
+
+    @staticmethod
+    def _split_arguments(inner: str) -> list[str]:
+        args, depth, in_literal, current = [], 0, False, []
+        for char in inner:
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                elif char == "," and depth == 0:
+                    args.append("".join(current).strip())
+                    current = []
+                    continue
+            current.append(char)
+        if "".join(current).strip():
+            args.append("".join(current).strip())
+        return args
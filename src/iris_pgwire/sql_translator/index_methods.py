@@ -0,0 +1,369 @@
+"""
+CREATE INDEX Access Methods
+
+IRIS's CREATE INDEX has no USING clause; its index types are chosen by
+keyword (BITMAP, COLUMNAR) or by index class (AS %iFind.Index.Basic).
+Migrations written for PostgreSQL ask for gin, gist and brin indexes, so
+instead of failing, the method is mapped onto an IRIS index type:
+
+- gin   → BITMAP index (many rows per key: arrays, tags, jsonb keys)
+- gist  → standard index
+- brin  → COLUMNAR index (range scans over large append-only tables)
+- spgist, btree, hash → standard index
+
+PGWIRE_INDEX_METHODS overrides these as comma separated METHOD=TYPE entries,
+TYPE one of standard, bitmap, columnar or ifind:
+
+    PGWIRE_INDEX_METHODS="gin=ifind,brin=bitmap"
+
+A gin or gist index on to_tsvector(config, column) is always an iFind index
+on the column, the only IRIS index that searches text; an ifind mapping of
+an index on several columns creates a standard index. Operator classes
+(gin_trgm_ops, jsonb_path_ops), COLLATE clauses, WITH (...) storage
+parameters and CONCURRENTLY are removed, and an unnamed index is named as
+PostgreSQL would (table_column_idx). For gin, gist, brin and spgist the
+client receives a NOTICE naming the IRIS index type created.
+
+IRIS has no partial indexes either: the WHERE predicate of CREATE INDEX ...
+WHERE is removed and the index covers every row, with a NOTICE. A unique
+partial index (one active row per key) cannot be unique over every row, so
+it is created as a non-unique index with a WARNING that uniqueness is not
+enforced.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements that are not
+  CREATE INDEX are returned after a prefix check)
+"""
+
+import os
+import re
+
+import structlog
+
+logger = structlog.get_logger()
+
+_CREATE_INDEX = re.compile(r"^\s*CREATE\s+(?:UNIQUE\s+)?INDEX\b", re.IGNORECASE)
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+(?:\.\d*)?)"
+    r"|(?P<op>[(),.;=])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+INDEX_TYPES = ("standard", "bitmap", "columnar", "ifind")
+
+DEFAULT_INDEX_METHODS = {
+    "btree": "standard",
+    "hash": "standard",
+    "gin": "bitmap",
+    "gist": "standard",
+    "spgist": "standard",
+    "brin": "columnar",
+}
+
+# Methods whose index differs from what PostgreSQL would build (reported in a NOTICE)
+_NOTICE_METHODS = frozenset({"gin", "gist", "spgist", "brin"})
+
+_KEYWORDS = {"standard": "", "bitmap": "BITMAP ", "columnar": "COLUMNAR ", "ifind": ""}
+_DESCRIPTIONS = {
+    "standard": "a standard",
+    "bitmap": "a bitmap",
+    "columnar": "a columnar",
+    "ifind": "an iFind (%iFind.Index.Basic)",
+}
+
+IFIND_INDEX_CLASS = "%iFind.Index.Basic"
+
+# Leading column name of a column list entry, for the name of an unnamed index
+_COLUMN_NAME = re.compile(r'"(?:[^"]|"")*"|[A-Za-z_][\w$]*')
+# Function of a parenthesized expression entry, ((lower(email))), named after it
+_FUNCTION_NAME = re.compile(r"\(\s*([A-Za-z_][\w$]*)\s*\(")
+
+
+def load_index_methods(spec: str | None = None) -> dict[str, str]:
+    """PGWIRE_INDEX_METHODS over DEFAULT_INDEX_METHODS (invalid entries are ignored)."""
+    if spec is None:
+        spec = os.getenv("PGWIRE_INDEX_METHODS", "")
+    methods = dict(DEFAULT_INDEX_METHODS)
+    for entry in spec.split(","):
+        if not entry.strip():
+            continue
+        method, _, index_type = entry.partition("=")
+        method, index_type = method.strip().lower(), index_type.strip().lower()
+        if method not in DEFAULT_INDEX_METHODS or index_type not in INDEX_TYPES:
+            logger.warning("Ignoring invalid PGWIRE_INDEX_METHODS entry", entry=entry)
+            continue
+        methods[method] = index_type
+    return methods
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ) closing the parenthesis at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text == "(":
+            depth += 1
+        elif text == ")":
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _split(tokens) -> list[list]:
+    """Top-level comma-separated groups of tokens."""
+    groups, current, depth = [], [], 0
+    for token in tokens:
+        if token[1] == "(":
+            depth += 1
+        elif token[1] == ")":
+            depth -= 1
+        elif token[1] == "," and depth == 0:
+            groups.append(current)
+            current = []
+            continue
+        current.append(token)
+    groups.append(current)
+    return groups
+
+
+def _is(token, *words: str) -> bool:
+    return token is not None and token[0] == "word" and token[1].upper() in words
+
+
+def _entry_name(entry: str) -> str:
+    """Name part of a column list entry: its column, or the function of an expression."""
+    if entry.startswith("("):
+        function = _FUNCTION_NAME.match(entry)
+        return function.group(1) if function else "expr"
+    column = _COLUMN_NAME.match(entry)
+    return column.group() if column else "expr"
+
+
+class IndexStatement:
+    """The parts of a CREATE INDEX statement the rewrite needs."""
+
+    def __init__(
+        self, sql: str, tokens, method: str | None, concurrently, name, on, columns, where
+    ):
+        self.sql = sql
+        self.tokens = tokens
+        self.unique = _is(tokens[1], "UNIQUE")
+        self.method = method  # Lowercase, None without a USING clause
+        self.concurrently = concurrently  # Token index of CONCURRENTLY, or None
+        self.name = name  # Token index of the index name, or None
+        self.on = on  # Token index of ON
+        self.columns = columns  # (open, close) token indexes of the column list
+        self.where = where  # Token index of the WHERE of a partial index, or None
+        # The table name ends before USING or the column list
+        self.table = columns[0] - 3 if method is not None else columns[0] - 1
+
+    def column_sql(self) -> tuple[list[str], str | None]:
+        """
+        Column list entries without operator classes and COLLATE clauses, and
+        the column of a to_tsvector(...) entry (None without one).
+        """
+        entries, text_column = [], None
+        open_index, close_index = self.columns
+        for group in _split(self.tokens[open_index + 1 : close_index]):
+            if not group:
+                continue
+            if _is(group[0], "TO_TSVECTOR") and len(group) > 1 and group[1][1] == "(":
+                arguments = _split(group[2 : _closing(group, 1)])
+                last = arguments[-1] if arguments else []
+                if len(last) == 1 and last[0][0] == "word":
+                    text_column = last[0][1]
+                    entries.append(text_column)
+                    continue
+            kept, skip_next = [], False
+            for position, token in enumerate(group):
+                if skip_next:
+                    skip_next = False
+                    continue
+                if position and _is(token, "COLLATE"):
+                    skip_next = True
+                    continue
+                if position and token[0] == "word" and token[1].upper().endswith("_OPS"):
+                    continue
+                kept.append(token)
+            entries.append(" ".join(self.sql[token[2] : token[3]] for token in kept))
+        return entries, text_column
+
+    def index_name(self, entries: list[str]) -> str:
+        """The index name, or PostgreSQL's name for an unnamed index."""
+        if self.name is not None:
+            return self.tokens[self.name][1]
+        table = self.tokens[self.table][1]
+        parts = [table, *(_entry_name(entry) for entry in entries)]
+        quoted = any(part.startswith('"') for part in parts)
+        name = "_".join(part.strip('"') for part in [*parts, "idx"])
+        if quoted:
+            return f'"{name}"'
+        return name.upper() if table.isupper() else name
+
+
+def parse_create_index(sql: str) -> IndexStatement | None:
+    """CREATE INDEX statement parts, None for other statements."""
+    if not _CREATE_INDEX.match(sql):
+        return None
+    tokens = _tokens(sql)
+    position = 2 if _is(tokens[1], "UNIQUE") else 1  # tokens[position] is INDEX
+    position += 1
+    concurrently = None
+    if _is(tokens[position] if position < len(tokens) else None, "CONCURRENTLY"):
+        concurrently = position
+        position += 1
+    if (
+        position + 2 < len(tokens)
+        and _is(tokens[position], "IF")
+        and _is(tokens[position + 1], "NOT")
+        and _is(tokens[position + 2], "EXISTS")
+    ):
+        position += 3
+    name = None
+    if position < len(tokens) and not _is(tokens[position], "ON"):
+        name = position
+        position += 1
+    if position >= len(tokens) or not _is(tokens[position], "ON"):
+        return None
+    on = position
+    position += 1
+    if _is(tokens[position] if position < len(tokens) else None, "ONLY"):
+        position += 1
+    while position + 2 < len(tokens) and tokens[position + 1][1] == ".":
+        position += 2
+    if position >= len(tokens) or tokens[position][0] != "word":
+        return None
+    position += 1
+
+    method = None
+    if _is(tokens[position] if position < len(tokens) else None, "USING"):
+        if position + 1 >= len(tokens) or tokens[position + 1][0] != "word":
+            return None
+        method = tokens[position + 1][1].strip('"').lower()
+        position += 2
+    if position >= len(tokens) or tokens[position][1] != "(":
+        return None
+    close = _closing(tokens, position)
+    if close is None:
+        return None
+    where = next(
+        (index for index in range(close + 1, len(tokens)) if _is(tokens[index], "WHERE")), None
+    )
+    return IndexStatement(sql, tokens, method, concurrently, name, on, (position, close), where)
+
+
+def _index_type(statement: IndexStatement, methods: dict[str, str]) -> str | None:
+    """IRIS index type of the statement's method (None for unknown methods)."""
+    if statement.method is None:
+        return "standard"
+    index_type = methods.get(statement.method)
+    if index_type is None:
+        return None
+    entries, text_column = statement.column_sql()
+    if text_column is not None and statement.method in ("gin", "gist"):
+        index_type = "ifind"
+    if index_type == "ifind" and len(entries) != 1:
+        index_type = "standard"  # An iFind index covers one column
+    return index_type
+
+
+def rewrite_create_index(sql: str, methods: dict[str, str] | None = None) -> str | None:
+    """
+    CREATE INDEX in IRIS syntax, or None when sql needs no rewrite (not
+    CREATE INDEX, no USING, CONCURRENTLY or WHERE, or an unknown method).
+    """
+    statement = parse_create_index(sql)
+    if statement is None or (
+        statement.method is None and statement.concurrently is None and statement.where is None
+    ):
+        return None
+    index_type = _index_type(statement, methods or load_index_methods())
+    if index_type is None:
+        return None
+
+    tokens = statement.tokens
+    entries, _ = statement.column_sql()
+    unique = ""
+    if statement.unique and index_type == "standard" and statement.where is None:
+        unique = "UNIQUE "
+    # [IF NOT EXISTS] name, after CREATE [UNIQUE] INDEX [CONCURRENTLY]
+    start = (statement.concurrently or (2 if statement.unique else 1)) + 1
+    name_sql = sql[tokens[start][2] : tokens[statement.on][2]]
+    if statement.name is None:
+        name_sql += statement.index_name(entries) + " "
+    table_sql = sql[tokens[statement.on][2] : tokens[statement.table][3]]
+
+    rewritten = (
+        f"CREATE {unique}{_KEYWORDS[index_type]}INDEX {name_sql}{table_sql} "
+        f"({', '.join(entries)})"
+    )
+    if index_type == "ifind":
+        rewritten += f" AS {IFIND_INDEX_CLASS}"
+
+    # What follows the column list, without WITH (...) storage parameters and WHERE
+    rest = statement.columns[1] + 1
+    if rest + 1 < len(tokens) and _is(tokens[rest], "WITH") and tokens[rest + 1][1] == "(":
+        rest = (_closing(tokens, rest + 1) or rest) + 1
+    end = len(tokens) if statement.where is None else statement.where
+    if rest < end:
+        rewritten += " " + sql[tokens[rest][2] : tokens[end - 1][3]].strip()
+    return rewritten
+
+
+def index_method_notice(sql: str) -> tuple[str, str, str, str] | None:
+    """NOTICE naming the IRIS index type a gin, gist, brin or spgist index is created as."""
+    statement = parse_create_index(sql)
+    if statement is None or statement.method not in _NOTICE_METHODS:
+        return None
+    methods = load_index_methods()
+    index_type = _index_type(statement, methods)
+    entries, _ = statement.column_sql()
+    name = statement.index_name(entries).strip('"')
+    message = (
+        f'{statement.method} index "{name}" created as {_DESCRIPTIONS[index_type]} IRIS index'
+    )
+    if index_type == "ifind":
+        table = statement.tokens[statement.table][1].strip('"').upper()
+        column = entries[0].strip('"').upper()
+        detail = f"to_tsquery searches use it once PGWIRE_FTS_INDEXES lists {table}.{column}={name}"
+    else:
+        detail = f"PGWIRE_INDEX_METHODS maps {statement.method} to {index_type} indexes"
+    return "NOTICE", "00000", message, detail
+
+
+def partial_index_notice(sql: str) -> tuple[str, str, str, str] | None:
+    """NOTICE (WARNING when it was unique) that a partial index covers every row."""
+    statement = parse_create_index(sql)
+    if statement is None or statement.where is None:
+        return None
+    entries, _ = statement.column_sql()
+    name = statement.index_name(entries).strip('"')
+    if statement.unique:
+        return (
+            "WARNING",
+            "01000",
+            f'unique partial index "{name}" created as a non-unique index on every row',
+            "IRIS has no partial indexes; uniqueness among the rows matching the WHERE "
+            "predicate is not enforced",
+        )
+    return (
+        "NOTICE",
+        "00000",
+        f'partial index "{name}" created on every row',
+        "IRIS has no partial indexes; the WHERE predicate is not part of the index",
+    )
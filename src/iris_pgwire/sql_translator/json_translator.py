@@ -0,0 +1,358 @@
+"""
+JSON Operator and Function Translator for PostgreSQL-Compatible SQL
+
+IRIS queries JSON text with the SQL/JSON functions, addressing values by a
+JSON path. PostgreSQL's json / jsonb operators and functions are rewritten
+onto them:
+
+- x -> 'key', x -> 2          → JSON_QUERY(x, '$.key'), JSON_QUERY(x, '$[2]')
+- x ->> 'key'                 → JSON_VALUE(x, '$.key')
+- x #> '{a,0,b}'              → JSON_QUERY(x, '$.a[0].b')
+- x #>> '{a,b}'               → JSON_VALUE(x, '$.a.b')
+- json[b]_extract_path(x, 'a', 'b')       → JSON_QUERY(x, '$.a.b')
+- json[b]_extract_path_text(x, 'a', 'b')  → JSON_VALUE(x, '$.a.b')
+- json[b]_build_object('k', v, ...)       → JSON_OBJECT('k' : v, ...)
+- json[b]_build_array(a, b, ...)          → JSON_ARRAY(a, b, ...)
+
+Chains (x -> 'a' -> 'b' ->> 'c') become one path; the last operator decides
+between JSON_QUERY (json) and JSON_VALUE (text). Negative subscripts count
+from the end ($[last]). Keys and paths must be literals: an operator whose
+right operand is a parameter or an identifier is left unchanged, which also
+keeps IRIS's arrow syntax for implicit joins (Employer->Name). A ::json or
+::jsonb cast of the left operand is dropped.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without
+  -> / #> or a JSON function name are returned after a substring check)
+"""
+
+import re
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<op>->>|->|#>>|#>|::|<>|!=|[-+*/%(),.?;:=<>\[\]])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+_OPERATORS = frozenset({"->", "->>", "#>", "#>>"})
+_TEXT_OPERATORS = frozenset({"->>", "#>>"})
+
+_FUNCTIONS = re.compile(r"\bJSONB?_(?:EXTRACT_PATH(?:_TEXT)?|BUILD_OBJECT|BUILD_ARRAY)\b", re.I)
+
+# Words before "(" that are not function names (the parentheses group an expression)
+_KEYWORDS = frozenset(
+    {
+        "SELECT", "WHERE", "AND", "OR", "NOT", "ON", "WHEN", "THEN", "ELSE", "IN", "BY",
+        "HAVING", "RETURNING", "SET", "VALUES", "AS", "IS", "LIKE", "BETWEEN", "CASE",
+        "DISTINCT", "FROM", "JOIN", "USING", "EXISTS", "ANY", "ALL", "SOME",
+    }
+)
+
+_PLAIN_KEY = re.compile(r"^[A-Za-z_][A-Za-z0-9_]*$")
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ) closing the parenthesis at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text in ("(", "["):
+            depth += 1
+        elif text in (")", "]"):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _opening(tokens, index: int) -> int | None:
+    """Index of the ( opening the parenthesis closed at tokens[index]."""
+    depth = 0
+    for position in range(index, -1, -1):
+        text = tokens[position][1]
+        if text in (")", "]"):
+            depth += 1
+        elif text in ("(", "["):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _split(tokens) -> list[list]:
+    """Top-level comma-separated groups of tokens."""
+    groups, current, depth = [], [], 0
+    for token in tokens:
+        if token[1] in ("(", "["):
+            depth += 1
+        elif token[1] in (")", "]"):
+            depth -= 1
+        elif token[1] == "," and depth == 0:
+            groups.append(current)
+            current = []
+            continue
+        current.append(token)
+    groups.append(current)
+    return groups
+
+
+def _string_value(token) -> str | None:
+    if token[0] != "string":
+        return None
+    return token[1][1:-1].replace("''", "'")
+
+
+def _key_step(key: str) -> str:
+    """JSON path step of an object key."""
+    if _PLAIN_KEY.match(key):
+        return f".{key}"
+    escaped = key.replace("\\", "\\\\").replace('"', '\\"')
+    return f'."{escaped}"'
+
+
+def _index_step(index: int) -> str:
+    """JSON path step of an array subscript (negative counts from the end)."""
+    if index >= 0:
+        return f"[{index}]"
+    return "[last]" if index == -1 else f"[last-{-index - 1}]"
+
+
+def _path_literal(steps: list[str]) -> str:
+    path = "$" + "".join(steps)
+    return "'" + path.replace("'", "''") + "'"
+
+
+def _text_array(text: str) -> list[str] | None:
+    """Elements of a text[] literal like {a,b,"c d"} (None for other text)."""
+    text = text.strip()
+    if not (text.startswith("{") and text.endswith("}")):
+        return None
+    elements, current, quoted, escaped = [], "", False, False
+    body = text[1:-1]
+    if not body.strip():
+        return []
+    for char in body:
+        if escaped:
+            current += char
+            escaped = False
+        elif char == "\\":
+            escaped = True
+        elif char == '"':
+            quoted = not quoted
+        elif char == "," and not quoted:
+            elements.append(current.strip())
+            current = ""
+        else:
+            current += char
+    elements.append(current.strip())
+    return elements
+
+
+def _uncast(sql: str, tokens, start: int, end: int) -> str:
+    """SQL of tokens[start..end], without a CAST(... AS JSON / JSONB) around it."""
+    if (
+        end - start >= 4
+        and tokens[start][1].upper() == "CAST"
+        and tokens[start + 1][1] == "("
+        and _closing(tokens, start + 1) == end
+        and tokens[end - 1][1].upper() in ("JSON", "JSONB")
+        and tokens[end - 2][1].upper() == "AS"
+    ):
+        return sql[tokens[start + 2][2] : tokens[end - 3][3]]
+    return sql[tokens[start][2] : tokens[end][3]]
+
+
+class JsonTranslator:
+    """Rewrites PostgreSQL JSON operators and functions into SQL/JSON functions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate JSON operators and functions.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, number of operators and functions rewritten)
+        """
+        if "->" not in sql and "#>" not in sql and not _FUNCTIONS.search(sql):
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            tokens = _tokens(sql)
+            position = next(
+                (
+                    i
+                    for i, token in enumerate(tokens)
+                    if token[2] >= search_from
+                    and (
+                        (token[0] == "op" and token[1] in _OPERATORS)
+                        or (token[0] == "word" and _FUNCTIONS.fullmatch(token[1]))
+                    )
+                ),
+                None,
+            )
+            if position is None:
+                return sql, count
+            search_from = tokens[position][3]
+
+            if tokens[position][0] == "op":
+                rewrite = self._operators(sql, tokens, position)
+            else:
+                rewrite = self._function(sql, tokens, position)
+            if rewrite is None:
+                continue
+            start, end, replacement = rewrite
+            sql = sql[:start] + replacement + sql[end:]
+            search_from = start
+            count += 1
+
+    # ------------------------------------------------------------------ operators
+
+    @staticmethod
+    def _steps(operator: str, tokens, index: int) -> tuple[list[str], int] | None:
+        """Path steps of the right operand at tokens[index], and the index after it."""
+        token = tokens[index] if index < len(tokens) else None
+        if token is None:
+            return None
+        if operator in ("->", "->>"):
+            if token[0] == "string":
+                return [_key_step(_string_value(token))], index + 1
+            if token[0] == "number" and token[1].isdigit():
+                return [_index_step(int(token[1]))], index + 1
+            if (
+                token[1] == "-"
+                and index + 1 < len(tokens)
+                and tokens[index + 1][0] == "number"
+                and tokens[index + 1][1].isdigit()
+            ):
+                return [_index_step(-int(tokens[index + 1][1]))], index + 2
+            return None
+        elements = _text_array(_string_value(token) or "") if token[0] == "string" else None
+        if elements is None:
+            return None
+        steps = []
+        for element in elements:
+            if element.lstrip("-").isdigit():
+                steps.append(_index_step(int(element)))
+            else:
+                steps.append(_key_step(element))
+        return steps, index + 1
+
+    @staticmethod
+    def _operand_start(tokens, index: int) -> tuple[int, int] | None:
+        """
+        First token of the left operand ending at tokens[index], and the last
+        token to keep (a trailing ::json / ::jsonb cast is dropped).
+        """
+        end = index
+        while (
+            index >= 2
+            and tokens[index][0] == "word"
+            and tokens[index - 1][1] == "::"
+        ):
+            if tokens[index][1].upper() in ("JSON", "JSONB") and index == end:
+                end = index - 2
+            index -= 2
+        token = tokens[index]
+        if token[1] == ")":
+            opening = _opening(tokens, index)
+            if opening is None:
+                return None
+            before = tokens[opening - 1] if opening > 0 else None
+            if (
+                before is not None
+                and before[0] == "word"
+                and before[1].upper() not in _KEYWORDS
+            ):
+                opening -= 1
+            return opening, end
+        if token[0] == "word":
+            while index >= 2 and tokens[index - 1][1] == "." and tokens[index - 2][0] == "word":
+                index -= 2
+            return index, end
+        if token[0] in ("string", "number") or token[1] == "?":
+            return index, end
+        return None
+
+    def _operators(self, sql: str, tokens, position: int) -> tuple[int, int, str] | None:
+        if position == 0:
+            return None
+        operand = self._operand_start(tokens, position - 1)
+        if operand is None:
+            return None
+        start, operand_end = operand
+
+        steps, index, operator = [], position, None
+        while index < len(tokens) and tokens[index][0] == "op" and tokens[index][1] in _OPERATORS:
+            if operator in _TEXT_OPERATORS:
+                break  # text has no members
+            parsed = self._steps(tokens[index][1], tokens, index + 1)
+            if parsed is None:
+                break
+            operator = tokens[index][1]
+            more, index = parsed
+            steps += more
+        if operator is None:
+            return None
+
+        function = "JSON_VALUE" if operator in _TEXT_OPERATORS else "JSON_QUERY"
+        operand_sql = _uncast(sql, tokens, start, operand_end)
+        replacement = f"{function}({operand_sql}, {_path_literal(steps)})"
+        return tokens[start][2], tokens[index - 1][3], replacement
+
+    # ------------------------------------------------------------------ functions
+
+    @staticmethod
+    def _function(sql: str, tokens, position: int) -> tuple[int, int, str] | None:
+        if position + 1 >= len(tokens) or tokens[position + 1][1] != "(":
+            return None
+        if position > 0 and tokens[position - 1][1] == ".":
+            return None  # A schema-qualified name, not PostgreSQL's function
+        close = _closing(tokens, position + 1)
+        if close is None:
+            return None
+        name = tokens[position][1].upper()
+        inner = tokens[position + 2 : close]
+        arguments = [] if not inner else _split(inner)
+        if any(not argument for argument in arguments):
+            return None
+        texts = [sql[argument[0][2] : argument[-1][3]] for argument in arguments]
+        start, end = tokens[position][2], tokens[close][3]
+
+        if name.endswith("_BUILD_ARRAY"):
+            return start, end, f"JSON_ARRAY({', '.join(texts)})" if texts else "'[]'"
+        if name.endswith("_BUILD_OBJECT"):
+            if len(texts) % 2:
+                return None  # PostgreSQL: argument list must have even number of elements
+            if not texts:
+                return start, end, "'{}'"
+            pairs = [f"{texts[i]} : {texts[i + 1]}" for i in range(0, len(texts), 2)]
+            return start, end, f"JSON_OBJECT({', '.join(pairs)})"
+
+        # json[b]_extract_path[_text](from_json, VARIADIC path_elems)
+        if len(arguments) < 2:
+            return None
+        steps = []
+        for argument in arguments[1:]:
+            key = _string_value(argument[0]) if len(argument) == 1 else None
+            if key is None:
+                return None
+            steps.append(_index_step(int(key)) if key.lstrip("-").isdigit() else _key_step(key))
+        function = "JSON_VALUE" if name.endswith("_TEXT") else "JSON_QUERY"
+        return start, end, f"{function}({texts[0]}, {_path_literal(steps)})"
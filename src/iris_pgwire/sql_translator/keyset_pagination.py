@@ -0,0 +1,273 @@
+"""
+Keyset Pagination for Deep OFFSET Queries
+
+BI tools page through tables with ORDER BY key LIMIT n OFFSET m. IRIS reads
+and discards the m skipped rows for every page, so deep pages degrade into
+full scans. For tables with a configured ordering key (unique, NOT NULL,
+typically the primary key), a deep page is rewritten to seek to its first
+key instead:
+
+    SELECT ... FROM t WHERE w ORDER BY k LIMIT n OFFSET m
+    →
+    SELECT ... FROM t WHERE (w) AND k >= (
+        SELECT CASE WHEN COUNT(*) > m THEN MAX(KEYSET_KEY) END
+        FROM (SELECT TOP m+1 k AS KEYSET_KEY FROM t WHERE w ORDER BY k) AS KEYSET_PAGE
+    ) ORDER BY k LIMIT n
+
+The subquery walks only the key's index to the page start; rows are read for
+the page alone. DESC pages use MIN and <=. The result is the same rows in
+the same order because the key is unique; a page past the end has no start
+key (NULL) and returns no rows, as the OFFSET would.
+
+Rewritten statements are single-table SELECTs ordered by the key alone, with
+a literal OFFSET of at least PGWIRE_KEYSET_MIN_OFFSET (default 1000) and no
+DISTINCT, GROUP BY, HAVING, joins, set operations or FOR UPDATE. Key columns
+get no NULL-rank sort key (null_ordering_translator.py), so IRIS can keep
+using the index order.
+
+Configuration:
+- PGWIRE_KEYSET_KEYS: comma-separated table.column or schema.table.column
+  (e.g. orders.id,SQLUser.events.event_id); unset disables the rewrite
+- PGWIRE_KEYSET_MIN_OFFSET: smallest OFFSET rewritten (default 1000)
+"""
+
+import os
+import re
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+(?:\.\d*)?)"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+# Keywords that rule a statement out when they appear outside parentheses
+_UNSUPPORTED = frozenset(
+    {
+        "DISTINCT", "TOP", "JOIN", "GROUP", "HAVING", "UNION", "INTERSECT", "EXCEPT",
+        "FOR", "WINDOW", "INTO",
+    }
+)
+
+DEFAULT_MIN_OFFSET = 1000
+
+
+def _identifier(name: str) -> str:
+    """Comparable form of an identifier: quoted as written, unquoted uppercased."""
+    if name.startswith('"') and name.endswith('"'):
+        return name[1:-1].replace('""', '"')
+    return name.upper()
+
+
+def split_name(name: str) -> list[str]:
+    """Comparable parts of a (qualified) identifier."""
+    return [_identifier(part) for part in re.findall(r'"(?:[^"]|"")*"|[^.\s]+', name)]
+
+
+def load_keyset_keys(value: str | None = None) -> dict[tuple[str | None, str], str]:
+    """(schema or None, table) -> key column, from PGWIRE_KEYSET_KEYS."""
+    if value is None:
+        value = os.getenv("PGWIRE_KEYSET_KEYS", "")
+    keys = {}
+    for entry in value.split(","):
+        parts = split_name(entry.strip())
+        if len(parts) == 2:
+            keys[(None, parts[0])] = parts[1]
+        elif len(parts) == 3:
+            keys[(parts[0], parts[1])] = parts[2]
+    return keys
+
+
+def key_column_names() -> frozenset[str]:
+    """Names of the configured key columns (unique and NOT NULL)."""
+    return frozenset(load_keyset_keys().values())
+
+
+def _min_offset() -> int:
+    try:
+        return int(os.getenv("PGWIRE_KEYSET_MIN_OFFSET", DEFAULT_MIN_OFFSET))
+    except ValueError:
+        return DEFAULT_MIN_OFFSET
+
+
+class KeysetPaginationTranslator:
+    """Rewrites deep OFFSET pages of tables with a configured key into key seeks."""
+
+    def __init__(self, keys: dict[tuple[str | None, str], str] | None = None):
+        self._keys = keys
+
+    @property
+    def keys(self) -> dict[tuple[str | None, str], str]:
+        return self._keys if self._keys is not None else load_keyset_keys()
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Rewrite a deep OFFSET page into a keyset seek.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, 1 if rewritten else 0)
+        """
+        upper = sql.upper()
+        if "OFFSET" not in upper or "ORDER" not in upper:
+            return sql, 0
+        keys = self.keys
+        if not keys:
+            return sql, 0
+        page = self._parse(sql)
+        if page is None or page["offset"] < _min_offset():
+            return sql, 0
+        key = self._key_reference(page, keys)
+        if key is None:
+            return sql, 0
+        return self._rewrite(page, key), 1
+
+    @staticmethod
+    def _parse(sql: str) -> dict | None:
+        """Clauses of a single-table SELECT ... ORDER BY ... LIMIT/OFFSET, or None."""
+        tokens = [
+            (kind, text, start, end)
+            for kind, text, start, end in (
+                (m.lastgroup, m.group(), m.start(), m.end()) for m in _TOKEN.finditer(sql)
+            )
+            if kind not in ("space", "skip")
+        ]
+        if tokens and tokens[-1][1] == ";":
+            tokens.pop()
+        if not tokens or tokens[0][1].upper() != "SELECT":
+            return None
+
+        # Positions of the top-level clause keywords
+        clauses: dict[str, int] = {}
+        depth = 0
+        for index, (kind, text, _, _) in enumerate(tokens):
+            upper = text.upper()
+            if text == "(":
+                depth += 1
+            elif text == ")":
+                depth -= 1
+            elif depth == 0 and kind == "word":
+                if upper in _UNSUPPORTED:
+                    return None
+                if upper in ("FROM", "WHERE", "ORDER", "LIMIT", "OFFSET", "FETCH"):
+                    if upper in clauses:
+                        return None
+                    clauses[upper] = index
+            elif depth == 0 and text == ";":
+                return None  # Several statements
+        if depth or not {"FROM", "ORDER", "OFFSET"} <= clauses.keys():
+            return None
+
+        order = clauses["ORDER"]
+        if order + 1 >= len(tokens) or tokens[order + 1][1].upper() != "BY":
+            return None
+        ends = sorted(clauses.values())
+
+        def span(keyword: str, skip: int = 1) -> tuple[int, int] | None:
+            if keyword not in clauses:
+                return None
+            first = clauses[keyword] + skip
+            following = [i for i in ends if i > clauses[keyword]]
+            last = following[0] if following else len(tokens)
+            if first >= last:
+                return None
+            return tokens[first][2], tokens[last - 1][3]
+
+        def text_of(bounds):
+            return sql[bounds[0] : bounds[1]] if bounds else None
+
+        # The paging clauses follow ORDER BY, as LIMIT n OFFSET m, OFFSET m LIMIT n
+        # or OFFSET m ROWS FETCH FIRST n ROWS ONLY
+        if not all(clauses[k] > order for k in ("LIMIT", "OFFSET", "FETCH") if k in clauses):
+            return None
+        if "WHERE" in clauses and not clauses["FROM"] < clauses["WHERE"] < order:
+            return None
+        if not clauses["FROM"] < order:
+            return None
+        offset = re.fullmatch(r"(\d+)(?:\s+ROWS?)?", text_of(span("OFFSET")) or "", re.I)
+        if offset is None:
+            return None
+        limit = None
+        if "LIMIT" in clauses:
+            limit = re.fullmatch(r"\d+", text_of(span("LIMIT")) or "")
+            if limit is None:
+                return None
+        elif "FETCH" in clauses:
+            limit = re.fullmatch(
+                r"(?:FIRST|NEXT)\s+(\d+)\s+ROWS?\s+ONLY", text_of(span("FETCH")) or "", re.I
+            )
+            if limit is None:
+                return None
+
+        source = text_of(span("FROM"))
+        table = re.fullmatch(
+            r'(?P<name>(?:"(?:[^"]|"")*"|\w+)(?:\s*\.\s*(?:"(?:[^"]|"")*"|\w+))?)'
+            r'(?:\s+(?:AS\s+)?(?P<alias>"(?:[^"]|"")*"|\w+))?',
+            source or "",
+            re.IGNORECASE,
+        )
+        if table is None:
+            return None
+        return {
+            "select": sql[tokens[0][3] : tokens[clauses["FROM"]][2]].strip(),
+            "source": source,
+            "table": split_name(table.group("name")),
+            "alias": table.group("alias"),
+            "where": text_of(span("WHERE")),
+            "order": text_of(span("ORDER", skip=2)),
+            "offset": int(offset.group(1)),
+            "limit": int(limit.group(limit.lastindex or 0)) if limit else None,
+        }
+
+    @staticmethod
+    def _key_reference(page: dict, keys) -> tuple[str, str] | None:
+        """(key expression as written, direction) when the page is ordered by its table's key."""
+        *schema, table = page["table"]
+        key = keys.get((schema[0], table)) if schema else None
+        key = key or keys.get((None, table))
+        if key is None:
+            return None
+
+        if "," in page["order"]:
+            return None
+        item = re.fullmatch(
+            r"(?P<expr>.+?)(?:\s+(?P<direction>ASC|DESC))?(?:\s+NULLS\s+(?:FIRST|LAST))?",
+            page["order"].strip(),
+            re.IGNORECASE | re.DOTALL,
+        )
+        if item is None:
+            return None
+        parts = split_name(item.group("expr"))
+        qualifiers = {table}
+        if page["alias"]:
+            qualifiers = {_identifier(page["alias"])}
+        if parts[-1] != key or (len(parts) == 2 and parts[0] not in qualifiers) or len(parts) > 2:
+            return None
+        return item.group("expr"), (item.group("direction") or "ASC").upper()
+
+    @staticmethod
+    def _rewrite(page: dict, key: tuple[str, str]) -> str:
+        expr, direction = key
+        descending = direction == "DESC"
+        where = page["where"]
+        inner_where = f" WHERE {where}" if where else ""
+        seek = (
+            f"{expr} {'<=' if descending else '>='} ("
+            f"SELECT CASE WHEN COUNT(*) > {page['offset']} "
+            f"THEN {'MIN' if descending else 'MAX'}(KEYSET_KEY) END "
+            f"FROM (SELECT TOP {page['offset'] + 1} {expr} AS KEYSET_KEY "
+            f"FROM {page['source']}{inner_where} ORDER BY {expr}{' DESC' if descending else ''}"
+            f") AS KEYSET_PAGE)"
+        )
+        condition = f"({where}) AND {seek}" if where else seek
+        limit = f" LIMIT {page['limit']}" if page["limit"] is not None else ""
+        order = f"{expr}{' DESC' if descending else ''}"
+        return (
+            f"SELECT {page['select']} FROM {page['source']} WHERE {condition} "
+            f"ORDER BY {order}{limit}"
+        )
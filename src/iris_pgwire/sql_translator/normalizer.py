@@ -15,8 +15,23 @@ Feature 030 Extension:
 import time
 
 from ..schema_mapper import translate_input_schema
+from .arithmetic_translator import ArithmeticTranslator
+from .array_translator import ArrayTranslator
+from .boolean_translator import BooleanTranslator
 from .date_translator import DATETranslator
+from .ddl_translator import DDLTranslator
+from .datetime_function_translator import DateTimeFunctionTranslator
+from .fts_translator import FullTextSearchTranslator
 from .identifier_normalizer import IdentifierNormalizer
+from .ifind_translator import IFindTranslator
+from .json_translator import JsonTranslator
+from .keyset_pagination import KeysetPaginationTranslator
+from .null_ordering_translator import NullOrderingTranslator
+from .string_function_translator import StringFunctionTranslator
+from .timezone_translator import TimeZoneTranslator
+from .trigram_translator import TrigramTranslator
+from .values_translator import ValuesTranslator
+from .vector_translator import VectorTranslator
 
 
 class SQLTranslator:
@@ -29,18 +44,68 @@ class SQLTranslator:
     Combines:
     - Identifier case normalization (unquoted → UPPERCASE, quoted → preserve)
     - DATE literal translation ('YYYY-MM-DD' → TO_DATE(...))
+    - AT TIME ZONE / timezone() → DATEADD offset arithmetic
+    - extract(epoch)/to_timestamp/make_* → DATEDIFF/DATEADD/DATEPART
+    - pg_trgm similarity()/word_similarity()/% → INSTR trigram arithmetic
+    - tsvector @@ tsquery → iFind %FIND (configured indexes) or LIKE matching
+    - ifind_match/ifind_rank/ifind_highlight → iFind %FIND and generated procedures
+    - PostgreSQL-only DDL clauses (DEFERRABLE, INITIALLY DEFERRED) → removed, BYTEA
+      columns → LONGVARBINARY streams, UUID columns → UNIQUEIDENTIFIER or CHAR(36),
+      JSON/JSONB columns → long VARCHARs
+    - CREATE TABLE ... USING columnar and PGWIRE_COLUMNAR_TABLES → columnar storage
+    - CREATE INDEX ... USING gin/gist/brin → bitmap, iFind, columnar or standard indexes
+    - VALUES lists as queries and table expressions → UNION ALL selects
+    - ORDER BY ... NULLS FIRST/LAST and PostgreSQL's NULL order → NULL-rank sort keys
+    - deep LIMIT/OFFSET pages of tables with a configured key → keyset seeks
+    - ARRAY[...], col[n] and col[m:n] → $LISTBUILD/$LISTGET/$LIST (%List arrays)
+    - integer division and % → IRIS integer division (\\) with PostgreSQL's signs
+    - left/right/split_part/strpos/starts_with/lpad/rpad/concat/concat_ws/format → IRIS
+    - ->, ->>, #>, #>> and json[b]_extract_path/_build_object/_build_array →
+      JSON_QUERY/JSON_VALUE/JSON_OBJECT/JSON_ARRAY
+    - pgvector's <=>, <#>, <-> and ::vector → VECTOR_COSINE/VECTOR_DOT_PRODUCT/TO_VECTOR
+    - TRUE/FALSE and 't'::bool → 1/0 (IRIS BIT values)
     """
 
     def __init__(self):
         """Initialize SQL translator with component normalizers"""
         self.identifier_normalizer = IdentifierNormalizer()
         self.date_translator = DATETranslator()
+        self.timezone_translator = TimeZoneTranslator()
+        self.datetime_function_translator = DateTimeFunctionTranslator()
+        self.trigram_translator = TrigramTranslator()
+        self.fts_translator = FullTextSearchTranslator()
+        self.ifind_translator = IFindTranslator(self.fts_translator.index_map)
+        self.ddl_translator = DDLTranslator()
+        self.values_translator = ValuesTranslator()
+        self.null_ordering_translator = NullOrderingTranslator()
+        self.keyset_translator = KeysetPaginationTranslator()
+        self.array_translator = ArrayTranslator()
+        self.arithmetic_translator = ArithmeticTranslator()
+        self.string_function_translator = StringFunctionTranslator()
+        self.json_translator = JsonTranslator()
+        self.vector_translator = VectorTranslator()
+        self.boolean_translator = BooleanTranslator()
 
         # Metrics tracking for last normalization
         self._last_metrics = {
             "normalization_time_ms": 0.0,
             "identifier_count": 0,
             "date_literal_count": 0,
+            "timezone_conversion_count": 0,
+            "datetime_function_count": 0,
+            "trigram_function_count": 0,
+            "full_text_search_count": 0,
+            "ifind_function_count": 0,
+            "ddl_clause_count": 0,
+            "values_list_count": 0,
+            "null_ordering_count": 0,
+            "keyset_page_count": 0,
+            "array_count": 0,
+            "arithmetic_count": 0,
+            "string_function_count": 0,
+            "json_operator_count": 0,
+            "vector_operator_count": 0,
+            "boolean_literal_count": 0,
             "sla_violated": False,
         }
 
@@ -70,6 +135,21 @@ class SQLTranslator:
                 "normalization_time_ms": 0.0,
                 "identifier_count": 0,
                 "date_literal_count": 0,
+                "timezone_conversion_count": 0,
+                "datetime_function_count": 0,
+                "trigram_function_count": 0,
+                "full_text_search_count": 0,
+                "ifind_function_count": 0,
+                "ddl_clause_count": 0,
+                "values_list_count": 0,
+                "null_ordering_count": 0,
+                "keyset_page_count": 0,
+                "array_count": 0,
+                "arithmetic_count": 0,
+                "string_function_count": 0,
+                "json_operator_count": 0,
+                "vector_operator_count": 0,
+                "boolean_literal_count": 0,
                 "sla_violated": False,
             }
             return sql
@@ -80,9 +160,61 @@ class SQLTranslator:
         # Step 1: Normalize identifiers (unquoted → UPPERCASE)
         normalized_sql, identifier_count = self.identifier_normalizer.normalize(normalized_sql)
 
-        # Step 2: Translate DATE literals ('YYYY-MM-DD' → TO_DATE(...))
+        # Step 2: Translate AT TIME ZONE (before DATE literals so operands stay recognizable)
+        normalized_sql, timezone_count = self.timezone_translator.translate(normalized_sql)
+
+        # Step 3: Translate date/time functions (extract(epoch), to_timestamp, make_*)
+        normalized_sql, datetime_count = self.datetime_function_translator.translate(
+            normalized_sql
+        )
+
+        # Step 4: pg_trgm similarity shims (similarity(), word_similarity(), %, <%, %>)
+        normalized_sql, trigram_count = self.trigram_translator.translate(normalized_sql)
+
+        # Step 5: Full text search (to_tsvector(...) @@ to_tsquery(...), ts_rank)
+        normalized_sql, fts_count = self.fts_translator.translate(normalized_sql)
+
+        # Step 6: iFind functions (ifind_match, ifind_rank, ifind_highlight)
+        normalized_sql, ifind_count = self.ifind_translator.translate(normalized_sql)
+
+        # Step 7: Translate DATE literals ('YYYY-MM-DD' → TO_DATE(...))
         normalized_sql, date_count = self.date_translator.translate(normalized_sql)
 
+        # Step 8: PostgreSQL-only DDL clauses (DEFERRABLE, INITIALLY DEFERRED), BYTEA, UUID
+        # and MONEY columns, CREATE INDEX access methods
+        normalized_sql, ddl_count = self.ddl_translator.translate(normalized_sql)
+
+        # Step 9: VALUES lists used as queries (VALUES (1), (2) / FROM (VALUES ...) AS t(a))
+        normalized_sql, values_count = self.values_translator.translate(normalized_sql)
+
+        # Step 10: NULL ordering (NULLS FIRST/LAST, PostgreSQL's NULLs-last ascending order)
+        normalized_sql, null_ordering_count = self.null_ordering_translator.translate(
+            normalized_sql
+        )
+
+        # Step 11: Deep OFFSET pages → keyset seeks (PGWIRE_KEYSET_KEYS, optional)
+        normalized_sql, keyset_count = self.keyset_translator.translate(normalized_sql)
+
+        # Step 12: Arrays (ARRAY[...], subscripts, slices) → %List functions
+        normalized_sql, array_count = self.array_translator.translate(normalized_sql)
+
+        # Step 13: Integer division and modulo (7 / 2 = 3, -7 % 2 = -1)
+        normalized_sql, arithmetic_count = self.arithmetic_translator.translate(normalized_sql)
+
+        # Step 14: String functions IRIS lacks (split_part, format, concat_ws, lpad, ...)
+        normalized_sql, string_function_count = self.string_function_translator.translate(
+            normalized_sql
+        )
+
+        # Step 15: JSON operators and functions → SQL/JSON (JSON_VALUE, JSON_OBJECT, ...)
+        normalized_sql, json_count = self.json_translator.translate(normalized_sql)
+
+        # Step 16: pgvector distance operators and ::vector casts → IRIS vector functions
+        normalized_sql, vector_count = self.vector_translator.translate(normalized_sql)
+
+        # Step 17: Boolean literals (TRUE/FALSE, 't'::bool) → BIT values 1/0
+        normalized_sql, boolean_count = self.boolean_translator.translate(normalized_sql)
+
         # Calculate performance metrics
         end_time = time.perf_counter()
         normalization_time_ms = (end_time - start_time) * 1000
@@ -93,6 +225,21 @@ class SQLTranslator:
             "normalization_time_ms": normalization_time_ms,
             "identifier_count": identifier_count,
             "date_literal_count": date_count,
+            "timezone_conversion_count": timezone_count,
+            "datetime_function_count": datetime_count,
+            "trigram_function_count": trigram_count,
+            "full_text_search_count": fts_count,
+            "ifind_function_count": ifind_count,
+            "ddl_clause_count": ddl_count,
+            "values_list_count": values_count,
+            "null_ordering_count": null_ordering_count,
+            "keyset_page_count": keyset_count,
+            "array_count": array_count,
+            "arithmetic_count": arithmetic_count,
+            "string_function_count": string_function_count,
+            "json_operator_count": json_count,
+            "vector_operator_count": vector_count,
+            "boolean_literal_count": boolean_count,
             "sla_violated": sla_violated,
         }
 
@@ -0,0 +1,369 @@
+"""
+NULL Ordering Translator for PostgreSQL-Compatible SQL
+
+PostgreSQL sorts NULLs as larger than every value: last in ascending order,
+first in descending order. IRIS sorts them as smaller: first ascending, last
+descending, and does not parse NULLS FIRST / NULLS LAST. Ordered pagination
+over a nullable column therefore returns different pages, so each ORDER BY
+item is rewritten to sort NULLs where PostgreSQL would:
+
+- expr [ASC] [NULLS LAST], expr DESC [NULLS FIRST]: a NULL-rank key is
+  sorted first, CASE WHEN expr IS NULL THEN 1 ELSE 0 END, expr (0 / 1 for
+  NULLs first)
+- expr [ASC] NULLS FIRST, expr DESC NULLS LAST: IRIS's own order; the NULLS
+  clause is dropped and no key is added, so an index can still give the order
+
+Ordinals and output-column aliases (ORDER BY 2, ORDER BY total) rank the
+select-list expression they name. No key is formed for ORDER BY of a set
+operation (UNION ...) or of SELECT DISTINCT (IRIS requires ORDER BY items in
+the select list), ordinals of SELECT *, USING operators, and vector
+distances (VECTOR_COSINE, <=>, <#>, and aliases of them), which keep the
+shape the vector optimizer and HNSW indexes recognize. Items already after
+their key are kept. Key columns listed in PGWIRE_KEYSET_KEYS are NOT NULL and
+need none (keyset_pagination.py); neither do NOT NULL columns of a
+single-table query, which the executor marks with declare_not_null() before
+the statement is normalized.
+ORDER BY inside aggregate calls (string_agg(x, ',' ORDER BY y)) is not
+touched; window ORDER BY in OVER (...) is.
+
+PGWIRE_NULL_ORDERING=iris keeps IRIS's order for items without a NULLS
+clause (no extra sort keys); explicit NULLS FIRST / LAST are still honored,
+and dropped where IRIS's order already matches. Where no key can be formed,
+NULLS clauses are dropped so IRIS can parse the statement.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without
+  ORDER BY are returned after a substring check)
+"""
+
+import os
+import re
+
+from .keyset_pagination import key_column_names, split_name
+
+NOT_NULL_COLUMNS_SQL = (
+    "SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS "
+    "WHERE IS_NULLABLE = 'NO'"
+)
+
+_TOKEN = re.compile(
+    r"(?P<skip>'(?:[^']|'')*'|\"(?:[^\"]|\"\")*\"|--[^\n]*|/\*.*?\*/)"
+    r"|(?P<word>[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+)"
+    r"|(?P<punct>[(),;])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+# Keywords ending an ORDER BY clause at its depth
+_CLAUSE_END = frozenset(
+    {"LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "ROWS", "RANGE", "GROUPS"}
+)
+_SET_OPERATIONS = frozenset({"UNION", "INTERSECT", "EXCEPT"})
+_SELECT_LIST_END = frozenset(
+    {"FROM", "INTO", "WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH"}
+)
+
+_MODIFIERS = re.compile(
+    r"^(?P<expr>.*?)(?:\s+(?P<direction>ASC|DESC))?(?:\s+NULLS\s+(?P<nulls>FIRST|LAST))?\s*$",
+    re.IGNORECASE | re.DOTALL,
+)
+_NULL_RANK = re.compile(
+    r"^CASE\s+WHEN\s+(?P<expr>.+)\s+IS\s+NULL\s+THEN\s+[01]\s+ELSE\s+[01]\s+END$",
+    re.IGNORECASE | re.DOTALL,
+)
+_AS_ALIAS = re.compile(
+    r'^(?P<expr>.+?)\s+AS\s+(?P<alias>"(?:[^"]|"")+"|\w+)\s*$', re.IGNORECASE | re.DOTALL
+)
+_VECTOR_DISTANCE = re.compile(r"\bVECTOR_\w+\s*\(|<=>|<#>|<->", re.IGNORECASE)
+_USING = re.compile(r"\sUSING\s", re.IGNORECASE)
+_COLUMN = r'(?:(?:"(?:[^"]|"")+"|\w+)\s*\.\s*)*(?:"(?:[^"]|"")+"|\w+)'
+# FROM table [[AS] alias] (a call or another table follows where it is not the only source)
+_SOURCE = re.compile(
+    rf"\s*(?P<relation>{_COLUMN})(?![\w\"])(?:\s+(?:AS\s+)?(?P<alias>\w+)\b)?\s*(?P<next>[(,]?)",
+    re.IGNORECASE,
+)
+# Words of queries where a NOT NULL column can still sort NULLs (outer joins, ROLLUP rows)
+_NULLABLE_SOURCES = frozenset(
+    {"JOIN", "UNION", "INTERSECT", "EXCEPT", "ROLLUP", "CUBE", "GROUPING", "LATERAL"}
+)
+
+
+def postgres_null_ordering() -> bool:
+    """Whether items without a NULLS clause get PostgreSQL's NULL order (PGWIRE_NULL_ORDERING)."""
+    return os.getenv("PGWIRE_NULL_ORDERING", "postgres").strip().lower() != "iris"
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+    ]
+
+
+def _split_items(tokens, end_keywords) -> list[tuple[int, int]]:
+    """Spans of the comma-separated items of tokens, up to ) ; or an end keyword at depth 0."""
+    items = []
+    depth = 0
+    item_start = last_end = None
+    for kind, text, start, stop in tokens:
+        if depth == 0 and (
+            text in (")", ";") or (kind == "word" and text.upper() in end_keywords)
+        ):
+            break
+        if text == "(":
+            depth += 1
+        elif text == ")":
+            depth -= 1
+        if depth == 0 and text == ",":
+            if item_start is not None:
+                items.append((item_start, last_end))
+            item_start = None
+            continue
+        if item_start is None:
+            item_start = start
+        last_end = stop
+    if item_start is not None:
+        items.append((item_start, last_end))
+    return items
+
+
+def _unquote(name: str) -> str:
+    if name.startswith('"') and name.endswith('"'):
+        return name[1:-1].replace('""', '"')
+    return name.upper()
+
+
+class _Clause:
+    """An ORDER BY clause: its items' spans and the query level it sorts."""
+
+    def __init__(self, start: int, end: int, items: list[tuple[int, int]]):
+        self.start = start
+        self.end = end
+        self.items = items
+        self.select_items: list[str] | None = None  # None: window ORDER BY
+        self.distinct = False
+        self.set_operation = False
+
+
+class NullOrderingTranslator:
+    """Rewrites ORDER BY items to sort NULLs as PostgreSQL does."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Rewrite the ORDER BY clauses of a statement for IRIS.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, items_rewritten)
+        """
+        if "ORDER" not in sql.upper():
+            return sql, 0
+        clauses = self._clauses(sql)
+        if not clauses:
+            return sql, 0
+
+        compensate_defaults = postgres_null_ordering()
+        replacements = []
+        for clause in clauses:
+            items = [sql[start:end] for start, end in clause.items]
+            skipped = self._ranked_items(items, clause)
+            for index, (start, end) in enumerate(clause.items):
+                if index in skipped:
+                    continue
+                rewritten = self._rewrite_item(items[index], clause, compensate_defaults)
+                if rewritten is not None:
+                    replacements.append((start, end, rewritten))
+
+        for start, end, text in sorted(replacements, reverse=True):
+            sql = sql[:start] + text + sql[end:]
+        return sql, len(replacements)
+
+    def _ranked_items(self, items: list[str], clause: _Clause) -> set[int]:
+        """Indexes of NULL-rank keys and the items they rank (already translated)."""
+        ranked = set()
+        for index, (item, following) in enumerate(zip(items, items[1:])):
+            key = _NULL_RANK.match(item.strip())
+            sorted_item = _MODIFIERS.match(following.strip())
+            if not key or not sorted_item:
+                continue
+            expr = self._ranked_expression(sorted_item.group("expr").strip(), clause)
+            if expr == key.group("expr").strip():
+                ranked.update((index, index + 1))
+        return ranked
+
+    def _rewrite_item(self, item: str, clause: _Clause, compensate_defaults: bool) -> str | None:
+        """The item as IRIS ORDER BY items, or None to leave it as written."""
+        match = _MODIFIERS.match(item.strip())
+        if not match or not match.group("expr").strip() or _USING.search(item):
+            return None
+        expr = match.group("expr").strip()
+        direction = (match.group("direction") or "").upper()
+        nulls = (match.group("nulls") or "").upper()
+        descending = direction == "DESC"
+        nulls_first = nulls == "FIRST" if nulls else descending
+        sorted_item = f"{expr} {direction}".rstrip()
+        if not nulls and not compensate_defaults:
+            return None
+
+        ranked = None
+        if not (clause.distinct or clause.set_operation or _VECTOR_DISTANCE.search(expr)):
+            ranked = self._ranked_expression(expr, clause)
+            if ranked is not None and _VECTOR_DISTANCE.search(ranked):
+                ranked = None  # An alias or ordinal of a distance
+        # IRIS's own order (NULLs first ascending, last descending) needs no key
+        if ranked is None or nulls_first != descending:
+            return sorted_item if nulls else None
+        if re.fullmatch(_COLUMN, expr) and split_name(expr)[-1] in key_column_names():
+            # Configured keyset keys are NOT NULL; a key would only cost the index order
+            return sorted_item if nulls else None
+        first, rest = ("0", "1") if nulls_first else ("1", "0")
+        return f"CASE WHEN {ranked} IS NULL THEN {first} ELSE {rest} END, {sorted_item}"
+
+    @staticmethod
+    def _ranked_expression(expr: str, clause: _Clause) -> str | None:
+        """The expression an item sorts by: itself, or the select item it names."""
+        if clause.select_items is None:
+            return expr
+        if expr.isdigit():
+            position = int(expr)
+            if not 1 <= position <= len(clause.select_items):
+                return None
+            selected = clause.select_items[position - 1]
+            if selected == "*" or selected.endswith(".*"):
+                return None
+            alias = _AS_ALIAS.match(selected)
+            return f"({alias.group('expr').strip() if alias else selected})"
+        for selected in clause.select_items:
+            alias = _AS_ALIAS.match(selected)
+            if alias and _unquote(alias.group("alias")) == _unquote(expr):
+                return f"({alias.group('expr').strip()})"
+        return expr
+
+    def _clauses(self, sql: str) -> list[_Clause]:
+        """The ORDER BY clauses of sql that sort a query level or a window."""
+        significant = [t for t in _tokens(sql) if t[0] not in ("space", "skip")]
+        clauses = []
+        # Per open parenthesis: (kind, index of its first token inside)
+        stack: list[tuple[str, int]] = [("query", 0)]
+        for index, (kind, text, _, _) in enumerate(significant):
+            if text == "(":
+                previous = significant[index - 1][1].upper() if index else ""
+                following = (
+                    significant[index + 1][1].upper() if index + 1 < len(significant) else ""
+                )
+                if following in ("SELECT", "WITH", "VALUES"):
+                    context = "query"
+                elif previous == "OVER":
+                    context = "window"
+                elif index and significant[index - 1][0] == "word" and previous not in (
+                    "IN", "FROM", "JOIN", "AS", "EXISTS", "ON", "AND", "OR", "NOT", "WHERE",
+                ):
+                    context = "call"
+                else:
+                    context = "group"
+                stack.append((context, index + 1))
+            elif text == ")":
+                if len(stack) > 1:
+                    stack.pop()
+            elif (
+                kind == "word"
+                and text.upper() == "ORDER"
+                and index + 1 < len(significant)
+                and significant[index + 1][1].upper() == "BY"
+                and stack[-1][0] in ("query", "window")
+            ):
+                clause = self._clause(significant, index + 2)
+                if clause is not None:
+                    if stack[-1][0] == "query":
+                        self._describe_query(sql, significant[stack[-1][1] : index], clause)
+                    clauses.append(clause)
+        # Nested clauses (inside an ORDER BY item) are left alone
+        return [
+            clause
+            for clause in clauses
+            if not any(o is not clause and o.start <= clause.start < o.end for o in clauses)
+        ]
+
+    @staticmethod
+    def _clause(significant, first: int) -> _Clause | None:
+        """The clause whose items start at significant[first], up to its end."""
+        items = _split_items(significant[first:], _CLAUSE_END)
+        if not items:
+            return None
+        return _Clause(items[0][0], items[-1][1], items)
+
+    @staticmethod
+    def _describe_query(sql: str, level, clause: _Clause) -> None:
+        """Fill in the select list, DISTINCT and set operation of the tokens before ORDER BY."""
+        depth = 0
+        select_index = None
+        for index, (kind, text, _, _) in enumerate(level):
+            if text == "(":
+                depth += 1
+            elif text == ")":
+                depth -= 1
+            elif depth == 0 and kind == "word":
+                upper = text.upper()
+                if upper in _SET_OPERATIONS:
+                    clause.set_operation = True
+                elif upper == "SELECT" and select_index is None:
+                    select_index = index
+        clause.select_items = []
+        if select_index is None:
+            return
+        first = select_index + 1
+        if first < len(level) and level[first][1].upper() in ("DISTINCT", "ALL"):
+            clause.distinct = level[first][1].upper() == "DISTINCT"
+            first += 1
+        clause.select_items = [
+            sql[start:end] for start, end in _split_items(level[first:], _SELECT_LIST_END)
+        ]
+
+
+def order_table(sql: str) -> str | None:
+    """The table of a single-table SELECT with ORDER BY, as written, or None."""
+    significant = [t for t in _tokens(sql) if t[0] not in ("space", "skip")]
+    words = [text.upper() for kind, text, _, _ in significant if kind == "word"]
+    if (
+        not words
+        or words[0] != "SELECT"
+        or words.count("SELECT") != 1
+        or words.count("FROM") != 1
+        or "ORDER" not in words
+        or _NULLABLE_SOURCES.intersection(words)
+    ):
+        return None
+    from_end = next(stop for kind, text, _, stop in significant if text.upper() == "FROM")
+    source = _SOURCE.match(sql, from_end)
+    if source is None or source.group("next") or (source.group("alias") or "").upper() == "ONLY":
+        return None
+    return source.group("relation")
+
+
+def declare_not_null(sql: str, columns: set[str]) -> str:
+    """
+    sql with the NULLS clause IRIS's own order has on ORDER BY items of NOT
+    NULL columns (uppercase names), so no NULL-rank key is added for them.
+    """
+    insertions = []
+    for clause in NullOrderingTranslator()._clauses(sql):
+        for start, end in clause.items:
+            match = _MODIFIERS.match(sql[start:end])
+            if not match or match.group("nulls") or _USING.search(sql[start:end]):
+                continue
+            expr = match.group("expr").strip()
+            if (
+                not re.fullmatch(_COLUMN, expr)
+                or NullOrderingTranslator._ranked_expression(expr, clause) != expr
+                or split_name(expr)[-1].upper() not in columns
+            ):
+                continue
+            descending = (match.group("direction") or "").upper() == "DESC"
+            insertions.append((end, " NULLS LAST" if descending else " NULLS FIRST"))
+    for position, text in sorted(insertions, reverse=True):
+        sql = sql[:position] + text + sql[position:]
+    return sql
@@ -0,0 +1,306 @@
+"""
+String Function Translator for PostgreSQL-Compatible SQL
+
+Report SQL uses PostgreSQL string functions that IRIS SQL does not have, or
+has with other arguments. They are rewritten into IRIS expressions:
+
+- left(s, n), right(s, n)     → SUBSTRING (negative n drops |n| characters
+                                from the other end, as in PostgreSQL)
+- split_part(s, delim, n)     → $PIECE(s, delim, n) (negative n counts from
+                                the end through $LENGTH(s, delim))
+- strpos(s, sub)              → INSTR(s, sub)
+- starts_with(s, prefix)      → case-sensitive SUBSTRING comparison on %EXACT
+- lpad / rpad(s, n [, fill])  → REPEAT padding, truncated to n like PostgreSQL
+- concat(a, ...)              → a || ... with NULL arguments ignored
+- concat_ws(sep, a, ...)      → the non-NULL arguments joined by sep
+- format('fmt', args...)      → concatenation of the literal format pieces and
+                                %s (NULL as ''), %I (quoted identifier) and
+                                %L (quoted literal, NULL unquoted) arguments,
+                                with n$ positions and %%
+
+position(sub IN s) is native IRIS. Calls whose arguments are all literals are
+folded into a string literal. format() with a non-literal format string or
+width specifiers is left unchanged, as are calls with argument counts
+PostgreSQL does not accept.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement
+"""
+
+import re
+
+from ..sql_text import find_outside_literals
+
+_FUNCTION_CALL = re.compile(
+    r"\b(LEFT|RIGHT|SPLIT_PART|STRPOS|STARTS_WITH|LPAD|RPAD|CONCAT_WS|CONCAT|FORMAT)\s*\(",
+    re.IGNORECASE,
+)
+_FUNCTION_NAMES = (
+    "LEFT",
+    "RIGHT",
+    "SPLIT_PART",
+    "STRPOS",
+    "STARTS_WITH",
+    "PAD",
+    "CONCAT",
+    "FORMAT",
+)
+# ODBC scalar functions ({fn LEFT(s, n)}) are native IRIS
+_ODBC_ESCAPE = re.compile(r"\{\s*fn\s*$", re.IGNORECASE)
+_STRING_LITERAL = re.compile(r"^'((?:[^']|'')*)'$", re.DOTALL)
+_INTEGER = re.compile(r"^[+-]?\d+$")
+_FORMAT_SPECIFIER = re.compile(r"%(?:(?P<position>\d+)\$)?(?P<width>[-\d*]*)(?P<type>[sIL%])?")
+
+
+def _quote(text: str) -> str:
+    return "'" + text.replace("'", "''") + "'"
+
+
+def _literal(expression: str) -> str | None:
+    """Value of a string literal argument, or None."""
+    match = _STRING_LITERAL.match(expression.strip())
+    return match.group(1).replace("''", "'") if match else None
+
+
+def _quote_ident(name: str) -> str:
+    """PostgreSQL quote_ident(): quoted unless a plain lowercase identifier."""
+    if re.fullmatch(r"[a-z_][a-z0-9_$]*", name):
+        return name
+    return '"' + name.replace('"', '""') + '"'
+
+
+class StringFunctionTranslator:
+    """Rewrites PostgreSQL string functions IRIS lacks into IRIS expressions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate PostgreSQL string functions in SQL.
+
+        Args:
+            sql: SQL that may contain left/right/split_part/format/... calls
+
+        Returns:
+            Tuple of (translated_sql, translation_count)
+        """
+        upper = sql.upper()
+        if not any(name in upper for name in _FUNCTION_NAMES):
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            match = find_outside_literals(_FUNCTION_CALL, sql, search_from)
+            if not match:
+                break
+            close = self._closing_paren(sql, match.end())
+            if close is None:
+                break
+            inner, inner_count = self.translate(sql[match.end() : close])
+            replacement = None
+            if not _ODBC_ESCAPE.search(sql[: match.start()]):
+                replacement = self._rewrite(match.group(1).upper(), self._split_arguments(inner))
+            if replacement is None:
+                search_from = match.end()
+                continue
+
+            sql = sql[: match.start()] + replacement + sql[close + 1 :]
+            search_from = match.start() + len(replacement)
+            count += 1 + inner_count
+
+        return sql, count
+
+    # ------------------------------------------------------------------ rewrites
+
+    def _rewrite(self, function: str, args: list[str]) -> str | None:
+        if function in ("LEFT", "RIGHT"):
+            if len(args) != 2:
+                return None
+            return self._left(*args) if function == "LEFT" else self._right(*args)
+        if function == "SPLIT_PART":
+            return self._split_part(*args) if len(args) == 3 else None
+        if function == "STRPOS":
+            return f"INSTR({args[0]}, {args[1]})" if len(args) == 2 else None
+        if function == "STARTS_WITH":
+            if len(args) != 2:
+                return None
+            text, prefix = args
+            return f"(SUBSTRING(%EXACT({text}), 1, CHAR_LENGTH({prefix})) = %EXACT({prefix}))"
+        if function in ("LPAD", "RPAD"):
+            if len(args) not in (2, 3):
+                return None
+            return self._pad(function == "LPAD", *args)
+        if function == "CONCAT":
+            return self._concat(args) if args else None
+        if function == "CONCAT_WS":
+            return self._concat_ws(args[0], args[1:]) if args else None
+        return self._format(args) if args else None
+
+    @staticmethod
+    def _left(text: str, count: str) -> str:
+        if _INTEGER.match(count):
+            number = int(count)
+            if number >= 0:
+                return f"SUBSTRING({text}, 1, {number})"
+            return f"SUBSTRING({text}, 1, GREATEST(CHAR_LENGTH({text}) - {-number}, 0))"
+        return (
+            f"SUBSTRING({text}, 1, CASE WHEN {count} < 0 "
+            f"THEN GREATEST(CHAR_LENGTH({text}) + {count}, 0) ELSE {count} END)"
+        )
+
+    @staticmethod
+    def _right(text: str, count: str) -> str:
+        if _INTEGER.match(count):
+            number = int(count)
+            if number >= 0:
+                return f"SUBSTRING({text}, GREATEST(CHAR_LENGTH({text}) - {number - 1}, 1))"
+            return f"SUBSTRING({text}, {1 - number})"
+        return (
+            f"SUBSTRING({text}, CASE WHEN {count} < 0 THEN 1 - {count} "
+            f"ELSE GREATEST(CHAR_LENGTH({text}) - {count} + 1, 1) END)"
+        )
+
+    @staticmethod
+    def _split_part(text: str, delimiter: str, field: str) -> str:
+        if _INTEGER.match(field) and int(field) < 0:
+            last = f"$LENGTH({text}, {delimiter})"
+            field = last if int(field) == -1 else f"{last} - {-int(field) - 1}"
+        return f"$PIECE({text}, {delimiter}, {field})"
+
+    @staticmethod
+    def _pad(left: bool, text: str, length: str, fill: str = "' '") -> str:
+        padding = f"SUBSTRING(REPEAT({fill}, {length}), 1, {length} - CHAR_LENGTH({text}))"
+        padded = f"{padding} || {text}" if left else f"{text} || {padding}"
+        return (
+            f"CASE WHEN CHAR_LENGTH({text}) >= {length} THEN SUBSTRING({text}, 1, {length}) "
+            f"ELSE {padded} END"
+        )
+
+    @staticmethod
+    def _concat(args: list[str]) -> str:
+        values = [_literal(arg) for arg in args]
+        if all(value is not None for value in values):
+            return _quote("".join(values))
+        return "(" + " || ".join(f"COALESCE({arg}, '')" for arg in args) + ")"
+
+    @staticmethod
+    def _concat_ws(separator: str, args: list[str]) -> str:
+        if not args:
+            return "''"
+        separator_value = _literal(separator)
+        values = [_literal(arg) for arg in args]
+        if separator_value is not None and all(value is not None for value in values):
+            return _quote(separator_value.join(values))
+        pieces = " || ".join(
+            f"CASE WHEN {arg} IS NULL THEN '' ELSE {separator} || {arg} END" for arg in args
+        )
+        if separator_value is not None:
+            return f"SUBSTRING({pieces}, {len(separator_value) + 1})"
+        return f"SUBSTRING({pieces}, CHAR_LENGTH({separator}) + 1)"
+
+    @staticmethod
+    def _format(args: list[str]) -> str | None:
+        template = _literal(args[0])
+        if template is None:
+            return None
+        values = args[1:]
+        parts: list[tuple[bool, str]] = []  # (is literal text, text or expression)
+        position = 0
+        next_argument = 0
+        while position < len(template):
+            percent = template.find("%", position)
+            if percent < 0:
+                parts.append((True, template[position:]))
+                break
+            if percent > position:
+                parts.append((True, template[position:percent]))
+            specifier = _FORMAT_SPECIFIER.match(template, percent)
+            kind = specifier.group("type")
+            if kind is None or specifier.group("width"):
+                return None  # Unterminated specifier or a width
+            position = specifier.end()
+            if kind == "%":
+                parts.append((True, "%"))
+                continue
+            if specifier.group("position"):
+                next_argument = int(specifier.group("position")) - 1
+            if not 0 <= next_argument < len(values):
+                return None  # PostgreSQL: too few arguments for format()
+            value = values[next_argument]
+            next_argument += 1
+            literal = _literal(value)
+            if kind == "s":
+                if literal is not None:
+                    parts.append((True, literal))
+                elif value.strip().upper() != "NULL":
+                    parts.append((False, f"COALESCE({value}, '')"))
+            elif kind == "I":
+                if literal is None:
+                    parts.append((False, f"""('"' || REPLACE({value}, '"', '""') || '"')"""))
+                else:
+                    parts.append((True, _quote_ident(literal)))
+            elif literal is not None:
+                parts.append((True, _quote(literal)))
+            elif value.strip().upper() == "NULL":
+                parts.append((True, "NULL"))
+            else:
+                parts.append(
+                    (
+                        False,
+                        f"CASE WHEN {value} IS NULL THEN 'NULL' "
+                        f"ELSE '''' || REPLACE({value}, '''', '''''') || '''' END",
+                    )
+                )
+
+        # Adjacent literal pieces are merged into one string literal
+        merged: list[str] = []
+        text = None
+        for is_text, piece in parts:
+            if is_text:
+                text = (text or "") + piece
+                continue
+            if text:
+                merged.append(_quote(text))
+            text = None
+            merged.append(piece)
+        if text or not merged:
+            merged.append(_quote(text or ""))
+        return merged[0] if len(merged) == 1 else "(" + " || ".join(merged) + ")"
+
+    # ------------------------------------------------------------------ scanning helpers
+
+    @staticmethod
+    def _closing_paren(sql: str, pos: int) -> int | None:
+        depth = 1
+        in_literal = False
+        for i in range(pos, len(sql)):
+            char = sql[i]
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                    if depth == 0:
+                        return i
+        return None
+
+    @staticmethod
+    def _split_arguments(inner: str) -> list[str]:
+        args, depth, in_literal, current = [], 0, False, []
+        for char in inner:
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                elif char == "," and depth == 0:
+                    args.append("".join(current).strip())
+                    current = []
+                    continue
+            current.append(char)
+        if "".join(current).strip():
+            args.append("".join(current).strip())
+        return args
@@ -0,0 +1,165 @@
+"""
+Columnar Table Storage
+
+IRIS stores a table by rows or by columns (WITH STORAGETYPE = COLUMNAR);
+columnar tables answer aggregates and scans over a few columns of many rows
+far faster. PostgreSQL clients never write IRIS DDL, so CREATE TABLE
+statements are given columnar storage in two ways:
+
+- a table access method, as PostgreSQL 12+ (and the Citus columnar
+  extension) write it: CREATE TABLE facts (...) USING columnar. USING heap
+  asks for row storage and is removed.
+- PGWIRE_COLUMNAR_TABLES: comma separated table name patterns (* and ?
+  wildcards, case-insensitive). A pattern without a schema matches the
+  table in every schema; schemas are named as in IRIS (public is SQLUser):
+
+      PGWIRE_COLUMNAR_TABLES="*_facts,analytics.*"
+
+An explicit USING heap overrides a matching pattern, and statements that
+already choose an IRIS storage type (WITH STORAGETYPE) are left alone.
+CREATE TABLE ... AS and PARTITION OF statements have no column list and are
+not changed. Other access methods are left for IRIS to reject.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (only CREATE TABLE
+  statements are tokenized)
+"""
+
+import fnmatch
+import os
+import re
+
+import structlog
+
+logger = structlog.get_logger()
+
+_CREATE_TABLE = re.compile(
+    r"^\s*CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\b",
+    re.IGNORECASE,
+)
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+(?:\.\d*)?)"
+    r"|(?P<op>[(),.;=])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+COLUMNAR_STORAGE = "WITH STORAGETYPE = COLUMNAR"
+
+
+def load_columnar_tables(spec: str | None = None) -> list[str]:
+    """Lowercase table name patterns from PGWIRE_COLUMNAR_TABLES."""
+    if spec is None:
+        spec = os.getenv("PGWIRE_COLUMNAR_TABLES", "")
+    return [pattern.strip().lower() for pattern in spec.split(",") if pattern.strip()]
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ) closing the parenthesis at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text == "(":
+            depth += 1
+        elif text == ")":
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _is(token, *words: str) -> bool:
+    return token is not None and token[0] == "word" and token[1].upper() in words
+
+
+def _unquote(name: str) -> str:
+    if name.startswith('"'):
+        return name[1:-1].replace('""', '"')
+    return name
+
+
+def matches_columnar_pattern(table: str, schema: str | None, patterns: list[str]) -> bool:
+    """Whether a table name matches one of the PGWIRE_COLUMNAR_TABLES patterns."""
+    table = table.lower()
+    qualified = f"{schema.lower()}.{table}" if schema else None
+    for pattern in patterns:
+        if "." in pattern:
+            if qualified and fnmatch.fnmatchcase(qualified, pattern):
+                return True
+        elif fnmatch.fnmatchcase(table, pattern):
+            return True
+    return False
+
+
+def rewrite_table_storage(sql: str, patterns: list[str] | None = None) -> tuple[str, int]:
+    """
+    CREATE TABLE with columnar storage when asked for by USING columnar or a
+    PGWIRE_COLUMNAR_TABLES pattern, and the number of clauses changed.
+    """
+    if not _CREATE_TABLE.match(sql):
+        return sql, 0
+    tokens = _tokens(sql)
+    position = next(
+        (index for index, token in enumerate(tokens) if _is(token, "TABLE")), len(tokens)
+    )
+    position += 1
+    if (
+        position + 2 < len(tokens)
+        and _is(tokens[position], "IF")
+        and _is(tokens[position + 1], "NOT")
+        and _is(tokens[position + 2], "EXISTS")
+    ):
+        position += 3
+    if position >= len(tokens) or tokens[position][0] != "word":
+        return sql, 0
+    schema, table = None, tokens[position][1]
+    while position + 2 < len(tokens) and tokens[position + 1][1] == ".":
+        schema, table = table, tokens[position + 2][1]
+        position += 2
+    position += 1
+    if position >= len(tokens) or tokens[position][1] != "(":
+        return sql, 0  # CREATE TABLE ... AS / PARTITION OF
+    close = _closing(tokens, position)
+    if close is None:
+        return sql, 0
+
+    method = None  # Token index of USING
+    for index in range(close + 1, len(tokens)):
+        token = tokens[index]
+        if _is(token, "STORAGETYPE"):
+            return sql, 0
+        if _is(token, "USING") and index + 1 < len(tokens) and tokens[index + 1][0] == "word":
+            method = index
+    if method is not None:
+        name = _unquote(tokens[method + 1][1]).lower()
+        if name not in ("columnar", "heap"):
+            return sql, 0
+        start, end = tokens[method][2], tokens[method + 1][3]
+        sql = f"{sql[:start].rstrip()}{sql[end:]}"
+        if name == "heap":
+            return sql, 1
+    else:
+        if patterns is None:
+            patterns = load_columnar_tables()
+        if not matches_columnar_pattern(
+            _unquote(table), _unquote(schema) if schema else None, patterns
+        ):
+            return sql, 0
+        logger.info("Creating table with columnar storage", table=_unquote(table))
+
+    # The table options follow every other clause (SHARD KEY, PARTITION BY)
+    statement = sql.rstrip().rstrip(";").rstrip()
+    return f"{statement} {COLUMNAR_STORAGE}{sql[len(statement):]}", 1
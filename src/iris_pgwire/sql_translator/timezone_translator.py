@@ -0,0 +1,327 @@
+"""
+AT TIME ZONE Translator for PostgreSQL-Compatible SQL
+
+IRIS SQL has no time zone conversion operator. PostgreSQL's `AT TIME ZONE` is
+rewritten into DATEADD() arithmetic that IRIS can evaluate:
+
+- timestamp AT TIME ZONE z   → timestamptz (value is wall-clock time in z, result is UTC)
+- timestamptz AT TIME ZONE z → timestamp   (UTC value, result is wall-clock time in z)
+- timezone(z, expr) is the function spelling of the same operator
+
+timestamptz values are stored in IRIS as naive UTC timestamps, so:
+- UTC/GMT (or a zero offset) is the identity conversion
+- Fixed offsets become DATEADD('ss', ±offset, expr)
+- Region zones call an IRIS function holding the zone's UTC offset transitions
+  (IANA tz database): DATEADD('ss', PGWIRE_TZ_America_New_York(epoch, 1), expr).
+  The executor creates the function (helper_ddl) the first time a zone is used
+- Literal operands are converted at translation time and emitted as literals
+
+Operands are typed as timestamptz when they are timestamptz casts/literals,
+now()/CURRENT_TIMESTAMP/to_timestamp(epoch), or the result of a previous timestamp AT TIME ZONE
+(e.g. Grafana's `ts AT TIME ZONE 'UTC' AT TIME ZONE 'Europe/Paris'`). Anything
+else - in particular IRIS columns, which have no zone - is a timestamp.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (a zone's rule table stays in IRIS)
+- Untranslatable forms (zone given as a parameter) are left unchanged
+"""
+
+import datetime
+import re
+import zoneinfo
+from functools import lru_cache
+
+from ..sql_text import find_outside_literals, operand_start, read_string_literal
+from ..timezone_support import normalize_timezone_name, resolve_timezone, to_utc
+
+# Years covered by the transition functions. Values outside the window use
+# the nearest covered offset.
+TRANSITION_WINDOW = (1970, 2038)
+
+# Offset changes are at least weeks apart, so scanning a week at a time finds them all
+_SCAN_STEP_SECONDS = 7 * 86400
+
+HELPER_PREFIX = "PGWIRE_TZ_"
+
+_TIMESTAMPTZ_CASTS = re.compile(
+    r"^CAST\s*\(.*\bAS\s+(TIMESTAMPTZ|TIMESTAMP\s+WITH\s+TIME\s+ZONE)\s*\)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_TIMESTAMP_CASTS = re.compile(
+    r"^CAST\s*\(\s*('(?:[^']|'')*')\s+AS\s+(TIMESTAMP|TIMESTAMP\s+WITHOUT\s+TIME\s+ZONE)\s*\)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_TIMESTAMPTZ_LITERAL_CAST = re.compile(
+    r"^CAST\s*\(\s*('(?:[^']|'')*')\s+AS\s+(TIMESTAMPTZ|TIMESTAMP\s+WITH\s+TIME\s+ZONE)\s*\)$",
+    re.IGNORECASE | re.DOTALL,
+)
+_TIMESTAMPTZ_FUNCTIONS = re.compile(
+    r"^(NOW|TRANSACTION_TIMESTAMP|STATEMENT_TIMESTAMP|CLOCK_TIMESTAMP)\s*\(\s*\)$"
+    r"|^CURRENT_TIMESTAMP(\s*\(\s*\d*\s*\))?$"
+    r"|^TO_TIMESTAMP\s*\([^,]*\)$",  # to_timestamp(epoch)
+    re.IGNORECASE,
+)
+_AT_TIME_ZONE = re.compile(r"\bAT\s+TIME\s+ZONE\s+", re.IGNORECASE)
+_TIMEZONE_FUNCTION = re.compile(r"\bTIMEZONE\s*\(\s*('(?:[^']|'')*')\s*,", re.IGNORECASE)
+
+
+@lru_cache(maxsize=64)
+def offset_transitions(zone_name: str) -> tuple[int, tuple[tuple[datetime.datetime, int], ...]]:
+    """
+    UTC offset history of a zone inside TRANSITION_WINDOW.
+
+    Returns:
+        (initial offset seconds, ((utc instant, offset seconds after), ...))
+    """
+    tz = resolve_timezone(zone_name)
+    start = int(datetime.datetime(TRANSITION_WINDOW[0], 1, 1, tzinfo=datetime.UTC).timestamp())
+    end = int(datetime.datetime(TRANSITION_WINDOW[1], 1, 1, tzinfo=datetime.UTC).timestamp())
+
+    def offset_at(epoch: int) -> int:
+        return int(datetime.datetime.fromtimestamp(epoch, tz).utcoffset().total_seconds())
+
+    initial = offset_at(start)
+    transitions = []
+    previous_epoch, previous_offset = start, initial
+
+    epoch = start + _SCAN_STEP_SECONDS
+    while epoch <= end:
+        current = offset_at(epoch)
+        if current != previous_offset:
+            # Bisect to the exact second of the change
+            low, high = previous_epoch, epoch
+            while high - low > 1:
+                middle = (low + high) // 2
+                if offset_at(middle) == previous_offset:
+                    low = middle
+                else:
+                    high = middle
+            transitions.append((datetime.datetime.fromtimestamp(high, datetime.UTC), current))
+            previous_offset = current
+        previous_epoch = epoch
+        epoch += _SCAN_STEP_SECONDS
+
+    return initial, tuple(transitions)
+
+
+def helper_function(zone_name: str) -> str:
+    """Name of the IRIS function converting to or from a region zone."""
+    return HELPER_PREFIX + re.sub(r"[^A-Za-z0-9]", "_", zone_name)
+
+
+def helper_ddl(zone_name: str) -> str:
+    """
+    CREATE FUNCTION for a region zone: given a Unix epoch second and 1 for a UTC
+    instant (timestamptz operand) or 0 for a wall-clock time in the zone
+    (timestamp operand), it returns the seconds to add to convert the value.
+    """
+    initial, transitions = offset_transitions(zone_name)
+    utc_cases, local_cases = [], []
+    offset_before = initial
+    for instant, offset_after in transitions:
+        epoch = int(instant.timestamp())
+        utc_cases.append(f"instant>={epoch}:{offset_after}")
+        # A wall-clock time changes offset at the old offset's reading of the instant
+        local_cases.append(f"instant>={epoch + offset_before}:{-offset_after}")
+        offset_before = offset_after
+    utc = ",".join([*reversed(utc_cases), f"1:{initial}"])
+    local = ",".join([*reversed(local_cases), f"1:{-initial}"])
+    return (
+        f"CREATE OR REPLACE FUNCTION {helper_function(zone_name)}(instant BIGINT, utc INT) "
+        "RETURNS INT LANGUAGE OBJECTSCRIPT {\n"
+        f" QUIT:utc $SELECT({utc})\n"
+        f" QUIT $SELECT({local})\n"
+        "}"
+    )
+
+
+def helper_zones(sql: str) -> list[str]:
+    """Region zones named in AT TIME ZONE / timezone() of sql, whose functions it may call."""
+    if "ZONE" not in sql.upper():
+        return []
+    zones = []
+    for pattern in (_AT_TIME_ZONE, _TIMEZONE_FUNCTION):
+        position = 0
+        while match := find_outside_literals(pattern, sql, position):
+            position = match.end()
+            if pattern is _TIMEZONE_FUNCTION:
+                literal = match.group(1)
+            else:
+                literal = read_string_literal(sql, match.end())
+            if literal is None:
+                continue
+            try:
+                zone_name = normalize_timezone_name(_unquote(literal))
+            except ValueError:
+                continue
+            region = isinstance(resolve_timezone(zone_name), zoneinfo.ZoneInfo)
+            if region and zone_name not in zones:
+                zones.append(zone_name)
+    return zones
+
+
+def _format_literal(value: datetime.datetime) -> str:
+    text = value.strftime("%Y-%m-%d %H:%M:%S")
+    if value.microsecond:
+        text += f".{value.microsecond:06d}".rstrip("0")
+    return f"'{text}'"
+
+
+def _unquote(literal: str) -> str:
+    return literal[1:-1].replace("''", "'")
+
+
+class TimeZoneTranslator:
+    """Rewrites PostgreSQL AT TIME ZONE / timezone() into IRIS expressions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate AT TIME ZONE expressions in SQL.
+
+        Args:
+            sql: SQL that may contain AT TIME ZONE or timezone(zone, expr)
+
+        Returns:
+            Tuple of (translated_sql, conversion_count)
+        """
+        if "ZONE" not in sql.upper():
+            return sql, 0
+
+        sql = self._rewrite_timezone_function(sql)
+
+        count = 0
+        # Expressions we generated → their result type (for chained conversions)
+        generated: dict[str, str] = {}
+        search_from = 0
+
+        while True:
+            match = find_outside_literals(_AT_TIME_ZONE, sql, search_from)
+            if not match:
+                break
+
+            zone_literal = read_string_literal(sql, match.end())
+            left_start = operand_start(sql, match.start())
+            if zone_literal is None or left_start is None:
+                search_from = match.end()
+                continue
+
+            operand = sql[left_start : match.start()].strip()
+            try:
+                zone_name = normalize_timezone_name(_unquote(zone_literal))
+            except ValueError:
+                search_from = match.end()
+                continue
+
+            replacement, result_type = self._convert(operand, zone_name, generated)
+            generated[replacement] = result_type
+
+            end = match.end() + len(zone_literal)
+            sql = sql[:left_start] + replacement + sql[end:]
+            search_from = left_start + len(replacement)
+            count += 1
+
+        return sql, count
+
+    # ------------------------------------------------------------------ conversion
+
+    def _convert(self, operand: str, zone_name: str, generated: dict[str, str]) -> tuple[str, str]:
+        operand_type = generated.get(operand) or self._operand_type(operand)
+        result_type = "timestamp" if operand_type == "timestamptz" else "timestamptz"
+
+        literal_value = self._literal_value(operand)
+        if literal_value is not None:
+            return self._convert_literal(literal_value, operand_type, zone_name), result_type
+
+        tz = resolve_timezone(zone_name)
+        if not isinstance(tz, zoneinfo.ZoneInfo):
+            offset = int(tz.utcoffset(None).total_seconds())
+            return self._shift(operand, offset, operand_type), result_type
+
+        # The zone's function (helper_ddl) returns the seconds to add
+        utc = 1 if operand_type == "timestamptz" else 0
+        epoch = f"DATEDIFF('s', '1970-01-01 00:00:00', {operand})"
+        return (
+            f"DATEADD('ss', {helper_function(zone_name)}({epoch}, {utc}), {operand})",
+            result_type,
+        )
+
+    @staticmethod
+    def _shift(operand: str, offset_seconds: int, operand_type: str) -> str:
+        """timestamptz→local adds the offset; local→timestamptz subtracts it."""
+        seconds = offset_seconds if operand_type == "timestamptz" else -offset_seconds
+        if seconds == 0:
+            return f"({operand})"
+        return f"DATEADD('ss', {seconds}, {operand})"
+
+    @staticmethod
+    def _convert_literal(value: str, operand_type: str, zone_name: str) -> str:
+        tz = resolve_timezone(zone_name)
+        if operand_type == "timestamptz":
+            # Offset-less timestamptz literals are read in UTC (the server's storage zone)
+            utc = to_utc(value, datetime.UTC)
+            return _format_literal(utc.astimezone(tz).replace(tzinfo=None))
+        # timestamp literals ignore any offset, as in PostgreSQL
+        wall_clock = re.sub(r"\s*(Z|[+-]\d{2}(:?\d{2})?)$", "", value.strip())
+        return _format_literal(to_utc(wall_clock, tz).replace(tzinfo=None))
+
+    # ------------------------------------------------------------------ operand analysis
+
+    @staticmethod
+    def _operand_type(operand: str) -> str:
+        stripped = operand.strip()
+        while stripped.startswith("(") and stripped.endswith(")"):
+            stripped = stripped[1:-1].strip()
+        if _TIMESTAMPTZ_CASTS.match(stripped) or _TIMESTAMPTZ_FUNCTIONS.match(stripped):
+            return "timestamptz"
+        if re.match(r"^(TIMESTAMPTZ|TIMESTAMP\s+WITH\s+TIME\s+ZONE)\s*'", stripped, re.I):
+            return "timestamptz"
+        if stripped.startswith("'"):
+            # Untyped literals resolve to timestamptz (the preferred datetime type)
+            return "timestamptz"
+        return "timestamp"
+
+    @staticmethod
+    def _literal_value(operand: str) -> str | None:
+        """Timestamp text of a literal operand, or None for expressions."""
+        stripped = operand.strip()
+        for pattern in (_TIMESTAMP_CASTS, _TIMESTAMPTZ_LITERAL_CAST):
+            match = pattern.match(stripped)
+            if match:
+                return _unquote(match.group(1))
+        match = re.match(
+            r"^(?:TIMESTAMPTZ|TIMESTAMP(?:\s+WITH(?:OUT)?\s+TIME\s+ZONE)?)?\s*('(?:[^']|'')*')$",
+            stripped,
+            re.IGNORECASE,
+        )
+        if match:
+            text = _unquote(match.group(1))
+            if re.match(r"^\d{4}-\d{2}-\d{2}", text):
+                return text
+        return None
+
+    # ------------------------------------------------------------------ scanning helpers
+
+    def _rewrite_timezone_function(self, sql: str) -> str:
+        """timezone('zone', expr) → (expr AT TIME ZONE 'zone')"""
+        while True:
+            match = find_outside_literals(_TIMEZONE_FUNCTION, sql, 0)
+            if not match:
+                return sql
+            # Find the closing parenthesis of the call
+            depth = 1
+            i = match.end()
+            in_literal = False
+            while i < len(sql) and depth:
+                char = sql[i]
+                if char == "'":
+                    in_literal = not in_literal
+                elif not in_literal:
+                    if char == "(":
+                        depth += 1
+                    elif char == ")":
+                        depth -= 1
+                i += 1
+            if depth:
+                return sql
+            expr = sql[match.end() : i - 1].strip()
+            sql = f"{sql[: match.start()]}({expr} AT TIME ZONE {match.group(1)}){sql[i:]}"
@@ -0,0 +1,400 @@
+"""
+pg_trgm Similarity Shims
+
+Applications with fuzzy search call pg_trgm functions that IRIS does not have.
+Instead of failing, they are rewritten into IRIS string arithmetic:
+
+- similarity(a, b)       → shared trigrams / (trigrams(a) + trigrams(b) - shared)
+- word_similarity(a, b)  → shared trigrams / trigrams(a)
+- a % b                  → similarity(a, b) >= 0.3   (pg_trgm.similarity_threshold)
+- a <% b, b %> a         → word_similarity(a, b) >= 0.6
+
+Trigrams follow pg_trgm: text is lowercased, split into alphanumeric words and
+each word is padded with two leading spaces and one trailing space.
+
+When both arguments are literals the result is computed exactly at translation
+time. When one argument is a literal (the usual search case), its trigrams are
+expanded into INSTR() probes against the space-padded column value. The
+column's trigram count is approximated as LENGTH + 1, which ignores repeated
+trigrams. Two non-literal arguments degrade to case-insensitive equality
+(1.0 / 0.0). The operators compare the shared trigram count with a bound
+instead of dividing, so the probes appear once:
+similarity >= t  ⇔  shared * (1 + t) >= t * (column trigrams + literal trigrams).
+
+Whether `a % $1` is pg_trgm or modulo depends on the parameter's type, which
+the bridge does not know: the executor writes the parameter's value into the
+statement first (inline_parameter_operands), text as a string literal and
+numbers as numeric literals.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement
+- `%` is only treated as pg_trgm when an operand is a string literal (otherwise modulo)
+"""
+
+import re
+
+from ..sql_text import find_outside_literals, operand_start
+
+SIMILARITY_THRESHOLD = 0.3
+WORD_SIMILARITY_THRESHOLD = 0.6
+
+_FUNCTION_CALL = re.compile(r"\b(SIMILARITY|WORD_SIMILARITY)\s*\(", re.IGNORECASE)
+_OPERATOR = re.compile(r"(<%|%>|%)")
+_WORD = re.compile(r"[^\W_]+", re.UNICODE)
+_STRING_LITERAL = re.compile(r"^'((?:[^']|'')*)'$", re.DOTALL)
+_PARAMETER = re.compile(r"^(?:\?|\$(\d+))$")
+_PLACEHOLDER = re.compile(r"\?")
+_NUMBER = re.compile(r"^[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$")
+
+
+class AmbiguousOperator(Exception):
+    """A pg_trgm operator has a parameter operand without a value (0A000)."""
+
+    sqlstate = "0A000"
+    condition_name = "feature_not_supported"
+
+
+def trigrams(text: str) -> set[str]:
+    """Trigram set of a string, as computed by pg_trgm's show_trgm()."""
+    result: set[str] = set()
+    for word in _WORD.findall(text.lower()):
+        padded = f"  {word} "
+        result.update(padded[i : i + 3] for i in range(len(padded) - 2))
+    return result
+
+
+def _ordered_trigrams(text: str) -> list[str]:
+    ordered: list[str] = []
+    for word in _WORD.findall(text.lower()):
+        padded = f"  {word} "
+        ordered.extend(padded[i : i + 3] for i in range(len(padded) - 2))
+    return ordered
+
+
+def similarity(a: str, b: str) -> float:
+    """pg_trgm similarity(): shared trigrams over the union of both sets."""
+    first, second = trigrams(a), trigrams(b)
+    if not first or not second:
+        return 0.0
+    shared = len(first & second)
+    return shared / len(first | second)
+
+
+def word_similarity(a: str, b: str) -> float:
+    """
+    pg_trgm word_similarity(): best similarity between the trigrams of `a`
+    and any continuous extent of the ordered trigrams of `b`.
+    """
+    first = trigrams(a)
+    ordered = _ordered_trigrams(b)
+    if not first or not ordered:
+        return 0.0
+    best = 0.0
+    for start in range(len(ordered)):
+        extent: set[str] = set()
+        for end in range(start, len(ordered)):
+            extent.add(ordered[end])
+            best = max(best, len(first & extent) / len(first | extent))
+    return best
+
+
+def _format_real(value: float) -> str:
+    # float4 precision, like PostgreSQL's real output
+    return f"{value:.6g}"
+
+
+def _sql_literal(value) -> str:
+    if value is None:
+        return "NULL"
+    if isinstance(value, int | float) and not isinstance(value, bool):
+        return str(value)
+    text = str(value)
+    if _NUMBER.match(text.strip()):
+        return text.strip()
+    return "'" + text.replace("'", "''") + "'"
+
+
+def inline_parameter_operands(sql: str, params: list | None) -> tuple[str, list | None]:
+    """
+    Write the values of parameters that are operands of %, <% or %> into the
+    statement: text becomes a string literal (pg_trgm) and numbers a numeric
+    literal (modulo). ? parameters are removed from params; $n stay, as the
+    statement may use them elsewhere.
+
+    Raises:
+        AmbiguousOperator: a parameter operand has no value
+    """
+    if "%" not in sql or ("?" not in sql and "$" not in sql):
+        return sql, params
+
+    operands = []
+    search_from = 0
+    while match := find_outside_literals(_OPERATOR, sql, search_from):
+        search_from = match.end()
+        if match.group(1) == "%" and sql[match.end() : match.end() + 1].isalpha():
+            continue
+        left_start = operand_start(sql, match.start())
+        right_end = TrigramTranslator._operand_end(sql, match.end())
+        for start, end in ((left_start, match.start()), (match.end(), right_end)):
+            if start is None or end is None:
+                continue
+            operand = sql[start:end]
+            parameter = _PARAMETER.match(operand.strip())
+            if parameter:
+                begin = start + len(operand) - len(operand.lstrip())
+                operands.append((begin, begin + len(operand.strip()), parameter, match.group(1)))
+
+    remaining = list(params or [])
+    for begin, end, parameter, operator in sorted(operands, key=lambda item: item[0], reverse=True):
+        if parameter.group(1):
+            index = int(parameter.group(1)) - 1
+        else:
+            index, position = 0, 0
+            while (placeholder := find_outside_literals(_PLACEHOLDER, sql, position)) and (
+                placeholder.start() < begin
+            ):
+                index += 1
+                position = placeholder.end()
+        values = params or []
+        if index >= len(values):
+            raise AmbiguousOperator(
+                f"operator {operator} with parameter {parameter.group(0)} needs its value "
+                "to choose between pg_trgm similarity and modulo"
+            )
+        sql = sql[:begin] + _sql_literal(values[index]) + sql[end:]
+        if not parameter.group(1):
+            del remaining[index]
+    return sql, remaining if params is not None else None
+
+
+class TrigramTranslator:
+    """Rewrites pg_trgm functions and operators into IRIS expressions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate pg_trgm similarity functions and operators in SQL.
+
+        Args:
+            sql: SQL that may contain similarity()/word_similarity()/%/<%/%>
+
+        Returns:
+            Tuple of (translated_sql, translation_count)
+        """
+        if "%" not in sql and "SIMILARITY" not in sql.upper():
+            return sql, 0
+
+        sql, count = self._translate_operators(sql)
+
+        search_from = 0
+        while True:
+            match = find_outside_literals(_FUNCTION_CALL, sql, search_from)
+            if not match:
+                break
+            close = self._closing_paren(sql, match.end())
+            if close is None:
+                break
+            inner, inner_count = self.translate(sql[match.end() : close])
+            args = self._split_arguments(inner)
+            if len(args) != 2:
+                search_from = match.end()
+                continue
+
+            if match.group(1).upper() == "SIMILARITY":
+                replacement = self.similarity_expression(args[0], args[1])
+            else:
+                replacement = self.word_similarity_expression(args[0], args[1])
+
+            sql = sql[: match.start()] + replacement + sql[close + 1 :]
+            search_from = match.start() + len(replacement)
+            count += 1 + inner_count
+
+        return sql, count
+
+    # ------------------------------------------------------------------ expressions
+
+    def similarity_expression(self, a: str, b: str) -> str:
+        literal_a, literal_b = self._literal_text(a), self._literal_text(b)
+        if literal_a is not None and literal_b is not None:
+            return _format_real(similarity(literal_a, literal_b))
+        if literal_a is None and literal_b is None:
+            return self._equality_fallback(a, b)
+
+        literal, column = (literal_b, a) if literal_b is not None else (literal_a, b)
+        probes = trigrams(literal)
+        if not probes:
+            return "0"
+        shared = self._shared_count(column, probes)
+        return (
+            f"(CAST({shared} AS DOUBLE) / "
+            f"({self._column_count(column)} + {len(probes)} - {shared}))"
+        )
+
+    def similarity_condition(self, a: str, b: str) -> str:
+        """a % b: similarity(a, b) >= SIMILARITY_THRESHOLD."""
+        literal_a, literal_b = self._literal_text(a), self._literal_text(b)
+        if (literal_a is None) == (literal_b is None):
+            return f"{self.similarity_expression(a, b)} >= {SIMILARITY_THRESHOLD}"
+
+        literal, column = (literal_b, a) if literal_b is not None else (literal_a, b)
+        probes = trigrams(literal)
+        if not probes:
+            return f"0 >= {SIMILARITY_THRESHOLD}"
+        # shared / (column + probes - shared) >= t, without the division
+        return (
+            f"{self._shared_count(column, probes)} * {_format_real(1 + SIMILARITY_THRESHOLD)} >= "
+            f"{SIMILARITY_THRESHOLD} * ({self._column_count(column)} + {len(probes)})"
+        )
+
+    def word_similarity_condition(self, a: str, b: str) -> str:
+        """a <% b: word_similarity(a, b) >= WORD_SIMILARITY_THRESHOLD."""
+        literal_a, literal_b = self._literal_text(a), self._literal_text(b)
+        if literal_a is None or literal_b is not None:
+            return f"{self.word_similarity_expression(a, b)} >= {WORD_SIMILARITY_THRESHOLD}"
+
+        probes = trigrams(literal_a)
+        if not probes:
+            return f"0 >= {WORD_SIMILARITY_THRESHOLD}"
+        bound = _format_real(WORD_SIMILARITY_THRESHOLD * len(probes))
+        return f"{self._shared_count(b, probes)} >= {bound}"
+
+    def word_similarity_expression(self, a: str, b: str) -> str:
+        literal_a, literal_b = self._literal_text(a), self._literal_text(b)
+        if literal_a is not None and literal_b is not None:
+            return _format_real(word_similarity(literal_a, literal_b))
+        if literal_a is None:
+            # The search term is a column: fall back to whole-string similarity
+            return self.similarity_expression(a, b)
+
+        probes = trigrams(literal_a)
+        if not probes:
+            return "0"
+        return f"(CAST({self._shared_count(b, probes)} AS DOUBLE) / {len(probes)})"
+
+    @staticmethod
+    def _shared_count(column: str, probes: set[str]) -> str:
+        # Words start after a space and end before one: '  a' (a word starting
+        # with a) is ' a' in the value padded with a single space
+        padded = f"' ' || LOWER({column}) || ' '"
+        terms = [
+            f"SIGN(INSTR({padded}, '{probe[1:] if probe.startswith('  ') else probe}'))"
+            for probe in sorted(probes)
+        ]
+        return f"({' + '.join(terms)})"
+
+    @staticmethod
+    def _column_count(column: str) -> str:
+        return f"(LENGTH(TRIM({column})) + 1)"
+
+    @staticmethod
+    def _equality_fallback(a: str, b: str) -> str:
+        return f"(CASE WHEN LOWER({a}) = LOWER({b}) THEN 1.0 ELSE 0.0 END)"
+
+    # ------------------------------------------------------------------ operators
+
+    def _translate_operators(self, sql: str) -> tuple[str, int]:
+        count = 0
+        search_from = 0
+        while True:
+            match = find_outside_literals(_OPERATOR, sql, search_from)
+            if not match:
+                return sql, count
+
+            if match.group(1) == "%" and sql[match.end() : match.end() + 1].isalpha():
+                # IRIS %-prefixed names (%ID, %EXACT) are not operators
+                search_from = match.end()
+                continue
+
+            left_start = operand_start(sql, match.start())
+            right_end = self._operand_end(sql, match.end())
+            if left_start is None or right_end is None:
+                search_from = match.end()
+                continue
+
+            left = sql[left_start : match.start()].strip()
+            right = sql[match.end() : right_end].strip()
+            operator = match.group(1)
+
+            if operator == "%":
+                if self._literal_text(left) is None and self._literal_text(right) is None:
+                    search_from = match.end()  # Numeric modulo
+                    continue
+                replacement = self.similarity_condition(left, right)
+            elif operator == "<%":
+                replacement = self.word_similarity_condition(left, right)
+            else:
+                replacement = self.word_similarity_condition(right, left)
+
+            sql = sql[:left_start] + replacement + sql[right_end:]
+            search_from = left_start + len(replacement)
+            count += 1
+
+    @staticmethod
+    def _operand_end(sql: str, start: int) -> int | None:
+        """Scan forward from `start` to the end of the right-hand operand."""
+        i = start
+        while i < len(sql) and sql[i].isspace():
+            i += 1
+        if i >= len(sql):
+            return None
+
+        if sql[i] == "'":
+            i += 1
+            while i < len(sql):
+                if sql[i] == "'":
+                    if i + 1 < len(sql) and sql[i + 1] == "'":
+                        i += 2
+                        continue
+                    return i + 1
+                i += 1
+            return None
+
+        begin = i
+        while i < len(sql) and (sql[i].isalnum() or sql[i] in '_."$?'):
+            i += 1
+        if i < len(sql) and sql[i] == "(":
+            close = TrigramTranslator._closing_paren(sql, i + 1)
+            return None if close is None else close + 1
+        return i if i > begin else None
+
+    # ------------------------------------------------------------------ helpers
+
+    @staticmethod
+    def _literal_text(expr: str) -> str | None:
+        match = _STRING_LITERAL.match(expr.strip())
+        return match.group(1).replace("''", "'") if match else None
+
+    @staticmethod
+    def _closing_paren(sql: str, pos: int) -> int | None:
+        depth = 1
+        in_literal = False
+        for i in range(pos, len(sql)):
+            char = sql[i]
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                    if depth == 0:
+                        return i
+        return None
+
+    def _split_arguments(self, inner: str) -> list[str]:
+        args, depth, in_literal, current = [], 0, False, []
+        for char in inner:
+            if char == "'":
+                in_literal = not in_literal
+            elif not in_literal:
+                if char == "(":
+                    depth += 1
+                elif char == ")":
+                    depth -= 1
+                elif char == "," and depth == 0:
+                    args.append("".join(current).strip())
+                    current = []
+                    continue
+            current.append(char)
+        if "".join(current).strip():
+            args.append("".join(current).strip())
+        return args
@@ -0,0 +1,207 @@
+"""
+VALUES List Translator for PostgreSQL-Compatible SQL
+
+ORMs look up batches of keys by joining against an inline table, and test
+fixtures select from literal rows; both use VALUES as a table expression,
+which IRIS only accepts in INSERT. Such lists are rewritten into UNION ALL
+selects:
+
+- VALUES (1, 'a'), (2, 'b')           → SELECT 1 AS column1, 'a' AS column2
+                                         UNION ALL SELECT 2, 'b'
+- (VALUES (1, 'a'), ...) AS t(id, x)  → (SELECT 1 AS id, 'a' AS x UNION ALL ...) AS t
+- x IN (VALUES (1), (2))              → x IN (SELECT 1 AS column1 UNION ALL SELECT 2)
+
+Columns are named column1, column2, ... as in PostgreSQL unless the alias
+lists names (names beyond the list keep their default). A standalone VALUES
+keeps its ORDER BY / LIMIT, which then apply to the whole union. INSERT ...
+VALUES, lists whose rows differ in length and VALUES inside string literals
+are left unchanged.
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without
+  VALUES are returned after a substring check)
+"""
+
+import re
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<op>::|<>|!=|[-+*/%(),.?;:=<>\[\]])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+# Words after which "(" opens a subquery rather than a column list or call arguments
+_SUBQUERY_CONTEXT = frozenset({"FROM", "JOIN", "IN", "EXISTS", "AS", "UNION", "ALL", "LATERAL"})
+
+# Set operators a VALUES list can follow without parentheses
+_SET_OPERATORS = frozenset({"UNION", "ALL", "INTERSECT", "EXCEPT"})
+
+# Words that end a table alias (t in "(VALUES ...) t") instead of being one
+_CLAUSE_KEYWORDS = frozenset(
+    {
+        "ON", "USING", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "UNION",
+        "INTERSECT", "EXCEPT", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS",
+        "NATURAL", "WINDOW", "FETCH", "FOR", "RETURNING", "SELECT", "AND", "OR",
+    }
+)
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ) closing the parenthesis at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text in ("(", "["):
+            depth += 1
+        elif text in (")", "]"):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _split(tokens) -> list[list]:
+    """Top-level comma-separated groups of tokens."""
+    groups, current, depth = [], [], 0
+    for token in tokens:
+        if token[1] in ("(", "["):
+            depth += 1
+        elif token[1] in (")", "]"):
+            depth -= 1
+        elif token[1] == "," and depth == 0:
+            groups.append(current)
+            current = []
+            continue
+        current.append(token)
+    groups.append(current)
+    return groups
+
+
+class ValuesTranslator:
+    """Rewrites VALUES table expressions into UNION ALL selects."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate VALUES lists used as queries.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, number of VALUES lists rewritten)
+        """
+        if "VALUES" not in sql.upper():
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            tokens = _tokens(sql)
+            position = next(
+                (
+                    i
+                    for i, token in enumerate(tokens)
+                    if token[2] >= search_from
+                    and token[0] == "word"
+                    and token[1].upper() == "VALUES"
+                ),
+                None,
+            )
+            if position is None:
+                return sql, count
+            search_from = tokens[position][3]
+
+            rewrite = self._rewrite(sql, tokens, position)
+            if rewrite is None:
+                continue
+            start, end, replacement = rewrite
+            sql = sql[:start] + replacement + sql[end:]
+            search_from = start
+            count += 1
+
+    @staticmethod
+    def _rows(sql: str, tokens, position: int) -> tuple[list[list[str]], int] | None:
+        """The rows after VALUES at tokens[position], and the index of the last )."""
+        rows, index = [], position + 1
+        while index < len(tokens) and tokens[index][1] == "(":
+            close = _closing(tokens, index)
+            if close is None or close == index + 1:
+                return None
+            groups = _split(tokens[index + 1 : close])
+            if not all(groups):
+                return None
+            rows.append([sql[group[0][2] : group[-1][3]] for group in groups])
+            if close + 1 < len(tokens) and tokens[close + 1][1] == ",":
+                index = close + 2
+                continue
+            if any(len(row) != len(rows[0]) for row in rows):
+                return None  # PostgreSQL: VALUES lists must all be the same length
+            return rows, close
+        return None
+
+    def _rewrite(self, sql: str, tokens, position: int) -> tuple[int, int, str] | None:
+        parsed = self._rows(sql, tokens, position)
+        if parsed is None:
+            return None
+        rows, last = parsed
+        names = [f"column{i + 1}" for i in range(len(rows[0]))]
+
+        previous = tokens[position - 1] if position > 0 else None
+        if previous is None or (previous[0] == "word" and previous[1].upper() in _SET_OPERATORS):
+            return tokens[position][2], tokens[last][3], self._union(rows, names)
+
+        opening = position - 1
+        if previous[1] != "(" or last + 1 >= len(tokens) or tokens[last + 1][1] != ")":
+            return None  # INSERT ... VALUES, or ORDER BY etc. inside the parentheses
+        before = tokens[opening - 1] if opening > 0 else None
+        before_word = before[1].upper() if before is not None and before[0] == "word" else None
+        if before is not None and before[1] not in ("(", ",") and (
+            before_word not in _SUBQUERY_CONTEXT
+        ):
+            return None  # INSERT INTO t (VALUES ...) or a function call
+
+        # FROM (VALUES ...) [AS] alias [(a, b)]: the column list names the columns
+        end = tokens[last + 1][3]
+        alias = last + 2
+        if alias < len(tokens) and tokens[alias][1].upper() == "AS":
+            alias += 1
+        if (
+            (before is None or before[1] == "," or before_word in ("FROM", "JOIN", "LATERAL"))
+            and alias + 1 < len(tokens)
+            and tokens[alias][0] == "word"
+            and tokens[alias][1].upper() not in _CLAUSE_KEYWORDS
+            and tokens[alias + 1][1] == "("
+        ):
+            close = _closing(tokens, alias + 1)
+            groups = _split(tokens[alias + 2 : close]) if close is not None else []
+            if close is None or not all(
+                len(group) == 1 and group[0][0] == "word" for group in groups
+            ):
+                return None
+            aliases = [group[0][1] for group in groups]
+            if len(aliases) > len(names):
+                return None  # PostgreSQL: table has fewer columns than specified
+            names[: len(aliases)] = aliases
+            table_alias = sql[tokens[last + 2][2] : tokens[alias][3]]
+            replacement = f"({self._union(rows, names)}) {table_alias}"
+            return tokens[opening][2], tokens[close][3], replacement
+        return tokens[opening][2], end, f"({self._union(rows, names)})"
+
+    @staticmethod
+    def _union(rows: list[list[str]], names: list[str]) -> str:
+        first = ", ".join(f"{value} AS {name}" for value, name in zip(rows[0], names, strict=True))
+        selects = [f"SELECT {first}"] + [f"SELECT {', '.join(row)}" for row in rows[1:]]
+        return " UNION ALL ".join(selects)
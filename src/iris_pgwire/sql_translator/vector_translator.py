@@ -0,0 +1,445 @@
+"""
+pgvector Distance Operator Translator for PostgreSQL-Compatible SQL
+
+IRIS compares VECTOR values with VECTOR_COSINE (cosine similarity) and
+VECTOR_DOT_PRODUCT (inner product). pgvector's distance operators are
+rewritten onto them:
+
+- a <=> b   → (1 - VECTOR_COSINE(a, b))                    cosine distance
+- a <#> b   → (-VECTOR_DOT_PRODUCT(a, b))                  negative inner product
+- a <-> b   → SQRT(VECTOR_DOT_PRODUCT(a, a) - 2 * VECTOR_DOT_PRODUCT(a, b)
+              + VECTOR_DOT_PRODUCT(b, b))                  Euclidean distance
+
+An ORDER BY item that is a distance only needs its order, and is written as
+the ranking IRIS's HNSW indexes and the vector optimizer recognize:
+ORDER BY a <=> b becomes ORDER BY VECTOR_COSINE(a, b) DESC, a <#> b
+VECTOR_DOT_PRODUCT(a, b) DESC, and a <-> b VECTOR_DOT_PRODUCT(a, a) - 2 *
+VECTOR_DOT_PRODUCT(a, b) (|b| is the same for every row). ORDER BY ... DESC
+reverses them.
+
+Parameters and string literals compared as vectors ([1,2,3], pgvector's
+text form) are read with TO_VECTOR(x, DOUBLE), with or without a ::vector
+cast; ::vector and CAST(x AS VECTOR) elsewhere become TO_VECTOR too, or are
+dropped from columns. a <-> ? outside ORDER BY reads the parameter more than
+once, so the executor writes its value into the statement first
+(inline_distance_parameters).
+
+Constitutional Requirements:
+- Part of < 5ms normalization overhead requirement (statements without a
+  distance operator or a vector cast are returned after a substring check)
+"""
+
+import re
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<op><=>|<#>|<->|::|<>|!=|[-+*/%(),.?;:=<>\[\]])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+_OPERATORS = frozenset({"<=>", "<#>", "<->"})
+
+_VECTOR_CAST = re.compile(r"::\s*vector\b|\bAS\s+VECTOR\s*(?:\(\s*\d+\s*\)\s*)?\)", re.IGNORECASE)
+
+# Words before "(" that are not function names (the parentheses group an expression)
+_KEYWORDS = frozenset(
+    {
+        "SELECT", "WHERE", "AND", "OR", "NOT", "ON", "WHEN", "THEN", "ELSE", "IN", "BY",
+        "HAVING", "RETURNING", "SET", "VALUES", "AS", "IS", "LIKE", "BETWEEN", "CASE",
+        "DISTINCT", "FROM", "JOIN", "USING", "EXISTS", "ANY", "ALL", "SOME",
+    }
+)
+
+# Words that start a clause or a condition: an ORDER BY item list ends before them
+_CLAUSE_WORDS = _KEYWORDS | {"LIMIT", "OFFSET", "FETCH", "TOP", "GROUP", "UNION", "WINDOW"}
+
+# What may follow a complete ORDER BY item
+_ITEM_END = frozenset({",", ")", ";", "LIMIT", "OFFSET", "FETCH", "FOR"})
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ) closing the parenthesis at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text in ("(", "["):
+            depth += 1
+        elif text in (")", "]"):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _opening(tokens, index: int) -> int | None:
+    """Index of the ( opening the parenthesis closed at tokens[index]."""
+    depth = 0
+    for position in range(index, -1, -1):
+        text = tokens[position][1]
+        if text in (")", "]"):
+            depth += 1
+        elif text in ("(", "["):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _is(token, *words: str) -> bool:
+    return token is not None and token[0] == "word" and token[1].upper() in words
+
+
+def _is_vector_type(tokens, index: int) -> int | None:
+    """Index of the last token of a vector type name at tokens[index] (vector, vector(3))."""
+    if index >= len(tokens) or not _is(tokens[index], "VECTOR"):
+        return None
+    if (
+        index + 3 < len(tokens)
+        and tokens[index + 1][1] == "("
+        and tokens[index + 2][0] == "number"
+        and tokens[index + 3][1] == ")"
+    ):
+        return index + 3
+    if index + 1 < len(tokens) and tokens[index + 1][1] == "(":
+        return None  # IRIS's VECTOR(DOUBLE, 3)
+    return index
+
+
+def _cast_value(tokens, start: int, end: int) -> tuple[int, int] | None:
+    """Tokens of x when tokens[start..end] is CAST(x AS VECTOR[(n)])."""
+    if not (
+        _is(tokens[start], "CAST")
+        and start + 1 < end
+        and tokens[start + 1][1] == "("
+        and _closing(tokens, start + 1) == end
+    ):
+        return None
+    depth = 0
+    for index in range(start + 2, end):
+        text = tokens[index][1]
+        if text == "(":
+            depth += 1
+        elif text == ")":
+            depth -= 1
+        elif depth == 0 and _is(tokens[index], "AS"):
+            if _is_vector_type(tokens, index + 1) == end - 1 and index > start + 2:
+                return start + 2, index - 1
+            return None
+    return None
+
+
+def _operand_sql(sql: str, tokens, start: int, end: int) -> str:
+    """SQL of the vector operand tokens[start..end], a parameter or literal as TO_VECTOR."""
+    inner = _cast_value(tokens, start, end)
+    if inner is not None:
+        start, end = inner
+    if start == end and (tokens[start][1] == "?" or tokens[start][0] == "string"):
+        return f"TO_VECTOR({tokens[start][1]}, DOUBLE)"
+    return sql[tokens[start][2] : tokens[end][3]]
+
+
+def _is_parameter(tokens, start: int, end: int) -> bool:
+    inner = _cast_value(tokens, start, end)
+    if inner is not None:
+        start, end = inner
+    return start == end and tokens[start][1] == "?"
+
+
+def _vector_literal(value) -> str:
+    if isinstance(value, list | tuple):
+        value = "[" + ",".join(str(element) for element in value) + "]"
+    return "'" + str(value).replace("'", "''") + "'"
+
+
+def inline_distance_parameters(sql: str, params: list | None) -> tuple[str, list | None]:
+    """
+    Write the values of ? parameters that are operands of <-> into the
+    statement as vector literals, where the Euclidean distance needs each
+    operand more than once (everywhere but an ORDER BY ranking against a
+    column). The values are removed from params; parameters without a value
+    are left for IRIS to report.
+    """
+    if "<->" not in sql or "?" not in sql or not params:
+        return sql, params
+
+    tokens = _tokens(sql)
+    placeholders = [index for index, token in enumerate(tokens) if token[1] == "?"]
+    inlined = set()
+    for position, token in enumerate(tokens):
+        if token[1] != "<->" or position == 0:
+            continue
+        left = VectorTranslator._left_operand(tokens, position - 1)
+        right = VectorTranslator._right_operand(tokens, position + 1)
+        if left is None or right is None:
+            continue
+        operands = [left, right[:2]]
+        parameters = [_is_parameter(tokens, start, end) for start, end in operands]
+        if VectorTranslator._order_by_item(tokens, left[0], right[2]) and not all(parameters):
+            continue
+        for (start, end), is_parameter in zip(operands, parameters, strict=True):
+            if is_parameter:
+                inlined.add((_cast_value(tokens, start, end) or (start, end))[0])
+
+    remaining = list(params)
+    for index in sorted(inlined, reverse=True):
+        number = placeholders.index(index)
+        if number >= len(params):
+            continue
+        start, end = tokens[index][2], tokens[index][3]
+        sql = sql[:start] + _vector_literal(params[number]) + sql[end:]
+        del remaining[number]
+    return sql, remaining
+
+
+class VectorTranslator:
+    """Rewrites pgvector distance operators and vector casts onto IRIS vector functions."""
+
+    def translate(self, sql: str) -> tuple[str, int]:
+        """
+        Translate pgvector distance operators and ::vector casts.
+
+        Args:
+            sql: SQL statement
+
+        Returns:
+            Tuple of (translated_sql, number of operators and casts rewritten)
+        """
+        if (
+            "<=>" not in sql
+            and "<#>" not in sql
+            and "<->" not in sql
+            and not _VECTOR_CAST.search(sql)
+        ):
+            return sql, 0
+
+        count = 0
+        search_from = 0
+        while True:
+            tokens = _tokens(sql)
+            position = next(
+                (
+                    i
+                    for i, token in enumerate(tokens)
+                    if token[2] >= search_from and token[0] == "op" and token[1] in _OPERATORS
+                ),
+                None,
+            )
+            if position is None:
+                break
+            search_from = tokens[position][3]
+            rewrite = self._operator(sql, tokens, position)
+            if rewrite is None:
+                continue
+            start, end, replacement = rewrite
+            sql = sql[:start] + replacement + sql[end:]
+            search_from = start
+            count += 1
+
+        sql, cast_count = self._casts(sql)
+        return sql, count + cast_count
+
+    # ------------------------------------------------------------------ operands
+
+    @staticmethod
+    def _left_operand(tokens, index: int) -> tuple[int, int] | None:
+        """
+        First and last token of the left operand ending at tokens[index],
+        without a trailing ::vector cast.
+        """
+        while index >= 2:
+            if tokens[index][0] == "word" and tokens[index - 1][1] == "::":
+                index -= 2
+            elif tokens[index][1] == ")":
+                opening = _opening(tokens, index)
+                if opening is None or opening < 3 or tokens[opening - 2][1] != "::":
+                    break
+                index = opening - 3  # ::vector(3)
+            else:
+                break
+        end = index
+        token = tokens[index]
+        if token[1] == ")":
+            opening = _opening(tokens, index)
+            if opening is None:
+                return None
+            before = tokens[opening - 1] if opening > 0 else None
+            if (
+                before is not None
+                and before[0] == "word"
+                and before[1].upper() not in _KEYWORDS
+            ):
+                opening -= 1
+            return opening, end
+        if token[0] == "word":
+            while index >= 2 and tokens[index - 1][1] == "." and tokens[index - 2][0] == "word":
+                index -= 2
+            return index, end
+        if token[0] in ("string", "number") or token[1] == "?":
+            return index, end
+        return None
+
+    @staticmethod
+    def _right_operand(tokens, index: int) -> tuple[int, int, int] | None:
+        """
+        First and last token of the right operand starting at tokens[index],
+        and its last token including trailing casts.
+        """
+        if index >= len(tokens):
+            return None
+        token = tokens[index]
+        if token[1] == "?" or token[0] in ("string", "number"):
+            end = index
+        elif token[1] == "(":
+            end = _closing(tokens, index)
+        elif token[0] == "word":
+            end = index
+            while (
+                end + 2 < len(tokens)
+                and tokens[end + 1][1] == "."
+                and tokens[end + 2][0] == "word"
+            ):
+                end += 2
+            if end + 1 < len(tokens) and tokens[end + 1][1] == "(":
+                end = _closing(tokens, end + 1)
+        else:
+            return None
+        if end is None:
+            return None
+        last = end
+        while (
+            last + 2 < len(tokens)
+            and tokens[last + 1][1] == "::"
+            and tokens[last + 2][0] == "word"
+        ):
+            last += 2
+            if last + 1 < len(tokens) and tokens[last + 1][1] == "(":
+                closing = _closing(tokens, last + 1)
+                if closing is None:
+                    return None
+                last = closing
+        return index, end, last
+
+    @staticmethod
+    def _order_by_item(tokens, start: int, last: int) -> bool:
+        """Whether tokens[start..last] is a whole ORDER BY item."""
+        after = tokens[last + 1] if last + 1 < len(tokens) else None
+        if after is not None and not (
+            after[1].upper() in _ITEM_END or _is(after, "ASC", "DESC", "NULLS")
+        ):
+            return False
+        if start == 0 or not (tokens[start - 1][1] == "," or _is(tokens[start - 1], "BY")):
+            return False
+        index = start - 1
+        while index >= 0:
+            token = tokens[index]
+            if token[1] == ")":
+                opening = _opening(tokens, index)
+                if opening is None:
+                    return False
+                index = opening - 1
+                continue
+            if token[1] == "(":
+                return False
+            if token[0] == "word" and token[1].upper() in _CLAUSE_WORDS:
+                return _is(token, "BY") and index > 0 and _is(tokens[index - 1], "ORDER")
+            index -= 1
+        return False
+
+    # ------------------------------------------------------------------ operators
+
+    def _operator(self, sql: str, tokens, position: int) -> tuple[int, int, str] | None:
+        if position == 0:
+            return None
+        left = self._left_operand(tokens, position - 1)
+        right = self._right_operand(tokens, position + 1)
+        if left is None or right is None:
+            return None
+        (left_start, left_end), (right_start, right_end, right_last) = left, right
+        operator = tokens[position][1]
+        a = _operand_sql(sql, tokens, left_start, left_end)
+        b = _operand_sql(sql, tokens, right_start, right_end)
+        start, end = tokens[left_start][2], tokens[right_last][3]
+
+        if self._order_by_item(tokens, left_start, right_last):
+            descending = False
+            direction = tokens[right_last + 1] if right_last + 1 < len(tokens) else None
+            if _is(direction, "ASC", "DESC"):
+                descending = direction[1].upper() == "DESC"
+                end = direction[3]
+            if operator == "<->":
+                if _is_parameter(tokens, left_start, left_end):
+                    if _is_parameter(tokens, right_start, right_end):
+                        return None
+                    a, b = b, a
+                ranking = f"VECTOR_DOT_PRODUCT({a}, {a}) - 2 * VECTOR_DOT_PRODUCT({a}, {b})"
+                return start, end, ranking + (" DESC" if descending else "")
+            function = "VECTOR_COSINE" if operator == "<=>" else "VECTOR_DOT_PRODUCT"
+            return start, end, f"{function}({a}, {b})" + ("" if descending else " DESC")
+
+        if operator == "<=>":
+            return start, end, f"(1 - VECTOR_COSINE({a}, {b}))"
+        if operator == "<#>":
+            return start, end, f"(-VECTOR_DOT_PRODUCT({a}, {b}))"
+        if _is_parameter(tokens, left_start, left_end) or _is_parameter(
+            tokens, right_start, right_end
+        ):
+            return None
+        return start, end, (
+            f"SQRT(VECTOR_DOT_PRODUCT({a}, {a}) - 2 * VECTOR_DOT_PRODUCT({a}, {b})"
+            f" + VECTOR_DOT_PRODUCT({b}, {b}))"
+        )
+
+    # ------------------------------------------------------------------ casts
+
+    @staticmethod
+    def _casts(sql: str) -> tuple[str, int]:
+        """x::vector and CAST(x AS VECTOR) outside distance operators."""
+        if not _VECTOR_CAST.search(sql):
+            return sql, 0
+        tokens = _tokens(sql)
+        pieces, cursor, count = [], 0, 0
+        for index, token in enumerate(tokens):
+            if token[2] < cursor:
+                continue
+            if _is(token, "CAST") and index + 1 < len(tokens) and tokens[index + 1][1] == "(":
+                close = _closing(tokens, index + 1)
+                inner = None if close is None else _cast_value(tokens, index, close)
+                if inner is None:
+                    continue
+                value_start, value_end, end = index, close, close
+            elif (
+                token[1] == "::"
+                and index > 0
+                and tokens[index - 1][2] >= cursor
+                and _is_vector_type(tokens, index + 1) is not None
+            ):
+                value_start = index - 1
+                if tokens[index - 1][1] == ")":
+                    value_start = _opening(tokens, index - 1)
+                    if value_start is None:
+                        continue
+                value_end = index - 1
+                end = _is_vector_type(tokens, index + 1)
+            else:
+                continue
+            pieces.append(sql[cursor : tokens[value_start][2]])
+            pieces.append(_operand_sql(sql, tokens, value_start, value_end))
+            cursor = tokens[end][3]
+            count += 1
+        if not count:
+            return sql, 0
+        pieces.append(sql[cursor:])
+        return "".join(pieces), count
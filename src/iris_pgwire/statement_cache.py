@@ -0,0 +1,144 @@
+"""
+Implicit Prepared Statements (Simple Query Protocol)
+
+Clients stuck in simple-query mode (BI tools refreshing a dashboard, ODBC
+drivers with UseServerSidePrepare off) send the same statement text over and
+over, and each iris.sql.exec() looks the text up among IRIS's cached queries
+and prepares it again. The embedded executor instead keeps IRIS prepared
+statements (iris.sql.prepare) for repeated texts and executes those:
+
+- a parameterless SELECT, WITH, INSERT, UPDATE or DELETE is counted each
+  time it runs; on its PGWIRE_IMPLICIT_PREPARE_THRESHOLD-th run (default 2)
+  it is prepared, and later runs of the identical text reuse the statement
+- at most PGWIRE_IMPLICIT_PREPARE_CACHE_SIZE statements (default 256) are
+  kept, least recently used first out; 0 turns reuse off
+- DDL and GRANT / REVOKE run through the bridge drop every statement, as they
+  drop the schema cache. A cached statement that fails (a table changed
+  outside the bridge) is dropped and the text runs once more through
+  iris.sql.exec(), which reports the error if it is real; a failing
+  statement has no effect, so the retry cannot apply a change twice.
+
+Statements run with parameters (the extended protocol) are left to IRIS's
+cached queries. External mode's DB-API driver prepares on every execute, so
+the cache applies to embedded mode only.
+"""
+
+import os
+import re
+import threading
+from collections import OrderedDict
+from collections.abc import Callable
+from typing import Any
+
+import structlog
+
+logger = structlog.get_logger()
+
+DEFAULT_THRESHOLD = 2
+DEFAULT_CACHE_SIZE = 256
+
+_REUSABLE = re.compile(r"^\s*(SELECT|WITH|INSERT|UPDATE|DELETE)\b", re.IGNORECASE)
+
+
+def _int_setting(name: str, default: int) -> int:
+    value = os.getenv(name)
+    if value is None or not value.strip():
+        return default
+    try:
+        return max(0, int(value))
+    except ValueError:
+        logger.warning(f"Ignoring invalid {name}", value=value)
+        return default
+
+
+class StatementCache:
+    """IRIS prepared statements of repeated parameterless statement texts."""
+
+    def __init__(self, capacity: int | None = None, threshold: int | None = None):
+        if capacity is None:
+            capacity = _int_setting("PGWIRE_IMPLICIT_PREPARE_CACHE_SIZE", DEFAULT_CACHE_SIZE)
+        if threshold is None:
+            threshold = _int_setting("PGWIRE_IMPLICIT_PREPARE_THRESHOLD", DEFAULT_THRESHOLD)
+        self.capacity = capacity
+        self.threshold = max(1, threshold)
+        self._statements: OrderedDict[str, Any] = OrderedDict()
+        self._seen: OrderedDict[str, int] = OrderedDict()  # Runs of texts not yet prepared
+        self._lock = threading.Lock()
+        self.hits = 0
+        self.prepares = 0
+        self.failures = 0
+
+    @property
+    def enabled(self) -> bool:
+        return self.capacity > 0
+
+    def __len__(self) -> int:
+        return len(self._statements)
+
+    def statement(self, sql: str, prepare: Callable[[str], Any]) -> Any | None:
+        """
+        The prepared statement for sql, prepared on its threshold-th run;
+        None while it should run unprepared.
+        """
+        if not self.enabled or not _REUSABLE.match(sql):
+            return None
+        with self._lock:
+            statement = self._statements.get(sql)
+            if statement is not None:
+                self._statements.move_to_end(sql)
+                self.hits += 1
+                return statement
+            runs = self._seen.pop(sql, 0) + 1
+            if runs < self.threshold:
+                self._seen[sql] = runs
+                while len(self._seen) > self.capacity * 4:
+                    self._seen.popitem(last=False)
+                return None
+
+        statement = prepare(sql)  # Outside the lock: IRIS compiles the query
+        with self._lock:
+            self._statements[sql] = statement
+            self.prepares += 1
+            while len(self._statements) > self.capacity:
+                self._statements.popitem(last=False)
+        logger.debug("Implicit prepared statement", sql=sql[:100], cached=len(self._statements))
+        return statement
+
+    def execute(self, sql: str, iris) -> Any:
+        """Run a parameterless statement in embedded mode, through a prepared statement."""
+        try:
+            statement = self.statement(sql, iris.sql.prepare)
+        except Exception as e:
+            # Preparing failed: iris.sql.exec reports the error
+            logger.debug("Implicit prepare failed", sql=sql[:100], error=str(e))
+            statement = None
+        if statement is None:
+            return iris.sql.exec(sql)
+        try:
+            return statement.execute()
+        except Exception as e:
+            self.forget(sql)
+            self.failures += 1
+            logger.info("Implicit prepared statement failed, running unprepared", error=str(e))
+            return iris.sql.exec(sql)
+
+    def forget(self, sql: str) -> None:
+        with self._lock:
+            self._statements.pop(sql, None)
+
+    def clear(self) -> None:
+        """Drop every statement (the schema changed)."""
+        with self._lock:
+            self._statements.clear()
+            self._seen.clear()
+
+
+_statement_cache: StatementCache | None = None
+
+
+def get_statement_cache() -> StatementCache:
+    """Process-wide statement cache (embedded mode runs in one IRIS process)."""
+    global _statement_cache
+    if _statement_cache is None:
+        _statement_cache = StatementCache()
+    return _statement_cache
@@ -0,0 +1,103 @@
+"""
+Savepoint per Statement
+
+Some frameworks expect a failed statement inside a transaction to leave the
+work done before it intact and the transaction usable, the way psycopg2
+applications get it by wrapping every statement in a savepoint (PgJDBC calls
+this autosave=always). The bridge can do the wrapping for them:
+
+    SET iris.statement_savepoints = on
+
+With the option on, every statement sent inside an explicit transaction
+(BEGIN ... COMMIT) runs under a savepoint managed by the bridge:
+
+    SAVEPOINT pgwire_statement
+    <statement>
+    RELEASE SAVEPOINT pgwire_statement          -- success
+    ROLLBACK TO SAVEPOINT pgwire_statement      -- failure, then RELEASE
+
+so a failed statement's partial effects are undone while the rest of the
+transaction can still be committed. Outside transactions, and for
+transaction control statements (BEGIN, COMMIT, SAVEPOINT, RELEASE, ROLLBACK
+[TO]), nothing changes. Each wrapped statement costs two extra round trips
+to IRIS, so the option is off by default; it can be enabled per role in the
+role settings file or with options=-c iris.statement_savepoints=on.
+"""
+
+import re
+from collections.abc import Awaitable, Callable
+from typing import Any
+
+import structlog
+
+logger = structlog.get_logger()
+
+STATEMENT_SAVEPOINTS_PARAMETER = "iris.statement_savepoints"
+
+SAVEPOINT_NAME = "pgwire_statement"
+
+# Statements that manage the transaction or its savepoints themselves
+_TRANSACTION_CONTROL = re.compile(
+    r"^\s*(?:BEGIN|START\s+TRANSACTION|COMMIT|END|ABORT|ROLLBACK|SAVEPOINT|RELEASE|PREPARE\s+"
+    r"TRANSACTION)\b",
+    re.IGNORECASE,
+)
+
+
+def savepoints_enabled(value: str | None) -> bool:
+    """Whether an iris.statement_savepoints setting turns wrapping on."""
+    return (value or "").lower() == "on"
+
+
+def wraps_statement(sql: str) -> bool:
+    """Whether a statement is wrapped (transaction control statements are not)."""
+    return not _TRANSACTION_CONTROL.match(sql)
+
+
+async def run_with_savepoint(
+    executor, execute: Callable[[], Awaitable[dict[str, Any]]]
+) -> dict[str, Any]:
+    """
+    Run execute() under the statement savepoint.
+
+    Args:
+        executor: IRISExecutor running the savepoint statements
+        execute: Runs the client's statement and returns its result dict
+
+    Returns:
+        The statement's result; a failure has already been rolled back to the
+        savepoint
+    """
+    savepoint = await executor.execute_query(f"SAVEPOINT {SAVEPOINT_NAME}")
+    if not savepoint.get("success"):
+        # Never fail the client's statement because the wrapper could not be set up
+        logger.warning("Statement savepoint not established", error=savepoint.get("error"))
+        return await execute()
+
+    try:
+        result = await execute()
+    except Exception:
+        await _roll_back(executor)
+        raise
+
+    if result.get("success"):
+        await _release(executor)
+    else:
+        await _roll_back(executor)
+        logger.info(
+            "Failed statement rolled back to its savepoint", sqlstate=result.get("sqlstate")
+        )
+    return result
+
+
+async def _roll_back(executor) -> None:
+    rollback = await executor.execute_query(f"ROLLBACK TO SAVEPOINT {SAVEPOINT_NAME}")
+    if not rollback.get("success"):
+        logger.warning("Rollback to statement savepoint failed", error=rollback.get("error"))
+    await _release(executor)
+
+
+async def _release(executor) -> None:
+    release = await executor.execute_query(f"RELEASE SAVEPOINT {SAVEPOINT_NAME}")
+    if not release.get("success"):
+        logger.debug("Statement savepoint not released", error=release.get("error"))
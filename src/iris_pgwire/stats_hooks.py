@@ -0,0 +1,485 @@
+"""
+Stats and Hooks API for Embedders
+
+Applications that run the server in-process can read live statistics and
+receive callbacks instead of scraping logs or an exporter:
+
+    from iris_pgwire.stats_hooks import StatsHooks, get_stats
+
+    class ShipToStatsd(StatsHooks):
+        def on_query(self, event):
+            statsd.timing("pgwire.query", event.duration_ms, tags=[event.command])
+
+    get_stats().add_hooks(ShipToStatsd())
+    ...
+    get_stats().snapshot()   # {"totals": {...}, "sessions": [...]}
+
+Every statement that reaches the IRIS executor produces one QueryEvent;
+sessions report on_session_start/on_session_end with their counters. Hooks
+run inline on the event loop, so they must be quick (hand off to a queue for
+anything slow); a hook that raises is logged and otherwise ignored.
+
+Open sessions back pg_stat_activity (catalog/pg_stat.py), including
+replication connections as "walsender" backends.
+
+The registry also keeps cumulative per-database and per-table activity
+counters, which back the pg_stat_database and pg_stat_user_tables views
+(catalog/pg_stat.py), and bridge I/O totals (result data read from IRIS,
+protocol bytes exchanged with clients) behind pg_stat_io and
+pg_stat_bgwriter, and the temporary files results spilled to (temp_spool.py)
+behind pg_stat_database.temp_files/temp_bytes. Like PostgreSQL's cumulative
+statistics they count since server start (or the last reset_activity()).
+
+The snapshot totals also count statement Describes (describe_cache_hits /
+describe_cache_misses: columns from the schema cache or from running the
+statement) and executions of a described statement whose Bind/Execute came
+without a Describe of its own (describes_skipped), the round trips statement
+caching drivers such as pgx save.
+"""
+
+import re
+import time
+from contextvars import ContextVar
+from dataclasses import asdict, dataclass, field
+from typing import Any
+
+import structlog
+
+logger = structlog.get_logger()
+
+@dataclass
+class SessionStats:
+    """Counters of one client session."""
+
+    connection_id: str
+    user: str | None = None
+    database: str | None = None
+    backend_pid: int | None = None
+    connected_at: float = field(default_factory=time.time)
+    queries: int = 0
+    errors: int = 0
+    rows: int = 0
+    total_query_ms: float = 0.0
+    last_query_at: float | None = None
+    backend_type: str = "client backend"  # "walsender" for replication connections
+    application_name: str | None = None
+    client_addr: str | None = None
+    client_port: int | None = None
+    state: str = "idle"  # "active" while a statement runs
+    query: str | None = None  # Current or last statement
+    query_started_at: float | None = None
+
+    def to_dict(self) -> dict[str, Any]:
+        return asdict(self)
+
+
+@dataclass
+class QueryEvent:
+    """Timing and outcome of one statement."""
+
+    connection_id: str | None
+    user: str | None
+    sql: str
+    command: str | None
+    row_count: int
+    duration_ms: float
+    success: bool
+    sqlstate: str | None = None
+    params: list | None = None  # Bind parameters as received (redact before exporting)
+    timestamp: float = field(default_factory=time.time)  # Completion time (epoch seconds)
+
+
+class StatsHooks:
+    """Callbacks for embedders; override the methods you need."""
+
+    def on_session_start(self, session: SessionStats) -> None:
+        pass
+
+    def on_query(self, event: QueryEvent) -> None:
+        pass
+
+    def on_session_end(self, session: SessionStats) -> None:
+        pass
+
+
+@dataclass
+class DatabaseActivity:
+    """Cumulative counters of one database (pg_stat_database)."""
+
+    datname: str
+    xact_commit: int = 0  # Statements that succeeded (each runs in its own transaction)
+    xact_rollback: int = 0  # Statements that failed
+    tup_returned: int = 0  # Rows returned by queries
+    tup_inserted: int = 0
+    tup_updated: int = 0
+    tup_deleted: int = 0
+    sessions: int = 0
+    session_time_ms: float = 0.0
+    active_time_ms: float = 0.0
+    temp_files: int = 0  # Results spilled to temporary files (temp_spool.py)
+    temp_bytes: int = 0
+
+
+@dataclass
+class TableActivity:
+    """Cumulative write counters of one table (pg_stat_user_tables)."""
+
+    schemaname: str
+    relname: str
+    n_tup_ins: int = 0
+    n_tup_upd: int = 0
+    n_tup_del: int = 0
+    last_write_at: float | None = None
+
+
+@dataclass
+class IOActivity:
+    """Bridge I/O totals (pg_stat_io / pg_stat_bgwriter)."""
+
+    iris_reads: int = 0  # Statements that returned rows from IRIS
+    iris_bytes_read: int = 0  # Estimated size of the result values read from IRIS
+    iris_read_time_ms: float = 0.0
+    client_bytes_received: int = 0
+    client_bytes_sent: int = 0
+    client_writes: int = 0
+
+
+def _result_bytes(result: dict[str, Any]) -> int:
+    """Approximate payload size of a result's rows (text length, 8 bytes per number)."""
+    total = 0
+    for row in result.get("rows") or ():
+        for value in row:
+            if value is None:
+                continue
+            if isinstance(value, str | bytes | bytearray):
+                total += len(value)
+            elif isinstance(value, int | float | bool):
+                total += 8
+            else:
+                total += len(str(value))
+    return total
+
+
+# INSERT INTO t / UPDATE t / DELETE FROM t, with optional schema and quotes
+_DML_TARGET = re.compile(
+    r'^\s*(?:/\*.*?\*/\s*)*(INSERT\s+INTO|UPDATE|DELETE\s+FROM)\s+(?:ONLY\s+)?'
+    r'(?:("?)([\w$%]+)\2\.)?("?)([\w$]+)\4',
+    re.IGNORECASE | re.DOTALL,
+)
+
+
+def dml_target(sql: str) -> tuple[str, str, str] | None:
+    """
+    (INSERT/UPDATE/DELETE, schema, table) written by a statement.
+
+    Unqualified tables are reported in the public schema; names are lowercased.
+    """
+    match = _DML_TARGET.match(sql)
+    if not match:
+        return None
+    command = match.group(1).split()[0].upper()
+    schema = (match.group(3) or "public").lower()
+    return command, schema, match.group(5).lower()
+
+
+_current_session: ContextVar[SessionStats | None] = ContextVar(
+    "pgwire_session_stats", default=None
+)
+
+
+class StatsRegistry:
+    """Server-wide query timings and per-session counters."""
+
+    def __init__(self):
+        self._hooks: list[StatsHooks] = []
+        self._sessions: dict[str, SessionStats] = {}
+        self.started_at = time.time()
+        self.total_sessions = 0
+        self.total_queries = 0
+        self.total_errors = 0
+        self.total_query_ms = 0.0
+        self.total_temp_files = 0
+        self.total_temp_bytes = 0
+        self.total_describe_hits = 0
+        self.total_describe_misses = 0
+        self.total_describes_skipped = 0
+        self._databases: dict[str, DatabaseActivity] = {}
+        self._tables: dict[tuple[str, str], TableActivity] = {}
+        self.io = IOActivity()
+        self.stats_reset = time.time()
+
+    def add_hooks(self, hooks: StatsHooks) -> None:
+        self._hooks.append(hooks)
+
+    def remove_hooks(self, hooks: StatsHooks) -> None:
+        if hooks in self._hooks:
+            self._hooks.remove(hooks)
+
+    def _notify(self, method: str, payload: Any) -> None:
+        for hooks in list(self._hooks):
+            try:
+                getattr(hooks, method)(payload)
+            except Exception as e:
+                logger.warning("Stats hook failed", hook=type(hooks).__name__, error=str(e))
+
+    def session_started(
+        self,
+        connection_id: str,
+        user: str | None = None,
+        database: str | None = None,
+        backend_pid: int | None = None,
+        backend_type: str = "client backend",
+        application_name: str | None = None,
+        client: tuple | None = None,  # Peer address (host, port, ...)
+    ) -> SessionStats:
+        """Start counting for a session (statements in this task count towards it)."""
+        session = SessionStats(
+            connection_id,
+            user,
+            database,
+            backend_pid,
+            backend_type=backend_type,
+            application_name=application_name,
+            client_addr=client[0] if client else None,
+            client_port=client[1] if client else None,
+        )
+        self._sessions[connection_id] = session
+        self.total_sessions += 1
+        if database is not None:
+            self._database(database).sessions += 1
+        _current_session.set(session)
+        self._notify("on_session_start", session)
+        return session
+
+    def session_ended(self, connection_id: str) -> SessionStats | None:
+        session = self._sessions.pop(connection_id, None)
+        if session is not None:
+            if session.database is not None:
+                self._database(session.database).session_time_ms += (
+                    time.time() - session.connected_at
+                ) * 1000
+            self._notify("on_session_end", session)
+        return session
+
+    def query_started(self, sql: str) -> None:
+        """Mark the current session active with sql (pg_stat_activity.state/query)."""
+        session = _current_session.get()
+        if session is not None:
+            session.state = "active"
+            session.query = sql
+            session.query_started_at = time.time()
+
+    def application_name_changed(self, application_name: str) -> None:
+        """Follow SET application_name in the current session."""
+        session = _current_session.get()
+        if session is not None:
+            session.application_name = application_name
+
+    def record_query(
+        self,
+        sql: str,
+        result: dict[str, Any] | None,
+        duration_ms: float,
+        params: list | None = None,
+    ) -> QueryEvent:
+        """
+        Record one executed statement.
+
+        Args:
+            sql: Statement text
+            result: Executor result dict (None if execution raised)
+            duration_ms: Wall-clock execution time
+            params: Bind parameters of the statement
+        """
+        success = bool(result and result.get("success"))
+        row_count = (result or {}).get("row_count") or 0
+        session = _current_session.get()
+        event = QueryEvent(
+            connection_id=session.connection_id if session else None,
+            user=session.user if session else None,
+            sql=sql,
+            command=(result or {}).get("command"),
+            row_count=row_count if isinstance(row_count, int) else 0,
+            duration_ms=duration_ms,
+            success=success,
+            sqlstate=None if success else (result or {}).get("sqlstate", "XX000"),
+            params=list(params) if params else None,
+        )
+
+        self.total_queries += 1
+        self.total_query_ms += duration_ms
+        if not success:
+            self.total_errors += 1
+        elif result.get("rows"):
+            self.io.iris_reads += 1
+            self.io.iris_bytes_read += _result_bytes(result)
+            self.io.iris_read_time_ms += duration_ms
+        if session is not None:
+            session.state = "idle"
+            session.queries += 1
+            session.total_query_ms += duration_ms
+            session.rows += event.row_count
+            session.last_query_at = time.time()
+            if not success:
+                session.errors += 1
+            if session.database is not None:
+                self._record_activity(session.database, sql, event)
+
+        self._notify("on_query", event)
+        return event
+
+    def _database(self, datname: str) -> DatabaseActivity:
+        activity = self._databases.get(datname)
+        if activity is None:
+            activity = self._databases[datname] = DatabaseActivity(datname)
+        return activity
+
+    def _record_activity(self, datname: str, sql: str, event: QueryEvent) -> None:
+        database = self._database(datname)
+        database.active_time_ms += event.duration_ms
+        if not event.success:
+            database.xact_rollback += 1
+            return
+        database.xact_commit += 1
+
+        target = dml_target(sql)
+        if target is None:
+            database.tup_returned += event.row_count
+            return
+        command, schema, table = target
+        activity = self._tables.get((schema, table))
+        if activity is None:
+            activity = self._tables[(schema, table)] = TableActivity(schema, table)
+        activity.last_write_at = event.timestamp
+        if command == "INSERT":
+            database.tup_inserted += event.row_count
+            activity.n_tup_ins += event.row_count
+        elif command == "UPDATE":
+            database.tup_updated += event.row_count
+            activity.n_tup_upd += event.row_count
+        else:
+            database.tup_deleted += event.row_count
+            activity.n_tup_del += event.row_count
+
+    def record_client_io(self, received: int = 0, sent: int = 0) -> None:
+        """Count protocol bytes read from / written to a client connection."""
+        self.io.client_bytes_received += received
+        if sent:
+            self.io.client_bytes_sent += sent
+            self.io.client_writes += 1
+
+    def record_temp_file(self, written: int, created: bool = False) -> None:
+        """Count bytes a result spilled to a temporary file (created: a new file)."""
+        self.total_temp_bytes += written
+        if created:
+            self.total_temp_files += 1
+        session = _current_session.get()
+        if session is not None and session.database is not None:
+            database = self._database(session.database)
+            database.temp_bytes += written
+            if created:
+                database.temp_files += 1
+
+    def record_describe(self, cached: bool) -> None:
+        """Count a statement Describe answered from the schema cache or by running it."""
+        if cached:
+            self.total_describe_hits += 1
+        else:
+            self.total_describe_misses += 1
+
+    def record_describe_skipped(self) -> None:
+        """Count an execution that reused its statement's RowDescription."""
+        self.total_describes_skipped += 1
+
+    def database_activity(self) -> list[DatabaseActivity]:
+        """Cumulative counters of every database that has seen a session."""
+        return list(self._databases.values())
+
+    def table_activity(self) -> dict[tuple[str, str], TableActivity]:
+        """Cumulative write counters keyed by (schema, table), lowercase."""
+        return dict(self._tables)
+
+    def active_sessions(self, datname: str) -> int:
+        """Open sessions connected to a database (pg_stat_database.numbackends)."""
+        return sum(1 for session in self._sessions.values() if session.database == datname)
+
+    def reset_activity(self) -> None:
+        """Zero the database and table counters (pg_stat_reset)."""
+        self._databases.clear()
+        self._tables.clear()
+        self.io = IOActivity()
+        self.stats_reset = time.time()
+
+    def session(self, connection_id: str) -> SessionStats | None:
+        return self._sessions.get(connection_id)
+
+    def sessions(self) -> list[SessionStats]:
+        """Open sessions, in connection order (pg_stat_activity)."""
+        return list(self._sessions.values())
+
+    def snapshot(self) -> dict[str, Any]:
+        """Point-in-time copy of server totals and open sessions."""
+        return {
+            "totals": {
+                "uptime_s": time.time() - self.started_at,
+                "active_sessions": len(self._sessions),
+                "sessions": self.total_sessions,
+                "queries": self.total_queries,
+                "errors": self.total_errors,
+                "total_query_ms": self.total_query_ms,
+                "temp_files": self.total_temp_files,
+                "temp_bytes": self.total_temp_bytes,
+                "describe_cache_hits": self.total_describe_hits,
+                "describe_cache_misses": self.total_describe_misses,
+                "describes_skipped": self.total_describes_skipped,
+            },
+            "sessions": [session.to_dict() for session in self._sessions.values()],
+        }
+
+
+class CountingStreamReader:
+    """StreamReader wrapper that counts client bytes in the registry's I/O totals."""
+
+    def __init__(self, stream, registry: StatsRegistry):
+        self.stream = stream
+        self.registry = registry
+
+    async def readexactly(self, n: int) -> bytes:
+        data = await self.stream.readexactly(n)
+        self.registry.record_client_io(received=len(data))
+        return data
+
+    async def read(self, n: int = -1) -> bytes:
+        data = await self.stream.read(n)
+        self.registry.record_client_io(received=len(data))
+        return data
+
+    def __getattr__(self, name):
+        return getattr(self.stream, name)
+
+
+class CountingStreamWriter:
+    """StreamWriter wrapper that counts client bytes in the registry's I/O totals."""
+
+    def __init__(self, stream, registry: StatsRegistry):
+        self.stream = stream
+        self.registry = registry
+
+    def write(self, data: bytes) -> None:
+        self.registry.record_client_io(sent=len(data))
+        self.stream.write(data)
+
+    def writelines(self, data) -> None:
+        for chunk in data:
+            self.write(chunk)
+
+    def __getattr__(self, name):
+        return getattr(self.stream, name)
+
+
+_stats = StatsRegistry()
+
+
+def get_stats() -> StatsRegistry:
+    """Get the global stats registry"""
+    return _stats
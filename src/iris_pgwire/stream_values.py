@@ -0,0 +1,281 @@
+"""
+IRIS Stream Results
+
+bytea columns are created in IRIS as LONGVARBINARY (ddl_translator.py), the
+SQL face of a %Stream.GlobalBinary property, so values are not bounded by the
+IRIS string limit (3,641,144 characters). A query does not return the
+stream's contents, though:
+
+- embedded mode returns the stream's OID, a $LISTBUILD naming its class
+  (%Stream.GlobalBinary, or %Library.GlobalBinaryStream for older classes)
+- the DB-API driver of external mode may return a stream object to read()
+
+read_stream_columns() replaces such values in bytea columns with their bytes,
+read CHUNK_BYTES at a time, before the connection is given back. Values IRIS
+already returned as a string (VARBINARY columns, or streams under the string
+limit) carry one byte per code point and are converted to bytes, so the
+column is sent as bytea_output text or, in binary format, as the raw bytes.
+
+Character streams (LONGVARCHAR, %Stream.GlobalCharacter, sent as text) are
+read the same way, but a value longer than PGWIRE_LARGE_VALUE_BYTES (default
+16 MB) is not joined in memory: its UTF-8 text is written a chunk at a time
+to a LargeValue, a temporary file like those of large Bind parameters, and
+the DataRow carrying it is written to the client from the file a chunk at a
+time. Wide result sets of CLOBs thus hold one chunk per value in memory.
+
+A stream longer than PGWIRE_MAX_STREAM_BYTES (default 1 GB, PostgreSQL's
+limit for a field; 0 = no limit) is not read further: its row fails with
+54000 program_limit_exceeded when it is sent, as does a value that would
+exceed PGWIRE_SPOOL_MAX_BYTES (53400), instead of sending a truncated value.
+"""
+
+import codecs
+import itertools
+import os
+from collections.abc import Iterator
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .iris_list import decode_list
+from .large_values import (
+    BYTEA_OID,
+    CHUNK_BYTES,
+    TEXT_OIDS,
+    LargeValue,
+    load_large_value_bytes,
+)
+from .message_framing import parse_byte_size
+from .temp_spool import TempFileLimitExceeded
+
+logger = structlog.get_logger()
+
+DEFAULT_MAX_STREAM_BYTES = 1024 * 1024 * 1024
+
+
+class StreamValueTooLarge(ValueError):
+    """A stream result value longer than PGWIRE_MAX_STREAM_BYTES (SQLSTATE 54000)."""
+
+    sqlstate = "54000"
+    condition_name = "program_limit_exceeded"
+
+
+@dataclass
+class FailedStreamValue:
+    """Stands for a stream value that could not be read; sending its row raises error."""
+
+    error: StreamValueTooLarge | TempFileLimitExceeded
+
+
+def load_max_stream_bytes(value: str | None = None) -> int:
+    """PGWIRE_MAX_STREAM_BYTES (invalid values fall back to the default)."""
+    if value is None:
+        value = os.getenv("PGWIRE_MAX_STREAM_BYTES", str(DEFAULT_MAX_STREAM_BYTES))
+    try:
+        return parse_byte_size(value)
+    except ValueError:
+        logger.warning("Ignoring invalid PGWIRE_MAX_STREAM_BYTES", value=value)
+        return DEFAULT_MAX_STREAM_BYTES
+
+
+def stream_class(value: Any) -> str | None:
+    """Class named by a stream OID value, or None if value is not one."""
+    if not isinstance(value, str | bytes):
+        return None
+    if ("Stream" if isinstance(value, str) else b"Stream") not in value:
+        return None
+    elements = decode_list(value)
+    if not elements or len(elements) > 3:
+        return None
+    for element in elements:
+        if isinstance(element, str) and element.startswith("%") and "Stream" in element:
+            return element
+    return None
+
+
+def _binary(data: Any) -> bytes:
+    if isinstance(data, bytes | bytearray | memoryview):
+        return bytes(data)
+    return str(data).encode("latin-1")
+
+
+def _is_stream(value: Any, iris) -> bool:
+    return hasattr(value, "read") or (iris is not None and stream_class(value) is not None)
+
+
+def _chunks(value: Any, iris) -> Iterator | None:
+    """
+    Chunks of a driver stream object or of the IRIS stream an OID names (None
+    for a stream deleted since the row was read).
+    """
+    if hasattr(value, "read"):
+
+        def driver_chunks():
+            while chunk := value.read(CHUNK_BYTES):
+                yield chunk
+
+        return driver_chunks()
+
+    stream = iris.cls(stream_class(value))._Open(value)
+    if stream is None:
+        return None
+
+    def iris_chunks():
+        while not stream.AtEnd:
+            yield stream.Read(CHUNK_BYTES)
+
+    return iris_chunks()
+
+
+def _too_large(column: str, max_bytes: int) -> FailedStreamValue:
+    return FailedStreamValue(
+        StreamValueTooLarge(
+            f'value of column "{column}" exceeds PGWIRE_MAX_STREAM_BYTES ({max_bytes} bytes)'
+        )
+    )
+
+
+def read_stream(value: Any, iris=None, max_bytes: int = 0, column: str = "?column?") -> Any:
+    """
+    Contents of a stream result value.
+
+    Args:
+        value: A result value: a stream OID, a driver stream object or data
+        iris: The embedded iris module (OIDs are opened with it), or None
+        max_bytes: Longest value read (0 = no limit)
+        column: Column name, for the error of a longer value
+
+    Returns:
+        bytes for streams and binary strings (None for a deleted stream, a
+        FailedStreamValue above max_bytes), other values unchanged
+    """
+    if _is_stream(value, iris):
+        stream_chunks = _chunks(value, iris)
+        if stream_chunks is None:
+            return None
+        chunks, size = [], 0
+        for chunk in stream_chunks:
+            data = _binary(chunk)
+            size += len(data)
+            if max_bytes and size > max_bytes:
+                return _too_large(column, max_bytes)
+            chunks.append(data)
+        return b"".join(chunks)
+
+    if isinstance(value, str):
+        try:
+            return value.encode("latin-1")
+        except UnicodeEncodeError:
+            return value.encode("utf-8")
+    return value
+
+
+def read_text_stream(
+    value: Any,
+    iris=None,
+    spool_bytes: int | None = None,
+    max_bytes: int = 0,
+    column: str = "?column?",
+) -> Any:
+    """
+    Contents of a character stream result value.
+
+    Args:
+        value: A result value: a stream OID, a driver stream object or text
+        iris: The embedded iris module (OIDs are opened with it), or None
+        spool_bytes: Longest text kept in memory (default PGWIRE_LARGE_VALUE_BYTES)
+        max_bytes: Longest value read, in UTF-8 bytes (0 = no limit)
+        column: Column name, for the error of a longer value
+
+    Returns:
+        str, or a LargeValue with the UTF-8 text of a longer value (None for a
+        deleted stream, a FailedStreamValue above max_bytes or the spool
+        limit); values that are not streams unchanged
+    """
+    if not _is_stream(value, iris):
+        return value
+    stream_chunks = _chunks(value, iris)
+    if stream_chunks is None:
+        return None
+    if spool_bytes is None:
+        spool_bytes = load_large_value_bytes()
+
+    decoder = codecs.getincrementaldecoder("utf-8")("replace")
+    parts, size, spooled = [], 0, None
+    try:
+        for chunk in itertools.chain(stream_chunks, [b""]):  # b"": flush the decoder
+            if isinstance(chunk, bytes | bytearray):
+                text = decoder.decode(chunk, final=not chunk)
+            else:
+                text = str(chunk)
+            data = text.encode("utf-8")
+            size += len(data)
+            if max_bytes and size > max_bytes:
+                if spooled is not None:
+                    spooled.close()
+                return _too_large(column, max_bytes)
+            if spooled is None and size > spool_bytes:
+                # Kept in a temporary file from here on, sent from it a chunk at a time
+                spooled = LargeValue(binary=False)
+                spooled.write("".join(parts).encode("utf-8"))
+                parts = []
+            if spooled is None:
+                parts.append(text)
+            else:
+                spooled.write(data)
+    except TempFileLimitExceeded as e:
+        spooled.close()
+        return FailedStreamValue(e)
+    return "".join(parts) if spooled is None else spooled
+
+
+def read_stream_columns(
+    rows: list,
+    columns: list[dict],
+    iris=None,
+    spool_bytes: int | None = None,
+    max_bytes: int | None = None,
+) -> int:
+    """
+    Read the stream values of bytea and text columns in place.
+
+    Args:
+        rows: Result rows (lists are updated, tuples replaced)
+        columns: Column descriptions with type_oid
+        iris: The embedded iris module, or None in external mode
+        spool_bytes: Longest text kept in memory (default PGWIRE_LARGE_VALUE_BYTES)
+        max_bytes: Longest stream read (default PGWIRE_MAX_STREAM_BYTES)
+
+    Returns:
+        Number of values read from streams
+    """
+    binary = [i for i, column in enumerate(columns) if column.get("type_oid") == BYTEA_OID]
+    text = {i for i, column in enumerate(columns) if column.get("type_oid") in TEXT_OIDS}
+    if not (binary or text) or not rows:
+        return 0
+    if max_bytes is None:
+        max_bytes = load_max_stream_bytes()
+
+    streams = 0
+    for row_index, row in enumerate(rows):
+        for index in [*binary, *text]:
+            value = row[index] if index < len(row) else None
+            if value is None or isinstance(value, bytes):
+                continue
+            is_stream = _is_stream(value, iris)
+            if index in text and not is_stream:
+                continue
+            streams += is_stream
+            name = columns[index].get("name", "?column?")
+            if index in text:
+                value = read_text_stream(value, iris, spool_bytes, max_bytes, name)
+            else:
+                value = read_stream(value, iris, max_bytes, name)
+            if isinstance(row, tuple):
+                row = rows[row_index] = list(row)
+            row[index] = value
+
+    if streams:
+        logger.debug("Read stream values", values=streams)
+    return streams
@@ -0,0 +1,248 @@
+"""
+IRIS System Function Bridge
+
+Selected $SYSTEM class methods are callable from PostgreSQL clients as
+functions in the `iris_system` schema, so operational scripts can query IRIS
+internals without ObjectScript:
+
+    SELECT iris_system.version(), iris_system.namespace()
+    SELECT iris_system.sql_table_exists('SQLUser.Orders') AS present
+
+Only SELECTs whose items are all iris_system calls with literal arguments are
+answered (no FROM clause); any other statement, including one that mentions
+iris_system in a string literal or beside a FROM clause, runs on IRIS as
+written. Each call becomes a class method invocation:
+iris.cls() in embedded mode, the Native API classMethodValue() over external
+connections. The functions are listed in pg_proc for discovery.
+"""
+
+import re
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .sql_text import split_top_level
+
+logger = structlog.get_logger()
+
+SCHEMA_NAME = "iris_system"
+
+# Type OIDs for result columns
+_TEXT = 25
+_INT4 = 23
+_BOOL = 16
+
+
+@dataclass(frozen=True)
+class SystemFunction:
+    """An iris_system.<name>() function backed by a $SYSTEM class method."""
+
+    name: str
+    class_name: str
+    method: str
+    arg_names: tuple[str, ...]
+    return_type: int
+    description: str
+
+
+SYSTEM_FUNCTIONS = {
+    f.name: f
+    for f in (
+        SystemFunction(
+            "version", "%SYSTEM.Version", "GetVersion", (), _TEXT, "IRIS version string ($ZVERSION)"
+        ),
+        SystemFunction(
+            "version_number", "%SYSTEM.Version", "GetNumber", (), _TEXT, "IRIS version number"
+        ),
+        SystemFunction(
+            "build_number", "%SYSTEM.Version", "GetBuildNumber", (), _TEXT, "IRIS build number"
+        ),
+        SystemFunction("product", "%SYSTEM.Version", "GetProduct", (), _TEXT, "IRIS product name"),
+        SystemFunction("os", "%SYSTEM.Version", "GetOS", (), _TEXT, "Operating system of the IRIS host"),
+        SystemFunction(
+            "namespace", "%SYSTEM.SYS", "NameSpace", (), _TEXT, "Current IRIS namespace"
+        ),
+        SystemFunction("process_id", "%SYSTEM.SYS", "ProcessID", (), _INT4, "IRIS process ID"),
+        SystemFunction(
+            "install_directory",
+            "%SYSTEM.Util",
+            "InstallDirectory",
+            (),
+            _TEXT,
+            "IRIS installation directory",
+        ),
+        SystemFunction(
+            "sql_default_schema",
+            "%SYSTEM.SQL.Schema",
+            "Default",
+            (),
+            _TEXT,
+            "Default SQL schema for unqualified names",
+        ),
+        SystemFunction(
+            "sql_table_exists",
+            "%SYSTEM.SQL.Schema",
+            "TableExists",
+            ("table_name",),
+            _BOOL,
+            "Whether an SQL table exists (Schema.Table)",
+        ),
+        SystemFunction(
+            "sql_view_exists",
+            "%SYSTEM.SQL.Schema",
+            "ViewExists",
+            ("view_name",),
+            _BOOL,
+            "Whether an SQL view exists (Schema.View)",
+        ),
+        SystemFunction(
+            "sql_option",
+            "%SYSTEM.SQL.Util",
+            "GetOption",
+            ("option_name",),
+            _TEXT,
+            "Current value of an SQL configuration option",
+        ),
+    )
+}
+
+_SELECT = re.compile(r"^\s*SELECT\s+(?P<items>.+?)\s*;?\s*$", re.IGNORECASE | re.DOTALL)
+_CALL = re.compile(
+    r"^" + SCHEMA_NAME + r"\.(?P<name>\w+)\s*\((?P<args>.*)\)(?:\s+(?:AS\s+)?(?P<alias>\w+|\"[^\"]+\"))?$",
+    re.IGNORECASE | re.DOTALL,
+)
+_ARGUMENT = re.compile(r"^(?:'((?:[^']|'')*)'|(-?\d+(?:\.\d+)?))$", re.DOTALL)
+
+
+class UnsupportedSystemCall(ValueError):
+    """iris_system call that cannot be answered (SQLSTATE 0A000 / 42883)."""
+
+    def __init__(self, message: str, sqlstate: str = "0A000"):
+        super().__init__(message)
+        self.sqlstate = sqlstate
+
+
+@dataclass
+class SystemCall:
+    function: SystemFunction
+    args: list[Any]
+    column_name: str
+
+
+class EmbeddedSystemInvoker:
+    """Class method calls through embedded Python (iris.cls)."""
+
+    def __init__(self, iris_module):
+        self._iris = iris_module
+
+    def call(self, class_name: str, method: str, args: list) -> Any:
+        return getattr(self._iris.cls(class_name), method)(*args)
+
+
+class NativeSystemInvoker:
+    """Class method calls through the IRIS Native API."""
+
+    def __init__(self, iris_native):
+        self._native = iris_native
+
+    def call(self, class_name: str, method: str, args: list) -> Any:
+        return self._native.classMethodValue(class_name, method, *args)
+
+
+class SystemFunctionHandler:
+    """Answers SELECTs made of iris_system.<function>() calls."""
+
+    def __init__(self, functions: dict[str, SystemFunction] | None = None):
+        self.functions = SYSTEM_FUNCTIONS if functions is None else functions
+
+    def references_system_function(self, sql: str) -> bool:
+        """Whether a statement is a SELECT of iris_system calls only (see parse)."""
+        if f"{SCHEMA_NAME}." not in sql.lower():
+            return False
+        try:
+            return self.parse(sql) is not None
+        except UnsupportedSystemCall:
+            return True
+
+    def parse(self, sql: str) -> list[SystemCall] | None:
+        """
+        Parse the select list into calls (None if the SQL is not ours: not a
+        SELECT, or an item other than an iris_system call).
+
+        Raises:
+            UnsupportedSystemCall: unknown function or non-literal arguments
+        """
+        match = _SELECT.match(sql)
+        if not match:
+            return None
+        matches = [_CALL.match(item) for item in split_top_level(match.group("items"))]
+        if not matches or not all(matches):
+            return None
+        calls = []
+        for call in matches:
+            name = call.group("name").lower()
+            function = self.functions.get(name)
+            args = split_top_level(call.group("args"))
+            if function is None or len(args) != len(function.arg_names):
+                raise UnsupportedSystemCall(
+                    f"function {SCHEMA_NAME}.{name}({', '.join('unknown' for _ in args)}) "
+                    "does not exist",
+                    sqlstate="42883",
+                )
+            values = []
+            for arg in args:
+                literal = _ARGUMENT.match(arg)
+                if not literal:
+                    raise UnsupportedSystemCall(
+                        f"arguments to {SCHEMA_NAME}.{name}() must be literals"
+                    )
+                if literal.group(1) is not None:
+                    values.append(literal.group(1).replace("''", "'"))
+                else:
+                    number = literal.group(2)
+                    values.append(float(number) if "." in number else int(number))
+            alias = (call.group("alias") or name).strip('"')
+            calls.append(SystemCall(function, values, alias))
+        return calls
+
+    def execute(self, sql: str, invoker) -> dict[str, Any] | None:
+        """Evaluate the calls and return an executor result dict."""
+        calls = self.parse(sql)
+        if calls is None:
+            return None
+
+        row = []
+        for call in calls:
+            value = invoker.call(call.function.class_name, call.function.method, call.args)
+            logger.info(
+                "System function call",
+                function=call.function.name,
+                target=f"{call.function.class_name}.{call.function.method}",
+            )
+            if value is None or (value == "" and call.function.return_type != _TEXT):
+                row.append(None)
+            elif call.function.return_type == _BOOL:
+                row.append(bool(int(value)))
+            elif call.function.return_type == _INT4:
+                row.append(int(value))
+            else:
+                row.append(str(value))
+
+        return {
+            "success": True,
+            "rows": [row],
+            "columns": [
+                {
+                    "name": call.column_name,
+                    "type_oid": call.function.return_type,
+                    "type_size": {_BOOL: 1, _INT4: 4}.get(call.function.return_type, -1),
+                    "type_modifier": -1,
+                    "format_code": 0,
+                }
+                for call in calls
+            ],
+            "row_count": 1,
+            "command": "SELECT",
+            "command_tag": "SELECT 1",
+        }
@@ -0,0 +1,183 @@
+"""
+LOCK TABLE
+
+pg_dump and migration tools serialize access to tables with
+
+    LOCK [TABLE] [ONLY] name [*] [, ...] [IN lockmode MODE] [NOWAIT]
+
+IRIS has table locks in two modes (LOCK TABLE ... IN SHARE | EXCLUSIVE MODE,
+held until UNLOCK), so the bridge maps PostgreSQL's eight modes onto them:
+
+    ACCESS SHARE, ROW SHARE, ROW EXCLUSIVE,   no table lock: these only conflict
+    SHARE UPDATE EXCLUSIVE                    with DDL and the stronger modes
+    SHARE                                     IN SHARE MODE
+    SHARE ROW EXCLUSIVE, EXCLUSIVE,           IN EXCLUSIVE MODE
+    ACCESS EXCLUSIVE (the default)
+
+NOWAIT becomes WAIT 0; otherwise the session's lock_timeout (rounded up to
+seconds) or, when it is 0, IRIS's own lock timeout applies. A lock that
+cannot be obtained fails with 55P03 (lock_not_available).
+
+As in PostgreSQL, LOCK TABLE outside a transaction block fails with 25P01 and
+the locks are released when the transaction commits or rolls back (or the
+client disconnects). IRIS locks belong to the IRIS process that takes them:
+in embedded mode all sessions share one process, so LOCK TABLE serializes
+bridge sessions against other IRIS processes but not against each other.
+"""
+
+import math
+import re
+from collections.abc import Awaitable, Callable
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .check_constraints import _IDENTIFIER, Relation, relation
+from .keepalive import parse_duration_ms
+from .sql_text import split_top_level
+
+logger = structlog.get_logger()
+
+LOCK_NOT_AVAILABLE = "55P03"
+
+# PostgreSQL lock mode -> IRIS table lock mode (None: no IRIS lock needed)
+IRIS_LOCK_MODES = {
+    "ACCESS SHARE": None,
+    "ROW SHARE": None,
+    "ROW EXCLUSIVE": None,
+    "SHARE UPDATE EXCLUSIVE": None,
+    "SHARE": "SHARE",
+    "SHARE ROW EXCLUSIVE": "EXCLUSIVE",
+    "EXCLUSIVE": "EXCLUSIVE",
+    "ACCESS EXCLUSIVE": "EXCLUSIVE",
+}
+
+_TARGET = rf"(?:ONLY\s+)?{_IDENTIFIER}(?:\s*\.\s*{_IDENTIFIER})?(?:\s*\*)?"
+_MODE = "|".join(mode.replace(" ", r"\s+") for mode in IRIS_LOCK_MODES)
+
+_LOCK = re.compile(
+    rf"^\s*LOCK\s+(?:TABLE\s+)?(?P<names>{_TARGET}(?:\s*,\s*{_TARGET})*)"
+    rf"(?:\s+IN\s+(?P<mode>{_MODE})\s+MODE)?"
+    r"(?P<nowait>\s+NOWAIT)?\s*;?\s*$",
+    re.IGNORECASE,
+)
+
+# IRIS lock conflicts and timeouts (SQLCODE -110, -114)
+_LOCK_FAILURE = re.compile(r"-11[04]\b|lock(?:ing)? conflict|timed? ?out", re.IGNORECASE)
+
+
+@dataclass
+class LockTable:
+    relations: list[Relation]
+    mode: str  # PostgreSQL lock mode
+    nowait: bool
+
+    @property
+    def iris_mode(self) -> str | None:
+        return IRIS_LOCK_MODES[self.mode]
+
+
+def parse_lock_table(sql: str, iris_schema: str) -> LockTable | None:
+    """LOCK [TABLE] statement (None for any other statement)."""
+    match = _LOCK.match(sql)
+    if not match:
+        return None
+    names = [
+        re.sub(r"^ONLY\s+", "", name.strip(), flags=re.IGNORECASE).rstrip("* ")
+        for name in split_top_level(match.group("names"))
+    ]
+    mode = re.sub(r"\s+", " ", match.group("mode") or "ACCESS EXCLUSIVE").upper()
+    return LockTable(
+        [relation(name, iris_schema) for name in names], mode, bool(match.group("nowait"))
+    )
+
+
+def wait_seconds(nowait: bool, lock_timeout: str | None) -> int | None:
+    """IRIS WAIT for a lock (None: IRIS's default lock timeout)."""
+    if nowait:
+        return 0
+    try:
+        milliseconds = parse_duration_ms(lock_timeout or "0")
+    except ValueError:
+        return None
+    return math.ceil(milliseconds / 1000) if milliseconds else None
+
+
+def lock_sql(target: Relation, iris_mode: str, wait: int | None) -> str:
+    sql = f"LOCK TABLE {target.sql} IN {iris_mode} MODE"
+    return sql if wait is None else f"{sql} WAIT {wait}"
+
+
+def unlock_sql(target: Relation, iris_mode: str) -> str:
+    return f"UNLOCK TABLE {target.sql} IN {iris_mode} MODE IMMEDIATE"
+
+
+def outside_transaction_error() -> dict[str, Any]:
+    return {
+        "success": False,
+        "error": "LOCK TABLE can only be used in transaction blocks",
+        "sqlstate": "25P01",
+        "condition_name": "no_active_sql_transaction",
+        "rows": [],
+        "columns": [],
+        "row_count": 0,
+    }
+
+
+def lock_failure(target: Relation, nowait: bool, result: dict[str, Any]) -> dict[str, Any]:
+    """PostgreSQL's lock_not_available error for a failed IRIS LOCK (others unchanged)."""
+    if not _LOCK_FAILURE.search(result.get("error") or ""):
+        return result
+    if nowait:
+        message = f'could not obtain lock on relation "{target.table}"'
+    else:
+        message = "canceling statement due to lock timeout"
+    return {
+        **result,
+        "error": message,
+        "sqlstate": LOCK_NOT_AVAILABLE,
+        "condition_name": "lock_not_available",
+    }
+
+
+class TableLocks:
+    """IRIS table locks a session holds until its transaction ends."""
+
+    def __init__(self, execute: Callable[[str], Awaitable[dict[str, Any]]]):
+        self.execute = execute  # Runs one IRIS statement, returns its result dict
+        self.held: list[tuple[Relation, str]] = []
+
+    async def lock(self, statement: LockTable, lock_timeout: str | None) -> dict[str, Any]:
+        """Take the IRIS locks for a LOCK TABLE statement, in the order named."""
+        mode = statement.iris_mode
+        wait = wait_seconds(statement.nowait, lock_timeout)
+        for target in statement.relations if mode else []:
+            if (target, mode) in self.held:
+                continue
+            result = await self.execute(lock_sql(target, mode, wait))
+            if not result.get("success"):
+                # Locks already taken are released with the (failed) transaction
+                return lock_failure(target, statement.nowait, result)
+            self.held.append((target, mode))
+        return {
+            "success": True,
+            "rows": [],
+            "columns": [],
+            "row_count": 0,
+            "command": "LOCK",
+            "command_tag": "LOCK TABLE",
+        }
+
+    async def release(self) -> None:
+        """Release every lock, most recent first (at COMMIT / ROLLBACK / disconnect)."""
+        held, self.held = self.held, []
+        for target, mode in reversed(held):
+            try:
+                result = await self.execute(unlock_sql(target, mode))
+            except Exception as e:
+                result = {"success": False, "error": str(e)}
+            if not result.get("success"):
+                logger.warning(
+                    "Table lock not released", table=target.sql, error=result.get("error")
+                )
@@ -0,0 +1,291 @@
+"""
+Temporary Spool Files for Held Results
+
+Some results must be kept after they are read from IRIS:
+
+- WITH HOLD cursors outlive the transaction that declared them; at COMMIT
+  their remaining rows are read into a spool and the IRIS result set is
+  closed, as PostgreSQL materializes held cursors (sql_cursors.py)
+- SCROLL cursors fetch backward, so the rows read so far are kept
+- portal result sets are kept until the portal is closed (portal_cursors.py)
+
+A TempSpool keeps rows in memory up to PGWIRE_SPOOL_MEMORY_BYTES (default
+4 MB, PostgreSQL's work_mem); beyond that it writes them to a temporary file
+in PGWIRE_SPOOL_DIR (default the system temporary directory) in chunks of
+about CHUNK_BYTES and reads chunks back on demand. The file is created
+unlinked with owner-only permissions and is gone when the spool is closed or
+the server exits.
+
+Configuration:
+- PGWIRE_SPOOL_MEMORY_BYTES: rows held in memory per spool before spilling
+  (0 = always spill)
+- PGWIRE_SPOOL_DIR: directory of the temporary files
+- PGWIRE_SPOOL_MAX_BYTES: disk space all spool files of the server may use
+  together (default 0 = unlimited); a result that needs more fails with
+  53400 configuration_limit_exceeded, like PostgreSQL's temp_file_limit
+- PGWIRE_SPOOL_ENCRYPT: encrypt spool files with AES-256-GCM under a key that
+  only exists in memory (requires the cryptography package)
+
+Spilled files and bytes are counted in pg_stat_database.temp_files and
+temp_bytes and in the stats snapshot (stats_hooks.py). Values already held in
+a file of their own (LargeValue, see stream_values.py) are not copied into
+the spool: its file refers to them, and the spool keeps them open.
+"""
+
+import bisect
+import importlib.util
+import io
+import os
+import pickle
+import tempfile
+from dataclasses import dataclass
+from typing import Any
+
+import structlog
+
+from .stats_hooks import get_stats
+
+logger = structlog.get_logger()
+
+DEFAULT_MEMORY_BYTES = 4 * 1024 * 1024
+
+# Rows are written and read back in chunks of about this size
+CHUNK_BYTES = 256 * 1024
+
+_NONCE_BYTES = 12
+
+_TRUE = ("1", "true", "on", "yes")
+
+# Bytes in open spool files, for PGWIRE_SPOOL_MAX_BYTES
+_disk_usage = 0
+
+
+class TempFileLimitExceeded(Exception):
+    """Spool files would exceed PGWIRE_SPOOL_MAX_BYTES (SQLSTATE 53400)."""
+
+    sqlstate = "53400"
+    condition_name = "configuration_limit_exceeded"
+
+
+def _int_setting(name: str, default: int) -> int:
+    value = os.getenv(name)
+    if value is None or not value.strip():
+        return default
+    try:
+        return int(value)
+    except ValueError:
+        raise ValueError(f"invalid {name} {value!r} (expected a number of bytes)") from None
+
+
+@dataclass
+class SpoolConfig:
+    """Where and how results spill to disk."""
+
+    memory_bytes: int = DEFAULT_MEMORY_BYTES
+    directory: str | None = None  # None = the system temporary directory
+    max_bytes: int = 0  # 0 = unlimited
+    encrypt: bool = False
+
+    def __post_init__(self):
+        if self.memory_bytes < 0:
+            raise ValueError("PGWIRE_SPOOL_MEMORY_BYTES must not be negative")
+        if self.max_bytes < 0:
+            raise ValueError("PGWIRE_SPOOL_MAX_BYTES must not be negative")
+        if self.encrypt and importlib.util.find_spec("cryptography") is None:
+            raise ValueError("PGWIRE_SPOOL_ENCRYPT requires the cryptography package")
+
+    @classmethod
+    def from_env(cls) -> "SpoolConfig":
+        return cls(
+            memory_bytes=_int_setting("PGWIRE_SPOOL_MEMORY_BYTES", DEFAULT_MEMORY_BYTES),
+            directory=os.getenv("PGWIRE_SPOOL_DIR") or None,
+            max_bytes=_int_setting("PGWIRE_SPOOL_MAX_BYTES", 0),
+            encrypt=os.getenv("PGWIRE_SPOOL_ENCRYPT", "").strip().lower() in _TRUE,
+        )
+
+
+def disk_usage() -> int:
+    """Bytes in the server's open spool files."""
+    return _disk_usage
+
+
+def reserve_disk(nbytes: int, limit: int) -> None:
+    """
+    Count nbytes about to be written to a spool file (limit 0 = unlimited).
+
+    Raises:
+        TempFileLimitExceeded: they would exceed limit
+    """
+    global _disk_usage
+    if limit and _disk_usage + nbytes > limit:
+        raise TempFileLimitExceeded(
+            f"temporary file size exceeds PGWIRE_SPOOL_MAX_BYTES ({limit} bytes)"
+        )
+    _disk_usage += nbytes
+
+
+def release_disk(nbytes: int) -> None:
+    """Uncount the bytes of a closed spool file."""
+    global _disk_usage
+    _disk_usage -= nbytes
+
+
+def _row_size(row) -> int:
+    """Approximate memory of a row (text length, 8 bytes per other value)."""
+    size = 8
+    for value in row:
+        if isinstance(value, str | bytes | bytearray):
+            size += len(value) + 8
+        else:
+            size += 8
+    return size
+
+
+class TempSpool:
+    """Rows kept in memory, then in a temporary file, readable in any order."""
+
+    def __init__(self, config: SpoolConfig | None = None):
+        self.config = config or SpoolConfig.from_env()
+        self.bytes_written = 0
+        self._rows: list = []  # Rows not written to the file (all of them until it spills)
+        self._pending_bytes = 0
+        self._row_count = 0
+        self._file = None
+        self._cipher = None
+        self._chunks: list[tuple[int, int, int]] = []  # (first row, offset, length)
+        self._chunk_starts: list[int] = []
+        self._cached: tuple[int, list] | None = None  # Last chunk read back
+        self._references: list = []  # Values written to the file by reference
+
+    def __len__(self) -> int:
+        return self._row_count
+
+    @property
+    def spilled(self) -> bool:
+        return self._file is not None
+
+    def append(self, rows: list) -> None:
+        """
+        Add rows at the end.
+
+        Raises:
+            TempFileLimitExceeded: writing them would exceed PGWIRE_SPOOL_MAX_BYTES
+        """
+        self._rows.extend(rows)
+        self._row_count += len(rows)
+        self._pending_bytes += sum(_row_size(row) for row in rows)
+        if not self.spilled:
+            if self._pending_bytes > self.config.memory_bytes:
+                self._open_file()
+                self._flush()
+        elif self._pending_bytes >= CHUNK_BYTES:
+            self._flush()
+
+    def rows(self, start: int, stop: int | None = None) -> list:
+        """Rows start to stop (exclusive; None = to the end), clamped to the spool."""
+        stop = self._row_count if stop is None else min(stop, self._row_count)
+        start = max(start, 0)
+        if start >= stop:
+            return []
+        written = self._row_count - len(self._rows)
+        result = []
+        position = start
+        while position < min(stop, written):
+            index = bisect.bisect_right(self._chunk_starts, position) - 1
+            first = self._chunk_starts[index]
+            chunk = self._read_chunk(index)
+            result.extend(chunk[position - first : stop - first])
+            position = first + len(chunk)
+        if stop > written:
+            result.extend(self._rows[max(start, written) - written : stop - written])
+        return result
+
+    def close(self) -> None:
+        """Drop the rows and delete the file."""
+        if self._file is not None:
+            try:
+                self._file.close()
+            except OSError as e:
+                logger.warning("Spool file not closed", error=str(e))
+            release_disk(self.bytes_written)
+            self._file = None
+        self._rows, self._chunks, self._chunk_starts = [], [], []
+        self._cached = None
+        self._references = []
+        self._row_count = self._pending_bytes = 0
+
+    def _open_file(self) -> None:
+        # Unlinked at once, mode 0600: nothing is left behind if the server dies
+        self._file = tempfile.TemporaryFile(prefix="pgwire_spool_", dir=self.config.directory)
+        if self.config.encrypt:
+            from cryptography.hazmat.primitives.ciphers.aead import AESGCM
+
+            self._cipher = AESGCM(AESGCM.generate_key(bit_length=256))
+        get_stats().record_temp_file(0, created=True)
+        logger.info(
+            "Result spilled to temporary file",
+            rows=self._row_count,
+            memory_bytes=self.config.memory_bytes,
+            encrypted=self._cipher is not None,
+        )
+
+    def _flush(self) -> None:
+        """Write the rows held in memory to the file, a chunk at a time."""
+        first = self._row_count - len(self._rows)
+        rows, self._rows, self._pending_bytes = self._rows, [], 0
+        chunk, size = [], 0
+        for row in rows:
+            chunk.append(row)
+            size += _row_size(row)
+            if size >= CHUNK_BYTES:
+                self._write_chunk(first, chunk)
+                first += len(chunk)
+                chunk, size = [], 0
+        if chunk:
+            self._write_chunk(first, chunk)
+
+    def _write_chunk(self, first: int, chunk: list) -> None:
+        try:
+            data = pickle.dumps(chunk, protocol=pickle.HIGHEST_PROTOCOL)
+        except TypeError:
+            data = self._dumps_by_reference(chunk)
+        if self._cipher is not None:
+            nonce = os.urandom(_NONCE_BYTES)
+            data = nonce + self._cipher.encrypt(nonce, data, None)
+        reserve_disk(len(data), self.config.max_bytes)
+        offset = self._file.seek(0, os.SEEK_END)
+        self._file.write(data)
+        self._chunks.append((first, offset, len(data)))
+        self._chunk_starts.append(first)
+        self.bytes_written += len(data)
+        get_stats().record_temp_file(len(data))
+
+    def _read_chunk(self, index: int) -> list[Any]:
+        if self._cached is not None and self._cached[0] == index:
+            return self._cached[1]
+        _, offset, length = self._chunks[index]
+        self._file.seek(offset)
+        data = self._file.read(length)
+        if self._cipher is not None:
+            data = self._cipher.decrypt(data[:_NONCE_BYTES], data[_NONCE_BYTES:], None)
+        # The file is private to this process (unlinked, 0600, optionally authenticated)
+        unpickler = pickle.Unpickler(io.BytesIO(data))
+        unpickler.persistent_load = self._references.__getitem__
+        chunk = unpickler.load()
+        self._cached = (index, chunk)
+        return chunk
+
+    def _dumps_by_reference(self, chunk: list) -> bytes:
+        """Pickle a chunk holding values that cannot be pickled, such as LargeValues."""
+
+        def persistent_id(value):
+            if not getattr(value, "spooled_by_reference", False):
+                return None
+            self._references.append(value)
+            return len(self._references) - 1
+
+        buffer = io.BytesIO()
+        pickler = pickle.Pickler(buffer, protocol=pickle.HIGHEST_PROTOCOL)
+        pickler.persistent_id = persistent_id
+        pickler.dump(chunk)
+        return buffer.getvalue()
@@ -0,0 +1,338 @@
+"""
+DATE, TIME, TIMETZ and INTERVAL Values
+
+Result values of the date/time types arrive from the executor in several
+forms and are sent to clients in PostgreSQL's text and binary forms:
+
+- date: datetime.date, ISO text, the executor's PostgreSQL day numbers
+  (days since 2000-01-01, see iris_executor._convert_iris_horolog_date_to_pg,
+  also as digit text) or IRIS $HOROLOG text ('67000,3600', days since
+  1840-12-31 and seconds since midnight).
+  Binary: int4 days since 2000-01-01.
+- time: datetime.time, timedelta, 'HH:MM:SS[.ffffff]' text or IRIS logical
+  %Time (seconds since midnight). Binary: int8 microseconds since midnight.
+- timetz: time text with a UTC offset ('12:00:00+02') or an aware
+  datetime.time; values without an offset are UTC. Binary: int8 microseconds
+  and int4 zone in seconds *west* of UTC.
+- interval: PostgreSQL interval text ('1 day 02:03:04', '1 year 2 mons',
+  '-3 hours ago', ISO 8601 'P1DT2H'), a timedelta or a number of seconds.
+  Binary: int8 microseconds, int4 days, int4 months.
+
+Text output follows PostgreSQL's ISO DateStyle and postgres IntervalStyle,
+with fractional seconds only when non-zero.
+"""
+
+import datetime
+import re
+import struct
+from decimal import Decimal
+
+from .bind_params import interval_text
+from .timezone_support import format_utc_offset
+
+HOROLOG_BASE = datetime.date(1840, 12, 31)
+PG_EPOCH_DATE = datetime.date(2000, 1, 1)
+
+TEMPORAL_TYPES = frozenset({1082, 1083, 1186, 1266})
+
+_HOROLOG = re.compile(r"^(\d+),(\d+)(?:\.\d+)?$")
+_TIME_WITH_OFFSET = re.compile(
+    r"^(?P<time>\d{1,2}:\d{2}(?::\d{2}(?:\.\d+)?)?)"
+    r"\s*(?P<offset>[+-]\d{1,2}(?::?\d{2}){0,2})?$"
+)
+
+_MICROS_PER_SECOND = 1_000_000
+_MICROS_PER_DAY = 86_400 * _MICROS_PER_SECOND
+
+# Interval units → (months, days, microseconds) per unit
+_INTERVAL_UNITS: dict[str, tuple[int, int, int]] = {}
+for _names, _amount in (
+    (("millennium", "millennia", "millenniums", "mil", "mils"), (12_000, 0, 0)),
+    (("century", "centuries", "cent", "c"), (1_200, 0, 0)),
+    (("decade", "decades", "dec", "decs"), (120, 0, 0)),
+    (("year", "years", "yr", "yrs", "y"), (12, 0, 0)),
+    (("month", "months", "mon", "mons"), (1, 0, 0)),
+    (("week", "weeks", "w"), (0, 7, 0)),
+    (("day", "days", "d"), (0, 1, 0)),
+    (("hour", "hours", "hr", "hrs", "h"), (0, 0, 3600 * _MICROS_PER_SECOND)),
+    (("minute", "minutes", "min", "mins", "m"), (0, 0, 60 * _MICROS_PER_SECOND)),
+    (("second", "seconds", "sec", "secs", "s"), (0, 0, _MICROS_PER_SECOND)),
+    (("millisecond", "milliseconds", "msec", "msecs", "ms"), (0, 0, 1_000)),
+    (("microsecond", "microseconds", "usec", "usecs", "us"), (0, 0, 1)),
+):
+    for _name in _names:
+        _INTERVAL_UNITS[_name] = _amount
+
+_INTERVAL_TOKEN = re.compile(
+    r"\s*(?:(?P<clock>[+-]?\d+:\d{1,2}(?::\d{1,2}(?:\.\d+)?)?)"
+    r"|(?P<number>[+-]?(?:\d+(?:\.\d*)?|\.\d+))\s*(?P<unit>[a-z]+)?"
+    r"|(?P<ago>ago)\b|(?P<at>@))",
+    re.IGNORECASE,
+)
+_ISO_INTERVAL = re.compile(
+    r"^(?P<sign>-)?P(?:(?P<years>[\d.]+)Y)?(?:(?P<months>[\d.]+)M)?(?:(?P<weeks>[\d.]+)W)?"
+    r"(?:(?P<days>[\d.]+)D)?(?:T(?:(?P<hours>[\d.]+)H)?(?:(?P<minutes>[\d.]+)M)?"
+    r"(?:(?P<seconds>[\d.]+)S)?)?$",
+    re.IGNORECASE,
+)
+
+
+# ----- date -----
+
+
+def horolog_to_date(days: int) -> datetime.date:
+    """date of an IRIS $HOROLOG day number (days since 1840-12-31)."""
+    return HOROLOG_BASE + datetime.timedelta(days=days)
+
+
+def date_value(value) -> datetime.date:
+    """
+    datetime.date of a stored date.
+
+    Raises:
+        ValueError: value cannot be read as a date
+    """
+    if isinstance(value, datetime.datetime):
+        return value.date()
+    if isinstance(value, datetime.date):
+        return value
+    if isinstance(value, int) and not isinstance(value, bool):
+        return PG_EPOCH_DATE + datetime.timedelta(days=value)  # The executor's day numbers
+    if isinstance(value, str):
+        text = value.strip()
+        horolog = _HOROLOG.match(text)
+        if horolog:
+            return horolog_to_date(int(horolog.group(1)))
+        if text.lstrip("-").isdigit():
+            return date_value(int(text))
+        return datetime.date.fromisoformat(text[:10])
+    raise ValueError(f"not a date: {value!r}")
+
+
+def format_date(value) -> str:
+    """PostgreSQL ISO text of a date ('2024-03-10')."""
+    return date_value(value).isoformat()
+
+
+# ----- time and timetz -----
+
+
+def _parse_time(text: str) -> datetime.time:
+    hours, minutes, *rest = text.split(":")
+    seconds = Decimal(rest[0]) if rest else Decimal(0)
+    micros = int(seconds * _MICROS_PER_SECOND)
+    seconds, fraction = divmod(micros, _MICROS_PER_SECOND)
+    return datetime.time(int(hours), int(minutes), seconds, fraction)
+
+
+def _parse_offset(text: str) -> int:
+    """Seconds east of UTC of an offset like '+02', '-05:30' or '+0530'."""
+    sign = -1 if text[0] == "-" else 1
+    digits = text[1:].replace(":", "")
+    if len(digits) <= 2:
+        digits = digits.zfill(2)
+    hours, minutes, seconds = int(digits[:2]), int(digits[2:4] or 0), int(digits[4:6] or 0)
+    return sign * (hours * 3600 + minutes * 60 + seconds)
+
+
+def time_value(value) -> datetime.time:
+    """
+    Naive datetime.time of a stored time (an offset in text is ignored).
+
+    Raises:
+        ValueError: value cannot be read as a time
+    """
+    if isinstance(value, datetime.datetime):
+        return value.time()
+    if isinstance(value, datetime.time):
+        return value.replace(tzinfo=None)
+    if isinstance(value, datetime.timedelta):
+        return (datetime.datetime.min + value).time()
+    if isinstance(value, int | float | Decimal) and not isinstance(value, bool):
+        micros = int(Decimal(str(value)) * _MICROS_PER_SECOND)  # Logical %Time: seconds
+        if not 0 <= micros < _MICROS_PER_DAY:
+            raise ValueError(f"time out of range: {value}")
+        return (datetime.datetime.min + datetime.timedelta(microseconds=micros)).time()
+    if isinstance(value, str):
+        match = _TIME_WITH_OFFSET.match(value.strip())
+        if match:
+            return _parse_time(match.group("time"))
+    raise ValueError(f"not a time: {value!r}")
+
+
+def _time_micros(moment: datetime.time) -> int:
+    seconds = (moment.hour * 60 + moment.minute) * 60 + moment.second
+    return seconds * _MICROS_PER_SECOND + moment.microsecond
+
+
+def _format_time(moment: datetime.time) -> str:
+    text = moment.strftime("%H:%M:%S")
+    if moment.microsecond:
+        text += f".{moment.microsecond:06d}".rstrip("0")
+    return text
+
+
+def format_time(value) -> str:
+    """PostgreSQL text of a time ('12:30:00', '12:30:00.5')."""
+    return _format_time(time_value(value))
+
+
+def timetz_value(value) -> tuple[datetime.time, int]:
+    """
+    (naive time, seconds east of UTC) of a stored timetz; no offset means UTC.
+
+    Raises:
+        ValueError: value cannot be read as a time
+    """
+    if isinstance(value, datetime.time) and value.tzinfo is not None:
+        offset = value.utcoffset()
+        return value.replace(tzinfo=None), int(offset.total_seconds()) if offset else 0
+    if isinstance(value, str):
+        match = _TIME_WITH_OFFSET.match(value.strip())
+        if match:
+            offset = match.group("offset")
+            return _parse_time(match.group("time")), _parse_offset(offset) if offset else 0
+    return time_value(value), 0
+
+
+def format_timetz(value) -> str:
+    """PostgreSQL text of a timetz ('12:30:00+02')."""
+    moment, offset = timetz_value(value)
+    return _format_time(moment) + format_utc_offset(datetime.timedelta(seconds=offset))
+
+
+# ----- interval -----
+
+
+def _add_units(total: list, count: Decimal, unit: tuple[int, int, int]) -> None:
+    """Add count units to total [months, days, micros], cascading fractions down."""
+    months, days, micros = unit
+    if months:
+        whole = int(count * months)
+        total[0] += whole
+        count_days = (count * months - whole) * 30
+        total[1] += int(count_days)
+        total[2] += int((count_days - int(count_days)) * _MICROS_PER_DAY)
+    elif days:
+        whole = int(count * days)
+        total[1] += whole
+        total[2] += int((count * days - whole) * _MICROS_PER_DAY)
+    else:
+        total[2] += int(count * micros)
+
+
+def parse_interval(text: str) -> tuple[int, int, int]:
+    """
+    (months, days, microseconds) of PostgreSQL interval input: unit amounts
+    ('1 year 2 mons', '1.5 days'), a clock ('02:03:04'), both ('1 day
+    02:03:04'), 'ago' and '@', or ISO 8601 ('P1Y2M3DT4H5M6S'). A bare number
+    is seconds.
+
+    Raises:
+        ValueError: not an interval
+    """
+    source = text.strip()
+    iso = _ISO_INTERVAL.match(source)
+    if iso and source.upper() != "P":
+        total = [0, 0, 0]
+        for group, unit in (
+            ("years", "year"), ("months", "month"), ("weeks", "week"), ("days", "day"),
+            ("hours", "hour"), ("minutes", "minute"), ("seconds", "second"),
+        ):
+            if iso.group(group):
+                _add_units(total, Decimal(iso.group(group)), _INTERVAL_UNITS[unit])
+        sign = -1 if iso.group("sign") else 1
+        return total[0] * sign, total[1] * sign, total[2] * sign
+
+    total, position, negate, seen = [0, 0, 0], 0, False, False
+    while position < len(source):
+        match = _INTERVAL_TOKEN.match(source, position)
+        if not match or match.end() == position:
+            raise ValueError(f'invalid input syntax for type interval: "{text}"')
+        position = match.end()
+        if match.group("at"):
+            continue
+        if match.group("ago"):
+            negate = True
+            continue
+        seen = True
+        if match.group("clock"):
+            clock = match.group("clock")
+            sign = -1 if clock.startswith("-") else 1
+            hours, minutes, *rest = clock.lstrip("+-").split(":")
+            seconds = Decimal(rest[0]) if rest else Decimal(0)
+            total[2] += sign * (
+                (int(hours) * 3600 + int(minutes) * 60) * _MICROS_PER_SECOND
+                + int(seconds * _MICROS_PER_SECOND)
+            )
+            continue
+        unit = (match.group("unit") or "second").lower()
+        if unit not in _INTERVAL_UNITS:
+            raise ValueError(f'invalid input syntax for type interval: "{text}"')
+        _add_units(total, Decimal(match.group("number")), _INTERVAL_UNITS[unit])
+    if not seen:
+        raise ValueError(f'invalid input syntax for type interval: "{text}"')
+    if negate:
+        total = [-part for part in total]
+    return total[0], total[1], total[2]
+
+
+def interval_value(value) -> tuple[int, int, int]:
+    """
+    (months, days, microseconds) of a stored interval.
+
+    Raises:
+        ValueError: value cannot be read as an interval
+    """
+    if isinstance(value, tuple) and len(value) == 3:
+        return value
+    if isinstance(value, datetime.timedelta):
+        return 0, value.days, value.seconds * _MICROS_PER_SECOND + value.microseconds
+    if isinstance(value, int | float | Decimal) and not isinstance(value, bool):
+        return 0, 0, int(Decimal(str(value)) * _MICROS_PER_SECOND)
+    if isinstance(value, str):
+        return parse_interval(value)
+    raise ValueError(f"not an interval: {value!r}")
+
+
+def format_interval(value) -> str:
+    """PostgreSQL (postgres IntervalStyle) text of an interval ('1 day 02:03:04')."""
+    months, days, micros = interval_value(value)
+    return interval_text(micros, days, months)
+
+
+# ----- text and binary forms by type -----
+
+
+def format_temporal(value, type_oid: int) -> str:
+    """
+    Text form of a non-NULL date, time, timetz or interval value.
+
+    Raises:
+        ValueError: value cannot be read as the type
+    """
+    if type_oid == 1082:
+        return format_date(value)
+    if type_oid == 1083:
+        return format_time(value)
+    if type_oid == 1266:
+        return format_timetz(value)
+    return format_interval(value)
+
+
+def encode_temporal(value, type_oid: int) -> bytes:
+    """
+    Binary form of a non-NULL date, time, timetz or interval value.
+
+    Raises:
+        ValueError: value cannot be read as the type
+    """
+    if type_oid == 1082:
+        return struct.pack("!i", (date_value(value) - PG_EPOCH_DATE).days)
+    if type_oid == 1083:
+        return struct.pack("!q", _time_micros(time_value(value)))
+    if type_oid == 1266:
+        moment, offset = timetz_value(value)
+        return struct.pack("!qi", _time_micros(moment), -offset)
+    months, days, micros = interval_value(value)
+    return struct.pack("!qii", micros, days, months)
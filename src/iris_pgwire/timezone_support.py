@@ -0,0 +1,262 @@
+"""
+Session TimeZone Support (IANA tz database)
+
+IRIS stores timestamps without zone information. The server treats stored
+timestamptz values as UTC and converts them to the session TimeZone when they
+are sent to the client, the same contract PostgreSQL uses internally.
+
+Zone resolution uses the IANA database through zoneinfo, so region names
+('America/New_York') follow DST transitions. zoneinfo reads the system tz
+database (/usr/share/zoneinfo) or the tzdata package when installed.
+
+Accepted TimeZone spellings:
+- IANA names, case-insensitive ('europe/berlin' → 'Europe/Berlin')
+- 'UTC', 'GMT', 'Z'
+- Numeric hours, SQL convention: '-8' is 8 hours west of UTC (shown as '<-08>+08')
+- POSIX offsets: '+05:30' is 5h30 *west* of UTC (PostgreSQL follows POSIX sign rules)
+- SET TIME ZONE INTERVAL '+05:30' HOUR TO MINUTE: SQL convention, 5h30 *east* of UTC
+
+Stored values arrive as datetime objects, ISO text (%TimeStamp) or, for
+%PosixTime columns read in logical mode, the encoded 64-bit integer
+(microseconds since 1970-01-01 UTC offset by 2**60; earlier instants are
+below -2**60). Text output follows PostgreSQL's ISO DateStyle and binary
+output is microseconds since 2000-01-01 (integer_datetimes = on).
+"""
+
+import datetime
+import re
+import zoneinfo
+from functools import lru_cache
+
+PG_EPOCH_UTC = datetime.datetime(2000, 1, 1, tzinfo=datetime.UTC)
+_UNIX_EPOCH = datetime.datetime(1970, 1, 1)
+
+# %PosixTime logical values: Unix microseconds moved away from zero by 2**60
+POSIX_TIME_OFFSET = 1 << 60
+
+_UTC_ALIASES = {"utc": "UTC", "gmt": "GMT", "z": "UTC", "zulu": "UTC", "etc/utc": "Etc/UTC"}
+_NUMERIC_HOURS = re.compile(r"^[+-]?\d+(\.\d+)?$")
+_POSIX_OFFSET = re.compile(r"^([+-])?(\d{1,2})(?::?(\d{2}))?$")
+_BRACKETED_OFFSET = re.compile(r"^<[^>]*>([+-]?)(\d{1,2})(?::(\d{2}))?$")
+_INTERVAL_ZONE = re.compile(
+    r"^INTERVAL\s+'([+-]?)(\d{1,2})(?::(\d{2}))?'(?:\s+HOUR(?:\s+TO\s+MINUTE)?)?$",
+    re.IGNORECASE,
+)
+
+
+@lru_cache(maxsize=1)
+def _zone_names_by_lower() -> dict[str, str]:
+    return {name.lower(): name for name in zoneinfo.available_timezones()}
+
+
+def _offset_zone_name(utc_offset_seconds: int) -> str:
+    """PostgreSQL display name for a fixed offset zone ('<-08>+08' for UTC-8)."""
+    absolute = abs(utc_offset_seconds)
+    text = f"{absolute // 3600:02d}"
+    if absolute % 3600:
+        text += f":{absolute % 3600 // 60:02d}"
+    if utc_offset_seconds < 0:
+        return f"<-{text}>+{text}"
+    return f"<+{text}>-{text}"
+
+
+def normalize_timezone_name(value: str) -> str:
+    """
+    Validate a TimeZone setting and return its canonical display form.
+
+    Raises:
+        ValueError: unknown zone name or malformed offset
+    """
+    name = value.strip()
+    lowered = name.lower()
+
+    if lowered in _UTC_ALIASES:
+        return _UTC_ALIASES[lowered]
+
+    canonical = _zone_names_by_lower().get(lowered)
+    if canonical:
+        return canonical
+
+    if _NUMERIC_HOURS.match(name):
+        # SQL convention: positive hours are east of Greenwich
+        hours = float(name)
+        if abs(hours) > 15:
+            raise ValueError(f"time zone offset out of range: {name}")
+        return _offset_zone_name(round(hours * 3600))
+
+    if _BRACKETED_OFFSET.match(name):
+        resolve_timezone(name)  # Validates the offset part
+        return name
+
+    match = _POSIX_OFFSET.match(name)
+    if match:
+        sign, hours, minutes = match.groups()
+        seconds = int(hours) * 3600 + int(minutes or 0) * 60
+        # POSIX convention: positive offsets are WEST of Greenwich
+        return _offset_zone_name(seconds if sign == "-" else -seconds)
+
+    raise ValueError(f"time zone \"{name}\" not recognized")
+
+
+def interval_timezone_name(value: str) -> str | None:
+    """
+    TimeZone value of SET TIME ZONE INTERVAL '+05:30' HOUR TO MINUTE (east of
+    UTC, as the SQL standard counts), or None when value is not an interval.
+
+    Raises:
+        ValueError: offset out of range
+    """
+    match = _INTERVAL_ZONE.match(value.strip())
+    if not match:
+        return None
+    sign, hours, minutes = match.groups()
+    seconds = int(hours) * 3600 + int(minutes or 0) * 60
+    if seconds > 15 * 3600 or int(minutes or 0) >= 60:
+        raise ValueError(f"invalid interval value for time zone: {value.strip()}")
+    return _offset_zone_name(-seconds if sign == "-" else seconds)
+
+
+@lru_cache(maxsize=256)
+def resolve_timezone(name: str) -> datetime.tzinfo:
+    """
+    Resolve a (normalized) TimeZone value to a tzinfo object.
+
+    Unknown names resolve to UTC - callers validate with normalize_timezone_name first.
+    """
+    lowered = name.strip().lower()
+    if lowered in _UTC_ALIASES or not lowered:
+        return datetime.UTC
+
+    canonical = _zone_names_by_lower().get(lowered)
+    if canonical:
+        return zoneinfo.ZoneInfo(canonical)
+
+    match = _BRACKETED_OFFSET.match(name.strip())
+    if match:
+        # The suffix is a POSIX offset (west-positive)
+        sign, hours, minutes = match.groups()
+        posix_seconds = int(hours) * 3600 + int(minutes or 0) * 60
+        if sign == "-":
+            posix_seconds = -posix_seconds
+        return datetime.timezone(datetime.timedelta(seconds=-posix_seconds))
+
+    try:
+        return resolve_timezone(normalize_timezone_name(name))
+    except ValueError:
+        return datetime.UTC
+
+
+def _parse_timestamp(value: str) -> datetime.datetime:
+    """Parse IRIS/PostgreSQL timestamp text, with or without a UTC offset."""
+    text = value.strip().replace("T", " ")
+    # PostgreSQL short offsets ('+05') → '+05:00' for fromisoformat
+    text = re.sub(r"([+-]\d{2})$", r"\1:00", text)
+    return datetime.datetime.fromisoformat(text)
+
+
+def posix_time_to_datetime(value: int) -> datetime.datetime:
+    """
+    Naive UTC datetime of a %PosixTime logical value.
+
+    Raises:
+        ValueError: value is not an encoded %PosixTime
+    """
+    if value >= POSIX_TIME_OFFSET:
+        micros = value - POSIX_TIME_OFFSET
+    elif value <= -POSIX_TIME_OFFSET:
+        micros = value + POSIX_TIME_OFFSET
+    else:
+        raise ValueError(f"not a %PosixTime value: {value}")
+    return _UNIX_EPOCH + datetime.timedelta(microseconds=micros)
+
+
+def timestamp_value(value) -> datetime.datetime:
+    """
+    datetime of a stored timestamp: a datetime, a date (midnight), a
+    %PosixTime integer or timestamp text.
+
+    Raises:
+        ValueError: value cannot be read as a timestamp
+    """
+    if isinstance(value, datetime.datetime):
+        return value
+    if isinstance(value, datetime.date):
+        return datetime.datetime(value.year, value.month, value.day)
+    if isinstance(value, int) and not isinstance(value, bool):
+        return posix_time_to_datetime(value)
+    if isinstance(value, str):
+        return _parse_timestamp(value)
+    raise ValueError(f"not a timestamp: {value!r}")
+
+
+def _format_wall_time(value: datetime.datetime) -> str:
+    """ISO DateStyle text of value's wall-clock time, fractional seconds only when non-zero."""
+    text = value.strftime("%Y-%m-%d %H:%M:%S")
+    if value.microsecond:
+        text += f".{value.microsecond:06d}".rstrip("0")
+    return text
+
+
+def format_timestamp(value) -> str:
+    """
+    Render a stored timestamp (without time zone) as PostgreSQL does:
+    '2024-03-10 03:30:00', '2024-03-10 03:30:00.25'.
+    """
+    return _format_wall_time(timestamp_value(value).replace(tzinfo=None))
+
+
+def timestamp_to_pg_microseconds(value) -> int:
+    """Binary timestamp: wall-clock microseconds since 2000-01-01 00:00:00."""
+    delta = timestamp_value(value).replace(tzinfo=None) - PG_EPOCH_UTC.replace(tzinfo=None)
+    return (delta.days * 86400 + delta.seconds) * 1_000_000 + delta.microseconds
+
+
+def to_utc(value: datetime.datetime | str, session_tz: datetime.tzinfo) -> datetime.datetime:
+    """
+    Convert a timestamptz input to an aware UTC datetime.
+
+    Naive values are interpreted in the session zone, as PostgreSQL does for
+    timestamptz literals without an explicit offset.
+    """
+    if isinstance(value, str):
+        value = _parse_timestamp(value)
+    if value.tzinfo is None:
+        value = value.replace(tzinfo=session_tz)
+    return value.astimezone(datetime.UTC)
+
+
+def format_utc_offset(offset: datetime.timedelta) -> str:
+    """PostgreSQL text of a UTC offset: '+02', '-05:30', '+00'."""
+    total = int(offset.total_seconds())
+    sign = "+" if total >= 0 else "-"
+    total = abs(total)
+    text = f"{sign}{total // 3600:02d}"
+    if total % 3600:
+        text += f":{total % 3600 // 60:02d}"
+        if total % 60:
+            text += f":{total % 60:02d}"
+    return text
+
+
+def format_timestamptz(value: datetime.datetime | str | int, session_tz: datetime.tzinfo) -> str:
+    """
+    Render a stored timestamptz (naive values are UTC) in the session zone.
+
+    Output matches PostgreSQL ISO DateStyle: '2024-03-10 03:30:00-04',
+    fractional seconds only when non-zero.
+    """
+    value = timestamp_value(value)
+    if value.tzinfo is None:
+        value = value.replace(tzinfo=datetime.UTC)
+
+    local = value.astimezone(session_tz)
+    return _format_wall_time(local) + format_utc_offset(local.utcoffset())
+
+
+def timestamptz_to_pg_microseconds(value: datetime.datetime | str | int) -> int:
+    """Binary timestamptz: microseconds since 2000-01-01 00:00:00 UTC (naive = UTC)."""
+    value = timestamp_value(value)
+    if value.tzinfo is None:
+        value = value.replace(tzinfo=datetime.UTC)
+    delta = value - PG_EPOCH_UTC
+    return (delta.days * 86400 + delta.seconds) * 1_000_000 + delta.microseconds
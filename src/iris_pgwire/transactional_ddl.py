@@ -0,0 +1,64 @@
+"""
+DDL Inside Transactions
+
+PostgreSQL DDL is transactional: a migration that fails halfway is rolled back
+as a whole. IRIS applies DDL as it runs (a table is a compiled class), so a
+ROLLBACK after CREATE / ALTER / DROP leaves the schema change in place. Rather
+than let migration frameworks half-apply migrations without noticing, the
+bridge says so:
+
+    SHOW iris.transactional_ddl           -- always off; also sent as ParameterStatus
+    SET iris.ddl_in_transaction = warn | error | allow
+
+warn (default)  DDL inside BEGIN ... COMMIT runs and is followed by a WARNING
+                that a rollback will not undo it
+error           DDL inside a transaction block fails with 25001
+                (active_sql_transaction), the way PostgreSQL rejects its own
+                non-transactional commands, so tools can run it outside one
+allow           DDL runs without comment
+
+Outside explicit transactions nothing changes. Like other parameters, the
+mode can be set per role in the role settings file or with
+options=-c iris.ddl_in_transaction=error.
+"""
+
+import re
+from typing import Any
+
+TRANSACTIONAL_DDL_PARAMETER = "iris.transactional_ddl"
+DDL_IN_TRANSACTION_PARAMETER = "iris.ddl_in_transaction"
+DDL_IN_TRANSACTION_MODES = ("warn", "error", "allow")
+
+_DDL = re.compile(
+    r"^\s*(?P<verb>CREATE|ALTER|DROP)\s+(?:OR\s+REPLACE\s+)?"
+    r"(?:(?:GLOBAL|LOCAL|TEMP|TEMPORARY|UNLOGGED|UNIQUE|MATERIALIZED)\s+)*(?P<object>\w+)",
+    re.IGNORECASE,
+)
+
+
+def ddl_command(sql: str) -> str | None:
+    """Command name of a DDL statement (CREATE TABLE, DROP INDEX, ...), None for others."""
+    match = _DDL.match(sql)
+    if not match:
+        return None
+    return f"{match.group('verb')} {match.group('object')}".upper()
+
+
+def ddl_warning(command: str) -> str:
+    return (
+        f"IRIS DDL is not transactional: {command} takes effect immediately "
+        "and is not undone if the transaction rolls back"
+    )
+
+
+def ddl_in_transaction_error(command: str) -> dict[str, Any]:
+    """Result rejecting DDL inside a transaction block (iris.ddl_in_transaction = error)."""
+    return {
+        "success": False,
+        "error": f"{command} cannot run inside a transaction block",
+        "sqlstate": "25001",
+        "condition_name": "active_sql_transaction",
+        "rows": [],
+        "columns": [],
+        "row_count": 0,
+    }
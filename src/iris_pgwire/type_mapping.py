@@ -47,6 +47,7 @@ DEFAULT_TYPE_MAPPINGS: dict[str, tuple[str, str, int]] = {
     'TINYINT': ('smallint', 'int2', 21),
     'NUMERIC': ('numeric', 'numeric', 1700),
     'DECIMAL': ('numeric', 'numeric', 1700),
+    'MONEY': ('money', 'money', 790),  # IRIS %Library.Currency
 
     # Floating point
     'DOUBLE': ('double precision', 'float8', 701),
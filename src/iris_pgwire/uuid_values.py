@@ -0,0 +1,89 @@
+"""
+UUID Columns and Parameters
+
+IRIS has no uuid type. UUID columns in CREATE TABLE / ALTER TABLE are
+created as PGWIRE_UUID_COLUMN_TYPE (ddl_translator.py):
+
+- UNIQUEIDENTIFIER (default): a %Library.UniqueIdentifier property, which
+  IRIS describes as SQL_GUID
+- CHAR(36), or another character type with a length: for schemas whose UUID
+  keys are already stored as text. Result columns of exactly that type and
+  length are reported as uuid (column_types.py), so choose a length no other
+  column uses.
+
+Either way result columns are sent as uuid (OID 2950): in text as the
+lowercase hyphenated form, in binary as the 16 bytes. Bind parameters
+declared uuid are bound as that same text form, whether sent in binary or
+as any text PostgreSQL accepts ('{A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11}',
+without hyphens, upper case), so ORMs with UUID primary keys need no casts.
+Text that is not a UUID fails Bind with 22P02, as uuid input does.
+"""
+
+import os
+import re
+import uuid
+
+import structlog
+
+logger = structlog.get_logger()
+
+UUID_OID = 2950
+DEFAULT_UUID_COLUMN_TYPE = "UNIQUEIDENTIFIER"
+
+_UNIQUEIDENTIFIER = frozenset({"UNIQUEIDENTIFIER", "%LIBRARY.UNIQUEIDENTIFIER"})
+_CHARACTER_TYPE = re.compile(r"^(CHAR|VARCHAR)\s*\(\s*(\d+)\s*\)$", re.IGNORECASE)
+
+
+class InvalidUUID(ValueError):
+    """A uuid Bind parameter that is not a UUID (SQLSTATE 22P02)."""
+
+    sqlstate = "22P02"
+    condition_name = "invalid_text_representation"
+
+
+def uuid_column_type(value: str | None = None) -> str:
+    """
+    IRIS type of UUID columns, PGWIRE_UUID_COLUMN_TYPE: UNIQUEIDENTIFIER or
+    CHAR(n) / VARCHAR(n) with n >= 32 (invalid values fall back to the default).
+    """
+    if value is None:
+        value = os.getenv("PGWIRE_UUID_COLUMN_TYPE", DEFAULT_UUID_COLUMN_TYPE)
+    text = value.strip()
+    if text.upper() in _UNIQUEIDENTIFIER:
+        return DEFAULT_UUID_COLUMN_TYPE
+    match = _CHARACTER_TYPE.match(text)
+    if match and int(match.group(2)) >= 32:
+        return f"{match.group(1).upper()}({int(match.group(2))})"
+    logger.warning("Ignoring invalid PGWIRE_UUID_COLUMN_TYPE", value=value)
+    return DEFAULT_UUID_COLUMN_TYPE
+
+
+def is_uuid_column(type_name: str, length: int | None) -> bool:
+    """Whether a character result column is of the configured UUID column type."""
+    column_type = uuid_column_type()
+    if column_type == DEFAULT_UUID_COLUMN_TYPE or length is None:
+        return False
+    return column_type == f"{type_name.upper()}({length})"
+
+
+def parse_uuid(text: str) -> str:
+    """
+    Lowercase hyphenated form of uuid input text.
+
+    Raises:
+        InvalidUUID: text is not a UUID
+    """
+    try:
+        return str(uuid.UUID(text.strip()))
+    except ValueError:
+        raise InvalidUUID(f'invalid input syntax for type uuid: "{text}"') from None
+
+
+def format_uuid(value) -> str:
+    """PostgreSQL text of a stored UUID (values that are not UUIDs unchanged)."""
+    if isinstance(value, uuid.UUID):
+        return str(value)
+    try:
+        return str(uuid.UUID(str(value).strip()))
+    except ValueError:
+        return str(value)
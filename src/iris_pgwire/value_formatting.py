@@ -0,0 +1,193 @@
+"""
+Text-Format Rendering Controlled by Session GUCs
+
+PostgreSQL renders some types differently depending on session settings:
+- bytea_output: 'hex' (\\x0a0b...) or 'escape' (octal escapes for non-printables);
+  parse_bytea() reads either form back, as bytea input does whatever the setting
+- lc_monetary: currency symbol, separators and precision of money values
+
+lc_numeric is accepted and reported by SHOW, but like PostgreSQL it does not
+change the text output of numeric/float columns - it only governs locale-aware
+to_char() patterns, which are evaluated by IRIS. numeric values are rendered
+with every digit and no exponent, padded to the column's declared scale.
+
+Locale conventions are built in rather than read from the host C library so that
+rendering is identical regardless of which locales the server image ships.
+"""
+
+from dataclasses import dataclass
+from decimal import ROUND_HALF_EVEN, Decimal, InvalidOperation
+from typing import Any
+
+from .copy_binary import numeric_value
+
+
+class InvalidBytea(ValueError):
+    """bytea text that is neither valid hex nor escape format (SQLSTATE 22P02)."""
+
+    sqlstate = "22P02"
+    condition_name = "invalid_text_representation"
+
+    def __init__(self):
+        super().__init__("invalid input syntax for type bytea")
+
+
+def format_bytea(value: bytes | bytearray | memoryview, output: str = "hex") -> str:
+    """
+    Render a bytea value in PostgreSQL text format.
+
+    Args:
+        value: Raw bytes
+        output: bytea_output setting ('hex' or 'escape')
+
+    Returns:
+        Text representation as PostgreSQL would send it
+    """
+    data = bytes(value)
+    if output == "escape":
+        parts = []
+        for byte in data:
+            if byte == 0x5C:  # backslash
+                parts.append("\\\\")
+            elif 0x20 <= byte < 0x7F:
+                parts.append(chr(byte))
+            else:
+                parts.append(f"\\{byte:03o}")
+        return "".join(parts)
+    return "\\x" + data.hex()
+
+
+def parse_bytea(text: str) -> bytes:
+    """
+    Read a bytea value from PostgreSQL text format (hex or escape).
+
+    Args:
+        text: '\\x' followed by hex digit pairs (whitespace between pairs is
+            ignored), or escape format: characters as their UTF-8 bytes, '\\\\'
+            for a backslash and '\\nnn' octal escapes
+
+    Raises:
+        InvalidBytea: invalid or an odd number of hex digits, or invalid
+            backslash sequences
+    """
+    if text.startswith("\\x"):
+        try:
+            return bytes.fromhex("".join(text[2:].split()))
+        except ValueError:
+            raise InvalidBytea() from None
+
+    data = bytearray()
+    position = 0
+    while position < len(text):
+        backslash = text.find("\\", position)
+        if backslash < 0:
+            data += text[position:].encode("utf-8")
+            break
+        data += text[position:backslash].encode("utf-8")
+        if text[backslash + 1 : backslash + 2] == "\\":
+            data.append(0x5C)
+            position = backslash + 2
+            continue
+        octal = text[backslash + 1 : backslash + 4]
+        if len(octal) != 3 or not all(c in "01234567" for c in octal) or octal[0] > "3":
+            raise InvalidBytea()
+        data.append(int(octal, 8))
+        position = backslash + 4
+    return bytes(data)
+
+
+@dataclass(frozen=True)
+class MonetaryConventions:
+    """Subset of localeconv() used to render money values."""
+
+    symbol: str
+    decimal_point: str
+    thousands_sep: str
+    frac_digits: int
+    symbol_precedes: bool  # True: "$1.00", False: "1,00 €"
+    symbol_space: bool  # Space between symbol and amount
+
+
+_C_MONETARY = MonetaryConventions("$", ".", ",", 2, True, False)
+
+# Language/territory prefix → conventions (encoding/modifier suffixes are ignored)
+MONETARY_CONVENTIONS: dict[str, MonetaryConventions] = {
+    "c": _C_MONETARY,
+    "posix": _C_MONETARY,
+    "en_us": _C_MONETARY,
+    "en_ca": _C_MONETARY,
+    "en_au": _C_MONETARY,
+    "en_gb": MonetaryConventions("£", ".", ",", 2, True, False),
+    "de_de": MonetaryConventions("€", ",", ".", 2, False, True),
+    "nl_nl": MonetaryConventions("€", ",", ".", 2, True, True),
+    "fr_fr": MonetaryConventions("€", ",", " ", 2, False, True),
+    "es_es": MonetaryConventions("€", ",", ".", 2, False, True),
+    "it_it": MonetaryConventions("€", ",", ".", 2, False, True),
+    "de_ch": MonetaryConventions("CHF", ".", "'", 2, True, True),
+    "ja_jp": MonetaryConventions("￥", ".", ",", 0, True, False),
+}
+
+
+def monetary_conventions(lc_monetary: str) -> MonetaryConventions:
+    """Resolve conventions for an lc_monetary value (unknown locales behave like C)."""
+    key = (lc_monetary or "C").split(".")[0].split("@")[0].lower()
+    return MONETARY_CONVENTIONS.get(key, _C_MONETARY)
+
+
+def _group_digits(digits: str, separator: str) -> str:
+    groups = []
+    while len(digits) > 3:
+        groups.insert(0, digits[-3:])
+        digits = digits[:-3]
+    groups.insert(0, digits)
+    return separator.join(groups)
+
+
+def format_money(value: Any, lc_monetary: str = "C") -> str:
+    """
+    Render a money value as PostgreSQL cash_out() does for the given locale.
+
+    Examples (C locale): 1234.5 → "$1,234.50", -3 → "-$3.00"
+    """
+    try:
+        amount = Decimal(str(value))
+    except InvalidOperation:
+        return str(value)
+
+    conv = monetary_conventions(lc_monetary)
+    quantum = Decimal(1).scaleb(-conv.frac_digits)
+    amount = amount.quantize(quantum, rounding=ROUND_HALF_EVEN)
+
+    negative = amount < 0
+    text = f"{abs(amount):f}"
+    int_part, _, frac_part = text.partition(".")
+
+    number = _group_digits(int_part, conv.thousands_sep)
+    if conv.frac_digits:
+        number += conv.decimal_point + frac_part
+
+    space = " " if conv.symbol_space else ""
+    if conv.symbol_precedes:
+        rendered = f"{conv.symbol}{space}{number}"
+    else:
+        rendered = f"{number}{space}{conv.symbol}"
+
+    return f"-{rendered}" if negative else rendered
+
+
+def format_numeric(value: Any, scale: int | None = None) -> str:
+    """
+    Render a numeric value as PostgreSQL numeric_out() does.
+
+    Examples: Decimal("1E+3") → "1000", 10.5 with scale 2 → "10.50",
+    Decimal("-Infinity") → "-Infinity"
+    """
+    try:
+        number = numeric_value(value, scale)
+    except InvalidOperation:
+        return str(value)
+    if number.is_nan():
+        return "NaN"
+    if number.is_infinite():
+        return "-Infinity" if number.is_signed() else "Infinity"
+    return format(number, "f")
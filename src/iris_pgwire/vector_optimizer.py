@@ -327,17 +327,14 @@ class VectorQueryOptimizer:
         - column <=> '[1,2,3]' → VECTOR_COSINE(column, TO_VECTOR('[1,2,3]', FLOAT))
         - column <#> '[1,2,3]' → (-VECTOR_DOT_PRODUCT(column, TO_VECTOR('[1,2,3]', FLOAT)))
 
-        REJECTS (Constitutional requirement):
-        - column <-> '[1,2,3]' → NotImplementedError (L2 distance not supported by IRIS)
+        <-> (L2 distance) is rewritten onto VECTOR_DOT_PRODUCT during
+        normalization (sql_translator/vector_translator.py).
 
         Args:
             sql: SQL with pgvector operators
 
         Returns:
             SQL with IRIS vector functions
-
-        Raises:
-            NotImplementedError: If L2 distance operator (<->) is found in query
         """
         print("\n🔍🔍🔍 _REWRITE_PGVECTOR_OPERATORS CALLED", flush=True)
         print(f"  Input SQL: {sql[:200]}...", flush=True)
@@ -457,18 +454,6 @@ class VectorQueryOptimizer:
             sql = re.sub(pattern, replace_cosine_distance, sql)
             print(f"  After <=> rewrite: {sql[:200]}...", flush=True)
 
-        # <-> operator (L2 distance) - NOT SUPPORTED BY IRIS
-        # Constitutional requirement: REJECT with NOT IMPLEMENTED error
-        if "<->" in sql:
-            error_msg = (
-                "L2 distance operator (<->) is not supported by IRIS. "
-                "IRIS only supports VECTOR_COSINE (use <=> operator) and VECTOR_DOT_PRODUCT (use <#> operator). "
-                "Please rewrite your query to use one of these supported distance functions."
-            )
-            logger.error(f"❌ L2 distance operator rejected: {error_msg}")
-            print(f"  ❌ REJECTING L2 operator: {error_msg}", flush=True)
-            raise NotImplementedError(error_msg)
-
         # <#> operator (negative inner product) -> -VECTOR_DOT_PRODUCT
         if "<#>" in sql:
             operators_found.append("<#>")
@@ -0,0 +1,241 @@
+"""
+pgvector's vector Type
+
+pgvector clients (pgvector-python for psycopg and asyncpg, LangChain and
+LlamaIndex stores, Npgsql's UseVector) look the vector type up in pg_type,
+register their codecs for its OID, and create VECTOR columns and distance
+queries. IRIS has native VECTOR columns, so vector is emulated on them:
+
+- pg_type lookups of vector (typname = 'vector', to_regtype('vector'),
+  typname = $1 with 'vector') answer one row for the type, OID 16388 in
+  schema public; the bulk type load already lists it
+- CREATE EXTENSION [IF NOT EXISTS] vector succeeds without doing anything
+- vector(n) columns in CREATE TABLE / ALTER TABLE are created as
+  VECTOR(DOUBLE, n) (ddl_translator.py), and VECTOR result columns are
+  described as vector
+- text values are pgvector's [1,2,3]: Bind parameters declared vector are
+  checked (22P02 for anything else) and results get the brackets IRIS's
+  1,2,3 lacks. Binary values are pgvector's: dimensions and an unused word
+  as int16, then each element as float4
+- <->, <=> and <#>, and ::vector casts, are rewritten onto VECTOR_COSINE,
+  VECTOR_DOT_PRODUCT and TO_VECTOR (vector_translator.py)
+
+halfvec, sparsevec and bit vectors have no IRIS counterpart.
+"""
+
+import math
+import re
+import struct
+
+from .column_types import TYPE_LENGTHS
+
+VECTOR_OID = 16388
+
+_CREATE_EXTENSION = re.compile(
+    r"^\s*CREATE\s+EXTENSION\s+(?P<if_not_exists>IF\s+NOT\s+EXISTS\s+)?"
+    r"(?:vector|\"vector\")(?:\s+(?:WITH\s+)?(?:SCHEMA\s+\S+|VERSION\s+\S+|CASCADE))*\s*;?\s*$",
+    re.IGNORECASE,
+)
+_PG_TYPE = re.compile(r"\bpg_type\b", re.IGNORECASE)
+_VECTOR_NAME = re.compile(r"'(?:public\.)?vector'|(?<![\w$.])16388\b", re.IGNORECASE)
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>\d+(?:\.\d*)?)"
+    r"|(?P<op>::|[(),.;])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+# pg_type columns of the vector type: value and column type OID
+_TYPE_COLUMNS: dict[str, tuple] = {
+    "oid": (VECTOR_OID, 26),
+    "typname": ("vector", 19),
+    "typnamespace": (2200, 26),
+    "nspname": ("public", 19),
+    "typtype": ("b", 18),
+    "typcategory": ("U", 18),
+    "typlen": (-1, 21),
+    "typbyval": (False, 16),
+    "typelem": (0, 26),
+    "typarray": (0, 26),
+    "typbasetype": (0, 26),
+    "typrelid": (0, 26),
+    "typdelim": (",", 18),
+    "typnotnull": (False, 16),
+    "typtypmod": (-1, 23),
+}
+
+
+class InvalidVector(ValueError):
+    """A vector Bind parameter that is not a vector (SQLSTATE 22P02)."""
+
+    sqlstate = "22P02"
+    condition_name = "invalid_text_representation"
+
+
+def parse_vector(text: str) -> str:
+    """
+    pgvector text ([1,2,3]) of vector input text, with at least one finite
+    element.
+
+    Raises:
+        InvalidVector: text is not a vector
+    """
+    body = text.strip()
+    if body.startswith("[") and body.endswith("]"):
+        try:
+            elements = [float(element) for element in body[1:-1].split(",")]
+        except ValueError:
+            elements = []
+        if elements and all(math.isfinite(element) for element in elements):
+            return "[" + ",".join(_element_text(element) for element in elements) + "]"
+    raise InvalidVector(f'invalid input syntax for type vector: "{text}"')
+
+
+def format_vector(value) -> str:
+    """pgvector text of a stored VECTOR (IRIS's 1,2,3 gets its brackets)."""
+    if isinstance(value, list | tuple):
+        return "[" + ",".join(_element_text(float(element)) for element in value) + "]"
+    text = str(value).strip()
+    return text if text.startswith("[") else f"[{text}]"
+
+
+def decode_vector(data: bytes) -> str:
+    """
+    pgvector text of a binary vector.
+
+    Raises:
+        ValueError: data is not a binary vector
+    """
+    dimensions, _unused = struct.unpack("!HH", data[:4])
+    if dimensions == 0 or len(data) != 4 + 4 * dimensions:
+        raise ValueError("vector length")
+    elements = struct.unpack(f"!{dimensions}f", data[4:])
+    return "[" + ",".join(_element_text(element) for element in elements) + "]"
+
+
+def encode_vector(value) -> bytes:
+    """
+    Binary form of a vector result value.
+
+    Raises:
+        ValueError: value is not a vector
+    """
+    elements = [float(element) for element in format_vector(value)[1:-1].split(",")]
+    return struct.pack(f"!HH{len(elements)}f", len(elements), 0, *elements)
+
+
+def _element_text(element: float) -> str:
+    return str(int(element)) if element.is_integer() else repr(element)
+
+
+def vector_column_type(dimensions: str | int | None = None) -> str:
+    """IRIS type of a vector(n) column: DOUBLE elements, as TO_VECTOR(x, DOUBLE) reads them."""
+    return f"VECTOR(DOUBLE, {int(dimensions)})" if dimensions else "VECTOR(DOUBLE)"
+
+
+def is_create_vector_extension(sql: str) -> bool:
+    """Whether a statement is CREATE EXTENSION vector."""
+    return bool(_CREATE_EXTENSION.match(sql))
+
+
+def create_extension_notices(sql: str) -> list:
+    """The NOTICE of CREATE EXTENSION IF NOT EXISTS vector: the type is always there."""
+    match = _CREATE_EXTENSION.match(sql)
+    if match is None or not match.group("if_not_exists"):
+        return []
+    return [("NOTICE", "42710", 'extension "vector" already exists, skipping')]
+
+
+def references_vector_type(sql: str, params: list | None = None) -> bool:
+    """Whether a pg_type query looks up the vector type, by name or OID."""
+    if not _PG_TYPE.search(sql):
+        return False
+    if _VECTOR_NAME.search(sql):
+        return True
+    return any(
+        isinstance(param, str) and param.strip().lower() in ("vector", "public.vector")
+        for param in params or ()
+    )
+
+
+def _tokens(sql: str) -> list[tuple[str, str]]:
+    return [
+        (match.lastgroup, match.group())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _select_list(tokens) -> list[list]:
+    """Top-level comma-separated items between the first SELECT and its FROM."""
+    start = next(
+        (i + 1 for i, token in enumerate(tokens) if token[1].upper() == "SELECT"), len(tokens)
+    )
+    items, current, depth = [], [], 0
+    for token in tokens[start:]:
+        if token[1] == "(":
+            depth += 1
+        elif token[1] == ")":
+            depth -= 1
+        elif depth == 0 and token[0] == "word" and token[1].upper() == "FROM":
+            break
+        elif depth == 0 and token[1] == ",":
+            items.append(current)
+            current = []
+            continue
+        current.append(token)
+    items.append(current)
+    return [item for item in items if item]
+
+
+def _unquote(name: str) -> str:
+    if name.startswith('"'):
+        return name[1:-1].replace('""', '"')
+    return name.lower()
+
+
+def vector_type_row(sql: str) -> tuple[list[dict], list[tuple]]:
+    """
+    Columns and the one row of a pg_type lookup of vector: each select-list
+    item is the vector type's value of the pg_type column it reads
+    (oid::regtype::text is 'vector'), NULL for columns not listed here.
+    """
+    columns, row = [], []
+    for item in _select_list(_tokens(sql)):
+        alias = None
+        if len(item) >= 2 and item[-2][1].upper() == "AS":
+            alias, item = _unquote(item[-1][1]), item[:-2]
+        elif len(item) >= 2 and item[-1][0] == "word" and item[-2][1] not in (".", "::"):
+            alias, item = _unquote(item[-1][1]), item[:-1]
+        words = [token[1].lower() for token in item if token[0] == "word"]
+        # A column is named after the last column it reads, not after its casts
+        named = [
+            _unquote(token[1])
+            for previous, token in zip([None, *item], item, strict=False)
+            if token[0] == "word" and (previous is None or previous[1] != "::")
+        ]
+        column = next((word for word in words if word in _TYPE_COLUMNS), None)
+        if "regtype" in words:
+            value, type_oid = "vector", 25
+        elif column is not None:
+            value, type_oid = _TYPE_COLUMNS[column]
+        else:
+            value, type_oid = None, 25
+        if words and words[-1] == "text" and "::" in (token[1] for token in item):
+            value, type_oid = None if value is None else str(value), 25
+        columns.append(
+            {
+                "name": alias or (named[-1] if named else "?column?"),
+                "type_oid": type_oid,
+                "type_size": TYPE_LENGTHS.get(type_oid, -1),
+                "type_modifier": -1,
+                "format_code": 0,
+            }
+        )
+        row.append(value)
+    return columns, [tuple(row)]
@@ -0,0 +1,95 @@
+"""
+Workload Tagging and Priority Hints
+
+Statements can be tagged with a workload class so that ETL batches, dashboard
+refreshes and OLTP traffic through the bridge are isolated from each other:
+
+    SET iris.workload = etl                          -- tags every statement of the session
+    /* workload:dashboard */ SELECT ... FROM sales   -- tags one statement (wins over SET)
+
+A workload class can carry:
+- a concurrency cap, enforced by admission control alongside the per-role caps
+  (PGWIRE_WORKLOAD_LIMITS, e.g. "etl=4,dashboard=2")
+- an IRIS process priority (PGWIRE_WORKLOAD_PRIORITIES, e.g. "etl=-5,oltp=5").
+  In external mode each prioritized workload gets its own connection pool whose
+  IRIS processes run at that priority ($SYSTEM.Util.SetPrio); embedded mode
+  shares the server process, so priorities only apply to external connections.
+
+Untagged statements use PGWIRE_DEFAULT_WORKLOAD (empty = no workload class).
+"""
+
+import os
+import re
+from contextvars import ContextVar
+
+import structlog
+
+from .admission import parse_limit_spec
+
+logger = structlog.get_logger()
+
+WORKLOAD_PARAMETER = "iris.workload"
+
+# /* workload:etl */ or /* workload=etl */ anywhere in the statement
+WORKLOAD_HINT = re.compile(r"/\*\s*workload\s*[:=]\s*([A-Za-z_][\w.-]*)\s*\*/", re.IGNORECASE)
+
+_WORKLOAD_NAME = re.compile(r"^[A-Za-z_][\w.-]*$")
+
+_session_workload: ContextVar[str | None] = ContextVar("pgwire_session_workload", default=None)
+
+
+def normalize_workload(value: str) -> str:
+    """Validate an iris.workload value (empty clears the tag)."""
+    value = value.strip().lower()
+    if value and not _WORKLOAD_NAME.match(value):
+        raise ValueError(f"invalid workload name: {value!r}")
+    return value
+
+
+def set_session_workload(workload: str | None) -> None:
+    """Record the session's iris.workload for the current connection task."""
+    _session_workload.set(workload or None)
+
+
+def extract_workload_hint(sql: str) -> str | None:
+    """Workload named by a /* workload:name */ comment, if any."""
+    match = WORKLOAD_HINT.search(sql)
+    return match.group(1).lower() if match else None
+
+
+def resolve_workload(sql: str) -> str | None:
+    """
+    Workload class of a statement.
+
+    Precedence: statement hint, then the session's iris.workload, then
+    PGWIRE_DEFAULT_WORKLOAD.
+    """
+    return (
+        extract_workload_hint(sql)
+        or _session_workload.get()
+        or os.getenv("PGWIRE_DEFAULT_WORKLOAD", "").strip().lower()
+        or None
+    )
+
+
+def load_workload_priorities(spec: str | None = None) -> dict[str, int]:
+    """PGWIRE_WORKLOAD_PRIORITIES as {workload: IRIS priority delta}."""
+    if spec is None:
+        spec = os.getenv("PGWIRE_WORKLOAD_PRIORITIES", "")
+    return {name.lower(): priority for name, priority in parse_limit_spec(spec).items()}
+
+
+def apply_process_priority(connection, priority: int) -> None:
+    """
+    Set the IRIS process priority of an external connection.
+
+    Failures are logged and ignored - a missing priority must not make the
+    connection unusable.
+    """
+    try:
+        import iris
+
+        iris.createIRIS(connection).classMethodValue("%SYSTEM.Util", "SetPrio", priority)
+        logger.debug("IRIS process priority set", priority=priority)
+    except Exception as e:
+        logger.warning("Could not set IRIS process priority", priority=priority, error=str(e))
@@ -0,0 +1,488 @@
+"""
+Data-Modifying WITH Queries
+
+ORMs and migration tools write several changes as one statement:
+
+    WITH ins AS (INSERT INTO orders (customer) VALUES (?) RETURNING id)
+    INSERT INTO order_events (order_id, kind) SELECT id, 'created' FROM ins
+
+IRIS accepts WITH only in front of a SELECT, and no data-modifying CTE. The
+bridge runs such a statement as a sequence of statements in one transaction
+(the client's, or one it opens and commits itself):
+
+1. each INSERT / UPDATE / DELETE CTE in order, its RETURNING rows kept
+2. the main statement, in which a CTE named as a table (after FROM, JOIN,
+   USING or in a FROM list) is replaced by a derived table of its rows:
+   (SELECT 1 AS id UNION ALL SELECT 2) ins, or a query with its RETURNING
+   columns and no rows when there were none
+
+A CTE body may read the CTEs before it the same way. Plain (SELECT) CTEs stay
+in the WITH of a SELECT main statement and are inlined as derived tables into
+INSERT, UPDATE and DELETE statements, which IRIS does not let WITH precede;
+this applies to WITH queries whose only DML is the main statement, too.
+Parameters (?) are handed to the statement that contains them.
+
+Unlike PostgreSQL, where every part of the statement sees the same snapshot,
+later parts see the changes of earlier ones: a main statement selecting from
+the modified table (rather than from the CTE) reads the new rows. WITH
+RECURSIVE queries are left to IRIS. Describe reports the columns of the main
+statement without running the CTEs (describe_sql).
+"""
+
+import datetime
+import re
+from collections.abc import Callable
+from dataclasses import dataclass, field
+from decimal import Decimal
+from typing import Any
+
+import structlog
+
+from .large_values import LargeValue
+from .temporal_values import format_temporal
+from .timezone_support import format_timestamp
+
+logger = structlog.get_logger()
+
+_TOKEN = re.compile(
+    r"(?P<skip>--[^\n]*|/\*.*?\*/)"
+    r"|(?P<string>'(?:[^']|'')*')"
+    r"|(?P<word>\"(?:[^\"]|\"\")*\"|[%$]?[A-Za-z_][\w$]*)"
+    r"|(?P<number>(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?)"
+    r"|(?P<op>::|<>|!=|[-+*/%(),.?;:=<>\[\]])"
+    r"|(?P<space>\s+)"
+    r"|(?P<other>.)",
+    re.DOTALL,
+)
+
+_DML_VERBS = frozenset({"INSERT", "UPDATE", "DELETE"})
+
+# Words after which a name is a table reference
+_TABLE_CONTEXT = frozenset({"FROM", "JOIN", "USING"})
+
+# Words ending a FROM list, before which ", name" is not a table reference
+_FROM_LIST_END = frozenset(
+    {"SELECT", "WHERE", "ON", "SET", "GROUP", "ORDER", "HAVING", "VALUES", "RETURNING", "BY"}
+)
+
+# Words that end a table reference instead of being its alias
+_CLAUSE_KEYWORDS = frozenset(
+    {
+        "ON", "USING", "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "UNION",
+        "INTERSECT", "EXCEPT", "JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS",
+        "NATURAL", "WINDOW", "FETCH", "FOR", "RETURNING", "SELECT", "SET",
+    }
+)
+
+# Words after the target table of INSERT / UPDATE / DELETE that are not its alias
+_TARGET_CLAUSES = _CLAUSE_KEYWORDS | {"VALUES", "DEFAULT", "OVERRIDING"}
+
+_PLAIN_NAME = re.compile(r"[A-Za-z_][\w$]*")
+
+_WITH_QUERY = re.compile(r"^\s*WITH\b", re.IGNORECASE)
+
+
+def _tokens(sql: str) -> list[tuple[str, str, int, int]]:
+    return [
+        (match.lastgroup, match.group(), match.start(), match.end())
+        for match in _TOKEN.finditer(sql)
+        if match.lastgroup not in ("space", "skip")
+    ]
+
+
+def _closing(tokens, index: int) -> int | None:
+    """Index of the ) closing the parenthesis at tokens[index]."""
+    depth = 0
+    for position in range(index, len(tokens)):
+        text = tokens[position][1]
+        if text in ("(", "["):
+            depth += 1
+        elif text in (")", "]"):
+            depth -= 1
+            if depth == 0:
+                return position
+    return None
+
+
+def _name_key(name: str) -> str:
+    """A name as PostgreSQL compares it: quoted names exactly, others lowercased."""
+    if name.startswith('"'):
+        return name[1:-1].replace('""', '"')
+    return name.lower()
+
+
+def _column_alias(name: str) -> str:
+    if _PLAIN_NAME.fullmatch(name):
+        return name
+    return '"' + name.replace('"', '""') + '"'
+
+
+def sql_literal(value: Any, type_oid: int | None = None) -> str:
+    """A result value as an SQL literal of its column type."""
+    if value is None:
+        return "NULL"
+    if isinstance(value, bool):
+        return "1" if value else "0"
+    if isinstance(value, datetime.datetime) or type_oid in (1114, 1184):
+        return f"CAST('{format_timestamp(value)}' AS TIMESTAMP)"
+    if isinstance(value, datetime.date) or type_oid == 1082:
+        return f"CAST('{format_temporal(value, 1082)}' AS DATE)"
+    if isinstance(value, datetime.time) or type_oid == 1083:
+        return f"CAST('{format_temporal(value, 1083)}' AS TIME)"
+    if isinstance(value, int | float | Decimal):
+        return str(value)
+    if isinstance(value, LargeValue):
+        value = value.read()
+    if isinstance(value, bytes | bytearray | memoryview):
+        value = bytes(value).decode("latin-1")
+    return "'" + str(value).replace("'", "''") + "'"
+
+
+@dataclass
+class CommonTableExpression:
+    name: str  # As written
+    columns: list[str] | None  # Column list as written, if any
+    start: int  # Token span of the body, without its parentheses
+    end: int
+    dml: bool  # Body is an INSERT, UPDATE or DELETE
+
+    @property
+    def key(self) -> str:
+        return _name_key(self.name)
+
+
+@dataclass
+class WritableCTE:
+    sql: str
+    ctes: list[CommonTableExpression]
+    main_start: int  # Token span of the main statement (a trailing ; excluded)
+    main_end: int
+    tokens: list = field(default_factory=list, repr=False)
+    parameter_numbers: dict[int, int] = field(init=False, repr=False)  # Token index → number
+
+    def __post_init__(self):
+        question_marks = [i for i, token in enumerate(self.tokens) if token[1] == "?"]
+        self.parameter_numbers = {index: number for number, index in enumerate(question_marks)}
+
+    @property
+    def main_is_dml(self) -> bool:
+        return self.tokens[self.main_start][1].upper() in _DML_VERBS
+
+    def _is_table_reference(self, index: int) -> bool:
+        tokens = self.tokens
+        if index + 1 < len(tokens) and tokens[index + 1][1] in ("(", "."):
+            return False  # A function call or qualified name
+        previous = tokens[index - 1]
+        if previous[0] == "word" and previous[1].upper() in _TABLE_CONTEXT:
+            return True
+        if previous[1] != ",":
+            return False
+        # FROM a, b: walk back to the FROM of the list
+        depth = 0
+        for position in range(index - 2, -1, -1):
+            text = tokens[position][1]
+            if text == ")":
+                depth += 1
+            elif text == "(":
+                if depth == 0:
+                    return False
+                depth -= 1
+            elif depth == 0 and tokens[position][0] == "word":
+                if text.upper() == "FROM":
+                    return True
+                if text.upper() in _FROM_LIST_END:
+                    return False
+        return False
+
+    def _has_alias(self, index: int) -> bool:
+        following = self.tokens[index + 1] if index + 1 < len(self.tokens) else None
+        return (
+            following is not None
+            and following[0] == "word"
+            and following[1].upper() not in _CLAUSE_KEYWORDS
+        )
+
+    def _render(
+        self,
+        start: int,
+        end: int,
+        visible: list[CommonTableExpression],
+        results: dict[str, dict[str, Any] | None],
+        inline: bool,
+    ) -> tuple[str, list[int]]:
+        """
+        The SQL of tokens[start:end] with its references to CTEs replaced, and
+        the numbers of its parameters in order.
+
+        DML CTEs are replaced by their rows (results[key], None while not run),
+        plain CTEs by their body when inline is set.
+        """
+        if start >= end:
+            return "", []
+        tokens = self.tokens
+        by_key = {cte.key: cte for cte in visible}
+        pieces, parameters = [], []
+        cursor = tokens[start][2]
+        for index in range(start, end):
+            kind, text, token_start, token_end = tokens[index]
+            if text == "?":
+                parameters.append(self.parameter_numbers[index])
+                continue
+            cte = by_key.get(_name_key(text)) if kind == "word" else None
+            if cte is None or not self._is_table_reference(index):
+                continue
+            replacement = self._replacement(cte, visible, results, inline)
+            if replacement is None:
+                continue
+            table, table_parameters = replacement
+            pieces.append(self.sql[cursor:token_start])
+            pieces.append(table if self._has_alias(index) else f"{table} {text}")
+            parameters.extend(table_parameters)
+            cursor = token_end
+        pieces.append(self.sql[cursor : tokens[end - 1][3]])
+        return "".join(pieces), parameters
+
+    def _replacement(
+        self,
+        cte: CommonTableExpression,
+        visible: list[CommonTableExpression],
+        results: dict[str, dict[str, Any] | None],
+        inline: bool,
+    ) -> tuple[str, list[int]] | None:
+        """Derived table standing for a CTE (None to keep the reference)."""
+        earlier = visible[: visible.index(cte)]
+        if not cte.dml:
+            if not inline or cte.columns:
+                return None
+            body, parameters = self._render(cte.start, cte.end, earlier, results, inline)
+            return f"({body})", parameters
+
+        result = results.get(cte.key)
+        if result and result.get("rows") and result.get("columns"):
+            return f"({self._rows_sql(result, cte.columns)})", []
+        empty = self._empty_sql(cte.start, cte.end)
+        if empty is None:
+            return None  # No RETURNING: PostgreSQL reports the reference as an error
+        return f"({empty[0]})", empty[1]
+
+    @staticmethod
+    def _rows_sql(result: dict[str, Any], names: list[str] | None) -> str:
+        columns = result["columns"]
+        aliases = [_column_alias(column["name"]) for column in columns]
+        if names:
+            aliases[: len(names)] = names
+        selects = []
+        for row_number, row in enumerate(result["rows"]):
+            values = [
+                sql_literal(value, column.get("type_oid"))
+                for value, column in zip(row, columns, strict=False)
+            ]
+            if row_number == 0:
+                values = [
+                    f"{value} AS {alias}" for value, alias in zip(values, aliases, strict=False)
+                ]
+            selects.append(f"SELECT {', '.join(values)}")
+        return " UNION ALL ".join(selects)
+
+    def _empty_sql(self, start: int, end: int) -> tuple[str, list[int]] | None:
+        """SELECT of a DML statement's RETURNING columns from its table, without rows."""
+        tokens = self.tokens
+        depth, returning = 0, None
+        for index in range(start, end):
+            text = tokens[index][1]
+            if text == "(":
+                depth += 1
+            elif text == ")":
+                depth -= 1
+            elif depth == 0 and tokens[index][0] == "word" and text.upper() == "RETURNING":
+                returning = index
+        if returning is None or returning + 1 >= end:
+            return None
+
+        verb = tokens[start][1].upper()
+        index = start + 1
+        if verb in ("INSERT", "DELETE"):
+            index += 1  # INTO / FROM
+        if index < end and tokens[index][1].upper() == "ONLY":
+            index += 1
+        table_start = index
+        while index + 2 < end and tokens[index + 1][1] == ".":
+            index += 2
+        table = self.sql[tokens[table_start][2] : tokens[index][3]]
+        index += 1
+        if index < end and tokens[index][1].upper() == "AS":
+            index += 1
+        if (
+            index < end
+            and tokens[index][0] == "word"
+            and tokens[index][1].upper() not in _TARGET_CLAUSES
+        ):
+            table += f" {tokens[index][1]}"
+
+        columns, parameters = self._render(returning + 1, end, [], {}, inline=False)
+        return f"SELECT {columns} FROM {table} WHERE 1 = 0", parameters
+
+    def statement_sql(
+        self, index: int | None, results: dict[str, dict[str, Any] | None]
+    ) -> tuple[str, list[int]]:
+        """
+        The SQL of a DML CTE (ctes[index]) or of the main statement (index None)
+        for IRIS, and the numbers of its parameters.
+        """
+        if index is not None:
+            cte = self.ctes[index]
+            return self._render(cte.start, cte.end, self.ctes[:index], results, inline=True)
+
+        if self.main_is_dml:
+            return self._render(self.main_start, self.main_end, self.ctes, results, inline=True)
+        plain, parameters = [], []
+        for position, cte in enumerate(self.ctes):
+            if cte.dml:
+                continue
+            body, body_parameters = self._render(
+                cte.start, cte.end, self.ctes[:position], results, inline=False
+            )
+            columns = f" ({', '.join(cte.columns)})" if cte.columns else ""
+            plain.append(f"{cte.name}{columns} AS ({body})")
+            parameters.extend(body_parameters)
+        main, main_parameters = self._render(
+            self.main_start, self.main_end, self.ctes, results, inline=False
+        )
+        if plain:
+            main = f"WITH {', '.join(plain)} {main}"
+        return main, parameters + main_parameters
+
+    def describe_sql(self) -> tuple[str, list[int]] | None:
+        """
+        A statement with the result columns of the main statement that changes
+        nothing, and its parameter numbers (None when the main statement
+        returns no rows).
+        """
+        if self.main_is_dml:
+            return self._empty_sql(self.main_start, self.main_end)
+        return self.statement_sql(None, {})
+
+
+def parse_writable_cte(sql: str) -> WritableCTE | None:
+    """Parse a WITH query whose CTEs or main statement modify data (None for others)."""
+    if not _WITH_QUERY.match(sql):
+        return None
+    tokens = _tokens(sql)
+    if len(tokens) < 2 or tokens[1][1].upper() == "RECURSIVE":
+        return None
+
+    ctes, index = [], 1
+    while True:
+        if index >= len(tokens) or tokens[index][0] != "word":
+            return None
+        name = tokens[index][1]
+        index += 1
+        columns = None
+        if index < len(tokens) and tokens[index][1] == "(":
+            close = _closing(tokens, index)
+            if close is None:
+                return None
+            columns = [token[1] for token in tokens[index + 1 : close] if token[1] != ","]
+            index = close + 1
+        if index >= len(tokens) or tokens[index][1].upper() != "AS":
+            return None
+        index += 1
+        if index < len(tokens) and tokens[index][1].upper() == "NOT":
+            index += 1
+        if index < len(tokens) and tokens[index][1].upper() == "MATERIALIZED":
+            index += 1
+        if index >= len(tokens) or tokens[index][1] != "(":
+            return None
+        close = _closing(tokens, index)
+        if close is None or close == index + 1:
+            return None
+        dml = tokens[index + 1][1].upper() in _DML_VERBS
+        ctes.append(CommonTableExpression(name, columns, index + 1, close, dml))
+        index = close + 1
+        if index < len(tokens) and tokens[index][1] == ",":
+            index += 1
+            continue
+        break
+
+    main_end = len(tokens)
+    while main_end > index and tokens[main_end - 1][1] == ";":
+        main_end -= 1
+    if index >= main_end:
+        return None
+    statement = WritableCTE(sql, ctes, index, main_end, tokens)
+    if not statement.main_is_dml and not any(cte.dml for cte in ctes):
+        return None
+    return statement
+
+
+def _bound(params: list | None, numbers: list[int]) -> list | None:
+    if not params or not numbers:
+        return None
+    return [params[number] for number in numbers]
+
+
+async def run_writable_cte(
+    executor,
+    statement: WritableCTE,
+    params: list | None = None,
+    own_transaction: bool = True,
+    record: Callable[[str, list | None, dict[str, Any]], None] | None = None,
+) -> dict[str, Any]:
+    """
+    Run a data-modifying WITH query as a sequence of statements.
+
+    Args:
+        executor: IRISExecutor running the statements
+        statement: The parsed query
+        params: The query's parameters
+        own_transaction: Run in a transaction of its own (the client is in none)
+        record: Called with (sql, params, result) of each DML statement once all
+            of them succeeded (and were committed, with own_transaction)
+
+    Returns:
+        The main statement's result, or the first failure
+    """
+    if own_transaction:
+        begin = await executor.execute_query("START TRANSACTION")
+        if not begin.get("success"):
+            return begin
+
+    results: dict[str, dict[str, Any] | None] = {}
+    steps = []
+    try:
+        for index, cte in enumerate(statement.ctes):
+            if not cte.dml:
+                continue
+            sql, numbers = statement.statement_sql(index, results)
+            result = await executor.execute_query(sql, params=_bound(params, numbers))
+            if not result.get("success"):
+                break
+            results[cte.key] = result
+            steps.append((sql, _bound(params, numbers), result))
+        else:
+            sql, numbers = statement.statement_sql(None, results)
+            result = await executor.execute_query(sql, params=_bound(params, numbers))
+            if statement.main_is_dml:
+                steps.append((sql, _bound(params, numbers), result))
+    except Exception:
+        if own_transaction:
+            await executor.execute_query("ROLLBACK")
+        raise
+
+    logger.info(
+        "Ran data-modifying WITH query",
+        statements=len(steps),
+        success=bool(result.get("success")),
+    )
+    if not result.get("success"):
+        if own_transaction:
+            await executor.execute_query("ROLLBACK")
+        return result
+    if own_transaction:
+        commit = await executor.execute_query("COMMIT")
+        if not commit.get("success"):
+            return commit
+    if record is not None:
+        for step in steps:
+            record(*step)
+    return result
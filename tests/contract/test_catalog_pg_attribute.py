@@ -129,6 +129,32 @@ class TestPgAttributeTypeModifier:
 
         assert attr.atttypmod == 14  # 10 + 4
 
+    def test_numeric_typmod(self):
+        """NUMERIC(10,2) -> atttypmod = ((10 << 16) | 2) + 4"""
+        from iris_pgwire.catalog.oid_generator import OIDGenerator
+        from iris_pgwire.catalog.pg_attribute import PgAttributeEmulator
+
+        emulator = PgAttributeEmulator(OIDGenerator())
+        attr = emulator.from_iris_column(
+            "SQLUser", "t", "c", "NUMERIC(10,2)", 1, "YES", None
+        )
+
+        assert attr.atttypid == 1700
+        assert attr.atttypmod == 655366
+
+    def test_money_column(self, monkeypatch):
+        """NUMERIC(19,4), the MONEY column type -> money (OID 790), no typmod"""
+        from iris_pgwire.catalog.oid_generator import OIDGenerator
+        from iris_pgwire.catalog.pg_attribute import PgAttributeEmulator
+
+        monkeypatch.delenv("PGWIRE_MONEY_COLUMN_TYPE", raising=False)
+        emulator = PgAttributeEmulator(OIDGenerator())
+        attr = emulator.from_iris_column(
+            "SQLUser", "t", "c", "NUMERIC(19,4)", 1, "YES", None
+        )
+
+        assert (attr.atttypid, attr.attlen, attr.atttypmod) == (790, 8, -1)
+
     def test_integer_no_typmod(self):
         """INTEGER -> atttypmod = -1"""
         from iris_pgwire.catalog.oid_generator import OIDGenerator
@@ -0,0 +1,136 @@
+"""
+Fuzz Target: Frontend Message Decoder
+
+Feeds arbitrary bytes to PGWireProtocol.message_loop (as a post-startup
+frontend stream) against a stub executor. A finding is any exception that
+escapes the loop, a hang, or a read larger than the configured message size.
+
+With atheris (coverage-guided):
+
+    pip install atheris
+    python tests/fuzz/fuzz_message_decoder.py -max_len=4096 -runs=1000000
+
+Without atheris, random mutations of valid messages are run instead:
+
+    python tests/fuzz/fuzz_message_decoder.py --iterations 100000
+"""
+
+import argparse
+import asyncio
+import random
+import struct
+import sys
+from unittest.mock import AsyncMock, MagicMock
+
+MAX_MESSAGE_SIZE = 64 * 1024
+
+
+class _Writer:
+    def write(self, data):
+        pass
+
+    async def drain(self):
+        pass
+
+    def is_closing(self):
+        return False
+
+    def get_extra_info(self, *args):
+        return None
+
+    def close(self):
+        pass
+
+
+def _message(message_type: bytes, body: bytes = b"") -> bytes:
+    return message_type + struct.pack("!I", len(body) + 4) + body
+
+
+SEEDS = [
+    _message(b"Q", b"SELECT 1\x00"),
+    _message(b"Q", b"BEGIN; SELECT 'x'; COMMIT\x00"),
+    _message(b"P", b"s1\x00SELECT $1\x00" + struct.pack("!HI", 1, 23)),
+    _message(b"B", b"\x00s1\x00" + struct.pack("!HHHI", 1, 0, 1, 1) + b"7" + b"\x00\x00"),
+    _message(b"D", b"P\x00"),
+    _message(b"E", b"\x00" + struct.pack("!I", 0)),
+    _message(b"C", b"S\x00"),
+    _message(b"H"),
+    _message(b"S"),
+]
+
+
+class _Reader:
+    """StreamReader that fails the run on reads beyond the message size limit."""
+
+    def __init__(self, data: bytes):
+        self._reader = asyncio.StreamReader()
+        self._reader.feed_data(data)
+        self._reader.feed_eof()
+
+    async def readexactly(self, n: int) -> bytes:
+        if n > MAX_MESSAGE_SIZE:
+            raise AssertionError(f"decoder requested {n} bytes")
+        return await self._reader.readexactly(n)
+
+    async def read(self, n: int = -1) -> bytes:
+        return await self._reader.read(min(n, MAX_MESSAGE_SIZE) if n >= 0 else MAX_MESSAGE_SIZE)
+
+
+async def _decode(data: bytes) -> None:
+    from iris_pgwire.protocol import PGWireProtocol
+
+    executor = MagicMock()
+    executor.execute_query = AsyncMock(
+        return_value={"success": True, "rows": [], "columns": [], "row_count": 0}
+    )
+    protocol = PGWireProtocol(_Reader(data), _Writer(), executor, "fuzz")
+    protocol.max_message_size = MAX_MESSAGE_SIZE
+    protocol.max_bind_message_size = MAX_MESSAGE_SIZE
+    await asyncio.wait_for(protocol.message_loop(), timeout=5)
+
+
+def run_one(data: bytes) -> None:
+    asyncio.run(_decode(data))
+
+
+def mutate(rng: random.Random) -> bytes:
+    data = bytearray(b"".join(rng.sample(SEEDS, rng.randint(1, len(SEEDS)))))
+    for _ in range(rng.randint(1, 8)):
+        data[rng.randrange(len(data))] = rng.randrange(256)
+    if rng.random() < 0.3:
+        del data[rng.randrange(len(data)) :]
+    return bytes(data)
+
+
+def main() -> None:
+    try:
+        import atheris
+    except ImportError:
+        atheris = None
+
+    if atheris is not None and "--iterations" not in sys.argv:
+        with atheris.instrument_imports():
+            import iris_pgwire.message_framing  # noqa: F401
+            import iris_pgwire.protocol  # noqa: F401
+        atheris.Setup(sys.argv, run_one)
+        atheris.Fuzz()
+        return
+
+    parser = argparse.ArgumentParser(description=__doc__.splitlines()[1])
+    parser.add_argument("--iterations", type=int, default=10000)
+    parser.add_argument("--seed", type=int, default=0)
+    args = parser.parse_args()
+
+    rng = random.Random(args.seed)
+    for iteration in range(args.iterations):
+        data = mutate(rng)
+        try:
+            run_one(data)
+        except Exception:
+            print(f"iteration {iteration}: input {data!r}", file=sys.stderr)
+            raise
+    print(f"{args.iterations} inputs decoded without escaping exceptions")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,65 @@
+"""
+PostgreSQL Wire Protocol Test Helpers
+
+A StreamWriter stand-in that keeps what the bridge sends, and the framing of
+the frontend messages tests feed the bridge and of the backend messages they
+read back.
+"""
+
+import struct
+
+PROTOCOL_VERSION_3 = 196608
+
+
+class FakeWriter:
+    """StreamWriter that keeps everything written to it (and counts drains)."""
+
+    def __init__(self, **extra_info):
+        self.buffer = b""
+        self.drains = 0
+        self.extra_info = extra_info  # e.g. peername, sockname, peercert
+
+    def write(self, data):
+        self.buffer += data
+
+    async def drain(self):
+        self.drains += 1
+
+    def is_closing(self):
+        return False
+
+    def close(self):
+        pass
+
+    async def wait_closed(self):
+        pass
+
+    def get_extra_info(self, name, default=None):
+        return self.extra_info.get(name, default)
+
+
+def frontend_message(message_type: bytes, body: bytes = b"") -> bytes:
+    return message_type + struct.pack("!I", len(body) + 4) + body
+
+
+def startup_message(**params) -> bytes:
+    """StartupMessage (protocol 3.0) with the given parameters, e.g. user="alice"."""
+    body = struct.pack("!I", PROTOCOL_VERSION_3)
+    for key, value in params.items():
+        body += key.encode() + b"\x00" + value.encode() + b"\x00"
+    body += b"\x00"
+    return struct.pack("!I", len(body) + 4) + body
+
+
+def query_message(sql: str) -> bytes:
+    return frontend_message(b"Q", sql.encode() + b"\x00")
+
+
+def backend_messages(buffer: bytes) -> list[tuple[bytes, bytes]]:
+    """(type, body) of each backend message in buffer."""
+    messages, pos = [], 0
+    while pos < len(buffer):
+        length = struct.unpack("!I", buffer[pos + 1 : pos + 5])[0]
+        messages.append((buffer[pos : pos + 1], buffer[pos + 5 : pos + 1 + length]))
+        pos += 1 + length
+    return messages
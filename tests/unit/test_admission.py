@@ -0,0 +1,133 @@
+"""
+Unit Tests: Admission Control
+
+Global and per-role concurrency caps, fair hand-off of freed slots and the
+queue timeout.
+"""
+
+import asyncio
+
+import pytest
+
+from iris_pgwire.admission import AdmissionController, AdmissionTimeout
+
+
+def _controller(**kwargs):
+    kwargs.setdefault("max_concurrent", 0)
+    kwargs.setdefault("per_role", 0)
+    kwargs.setdefault("role_limits", {})
+    kwargs.setdefault("timeout", 5)
+    return AdmissionController(**kwargs)
+
+
+class TestLimits:
+    def test_disabled_by_default(self, monkeypatch):
+        for name in (
+            "PGWIRE_MAX_CONCURRENT_QUERIES",
+            "PGWIRE_MAX_QUERIES_PER_ROLE",
+            "PGWIRE_ROLE_QUERY_LIMITS",
+        ):
+            monkeypatch.delenv(name, raising=False)
+
+        assert not AdmissionController().enabled
+
+    def test_role_limits_from_environment(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_MAX_QUERIES_PER_ROLE", "8")
+        monkeypatch.setenv("PGWIRE_ROLE_QUERY_LIMITS", "dashboard=2, etl=4,bogus=x")
+
+        controller = AdmissionController()
+
+        assert controller.role_limit("dashboard") == 2
+        assert controller.role_limit("etl") == 4
+        assert controller.role_limit("app") == 8
+        assert "bogus" not in controller.role_limits
+
+    def test_global_cap_queues_excess(self):
+        async def scenario():
+            controller = _controller(max_concurrent=2)
+            await controller.acquire("a")
+            await controller.acquire("b")
+            waiter = asyncio.create_task(controller.acquire("c"))
+            await asyncio.sleep(0)
+            assert not waiter.done()
+            assert controller.stats()["queued"] == 1
+
+            controller.release("a")
+            await waiter
+            return controller.stats()
+
+        stats = asyncio.run(scenario())
+
+        assert stats["running"] == 2
+        assert stats["running_by_role"] == {"b": 1, "c": 1}
+
+    def test_role_cap_does_not_block_other_roles(self):
+        async def scenario():
+            controller = _controller(max_concurrent=10, role_limits={"dashboard": 1})
+            await controller.acquire("dashboard")
+            queued = asyncio.create_task(controller.acquire("dashboard"))
+            await asyncio.sleep(0)
+
+            await asyncio.wait_for(controller.acquire("oltp"), timeout=1)
+            return queued.done(), controller.stats()
+
+        queued_done, stats = asyncio.run(scenario())
+
+        assert not queued_done
+        assert stats["running_by_role"] == {"dashboard": 1, "oltp": 1}
+        assert stats["queued_by_role"] == {"dashboard": 1}
+
+
+class TestFairness:
+    def test_freed_slot_goes_to_least_served_role(self):
+        async def scenario():
+            controller = _controller(max_concurrent=2)
+            granted = []
+
+            async def statement(role):
+                await controller.acquire(role)
+                granted.append(role)
+
+            await controller.acquire("dashboard")
+            await controller.acquire("dashboard")
+            tasks = [
+                asyncio.create_task(statement(role)) for role in ("dashboard", "dashboard", "oltp")
+            ]
+            await asyncio.sleep(0)
+
+            controller.release("dashboard")
+            await asyncio.sleep(0)
+            await asyncio.sleep(0)
+            for task in tasks:
+                task.cancel()
+            await asyncio.gather(*tasks, return_exceptions=True)
+            return granted
+
+        assert asyncio.run(scenario()) == ["oltp"]
+
+
+class TestTimeout:
+    def test_timeout_raises_insufficient_resources(self):
+        async def scenario():
+            controller = _controller(max_concurrent=1, timeout=0.05)
+            await controller.acquire("a")
+            with pytest.raises(AdmissionTimeout) as excinfo:
+                await controller.acquire("b")
+            return excinfo.value, controller.stats()
+
+        error, stats = asyncio.run(scenario())
+
+        assert error.sqlstate == "53000"
+        assert error.condition_name == "insufficient_resources"
+        assert stats["queued"] == 0
+        assert stats["running"] == 1
+
+    def test_admit_releases_on_error(self):
+        async def scenario():
+            controller = _controller(max_concurrent=1)
+            with pytest.raises(RuntimeError):
+                async with controller.admit("a"):
+                    raise RuntimeError("boom")
+            return controller.stats()
+
+        assert asyncio.run(scenario())["running"] == 0
@@ -0,0 +1,81 @@
+"""
+Unit Tests: Integer Arithmetic Translation
+
+Division of integer operands truncating toward zero, % with the sign of the
+dividend, and IRIS <DIVIDE> errors reported as 22012.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.arithmetic_translator import (
+    ArithmeticTranslator,
+    annotate_division_by_zero,
+)
+
+
+@pytest.fixture
+def translator():
+    return ArithmeticTranslator()
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        ("SELECT 7 / 2", "SELECT (7 \\ 2)"),
+        ("SELECT -7 / 2", "SELECT ((-7) \\ 2)"),
+        ("SELECT COUNT(*) / 3 FROM t", "SELECT (COUNT(*) \\ 3) FROM t"),
+        ("SELECT CAST(? AS INTEGER) / 4", "SELECT (CAST(? AS INTEGER) \\ 4)"),
+        ("SELECT 7 / 2 / 2, (1 + 6) / 2", "SELECT ((7 \\ 2) \\ 2), ((1 + 6) \\ 2)"),
+        ("SELECT 10 - 7 / 2", "SELECT 10 - (7 \\ 2)"),
+        ("SELECT -7 % 2", "SELECT ((-7) - 2 * ((-7) \\ 2))"),
+        ("SELECT t.a % (b + 1) FROM t", "SELECT (t.a - (b + 1) * (t.a \\ (b + 1))) FROM t"),
+        ("SELECT a * b % 10", "SELECT ((a * b) - 10 * ((a * b) \\ 10))"),
+        ("SELECT $LISTGET(t, 1) % 2", "SELECT ($LISTGET(t, 1) - 2 * ($LISTGET(t, 1) \\ 2))"),
+        ("SELECT 2^3 / 2", "SELECT (2^3) / 2"),
+        ("SELECT 8 / 2 ^ -1 * 3", "SELECT 8 / (2 ^ -1) * 3"),
+        ("SELECT a ^ 2 % 3", "SELECT ((a ^ 2) - 3 * ((a ^ 2) \\ 3))"),
+    ],
+)
+def test_postgres_semantics(translator, sql, expected):
+    assert translator.translate(sql)[0] == expected
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT 7.0 / 2, a / b, SUM(x) / COUNT(*) FROM t",  # Not known to be integers
+        "SELECT %ID FROM t WHERE name %STARTSWITH 'A'",
+        "SELECT a FROM t WHERE path LIKE '%a/b%' -- 1 / 2",
+        "SELECT a FROM t WHERE a <% b",
+    ],
+)
+def test_other_statements_are_left_alone(translator, sql):
+    assert translator.translate(sql) == (sql, 0)
+
+
+def test_translation_is_idempotent(translator):
+    translated, count = translator.translate("SELECT 7 / 2, a % 3, -a % -b, 2^3 / 2 FROM t")
+
+    assert count == 4
+    assert translator.translate(translated) == (translated, 0)
+
+
+def test_divide_errors_become_division_by_zero():
+    result = annotate_division_by_zero(
+        {"success": False, "error": "[SQLCODE: <-400>] [%msg: <Unexpected error: <DIVIDE>>]"}
+    )
+
+    assert (result["sqlstate"], result["condition_name"]) == ("22012", "division_by_zero")
+    assert result["error"] == "division by zero"
+    other = {"success": False, "error": "Table not found"}
+    assert "sqlstate" not in annotate_division_by_zero(other)
+
+
+def test_normalizer_counts_arithmetic():
+    sql_translator = SQLTranslator()
+
+    assert sql_translator.normalize_sql("SELECT qty / 2, 9 / 2 FROM items") == (
+        "SELECT QTY / 2, (9 \\ 2) FROM ITEMS"
+    )
+    assert sql_translator.get_normalization_metrics()["arithmetic_count"] == 1
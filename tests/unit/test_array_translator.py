@@ -0,0 +1,70 @@
+"""
+Unit Tests: Array Constructor and Subscript Translation
+
+ARRAY[...] constructors, ANY/ALL over constructors, subscripts and slices
+rewritten onto %List ($LISTBUILD) functions.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.array_translator import ArrayTranslator
+
+
+@pytest.fixture
+def translator():
+    return ArrayTranslator()
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        ("SELECT ARRAY[1, 2, 3]", "SELECT $LISTBUILD(1, 2, 3)"),
+        ("SELECT ARRAY['a','b']::text[]", "SELECT $LISTBUILD('a', 'b')"),
+        (
+            "SELECT * FROM t WHERE id = ANY(ARRAY[1,2,3])",
+            "SELECT * FROM t WHERE id IN (1, 2, 3)",
+        ),
+        ("SELECT * FROM t WHERE x <> ALL (ARRAY['a'])", "SELECT * FROM t WHERE x NOT IN ('a')"),
+        ("SELECT tags[1] FROM t", "SELECT $LISTGET(tags, 1) FROM t"),
+        ("SELECT t.tags[?] FROM t", "SELECT $LISTGET(t.tags, ?) FROM t"),
+        ("SELECT tags[2:3] FROM t", "SELECT $LIST(tags, 2, 3) FROM t"),
+        ("SELECT tags[:2], tags[2:] FROM t", "SELECT $LIST(tags, 1, 2), $LIST(tags, 2, -1) FROM t"),
+        ("SELECT (ARRAY[4,5,6])[2]", "SELECT $LISTGET(($LISTBUILD(4, 5, 6)), 2)"),
+        ("SELECT f(x)[1] FROM t", "SELECT $LISTGET(f(x), 1) FROM t"),
+        ("SELECT ARRAY[tags[1], 'x'] FROM t", "SELECT $LISTBUILD($LISTGET(tags, 1), 'x') FROM t"),
+    ],
+)
+def test_arrays(translator, sql, expected):
+    assert translator.translate(sql)[0] == expected
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT ARRAY[[1,2],[3,4]], ARRAY[ARRAY[1]] FROM t",  # Multi-dimensional
+        "SELECT * FROM t WHERE name [ 'abc' AND b ] 'x'",  # IRIS contains/follows
+        "SELECT 'a[1]', ARRAY(SELECT id FROM u) FROM t",
+        "SELECT * FROM t WHERE y = ANY(tags)",
+    ],
+)
+def test_other_statements_are_left_alone(translator, sql):
+    assert translator.translate(sql) == (sql, 0)
+
+
+def test_translation_is_idempotent(translator):
+    translated, count = translator.translate("SELECT ARRAY[a[1], b], c[2:] FROM t")
+
+    assert count == 3
+    assert translator.translate(translated) == (translated, 0)
+
+
+def test_normalizer_counts_arrays():
+    sql_translator = SQLTranslator()
+
+    sql = "SELECT tags[1] FROM items WHERE id = ANY(ARRAY[1, 2])"
+
+    assert sql_translator.normalize_sql(sql) == (
+        "SELECT $LISTGET(TAGS, 1) FROM ITEMS WHERE ID IN (1, 2)"
+    )
+    assert sql_translator.get_normalization_metrics()["array_count"] == 2
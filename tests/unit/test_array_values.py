@@ -0,0 +1,159 @@
+"""
+Unit Tests: One-Dimensional Arrays on the Wire
+
+Array literals and binary arrays as Bind parameters, = ANY(?) expanded into
+IN lists, and %List result columns typed and encoded as PostgreSQL arrays.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.array_values import (
+    ArrayParameter,
+    array_parameter,
+    array_type_oid,
+    encode_array,
+    expand_any_parameters,
+    parse_array_literal,
+)
+from iris_pgwire.bind_params import decode_binary_parameter
+from iris_pgwire.iris_list import decode_list_columns
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.result_encoding import encode_result_value, result_format
+
+
+def _binary_array(element_oid: int, values: list[bytes | None]) -> bytes:
+    data = struct.pack("!iiIii", 1, int(None in values), element_oid, len(values), 1)
+    for value in values:
+        data += struct.pack("!i", -1) if value is None else struct.pack("!i", len(value)) + value
+    return data
+
+
+class TestLiterals:
+    @pytest.mark.parametrize(
+        "text,expected",
+        [
+            ('{a,"b c",NULL,"q\\"",""}', ["a", "b c", None, 'q"', ""]),
+            ("{ 1 , 2 }", ["1", "2"]),
+            ("{{1,2},{3,4}}", ["1", "2", "3", "4"]),
+            ("[0:1]={7,8}", ["7", "8"]),
+            ("{}", []),
+        ],
+    )
+    def test_parse(self, text, expected):
+        assert parse_array_literal(text) == expected
+
+    @pytest.mark.parametrize("text", ["1,2", "{1,2", '{"a}', "{a}b", "[1,2]"])
+    def test_invalid(self, text):
+        with pytest.raises(ValueError):
+            parse_array_literal(text)
+
+    def test_parameter_elements_by_type(self):
+        assert array_parameter("{1,NULL,-3}", 23).elements == [1, None, -3]
+        assert array_parameter("{t,false}", 16).elements == [1, 0]
+        assert array_parameter("{1.5,2}", 701).elements == [1.5, 2.0]
+        assert array_parameter('{a,"b c"}', 25) == '{a,"b c"}'
+        with pytest.raises(ValueError):
+            array_parameter("{x}", 23)
+
+    def test_binary_parameters_keep_elements(self):
+        integers = decode_binary_parameter(
+            _binary_array(23, [struct.pack("!i", 1), struct.pack("!i", 2)]), 0, 1007
+        )
+        booleans = decode_binary_parameter(_binary_array(16, [b"\x01", None]), 0, 1000)
+
+        assert (integers, integers.elements) == ("[1,2]", [1, 2])
+        assert (booleans, booleans.elements) == ("{t,NULL}", [1, None])
+
+
+class TestAnyExpansion:
+    def test_parameter(self):
+        sql, params = expand_any_parameters(
+            "SELECT * FROM t WHERE a = ? AND id = ANY (?) AND b = ?",
+            ["x", ArrayParameter("{1,2,3}", [1, 2, 3]), "y"],
+        )
+
+        assert sql == "SELECT * FROM t WHERE a = ? AND id IN (?, ?, ?) AND b = ?"
+        assert params == ["x", 1, 2, 3, "y"]
+
+    def test_literal_and_text_parameter(self):
+        assert expand_any_parameters("SELECT * FROM t WHERE k = SOME(?::text[])", ["{a,b}"]) == (
+            "SELECT * FROM t WHERE k IN (?, ?)",
+            ["a", "b"],
+        )
+        assert expand_any_parameters("SELECT * FROM t WHERE k = ANY('{a,O''Brien}')", None) == (
+            "SELECT * FROM t WHERE k IN ('a', 'O''Brien')",
+            None,
+        )
+
+    def test_empty_array_matches_nothing(self):
+        assert expand_any_parameters(
+            "DELETE FROM t WHERE id = ANY(?)", [ArrayParameter("{}", [])]
+        ) == ("DELETE FROM t WHERE id IN (NULL)", None)
+
+    def test_array_elsewhere_bound_as_text(self):
+        sql, params = expand_any_parameters(
+            "INSERT INTO t (tags) VALUES (?)", [ArrayParameter("{a}", ["a"])]
+        )
+
+        assert sql == "INSERT INTO t (tags) VALUES (?)"
+        assert type(params[0]) is str
+
+    def test_catalog_queries_unchanged(self):
+        sql = "SELECT nspname FROM pg_namespace WHERE nspname = ANY(?)"
+        params = [ArrayParameter("{public}", ["public"])]
+
+        assert expand_any_parameters(sql, params) == (sql, params)
+
+    def test_statement_runs_expanded(self):
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(return_value={"success": True, "rows": []})
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), executor, "arrays")
+
+        asyncio.run(
+            protocol._execute_statement(
+                "SELECT * FROM t WHERE id = ANY(?)", [ArrayParameter("{4,5}", [4, 5])]
+            )
+        )
+
+        executor.execute_query.assert_awaited_once_with(
+            "SELECT * FROM t WHERE id IN (?, ?)", params=[4, 5]
+        )
+
+
+class TestResults:
+    @pytest.mark.parametrize(
+        "elements,expected",
+        [
+            ([1, None, 2], 1007),
+            ([1, 2**40], 1016),
+            ([1, 2.5], 1022),
+            ([None], 1009),
+            (["a", 1], 1009),
+        ],
+    )
+    def test_column_type(self, elements, expected):
+        assert array_type_oid(elements) == expected
+
+    def test_list_column_typed_by_elements(self):
+        column = {"name": "scores", "type_oid": 1043, "type_size": 50, "type_modifier": 54}
+        result = {"rows": [["\x03\x04\x01\x03\x04\x02"], [None]], "columns": [column]}
+
+        decode_list_columns(result, "array")
+
+        assert result["rows"][0][0] == "{1,2}"
+        assert column["type_oid"] == 1007
+
+    def test_binary_encoding(self):
+        assert result_format([1], 0, 1007) == 1
+        assert encode_result_value("{1,NULL}", 1007) == (
+            struct.pack("!iiIii", 1, 1, 23, 2, 1) + struct.pack("!ii", 4, 1) + struct.pack("!i", -1)
+        )
+        assert encode_array('{"b c"}', 1009) == (
+            struct.pack("!iiIii", 1, 0, 25, 1, 1) + struct.pack("!i", 3) + b"b c"
+        )
+        assert encode_array("{t}", 1000) == struct.pack("!iiIiii", 1, 0, 16, 1, 1, 1) + b"\x01"
+        assert encode_array("{}", 1022) == struct.pack("!iiI", 0, 0, 701)
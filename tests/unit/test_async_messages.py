@@ -0,0 +1,126 @@
+"""
+Unit Tests: Asynchronous Backend Messages
+
+Notices, notifications and ParameterStatus reports written while a query
+runs, between the session's other messages.
+"""
+
+import asyncio
+import struct
+import threading
+from unittest.mock import AsyncMock, MagicMock
+
+from iris_pgwire.async_messages import AsyncMessages, notice_response, parameter_status
+from iris_pgwire.notifications import Notification
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, query_message, startup_message
+
+RESULT = {
+    "success": True,
+    "rows": [[1]],
+    "columns": [
+        {"name": "n", "type_oid": 23, "type_size": 4, "type_modifier": -1, "format_code": 0}
+    ],
+    "row_count": 1,
+    "command_tag": "SELECT",
+}
+
+
+def _kinds(buffer: bytes) -> list[bytes]:
+    kinds, pos = [], 0
+    while pos < len(buffer):
+        kinds.append(buffer[pos : pos + 1])
+        pos += 1 + struct.unpack("!I", buffer[pos + 1 : pos + 5])[0]
+    return kinds
+
+
+async def _run_session(query: str, during_query):
+    """Run one query whose IRIS execution calls during_query(protocol) midway."""
+    reader = asyncio.StreamReader()
+    reader.feed_data(startup_message(user="app", database="USER"))
+    writer = FakeWriter()
+    executor = MagicMock()
+    protocol = PGWireProtocol(reader, writer, executor, "async-messages")
+
+    async def execute_query(sql, params=None, **kwargs):
+        during_query(protocol)
+        await asyncio.sleep(0)
+        return dict(RESULT)
+
+    executor.execute_query = AsyncMock(side_effect=execute_query)
+    await protocol.handle_ssl_probe(None)
+    await protocol.handle_startup_sequence()
+    handshake = len(writer.buffer)
+    reader.feed_data(query_message(query) + b"X\x00\x00\x00\x04")
+    reader.feed_eof()
+    try:
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+    finally:
+        protocol.notifications.close()
+        get_stats().session_ended(protocol.connection_id)
+    return protocol, _kinds(writer.buffer[handshake:])
+
+
+def test_notification_delivered_while_query_runs():
+    def notify(protocol):
+        protocol.notifications.listen("jobs")
+        protocol.notifications.hub.publish([Notification("jobs", "done", 4242)])
+
+    _, kinds = asyncio.run(_run_session("SELECT 1", notify))
+
+    assert kinds == [b"A", b"T", b"D", b"C", b"Z"]
+
+
+def test_notification_held_in_transaction_block():
+    def notify(protocol):
+        protocol.transaction_status = b"T"
+        protocol.notifications.listen("jobs")
+        protocol.notifications.hub.publish([Notification("jobs", "done", 4242)])
+
+    _, kinds = asyncio.run(_run_session("SELECT 1", notify))
+
+    assert kinds == [b"T", b"D", b"C", b"Z"]  # Sent before a ReadyForQuery outside the block
+
+
+def test_notice_while_query_runs():
+    def notice(protocol):
+        protocol.async_messages.notice("NOTICE", "00000", "still working")
+
+    _, kinds = asyncio.run(_run_session("SELECT 1", notice))
+
+    assert kinds == [b"N", b"T", b"D", b"C", b"Z"]
+
+
+def test_parameter_status_precedes_next_statement():
+    _, kinds = asyncio.run(
+        _run_session("SET application_name = 'report'; SELECT 1", lambda protocol: None)
+    )
+
+    assert kinds == [b"C", b"S", b"T", b"D", b"C", b"Z"]
+
+
+def test_messages_held_until_open_and_written_from_threads():
+    async def run():
+        written = []
+        messages = AsyncMessages()
+        messages.send(parameter_status("TimeZone", "UTC"))
+        messages.open(written.append)
+
+        thread = threading.Thread(
+            target=messages.notice, args=("WARNING", "01000", "from a worker thread")
+        )
+        thread.start()
+        thread.join()
+        await asyncio.sleep(0)
+
+        messages.close()
+        messages.send(b"ignored")
+        return written
+
+    written = asyncio.run(run())
+
+    assert written == [
+        parameter_status("TimeZone", "UTC"),
+        notice_response("WARNING", "01000", "from a worker thread"),
+    ]
@@ -0,0 +1,190 @@
+"""
+Unit Tests: Backend Authentication Modes
+
+Credential passthrough handshake, per-user connection pools and the
+service-account default.
+"""
+
+import asyncio
+import struct
+import sys
+from datetime import datetime
+from types import SimpleNamespace
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.auth.gssapi_auth import GSSAPIAuthenticator, KerberosConfig, KerberosPrincipal
+from iris_pgwire.backend_auth import (
+    BackendCredentials,
+    BackendLoginUnavailable,
+    DelegationFailed,
+    PasswordAuthenticationFailed,
+    apply_delegated_login,
+    current_backend_credentials,
+    load_backend_auth_mode,
+)
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.protocol import PGWireProtocol
+from tests.protocol_messages import FakeWriter, startup_message
+
+
+def _password(password: str) -> bytes:
+    body = password.encode() + b"\x00"
+    return b"p" + struct.pack("!I", len(body) + 4) + body
+
+
+async def _passthrough_handshake(verify):
+    reader = asyncio.StreamReader()
+    reader.feed_data(startup_message(user="alice") + _password("s3cret"))
+    reader.feed_eof()
+    writer = FakeWriter()
+    executor = MagicMock()
+    executor.backend_auth_mode = "passthrough"
+    executor.verify_backend_credentials = verify
+    protocol = PGWireProtocol(reader, writer, executor, "passthrough")
+    try:
+        await protocol.handle_ssl_probe(None)
+        await protocol.handle_startup_sequence()
+        return writer, current_backend_credentials()
+    except ConnectionAbortedError:
+        return writer, None
+
+
+class TestConfiguration:
+    def test_service_is_default(self, monkeypatch):
+        monkeypatch.delenv("PGWIRE_BACKEND_AUTH_MODE", raising=False)
+        assert load_backend_auth_mode() == "service"
+
+        monkeypatch.setenv("PGWIRE_BACKEND_AUTH_MODE", "Passthrough")
+        assert load_backend_auth_mode() == "passthrough"
+
+        monkeypatch.setenv("PGWIRE_BACKEND_AUTH_MODE", "kerberos")
+        assert load_backend_auth_mode() == "service"
+
+    def test_password_not_in_repr(self):
+        assert "s3cret" not in repr(BackendCredentials("alice", "s3cret"))
+
+
+class TestPassthroughHandshake:
+    def test_password_verified_against_iris(self):
+        verify = AsyncMock(return_value=BackendCredentials("alice", "s3cret"))
+
+        writer, credentials = asyncio.run(_passthrough_handshake(verify))
+
+        # AuthenticationCleartextPassword, then AuthenticationOk
+        assert writer.buffer.startswith(b"R\x00\x00\x00\x08\x00\x00\x00\x03")
+        assert b"R\x00\x00\x00\x08\x00\x00\x00\x00" in writer.buffer
+        verify.assert_awaited_once_with("alice", "s3cret")
+        assert credentials == BackendCredentials("alice", "s3cret")
+
+    def test_rejected_password(self):
+        verify = AsyncMock(side_effect=PasswordAuthenticationFailed("alice"))
+
+        writer, credentials = asyncio.run(_passthrough_handshake(verify))
+
+        assert credentials is None
+        assert b"C28P01\x00" in writer.buffer
+        assert b'password authentication failed for user "alice"' in writer.buffer
+        assert not writer.buffer.endswith(b"Z\x00\x00\x00\x05I")
+
+
+    def test_unreachable_iris_is_not_a_rejected_password(self):
+        verify = AsyncMock(side_effect=BackendLoginUnavailable("alice"))
+
+        writer, credentials = asyncio.run(_passthrough_handshake(verify))
+
+        assert credentials is None
+        assert b"C08006\x00" in writer.buffer
+        assert b"C28P01\x00" not in writer.buffer
+
+    @pytest.mark.parametrize(
+        "error,expected",
+        [
+            (RuntimeError("Access Denied"), PasswordAuthenticationFailed),
+            (ConnectionRefusedError("[Errno 111] Connection refused"), BackendLoginUnavailable),
+        ],
+    )
+    def test_only_refused_credentials_fail_with_28p01(self, monkeypatch, error, expected):
+        iris = MagicMock()
+        iris.connect.side_effect = error
+        monkeypatch.setitem(sys.modules, "iris", iris)
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.thread_pool = None
+        executor.iris_config = {"host": "localhost", "port": 1972, "namespace": "USER"}
+
+        with pytest.raises(expected):
+            asyncio.run(executor.verify_iris_password("alice", "s3cret"))
+        with pytest.raises(expected):
+            asyncio.run(executor.verify_backend_credentials("alice", "s3cret"))
+
+
+class TestUserPools:
+    @pytest.fixture
+    def executor(self):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.workload_priorities = {"etl": 5}
+        executor._connection_pool = []
+        executor._workload_pools = {}
+        executor._user_pools = {}
+        return executor
+
+    def test_users_never_share_connections(self, executor):
+        alice = BackendCredentials("alice", "a")
+        bob = BackendCredentials("bob", "b")
+
+        assert executor._workload_pool(None, alice) is executor._workload_pool(None, alice)
+        assert executor._workload_pool(None, alice) is not executor._workload_pool(None, bob)
+        assert executor._workload_pool(None, alice) is not executor._workload_pool(None)
+
+    def test_prioritized_workloads_split_user_pools(self, executor):
+        alice = BackendCredentials("alice", "a")
+
+        assert executor._workload_pool("etl", alice) is not executor._workload_pool(None, alice)
+        assert executor._workload_pool("adhoc", alice) is executor._workload_pool(None, alice)
+
+    def test_delegated_identity_has_its_own_pool(self, executor):
+        assert executor._workload_pool(None, BackendCredentials("ALICE")) is not (
+            executor._workload_pool(None, BackendCredentials("ALICE", "a"))
+        )
+
+
+class TestKerberosDelegation:
+    @staticmethod
+    def _authenticator(delegate):
+        authenticator = GSSAPIAuthenticator.__new__(GSSAPIAuthenticator)
+        authenticator.config = KerberosConfig(delegate_credentials=delegate)
+        return authenticator
+
+    @staticmethod
+    def _principal():
+        return KerberosPrincipal(
+            principal="alice@EXAMPLE.COM",
+            username="alice",
+            realm="EXAMPLE.COM",
+            mapped_iris_user="ALICE",
+            authenticated_at=datetime.now(),
+        )
+
+    def test_delegation_requires_opt_in_and_forwarded_credentials(self):
+        forwarded = SimpleNamespace(delegated_creds=object())
+        not_forwarded = SimpleNamespace(delegated_creds=None)
+
+        assert self._authenticator(False).delegated_backend_credentials(self._principal()) is None
+        delegating = self._authenticator(True)
+        assert delegating.delegated_backend_credentials(self._principal(), not_forwarded) is None
+
+        credentials = delegating.delegated_backend_credentials(self._principal(), forwarded)
+        assert credentials == BackendCredentials("ALICE")
+        assert credentials.delegated
+
+    def test_delegated_login_switches_iris_user(self, monkeypatch):
+        native = MagicMock()
+        native.classMethodValue.side_effect = [1, "0 ERROR #952: Access Denied"]
+        monkeypatch.setitem(sys.modules, "iris", SimpleNamespace(createIRIS=lambda conn: native))
+
+        apply_delegated_login(object(), "ALICE")
+        native.classMethodValue.assert_called_with("%SYSTEM.Security", "Login", "ALICE")
+
+        with pytest.raises(DelegationFailed, match="ALICE"):
+            apply_delegated_login(object(), "ALICE")
@@ -0,0 +1,234 @@
+"""
+Unit Tests: Binary Result Columns
+
+Bind's result-format codes applied per column type, the binary send forms of
+the core types, and RowDescription fields that agree with the DataRows.
+"""
+
+import asyncio
+import struct
+import uuid
+from datetime import UTC, date, datetime, time
+from decimal import Decimal
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.copy_binary import decode_numeric
+from iris_pgwire.numeric_range import NumericValueOutOfRange
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.result_encoding import InvalidResultValue, encode_result_value, result_format
+from tests.protocol_messages import FakeWriter, backend_messages, frontend_message
+
+UUID = uuid.UUID("12345678-1234-5678-1234-567812345678")
+
+
+@pytest.mark.parametrize(
+    "value, type_oid, expected",
+    [
+        (7, 21, struct.pack("!h", 7)),
+        ("42", 23, struct.pack("!i", 42)),
+        (2**40, 20, struct.pack("!q", 2**40)),
+        (1.5, 700, struct.pack("!f", 1.5)),
+        (Decimal("2.25"), 701, struct.pack("!d", 2.25)),
+        (12, 26, struct.pack("!I", 12)),
+        ("f", 16, b"\x00"),
+        (1, 16, b"\x01"),
+        ("caf\u00e9", 1043, b"caf\xc3\xa9"),
+        (True, 25, b"t"),
+        (b"\x00\xff", 17, b"\x00\xff"),
+        (memoryview(b"ab"), 17, b"ab"),
+        (9000, 1082, struct.pack("!i", 9000)),  # Days since 2000-01-01 (executor)
+        (date(2000, 1, 31), 1082, struct.pack("!i", 30)),
+        ("1999-12-31", 1082, struct.pack("!i", -1)),
+        (time(0, 0, 1, 5), 1083, struct.pack("!q", 1_000_005)),
+        ("1 day 00:00:01", 1186, struct.pack("!qii", 1_000_000, 1, 0)),
+        ("00:00:01+01", 1266, struct.pack("!qi", 1_000_000, -3600)),
+        ("2000-01-01 00:00:01.5", 1114, struct.pack("!q", 1_500_000)),
+        (2**60 + 946_684_800_000_000, 1114, struct.pack("!q", 0)),  # %PosixTime
+        (datetime(2000, 1, 1, 2, tzinfo=UTC), 1184, struct.pack("!q", 7_200_000_000)),
+        (str(UUID), 2950, UUID.bytes),
+        ('{"a": 1}', 3802, b'\x01{"a": 1}'),
+    ],
+)
+def test_binary_send_forms(value, type_oid, expected):
+    assert encode_result_value(value, type_oid) == expected
+
+
+@pytest.mark.parametrize("value", ["12345678901234567890.123456789012", "-0.0001", "100", "0"])
+def test_numeric_keeps_every_digit(value):
+    assert decode_numeric(encode_result_value(Decimal(value), 1700)) == value
+    assert decode_numeric(encode_result_value(value, 1700)) == value
+
+
+def test_numeric_uses_the_column_scale():
+    typmod = ((12 << 16) | 2) + 4  # numeric(12,2)
+
+    assert decode_numeric(encode_result_value(Decimal("10.5"), 1700, typmod)) == "10.50"
+    assert decode_numeric(encode_result_value(19.99, 1700, typmod)) == "19.99"
+    assert decode_numeric(encode_result_value(Decimal("7"), 1700)) == "7"
+
+
+def test_values_not_of_the_column_type_fail():
+    with pytest.raises(InvalidResultValue, match='type integer: "abc"') as error:
+        encode_result_value("abc", 23)
+    assert error.value.sqlstate == "22P02"
+    with pytest.raises(InvalidResultValue, match="type date"):
+        encode_result_value("soon", 1082)
+    with pytest.raises(NumericValueOutOfRange):
+        encode_result_value(2**31, 23)
+
+
+def test_format_codes_per_column():
+    assert result_format([], 0, 23) == 0
+    assert result_format([1], 3, 23) == 1
+    assert result_format([0, 1], 1, 1700) == 1
+    assert result_format([1, 0], 1, 1700) == 0
+    assert result_format([1], 0, 790) == 0  # money: no binary form, sent as text
+
+
+def _fields(data_row: bytes) -> list[bytes | None]:
+    count = struct.unpack("!H", data_row[:2])[0]
+    fields, pos = [], 2
+    for _ in range(count):
+        length = struct.unpack("!i", data_row[pos : pos + 4])[0]
+        pos += 4
+        if length < 0:
+            fields.append(None)
+            continue
+        fields.append(data_row[pos : pos + length])
+        pos += length
+    return fields
+
+
+def _format_codes(row_description: bytes) -> list[int]:
+    count = struct.unpack("!H", row_description[:2])[0]
+    codes, pos = [], 2
+    for _ in range(count):
+        pos = row_description.index(b"\x00", pos) + 1 + 16
+        codes.append(struct.unpack("!H", row_description[pos : pos + 2])[0])
+        pos += 2
+    return codes
+
+
+COLUMNS = [
+    {"name": "id", "type_oid": 20, "type_size": 8},
+    {"name": "price", "type_oid": 1700, "type_size": -1},
+    {"name": "active", "type_oid": 16, "type_size": 1},
+    {"name": "photo", "type_oid": 17, "type_size": -1},
+    {"name": "created", "type_oid": 1114, "type_size": 8},
+    {"name": "fee", "type_oid": 790, "type_size": 8},
+    {"name": "note", "type_oid": 25, "type_size": -1},
+]
+ROW = [7, Decimal("19.99"), 0, b"\x89PNG", "2000-01-02 00:00:00", Decimal("1.5"), None]
+
+
+def _run(rows):
+    async def run():
+        reader = asyncio.StreamReader()
+        parse = frontend_message(b"P", b"\x00SELECT * FROM items\x00" + struct.pack("!H", 0))
+        bind = frontend_message(b"B", b"\x00\x00" + struct.pack("!HHHH", 0, 0, 1, 1))
+        describe = frontend_message(b"D", b"P\x00")
+        execute = frontend_message(b"E", b"\x00" + struct.pack("!I", 0))
+        reader.feed_data(
+            parse + bind + describe + execute + frontend_message(b"S") + frontend_message(b"X")
+        )
+        reader.feed_eof()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(
+            return_value={
+                "success": True,
+                "rows": rows,
+                "columns": COLUMNS,
+                "row_count": len(rows),
+                "command_tag": f"SELECT {len(rows)}",
+            }
+        )
+        protocol = PGWireProtocol(reader, FakeWriter(), executor, "binary")
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return backend_messages(protocol.writer.buffer)
+
+    return asyncio.run(run())
+
+
+def test_data_rows_follow_the_row_description():
+    messages = _run([ROW])
+
+    types = [m[0] for m in messages]
+    row_description = messages[types.index(b"T")][1]
+    data_row = messages[types.index(b"D")][1]
+    assert _format_codes(row_description) == [1, 1, 1, 1, 1, 0, 1]
+    fields = _fields(data_row)
+    assert fields[0] == struct.pack("!q", 7)
+    assert decode_numeric(fields[1]) == "19.99"
+    assert fields[2:5] == [b"\x00", b"\x89PNG", struct.pack("!q", 86_400_000_000)]
+    assert fields[5:] == [b"$1.50", None]  # money in text, as described
+
+
+def test_unencodable_value_fails_the_statement():
+    messages = _run([[7, "n/a", 1, b"", "2000-01-02 00:00:00", "1 day", None]])
+
+    errors = [body for kind, body in messages if kind == b"E"]
+    assert len(errors) == 1
+    assert b"C22P02" in errors[0] and b'type numeric: "n/a"' in errors[0]
+    assert b"D" not in [kind for kind, _ in messages]
+
+
+def _bound_text_parameters(type_oids: list[int], values: list[bytes], name: str):
+    """Parameters the executor receives for text-format Bind values of type_oids."""
+
+    async def run():
+        reader = asyncio.StreamReader()
+        placeholders = ", ".join(f"${i + 1}" for i in range(len(values)))
+        parse = f"\x00INSERT INTO t VALUES ({placeholders})\x00".encode() + struct.pack(
+            f"!H{len(type_oids)}I", len(type_oids), *type_oids
+        )
+        bind = b"\x00\x00" + struct.pack("!HH", 0, len(values))
+        bind += b"".join(struct.pack("!I", len(v)) + v for v in values) + struct.pack("!H", 0)
+        execute = frontend_message(b"E", b"\x00" + struct.pack("!I", 0))
+        reader.feed_data(
+            frontend_message(b"P", parse)
+            + frontend_message(b"B", bind)
+            + execute
+            + frontend_message(b"S")
+            + frontend_message(b"X")
+        )
+        reader.feed_eof()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(
+            return_value={"success": True, "rows": [], "columns": [], "row_count": 1}
+        )
+        protocol = PGWireProtocol(reader, FakeWriter(), executor, name)
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return executor.execute_query.await_args.kwargs.get("params")
+
+    return asyncio.run(run())
+
+
+def test_text_decimal_parameters_keep_every_digit():
+    values = [b"12345678901234567.89", b"0.1", b"98765432109876543.21", b"2.5"]
+    params = _bound_text_parameters([1700, 1700, 0, 701], values, "numeric")
+
+    # Declared numeric and unspecified decimals a float would round stay decimal text
+    assert params == ["12345678901234567.89", "0.1", "98765432109876543.21", 2.5]
+
+
+def test_text_bytea_parameters_are_bound_as_bytes():
+    values = [b"\\x89504e47", b"a\\000b"]
+    params = _bound_text_parameters([17, 17], values, "bytea")
+
+    assert params == [b"\x89PNG", b"a\x00b"]
+
+
+@pytest.mark.parametrize("value", [b"\\xzz", b"\\xabc", b"a\\b"])
+def test_invalid_text_bytea_parameter_fails_bind(value):
+    protocol = PGWireProtocol(MagicMock(), FakeWriter(), MagicMock(), "bytea")
+    protocol.prepared_statements["s"] = {"query": "SELECT ?", "param_types": [17]}
+    bind = b"\x00s\x00" + struct.pack("!HHI", 0, 1, len(value)) + value + struct.pack("!H", 0)
+
+    asyncio.run(protocol.handle_bind_message(bind))
+
+    errors = [body for kind, body in backend_messages(protocol.writer.buffer) if kind == b"E"]
+    assert b"C22P02\x00" in errors[0]
+    assert b"Minvalid input syntax for type bytea\x00" in errors[0]
+    assert "" not in protocol.portals
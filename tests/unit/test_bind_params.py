@@ -0,0 +1,121 @@
+"""
+Unit Tests: Binary Bind Parameters
+
+Binary-format Bind parameters of the core types decoded into IRIS parameter
+values, arrays as vector text or array literals, and 22P03 for data that is
+not a value of its declared type.
+"""
+
+import struct
+import uuid
+from decimal import Decimal
+
+import pytest
+
+from iris_pgwire.bind_params import InvalidBinaryParameter, decode_binary_parameter
+from iris_pgwire.copy_binary import encode_numeric
+from iris_pgwire.numeric_range import NumericValueOutOfRange
+
+UUID = uuid.UUID("12345678-1234-5678-1234-567812345678")
+
+
+def _array(element_oid: int, elements: list[bytes | None], dimensions=None) -> bytes:
+    dimensions = dimensions or [len(elements)]
+    header = struct.pack("!iiI", len(dimensions), int(None in elements), element_oid)
+    header += b"".join(struct.pack("!ii", size, 1) for size in dimensions)
+    body = b"".join(
+        b"\xff\xff\xff\xff" if e is None else struct.pack("!i", len(e)) + e for e in elements
+    )
+    return header + body
+
+
+@pytest.mark.parametrize(
+    "data, type_oid, expected",
+    [
+        (struct.pack("!h", -7), 21, -7),
+        (struct.pack("!i", 42), 23, 42),
+        (struct.pack("!q", 2**40), 20, 2**40),
+        (struct.pack("!i", 5), 20, 5),  # Narrower width than declared
+        (struct.pack("!I", 2**32 - 1), 26, 2**32 - 1),
+        (struct.pack("!f", 1.5), 700, 1.5),
+        (struct.pack("!d", -0.25), 701, -0.25),
+        (
+            encode_numeric(Decimal("12345678901234567890.000000000001")),
+            1700,
+            "12345678901234567890.000000000001",
+        ),
+        (b"\x01", 16, 1),
+        (b"\x00", 16, 0),
+        (UUID.bytes, 2950, str(UUID)),
+        (b"\x00\xff", 17, b"\x00\xff"),
+        (b"caf\xc3\xa9", 1043, "café"),
+        (b'\x01{"a": 1}', 3802, '{"a": 1}'),
+        (struct.pack("!i", -1), 1082, "1999-12-31"),
+        (struct.pack("!i", 2**31 - 1), 1082, "infinity"),
+        (struct.pack("!q", 3_723_000_004), 1083, "01:02:03.000004"),
+        (struct.pack("!qi", 3_600_000_000, -7200), 1266, "23:00:00.000000"),  # 01:00+02 in UTC
+        (struct.pack("!q", 86_400_500_000), 1114, "2000-01-02 00:00:00.500000"),
+        (struct.pack("!q", -(2**63)), 1184, "-infinity"),
+        (struct.pack("!qii", 14_706_000_000, 3, 14), 1186, "1 year 2 mons 3 days 04:05:06"),
+        (struct.pack("!qii", 0, 0, 0), 1186, "00:00:00"),
+        (struct.pack("!q", -1234), 790, "-12.34"),
+    ],
+)
+def test_core_types(data, type_oid, expected):
+    assert decode_binary_parameter(data, 0, type_oid) == expected
+
+
+def test_timestamptz_is_sent_in_utc():
+    # Binary timestamptz is microseconds since 2000-01-01 00:00 UTC
+    data = struct.pack("!q", 3_600_000_000)
+    assert decode_binary_parameter(data, 0, 1184) == "2000-01-01 01:00:00.000000"
+
+
+def test_numeric_arrays_become_vector_text():
+    floats = _array(701, [struct.pack("!d", 0.5), struct.pack("!d", 1.25)])
+    assert decode_binary_parameter(floats, 0, 1022) == "[0.5,1.25]"
+    integers = _array(23, [struct.pack("!i", 1), struct.pack("!i", -2)])
+    assert decode_binary_parameter(integers, 0, 1007) == "[1,-2]"
+    assert decode_binary_parameter(integers, 0, 0) == "[1,-2]"  # Unspecified type
+
+
+def test_other_arrays_become_array_literals():
+    texts = _array(25, [b"a", b"b c", None, b'q"', b""])
+    assert decode_binary_parameter(texts, 0, 1009) == '{a,"b c",NULL,"q\\"",""}'
+    booleans = _array(16, [b"\x01", b"\x00"])
+    assert decode_binary_parameter(booleans, 0, 1000) == "{t,f}"
+    with_null = _array(23, [struct.pack("!i", 1), None])
+    assert decode_binary_parameter(with_null, 0, 1007) == "{1,NULL}"
+    matrix = _array(23, [struct.pack("!i", n) for n in range(1, 5)], dimensions=[2, 2])
+    assert decode_binary_parameter(matrix, 0, 1007) == "{{1,2},{3,4}}"
+    assert decode_binary_parameter(struct.pack("!iiI", 0, 0, 25), 0, 1009) == "{}"
+
+
+def test_unspecified_types_are_read_by_length():
+    assert decode_binary_parameter(b"\x01", 0, 0) == 1
+    assert decode_binary_parameter(struct.pack("!q", 9), 0, 0) == 9
+    assert decode_binary_parameter(b"hello", 0, 0) == "hello"
+
+
+@pytest.mark.parametrize(
+    "data, type_oid",
+    [
+        (b"\x00\x00\x01", 23),
+        (b"\x00" * 3, 701),
+        (b"\x02", 16),
+        (b"\x00" * 4, 1114),
+        (b"\x00" * 15, 2950),
+        (b'{"a": 1}', 3802),  # No version byte
+        (b"\xff\xfe", 25),
+        (_array(23, [struct.pack("!i", 1)])[:-2], 1007),
+    ],
+)
+def test_invalid_data_fails_with_22p03(data, type_oid):
+    with pytest.raises(InvalidBinaryParameter, match="bind parameter 3") as error:
+        decode_binary_parameter(data, 2, type_oid)
+    assert error.value.sqlstate == "22P03"
+
+
+def test_integers_are_range_checked():
+    with pytest.raises(NumericValueOutOfRange):
+        decode_binary_parameter(struct.pack("!q", 2**40), 0, 23)
@@ -0,0 +1,150 @@
+"""
+Unit Tests: Boolean Columns, Parameters and Literals
+
+BIT columns are described and sent as bool, bool parameters are bound as
+1 / 0 from any text boolin accepts, and TRUE / FALSE and boolean casts of
+string literals are rewritten onto BIT values.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.bool_values import InvalidBoolean, format_bool, parse_bool
+from iris_pgwire.column_types import describe_column
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.boolean_translator import BooleanTranslator
+
+
+@pytest.mark.parametrize(
+    "text,expected",
+    [
+        ("t", 1),
+        ("TRUE", 1),
+        (" yes ", 1),
+        ("on", 1),
+        ("1", 1),
+        ("tr", 1),
+        ("f", 0),
+        ("False", 0),
+        ("n", 0),
+        ("of", 0),
+        ("0", 0),
+    ],
+)
+def test_boolean_input(text, expected):
+    assert parse_bool(text) == expected
+
+
+@pytest.mark.parametrize("text", ["", "o", "2", "truth", "nope"])
+def test_invalid_boolean_input(text):
+    with pytest.raises(InvalidBoolean, match="invalid input syntax for type boolean"):
+        parse_bool(text)
+
+
+@pytest.mark.parametrize(
+    "value,expected",
+    [(1, "t"), (0, "f"), (True, "t"), (False, "f"), ("1", "t"), ("0", "f"), ("false", "f")],
+)
+def test_boolean_output(value, expected):
+    assert format_bool(value) == expected
+
+
+@pytest.mark.parametrize("iris_type", [-7, "BIT", "%Library.Boolean", "BOOLEAN"])
+def test_bit_columns_described_as_bool(iris_type):
+    assert describe_column(iris_type) == (16, 1, -1)
+
+
+class TestLiterals:
+    @pytest.mark.parametrize(
+        "sql,expected,count",
+        [
+            (
+                "SELECT * FROM users WHERE active = TRUE AND deleted = false",
+                "SELECT * FROM users WHERE active = 1 AND deleted = 0",
+                2,
+            ),
+            (
+                "INSERT INTO flags (a, b) VALUES ('t'::bool, CAST('no' AS BOOLEAN))",
+                "INSERT INTO flags (a, b) VALUES (CAST(1 AS BIT), CAST(0 AS BIT))",
+                2,
+            ),
+            ("UPDATE users SET active = FALSE", "UPDATE users SET active = 0", 1),
+            (
+                "CREATE TABLE users (id INT, active BOOLEAN DEFAULT TRUE)",
+                "CREATE TABLE users (id INT, active BOOLEAN DEFAULT 1)",
+                1,
+            ),
+        ],
+    )
+    def test_translate(self, sql, expected, count):
+        assert BooleanTranslator().translate(sql) == (expected, count)
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "SELECT * FROM users WHERE active IS TRUE OR deleted IS NOT FALSE",
+            "SELECT 'TRUE' FROM t",
+            "SELECT t.true FROM t",
+            "SELECT CAST(? AS BIT), 'maybe'::boolean FROM t",
+            "SET standard_conforming_strings = true",
+            "EXPLAIN (ANALYZE TRUE) SELECT 1",
+        ],
+    )
+    def test_unchanged(self, sql):
+        assert BooleanTranslator().translate(sql) == (sql, 0)
+
+    def test_normalizer_counts_literals(self):
+        translator = SQLTranslator()
+        sql = translator.normalize_sql("SELECT id FROM users WHERE active = true")
+
+        assert sql == "SELECT ID FROM USERS WHERE ACTIVE = 1"
+        assert translator.get_normalization_metrics()["boolean_literal_count"] == 1
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol():
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), MagicMock(), "bool")
+        protocol.writer.drain = AsyncMock()
+        protocol.prepared_statements["s"] = {"query": "SELECT ?", "param_types": [16, 16, 16]}
+        return protocol
+
+    @staticmethod
+    def _bind(values, formats) -> bytes:
+        body = b"\x00s\x00" + struct.pack("!H", len(formats))
+        body += b"".join(struct.pack("!H", code) for code in formats)
+        body += struct.pack("!H", len(values))
+        body += b"".join(struct.pack("!I", len(value)) + value for value in values)
+        return body + struct.pack("!H", 0)
+
+    def test_parameters_bound_as_bit_values(self):
+        protocol = self._protocol()
+        # pgjdbc's setBoolean, psycopg's text form and Go's binary bool
+        body = self._bind([b"TRUE", b"f", b"\x01"], [0, 0, 1])
+
+        asyncio.run(protocol.handle_bind_message(body))
+
+        assert protocol.portals[""]["params"] == [1, 0, 1]
+
+    def test_invalid_parameter_fails_with_22p02(self):
+        protocol = self._protocol()
+        protocol.send_error_response = AsyncMock()
+
+        asyncio.run(protocol.handle_bind_message(self._bind([b"maybe"], [0])))
+
+        assert "" not in protocol.portals
+        assert protocol.send_error_response.call_args.args[1] == "22P02"
+
+    def test_results_sent_in_text_and_binary(self):
+        protocol = self._protocol()
+        columns = [{"name": "a", "type_oid": 16}, {"name": "b", "type_oid": 16}]
+
+        protocol._current_result_formats = [0, 1]
+        asyncio.run(protocol.send_data_row([1, 0], columns))
+
+        sent = b"".join(call.args[0] for call in protocol.writer.write.call_args_list)
+        assert sent.endswith(struct.pack("!I", 1) + b"t" + struct.pack("!I", 1) + b"\x00")
@@ -0,0 +1,208 @@
+"""
+Unit Tests: Query Cancellation
+
+BackendKeyData keys unique per live session, and CancelRequest cancelling
+the session's running statement (57014) and terminating its IRIS process.
+"""
+
+import asyncio
+import secrets
+import sys
+import threading
+import types
+from concurrent.futures import ThreadPoolExecutor
+
+import pytest
+
+import iris_pgwire.cancellation as cancellation_module
+from iris_pgwire.cancellation import (
+    BackendKeys,
+    StatementCancel,
+    current_statement_cancel,
+    set_statement_cancel,
+)
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.session_labels import SessionLabel, SessionLabels
+
+
+def test_backend_keys_unique_among_live_sessions(monkeypatch):
+    draws = iter([5, 5, 7, 5])
+    fake_secrets = types.SimpleNamespace(randbelow=lambda n: next(draws), randbits=secrets.randbits)
+    monkeypatch.setattr(cancellation_module, "secrets", fake_secrets)
+    keys = BackendKeys()
+
+    first, _ = keys.allocate()
+    second, _ = keys.allocate()
+    keys.release(first)
+
+    third, secret = keys.allocate()
+
+    assert (first, second, third) == (1005, 1007, 1005)
+    assert 0 <= secret < 2**32
+
+
+def test_cancel_running_statement():
+    async def session():
+        statement_cancel = StatementCancel()
+        started = asyncio.Event()
+
+        async def slow_statement():
+            started.set()
+            await asyncio.sleep(60)
+
+        running = asyncio.ensure_future(statement_cancel.run(slow_statement()))
+        await started.wait()
+        cancelled = statement_cancel.cancel()
+        return cancelled, await running, statement_cancel.running
+
+    cancelled, result, still_running = asyncio.run(session())
+
+    assert cancelled is True and still_running is False
+    assert (result["sqlstate"], result["error"]) == (
+        "57014",
+        "canceling statement due to user request",
+    )
+
+
+def test_cancel_without_running_statement():
+    async def session():
+        statement_cancel = StatementCancel()
+        await statement_cancel.run(asyncio.sleep(0, {"success": True}))
+        return statement_cancel.cancel()
+
+    assert asyncio.run(session()) is False
+
+
+def test_session_shutdown_still_cancels():
+    async def session():
+        statement_cancel = StatementCancel()
+        task = asyncio.ensure_future(statement_cancel.run(asyncio.sleep(60)))
+        await asyncio.sleep(0)
+        task.cancel()
+        with pytest.raises(asyncio.CancelledError):
+            await task
+
+    asyncio.run(session())
+
+
+class FakeProtocol:
+    def __init__(self):
+        self.statement_cancel = StatementCancel()
+
+
+class FakeServer:
+    def __init__(self, protocol):
+        self.protocol = protocol
+
+    def find_connection_for_cancellation(self, backend_pid, backend_secret):
+        return self.protocol if (backend_pid, backend_secret) == (4242, 99) else None
+
+
+@pytest.fixture
+def executor():
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.embedded_mode = True
+    executor.server = FakeServer(FakeProtocol())
+    executor.shadow = None
+    executor.iris_config = {"namespace": "USER"}
+    return executor
+
+
+def _cancel_while_running(executor, key=(4242, 99)):
+    protocol = executor.server.protocol
+    started = asyncio.Event()
+
+    async def slow_query(sql, params=None, session_id=None):
+        started.set()
+        await asyncio.sleep(60)
+
+    executor._execute_query = slow_query
+
+    async def client_session():
+        set_statement_cancel(protocol.statement_cancel)
+        return await executor.execute_query("SELECT pg_sleep(60)")
+
+    async def main():
+        query = asyncio.ensure_future(client_session())
+        await started.wait()
+        cancelled = await executor.cancel_query(*key)
+        if not cancelled:
+            query.cancel()
+            return cancelled, None
+        return cancelled, await query
+
+    return asyncio.run(main())
+
+
+def test_executor_cancels_session_statement(executor):
+    cancelled, result = _cancel_while_running(executor)
+
+    assert cancelled is True
+    assert result["success"] is False and result["sqlstate"] == "57014"
+    assert current_statement_cancel() is None
+
+
+def test_wrong_secret_ignored(executor):
+    cancelled, _ = _cancel_while_running(executor, key=(4242, 98))
+
+    assert cancelled is False
+
+
+def test_external_mode_terminates_iris_process(executor, monkeypatch):
+    calls = []
+
+    class FakeNative:
+        def classMethodValue(self, class_name, method, *args):
+            calls.append((class_name, method, *args))
+            return 1
+
+    iris = types.ModuleType("iris")
+    iris.createIRIS = lambda connection: FakeNative()
+    monkeypatch.setitem(sys.modules, "iris", iris)
+
+    statement_connection, admin_connection = object(), object()
+    executor.embedded_mode = False
+    executor.thread_pool = ThreadPoolExecutor(max_workers=1)
+    executor._connection_lock = threading.RLock()
+    executor.session_labels = SessionLabels(enabled=False)
+    executor.session_labels._applied[id(statement_connection)] = (31337, "label")
+    executor._statement_connections = {}
+    executor._session_connection(statement_connection, SessionLabel(4242, "c", "u", ""))
+    executor._get_pooled_connection = lambda *args: admin_connection
+    returned = []
+    executor._return_connection = lambda conn, *args: returned.append(conn)
+
+    cancelled, result = _cancel_while_running(executor)
+
+    assert cancelled is True and result["sqlstate"] == "57014"
+    assert calls == [("%SYSTEM.Process", "Terminate", 31337)]
+    assert returned == [admin_connection]
+
+
+def test_connection_reused_by_another_session_is_not_terminated(executor, monkeypatch):
+    calls = []
+    iris = types.ModuleType("iris")
+    iris.createIRIS = lambda connection: types.SimpleNamespace(
+        classMethodValue=lambda *args: calls.append(args) or 1
+    )
+    monkeypatch.setitem(sys.modules, "iris", iris)
+
+    statement_connection = object()
+    executor.thread_pool = ThreadPoolExecutor(max_workers=1)
+    executor._connection_lock = threading.RLock()
+    executor.session_labels = SessionLabels(enabled=False)
+    executor.session_labels._applied[id(statement_connection)] = (31337, "label")
+    executor._statement_connections = {}
+    executor._session_connection(statement_connection, SessionLabel(4242, "c", "u", ""))
+
+    def statement_ends_meanwhile(*args):
+        # Returned to the pool and taken by session 5151 before Terminate
+        del executor._statement_connections[4242]
+        executor._session_connection(statement_connection, SessionLabel(5151, "c", "u", ""))
+        return object()
+
+    executor._get_pooled_connection = statement_ends_meanwhile
+    executor._return_connection = lambda conn, *args: None
+
+    assert asyncio.run(executor._interrupt_iris_statement(4242)) is False
+    assert calls == []
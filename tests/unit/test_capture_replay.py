@@ -0,0 +1,134 @@
+"""
+Unit Tests: Wire Protocol Capture and Replay
+
+Records a session through the proxy against a minimal fake server, then
+replays the capture against matching and diverging servers.
+"""
+
+import asyncio
+import json
+import struct
+
+import pytest
+
+from iris_pgwire.capture import (
+    CaptureFormatError,
+    CaptureWriter,
+    RecordingProxy,
+    read_capture,
+    replay_capture,
+)
+from iris_pgwire.capture.capture_file import SSL_REQUEST_CODE
+from tests.protocol_messages import frontend_message
+
+
+async def _read_typed(reader):
+    header = await reader.readexactly(5)
+    body = await reader.readexactly(struct.unpack("!I", header[1:])[0] - 4)
+    return header[:1], body
+
+
+def fake_server(fail_queries: bool = False):
+    """Trust-auth server answering every simple query with one row."""
+
+    async def handle(reader, writer):
+        length = struct.unpack("!I", await reader.readexactly(4))[0]
+        await reader.readexactly(length - 4)
+        writer.write(frontend_message(b"R", struct.pack("!I", 0)) + frontend_message(b"Z", b"I"))
+        try:
+            while True:
+                message_type, _ = await _read_typed(reader)
+                if message_type == b"X":
+                    break
+                if fail_queries:
+                    writer.write(frontend_message(b"E", b"SERROR\x00C42P01\x00\x00"))
+                else:
+                    writer.write(frontend_message(b"D", struct.pack("!HI", 1, 1) + b"1"))
+                    writer.write(frontend_message(b"C", b"SELECT 1\x00"))
+                writer.write(frontend_message(b"Z", b"I"))
+                await writer.drain()
+        except asyncio.IncompleteReadError:
+            pass
+        writer.close()
+
+    return handle
+
+
+async def _client(port: int, queries: int = 2):
+    reader, writer = await asyncio.open_connection("127.0.0.1", port)
+    writer.write(struct.pack("!II", 8, SSL_REQUEST_CODE))
+    assert await reader.readexactly(1) == b"N"
+
+    body = struct.pack("!I", 196608) + b"user\x00test\x00\x00"
+    writer.write(struct.pack("!I", len(body) + 4) + body)
+    for _ in range(2):
+        await _read_typed(reader)
+    for _ in range(queries):
+        writer.write(frontend_message(b"Q", b"SELECT 1\x00"))
+        while (await _read_typed(reader))[0] != b"Z":
+            pass
+    writer.write(frontend_message(b"X"))
+    await writer.drain()
+    writer.close()
+
+
+async def _record(tmp_path):
+    server = await asyncio.start_server(fake_server(), "127.0.0.1", 0)
+    port = server.sockets[0].getsockname()[1]
+    capture = tmp_path / "session.capture"
+    proxy = RecordingProxy(CaptureWriter(capture), "127.0.0.1", port, listen_port=0)
+    await proxy.start()
+    await _client(proxy.listen_port)
+    await asyncio.sleep(0.1)
+    await proxy.stop()
+    server.close()
+    return capture
+
+
+class TestCapture:
+    def test_records_both_directions(self, tmp_path):
+        capture = asyncio.run(_record(tmp_path))
+
+        messages = read_capture(capture)[1]
+        frontend = [m.message_type for m in messages if m.direction == "F"]
+        backend = [m.message_type for m in messages if m.direction == "B"]
+
+        # SSLRequest is answered by the proxy and not recorded
+        assert frontend == ["", "Q", "Q", "X"]
+        assert backend == ["R", "Z", "D", "C", "Z", "D", "C", "Z"]
+
+    def test_rejects_foreign_file(self, tmp_path):
+        path = tmp_path / "not-a-capture.jsonl"
+        path.write_text(json.dumps({"format": "pcap"}) + "\n")
+
+        with pytest.raises(CaptureFormatError):
+            read_capture(path)
+
+
+class TestReplay:
+    @staticmethod
+    def _replay(tmp_path, fail_queries):
+        async def scenario():
+            capture = await _record(tmp_path)
+            server = await asyncio.start_server(fake_server(fail_queries), "127.0.0.1", 0)
+            port = server.sockets[0].getsockname()[1]
+            try:
+                return await replay_capture(str(capture), "127.0.0.1", port, sync_timeout=5)
+            finally:
+                server.close()
+
+        return asyncio.run(scenario())
+
+    def test_matching_server(self, tmp_path):
+        report = self._replay(tmp_path, fail_queries=False)
+
+        assert report.ok
+        assert report.sessions[0].received == ["R", "Z", "D", "C", "Z", "D", "C", "Z"]
+
+    def test_diverging_server(self, tmp_path):
+        report = self._replay(tmp_path, fail_queries=True)
+
+        assert not report.ok
+        session = report.sessions[0]
+        assert session.first_divergence() == 2
+        assert "expected 'D', got 'E'" in session.describe()
@@ -0,0 +1,171 @@
+"""
+Unit Tests: DROP ... CASCADE
+
+Dependent views and foreign keys resolved from INFORMATION_SCHEMA (simulated
+in memory) and dropped before the named tables, views and schemas.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.cascade import (
+    CascadeDrop,
+    DroppedObject,
+    parse_cascade_drop,
+    plan_cascade,
+)
+from iris_pgwire.iris_executor import IRISExecutor
+
+
+class FakeCatalog:
+    """INFORMATION_SCHEMA answers for the lookups plan_cascade makes."""
+
+    def __init__(self, tables=(), views=None, keys=None):
+        self.tables = list(tables)  # (schema, name, type)
+        self.views = views or {}  # (schema, name) -> [(view schema, view name)]
+        self.keys = keys or {}  # (schema, table) -> [(schema, table, constraint)]
+
+    async def lookup(self, sql):
+        if "VIEW_TABLE_USAGE" in sql or "REFERENTIAL_CONSTRAINTS" in sql:
+            dependents = self.views if "VIEW_TABLE_USAGE" in sql else self.keys
+            matching = [rows for key, rows in dependents.items() if self._about(sql, key)]
+            return [row for rows in matching for row in rows]
+        return [(name, kind) for schema, name, kind in self.tables if f"LOWER('{schema}')" in sql]
+
+    @staticmethod
+    def _about(sql, key):
+        schema, name = key
+        return f"LOWER('{schema}')" in sql and f"LOWER('{name}')" in sql
+
+
+def plan(drop, catalog):
+    return asyncio.run(plan_cascade(drop, "SQLUser", catalog.lookup))
+
+
+class TestParsing:
+    def test_drop_cascade(self):
+        drop = parse_cascade_drop('DROP TABLE orders, sales."Items" CASCADE;')
+
+        assert drop == CascadeDrop("table", ["orders", 'sales."Items"'])
+        assert drop.tag == "DROP TABLE"
+
+    @pytest.mark.parametrize(
+        "sql",
+        ["DROP TABLE orders", "DROP TABLE orders RESTRICT", "DROP INDEX idx CASCADE"],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_cascade_drop(sql) is None
+
+
+class TestPlan:
+    def test_dependent_views_first(self):
+        catalog = FakeCatalog(
+            views={
+                ("SQLUser", "orders"): [("SQLUser", "v_totals"), ("SQLUser", "v_orders")],
+                ("SQLUser", "v_orders"): [("SQLUser", "v_totals")],
+            }
+        )
+
+        result = plan(CascadeDrop("table", ["public.orders"]), catalog)
+
+        assert [dropped.drop_sql() for dropped in result.drops] == [
+            "DROP VIEW SQLUser.v_totals",
+            "DROP VIEW SQLUser.v_orders",
+            "DROP TABLE SQLUser.orders",
+        ]
+        assert result.notice() == (
+            "NOTICE",
+            "00000",
+            "drop cascades to 2 other objects",
+            "drop cascades to view v_totals\ndrop cascades to view v_orders",
+        )
+
+    def test_foreign_keys(self):
+        catalog = FakeCatalog(
+            keys={
+                ("SQLUser", "customers"): [
+                    ("SQLUser", "orders", "orders_customer_fk"),
+                    ("SQLUser", "customers", "customers_parent_fk"),
+                ]
+            }
+        )
+
+        result = plan(CascadeDrop("table", ["customers"]), catalog)
+
+        assert [dropped.drop_sql() for dropped in result.drops] == [
+            "ALTER TABLE SQLUser.orders DROP CONSTRAINT orders_customer_fk",
+            "DROP TABLE SQLUser.customers",
+        ]
+        assert result.notice()[2] == (
+            "drop cascades to constraint orders_customer_fk on table orders"
+        )
+
+    def test_schema(self):
+        catalog = FakeCatalog(
+            tables=[("sales", "Orders", "BASE TABLE"), ("sales", "OrderTotals", "VIEW")],
+            views={("sales", "Orders"): [("sales", "OrderTotals")]},
+        )
+
+        result = plan(CascadeDrop("schema", ["sales"]), catalog)
+
+        assert [dropped.drop_sql() for dropped in result.drops] == [
+            'DROP VIEW sales."OrderTotals"',
+            'DROP TABLE sales."Orders"',
+            "DROP SCHEMA sales",
+        ]
+        assert result.cascaded == [
+            DroppedObject("view", "sales", "OrderTotals"),
+            DroppedObject("table", "sales", "Orders"),
+        ]
+
+    def test_public_schema_emptied_not_dropped(self):
+        catalog = FakeCatalog(tables=[("SQLUser", "orders", "BASE TABLE")])
+
+        result = plan(CascadeDrop("schema", ["public"]), catalog)
+
+        assert [dropped.drop_sql() for dropped in result.drops] == ["DROP TABLE SQLUser.orders"]
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch, failing=None):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.executed = []
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_execute(sql, params=None, session_id=None):
+            if "VIEW_TABLE_USAGE" in sql and "LOWER('orders')" in sql:
+                return {"success": True, "rows": [("SQLUser", "v_orders")], "columns": []}
+            if sql.startswith("SELECT"):
+                return {"success": True, "rows": [], "columns": []}
+            executor.executed.append(sql)
+            if failing and failing in sql:
+                return {"success": False, "error": "SQLCODE -30", "sqlstate": "42P01"}
+            return {"success": True, "rows": [], "columns": []}
+
+        executor._execute_query = fake_execute
+        return executor
+
+    def _run(self, executor, sql):
+        return asyncio.run(executor._execute_cascade_drop(parse_cascade_drop(sql)))
+
+    def test_drops_in_order(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        result = self._run(executor, "DROP TABLE orders CASCADE")
+
+        assert executor.executed == ["DROP VIEW SQLUser.v_orders", "DROP TABLE SQLUser.orders"]
+        assert result["command_tag"] == "DROP TABLE"
+        assert result["notices"] == [("NOTICE", "00000", "drop cascades to view v_orders")]
+
+    def test_failed_drop_stops(self, monkeypatch):
+        executor = self._executor(monkeypatch, failing="DROP VIEW")
+
+        result = self._run(executor, "DROP TABLE orders CASCADE")
+
+        assert result["sqlstate"] == "42P01"
+        assert executor.executed == ["DROP VIEW SQLUser.v_orders"]
@@ -0,0 +1,174 @@
+"""
+Unit Tests: Catalog Query Fast Path
+
+Well-known driver catalog queries (Npgsql type loading, pgJDBC getTables,
+psql \\d) recognized by shape and answered from the schema cache.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.catalog.schema_cache as schema_cache_module
+from iris_pgwire.catalog.fast_path import fingerprint, recognize, shape
+from iris_pgwire.catalog.schema_cache import STAMP_SQL, SchemaCache
+from iris_pgwire.iris_executor import IRISExecutor
+
+NPGSQL_TYPES = """
+-- Load types
+SELECT ns.nspname, t.oid, t.typname, t.typtype, t.typnotnull, t.elemtypoid
+FROM (
+    -- Arrays have typtype=b - this subquery identifies them by their typreceive and converts
+    -- their typtype to a
+    SELECT
+        typ.oid, typ.typnamespace, typ.typname, typ.typtype, typ.typrelid, typ.typnotnull,
+        typ.relkind, elemtyp.oid AS elemtypoid, elemtyp.typname AS elemtypname
+    FROM pg_type AS typ
+    LEFT JOIN pg_type AS elemtyp ON elemtyp.oid = typ.typelem
+) AS t
+JOIN pg_namespace AS ns ON (ns.oid = typnamespace)
+ORDER BY CASE WHEN typtype IN ('b', 'e', 'p') THEN 0 ELSE 1 END
+"""
+
+JDBC_GET_TABLES = (
+    "SELECT NULL AS TABLE_CAT, n.nspname AS TABLE_SCHEM, c.relname AS TABLE_NAME,  "
+    "CASE n.nspname ~ '^pg_' OR n.nspname = 'information_schema'  WHEN true THEN "
+    "CASE  WHEN n.nspname = 'pg_catalog' OR n.nspname = 'information_schema' THEN "
+    "CASE c.relkind   WHEN 'r' THEN 'SYSTEM TABLE'   ELSE NULL   END  ELSE NULL END "
+    "WHEN false THEN CASE c.relkind  WHEN 'r' THEN 'TABLE'  WHEN 'v' THEN 'VIEW'  ELSE NULL  END "
+    "ELSE NULL  END  AS TABLE_TYPE, d.description AS REMARKS  "
+    "FROM pg_catalog.pg_namespace n, pg_catalog.pg_class c  "
+    "LEFT JOIN pg_catalog.pg_description d ON (c.oid = d.objoid AND d.objsubid = 0) "
+    "WHERE c.relnamespace = n.oid  AND c.relname LIKE 'orders' "
+    "ORDER BY TABLE_TYPE,TABLE_SCHEM,TABLE_NAME "
+)
+
+
+def psql_describe(table):
+    return f"""SELECT c.oid,
+  n.nspname,
+  c.relname
+FROM pg_catalog.pg_class c
+     LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relname OPERATOR(pg_catalog.~) '^({table})$' COLLATE pg_catalog.default
+  AND pg_catalog.pg_table_is_visible(c.oid)
+ORDER BY 2, 3;"""
+
+
+PSQL_COLUMNS = """SELECT a.attname,
+  pg_catalog.format_type(a.atttypid, a.atttypmod),
+  a.attnotnull
+FROM pg_catalog.pg_attribute a
+WHERE a.attrelid = '16385' AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY a.attnum;"""
+
+
+@pytest.mark.parametrize(
+    "sql, name",
+    [
+        (NPGSQL_TYPES, "npgsql_types"),
+        (JDBC_GET_TABLES, "jdbc_get_tables"),
+        (psql_describe("orders"), "psql_describe_lookup"),
+        (PSQL_COLUMNS, "psql_describe_columns"),
+    ],
+)
+def test_driver_queries_recognized(sql, name):
+    assert recognize(sql).name == name
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT * FROM pg_class",
+        "SELECT typname FROM pg_type WHERE oid = $1",
+        "SELECT 'elemtypoid typnotnull from pg_type' AS trick",
+        "SELECT * FROM orders",
+    ],
+)
+def test_other_queries_not_recognized(sql):
+    assert recognize(sql) is None
+
+
+def test_shape_ignores_literals_comments_and_layout():
+    assert fingerprint(psql_describe("orders")) == fingerprint(psql_describe("customers"))
+    assert shape("SELECT  a -- note\n FROM t WHERE x = 'it''s' AND y > 10;") == (
+        "select a from t where x=? and y > ?"
+    )
+
+
+class FakeIRIS:
+    """Counts executions of the catalog query; answers the stamp query with stamp."""
+
+    def __init__(self):
+        self.stamp = [3, "2026-10-01 09:00:00"]
+        self.catalog_runs = 0
+        self.failing = False
+
+    async def execute(self, sql, params=None, session_id=None):
+        if sql == STAMP_SQL:
+            return {"success": True, "rows": [list(self.stamp)]}
+        if sql.startswith("CREATE"):
+            return {"success": True, "rows": [], "command_tag": "CREATE TABLE"}
+        self.catalog_runs += 1
+        if self.failing:
+            return {"success": False, "error": "boom", "rows": []}
+        return {
+            "success": True,
+            "rows": [[16385, "SQLUser", "orders"]],
+            "columns": [{"name": "oid"}, {"name": "nspname"}, {"name": "relname"}],
+        }
+
+
+@pytest.fixture
+def executor(monkeypatch):
+    monkeypatch.setattr(schema_cache_module, "_schema_cache", None)
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.iris_config = {"namespace": "USER"}
+    executor.schema_cache = SchemaCache(enabled=True, check_interval=60)
+    executor.shadow = None
+    executor.fake = FakeIRIS()
+    executor._execute_query = executor.fake.execute
+    return executor
+
+
+def _run(executor, *statements):
+    async def session():
+        return [await executor.execute_query(sql) for sql in statements]
+
+    return asyncio.run(session())
+
+
+def test_repeated_query_served_from_cache(executor):
+    first, second = _run(executor, psql_describe("orders"), psql_describe("orders"))
+
+    assert executor.fake.catalog_runs == 1
+    assert second["rows"] == [(16385, "SQLUser", "orders")]
+    assert second["columns"] == first["columns"]
+
+
+def test_cached_per_statement(executor):
+    _run(executor, psql_describe("orders"), psql_describe("customers"))
+
+    assert executor.fake.catalog_runs == 2
+
+
+def test_ddl_invalidates(executor):
+    _run(executor, PSQL_COLUMNS, "CREATE TABLE t (id INT)", PSQL_COLUMNS)
+
+    assert executor.fake.catalog_runs == 2
+
+
+def test_failures_not_cached(executor):
+    executor.fake.failing = True
+
+    _run(executor, PSQL_COLUMNS, PSQL_COLUMNS)
+
+    assert executor.fake.catalog_runs == 2
+
+
+def test_disabled(executor, monkeypatch):
+    monkeypatch.setenv("PGWIRE_CATALOG_FAST_PATH", "off")
+
+    _run(executor, PSQL_COLUMNS, PSQL_COLUMNS)
+
+    assert executor.fake.catalog_runs == 2
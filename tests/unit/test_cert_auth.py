@@ -0,0 +1,209 @@
+"""
+Unit Tests: Client Certificate Authentication
+
+pg_ident.conf user name maps, the names taken from client certificates and
+mutual TLS logins without a password.
+"""
+
+import asyncio
+import shutil
+import ssl
+import struct
+import subprocess
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.cert_auth import (
+    CertAuthConfig,
+    CertificateAuthenticationFailed,
+    authenticate_certificate,
+    certificate_names,
+)
+from iris_pgwire.ident_map import IdentMap
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.server_tls import ServerTLSConfig
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import startup_message
+
+IDENT_FILE = r"""
+# MAPNAME  SYSTEM-USERNAME          DATABASE-USERNAME
+certmap    billing-service          BILLING
+certmap    /^(.*)@example\.com$     \1   # e-mail addresses
+certmap    ops.example.com          all
+certmap    "/not a pattern"         LITERAL
+othermap   billing-service          ADMIN
+"""
+
+PEERCERT = {
+    "subject": (
+        (("countryName", "US"),),
+        (("organizationName", "Example, Inc."),),
+        (("commonName", "billing-service"),),
+    ),
+    "subjectAltName": (("DNS", "billing.example.com"), ("email", "svc@example.com")),
+}
+
+
+@pytest.mark.parametrize(
+    "map_name, system_user, database_user, allowed",
+    [
+        ("certmap", "billing-service", "BILLING", True),
+        ("certmap", "billing-service", "billing", False),  # Case-sensitive
+        ("certmap", "alice@example.com", "alice", True),
+        ("certmap", "alice@example.com", "bob", False),
+        ("certmap", "ops.example.com", "ANYONE", True),
+        ("certmap", "/not a pattern", "LITERAL", True),
+        ("othermap", "billing-service", "BILLING", False),
+    ],
+)
+def test_ident_map(map_name, system_user, database_user, allowed):
+    ident_map = IdentMap.parse(IDENT_FILE)
+
+    assert ident_map.check(map_name, system_user, database_user) is allowed
+
+
+def test_certificate_names():
+    assert certificate_names(PEERCERT) == ["billing-service"]
+    assert certificate_names(PEERCERT, "DN") == ["CN=billing-service,O=Example\\, Inc.,C=US"]
+    assert certificate_names(PEERCERT, "SAN") == ["billing.example.com", "svc@example.com"]
+
+
+def test_authenticate_certificate():
+    ident_map = IdentMap.parse(IDENT_FILE)
+
+    assert authenticate_certificate(CertAuthConfig("optional"), PEERCERT, "billing-service")
+    assert authenticate_certificate(
+        CertAuthConfig("optional", "certmap"), PEERCERT, "BILLING", ident_map
+    )
+    assert authenticate_certificate(
+        CertAuthConfig("required", "certmap", "SAN"), PEERCERT, "svc", ident_map
+    )
+    assert not authenticate_certificate(CertAuthConfig("off"), PEERCERT, "anyone")
+    assert not authenticate_certificate(CertAuthConfig("optional"), None, "alice")
+
+    with pytest.raises(CertificateAuthenticationFailed) as error:
+        authenticate_certificate(CertAuthConfig("optional"), PEERCERT, "BILLING")
+    assert error.value.sqlstate == "28000"
+    assert str(error.value) == 'certificate authentication failed for user "BILLING"'
+
+    with pytest.raises(CertificateAuthenticationFailed, match="requires a valid client"):
+        authenticate_certificate(CertAuthConfig("required"), None, "alice")
+
+
+def test_invalid_settings(monkeypatch):
+    monkeypatch.setenv("PGWIRE_CERT_AUTH", "verify-full")
+    with pytest.raises(ValueError, match="PGWIRE_CERT_AUTH"):
+        CertAuthConfig.from_env()
+
+    monkeypatch.setenv("PGWIRE_CERT_AUTH", "Required")
+    monkeypatch.setenv("PGWIRE_CERT_CLIENTNAME", "dn")
+    assert CertAuthConfig.from_env() == CertAuthConfig("required", None, "DN")
+
+
+@pytest.fixture
+def certificates(tmp_path):
+    """A CA, a localhost server certificate and a client certificate for CN=alice."""
+    if shutil.which("openssl") is None:
+        pytest.skip("openssl not available")
+
+    def openssl(*args):
+        subprocess.run(["openssl", *args], cwd=tmp_path, check=True, capture_output=True)
+
+    openssl(
+        *("req", "-x509", "-newkey", "rsa:2048", "-nodes", "-days", "1"),
+        *("-subj", "/CN=Test CA", "-keyout", "ca.key", "-out", "ca.pem"),
+    )
+    (tmp_path / "san.ext").write_text("subjectAltName=DNS:localhost\n")
+    for name, subject in (("server", "/CN=localhost"), ("client", "/O=Example/CN=alice")):
+        openssl(
+            *("req", "-newkey", "rsa:2048", "-nodes", "-subj", subject),
+            *("-keyout", f"{name}.key", "-out", f"{name}.csr"),
+        )
+        openssl(
+            *("x509", "-req", "-in", f"{name}.csr", "-CA", "ca.pem", "-CAkey", "ca.key"),
+            *("-CAcreateserial", "-days", "1", "-extfile", "san.ext", "-out", f"{name}.pem"),
+        )
+    return tmp_path
+
+
+def _login(certificates, user: str, client_cert: bool) -> bytes:
+    """First message the bridge answers a mutual TLS StartupMessage with."""
+    server_context = ServerTLSConfig(
+        cert=str(certificates / "server.pem"),
+        key=str(certificates / "server.key"),
+        ca_file=str(certificates / "ca.pem"),
+    ).create_context()
+    client_context = ssl.create_default_context(cafile=str(certificates / "ca.pem"))
+    if client_cert:
+        client_context.load_cert_chain(certificates / "client.pem", certificates / "client.key")
+
+    async def run():
+        handled = asyncio.Event()
+
+        async def handle(reader, writer):
+            executor = MagicMock()
+            executor.backend_auth_mode = "service"
+            executor.iris_config = {"username": "_SYSTEM"}
+            protocol = PGWireProtocol(reader, writer, executor, "mtls")
+            protocol.cert_auth = CertAuthConfig("required")
+            try:
+                await protocol.handle_ssl_probe(server_context)
+                await protocol.handle_startup_sequence()
+            except (ConnectionAbortedError, ConnectionResetError):
+                pass
+            finally:
+                get_stats().session_ended(protocol.connection_id)
+                writer.close()
+                handled.set()
+
+        server = await asyncio.start_server(handle, "127.0.0.1", 0)
+        port = server.sockets[0].getsockname()[1]
+        reader, writer = await asyncio.open_connection("127.0.0.1", port)
+        try:
+            writer.write(struct.pack("!II", 8, 80877103))
+            assert await reader.readexactly(1) == b"S"
+            await writer.start_tls(client_context, server_hostname="localhost")
+            writer.write(startup_message(user=user))
+            kind, length = struct.unpack("!cI", await reader.readexactly(5))
+            return kind + await reader.readexactly(length - 4)
+        finally:
+            writer.close()
+            await asyncio.wait_for(handled.wait(), timeout=5)
+            server.close()
+            await server.wait_closed()
+
+    return asyncio.run(run())
+
+
+def test_client_certificate_login_without_password(certificates):
+    assert _login(certificates, "alice", client_cert=True) == b"R\x00\x00\x00\x00"
+
+
+def test_client_certificate_for_other_user_refused(certificates):
+    error = _login(certificates, "bob", client_cert=True)
+
+    assert error[:1] == b"E"
+    assert b"C28000" in error and b'user "bob"' in error
+
+
+def test_required_certificate_missing(certificates):
+    error = _login(certificates, "alice", client_cert=False)
+
+    assert error[:1] == b"E" and b"requires a valid client certificate" in error
+
+
+def test_server_requests_client_certificates_with_ca_file(certificates):
+    context = ServerTLSConfig(
+        cert=str(certificates / "server.pem"),
+        key=str(certificates / "server.key"),
+        ca_file=str(certificates / "ca.pem"),
+    ).create_context()
+
+    assert context.verify_mode == ssl.CERT_OPTIONAL
+    with pytest.raises(ValueError, match="client CA"):
+        ServerTLSConfig(
+            cert=str(certificates / "server.pem"),
+            key=str(certificates / "server.key"),
+            ca_file=str(certificates / "missing.pem"),
+        ).create_context()
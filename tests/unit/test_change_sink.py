@@ -0,0 +1,166 @@
+"""
+Unit Tests: Change Data Sinks
+
+Per-table routes to Kafka and webhook destinations, changes exported at
+COMMIT and dropped on ROLLBACK, and ordered delivery with retries.
+"""
+
+import asyncio
+import json
+import urllib.request
+
+import pytest
+
+from iris_pgwire.change_sink import (
+    ChangeEvent,
+    ChangeExporter,
+    KafkaSink,
+    SessionChanges,
+    SinkConfigError,
+    SinkQueue,
+    WebhookSink,
+    load_queue_size,
+    parse_sink_routes,
+    set_change_exporter,
+)
+
+
+class FakeSink:
+    name = "fake"
+
+    def __init__(self, failures: int = 0):
+        self.failures = failures
+        self.batches = []
+
+    def send(self, events):
+        if self.failures:
+            self.failures -= 1
+            raise ConnectionError("unreachable")
+        self.batches.append(list(events))
+
+
+@pytest.fixture
+def sink():
+    sink = FakeSink()
+    exporter = ChangeExporter(parse_sink_routes("orders=webhook:http://hooks/cdc"))
+    for queue in exporter.queues.values():
+        queue.sink = sink
+    set_change_exporter(exporter)
+    yield next(iter(exporter.queues.values()))
+    set_change_exporter(None)
+
+
+def _ok(rows: int = 1):
+    return {"success": True, "rows": [], "columns": [], "row_count": rows}
+
+
+def test_routes_select_tables_and_share_destinations():
+    routes = parse_sink_routes("orders=kafka:orders; sales.*=webhook:https://h/cdc; *=kafka:orders")
+
+    assert [(r.schema, r.table) for r in routes] == [
+        ("public", "orders"),
+        ("sales", "*"),
+        ("*", "*"),
+    ]
+    assert isinstance(routes[0].sink, KafkaSink) and routes[0].sink is routes[2].sink
+    assert isinstance(routes[1].sink, WebhookSink)
+
+    exporter = ChangeExporter(routes)
+    assert len(exporter.routed("public", "orders")) == 1
+    assert len(exporter.routed("sales", "leads")) == 2
+
+
+@pytest.mark.parametrize("spec", ["orders", "orders=s3:bucket", "orders=webhook:ftp://h"])
+def test_invalid_routes(spec):
+    with pytest.raises(SinkConfigError):
+        parse_sink_routes(spec)
+
+
+def test_autocommit_change_is_queued(sink):
+    changes = SessionChanges(1234)
+    changes.user, changes.database = "app", "USER"
+
+    changes.record("INSERT INTO orders VALUES (?)", [42], _ok(), in_transaction=False)
+    changes.record("INSERT INTO customers VALUES (1)", None, _ok(), in_transaction=False)
+    changes.record("UPDATE orders SET x = 1 WHERE 0 = 1", None, _ok(0), in_transaction=False)
+
+    [event] = sink.events
+    assert (event.schema, event.table, event.op, event.rows, event.params) == (
+        "public",
+        "orders",
+        "INSERT",
+        1,
+        [42],
+    )
+    assert event.source == {"database": "USER", "user": "app", "pid": 1234}
+
+
+def test_transaction_changes_wait_for_commit(sink):
+    changes = SessionChanges(1)
+
+    changes.record("DELETE FROM orders WHERE id = 1", None, _ok(), in_transaction=True)
+    changes.record("UPDATE orders SET x = 2", None, _ok(3), in_transaction=True)
+    assert not sink.events
+    changes.end_transaction(committed=True)
+    assert [(e.op, e.rows) for e in sink.events] == [("DELETE", 1), ("UPDATE", 3)]
+
+    changes.record("DELETE FROM orders", None, _ok(), in_transaction=True)
+    changes.end_transaction(committed=False)
+    assert len(sink.events) == 2
+
+
+def test_failed_delivery_is_retried_in_order():
+    fake = FakeSink(failures=1)
+    queue = SinkQueue(fake)
+    queue.put(ChangeEvent("public", "orders", "INSERT", 1, "INSERT ..."))
+    queue.put(ChangeEvent("public", "orders", "DELETE", 1, "DELETE ..."))
+
+    async def deliver():
+        return [await queue.deliver_batch(), await queue.deliver_batch()]
+
+    assert asyncio.run(deliver()) == [False, True]
+    assert [e.op for e in fake.batches[0]] == ["INSERT", "DELETE"]
+    assert not queue.events and queue.backoff == 0
+
+
+def test_full_queue_drops_oldest():
+    queue = SinkQueue(FakeSink(), max_size=2)
+    for n in range(3):
+        queue.put(ChangeEvent("public", "orders", "INSERT", 1, f"INSERT {n}"))
+
+    assert [e.sql for e in queue.events] == ["INSERT 1", "INSERT 2"]
+    assert queue.dropped == 1
+
+
+def test_webhook_posts_json_array(monkeypatch):
+    posted = {}
+
+    class Response:
+        def __enter__(self):
+            return self
+
+        def __exit__(self, *exc):
+            return False
+
+        def read(self):
+            return b""
+
+    def urlopen(request, timeout):
+        posted.update(url=request.full_url, body=request.data, method=request.get_method())
+        return Response()
+
+    monkeypatch.setattr(urllib.request, "urlopen", urlopen)
+    WebhookSink("http://hooks/cdc").send(
+        [ChangeEvent("public", "orders", "INSERT", 1, "INSERT ...", [b"\x01"], ts_ms=5)]
+    )
+
+    assert posted["method"] == "POST" and posted["url"] == "http://hooks/cdc"
+    assert json.loads(posted["body"])[0]["params"] == ["01"]
+
+
+def test_queue_size_from_environment(monkeypatch):
+    monkeypatch.setenv("PGWIRE_CDC_QUEUE_SIZE", "50")
+    assert load_queue_size() == 50
+
+    monkeypatch.setenv("PGWIRE_CDC_QUEUE_SIZE", "-1")
+    assert load_queue_size() == 10_000
@@ -0,0 +1,258 @@
+"""
+Unit Tests: CHECK Constraints
+
+CHECK clauses removed from CREATE / ALTER TABLE, enforced by triggers and
+kept in an IRIS global (simulated in memory) for introspection.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.check_constraints import (
+    CheckConstraint,
+    CheckConstraints,
+    CheckConstraintStore,
+    CheckViolation,
+    annotate_violation,
+    parse_check_ddl,
+    relation,
+    trigger_sql,
+)
+from iris_pgwire.iris_executor import IRISExecutor
+
+
+class MemoryGlobalAccessor:
+    """Global accessor over a dict keyed by subscript tuples."""
+
+    def __init__(self):
+        self.nodes = {}
+
+    def next_subscript(self, global_name, subscripts, previous):
+        depth = len(subscripts)
+        following = sorted(
+            {
+                key[depth]
+                for (name, key) in self.nodes
+                if name == global_name and list(key[:depth]) == subscripts and len(key) > depth
+            }
+        )
+        following = [s for s in following if previous == "" or s > previous]
+        return following[0] if following else None
+
+    def get(self, global_name, subscripts):
+        return self.nodes[(global_name, tuple(subscripts))]
+
+    def data(self, global_name, subscripts):
+        return int((global_name, tuple(subscripts)) in self.nodes)
+
+    def set(self, global_name, subscripts, value):
+        self.nodes[(global_name, tuple(subscripts))] = value
+
+    def kill(self, global_name, subscripts):
+        for name, key in list(self.nodes):
+            if name == global_name and list(key[: len(subscripts)]) == subscripts:
+                del self.nodes[(name, key)]
+
+
+class FakeIRIS:
+    """Records statements; COUNT(*) queries report violating_rows."""
+
+    def __init__(self, violating_rows=0, failing=None):
+        self.statements = []
+        self.violating_rows = violating_rows
+        self.failing = failing
+
+    def run(self, sql):
+        self.statements.append(sql)
+        if self.failing and self.failing in sql:
+            raise RuntimeError("SQLCODE -1 invalid trigger")
+        return [(self.violating_rows,)] if sql.startswith("SELECT COUNT(*)") else []
+
+
+ORDERS = relation("public.orders", "SQLUser")
+
+
+@pytest.fixture
+def store():
+    return CheckConstraintStore(MemoryGlobalAccessor(), "^PGWire.Checks")
+
+
+class TestParsing:
+    def test_create_table(self):
+        ddl = parse_check_ddl(
+            "CREATE TABLE orders (id INT PRIMARY KEY, qty INT NOT NULL CHECK (qty > 0), "
+            "note VARCHAR(20) DEFAULT 'check (x)', "
+            "CONSTRAINT qty_max CHECK (qty < 1000), CHECK (qty <> id), CHECK (qty > id));"
+        )
+
+        assert ddl.statement == (
+            "CREATE TABLE orders (id INT PRIMARY KEY, qty INT NOT NULL, "
+            "note VARCHAR(20) DEFAULT 'check (x)')"
+        )
+        assert [(c.name, c.expression) for c in ddl.checks] == [
+            ("orders_qty_check", "qty > 0"),
+            ("qty_max", "qty < 1000"),
+            ("orders_check", "qty <> id"),
+            ("orders_check1", "qty > id"),
+        ]
+        assert ddl.creates_table
+
+    def test_alter_table(self):
+        ddl = parse_check_ddl(
+            'ALTER TABLE sales."Orders" ADD CONSTRAINT "Positive" CHECK (total >= 0) NOT VALID, '
+            "ADD COLUMN discount INT CHECK (discount BETWEEN 0 AND 100)"
+        )
+
+        assert ddl.statement == 'ALTER TABLE sales."Orders" ADD COLUMN discount INT'
+        assert [(c.name, c.validate) for c in ddl.checks] == [
+            ("Positive", False),
+            ("Orders_discount_check", True),
+        ]
+        added = parse_check_ddl("ALTER TABLE orders ADD CHECK (upper(code) = code)")
+        assert (added.statement, added.checks[0].name) == (None, "orders_code_check")
+
+    def test_drop_constraint(self):
+        ddl = parse_check_ddl("ALTER TABLE orders DROP CONSTRAINT IF EXISTS qty_max CASCADE")
+
+        assert (ddl.drop, ddl.checks) == ("qty_max", [])
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "CREATE TABLE orders (id INT PRIMARY KEY)",
+            "ALTER TABLE orders DROP COLUMN note",
+            "SELECT * FROM orders WHERE status = 'CHECK (1)'",
+            "DROP TABLE orders",
+        ],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_check_ddl(sql) is None
+
+    def test_relations(self):
+        assert relation("public.Orders", "SQLUser").sql == "SQLUser.Orders"
+        assert relation('sales."Orders"', "SQLUser").table == "Orders"
+
+
+class TestEnforcement:
+    def test_trigger_body(self):
+        sql = trigger_sql(ORDERS, CheckConstraint("orders_qty_check", "qty > 0"))
+
+        assert sql.startswith(
+            'CREATE TRIGGER "orders_qty_check" AFTER INSERT,UPDATE ON SQLUser.orders'
+        )
+        assert "WHERE %ID = :id AND NOT (qty > 0))" in sql
+        assert '%msg="new row for relation ""orders"" violates check constraint' in sql
+
+    def test_added_to_existing_table(self, store):
+        iris = FakeIRIS()
+        checks = CheckConstraints(iris.run, store)
+
+        checks.add(ORDERS, [CheckConstraint("qty_max", "qty < 1000")], new_table=False)
+
+        assert iris.statements[0] == "SELECT COUNT(*) FROM SQLUser.orders WHERE NOT (qty < 1000)"
+        assert iris.statements[1].startswith('CREATE TRIGGER "qty_max"')
+        assert store.rows() == [("SQLUser", "orders", "qty_max", "qty < 1000")]
+
+    def test_existing_rows_violate(self, store):
+        checks = CheckConstraints(FakeIRIS(violating_rows=3).run, store)
+
+        with pytest.raises(CheckViolation, match='"qty_max" of relation "orders"'):
+            checks.add(ORDERS, [CheckConstraint("qty_max", "qty < 1000")], new_table=False)
+        assert store.rows() == []
+
+    def test_all_or_nothing(self, store):
+        iris = FakeIRIS(failing="qty::int")
+        checks = CheckConstraints(iris.run, store)
+
+        with pytest.raises(RuntimeError):
+            checks.add(
+                ORDERS,
+                [CheckConstraint("a", "qty > 0"), CheckConstraint("b", "qty::int > 0")],
+                new_table=True,
+            )
+
+        assert iris.statements[-1] == 'DROP TRIGGER "a" FROM SQLUser.orders'
+        assert store.rows() == []
+
+    def test_drop(self, store):
+        iris = FakeIRIS()
+        checks = CheckConstraints(iris.run, store)
+        store.set("SQLUser", "orders", "qty_max", "qty < 1000")
+
+        assert checks.drop(ORDERS, "orders_pkey") is False
+        assert checks.drop(ORDERS, "qty_max") is True
+        assert iris.statements == ['DROP TRIGGER "qty_max" FROM SQLUser.orders']
+
+    def test_violation_reported(self):
+        result = annotate_violation(
+            {
+                "success": False,
+                "error": "[SQLCODE: <-131>:<After trigger failed>] [%msg: <new row for relation "
+                '"orders" violates check constraint "orders_qty_check">]',
+            }
+        )
+
+        assert result["sqlstate"] == "23514"
+        assert result["error"] == (
+            'new row for relation "orders" violates check constraint "orders_qty_check"'
+        )
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch, store, iris):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.executed = []
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_execute(sql, params=None, session_id=None):
+            executor.executed.append(sql)
+            return {"success": True, "rows": [], "columns": [], "command_tag": "CREATE TABLE"}
+
+        async def fake_checks_call(operation):
+            return operation(CheckConstraints(iris.run, store))
+
+        executor._execute_query = fake_execute
+        executor._check_constraints_call = fake_checks_call
+        return executor
+
+    def test_create_table(self, monkeypatch, store):
+        iris = FakeIRIS()
+        executor = self._executor(monkeypatch, store, iris)
+
+        result = asyncio.run(
+            executor._execute_check_ddl(
+                parse_check_ddl("CREATE TABLE orders (id INT, qty INT CHECK (qty > 0))")
+            )
+        )
+
+        assert result["command_tag"] == "CREATE TABLE"
+        assert executor.executed == ["CREATE TABLE orders (id INT, qty INT)"]
+        assert store.get("SQLUser", "orders", "orders_qty_check") == "qty > 0"
+
+    def test_failed_trigger_drops_new_table(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store, FakeIRIS(failing="CREATE TRIGGER"))
+
+        result = asyncio.run(
+            executor._execute_check_ddl(
+                parse_check_ddl("CREATE TABLE orders (qty INT CHECK (qty ~ '^1'))")
+            )
+        )
+
+        assert not result["success"]
+        assert executor.executed[-1] == "DROP TABLE SQLUser.orders"
+
+    def test_unknown_constraint_passed_to_iris(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store, FakeIRIS())
+
+        result = asyncio.run(
+            executor._execute_check_ddl(
+                parse_check_ddl("ALTER TABLE orders DROP CONSTRAINT orders_pkey")
+            )
+        )
+
+        assert result is None
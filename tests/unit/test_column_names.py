@@ -0,0 +1,127 @@
+"""
+Unit Tests: Result Column Names
+
+Columns named from the client's select list as PostgreSQL names them:
+aliases with their case, ?column?, function and type names, duplicates kept.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.column_names import name_result_columns, result_column_names
+from iris_pgwire.protocol import PGWireProtocol
+from tests.protocol_messages import FakeWriter, frontend_message
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        ("SELECT 1", ["?column?"]),
+        ("SELECT 1, 2", ["?column?", "?column?"]),
+        ("SELECT id, id FROM t", ["id", "id"]),
+        (
+            'SELECT t.Name, "Total", x AS "MixedCase", y AS Foo FROM t',
+            ["name", "Total", "MixedCase", "foo"],
+        ),
+        ("SELECT count(*) total, a b FROM t", ["total", "b"]),
+        (
+            "SELECT count(*), pg_catalog.lower(x), sum(a) FILTER (WHERE b) FROM t",
+            ["count", "lower", "sum"],
+        ),
+        ("SELECT rank() OVER (ORDER BY a) FROM t", ["rank"]),
+        (
+            "SELECT id::text, 1::int, 'x'::varchar(3), $1::bigint",
+            ["id", "int4", "varchar", "int8"],
+        ),
+        ("SELECT 1::double precision, now()::timestamp with time zone", ["float8", "now"]),
+        ("SELECT CAST(a AS INTEGER), CAST(1 AS INTEGER) FROM t", ["a", "int4"]),
+        ("SELECT true, DATE '2024-01-01', interval '1' day", ["bool", "date", "interval"]),
+        ("SELECT CASE WHEN a THEN 1 END, CASE WHEN a THEN 1 END::text FROM t", ["case", "text"]),
+        (
+            "SELECT coalesce(a, b), EXISTS (SELECT 1), ARRAY[1, 2], (a, b) FROM t",
+            ["coalesce", "exists", "array", "row"],
+        ),
+        (
+            "SELECT current_timestamp, TRIM(LEADING 'x' FROM y), extract(year FROM d) FROM t",
+            ["current_timestamp", "ltrim", "extract"],
+        ),
+        (
+            "SELECT (SELECT max(x) FROM u), (SELECT 1), a + b, -a, $1 FROM t",
+            ["max", "?column?", "?column?", "?column?", "?column?"],
+        ),
+        (
+            "SELECT x AT TIME ZONE 'UTC', a IS DISTINCT FROM b, c FROM t",
+            ["timezone", "?column?", "c"],
+        ),
+        ("SELECT DISTINCT ON (a) a, b FROM t", ["a", "b"]),
+        ("SELECT q FROM x UNION SELECT 2", ["q"]),
+        ("WITH x AS (SELECT 1 AS q) SELECT q FROM x", ["q"]),
+        ("VALUES (1, 2)", ["column1", "column2"]),
+        ("INSERT INTO t (a) VALUES (1) RETURNING id, a * 2", ["id", "?column?"]),
+        ("SELECT *, 1 AS one FROM t", [None, "one"]),
+        ("UPDATE t SET a = 1", None),
+    ],
+)
+def test_postgres_column_names(sql, expected):
+    assert result_column_names(sql) == expected
+
+
+def test_long_aliases_are_truncated_like_identifiers():
+    assert result_column_names(f"SELECT 1 AS {'a' * 70}") == ["a" * 63]
+    assert len(result_column_names(f'SELECT 1 AS "{"é" * 40}"')[0].encode()) == 62
+
+
+def test_columns_renamed_around_a_star():
+    columns = [{"name": "ID"}, {"name": "NAME"}, {"name": "Expression_1"}]
+
+    named = name_result_columns(columns, "SELECT *, a + 1 AS Next FROM t")
+
+    assert [c["name"] for c in named] == ["ID", "NAME", "next"]
+    assert named[2]["name_from_sql"] and "name_from_sql" not in named[0]
+    assert name_result_columns(columns, "SELECT a, b FROM t") is columns  # Count mismatch
+
+
+def _field_names(buffer: bytes) -> list[str]:
+    pos = 0
+    while pos < len(buffer):
+        length = struct.unpack("!I", buffer[pos + 1 : pos + 5])[0]
+        if buffer[pos : pos + 1] == b"T":
+            body = buffer[pos + 5 : pos + 1 + length]
+            names, offset = [], 2
+            for _ in range(struct.unpack("!H", body[:2])[0]):
+                end = body.index(b"\x00", offset)
+                names.append(body[offset:end].decode())
+                offset = end + 1 + 18
+            return names
+        pos += 1 + length
+    return []
+
+
+def test_row_description_uses_the_client_names():
+    async def run():
+        reader = asyncio.StreamReader()
+        query = b'SELECT "UserId", 1::int, count(*) FROM users\x00'
+        reader.feed_data(frontend_message(b"Q", query) + frontend_message(b"X"))
+        reader.feed_eof()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(
+            return_value={
+                "success": True,
+                "rows": [[1, 1, 3]],
+                "columns": [
+                    {"name": "USERID", "type_oid": 23, "type_size": 4},
+                    {"name": "Expression_2", "type_oid": 23, "type_size": 4},
+                    {"name": "Aggregate_3", "type_oid": 20, "type_size": 8},
+                ],
+                "row_count": 1,
+                "command_tag": "SELECT 1",
+            }
+        )
+        protocol = PGWireProtocol(reader, FakeWriter(), executor, "names")
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return protocol.writer.buffer
+
+    assert _field_names(asyncio.run(run())) == ["UserId", "int4", "count"]
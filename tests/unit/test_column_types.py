@@ -0,0 +1,74 @@
+"""
+Unit Tests: Result Column Types
+
+RowDescription type OID, typlen and typmod of result columns from IRIS ODBC
+type codes with precision and scale, and from IRIS type names.
+"""
+
+import pytest
+
+from iris_pgwire.column_types import describe_column
+from iris_pgwire.iris_executor import IRISExecutor
+
+
+@pytest.mark.parametrize(
+    "iris_type, precision, scale, expected",
+    [
+        (5, 5, 0, (21, 2, -1)),  # SMALLINT → int2
+        (-6, 3, 0, (21, 2, -1)),  # TINYINT → int2
+        (4, 10, 0, (23, 4, -1)),  # INTEGER → int4
+        (-5, 19, 0, (20, 8, -1)),  # BIGINT → int8
+        (2, 10, 2, (1700, -1, (10 << 16 | 2) + 4)),  # NUMERIC(10,2)
+        (3, 18, 0, (1700, -1, (18 << 16) + 4)),  # DECIMAL(18)
+        (8, 15, 0, (701, 8, -1)),  # DOUBLE → float8
+        (7, 7, 0, (700, 4, -1)),  # REAL → float4
+        (12, 50, 0, (1043, -1, 54)),  # VARCHAR(50)
+        (12, 0, 0, (1043, -1, -1)),  # VARCHAR without a length
+        (1, 3, 0, (1042, -1, 7)),  # CHAR(3) → bpchar
+        (-1, 2**31 - 1, 0, (25, -1, -1)),  # LONGVARCHAR → text
+        (9, 10, 0, (1082, 4, -1)),  # DATE
+        (93, 19, 0, (1114, 8, -1)),  # TIMESTAMP
+        (92, 8, 0, (1083, 8, -1)),  # TIME
+        (-7, 1, 0, (16, 1, -1)),  # BIT → bool
+        (-3, 100, 0, (17, -1, -1)),  # VARBINARY → bytea
+        (-4, 0, 0, (17, -1, -1)),  # LONGVARBINARY → bytea
+        (999, None, None, (25, -1, -1)),  # Unknown code → text
+        ("VARCHAR(255)", None, None, (1043, -1, 259)),
+        ("NUMERIC(12,4)", None, None, (1700, -1, (12 << 16 | 4) + 4)),
+        ("numeric", 20, "6", (1700, -1, (20 << 16 | 6) + 4)),
+        ("INT", None, None, (23, 4, -1)),
+        ("timestamp", None, None, (1114, 8, -1)),
+    ],
+)
+def test_describe_column(iris_type, precision, scale, expected):
+    assert describe_column(iris_type, precision, scale) == expected
+
+
+def test_out_of_range_modifiers_are_omitted():
+    assert describe_column(2, 5000, 2)[2] == -1  # Precision beyond numeric's 1000
+    assert describe_column(2, 10, 12)[2] == -1  # Scale beyond precision
+    assert describe_column(12, 32_000_000, 0)[2] == -1  # Longer than varchar allows
+
+
+def test_external_columns_use_description_metadata():
+    executor = IRISExecutor.__new__(IRISExecutor)
+    sql = "SELECT id, price, name, created, 3.5 FROM items"
+
+    columns = [
+        executor._external_column(desc, sql, sql)
+        for desc in [
+            ("ID", -5, 20, 20, 19, 0, False),
+            ("PRICE", 2, 12, 12, 10, 2, True),
+            ("NAME", 12, 80, 80, 80, 0, True),
+            ("CREATED", 93, 19, 19, 19, 0, True),
+            ("Expression_5", 2, 4, 4, 2, 1, True),
+        ]
+    ]
+
+    assert [(c["type_oid"], c["type_size"], c["type_modifier"]) for c in columns] == [
+        (20, 8, -1),
+        (1700, -1, (10 << 16 | 2) + 4),
+        (1043, -1, 84),
+        (1114, 8, -1),
+        (701, 8, -1),  # Decimal literals stay float8 for node-postgres
+    ]
@@ -0,0 +1,178 @@
+"""
+Unit Tests: Connection Rate Limiting and Authentication Lockout
+
+Per-IP rate limit, failure lockout and the audit events they produce.
+"""
+
+import asyncio
+import json
+import logging
+from unittest.mock import AsyncMock, MagicMock, patch
+
+import pytest
+
+from iris_pgwire.audit import AuditLog
+from iris_pgwire.backend_auth import PasswordAuthenticationFailed
+from iris_pgwire.connection_guard import ConnectionGuard, ConnectionRejected
+from iris_pgwire.server import PGWireServer
+from tests.protocol_messages import FakeWriter, frontend_message, startup_message
+
+
+class FakeClock:
+    def __init__(self):
+        self.now = 1000.0
+
+    def __call__(self):
+        return self.now
+
+
+class RecordingAudit(AuditLog):
+    def __init__(self):
+        super().__init__()
+        self.events = []
+
+    def record(self, event, **fields):
+        self.events.append((event, fields))
+
+
+def _guard(**kwargs):
+    clock = FakeClock()
+    audit = RecordingAudit()
+    return ConnectionGuard(audit=audit, clock=clock, **kwargs), clock, audit
+
+
+class TestRateLimit:
+    def test_window_slides(self):
+        guard, clock, audit = _guard(rate_limit=2, rate_window=10)
+
+        guard.check_connection("10.0.0.1")
+        guard.check_connection("10.0.0.1")
+        with pytest.raises(ConnectionRejected) as exc_info:
+            guard.check_connection("10.0.0.1")
+        assert exc_info.value.sqlstate == "53300"
+        assert audit.events[-1] == (
+            "connection_rejected",
+            {"client": "10.0.0.1", "reason": "rate_limit"},
+        )
+
+        # Other addresses are unaffected; the window frees up over time
+        guard.check_connection("10.0.0.2")
+        clock.now += 11
+        guard.check_connection("10.0.0.1")
+
+    def test_off_by_default(self):
+        guard, _, _ = _guard()
+
+        for _ in range(100):
+            guard.check_connection("10.0.0.1")
+
+
+class TestLockout:
+    def test_failures_lock_out_then_expire(self):
+        guard, clock, audit = _guard(failure_limit=3, failure_window=60, lockout_seconds=120)
+
+        for _ in range(3):
+            guard.record_auth_failure("10.0.0.1", "admin")
+
+        with pytest.raises(ConnectionRejected) as exc_info:
+            guard.check_connection("10.0.0.1")
+        assert exc_info.value.sqlstate == "28000"
+        assert [event for event, _ in audit.events].count("auth_lockout") == 1
+
+        clock.now += 121
+        guard.check_connection("10.0.0.1")
+
+    def test_success_resets_and_old_failures_age_out(self):
+        guard, clock, _ = _guard(failure_limit=3, failure_window=60)
+
+        guard.record_auth_failure("10.0.0.1", "admin")
+        guard.record_auth_failure("10.0.0.1", "admin")
+        guard.record_auth_success("10.0.0.1", "admin")
+        guard.record_auth_failure("10.0.0.1", "admin")
+        clock.now += 61
+        guard.record_auth_failure("10.0.0.1", "admin")
+        guard.record_auth_failure("10.0.0.1", "admin")
+
+        assert not guard.locked_out("10.0.0.1")
+
+    def test_exempt_hosts(self):
+        guard, _, _ = _guard(failure_limit=1, exempt_hosts=frozenset({"127.0.0.1"}))
+
+        guard.record_auth_failure("127.0.0.1", "admin")
+
+        guard.check_connection("127.0.0.1")
+
+
+class TestHandshakeFailures:
+    """Which failed handshakes count towards lockout."""
+
+    @staticmethod
+    def _server():
+        with (
+            patch("iris_pgwire.server.IRISExecutor"),
+            patch("iris_pgwire.server.enhance_iris_executor_with_integratedml") as enhance,
+        ):
+            enhance.return_value = MagicMock()
+            server = PGWireServer()
+        server.auth_methods = ("password",)
+        server.iris_executor.backend_auth_mode = "service"
+        server.iris_executor.verify_iris_password = AsyncMock(
+            side_effect=PasswordAuthenticationFailed("alice")
+        )
+        server.connection_guard, _, audit = _guard(failure_limit=1)
+        return server, audit
+
+    @staticmethod
+    def _connect(server, data: bytes):
+        async def run():
+            reader = asyncio.StreamReader()
+            reader.feed_data(data)
+            reader.feed_eof()
+            await server.handle_client(reader, FakeWriter(peername=("10.0.0.1", 50000)))
+
+        asyncio.run(run())
+
+    def test_disconnect_at_password_prompt_is_not_a_failure(self):
+        server, audit = self._server()
+
+        # psql hangs up when asked for a password, then reconnects with one
+        self._connect(server, startup_message(user="alice", database="USER"))
+
+        assert "auth_failed" not in [event for event, _ in audit.events]
+        assert not server.connection_guard.locked_out("10.0.0.1")
+
+    def test_rejected_password_is_a_failure(self):
+        server, audit = self._server()
+
+        self._connect(
+            server,
+            startup_message(user="alice", database="USER")
+            + frontend_message(b"p", b"wrong\x00"),
+        )
+
+        assert ("auth_failed", {"client": "10.0.0.1", "user": "alice", "failures": 1}) in (
+            audit.events
+        )
+        assert server.connection_guard.locked_out("10.0.0.1")
+
+
+class TestAuditLog:
+    def test_json_records(self, tmp_path):
+        path = tmp_path / "audit.jsonl"
+        audit = AuditLog(logging.FileHandler(path, encoding="utf-8"))
+
+        audit.record("auth_failed", client="10.0.0.1", user="admin", failures=1)
+        audit.close()
+
+        record = json.loads(path.read_text().strip())
+        assert record["event"] == "auth_failed"
+        assert record["client"] == "10.0.0.1"
+        assert record["ts"].endswith("Z")
+
+    def test_rejection_is_an_error_response(self):
+        rejected = ConnectionRejected("go away", "53300", "too_many_connections")
+
+        message = rejected.error_response()
+
+        assert message[:1] == b"E"
+        assert b"SFATAL\x00C53300\x00Mgo away\x00\x00" in message
@@ -0,0 +1,208 @@
+"""
+Unit Tests: Binary COPY
+
+PostgreSQL's binary copy format (signature header, length-prefixed fields,
+trailer) for COPY FROM STDIN and COPY TO STDOUT, as pgx's CopyFrom sends it.
+"""
+
+import asyncio
+import struct
+from datetime import date, datetime
+from decimal import Decimal
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.copy_binary import (
+    HEADER,
+    TRAILER,
+    BinaryCopyError,
+    column_oids,
+    decode_numeric,
+    decode_value,
+    encode_binary_rows,
+    encode_numeric,
+    encode_value,
+    parse_binary_rows,
+)
+from iris_pgwire.protocol import PGWireProtocol
+
+
+async def _stream(*chunks):
+    for chunk in chunks:
+        yield chunk
+
+
+def _parse(chunks, names, oids):
+    async def collect():
+        return [row async for row in parse_binary_rows(_stream(*chunks), names, oids)]
+
+    return asyncio.run(collect())
+
+
+@pytest.mark.parametrize(
+    "value, oid, encoded",
+    [
+        (True, 16, b"\x01"),
+        (7, 21, b"\x00\x07"),
+        (-2, 23, b"\xff\xff\xff\xfe"),
+        (2**40, 20, struct.pack("!q", 2**40)),
+        (1.5, 701, struct.pack("!d", 1.5)),
+        ("2000-01-02", 1082, struct.pack("!i", 1)),
+        (date(1999, 12, 31), 1082, struct.pack("!i", -1)),
+        ("2000-01-01 00:00:01.5", 1114, struct.pack("!q", 1_500_000)),
+        ("00:01:00", 1083, struct.pack("!q", 60_000_000)),
+        ("12345678-1234-5678-1234-567812345678", 2950, bytes.fromhex("1234567812345678" * 2)),
+        ('{"a": 1}', 3802, b'\x01{"a": 1}'),
+        ("héllo", 1043, "héllo".encode()),
+    ],
+)
+def test_encode_value(value, oid, encoded):
+    assert encode_value(value, oid) == encoded
+
+
+@pytest.mark.parametrize(
+    "text", ["0", "1", "-12345.678", "0.0001", "10000", "123456789012345678.90", "0.00"]
+)
+def test_numeric_round_trip(text):
+    assert decode_numeric(encode_numeric(Decimal(text))) == text
+
+
+def test_numeric_layout():
+    # 12345.678 = 1 2345 . 6780: weight 1, dscale 3
+    assert encode_numeric("12345.678") == struct.pack("!hhHh3h", 3, 1, 0, 3, 1, 2345, 6780)
+    assert decode_numeric(encode_numeric("NaN")) == "NaN"
+
+
+def test_numeric_special_values_and_scale():
+    assert decode_numeric(encode_numeric(Decimal("-Infinity"))) == "-Infinity"
+    assert decode_numeric(encode_numeric(float("inf"))) == "Infinity"
+    assert decode_numeric(encode_numeric(0.1)) == "0.1"  # Not the float's binary expansion
+    assert decode_numeric(encode_numeric("10.5", scale=2)) == "10.50"
+    assert decode_numeric(encode_numeric("-0.004", scale=2)) == "0.00"
+
+
+@pytest.mark.parametrize(
+    "data",
+    [
+        struct.pack("!hhHhh", 1, 0, 0, 0, 10000),  # Digit beyond base 10000
+        struct.pack("!hhHh", 1, 0, 0, 0),  # Missing digit
+        struct.pack("!hhHhh", 1, 0, 0x1000, 0, 1),  # Unknown sign
+    ],
+)
+def test_invalid_numeric_is_rejected(data):
+    with pytest.raises(ValueError):
+        decode_numeric(data)
+
+
+def test_decoded_for_iris_parameters():
+    assert decode_value(b"\x01", 16) == 1
+    assert decode_value(struct.pack("!q", 0), 1114) == "2000-01-01 00:00:00.000000"
+    assert decode_value(struct.pack("!i", 3), 1082) == "2000-01-04"
+
+
+def test_width_mismatch_read_leniently():
+    # A client describing an INTEGER column as int8, or a DATE column as text
+    assert decode_value(struct.pack("!q", 5), 23) == 5
+    assert decode_value(b"2024-02-29", 1082) == "2024-02-29"
+
+
+def test_column_oids():
+    columns = [("id", "INTEGER"), ("amount", "NUMERIC"), ("placed", "DATE"), ("x", "%List")]
+
+    assert column_oids(columns) == [23, 1700, 1082, 25]
+
+
+def test_rows_across_arbitrary_chunks():
+    data = HEADER + encode_binary_rows([(1, "Ann", None), (2, "Bob", "2024-01-31")], [23, 25, 1082])
+    data += TRAILER
+    chunks = [data[i : i + 3] for i in range(0, len(data), 3)]
+
+    rows = _parse(chunks, ["id", "name", "born"], [23, 25, 1082])
+
+    assert rows == [
+        {"id": 1, "name": "Ann", "born": None},
+        {"id": 2, "name": "Bob", "born": "2024-01-31"},
+    ]
+
+
+def test_header_extension_skipped():
+    header = HEADER[:-4] + struct.pack("!i", 3) + b"xyz"
+    data = header + encode_binary_rows([(9,)], [23]) + TRAILER
+
+    assert _parse([data], ["id"], [23]) == [{"id": 9}]
+
+
+def test_bad_signature():
+    with pytest.raises(BinaryCopyError) as exc_info:
+        _parse([b"1\tAnn\n" + b"\x00" * 16], ["id"], [23])
+
+    assert exc_info.value.sqlstate == "22P04"
+
+
+def test_field_count_mismatch():
+    data = HEADER + encode_binary_rows([(1, "Ann")], [23, 25]) + TRAILER
+
+    with pytest.raises(BinaryCopyError) as exc_info:
+        _parse([data], ["id"], [23])
+
+    assert "row field count is 2, expected 1" in str(exc_info.value)
+
+
+def test_truncated_data():
+    data = HEADER + encode_binary_rows([(1,)], [23])
+
+    with pytest.raises(BinaryCopyError, match="unexpected EOF"):
+        _parse([data[:-2]], ["id"], [23])
+
+
+def test_undecodable_field():
+    data = HEADER + struct.pack("!hi", 1, 3) + b"\xff\xfe\xfd" + TRAILER
+
+    with pytest.raises(BinaryCopyError) as exc_info:
+        _parse([data], ["name"], [25])
+
+    assert exc_info.value.sqlstate == "22P03"
+
+
+class TestCopyOut:
+    @staticmethod
+    def _sent(writer):
+        return b"".join(call.args[0] for call in writer.write.call_args_list)
+
+    @pytest.mark.asyncio
+    async def test_query_copy(self):
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(
+            return_value={
+                "success": True,
+                "rows": [[1, datetime(2000, 1, 1, 0, 0, 2)], [2, None]],
+                "columns": [{"name": "id", "type_oid": 20}, {"name": "at", "type_oid": 1114}],
+            }
+        )
+        writer = MagicMock()
+        writer.drain = AsyncMock()
+        protocol = PGWireProtocol(asyncio.StreamReader(), writer, executor, "copy")
+
+        await protocol.handle_copy_command("COPY (SELECT id, at FROM t) TO STDOUT (FORMAT BINARY)")
+
+        sent = self._sent(writer)
+        assert sent.startswith(b"H" + struct.pack("!IbHHH", 11, 1, 2, 1, 1))
+        rows = struct.pack("!hiqiq", 2, 8, 1, 8, 2_000_000) + struct.pack("!hiqi", 2, 8, 2, -1)
+        payload = HEADER + rows + TRAILER
+        copy_data = b"".join(
+            sent[i + 5 : i + 1 + struct.unpack("!I", sent[i + 1 : i + 5])[0]]
+            for i in _messages(sent, b"d")
+        )
+        assert copy_data == payload
+        assert b"COPY 2\x00" in sent
+
+
+def _messages(sent, msg_type):
+    """Offsets of the messages of msg_type in a stream of backend messages."""
+    offsets, i = [], 0
+    while i < len(sent):
+        if sent[i : i + 1] == msg_type:
+            offsets.append(i)
+        i += 1 + struct.unpack("!I", sent[i + 1 : i + 5])[0]
+    return offsets
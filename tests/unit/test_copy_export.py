@@ -0,0 +1,240 @@
+"""
+Unit Tests: COPY TO STDOUT
+
+pg_dump's COPY schema.table (...) TO stdout: column resolution, the IRIS
+extraction SELECT, text-format encoding and batched streaming; psql's \\copy
+options, CSV encoding and COPY (query) TO STDOUT.
+"""
+
+import asyncio
+import struct
+from datetime import date
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.bulk_executor import BulkExecutor
+from iris_pgwire.check_constraints import relation
+from iris_pgwire.copy_export import (
+    CopyExportError,
+    encode_csv_rows,
+    encode_text_rows,
+    fetch_size,
+    force_quote_columns,
+    table_export,
+)
+from iris_pgwire.copy_handler import CopyHandler
+from iris_pgwire.csv_processor import CSVProcessor
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_translator.copy_parser import CopyDirection, parse_copy_command
+
+COLUMNS = [("id", "integer"), ("Name", "varchar"), ("born", "date"), ("seen", "timestamp")]
+
+
+class FakeIRIS:
+    """Answers queries (the column lookup) with query_rows and streams rows in batches."""
+
+    def __init__(self, rows=(), query_rows=COLUMNS):
+        self.rows = list(rows)
+        self.query_rows = query_rows
+        self.statements = []
+        self.batch_sizes = []
+
+    async def execute_query(self, sql, params=None, session_id=None):
+        self.statements.append(sql)
+        return {"success": True, "rows": self.query_rows, "columns": []}
+
+    async def stream_rows(self, sql, batch_size):
+        self.statements.append(sql)
+        self.batch_sizes.append(batch_size)
+        for start in range(0, len(self.rows), batch_size):
+            yield self.rows[start : start + batch_size]
+
+
+class TestParsing:
+    def test_pg_dump_statement(self):
+        command = parse_copy_command('COPY public."Orders" (id, "Name") TO stdout;')
+
+        assert command.table_name == 'public."Orders"'
+        assert command.column_list == ["id", '"Name"']
+        assert command.direction == CopyDirection.TO_STDOUT
+        assert (command.csv_options.format, command.csv_options.delimiter) == ("TEXT", "\t")
+
+    def test_explicit_csv_format(self):
+        options = parse_copy_command("COPY t TO STDOUT WITH (FORMAT CSV, HEADER)").csv_options
+
+        assert (options.format, options.delimiter, options.header) == ("CSV", ",", True)
+
+    def test_text_format_with_delimiter(self):
+        options = parse_copy_command("COPY t TO STDOUT WITH (FORMAT TEXT, DELIMITER '|')")
+
+        assert options.csv_options.delimiter == "|"
+
+
+    def test_psql_copy_options(self):
+        options = parse_copy_command("COPY t TO STDOUT csv header force quote *").csv_options
+
+        assert (options.format, options.header, options.force_quote) == ("CSV", True, ["*"])
+        assert options.null_string == ""
+
+    def test_query_options_without_with(self):
+        command = parse_copy_command(
+            "COPY (SELECT a, (b) FROM t) TO STDOUT (FORMAT csv, FORCE_QUOTE (a, \"B\"));"
+        )
+
+        assert command.query == "SELECT a, (b) FROM t"
+        assert command.csv_options.force_quote == ["a", '"B"']
+
+
+class TestTableExport:
+    def test_extraction_sql(self):
+        export = table_export(relation("public.people", "SQLUser"), COLUMNS, None)
+
+        assert export.sql == (
+            'SELECT id, "Name", %ODBCOUT(born), %ODBCOUT(seen) FROM SQLUser.people'
+        )
+        assert export.column_names == ["id", "Name", "born", "seen"]
+
+    def test_column_list_in_statement_order(self):
+        export = table_export(relation("people", "SQLUser"), COLUMNS, ['"Name"', "ID"])
+
+        assert export.sql == 'SELECT "Name", id FROM SQLUser.people'
+
+    def test_unknown_table(self):
+        with pytest.raises(CopyExportError) as exc_info:
+            table_export(relation("missing", "SQLUser"), [], None)
+
+        assert exc_info.value.sqlstate == "42P01"
+        assert str(exc_info.value) == 'relation "missing" does not exist'
+
+    def test_unknown_column(self):
+        with pytest.raises(CopyExportError) as exc_info:
+            table_export(relation("people", "SQLUser"), COLUMNS, ["nope"])
+
+        assert exc_info.value.sqlstate == "42703"
+
+    def test_fetch_size(self, monkeypatch):
+        assert fetch_size() == 10000
+        monkeypatch.setenv("PGWIRE_COPY_FETCH_SIZE", "500")
+        assert fetch_size() == 500
+        monkeypatch.setenv("PGWIRE_COPY_FETCH_SIZE", "lots")
+        assert fetch_size() == 10000
+
+
+class TestTextEncoding:
+    def test_values(self):
+        rows = [(1, "a\tb\nc\\d", None, True, b"\x01\xff", date(2024, 1, 2))]
+
+        assert encode_text_rows(rows) == b"1\ta\\tb\\nc\\\\d\t\\N\tt\t\\\\x01ff\t2024-01-02\n"
+
+    def test_custom_delimiter_and_null(self):
+        assert encode_text_rows([("a|b", None)], "|", "") == b"a\\|b|\n"
+
+
+class TestCSVEncoding:
+    def test_null_and_empty_string(self):
+        assert encode_csv_rows([(None, "", "x")]) == b',"",x\n'
+
+    def test_quoting(self):
+        rows = [('a,b', 'say "hi"', "two\nlines", "\\.", True)]
+
+        assert encode_csv_rows(rows) == b'"a,b","say ""hi""","two\nlines","\\.",t\n'
+
+    def test_escape_and_forced_columns(self):
+        rows = [('a"b', "plain", 7)]
+
+        assert encode_csv_rows(rows, escape="\\", forced={1, 2}) == b'"a\\"b","plain","7"\n'
+
+    def test_force_quote_columns(self):
+        assert force_quote_columns(["*"], ["a", "b"]) == {0, 1}
+        assert force_quote_columns(['"B"'], ["a", "b"]) == {1}
+        with pytest.raises(CopyExportError) as exc_info:
+            force_quote_columns(["c"], ["a", "b"])
+
+        assert exc_info.value.sqlstate == "42P10"
+
+
+class TestStreaming:
+    @pytest.mark.asyncio
+    async def test_table_copy_streams_batches(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_COPY_FETCH_SIZE", "2")
+        iris = FakeIRIS([(1, "Ann"), (2, None), (3, "C\tD")])
+        bulk_executor = BulkExecutor(iris)
+        handler = CopyHandler(CSVProcessor(), bulk_executor)
+        command = parse_copy_command("COPY public.people (id, name) TO stdout;")
+
+        export = await bulk_executor.table_export(command.table_name, command.column_list)
+        chunks = [chunk async for chunk in handler.handle_copy_to_stdout(command, export)]
+
+        assert iris.batch_sizes == [2]
+        assert iris.statements[-1] == 'SELECT id, "Name" FROM SQLUser.people'
+        assert chunks == [b"1\tAnn\n2\t\\N\n", b"3\tC\\tD\n"]
+
+    @pytest.mark.asyncio
+    async def test_query_copy_reads_result_rows(self):
+        iris = FakeIRIS(query_rows=[[1, "x"], [2, None]])
+        handler = CopyHandler(CSVProcessor(), BulkExecutor(iris))
+        command = parse_copy_command("COPY (SELECT a, b FROM t) TO STDOUT")
+
+        chunks = [chunk async for chunk in handler.handle_copy_to_stdout(command)]
+
+        assert b"".join(chunks) == b"1\tx\n2\t\\N\n"
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol(result):
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(return_value=result)
+        writer = MagicMock()
+        writer.drain = AsyncMock()
+        protocol = PGWireProtocol(asyncio.StreamReader(), writer, executor, "copy")
+        return protocol, writer
+
+    @staticmethod
+    def _sent(writer):
+        return b"".join(call.args[0] for call in writer.write.call_args_list)
+
+    @pytest.mark.asyncio
+    async def test_query_copy_csv_with_header(self):
+        protocol, writer = self._protocol(
+            {
+                "success": True,
+                "rows": [[1, "a\nb"], [2, None]],
+                "columns": [{"name": "id"}, {"name": "note"}],
+            }
+        )
+
+        await protocol.handle_copy_command(
+            "COPY (SELECT id, note FROM t) TO STDOUT WITH CSV HEADER"
+        )
+
+        sent = self._sent(writer)
+        assert sent.startswith(b"H" + struct.pack("!IbH", 11, 0, 2))
+        assert b"id,note\n" in sent and b'1,"a\nb"\n2,\n' in sent
+        assert b"COPY 2\x00" in sent  # The quoted line break is not a row
+
+    @pytest.mark.asyncio
+    async def test_failing_query_keeps_its_error(self):
+        protocol, writer = self._protocol(
+            {
+                "success": False,
+                "error": 'relation "nope" does not exist',
+                "sqlstate": "42P01",
+                "condition_name": "undefined_table",
+            }
+        )
+
+        await protocol.handle_copy_command("COPY (SELECT * FROM nope) TO STDOUT")
+
+        sent = self._sent(writer)
+        assert not sent.startswith(b"H")
+        assert b"42P01" in sent and sent.endswith(b"Z\x00\x00\x00\x05I")
+
+    @pytest.mark.asyncio
+    async def test_force_quote_requires_csv(self):
+        protocol, writer = self._protocol({"success": True, "rows": [], "columns": []})
+
+        await protocol.handle_copy_command("COPY t TO STDOUT (FORCE_QUOTE *)")
+
+        assert b"0A000" in self._sent(writer)
@@ -0,0 +1,179 @@
+"""
+Unit Tests: COPY FROM STDIN
+
+Text-format (PostgreSQL's default), CSV and binary data through the
+CopyInResponse / CopyData / CopyDone flow into batched IRIS INSERTs.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.copy_binary import HEADER, TRAILER, encode_binary_rows
+from iris_pgwire.csv_processor import CSVParsingError, CSVProcessor, split_text_line
+from iris_pgwire.protocol import STATUS_IDLE, STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.sql_translator.copy_parser import parse_copy_command
+from tests.protocol_messages import frontend_message
+
+
+class FakeIRIS:
+    """Answers the column lookup with COLUMNS and records statements and batches."""
+
+    COLUMNS = [("id", "integer"), ("name", "varchar")]
+
+    def __init__(self):
+        self.statements = []
+        self.batches = []
+
+    async def execute_query(self, sql, params=None, session_id=None):
+        self.statements.append(sql)
+        return {"success": True, "rows": self.COLUMNS, "columns": []}
+
+    async def execute_many(self, sql, params_list, session_id=None):
+        self.statements.append(sql)
+        self.batches.append(params_list)
+        return {"success": True, "rows_affected": len(params_list)}
+
+
+async def _stream(*chunks):
+    for chunk in chunks:
+        yield chunk
+
+
+async def _rows(data, sql, column_names=None):
+    options = parse_copy_command(sql).csv_options
+    stream = CSVProcessor().parse_csv_rows(_stream(*data), options, column_names)
+    return [row async for row in stream]
+
+
+class TestTextFormat:
+    def test_split_plain_line(self):
+        assert split_text_line("1\tAnn\t\\N") == ["1", "Ann", None]
+
+    def test_escapes(self):
+        assert split_text_line("a\\tb\\\\c\\nd\t\\x41\\101\t\\N\\N") == [
+            "a\tb\\c\nd",
+            "AA",
+            "NN",  # Only a field written exactly as \N is NULL
+        ]
+
+    def test_custom_delimiter_and_null(self):
+        assert split_text_line("a\\|b||x", "|", "") == ["a|b", None, "x"]
+
+    @pytest.mark.asyncio
+    async def test_rows_across_chunks(self):
+        data = [b"1\tAn", b"n\n2\t\\N\r\n", b"\\.\n"]
+
+        rows = await _rows(data, "COPY t FROM STDIN", ["id", "name"])
+
+        assert rows == [{"id": "1", "name": "Ann"}, {"id": "2", "name": None}]
+
+    @pytest.mark.asyncio
+    async def test_header_skipped(self):
+        rows = await _rows([b"id\tname\n1\tAnn"], "COPY t FROM STDIN (HEADER)", ["id", "name"])
+
+        assert rows == [{"id": "1", "name": "Ann"}]
+
+    @pytest.mark.asyncio
+    async def test_column_count_mismatch(self):
+        with pytest.raises(CSVParsingError) as exc_info:
+            await _rows([b"1\tAnn\n2\n"], "COPY t FROM STDIN", ["id", "name"])
+
+        assert exc_info.value.line_number == 2
+
+
+class TestCSVFormat:
+    @pytest.mark.asyncio
+    async def test_legacy_csv_header_syntax(self):
+        rows = await _rows(
+            [b"ID,NAME\n1,Ann\n"], "COPY t (id, name) FROM STDIN WITH CSV HEADER", ["id", "name"]
+        )
+
+        assert rows == [{"id": "1", "name": "Ann"}]
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol(transaction_status, *messages):
+        iris = FakeIRIS()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(side_effect=iris.execute_query)
+        executor.execute_many = AsyncMock(side_effect=iris.execute_many)
+        reader = asyncio.StreamReader()
+        reader.feed_data(b"".join(messages))
+        writer = MagicMock()
+        writer.drain = AsyncMock()
+        protocol = PGWireProtocol(reader, writer, executor, "copy")
+        protocol.transaction_status = transaction_status
+        return protocol, iris, writer
+
+    @staticmethod
+    def _sent(writer):
+        return b"".join(call.args[0] for call in writer.write.call_args_list)
+
+    @pytest.mark.asyncio
+    async def test_text_copy_into_qualified_table(self):
+        protocol, iris, writer = self._protocol(
+            STATUS_IDLE,
+            frontend_message(b"d", b"1\tAnn\n2\t\\N\n"),
+            frontend_message(b"c"),
+        )
+
+        await protocol.handle_copy_command("COPY public.people FROM STDIN;")
+
+        assert iris.batches == [[["1", "Ann"], ["2", None]]]
+        assert "INSERT INTO SQLUser.people (id, name) VALUES (?, ?)" in iris.statements
+        assert iris.statements[1] == "START TRANSACTION" and iris.statements[-1] == "COMMIT"
+        sent = self._sent(writer)
+        assert sent.startswith(b"G") and b"COPY 2\x00" in sent
+
+    @pytest.mark.asyncio
+    async def test_inside_transaction_block(self):
+        protocol, iris, writer = self._protocol(
+            STATUS_IN_TRANSACTION, frontend_message(b"d", b"1\tAnn\n"), frontend_message(b"c")
+        )
+
+        await protocol.handle_copy_command("COPY people (id, name) FROM STDIN")
+
+        assert "START TRANSACTION" not in iris.statements
+        assert "COMMIT" not in iris.statements
+        assert b"COPY 1\x00" in self._sent(writer)
+
+    @pytest.mark.asyncio
+    async def test_bad_data_discards_rest_of_copy(self):
+        protocol, iris, writer = self._protocol(
+            STATUS_IDLE,
+            frontend_message(b"d", b"1\n"),
+            frontend_message(b"d", b"2\tBob\n"),
+            frontend_message(b"c"),
+        )
+
+        await protocol.handle_copy_command("COPY people FROM STDIN")
+
+        assert iris.statements[-1] == "ROLLBACK"
+        assert reader_drained(protocol)
+        sent = self._sent(writer)
+        assert b"22P04" in sent and sent.endswith(b"Z\x00\x00\x00\x05I")
+
+    @pytest.mark.asyncio
+    async def test_binary_copy(self):
+        data = encode_binary_rows([(1, "Ann"), (2, None)], [23, 1043])
+        protocol, iris, writer = self._protocol(
+            STATUS_IDLE,
+            frontend_message(b"d", HEADER + data[:7]),
+            frontend_message(b"d", data[7:] + TRAILER),
+            frontend_message(b"c"),
+        )
+
+        await protocol.handle_copy_command('copy "people" ( "id", "name" ) from stdin binary;')
+
+        assert iris.batches == [[[1, "Ann"], [2, None]]]
+        sent = self._sent(writer)
+        assert sent.startswith(b"G" + struct.pack("!IbHHH", 11, 1, 2, 1, 1))
+        assert b"COPY 2\x00" in sent
+
+
+def reader_drained(protocol):
+    return protocol.reader._buffer == b""
@@ -40,7 +40,8 @@ class TestCopyCommandParser:
         assert cmd.table_name == "Patients"
         assert cmd.direction == CopyDirection.FROM_STDIN
         assert cmd.column_list is None
-        assert cmd.csv_options.format == "CSV"
+        assert cmd.csv_options.format == "TEXT"  # PostgreSQL's default format
+        assert cmd.csv_options.delimiter == "\t"
         assert cmd.csv_options.header is False
 
     def test_copy_from_stdin_with_columns(self):
@@ -0,0 +1,124 @@
+"""
+Unit Tests: Epoch and Date/Time Function Translation
+
+extract(epoch), to_timestamp(epoch), make_timestamp/make_date and
+make_interval arithmetic rewritten to IRIS DATEDIFF/DATEADD/DATEPART.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator.datetime_function_translator import DateTimeFunctionTranslator
+
+
+@pytest.fixture
+def translator():
+    return DateTimeFunctionTranslator()
+
+
+class TestExtract:
+    """extract() and date_part()."""
+
+    def test_epoch_from_column(self, translator):
+        sql, count = translator.translate("SELECT EXTRACT(EPOCH FROM CREATED_AT) FROM EVENTS")
+
+        assert sql == "SELECT DATEDIFF('s', '1970-01-01 00:00:00', CREATED_AT) FROM EVENTS"
+        assert count == 1
+
+    def test_epoch_of_timestamp_difference(self, translator):
+        # pg_stat_activity style: extract(epoch from now() - query_start)
+        sql, _ = translator.translate("SELECT EXTRACT(EPOCH FROM (NOW() - QUERY_START)) FROM X")
+
+        assert sql == "SELECT DATEDIFF('s', QUERY_START, NOW()) FROM X"
+
+    def test_date_part_epoch(self, translator):
+        sql, _ = translator.translate("SELECT DATE_PART('epoch', TS) FROM T")
+
+        assert sql == "SELECT DATEDIFF('s', '1970-01-01 00:00:00', TS) FROM T"
+
+    @pytest.mark.parametrize(
+        "field,expected",
+        [
+            ("DOW", "(DATEPART('dw', TS) - 1)"),
+            ("ISODOW", "(CASE DATEPART('dw', TS) WHEN 1 THEN 7 ELSE DATEPART('dw', TS) - 1 END)"),
+            ("DOY", "DATEPART('dy', TS)"),
+            ("YEAR", "DATEPART('yy', TS)"),
+        ],
+    )
+    def test_fields_map_to_datepart(self, translator, field, expected):
+        sql, _ = translator.translate(f"SELECT EXTRACT({field} FROM TS)")
+
+        assert sql == f"SELECT {expected}"
+
+    def test_unknown_field_left_alone(self, translator):
+        sql = "SELECT EXTRACT(JULIAN FROM TS)"
+
+        assert translator.translate(sql) == (sql, 0)
+
+
+class TestConstructors:
+    """to_timestamp(), make_timestamp(), make_date()."""
+
+    def test_to_timestamp_literal_is_folded(self, translator):
+        sql, _ = translator.translate("SELECT TO_TIMESTAMP(1700000000)")
+
+        assert sql == "SELECT CAST('2023-11-14 22:13:20' AS TIMESTAMP)"
+
+    def test_to_timestamp_expression(self, translator):
+        sql, _ = translator.translate("SELECT TO_TIMESTAMP(E.SECS) FROM E")
+
+        assert sql == "SELECT DATEADD('s', E.SECS, '1970-01-01 00:00:00') FROM E"
+
+    def test_to_timestamp_with_format_is_native(self, translator):
+        sql = "SELECT TO_TIMESTAMP('2024-01-15', 'YYYY-MM-DD')"
+
+        assert translator.translate(sql) == (sql, 0)
+
+    def test_make_timestamp_literal(self, translator):
+        sql, _ = translator.translate("SELECT MAKE_TIMESTAMP(2024, 1, 15, 10, 30, 5.5)")
+
+        assert sql == "SELECT CAST('2024-01-15 10:30:05.5' AS TIMESTAMP)"
+
+    def test_make_date_expression(self, translator):
+        sql, _ = translator.translate("SELECT MAKE_DATE(Y, M, 1) FROM T")
+
+        assert sql == (
+            "SELECT CAST(DATEADD('dd', (1) - 1, DATEADD('mm', (M) - 1, "
+            "DATEADD('yy', (Y) - 1900, '1900-01-01 00:00:00'))) AS DATE) FROM T"
+        )
+
+    def test_nested_calls_counted(self, translator):
+        sql, count = translator.translate("SELECT EXTRACT(EPOCH FROM TO_TIMESTAMP(X))")
+
+        assert sql == (
+            "SELECT DATEDIFF('s', '1970-01-01 00:00:00', DATEADD('s', X, '1970-01-01 00:00:00'))"
+        )
+        assert count == 2
+
+
+class TestMakeInterval:
+    """Timestamp ± make_interval()."""
+
+    def test_now_minus_named_interval(self, translator):
+        sql, _ = translator.translate("SELECT * FROM T WHERE TS > NOW() - MAKE_INTERVAL(SECS => 30)")
+
+        assert sql == "SELECT * FROM T WHERE TS > DATEADD('ss', -30, NOW())"
+
+    def test_positional_components_applied_largest_first(self, translator):
+        sql, _ = translator.translate("SELECT TS + MAKE_INTERVAL(0, 1, 0, 2) FROM T")
+
+        assert sql == "SELECT DATEADD('dd', 2, DATEADD('mm', 1, TS)) FROM T"
+
+    def test_subtracting_expression_component(self, translator):
+        sql, _ = translator.translate("SELECT TS - MAKE_INTERVAL(DAYS => N) FROM T")
+
+        assert sql == "SELECT DATEADD('dd', -(N), TS) FROM T"
+
+    def test_standalone_interval_left_alone(self, translator):
+        sql = "SELECT MAKE_INTERVAL(DAYS => 1)"
+
+        assert translator.translate(sql) == (sql, 0)
+
+    def test_text_inside_literal_ignored(self, translator):
+        sql = "SELECT 'extract(epoch from x)'"
+
+        assert translator.translate(sql) == (sql, 0)
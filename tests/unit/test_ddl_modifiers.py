@@ -0,0 +1,171 @@
+"""
+Unit Tests: IF [NOT] EXISTS and UNLOGGED
+
+Modifiers parsed off CREATE / DROP / ALTER statements, existence checked
+against INFORMATION_SCHEMA and statements skipped with PostgreSQL's NOTICEs.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.ddl_modifiers import (
+    Condition,
+    existence_sql,
+    parse_ddl_modifiers,
+    skip_notice,
+)
+from iris_pgwire.iris_executor import IRISExecutor
+
+
+class TestParsing:
+    def test_create_if_not_exists(self):
+        ddl = parse_ddl_modifiers("CREATE UNIQUE INDEX IF NOT EXISTS idx_email ON users (email);")
+
+        assert ddl.statement() == "CREATE UNIQUE INDEX idx_email ON users (email)"
+        assert ddl.tag == "CREATE INDEX"
+        assert ddl.conditions == [Condition("index", "idx_email", exists=False)]
+
+    def test_unlogged(self):
+        ddl = parse_ddl_modifiers("CREATE UNLOGGED TABLE IF NOT EXISTS cache (k TEXT, v TEXT)")
+
+        assert ddl.statement() == "CREATE TABLE cache (k TEXT, v TEXT)"
+        assert ddl.notices[0][:2] == ("NOTICE", "00000")
+        assert '"cache"' in ddl.notices[0][2]
+
+    def test_drop_several(self):
+        ddl = parse_ddl_modifiers('DROP TABLE IF EXISTS orders, sales."Items" CASCADE')
+
+        assert ddl.names == ["orders", 'sales."Items"']
+        assert ddl.statement(["orders"]) == "DROP TABLE orders CASCADE"
+        assert all(condition.exists for condition in ddl.conditions)
+
+    def test_alter(self):
+        ddl = parse_ddl_modifiers("ALTER TABLE IF EXISTS orders RENAME TO purchases")
+
+        assert ddl.statement() == "ALTER TABLE orders RENAME TO purchases"
+        assert ddl.conditions == [Condition("table", "orders", exists=True)]
+
+    def test_add_column_if_not_exists(self):
+        ddl = parse_ddl_modifiers("ALTER TABLE orders ADD COLUMN IF NOT EXISTS note VARCHAR(50)")
+
+        assert ddl.statement() == "ALTER TABLE orders ADD COLUMN note VARCHAR(50)"
+        assert ddl.conditions == [Condition("column", "note", exists=False, relation="orders")]
+
+    def test_set_unlogged(self):
+        ddl = parse_ddl_modifiers("ALTER TABLE orders SET UNLOGGED")
+
+        assert not ddl.runs
+        assert ddl.notices
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "CREATE TABLE orders (id INT)",
+            "DROP TABLE orders",
+            "ALTER TABLE orders ADD COLUMN note TEXT",
+            "SELECT * FROM orders WHERE EXISTS (SELECT 1)",
+        ],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_ddl_modifiers(sql) is None
+
+
+class TestExistence:
+    def test_queries(self):
+        table = existence_sql(Condition("view", "public.v_orders", exists=True), "SQLUser")
+        column = existence_sql(
+            Condition("column", "note", exists=False, relation="orders"), "SQLUser"
+        )
+
+        assert table == (
+            "SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE "
+            "LOWER(TABLE_SCHEMA) = LOWER('SQLUser') AND LOWER(TABLE_NAME) = LOWER('v_orders') "
+            "AND TABLE_TYPE = 'VIEW'"
+        )
+        assert "INFORMATION_SCHEMA.COLUMNS" in column and "LOWER('note')" in column
+        assert existence_sql(Condition("sequence", "s", exists=True), "SQLUser") is None
+
+    @pytest.mark.parametrize(
+        "condition,tag,notice",
+        [
+            (
+                Condition("table", "orders", exists=False),
+                "CREATE TABLE",
+                ("NOTICE", "42P07", 'relation "orders" already exists, skipping'),
+            ),
+            (
+                Condition("schema", "sales", exists=False),
+                "CREATE SCHEMA",
+                ("NOTICE", "42P06", 'schema "sales" already exists, skipping'),
+            ),
+            (
+                Condition("index", "public.idx", exists=True),
+                "DROP INDEX",
+                ("NOTICE", "00000", 'index "idx" does not exist, skipping'),
+            ),
+            (
+                Condition("table", "orders", exists=True),
+                "ALTER TABLE",
+                ("NOTICE", "00000", 'relation "orders" does not exist, skipping'),
+            ),
+            (
+                Condition("column", "note", exists=False, relation="orders"),
+                "ALTER TABLE",
+                ("NOTICE", "42701", 'column "note" of relation "orders" already exists, skipping'),
+            ),
+        ],
+    )
+    def test_skip_notices(self, condition, tag, notice):
+        assert skip_notice(condition, tag) == notice
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch, existing):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.executed = []
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_execute(sql, params=None, session_id=None):
+            if sql.startswith("SELECT"):
+                found = [name for name in existing if f"LOWER('{name}')" in sql]
+                return {"success": True, "rows": [(name,) for name in found], "columns": []}
+            executor.executed.append(sql)
+            return {"success": True, "rows": [], "columns": [], "command_tag": "DROP TABLE"}
+
+        executor._execute_query = fake_execute
+        return executor
+
+    def _run(self, executor, sql):
+        return asyncio.run(executor._execute_modified_ddl(parse_ddl_modifiers(sql)))
+
+    def test_existing_object_skipped(self, monkeypatch):
+        executor = self._executor(monkeypatch, ["orders"])
+
+        result = self._run(executor, "CREATE TABLE IF NOT EXISTS orders (id INT)")
+
+        assert executor.executed == []
+        assert result["command_tag"] == "CREATE TABLE"
+        assert result["notices"][0][1] == "42P07"
+
+    def test_drop_keeps_existing_names(self, monkeypatch):
+        executor = self._executor(monkeypatch, ["orders"])
+
+        result = self._run(executor, "DROP TABLE IF EXISTS orders, missing")
+
+        assert executor.executed == ["DROP TABLE orders"]
+        assert result["notices"] == [
+            ("NOTICE", "00000", 'table "missing" does not exist, skipping')
+        ]
+
+    def test_drop_sequence_always_skipped(self, monkeypatch):
+        executor = self._executor(monkeypatch, [])
+
+        result = self._run(executor, "DROP SEQUENCE IF EXISTS order_seq")
+
+        assert result["success"] and executor.executed == []
+        assert result["command_tag"] == "DROP SEQUENCE"
@@ -0,0 +1,116 @@
+"""
+Unit Tests: PostgreSQL-only DDL Clauses
+
+DEFERRABLE / INITIALLY DEFERRED removed from CREATE/ALTER TABLE, with the
+WARNING clients receive when deferred checking was requested.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.ddl_translator import DDLTranslator, ddl_notices
+from tests.protocol_messages import FakeWriter
+
+
+@pytest.fixture
+def translator():
+    return DDLTranslator()
+
+
+class TestConstraintTiming:
+    @pytest.mark.parametrize(
+        "sql,expected,count",
+        [
+            (
+                "CREATE TABLE b (a_id INT REFERENCES a (id) DEFERRABLE INITIALLY DEFERRED)",
+                "CREATE TABLE b (a_id INT REFERENCES a (id))",
+                2,
+            ),
+            (
+                "ALTER TABLE b ADD CONSTRAINT b_fk FOREIGN KEY (a_id) REFERENCES a (id) "
+                "not deferrable initially immediate",
+                "ALTER TABLE b ADD CONSTRAINT b_fk FOREIGN KEY (a_id) REFERENCES a (id)",
+                2,
+            ),
+            (
+                "CREATE TABLE t (code VARCHAR(10) DEFAULT 'DEFERRABLE' UNIQUE DEFERRABLE)",
+                "CREATE TABLE t (code VARCHAR(10) DEFAULT 'DEFERRABLE' UNIQUE)",
+                1,
+            ),
+            (
+                "SELECT 'x' INITIALLY DEFERRED FROM t",
+                "SELECT 'x' INITIALLY DEFERRED FROM t",
+                0,
+            ),
+        ],
+    )
+    def test_clauses_removed(self, translator, sql, expected, count):
+        assert translator.translate(sql) == (expected, count)
+
+    def test_only_initially_deferred_warns(self):
+        assert ddl_notices("CREATE TABLE b (a INT UNIQUE DEFERRABLE INITIALLY DEFERRED)")
+        assert ddl_notices("CREATE TABLE b (a INT UNIQUE DEFERRABLE)") == []
+        assert ddl_notices("INSERT INTO t VALUES ('INITIALLY DEFERRED')") == []
+
+    def test_part_of_normalization(self):
+        normalized = SQLTranslator().normalize_sql(
+            "CREATE TABLE b (a_id INT REFERENCES a (id) DEFERRABLE INITIALLY DEFERRED)"
+        )
+
+        assert "DEFERR" not in normalized.upper()
+
+
+class TestByteaColumns:
+    def test_created_as_binary_streams(self, translator):
+        assert translator.translate(
+            "CREATE TABLE files (name TEXT DEFAULT 'bytea', \"bytea\" INT, body BYTEA NOT NULL)"
+        ) == (
+            "CREATE TABLE files (name TEXT DEFAULT 'bytea', \"bytea\" INT, "
+            "body LONGVARBINARY NOT NULL)",
+            1,
+        )
+        assert translator.translate("ALTER TABLE files ADD COLUMN thumb bytea") == (
+            "ALTER TABLE files ADD COLUMN thumb LONGVARBINARY",
+            1,
+        )
+        assert translator.translate("SELECT CAST(x AS BYTEA) FROM t")[1] == 0
+
+
+class TestWarnings:
+    @staticmethod
+    def _protocol():
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(
+            return_value={"success": True, "rows": [], "columns": [], "command_tag": "CREATE"}
+        )
+        writer = FakeWriter()
+        return PGWireProtocol(MagicMock(), writer, executor, "ddl"), writer
+
+    def test_deferred_constraint_warning_before_command_complete(self):
+        protocol, writer = self._protocol()
+
+        asyncio.run(
+            protocol.handle_query_message(
+                b"CREATE TABLE b (a_id INT REFERENCES a (id) INITIALLY DEFERRED)\x00"
+            )
+        )
+
+        notice = writer.buffer.index(b"N")
+        assert b"SWARNING\x00C01000\x00" in writer.buffer
+        assert b"checked immediately" in writer.buffer
+        assert notice < writer.buffer.index(b"C\x00\x00\x00")
+
+    def test_set_constraints_deferred_warns(self):
+        protocol, writer = self._protocol()
+
+        asyncio.run(protocol.handle_query_message(b"SET CONSTRAINTS ALL DEFERRED\x00"))
+        deferred = writer.buffer
+        writer.buffer = b""
+        asyncio.run(protocol.handle_query_message(b"SET CONSTRAINTS ALL IMMEDIATE\x00"))
+
+        assert b"checked immediately" in deferred
+        assert b"checked immediately" not in writer.buffer
@@ -0,0 +1,173 @@
+"""
+Unit Tests: Statement Describe Cache
+
+Statement Describes answered from the schema cache, executions that reuse
+the statement's RowDescription without a Describe of their own (pgx's
+statement cache), and the counters in the stats snapshot.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+import iris_pgwire.catalog.schema_cache as schema_cache_module
+from iris_pgwire.catalog.schema_cache import STAMP_SQL, SchemaCache
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, backend_messages, frontend_message
+
+QUERY = "SELECT id, name FROM orders WHERE id = ?"
+COLUMNS = [
+    {"name": "id", "type_oid": 23, "type_size": 4, "type_modifier": -1, "format_code": 0},
+    {"name": "name", "type_oid": 1043, "type_size": -1, "type_modifier": -1, "format_code": 0},
+]
+
+
+class FakeIRIS:
+    """Counts statement runs; answers the stamp query with stamp."""
+
+    def __init__(self):
+        self.stamp = [3, "2026-10-01 09:00:00"]
+        self.runs = []
+
+    async def execute(self, sql, params=None, session_id=None):
+        if sql == STAMP_SQL:
+            return {"success": True, "rows": [list(self.stamp)]}
+        self.runs.append((sql, params))
+        if sql.startswith("CREATE"):
+            return {"success": True, "rows": [], "command_tag": "CREATE TABLE"}
+        if sql.startswith("SELECT broken"):
+            return {"success": False, "error": "boom", "rows": []}
+        return {"success": True, "rows": [[1, "a"]], "columns": COLUMNS, "row_count": 1}
+
+
+@pytest.fixture
+def executor(monkeypatch):
+    monkeypatch.setattr(schema_cache_module, "_schema_cache", None)
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.iris_config = {"namespace": "USER"}
+    executor.schema_cache = SchemaCache(enabled=True, check_interval=60)
+    executor.shadow = None
+    executor.fake = FakeIRIS()
+    executor._execute_query = executor.fake.execute
+    return executor
+
+
+def _run(coroutine):
+    return asyncio.run(coroutine)
+
+
+def test_description_cached_per_statement_and_parameter_types(executor):
+    stats = get_stats()
+    hits, misses = stats.total_describe_hits, stats.total_describe_misses
+
+    first = _run(executor.describe_statement(QUERY, [23]))
+    second = _run(executor.describe_statement(QUERY, [23]))
+    _run(executor.describe_statement(QUERY, [20]))
+
+    assert first == (COLUMNS, False) and second == (COLUMNS, True)
+    assert executor.fake.runs == [(QUERY, [None]), (QUERY, [None])]
+    assert (stats.total_describe_hits - hits, stats.total_describe_misses - misses) == (1, 2)
+    assert executor.schema_cache.describe_hits == 1
+
+
+def test_failures_not_cached_and_ddl_invalidates(executor):
+    assert _run(executor.describe_statement("SELECT broken", [])) == (None, False)
+    assert _run(executor.describe_statement("SELECT broken", [])) == (None, False)
+
+    async def session():
+        await executor.describe_statement(QUERY, [23])
+        await executor.execute_query("CREATE TABLE t (id INT)")
+        return await executor.describe_statement(QUERY, [23])
+
+    assert _run(session())[1] is False
+    assert [sql for sql, _ in executor.fake.runs].count(QUERY) == 2
+
+
+def test_dictionary_change_and_forget(executor):
+    _run(executor.describe_statement(QUERY, [23]))
+    executor.schema_cache.check_interval = 0
+    executor.fake.stamp = [4, "2026-10-02 09:00:00"]
+    assert _run(executor.describe_statement(QUERY, [23]))[1] is False
+
+    executor.forget_statement_description(QUERY, [23])
+    assert _run(executor.describe_statement(QUERY, [23]))[1] is False
+
+
+def _prepare() -> bytes:
+    parse = b"s\x00SELECT id, name FROM orders WHERE id = $1\x00" + struct.pack("!HI", 1, 23)
+    return (
+        frontend_message(b"P", parse)
+        + frontend_message(b"D", b"Ss\x00")
+        + frontend_message(b"S")
+    )
+
+
+def _execute(value: bytes) -> bytes:
+    # Binary results, chosen from the cached RowDescription as pgx does
+    bind = b"\x00s\x00" + struct.pack("!HHI", 0, 1, len(value)) + value
+    bind += struct.pack("!HH", 1, 1)
+    return (
+        frontend_message(b"B", bind)
+        + frontend_message(b"E", b"\x00" + struct.pack("!I", 0))
+        + frontend_message(b"S")
+    )
+
+
+def _protocol(data: bytes, result_columns):
+    async def run():
+        reader = asyncio.StreamReader()
+        reader.feed_data(data + frontend_message(b"X"))
+        reader.feed_eof()
+        executor = MagicMock()
+        executor.describe_statement = AsyncMock(return_value=(COLUMNS, True))
+        executor.execute_query = AsyncMock(
+            return_value={
+                "success": True,
+                "rows": [[7, "seven"]],
+                "columns": result_columns,
+                "row_count": 1,
+                "command_tag": "SELECT 1",
+            }
+        )
+        protocol = PGWireProtocol(reader, FakeWriter(), executor, "describe")
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return protocol
+
+    return asyncio.run(run())
+
+
+def test_executions_reuse_the_statement_description():
+    skipped = get_stats().total_describes_skipped
+    # IRIS types the bound column differently than in the described run
+    result_columns = [{**COLUMNS[0], "type_oid": 1043, "type_size": -1}, COLUMNS[1]]
+
+    protocol = _protocol(_prepare() + _execute(b"7") + _execute(b"8"), result_columns)
+
+    messages = backend_messages(protocol.writer.buffer)
+    assert [m[0] for m in messages] == [
+        *(b"1", b"t", b"T", b"Z"),
+        *(b"2", b"D", b"C", b"Z"),
+        *(b"2", b"D", b"C", b"Z"),
+    ]
+    protocol.iris_executor.describe_statement.assert_awaited_once()
+    assert protocol.iris_executor.execute_query.await_count == 2
+    # DataRows follow the described int4, in binary
+    assert messages[5][1] == struct.pack("!HIiI", 2, 4, 7, 5) + b"seven"
+    assert get_stats().total_describes_skipped - skipped == 2
+    assert "describes_skipped" in get_stats().snapshot()["totals"]
+
+
+def test_changed_result_shape_invalidates_the_description():
+    protocol = _protocol(_prepare() + _execute(b"7"), COLUMNS + [{"name": "extra"}])
+
+    messages = backend_messages(protocol.writer.buffer)
+    assert [m[0] for m in messages][4:] == [b"2", b"E", b"Z"]
+    assert b"C0A000" in messages[5][1]
+    assert b"cached plan must not change result type" in messages[5][1]
+    forget = protocol.iris_executor.forget_statement_description
+    assert forget.call_args == protocol.iris_executor.describe_statement.await_args
+    assert "described_columns" not in protocol.prepared_statements["s"]
@@ -0,0 +1,284 @@
+"""
+Unit Tests: Expression Indexes
+
+CREATE INDEX on lower(column) / upper(column) is created on a computed column,
+and single-table queries comparing the expression read that column.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.check_constraints import relation
+from iris_pgwire.expression_indexes import (
+    ComputedColumn,
+    add_column_sql,
+    computed_column_type,
+    parse_drop_index,
+    parse_expression_index,
+    query_table,
+    references_indexed_expression,
+    rewrite_indexed_expressions,
+)
+from iris_pgwire.iris_executor import IRISExecutor
+
+USERS = relation("users", "SQLUser")
+
+
+class TestParsing:
+    @pytest.mark.parametrize(
+        "sql,statement",
+        [
+            (
+                "CREATE INDEX ON users ((lower(email)))",
+                "CREATE INDEX users_lower_idx ON users (PGWIRE_LOWER_EMAIL)",
+            ),
+            (
+                "CREATE UNIQUE INDEX users_email ON public.users (lower(email) text_pattern_ops)",
+                "CREATE UNIQUE INDEX users_email ON public.users (PGWIRE_LOWER_EMAIL)",
+            ),
+            (
+                "CREATE INDEX people_name ON people ((upper(last)), first DESC) WHERE active",
+                "CREATE INDEX people_name ON people (PGWIRE_UPPER_LAST, first DESC) WHERE active",
+            ),
+        ],
+    )
+    def test_statement(self, sql, statement):
+        assert parse_expression_index(sql).statement == statement
+
+    def test_columns(self):
+        index = parse_expression_index(
+            'CREATE INDEX ON app.users ((lower("Email")), (lower("Email")), upper(code))'
+        )
+
+        assert index.relation == "app.users"
+        assert index.columns == [
+            ComputedColumn("LOWER", '"Email"'),
+            ComputedColumn("UPPER", "code"),
+        ]
+        assert [column.name for column in index.columns] == [
+            "PGWIRE_LOWER_EMAIL",
+            "PGWIRE_UPPER_CODE",
+        ]
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "CREATE INDEX i ON users (email)",
+            "CREATE INDEX i ON users ((lower(email || name)))",
+            "CREATE INDEX i ON users ((lower(email)), (date_trunc('day', created_at)))",
+            "CREATE INDEX i ON users (substr(email, 1, 3))",
+            "CREATE TABLE users (email VARCHAR(255))",
+        ],
+    )
+    def test_other_statements(self, sql):
+        assert parse_expression_index(sql) is None
+
+    @pytest.mark.parametrize(
+        "sql,names",
+        [
+            ("DROP INDEX users_lower_idx", ["users_lower_idx"]),
+            ('DROP INDEX CONCURRENTLY public.a, "B" RESTRICT;', ["public.a", '"B"']),
+            ("DROP INDEX a ON SQLUser.users", None),
+            ("DROP TABLE users", None),
+        ],
+    )
+    def test_drop_index(self, sql, names):
+        assert parse_drop_index(sql) == names
+
+
+class TestComputedColumns:
+    def test_add_column(self):
+        column = ComputedColumn("LOWER", "email")
+
+        assert add_column_sql(USERS, column, "EMAIL", "VARCHAR(255)") == (
+            "ALTER TABLE SQLUser.users ADD COLUMN PGWIRE_LOWER_EMAIL VARCHAR(255) "
+            'COMPUTECODE {SET {*} = $ZCONVERT({EMAIL},"L")} CALCULATED'
+        )
+
+    @pytest.mark.parametrize(
+        "row,column_type",
+        [
+            (("EMAIL", "varchar", 255), "VARCHAR(255)"),
+            (("CODE", "CHAR", 8), "VARCHAR(8)"),
+            (("AGE", "integer", None), None),
+            (("NOTES", "longvarchar", None), None),
+            (None, None),
+        ],
+    )
+    def test_column_type(self, row, column_type):
+        assert computed_column_type(row) == column_type
+
+
+class TestQueries:
+    COMPUTED = {"PGWIRE_LOWER_EMAIL"}
+
+    def _rewrite(self, sql):
+        query = query_table(sql)
+        return rewrite_indexed_expressions(sql, query, self.COMPUTED)[0] if query else sql
+
+    @pytest.mark.parametrize(
+        "sql,expected",
+        [
+            (
+                "SELECT id FROM users WHERE lower(email) = ?",
+                "SELECT id FROM users WHERE PGWIRE_LOWER_EMAIL = ?",
+            ),
+            (
+                "SELECT u.id FROM users AS u WHERE LOWER( u.email ) = ? ORDER BY lower(email)",
+                "SELECT u.id FROM users AS u WHERE u.PGWIRE_LOWER_EMAIL = ? ORDER BY lower(email)",
+            ),
+            (
+                "UPDATE users SET name = 'lower(email)' WHERE lower(email) IN (?, ?)",
+                "UPDATE users SET name = 'lower(email)' WHERE PGWIRE_LOWER_EMAIL IN (?, ?)",
+            ),
+            (
+                "DELETE FROM public.users WHERE lower(email) LIKE 'a%' AND upper(email) = 'A'",
+                "DELETE FROM public.users WHERE PGWIRE_LOWER_EMAIL LIKE 'a%' "
+                "AND upper(email) = 'A'",
+            ),
+        ],
+    )
+    def test_rewrite(self, sql, expected):
+        assert self._rewrite(sql) == expected
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "SELECT lower(email) FROM users",
+            "SELECT id FROM users JOIN orders ON orders.user_id = users.id "
+            "WHERE lower(email) = ?",
+            "SELECT id FROM users, orders WHERE lower(email) = ?",
+            "SELECT id FROM users WHERE id IN (SELECT user_id FROM orders) AND lower(email) = ?",
+            "UPDATE users SET email = ? FROM orders WHERE lower(email) = ?",
+            "SELECT id FROM users WHERE lower(name) = ?",
+            "SELECT id FROM users WHERE lower(other.email) = ?",
+            "SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE LOWER(TABLE_NAME) = 'users'",
+        ],
+    )
+    def test_unchanged(self, sql):
+        assert self._rewrite(sql) == sql
+
+    def test_quick_check(self):
+        assert references_indexed_expression("select * from t where lower(a) = ?")
+        assert not references_indexed_expression("SELECT lower('ABC')")
+        assert not references_indexed_expression("SELECT 1")
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch, columns=("ID", "EMAIL"), source=("EMAIL", "varchar", 120)):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.executed = []
+        executor.fail = None
+        executor.indexes = [("SQLUser", "USERS", "USERS_LOWER_IDX", "PGWIRE_LOWER_EMAIL")]
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_column_names(schema, table, session_id=None):
+            return list(columns)
+
+        async def fake_listing(sql, session_id=None):
+            if "CHARACTER_MAXIMUM_LENGTH" in sql:
+                return {"success": True, "rows": [source] if source else []}
+            return {"success": True, "rows": [("SQLUser", "USERS", "PGWIRE_LOWER_EMAIL")]}
+
+        async def fake_execute(sql, params=None, session_id=None):
+            if "INFORMATION_SCHEMA.INDEXES" in sql:
+                return {"success": True, "rows": list(executor.indexes)}
+            executor.executed.append(sql)
+            if executor.fail and sql.startswith(executor.fail):
+                return {"success": False, "error": "SQLCODE -400", "sqlstate": "XX000"}
+            return {"success": True, "rows": [], "columns": [], "command_tag": "OK"}
+
+        executor._table_column_names = fake_column_names
+        executor._dictionary_listing = fake_listing
+        executor._execute_query = fake_execute
+        return executor
+
+    @staticmethod
+    def _run(executor, sql):
+        return asyncio.run(executor._execute_expression_index(parse_expression_index(sql)))
+
+    def test_index_created_on_computed_column(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        result = self._run(executor, "CREATE INDEX ON users ((lower(email)))")
+
+        assert executor.executed == [
+            "ALTER TABLE SQLUser.users ADD COLUMN PGWIRE_LOWER_EMAIL VARCHAR(120) "
+            'COMPUTECODE {SET {*} = $ZCONVERT({EMAIL},"L")} CALCULATED',
+            "CREATE INDEX users_lower_idx ON users (PGWIRE_LOWER_EMAIL)",
+        ]
+        assert result["command_tag"] == "CREATE INDEX"
+        assert result["notices"] == [
+            (
+                "NOTICE",
+                "00000",
+                'expression index on "users" is created on computed column PGWIRE_LOWER_EMAIL',
+            )
+        ]
+
+    def test_drop_index_drops_computed_column(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        result = asyncio.run(executor._execute_drop_index(["users_lower_idx", "other_idx"]))
+
+        assert executor.executed == [
+            "DROP INDEX USERS_LOWER_IDX ON SQLUser.USERS",
+            "DROP INDEX other_idx",
+            "ALTER TABLE SQLUser.USERS DROP COLUMN PGWIRE_LOWER_EMAIL",
+        ]
+        assert result["command_tag"] == "DROP INDEX"
+
+    def test_column_of_another_index_kept(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+        executor.indexes.append(("SQLUser", "USERS", "USERS_EMAIL", "PGWIRE_LOWER_EMAIL"))
+
+        asyncio.run(executor._execute_drop_index(["users_lower_idx"]))
+
+        assert executor.executed == ["DROP INDEX USERS_LOWER_IDX ON SQLUser.USERS"]
+
+    def test_other_drop_index_left_to_iris(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        assert asyncio.run(executor._execute_drop_index(["orders_idx"])) is None
+        assert executor.executed == []
+
+    def test_existing_computed_column_reused(self, monkeypatch):
+        executor = self._executor(monkeypatch, columns=("ID", "EMAIL", "PGWIRE_LOWER_EMAIL"))
+
+        self._run(executor, "CREATE UNIQUE INDEX users_email ON users (lower(email))")
+
+        assert executor.executed == [
+            "CREATE UNIQUE INDEX users_email ON users (PGWIRE_LOWER_EMAIL)",
+        ]
+
+    def test_not_a_character_column(self, monkeypatch):
+        executor = self._executor(monkeypatch, source=("EMAIL", "integer", None))
+
+        assert self._run(executor, "CREATE INDEX ON users ((lower(email)))") is None
+        assert executor.executed == []
+
+    def test_failed_index_drops_added_column(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+        executor.fail = "CREATE INDEX"
+
+        result = self._run(executor, "CREATE INDEX ON users ((lower(email)))")
+
+        assert result["success"] is False
+        assert executor.executed[-1] == "ALTER TABLE SQLUser.users DROP COLUMN PGWIRE_LOWER_EMAIL"
+
+    def test_query_reads_computed_column(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        sql = asyncio.run(
+            executor._read_indexed_expressions("SELECT * FROM users WHERE lower(email) = ?")
+        )
+
+        assert sql == "SELECT * FROM users WHERE PGWIRE_LOWER_EMAIL = ?"
+        assert asyncio.run(
+            executor._read_indexed_expressions("SELECT * FROM orders WHERE lower(email) = ?")
+        ) == ("SELECT * FROM orders WHERE lower(email) = ?")
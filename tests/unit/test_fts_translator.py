@@ -0,0 +1,126 @@
+"""
+Unit Tests: Full Text Search Emulation
+
+tsvector @@ tsquery predicates mapped onto IRIS iFind (%FIND) for configured
+indexes and onto LIKE matching otherwise.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator.fts_translator import (
+    BoolOp,
+    FullTextSearchTranslator,
+    Not,
+    Term,
+    load_fts_index_map,
+    parse_tsquery,
+    parse_websearch_query,
+)
+
+
+@pytest.fixture
+def like_translator():
+    return FullTextSearchTranslator(index_map={})
+
+
+@pytest.fixture
+def ifind_translator():
+    return FullTextSearchTranslator(
+        index_map=load_fts_index_map("ARTICLES.BODY=BodyIdx, TITLE=TitleIdx")
+    )
+
+
+class TestQueryParsing:
+    """tsquery text → boolean tree."""
+
+    def test_operators_and_precedence(self):
+        tree = parse_tsquery("a | !(b & c:*)")
+
+        assert tree == BoolOp(
+            "OR", Term(("a",)), Not(BoolOp("AND", Term(("b",)), Term(("c",))))
+        )
+
+    def test_websearch_syntax(self):
+        tree = parse_websearch_query('"sad cat" or -rat')
+
+        assert tree == BoolOp("OR", Term(("sad", "cat")), Not(Term(("rat",))))
+
+    def test_index_map_parsing(self):
+        assert load_fts_index_map("articles.body = BodyIdx,bad,TITLE=") == {
+            "ARTICLES.BODY": "BodyIdx"
+        }
+
+
+class TestLikeFallback:
+    """Columns without an iFind index."""
+
+    def test_to_tsquery_predicate(self, like_translator):
+        sql, count = like_translator.translate(
+            "SELECT * FROM DOCS WHERE TO_TSVECTOR('english', BODY) @@ TO_TSQUERY('english', 'cat & !dog')"
+        )
+
+        assert sql == (
+            "SELECT * FROM DOCS WHERE (LOWER(BODY) LIKE '%cat%' AND NOT (LOWER(BODY) LIKE '%dog%'))"
+        )
+        assert count == 1
+
+    def test_plainto_tsquery_requires_every_word(self, like_translator):
+        sql, _ = like_translator.translate(
+            "SELECT * FROM DOCS WHERE TO_TSVECTOR(BODY) @@ PLAINTO_TSQUERY('Fat Rats')"
+        )
+
+        assert sql == "SELECT * FROM DOCS WHERE (LOWER(BODY) LIKE '%fat%' AND LOWER(BODY) LIKE '%rats%')"
+
+    def test_cast_tsquery_on_column(self, like_translator):
+        sql, _ = like_translator.translate("SELECT * FROM DOCS WHERE BODY @@ CAST('cat' AS TSQUERY)")
+
+        assert sql == "SELECT * FROM DOCS WHERE LOWER(BODY) LIKE '%cat%'"
+
+    def test_parameter_query_matches_as_phrase(self, like_translator):
+        sql, _ = like_translator.translate(
+            "SELECT * FROM DOCS D WHERE TO_TSVECTOR(D.BODY) @@ PLAINTO_TSQUERY(?)"
+        )
+
+        assert sql == "SELECT * FROM DOCS D WHERE (LOWER(D.BODY) LIKE '%' || LOWER(?) || '%')"
+
+    def test_ts_rank(self, like_translator):
+        sql, _ = like_translator.translate(
+            "SELECT TS_RANK(TO_TSVECTOR(BODY), TO_TSQUERY('cat | !dog')) FROM DOCS"
+        )
+
+        assert sql == (
+            "SELECT (CAST((CASE WHEN LOWER(BODY) LIKE '%cat%' THEN 1 ELSE 0 END) AS DOUBLE) / 1) "
+            "FROM DOCS"
+        )
+
+
+class TestIFind:
+    """Columns with a configured iFind index."""
+
+    def test_alias_qualified_column(self, ifind_translator):
+        sql, _ = ifind_translator.translate(
+            "SELECT * FROM ARTICLES A WHERE TO_TSVECTOR(A.BODY) @@ TO_TSQUERY('cat & !dog')"
+        )
+
+        assert sql == (
+            "SELECT * FROM ARTICLES A WHERE A.%ID %FIND search_index(BodyIdx, '(cat AND NOT dog)')"
+        )
+
+    def test_phrase_query(self, ifind_translator):
+        sql, _ = ifind_translator.translate(
+            "SELECT * FROM ARTICLES WHERE TITLE @@ PHRASETO_TSQUERY('big cat')"
+        )
+
+        assert sql == "SELECT * FROM ARTICLES WHERE %ID %FIND search_index(TitleIdx, '\"big cat\"')"
+
+    def test_parameter_query_passed_to_ifind(self, ifind_translator):
+        sql, _ = ifind_translator.translate(
+            "SELECT * FROM ARTICLES WHERE TITLE @@ WEBSEARCH_TO_TSQUERY(?)"
+        )
+
+        assert sql == "SELECT * FROM ARTICLES WHERE %ID %FIND search_index(TitleIdx, ?)"
+
+    def test_no_fts_untouched(self, ifind_translator):
+        sql = "SELECT '@@' FROM ARTICLES"
+
+        assert ifind_translator.translate(sql) == (sql, 0)
@@ -0,0 +1,112 @@
+"""
+Unit Tests: IRIS Globals as Virtual Tables
+
+Configuration parsing, query parsing and global walking against an in-memory
+global accessor.
+"""
+
+import pytest
+
+from iris_pgwire.global_tables import (
+    GlobalTableHandler,
+    ReadOnlyGlobalTable,
+    load_global_tables,
+)
+
+
+class FakeGlobalAccessor:
+    """In-memory global store with $ORDER/$DATA semantics."""
+
+    def __init__(self, nodes: dict[tuple, str]):
+        self.nodes = nodes
+        self.visited = []
+
+    def _children(self, subscripts):
+        depth = len(subscripts)
+        return sorted(
+            {key[depth] for key in self.nodes if len(key) > depth and list(key[:depth]) == subscripts}
+        )
+
+    def next_subscript(self, global_name, subscripts, previous):
+        self.visited.append(tuple(subscripts))
+        children = self._children([str(s) for s in subscripts])
+        later = [child for child in children if previous == "" or child > str(previous)]
+        return later[0] if later else None
+
+    def get(self, global_name, subscripts):
+        return self.nodes.get(tuple(str(s) for s in subscripts))
+
+    def data(self, global_name, subscripts):
+        subscripts = [str(s) for s in subscripts]
+        value = 1 if tuple(subscripts) in self.nodes else 0
+        children = 10 if self._children(subscripts) else 0
+        return value + children
+
+
+@pytest.fixture
+def handler():
+    return GlobalTableHandler(load_global_tables("orders=^Orders(order_id, line); cfg=AppConfig(key)"))
+
+
+@pytest.fixture
+def accessor():
+    return FakeGlobalAccessor(
+        {
+            ("1", "1"): "widget",
+            ("1", "2"): "gadget",
+            ("2", "1"): "gizmo",
+            ("2",): "header",  # Not at full depth - not a row
+        }
+    )
+
+
+class TestConfiguration:
+    def test_definitions_parsed(self):
+        tables = load_global_tables("orders=^Orders(order_id,line);cfg=AppConfig(key);bad")
+
+        assert tables["orders"].global_name == "^Orders"
+        assert tables["orders"].columns == ["order_id", "line", "value"]
+        assert tables["cfg"].global_name == "^AppConfig"
+        assert "bad" not in tables
+
+
+class TestQueries:
+    def test_select_star_walks_in_collation_order(self, handler, accessor):
+        result = handler.execute("SELECT * FROM globals.orders", accessor)
+
+        assert result["rows"] == [["1", "1", "widget"], ["1", "2", "gadget"], ["2", "1", "gizmo"]]
+        assert [c["name"] for c in result["columns"]] == ["order_id", "line", "value"]
+        assert result["command_tag"] == "SELECT 3"
+
+    def test_leading_subscript_filter_descends_directly(self, handler, accessor):
+        result = handler.execute(
+            "SELECT line, value FROM globals.orders WHERE order_id = 2", accessor
+        )
+
+        assert result["rows"] == [["1", "gizmo"]]
+        assert () not in accessor.visited  # Top level never scanned
+
+    def test_value_filter_and_limit(self, handler, accessor):
+        result = handler.execute(
+            "SELECT order_id FROM globals.orders WHERE value = 'gadget' LIMIT 5", accessor
+        )
+
+        assert result["rows"] == [["1"]]
+
+    def test_limit_stops_walk(self, handler, accessor):
+        result = handler.execute("SELECT * FROM globals.orders LIMIT 1", accessor)
+
+        assert result["row_count"] == 1
+
+    def test_unknown_table_not_handled(self, handler, accessor):
+        assert handler.execute("SELECT * FROM globals.missing", accessor) is None
+
+    def test_writes_rejected(self, handler, accessor):
+        with pytest.raises(ReadOnlyGlobalTable) as exc_info:
+            handler.execute("DELETE FROM globals.orders WHERE order_id = 1", accessor)
+
+        assert exc_info.value.sqlstate == "25006"
+
+    def test_unsupported_predicate(self, handler, accessor):
+        with pytest.raises(ValueError):
+            handler.execute("SELECT * FROM globals.orders WHERE line > 1", accessor)
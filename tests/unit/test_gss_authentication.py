@@ -0,0 +1,167 @@
+"""
+Unit Tests: GSSAPI Authentication
+
+The AuthenticationGSS / GSSResponse token exchange, the principal checks
+(realm, include_realm, user name maps), the gss method in
+PGWIRE_AUTH_METHODS and pg_hba.conf, and the IRIS identity of the session.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.auth import gssapi_auth
+from iris_pgwire.auth.gssapi_auth import (
+    GSSAPIAuthenticator,
+    KerberosAuthenticationError,
+    KerberosConfig,
+)
+from iris_pgwire.auth_methods import parse_auth_methods
+from iris_pgwire.backend_auth import (
+    BackendCredentials,
+    current_backend_credentials,
+    set_backend_credentials,
+)
+from iris_pgwire.hba import HbaConfigError, HbaRules
+from iris_pgwire.ident_map import IdentMap
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, frontend_message, startup_message
+
+
+def _authenticator(**config) -> GSSAPIAuthenticator:
+    authenticator = GSSAPIAuthenticator.__new__(GSSAPIAuthenticator)
+    authenticator.config = KerberosConfig(**config)
+    return authenticator
+
+
+def test_gss_is_listed_alone():
+    assert parse_auth_methods("GSS") == ("gss",)
+    with pytest.raises(ValueError, match="cannot combine gss"):
+        parse_auth_methods("gss,scram-sha-256")
+
+
+def test_hba_gss_options():
+    rules = HbaRules.parse("host all all 10.0.0.0/8 gss include_realm=0 krb_realm=EXAMPLE.COM")
+    assert rules.rules[0].method == "gss"
+    assert rules.rules[0].options == {"include_realm": "0", "krb_realm": "EXAMPLE.COM"}
+
+    with pytest.raises(HbaConfigError, match="only valid for gss"):
+        HbaRules.parse("host all all 10.0.0.0/8 md5 include_realm=0")
+    with pytest.raises(HbaConfigError, match="must be 0 or 1"):
+        HbaRules.parse("host all all 10.0.0.0/8 gss include_realm=yes")
+
+
+def test_principal_must_allow_the_user(monkeypatch):
+    authenticator = _authenticator()
+
+    principal = authenticator.authorize_principal("alice@EXAMPLE.COM", "ALICE")
+    assert (principal.username, principal.realm, principal.mapped_iris_user) == (
+        "alice",
+        "EXAMPLE.COM",
+        "ALICE",
+    )
+    with pytest.raises(KerberosAuthenticationError, match='failed for user "bob"'):
+        authenticator.authorize_principal("alice@EXAMPLE.COM", "bob")
+    with pytest.raises(KerberosAuthenticationError):
+        authenticator.authorize_principal("alice@EXAMPLE.COM", "alice", include_realm=True)
+    authenticator.authorize_principal("alice@EXAMPLE.COM", "alice@example.com", include_realm=True)
+
+    with pytest.raises(KerberosAuthenticationError):
+        authenticator.authorize_principal("alice@OTHER.ORG", "alice", realm="EXAMPLE.COM")
+
+    ident = IdentMap.parse('krb /^(.*)@EXAMPLE\\.COM$ \\1\nkrb "svc@EXAMPLE.COM" reporting')
+    monkeypatch.setattr(gssapi_auth, "get_ident_map", lambda: ident)
+    mapped = _authenticator(include_realm=True, map_name="krb")
+    mapped.authorize_principal("svc@EXAMPLE.COM", "reporting")
+    mapped.authorize_principal("carol@EXAMPLE.COM", "carol")
+    with pytest.raises(KerberosAuthenticationError):
+        mapped.authorize_principal("carol@EXAMPLE.COM", "CAROL")  # Maps are case-sensitive
+
+
+class FakeContext:
+    """Accepts two client tokens, answering the first."""
+
+    def __init__(self, principal="alice@EXAMPLE.COM", fail=False):
+        self.tokens = []
+        self.complete = False
+        self.initiator_name = principal
+        self.delegated_creds = None
+        self.fail = fail
+
+    def step(self, token):
+        if self.fail:
+            raise RuntimeError("Ticket expired")
+        self.tokens.append(token)
+        if len(self.tokens) == 1:
+            return b"server-token"
+        self.complete = True
+        return None
+
+
+def _handshake(context, user="alice", backend_auth_mode="service", **config):
+    """(protocol, IRIS credentials of the session, or the handshake's error)"""
+
+    async def run():
+        reader = asyncio.StreamReader()
+        reader.feed_data(
+            startup_message(user=user)
+            + frontend_message(b"p", b"token-1")
+            + frontend_message(b"p", b"token-2")
+        )
+        executor = MagicMock()
+        executor.backend_auth_mode = backend_auth_mode
+        executor.iris_config = {"username": "_SYSTEM"}
+        executor.get_role_settings = AsyncMock(return_value={})
+        protocol = PGWireProtocol(reader, FakeWriter(), executor, "gss")
+        protocol.auth_methods = ("gss",)
+        authenticator = _authenticator(**config)
+        authenticator.accept_context = AsyncMock(return_value=context)
+        protocol.gssapi_authenticator = authenticator
+        set_backend_credentials(None)
+        try:
+            await protocol.handle_startup_sequence()
+            return protocol, current_backend_credentials()
+        except ConnectionAbortedError as e:
+            return protocol, e
+        finally:
+            get_stats().session_ended(protocol.connection_id)
+
+    return asyncio.run(run())
+
+
+def test_token_exchange():
+    context = FakeContext()
+
+    protocol, credentials = _handshake(context)
+
+    buffer = protocol.writer.buffer
+    assert buffer.startswith(struct.pack("!cII", b"R", 8, 7))
+    continue_message = struct.pack("!cII", b"R", 8 + 12, 8) + b"server-token"
+    assert buffer[9 : 9 + len(continue_message)] == continue_message
+    assert buffer[9 + len(continue_message) :].startswith(struct.pack("!cII", b"R", 8, 0))
+    assert context.tokens == [b"token-1", b"token-2"]
+    assert credentials is None  # Service account
+
+
+def test_backend_identity():
+    _, credentials = _handshake(FakeContext(), backend_auth_mode="passthrough")
+    assert credentials == BackendCredentials("alice")
+
+    forwarded = FakeContext()
+    forwarded.delegated_creds = object()
+    _, credentials = _handshake(forwarded, delegate_credentials=True)
+    assert credentials == BackendCredentials("alice") and credentials.delegated
+
+
+@pytest.mark.parametrize(
+    "context, user", [(FakeContext(fail=True), "alice"), (FakeContext(), "mallory")]
+)
+def test_refused_with_fatal_error(context, user):
+    protocol, error = _handshake(context, user=user)
+
+    assert isinstance(error, ConnectionAbortedError)
+    assert b"SFATAL" in protocol.writer.buffer and b"C28000" in protocol.writer.buffer
+    assert b"\x00\x00\x00\x08\x00\x00\x00\x00" not in protocol.writer.buffer  # No AuthenticationOk
@@ -0,0 +1,213 @@
+"""
+Unit Tests: Host-Based Access Control
+
+pg_hba.conf parsing, first-match evaluation by connection type, database,
+user and address, PostgreSQL's refusal messages, reloading, and the
+authentication method the protocol takes from the matching line.
+"""
+
+import asyncio
+import os
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire import hba
+from iris_pgwire.cert_auth import CertificateAuthenticationFailed
+from iris_pgwire.hba import HbaConfigError, HbaRules, HostAccessDenied, get_hba, load_hba
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, startup_message
+
+RULES = """
+# TYPE    DATABASE          USER       ADDRESS                  METHOD
+hostssl   all               all        10.0.0.0/8               scram-sha-256
+host      USER,"all"        pooler     192.168.1.5/32           md5
+host      sameuser          all        192.168.2.0 255.255.255.0  password
+hostnossl all               /^svc_     ::1/128                  trust
+host      replication       repl       samehost                 scram-sha-256
+host      all               mallory    all                      reject
+hostssl   all               all        0.0.0.0/0                cert  map=certmap clientname=DN
+"""
+
+
+def _rules() -> HbaRules:
+    return HbaRules.parse(RULES)
+
+
+def test_first_matching_line_decides():
+    rules = _rules()
+
+    assert rules.match("10.1.2.3", True, "USER", "alice").line == 3
+    assert rules.match("192.168.1.5", False, "all", "pooler").method == "md5"  # Quoted name
+    assert rules.match("192.168.2.77", False, "bob", "bob").method == "password"
+    assert rules.match("::1", False, "USER", "svc_billing").method == "trust"
+    assert rules.match("::ffff:10.0.0.1", True, "USER", "alice").line == 3  # IPv4-mapped
+    assert rules.match("127.0.0.1", False, "x", "repl", replication=True).line == 7
+    assert rules.match("172.16.0.1", True, "USER", "carol").method == "cert"
+    assert rules.match("172.16.0.1", True, "USER", "carol").options == {
+        "map": "certmap",
+        "clientname": "DN",
+    }
+
+
+def test_refusals_use_postgres_messages():
+    rules = _rules()
+
+    with pytest.raises(HostAccessDenied) as error:
+        rules.match("10.1.2.3", False, "USER", "alice")  # hostssl only
+    assert error.value.sqlstate == "28000"
+    assert str(error.value) == (
+        'no pg_hba.conf entry for host "10.1.2.3", user "alice", database "USER", no encryption'
+    )
+    with pytest.raises(HostAccessDenied, match='rejects connection for host "8.8.8.8"'):
+        rules.match("8.8.8.8", False, "USER", "mallory")
+    with pytest.raises(HostAccessDenied, match="replication connection from host"):
+        rules.match("192.168.1.5", False, "USER", "pooler", replication=True)
+    with pytest.raises(HostAccessDenied):
+        rules.match("192.168.2.77", False, "USER", "bob")  # sameuser
+
+
+def test_samehost_matches_the_local_address():
+    rules = HbaRules.parse("host all all samehost trust")
+
+    assert rules.match("10.0.0.9", False, "d", "u", local_address="10.0.0.9").method == "trust"
+    with pytest.raises(HostAccessDenied):
+        rules.match("10.0.0.8", False, "d", "u", local_address="10.0.0.9")
+
+
+@pytest.mark.parametrize(
+    "line, message",
+    [
+        ("host all all db.example.com md5", "host names are not supported"),
+        ("host all +admins 10.0.0.0/8 md5", "references are not supported"),
+        ("host all all 10.0.0.0/8 ident", "invalid authentication method"),
+        ("host all all 10.0.0.0/8 md5 clientcert=verify-full", "only be used on hostssl"),
+        ("host all all 10.0.0.0/8 cert", "only supported on hostssl"),
+        ("hostssl all all 10.0.0.0/33 md5", "invalid address"),
+        ("hostssl all all 10.0.0.0/8", "end-of-line before authentication method"),
+        ("hosts all all 10.0.0.0/8 md5", "invalid connection type"),
+        ("host all all samenet md5", "samenet is not supported"),
+    ],
+)
+def test_invalid_lines(line, message):
+    with pytest.raises(HbaConfigError, match=message):
+        HbaRules.parse(line)
+
+
+def test_reload_keeps_previous_rules_on_invalid_edit(tmp_path, monkeypatch):
+    path = tmp_path / "pg_hba.conf"
+    path.write_text("host all all 0.0.0.0/0 trust\n")
+    monkeypatch.setenv("PGWIRE_HBA_FILE", str(path))
+    monkeypatch.setattr(hba, "_cache", None)
+
+    assert load_hba().rules[0].method == "trust"
+
+    path.write_text("host all all 0.0.0.0/0 md5\n")
+    os.utime(path, (1, 1))
+    assert get_hba().rules[0].method == "md5"
+
+    path.write_text("host all all 0.0.0.0/0 kerberos\n")
+    os.utime(path, (2, 2))
+    assert get_hba().rules[0].method == "md5"
+
+
+def test_unloadable_file(tmp_path, monkeypatch):
+    path = tmp_path / "pg_hba.conf"
+    path.write_text("host all all everywhere trust\n")
+    monkeypatch.setenv("PGWIRE_HBA_FILE", str(path))
+    monkeypatch.setattr(hba, "_cache", None)
+
+    with pytest.raises(ValueError, match="invalid PGWIRE_HBA_FILE"):
+        load_hba()
+    assert get_hba().rules == []  # Refuses everything
+
+    monkeypatch.delenv("PGWIRE_HBA_FILE")
+    assert load_hba() is None and get_hba() is None
+
+
+def _protocol(client: str, ssl: bool = False, peercert=None):
+    executor = MagicMock()
+    executor.backend_auth_mode = "service"
+    executor.iris_config = {"username": "_SYSTEM"}
+    executor.md5_secret = AsyncMock(return_value=None)
+    executor.scram_verifier = AsyncMock(return_value=None)
+    writer = FakeWriter(peercert=peercert, sockname=("10.0.0.1", 5432))
+    protocol = PGWireProtocol(None, writer, executor, "hba")
+    protocol.client_address = (client, 40000)
+    protocol.ssl_enabled = ssl
+    return protocol
+
+
+@pytest.fixture
+def hba_file(tmp_path, monkeypatch):
+    path = tmp_path / "pg_hba.conf"
+    path.write_text(RULES)
+    monkeypatch.setenv("PGWIRE_HBA_FILE", str(path))
+    monkeypatch.setattr(hba, "_cache", None)
+    return path
+
+
+def test_startup_refused_without_matching_line(hba_file):
+    protocol = _protocol("8.8.4.4")
+
+    async def run():
+        protocol.reader = asyncio.StreamReader()
+        protocol.reader.feed_data(startup_message(user="alice", database="USER"))
+        with pytest.raises(ConnectionAbortedError):
+            await protocol.handle_startup_sequence()
+        get_stats().session_ended(protocol.connection_id)
+
+    asyncio.run(run())
+
+    assert protocol.writer.buffer[:1] == b"E"
+    assert b"SFATAL" in protocol.writer.buffer and b"C28000" in protocol.writer.buffer
+    assert b'no pg_hba.conf entry for host "8.8.4.4"' in protocol.writer.buffer
+
+
+def test_matching_line_sets_the_method(hba_file):
+    protocol = _protocol("192.168.1.5")
+    protocol.startup_params = {"user": "pooler", "database": "USER"}
+    protocol.apply_host_access_rules()
+    assert protocol.auth_methods == ("scram-sha-256", "md5")
+    assert not protocol.cert_auth.enabled
+
+    protocol = _protocol("172.16.0.1", ssl=True)
+    protocol.startup_params = {"user": "carol"}
+    protocol.apply_host_access_rules()
+    assert protocol.cert_auth.mode == "required"
+    assert (protocol.cert_auth.map_name, protocol.cert_auth.client_name) == ("certmap", "DN")
+
+
+def test_clientcert_option(tmp_path, monkeypatch):
+    path = tmp_path / "pg_hba.conf"
+    path.write_text("hostssl all all all password clientcert=verify-full\n")
+    monkeypatch.setenv("PGWIRE_HBA_FILE", str(path))
+    monkeypatch.setattr(hba, "_cache", None)
+    certificate = {"subject": ((("commonName", "alice"),),)}
+
+    protocol = _protocol("10.0.0.2", ssl=True, peercert=certificate)
+    protocol.startup_params = {"user": "alice"}
+    protocol.apply_host_access_rules()
+    assert protocol.auth_methods == ("password",)
+
+    protocol = _protocol("10.0.0.2", ssl=True, peercert=certificate)
+    protocol.startup_params = {"user": "bob"}
+    with pytest.raises(CertificateAuthenticationFailed):
+        protocol.apply_host_access_rules()
+
+    protocol = _protocol("10.0.0.2", ssl=True)
+    protocol.startup_params = {"user": "alice"}
+    with pytest.raises(CertificateAuthenticationFailed, match="requires a valid client"):
+        protocol.apply_host_access_rules()
+
+
+def test_no_file_leaves_methods_alone(monkeypatch):
+    monkeypatch.delenv("PGWIRE_HBA_FILE", raising=False)
+    protocol = _protocol("8.8.4.4")
+    protocol.auth_methods = ("md5",)
+    protocol.startup_params = {"user": "alice"}
+
+    protocol.apply_host_access_rules()
+
+    assert protocol.auth_methods == ("md5",)
@@ -0,0 +1,75 @@
+"""
+Unit Tests: iFind Functions
+
+ifind_match/ifind_rank/ifind_highlight translation and their pg_proc entries.
+"""
+
+import pytest
+
+from iris_pgwire.catalog.pg_proc import PgProcEmulator
+from iris_pgwire.sql_translator.ifind_translator import IFindTranslator
+
+
+@pytest.fixture
+def translator():
+    return IFindTranslator(index_map={"ARTICLES.BODY": "BodyIdx", "NOTES": "NotesIdx"})
+
+
+class TestIFindTranslation:
+    """ifind_* calls → iFind SQL."""
+
+    def test_match_with_configured_index(self, translator):
+        sql, count = translator.translate(
+            "SELECT * FROM ARTICLES A WHERE IFIND_MATCH(A.BODY, 'cat AND NOT dog')"
+        )
+
+        assert sql == "SELECT * FROM ARTICLES A WHERE A.%ID %FIND search_index(BodyIdx, 'cat AND NOT dog')"
+        assert count == 1
+
+    def test_match_with_explicit_index(self, translator):
+        sql, _ = translator.translate("SELECT * FROM T WHERE IFIND_MATCH(TXT, ?, 'TxtIdx')")
+
+        assert sql == "SELECT * FROM T WHERE %ID %FIND search_index(TxtIdx, ?)"
+
+    def test_rank_uses_generated_procedure(self, translator):
+        sql, _ = translator.translate("SELECT IFIND_RANK(BODY, 'cat') FROM ARTICLES")
+
+        assert sql == "SELECT SQLUser.ARTICLES_BodyIdxRank(%ID, 'cat') FROM ARTICLES"
+
+    def test_highlight_without_table_returns_column(self, translator):
+        sql, _ = translator.translate("SELECT IFIND_HIGHLIGHT(NOTES, 'cat') FROM T")
+
+        assert sql == "SELECT NOTES FROM T"
+
+    def test_unindexed_column_degrades_to_like(self, translator):
+        sql, _ = translator.translate("SELECT * FROM T WHERE IFIND_MATCH(TITLE, 'cat')")
+
+        assert sql == "SELECT * FROM T WHERE (LOWER(TITLE) LIKE '%' || LOWER('cat') || '%')"
+
+
+class TestPgProc:
+    """Registered functions are visible in pg_proc."""
+
+    def test_functions_registered_with_stable_oids(self):
+        first, second = PgProcEmulator(), PgProcEmulator()
+
+        names = sorted({proc.proname for proc in first.get_all() if proc.pronamespace == 2200})
+        assert names == ["ifind_highlight", "ifind_match", "ifind_rank"]
+        assert [p.oid for p in first.get_all()] == [p.oid for p in second.get_all()]
+        assert len(first.get_by_name("IFIND_MATCH")) == 2
+
+    def test_query_projects_and_filters(self):
+        columns, rows = PgProcEmulator().query(
+            "SELECT p.proname, p.pronargs AS nargs FROM pg_proc p WHERE p.proname = 'ifind_rank'"
+        )
+
+        assert columns == [
+            {"name": "proname", "type_oid": 19},
+            {"name": "nargs", "type_oid": 21},
+        ]
+        assert rows == [("ifind_rank", 2)]
+
+    def test_like_filter(self):
+        _, rows = PgProcEmulator().query("SELECT * FROM pg_proc WHERE proname LIKE 'ifind_m%'")
+
+        assert len(rows) == 2
@@ -0,0 +1,156 @@
+"""
+Unit Tests: CREATE INDEX Access Methods
+
+USING gin / gist / brin indexes are created as IRIS bitmap, iFind, columnar
+or standard indexes (PGWIRE_INDEX_METHODS), with a NOTICE naming the type;
+partial indexes cover every row.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.index_methods import (
+    index_method_notice,
+    load_index_methods,
+    partial_index_notice,
+    rewrite_create_index,
+)
+
+
+@pytest.fixture(autouse=True)
+def default_methods(monkeypatch):
+    monkeypatch.delenv("PGWIRE_INDEX_METHODS", raising=False)
+
+
+@pytest.mark.parametrize(
+    "sql,expected",
+    [
+        (
+            "CREATE INDEX items_tags ON items USING gin (tags gin_trgm_ops)",
+            "CREATE BITMAP INDEX items_tags ON items (tags)",
+        ),
+        (
+            "CREATE INDEX shapes_box ON shapes USING gist (box)",
+            "CREATE INDEX shapes_box ON shapes (box)",
+        ),
+        (
+            "CREATE INDEX events_at ON events USING BRIN (created_at) WITH (pages_per_range = 32)",
+            "CREATE COLUMNAR INDEX events_at ON events (created_at)",
+        ),
+        (
+            "CREATE INDEX docs_fts ON docs USING gin (to_tsvector('english', body))",
+            "CREATE INDEX docs_fts ON docs (body) AS %iFind.Index.Basic",
+        ),
+        (
+            "CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS users_email ON ONLY public.users "
+            'USING btree (email COLLATE "C" text_pattern_ops DESC) WHERE active = 1',
+            "CREATE INDEX IF NOT EXISTS users_email ON ONLY public.users (email DESC)",
+        ),
+        (
+            "CREATE INDEX orders_open ON orders (customer_id) INCLUDE (total) "
+            "WHERE status <> 'closed'",
+            "CREATE INDEX orders_open ON orders (customer_id) INCLUDE (total)",
+        ),
+        (
+            "create index on events using brin (created_at, kind)",
+            "CREATE COLUMNAR INDEX events_created_at_kind_idx on events (created_at, kind)",
+        ),
+    ],
+)
+def test_rewrite(sql, expected):
+    assert rewrite_create_index(sql) == expected
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "CREATE INDEX i ON t (a)",
+        "CREATE INDEX i ON t USING hnsw (embedding)",  # Not a PostgreSQL core method
+        "CREATE INDEX ON users ((lower(email)))",  # See expression_indexes.py
+        "CREATE TABLE t (a INT)",
+        "SELECT 'CREATE INDEX i ON t USING gin (a)'",
+    ],
+)
+def test_other_statements_unchanged(sql):
+    assert rewrite_create_index(sql) is None
+
+
+def test_configured_methods(monkeypatch):
+    monkeypatch.setenv("PGWIRE_INDEX_METHODS", "gin=ifind, brin=bitmap, gist=spatial, x=bitmap")
+
+    assert load_index_methods()["gin"] == "ifind"
+    assert load_index_methods()["gist"] == "standard"  # Invalid entries are ignored
+    assert rewrite_create_index("CREATE INDEX n ON notes USING gin (text)") == (
+        "CREATE INDEX n ON notes (text) AS %iFind.Index.Basic"
+    )
+    assert rewrite_create_index("CREATE INDEX n ON notes USING gin (a, b)") == (
+        "CREATE INDEX n ON notes (a, b)"  # iFind indexes one column
+    )
+    assert rewrite_create_index("CREATE INDEX e ON events USING brin (at)") == (
+        "CREATE BITMAP INDEX e ON events (at)"
+    )
+
+
+def test_notices():
+    assert index_method_notice("CREATE INDEX items_tags ON items USING gin (tags)") == (
+        "NOTICE",
+        "00000",
+        'gin index "items_tags" created as a bitmap IRIS index',
+        "PGWIRE_INDEX_METHODS maps gin to bitmap indexes",
+    )
+    notice = index_method_notice("CREATE INDEX ON docs USING gin (to_tsvector('simple', body))")
+    assert notice[2] == (
+        'gin index "docs_body_idx" created as an iFind (%iFind.Index.Basic) IRIS index'
+    )
+    assert notice[3] == (
+        "to_tsquery searches use it once PGWIRE_FTS_INDEXES lists DOCS.BODY=docs_body_idx"
+    )
+    assert index_method_notice("CREATE INDEX i ON t USING btree (a)") is None
+
+
+def test_partial_index_notices():
+    assert partial_index_notice("CREATE INDEX open ON orders (id) WHERE NOT closed") == (
+        "NOTICE",
+        "00000",
+        'partial index "open" created on every row',
+        "IRIS has no partial indexes; the WHERE predicate is not part of the index",
+    )
+    notice = partial_index_notice(
+        "CREATE UNIQUE INDEX ON users (email) WHERE deleted_at IS NULL"
+    )
+    assert notice[:3] == (
+        "WARNING",
+        "01000",
+        'unique partial index "users_email_idx" created as a non-unique index on every row',
+    )
+    assert partial_index_notice("CREATE INDEX i ON t (a)") is None
+    assert index_method_notice("CREATE INDEX ON t USING gin ((lower(name)))")[2] == (
+        'gin index "t_lower_idx" created as a bitmap IRIS index'
+    )
+
+
+def test_normalized_statement():
+    assert SQLTranslator().normalize_sql("CREATE INDEX on events USING brin (created_at)") == (
+        "CREATE COLUMNAR INDEX EVENTS_CREATED_AT_IDX ON EVENTS (CREATED_AT)"
+    )
+
+
+def test_notice_sent_before_command_complete():
+    writer = MagicMock()
+    executor = MagicMock()
+    executor.execute_query = AsyncMock(
+        return_value={"success": True, "rows": [], "columns": [], "command_tag": "CREATE INDEX"}
+    )
+    protocol = PGWireProtocol(MagicMock(), writer, executor, "index-methods")
+    protocol.writer.drain = AsyncMock()
+
+    asyncio.run(protocol.handle_query_message(b"CREATE INDEX t_a ON t USING gist (a)\x00"))
+
+    sent = b"".join(call.args[0] for call in writer.write.call_args_list)
+    assert b"SNOTICE\x00C00000\x00" in sent
+    assert b'gist index "t_a" created as a standard IRIS index' in sent
+    assert sent.index(b"SNOTICE") < sent.index(b"C\x00\x00\x00")
@@ -0,0 +1,75 @@
+"""
+Unit Tests: %List Column Decoding
+
+Detection of $LISTBUILD result columns and their rendering as text[] literals
+or JSON arrays.
+"""
+
+from decimal import Decimal
+
+from iris_pgwire.iris_list import (
+    decode_list_columns,
+    format_array_literal,
+    format_json_array,
+    load_list_format,
+)
+
+
+def _column(name, type_oid=25):
+    return {"name": name, "type_oid": type_oid, "type_size": -1, "type_modifier": -1, "format_code": 0}
+
+
+def _result():
+    return {
+        "success": True,
+        "rows": [(1, "\x03\x01a\x05\x01b c"), (2, None), (3, "\x01\x03\x04\x07")],
+        "columns": [_column("id", 23), _column("tags")],
+    }
+
+
+class TestFormatting:
+    def test_array_literal_quoting(self):
+        assert format_array_literal(["a", "b c", None, "", "x,y", 'q"', "null", 5]) == (
+            '{a,"b c",NULL,"","x,y","q\\"","null",5}'
+        )
+
+    def test_json_array(self):
+        assert format_json_array(["a", None, Decimal("1.5"), Decimal("20")]) == '["a", null, 1.5, 20]'
+
+    def test_list_format_setting(self):
+        assert load_list_format("JSON") == "json"
+        assert load_list_format("xml") == "array"
+
+
+class TestDecodeListColumns:
+    def test_array(self):
+        result = _result()
+
+        assert decode_list_columns(result, "array") == 1
+        assert [row[1] for row in result["rows"]] == ['{a,"b c"}', None, "{NULL,7}"]
+        assert result["columns"][1]["type_oid"] == 1009
+        assert result["columns"][0]["type_oid"] == 23
+
+    def test_json(self):
+        result = _result()
+
+        decode_list_columns(result, "json")
+
+        assert result["rows"][0][1] == '["a", "b c"]'
+        assert result["columns"][1]["type_oid"] == 114
+
+    def test_text_leaves_values(self):
+        result = _result()
+
+        assert decode_list_columns(result, "text") == 0
+        assert result["rows"][0][1] == "\x03\x01a\x05\x01b c"
+
+    def test_mixed_column_not_decoded(self):
+        result = {
+            "rows": [["\x03\x01a"], ["plain text"]],
+            "columns": [_column("notes")],
+        }
+
+        assert decode_list_columns(result, "array") == 0
+        assert result["rows"][0][0] == "\x03\x01a"
+        assert result["columns"][0]["type_oid"] == 25
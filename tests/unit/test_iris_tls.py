@@ -0,0 +1,51 @@
+"""
+Unit Tests: TLS to IRIS
+
+sslmode handling for the bridge → IRIS connection.
+"""
+
+import ssl
+
+import pytest
+
+from iris_pgwire.iris_tls import IrisTLSConfig
+
+
+class TestIrisTLSConfig:
+    def test_disabled_by_default(self, monkeypatch):
+        monkeypatch.delenv("PGWIRE_IRIS_SSLMODE", raising=False)
+
+        config = IrisTLSConfig.from_env()
+
+        assert not config.enabled
+        assert config.create_context() is None
+
+    def test_require_skips_verification(self):
+        context = IrisTLSConfig(mode="require").create_context()
+
+        assert context.verify_mode == ssl.CERT_NONE
+        assert not context.check_hostname
+
+    def test_verify_modes(self):
+        verify_ca = IrisTLSConfig(mode="verify-ca").create_context()
+        verify_full = IrisTLSConfig(mode="verify-full").create_context()
+
+        assert verify_ca.verify_mode == ssl.CERT_REQUIRED
+        assert not verify_ca.check_hostname
+        assert verify_full.verify_mode == ssl.CERT_REQUIRED
+        assert verify_full.check_hostname
+        assert verify_full.minimum_version == ssl.TLSVersion.TLSv1_2
+
+    def test_invalid_settings(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_IRIS_SSLMODE", "prefer")
+        with pytest.raises(ValueError, match="PGWIRE_IRIS_SSLMODE"):
+            IrisTLSConfig.from_env()
+
+        with pytest.raises(ValueError, match="PGWIRE_IRIS_SSLCERT"):
+            IrisTLSConfig(mode="require", key="/etc/pgwire/client.key")
+
+    def test_missing_root_cert_fails_loudly(self, tmp_path):
+        config = IrisTLSConfig(mode="verify-full", root_cert=str(tmp_path / "missing.pem"))
+
+        with pytest.raises(OSError):
+            config.create_context()
@@ -0,0 +1,117 @@
+"""
+Unit Tests: JSON and JSONB
+
+The ->, ->>, #>, #>> operators and json[b] functions are rewritten onto the
+IRIS SQL/JSON functions; JSON and JSONB columns are created as
+PGWIRE_JSON_COLUMN_TYPE and described as jsonb.
+"""
+
+import pytest
+
+from iris_pgwire.column_types import describe_column
+from iris_pgwire.json_values import json_column_type
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.ddl_translator import DDLTranslator
+from iris_pgwire.sql_translator.json_translator import JsonTranslator
+
+
+@pytest.fixture(autouse=True)
+def default_column_type(monkeypatch):
+    monkeypatch.delenv("PGWIRE_JSON_COLUMN_TYPE", raising=False)
+
+
+@pytest.mark.parametrize(
+    "sql,expected",
+    [
+        ("SELECT data -> 'a' FROM docs", "SELECT JSON_QUERY(data, '$.a') FROM docs"),
+        ("SELECT data->>'a' FROM docs", "SELECT JSON_VALUE(data, '$.a') FROM docs"),
+        ("SELECT data -> 2 FROM docs", "SELECT JSON_QUERY(data, '$[2]') FROM docs"),
+        ("SELECT data ->> -1 FROM docs", "SELECT JSON_VALUE(data, '$[last]') FROM docs"),
+        (
+            "SELECT data -> 'a' -> 0 ->> 'b c' FROM docs",
+            "SELECT JSON_VALUE(data, '$.a[0].\"b c\"') FROM docs",
+        ),
+        ("SELECT data #> '{a,0,b}' FROM docs", "SELECT JSON_QUERY(data, '$.a[0].b') FROM docs"),
+        ("SELECT data #>> '{a,b}' FROM docs", "SELECT JSON_VALUE(data, '$.a.b') FROM docs"),
+        (
+            "SELECT d.data::jsonb ->> 'k' FROM docs d",
+            "SELECT JSON_VALUE(d.data, '$.k') FROM docs d",
+        ),
+        (
+            "SELECT jsonb_extract_path_text(data, 'a', 'b') FROM docs",
+            "SELECT JSON_VALUE(data, '$.a.b') FROM docs",
+        ),
+        (
+            "SELECT json_extract_path(data, 'a') FROM docs",
+            "SELECT JSON_QUERY(data, '$.a') FROM docs",
+        ),
+        (
+            "SELECT jsonb_build_object('id', id, 'name', name) FROM users",
+            "SELECT JSON_OBJECT('id' : id, 'name' : name) FROM users",
+        ),
+        ("SELECT json_build_array(1, 'x', id) FROM t", "SELECT JSON_ARRAY(1, 'x', id) FROM t"),
+        ("SELECT jsonb_build_object()", "SELECT '{}'"),
+    ],
+)
+def test_translate(sql, expected):
+    assert JsonTranslator().translate(sql)[0] == expected
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT Employer->Name FROM Person",  # IRIS implicit join
+        "SELECT data ->> ? FROM docs",
+        "SELECT '->>' FROM docs",
+        "SELECT myschema.jsonb_build_object(1) FROM t",
+    ],
+)
+def test_unchanged(sql):
+    assert JsonTranslator().translate(sql) == (sql, 0)
+
+
+def test_normalizer_counts_operators():
+    translator = SQLTranslator()
+    sql = translator.normalize_sql("SELECT data->'a'->>'b' FROM docs WHERE data->>'k' = 'v'")
+
+    assert sql == "SELECT JSON_VALUE(DATA, '$.a.b') FROM DOCS WHERE JSON_VALUE(DATA, '$.k') = 'v'"
+    assert translator.get_normalization_metrics()["json_operator_count"] == 2
+
+
+@pytest.mark.parametrize(
+    "value,expected",
+    [
+        (None, "VARCHAR(3641144)"),
+        ("varchar(100000)", "VARCHAR(100000)"),
+        ("TEXT", "VARCHAR(3641144)"),
+        ("VARCHAR(0)", "VARCHAR(3641144)"),
+    ],
+)
+def test_column_type_setting(monkeypatch, value, expected):
+    if value is not None:
+        monkeypatch.setenv("PGWIRE_JSON_COLUMN_TYPE", value)
+
+    assert json_column_type() == expected
+
+
+def test_json_columns_created_as_configured_type():
+    translator = DDLTranslator()
+
+    assert translator.translate("CREATE TABLE docs (id INT, data JSONB, meta json)") == (
+        "CREATE TABLE docs (id INT, data VARCHAR(3641144), meta VARCHAR(3641144))",
+        2,
+    )
+    assert translator.translate("CREATE TABLE t (json TEXT, note TEXT DEFAULT 'jsonb')") == (
+        "CREATE TABLE t (json TEXT, note TEXT DEFAULT 'jsonb')",
+        0,
+    )
+
+
+def test_result_columns_described_as_jsonb(monkeypatch):
+    assert describe_column(12, 3641144, 0) == (3802, -1, -1)
+    assert describe_column("VARCHAR(3641144)") == (3802, -1, -1)
+    assert describe_column(12, 255, 0) == (1043, -1, 259)
+
+    monkeypatch.setenv("PGWIRE_JSON_COLUMN_TYPE", "VARCHAR(100000)")
+    assert describe_column(12, 100000, 0) == (3802, -1, -1)
+    assert describe_column(12, 3641144, 0) == (1043, -1, 3641148)
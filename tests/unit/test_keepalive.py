@@ -0,0 +1,107 @@
+"""
+Unit Tests: Idle Sessions and TCP Keepalives
+
+idle_session_timeout enforcement and keepalive socket options.
+"""
+
+import asyncio
+import socket
+from unittest.mock import MagicMock
+
+import pytest
+
+from iris_pgwire.keepalive import (
+    TCPKeepaliveConfig,
+    idle_timeout_seconds,
+    normalize_duration,
+    parse_duration_ms,
+)
+from iris_pgwire.protocol import STATUS_IDLE, STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.session_settings import InvalidParameterValue, SessionSettings
+from tests.protocol_messages import FakeWriter
+
+
+class TestDurations:
+    @pytest.mark.parametrize(
+        "value,milliseconds,canonical",
+        [
+            ("0", 0, "0"),
+            ("1500", 1500, "1500ms"),
+            ("30s", 30_000, "30s"),
+            ("600000", 600_000, "10min"),
+            (" 2 H", 7_200_000, "2h"),
+        ],
+    )
+    def test_units(self, value, milliseconds, canonical):
+        assert parse_duration_ms(value) == milliseconds
+        assert normalize_duration(value) == canonical
+
+    def test_setting_validated(self):
+        settings = SessionSettings()
+        settings.set("IDLE_SESSION_TIMEOUT", "5min")
+
+        assert settings.get("idle_session_timeout") == "5min"
+        assert idle_timeout_seconds(settings.get("idle_session_timeout")) == 300
+        assert idle_timeout_seconds("0") is None
+        with pytest.raises(InvalidParameterValue):
+            settings.set("idle_session_timeout", "soon")
+
+
+class TestIdleSessionTimeout:
+    @staticmethod
+    async def _idle_session(timeout, transaction_status=STATUS_IDLE, command=None):
+        reader = asyncio.StreamReader()
+        writer = FakeWriter()
+        protocol = PGWireProtocol(reader, writer, MagicMock(), "idle")
+        protocol.session_settings.set("idle_session_timeout", timeout)
+        protocol.transaction_status = transaction_status
+        await protocol.send_ready_for_query()
+        writer.buffer = b""
+
+        if command is not None:
+            asyncio.get_running_loop().call_later(0.1, reader.feed_data, command)
+        asyncio.get_running_loop().call_later(0.2, reader.feed_eof)
+        await protocol.message_loop()
+        return writer.buffer
+
+    def test_idle_session_terminated(self):
+        buffer = asyncio.run(self._idle_session("50ms"))
+
+        assert b"SFATAL\x00" in buffer
+        assert b"C57P05\x00" in buffer
+        assert b"idle-session timeout" in buffer
+
+    def test_not_applied_inside_transaction(self):
+        buffer = asyncio.run(self._idle_session("50ms", STATUS_IN_TRANSACTION))
+
+        assert buffer == b""
+
+    def test_disabled_by_default(self):
+        terminate = b"X\x00\x00\x00\x04"
+        buffer = asyncio.run(self._idle_session("0", command=terminate))
+
+        assert buffer == b""
+
+
+class TestTCPKeepalive:
+    def test_from_env(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_TCP_KEEPALIVES_IDLE", "60")
+        monkeypatch.setenv("PGWIRE_TCP_KEEPALIVES_COUNT", "many")
+
+        assert TCPKeepaliveConfig.from_env() == TCPKeepaliveConfig(idle=60, interval=0, count=0)
+
+    def test_socket_options(self):
+        with socket.socket(socket.AF_INET, socket.SOCK_STREAM) as sock:
+            TCPKeepaliveConfig(idle=60, interval=10, count=3).apply(sock)
+
+            assert sock.getsockopt(socket.SOL_SOCKET, socket.SO_KEEPALIVE)
+            if hasattr(socket, "TCP_KEEPIDLE"):
+                assert sock.getsockopt(socket.IPPROTO_TCP, socket.TCP_KEEPIDLE) == 60
+                assert sock.getsockopt(socket.IPPROTO_TCP, socket.TCP_KEEPCNT) == 3
+
+    def test_non_tcp_sockets_ignored(self):
+        TCPKeepaliveConfig(idle=60).apply(None)
+        with socket.socket(socket.AF_UNIX, socket.SOCK_STREAM) as sock:
+            TCPKeepaliveConfig(idle=60).apply(sock)
+
+            assert not sock.getsockopt(socket.SOL_SOCKET, socket.SO_KEEPALIVE)
@@ -0,0 +1,94 @@
+"""
+Unit Tests: Keyset Pagination
+
+Deep LIMIT/OFFSET pages of tables with a configured key rewritten into seeks
+to the page's first key, and statements the rewrite must leave alone.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.keyset_pagination import (
+    KeysetPaginationTranslator,
+    load_keyset_keys,
+)
+
+
+@pytest.fixture
+def translator():
+    return KeysetPaginationTranslator(load_keyset_keys("orders.id,SQLUser.events.event_id"))
+
+
+def test_load_keyset_keys():
+    assert load_keyset_keys('orders.id, SQLUser."Events".event_id,bad') == {
+        (None, "ORDERS"): "ID",
+        ("SQLUSER", "Events"): "EVENT_ID",
+    }
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        (
+            "SELECT * FROM orders ORDER BY id LIMIT 50 OFFSET 5000",
+            "SELECT * FROM orders WHERE id >= (SELECT CASE WHEN COUNT(*) > 5000 "
+            "THEN MAX(KEYSET_KEY) END FROM (SELECT TOP 5001 id AS KEYSET_KEY FROM orders "
+            "ORDER BY id) AS KEYSET_PAGE) ORDER BY id LIMIT 50",
+        ),
+        (
+            "SELECT o.a FROM orders o WHERE o.x = 1 OR o.y = 2 ORDER BY o.id DESC "
+            "OFFSET 2000 LIMIT 10",
+            "SELECT o.a FROM orders o WHERE (o.x = 1 OR o.y = 2) AND o.id <= (SELECT CASE "
+            "WHEN COUNT(*) > 2000 THEN MIN(KEYSET_KEY) END FROM (SELECT TOP 2001 o.id AS "
+            "KEYSET_KEY FROM orders o WHERE o.x = 1 OR o.y = 2 ORDER BY o.id DESC) AS "
+            "KEYSET_PAGE) ORDER BY o.id DESC LIMIT 10",
+        ),
+        (
+            "SELECT * FROM SQLUser.events ORDER BY event_id "
+            "OFFSET 1000 ROWS FETCH FIRST 10 ROWS ONLY",
+            "SELECT * FROM SQLUser.events WHERE event_id >= (SELECT CASE WHEN COUNT(*) > 1000 "
+            "THEN MAX(KEYSET_KEY) END FROM (SELECT TOP 1001 event_id AS KEYSET_KEY FROM "
+            "SQLUser.events ORDER BY event_id) AS KEYSET_PAGE) ORDER BY event_id LIMIT 10",
+        ),
+    ],
+)
+def test_deep_pages_become_seeks(translator, sql, expected):
+    assert translator.translate(sql) == (expected, 1)
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT * FROM orders ORDER BY id LIMIT 50 OFFSET 50",  # Shallow page
+        "SELECT * FROM orders ORDER BY name LIMIT 50 OFFSET 5000",  # Not the key
+        "SELECT * FROM orders ORDER BY id, name LIMIT 50 OFFSET 5000",
+        "SELECT * FROM orders ORDER BY id LIMIT ? OFFSET ?",  # Parameters
+        "SELECT * FROM customers ORDER BY id LIMIT 50 OFFSET 5000",  # No key configured
+        "SELECT * FROM other.events ORDER BY event_id LIMIT 50 OFFSET 5000",
+        "SELECT * FROM orders o JOIN lines l ON l.oid = o.id ORDER BY o.id LIMIT 5 OFFSET 5000",
+        "SELECT DISTINCT id FROM orders ORDER BY id LIMIT 50 OFFSET 5000",
+        "SELECT status, COUNT(*) FROM orders GROUP BY status ORDER BY id OFFSET 5000",
+    ],
+)
+def test_other_statements_are_left_alone(translator, sql):
+    assert translator.translate(sql) == (sql, 0)
+
+
+def test_min_offset_is_configurable(translator, monkeypatch):
+    monkeypatch.setenv("PGWIRE_KEYSET_MIN_OFFSET", "100")
+
+    assert translator.translate("SELECT * FROM orders ORDER BY id LIMIT 50 OFFSET 100")[1] == 1
+
+
+def test_normalizer_rewrites_configured_keys_once(monkeypatch):
+    monkeypatch.setenv("PGWIRE_KEYSET_KEYS", "orders.id")
+    sql_translator = SQLTranslator()
+
+    normalized = sql_translator.normalize_sql(
+        "SELECT * FROM orders ORDER BY id LIMIT 50 OFFSET 5000"
+    )
+
+    assert "ID >= (SELECT CASE WHEN COUNT(*) > 5000" in normalized
+    assert "IS NULL" not in normalized  # Key columns take no NULL-rank key
+    assert sql_translator.get_normalization_metrics()["keyset_page_count"] == 1
+    assert sql_translator.normalize_sql(normalized) == normalized
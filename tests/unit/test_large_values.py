@@ -0,0 +1,263 @@
+"""
+Unit Tests: Large Parameter Values
+
+Bind messages above PGWIRE_LARGE_VALUE_BYTES read field by field, values
+spooled to temporary files and decoded per format and type, binding as IRIS
+streams, and DataRows carrying large values.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire import large_values, temp_spool
+from iris_pgwire.large_values import (
+    LargeValue,
+    has_large_values,
+    iris_stream_params,
+    load_large_value_bytes,
+    materialized_params,
+    read_bind_message,
+)
+from iris_pgwire.message_framing import InvalidTextEncoding
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.temp_spool import SpoolConfig, TempFileLimitExceeded
+from tests.protocol_messages import FakeWriter, backend_messages, frontend_message
+
+THRESHOLD = 1024
+DATA = bytes(range(256)) * 40  # 10 KB
+
+
+def _bind(values, formats=(), statement=b"s") -> bytes:
+    body = b"\x00" + statement + b"\x00"
+    body += struct.pack("!H", len(formats)) + b"".join(struct.pack("!H", f) for f in formats)
+    body += struct.pack("!H", len(values))
+    for value in values:
+        if value is None:
+            body += struct.pack("!i", -1)
+        else:
+            body += struct.pack("!I", len(value)) + value
+    return body + struct.pack("!H", 0)
+
+
+def _parse(sql: bytes, types=(), statement=b"s") -> bytes:
+    body = statement + b"\x00" + sql + b"\x00" + struct.pack("!H", len(types))
+    return body + b"".join(struct.pack("!I", oid) for oid in types)
+
+
+async def _read(body: bytes, config=None, extra: bytes = b""):
+    reader = asyncio.StreamReader()
+    reader.feed_data(body + extra)
+    result = await read_bind_message(reader, len(body), THRESHOLD, config)
+    return result, reader
+
+
+def test_large_values_spooled_small_ones_kept(tmp_path):
+    config = SpoolConfig(directory=str(tmp_path))
+    body = _bind([b"42", DATA, None])
+
+    async def run():
+        (streamed, spooled), reader = await _read(body, config, extra=b"next")
+        assert await reader.readexactly(4) == b"next"  # Exactly the message was consumed
+        return streamed, spooled
+
+    streamed, spooled = asyncio.run(run())
+
+    assert list(spooled) == [1] and spooled[1].read() == DATA
+    assert streamed == _bind([b"42", None, None])
+
+
+def test_truncated_message_consumed_and_left_to_the_bind_handler(tmp_path):
+    body = _bind([DATA])[: len(DATA) // 2]
+
+    async def run():
+        (streamed, spooled), reader = await _read(body, SpoolConfig(directory=str(tmp_path)))
+        assert reader._buffer == b""
+        return streamed, spooled
+
+    streamed, spooled = asyncio.run(run())
+
+    assert spooled == {} and len(streamed) < len(body)
+
+
+def test_disk_limit(tmp_path):
+    config = SpoolConfig(directory=str(tmp_path), max_bytes=4096)
+    body = _bind([DATA])
+
+    async def run():
+        reader = asyncio.StreamReader()
+        reader.feed_data(body + b"next")
+        with pytest.raises(TempFileLimitExceeded):
+            await read_bind_message(reader, len(body), THRESHOLD, config)
+        assert await reader.readexactly(4) == b"next"
+
+    usage = temp_spool.disk_usage()
+    asyncio.run(run())
+    assert temp_spool.disk_usage() == usage
+
+
+def _spooled(data: bytes, tmp_path) -> LargeValue:
+    value = LargeValue(True, SpoolConfig(directory=str(tmp_path)))
+    value.write(data)
+    return value
+
+
+def test_decoding_by_format_and_type(tmp_path, monkeypatch):
+    # Hex digits and UTF-8 sequences split across chunks
+    monkeypatch.setattr(large_values, "CHUNK_BYTES", 7)
+
+    hex_text = b"\\x" + DATA.hex().encode()
+    assert _spooled(hex_text, tmp_path).decoded(0, 17).read() == DATA
+    assert _spooled(DATA, tmp_path).decoded(1, 17).read() == DATA
+    assert _spooled(b'\x01{"k": "\xc3\xa9"}', tmp_path).decoded(1, 3802).read() == '{"k": "é"}'
+    text = _spooled(("naïve " * 10).encode(), tmp_path).decoded(0, 25)
+    assert not text.binary and text.read() == "naïve " * 10
+
+    with pytest.raises(ValueError, match="hex or binary"):
+        _spooled(b"\\000abc", tmp_path).decoded(0, 17)
+    with pytest.raises(ValueError, match="odd number"):
+        _spooled(b"\\xabc", tmp_path).decoded(0, 17)
+    with pytest.raises(InvalidTextEncoding):
+        _spooled(b"abc\xff", tmp_path).decoded(0, 25).read()
+
+
+def test_bound_as_iris_streams_or_in_memory(tmp_path):
+    iris = MagicMock()
+    streams = {}
+
+    def stream_class(name):
+        stream = MagicMock()
+        streams[name] = stream
+        cls = MagicMock()
+        cls._New.return_value = stream
+        return cls
+
+    iris.cls.side_effect = stream_class
+    binary = _spooled(DATA, tmp_path)
+    text = _spooled(b"text value", tmp_path).decoded(0, 25)
+
+    bound = iris_stream_params([1, binary, text], iris)
+
+    assert bound[0] == 1
+    assert bound[1] is streams["%Stream.TempBinary"]
+    assert b"".join(c.args[0] for c in bound[1].Write.call_args_list) == DATA
+    bound[2].Write.assert_called_once_with("text value")
+    assert materialized_params([1, binary, text]) == [1, DATA, "text value"]
+
+
+def test_bytea_over_the_string_limit_bound_as_stream(monkeypatch):
+    monkeypatch.setattr(large_values, "IRIS_STRING_LIMIT", 1000)
+    monkeypatch.setattr(large_values, "CHUNK_BYTES", 4096)
+    iris = MagicMock()
+    stream = iris.cls.return_value._New.return_value
+
+    assert not has_large_values([b"x" * 1000, "y" * 2000])
+    assert has_large_values([1, DATA])
+    bound = iris_stream_params([b"small", DATA], iris)
+
+    assert bound == [b"small", stream]
+    iris.cls.assert_called_once_with("%Stream.TempBinary")
+    assert b"".join(c.args[0] for c in stream.Write.call_args_list) == DATA
+    assert stream.Write.call_count == 3
+
+
+def test_threshold_from_environment(monkeypatch):
+    monkeypatch.setenv("PGWIRE_LARGE_VALUE_BYTES", "64MB")
+    assert load_large_value_bytes() == 64 * 1024 * 1024
+    assert load_large_value_bytes("1kB") == large_values.DEFAULT_LARGE_VALUE_BYTES
+
+
+def _protocol(tmp_path, data: bytes):
+    reader = asyncio.StreamReader()
+    reader.feed_data(data)
+    reader.feed_eof()
+    executor = MagicMock()
+    executor.execute_query = AsyncMock(
+        return_value={"success": True, "rows": [], "columns": [], "row_count": 1}
+    )
+    protocol = PGWireProtocol(reader, FakeWriter(), executor, "large")
+    protocol.large_value_bytes = THRESHOLD
+    protocol.portal_cursors.spool_config = SpoolConfig(directory=str(tmp_path))
+    return protocol
+
+
+def test_bind_through_the_message_loop(tmp_path):
+    hex_text = b"\\x" + DATA.hex().encode()
+
+    async def run():
+        protocol = _protocol(
+            tmp_path,
+            frontend_message(b"P", _parse(b"INSERT INTO t VALUES ($1, $2)", (23, 17)))
+            + frontend_message(b"B", _bind([b"7", hex_text]))
+            + frontend_message(b"E", b"\x00" + struct.pack("!I", 0))
+            + frontend_message(b"S")
+            + frontend_message(b"X"),
+        )
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return protocol
+
+    protocol = asyncio.run(run())
+
+    messages = backend_messages(protocol.writer.buffer)
+    assert [m[0] for m in messages] == [b"1", b"2", b"C", b"Z"]
+    params = protocol.iris_executor.execute_query.await_args.kwargs.get("params")
+    assert params[0] == 7
+    assert isinstance(params[1], LargeValue) and params[1].read() == DATA
+
+
+def test_spool_limit_skips_to_sync(tmp_path):
+    async def run():
+        protocol = _protocol(
+            tmp_path,
+            frontend_message(b"P", _parse(b"SELECT $1", (17,)))
+            + frontend_message(b"B", _bind([DATA], (1,)))
+            + frontend_message(b"S")
+            + frontend_message(b"X"),
+        )
+        protocol.portal_cursors.spool_config = SpoolConfig(
+            directory=str(tmp_path), max_bytes=2048
+        )
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return protocol
+
+    protocol = asyncio.run(run())
+
+    messages = backend_messages(protocol.writer.buffer)
+    assert [m[0] for m in messages] == [b"1", b"E", b"Z"]
+    assert b"C53400" in messages[1][1]
+
+
+def test_large_data_row(tmp_path):
+    text = "x" * 100_000
+
+    async def run():
+        protocol = _protocol(tmp_path, b"")
+        await protocol.send_data_row([1, text], [{"type_oid": 23}, {"type_oid": 25}])
+        return protocol.writer.buffer
+
+    buffer = asyncio.run(run())
+
+    assert buffer[:1] == b"D"
+    assert struct.unpack("!I", buffer[1:5])[0] == len(buffer) - 1
+    assert buffer.endswith(struct.pack("!I", len(text)) + text.encode())
+
+
+def test_data_row_from_large_value(tmp_path):
+    text = "é" * 5000
+    value = LargeValue(binary=False, config=SpoolConfig(directory=str(tmp_path)))
+    value.write(text.encode())
+
+    async def run():
+        protocol = _protocol(tmp_path, b"")
+        columns = [{"type_oid": 23}, {"type_oid": 25}, {"type_oid": 25}]
+        await protocol.send_data_row([1, value, "z"], columns)
+        return protocol.writer.buffer
+
+    buffer = asyncio.run(run())
+
+    assert struct.unpack("!I", buffer[1:5])[0] == len(buffer) - 1
+    assert buffer.endswith(
+        struct.pack("!I", len(text.encode())) + text.encode() + struct.pack("!I", 1) + b"z"
+    )
@@ -0,0 +1,222 @@
+"""
+Unit Tests: LDAP Authentication
+
+Simple bind and search+bind against a fake directory, server failover, the
+option checks of pg_hba.conf lines and PGWIRE_LDAP_OPTIONS, and the
+cleartext password exchange of the ldap method.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+import iris_pgwire.ldap_auth as ldap_auth_module
+import iris_pgwire.protocol as protocol_module
+from iris_pgwire.auth_methods import parse_auth_methods
+from iris_pgwire.backend_auth import (
+    BackendCredentials,
+    current_backend_credentials,
+    set_backend_credentials,
+)
+from iris_pgwire.hba import HbaConfigError, HbaRules
+from iris_pgwire.ldap_auth import (
+    LdapAuthenticationFailed,
+    LdapConfig,
+    authenticate_ldap,
+    load_ldap_config,
+    search_filter,
+)
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, frontend_message, startup_message
+
+PEOPLE = "ou=people,dc=example,dc=com"
+
+
+class FakeDirectory:
+    """Entries by DN with their passwords; records binds and searches."""
+
+    def __init__(self, entries, down=()):
+        self.entries = entries
+        self.down = set(down)
+        self.binds = []
+        self.filters = []
+
+    def connect(self, config, host):
+        if host in self.down:
+            raise ConnectionError(f"{host}: connection refused")
+        return FakeConnection(self, host)
+
+
+class FakeConnection:
+    def __init__(self, directory, host):
+        self.directory = directory
+        self.host = host
+
+    def bind(self, dn, password):
+        self.directory.binds.append((self.host, dn))
+        return dn is None or self.directory.entries.get(dn) == password
+
+    def search(self, basedn, search_filter):
+        self.directory.filters.append(search_filter)
+        attribute, value = search_filter.strip("()").split("=", 1)
+        return [
+            dn
+            for dn in self.directory.entries
+            if dn.endswith(basedn) and dn.split(",")[0] == f"{attribute}={value}"
+        ]
+
+    def close(self):
+        pass
+
+
+DIRECTORY = {
+    f"uid=alice,{PEOPLE}": "secret",
+    "uid=bob,ou=people,dc=example,dc=com": "hunter2",
+    "uid=bob,ou=contractors,dc=example,dc=com": "other",
+    "cn=reader,dc=example,dc=com": "reader-pw",
+}
+
+
+def _config(**options) -> LdapConfig:
+    return LdapConfig.from_options({"ldapserver": "dc1 dc2", **options})
+
+
+def test_simple_bind():
+    directory = FakeDirectory(DIRECTORY)
+    config = _config(ldapprefix="uid=", ldapsuffix=f",{PEOPLE}")
+
+    assert authenticate_ldap(config, "alice", "secret", directory.connect) == f"uid=alice,{PEOPLE}"
+    with pytest.raises(LdapAuthenticationFailed, match='failed for user "alice"'):
+        authenticate_ldap(config, "alice", "wrong", directory.connect)
+    with pytest.raises(LdapAuthenticationFailed):
+        authenticate_ldap(config, "alice", "", directory.connect)  # No unauthenticated binds
+    assert directory.binds == [("dc1", f"uid=alice,{PEOPLE}")] * 2
+
+
+def test_search_and_bind():
+    directory = FakeDirectory(DIRECTORY)
+    config = _config(
+        ldapbasedn="dc=example,dc=com",
+        ldapbinddn="cn=reader,dc=example,dc=com",
+        ldapbindpasswd="reader-pw",
+    )
+
+    assert authenticate_ldap(config, "alice", "secret", directory.connect).startswith("uid=alice")
+    assert directory.binds[-2:] == [
+        ("dc1", "cn=reader,dc=example,dc=com"),
+        ("dc1", f"uid=alice,{PEOPLE}"),
+    ]
+    for user in ("bob", "carol", "al*"):  # Ambiguous, unknown, wildcard
+        with pytest.raises(LdapAuthenticationFailed):
+            authenticate_ldap(config, user, "hunter2", directory.connect)
+    assert directory.filters == ["(uid=alice)", "(uid=bob)", "(uid=carol)"]
+
+
+def test_search_filter_escapes_the_user():
+    user_filter = "(&(objectClass=user)(cn=$username))"
+    config = _config(ldapbasedn="dc=example,dc=com", ldapsearchfilter=user_filter)
+    assert search_filter(config, "a(b)\\") == "(&(objectClass=user)(cn=a\\28b\\29\\5c))"
+    ad = _config(ldapbasedn="dc=example,dc=com", ldapsearchattribute="sAMAccountName")
+    assert search_filter(ad, "alice") == "(sAMAccountName=alice)"
+
+
+def test_next_server_when_unreachable():
+    directory = FakeDirectory(DIRECTORY, down={"dc1"})
+    config = _config(ldapprefix="uid=", ldapsuffix=f",{PEOPLE}")
+
+    authenticate_ldap(config, "alice", "secret", directory.connect)
+    assert directory.binds == [("dc2", f"uid=alice,{PEOPLE}")]
+
+    directory.down.add("dc2")
+    with pytest.raises(LdapAuthenticationFailed):
+        authenticate_ldap(config, "alice", "secret", directory.connect)
+
+
+@pytest.mark.parametrize(
+    "options, message",
+    [
+        ({}, 'requires argument "ldapserver"'),
+        ({"ldapserver": "dc1"}, 'ldapbasedn", "ldapprefix", or "ldapsuffix"'),
+        ({"ldapserver": "dc1", "ldapprefix": "cn=", "ldapbasedn": "dc=x"}, "together with"),
+        ({"ldapserver": "dc1", "ldapbinddn": "cn=reader"}, 'requires argument "ldapbasedn"'),
+        ({"ldapserver": "dc1", "ldapsuffix": "@AD", "ldapport": "0"}, "invalid ldapport"),
+        ({"ldapserver": "dc1", "ldapsuffix": "@AD", "ldapscheme": "ldapi"}, "ldapscheme"),
+        ({"ldapserver": "dc1", "ldapsuffix": "@AD", "ldapurl": "x"}, "invalid LDAP option"),
+    ],
+)
+def test_invalid_options(options, message):
+    with pytest.raises(ValueError, match=message):
+        LdapConfig.from_options(options)
+
+
+def test_hba_and_environment_options(monkeypatch):
+    rules = HbaRules.parse(
+        'hostssl all /^ad_ 10.0.0.0/8 ldap ldapserver="dc1 dc2" '
+        'ldapbasedn="dc=example,dc=com" ldapsearchattribute=sAMAccountName'
+    )
+    assert rules.rules[0].options["ldapbasedn"] == "dc=example,dc=com"
+    with pytest.raises(HbaConfigError, match="line 1: .*ldapserver"):
+        HbaRules.parse("host all all 10.0.0.0/8 ldap ldapsuffix=@AD")
+    with pytest.raises(HbaConfigError, match="only valid for ldap"):
+        HbaRules.parse("host all all 10.0.0.0/8 md5 ldapserver=dc1")
+
+    monkeypatch.setattr(ldap_auth_module, "_cache", None)
+    monkeypatch.setenv("PGWIRE_LDAP_OPTIONS", 'ldapserver=dc1 ldapsuffix="@EXAMPLE.COM"')
+    assert load_ldap_config().suffix == "@EXAMPLE.COM"
+    monkeypatch.setenv("PGWIRE_LDAP_OPTIONS", "ldapserver=dc1 ldapsuffix")
+    with pytest.raises(ValueError, match="PGWIRE_LDAP_OPTIONS"):
+        load_ldap_config()
+    assert parse_auth_methods("scram-sha-256,LDAP") == ("scram-sha-256", "ldap")
+
+
+def _handshake(monkeypatch, password, backend_auth_mode="service"):
+    """(protocol, IRIS credentials of the session, or the handshake's error)"""
+    directory = FakeDirectory(DIRECTORY)
+
+    def authenticate(config, user, password):
+        return authenticate_ldap(config, user, password, directory.connect)
+
+    monkeypatch.setattr(protocol_module, "authenticate_ldap", authenticate)
+
+    async def run():
+        reader = asyncio.StreamReader()
+        reader.feed_data(startup_message(user="alice") + frontend_message(b"p", password + b"\x00"))
+        executor = MagicMock()
+        executor.backend_auth_mode = backend_auth_mode
+        executor.iris_config = {"username": "_SYSTEM"}
+        executor.get_role_settings = AsyncMock(return_value={})
+        protocol = PGWireProtocol(reader, FakeWriter(), executor, "ldap")
+        protocol.auth_methods = ("ldap",)
+        protocol.ldap_config = _config(ldapprefix="uid=", ldapsuffix=f",{PEOPLE}")
+        set_backend_credentials(None)
+        try:
+            await protocol.handle_startup_sequence()
+            return protocol, current_backend_credentials()
+        except ConnectionAbortedError as e:
+            return protocol, e
+        finally:
+            get_stats().session_ended(protocol.connection_id)
+
+    return asyncio.run(run())
+
+
+def test_cleartext_password_checked_by_the_directory(monkeypatch):
+    protocol, credentials = _handshake(monkeypatch, b"secret")
+    assert protocol.writer.buffer.startswith(struct.pack("!cII", b"R", 8, 3))
+    assert struct.pack("!cII", b"R", 8, 0) in protocol.writer.buffer
+    assert credentials is None  # Service account
+
+    protocol, credentials = _handshake(monkeypatch, b"secret", backend_auth_mode="passthrough")
+    assert credentials == BackendCredentials("alice")
+    protocol.iris_executor.verify_backend_credentials.assert_not_called()
+
+
+def test_refused_with_fatal_error(monkeypatch):
+    protocol, error = _handshake(monkeypatch, b"wrong")
+
+    assert isinstance(error, ConnectionAbortedError)
+    assert b"SFATAL" in protocol.writer.buffer and b"C28000" in protocol.writer.buffer
+    assert b'LDAP authentication failed for user "alice"' in protocol.writer.buffer
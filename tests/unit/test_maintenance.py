@@ -0,0 +1,147 @@
+"""
+Unit Tests: REINDEX and CLUSTER
+
+REINDEX runs as BUILD INDEX statements (index tables and schemas resolved
+from INFORMATION_SCHEMA, simulated in memory); CLUSTER is recognized and
+accepted without doing anything.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.maintenance import (
+    SYSTEM_REINDEX_NOTICE,
+    Cluster,
+    Reindex,
+    UndefinedIndex,
+    build_index_statements,
+    parse_cluster,
+    parse_reindex,
+)
+
+
+async def _lookup(sql):
+    if "INFORMATION_SCHEMA.INDEXES" in sql:
+        if "LOWER('orders_customer_idx')" in sql:
+            return [("SQLUser", "Orders", "orders_customer_idx")] * 2  # One row per column
+        return []
+    return [("SQLUser",), ("sales",)]
+
+
+def _statements(sql):
+    return asyncio.run(build_index_statements(parse_reindex(sql), "SQLUser", _lookup))
+
+
+class TestParsing:
+    @pytest.mark.parametrize(
+        "sql,reindex",
+        [
+            ("REINDEX TABLE orders", Reindex("table", "orders")),
+            (
+                "reindex (verbose) index concurrently public.orders_pkey;",
+                Reindex("index", "public.orders_pkey"),
+            ),
+            ('REINDEX SCHEMA "Sales"', Reindex("schema", '"Sales"')),
+            ("REINDEX DATABASE", Reindex("database", None)),
+            ("REINDEX SYSTEM app", Reindex("system", "app")),
+        ],
+    )
+    def test_reindex(self, sql, reindex):
+        assert parse_reindex(sql) == reindex
+
+    @pytest.mark.parametrize(
+        "sql,cluster",
+        [
+            ("CLUSTER", Cluster(None, "CLUSTER")),
+            ("CLUSTER VERBOSE orders USING orders_pkey", Cluster("orders", "CLUSTER")),
+            ("CLUSTER orders_pkey ON public.orders", Cluster("public.orders", "CLUSTER")),
+            ("ALTER TABLE ONLY orders CLUSTER ON orders_pkey;", Cluster("orders", "ALTER TABLE")),
+            ("ALTER TABLE orders SET WITHOUT CLUSTER", Cluster("orders", "ALTER TABLE")),
+        ],
+    )
+    def test_cluster(self, sql, cluster):
+        assert parse_cluster(sql) == cluster
+
+    @pytest.mark.parametrize(
+        "sql",
+        ["REINDEX TABLE", "SELECT * FROM cluster", "ALTER TABLE orders ADD COLUMN cluster INT"],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_reindex(sql) is None
+        assert parse_cluster(sql) is None
+
+
+class TestBuildIndex:
+    @pytest.mark.parametrize(
+        "sql,statements",
+        [
+            ("REINDEX TABLE public.orders", ["BUILD INDEX FOR TABLE SQLUser.orders"]),
+            (
+                "REINDEX INDEX orders_customer_idx",
+                ['BUILD INDEX FOR TABLE "SQLUser"."Orders" INDEX orders_customer_idx'],
+            ),
+            ("REINDEX SCHEMA public", ['BUILD INDEX FOR SCHEMA "SQLUser"']),
+            (
+                "REINDEX DATABASE app",
+                ['BUILD INDEX FOR SCHEMA "SQLUser"', "BUILD INDEX FOR SCHEMA sales"],
+            ),
+            ("REINDEX SYSTEM", []),
+        ],
+    )
+    def test_statements(self, sql, statements):
+        assert _statements(sql) == statements
+
+    def test_unknown_index(self):
+        with pytest.raises(UndefinedIndex, match='index "missing_idx" does not exist'):
+            _statements("REINDEX INDEX missing_idx")
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.executed = []
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_execute(sql, params=None, session_id=None):
+            if sql.startswith("SELECT"):
+                return {"success": True, "rows": await _lookup(sql), "columns": []}
+            executor.executed.append(sql)
+            return {"success": True, "rows": [], "columns": []}
+
+        executor._execute_query = fake_execute
+        return executor
+
+    def _run(self, executor, sql):
+        return asyncio.run(executor._execute_reindex(parse_reindex(sql)))
+
+    def test_reindex_runs_build_index(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        result = self._run(executor, "REINDEX TABLE orders")
+
+        assert executor.executed == ["BUILD INDEX FOR TABLE SQLUser.orders"]
+        assert result["command_tag"] == "REINDEX"
+
+    def test_unknown_index_fails_with_42704(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        result = self._run(executor, "REINDEX INDEX missing_idx")
+
+        assert result["success"] is False
+        assert result["sqlstate"] == "42704"
+        assert executor.executed == []
+
+    def test_reindex_system_notice(self, monkeypatch):
+        executor = self._executor(monkeypatch)
+
+        result = self._run(executor, "REINDEX SYSTEM")
+
+        assert result["success"] is True
+        assert result["notices"] == [SYSTEM_REINDEX_NOTICE]
+        assert executor.executed == []
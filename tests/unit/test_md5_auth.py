@@ -0,0 +1,184 @@
+"""
+Unit Tests: MD5 Password Authentication
+
+AuthenticationMD5Password against stored secrets, enrollment, and the
+password methods each listener offers.
+"""
+
+import asyncio
+import hashlib
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.auth_methods import Listener, load_auth_methods, parse_listeners
+from iris_pgwire.backend_auth import PasswordAuthenticationFailed
+from iris_pgwire.md5_auth import md5_response, md5_secret, verify_md5_response
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.scram import ScramVerifier
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, startup_message
+
+
+def _client_response(password: str, user: str, salt: bytes) -> bytes:
+    """What libpq sends for AuthenticationMD5Password."""
+    inner = hashlib.md5((password + user).encode()).hexdigest()
+    return b"md5" + hashlib.md5(inner.encode() + salt).hexdigest().encode() + b"\x00"
+
+
+def test_secret_in_pg_authid_form():
+    assert md5_secret("postgres", "postgres") == "md53175bce1d3201d16594cebf9d7eb3f9d"
+
+
+def test_response_verification():
+    secret = md5_secret("secret", "alice")
+    salt = b"\x01\x02\x03\x04"
+    response = _client_response("secret", "alice", salt).rstrip(b"\x00").decode()
+
+    assert md5_response(secret, salt) == response
+    assert verify_md5_response(secret, salt, response)
+    assert not verify_md5_response(secret, b"\x00\x00\x00\x00", response)
+    assert not verify_md5_response(None, salt, response)
+
+
+def test_listeners_and_methods(monkeypatch):
+    assert parse_listeners("10.0.0.5:6432=md5; [::1]:5433 = scram-sha-256, MD5") == [
+        Listener("10.0.0.5", 6432, ("md5",)),
+        Listener("::1", 5433, ("scram-sha-256", "md5")),
+    ]
+    with pytest.raises(ValueError, match="host:port=methods"):
+        parse_listeners("6432=md5")
+    with pytest.raises(ValueError, match="'kerberos'"):
+        parse_listeners("localhost:6432=kerberos")
+
+    monkeypatch.delenv("PGWIRE_AUTH_METHODS", raising=False)
+    assert load_auth_methods(enable_scram=True) == ("scram-sha-256",)
+    assert load_auth_methods() == ("trust",)
+    monkeypatch.setenv("PGWIRE_AUTH_METHODS", "scram-sha-256,md5")
+    assert load_auth_methods() == ("scram-sha-256", "md5")
+
+
+def _password_message(data: bytes) -> bytes:
+    return b"p" + struct.pack("!I", len(data) + 4) + data
+
+
+def _protocol(methods, md5=None, scram=None, ssl_enabled=False):
+    executor = MagicMock()
+    executor.backend_auth_mode = "service"
+    executor.iris_config = {"username": "_SYSTEM"}
+    executor.md5_secret = AsyncMock(return_value=md5)
+    executor.scram_verifier = AsyncMock(return_value=scram)
+    executor.verify_iris_password = AsyncMock()
+    executor.store_md5_secret = AsyncMock()
+    executor.store_scram_verifier = AsyncMock()
+    protocol = PGWireProtocol(None, FakeWriter(), executor, "md5")  # Reader set in the loop
+    protocol.auth_methods = methods
+    protocol.ssl_enabled = ssl_enabled
+    return protocol
+
+
+def _login(protocol, user: str, password: str) -> list[bytes]:
+    """Run the startup handshake, answering the MD5 salt; the messages received."""
+
+    async def run():
+        protocol.reader = asyncio.StreamReader()
+        protocol.reader.feed_data(startup_message(user=user))
+        task = asyncio.ensure_future(protocol.handle_startup_sequence())
+        while len(protocol.writer.buffer) < 13 and not task.done():
+            await asyncio.sleep(0)
+        salt = protocol.writer.buffer[9:13]
+        protocol.reader.feed_data(_password_message(_client_response(password, user, salt)))
+        try:
+            await task
+        except ConnectionAbortedError:
+            pass
+        finally:
+            get_stats().session_ended(protocol.connection_id)
+        messages, buffer = [], protocol.writer.buffer
+        while buffer:
+            length = struct.unpack("!I", buffer[1:5])[0]
+            messages.append(buffer[: 1 + length])
+            buffer = buffer[1 + length :]
+        return messages
+
+    return asyncio.run(run())
+
+
+def test_md5_login():
+    protocol = _protocol(("md5",), md5=md5_secret("secret", "alice"))
+
+    messages = _login(protocol, "alice", "secret")
+
+    assert messages[0][:9] == b"R\x00\x00\x00\x0c\x00\x00\x00\x05"
+    assert messages[1] == b"R\x00\x00\x00\x08\x00\x00\x00\x00"
+    assert messages[-1][:1] == b"Z"
+
+
+def test_wrong_password_fails_with_28p01():
+    protocol = _protocol(("md5",), md5=md5_secret("secret", "alice"))
+
+    messages = _login(protocol, "alice", "guess")
+
+    assert messages[-1][:1] == b"E"
+    assert b"C28P01" in messages[-1] and b'user "alice"' in messages[-1]
+
+
+def test_unknown_user_fails_like_a_wrong_password(monkeypatch):
+    monkeypatch.setenv("PGWIRE_SCRAM_ENROLL", "off")
+    protocol = _protocol(("md5",))
+
+    messages = _login(protocol, "mallory", "secret")
+
+    assert messages[0][:9] == b"R\x00\x00\x00\x0c\x00\x00\x00\x05"
+    assert b"C28P01" in messages[-1]
+
+
+def test_method_follows_stored_secrets():
+    async def choose(protocol):
+        return await protocol.choose_auth_method("alice")
+
+    both = ("scram-sha-256", "md5")
+    assert asyncio.run(choose(_protocol(both, md5=md5_secret("s", "alice")))) == "md5"
+    verifier = ScramVerifier.from_password("s")
+    assert asyncio.run(choose(_protocol(both, md5="md5" + "0" * 32, scram=verifier))) == (
+        "scram-sha-256"
+    )
+    assert asyncio.run(choose(_protocol(both))) == "scram-sha-256"  # Enrolls
+    assert asyncio.run(choose(_protocol(("md5", "password")))) == "password"
+
+
+def test_enrollment_stores_secrets_for_the_listener_methods(monkeypatch):
+    monkeypatch.delenv("PGWIRE_SCRAM_ENROLL", raising=False)
+    protocol = _protocol(("md5", "scram-sha-256"), ssl_enabled=True)
+
+    async def run():
+        protocol.startup_params = {"user": "alice"}
+        protocol.reader = asyncio.StreamReader()
+        protocol.reader.feed_data(_password_message(b"secret\x00"))
+        await protocol.authenticate_password_method()
+
+    asyncio.run(run())
+
+    executor = protocol.iris_executor
+    executor.verify_iris_password.assert_awaited_once_with("alice", "secret")
+    executor.store_md5_secret.assert_awaited_once_with("alice", md5_secret("secret", "alice"))
+    user, verifier = executor.store_scram_verifier.await_args.args
+    assert verifier == ScramVerifier.from_password("secret", verifier.salt)
+
+
+def test_cleartext_password_method_checks_iris():
+    protocol = _protocol(("password",))
+    protocol.iris_executor.verify_iris_password = AsyncMock(
+        side_effect=PasswordAuthenticationFailed("alice")
+    )
+
+    async def run():
+        protocol.startup_params = {"user": "alice"}
+        protocol.reader = asyncio.StreamReader()
+        protocol.reader.feed_data(_password_message(b"guess\x00"))
+        await protocol.authenticate_password_method()
+
+    with pytest.raises(PasswordAuthenticationFailed):
+        asyncio.run(run())
+    assert protocol.writer.buffer == b"R\x00\x00\x00\x08\x00\x00\x00\x03"
@@ -0,0 +1,141 @@
+"""
+Unit Tests: Message Framing and Malformed-Message Hardening
+
+Length-word validation, strict UTF-8 decoding, the error responses the
+message loop sends for malformed frames, and a bounded random-mutation run
+over the decoder (tests/fuzz/fuzz_message_decoder.py is the open-ended
+version).
+"""
+
+import asyncio
+import random
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.message_framing import (
+    DEFAULT_MAX_BIND_MESSAGE_SIZE,
+    DEFAULT_MAX_MESSAGE_SIZE,
+    InvalidTextEncoding,
+    ProtocolViolation,
+    check_startup_length,
+    decode_text,
+    load_max_bind_message_size,
+    load_max_message_size,
+    parse_message_header,
+)
+from iris_pgwire.protocol import PGWireProtocol
+from tests.protocol_messages import FakeWriter, backend_messages, frontend_message
+
+
+def _sqlstates(messages) -> list[str]:
+    states = []
+    for message_type, body in messages:
+        if message_type == b"E":
+            fields = dict((f[:1], f[1:]) for f in body.split(b"\x00") if f)
+            states.append(fields[b"C"].decode())
+    return states
+
+
+async def _run(data: bytes, max_message_size: int = DEFAULT_MAX_MESSAGE_SIZE):
+    reader = asyncio.StreamReader()
+    reader.feed_data(data)
+    reader.feed_eof()
+    writer = FakeWriter()
+    executor = MagicMock()
+    executor.execute_query = AsyncMock(
+        return_value={"success": True, "rows": [], "columns": [], "row_count": 0}
+    )
+    protocol = PGWireProtocol(reader, writer, executor, "fuzz")
+    protocol.max_message_size = max_message_size
+    await asyncio.wait_for(protocol.message_loop(), timeout=5)
+    return backend_messages(writer.buffer)
+
+
+class TestFraming:
+    def test_header_bounds(self):
+        assert parse_message_header(b"Q" + struct.pack("!I", 4), 1024) == (b"Q", 0)
+
+        with pytest.raises(ProtocolViolation):
+            parse_message_header(b"Q" + struct.pack("!I", 3), 1024)
+        with pytest.raises(ProtocolViolation, match="PGWIRE_MAX_MESSAGE_SIZE"):
+            parse_message_header(b"Q" + struct.pack("!I", 0xFFFFFFFF), 1024)
+
+    def test_only_bind_messages_exceed_max_message_size(self):
+        assert parse_message_header(b"B" + struct.pack("!I", 4096), 1024, 8192) == (b"B", 4092)
+
+        with pytest.raises(ProtocolViolation, match="PGWIRE_MAX_MESSAGE_SIZE"):
+            parse_message_header(b"Q" + struct.pack("!I", 4096), 1024, 8192)
+        with pytest.raises(ProtocolViolation, match="PGWIRE_MAX_BIND_MESSAGE_SIZE"):
+            parse_message_header(b"B" + struct.pack("!I", 16384), 1024, 8192)
+
+    def test_startup_length(self):
+        check_startup_length(8)
+        with pytest.raises(ProtocolViolation):
+            check_startup_length(4)
+        with pytest.raises(ProtocolViolation):
+            check_startup_length(1 << 30)
+
+    def test_decode_text(self):
+        assert decode_text("héllo".encode()) == "héllo"
+        with pytest.raises(InvalidTextEncoding, match="0xc3 0x28") as exc_info:
+            decode_text(b"SELECT '\xc3\x28'")
+        assert exc_info.value.sqlstate == "22021"
+
+    def test_max_size_from_environment(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_MAX_MESSAGE_SIZE", "1048576")
+        assert load_max_message_size() == 1048576
+
+        assert load_max_message_size("256MB") == 256 * 1024 * 1024
+        assert load_max_message_size("12") == DEFAULT_MAX_MESSAGE_SIZE
+        assert load_max_message_size("4GB") == DEFAULT_MAX_MESSAGE_SIZE
+        assert load_max_message_size("lots") == DEFAULT_MAX_MESSAGE_SIZE
+
+        assert DEFAULT_MAX_MESSAGE_SIZE == 64 * 1024 * 1024
+        assert load_max_bind_message_size() == DEFAULT_MAX_BIND_MESSAGE_SIZE == 1024**3
+        monkeypatch.setenv("PGWIRE_MAX_BIND_MESSAGE_SIZE", "256MB")
+        assert load_max_bind_message_size() == 256 * 1024 * 1024
+
+
+class TestMessageLoop:
+    def test_oversized_length_is_fatal_without_reading_body(self):
+        messages = asyncio.run(_run(b"Q" + struct.pack("!I", 0x7FFFFFFF), max_message_size=4096))
+
+        assert _sqlstates(messages) == ["08P01"]
+        assert b"SFATAL" in messages[0][1]
+
+    def test_invalid_utf8_query_keeps_session(self):
+        data = frontend_message(b"Q", b"SELECT '\xff'\x00") + frontend_message(b"X")
+
+        messages = asyncio.run(_run(data))
+
+        assert _sqlstates(messages) == ["22021"]
+        assert messages[-1] == (b"Z", b"I")
+
+    def test_truncated_bind_is_protocol_violation(self):
+        data = frontend_message(b"B", b"portal\x00") + frontend_message(b"X")
+
+        assert _sqlstates(asyncio.run(_run(data))) == ["08P01"]
+
+    def test_random_mutations_are_contained(self):
+        seeds = [
+            frontend_message(b"Q", b"SELECT 1\x00"),
+            frontend_message(b"P", b"s1\x00SELECT $1\x00" + struct.pack("!HI", 1, 23)),
+            frontend_message(b"B", b"\x00s1\x00" + struct.pack("!HHHI", 0, 0, 1, 1) + b"7"),
+            frontend_message(b"D", b"S\x00"),
+            frontend_message(b"E", b"\x00" + struct.pack("!I", 0)),
+            frontend_message(b"C", b"S\x00"),
+            frontend_message(b"S"),
+        ]
+        rng = random.Random(477)
+
+        for _ in range(200):
+            data = bytearray(b"".join(rng.sample(seeds, rng.randint(1, len(seeds)))))
+            for _ in range(rng.randint(1, 4)):
+                data[rng.randrange(len(data))] = rng.randrange(256)
+            if rng.random() < 0.3:
+                del data[rng.randrange(len(data)) :]
+
+            # Must terminate (no unbounded read) and never raise
+            asyncio.run(_run(bytes(data), max_message_size=4096))
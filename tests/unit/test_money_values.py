@@ -0,0 +1,191 @@
+"""
+Unit Tests: Money Columns and Parameters
+
+MONEY columns are created as PGWIRE_MONEY_COLUMN_TYPE with a marker and only
+marked columns are described as money; money parameters in cash_in's text
+forms are bound as decimal text.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.column_types import describe_column
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.money_values import (
+    InvalidMoney,
+    is_money_column,
+    money_column_type,
+    parse_money,
+)
+from iris_pgwire.numeric_range import NumericValueOutOfRange
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_translator.ddl_translator import DDLTranslator
+
+
+@pytest.mark.parametrize(
+    "text,expected",
+    [
+        ("$1,234.50", "1234.50"),
+        ("1234.5", "1234.50"),
+        ("-$3", "-3.00"),
+        ("$-3", "-3.00"),
+        ("($3.00)", "-3.00"),
+        (" +$0.125 ", "0.13"),  # Rounded half up, as cash_in does
+        (".5", "0.50"),
+        ("-0.001", "0.00"),
+    ],
+)
+def test_money_input_forms(text, expected):
+    assert parse_money(text) == expected
+
+
+def test_money_input_per_lc_monetary():
+    assert parse_money("1.234,56 €", "de_DE.UTF-8") == "1234.56"
+    assert parse_money("￥1,234", "ja_JP") == "1234"
+
+
+@pytest.mark.parametrize("text", ["abc", "", "$", "1.2.3", "$1 000"])
+def test_invalid_money_input(text):
+    with pytest.raises(InvalidMoney, match="invalid input syntax for type money"):
+        parse_money(text)
+
+
+def test_money_out_of_range():
+    with pytest.raises(NumericValueOutOfRange, match="out of range for type money"):
+        parse_money("92233720368547758.08")
+    assert parse_money("92233720368547758.07") == "92233720368547758.07"
+
+
+@pytest.mark.parametrize(
+    "value,expected",
+    [
+        (None, "NUMERIC(19,4)"),
+        ("decimal(18, 2)", "DECIMAL(18,2)"),
+        ("NUMERIC(19,0)", "NUMERIC(19,4)"),  # No cents
+        ("MONEY", "NUMERIC(19,4)"),
+    ],
+)
+def test_column_type_setting(monkeypatch, value, expected):
+    monkeypatch.delenv("PGWIRE_MONEY_COLUMN_TYPE", raising=False)
+    if value is not None:
+        monkeypatch.setenv("PGWIRE_MONEY_COLUMN_TYPE", value)
+
+    assert money_column_type() == expected
+
+
+def test_money_columns_created_as_configured_type(monkeypatch):
+    translator = DDLTranslator()
+    sql = "CREATE TABLE orders (total MONEY NOT NULL, \"money\" INT, note TEXT DEFAULT 'money')"
+
+    monkeypatch.delenv("PGWIRE_MONEY_COLUMN_TYPE", raising=False)
+    assert translator.translate(sql) == (
+        "CREATE TABLE orders (total NUMERIC(19,4) %DESCRIPTION 'pgwire:money' NOT NULL, "
+        "\"money\" INT, note TEXT DEFAULT 'money')",
+        1,
+    )
+    monkeypatch.setenv("PGWIRE_MONEY_COLUMN_TYPE", "DECIMAL(18,2)")
+    assert translator.translate("ALTER TABLE orders ADD COLUMN tax money") == (
+        "ALTER TABLE orders ADD COLUMN tax DECIMAL(18,2) %DESCRIPTION 'pgwire:money'",
+        1,
+    )
+    # A type change has no column definition to carry the marker
+    assert translator.translate("ALTER TABLE orders ALTER COLUMN tax TYPE money") == (
+        "ALTER TABLE orders ALTER COLUMN tax TYPE DECIMAL(18,2)",
+        1,
+    )
+
+
+def test_only_iris_money_types_described_as_money_by_type():
+    assert describe_column("MONEY") == (790, 8, -1)
+    assert describe_column("%Library.Currency") == (790, 8, -1)
+    # The money column type alone is not enough: other columns may share it
+    assert describe_column(2, 19, 4) == (1700, -1, (19 << 16 | 4) + 4)
+    assert describe_column("NUMERIC(19,4)") == (1700, -1, (19 << 16 | 4) + 4)
+
+
+def test_marker():
+    assert is_money_column("pgwire:money")
+    assert not is_money_column("Order total")
+    assert not is_money_column(None)
+
+
+def test_result_columns_of_marked_columns_described_as_money():
+    executor = IRISExecutor.__new__(IRISExecutor)
+
+    async def listing(sql, session_id=None):
+        return {"success": True, "rows": [("SQLUser", "ORDERS", "TOTAL")]}
+
+    executor._dictionary_listing = listing
+
+    def result():
+        numeric = {"type_oid": 1700, "type_size": -1, "type_modifier": (19 << 16 | 4) + 4}
+        return {
+            "success": True,
+            "columns": [{"name": "total", **numeric}, {"name": "tax", **numeric}],
+        }
+
+    described = asyncio.run(
+        executor._describe_money_columns("SELECT total, tax FROM orders", result())
+    )
+    elsewhere = asyncio.run(
+        executor._describe_money_columns("SELECT total, tax FROM invoices", result())
+    )
+
+    assert [column["type_oid"] for column in described["columns"]] == [790, 1700]
+    assert described["columns"][0]["type_modifier"] == -1
+    assert [column["type_oid"] for column in elsewhere["columns"]] == [1700, 1700]
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol():
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), MagicMock(), "money")
+        protocol.writer.drain = AsyncMock()
+        protocol.prepared_statements["s"] = {"query": "SELECT ?", "param_types": [790, 790]}
+        return protocol
+
+    @staticmethod
+    def _bind(values, formats) -> bytes:
+        body = b"\x00s\x00" + struct.pack("!H", len(formats))
+        body += b"".join(struct.pack("!H", code) for code in formats)
+        body += struct.pack("!H", len(values))
+        body += b"".join(struct.pack("!I", len(value)) + value for value in values)
+        return body + struct.pack("!H", 0)
+
+    def test_parameters_bound_as_decimal_text(self):
+        protocol = self._protocol()
+        body = self._bind([b"$1,234.50", struct.pack("!q", -350)], [0, 1])
+
+        asyncio.run(protocol.handle_bind_message(body))
+
+        assert protocol.portals[""]["params"] == ["1234.50", "-3.50"]
+
+    def test_text_parameters_read_per_lc_monetary(self):
+        protocol = self._protocol()
+        protocol.session_settings.set("lc_monetary", "de_DE")
+
+        asyncio.run(protocol.handle_bind_message(self._bind([b"1.234,50 \xe2\x82\xac"], [0])))
+
+        assert protocol.portals[""]["params"] == ["1234.50"]
+
+    @pytest.mark.parametrize("value", [b"ten", b"1e30"])
+    def test_invalid_parameter_fails_with_22p02(self, value):
+        protocol = self._protocol()
+        protocol.send_error_response = AsyncMock()
+
+        asyncio.run(protocol.handle_bind_message(self._bind([value], [0])))
+
+        assert "" not in protocol.portals
+        assert protocol.send_error_response.call_args.args[1] == "22P02"
+
+    def test_out_of_range_parameter_fails_with_22003(self):
+        protocol = self._protocol()
+        protocol.send_error_response = AsyncMock()
+
+        asyncio.run(protocol.handle_bind_message(self._bind([b"$100000000000000000"], [0])))
+
+        assert "" not in protocol.portals
+        assert protocol.send_error_response.call_args.args[1] == "22003"
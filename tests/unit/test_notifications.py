@@ -0,0 +1,340 @@
+"""
+Unit Tests: LISTEN / NOTIFY
+
+Command parsing, transactional delivery through the hub, the global queue
+shared by bridge instances and NotificationResponse messages in a session.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.notifications import (
+    GlobalQueueBridge,
+    Listen,
+    Notification,
+    NotificationHub,
+    Notify,
+    NotifyCall,
+    NotifyError,
+    QueueUsageCall,
+    SessionNotifications,
+    Unlisten,
+    parse_notification_command,
+    parse_notify_call,
+)
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, backend_messages, query_message, startup_message
+
+
+@pytest.mark.parametrize(
+    "sql, command",
+    [
+        ("LISTEN Cache_Invalidation", Listen("cache_invalidation")),
+        ('listen "Orders";', Listen("Orders")),
+        ("UNLISTEN orders", Unlisten("orders")),
+        ("UNLISTEN *", Unlisten(None)),
+        ("NOTIFY orders", Notify("orders")),
+        ("NOTIFY orders, 'it''s 42'", Notify("orders", "it's 42")),
+        ("SELECT 1", None),
+    ],
+)
+def test_parse(sql, command):
+    assert parse_notification_command(sql) == command
+
+
+def test_notify_outside_transaction_delivered_at_once():
+    hub = NotificationHub()
+    listener, sender = SessionNotifications(1, hub), SessionNotifications(2, hub)
+    listener.listen("orders")
+
+    sender.notify("orders", "42", in_transaction=False)
+    sender.notify("customers", "7", in_transaction=False)
+
+    assert listener.take() == [Notification("orders", "42", 2)]
+    assert listener.take() == []
+
+
+def test_notify_in_transaction_waits_for_commit():
+    hub = NotificationHub()
+    listener, sender = SessionNotifications(1, hub), SessionNotifications(2, hub)
+    listener.listen("orders")
+
+    for payload in ("42", "42", "43"):
+        sender.notify("orders", payload, in_transaction=True)
+    pending = listener.take()
+    sender.end_transaction(committed=True)
+
+    assert pending == []
+    assert [n.payload for n in listener.take()] == ["42", "43"]
+
+
+def test_rollback_drops_notifications():
+    hub = NotificationHub()
+    listener, sender = SessionNotifications(1, hub), SessionNotifications(2, hub)
+    listener.listen("orders")
+
+    sender.notify("orders", "42", in_transaction=True)
+    sender.end_transaction(committed=False)
+    sender.end_transaction(committed=True)
+
+    assert listener.take() == []
+
+
+def test_unlisten_and_close():
+    hub = NotificationHub()
+    session = SessionNotifications(1, hub)
+    for channel in ("a", "b", "c"):
+        session.listen(channel)
+
+    session.unlisten("b")
+    listening = hub.listening(1)
+    session.close()
+
+    assert listening == ["a", "c"]
+    assert hub.listening(1) == []
+
+
+class FakeGlobals:
+    """Global accessor over nested dicts, subscripts in sorted order."""
+
+    def __init__(self):
+        self.root = {}
+
+    def _node(self, subscripts):
+        node = self.root
+        for subscript in subscripts:
+            node = node.get(subscript, {})
+        return node
+
+    def next_subscript(self, global_name, subscripts, previous):
+        keys = sorted(self._node(subscripts))
+        later = [key for key in keys if previous == "" or key > previous]
+        return later[0] if later else None
+
+    def get(self, global_name, subscripts):
+        return self._node(subscripts[:-1])[subscripts[-1]]
+
+    def set(self, global_name, subscripts, value):
+        node = self.root
+        for subscript in subscripts[:-1]:
+            node = node.setdefault(subscript, {})
+        node[subscripts[-1]] = value
+
+    def kill(self, global_name, subscripts):
+        self._node(subscripts[:-1]).pop(subscripts[-1], None)
+
+
+def test_global_queue_between_instances():
+    globals_ = FakeGlobals()
+    first, second = GlobalQueueBridge(None, origin="a"), GlobalQueueBridge(None, origin="b")
+    now = 10**18
+
+    sent = first.sync_exchange(globals_, [Notification("orders", "42", 7)], now)
+    received = second.sync_exchange(globals_, [], now + 1000)
+    again = second.sync_exchange(globals_, [], now + 2000)
+
+    assert sent == []
+    assert received == [Notification("orders", "42", 7)]
+    assert again == []
+
+
+def test_global_queue_prunes_old_entries():
+    globals_ = FakeGlobals()
+    bridge = GlobalQueueBridge(None, origin="a")
+    bridge.sync_exchange(globals_, [Notification("orders", "42", 7)], 10**18)
+
+    bridge.sync_exchange(globals_, [], 10**18 + 120 * 10**9)
+
+    assert globals_.root == {}
+
+
+def test_bridge_exchange_publishes_without_forwarding():
+    hub = NotificationHub()
+    listener = SessionNotifications(1, hub)
+    listener.listen("orders")
+    entry = Notification("orders", "42", 7)
+
+    async def global_operation(operation):
+        return [entry]
+
+    bridge = GlobalQueueBridge(global_operation, origin="a")
+    hub.bridge = bridge
+    listener.notify("orders", "local", in_transaction=False)
+    asyncio.run(bridge.exchange(hub))
+
+    assert [n.payload for n in listener.take()] == ["local", "42"]
+    assert bridge.outbox == []
+
+
+@pytest.mark.parametrize(
+    "sql, call",
+    [
+        ("SELECT pg_notify('Orders', 'it''s')", NotifyCall("Orders", "it's")),
+        ("select pg_notify($1, $2);", NotifyCall(0, 1)),
+        ("SELECT pg_notify($2::text, $1::varchar(64))", NotifyCall(1, 0)),
+        ("SELECT pg_notify(?, 'a::b'::text) AS sent", NotifyCall(0, "a::b", "sent")),
+        ("SELECT pg_notify('orders', NULL)", NotifyCall("orders", None)),
+        ("SELECT pg_notification_queue_usage()", QueueUsageCall()),
+        ("SELECT pg_notify('orders', 'x'), 1", None),
+        ("SELECT * FROM pg_notify_log", None),
+    ],
+)
+def test_parse_notify_call(sql, call):
+    assert parse_notify_call(sql) == call
+
+
+@pytest.mark.parametrize(
+    "channel, payload, message",
+    [
+        ("", "x", "channel name cannot be empty"),
+        (None, "x", "channel name cannot be empty"),
+        ("c" * 64, "x", "channel name too long"),
+        ("orders", "\u00e9" * 4000, "payload string too long"),
+    ],
+)
+def test_notify_limits(channel, payload, message):
+    session = SessionNotifications(1, NotificationHub())
+
+    with pytest.raises(NotifyError, match=message) as error:
+        session.notify(channel, payload, in_transaction=False)
+
+    assert error.value.sqlstate == "22023"
+
+
+def test_full_queue_rejects_notify():
+    hub = NotificationHub(queue_size=2)
+    listener, sender = SessionNotifications(1, hub), SessionNotifications(2, hub)
+    listener.listen("orders")
+
+    sender.notify("orders", "1", in_transaction=False)
+    assert hub.queue_usage() == 0.5
+    sender.notify("orders", None, in_transaction=False)
+    with pytest.raises(NotifyError) as error:
+        sender.notify("orders", "3", in_transaction=False)
+
+    assert error.value.sqlstate == "54000"
+    assert hub.queue_usage() == 1.0
+    sender.notify("other", "ok", in_transaction=False)  # Nobody listening there
+    assert [n.payload for n in listener.take()] == ["1", ""]
+    assert hub.queue_usage() == 0.0
+
+
+async def _protocol(data: bytes) -> tuple[PGWireProtocol, FakeWriter, int]:
+    reader = asyncio.StreamReader()
+    reader.feed_data(startup_message(user="app", database="USER") + data)
+    reader.feed_eof()
+    writer = FakeWriter()
+    executor = MagicMock()
+    executor.begin_transaction = AsyncMock()
+    executor.commit_transaction = AsyncMock()
+    executor.rollback_transaction = AsyncMock()
+    protocol = PGWireProtocol(reader, writer, executor, "notifications")
+    await protocol.handle_ssl_probe(None)
+    await protocol.handle_startup_sequence()
+    return protocol, writer, len(writer.buffer)
+
+
+async def _session(*queries: str):
+    protocol, writer, handshake = await _protocol(
+        b"".join(query_message(sql) for sql in queries) + b"X\x00\x00\x00\x04"
+    )
+    try:
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+    finally:
+        protocol.notifications.close()
+        get_stats().session_ended(protocol.connection_id)
+    return protocol, backend_messages(writer.buffer[handshake:])
+
+
+def _notification(body: bytes) -> tuple[int, str, str]:
+    channel, payload, _ = body[4:].split(b"\x00")
+    return struct.unpack("!I", body[:4])[0], channel.decode(), payload.decode()
+
+
+def test_session_notified_after_commit():
+    protocol, messages = asyncio.run(
+        _session(
+            "LISTEN orders",
+            "BEGIN",
+            "NOTIFY orders, 'new'",
+            "NOTIFY orders, 'new'",
+            "COMMIT",
+            "NOTIFY other",
+        )
+    )
+
+    kinds = [kind for kind, _ in messages]
+    assert kinds == [b"C", b"Z", b"C", b"Z", b"C", b"Z", b"C", b"Z", b"C", b"A", b"Z", b"C", b"Z"]
+    assert _notification(messages[9][1]) == (protocol.backend_pid, "orders", "new")
+    tags = [body.rstrip(b"\x00") for kind, body in messages if kind == b"C"]
+    assert tags[:3] == [b"LISTEN", b"BEGIN", b"NOTIFY"]
+
+
+def test_idle_session_notified_at_once():
+    async def run():
+        protocol, writer, handshake = await _protocol(b"")
+        try:
+            protocol.notifications.listen("orders")
+            protocol.notifications.hub.publish([Notification("orders", "", 99)])
+            return backend_messages(writer.buffer[handshake:])
+        finally:
+            protocol.notifications.close()
+            get_stats().session_ended(protocol.connection_id)
+
+    messages = asyncio.run(run())
+
+    assert messages[0][0] == b"A" and _notification(messages[0][1]) == (99, "orders", "")
+
+
+def _row_values(body: bytes) -> list[bytes]:
+    values, pos = [], 2
+    for _ in range(struct.unpack("!H", body[:2])[0]):
+        length = struct.unpack("!i", body[pos : pos + 4])[0]
+        values.append(body[pos + 4 : pos + 4 + length])
+        pos += 4 + length
+    return values
+
+
+def test_pg_notify_function():
+    protocol, messages = asyncio.run(
+        _session(
+            "LISTEN Orders",
+            "SELECT pg_notify('orders', 'via function')",
+            f"SELECT pg_notify('orders', '{'x' * 8000}')",
+            "SELECT pg_notify('', 'x')",
+            "SELECT pg_notification_queue_usage()",
+        )
+    )
+
+    kinds = [kind for kind, _ in messages]
+    assert kinds[:7] == [b"C", b"Z", b"T", b"D", b"C", b"A", b"Z"]
+    assert b"pg_notify\x00" in messages[2][1]
+    assert _row_values(messages[3][1]) == [b""]
+    assert _notification(messages[5][1]) == (protocol.backend_pid, "orders", "via function")
+    errors = [body for kind, body in messages if kind == b"E"]
+    assert len(errors) == 2 and all(b"C22023" in error for error in errors)
+    assert kinds[-4:] == [b"T", b"D", b"C", b"Z"]
+
+
+def test_pg_notify_with_bound_parameters():
+    async def run():
+        protocol, _, _ = await _protocol(b"")
+        try:
+            protocol.notifications.listen("orders")
+            protocol.awaiting_command = False  # Mid-query: held until ReadyForQuery
+            result = await protocol._execute_statement(
+                "SELECT pg_notify($1, $2)", [b"orders", "bound"]
+            )
+            return result, protocol.notifications.take()
+        finally:
+            protocol.notifications.close()
+            get_stats().session_ended(protocol.connection_id)
+
+    result, notifications = asyncio.run(run())
+
+    assert result["success"] and result["columns"][0]["type_oid"] == 2278
+    assert [n.payload for n in notifications] == ["bound"]
@@ -0,0 +1,167 @@
+"""
+Unit Tests: NULL Ordering Translation
+
+ORDER BY items sorted with NULLs where PostgreSQL puts them (last ascending,
+first descending, or as NULLS FIRST / LAST says) through NULL-rank keys, with
+items left alone where IRIS cannot take a key.
+"""
+
+import asyncio
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.null_ordering_translator import (
+    NullOrderingTranslator,
+    declare_not_null,
+    order_table,
+)
+
+LAST = "CASE WHEN {} IS NULL THEN 1 ELSE 0 END"
+FIRST = "CASE WHEN {} IS NULL THEN 0 ELSE 1 END"
+
+
+@pytest.fixture
+def translator():
+    return NullOrderingTranslator()
+
+
+@pytest.mark.parametrize(
+    "sql, expected, count",
+    [
+        ("SELECT a FROM t ORDER BY a", f"SELECT a FROM t ORDER BY {LAST.format('a')}, a", 1),
+        (
+            "SELECT a FROM t ORDER BY a DESC LIMIT 10",
+            f"SELECT a FROM t ORDER BY {FIRST.format('a')}, a DESC LIMIT 10",
+            1,
+        ),
+        (
+            "SELECT a FROM t ORDER BY a ASC NULLS LAST, b DESC NULLS FIRST",
+            f"SELECT a FROM t ORDER BY {LAST.format('a')}, a ASC, {FIRST.format('b')}, b DESC",
+            2,
+        ),
+        (
+            "SELECT a, b + 1 AS next FROM t ORDER BY next, 1 DESC",
+            f"SELECT a, b + 1 AS next FROM t ORDER BY {LAST.format('(b + 1)')}, next, "
+            f"{FIRST.format('(a)')}, 1 DESC",
+            2,
+        ),
+        (
+            "SELECT rank() OVER (PARTITION BY g ORDER BY v) FROM t",
+            f"SELECT rank() OVER (PARTITION BY g ORDER BY {LAST.format('v')}, v) FROM t",
+            1,
+        ),
+        (
+            "SELECT * FROM (SELECT a FROM t ORDER BY a LIMIT 3) s",
+            f"SELECT * FROM (SELECT a FROM t ORDER BY {LAST.format('a')}, a LIMIT 3) s",
+            1,
+        ),
+    ],
+)
+def test_items_get_null_rank_keys(translator, sql, expected, count):
+    assert translator.translate(sql) == (expected, count)
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        ("SELECT DISTINCT a FROM t ORDER BY a NULLS FIRST", "SELECT DISTINCT a FROM t ORDER BY a"),
+        (
+            "SELECT a FROM t UNION SELECT b FROM u ORDER BY 1 DESC NULLS LAST",
+            "SELECT a FROM t UNION SELECT b FROM u ORDER BY 1 DESC",
+        ),
+        (
+            "SELECT a FROM t ORDER BY a ASC NULLS FIRST, b DESC NULLS LAST",
+            "SELECT a FROM t ORDER BY a ASC, b DESC",
+        ),
+        ("SELECT * FROM t ORDER BY 1", None),
+        ("SELECT id FROM t ORDER BY VECTOR_COSINE(e, TO_VECTOR(?)) DESC LIMIT 5", None),
+        ("SELECT id, e <=> ? AS distance FROM t ORDER BY distance LIMIT 5", None),
+        ("SELECT string_agg(x, ',' ORDER BY y) FROM t", None),
+        ("SELECT 'ORDER BY a' FROM t -- ORDER BY b", None),
+        ("SELECT a FROM t ORDER BY a USING <", None),
+    ],
+)
+def test_items_without_a_key(translator, sql, expected):
+    # IRIS's own order or no key possible: NULLS clauses are dropped; None: left as written
+    assert translator.translate(sql)[0] == (expected or sql)
+
+
+def test_translation_is_idempotent(translator):
+    sql = 'SELECT a, b AS "B" FROM t ORDER BY "B" DESC, 1, c NULLS LAST'
+    translated, _ = translator.translate(sql)
+
+    assert translator.translate(translated) == (translated, 0)
+
+
+def test_iris_mode_only_translates_explicit_clauses(translator, monkeypatch):
+    monkeypatch.setenv("PGWIRE_NULL_ORDERING", "iris")
+
+    assert translator.translate("SELECT a FROM t ORDER BY a DESC") == (
+        "SELECT a FROM t ORDER BY a DESC",
+        0,
+    )
+    assert translator.translate("SELECT a FROM t ORDER BY a NULLS FIRST, b NULLS LAST") == (
+        f"SELECT a FROM t ORDER BY a, {LAST.format('b')}, b",
+        2,
+    )
+
+
+def test_normalizer_counts_null_ordering():
+    sql_translator = SQLTranslator()
+
+    normalized = sql_translator.normalize_sql(
+        "SELECT name FROM users ORDER BY name DESC NULLS FIRST"
+    )
+
+    assert normalized == f"SELECT NAME FROM USERS ORDER BY {FIRST.format('NAME')}, NAME DESC"
+    assert sql_translator.get_normalization_metrics()["null_ordering_count"] == 1
+
+
+class TestNotNullColumns:
+    """NOT NULL columns of a single-table query keep IRIS's order without a key."""
+
+    def test_items_declared_in_iris_order(self, translator):
+        sql = declare_not_null(
+            "SELECT id, note AS n, rank() OVER (ORDER BY id DESC) FROM t "
+            "ORDER BY t.id, n, note DESC, id NULLS LAST",
+            {"ID", "NOTE"},
+        )
+
+        assert sql == (
+            "SELECT id, note AS n, rank() OVER (ORDER BY id DESC NULLS LAST) FROM t "
+            "ORDER BY t.id NULLS FIRST, n, note DESC NULLS LAST, id NULLS LAST"
+        )
+        # The alias n still ranks; the explicit NULLS LAST is PostgreSQL's order
+        assert translator.translate(sql)[0] == (
+            "SELECT id, note AS n, rank() OVER (ORDER BY id DESC) FROM t "
+            f"ORDER BY t.id, {LAST.format('(note)')}, n, note DESC, {LAST.format('id')}, id"
+        )
+
+    @pytest.mark.parametrize(
+        "sql, table",
+        [
+            ("SELECT a FROM s.t AS x WHERE a > 1 ORDER BY a", "s.t"),
+            ('SELECT a FROM "My T" ORDER BY a', '"My T"'),
+            ("SELECT a FROM t LEFT JOIN u ON t.k = u.k ORDER BY a", None),
+            ("SELECT a FROM t x, u ORDER BY a", None),
+            ("SELECT a FROM t WHERE k IN (SELECT k FROM u) ORDER BY a", None),
+            ("SELECT a FROM t", None),
+        ],
+    )
+    def test_single_table_queries(self, sql, table):
+        assert order_table(sql) == table
+
+    def test_executor_reads_not_null_columns(self):
+        executor = IRISExecutor.__new__(IRISExecutor)
+
+        async def listing(sql, session_id=None):
+            return {"success": True, "rows": [("SQLUser", "orders", "ID"), ("SQLUser", "x", "A")]}
+
+        executor._dictionary_listing = listing
+        sql = "SELECT id, a FROM orders ORDER BY id DESC, a"
+
+        declared = asyncio.run(executor._declare_not_null_order(sql))
+
+        assert declared == "SELECT id, a FROM orders ORDER BY id DESC NULLS LAST, a"
@@ -0,0 +1,85 @@
+"""
+Unit Tests: Strict Integer Range Checking
+
+Out-of-range int2/int4/int8 values must raise SQLSTATE 22003 instead of
+wrapping or being truncated by IRIS.
+"""
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.numeric_range import (
+    NumericValueOutOfRange,
+    check_integer_range,
+    is_integer_type,
+    parse_integer_text,
+)
+
+
+class TestCheckIntegerRange:
+    """Range validation for integer type OIDs."""
+
+    @pytest.mark.parametrize(
+        "type_oid,minimum,maximum",
+        [
+            (21, -32768, 32767),
+            (23, -2147483648, 2147483647),
+            (20, -9223372036854775808, 9223372036854775807),
+        ],
+    )
+    def test_boundaries_accepted(self, type_oid, minimum, maximum):
+        assert check_integer_range(minimum, type_oid) == minimum
+        assert check_integer_range(maximum, type_oid) == maximum
+
+    @pytest.mark.parametrize(
+        "type_oid,value,type_name",
+        [
+            (21, 32768, "smallint"),
+            (21, -32769, "smallint"),
+            (23, 2147483648, "integer"),
+            (20, 2**63, "bigint"),
+        ],
+    )
+    def test_overflow_raises_22003(self, type_oid, value, type_name):
+        with pytest.raises(NumericValueOutOfRange) as exc_info:
+            check_integer_range(value, type_oid)
+
+        assert exc_info.value.sqlstate == "22003"
+        assert str(exc_info.value) == f"{type_name} out of range"
+
+    def test_non_integer_oid_passes_through(self):
+        assert check_integer_range(2**70, 1700) == 2**70
+        assert not is_integer_type(1700)
+
+
+class TestParseIntegerText:
+    """Text-format parameter parsing."""
+
+    def test_valid_text(self):
+        assert parse_integer_text("42", 21) == 42
+        assert parse_integer_text(" -7 ", 23) == -7
+
+    def test_out_of_range_text_uses_postgres_message(self):
+        with pytest.raises(NumericValueOutOfRange) as exc_info:
+            parse_integer_text("99999", 21)
+
+        assert str(exc_info.value) == 'value "99999" is out of range for type smallint'
+
+    def test_non_integer_text_is_plain_value_error(self):
+        with pytest.raises(ValueError) as exc_info:
+            parse_integer_text("abc", 23)
+
+        assert not isinstance(exc_info.value, NumericValueOutOfRange)
+
+
+class TestInferredColumnTypes:
+    """Columns without IRIS metadata are typed from all their values, not the first row."""
+
+    def test_integers_beyond_int4_in_later_rows_make_int8(self):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        rows = [[1, "a"], [2**40, "b"], [None, "c"]]
+
+        assert executor._infer_column_type(rows, 0) == 20
+        assert executor._infer_column_type(rows, 1) == 25
+        assert executor._infer_column_type([[1], [2]], 0) == 23
+        assert executor._infer_column_type([[1]], 1) == 25
@@ -0,0 +1,226 @@
+"""
+Unit Tests: Declarative Partitioning
+
+PARTITION BY tables created as plain tables, PARTITION OF partitions as views
+of their parent, and pg_partitioned_table from the partitions global
+(simulated in memory).
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.partitioning import (
+    PartitionStore,
+    bound_filter,
+    parse_partition_ddl,
+    partition_view_sql,
+    referenced_view,
+    view_rows,
+)
+
+
+class MemoryGlobalAccessor:
+    """Global accessor over a dict keyed by subscript tuples ($DATA semantics)."""
+
+    def __init__(self):
+        self.nodes = {}
+
+    def _children(self, global_name, subscripts):
+        depth = len(subscripts)
+        return {
+            key[depth]
+            for (name, key) in self.nodes
+            if name == global_name and list(key[:depth]) == subscripts and len(key) > depth
+        }
+
+    def next_subscript(self, global_name, subscripts, previous):
+        following = sorted(self._children(global_name, subscripts))
+        following = [s for s in following if previous == "" or s > previous]
+        return following[0] if following else None
+
+    def get(self, global_name, subscripts):
+        return self.nodes[(global_name, tuple(subscripts))]
+
+    def data(self, global_name, subscripts):
+        has_value = (global_name, tuple(subscripts)) in self.nodes
+        return int(has_value) + (10 if self._children(global_name, subscripts) else 0)
+
+    def set(self, global_name, subscripts, value):
+        self.nodes[(global_name, tuple(subscripts))] = value
+
+    def kill(self, global_name, subscripts):
+        for name, key in list(self.nodes):
+            if name == global_name and list(key[: len(subscripts)]) == subscripts:
+                del self.nodes[(name, key)]
+
+
+@pytest.fixture
+def store():
+    return PartitionStore(MemoryGlobalAccessor(), "^PGWire.Partitions")
+
+
+class TestParsing:
+    def test_partitioned_table(self):
+        ddl = parse_partition_ddl(
+            "CREATE TABLE measurement (city_id INT, logdate DATE) "
+            "PARTITION BY RANGE (logdate) WITH (fillfactor = 70);"
+        )
+
+        assert ddl.statement == (
+            "CREATE TABLE measurement (city_id INT, logdate DATE) WITH (fillfactor = 70)"
+        )
+        assert ddl.key == "RANGE (logdate)"
+
+    def test_hash_as_shard_key(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_HASH_PARTITIONS_AS_SHARD_KEY", "true")
+
+        ddl = parse_partition_ddl(
+            "CREATE TABLE events (id INT, tenant INT) PARTITION BY HASH (tenant)"
+        )
+
+        assert ddl.statement == "CREATE TABLE events (id INT, tenant INT, SHARD KEY (tenant))"
+
+    def test_partitions(self):
+        ranged = parse_partition_ddl(
+            "CREATE TABLE m_2024 PARTITION OF measurement (CONSTRAINT p CHECK (peak > 0)) "
+            "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01') PARTITION BY LIST (city_id)"
+        )
+        default = parse_partition_ddl("create table m_rest partition of public.measurement default")
+
+        assert (ranged.relation, ranged.parent) == ("m_2024", "measurement")
+        assert ranged.bound == "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"
+        assert ranged.key == "LIST (city_id)"
+        assert (default.parent, default.bound) == ("public.measurement", "default")
+
+    @pytest.mark.parametrize(
+        "sql",
+        ["CREATE TABLE t (id INT)", "SELECT * FROM t PARTITION", "DROP INDEX t_idx"],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_partition_ddl(sql) is None
+
+    def test_drop_table(self):
+        assert parse_partition_ddl("DROP TABLE IF EXISTS m_2024 CASCADE").drop
+
+
+class TestBounds:
+    @pytest.mark.parametrize(
+        "key,bound,condition",
+        [
+            (
+                "RANGE (logdate)",
+                "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+                "logdate >= '2024-01-01' AND logdate < '2025-01-01'",
+            ),
+            ("RANGE (id)", "FOR VALUES FROM (MINVALUE) TO (100)", "id < 100"),
+            (
+                "LIST (region)",
+                "FOR VALUES IN ('eu', 'uk', NULL)",
+                "region IN ('eu', 'uk') OR region IS NULL",
+            ),
+            ("HASH (id)", "FOR VALUES WITH (MODULUS 4, REMAINDER 0)", None),
+            ("RANGE (a, b)", "FOR VALUES FROM (1, 1) TO (2, 2)", None),
+            ("LIST (region)", "DEFAULT", None),
+        ],
+    )
+    def test_filters(self, key, bound, condition):
+        assert bound_filter(key, bound) == condition
+
+    def test_view(self):
+        assert partition_view_sql("SQLUser.m_id", "SQLUser.m", "HASH (id)", "DEFAULT") == (
+            "CREATE VIEW SQLUser.m_id AS SELECT * FROM SQLUser.m"
+        )
+
+
+class TestCatalog:
+    def test_pg_partitioned_table(self, store):
+        store.set_key("SQLUser", "measurement", "RANGE (logdate)")
+        store.add_partition("SQLUser", "measurement", "m_2024", "FOR VALUES FROM (1) TO (2)")
+        store.add_partition("SQLUser", "measurement", "m_rest", "DEFAULT")
+        oids = OIDGenerator()
+
+        rows = view_rows(store.rows(), oids, {("SQLUser", "measurement"): {"logdate": 2}})
+
+        assert rows[0]["partrelid"] == oids.get_table_oid("SQLUser", "measurement")
+        assert (rows[0]["partstrat"], rows[0]["partnatts"], rows[0]["partattrs"]) == ("r", 1, "2")
+        assert rows[0]["partdefid"] == oids.get_table_oid("SQLUser", "m_rest")
+        assert store.parent("SQLUser", "m_rest") == "measurement"
+        assert referenced_view("SELECT partstrat FROM pg_catalog.pg_partitioned_table")
+        assert not referenced_view("SELECT 1 FROM pg_partitioned_table p JOIN pg_class c ON true")
+
+    def test_recreated_table_forgets_partitions(self, store):
+        store.set_key("SQLUser", "measurement", "RANGE (logdate)")
+        store.add_partition("SQLUser", "measurement", "m_2024", "DEFAULT")
+
+        store.set_key("SQLUser", "measurement", "LIST (city_id)")
+
+        assert store.partitions("SQLUser", "measurement") == []
+        assert store.key("SQLUser", "measurement") == "LIST (city_id)"
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch, store):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.executed = []
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_execute(sql, params=None, session_id=None):
+            executor.executed.append(sql)
+            return {"success": True, "rows": [], "columns": [], "command_tag": "CREATE"}
+
+        async def fake_store_call(operation):
+            return operation(store)
+
+        executor._execute_query = fake_execute
+        executor._partition_store_call = fake_store_call
+        return executor
+
+    def _run(self, executor, sql):
+        return asyncio.run(executor._execute_partition_ddl(parse_partition_ddl(sql)))
+
+    def test_partitioned_table_and_partition(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store)
+
+        created = self._run(executor, "CREATE TABLE m (id INT, d DATE) PARTITION BY RANGE (d)")
+        partition = self._run(
+            executor,
+            "CREATE TABLE m_2024 PARTITION OF m FOR VALUES FROM ('2024-01-01') TO (MAXVALUE)",
+        )
+
+        assert executor.executed == [
+            "CREATE TABLE m (id INT, d DATE)",
+            "CREATE VIEW SQLUser.m_2024 AS SELECT * FROM SQLUser.m WHERE d >= '2024-01-01'",
+        ]
+        assert created["notices"][0][:2] == ("NOTICE", "00000")
+        assert partition["command_tag"] == "CREATE TABLE"
+        assert store.partitions("SQLUser", "m") == ["m_2024"]
+
+    def test_parent_not_partitioned(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store)
+
+        result = self._run(executor, "CREATE TABLE t_1 PARTITION OF t DEFAULT")
+
+        assert result["sqlstate"] == "42809"
+        assert executor.executed == []
+
+    def test_drop_partitioned_table(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store)
+        store.set_key("SQLUser", "m", "RANGE (d)")
+        store.add_partition("SQLUser", "m", "m_2024", "DEFAULT")
+
+        assert self._run(executor, "DROP TABLE m") is None
+        assert executor.executed == ["DROP VIEW SQLUser.m_2024"]
+        assert store.rows() == []
+
+    def test_drop_other_table_passed_to_iris(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store)
+
+        assert self._run(executor, "DROP TABLE orders") is None
+        assert executor.executed == []
@@ -0,0 +1,233 @@
+"""
+Unit Tests: COMMENT ON and pg_description
+
+Comments kept in an IRIS global (simulated in memory) and read back through
+pg_description and the description functions.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.catalog.pg_description import (
+    CommentHandler,
+    CommentStore,
+    CommentTargetMissing,
+    columns_sql,
+    parse_comment,
+    referenced_view,
+)
+from iris_pgwire.catalog.pg_proc import PgProcEmulator
+from iris_pgwire.iris_executor import IRISExecutor
+
+ORDERS_COLUMNS = [("id", 1), ("Status", 2)]
+
+
+class MemoryGlobalAccessor:
+    """Global accessor over a dict keyed by subscript tuples."""
+
+    def __init__(self):
+        self.nodes = {}
+
+    def next_subscript(self, global_name, subscripts, previous):
+        depth = len(subscripts)
+        following = sorted(
+            {
+                key[depth]
+                for (name, key) in self.nodes
+                if name == global_name and list(key[:depth]) == subscripts
+            }
+        )
+        following = [s for s in following if previous == "" or s > previous]
+        return following[0] if following else None
+
+    def get(self, global_name, subscripts):
+        return self.nodes[(global_name, tuple(subscripts))]
+
+    def data(self, global_name, subscripts):
+        return int((global_name, tuple(subscripts)) in self.nodes)
+
+    def set(self, global_name, subscripts, value):
+        self.nodes[(global_name, tuple(subscripts))] = value
+
+    def kill(self, global_name, subscripts):
+        self.nodes.pop((global_name, tuple(subscripts)), None)
+
+
+@pytest.fixture
+def handler():
+    return CommentHandler(OIDGenerator(), iris_schema="SQLUser")
+
+
+@pytest.fixture
+def store():
+    return CommentStore(MemoryGlobalAccessor(), "^PGWire.Comments")
+
+
+class TestParsing:
+    @pytest.mark.parametrize(
+        "sql,object_type,name,column,text",
+        [
+            ("COMMENT ON TABLE orders IS 'Orders'", "TABLE", "orders", None, "Orders"),
+            (
+                "comment on column public.orders.\"Status\" is 'It''s open';",
+                "COLUMN",
+                "public.orders",
+                "Status",
+                "It's open",
+            ),
+            ("COMMENT ON MATERIALIZED VIEW recent IS NULL", "VIEW", "recent", None, None),
+            ("COMMENT ON PROCEDURE p(int) IS ''", "FUNCTION", "p(int)", None, None),
+        ],
+    )
+    def test_statements(self, sql, object_type, name, column, text):
+        comment = parse_comment(sql)
+
+        assert (comment.object_type, comment.name, comment.column, comment.text) == (
+            object_type,
+            name,
+            column,
+            text,
+        )
+
+    @pytest.mark.parametrize(
+        "sql",
+        ["COMMENT ON TRIGGER t ON orders IS 'x'", "COMMENT ON COLUMN status IS 'x'", "SELECT 1"],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_comment(sql) is None
+
+    def test_single_view_selects_only(self):
+        assert referenced_view("SELECT * FROM pg_catalog.pg_description") == "pg_description"
+        assert referenced_view("SELECT 1 FROM pg_description d JOIN pg_class c ON true") is None
+
+
+class TestTargets:
+    def test_relations_and_columns(self, handler):
+        table_oid = OIDGenerator().get_table_oid("SQLUser", "orders")
+
+        assert handler.relation("public.Orders") == ("SQLUser", "orders")
+        assert handler.relation('sales."Returns"') == ("sales", "Returns")
+        assert handler.target(parse_comment("COMMENT ON TABLE orders IS 'x'"), ORDERS_COLUMNS) == (
+            1259,
+            table_oid,
+            0,
+        )
+        assert handler.target(
+            parse_comment("COMMENT ON COLUMN orders.status IS 'x'"), ORDERS_COLUMNS
+        ) == (1259, table_oid, 2)
+
+    def test_other_objects(self, handler):
+        ifind_match = PgProcEmulator(OIDGenerator()).get_by_name("ifind_match")[0].oid
+
+        assert handler.target(parse_comment("COMMENT ON FUNCTION ifind_match IS 'x'")) == (
+            1255,
+            ifind_match,
+            0,
+        )
+        assert handler.target(parse_comment("COMMENT ON SCHEMA public IS 'x'")) == (2615, 2200, 0)
+        assert handler.target(parse_comment('COMMENT ON DATABASE "USER" IS \'x\''))[0] == 1262
+
+    @pytest.mark.parametrize(
+        "sql,columns,sqlstate",
+        [
+            ("COMMENT ON TABLE missing IS 'x'", [], "42P01"),
+            ("COMMENT ON COLUMN orders.total IS 'x'", ORDERS_COLUMNS, "42703"),
+            ("COMMENT ON FUNCTION no_such_fn() IS 'x'", None, "42883"),
+        ],
+    )
+    def test_missing_objects(self, handler, sql, columns, sqlstate):
+        with pytest.raises(CommentTargetMissing) as exc_info:
+            handler.target(parse_comment(sql), columns)
+        assert exc_info.value.sqlstate == sqlstate
+
+    def test_columns_listing_escaped(self):
+        assert "LOWER('o''brien')" in columns_sql("SQLUser", "o'brien")
+
+
+class TestDescriptions:
+    def test_store_round_trip(self, store):
+        store.set(1259, 16400, 0, "Customer orders")
+        store.set(1259, 16400, 2, "Order status")
+        store.set(1262, 16500, 0, "Shared")
+        store.set(1259, 16400, 2, None)
+
+        assert store.get(1259, 16400) == "Customer orders"
+        assert store.get(1259, 16400, 2) is None
+        assert [row["objoid"] for row in store.view_rows("pg_description")] == [16400]
+        assert store.view_rows("pg_shdescription") == [
+            {"objoid": 16500, "classoid": 1262, "objsubid": 0, "description": "Shared"}
+        ]
+
+    def test_description_functions(self, handler, store):
+        store.set(1259, 16400, 0, "Customer orders")
+        store.set(1259, 16400, 2, "Order status")
+
+        result = handler.execute_functions(
+            "SELECT obj_description(16400, 'pg_class') AS doc, col_description(16400, 2), "
+            "pg_catalog.obj_description(16400, 'pg_proc'), obj_description(16400)",
+            store,
+        )
+
+        assert result["rows"] == [["Customer orders", "Order status", None, "Customer orders"]]
+        assert [c["name"] for c in result["columns"]] == [
+            "doc",
+            "col_description",
+            "obj_description",
+            "obj_description",
+        ]
+
+    def test_catalog_queries_not_handled(self, handler, store):
+        sql = "SELECT c.relname, obj_description(c.oid, 'pg_class') FROM pg_class c"
+
+        assert handler.execute_functions(sql, store) is None
+
+
+class TestExecutor:
+    @staticmethod
+    def _executor(monkeypatch, store, columns):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+
+        async def fake_execute(sql, params=None, session_id=None):
+            return {"success": True, "rows": [list(row) for row in columns]}
+
+        async def fake_store_call(operation):
+            return operation(store)
+
+        executor._execute_query = fake_execute
+        executor._comment_store_call = fake_store_call
+        return executor
+
+    def test_comment_then_pg_description(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store, ORDERS_COLUMNS)
+        table_oid = OIDGenerator().get_table_oid("SQLUser", "orders")
+
+        comment = asyncio.run(
+            executor._execute_comment(parse_comment("COMMENT ON COLUMN orders.status IS 'Open?'"))
+        )
+        view = asyncio.run(
+            executor._execute_description_query(
+                f"SELECT objsubid, description FROM pg_description WHERE objoid = {table_oid}",
+                "pg_description",
+            )
+        )
+
+        assert comment["command_tag"] == "COMMENT"
+        assert view["rows"] == [(2, "Open?")]
+        assert [c["type_oid"] for c in view["columns"]] == [23, 25]
+
+    def test_missing_table_reported(self, monkeypatch, store):
+        executor = self._executor(monkeypatch, store, [])
+
+        result = asyncio.run(
+            executor._execute_comment(parse_comment("COMMENT ON TABLE missing IS 'x'"))
+        )
+
+        assert not result["success"]
+        assert result["sqlstate"] == "42P01"
+        assert store.rows() == []
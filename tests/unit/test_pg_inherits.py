@@ -0,0 +1,72 @@
+"""
+Unit Tests: pg_inherits Emulation
+
+IRIS persistent class inheritance exposed as PostgreSQL table inheritance.
+"""
+
+import pytest
+
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.catalog.pg_inherits import PgInheritsEmulator, inheritance_as_partitions
+
+CLASSES = [
+    ("Sample.Person", "%Persistent,%Populate", "Sample", "Person"),
+    ("Sample.Employee", "Sample.Person", "Sample", "Employee"),
+    ("Sample.Manager", "Sample.Employee,Sample.Auditable", "Sample", "Manager"),
+    ("Sample.Auditable", "%Persistent", "Sample", "Auditable"),
+    ("%Library.Persistent", "", "", ""),
+]
+
+
+@pytest.fixture
+def emulator():
+    emulator = PgInheritsEmulator(OIDGenerator())
+    emulator.load_classes(CLASSES)
+    return emulator
+
+
+class TestHierarchy:
+    def test_edges(self, emulator):
+        edges = [(e.child_name, e.parent_name, e.inhseqno) for e in emulator.get_all()]
+
+        assert edges == [
+            ("employee", "person", 1),
+            ("manager", "employee", 1),
+            ("manager", "auditable", 2),
+        ]
+
+    def test_oids_match_table_oids(self, emulator):
+        oid_gen = OIDGenerator()
+        employee = emulator.get_all()[0]
+
+        assert employee.inhrelid == oid_gen.get_table_oid("Sample", "Employee")
+        assert employee.inhparent == oid_gen.get_table_oid("Sample", "Person")
+
+    def test_parent_and_child_sets(self, emulator):
+        assert emulator.parent_tables() == {"person", "employee", "auditable"}
+        assert emulator.child_tables() == {"employee", "manager"}
+
+    def test_partition_flag_configurable(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_INHERITANCE_AS_PARTITIONS", "false")
+        assert inheritance_as_partitions() is False
+        monkeypatch.setenv("PGWIRE_INHERITANCE_AS_PARTITIONS", "true")
+        assert inheritance_as_partitions() is True
+
+
+class TestQuery:
+    def test_select_star(self, emulator):
+        columns, rows = emulator.query("SELECT * FROM pg_catalog.pg_inherits")
+
+        assert [c["name"] for c in columns] == ["inhrelid", "inhparent", "inhseqno", "inhdetachpending"]
+        assert len(rows) == 3
+
+    def test_regclass_projection_and_filter(self, emulator):
+        parent_oid = OIDGenerator().get_table_oid("Sample", "Person")
+
+        columns, rows = emulator.query(
+            f"SELECT i.inhrelid::regclass AS child, i.inhseqno FROM pg_inherits i "
+            f"WHERE i.inhparent = {parent_oid}"
+        )
+
+        assert columns == [{"name": "child", "type_oid": 2205}, {"name": "inhseqno", "type_oid": 23}]
+        assert rows == [("employee", 1)]
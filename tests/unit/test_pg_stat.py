@@ -0,0 +1,324 @@
+"""
+Unit Tests: pg_stat_* Statistics Views
+
+Bridge-side cumulative statistics and the view emulation built on them.
+"""
+
+import asyncio
+import contextvars
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.catalog.pg_stat import (
+    PG_REPLICATION_SLOTS_COLUMNS,
+    PG_STAT_ACTIVITY_COLUMNS,
+    PG_STAT_BGWRITER_COLUMNS,
+    PG_STAT_DATABASE_COLUMNS,
+    PG_STAT_IO_COLUMNS,
+    PG_STAT_REPLICATION_COLUMNS,
+    PG_STAT_USER_TABLES_COLUMNS,
+    PgStatEmulator,
+    referenced_view,
+)
+from iris_pgwire.catalog.schema_cache import SchemaCache
+from iris_pgwire.catalog.view_query import query_view
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.replication import WalSenders
+from iris_pgwire.stats_hooks import (
+    CountingStreamReader,
+    CountingStreamWriter,
+    StatsRegistry,
+    dml_target,
+)
+
+
+def _result(rows, success=True):
+    return {"success": success, "rows": [], "columns": [], "row_count": rows}
+
+
+@pytest.fixture
+def registry():
+    registry = StatsRegistry()
+
+    def session(connection_id, database, statements):
+        registry.session_started(connection_id, user="alice", database=database)
+        for sql, result in statements:
+            registry.record_query(sql, result, 2.0)
+
+    contextvars.copy_context().run(
+        session,
+        "c1",
+        "USER",
+        [
+            ("SELECT * FROM orders", _result(10)),
+            ("INSERT INTO orders VALUES (1)", _result(1)),
+            ("UPDATE SQLUser.Orders SET total = 0", _result(3)),
+            ('DELETE FROM "Sales"."Returns" WHERE id = 1', _result(2)),
+            ("SELEC 1", _result(0, success=False)),
+        ],
+    )
+    contextvars.copy_context().run(session, "c2", "ANALYTICS", [("SELECT 1", _result(1))])
+    registry.session_ended("c2")
+    return registry
+
+
+class TestActivityCounters:
+    @pytest.mark.parametrize(
+        "sql,target",
+        [
+            ("insert into Orders (id) values (1)", ("INSERT", "public", "orders")),
+            ("/* etl */ UPDATE ONLY sales.items SET x = 1", ("UPDATE", "sales", "items")),
+            ('DELETE FROM "SQLUser"."Orders"', ("DELETE", "sqluser", "orders")),
+            ("SELECT * FROM orders", None),
+        ],
+    )
+    def test_dml_target(self, sql, target):
+        assert dml_target(sql) == target
+
+    def test_database_counters(self, registry):
+        user = {activity.datname: activity for activity in registry.database_activity()}["USER"]
+
+        assert (user.xact_commit, user.xact_rollback) == (4, 1)
+        assert user.tup_returned == 10
+        assert (user.tup_inserted, user.tup_updated, user.tup_deleted) == (1, 3, 2)
+        assert user.sessions == 1
+        assert registry.active_sessions("USER") == 1
+        assert registry.active_sessions("ANALYTICS") == 0
+
+    def test_reset(self, registry):
+        registry.reset_activity()
+
+        assert registry.database_activity() == []
+        assert registry.table_activity() == {}
+        assert registry.io.iris_bytes_read == 0
+
+    def test_iris_reads(self):
+        registry = StatsRegistry()
+        rows = {"success": True, "rows": [("abc", 12, None), (b"\x00\x01", 1.5, "")]}
+        registry.record_query("SELECT * FROM t", rows, 4.0)
+        registry.record_query("SELECT * FROM t", {"success": False, "rows": []}, 1.0)
+
+        assert registry.io.iris_reads == 1
+        assert registry.io.iris_bytes_read == 3 + 8 + 2 + 8
+        assert registry.io.iris_read_time_ms == 4.0
+
+    def test_client_streams_counted(self):
+        registry = StatsRegistry()
+
+        async def scenario():
+            stream = asyncio.StreamReader()
+            stream.feed_data(b"Q\x00\x00\x00\x0dSELECT 1\x00")
+            reader = CountingStreamReader(stream, registry)
+            await reader.readexactly(5)
+            await reader.readexactly(9)
+            return reader.at_eof()
+
+        sent = []
+        writer = CountingStreamWriter(type("Stream", (), {"write": sent.append})(), registry)
+        writer.write(b"Z\x00\x00\x00\x05I")
+        writer.writelines([b"ab", b"c"])
+
+        assert not asyncio.run(scenario())
+        assert registry.io.client_bytes_received == 14
+        assert (registry.io.client_bytes_sent, registry.io.client_writes) == (9, 3)
+        assert sent == [b"Z\x00\x00\x00\x05I", b"ab", b"c"]
+
+
+class TestViews:
+    def test_referenced_view(self):
+        assert referenced_view("SELECT * FROM pg_catalog.pg_stat_database") == "pg_stat_database"
+        assert referenced_view("select relname from PG_STAT_USER_TABLES") == "pg_stat_user_tables"
+        assert referenced_view("SELECT * FROM pg_stat_bgwriter") == "pg_stat_bgwriter"
+        assert referenced_view("SELECT * FROM pg_stat_activity") == "pg_stat_activity"
+        assert referenced_view("SELECT * FROM pg_stat_statements") is None
+
+    def test_pg_stat_database(self, registry):
+        emulator = PgStatEmulator(registry)
+        columns, rows = query_view(
+            "SELECT datname, numbackends, xact_commit, blks_hit FROM pg_stat_database "
+            "WHERE datname = 'USER'",
+            PG_STAT_DATABASE_COLUMNS,
+            emulator.database_rows(),
+        )
+
+        assert [c["name"] for c in columns] == ["datname", "numbackends", "xact_commit", "blks_hit"]
+        assert rows == [("USER", 1, 4, None)]
+
+    def test_aggregates(self, registry):
+        emulator = PgStatEmulator(registry)
+        columns, rows = query_view(
+            "SELECT sum(xact_commit) AS commits, count(*) FROM pg_stat_database",
+            PG_STAT_DATABASE_COLUMNS,
+            emulator.database_rows(),
+        )
+
+        assert [c["name"] for c in columns] == ["commits", "count"]
+        assert rows == [(5, 2)]
+
+    def test_pg_stat_user_tables(self, registry):
+        emulator = PgStatEmulator(registry, OIDGenerator(), iris_schema="SQLUser")
+        view_rows = emulator.user_table_rows([("SQLUser", "Orders"), ("SQLUser", "Customers")])
+
+        columns, rows = query_view(
+            "SELECT s.relid, s.schemaname, s.relname, s.n_tup_ins, s.n_tup_upd, s.seq_scan "
+            "FROM pg_stat_user_tables s ORDER BY relname",
+            PG_STAT_USER_TABLES_COLUMNS,
+            view_rows,
+        )
+
+        oid = OIDGenerator().get_table_oid
+        assert [c["name"] for c in columns][:3] == ["relid", "schemaname", "relname"]
+        assert rows == [
+            (oid("SQLUser", "Customers"), "public", "customers", 0, 0, None),
+            (oid("SQLUser", "Orders"), "public", "orders", 1, 3, None),
+            (oid("sales", "returns"), "sales", "returns", 0, 0, None),
+        ]
+
+
+    def test_pg_stat_io(self):
+        registry = StatsRegistry()
+        registry.io.iris_bytes_read = 20000
+        registry.io.iris_read_time_ms = 12.5
+        registry.record_client_io(sent=8192)
+        emulator = PgStatEmulator(registry)
+
+        columns, rows = query_view(
+            "SELECT backend_type, reads, read_time, writes, op_bytes FROM pg_stat_io "
+            "WHERE backend_type = 'client backend'",
+            PG_STAT_IO_COLUMNS,
+            emulator.io_rows(),
+        )
+
+        assert rows == [("client backend", 3, 12.5, 1, 8192)]
+        assert len(emulator.io_rows()) == 5
+
+    def test_pg_stat_bgwriter(self):
+        registry = StatsRegistry()
+        registry.io.iris_bytes_read = 9000
+        emulator = PgStatEmulator(registry)
+
+        columns, rows = query_view(
+            "SELECT * FROM pg_stat_bgwriter", PG_STAT_BGWRITER_COLUMNS, emulator.bgwriter_rows()
+        )
+
+        assert [c["name"] for c in columns] == [name for name, _ in PG_STAT_BGWRITER_COLUMNS]
+        row = dict(zip([c["name"] for c in columns], rows[0]))
+        assert (row["checkpoints_timed"], row["buffers_backend"], row["buffers_alloc"]) == (0, 0, 2)
+        assert row["stats_reset"] is not None
+
+    def test_pg_stat_activity(self):
+        registry = StatsRegistry()
+
+        def client():
+            registry.session_started(
+                "c1", user="alice", database="USER", backend_pid=4242, client=("10.0.0.5", 51000)
+            )
+            registry.application_name_changed("psql")
+            registry.record_query("SELECT 1", _result(1), 1.0)
+            registry.query_started("SELECT pg_sleep(10)")
+
+        contextvars.copy_context().run(client)
+        contextvars.copy_context().run(
+            registry.session_started,
+            "c2",
+            user="debezium",
+            database="USER",
+            backend_pid=4343,
+            backend_type="walsender",
+        )
+
+        columns, rows = query_view(
+            "SELECT pid, usename, application_name, client_addr, state, query, backend_type "
+            "FROM pg_stat_activity ORDER BY pid",
+            PG_STAT_ACTIVITY_COLUMNS,
+            PgStatEmulator(registry).activity_rows(),
+        )
+
+        assert rows == [
+            (4242, "alice", "psql", "10.0.0.5", "active", "SELECT pg_sleep(10)", "client backend"),
+            (4343, "debezium", "", None, "idle", "", "walsender"),
+        ]
+
+    def test_pg_stat_replication(self):
+        registry = StatsRegistry()
+        for connection_id, pid, backend_type in (
+            ("c1", 4242, "client backend"),
+            ("c2", 4343, "walsender"),
+        ):
+            contextvars.copy_context().run(
+                registry.session_started,
+                connection_id,
+                user="debezium",
+                backend_pid=pid,
+                backend_type=backend_type,
+                application_name="dbz",
+            )
+
+        columns, rows = query_view(
+            "SELECT pid, application_name, state, sent_lsn, sync_state FROM pg_stat_replication",
+            PG_STAT_REPLICATION_COLUMNS,
+            PgStatEmulator(registry).replication_rows(),
+        )
+
+        assert rows == [(4343, "dbz", "startup", None, "async")]
+
+    def test_streaming_subscriber(self):
+        registry = StatsRegistry()
+        contextvars.copy_context().run(
+            registry.session_started,
+            "c1",
+            user="debezium",
+            database="USER",
+            backend_pid=4343,
+            backend_type="walsender",
+        )
+        wal_senders = WalSenders()
+        progress = wal_senders.register(4343)
+        progress.start_streaming("debezium", "pgoutput")
+        progress.sent(0x1_00000010, now=100.0)
+        progress.sent(0x1_00000020, now=101.0)
+        progress.confirmed(0x1_00000020, 0x1_00000010, 0x1_00000010, now=101.5)
+        emulator = PgStatEmulator(registry, wal_senders=wal_senders)
+
+        _, rows = query_view(
+            "SELECT state, sent_lsn, write_lsn, flush_lsn, write_lag, flush_lag "
+            "FROM pg_stat_replication",
+            PG_STAT_REPLICATION_COLUMNS,
+            emulator.replication_rows(),
+        )
+        assert rows == [
+            ("streaming", "1/20", "1/20", "1/10", "00:00:00.500000", "00:00:01.500000")
+        ]
+
+        _, rows = query_view(
+            "SELECT slot_name, plugin, slot_type, database, active, active_pid, "
+            "confirmed_flush_lsn FROM pg_replication_slots",
+            PG_REPLICATION_SLOTS_COLUMNS,
+            emulator.replication_slot_rows(),
+        )
+        assert rows == [("debezium", "pgoutput", "logical", "USER", True, 4343, "1/10")]
+
+class TestExecutor:
+    def test_user_tables_listed_from_iris(self, monkeypatch):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.iris_config = {"namespace": "USER"}
+        executor.schema_cache = SchemaCache(enabled=False)
+        monkeypatch.setattr(iris_executor_module, "get_stats", lambda: StatsRegistry())
+        listing = {"success": True, "rows": [["SQLUser", "Orders"]], "row_count": 1}
+
+        async def fake_execute(sql, params=None, session_id=None):
+            assert "INFORMATION_SCHEMA.TABLES" in sql
+            return listing
+
+        executor._execute_query = fake_execute
+        result = asyncio.run(
+            executor._execute_pg_stat_query(
+                "SELECT relname, n_tup_ins FROM pg_stat_user_tables", "pg_stat_user_tables"
+            )
+        )
+
+        assert result["success"]
+        assert result["rows"] == [("orders", 0)]
+        assert [c["type_oid"] for c in result["columns"]] == [19, 20]
@@ -0,0 +1,127 @@
+"""
+Unit Tests: Extended Query Pipelines
+
+Many Parse/Bind/Execute sequences before one Sync, Flush, skipping the rest
+of a failed pipeline until Sync, and responses written without waiting on
+a client that is still sending its batch.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+from iris_pgwire.pipeline import PipelineWriter, load_pipeline_buffer_bytes
+from iris_pgwire.protocol import PGWireProtocol
+from tests.protocol_messages import FakeWriter, backend_messages, frontend_message
+
+
+def _parse(sql: str, statement: str = "") -> bytes:
+    return frontend_message(b"P", statement.encode() + b"\x00" + sql.encode() + b"\x00\x00\x00")
+
+
+def _bind(portal: str = "", statement: str = "") -> bytes:
+    body = portal.encode() + b"\x00" + statement.encode() + b"\x00" + b"\x00" * 6
+    return frontend_message(b"B", body)
+
+
+def _execute(portal: str = "") -> bytes:
+    return frontend_message(b"E", portal.encode() + b"\x00" + struct.pack("!i", 0))
+
+
+def _statement(sql: str) -> bytes:
+    return _parse(sql) + _bind() + _execute()
+
+
+SYNC = frontend_message(b"S")
+FLUSH = frontend_message(b"H")
+
+
+async def _fake_execute(sql, params=None):
+    if "missing" in sql:
+        return {"success": False, "error": 'Table "missing" not found', "sqlstate": "42P01"}
+    return {
+        "success": True,
+        "rows": [[1]],
+        "columns": [{"name": "x", "type_oid": 23}],
+        "row_count": 1,
+        "command_tag": "SELECT",
+    }
+
+
+def _run(data: bytes):
+    async def session():
+        reader = asyncio.StreamReader()
+        reader.feed_data(data + frontend_message(b"X"))
+        reader.feed_eof()
+        writer = FakeWriter()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(side_effect=_fake_execute)
+        protocol = PGWireProtocol(reader, writer, executor, "pipeline")
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return executor, writer
+
+    executor, writer = asyncio.run(session())
+    return executor, writer, [kind for kind, _ in backend_messages(writer.buffer)]
+
+
+def test_writer_holds_back_drain_while_pipelining():
+    writer = FakeWriter()
+    pipeline = PipelineWriter(writer, limit=10)
+
+    async def run():
+        pipeline.pipelining = True
+        pipeline.write(b"12345")
+        await pipeline.drain()
+        held = writer.drains
+        pipeline.write(b"67890")
+        await pipeline.drain()  # Limit reached
+        return held
+
+    held = asyncio.run(run())
+
+    assert (held, writer.drains, pipeline.outstanding) == (0, 1, 0)
+
+
+def test_buffer_limit_from_environment(monkeypatch):
+    monkeypatch.setenv("PGWIRE_PIPELINE_BUFFER_BYTES", "4096")
+    assert load_pipeline_buffer_bytes() == 4096
+
+    monkeypatch.setenv("PGWIRE_PIPELINE_BUFFER_BYTES", "lots")
+    assert load_pipeline_buffer_bytes() == 16 * 1024 * 1024
+
+
+def test_many_statements_before_sync():
+    executor, writer, kinds = _run(b"".join(_statement(f"SELECT {n}") for n in range(200)) + SYNC)
+
+    assert executor.execute_query.call_count == 200
+    assert kinds.count(b"C") == 200
+    assert kinds.count(b"Z") == 1 and kinds[-1] == b"Z"
+    assert writer.drains == 1  # Only for ReadyForQuery
+
+
+def test_flush_sends_responses_without_ready_for_query():
+    _, writer, kinds = _run(_statement("SELECT 1") + FLUSH)
+
+    assert kinds == [b"1", b"2", b"D", b"C"]
+    assert writer.drains == 1
+
+
+def test_error_skips_rest_of_pipeline_until_sync():
+    executor, _, kinds = _run(
+        _statement("SELECT 1")
+        + _statement("SELECT * FROM missing")
+        + _statement("SELECT 2")
+        + FLUSH
+        + SYNC
+        + _statement("SELECT 3")
+        + SYNC
+    )
+
+    assert kinds == [
+        *(b"1", b"2", b"D", b"C"),
+        *(b"1", b"2", b"E"),
+        b"Z",
+        *(b"1", b"2", b"D", b"C"),
+        b"Z",
+    ]
+    assert executor.execute_query.call_count == 3
@@ -0,0 +1,168 @@
+"""
+Unit Tests: Per-Portal Result Sets
+
+Independent read positions per portal, max_rows batching and the open portal
+limit; Execute row limits with PortalSuspended over the extended protocol.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.portal_cursors import PortalCursorRegistry, TooManyOpenPortals
+from iris_pgwire.protocol import STATUS_IN_TRANSACTION, PGWireProtocol
+from tests.protocol_messages import FakeWriter, backend_messages, frontend_message
+
+
+def _result(count):
+    return {
+        "success": True,
+        "rows": [[i] for i in range(count)],
+        "columns": [{"name": "x", "type_oid": 23}],
+        "row_count": count,
+        "command_tag": "SELECT",
+    }
+
+
+class TestPortalCursor:
+    def test_batches_until_exhausted(self):
+        cursor = PortalCursorRegistry().open("p", _result(5))
+
+        assert cursor.fetch(2) == [[0], [1]]
+        assert not cursor.exhausted
+        assert cursor.fetch(0) == [[2], [3], [4]]
+        assert cursor.exhausted
+        assert cursor.fetch(2) == []
+
+    def test_statement_without_rows(self):
+        cursor = PortalCursorRegistry().open("p", {"success": True, "rows": [], "columns": []})
+
+        assert not cursor.returns_rows
+        assert cursor.exhausted
+
+
+class TestRegistry:
+    def test_interleaved_portals_keep_positions(self):
+        registry = PortalCursorRegistry()
+        a = registry.open("a", _result(4))
+        b = registry.open("b", _result(4))
+
+        assert a.fetch(1) == [[0]]
+        assert b.fetch(2) == [[0], [1]]
+        assert a.fetch(1) == [[1]]
+        assert registry.get("b").fetch(1) == [[2]]
+
+    def test_reopen_replaces_result_set(self):
+        registry = PortalCursorRegistry()
+        registry.open("a", _result(3)).fetch(3)
+
+        assert registry.open("a", _result(3)).fetch(1) == [[0]]
+        assert len(registry) == 1
+
+    def test_open_limit(self):
+        registry = PortalCursorRegistry(max_open=2)
+        registry.open("a", _result(1))
+        registry.open("b", _result(1))
+
+        with pytest.raises(TooManyOpenPortals) as exc_info:
+            registry.open("c", _result(1))
+        assert exc_info.value.sqlstate == "54000"
+
+        registry.close("a")
+        registry.open("c", _result(1))
+        assert "c" in registry
+
+    def test_limit_from_environment(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_MAX_OPEN_PORTALS", "3")
+
+        assert PortalCursorRegistry().max_open == 3
+
+    def test_close_all(self):
+        registry = PortalCursorRegistry()
+        registry.open("a", _result(1))
+        registry.close_all()
+
+        assert registry.get("a") is None
+
+
+def _parse(sql: str, statement: str = "") -> bytes:
+    return frontend_message(b"P", statement.encode() + b"\x00" + sql.encode() + b"\x00\x00\x00")
+
+
+def _bind(portal: str, statement: str = "") -> bytes:
+    body = portal.encode() + b"\x00" + statement.encode() + b"\x00" + b"\x00" * 6
+    return frontend_message(b"B", body)
+
+
+def _execute(portal: str, max_rows: int) -> bytes:
+    return frontend_message(b"E", portal.encode() + b"\x00" + struct.pack("!i", max_rows))
+
+
+SYNC = frontend_message(b"S")
+
+
+def _run(data: bytes, in_transaction: bool = False):
+    async def session():
+        reader = asyncio.StreamReader()
+        reader.feed_data(data + frontend_message(b"X"))
+        reader.feed_eof()
+        writer = FakeWriter()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(return_value=_result(5))
+        protocol = PGWireProtocol(reader, writer, executor, "portals")
+        if in_transaction:
+            protocol.transaction_status = STATUS_IN_TRANSACTION
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        return executor, backend_messages(writer.buffer)
+
+    executor, messages = asyncio.run(session())
+    # Data rows, PortalSuspended (s), CommandComplete (C) and ErrorResponse (E)
+    summary = []
+    for message_type, body in messages:
+        if message_type == b"D":
+            summary.append(int(body[6:].decode()))
+        elif message_type in (b"s", b"C", b"E"):
+            summary.append(message_type.decode())
+    return executor, summary, messages
+
+
+class TestExecuteRowLimit:
+    def test_portal_suspended_and_resumed(self):
+        executor, summary, messages = _run(
+            _parse("SELECT x FROM t", "s1")
+            + _bind("c1", "s1")
+            + _execute("c1", 2)
+            + SYNC
+            + _execute("c1", 2)
+            + SYNC
+            + _execute("c1", 2)
+            + SYNC,
+            in_transaction=True,
+        )
+
+        assert summary == [0, 1, "s", 2, 3, "s", 4, "C"]
+        assert (b"C", b"SELECT 1\x00") in messages
+        executor.execute_query.assert_called_once()
+
+    def test_resume_ignores_reparsed_unnamed_statement(self):
+        _, summary, _ = _run(
+            _parse("SELECT x FROM t")
+            + _bind("c1")
+            + _execute("c1", 3)
+            + _parse("SET application_name = 'etl'")
+            + _execute("c1", 0)
+            + SYNC
+        )
+
+        assert summary == [0, 1, 2, "s", 3, 4, "C"]
+
+    def test_portal_gone_after_implicit_transaction(self):
+        executor, summary, messages = _run(
+            _parse("SELECT x FROM t") + _bind("c1") + _execute("c1", 2) + SYNC + _execute("c1", 2)
+        )
+
+        assert summary == [0, 1, "s", "E"]
+        assert b"\x00C34000\x00" in messages[-1][1]
+        executor.execute_query.assert_called_once()
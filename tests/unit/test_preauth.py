@@ -0,0 +1,92 @@
+"""
+Unit Tests: Pre-Authentication Limits
+
+Handshake timeout and the pre-authentication byte budget.
+"""
+
+import asyncio
+import struct
+from unittest.mock import MagicMock
+
+import pytest
+
+from iris_pgwire.preauth import (
+    DEFAULT_AUTHENTICATION_TIMEOUT,
+    PreAuthLimitExceeded,
+    PreAuthReader,
+    load_authentication_timeout,
+    load_max_preauth_bytes,
+    run_handshake,
+)
+from iris_pgwire.protocol import PGWireProtocol
+from tests.protocol_messages import FakeWriter
+
+
+async def _handshake(data: bytes, eof: bool, timeout=0.2, max_bytes=100000):
+    reader = asyncio.StreamReader()
+    reader.feed_data(data)
+    if eof:
+        reader.feed_eof()
+    writer = FakeWriter()
+    protocol = PGWireProtocol(reader, writer, MagicMock(), "preauth")
+    try:
+        await run_handshake(protocol, None, timeout, max_bytes)
+    finally:
+        assert protocol.reader is reader
+    return protocol, writer
+
+
+class TestConfiguration:
+    def test_defaults(self, monkeypatch):
+        monkeypatch.delenv("PGWIRE_AUTHENTICATION_TIMEOUT", raising=False)
+        monkeypatch.delenv("PGWIRE_MAX_PREAUTH_BYTES", raising=False)
+
+        assert load_authentication_timeout() == DEFAULT_AUTHENTICATION_TIMEOUT
+        assert load_max_preauth_bytes() == 128 * 1024
+
+    def test_zero_timeout_disables(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_AUTHENTICATION_TIMEOUT", "0")
+
+        assert load_authentication_timeout() is None
+
+    def test_byte_limit_covers_startup_packet(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_MAX_PREAUTH_BYTES", "100")
+
+        assert load_max_preauth_bytes() == 10000
+
+
+class TestPreAuthReader:
+    def test_budget_checked_before_reading(self):
+        async def scenario():
+            stream = asyncio.StreamReader()
+            stream.feed_data(b"x" * 16)
+            reader = PreAuthReader(stream, max_bytes=12)
+
+            assert await reader.readexactly(8) == b"x" * 8
+            with pytest.raises(PreAuthLimitExceeded):
+                await reader.readexactly(8)
+            assert await reader.read() == b"xxxx"
+
+        asyncio.run(scenario())
+
+
+class TestHandshake:
+    def test_trickling_client_times_out(self):
+        # Half a StartupMessage, then silence
+        with pytest.raises(ConnectionAbortedError, match="timeout"):
+            asyncio.run(_handshake(struct.pack("!II", 40, 196608), eof=False))
+
+    def test_ssl_request_loop_hits_byte_limit(self):
+        ssl_request = struct.pack("!II", 8, 80877103)
+
+        with pytest.raises(ConnectionAbortedError):
+            asyncio.run(_handshake(ssl_request * 2000, eof=False, timeout=5, max_bytes=10000))
+
+    def test_completed_handshake_lifts_limits(self):
+        body = struct.pack("!I", 196608) + b"user\x00alice\x00\x00"
+        startup = struct.pack("!I", len(body) + 4) + body
+
+        protocol, writer = asyncio.run(_handshake(startup, eof=True))
+
+        assert protocol.authenticated
+        assert writer.buffer.endswith(b"Z\x00\x00\x00\x05I")
@@ -0,0 +1,135 @@
+"""
+Unit Tests: has_table_privilege / has_schema_privilege
+
+Parsing of privilege inquiry calls and their evaluation against a recording
+%SYSTEM.SQL.Security invoker.
+"""
+
+import asyncio
+
+import pytest
+
+from iris_pgwire.privileges import (
+    SECURITY_CLASS,
+    InvalidPrivilegeType,
+    PrivilegeFunctionHandler,
+)
+from iris_pgwire.read_only import set_session_read_only
+
+
+class RecordingInvoker:
+    """Grants the (object type, object, action) triples it was built with."""
+
+    def __init__(self, granted=()):
+        self.granted = set(granted)
+        self.calls = []
+
+    def call(self, class_name, method, args):
+        self.calls.append((class_name, method, list(args)))
+        _, object_type, obj, action, _ = args
+        return 1 if (object_type, obj, action) in self.granted else 0
+
+
+@pytest.fixture
+def handler():
+    return PrivilegeFunctionHandler(iris_schema="SQLUser")
+
+
+class TestParsing:
+    def test_two_and_three_argument_forms(self, handler):
+        checks = handler.parse(
+            "SELECT has_table_privilege('public.orders', 'select'), "
+            "pg_catalog.has_table_privilege('bi_reader', 'sales.returns', 'SELECT, UPDATE') "
+            "AS can_edit;"
+        )
+
+        assert [(c.user, c.target, c.privileges, c.column_name) for c in checks] == [
+            (None, "public.orders", ["SELECT"], "has_table_privilege"),
+            ("bi_reader", "sales.returns", ["SELECT", "UPDATE"], "can_edit"),
+        ]
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "SELECT relname FROM pg_class WHERE has_table_privilege(oid, 'SELECT')",
+            "SELECT has_table_privilege(relname, 'SELECT')",
+            "SELECT has_table_privilege('orders', 'SELECT'), 1",
+            "SELECT has_table_privilege('orders')",
+        ],
+    )
+    def test_other_queries_not_handled(self, handler, sql):
+        assert handler.parse(sql) is None
+
+    def test_unknown_privilege(self, handler):
+        with pytest.raises(InvalidPrivilegeType) as exc_info:
+            handler.parse("SELECT has_schema_privilege('public', 'SELECT')")
+
+        assert exc_info.value.sqlstate == "22023"
+        assert "SELECT" in str(exc_info.value)
+
+    def test_grant_option_accepted(self, handler):
+        checks = handler.parse("SELECT has_table_privilege('orders', 'INSERT WITH GRANT OPTION')")
+
+        assert checks[0].privileges == ["INSERT"]
+
+
+class TestTablePrivileges:
+    def test_checked_against_iris_privileges(self, handler):
+        invoker = RecordingInvoker({(1, "SQLUser.orders", "i")})
+
+        result = handler.execute(
+            "SELECT has_table_privilege('orders', 'SELECT, INSERT') AS can_write",
+            "app",
+            invoker,
+        )
+
+        assert result["rows"] == [[True]]
+        assert result["columns"][0]["name"] == "can_write"
+        assert result["columns"][0]["type_oid"] == 16
+        assert invoker.calls == [
+            (SECURITY_CLASS, "CheckPrivilege", ["app", 1, "SQLUser.orders", "s", ""]),
+            (SECURITY_CLASS, "CheckPrivilege", ["app", 3, "SQLUser.orders", "s", ""]),
+            (SECURITY_CLASS, "CheckPrivilege", ["app", 1, "SQLUser.orders", "i", ""]),
+        ]
+
+    def test_views_and_explicit_user(self, handler):
+        invoker = RecordingInvoker({(3, "sales.Recent", "s")})
+
+        result = handler.execute(
+            "SELECT has_table_privilege('bi', 'sales.\"Recent\"', 'SELECT'), "
+            "has_table_privilege('bi', 'sales.\"Recent\"', 'DELETE')",
+            "app",
+            invoker,
+        )
+
+        assert result["rows"] == [[True, False]]
+        assert {call[2][0] for call in invoker.calls} == {"bi"}
+
+    def test_read_only_session_has_no_write_privileges(self, handler):
+        invoker = RecordingInvoker({(1, "SQLUser.orders", "i"), (1, "SQLUser.orders", "s")})
+
+        async def run():
+            set_session_read_only(True)
+            return handler.execute(
+                "SELECT has_table_privilege('orders', 'INSERT'), "
+                "has_table_privilege('orders', 'SELECT'), "
+                "has_table_privilege('app', 'orders', 'INSERT')",
+                "app",
+                invoker,
+            )
+
+        assert asyncio.run(run())["rows"] == [[False, True, True]]
+
+
+class TestSchemaPrivileges:
+    def test_usage_and_create(self, handler):
+        sql = "SELECT has_schema_privilege('public', 'USAGE'), has_schema_privilege('x', 'CREATE')"
+        invoker = RecordingInvoker()
+
+        async def read_only():
+            set_session_read_only(True)
+            return handler.execute(sql, "app", invoker)
+
+        assert handler.execute(sql, "app", invoker)["rows"] == [[True, True]]
+        assert asyncio.run(read_only())["rows"] == [[True, False]]
+        assert invoker.calls == []
@@ -0,0 +1,343 @@
+"""
+Unit Tests: Publications
+
+CREATE / ALTER / DROP PUBLICATION parsing, stored definitions, row filters
+and column lists applied to row changes, and the pg_publication views.
+"""
+
+import asyncio
+import datetime
+from decimal import Decimal
+
+import pytest
+
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.publications import (
+    ChangeFilter,
+    Publication,
+    PublicationDDL,
+    PublicationError,
+    PublicationStore,
+    PublishedTable,
+    RowChange,
+    TableSpec,
+    apply_publication_ddl,
+    compile_row_filter,
+    parse_publication_ddl,
+    publication_rows,
+    publication_table_rows,
+)
+
+
+class FakeGlobals:
+    """Global accessor over nested dicts, subscripts in sorted order."""
+
+    def __init__(self):
+        self.root = {}
+
+    def _node(self, subscripts):
+        node = self.root
+        for subscript in subscripts:
+            node = node.get(subscript, {}) if isinstance(node, dict) else {}
+        return node
+
+    def next_subscript(self, global_name, subscripts, previous):
+        keys = sorted(key for key in self._node(subscripts) if key is not None)
+        later = [key for key in keys if previous == "" or key > previous]
+        return later[0] if later else None
+
+    def get(self, global_name, subscripts):
+        return self._node(subscripts).get(None)
+
+    def data(self, global_name, subscripts):
+        node = self._node(subscripts)
+        has_value = None in node
+        has_children = any(key is not None for key in node)
+        return (1 if has_value else 0) + (10 if has_children else 0)
+
+    def set(self, global_name, subscripts, value):
+        node = self.root
+        for subscript in subscripts:
+            node = node.setdefault(subscript, {})
+        node[None] = value
+
+    def kill(self, global_name, subscripts):
+        self._node(subscripts[:-1]).pop(subscripts[-1], None)
+
+
+@pytest.mark.parametrize(
+    "sql, ddl",
+    [
+        (
+            "CREATE PUBLICATION dbz FOR TABLE patients (id, status) WHERE (status <> 'test')",
+            PublicationDDL(
+                "CREATE",
+                ["dbz"],
+                tables=[TableSpec("patients", ["id", "status"], "status <> 'test'")],
+            ),
+        ),
+        (
+            "CREATE PUBLICATION all_changes FOR ALL TABLES WITH (publish = 'insert, update')",
+            PublicationDDL(
+                "CREATE", ["all_changes"], all_tables=True, options={"publish": "insert, update"}
+            ),
+        ),
+        (
+            'ALTER PUBLICATION dbz ADD TABLE "Orders", public.visits WHERE (ward IN (1, 2))',
+            PublicationDDL(
+                "ALTER",
+                ["dbz"],
+                tables=[TableSpec('"Orders"'), TableSpec("public.visits", where="ward IN (1, 2)")],
+                table_action="ADD",
+            ),
+        ),
+        (
+            "ALTER PUBLICATION dbz DROP TABLE orders",
+            PublicationDDL("ALTER", ["dbz"], tables=[TableSpec("orders")], table_action="DROP"),
+        ),
+        (
+            "ALTER PUBLICATION dbz SET (publish = 'delete')",
+            PublicationDDL("ALTER", ["dbz"], options={"publish": "delete"}),
+        ),
+        (
+            "DROP PUBLICATION IF EXISTS a, b",
+            PublicationDDL("DROP", ["a", "b"], if_exists=True),
+        ),
+        ("SELECT * FROM pg_publication", None),
+    ],
+)
+def test_parse(sql, ddl):
+    assert parse_publication_ddl(sql) == ddl
+
+
+@pytest.mark.parametrize(
+    "sql, sqlstate",
+    [
+        ("CREATE PUBLICATION p FOR TABLE t WHERE status = 'x'", "42601"),
+        ("CREATE PUBLICATION p FOR TABLE t WHERE (lower(status) = 'x')", "0A000"),
+        ("CREATE PUBLICATION p FOR TABLE t (id, id)", "42701"),
+        ("CREATE PUBLICATION p FOR TABLES IN SCHEMA public", "0A000"),
+        ("ALTER PUBLICATION p RENAME TO q", "0A000"),
+    ],
+)
+def test_parse_rejected(sql, sqlstate):
+    with pytest.raises(PublicationError) as error:
+        parse_publication_ddl(sql)
+
+    assert error.value.sqlstate == sqlstate
+
+
+@pytest.mark.parametrize(
+    "condition, row, matches",
+    [
+        ("status <> 'test'", {"STATUS": "live"}, True),
+        ("status <> 'test'", {"status": None}, False),
+        ("ward IN (1, 2) AND NOT discharged", {"ward": 2, "discharged": False}, True),
+        ("ward NOT IN (1, 2) OR ward IS NULL", {"ward": None}, True),
+        ("amount >= 10.5", {"amount": Decimal("10.50")}, True),
+        ("amount > -1", {"amount": "0"}, True),
+        ("admitted < '2024-01-01'", {"admitted": datetime.date(2023, 12, 31)}, True),
+        ("(a = 1 OR b = 1) AND c IS NOT NULL", {"a": 0, "b": 1, "c": 3}, True),
+        ("flag IS TRUE", {"flag": None}, False),
+    ],
+)
+def test_row_filter(condition, row, matches):
+    assert compile_row_filter(condition).matches(row) is matches
+
+
+def test_row_filter_columns():
+    assert compile_row_filter('ward = 1 AND "Status" IS NULL').columns == {"ward", "Status"}
+
+
+def _filtered(where=None, columns=None, publish=None) -> ChangeFilter:
+    publication = Publication("dbz", tables=[PublishedTable("SQLUser", "patients", columns, where)])
+    if publish is not None:
+        publication.publish = publish
+    return ChangeFilter([publication])
+
+
+def test_insert_filtered_and_projected():
+    change_filter = _filtered("status <> 'test'", ["id", "status"])
+    row = {"id": 1, "status": "live", "ssn": "123-45-6789"}
+
+    published = change_filter.apply(RowChange("insert", "SQLUser", "patients", new=row))
+    skipped = change_filter.apply(
+        RowChange("insert", "SQLUser", "patients", new={**row, "status": "test"})
+    )
+
+    assert published == RowChange("insert", "SQLUser", "patients", new={"id": 1, "status": "live"})
+    assert skipped is None
+
+
+@pytest.mark.parametrize(
+    "old_status, new_status, operation",
+    [
+        ("live", "live", "update"),
+        ("test", "live", "insert"),
+        ("live", "test", "delete"),
+        ("test", "test", None),
+    ],
+)
+def test_update_transformed(old_status, new_status, operation):
+    change = RowChange(
+        "update",
+        "SQLUser",
+        "patients",
+        new={"id": 1, "status": new_status},
+        old={"id": 1, "status": old_status},
+    )
+
+    published = _filtered("status <> 'test'").apply(change)
+
+    assert (published.operation if published else None) == operation
+    if operation == "insert":
+        assert published.old is None
+    if operation == "delete":
+        assert published.new is None and published.old["status"] == "live"
+
+
+def test_update_without_old_row_uses_new_row():
+    change = RowChange("update", "SQLUser", "patients", new={"id": 1, "status": "live"})
+
+    assert _filtered("status <> 'test'").apply(change).operation == "update"
+
+
+def test_unpublished_tables_and_actions():
+    change_filter = _filtered(publish=["insert"])
+
+    assert change_filter.apply(RowChange("insert", "SQLUser", "visits", new={"id": 1})) is None
+    assert change_filter.apply(RowChange("delete", "SQLUser", "patients", old={"id": 1})) is None
+    assert change_filter.apply(RowChange("truncate", "SQLUser", "patients")) is None
+
+
+def test_filters_of_several_publications_combine():
+    publications = [
+        Publication("a", tables=[PublishedTable("SQLUser", "patients", where="ward = 1")]),
+        Publication("b", tables=[PublishedTable("SQLUser", "patients", where="ward = 2")]),
+    ]
+    change_filter = ChangeFilter(publications)
+
+    wards = [
+        ward
+        for ward in (1, 2, 3)
+        if change_filter.apply(RowChange("insert", "SQLUser", "patients", new={"ward": ward}))
+    ]
+    unfiltered = ChangeFilter([*publications, Publication("c", all_tables=True)])
+
+    assert wards == [1, 2]
+    assert unfiltered.apply(RowChange("insert", "SQLUser", "patients", new={"ward": 3}))
+
+
+def test_different_column_lists_rejected():
+    change_filter = ChangeFilter(
+        [
+            Publication("a", tables=[PublishedTable("SQLUser", "patients", ["id"])]),
+            Publication("b", tables=[PublishedTable("SQLUser", "patients", ["id", "ward"])]),
+        ]
+    )
+
+    with pytest.raises(PublicationError, match="different column lists"):
+        change_filter.apply(RowChange("insert", "SQLUser", "patients", new={"id": 1}))
+
+
+def test_store_round_trip_and_alter():
+    store = PublicationStore(FakeGlobals())
+    patients = PublishedTable("SQLUser", "patients", ["id"], "ward = 1")
+    visits = PublishedTable("SQLUser", "visits")
+
+    apply_publication_ddl(
+        store, PublicationDDL("CREATE", ["dbz"], options={"publish": "insert"}), [patients]
+    )
+    apply_publication_ddl(store, PublicationDDL("ALTER", ["dbz"], table_action="ADD"), [visits])
+    with pytest.raises(PublicationError) as duplicate:
+        apply_publication_ddl(store, PublicationDDL("ALTER", ["dbz"], table_action="ADD"), [visits])
+    apply_publication_ddl(store, PublicationDDL("ALTER", ["dbz"], table_action="DROP"), [patients])
+    stored = store.get("dbz")
+
+    assert duplicate.value.sqlstate == "42710"
+    assert stored == Publication("dbz", publish=["insert"], tables=[visits])
+
+
+def test_create_existing_and_drop_missing():
+    store = PublicationStore(FakeGlobals())
+    apply_publication_ddl(store, PublicationDDL("CREATE", ["dbz"], all_tables=True), [])
+
+    with pytest.raises(PublicationError) as existing:
+        apply_publication_ddl(store, PublicationDDL("CREATE", ["dbz"]), [])
+    with pytest.raises(PublicationError) as all_tables:
+        apply_publication_ddl(
+            store,
+            PublicationDDL("ALTER", ["dbz"], table_action="ADD"),
+            [PublishedTable("SQLUser", "t")],
+        )
+    with pytest.raises(PublicationError) as missing:
+        apply_publication_ddl(store, PublicationDDL("DROP", ["dbz", "other"]), [])
+    apply_publication_ddl(store, PublicationDDL("DROP", ["dbz", "other"], if_exists=True), [])
+
+    assert (existing.value.sqlstate, all_tables.value.sqlstate) == ("42710", "55000")
+    assert missing.value.sqlstate == "42704"
+    assert store.all() == []
+
+
+def test_view_rows():
+    publications = [
+        Publication(
+            "dbz",
+            publish=["insert", "update"],
+            tables=[PublishedTable("SQLUser", "patients", ["id", "status"], "status <> 'test'")],
+        ),
+        Publication("everything", all_tables=True),
+    ]
+    columns = {
+        ("SQLUser", "patients"): ["ID", "Status", "SSN"],
+        ("Billing", "invoices"): ["ID"],
+    }
+
+    publication = publication_rows(publications, OIDGenerator())[0]
+    tables = publication_table_rows(publications, columns, "SQLUser")
+
+    assert (publication["pubinsert"], publication["pubdelete"]) == (True, False)
+    assert tables[0] == {
+        "pubname": "dbz",
+        "schemaname": "public",
+        "tablename": "patients",
+        "attnames": "{id,status}",
+        "rowfilter": "(status <> 'test')",
+    }
+    assert [(row["schemaname"], row["attnames"]) for row in tables[1:]] == [
+        ("public", "{id,status,ssn}"),
+        ("billing", "{id}"),
+    ]
+
+
+def _executor(globals_: FakeGlobals, table_columns: dict) -> IRISExecutor:
+    executor = IRISExecutor.__new__(IRISExecutor)
+
+    async def global_operation(operation):
+        return operation(globals_)
+
+    async def table_column_names(schema, table, session_id=None):
+        return table_columns.get(table, [])
+
+    executor.global_operation = global_operation
+    executor._table_column_names = table_column_names
+    return executor
+
+
+def test_executor_ddl_validates_tables_and_columns():
+    globals_ = FakeGlobals()
+    executor = _executor(globals_, {"patients": ["ID", "Status", "SSN"]})
+
+    async def run(sql):
+        return await executor._execute_publication_ddl(parse_publication_ddl(sql))
+
+    created = asyncio.run(
+        run("CREATE PUBLICATION dbz FOR TABLE patients (id, status) WHERE (status <> 'x')")
+    )
+    no_table = asyncio.run(run("CREATE PUBLICATION p2 FOR TABLE missing"))
+    no_column = asyncio.run(run("CREATE PUBLICATION p3 FOR TABLE patients WHERE (ward = 1)"))
+
+    assert created["command_tag"] == "CREATE PUBLICATION"
+    assert no_table["sqlstate"] == "42P01" and no_column["sqlstate"] == "42703"
+    assert [p.name for p in PublicationStore(globals_).all()] == ["dbz"]
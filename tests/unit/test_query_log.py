@@ -0,0 +1,102 @@
+"""
+Unit Tests: Structured Query Log
+
+JSON Lines records, parameter redaction and destination configuration.
+"""
+
+import json
+import logging
+
+import pytest
+
+from iris_pgwire.query_log import QueryLogWriter, install_query_log
+from iris_pgwire.stats_hooks import QueryEvent, get_stats
+
+
+class ListHandler(logging.Handler):
+    def __init__(self):
+        super().__init__()
+        self.lines = []
+
+    def emit(self, record):
+        self.lines.append(self.format(record))
+
+
+def _event(**kwargs):
+    values = {
+        "connection_id": "127.0.0.1:5000",
+        "user": "etl",
+        "sql": "INSERT INTO t VALUES (?, ?)",
+        "command": "INSERT",
+        "row_count": 1,
+        "duration_ms": 3.21456,
+        "success": True,
+        "params": ["secret", None],
+        "timestamp": 1767609062.114,
+    }
+    values.update(kwargs)
+    return QueryEvent(**values)
+
+
+class TestQueryLogWriter:
+    def test_record_fields(self):
+        handler = ListHandler()
+        QueryLogWriter(handler).on_query(_event())
+
+        record = json.loads(handler.lines[0])
+
+        assert record == {
+            "ts": "2026-01-05T10:31:02.114Z",
+            "connection_id": "127.0.0.1:5000",
+            "user": "etl",
+            "command": "INSERT",
+            "sql": "INSERT INTO t VALUES (?, ?)",
+            "params": ["***", None],
+            "duration_ms": 3.215,
+            "rows": 1,
+            "success": True,
+            "sqlstate": None,
+        }
+
+    def test_full_and_omitted_parameters(self):
+        full, omitted = ListHandler(), ListHandler()
+        QueryLogWriter(full, "full").on_query(_event(params=["secret", b"\x01"]))
+        QueryLogWriter(omitted, "none").on_query(_event())
+
+        assert json.loads(full.lines[0])["params"] == ["secret", "01"]
+        assert "params" not in json.loads(omitted.lines[0])
+
+    def test_failed_statement(self):
+        handler = ListHandler()
+        QueryLogWriter(handler).on_query(_event(success=False, sqlstate="23505", row_count=0))
+
+        record = json.loads(handler.lines[0])
+
+        assert (record["success"], record["sqlstate"]) == (False, "23505")
+
+    def test_invalid_param_mode(self):
+        with pytest.raises(ValueError):
+            QueryLogWriter(ListHandler(), "hash")
+
+
+class TestInstallQueryLog:
+    def test_disabled_without_destination(self, monkeypatch):
+        monkeypatch.delenv("PGWIRE_QUERY_LOG", raising=False)
+
+        assert install_query_log() is None
+
+    def test_jsonl_file_receives_statements(self, monkeypatch, tmp_path):
+        path = tmp_path / "queries.jsonl"
+        monkeypatch.setenv("PGWIRE_QUERY_LOG", str(path))
+
+        writer = install_query_log()
+        try:
+            get_stats().record_query(
+                "SELECT ?", {"success": True, "row_count": 1, "command": "SELECT"}, 1.0, ["x"]
+            )
+        finally:
+            get_stats().remove_hooks(writer)
+            writer.close()
+
+        record = json.loads(path.read_text().splitlines()[0])
+        assert (record["sql"], record["params"], record["rows"]) == ("SELECT ?", ["***"], 1)
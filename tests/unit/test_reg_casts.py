@@ -0,0 +1,104 @@
+"""
+Unit Tests: ::regclass / ::regtype / ::regproc / ::regnamespace Casts
+
+Literal OID alias casts resolved before translation.
+"""
+
+import asyncio
+from unittest.mock import MagicMock
+
+import pytest
+
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.catalog.pg_proc import PgProcEmulator
+from iris_pgwire.catalog.reg_casts import RegCastResolver, UndefinedRegName
+from iris_pgwire.protocol import PGWireProtocol
+from tests.protocol_messages import FakeWriter
+
+
+@pytest.fixture
+def resolver():
+    return RegCastResolver(iris_schema="SQLUser")
+
+
+class TestNameToOid:
+    def test_regclass_uses_catalog_table_oids(self, resolver):
+        oid = OIDGenerator().get_table_oid
+
+        assert resolver.resolve(
+            "SELECT attname FROM pg_attribute WHERE attrelid = 'Orders'::regclass"
+        ) == f"SELECT attname FROM pg_attribute WHERE attrelid = {oid('SQLUser', 'orders')}"
+        assert resolver.resolve("SELECT 'public.orders'::regclass::oid") == (
+            f"SELECT {oid('SQLUser', 'orders')}"
+        )
+        assert resolver.resolve("SELECT 'sales.\"Returns\"' :: regclass") == (
+            f"SELECT {oid('sales', 'Returns')}"
+        )
+
+    @pytest.mark.parametrize(
+        "name,oid",
+        [
+            ("int4", 23),
+            ("integer", 23),
+            ("Character  Varying(255)", 1043),
+            ("pg_catalog.timestamptz", 1184),
+            ("text[]", 1009),
+            ("name", 19),
+        ],
+    )
+    def test_regtype(self, resolver, name, oid):
+        assert resolver.resolve(f"SELECT '{name}'::regtype") == f"SELECT {oid}"
+
+    def test_regproc_and_regnamespace(self, resolver):
+        ifind_match = PgProcEmulator(OIDGenerator()).get_by_name("ifind_match")[0].oid
+
+        assert resolver.resolve("SELECT 'ifind_match'::regproc") == f"SELECT {ifind_match}"
+        assert resolver.resolve("SELECT 'ifind_match(text, text)'::regprocedure") == (
+            f"SELECT {ifind_match}"
+        )
+        assert resolver.resolve("SELECT 'information_schema'::regnamespace") == "SELECT 11323"
+
+    @pytest.mark.parametrize(
+        "sql,sqlstate",
+        [("SELECT 'widget'::regtype", "42704"), ("SELECT 'no_such_fn'::regproc", "42883")],
+    )
+    def test_unknown_names(self, resolver, sql, sqlstate):
+        with pytest.raises(UndefinedRegName) as exc_info:
+            resolver.resolve(sql)
+        assert exc_info.value.sqlstate == sqlstate
+
+
+class TestOidToName:
+    def test_known_oids_render_as_names(self, resolver):
+        relation = resolver.relation_oid("sales.returns")
+
+        assert resolver.resolve(f"SELECT {relation}::regclass, '1043'::regtype") == (
+            "SELECT 'sales.returns', 'character varying'"
+        )
+        assert resolver.resolve("SELECT 2200::regnamespace, 99::regtype") == "SELECT 'public', '99'"
+
+    def test_only_literal_casts_rewritten(self, resolver):
+        sql = (
+            "SELECT inhrelid::regclass, 'nextval(''seq''::regclass)' FROM pg_inherits "
+            "WHERE inhparent = $1::regclass"
+        )
+        assert resolver.resolve(sql) == sql
+
+
+class TestProtocol:
+    def test_casts_resolved_before_translation(self):
+        protocol = PGWireProtocol(asyncio.StreamReader(), FakeWriter(), MagicMock(), "reg")
+
+        assert protocol.translate_postgres_parameters(
+            "SELECT typname FROM pg_type WHERE oid = 'int8'::regtype AND typlen = $1::int"
+        ) == "SELECT typname FROM pg_type WHERE oid = 20 AND typlen = CAST(? AS INTEGER)"
+
+    def test_unknown_type_reported(self):
+        writer = FakeWriter()
+        protocol = PGWireProtocol(asyncio.StreamReader(), writer, MagicMock(), "reg")
+
+        asyncio.run(protocol.handle_query_message(b"SELECT 'widget'::regtype\x00"))
+
+        assert b"C42704\x00" in writer.buffer
+        assert b'type "widget" does not exist' in writer.buffer
+        assert writer.buffer.endswith(b"Z\x00\x00\x00\x05I")
@@ -0,0 +1,221 @@
+"""
+Unit Tests: Replication Connections
+
+StartupMessage replication=database/true, the walsender command grammar
+(IDENTIFY_SYSTEM) and the restrictions of walsender sessions.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.replication import (
+    LOGICAL,
+    PHYSICAL,
+    InvalidReplicationParameter,
+    WalSenderProgress,
+    format_lsn,
+    replication_command,
+    replication_mode,
+    get_wal_senders,
+    system_identifier,
+)
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import (
+    FakeWriter,
+    backend_messages,
+    frontend_message,
+    query_message,
+    startup_message,
+)
+
+
+def _errors(messages) -> list[tuple[str, str]]:
+    errors = []
+    for message_type, body in messages:
+        if message_type == b"E":
+            fields = dict((f[:1].decode(), f[1:].decode()) for f in body.split(b"\x00") if f)
+            errors.append((fields["C"], fields["M"]))
+    return errors
+
+
+def _data_rows(messages) -> list[list[str | None]]:
+    rows = []
+    for message_type, body in messages:
+        if message_type != b"D":
+            continue
+        values, pos = [], 2
+        for _ in range(struct.unpack("!H", body[:2])[0]):
+            length = struct.unpack("!i", body[pos : pos + 4])[0]
+            pos += 4
+            values.append(None if length < 0 else body[pos : pos + length].decode())
+            pos += max(length, 0)
+        rows.append(values)
+    return rows
+
+
+async def _session(messages: bytes, **params):
+    reader = asyncio.StreamReader()
+    reader.feed_data(startup_message(**params) + messages + frontend_message(b"X"))
+    reader.feed_eof()
+    writer = FakeWriter()
+    executor = MagicMock()
+    executor.iris_config = {"host": "iris", "port": 1972, "namespace": "USER"}
+    executor.execute_query = AsyncMock(
+        return_value={"success": True, "rows": [], "columns": [], "row_count": 0}
+    )
+    protocol = PGWireProtocol(reader, writer, executor, "walsender")
+    await protocol.handle_ssl_probe(None)
+    await protocol.handle_startup_sequence()
+    handshake = len(writer.buffer)
+    try:
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+    finally:
+        get_stats().session_ended(protocol.connection_id)
+    return protocol, executor, backend_messages(writer.buffer[handshake:])
+
+
+@pytest.mark.parametrize(
+    "value, mode",
+    [
+        ("database", LOGICAL),
+        ("DATABASE", LOGICAL),
+        ("true", PHYSICAL),
+        ("on", PHYSICAL),
+        ("1", PHYSICAL),
+        ("false", None),
+        (None, None),
+    ],
+)
+def test_replication_mode(value, mode):
+    assert replication_mode(value) == mode
+
+
+def test_invalid_replication_mode():
+    with pytest.raises(InvalidReplicationParameter, match='"replication": "logical"'):
+        replication_mode("logical")
+
+
+def test_replication_command():
+    assert replication_command("IDENTIFY_SYSTEM") == "IDENTIFY_SYSTEM"
+    assert replication_command(" create_replication_slot s LOGICAL pgoutput") == (
+        "CREATE_REPLICATION_SLOT"
+    )
+    assert replication_command("SELECT * FROM pg_publication") is None
+
+
+def test_lsn_and_system_identifier():
+    assert format_lsn(0x16_B374D848) == "16/B374D848"
+    assert format_lsn(0) == "0/0"
+    systemid = system_identifier("iris", 1972, "USER")
+    assert systemid == system_identifier("iris", 1972, "USER")
+    assert systemid != system_identifier("iris", 1972, "SALES")
+    assert 0 < int(systemid) < 2**63
+
+
+def test_identify_system():
+    query = query_message("IDENTIFY_SYSTEM")
+    _, executor, messages = asyncio.run(
+        _session(query, user="debezium", database="USER", replication="database")
+    )
+
+    [row] = _data_rows(messages)
+    assert row[0] == system_identifier("iris", 1972, "USER")
+    assert row[1] == "1" and row[3] == "USER"
+    assert "/" in row[2]
+    assert b"IDENTIFY_SYSTEM\x00" in [body for kind, body in messages if kind == b"C"]
+    executor.execute_query.assert_not_called()
+
+
+def test_unsupported_command():
+    _, _, messages = asyncio.run(_session(query_message("BASE_BACKUP"), replication="database"))
+
+    assert _errors(messages) == [("0A000", "BASE_BACKUP is not supported")]
+    assert messages[-1][0] == b"Z"
+
+
+def test_logical_connection_runs_sql():
+    _, executor, messages = asyncio.run(
+        _session(query_message("SELECT * FROM pg_publication"), replication="database")
+    )
+
+    executor.execute_query.assert_called_once()
+    assert _errors(messages) == []
+
+
+def test_physical_connection_rejects_sql():
+    _, executor, messages = asyncio.run(_session(query_message("SELECT 1"), replication="true"))
+
+    assert _errors(messages) == [
+        ("0A000", "cannot execute SQL commands in WAL sender for physical replication")
+    ]
+    executor.execute_query.assert_not_called()
+
+
+def test_extended_protocol_rejected():
+    parse = frontend_message(b"P", b"\x00SELECT 1\x00\x00\x00")
+    _, executor, messages = asyncio.run(
+        _session(parse + frontend_message(b"S"), replication="database")
+    )
+
+    assert [state for state, _ in _errors(messages)] == ["08P01", "08P01"]
+    executor.execute_query.assert_not_called()
+
+
+def test_invalid_startup_value_is_fatal():
+    with pytest.raises(ConnectionAbortedError):
+        asyncio.run(_session(b"", replication="maybe"))
+
+
+def test_listed_as_walsender():
+    async def observe():
+        reader = asyncio.StreamReader()
+        reader.feed_data(startup_message(user="debezium", database="USER", replication="database"))
+        protocol = PGWireProtocol(reader, FakeWriter(), MagicMock(), "observed")
+        await protocol.handle_ssl_probe(None)
+        await protocol.handle_startup_sequence()
+        try:
+            return get_stats().session("observed")
+        finally:
+            get_stats().session_ended("observed")
+
+    session = asyncio.run(observe())
+
+    assert (session.backend_type, session.database) == ("walsender", "USER")
+    assert get_wal_senders().get(session.backend_pid) is not None
+    get_wal_senders().unregister(session.backend_pid)
+
+
+class TestWalSenderProgress:
+    def test_lag_is_time_until_confirmed(self):
+        progress = WalSenderProgress()
+        progress.sent(100, now=10.0)
+        progress.sent(200, now=11.0)
+        progress.sent(300, now=12.0)
+
+        progress.confirmed(250, 200, 100, reply_time=13.0, now=13.0)
+
+        assert (progress.write_lsn, progress.flush_lsn, progress.replay_lsn) == (250, 200, 100)
+        assert (progress.write_lag, progress.flush_lag, progress.replay_lag) == (2.0, 2.0, 3.0)
+        assert progress.reply_time == 13.0
+
+    def test_lag_kept_until_new_position_confirmed(self):
+        progress = WalSenderProgress()
+        progress.sent(100, now=10.0)
+        progress.confirmed(100, 100, 100, now=10.5)
+
+        progress.confirmed(100, 100, 100, now=30.0)
+
+        assert progress.replay_lag == 0.5
+
+    def test_unreported_positions_left_alone(self):
+        progress = WalSenderProgress()
+        progress.sent(100, now=10.0)
+        progress.confirmed(100, 100, 100, now=11.0)
+
+        progress.confirmed(150, 0, 0, now=12.0)
+
+        assert (progress.write_lsn, progress.flush_lsn, progress.replay_lsn) == (150, 100, 100)
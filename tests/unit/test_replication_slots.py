@@ -0,0 +1,349 @@
+"""
+Unit Tests: Replication Slots
+
+Slot command parsing, journal positions, slots kept in an IRIS global with
+transactional confirmations, and streams resuming at a slot's confirmed
+position.
+"""
+
+import asyncio
+import struct
+from unittest.mock import MagicMock
+
+import pytest
+
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.catalog.pg_stat import PgStatEmulator
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.replication import current_lsn, get_wal_senders
+from iris_pgwire.replication_slots import (
+    ActiveSlots,
+    CreateSlot,
+    JournalUnavailable,
+    ReplicationSlot,
+    SlotError,
+    SlotStore,
+    get_active_slots,
+    journal_location,
+    journal_lsn,
+    parse_create_slot,
+    parse_slot_command,
+)
+from iris_pgwire.replication_stream import parse_lsn, pg_timestamp
+from iris_pgwire.stats_hooks import StatsRegistry, get_stats
+from tests.protocol_messages import (
+    FakeWriter,
+    backend_messages,
+    frontend_message,
+    query_message,
+    startup_message,
+)
+
+
+@pytest.mark.parametrize(
+    "sql, create",
+    [
+        (
+            "CREATE_REPLICATION_SLOT debezium LOGICAL pgoutput",
+            CreateSlot("debezium", True, "pgoutput"),
+        ),
+        (
+            'CREATE_REPLICATION_SLOT "cdc_1" TEMPORARY LOGICAL "wal2json" (SNAPSHOT \'nothing\')',
+            CreateSlot("cdc_1", True, "wal2json", temporary=True),
+        ),
+        (
+            "CREATE_REPLICATION_SLOT Standby PHYSICAL RESERVE_WAL",
+            CreateSlot("standby", False),
+        ),
+        (
+            "CREATE_REPLICATION_SLOT s LOGICAL test_decoding NOEXPORT_SNAPSHOT;",
+            CreateSlot("s", True, "test_decoding"),
+        ),
+    ],
+)
+def test_parse_create(sql, create):
+    assert parse_create_slot(sql) == create
+
+
+def test_parse_errors():
+    with pytest.raises(SlotError) as syntax:
+        parse_create_slot("CREATE_REPLICATION_SLOT debezium")
+    with pytest.raises(SlotError) as name:
+        parse_create_slot('CREATE_REPLICATION_SLOT "Debezium" LOGICAL pgoutput')
+
+    assert syntax.value.sqlstate == "42601"
+    assert name.value.sqlstate == "42602"
+
+
+def test_parse_slot_command():
+    assert parse_slot_command("DROP_REPLICATION_SLOT debezium WAIT") == "debezium"
+    assert parse_slot_command('READ_REPLICATION_SLOT "standby";') == "standby"
+
+
+def test_journal_positions():
+    position = journal_lsn("/usr/irissys/mgr/journal/20261016.003", 0x1F40)
+
+    assert journal_location(position) == ("20261016.003", 0x1F40)
+    assert journal_lsn("/usr/irissys/mgr/journal/20261016.004", 0) > position > current_lsn()
+    assert journal_location(current_lsn()) is None
+    with pytest.raises(JournalUnavailable):
+        journal_lsn("", 0)
+
+
+class FakeGlobals:
+    """Global accessor over nested dicts, with a transaction log."""
+
+    def __init__(self):
+        self.root = {}
+        self.transactions = []
+
+    def _node(self, subscripts):
+        node = self.root
+        for subscript in subscripts:
+            node = node.get(subscript, {})
+        return node
+
+    def next_subscript(self, global_name, subscripts, previous):
+        later = [key for key in sorted(self._node(subscripts)) if previous == "" or key > previous]
+        return later[0] if later else None
+
+    def get(self, global_name, subscripts):
+        return self._node(subscripts[:-1])[subscripts[-1]]
+
+    def data(self, global_name, subscripts):
+        return int(subscripts[-1] in self._node(subscripts[:-1]))
+
+    def set(self, global_name, subscripts, value):
+        node = self.root
+        for subscript in subscripts[:-1]:
+            node = node.setdefault(subscript, {})
+        node[subscripts[-1]] = value
+
+    def kill(self, global_name, subscripts):
+        self._node(subscripts[:-1]).pop(subscripts[-1], None)
+
+    def tstart(self):
+        self.transactions.append("TSTART")
+
+    def tcommit(self):
+        self.transactions.append("TCOMMIT")
+
+    def trollback(self):
+        self.transactions.append("TROLLBACK")
+
+
+def _slot(name="debezium", position=100) -> ReplicationSlot:
+    return ReplicationSlot(name, "logical", "pgoutput", "USER", False, position, position)
+
+
+def test_store_create_and_drop():
+    store = SlotStore(FakeGlobals())
+    store.create(_slot())
+
+    with pytest.raises(SlotError) as duplicate:
+        store.create(_slot())
+    listed = [slot.name for slot in store.all()]
+    store.drop("debezium")
+    with pytest.raises(SlotError) as missing:
+        store.drop("debezium")
+
+    assert duplicate.value.sqlstate == "42710"
+    assert listed == ["debezium"]
+    assert missing.value.sqlstate == "42704"
+    assert str(missing.value) == 'replication slot "debezium" does not exist'
+
+
+def test_confirm_is_transactional_and_moves_forward():
+    globals_ = FakeGlobals()
+    store = SlotStore(globals_)
+    store.create(_slot())
+
+    advanced = [store.confirm("debezium", lsn) for lsn in (300, 200, 300)]
+
+    assert advanced == [True, False, False]
+    assert globals_.transactions == ["TSTART", "TCOMMIT"] * 3
+    slot = store.get("debezium")
+    assert (slot.restart_lsn, slot.confirmed_flush_lsn) == (300, 300)
+
+
+def test_confirm_rolls_back_on_failure():
+    globals_ = FakeGlobals()
+    store = SlotStore(globals_)
+    store.create(_slot())
+    globals_.set = MagicMock(side_effect=RuntimeError("<PROTECT>"))
+
+    with pytest.raises(RuntimeError):
+        store.confirm("debezium", 300)
+
+    assert globals_.transactions == ["TSTART", "TROLLBACK"]
+    assert store.get("debezium").confirmed_flush_lsn == 100
+
+
+def test_resume_after_restart_skips_confirmed_changes():
+    globals_ = FakeGlobals()
+    SlotStore(globals_).create(_slot())
+    SlotStore(globals_).confirm("debezium", 500)
+
+    slot = SlotStore(globals_).get("debezium")  # A new bridge process
+
+    assert slot.resume_lsn(0) == 500
+    assert slot.resume_lsn(800) == 800
+
+
+def test_active_slots():
+    active = ActiveSlots()
+    active.create_temporary(ReplicationSlot("tmp", "logical", temporary=True), pid=1)
+    active.acquire("debezium", pid=1)
+
+    with pytest.raises(SlotError) as in_use:
+        active.acquire("debezium", pid=2)
+    active.release("debezium", pid=1)
+    active.acquire("debezium", pid=2)
+    active.session_ended(1)
+
+    assert in_use.value.sqlstate == "55006"
+    assert str(in_use.value) == 'replication slot "debezium" is active for PID 1'
+    assert active.temporary == {}
+    assert active.owner("debezium") == 2
+
+
+def test_pg_replication_slots_lists_defined_slots():
+    emulator = PgStatEmulator(StatsRegistry(), OIDGenerator(), "SQLUser")
+
+    [row] = emulator.replication_slot_rows([_slot(position=0x10)])
+
+    assert (row["slot_name"], row["slot_type"], row["active"]) == ("debezium", "logical", False)
+    assert row["confirmed_flush_lsn"] == "0/10"
+
+
+def _standby_status(position: int) -> bytes:
+    body = b"r" + struct.pack("!QQQqB", position, position, position, pg_timestamp(), 0)
+    return frontend_message(b"d", body)
+
+
+def _data_row(body: bytes) -> list[str | None]:
+    values, pos = [], 2
+    for _ in range(struct.unpack("!H", body[:2])[0]):
+        length = struct.unpack("!i", body[pos : pos + 4])[0]
+        pos += 4
+        values.append(None if length < 0 else body[pos : pos + length].decode())
+        pos += max(length, 0)
+    return values
+
+
+def _errors(messages) -> list[tuple[str, str]]:
+    errors = []
+    for kind, body in messages:
+        if kind == b"E":
+            fields = {part[:1]: part[1:].decode() for part in body.split(b"\x00") if part}
+            errors.append((fields[b"C"], fields[b"M"]))
+    return errors
+
+
+def _executor(globals_: FakeGlobals, journal_file: str) -> MagicMock:
+    executor = MagicMock()
+    executor.iris_config = {"host": "iris", "port": 1972, "namespace": "USER"}
+
+    async def global_operation(operation):
+        return operation(globals_)
+
+    async def journal_position():
+        return journal_file, 0x800
+
+    executor.global_operation = global_operation
+    executor.journal_position = journal_position
+    return executor
+
+
+def _session(executor, *messages: bytes, replication="database"):
+    async def run():
+        reader = asyncio.StreamReader()
+        reader.feed_data(
+            startup_message(user="debezium", database="USER", replication=replication)
+            + b"".join(messages)
+            + frontend_message(b"X")
+        )
+        reader.feed_eof()
+        writer = FakeWriter()
+        protocol = PGWireProtocol(reader, writer, executor, "slots")
+        await protocol.handle_ssl_probe(None)
+        await protocol.handle_startup_sequence()
+        handshake = len(writer.buffer)
+        try:
+            await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        finally:
+            get_stats().session_ended(protocol.connection_id)
+            get_wal_senders().unregister(protocol.backend_pid)
+            get_active_slots().session_ended(protocol.backend_pid)
+        return backend_messages(writer.buffer[handshake:])
+
+    return asyncio.run(run())
+
+
+def test_stream_resumes_at_confirmed_journal_position():
+    globals_ = FakeGlobals()
+    executor = _executor(globals_, "/usr/irissys/mgr/journal/20261016.001")
+    created = _session(executor, query_message("CREATE_REPLICATION_SLOT debezium LOGICAL pgoutput"))
+    consistent_point = parse_lsn(_data_row(created[1][1])[1])
+
+    # The subscriber flushes changes up to a later journal position, then the bridge restarts
+    confirmed = consistent_point + 0x100
+    _session(
+        executor,
+        query_message("START_REPLICATION SLOT debezium LOGICAL 0/0"),
+        _standby_status(confirmed),
+        frontend_message(b"c"),
+    )
+    resumed = _session(
+        executor,
+        query_message("START_REPLICATION SLOT debezium LOGICAL 0/0"),
+        frontend_message(b"d", b"r" + struct.pack("!QQQqB", 0, 0, 0, pg_timestamp(), 1)),
+        frontend_message(b"c"),
+    )
+
+    assert _data_row(created[1][1])[0::2] == ["debezium", None]
+    assert consistent_point == journal_lsn("20261016.001", 0x800)
+    assert SlotStore(globals_).get("debezium").confirmed_flush_lsn == confirmed
+    assert "TCOMMIT" in globals_.transactions
+    keepalive = next(body for kind, body in resumed if kind == b"d" and body[:1] == b"k")
+    assert struct.unpack("!Q", keepalive[1:9])[0] == confirmed
+
+
+def test_slot_commands_in_session():
+    globals_ = FakeGlobals()
+    executor = _executor(globals_, "/usr/irissys/mgr/journal/20261016.001")
+
+    messages = _session(
+        executor,
+        query_message("CREATE_REPLICATION_SLOT standby PHYSICAL"),
+        query_message("READ_REPLICATION_SLOT standby"),
+        query_message("READ_REPLICATION_SLOT missing"),
+        query_message("CREATE_REPLICATION_SLOT standby PHYSICAL"),
+        query_message("START_REPLICATION SLOT standby LOGICAL 0/0"),
+        query_message("DROP_REPLICATION_SLOT standby"),
+        query_message("DROP_REPLICATION_SLOT standby"),
+    )
+
+    rows = [_data_row(body) for kind, body in messages if kind == b"D"]
+    assert rows[1] == ["physical", "13C7F71/800", "1"]
+    assert rows[2] == [None, None, None]
+    assert _errors(messages) == [
+        ("42710", 'replication slot "standby" already exists'),
+        ("55000", "cannot use physical replication slot for logical decoding"),
+        ("42704", 'replication slot "standby" does not exist'),
+    ]
+    assert globals_.root == {}
+
+
+def test_slot_in_use_by_another_connection():
+    globals_ = FakeGlobals()
+    SlotStore(globals_).create(_slot())
+    get_active_slots().acquire("debezium", pid=-1)
+    try:
+        messages = _session(
+            _executor(globals_, ""), query_message("START_REPLICATION SLOT debezium LOGICAL 0/0")
+        )
+    finally:
+        get_active_slots().release("debezium", pid=-1)
+
+    assert _errors(messages) == [("55006", 'replication slot "debezium" is active for PID -1')]
@@ -0,0 +1,193 @@
+"""
+Unit Tests: Replication Streams
+
+START_REPLICATION parsing, keepalives on quiet CopyBoth streams, standby
+status updates and the walsender timeout.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.replication import WalSenderProgress, get_wal_senders
+from iris_pgwire.replication_slots import get_active_slots
+from iris_pgwire.replication_stream import (
+    ClientTerminated,
+    InvalidStartReplication,
+    ReplicationStream,
+    StartReplication,
+    WalSenderTimeout,
+    parse_start_replication,
+    pg_timestamp,
+)
+from iris_pgwire.stats_hooks import get_stats
+from tests.protocol_messages import FakeWriter, frontend_message, startup_message
+
+
+def _standby_status(position: int, reply: bool = False) -> bytes:
+    return b"r" + struct.pack("!QQQqB", position, position, position, pg_timestamp(), reply)
+
+
+def _keepalives(written: list[bytes]) -> list[tuple[int, bool]]:
+    keepalives = []
+    for data in written:
+        if data[:1] == b"d" and data[5:6] == b"k":
+            wal_end, _, reply = struct.unpack("!QqB", data[6:23])
+            keepalives.append((wal_end, bool(reply)))
+    return keepalives
+
+
+def _append(written):
+    async def write(data):
+        written.append(data)
+
+    return write
+
+
+async def _stream(client_messages, keepalive_interval=0.01, timeout=0):
+    """Run a stream; client_messages are (delay, type, body) sent in order."""
+    queue = asyncio.Queue()
+    written = []
+    progress = WalSenderProgress()
+
+    async def client():
+        for delay, message_type, body in client_messages:
+            await asyncio.sleep(delay)
+            await queue.put((message_type, body))
+
+    feeder = asyncio.ensure_future(client())
+    stream = ReplicationStream(progress, queue.get, _append(written), keepalive_interval, timeout)
+    try:
+        await stream.run(StartReplication("debezium", True, 0, options={"proto_version": "1"}))
+    finally:
+        feeder.cancel()
+    return progress, written
+
+
+def test_parse_logical():
+    start = parse_start_replication(
+        'START_REPLICATION SLOT "Debezium" LOGICAL 16/B374D848 '
+        "(\"proto_version\" '1', publication_names 'dbz_publication', binary)"
+    )
+
+    assert (start.slot_name, start.logical, start.start_lsn) == ("Debezium", True, 0x16_B374D848)
+    assert start.options == {
+        "proto_version": "1",
+        "publication_names": "dbz_publication",
+        "binary": None,
+    }
+
+
+def test_parse_physical():
+    start = parse_start_replication("START_REPLICATION 0/0 TIMELINE 1")
+
+    assert (start.slot_name, start.logical, start.timeline) == (None, False, 1)
+
+
+@pytest.mark.parametrize(
+    "sql",
+    ["START_REPLICATION LOGICAL 0/0", "START_REPLICATION SLOT s LOGICAL", "START_REPLICATION"],
+)
+def test_parse_invalid(sql):
+    with pytest.raises(InvalidStartReplication):
+        parse_start_replication(sql)
+
+
+def test_keepalives_while_quiet():
+    progress, written = asyncio.run(_stream([(0.05, b"c", b"")]))
+
+    assert written[0][:1] == b"W" and written[-1] == frontend_message(b"c")
+    keepalives = _keepalives(written)
+    assert len(keepalives) >= 2
+    assert not any(reply for _, reply in keepalives)
+    assert progress.sent_lsn == keepalives[-1][0]
+    assert (progress.state, progress.slot_name) == ("startup", None)
+
+
+def test_standby_status_recorded_and_answered():
+    messages = [
+        (0, b"d", _standby_status(0x1000, reply=True)),
+        (0, b"d", b"h" + bytes(16)),
+        (0, b"c", b""),
+    ]
+    progress, written = asyncio.run(_stream(messages, keepalive_interval=60))
+
+    assert (progress.write_lsn, progress.flush_lsn, progress.replay_lsn) == (0x1000,) * 3
+    assert len(_keepalives(written)) == 1
+
+
+def test_reply_requested_then_timeout():
+    with pytest.raises(WalSenderTimeout):
+        asyncio.run(_stream([(60, b"c", b"")], keepalive_interval=60, timeout=0.05))
+
+
+def test_reply_requested_before_timeout():
+    written = []
+
+    async def run():
+        try:
+            await asyncio.wait_for(
+                ReplicationStream(
+                    WalSenderProgress(), asyncio.Queue().get, _append(written), 60, 0.2
+                ).run(StartReplication(None, False, 0)),
+                timeout=0.15,
+            )
+        except asyncio.TimeoutError:
+            pass
+
+    asyncio.run(run())
+
+    assert [reply for _, reply in _keepalives(written)] == [True]
+
+
+def test_terminate_during_stream():
+    with pytest.raises(ClientTerminated):
+        asyncio.run(_stream([(0, b"X", b"")]))
+
+
+def _backend_message_types(buffer: bytes) -> list[bytes]:
+    types, pos = [], 0
+    while pos < len(buffer):
+        length = struct.unpack("!I", buffer[pos + 1 : pos + 5])[0]
+        types.append(buffer[pos : pos + 1])
+        pos += 1 + length
+    return types
+
+
+def test_start_replication_session():
+    async def session():
+        reader = asyncio.StreamReader()
+        create = b"CREATE_REPLICATION_SLOT debezium TEMPORARY LOGICAL pgoutput\x00"
+        sql = b"START_REPLICATION SLOT debezium LOGICAL 0/0\x00"
+        reader.feed_data(
+            startup_message(user="debezium", database="USER", replication="database")
+            + frontend_message(b"Q", create)
+            + frontend_message(b"Q", sql)
+            + frontend_message(b"d", _standby_status(0x2000))
+            + frontend_message(b"c")
+            + frontend_message(b"X")
+        )
+        reader.feed_eof()
+        writer = FakeWriter()
+        executor = MagicMock()
+        executor.global_operation = AsyncMock(return_value=None)  # No stored slots
+        protocol = PGWireProtocol(reader, writer, executor, "streaming")
+        await protocol.handle_ssl_probe(None)
+        await protocol.handle_startup_sequence()
+        handshake = len(writer.buffer)
+        try:
+            await asyncio.wait_for(protocol.message_loop(), timeout=5)
+        finally:
+            get_stats().session_ended(protocol.connection_id)
+            get_wal_senders().unregister(protocol.backend_pid)
+            get_active_slots().session_ended(protocol.backend_pid)
+        return protocol, writer.buffer[handshake:]
+
+    protocol, buffer = asyncio.run(session())
+
+    assert _backend_message_types(buffer) == [b"T", b"D", b"C", b"Z", b"W", b"c", b"C", b"Z"]
+    assert b"START_REPLICATION\x00" in buffer
+    assert protocol.wal_sender.flush_lsn == 0x2000
@@ -0,0 +1,284 @@
+"""
+Unit Tests: Per-Role Session Defaults
+
+Role/database defaults, init statements and read-only sessions.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+import iris_pgwire.role_settings as role_settings_module
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.read_only import (
+    ReadOnlyTransaction,
+    check_read_only,
+    session_read_only,
+    write_command,
+)
+from iris_pgwire.role_settings import (
+    AlterSetting,
+    RoleSettings,
+    RoleSettingsEntry,
+    RoleSettingsNotPersistent,
+    parse_alter_setting,
+)
+from iris_pgwire.session_settings import SessionSettings
+from tests.protocol_messages import FakeWriter, startup_message
+
+ROLE_SETTINGS_YAML = """
+- settings:
+    statement_timeout: 60000
+- role: bi_reader
+  settings:
+    default_transaction_read_only: on
+    search_path: [reporting, public]
+- role: BI_READER
+  database: analytics
+  settings:
+    statement_timeout: 5000
+  init_sql: SET iris.workload = dashboard
+- database: analytics
+  settings:
+    search_path: analytics
+"""
+
+
+async def _handshake(role_settings, executor=None, **params):
+    role_settings_module._role_settings = role_settings
+    reader = asyncio.StreamReader()
+    reader.feed_data(startup_message(**params))
+    reader.feed_eof()
+    writer = FakeWriter()
+    protocol = PGWireProtocol(reader, writer, executor or MagicMock(), "roles")
+    try:
+        await protocol.handle_ssl_probe(None)
+        await protocol.handle_startup_sequence()
+        return protocol, writer, session_read_only()
+    except ConnectionAbortedError:
+        return protocol, writer, None
+    finally:
+        role_settings_module._role_settings = None
+
+
+class TestRoleSettings:
+    @pytest.fixture
+    def role_settings(self, tmp_path):
+        path = tmp_path / "roles.yaml"
+        path.write_text(ROLE_SETTINGS_YAML)
+        return RoleSettings.from_file(path)
+
+    def test_most_specific_entry_wins(self, role_settings):
+        settings, init_sql = role_settings.resolve("bi_reader", "ANALYTICS")
+
+        assert settings == {
+            "statement_timeout": "5000",
+            "search_path": "reporting, public",
+            "default_transaction_read_only": "on",
+        }
+        assert init_sql == ["SET iris.workload = dashboard"]
+
+    def test_database_entry_below_role_entry(self, role_settings):
+        assert role_settings.resolve("alice", "analytics") == (
+            {"statement_timeout": "60000", "search_path": "analytics"},
+            [],
+        )
+        assert role_settings.resolve(None, None) == ({"statement_timeout": "60000"}, [])
+
+    def test_invalid_entries_rejected(self, tmp_path):
+        path = tmp_path / "roles.yaml"
+        path.write_text("- role: bi\n  settings:\n    bytea_output: base64\n")
+        with pytest.raises(ValueError, match="entry 1"):
+            RoleSettings.from_file(path)
+
+        path.write_text("- role: bi\n  search_path: reporting\n")
+        with pytest.raises(ValueError, match="unknown keys"):
+            RoleSettings.from_file(path)
+
+
+class TestReadOnly:
+    @pytest.mark.parametrize(
+        "sql,command",
+        [
+            ("SELECT * FROM sales", None),
+            ("/* dashboard */ insert into t values (1)", "INSERT"),
+            ("CREATE TABLE t (id INT)", "CREATE"),
+            ("WITH moved AS (DELETE FROM t RETURNING *) SELECT * FROM moved", "DELETE"),
+            ("WITH s AS (SELECT 'update' AS op) SELECT * FROM s", None),
+            ("SELECT * INTO backup FROM t", "SELECT INTO"),
+            ("COPY t FROM STDIN", "COPY FROM"),
+            ("COPY t TO STDOUT", None),
+            ("EXPLAIN SELECT 1", None),
+        ],
+    )
+    def test_write_command(self, sql, command):
+        assert write_command(sql) == command
+
+    def test_setting_marks_session_read_only(self):
+        async def scenario():
+            settings = SessionSettings()
+            settings.set("default_transaction_read_only", "true")
+            check_read_only("SELECT 1")
+            with pytest.raises(ReadOnlyTransaction, match="cannot execute UPDATE") as exc_info:
+                check_read_only("UPDATE t SET x = 1")
+            assert exc_info.value.sqlstate == "25006"
+
+            settings.set("default_transaction_read_only", "off")
+            check_read_only("UPDATE t SET x = 1")
+
+        asyncio.run(scenario())
+        assert not session_read_only()
+
+
+class TestSessionStart:
+    ROLE_SETTINGS = RoleSettings(
+        [
+            RoleSettingsEntry(
+                role="bi_reader",
+                settings={"default_transaction_read_only": "on", "search_path": "reporting"},
+                init_sql=["SET application_name = 'bi'", "CALL Reporting.Warmup()"],
+            )
+        ]
+    )
+
+    def test_defaults_and_init_sql_applied(self):
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(return_value={"success": True})
+
+        protocol, writer, read_only = asyncio.run(
+            _handshake(self.ROLE_SETTINGS, executor, user="bi_reader")
+        )
+
+        assert read_only
+        assert protocol.session_settings.get("search_path") == "reporting"
+        assert b"default_transaction_read_only\x00on\x00" in writer.buffer
+        assert b"application_name\x00bi\x00" in writer.buffer
+        executor.execute_query.assert_awaited_once_with("CALL Reporting.Warmup()")
+
+        # RESET returns to the role default, not the built-in one
+        protocol.session_settings.set("search_path", "public")
+        protocol.session_settings.reset("search_path")
+        assert protocol.session_settings.get("search_path") == "reporting"
+
+    def test_startup_parameters_override_role_defaults(self):
+        protocol, _, read_only = asyncio.run(
+            _handshake(
+                RoleSettings([RoleSettingsEntry(settings={"search_path": "reporting"})]),
+                user="alice",
+                search_path="sales",
+            )
+        )
+
+        assert protocol.session_settings.get("search_path") == "sales"
+        assert not read_only
+
+    def test_failed_init_statement_closes_session(self):
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(
+            return_value={"success": False, "error": "Class 'Reporting' does not exist"}
+        )
+
+        _, writer, read_only = asyncio.run(
+            _handshake(self.ROLE_SETTINGS, executor, user="bi_reader")
+        )
+
+        assert read_only is None
+        assert b"SFATAL\x00" in writer.buffer
+        assert b"Class 'Reporting' does not exist" in writer.buffer
+        assert not writer.buffer.endswith(b"Z\x00\x00\x00\x05I")
+
+
+class TestAlterSetting:
+    @pytest.mark.parametrize(
+        "sql,expected",
+        [
+            (
+                "ALTER ROLE bi_reader SET default_transaction_read_only = on",
+                AlterSetting("ALTER ROLE", "bi_reader", None, "default_transaction_read_only", "on"),
+            ),
+            (
+                'alter user "BI" in database analytics set search_path to reporting, public;',
+                AlterSetting("ALTER ROLE", "BI", "analytics", "search_path", "reporting, public"),
+            ),
+            (
+                "ALTER ROLE ALL SET statement_timeout = DEFAULT",
+                AlterSetting("ALTER ROLE", None, None, "statement_timeout"),
+            ),
+            (
+                "ALTER DATABASE analytics RESET ALL",
+                AlterSetting("ALTER DATABASE", None, "analytics", None),
+            ),
+            (
+                "ALTER ROLE CURRENT_USER SET TimeZone FROM CURRENT",
+                AlterSetting("ALTER ROLE", "CURRENT_USER", None, "TimeZone", from_current=True),
+            ),
+            ("ALTER ROLE bi_reader WITH PASSWORD 'x'", None),
+            ("ALTER TABLE t SET SCHEMA reporting", None),
+        ],
+    )
+    def test_parse(self, sql, expected):
+        assert parse_alter_setting(sql) == expected
+
+    def test_changes_persisted(self, tmp_path):
+        path = tmp_path / "roles.yaml"
+        role_settings = RoleSettings(path=path)
+
+        role_settings.alter("bi_reader", None, "default_transaction_read_only", "true")
+        role_settings.alter("bi_reader", None, "search_path", "reporting")
+        role_settings.alter(None, "analytics", "statement_timeout", "5000")
+        role_settings.alter("BI_READER", None, "search_path", None)
+
+        reloaded = RoleSettings.from_file(path)
+        assert reloaded.resolve("bi_reader", "analytics") == (
+            {"statement_timeout": "5000", "default_transaction_read_only": "on"},
+            [],
+        )
+
+        reloaded.alter(None, "analytics", None, None)
+        assert [entry.database for entry in RoleSettings.from_file(path).entries] == [None]
+
+    def test_requires_settings_file(self):
+        with pytest.raises(RoleSettingsNotPersistent):
+            RoleSettings().alter("bi_reader", None, "search_path", "reporting")
+
+
+class TestAlterSettingCommand:
+    @staticmethod
+    async def _run(sql, user, role_settings):
+        role_settings_module._role_settings = role_settings
+        writer = FakeWriter()
+        protocol = PGWireProtocol(asyncio.StreamReader(), writer, MagicMock(), "alter")
+        protocol.startup_params = {"user": user}
+        protocol.session_settings.set("TimeZone", "Europe/Berlin")
+        protocol.session_settings.take_pending_reports()
+        try:
+            await protocol._handle_single_statement(sql)
+        finally:
+            role_settings_module._role_settings = None
+        return writer.buffer
+
+    def test_admin_changes_defaults(self, tmp_path, monkeypatch):
+        monkeypatch.setenv("PGWIRE_ROLE_SETTINGS_ADMINS", "dba, _SYSTEM")
+        role_settings = RoleSettings(path=tmp_path / "roles.yaml")
+
+        buffer = asyncio.run(
+            self._run("ALTER USER current_user SET TimeZone FROM CURRENT", "_system", role_settings)
+        )
+
+        assert b"ALTER ROLE\x00Z" in buffer
+        assert role_settings.resolve("_SYSTEM", None) == ({"timezone": "Europe/Berlin"}, [])
+
+    def test_other_users_rejected(self, tmp_path, monkeypatch):
+        monkeypatch.setenv("PGWIRE_ROLE_SETTINGS_ADMINS", "dba")
+        role_settings = RoleSettings(path=tmp_path / "roles.yaml")
+
+        buffer = asyncio.run(
+            self._run(
+                "ALTER ROLE bi_reader RESET default_transaction_read_only", "bi_reader", role_settings
+            )
+        )
+
+        assert b"C42501\x00" in buffer
+        assert buffer.endswith(b"Z\x00\x00\x00\x05I")
+        assert not (tmp_path / "roles.yaml").exists()
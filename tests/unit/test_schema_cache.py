@@ -0,0 +1,176 @@
+"""
+Unit Tests: Schema Cache
+
+Dictionary listings behind catalog emulation cached per IRIS namespace and
+login, invalidated by the %Dictionary change stamp and by DDL run through
+the bridge.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.catalog.schema_cache as schema_cache_module
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.catalog.schema_cache import STAMP_SQL, SchemaCache, changes_schema
+from iris_pgwire.catalog.system_views import TABLES_SQL
+from iris_pgwire.iris_executor import IRISExecutor
+
+LISTING = "SELECT TABLE_SCHEMA, TABLE_NAME FROM INFORMATION_SCHEMA.TABLES"
+
+
+class FakeIRIS:
+    """Answers the stamp query with stamp and listings with tables, recording both."""
+
+    def __init__(self):
+        self.stamp = [3, "2026-10-01 09:00:00"]
+        self.tables = [["SQLUser", "Orders"]]
+        self.executed = []
+
+    async def run(self, sql):
+        self.executed.append(sql)
+        if sql == STAMP_SQL:
+            if self.stamp is None:
+                return {"success": False, "error": "Access denied to %Dictionary"}
+            return {"success": True, "rows": [list(self.stamp)]}
+        return {"success": True, "rows": [list(row) for row in self.tables]}
+
+
+def _listing(cache, iris, namespace="USER", login=None):
+    return asyncio.run(cache.listing(namespace, login, LISTING, iris.run))["rows"]
+
+
+@pytest.fixture
+def iris():
+    return FakeIRIS()
+
+
+def test_unchanged_dictionary_served_from_cache(iris):
+    cache = SchemaCache(enabled=True, check_interval=0)
+
+    first = _listing(cache, iris)
+    second = _listing(cache, iris)
+
+    assert first == [["SQLUser", "Orders"]] and second == [("SQLUser", "Orders")]
+    assert iris.executed == [STAMP_SQL, LISTING, STAMP_SQL]
+    assert (cache.hits, cache.misses) == (1, 1)
+
+
+def test_changed_stamp_reloads(iris):
+    cache = SchemaCache(enabled=True, check_interval=0)
+    _listing(cache, iris)
+
+    iris.stamp = [4, "2026-10-01 09:05:00"]
+    iris.tables.append(["SQLUser", "Customers"])
+
+    assert len(_listing(cache, iris)) == 2
+
+
+def test_stamp_checked_once_per_interval(iris):
+    cache = SchemaCache(enabled=True, check_interval=60)
+    _listing(cache, iris)
+    iris.stamp = [4, "2026-10-01 09:05:00"]
+
+    _listing(cache, iris)
+
+    assert iris.executed == [STAMP_SQL, LISTING]
+
+
+def test_entries_per_namespace_and_login(iris):
+    cache = SchemaCache(enabled=True, check_interval=60)
+    _listing(cache, iris, "USER")
+    _listing(cache, iris, "user")
+    _listing(cache, iris, "USER", login="alice")
+    _listing(cache, iris, "SAMPLES")
+
+    assert iris.executed.count(LISTING) == 3
+
+
+def test_invalidate_namespace(iris):
+    cache = SchemaCache(enabled=True, check_interval=60)
+    _listing(cache, iris, "USER")
+    _listing(cache, iris, "SAMPLES")
+
+    cache.invalidate("user")
+    _listing(cache, iris, "USER")
+    _listing(cache, iris, "SAMPLES")
+
+    assert iris.executed.count(LISTING) == 3
+
+
+def test_not_cached_without_stamp(iris):
+    iris.stamp = None
+    cache = SchemaCache(enabled=True, check_interval=60)
+
+    _listing(cache, iris)
+    _listing(cache, iris)
+
+    assert iris.executed.count(LISTING) == 2
+
+
+def test_disabled(iris, monkeypatch):
+    monkeypatch.setenv("PGWIRE_SCHEMA_CACHE", "off")
+    cache = SchemaCache()
+
+    _listing(cache, iris)
+
+    assert iris.executed == [LISTING]
+
+
+def test_check_interval_setting(monkeypatch):
+    assert SchemaCache().check_interval == 2.0
+    monkeypatch.setenv("PGWIRE_SCHEMA_CACHE_CHECK_INTERVAL", "0.5")
+    assert SchemaCache().check_interval == 0.5
+    monkeypatch.setenv("PGWIRE_SCHEMA_CACHE_CHECK_INTERVAL", "soon")
+    assert SchemaCache().check_interval == 2.0
+
+
+@pytest.mark.parametrize(
+    "sql, changes",
+    [
+        ("CREATE TABLE t (id INT)", True),
+        ("drop view v", True),
+        ("ALTER TABLE t ADD COLUMN c INT", True),
+        ("GRANT SELECT ON t TO reporting", True),
+        ("REVOKE ALL ON t FROM PUBLIC", True),
+        ("SELECT * FROM pg_tables", False),
+        ("INSERT INTO t VALUES (1)", False),
+    ],
+)
+def test_changes_schema(sql, changes):
+    assert changes_schema(sql) is changes
+
+
+def test_executor_ddl_invalidates(monkeypatch, iris):
+    monkeypatch.setattr(schema_cache_module, "_schema_cache", None)
+    monkeypatch.setenv("PGWIRE_SCHEMA_CACHE_CHECK_INTERVAL", "60")
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.iris_config = {"namespace": "USER"}
+    executor.schema_cache = schema_cache_module.get_schema_cache()
+    executor.shadow = None
+    monkeypatch.setattr(
+        iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+    )
+
+    async def fake_execute(sql, params=None, session_id=None):
+        if sql.startswith("CREATE"):
+            return {"success": True, "rows": [], "command_tag": "CREATE TABLE"}
+        iris.executed.append(sql)
+        if sql == STAMP_SQL:
+            return {"success": True, "rows": [list(iris.stamp)]}
+        if sql == TABLES_SQL:
+            return {"success": True, "rows": [["SQLUser", "Orders", "BASE TABLE", "_SYSTEM"]]}
+        return {"success": True, "rows": []}
+
+    executor._execute_query = fake_execute
+
+    async def session():
+        await executor._execute_system_view_query("SELECT * FROM pg_views", "pg_views")
+        await executor._execute_system_view_query("SELECT * FROM pg_tables", "pg_tables")
+        await executor.execute_query("CREATE TABLE Customers (id INT)")
+        await executor._execute_system_view_query("SELECT * FROM pg_tables", "pg_tables")
+
+    asyncio.run(session())
+
+    assert iris.executed.count(TABLES_SQL) == 2
+    assert iris.executed.count(STAMP_SQL) == 2
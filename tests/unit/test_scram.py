@@ -0,0 +1,286 @@
+"""
+Unit Tests: SCRAM-SHA-256 Authentication
+
+RFC 7677 exchanges against stored verifiers, SCRAM-SHA-256-PLUS channel
+binding, enrollment of users without a verifier, and verifiers outdated by an
+IRIS password change.
+"""
+
+import asyncio
+import base64
+import hashlib
+import hmac
+import struct
+import sys
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.md5_auth import Md5SecretStore, md5_secret
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.scram import (
+    ScramError,
+    ScramExchange,
+    ScramVerifier,
+    ScramVerifierStore,
+    mock_verifier,
+    password_stamp,
+    saslprep,
+    tls_server_end_point,
+)
+from tests.protocol_messages import FakeWriter
+
+# RFC 7677 section 3
+SALT = base64.b64decode("W22ZaJ0SNY7soEsUEjb6gQ==")
+CLIENT_NONCE = "rOprNGfwEbeRWgbNEkqO"
+NONCE = CLIENT_NONCE + "%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+
+
+def _proof(password: str, salt: bytes, auth_message: bytes) -> str:
+    salted = hashlib.pbkdf2_hmac("sha256", password.encode(), salt, 4096)
+    client_key = hmac.new(salted, b"Client Key", hashlib.sha256).digest()
+    signature = hmac.new(hashlib.sha256(client_key).digest(), auth_message, hashlib.sha256)
+    return base64.b64encode(bytes(a ^ b for a, b in zip(client_key, signature.digest()))).decode()
+
+
+def _client_final(exchange: ScramExchange, password: str, gs2: bytes = b"n,,") -> bytes:
+    without_proof = f"c={base64.b64encode(gs2).decode()},r={exchange.nonce}"
+    auth_message = f"{exchange.client_first_bare},{exchange.server_first},{without_proof}"
+    proof = _proof(password, exchange.verifier.salt, auth_message.encode())
+    return f"{without_proof},p={proof}".encode()
+
+
+def test_rfc7677_exchange():
+    exchange = ScramExchange("user", ScramVerifier.from_password("pencil", SALT))
+    exchange.client_first("SCRAM-SHA-256", f"n,,n=user,r={CLIENT_NONCE}".encode())
+    exchange.nonce = NONCE
+    exchange.server_first = f"r={NONCE},s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+
+    server_final = exchange.client_final(
+        b"c=biws,r=" + NONCE.encode() + b",p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+    )
+
+    assert server_final == b"v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+
+
+def test_wrong_password_fails_with_28p01():
+    exchange = ScramExchange("alice", ScramVerifier.from_password("secret"))
+    exchange.client_first("SCRAM-SHA-256", b"n,,n=,r=abc")
+
+    with pytest.raises(ScramError) as error:
+        exchange.client_final(_client_final(exchange, "guess"))
+
+    assert error.value.sqlstate == "28P01"
+    assert str(error.value) == 'password authentication failed for user "alice"'
+
+
+def test_unknown_user_fails_like_a_wrong_password():
+    exchange = ScramExchange("ghost", mock_verifier("ghost"))
+    server_first = exchange.client_first("SCRAM-SHA-256", b"n,,n=,r=abc")
+
+    assert mock_verifier("ghost").salt == exchange.verifier.salt
+    assert b",i=4096" in server_first
+    with pytest.raises(ScramError) as error:
+        exchange.client_final(_client_final(exchange, ""))
+    assert error.value.sqlstate == "28P01"
+
+
+def test_channel_binding():
+    binding = hashlib.sha256(b"certificate").digest()
+    gs2 = b"p=tls-server-end-point,,"
+    exchange = ScramExchange("alice", ScramVerifier.from_password("secret"), binding)
+    assert exchange.mechanisms == ["SCRAM-SHA-256-PLUS", "SCRAM-SHA-256"]
+
+    exchange.client_first("SCRAM-SHA-256-PLUS", gs2 + b"n=,r=abc")
+    assert exchange.client_final(_client_final(exchange, "secret", gs2 + binding))
+
+    exchange.client_first("SCRAM-SHA-256-PLUS", gs2 + b"n=,r=abc")
+    with pytest.raises(ScramError) as error:
+        exchange.client_final(_client_final(exchange, "secret", gs2 + b"\x00" * 32))
+    assert error.value.sqlstate == "28000"
+
+
+@pytest.mark.parametrize(
+    "mechanism, message",
+    [
+        ("SCRAM-SHA-256", b"y,,n=,r=abc"),  # Downgrade: the server offered -PLUS
+        ("SCRAM-SHA-256-PLUS", b"n,,n=,r=abc"),
+        ("SCRAM-SHA-256", b"p=tls-server-end-point,,n=,r=abc"),
+        ("SCRAM-SHA-1", b"n,,n=,r=abc"),
+        ("SCRAM-SHA-256", b"n,,n=,m=ext,r=abc"),
+    ],
+)
+def test_negotiation_errors(mechanism, message):
+    exchange = ScramExchange("alice", ScramVerifier.from_password("secret"), b"\x01" * 32)
+
+    with pytest.raises(ScramError) as error:
+        exchange.client_first(mechanism, message)
+
+    assert error.value.sqlstate == "08P01"
+
+
+def test_verifier_round_trip():
+    verifier = ScramVerifier.from_password("secret", SALT)
+
+    text = str(verifier)
+    assert text.startswith("SCRAM-SHA-256$4096:W22ZaJ0SNY7soEsUEjb6gQ==$")
+    assert ScramVerifier.parse(text) == verifier
+    with pytest.raises(ValueError):
+        ScramVerifier.parse("md5abcdef")
+
+
+class DictGlobal:
+    """Global accessor over a dict keyed by subscript tuples ($DATA semantics)."""
+
+    def __init__(self):
+        self.nodes = {}
+
+    def data(self, global_name, subscripts):
+        key = (global_name, *subscripts)
+        below = any(node[: len(key)] == key and len(node) > len(key) for node in self.nodes)
+        return int(key in self.nodes) + 10 * below
+
+    def get(self, global_name, subscripts):
+        return self.nodes[(global_name, *subscripts)]
+
+    def set(self, global_name, subscripts, value):
+        self.nodes[(global_name, *subscripts)] = value
+
+    def kill(self, global_name, subscripts):
+        self.nodes.pop((global_name, *subscripts), None)
+
+
+@pytest.mark.parametrize(
+    "store,secret",
+    [
+        (ScramVerifierStore(DictGlobal()), ScramVerifier.from_password("old", SALT)),
+        (Md5SecretStore(DictGlobal()), md5_secret("old", "alice")),
+    ],
+)
+def test_secrets_of_a_changed_iris_password_are_ignored(store, secret):
+    store.set("alice", secret, password_stamp(b"hash-1"))
+
+    assert store.get("alice", password_stamp(b"hash-1")) == secret
+    assert store.get("alice", password_stamp(b"hash-2")) is None
+    assert store.get("alice") is None  # Security.Users unreadable: fails closed
+
+    # Re-enrolled with the new password; provisioned secrets have no stamp
+    store.set("alice", secret, password_stamp(b"hash-2"))
+    assert store.get("alice", password_stamp(b"hash-2")) == secret
+    store.set("alice", secret)
+    assert store.get("alice", password_stamp(b"hash-3")) == secret
+
+
+def test_iris_password_stamp_is_read_once_per_login(monkeypatch):
+    iris = MagicMock()
+    accounts = iris.createIRIS.return_value.classMethodObject
+    accounts.return_value = {"Password": b"hash-1"}
+    monkeypatch.setitem(sys.modules, "iris", iris)
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.thread_pool = None
+    executor._connect_sys = MagicMock()
+
+    async def login():
+        return [await executor.iris_password_stamp("Alice") for _ in range(3)]
+
+    assert asyncio.run(login()) == [password_stamp(b"hash-1")] * 3
+    accounts.assert_called_once_with("Security.Users", "%OpenId", "alice")
+    asyncio.run(login())  # The next connection reads it again
+    assert accounts.call_count == 2
+
+
+def test_saslprep_maps_spaces_and_soft_hyphens():
+    assert saslprep("pass\u00a0word\u00ad") == "pass word"
+    assert saslprep("\u2168") == "IX"  # NFKC
+
+
+def test_tls_server_end_point_follows_signature_hash():
+    sha384_rsa = bytes.fromhex("06092a864886f70d01010c")
+    certificate = b"\x30\x12\x30\x00\x30\x0b" + sha384_rsa + b"\x03\x01\x00"
+
+    assert tls_server_end_point(certificate) == hashlib.sha384(certificate).digest()
+    assert tls_server_end_point(b"\x30\x00") == hashlib.sha256(b"\x30\x00").digest()
+
+
+def _password_message(data: bytes) -> bytes:
+    return b"p" + struct.pack("!I", len(data) + 4) + data
+
+
+def _auth_messages(buffer: bytes) -> list[tuple[int, bytes]]:
+    messages, pos = [], 0
+    while pos < len(buffer):
+        length = struct.unpack("!I", buffer[pos + 1 : pos + 5])[0]
+        body = buffer[pos + 5 : pos + 1 + length]
+        messages.append((struct.unpack("!I", body[:4])[0], body[4:]))
+        pos += 1 + length
+    return messages
+
+
+def _protocol(verifier, ssl_enabled=False):
+    executor = MagicMock()
+    executor.scram_verifier = AsyncMock(return_value=verifier)
+    executor.verify_iris_password = AsyncMock()
+    executor.store_scram_verifier = AsyncMock()
+    protocol = PGWireProtocol(asyncio.StreamReader(), FakeWriter(), executor, "scram", True)
+    protocol.startup_params = {"user": "alice"}
+    protocol.ssl_enabled = ssl_enabled
+    return protocol
+
+
+def test_handshake_authenticates_with_stored_verifier():
+    async def run():
+        protocol = _protocol(ScramVerifier.from_password("secret"))
+        first = b"n,,n=,r=clientnonce"
+        protocol.reader.feed_data(
+            _password_message(b"SCRAM-SHA-256\x00" + struct.pack("!i", len(first)) + first)
+        )
+        assert await protocol.start_scram_authentication()
+        client_final = _client_final(protocol.scram_exchange, "secret")
+        protocol.reader.feed_data(_password_message(client_final))
+        await protocol.handle_scram_client_final()
+        await protocol.complete_scram_authentication()
+        return _auth_messages(protocol.writer.buffer)
+
+    messages = asyncio.run(run())
+
+    assert [kind for kind, _ in messages] == [10, 11, 12, 0]
+    assert messages[0][1] == b"SCRAM-SHA-256\x00\x00"
+    assert messages[2][1].startswith(b"v=")
+
+
+def test_user_without_verifier_enrolls_over_tls(monkeypatch):
+    monkeypatch.delenv("PGWIRE_SCRAM_ENROLL", raising=False)
+
+    async def run():
+        protocol = _protocol(None, ssl_enabled=True)
+        protocol.reader.feed_data(_password_message(b"secret\x00"))
+        assert not await protocol.start_scram_authentication()
+        return protocol
+
+    protocol = asyncio.run(run())
+
+    assert [kind for kind, _ in _auth_messages(protocol.writer.buffer)] == [3, 0]
+    protocol.iris_executor.verify_iris_password.assert_awaited_once_with("alice", "secret")
+    user, verifier = protocol.iris_executor.store_scram_verifier.await_args.args
+    assert user == "alice"
+    assert verifier == ScramVerifier.from_password("secret", verifier.salt)
+
+
+def test_no_enrollment_without_tls(monkeypatch):
+    monkeypatch.delenv("PGWIRE_SCRAM_ENROLL", raising=False)
+
+    async def run():
+        protocol = _protocol(None)
+        first = b"n,,n=,r=clientnonce"
+        protocol.reader.feed_data(
+            _password_message(b"SCRAM-SHA-256\x00" + struct.pack("!i", len(first)) + first)
+        )
+        assert await protocol.start_scram_authentication()
+        return protocol
+
+    protocol = asyncio.run(run())
+
+    protocol.iris_executor.verify_iris_password.assert_not_awaited()
+    with pytest.raises(ScramError):
+        protocol.scram_exchange.client_final(_client_final(protocol.scram_exchange, "secret"))
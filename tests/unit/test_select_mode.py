@@ -0,0 +1,77 @@
+"""
+Unit Tests: IRIS SELECTMODE Emulation and $LISTBUILD Decoding
+
+Covers iris.select_mode validation, per-mode value rendering and declared
+types, and strict $LIST decoding.
+"""
+
+import datetime
+import struct
+from decimal import Decimal
+
+import pytest
+
+from iris_pgwire.iris_list import decode_list
+from iris_pgwire.select_mode import render_value, result_type
+from iris_pgwire.session_settings import InvalidParameterValue, SessionSettings
+
+# PostgreSQL day number (since 2000-01-01) for 2025-11-13, as the executor hands it over
+PG_DAYS_2025_11_13 = 9448
+
+
+class TestDecodeList:
+    def test_strings_and_integers(self):
+        assert decode_list(b"\x03\x01a\x04\x01bc\x03\x04\x05") == ["a", "bc", 5]
+
+    def test_negative_integer_decimal_and_double(self):
+        assert decode_list(b"\x03\x05\xff") == [-1]
+        assert decode_list(b"\x04\x06\xfe\x7b") == [Decimal("1.23")]
+        assert decode_list(b"\x0a\x08" + struct.pack("<d", 1.5)) == [1.5]
+
+    def test_undefined_element_and_unicode(self):
+        assert decode_list(b"\x01\x04\x02\x3b\x04") == [None, "л"]
+
+    def test_extended_length(self):
+        data = b"x" * 300
+        encoded = b"\x00" + (len(data) + 1).to_bytes(2, "little") + b"\x01" + data
+
+        assert decode_list(encoded) == ["x" * 300]
+
+    def test_str_carrier(self):
+        assert decode_list("\x03\x01a\x03\x01b") == ["a", "b"]
+
+    @pytest.mark.parametrize("value", ["hello", "", b"\x05\x01ab", b"\x03\x09a", 42, "л"])
+    def test_non_lists_rejected(self, value):
+        assert decode_list(value) is None
+
+
+class TestSelectMode:
+    def test_setting_validated(self):
+        settings = SessionSettings()
+
+        assert settings.get("iris.select_mode") == "odbc"
+        assert settings.set("IRIS.SELECT_MODE", "Display") == "display"
+        with pytest.raises(InvalidParameterValue):
+            settings.set("iris.select_mode", "external")
+
+    def test_odbc_keeps_types_and_joins_lists(self):
+        assert result_type(1082, "odbc") == 1082
+        assert render_value(True, 16, "odbc") is True
+        assert render_value("\x03\x01a\x03\x04\x05", 25, "odbc") == "a,5"
+
+    def test_logical(self):
+        assert result_type(1082, "logical") == 23
+        assert render_value(PG_DAYS_2025_11_13, 1082, "logical") == 67522
+        assert render_value("2025-11-13", 1082, "logical") == 67522
+        assert render_value(datetime.time(1, 2, 3), 1083, "logical") == 3723
+        assert render_value("t", 16, "logical") == 1
+        assert render_value("\x03\x01a", 25, "logical") == "\x03\x01a"
+
+    def test_display(self):
+        assert result_type(1114, "display") == 25
+        assert render_value(PG_DAYS_2025_11_13, 1082, "display") == "11/13/2025"
+        assert render_value("2025-11-13 08:30:00", 1114, "display") == "11/13/2025 08:30:00"
+        assert render_value(False, 16, "display") == "0"
+
+    def test_unconvertible_value_unchanged(self):
+        assert render_value("not a date", 1082, "display") == "not a date"
@@ -0,0 +1,166 @@
+"""
+Unit Tests: Client-Facing TLS
+
+SSLRequest negotiation and certificates that verify-ca / verify-full
+clients accept.
+"""
+
+import asyncio
+import shutil
+import ssl
+import struct
+import subprocess
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.server_tls import ServerTLSConfig
+
+SSL_REQUEST = struct.pack("!II", 8, 80877103)
+
+
+@pytest.fixture
+def certificates(tmp_path):
+    """A CA and a server certificate it signed for localhost."""
+    if shutil.which("openssl") is None:
+        pytest.skip("openssl not available")
+
+    def openssl(*args):
+        subprocess.run(["openssl", *args], cwd=tmp_path, check=True, capture_output=True)
+
+    openssl(
+        *("req", "-x509", "-newkey", "rsa:2048", "-nodes", "-days", "1"),
+        *("-subj", "/CN=Test CA", "-keyout", "ca.key", "-out", "ca.pem"),
+    )
+    openssl(
+        *("req", "-newkey", "rsa:2048", "-nodes", "-subj", "/CN=localhost"),
+        *("-keyout", "server.key", "-out", "server.csr"),
+    )
+    (tmp_path / "san.ext").write_text("subjectAltName=DNS:localhost\n")
+    openssl(
+        *("x509", "-req", "-in", "server.csr", "-CA", "ca.pem", "-CAkey", "ca.key"),
+        *("-CAcreateserial", "-days", "1", "-extfile", "san.ext", "-out", "server.pem"),
+    )
+    return tmp_path
+
+
+def _handshake(server_context, client_context, server_hostname="localhost", sent_after=b""):
+    async def run():
+        handled = asyncio.Event()
+
+        async def handle(reader, writer):
+            protocol = PGWireProtocol(reader, writer, MagicMock(), "tls")
+            try:
+                await protocol.handle_ssl_probe(server_context)
+                protocol.writer.write(b"ok")
+                await protocol.writer.drain()
+            except ConnectionAbortedError:
+                pass
+            finally:
+                writer.close()
+                handled.set()
+
+        server = await asyncio.start_server(handle, "127.0.0.1", 0)
+        port = server.sockets[0].getsockname()[1]
+        reader, writer = await asyncio.open_connection("127.0.0.1", port)
+        try:
+            writer.write(SSL_REQUEST + sent_after)
+            answer = await reader.readexactly(1)
+            if answer == b"S":
+                await writer.start_tls(client_context, server_hostname=server_hostname)
+                answer += await reader.read(2)
+            elif answer == b"E":
+                answer += await reader.read()
+            return answer
+        finally:
+            writer.close()
+            await asyncio.wait_for(handled.wait(), timeout=5)
+            server.close()
+            await server.wait_closed()
+
+    return asyncio.run(run())
+
+
+def test_verify_full_client_accepts_certificate(certificates):
+    server_context = ServerTLSConfig(
+        cert=str(certificates / "server.pem"), key=str(certificates / "server.key")
+    ).create_context()
+    verify_full = ssl.create_default_context(cafile=str(certificates / "ca.pem"))
+
+    assert _handshake(server_context, verify_full) == b"Sok"
+
+
+def test_verify_full_client_rejects_other_host_name(certificates):
+    server_context = ServerTLSConfig(
+        cert=str(certificates / "server.pem"), key=str(certificates / "server.key")
+    ).create_context()
+    verify_full = ssl.create_default_context(cafile=str(certificates / "ca.pem"))
+
+    with pytest.raises(ssl.SSLCertVerificationError):
+        _handshake(server_context, verify_full, server_hostname="db.example.com")
+
+
+def test_unencrypted_data_after_ssl_request_is_refused(certificates):
+    server_context = ServerTLSConfig(
+        cert=str(certificates / "server.pem"), key=str(certificates / "server.key")
+    ).create_context()
+
+    # Sent in the clear right behind SSLRequest, as a man in the middle would inject it
+    answer = _handshake(server_context, None, sent_after=struct.pack("!II", 8, 196608))
+
+    assert answer.startswith(b"E")
+    assert b"SFATAL\x00" in answer
+    assert b"C08P01\x00" in answer
+    assert b"received unencrypted data after SSL request" in answer
+
+
+def test_ssl_request_refused_without_tls():
+    assert _handshake(None, None) == b"N"
+
+
+def test_missing_certificate_fails_loudly(tmp_path):
+    with pytest.raises(ValueError, match="PGWIRE_SSL_CERT"):
+        ServerTLSConfig().create_context()
+
+    with pytest.raises(ValueError, match="cannot load TLS certificate"):
+        ServerTLSConfig(cert=str(tmp_path / "missing.pem")).create_context()
+
+
+def test_settings_from_environment(monkeypatch):
+    monkeypatch.setenv("PGWIRE_SSL_CERT", "/etc/pgwire/server.pem")
+    monkeypatch.setenv("PGWIRE_SSL_MIN_PROTOCOL_VERSION", "TLSv1.3")
+
+    config = ServerTLSConfig.from_env(key="/etc/pgwire/server.key")
+
+    assert (config.cert, config.key) == ("/etc/pgwire/server.pem", "/etc/pgwire/server.key")
+    assert config.min_protocol_version == "TLSv1.3"
+
+    monkeypatch.setenv("PGWIRE_SSL_MIN_PROTOCOL_VERSION", "SSLv3")
+    with pytest.raises(ValueError, match="PGWIRE_SSL_MIN_PROTOCOL_VERSION"):
+        ServerTLSConfig.from_env()
+
+
+def test_certificate_from_iris_configuration():
+    executor = MagicMock()
+    executor.iris_ssl_config = AsyncMock(
+        return_value={
+            "CertificateFile": "/iris/mgr/pgwire.cer",
+            "PrivateKeyFile": "/iris/mgr/pgwire.key",
+            "PrivateKeyPassword": "",
+            "CAFile": "",
+        }
+    )
+    config = ServerTLSConfig(iris_config="PGWire")
+
+    asyncio.run(config.load_iris_config(executor))
+
+    assert (config.cert, config.key, config.key_password) == (
+        "/iris/mgr/pgwire.cer",
+        "/iris/mgr/pgwire.key",
+        None,
+    )
+
+    executor.iris_ssl_config = AsyncMock(return_value=None)
+    with pytest.raises(ValueError, match="does not exist"):
+        asyncio.run(ServerTLSConfig(iris_config="Missing").load_iris_config(executor))
@@ -0,0 +1,125 @@
+"""
+Unit Tests: Session Labels
+
+IRIS processes of external connections labelled with the pgwire session they
+serve (^PGWire.Sessions(<IRIS pid>)), following SET application_name.
+"""
+
+import contextvars
+import sys
+import types
+
+import pytest
+
+from iris_pgwire.session_labels import (
+    SessionLabel,
+    SessionLabels,
+    current_session_label,
+    set_session_label,
+)
+from iris_pgwire.session_settings import SessionSettings
+
+LABEL = SessionLabel(4242, "10.0.0.7:51234", "app", "psql")
+
+
+class FakeNative:
+    """Records global sets/kills; each connection's IRIS process id is fixed."""
+
+    def __init__(self, connection, calls):
+        self.connection = connection
+        self.calls = calls
+
+    def classMethodValue(self, class_name, method):
+        self.calls.append(("pid", self.connection))
+        return str(self.connection.pid)
+
+    def set(self, value, global_name, *subscripts):
+        self.calls.append(("set", global_name, *subscripts, value))
+
+    def kill(self, global_name, *subscripts):
+        self.calls.append(("kill", global_name, *subscripts))
+
+
+class FakeConnection:
+    def __init__(self, pid):
+        self.pid = pid
+
+
+@pytest.fixture
+def calls(monkeypatch):
+    calls = []
+    iris = types.ModuleType("iris")
+    iris.createIRIS = lambda connection: FakeNative(connection, calls)
+    monkeypatch.setitem(sys.modules, "iris", iris)
+    return calls
+
+
+def test_label_text():
+    assert LABEL.text() == "pid=4242 client=10.0.0.7:51234 user=app application=psql"
+
+
+def test_label_written_once_per_session(calls):
+    labels = SessionLabels(enabled=True)
+    connection = FakeConnection(9001)
+
+    assert labels.apply(connection, LABEL, new=True) is connection
+    labels.apply(connection, LABEL)
+
+    assert calls == [("pid", connection), ("set", "PGWire.Sessions", 9001, LABEL.text())]
+
+
+def test_relabelled_for_another_session(calls, monkeypatch):
+    monkeypatch.setenv("PGWIRE_SESSIONS_GLOBAL", "^Audit.Sessions")
+    labels = SessionLabels(enabled=True)
+    connection = FakeConnection(9001)
+    other = SessionLabel(4243, "10.0.0.8:40000", "etl", "")
+
+    labels.apply(connection, LABEL, new=True)
+    labels.apply(connection, other)
+
+    assert calls[-1] == ("set", "Audit.Sessions", 9001, other.text())
+    assert [call[0] for call in calls].count("pid") == 1
+
+
+def test_forget_removes_label(calls):
+    labels = SessionLabels(enabled=True)
+    connection = FakeConnection(9001)
+    labels.apply(connection, LABEL, new=True)
+
+    labels.forget(connection)
+    labels.forget(connection)
+
+    assert calls[-1] == ("kill", "PGWire.Sessions", 9001)
+    assert [call[0] for call in calls].count("kill") == 1
+
+
+def test_disabled_or_unlabelled(calls, monkeypatch):
+    monkeypatch.setenv("PGWIRE_SESSION_LABELS", "off")
+    connection = FakeConnection(9001)
+
+    SessionLabels().apply(connection, LABEL, new=True)
+    SessionLabels(enabled=True).apply(connection, None, new=True)
+
+    assert calls == []
+
+
+def test_failures_leave_connection_usable(monkeypatch):
+    iris = types.ModuleType("iris")
+    iris.createIRIS = lambda connection: (_ for _ in ()).throw(RuntimeError("no native API"))
+    monkeypatch.setitem(sys.modules, "iris", iris)
+    connection = FakeConnection(9001)
+
+    assert SessionLabels(enabled=True).apply(connection, LABEL, new=True) is connection
+
+
+def test_set_application_name_follows_into_label():
+    def session():
+        set_session_label(SessionLabel(4242, "10.0.0.7:51234", "app", ""))
+        settings = SessionSettings()
+        settings.set("application_name", "pg_dump")
+        return current_session_label()
+
+    label = contextvars.copy_context().run(session)
+
+    assert label.application == "pg_dump"
+    assert current_session_label() is None
@@ -0,0 +1,223 @@
+"""
+Unit Tests: Session Settings (SET/RESET/SHOW state)
+
+Covers GUC validation, RESET semantics, SET LOCAL and ParameterStatus change
+reporting.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.protocol import STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.session_settings import (
+    InvalidParameterValue,
+    SessionSettings,
+    UnrecognizedParameter,
+    evaluate_setting_functions,
+    strip_setting_value,
+)
+
+
+class TestSessionSettings:
+    """Per-session GUC store behavior."""
+
+    def test_defaults(self):
+        settings = SessionSettings()
+
+        assert settings.get("bytea_output") == "hex"
+        assert settings.get("TIMEZONE") == "UTC"
+        assert settings.get("lc_monetary") == "C"
+
+    def test_enumerated_value_normalized(self):
+        settings = SessionSettings()
+
+        assert settings.set("BYTEA_OUTPUT", "Escape") == "escape"
+        assert settings.get("bytea_output") == "escape"
+
+    def test_invalid_enumerated_value_rejected(self):
+        settings = SessionSettings()
+
+        with pytest.raises(InvalidParameterValue) as exc_info:
+            settings.set("bytea_output", "base64")
+
+        assert exc_info.value.sqlstate == "22023"
+        assert settings.get("bytea_output") == "hex"
+
+    def test_invalid_locale_rejected(self):
+        settings = SessionSettings()
+
+        assert settings.set("lc_monetary", "de_DE.UTF-8") == "de_DE.UTF-8"
+        with pytest.raises(InvalidParameterValue):
+            settings.set("lc_numeric", "not a locale")
+
+    def test_unknown_parameters_accepted(self):
+        settings = SessionSettings()
+
+        settings.set("myapp.tenant", "42")
+        assert settings.get("MYAPP.TENANT") == "42"
+
+        settings.reset("myapp.tenant")
+        assert settings.get("myapp.tenant") is None
+
+    def test_reset_all_restores_defaults(self):
+        settings = SessionSettings()
+        settings.set("bytea_output", "escape")
+        settings.set("TimeZone", "Europe/Berlin")
+
+        settings.reset_all()
+
+        assert settings.get("bytea_output") == "hex"
+        assert settings.get("timezone") == "UTC"
+
+    def test_reportable_changes_queued(self):
+        settings = SessionSettings()
+        settings.set("timezone", "Europe/Berlin")
+        settings.set("bytea_output", "escape")  # Not GUC_REPORT
+
+        assert settings.take_pending_reports() == {"TimeZone": "Europe/Berlin"}
+        assert settings.take_pending_reports() == {}
+
+    def test_unchanged_value_not_reported(self):
+        settings = SessionSettings()
+        settings.set("TimeZone", "UTC")
+
+        assert settings.take_pending_reports() == {}
+
+
+class TestSetLocal:
+    """SET LOCAL values end with the transaction."""
+
+    def test_local_value_restored_at_transaction_end(self):
+        settings = SessionSettings()
+        settings.set("bytea_output", "escape")
+        settings.set_local("bytea_output", "hex")
+        settings.set_local("bytea_output", "escape")
+        settings.set_local("myapp.tenant", "42")
+
+        settings.end_transaction()
+
+        assert settings.get("bytea_output") == "escape"
+        assert settings.get("myapp.tenant") is None
+        assert settings.local_restore == {}
+
+    def test_session_set_after_local_is_kept(self):
+        settings = SessionSettings()
+        settings.set_local("TimeZone", "Europe/Berlin")
+        settings.set("TimeZone", "Asia/Tokyo")
+
+        settings.end_transaction()
+
+        assert settings.get("timezone") == "Asia/Tokyo"
+
+    def test_local_reset(self):
+        settings = SessionSettings()
+        settings.set("bytea_output", "escape")
+        settings.reset_local("bytea_output")
+        assert settings.get("bytea_output") == "hex"
+
+        settings.end_transaction()
+
+        assert settings.get("bytea_output") == "escape"
+
+    @staticmethod
+    def _protocol():
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), MagicMock(), "set-local")
+        protocol.writer.drain = AsyncMock()
+        protocol.send_notice_response = AsyncMock()
+        return protocol
+
+    def test_protocol_local_value_ends_with_transaction(self):
+        protocol = self._protocol()
+        protocol.transaction_status = STATUS_IN_TRANSACTION
+
+        asyncio.run(protocol.handle_set_command("SET LOCAL bytea_output = escape"))
+        assert protocol.session_settings.get("bytea_output") == "escape"
+        asyncio.run(protocol.end_transaction(committed=True))
+
+        assert protocol.session_settings.get("bytea_output") == "hex"
+        protocol.send_notice_response.assert_not_called()
+
+    def test_protocol_local_outside_transaction_warns_without_effect(self):
+        protocol = self._protocol()
+
+        asyncio.run(protocol.handle_set_command("SET LOCAL bytea_output = escape"))
+
+        assert protocol.session_settings.get("bytea_output") == "hex"
+        protocol.send_notice_response.assert_awaited_once_with(
+            "WARNING", "25P01", "SET LOCAL can only be used in transaction blocks"
+        )
+
+
+    def test_local_set_config_outside_transaction_ends_with_statement(self):
+        protocol = self._protocol()
+        settings = protocol.session_settings
+
+        evaluate_setting_functions("SELECT set_config('bytea_output', 'escape', true)", None, settings)
+        asyncio.run(protocol.send_ready_for_query())
+
+        assert settings.get("bytea_output") == "hex"
+
+class TestSettingFunctions:
+    """current_setting() and set_config() on the session's values."""
+
+    def test_current_setting_reads_session_values(self):
+        settings = SessionSettings()
+        settings.set("bytea_output", "escape")
+
+        sql = "SELECT current_setting('bytea_output'), current_setting('x.y', true) AS y"
+        assert evaluate_setting_functions(sql, None, settings) == [
+            ("current_setting", "escape"),
+            ("y", None),
+        ]
+        with pytest.raises(UnrecognizedParameter, match='"x.y"'):
+            evaluate_setting_functions("SELECT current_setting('x.y')", None, settings)
+        assert evaluate_setting_functions("SELECT current_setting('a') FROM t", None, settings) is (
+            None
+        )
+
+    def test_set_config_changes_session_values(self):
+        settings = SessionSettings()
+        sql = "SELECT CURRENT_SETTING('jit') AS CUR, SET_CONFIG('jit', $1, FALSE) AS NEW"
+
+        assert evaluate_setting_functions(sql, ["on"], settings) == [("cur", "off"), ("new", "on")]
+        assert settings.get("jit") == "on"
+        with pytest.raises(InvalidParameterValue):
+            evaluate_setting_functions("SELECT set_config('jit', 'x', false)", None, settings)
+
+        evaluate_setting_functions("SELECT set_config('jit', 'off', true)", None, settings)
+        settings.end_transaction()
+        assert settings.get("jit") == "on"
+
+    def test_executor_answers_from_the_connection_settings(self):
+        protocol = TestSetLocal._protocol()
+        protocol.session_settings.set("TimeZone", "Europe/Paris")
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.global_tables = MagicMock(**{"references_virtual_table.return_value": False})
+        executor.system_functions = MagicMock(**{"references_system_function.return_value": False})
+        executor.privilege_functions = MagicMock(
+            **{"references_privilege_function.return_value": False}
+        )
+
+        result = asyncio.run(executor._execute_query("SELECT current_setting('TimeZone')"))
+
+        assert result["rows"] == [["Europe/Paris"]]
+
+
+class TestStripSettingValue:
+    """SET value literal handling."""
+
+    @pytest.mark.parametrize(
+        "raw,expected",
+        [
+            ("'escape'", "escape"),
+            ('"UTC"', "UTC"),
+            ("hex;", "hex"),
+            ("'it''s'", "it's"),
+            ("public, sales", "public, sales"),
+        ],
+    )
+    def test_strip(self, raw, expected):
+        assert strip_setting_value(raw) == expected
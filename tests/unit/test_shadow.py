@@ -0,0 +1,132 @@
+"""
+Unit Tests: Shadow Comparison
+
+Placeholder rewriting, value normalization and IRIS vs PostgreSQL diffing.
+"""
+
+import datetime
+from decimal import Decimal
+
+from iris_pgwire.shadow import (
+    ShadowComparator,
+    ShadowOutcome,
+    compare_outcomes,
+    normalize_value,
+    outcome_from_result,
+    to_pyformat,
+)
+
+
+class FakePgError(Exception):
+    def __init__(self, pgcode):
+        super().__init__(pgcode)
+        self.pgcode = pgcode
+
+
+class FakeCursor:
+    def __init__(self, connection):
+        self.connection = connection
+        self.description = None
+        self.rowcount = -1
+
+    def execute(self, sql, params):
+        self.connection.executed.append((sql, params))
+        outcome = self.connection.outcome
+        if isinstance(outcome, Exception):
+            raise outcome
+        self.description = [("x",)]
+        self._rows = outcome
+
+    def fetchall(self):
+        return self._rows
+
+    def close(self):
+        pass
+
+
+class FakeConnection:
+    def __init__(self, outcome):
+        self.outcome = outcome
+        self.executed = []
+        self.autocommit = False
+
+    def cursor(self):
+        return FakeCursor(self)
+
+
+def _comparator(outcome):
+    connection = FakeConnection(outcome)
+    return ShadowComparator("dbname=shadow", connect=lambda dsn: connection), connection
+
+
+def _iris(rows, type_oid=23):
+    return {
+        "success": True,
+        "rows": rows,
+        "columns": [{"name": "x", "type_oid": type_oid}],
+        "row_count": len(rows),
+    }
+
+
+class TestPlaceholders:
+    def test_question_marks_and_literal_percent(self):
+        sql, params = to_pyformat("SELECT '?', name FROM t WHERE a LIKE 'x%' AND id = ?", [7])
+
+        assert sql == "SELECT '?', name FROM t WHERE a LIKE 'x%%' AND id = %s"
+        assert params == [7]
+
+    def test_dollar_placeholders_reordered(self):
+        assert to_pyformat("SELECT $2, $1", ["a", "b"]) == ("SELECT %s, %s", ["b", "a"])
+
+
+class TestNormalization:
+    def test_numbers_compare_by_value(self):
+        assert normalize_value(Decimal("1.50")) == normalize_value(1.5) == "1.5"
+        assert normalize_value("10") == normalize_value(10) == "10"
+
+    def test_iris_dates_and_booleans(self):
+        assert normalize_value(9132, 1082) == normalize_value(datetime.date(2025, 1, 1))
+        assert normalize_value(1, 16) == normalize_value(True) == "t"
+
+
+class TestCompare:
+    def test_unordered_rows_match(self):
+        iris = outcome_from_result(_iris([[2], [1]]))
+        postgres = ShadowOutcome(success=True, row_count=2, rows=[("1",), ("2",)])
+
+        assert compare_outcomes("SELECT x FROM t", iris, postgres) == []
+        assert compare_outcomes("SELECT x FROM t ORDER BY x DESC", iris, postgres) != []
+
+    def test_sqlstate_difference(self):
+        iris = ShadowOutcome(success=False, sqlstate="42000")
+        postgres = ShadowOutcome(success=False, sqlstate="42P01")
+
+        assert compare_outcomes("SELECT * FROM nope", iris, postgres) == [
+            "sqlstate: IRIS 42000, PostgreSQL 42P01"
+        ]
+
+
+class TestComparator:
+    def test_mismatch_is_counted(self):
+        comparator, connection = _comparator([(1,), (3,)])
+
+        differences = comparator.compare("SELECT x FROM t;", None, _iris([[1], [2]]))
+
+        assert differences and differences[0].startswith("values:")
+        assert connection.executed == [("SELECT x FROM t", None)]
+        assert comparator.stats.mismatched == 1
+
+    def test_postgres_error_is_an_outcome(self):
+        comparator, _ = _comparator(FakePgError("42703"))
+
+        differences = comparator.compare("SELECT nope FROM t", None, _iris([[1]]))
+
+        assert differences == ["error: only PostgreSQL failed (SQLSTATE 42703)"]
+
+    def test_writes_not_shadowed_by_default(self):
+        comparator, _ = _comparator([])
+
+        assert comparator.should_shadow("SELECT 1")
+        assert comparator.should_shadow("(SELECT 1) UNION (SELECT 2)")
+        assert not comparator.should_shadow("INSERT INTO t VALUES (1)")
+        assert not comparator.should_shadow("COMMIT")
@@ -0,0 +1,446 @@
+"""
+Unit Tests: SQL Cursors
+
+DECLARE / FETCH / MOVE / CLOSE parsing, incremental reads from the cursor's
+result set, cursor lifetime across transactions and the simple Query flow.
+"""
+
+import asyncio
+import types
+from concurrent.futures import ThreadPoolExecutor
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_cursors import (
+    BATCH_SIZE,
+    CloseCursor,
+    CursorError,
+    DeclareCursor,
+    FetchCursor,
+    ResultStream,
+    SqlCursors,
+    materialized_stream,
+    parse_cursor_statement,
+)
+from iris_pgwire.stats_hooks import get_stats
+from iris_pgwire.temp_spool import SpoolConfig
+from tests.protocol_messages import FakeWriter, backend_messages, query_message, startup_message
+
+COLUMNS = [{"name": "id", "type_oid": 23, "type_size": 4, "type_modifier": -1, "format_code": 0}]
+
+
+def _result(count: int) -> dict:
+    return {
+        "success": True,
+        "rows": [[i] for i in range(1, count + 1)],
+        "columns": COLUMNS,
+        "row_count": count,
+    }
+
+
+async def _ready(value):
+    return value
+
+
+def _recording_stream(count: int, requests: list[int], closed: list[bool]) -> ResultStream:
+    stream = materialized_stream(_result(count))
+    fetch = stream.fetch
+
+    async def recording_fetch(n):
+        requests.append(n)
+        return await fetch(n)
+
+    async def close():
+        closed.append(True)
+
+    return ResultStream(stream.columns, recording_fetch, close)
+
+
+@pytest.mark.parametrize(
+    "sql, statement",
+    [
+        (
+            "DECLARE c1 CURSOR FOR SELECT * FROM orders",
+            DeclareCursor("c1", "SELECT * FROM orders"),
+        ),
+        (
+            'declare "Big" no scroll cursor with hold for select 1;',
+            DeclareCursor("Big", "select 1", hold=True),
+        ),
+        ("FETCH FORWARD 2000 FROM c1", FetchCursor("c1", 2000)),
+        ("FETCH c1", FetchCursor("c1", 1)),
+        ("fetch all in C1", FetchCursor("c1", None)),
+        ("FETCH FORWARD ALL FROM c1", FetchCursor("c1", None)),
+        ("MOVE 10 IN c1", FetchCursor("c1", 10, move=True)),
+        (
+            "DECLARE c1 SCROLL CURSOR WITH HOLD FOR SELECT 1",
+            DeclareCursor("c1", "SELECT 1", hold=True, scroll=True),
+        ),
+        ("FETCH PRIOR FROM c1", FetchCursor("c1", 1, direction="BACKWARD")),
+        ("FETCH BACKWARD ALL FROM c1", FetchCursor("c1", None, direction="BACKWARD")),
+        ("FETCH -3 FROM c1", FetchCursor("c1", 3, direction="BACKWARD")),
+        ("FETCH 0 FROM c1", FetchCursor("c1", 0, direction="RELATIVE")),
+        ("FETCH LAST FROM c1", FetchCursor("c1", -1, direction="ABSOLUTE")),
+        ("MOVE ABSOLUTE 7 IN c1", FetchCursor("c1", 7, move=True, direction="ABSOLUTE")),
+        ("CLOSE c1", CloseCursor("c1")),
+        ("CLOSE ALL", CloseCursor(None)),
+        ("SELECT 1", None),
+    ],
+)
+def test_parse(sql, statement):
+    assert parse_cursor_statement(sql) == statement
+
+
+@pytest.mark.parametrize(
+    "sql, sqlstate",
+    [
+        ("FETCH SIDEWAYS FROM c1", "42601"),
+        ("FETCH ABSOLUTE ALL FROM c1", "42601"),
+        ("DECLARE c1 BINARY CURSOR FOR SELECT 1", "0A000"),
+        ("DECLARE c1 CURSOR FOR DELETE FROM orders", "42601"),
+    ],
+)
+def test_parse_rejected(sql, sqlstate):
+    with pytest.raises(CursorError) as error:
+        parse_cursor_statement(sql)
+
+    assert error.value.sqlstate == sqlstate
+
+
+def test_fetch_reads_only_what_is_asked():
+    async def run():
+        requests, closed = [], []
+        cursors = SqlCursors()
+        cursor = await cursors.declare(
+            DeclareCursor("c1", "SELECT 1"), lambda: _ready(_recording_stream(25, requests, closed))
+        )
+        first = await cursor.fetch(10)
+        moved = await cursor.move(5)
+        rest = await cursor.fetch(None)
+        await cursors.close("c1")
+        return first, moved, rest, requests, closed, cursor.position
+
+    first, moved, rest, requests, closed, position = asyncio.run(run())
+
+    assert first == [[i] for i in range(1, 11)]
+    assert moved == 5
+    assert rest == [[i] for i in range(16, 26)]
+    assert requests[:2] == [10, 5]
+    assert closed == [True] and position == 25
+
+
+def test_duplicate_and_unknown_cursors():
+    async def run():
+        cursors = SqlCursors()
+        declare = DeclareCursor("c1", "SELECT 1")
+        await cursors.declare(declare, lambda: _ready(materialized_stream(_result(1))))
+        with pytest.raises(CursorError) as duplicate:
+            await cursors.declare(declare, lambda: _ready(materialized_stream(_result(1))))
+        with pytest.raises(CursorError) as unknown:
+            cursors.get("c2")
+        return duplicate.value.sqlstate, unknown.value.sqlstate
+
+    assert asyncio.run(run()) == ("42P03", "34000")
+
+
+def test_open_cursor_limit():
+    async def run():
+        cursors = SqlCursors(max_open=1)
+        await cursors.declare(
+            DeclareCursor("c1", "SELECT 1"), lambda: _ready(materialized_stream(_result(1)))
+        )
+        await cursors.declare(
+            DeclareCursor("c2", "SELECT 1"), lambda: _ready(materialized_stream(_result(1)))
+        )
+
+    with pytest.raises(CursorError, match="PGWIRE_MAX_OPEN_CURSORS"):
+        asyncio.run(run())
+
+
+def test_transaction_end_closes_cursors_without_hold():
+    async def run():
+        closed = []
+        cursors = SqlCursors()
+        for name, hold in (("plain", False), ("held", True)):
+            await cursors.declare(
+                DeclareCursor(name, "SELECT 1", hold=hold),
+                lambda: _ready(_recording_stream(1, [], closed)),
+            )
+        await cursors.end_transaction(committed=True)
+        after_commit = "plain" in cursors, "held" in cursors
+        await cursors.end_transaction(committed=False)
+        return after_commit, "held" in cursors, len(closed)
+
+    # The held cursor's IRIS result set is closed too, its rows spooled at COMMIT
+    assert asyncio.run(run()) == ((False, True), True, 2)
+
+
+def test_rollback_closes_cursors_held_in_the_transaction():
+    async def run():
+        cursors = SqlCursors()
+        await cursors.declare(
+            DeclareCursor("held", "SELECT 1", hold=True),
+            lambda: _ready(materialized_stream(_result(1))),
+        )
+        await cursors.end_transaction(committed=False)
+        return "held" in cursors
+
+    assert asyncio.run(run()) is False
+
+
+async def _fetch(cursor, sql: str) -> list:
+    rows, count = await cursor.execute(parse_cursor_statement(sql))
+    return [row[0] for row in rows] if sql.startswith("FETCH") else count
+
+
+def test_scroll_cursor_fetches_backward_from_spool():
+    async def run():
+        requests, closed = [], []
+        cursors = SqlCursors(spool_config=SpoolConfig(memory_bytes=0))  # Spill every row
+        cursor = await cursors.declare(
+            DeclareCursor("c1", "SELECT 1", scroll=True),
+            lambda: _ready(_recording_stream(10, requests, closed)),
+        )
+        results = [
+            await _fetch(cursor, sql)
+            for sql in (
+                "FETCH 3 FROM c1",
+                "FETCH PRIOR FROM c1",
+                "FETCH BACKWARD ALL FROM c1",
+                "FETCH ABSOLUTE 2 FROM c1",
+                "FETCH 0 FROM c1",
+                "FETCH LAST FROM c1",
+                "FETCH ABSOLUTE -3 FROM c1",
+                "FETCH RELATIVE 2 FROM c1",
+                "FETCH NEXT FROM c1",
+                "FETCH PRIOR FROM c1",
+                "MOVE ABSOLUTE 20 IN c1",
+                "FETCH BACKWARD 2 FROM c1",
+                "MOVE FIRST IN c1",
+            )
+        ]
+        spilled = cursor._spool.spilled
+        await cursors.close("c1")
+        return results, requests, spilled, closed
+
+    results, requests, spilled, closed = asyncio.run(run())
+
+    assert results == [
+        [1, 2, 3],
+        [2],
+        [1],
+        [2],
+        [2],
+        [10],
+        [8],
+        [10],
+        [],
+        [10],
+        0,
+        [10, 9],
+        1,
+    ]
+    assert requests[:2] == [3, BATCH_SIZE]  # Read as far as needed: 3 rows, then up to LAST
+    assert spilled and closed == [True]
+
+
+def test_forward_only_cursor_rejects_moving_back():
+    async def run():
+        cursors = SqlCursors()
+        cursor = await cursors.declare(
+            DeclareCursor("c1", "SELECT 1"), lambda: _ready(materialized_stream(_result(10)))
+        )
+        skipped = await _fetch(cursor, "FETCH ABSOLUTE 3 FROM c1")
+        states = []
+        for sql in ("FETCH PRIOR FROM c1", "FETCH 0 FROM c1", "FETCH ABSOLUTE 2 FROM c1"):
+            with pytest.raises(CursorError) as error:
+                await _fetch(cursor, sql)
+            states.append(error.value.sqlstate)
+        return skipped, states, await _fetch(cursor, "FETCH RELATIVE 2 FROM c1")
+
+    assert asyncio.run(run()) == ([3], ["55000"] * 3, [5])
+
+
+def test_held_cursor_rows_spooled_at_commit():
+    async def run():
+        requests, closed = [], []
+        stats = get_stats()
+        temp_files = stats.total_temp_files
+        cursors = SqlCursors(spool_config=SpoolConfig(memory_bytes=0))
+        cursor = await cursors.declare(
+            DeclareCursor("held", "SELECT 1", hold=True),
+            lambda: _ready(_recording_stream(6, requests, closed)),
+        )
+        first = await cursor.fetch(2)
+        await cursors.end_transaction(committed=True)
+        closed_at_commit = list(closed)
+        rest = await cursor.fetch(None)
+        await cursors.close("held")
+        return first, closed_at_commit, rest, stats.total_temp_files - temp_files
+
+    first, closed_at_commit, rest, temp_files = asyncio.run(run())
+
+    assert first == [[1], [2]]
+    assert closed_at_commit == [True]  # The IRIS result set is not kept open
+    assert rest == [[3], [4], [5], [6]]
+    assert temp_files == 1
+
+
+class FakeCursor:
+    def __init__(self, rows):
+        self.description = [("ID", 4, 4)]
+        self.rows = rows
+        self.executed = []
+        self.fetches = []
+        self.closed = False
+
+    def execute(self, sql):
+        self.executed.append(sql)
+
+    def fetchmany(self, size):
+        self.fetches.append(size)
+        batch, self.rows = self.rows[:size], self.rows[size:]
+        return batch
+
+    def close(self):
+        self.closed = True
+
+
+def test_external_stream_reads_with_fetchmany():
+    fake_cursor = FakeCursor([(i,) for i in range(100)])
+    connection = types.SimpleNamespace(cursor=lambda: fake_cursor)
+    returned = []
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.embedded_mode = False
+    executor.thread_pool = ThreadPoolExecutor(max_workers=1)
+    executor.global_tables = types.SimpleNamespace(references_virtual_table=lambda sql: False)
+    executor.system_functions = types.SimpleNamespace(references_system_function=lambda sql: False)
+    executor.privilege_functions = types.SimpleNamespace(
+        references_privilege_function=lambda sql: False
+    )
+    executor._get_pooled_connection = lambda *args, **kwargs: connection
+    executor._return_connection = lambda conn, *args, **kwargs: returned.append(conn)
+
+    async def run():
+        stream = await executor.open_cursor_stream("SELECT id FROM orders;")
+        batch = await stream.fetch(30)
+        returned_while_open = list(returned)
+        await stream.close()
+        return stream.columns, batch, returned_while_open
+
+    columns, batch, returned_while_open = asyncio.run(run())
+
+    assert [column["name"] for column in columns] == ["id"]
+    assert batch == [[i] for i in range(30)]
+    assert fake_cursor.fetches == [30]
+    assert returned_while_open == [] and returned == [connection]
+    assert fake_cursor.closed
+
+
+async def _session(*queries: str):
+    reader = asyncio.StreamReader()
+    reader.feed_data(
+        startup_message(user="app", database="USER")
+        + b"".join(query_message(sql) for sql in queries)
+        + b"X\x00\x00\x00\x04"
+    )
+    reader.feed_eof()
+    writer = FakeWriter()
+    executor = MagicMock()
+    executor.begin_transaction = AsyncMock()
+    executor.commit_transaction = AsyncMock()
+    executor.rollback_transaction = AsyncMock()
+    executor.open_cursor_stream = AsyncMock(return_value=materialized_stream(_result(5)))
+    protocol = PGWireProtocol(reader, writer, executor, "cursors")
+    await protocol.handle_ssl_probe(None)
+    await protocol.handle_startup_sequence()
+    handshake = len(writer.buffer)
+    try:
+        await asyncio.wait_for(protocol.message_loop(), timeout=5)
+    finally:
+        get_stats().session_ended(protocol.connection_id)
+    return protocol, executor, backend_messages(writer.buffer[handshake:])
+
+
+def _tags(messages) -> list[str]:
+    return [body.rstrip(b"\x00").decode() for kind, body in messages if kind == b"C"]
+
+
+def _errors(messages) -> list[str]:
+    states = []
+    for kind, body in messages:
+        if kind == b"E":
+            fields = {f[:1]: f[1:].decode() for f in body.split(b"\x00") if f}
+            states.append(fields[b"C"])
+    return states
+
+
+def test_declare_fetch_move_close():
+    protocol, executor, messages = asyncio.run(
+        _session(
+            "BEGIN",
+            "DECLARE c1 CURSOR FOR SELECT id FROM orders",
+            "FETCH 2 FROM c1",
+            "MOVE 1 IN c1",
+            "FETCH ALL FROM c1",
+            "CLOSE c1",
+            "COMMIT",
+        )
+    )
+
+    assert _tags(messages) == [
+        "BEGIN",
+        "DECLARE CURSOR",
+        "FETCH 2",
+        "MOVE 1",
+        "FETCH 2",
+        "CLOSE CURSOR",
+        "COMMIT",
+    ]
+    assert [body for kind, body in messages if kind == b"D"] == [
+        b"\x00\x01\x00\x00\x00\x011",
+        b"\x00\x01\x00\x00\x00\x012",
+        b"\x00\x01\x00\x00\x00\x014",
+        b"\x00\x01\x00\x00\x00\x015",
+    ]
+    executor.open_cursor_stream.assert_awaited_once_with("SELECT id FROM orders;")
+    assert len(protocol.sql_cursors) == 0
+
+
+def test_declare_outside_transaction_block():
+    _, executor, messages = asyncio.run(_session("DECLARE c1 CURSOR FOR SELECT 1"))
+
+    assert _errors(messages) == ["25P01"]
+    executor.open_cursor_stream.assert_not_called()
+
+
+def test_cursor_closed_at_commit():
+    _, _, messages = asyncio.run(
+        _session("BEGIN", "DECLARE c1 CURSOR FOR SELECT 1", "COMMIT", "FETCH c1")
+    )
+
+    assert _errors(messages) == ["34000"]
+    assert messages[-1][0] == b"Z"
+
+
+def test_scroll_cursor_session():
+    _, _, messages = asyncio.run(
+        _session(
+            "BEGIN",
+            "DECLARE c1 SCROLL CURSOR FOR SELECT id FROM orders",
+            "FETCH LAST FROM c1",
+            "FETCH PRIOR FROM c1",
+            "DECLARE c2 CURSOR FOR SELECT id FROM orders",
+            "FETCH PRIOR FROM c2",
+        )
+    )
+
+    assert _tags(messages)[2:4] == ["FETCH 1", "FETCH 1"]
+    assert [body for kind, body in messages if kind == b"D"] == [
+        b"\x00\x01\x00\x00\x00\x015",
+        b"\x00\x01\x00\x00\x00\x014",
+    ]
+    assert _errors(messages) == ["55000"]
@@ -0,0 +1,55 @@
+"""
+Unit Tests: Lexical Helpers for SQL Text
+"""
+
+import re
+
+import pytest
+
+from iris_pgwire.sql_text import find_outside_literals, operand_start, split_top_level
+
+
+def test_find_outside_literals_skips_string_literals():
+    sql = "SELECT 'at time zone' AS label, ts AT TIME ZONE 'UTC' FROM t"
+    match = find_outside_literals(re.compile(r"AT TIME ZONE", re.IGNORECASE), sql, 0)
+
+    assert match.start() == sql.index("ts AT") + 3
+
+
+@pytest.mark.parametrize(
+    "sql,operand",
+    [
+        ("SELECT created_at AT", "created_at"),
+        ('SELECT o."Created" AT', 'o."Created"'),
+        ("SELECT now() AT", "now()"),
+        ("SELECT CAST('2024-01-01' AS TIMESTAMP) AT", "CAST('2024-01-01' AS TIMESTAMP)"),
+        ("SELECT TIMESTAMP '2024-01-01 00:00' AT", "TIMESTAMP '2024-01-01 00:00'"),
+        ("SELECT 'it''s' AT", "'it''s'"),
+        ("WHERE (a + b) AT", "(a + b)"),
+    ],
+)
+def test_operand_start(sql, operand):
+    end = sql.rindex(" AT")
+
+    assert sql[operand_start(sql, end) : end] == operand
+
+
+def test_no_operand():
+    assert operand_start("  ", 2) is None
+
+
+@pytest.mark.parametrize(
+    "text,expected",
+    [
+        ("a, b ,c", ["a", "b", "c"]),
+        ("f(a, b), g((c, d))", ["f(a, b)", "g((c, d))"]),
+        ("'a,b', 'it''s, here'", ["'a,b'", "'it''s, here'"]),
+        ('"a,b", "say ""hi, there"""', ['"a,b"', '"say ""hi, there"""']),
+        ("'(', \"(\", x", ["'('", '"("', "x"]),
+        ("'\"', \"'\", y", ["'\"'", "\"'\"", "y"]),
+        ("a,", ["a"]),
+        ("", []),
+    ],
+)
+def test_split_top_level(text, expected):
+    assert split_top_level(text) == expected
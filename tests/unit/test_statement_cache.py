@@ -0,0 +1,129 @@
+"""
+Unit Tests: Implicit Prepared Statements
+
+Repeated parameterless statement texts prepared once and reused in embedded
+mode, the threshold and LRU bound, fallback when a cached statement fails,
+and invalidation by DDL.
+"""
+
+import asyncio
+from types import SimpleNamespace
+
+import pytest
+
+import iris_pgwire.catalog.schema_cache as schema_cache_module
+import iris_pgwire.statement_cache as statement_cache_module
+from iris_pgwire.catalog.schema_cache import SchemaCache
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.statement_cache import StatementCache, get_statement_cache
+
+
+class FakeStatement:
+    def __init__(self, sql, iris):
+        self.sql = sql
+        self.iris = iris
+
+    def execute(self):
+        if self.sql in self.iris.broken:
+            raise RuntimeError("SQLCODE -30: table not found")
+        self.iris.executed.append(("prepared", self.sql))
+        return [self.sql]
+
+
+class FakeIRIS:
+    def __init__(self):
+        self.prepared = []
+        self.executed = []
+        self.broken = set()
+        self.sql = SimpleNamespace(prepare=self.prepare, exec=self.exec)
+
+    def prepare(self, sql):
+        self.prepared.append(sql)
+        return FakeStatement(sql, self)
+
+    def exec(self, sql):
+        self.executed.append(("exec", sql))
+        return [sql]
+
+
+QUERY = "SELECT COUNT(*) FROM orders"
+
+
+def test_prepared_on_second_run_then_reused():
+    cache = StatementCache(capacity=8, threshold=2)
+    iris = FakeIRIS()
+
+    for _ in range(4):
+        assert cache.execute(QUERY, iris) == [QUERY]
+
+    assert iris.prepared == [QUERY]
+    assert [kind for kind, _ in iris.executed] == ["exec", "prepared", "prepared", "prepared"]
+    assert (cache.prepares, cache.hits) == (1, 2)
+
+
+@pytest.mark.parametrize(
+    "sql", ["CREATE TABLE t (id INT)", "SET OPTION x = 1", "CALL refresh()", "  "]
+)
+def test_only_queries_and_dml_are_prepared(sql):
+    cache = StatementCache(capacity=8, threshold=1)
+    iris = FakeIRIS()
+
+    cache.execute(sql, iris)
+
+    assert iris.prepared == [] and len(cache) == 0
+
+
+def test_least_recently_used_statements_dropped():
+    cache = StatementCache(capacity=2, threshold=1)
+    iris = FakeIRIS()
+
+    for sql in ("SELECT 1", "SELECT 2", "SELECT 1", "SELECT 3", "SELECT 1", "SELECT 2"):
+        cache.execute(sql, iris)
+
+    assert iris.prepared == ["SELECT 1", "SELECT 2", "SELECT 3", "SELECT 2"]
+    assert len(cache) == 2
+
+
+def test_failing_statement_dropped_and_run_unprepared():
+    cache = StatementCache(capacity=8, threshold=1)
+    iris = FakeIRIS()
+    cache.execute(QUERY, iris)
+    iris.broken.add(QUERY)
+
+    assert cache.execute(QUERY, iris) == [QUERY]  # Through iris.sql.exec
+
+    assert iris.executed[-1] == ("exec", QUERY)
+    assert len(cache) == 0 and cache.failures == 1
+
+
+def test_disabled_and_settings(monkeypatch):
+    iris = FakeIRIS()
+    cache = StatementCache(capacity=0, threshold=1)
+    cache.execute(QUERY, iris)
+    assert not cache.enabled and iris.prepared == []
+
+    monkeypatch.setenv("PGWIRE_IMPLICIT_PREPARE_CACHE_SIZE", "16")
+    monkeypatch.setenv("PGWIRE_IMPLICIT_PREPARE_THRESHOLD", "many")
+    cache = StatementCache()
+    assert (cache.capacity, cache.threshold) == (16, 2)
+
+
+def test_ddl_through_the_executor_clears(monkeypatch):
+    monkeypatch.setattr(schema_cache_module, "_schema_cache", None)
+    monkeypatch.setattr(statement_cache_module, "_statement_cache", None)
+    iris = FakeIRIS()
+    get_statement_cache().execute(QUERY, iris)
+    get_statement_cache().execute(QUERY, iris)
+    assert len(get_statement_cache()) == 1
+
+    async def execute(sql, params=None, session_id=None):
+        return {"success": True, "rows": [], "command_tag": "CREATE TABLE"}
+
+    executor = IRISExecutor.__new__(IRISExecutor)
+    executor.iris_config = {"namespace": "USER"}
+    executor.schema_cache = SchemaCache(enabled=True, check_interval=60)
+    executor.shadow = None
+    executor._execute_query = execute
+    asyncio.run(executor.execute_query("CREATE TABLE t (id INT)"))
+
+    assert len(get_statement_cache()) == 0
@@ -0,0 +1,104 @@
+"""
+Unit Tests: Savepoint per Statement
+
+iris.statement_savepoints wrapping of statements inside explicit transactions.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import STATUS_IDLE, STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.session_settings import InvalidParameterValue
+from iris_pgwire.statement_savepoints import wraps_statement
+from tests.protocol_messages import FakeWriter
+
+
+def _protocol(failing=(), transaction_status=STATUS_IN_TRANSACTION, enabled="on"):
+    executor = MagicMock()
+
+    async def execute_query(sql, params=None, session_id=None):
+        if sql.rstrip(";") in failing:
+            return {"success": False, "error": "boom", "sqlstate": "23505"}
+        return {"success": True, "rows": [], "columns": [], "command_tag": "INSERT 0 1"}
+
+    executor.execute_query = AsyncMock(side_effect=execute_query)
+    writer = FakeWriter()
+    protocol = PGWireProtocol(MagicMock(), writer, executor, "savepoints")
+    protocol.session_settings.set("iris.statement_savepoints", enabled)
+    protocol.transaction_status = transaction_status
+    return protocol, executor, writer
+
+
+def _executed(executor):
+    return [call.args[0] for call in executor.execute_query.await_args_list]
+
+
+class TestWrapping:
+    def test_successful_statement_released(self):
+        protocol, executor, _ = _protocol()
+
+        asyncio.run(protocol._execute_statement("INSERT INTO t VALUES (1)"))
+
+        assert _executed(executor) == [
+            "SAVEPOINT pgwire_statement",
+            "INSERT INTO t VALUES (1)",
+            "RELEASE SAVEPOINT pgwire_statement",
+        ]
+
+    def test_failed_statement_rolled_back(self):
+        protocol, executor, writer = _protocol(failing={"INSERT INTO t VALUES (1)"})
+
+        asyncio.run(protocol.handle_query_message(b"INSERT INTO t VALUES (1)\x00"))
+
+        assert _executed(executor) == [
+            "SAVEPOINT pgwire_statement",
+            "INSERT INTO t VALUES (1);",
+            "ROLLBACK TO SAVEPOINT pgwire_statement",
+            "RELEASE SAVEPOINT pgwire_statement",
+        ]
+        assert b"C23505\x00" in writer.buffer
+        assert writer.buffer.endswith(b"Z\x00\x00\x00\x05T")
+
+    def test_parameters_passed_through(self):
+        protocol, executor, _ = _protocol()
+
+        asyncio.run(protocol._execute_statement("UPDATE t SET a = ?", [5]))
+
+        assert executor.execute_query.await_args_list[1].kwargs == {"params": [5]}
+
+    @pytest.mark.parametrize(
+        "transaction_status,enabled", [(STATUS_IDLE, "on"), (STATUS_IN_TRANSACTION, "off")]
+    )
+    def test_not_wrapped(self, transaction_status, enabled):
+        protocol, executor, _ = _protocol(transaction_status=transaction_status, enabled=enabled)
+
+        asyncio.run(protocol._execute_statement("INSERT INTO t VALUES (1)"))
+
+        assert _executed(executor) == ["INSERT INTO t VALUES (1)"]
+
+    @pytest.mark.parametrize(
+        "sql,wrapped",
+        [
+            ("SAVEPOINT app_sp", False),
+            ("rollback to app_sp", False),
+            ("RELEASE SAVEPOINT app_sp", False),
+            ("  COMMIT", False),
+            ("SELECT * FROM savepoints", True),
+            ("DELETE FROM t", True),
+        ],
+    )
+    def test_transaction_control_not_wrapped(self, sql, wrapped):
+        assert wraps_statement(sql) is wrapped
+
+
+class TestSetting:
+    def test_boolean_spellings(self):
+        protocol, _, _ = _protocol(enabled="off")
+
+        protocol.session_settings.set("IRIS.STATEMENT_SAVEPOINTS", "true")
+
+        assert protocol.session_settings.get("iris.statement_savepoints") == "on"
+        with pytest.raises(InvalidParameterValue):
+            protocol.session_settings.set("iris.statement_savepoints", "always")
@@ -0,0 +1,91 @@
+"""
+Unit Tests: Stats and Hooks API
+
+Per-query events, per-session counters and hook isolation.
+"""
+
+import contextvars
+
+from iris_pgwire.stats_hooks import StatsHooks, StatsRegistry
+
+
+class RecordingHooks(StatsHooks):
+    def __init__(self):
+        self.events = []
+
+    def on_session_start(self, session):
+        self.events.append(("start", session.connection_id))
+
+    def on_query(self, event):
+        self.events.append(("query", event.connection_id, event.command, event.success))
+
+    def on_session_end(self, session):
+        self.events.append(("end", session.connection_id, session.queries))
+
+
+class FailingHooks(StatsHooks):
+    def on_query(self, event):
+        raise RuntimeError("metrics backend down")
+
+
+def _ok(rows):
+    return {"success": True, "rows": [], "columns": [], "row_count": rows, "command": "SELECT"}
+
+
+class TestStatsRegistry:
+    def test_session_counters(self):
+        registry = StatsRegistry()
+
+        def session():
+            registry.session_started("c1", user="alice", database="USER")
+            registry.record_query("SELECT 1", _ok(1), 2.0)
+            registry.record_query("SELECT * FROM t", _ok(10), 3.0)
+            registry.record_query("SELEC", {"success": False, "sqlstate": "42601"}, 1.0)
+
+        contextvars.copy_context().run(session)
+        stats = registry.session("c1")
+
+        assert (stats.queries, stats.errors, stats.rows) == (3, 1, 11)
+        assert stats.total_query_ms == 6.0
+        assert registry.snapshot()["totals"]["errors"] == 1
+
+    def test_hooks_receive_events(self):
+        registry = StatsRegistry()
+        hooks = RecordingHooks()
+        registry.add_hooks(hooks)
+
+        def session():
+            registry.session_started("c1")
+            registry.record_query("SELECT 1", _ok(1), 1.0)
+            registry.record_query("BAD", None, 1.0)
+
+        contextvars.copy_context().run(session)
+        registry.session_ended("c1")
+
+        assert hooks.events == [
+            ("start", "c1"),
+            ("query", "c1", "SELECT", True),
+            ("query", "c1", None, False),
+            ("end", "c1", 2),
+        ]
+        assert registry.snapshot()["totals"]["active_sessions"] == 0
+
+    def test_failed_query_reports_sqlstate(self):
+        registry = StatsRegistry()
+
+        event = registry.record_query("SELECT x", {"success": False, "sqlstate": "42703"}, 1.0)
+        crashed = registry.record_query("SELECT y", None, 1.0)
+
+        assert event.sqlstate == "42703"
+        assert crashed.sqlstate == "XX000"
+        assert event.connection_id is None
+
+    def test_failing_hook_is_isolated(self):
+        registry = StatsRegistry()
+        recording = RecordingHooks()
+        registry.add_hooks(FailingHooks())
+        registry.add_hooks(recording)
+
+        registry.record_query("SELECT 1", _ok(1), 1.0)
+
+        assert recording.events == [("query", None, "SELECT", True)]
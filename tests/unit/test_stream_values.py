@@ -0,0 +1,127 @@
+"""
+Unit Tests: IRIS Stream Results
+
+bytea and text columns backed by %Stream.GlobalBinary / GlobalCharacter are
+returned as stream OIDs (embedded) or driver stream objects (external);
+results carry their bytes or text, long text in a LargeValue.
+"""
+
+import io
+from unittest.mock import MagicMock
+
+import pytest
+
+from iris_pgwire import stream_values
+from iris_pgwire.large_values import LargeValue
+from iris_pgwire.stream_values import (
+    FailedStreamValue,
+    StreamValueTooLarge,
+    load_max_stream_bytes,
+    read_stream,
+    read_stream_columns,
+    read_text_stream,
+    stream_class,
+)
+
+# $LISTBUILD("12", "%Stream.GlobalBinary"), the OID of a stored stream
+STREAM_OID = "\x04\x0112\x16\x01%Stream.GlobalBinary"
+CHARACTER_OID = "\x04\x0112\x19\x01%Stream.GlobalCharacter"
+DATA = bytes(range(256)) * 10
+
+
+def _iris(chunks):
+    iris = MagicMock()
+    stream = iris.cls.return_value._Open.return_value
+    remaining = list(chunks)
+
+    def read(length):
+        return remaining.pop(0)
+
+    stream.Read.side_effect = read
+    type(stream).AtEnd = property(lambda self: not remaining)
+    return iris
+
+
+def test_stream_oids_are_recognized():
+    assert stream_class(STREAM_OID) == "%Stream.GlobalBinary"
+    assert stream_class(STREAM_OID.encode("latin-1")) == "%Stream.GlobalBinary"
+    assert stream_class("\x04\x01ab") is None  # $LISTBUILD("ab")
+    assert stream_class("plain text") is None
+    assert stream_class(12) is None
+
+
+def test_embedded_oids_read_in_chunks(monkeypatch):
+    monkeypatch.setattr(stream_values, "CHUNK_BYTES", 1000)
+    chunks = [DATA[:1000].decode("latin-1"), DATA[1000:2000], DATA[2000:].decode("latin-1")]
+    iris = _iris(chunks)
+
+    assert read_stream(STREAM_OID, iris) == DATA
+    iris.cls.assert_called_once_with("%Stream.GlobalBinary")
+    iris.cls.return_value._Open.assert_called_once_with(STREAM_OID)
+    iris.cls.return_value._Open.return_value.Read.assert_called_with(1000)
+
+
+def test_driver_streams_and_strings():
+    assert read_stream(io.BytesIO(DATA)) == DATA
+    assert read_stream("\x00\xffA") == b"\x00\xffA"
+    assert read_stream("€") == "€".encode()
+    assert read_stream(7) == 7
+
+
+def test_bytea_columns_read_in_place():
+    columns = [{"name": "id", "type_oid": 23}, {"name": "body", "type_oid": 17}]
+    rows = [(1, STREAM_OID), [2, "ab"], [3, None], [STREAM_OID, b"raw"]]
+
+    assert read_stream_columns(rows, columns, _iris([DATA])) == 1
+    assert rows == [[1, DATA], [2, b"ab"], [3, None], [STREAM_OID, b"raw"]]
+    assert read_stream_columns(rows, [{"name": "id", "type_oid": 23}]) == 0
+
+
+def test_character_streams_read_as_text():
+    iris = _iris(["naïve ", "text"])
+
+    assert stream_class(CHARACTER_OID) == "%Stream.GlobalCharacter"
+    assert read_text_stream(CHARACTER_OID, iris) == "naïve text"
+    assert read_text_stream(io.BytesIO("é€".encode()), spool_bytes=100) == "é€"
+    assert read_text_stream("plain", iris) == "plain"
+
+
+def test_long_character_streams_spooled(monkeypatch):
+    monkeypatch.setattr(stream_values, "CHUNK_BYTES", 4)
+    value = read_text_stream(io.StringIO("é" * 10), spool_bytes=8)
+
+    assert isinstance(value, LargeValue) and not value.binary
+    assert value.size == 20 and value.read() == "é" * 10
+    value.close()
+
+
+def test_streams_over_the_maximum_not_read_further():
+    iris = _iris(["x" * 10] * 3)
+
+    text = read_text_stream(CHARACTER_OID, iris, max_bytes=15, column="doc")
+    binary = read_stream(io.BytesIO(b"y" * 20), max_bytes=15, column="blob")
+
+    assert isinstance(text, FailedStreamValue)
+    assert iris.cls.return_value._Open.return_value.Read.call_count == 2
+    assert isinstance(text.error, StreamValueTooLarge)
+    assert str(text.error) == 'value of column "doc" exceeds PGWIRE_MAX_STREAM_BYTES (15 bytes)'
+    assert isinstance(binary, FailedStreamValue) and binary.error.sqlstate == "54000"
+
+
+def test_text_columns_read_in_place():
+    columns = [{"name": "id", "type_oid": 23}, {"name": "doc", "type_oid": 25}]
+    rows = [(1, CHARACTER_OID), (2, "short"), (3, None)]
+
+    assert read_stream_columns(rows, columns, _iris(["body"]), max_bytes=0) == 1
+    assert rows == [[1, "body"], (2, "short"), (3, None)]
+
+
+@pytest.mark.parametrize(
+    "value,expected", [(None, 1024**3), ("64MB", 64 * 1024**2), ("0", 0), ("lots", 1024**3)]
+)
+def test_max_stream_bytes_setting(monkeypatch, value, expected):
+    monkeypatch.delenv("PGWIRE_MAX_STREAM_BYTES", raising=False)
+    if value is not None:
+        monkeypatch.setenv("PGWIRE_MAX_STREAM_BYTES", value)
+
+    assert load_max_stream_bytes() == expected
@@ -0,0 +1,115 @@
+"""
+Unit Tests: String Function Translation
+
+left/right, split_part, strpos, starts_with, lpad/rpad, concat, concat_ws and
+format() rewritten into IRIS expressions with PostgreSQL's results.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.string_function_translator import StringFunctionTranslator
+
+
+@pytest.fixture
+def translator():
+    return StringFunctionTranslator()
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        ("SELECT left(name, 3)", "SELECT SUBSTRING(name, 1, 3)"),
+        (
+            "SELECT left(name, -2)",
+            "SELECT SUBSTRING(name, 1, GREATEST(CHAR_LENGTH(name) - 2, 0))",
+        ),
+        ("SELECT right(name, 2)", "SELECT SUBSTRING(name, GREATEST(CHAR_LENGTH(name) - 1, 1))"),
+        ("SELECT right(name, -2)", "SELECT SUBSTRING(name, 3)"),
+        ("SELECT split_part(path, '/', 2)", "SELECT $PIECE(path, '/', 2)"),
+        ("SELECT split_part(path, '/', -1)", "SELECT $PIECE(path, '/', $LENGTH(path, '/'))"),
+        (
+            "SELECT split_part(path, '/', -2)",
+            "SELECT $PIECE(path, '/', $LENGTH(path, '/') - 1)",
+        ),
+        ("SELECT strpos(name, 'x')", "SELECT INSTR(name, 'x')"),
+        (
+            "SELECT starts_with(name, 'Ab')",
+            "SELECT (SUBSTRING(%EXACT(name), 1, CHAR_LENGTH('Ab')) = %EXACT('Ab'))",
+        ),
+        (
+            "SELECT lpad(code, 5, '0')",
+            "SELECT CASE WHEN CHAR_LENGTH(code) >= 5 THEN SUBSTRING(code, 1, 5) "
+            "ELSE SUBSTRING(REPEAT('0', 5), 1, 5 - CHAR_LENGTH(code)) || code END",
+        ),
+        (
+            "SELECT rpad(name, 10)",
+            "SELECT CASE WHEN CHAR_LENGTH(name) >= 10 THEN SUBSTRING(name, 1, 10) "
+            "ELSE name || SUBSTRING(REPEAT(' ', 10), 1, 10 - CHAR_LENGTH(name)) END",
+        ),
+    ],
+)
+def test_functions(translator, sql, expected):
+    assert translator.translate(sql)[0] == expected
+
+
+def test_concat_ignores_nulls(translator):
+    assert translator.translate("SELECT concat(first, ' ', last)")[0] == (
+        "SELECT (COALESCE(first, '') || COALESCE(' ', '') || COALESCE(last, ''))"
+    )
+    assert translator.translate("SELECT concat('a', 'b', 'c')")[0] == "SELECT 'abc'"
+
+
+def test_concat_ws_joins_non_null_arguments(translator):
+    assert translator.translate("SELECT concat_ws(', ', city, zip)")[0] == (
+        "SELECT SUBSTRING(CASE WHEN city IS NULL THEN '' ELSE ', ' || city END || "
+        "CASE WHEN zip IS NULL THEN '' ELSE ', ' || zip END, 3)"
+    )
+    assert translator.translate("SELECT concat_ws('-', 'a', 'b')")[0] == "SELECT 'a-b'"
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        (
+            "SELECT format('Hello %s, you are %s', name, age)",
+            "SELECT ('Hello ' || COALESCE(name, '') || ', you are ' || COALESCE(age, ''))",
+        ),
+        ("SELECT format('%I.%I', 'public', 'My Table')", "SELECT 'public.\"My Table\"'"),
+        ("SELECT format('%L = %L', 'O''Brien', NULL)", "SELECT '''O''''Brien'' = NULL'"),
+        ("SELECT format('%2$s %1$s %%', 'a', 'b')", "SELECT 'b a %'"),
+    ],
+)
+def test_format(translator, sql, expected):
+    assert translator.translate(sql)[0] == expected
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT format('%10s', a), format(fmt, a), format('%s %s', a) FROM t",
+        "SELECT {fn LEFT(name, 3)} FROM t LEFT JOIN u ON t.id = u.id",
+        "SELECT 'left(x, 1)', split_part(a, ',') FROM t",
+    ],
+)
+def test_other_statements_are_left_alone(translator, sql):
+    assert translator.translate(sql) == (sql, 0)
+
+
+def test_nested_calls_and_idempotence(translator):
+    translated, count = translator.translate("SELECT upper(left(concat(a, b), 2)) FROM t")
+
+    assert translated == (
+        "SELECT upper(SUBSTRING((COALESCE(a, '') || COALESCE(b, '')), 1, 2)) FROM t"
+    )
+    assert count == 2
+    assert translator.translate(translated) == (translated, 0)
+
+
+def test_normalizer_counts_string_functions():
+    sql_translator = SQLTranslator()
+
+    assert sql_translator.normalize_sql("SELECT split_part(path, '/', 1) FROM files") == (
+        "SELECT $PIECE(PATH, '/', 1) FROM FILES"
+    )
+    assert sql_translator.get_normalization_metrics()["string_function_count"] == 1
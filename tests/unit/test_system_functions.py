@@ -0,0 +1,109 @@
+"""
+Unit Tests: iris_system Function Bridge
+
+Parsing and evaluation of iris_system.<function>() calls against a recording
+class method invoker, plus their pg_proc registration.
+"""
+
+import pytest
+
+from iris_pgwire.catalog.pg_proc import PgProcEmulator
+from iris_pgwire.catalog.oid_generator import OIDGenerator
+from iris_pgwire.system_functions import SystemFunctionHandler, UnsupportedSystemCall
+
+
+class RecordingInvoker:
+    def __init__(self, results: dict[tuple[str, str], object]):
+        self.results = results
+        self.calls = []
+
+    def call(self, class_name, method, args):
+        self.calls.append((class_name, method, list(args)))
+        return self.results[(class_name, method)]
+
+
+@pytest.fixture
+def handler():
+    return SystemFunctionHandler()
+
+
+class TestExecution:
+    def test_version(self, handler):
+        invoker = RecordingInvoker({("%SYSTEM.Version", "GetVersion"): "IRIS for UNIX 2025.1"})
+
+        result = handler.execute("SELECT iris_system.version()", invoker)
+
+        assert result["rows"] == [["IRIS for UNIX 2025.1"]]
+        assert result["columns"][0]["name"] == "version"
+        assert result["columns"][0]["type_oid"] == 25
+        assert invoker.calls == [("%SYSTEM.Version", "GetVersion", [])]
+
+    def test_multiple_calls_with_aliases(self, handler):
+        invoker = RecordingInvoker(
+            {("%SYSTEM.SYS", "NameSpace"): "USER", ("%SYSTEM.SYS", "ProcessID"): "4242"}
+        )
+
+        result = handler.execute(
+            "SELECT iris_system.namespace() AS ns, iris_system.process_id() pid;", invoker
+        )
+
+        assert result["rows"] == [["USER", 4242]]
+        assert [c["name"] for c in result["columns"]] == ["ns", "pid"]
+        assert result["columns"][1]["type_oid"] == 23
+
+    def test_literal_argument_and_boolean_result(self, handler):
+        invoker = RecordingInvoker({("%SYSTEM.SQL.Schema", "TableExists"): 1})
+
+        result = handler.execute("SELECT iris_system.sql_table_exists('SQLUser.Orders')", invoker)
+
+        assert result["rows"] == [[True]]
+        assert invoker.calls == [("%SYSTEM.SQL.Schema", "TableExists", ["SQLUser.Orders"])]
+
+    def test_non_select_not_handled(self, handler):
+        assert handler.execute("CALL iris_system.version()", RecordingInvoker({})) is None
+
+
+class TestUnsupported:
+    def test_unknown_function(self, handler):
+        with pytest.raises(UnsupportedSystemCall) as exc_info:
+            handler.parse("SELECT iris_system.shutdown()")
+        assert exc_info.value.sqlstate == "42883"
+
+    def test_wrong_argument_count(self, handler):
+        with pytest.raises(UnsupportedSystemCall) as exc_info:
+            handler.parse("SELECT iris_system.version('x')")
+        assert exc_info.value.sqlstate == "42883"
+
+
+    def test_non_literal_argument(self, handler):
+        with pytest.raises(UnsupportedSystemCall):
+            handler.parse("SELECT iris_system.sql_option(?)")
+
+
+class TestOtherStatements:
+    """Statements that only mention iris_system run on IRIS as written."""
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "SELECT name, iris_system.version() FROM users",
+            "SELECT iris_system.version() FROM t",
+            "SELECT 'see iris_system.version()'",
+            "SELECT 'iris_system.version()', iris_system.version()",
+        ],
+    )
+    def test_not_taken_over(self, handler, sql):
+        assert handler.parse(sql) is None
+        assert handler.references_system_function(sql) is False
+
+    def test_unknown_function_is_still_ours(self, handler):
+        assert handler.references_system_function("SELECT iris_system.shutdown()") is True
+
+
+class TestCatalog:
+    def test_registered_in_iris_system_namespace(self):
+        procs = PgProcEmulator().get_by_name("version")
+
+        assert len(procs) == 1
+        assert procs[0].pronamespace == OIDGenerator().get_namespace_oid("iris_system")
+        assert procs[0].prosrc == "%SYSTEM.Version.GetVersion()"
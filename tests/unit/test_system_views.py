@@ -0,0 +1,159 @@
+"""
+Unit Tests: pg_tables / pg_views / pg_indexes / pg_matviews
+
+Convenience views built from IRIS INFORMATION_SCHEMA listings.
+"""
+
+import asyncio
+
+import pytest
+
+import iris_pgwire.iris_executor as iris_executor_module
+from iris_pgwire.catalog.schema_cache import SchemaCache
+from iris_pgwire.catalog.system_views import (
+    INDEXES_SQL,
+    PG_INDEXES_COLUMNS,
+    PG_TABLES_COLUMNS,
+    TABLES_SQL,
+    VIEWS_SQL,
+    SystemViewEmulator,
+    referenced_view,
+)
+from iris_pgwire.catalog.view_query import query_view
+from iris_pgwire.iris_executor import IRISExecutor
+
+TABLES = [
+    ("SQLUser", "Customers", "BASE TABLE", "_SYSTEM"),
+    ("SQLUser", "Orders", "BASE TABLE", "_SYSTEM"),
+    ("Sales", "Returns", "BASE TABLE", "etl"),
+    ("SQLUser", "OpenOrders", "VIEW", "bi"),
+]
+
+INDEXES = [
+    ("SQLUser", "Orders", "$Orders", "%%ID", 0, 1),
+    ("SQLUser", "Orders", "OrdersPKey", "ID", 1, 0),
+    ("SQLUser", "Orders", "ByCustomerDate", "Customer", 0, 1),
+    ("SQLUser", "Orders", "ByCustomerDate", "OrderDate", 0, 1),
+    ("Sales", "Returns", "ReturnNo", "Number", 0, 0),
+]
+
+VIEWS = [("SQLUser", "OpenOrders", "SELECT * FROM Orders WHERE Status = 'open'")]
+
+
+@pytest.fixture
+def emulator():
+    return SystemViewEmulator(iris_schema="SQLUser")
+
+
+class TestReferencedView:
+    @pytest.mark.parametrize(
+        "sql,view",
+        [
+            ("SELECT * FROM pg_catalog.pg_tables", "pg_tables"),
+            ("select indexdef from PG_INDEXES where tablename = 'orders'", "pg_indexes"),
+            ("SELECT EXISTS (SELECT FROM pg_tables WHERE tablename = 'x')", "pg_tables"),
+            (
+                "SELECT v.viewname FROM pg_views v JOIN pg_namespace n ON n.nspname = v.schemaname",
+                None,
+            ),
+            ("SELECT * FROM pg_tables t, pg_class c", None),
+            ("SELECT * FROM pg_class", None),
+        ],
+    )
+    def test_single_view_selects_only(self, sql, view):
+        assert referenced_view(sql) == view
+
+
+class TestViews:
+    def test_pg_tables(self, emulator):
+        columns, rows = query_view(
+            "SELECT tablename, tableowner, hasindexes FROM pg_tables "
+            "WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY tablename DESC",
+            PG_TABLES_COLUMNS,
+            emulator.table_rows(TABLES, INDEXES),
+        )
+
+        assert [c["name"] for c in columns] == ["tablename", "tableowner", "hasindexes"]
+        assert rows == [
+            ("returns", "etl", True),
+            ("orders", "_SYSTEM", True),
+            ("customers", "_SYSTEM", False),
+        ]
+
+    def test_table_exists(self, emulator):
+        view_rows = emulator.table_rows(TABLES, INDEXES)
+
+        assert query_view(
+            "SELECT EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' "
+            "AND tablename = 'orders')",
+            PG_TABLES_COLUMNS,
+            view_rows,
+        ) == ([{"name": "exists", "type_oid": 16}], [(True,)])
+        assert query_view(
+            "SELECT EXISTS (SELECT 1 FROM pg_tables WHERE tablename = 'returns' "
+            "AND schemaname = 'public') AS found",
+            PG_TABLES_COLUMNS,
+            view_rows,
+        )[1] == [(False,)]
+
+    def test_pg_indexes(self, emulator):
+        _, rows = query_view(
+            "SELECT indexname, indexdef FROM pg_indexes ORDER BY schemaname, indexname",
+            PG_INDEXES_COLUMNS,
+            emulator.index_rows(INDEXES),
+        )
+
+        assert rows == [
+            (
+                "bycustomerdate",
+                "CREATE INDEX bycustomerdate ON public.orders USING btree (customer, orderdate)",
+            ),
+            ("orderspkey", "CREATE UNIQUE INDEX orderspkey ON public.orders USING btree (id)"),
+            ("returnno", "CREATE UNIQUE INDEX returnno ON sales.returns USING btree (number)"),
+        ]
+
+    def test_pg_views(self, emulator):
+        assert emulator.build_rows("pg_views", TABLES, VIEWS) == [
+            {
+                "schemaname": "public",
+                "viewname": "openorders",
+                "viewowner": "bi",
+                "definition": "SELECT * FROM Orders WHERE Status = 'open'",
+            }
+        ]
+        assert emulator.build_rows("pg_matviews") == []
+
+
+class TestExecutor:
+    def test_listings_fetched_from_iris(self, monkeypatch):
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.iris_config = {"namespace": "USER"}
+        executor.schema_cache = SchemaCache(enabled=False)
+        monkeypatch.setattr(
+            iris_executor_module, "get_schema_config", lambda: {"iris_schema": "SQLUser"}
+        )
+        listings = {TABLES_SQL: TABLES, INDEXES_SQL: INDEXES}
+        executed = []
+
+        async def fake_execute(sql, params=None, session_id=None):
+            executed.append(sql)
+            if sql == VIEWS_SQL:
+                return {"success": False, "error": "INFORMATION_SCHEMA.VIEWS not found"}
+            return {"success": True, "rows": [list(row) for row in listings[sql]]}
+
+        executor._execute_query = fake_execute
+        tables = asyncio.run(
+            executor._execute_system_view_query(
+                "SELECT tablename, hasindexes FROM pg_tables WHERE schemaname = 'public'",
+                "pg_tables",
+            )
+        )
+        views = asyncio.run(
+            executor._execute_system_view_query("SELECT * FROM pg_views", "pg_views")
+        )
+
+        assert executed == [TABLES_SQL, INDEXES_SQL, TABLES_SQL, VIEWS_SQL]
+        assert tables["rows"] == [("customers", False), ("orders", True)]
+        assert [c["type_size"] for c in tables["columns"]] == [64, 1]
+        assert tables["command_tag"] == "SELECT 2"
+        assert views["success"] and views["rows"] == []
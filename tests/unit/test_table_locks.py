@@ -0,0 +1,146 @@
+"""
+Unit Tests: LOCK TABLE
+
+PostgreSQL lock modes mapped to IRIS table locks, NOWAIT / lock_timeout, and
+release when the transaction ends.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import STATUS_IDLE, STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.table_locks import TableLocks, parse_lock_table, wait_seconds
+
+
+class FakeIRIS:
+    """Records statements; those containing `failing` fail with error."""
+
+    def __init__(self, failing=None, error="[SQLCODE: <-110>:<Locking conflict in filing>]"):
+        self.statements = []
+        self.failing = failing
+        self.error = error
+
+    async def execute(self, sql, params=None, session_id=None):
+        self.statements.append(sql)
+        if self.failing and self.failing in sql:
+            return {"success": False, "error": self.error, "sqlstate": "HV000"}
+        return {"success": True, "rows": [], "columns": []}
+
+
+def lock(sql):
+    return parse_lock_table(sql, "SQLUser")
+
+
+class TestParsing:
+    def test_lock_table(self):
+        statement = lock(
+            'LOCK TABLE ONLY public.orders *, "Items" IN SHARE ROW EXCLUSIVE MODE NOWAIT;'
+        )
+
+        assert [target.sql for target in statement.relations] == [
+            "SQLUser.orders",
+            'SQLUser."Items"',
+        ]
+        assert (statement.mode, statement.iris_mode, statement.nowait) == (
+            "SHARE ROW EXCLUSIVE",
+            "EXCLUSIVE",
+            True,
+        )
+
+    def test_quoted_names_with_commas(self):
+        statement = lock('LOCK TABLE "orders,2024", items')
+
+        assert [target.sql for target in statement.relations] == [
+            'SQLUser."orders,2024"',
+            "SQLUser.items",
+        ]
+
+    def test_default_mode(self):
+        assert lock("lock orders").mode == "ACCESS EXCLUSIVE"
+        assert lock("LOCK TABLE orders IN ACCESS SHARE MODE").iris_mode is None
+
+    @pytest.mark.parametrize(
+        "sql", ["LOCK TABLE orders IN BOGUS MODE", "SELECT * FROM locks", "UNLOCK TABLE orders"]
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert lock(sql) is None
+
+    @pytest.mark.parametrize(
+        "nowait,lock_timeout,wait", [(True, "5s", 0), (False, "1500ms", 2), (False, "0", None)]
+    )
+    def test_wait(self, nowait, lock_timeout, wait):
+        assert wait_seconds(nowait, lock_timeout) == wait
+
+
+class TestLocks:
+    def test_lock_and_release(self):
+        iris = FakeIRIS()
+        locks = TableLocks(iris.execute)
+
+        result = asyncio.run(locks.lock(lock("LOCK TABLE orders, items IN SHARE MODE"), "2s"))
+        asyncio.run(locks.release())
+
+        assert result["command_tag"] == "LOCK TABLE"
+        assert iris.statements == [
+            "LOCK TABLE SQLUser.orders IN SHARE MODE WAIT 2",
+            "LOCK TABLE SQLUser.items IN SHARE MODE WAIT 2",
+            "UNLOCK TABLE SQLUser.items IN SHARE MODE IMMEDIATE",
+            "UNLOCK TABLE SQLUser.orders IN SHARE MODE IMMEDIATE",
+        ]
+        assert locks.held == []
+
+    def test_weak_modes_take_no_lock(self):
+        iris = FakeIRIS()
+
+        result = asyncio.run(
+            TableLocks(iris.execute).lock(lock("LOCK TABLE orders IN ROW EXCLUSIVE MODE"), "0")
+        )
+
+        assert result["success"] and iris.statements == []
+
+    def test_nowait_conflict(self):
+        locks = TableLocks(FakeIRIS(failing="orders").execute)
+
+        result = asyncio.run(locks.lock(lock("LOCK TABLE orders NOWAIT"), "0"))
+
+        assert (result["sqlstate"], result["error"]) == (
+            "55P03",
+            'could not obtain lock on relation "orders"',
+        )
+
+    def test_other_failures_unchanged(self):
+        iris = FakeIRIS(failing="missing", error="Table 'SQLUSER.MISSING' not found")
+
+        result = asyncio.run(TableLocks(iris.execute).lock(lock("LOCK missing"), "0"))
+
+        assert (result["sqlstate"], result["error"]) == ("HV000", iris.error)
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol(transaction_status):
+        iris = FakeIRIS()
+        executor = MagicMock()
+        executor.execute_query = AsyncMock(side_effect=iris.execute)
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), executor, "locks")
+        protocol.transaction_status = transaction_status
+        return protocol, iris
+
+    def test_outside_transaction(self):
+        protocol, iris = self._protocol(STATUS_IDLE)
+
+        result = asyncio.run(protocol._execute_statement("LOCK TABLE orders;"))
+
+        assert result["sqlstate"] == "25P01"
+        assert iris.statements == []
+
+    def test_lock_timeout_setting(self):
+        protocol, iris = self._protocol(STATUS_IN_TRANSACTION)
+        protocol.session_settings.set("lock_timeout", "3000")
+
+        asyncio.run(protocol._execute_statement("LOCK TABLE orders IN EXCLUSIVE MODE;"))
+
+        assert protocol.session_settings.get("lock_timeout") == "3s"
+        assert iris.statements == ["LOCK TABLE SQLUser.orders IN EXCLUSIVE MODE WAIT 3"]
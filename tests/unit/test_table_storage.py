@@ -0,0 +1,77 @@
+"""
+Unit Tests: Columnar Table Storage
+
+CREATE TABLE ... USING columnar, and tables matching PGWIRE_COLUMNAR_TABLES,
+are created WITH STORAGETYPE = COLUMNAR.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.table_storage import (
+    load_columnar_tables,
+    matches_columnar_pattern,
+    rewrite_table_storage,
+)
+
+PATTERNS = ["*_facts", "analytics.*"]
+
+
+@pytest.mark.parametrize(
+    "sql,expected",
+    [
+        (
+            "CREATE TABLE sales_facts (id INT, amount NUMERIC(10, 2));",
+            "CREATE TABLE sales_facts (id INT, amount NUMERIC(10, 2)) "
+            "WITH STORAGETYPE = COLUMNAR;",
+        ),
+        (
+            "CREATE TABLE IF NOT EXISTS Analytics.Events (id INT) PARTITION BY RANGE (id)",
+            "CREATE TABLE IF NOT EXISTS Analytics.Events (id INT) PARTITION BY RANGE (id) "
+            "WITH STORAGETYPE = COLUMNAR",
+        ),
+        (
+            "CREATE TABLE readings (id INT) USING columnar",
+            "CREATE TABLE readings (id INT) WITH STORAGETYPE = COLUMNAR",
+        ),
+        ("CREATE TABLE sales_facts (id INT) USING heap", "CREATE TABLE sales_facts (id INT)"),
+    ],
+)
+def test_rewrite(sql, expected):
+    assert rewrite_table_storage(sql, PATTERNS) == (expected, 1)
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "CREATE TABLE orders (id INT)",
+        "CREATE TABLE sqluser.events (id INT)",
+        "CREATE TABLE sales_facts (id INT) WITH STORAGETYPE = ROW",
+        "CREATE TABLE sales_facts AS SELECT * FROM sales",
+        "CREATE TABLE readings (id INT) USING zheap",
+        "ALTER TABLE sales_facts ADD COLUMN note VARCHAR(50)",
+        "SELECT * FROM sales_facts",
+    ],
+)
+def test_unchanged(sql):
+    assert rewrite_table_storage(sql, PATTERNS) == (sql, 0)
+
+
+def test_patterns(monkeypatch):
+    monkeypatch.setenv("PGWIRE_COLUMNAR_TABLES", " *_Facts , analytics.* ,")
+
+    assert load_columnar_tables() == PATTERNS
+    assert matches_columnar_pattern("DAILY_FACTS", "SQLUser", PATTERNS)
+    assert matches_columnar_pattern("events", "ANALYTICS", PATTERNS)
+    assert not matches_columnar_pattern("events", None, PATTERNS)
+    assert not matches_columnar_pattern("facts", "SQLUser", PATTERNS)
+
+
+def test_normalizer_applies_configured_patterns(monkeypatch):
+    monkeypatch.setenv("PGWIRE_COLUMNAR_TABLES", "*_facts")
+    translator = SQLTranslator()
+
+    assert translator.normalize_sql("CREATE TABLE sales_facts (id INT)") == (
+        "CREATE TABLE SALES_FACTS (id INT) WITH STORAGETYPE = COLUMNAR"
+    )
+    assert translator.get_normalization_metrics()["ddl_clause_count"] == 1
@@ -0,0 +1,128 @@
+"""
+Unit Tests: Temporary Spool Files
+
+Rows kept in memory and spilled to temporary files, the disk limit,
+encryption at rest and the temp_files/temp_bytes statistics.
+"""
+
+import os
+
+import pytest
+
+from iris_pgwire import temp_spool
+from iris_pgwire.large_values import LargeValue
+from iris_pgwire.portal_cursors import PortalCursorRegistry
+from iris_pgwire.stats_hooks import get_stats
+from iris_pgwire.temp_spool import (
+    SpoolConfig,
+    TempFileLimitExceeded,
+    TempSpool,
+    disk_usage,
+)
+
+ROWS = [[i, f"row {i}", None] for i in range(1000)]
+
+
+def test_small_result_stays_in_memory(tmp_path):
+    spool = TempSpool(SpoolConfig(directory=str(tmp_path)))
+    spool.append(ROWS[:10])
+
+    assert not spool.spilled
+    assert spool.rows(8) == ROWS[8:10]
+    assert spool.rows(3, 5) == ROWS[3:5]
+    spool.close()
+
+
+def test_spilled_rows_read_back_in_any_order(tmp_path, monkeypatch):
+    monkeypatch.setattr(temp_spool, "CHUNK_BYTES", 512)
+    spool = TempSpool(SpoolConfig(memory_bytes=1024, directory=str(tmp_path)))
+    for start in range(0, len(ROWS), 100):
+        spool.append(ROWS[start : start + 100])
+
+    assert spool.spilled and len(spool) == 1000
+    assert spool.rows(0) == ROWS
+    assert spool.rows(995, 2000) == ROWS[995:]
+    assert spool.rows(500, 501) == [ROWS[500]]
+    assert spool.rows(10, 5) == []
+    assert os.listdir(tmp_path) == []  # The file is unlinked while open
+
+    usage = disk_usage()
+    spool.close()
+    assert disk_usage() == usage - spool.bytes_written
+    assert len(spool) == 0
+
+
+def test_disk_limit(tmp_path):
+    spool = TempSpool(SpoolConfig(memory_bytes=0, directory=str(tmp_path), max_bytes=2048))
+
+    with pytest.raises(TempFileLimitExceeded) as error:
+        spool.append(ROWS)
+    spool.close()
+
+    assert error.value.sqlstate == "53400"
+    assert "PGWIRE_SPOOL_MAX_BYTES" in str(error.value)
+
+
+def test_encrypted_at_rest(tmp_path):
+    pytest.importorskip("cryptography")
+    spool = TempSpool(SpoolConfig(memory_bytes=0, directory=str(tmp_path), encrypt=True))
+    spool.append([["secret value"]])
+
+    spool._file.seek(0)
+    assert b"secret value" not in spool._file.read()
+    assert spool.rows(0) == [["secret value"]]
+    spool.close()
+
+
+def test_spill_counted_in_database_statistics(tmp_path):
+    stats = get_stats()
+    stats.session_started("spool-stats", database="SPOOLDB")
+    try:
+        spool = TempSpool(SpoolConfig(memory_bytes=0, directory=str(tmp_path)))
+        spool.append(ROWS[:100])
+        spool.close()
+    finally:
+        stats.session_ended("spool-stats")
+
+    database = next(a for a in stats.database_activity() if a.datname == "SPOOLDB")
+    assert database.temp_files == 1
+    assert database.temp_bytes == spool.bytes_written > 0
+    assert stats.snapshot()["totals"]["temp_bytes"] >= spool.bytes_written
+
+
+def test_settings_from_environment(monkeypatch, tmp_path):
+    monkeypatch.setenv("PGWIRE_SPOOL_MEMORY_BYTES", "65536")
+    monkeypatch.setenv("PGWIRE_SPOOL_DIR", str(tmp_path))
+    monkeypatch.setenv("PGWIRE_SPOOL_MAX_BYTES", "1048576")
+
+    assert SpoolConfig.from_env() == SpoolConfig(65536, str(tmp_path), 1048576, False)
+
+    monkeypatch.setenv("PGWIRE_SPOOL_MAX_BYTES", "1GB")
+    with pytest.raises(ValueError, match="PGWIRE_SPOOL_MAX_BYTES"):
+        SpoolConfig.from_env()
+
+
+def test_large_portal_result_spooled(tmp_path):
+    config = SpoolConfig(memory_bytes=0, directory=str(tmp_path))
+    registry = PortalCursorRegistry(spool_config=config)
+    result = {"success": True, "rows": ROWS[:5], "columns": [{"name": "id", "type_oid": 23}]}
+    cursor = registry.open("p", result)
+
+    assert cursor.spool is not None and cursor.result["rows"] == []
+    assert cursor.fetch(3) == ROWS[:3]
+    assert cursor.fetch(0) == ROWS[3:5] and cursor.exhausted
+
+    registry.close("p")
+    assert len(cursor.spool) == 0
+
+
+def test_large_values_spilled_by_reference(tmp_path):
+    value = LargeValue(binary=False, config=SpoolConfig(directory=str(tmp_path)))
+    value.write("é".encode() * 10)
+    spool = TempSpool(SpoolConfig(memory_bytes=0, directory=str(tmp_path)))
+    spool.append([[1, value], [2, "two"]])
+
+    assert spool.spilled
+    assert spool.rows(0) == [[1, value], [2, "two"]]
+    assert spool.rows(0)[0][1].read() == "é" * 10
+    spool.close()
@@ -0,0 +1,114 @@
+"""
+Unit Tests: DATE, TIME, TIMETZ and INTERVAL Values
+
+Text and binary result forms, $HOROLOG dates and interval input parsing.
+"""
+
+import datetime
+import struct
+
+import pytest
+
+from iris_pgwire.bind_params import interval_text
+from iris_pgwire.temporal_values import (
+    encode_temporal,
+    format_temporal,
+    horolog_to_date,
+    parse_interval,
+)
+
+DAY = 86_400_000_000
+HOUR = 3_600_000_000
+
+
+class TestDates:
+    """The executor's day numbers, ISO text and $HOROLOG."""
+
+    def test_horolog_epoch(self):
+        assert horolog_to_date(0) == datetime.date(1840, 12, 31)
+        assert horolog_to_date(58074) == datetime.date(2000, 1, 1)
+
+    @pytest.mark.parametrize(
+        "value",
+        [datetime.date(2024, 3, 10), 8835, "8835", "2024-03-10", "66909,3600"],
+    )
+    def test_text_and_binary(self, value):
+        assert format_temporal(value, 1082) == "2024-03-10"
+        assert encode_temporal(value, 1082) == struct.pack("!i", 8835)
+
+
+class TestTimes:
+    """time and timetz."""
+
+    @pytest.mark.parametrize(
+        "value, text",
+        [
+            (datetime.time(12, 30), "12:30:00"),
+            ("12:30:00.500", "12:30:00.5"),
+            (45_000, "12:30:00"),  # Logical %Time: seconds since midnight
+            (datetime.timedelta(hours=12, minutes=30), "12:30:00"),
+        ],
+    )
+    def test_time(self, value, text):
+        assert format_temporal(value, 1083) == text
+        micros = struct.unpack("!q", encode_temporal(value, 1083))[0]
+        assert micros // 1_000_000 == 45_000
+
+    def test_timetz(self):
+        assert format_temporal("12:30:00+02", 1266) == "12:30:00+02"
+        assert format_temporal("12:30:00-05:30", 1266) == "12:30:00-05:30"
+        assert format_temporal("12:30:00", 1266) == "12:30:00+00"
+        # Binary zone is in seconds west of UTC
+        assert encode_temporal("00:00:01+02", 1266) == struct.pack("!qi", 1_000_000, -7200)
+
+    def test_invalid_time(self):
+        with pytest.raises(ValueError):
+            format_temporal("noon", 1083)
+
+
+class TestIntervals:
+    """Interval input parsing and postgres-style output."""
+
+    @pytest.mark.parametrize(
+        "text, expected",
+        [
+            ("1 day 02:03:04", (0, 1, 2 * HOUR + 3 * 60_000_000 + 4_000_000)),
+            ("1 year 2 mons 3 days", (14, 3, 0)),
+            ("@ 3 hours ago", (0, 0, -3 * HOUR)),
+            ("1.5 days", (0, 1, 12 * HOUR)),
+            ("1.5 months", (1, 15, 0)),
+            ("2 weeks 90 seconds", (0, 14, 90_000_000)),
+            ("-01:30", (0, 0, -90 * 60_000_000)),
+            ("P1Y2M3DT4H5M6S", (14, 3, 4 * HOUR + 5 * 60_000_000 + 6_000_000)),
+            ("10", (0, 0, 10_000_000)),
+        ],
+    )
+    def test_parse(self, text, expected):
+        assert parse_interval(text) == expected
+
+    @pytest.mark.parametrize("text", ["", "1 fortnight", "yesterday"])
+    def test_invalid(self, text):
+        with pytest.raises(ValueError):
+            parse_interval(text)
+
+    @pytest.mark.parametrize(
+        "value, text",
+        [
+            ("1 day 02:03:04", "1 day 02:03:04"),
+            ("1 year 2 mons", "1 year 2 mons"),
+            (datetime.timedelta(days=2, seconds=1.5), "2 days 00:00:01.5"),
+            (0, "00:00:00"),
+            ("-1 days 2 hours", "-1 days +02:00:00"),
+        ],
+    )
+    def test_text(self, value, text):
+        assert format_temporal(value, 1186) == text
+
+    def test_binary(self):
+        assert encode_temporal("1 mon 2 days 00:00:03", 1186) == struct.pack(
+            "!qii", 3_000_000, 2, 1
+        )
+
+    def test_negative_fields_are_plural(self):
+        assert interval_text(0, -1, 0) == "-1 days"
+        assert interval_text(0, 1, -12) == "-1 years +1 day"
@@ -0,0 +1,155 @@
+"""
+Unit Tests: Session TimeZone Support
+
+Zone name validation, offset conventions, DST-correct timestamptz rendering
+and timestamp values from IRIS (%TimeStamp text, %PosixTime integers).
+"""
+
+import datetime
+
+import pytest
+
+from iris_pgwire.session_settings import InvalidParameterValue, SessionSettings
+from iris_pgwire.timezone_support import (
+    POSIX_TIME_OFFSET,
+    format_timestamp,
+    format_timestamptz,
+    interval_timezone_name,
+    normalize_timezone_name,
+    posix_time_to_datetime,
+    resolve_timezone,
+    timestamp_to_pg_microseconds,
+    timestamptz_to_pg_microseconds,
+    to_utc,
+)
+
+
+class TestTimezoneNames:
+    """TimeZone setting validation and canonical display."""
+
+    @pytest.mark.parametrize(
+        "value,expected",
+        [
+            ("america/new_york", "America/New_York"),
+            ("Europe/Berlin", "Europe/Berlin"),
+            ("utc", "UTC"),
+            ("-8", "<-08>+08"),  # SQL convention: west of UTC
+            ("5.5", "<+05:30>-05:30"),
+            ("+05:30", "<-05:30>+05:30"),  # POSIX convention: west of UTC
+        ],
+    )
+    def test_normalize(self, value, expected):
+        assert normalize_timezone_name(value) == expected
+
+    def test_unknown_zone_rejected(self):
+        with pytest.raises(ValueError):
+            normalize_timezone_name("Mars/Olympus_Mons")
+
+    def test_session_setting_rejects_unknown_zone(self):
+        settings = SessionSettings()
+
+        with pytest.raises(InvalidParameterValue) as exc_info:
+            settings.set("TimeZone", "Mars/Olympus_Mons")
+
+        assert exc_info.value.sqlstate == "22023"
+
+    def test_offset_zone_resolves(self):
+        tz = resolve_timezone("<-08>+08")
+
+        assert tz.utcoffset(None) == datetime.timedelta(hours=-8)
+
+    def test_interval_zone_counts_east(self):
+        assert interval_timezone_name("INTERVAL '+05:30' HOUR TO MINUTE") == "<+05:30>-05:30"
+        assert interval_timezone_name("interval '-08:00'") == "<-08>+08"
+        assert interval_timezone_name("'Europe/Berlin'") is None
+        with pytest.raises(ValueError):
+            interval_timezone_name("INTERVAL '+16:00' HOUR TO MINUTE")
+
+    def test_integer_datetimes_is_read_only(self):
+        settings = SessionSettings()
+
+        assert settings.get("integer_datetimes") == "on"
+        with pytest.raises(InvalidParameterValue):
+            settings.set("integer_datetimes", "off")
+
+
+class TestTimestamptzRendering:
+    """Stored UTC values rendered in the session zone."""
+
+    def test_dst_transition_new_york(self):
+        tz = resolve_timezone("America/New_York")
+
+        assert format_timestamptz("2024-03-10 06:59:59", tz) == "2024-03-10 01:59:59-05"
+        assert format_timestamptz("2024-03-10 07:00:00", tz) == "2024-03-10 03:00:00-04"
+
+    def test_fractional_seconds_and_half_hour_offset(self):
+        tz = resolve_timezone("Asia/Kolkata")
+        value = datetime.datetime(2024, 1, 1, 0, 0, 0, 250000)
+
+        assert format_timestamptz(value, tz) == "2024-01-01 05:30:00.25+05:30"
+
+    def test_utc(self):
+        assert format_timestamptz("2024-01-01 12:00:00", datetime.UTC) == "2024-01-01 12:00:00+00"
+
+
+class TestTimestamptzInput:
+    """Client timestamptz values converted to UTC for IRIS storage."""
+
+    def test_naive_input_uses_session_zone(self):
+        tz = resolve_timezone("Europe/Berlin")
+
+        utc = to_utc("2024-07-01 12:00:00", tz)
+
+        assert utc == datetime.datetime(2024, 7, 1, 10, 0, tzinfo=datetime.UTC)
+
+    def test_explicit_offset_wins(self):
+        tz = resolve_timezone("Europe/Berlin")
+
+        utc = to_utc("2024-07-01 12:00:00-05", tz)
+
+        assert utc == datetime.datetime(2024, 7, 1, 17, 0, tzinfo=datetime.UTC)
+
+    def test_binary_epoch(self):
+        assert timestamptz_to_pg_microseconds("2000-01-01 00:00:01") == 1_000_000
+
+
+class TestTimestampValues:
+    """Stored timestamps: %PosixTime integers and PostgreSQL text/binary forms."""
+
+    def test_posix_time(self):
+        moment = datetime.datetime(2024, 1, 1, 12, 0, 0, 500000)
+        micros = (moment - datetime.datetime(1970, 1, 1)) // datetime.timedelta(microseconds=1)
+
+        assert posix_time_to_datetime(micros + POSIX_TIME_OFFSET) == moment
+        assert posix_time_to_datetime(-POSIX_TIME_OFFSET - 1_000_000) == datetime.datetime(
+            1969, 12, 31, 23, 59, 59
+        )
+        with pytest.raises(ValueError):
+            posix_time_to_datetime(42)
+
+    @pytest.mark.parametrize(
+        "value,expected",
+        [
+            ("2024-01-01 12:00:00.000", "2024-01-01 12:00:00"),
+            (datetime.datetime(2024, 1, 1, 12, 0, 0, 250000), "2024-01-01 12:00:00.25"),
+            (datetime.date(2024, 1, 1), "2024-01-01 00:00:00"),
+            (POSIX_TIME_OFFSET + 1_000_001, "1970-01-01 00:00:01.000001"),
+        ],
+    )
+    def test_format_timestamp(self, value, expected):
+        assert format_timestamp(value) == expected
+
+    def test_binary_timestamp_keeps_wall_time(self):
+        aware = datetime.datetime(2000, 1, 1, 0, 0, 1, tzinfo=resolve_timezone("Europe/Berlin"))
+
+        assert timestamp_to_pg_microseconds("2000-01-01 00:00:01") == 1_000_000
+        assert timestamp_to_pg_microseconds(aware) == 1_000_000
+        assert timestamp_to_pg_microseconds(POSIX_TIME_OFFSET) == -946_684_800_000_000
+
+    def test_posix_time_timestamptz(self):
+        value = POSIX_TIME_OFFSET + 946_684_800_000_000  # 2000-01-01 00:00:00 UTC
+
+        assert timestamptz_to_pg_microseconds(value) == 0
+        assert format_timestamptz(value, resolve_timezone("Asia/Tokyo")) == (
+            "2000-01-01 09:00:00+09"
+        )
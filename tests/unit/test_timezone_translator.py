@@ -0,0 +1,174 @@
+"""
+Unit Tests: AT TIME ZONE Translation
+
+PostgreSQL AT TIME ZONE (and timezone()) rewritten to IRIS DATEADD arithmetic.
+timestamptz values are stored as naive UTC in IRIS.
+"""
+
+import asyncio
+import sys
+from concurrent.futures import ThreadPoolExecutor
+from types import SimpleNamespace
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.sql_translator.timezone_translator import (
+    TimeZoneTranslator,
+    helper_ddl,
+    helper_zones,
+    offset_transitions,
+)
+
+
+@pytest.fixture
+def translator():
+    return TimeZoneTranslator()
+
+
+class TestIdentityAndFixedOffsets:
+    """Zones without DST."""
+
+    def test_utc_is_identity(self, translator):
+        sql, count = translator.translate("SELECT TS AT TIME ZONE 'UTC' AS T FROM EVENTS")
+
+        assert sql == "SELECT (TS) AS T FROM EVENTS"
+        assert count == 1
+
+    def test_fixed_offset_column(self, translator):
+        sql, _ = translator.translate("SELECT TS AT TIME ZONE '+05:30' FROM EVENTS")
+
+        # POSIX '+05:30' is 5h30 west of UTC: local wall clock → UTC adds 19800 seconds
+        assert sql == "SELECT DATEADD('ss', 19800, TS) FROM EVENTS"
+
+    def test_timezone_function_form(self, translator):
+        sql, count = translator.translate("SELECT TIMEZONE('UTC', E.TS) FROM EVENTS E")
+
+        assert sql == "SELECT ((E.TS)) FROM EVENTS E"
+        assert count == 1
+
+
+class TestLiteralOperands:
+    """Literals are converted at translation time."""
+
+    def test_timestamptz_literal_to_local(self, translator):
+        sql, _ = translator.translate(
+            "SELECT CAST('2024-07-01 12:00:00+00' AS TIMESTAMPTZ) AT TIME ZONE 'America/New_York'"
+        )
+
+        assert sql == "SELECT '2024-07-01 08:00:00'"
+
+    def test_timestamp_literal_to_utc(self, translator):
+        sql, _ = translator.translate(
+            "SELECT TIMESTAMP '2024-01-15 12:00:00' AT TIME ZONE 'Europe/Berlin'"
+        )
+
+        assert sql == "SELECT '2024-01-15 11:00:00'"
+
+
+class TestDstZones:
+    """Region zones call the zone's IRIS function over its offset transitions."""
+
+    def test_new_york_transitions_found(self):
+        initial, transitions = offset_transitions("America/New_York")
+
+        assert initial == -18000
+        spring_2024 = [t for t, offset in transitions if t.year == 2024 and offset == -14400]
+        assert spring_2024[0].strftime("%Y-%m-%d %H:%M:%S") == "2024-03-10 07:00:00"
+
+    def test_column_calls_zone_function(self, translator):
+        sql, count = translator.translate(
+            "SELECT CREATED_AT AT TIME ZONE 'America/New_York' FROM T"
+        )
+
+        assert count == 1
+        # A timestamp column is wall-clock time in the zone (0), converted to UTC
+        assert sql == (
+            "SELECT DATEADD('ss', PGWIRE_TZ_America_New_York("
+            "DATEDIFF('s', '1970-01-01 00:00:00', CREATED_AT), 0), CREATED_AT) FROM T"
+        )
+
+    def test_chained_grafana_pattern(self, translator):
+        sql, count = translator.translate(
+            "SELECT TS AT TIME ZONE 'UTC' AT TIME ZONE 'America/New_York' FROM METRICS"
+        )
+
+        assert count == 2
+        # Second conversion treats the UTC result as timestamptz (1)
+        assert sql == (
+            "SELECT DATEADD('ss', PGWIRE_TZ_America_New_York("
+            "DATEDIFF('s', '1970-01-01 00:00:00', (TS)), 1), (TS)) FROM METRICS"
+        )
+
+    def test_zone_function_holds_both_directions(self):
+        ddl = helper_ddl("America/New_York")
+
+        assert ddl.startswith(
+            "CREATE OR REPLACE FUNCTION PGWIRE_TZ_America_New_York(instant BIGINT, utc INT)"
+        )
+        # 2024-03-10 07:00:00 UTC, which is 02:00 New York standard time
+        assert "instant>=1710054000:-14400," in ddl
+        assert "instant>=1710036000:14400," in ddl
+        assert ddl.endswith(",1:18000)\n}")
+
+    def test_zones_needing_functions(self):
+        sql = (
+            "SELECT TS AT TIME ZONE 'utc', TIMEZONE('europe/paris', TS), "
+            "TS AT TIME ZONE 'America/New_York' FROM T "
+            "WHERE NOTE = 'at time zone ''Europe/London'''"
+        )
+
+        assert helper_zones(sql) == ["America/New_York", "Europe/Paris"]
+
+
+class TestUntranslatable:
+    """Forms that must be left unchanged."""
+
+    def test_parameter_zone_left_alone(self, translator):
+        sql = "SELECT TS AT TIME ZONE ? FROM EVENTS"
+
+        assert translator.translate(sql) == (sql, 0)
+
+    def test_phrase_inside_literal_ignored(self, translator):
+        sql = "SELECT X FROM T WHERE NOTE = 'at time zone ''UTC'''"
+
+        assert translator.translate(sql) == (sql, 0)
+
+    def test_unknown_zone_left_alone(self, translator):
+        sql = "SELECT TS AT TIME ZONE 'Mars/Base' FROM EVENTS"
+
+        assert translator.translate(sql) == (sql, 0)
+
+
+class TestZoneFunctions:
+    """The executor creates a region zone's function once."""
+
+    @staticmethod
+    def _executor(monkeypatch, executed):
+        iris = SimpleNamespace(sql=SimpleNamespace(exec=executed.append))
+        monkeypatch.setitem(sys.modules, "iris", iris)
+        executor = IRISExecutor.__new__(IRISExecutor)
+        executor.embedded_mode = True
+        executor.thread_pool = ThreadPoolExecutor(max_workers=1)
+        executor._timezone_helpers = set()
+        return executor
+
+    def test_function_created_for_new_zone(self, monkeypatch):
+        executed = []
+        executor = self._executor(monkeypatch, executed)
+
+        asyncio.run(executor._create_timezone_helpers(["Europe/Paris"]))
+
+        assert executed == [helper_ddl("Europe/Paris")]
+        assert executor._timezone_helpers == {"Europe/Paris"}
+
+    def test_failed_creation_is_retried_later(self, monkeypatch):
+        def refuse(statement):
+            raise RuntimeError("User lacks %CREATE_FUNCTION privilege")
+
+        executor = self._executor(monkeypatch, [])
+        sys.modules["iris"].sql.exec = refuse
+
+        asyncio.run(executor._create_timezone_helpers(["Europe/Paris"]))
+
+        assert executor._timezone_helpers == set()
@@ -0,0 +1,100 @@
+"""
+Unit Tests: DDL Inside Transactions
+
+iris.ddl_in_transaction warnings and errors for DDL in explicit transactions,
+and the iris.transactional_ddl capability parameter.
+"""
+
+import asyncio
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import STATUS_IDLE, STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.session_settings import InvalidParameterValue
+from iris_pgwire.transactional_ddl import ddl_command
+from tests.protocol_messages import FakeWriter
+
+
+def _protocol(mode="warn", transaction_status=STATUS_IN_TRANSACTION):
+    executor = MagicMock()
+    executor.execute_query = AsyncMock(
+        return_value={"success": True, "rows": [], "columns": [], "command_tag": "CREATE TABLE"}
+    )
+    writer = FakeWriter()
+    protocol = PGWireProtocol(MagicMock(), writer, executor, "transactional-ddl")
+    protocol.session_settings.set("iris.ddl_in_transaction", mode)
+    protocol.transaction_status = transaction_status
+    return protocol, executor, writer
+
+
+class TestDetection:
+    @pytest.mark.parametrize(
+        "sql,command",
+        [
+            ("CREATE TABLE t (id INT)", "CREATE TABLE"),
+            ("create unique index idx on t (id)", "CREATE INDEX"),
+            ("CREATE OR REPLACE VIEW v AS SELECT 1", "CREATE VIEW"),
+            ("DROP MATERIALIZED VIEW mv", "DROP VIEW"),
+            ("ALTER TABLE t ADD COLUMN c INT", "ALTER TABLE"),
+            ("INSERT INTO t VALUES (1)", None),
+            ("SELECT 'CREATE TABLE'", None),
+        ],
+    )
+    def test_ddl_command(self, sql, command):
+        assert ddl_command(sql) == command
+
+
+class TestInTransaction:
+    def test_warning(self):
+        protocol, executor, _ = _protocol()
+
+        result = asyncio.run(protocol._execute_statement("CREATE TABLE t (id INT)"))
+
+        assert result["success"]
+        assert "CREATE TABLE takes effect immediately" in result["notices"][0]
+        executor.execute_query.assert_awaited_once()
+
+    def test_error(self):
+        protocol, executor, _ = _protocol(mode="error")
+
+        result = asyncio.run(protocol._execute_statement("DROP TABLE t"))
+
+        assert (result["sqlstate"], result["error"]) == (
+            "25001",
+            "DROP TABLE cannot run inside a transaction block",
+        )
+        executor.execute_query.assert_not_awaited()
+
+    @pytest.mark.parametrize(
+        "mode,transaction_status,sql",
+        [
+            ("allow", STATUS_IN_TRANSACTION, "CREATE TABLE t (id INT)"),
+            ("error", STATUS_IDLE, "CREATE TABLE t (id INT)"),
+            ("error", STATUS_IN_TRANSACTION, "INSERT INTO t VALUES (1)"),
+        ],
+    )
+    def test_unaffected(self, mode, transaction_status, sql):
+        protocol, executor, _ = _protocol(mode, transaction_status)
+
+        result = asyncio.run(protocol._execute_statement(sql))
+
+        assert result["success"] and "notices" not in result
+        executor.execute_query.assert_awaited_once()
+
+
+class TestParameters:
+    def test_capability_reported(self):
+        protocol, _, writer = _protocol()
+
+        asyncio.run(protocol.send_parameter_status())
+
+        assert b"iris.transactional_ddl\x00off\x00" in writer.buffer
+        with pytest.raises(InvalidParameterValue):
+            protocol.session_settings.set("iris.transactional_ddl", "on")
+
+    def test_mode_validated(self):
+        protocol, _, _ = _protocol()
+
+        with pytest.raises(InvalidParameterValue):
+            protocol.session_settings.set("iris.ddl_in_transaction", "ignore")
@@ -0,0 +1,137 @@
+"""
+Unit Tests: pg_trgm Similarity Shims
+
+similarity()/word_similarity() and the %, <%, %> operators rewritten into
+IRIS INSTR() trigram arithmetic.
+"""
+
+import asyncio
+
+import pytest
+
+from iris_pgwire.iris_executor import IRISExecutor
+from iris_pgwire.sql_translator.trigram_translator import (
+    AmbiguousOperator,
+    TrigramTranslator,
+    inline_parameter_operands,
+    similarity,
+    trigrams,
+    word_similarity,
+)
+
+
+@pytest.fixture
+def translator():
+    return TrigramTranslator()
+
+
+class TestTrigramMath:
+    """Python reference implementation matches pg_trgm."""
+
+    def test_trigrams_match_show_trgm(self):
+        # SELECT show_trgm('Cat') → {"  c"," ca","at ",cat}
+        assert trigrams("Cat") == {"  c", " ca", "at ", "cat"}
+
+    def test_similarity(self):
+        # SELECT similarity('word', 'two words') → 0.36363637
+        assert similarity("word", "two words") == pytest.approx(0.363636, abs=1e-6)
+
+    def test_word_similarity(self):
+        # SELECT word_similarity('word', 'two words') → 0.8
+        assert word_similarity("word", "two words") == pytest.approx(0.8)
+
+    def test_empty_strings(self):
+        assert similarity("", "abc") == 0.0
+
+
+class TestFunctionTranslation:
+    """similarity() / word_similarity() calls."""
+
+    def test_literal_arguments_are_folded(self, translator):
+        sql, count = translator.translate("SELECT SIMILARITY('word', 'two words')")
+
+        assert sql == "SELECT 0.363636"
+        assert count == 1
+
+    def test_column_against_literal_uses_instr_probes(self, translator):
+        sql, _ = translator.translate("SELECT SIMILARITY(NAME, 'ab') FROM T")
+
+        padded = "' ' || LOWER(NAME) || ' '"
+        shared = (
+            f"(SIGN(INSTR({padded}, ' a')) + "
+            f"SIGN(INSTR({padded}, ' ab')) + "
+            f"SIGN(INSTR({padded}, 'ab ')))"
+        )
+        assert sql == (
+            f"SELECT (CAST({shared} AS DOUBLE) / ((LENGTH(TRIM(NAME)) + 1) + 3 - {shared})) FROM T"
+        )
+
+    def test_two_columns_degrade_to_equality(self, translator):
+        sql, _ = translator.translate("SELECT SIMILARITY(A.X, B.Y) FROM A, B")
+
+        assert sql == "SELECT (CASE WHEN LOWER(A.X) = LOWER(B.Y) THEN 1.0 ELSE 0.0 END) FROM A, B"
+
+
+class TestOperators:
+    """%, <% and %> operators."""
+
+    def test_percent_with_literal_is_similarity_threshold(self, translator):
+        sql, count = translator.translate("SELECT * FROM T WHERE NAME % 'ab'")
+
+        # shared / (column + 3 - shared) >= 0.3, with the probes written once
+        padded = "' ' || LOWER(NAME) || ' '"
+        assert sql == (
+            f"SELECT * FROM T WHERE (SIGN(INSTR({padded}, ' a')) + SIGN(INSTR({padded}, ' ab')) + "
+            f"SIGN(INSTR({padded}, 'ab '))) * 1.3 >= 0.3 * ((LENGTH(TRIM(NAME)) + 1) + 3)"
+        )
+        assert count == 1
+
+    def test_word_similarity_operator(self, translator):
+        sql, _ = translator.translate("SELECT * FROM T WHERE 'ab' <% NAME")
+
+        assert sql.endswith("SIGN(INSTR(' ' || LOWER(NAME) || ' ', 'ab '))) >= 1.8")
+
+    def test_modulo_is_left_alone(self, translator):
+        sql = "SELECT ID % 3 FROM T"
+
+        assert translator.translate(sql) == (sql, 0)
+
+    def test_iris_percent_names_and_like_patterns_left_alone(self, translator):
+        sql = "SELECT %ID FROM T WHERE NAME LIKE '%ab%'"
+
+        assert translator.translate(sql) == (sql, 0)
+
+
+class TestParameterOperands:
+    """% with a parameter is pg_trgm for text values and modulo for numbers."""
+
+    def test_text_value_becomes_similarity_literal(self):
+        sql, params = inline_parameter_operands(
+            "SELECT * FROM T WHERE NAME % ? AND ID = ?", ["o'brien", 7]
+        )
+
+        assert sql == "SELECT * FROM T WHERE NAME % 'o''brien' AND ID = ?"
+        assert params == [7]
+
+    def test_numeric_value_becomes_modulo_literal(self):
+        assert inline_parameter_operands("SELECT ID % $1 FROM T WHERE $1 > 0", ["3"]) == (
+            "SELECT ID % 3 FROM T WHERE $1 > 0",
+            ["3"],
+        )
+
+    def test_parameter_without_value_is_not_supported(self):
+        with pytest.raises(AmbiguousOperator, match=r"operator % with parameter \$1"):
+            inline_parameter_operands("SELECT * FROM T WHERE NAME % $1", None)
+
+    def test_statements_without_parameter_operands_unchanged(self):
+        sql = "SELECT * FROM T WHERE NAME LIKE '%' || ? AND ID % 2 = 0"
+
+        assert inline_parameter_operands(sql, ["a"]) == (sql, ["a"])
+
+    def test_executor_reports_feature_not_supported(self):
+        executor = IRISExecutor.__new__(IRISExecutor)
+
+        result = asyncio.run(executor._execute_query("SELECT * FROM T WHERE NAME % $1"))
+
+        assert result["success"] is False
+        assert result["sqlstate"] == "0A000"
@@ -0,0 +1,141 @@
+"""
+Unit Tests: UUID Columns and Parameters
+
+UUID columns are created as PGWIRE_UUID_COLUMN_TYPE and described as uuid;
+uuid parameters in any accepted form are bound as lowercase hyphenated text.
+"""
+
+import asyncio
+import struct
+import uuid
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.column_types import describe_column
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.sql_translator.ddl_translator import DDLTranslator
+from iris_pgwire.uuid_values import InvalidUUID, format_uuid, parse_uuid, uuid_column_type
+
+VALUE = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"
+
+
+@pytest.mark.parametrize(
+    "text",
+    [
+        VALUE,
+        VALUE.upper(),
+        "{" + VALUE + "}",
+        VALUE.replace("-", ""),
+        " " + VALUE + " ",
+    ],
+)
+def test_uuid_input_forms(text):
+    assert parse_uuid(text) == VALUE
+
+
+def test_invalid_uuid_input():
+    with pytest.raises(InvalidUUID, match='invalid input syntax for type uuid: "abc"'):
+        parse_uuid("abc")
+
+
+def test_uuid_output():
+    assert format_uuid(VALUE.upper()) == VALUE
+    assert format_uuid(uuid.UUID(VALUE)) == VALUE
+    assert format_uuid("not a uuid") == "not a uuid"
+
+
+@pytest.mark.parametrize(
+    "value,expected",
+    [
+        (None, "UNIQUEIDENTIFIER"),
+        ("%Library.UniqueIdentifier", "UNIQUEIDENTIFIER"),
+        ("char(36)", "CHAR(36)"),
+        ("VARCHAR ( 40 )", "VARCHAR(40)"),
+        ("CHAR(16)", "UNIQUEIDENTIFIER"),  # Too short for the text form
+        ("INTEGER", "UNIQUEIDENTIFIER"),
+    ],
+)
+def test_column_type_setting(monkeypatch, value, expected):
+    monkeypatch.delenv("PGWIRE_UUID_COLUMN_TYPE", raising=False)
+    if value is not None:
+        monkeypatch.setenv("PGWIRE_UUID_COLUMN_TYPE", value)
+
+    assert uuid_column_type() == expected
+
+
+def test_uuid_columns_created_as_configured_type(monkeypatch):
+    translator = DDLTranslator()
+    sql = "CREATE TABLE users (id UUID PRIMARY KEY, \"uuid\" INT, note TEXT DEFAULT 'uuid')"
+
+    monkeypatch.delenv("PGWIRE_UUID_COLUMN_TYPE", raising=False)
+    assert translator.translate(sql) == (
+        "CREATE TABLE users (id UNIQUEIDENTIFIER PRIMARY KEY, \"uuid\" INT, "
+        "note TEXT DEFAULT 'uuid')",
+        1,
+    )
+    monkeypatch.setenv("PGWIRE_UUID_COLUMN_TYPE", "CHAR(36)")
+    assert translator.translate("ALTER TABLE users ADD COLUMN ref uuid") == (
+        "ALTER TABLE users ADD COLUMN ref CHAR(36)",
+        1,
+    )
+    assert translator.translate("SELECT gen_random_uuid()")[1] == 0
+
+
+def test_result_columns_described_as_uuid(monkeypatch):
+    monkeypatch.delenv("PGWIRE_UUID_COLUMN_TYPE", raising=False)
+    assert describe_column(-11, 36, 0) == (2950, 16, -1)  # SQL_GUID
+    assert describe_column("UNIQUEIDENTIFIER") == (2950, 16, -1)
+    assert describe_column(1, 36, 0) == (1042, -1, 40)
+
+    monkeypatch.setenv("PGWIRE_UUID_COLUMN_TYPE", "CHAR(36)")
+    assert describe_column(1, 36, 0) == (2950, 16, -1)
+    assert describe_column("CHAR(36)") == (2950, 16, -1)
+    assert describe_column(1, 35, 0) == (1042, -1, 39)
+    assert describe_column(12, 36, 0) == (1043, -1, 40)  # VARCHAR(36) is not the type
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol():
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), MagicMock(), "uuid")
+        protocol.writer.drain = AsyncMock()
+        protocol.prepared_statements["s"] = {"query": "SELECT ?", "param_types": [2950, 2950]}
+        return protocol
+
+    @staticmethod
+    def _bind(values, formats) -> bytes:
+        body = b"\x00s\x00" + struct.pack("!H", len(formats))
+        body += b"".join(struct.pack("!H", code) for code in formats)
+        body += struct.pack("!H", len(values))
+        body += b"".join(struct.pack("!I", len(value)) + value for value in values)
+        return body + struct.pack("!H", 0)
+
+    def test_parameters_bound_as_text_form(self):
+        protocol = self._protocol()
+        body = self._bind([VALUE.upper().encode(), uuid.UUID(VALUE).bytes], [0, 1])
+
+        asyncio.run(protocol.handle_bind_message(body))
+
+        assert protocol.portals[""]["params"] == [VALUE, VALUE]
+
+    def test_invalid_parameter_fails_with_22p02(self):
+        protocol = self._protocol()
+        protocol.send_error_response = AsyncMock()
+
+        asyncio.run(protocol.handle_bind_message(self._bind([b"12345", VALUE.encode()], [0])))
+
+        assert "" not in protocol.portals
+        assert protocol.send_error_response.call_args.args[1] == "22P02"
+
+    def test_results_sent_in_text_and_binary(self):
+        protocol = self._protocol()
+        columns = [{"name": "id", "type_oid": 2950}, {"name": "ref", "type_oid": 2950}]
+
+        protocol._current_result_formats = [0, 1]
+        asyncio.run(protocol.send_data_row([VALUE.upper(), VALUE.upper()], columns))
+
+        sent = b"".join(call.args[0] for call in protocol.writer.write.call_args_list)
+        assert sent.endswith(
+            struct.pack("!I", 36) + VALUE.encode() + struct.pack("!I", 16) + uuid.UUID(VALUE).bytes
+        )
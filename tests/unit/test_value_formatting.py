@@ -0,0 +1,97 @@
+"""
+Unit Tests: GUC-Controlled Text Rendering
+
+bytea_output and lc_monetary must change text-format output exactly as
+PostgreSQL does; numeric text keeps every digit.
+"""
+
+from decimal import Decimal
+
+import pytest
+
+from iris_pgwire.value_formatting import (
+    InvalidBytea,
+    format_bytea,
+    format_money,
+    format_numeric,
+    parse_bytea,
+)
+
+
+class TestFormatBytea:
+    """bytea_output = hex | escape."""
+
+    def test_hex_output(self):
+        assert format_bytea(b"\x00\xffA") == "\\x00ff41"
+
+    def test_escape_output(self):
+        assert format_bytea(b"ab\x00\\\n", "escape") == "ab\\000\\\\\\012"
+
+    def test_empty_value(self):
+        assert format_bytea(b"") == "\\x"
+        assert format_bytea(b"", "escape") == ""
+
+    @pytest.mark.parametrize(
+        "text,expected",
+        [
+            ("\\x00ff41", b"\x00\xffA"),
+            ("\\x00 FF\n41", b"\x00\xffA"),
+            ("ab\\000\\\\\\012", b"ab\x00\\\n"),
+            ("é", "é".encode()),
+            ("", b""),
+        ],
+    )
+    def test_parse_reads_both_forms(self, text, expected):
+        assert parse_bytea(text) == expected
+        assert parse_bytea(format_bytea(expected, "escape")) == expected
+
+    @pytest.mark.parametrize("text", ["\\xabc", "\\xzz", "a\\b", "\\400", "\\12"])
+    def test_parse_rejects_invalid_text(self, text):
+        with pytest.raises(InvalidBytea, match="invalid input syntax for type bytea"):
+            parse_bytea(text)
+
+
+class TestFormatMoney:
+    """lc_monetary-dependent money rendering."""
+
+    @pytest.mark.parametrize(
+        "value,lc_monetary,expected",
+        [
+            ("1234.5", "C", "$1,234.50"),
+            ("-3", "C", "-$3.00"),
+            ("1234567.891", "en_US.UTF-8", "$1,234,567.89"),
+            ("1234.5", "en_GB", "£1,234.50"),
+            ("1234.5", "de_DE.UTF-8", "1.234,50 €"),
+            ("1234.5", "ja_JP", "￥1,234"),
+            ("12", "xx_YY", "$12.00"),
+        ],
+    )
+    def test_locales(self, value, lc_monetary, expected):
+        assert format_money(value, lc_monetary) == expected
+
+    def test_non_numeric_passthrough(self):
+        assert format_money("n/a") == "n/a"
+
+
+class TestFormatNumeric:
+    """numeric text output: every digit, no exponent, the column's scale."""
+
+    @pytest.mark.parametrize(
+        "value,scale,expected",
+        [
+            (Decimal("12345678901234567890.123456789"), None, "12345678901234567890.123456789"),
+            (Decimal("1E+3"), None, "1000"),
+            (0.1, None, "0.1"),
+            (10.5, 2, "10.50"),
+            ("2.345", 2, "2.35"),  # Half away from zero, as numeric rounds
+            ("-2.345", 2, "-2.35"),
+            (Decimal("-0.00"), None, "0.00"),
+            (Decimal("-Infinity"), 2, "-Infinity"),
+            ("NaN", None, "NaN"),
+        ],
+    )
+    def test_numeric_text(self, value, scale, expected):
+        assert format_numeric(value, scale) == expected
+
+    def test_non_numeric_passthrough(self):
+        assert format_numeric("n/a") == "n/a"
@@ -0,0 +1,87 @@
+"""
+Unit Tests: VALUES List Translation
+
+Standalone VALUES and VALUES table expressions rewritten into UNION ALL
+selects with PostgreSQL's column names; INSERT ... VALUES left alone.
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.values_translator import ValuesTranslator
+
+
+@pytest.fixture
+def translator():
+    return ValuesTranslator()
+
+
+@pytest.mark.parametrize(
+    "sql, expected",
+    [
+        (
+            "VALUES (1, 'a'), (2, 'b') ORDER BY 1",
+            "SELECT 1 AS column1, 'a' AS column2 UNION ALL SELECT 2, 'b' ORDER BY 1",
+        ),
+        ("VALUES (?, ?), (?, ?)", "SELECT ? AS column1, ? AS column2 UNION ALL SELECT ?, ?"),
+        ("SELECT 1 UNION ALL VALUES (2)", "SELECT 1 UNION ALL SELECT 2 AS column1"),
+        (
+            "SELECT * FROM (VALUES (1, 'a'), (2, 'b')) AS t(id, name) WHERE t.id = 1",
+            "SELECT * FROM (SELECT 1 AS id, 'a' AS name UNION ALL SELECT 2, 'b') AS t "
+            "WHERE t.id = 1",
+        ),
+        (
+            "SELECT * FROM users u JOIN (VALUES (1), (2)) v (id) ON u.id = v.id",
+            "SELECT * FROM users u JOIN (SELECT 1 AS id UNION ALL SELECT 2) v ON u.id = v.id",
+        ),
+        (
+            "SELECT * FROM (VALUES (1, 2)) t(a)",
+            "SELECT * FROM (SELECT 1 AS a, 2 AS column2) t",
+        ),
+        (
+            "SELECT t.column1 FROM (VALUES (1, 2)) t",
+            "SELECT t.column1 FROM (SELECT 1 AS column1, 2 AS column2) t",
+        ),
+        (
+            "SELECT * FROM t WHERE x IN (VALUES (1), (2)) AND f(y)",
+            "SELECT * FROM t WHERE x IN (SELECT 1 AS column1 UNION ALL SELECT 2) AND f(y)",
+        ),
+        (
+            "WITH v(a) AS (VALUES (1), (2)) SELECT (a) FROM v",
+            "WITH v(a) AS (SELECT 1 AS column1 UNION ALL SELECT 2) SELECT (a) FROM v",
+        ),
+        (
+            "INSERT INTO t SELECT * FROM (VALUES (1, 2)) x(a, b)",
+            "INSERT INTO t SELECT * FROM (SELECT 1 AS a, 2 AS b) x",
+        ),
+    ],
+)
+def test_values_lists(translator, sql, expected):
+    translated, count = translator.translate(sql)
+
+    assert translated == expected
+    assert count == 1
+    assert translator.translate(translated) == (translated, 0)
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "INSERT INTO t VALUES (1, 2), (3, 4)",
+        "INSERT INTO t (a, b) VALUES (1, 2)",
+        "SELECT 'VALUES (1)' FROM t",
+        "VALUES (1, 2), (3)",
+        "SELECT * FROM (VALUES (1, 2)) t(a, b, c)",
+    ],
+)
+def test_other_statements_are_left_alone(translator, sql):
+    assert translator.translate(sql) == (sql, 0)
+
+
+def test_normalizer_counts_values_lists():
+    sql_translator = SQLTranslator()
+
+    assert sql_translator.normalize_sql("SELECT id FROM (VALUES (1), (2)) AS k(id)") == (
+        "SELECT ID FROM (SELECT 1 AS ID UNION ALL SELECT 2) AS K"
+    )
+    assert sql_translator.get_normalization_metrics()["values_list_count"] == 1
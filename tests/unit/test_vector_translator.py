@@ -0,0 +1,154 @@
+"""
+Unit Tests: pgvector Distance Operators
+
+<=>, <#> and <-> are rewritten onto VECTOR_COSINE and VECTOR_DOT_PRODUCT
+(ORDER BY items as rankings), ::vector casts onto TO_VECTOR, and vector(n)
+columns are created as VECTOR(DOUBLE, n).
+"""
+
+import pytest
+
+from iris_pgwire.sql_translator import SQLTranslator
+from iris_pgwire.sql_translator.ddl_translator import DDLTranslator
+from iris_pgwire.sql_translator.vector_translator import (
+    VectorTranslator,
+    inline_distance_parameters,
+)
+
+
+@pytest.mark.parametrize(
+    "sql,expected",
+    [
+        (
+            "SELECT id, embedding <=> ? AS distance FROM items",
+            "SELECT id, (1 - VECTOR_COSINE(embedding, TO_VECTOR(?, DOUBLE))) AS distance "
+            "FROM items",
+        ),
+        (
+            "SELECT i.embedding <#> '[1,2,3]' FROM items i",
+            "SELECT (-VECTOR_DOT_PRODUCT(i.embedding, TO_VECTOR('[1,2,3]', DOUBLE))) "
+            "FROM items i",
+        ),
+        (
+            "SELECT id FROM items WHERE embedding <-> '[1,2]'::vector(2) < 5",
+            "SELECT id FROM items WHERE SQRT(VECTOR_DOT_PRODUCT(embedding, embedding) "
+            "- 2 * VECTOR_DOT_PRODUCT(embedding, TO_VECTOR('[1,2]', DOUBLE)) "
+            "+ VECTOR_DOT_PRODUCT(TO_VECTOR('[1,2]', DOUBLE), TO_VECTOR('[1,2]', DOUBLE))) < 5",
+        ),
+        (
+            "SELECT 1 - (embedding <=> CAST(? AS VECTOR(3))) FROM items",
+            "SELECT 1 - ((1 - VECTOR_COSINE(embedding, TO_VECTOR(?, DOUBLE)))) FROM items",
+        ),
+    ],
+)
+def test_distances(sql, expected):
+    assert VectorTranslator().translate(sql) == (expected, 1)
+
+
+@pytest.mark.parametrize(
+    "sql,expected",
+    [
+        (
+            "SELECT id FROM items ORDER BY embedding <=> ? LIMIT 5",
+            "SELECT id FROM items ORDER BY VECTOR_COSINE(embedding, TO_VECTOR(?, DOUBLE)) DESC "
+            "LIMIT 5",
+        ),
+        (
+            "SELECT id FROM items ORDER BY embedding <#> ?::vector DESC, id",
+            "SELECT id FROM items ORDER BY VECTOR_DOT_PRODUCT(embedding, TO_VECTOR(?, DOUBLE)), id",
+        ),
+        (
+            "SELECT id FROM items ORDER BY id, ? <-> embedding",
+            "SELECT id FROM items ORDER BY id, VECTOR_DOT_PRODUCT(embedding, embedding) "
+            "- 2 * VECTOR_DOT_PRODUCT(embedding, TO_VECTOR(?, DOUBLE))",
+        ),
+        (
+            "SELECT id FROM items ORDER BY embedding <-> '[3,1,2]' DESC",
+            "SELECT id FROM items ORDER BY VECTOR_DOT_PRODUCT(embedding, embedding) "
+            "- 2 * VECTOR_DOT_PRODUCT(embedding, TO_VECTOR('[3,1,2]', DOUBLE)) DESC",
+        ),
+    ],
+)
+def test_order_by_rankings(sql, expected):
+    assert VectorTranslator().translate(sql) == (expected, 1)
+
+
+@pytest.mark.parametrize(
+    "sql,expected,count",
+    [
+        (
+            "INSERT INTO items (embedding) VALUES ('[1,2,3]'::vector), (CAST(? AS VECTOR))",
+            "INSERT INTO items (embedding) VALUES (TO_VECTOR('[1,2,3]', DOUBLE)), "
+            "(TO_VECTOR(?, DOUBLE))",
+            2,
+        ),
+        ("SELECT embedding::vector FROM items", "SELECT embedding FROM items", 1),
+    ],
+)
+def test_casts(sql, expected, count):
+    assert VectorTranslator().translate(sql) == (expected, count)
+
+
+@pytest.mark.parametrize(
+    "sql",
+    [
+        "SELECT id FROM items WHERE embedding <-> ? < 5",  # Its value is inlined first
+        "SELECT CAST(x AS VECTOR(DOUBLE, 3)) FROM t",
+        "SELECT '<=>' FROM t",
+        "SELECT id FROM t ORDER BY id",
+    ],
+)
+def test_unchanged(sql):
+    assert VectorTranslator().translate(sql) == (sql, 0)
+
+
+@pytest.mark.parametrize(
+    "sql, params, expected, remaining",
+    [
+        (
+            "SELECT id FROM items WHERE id > ? AND embedding <-> ? < 5",
+            [3, "[1,2]"],
+            "SELECT id FROM items WHERE id > ? AND embedding <-> '[1,2]' < 5",
+            [3],
+        ),
+        (
+            "SELECT CAST(? AS VECTOR) <-> ? FROM t ORDER BY e <-> ?",
+            [(1.0, 2.0), "[3,4]", "[5,6]"],
+            "SELECT CAST('[1.0,2.0]' AS VECTOR) <-> '[3,4]' FROM t ORDER BY e <-> ?",
+            ["[5,6]"],
+        ),
+        ("SELECT e <-> ? FROM t", [], "SELECT e <-> ? FROM t", []),
+    ],
+)
+def test_distance_parameters_inlined(sql, params, expected, remaining):
+    assert inline_distance_parameters(sql, params) == (expected, remaining)
+
+
+def test_normalizer_counts_operators():
+    translator = SQLTranslator()
+    sql = translator.normalize_sql(
+        "SELECT id, embedding <=> ? AS distance FROM items ORDER BY distance LIMIT 5"
+    )
+
+    assert sql == (
+        "SELECT ID, (1 - VECTOR_COSINE(EMBEDDING, TO_VECTOR(?, DOUBLE))) AS DISTANCE "
+        "FROM ITEMS ORDER BY DISTANCE LIMIT 5"
+    )
+    assert translator.get_normalization_metrics()["vector_operator_count"] == 1
+
+
+def test_vector_columns():
+    translator = DDLTranslator()
+
+    assert translator.translate(
+        "CREATE TABLE items (id INT, embedding vector(1536), raw VECTOR, "
+        "native VECTOR(DOUBLE, 3), vector INT)"
+    ) == (
+        "CREATE TABLE items (id INT, embedding VECTOR(DOUBLE, 1536), raw VECTOR(DOUBLE), "
+        "native VECTOR(DOUBLE, 3), vector INT)",
+        2,
+    )
+    assert translator.translate("ALTER TABLE items ADD COLUMN e vector (3)") == (
+        "ALTER TABLE items ADD COLUMN e VECTOR(DOUBLE, 3)",
+        1,
+    )
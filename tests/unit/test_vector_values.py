@@ -0,0 +1,166 @@
+"""
+Unit Tests: pgvector's vector Type
+
+vector parameters and results are pgvector's [1,2,3] text and dimensions +
+float4 binary form, VECTOR columns are described as vector, and pg_type
+lookups and CREATE EXTENSION vector are answered for pgvector clients.
+"""
+
+import asyncio
+import struct
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.column_types import describe_column
+from iris_pgwire.protocol import PGWireProtocol
+from iris_pgwire.result_encoding import encode_result_value
+from iris_pgwire.vector_values import (
+    VECTOR_OID,
+    InvalidVector,
+    create_extension_notices,
+    decode_vector,
+    encode_vector,
+    format_vector,
+    is_create_vector_extension,
+    parse_vector,
+    references_vector_type,
+    vector_column_type,
+    vector_type_row,
+)
+
+BINARY = struct.pack("!HH3f", 3, 0, 1.0, 2.5, -3.0)
+
+
+@pytest.mark.parametrize(
+    "text,expected",
+    [
+        ("[1,2,3]", "[1,2,3]"),
+        (" [1.5, -2e1 ,0.25] ", "[1.5,-20,0.25]"),
+    ],
+)
+def test_vector_input(text, expected):
+    assert parse_vector(text) == expected
+
+
+@pytest.mark.parametrize("text", ["1,2,3", "[]", "[1,,2]", "[1,nan]", "[a]"])
+def test_invalid_vector_input(text):
+    with pytest.raises(InvalidVector, match="invalid input syntax for type vector"):
+        parse_vector(text)
+
+
+def test_vector_output():
+    assert format_vector("1,2.5,3") == "[1,2.5,3]"
+    assert format_vector("[1,2]") == "[1,2]"
+    assert format_vector([1.0, 0.5]) == "[1,0.5]"
+
+
+def test_binary_form():
+    assert decode_vector(BINARY) == "[1,2.5,-3]"
+    assert encode_vector("1,2.5,-3") == BINARY
+    assert encode_result_value("1,2.5,-3", VECTOR_OID) == BINARY
+    with pytest.raises(ValueError):
+        decode_vector(BINARY[:-1])
+
+
+def test_column_types():
+    assert vector_column_type("3") == "VECTOR(DOUBLE, 3)"
+    assert vector_column_type() == "VECTOR(DOUBLE)"
+    assert describe_column("VECTOR")[0] == VECTOR_OID
+
+
+def test_create_extension():
+    assert is_create_vector_extension("CREATE EXTENSION vector")
+    assert is_create_vector_extension('create extension if not exists "vector" with schema public;')
+    assert not is_create_vector_extension("CREATE EXTENSION hstore")
+    assert create_extension_notices("CREATE EXTENSION vector") == []
+    assert create_extension_notices("CREATE EXTENSION IF NOT EXISTS vector") == [
+        ("NOTICE", "42710", 'extension "vector" already exists, skipping')
+    ]
+
+
+class TestTypeLookups:
+    def test_references(self):
+        assert references_vector_type("SELECT oid FROM pg_type WHERE typname = 'vector'")
+        assert references_vector_type("SELECT typname FROM pg_type WHERE oid = 16388")
+        assert references_vector_type("SELECT oid FROM pg_type WHERE typname = ?", ["vector"])
+        assert not references_vector_type("SELECT oid FROM pg_type WHERE typname = ?", ["int4"])
+        assert not references_vector_type("SELECT 'vector' FROM items")
+
+    def test_psycopg_type_info(self):
+        # psycopg's TypeInfo.fetch (used by pgvector-python's register_vector)
+        columns, rows = vector_type_row(
+            "SELECT t.typname AS name, t.oid, t.typarray AS array_oid, "
+            "t.oid::regtype::text AS regtype, t.typdelim AS delimiter "
+            "FROM pg_type t WHERE t.oid = to_regtype(?)"
+        )
+
+        assert [column["name"] for column in columns] == [
+            "name",
+            "oid",
+            "array_oid",
+            "regtype",
+            "delimiter",
+        ]
+        assert rows == [("vector", VECTOR_OID, 0, "vector", ",")]
+
+    def test_unknown_columns_are_null(self):
+        columns, rows = vector_type_row(
+            "SELECT oid, typsend, t.typlen len FROM pg_type t WHERE typname = 'vector'"
+        )
+
+        assert [(column["name"], column["type_oid"]) for column in columns] == [
+            ("oid", 26),
+            ("typsend", 25),
+            ("len", 21),
+        ]
+        assert rows == [(VECTOR_OID, None, -1)]
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol():
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), MagicMock(), "vector")
+        protocol.writer.drain = AsyncMock()
+        protocol.prepared_statements["s"] = {
+            "query": "SELECT ?",
+            "param_types": [VECTOR_OID, VECTOR_OID],
+        }
+        return protocol
+
+    @staticmethod
+    def _bind(values, formats) -> bytes:
+        body = b"\x00s\x00" + struct.pack("!H", len(formats))
+        body += b"".join(struct.pack("!H", code) for code in formats)
+        body += struct.pack("!H", len(values))
+        body += b"".join(struct.pack("!I", len(value)) + value for value in values)
+        return body + struct.pack("!H", 0)
+
+    def test_parameters_bound_as_text_form(self):
+        protocol = self._protocol()
+        body = self._bind([b"[1, 2.5, -3]", BINARY], [0, 1])
+
+        asyncio.run(protocol.handle_bind_message(body))
+
+        assert protocol.portals[""]["params"] == ["[1,2.5,-3]", "[1,2.5,-3]"]
+
+    def test_invalid_parameter_fails_with_22p02(self):
+        protocol = self._protocol()
+        protocol.send_error_response = AsyncMock()
+
+        asyncio.run(protocol.handle_bind_message(self._bind([b"1,2,3", b"[1]"], [0])))
+
+        assert "" not in protocol.portals
+        assert protocol.send_error_response.call_args.args[1] == "22P02"
+
+    def test_results_sent_in_text_and_binary(self):
+        protocol = self._protocol()
+        columns = [{"name": "a", "type_oid": VECTOR_OID}, {"name": "b", "type_oid": VECTOR_OID}]
+
+        protocol._current_result_formats = [0, 1]
+        asyncio.run(protocol.send_data_row(["1,2.5,-3", "1,2.5,-3"], columns))
+
+        sent = b"".join(call.args[0] for call in protocol.writer.write.call_args_list)
+        assert sent.endswith(
+            struct.pack("!I", 10) + b"[1,2.5,-3]" + struct.pack("!I", len(BINARY)) + BINARY
+        )
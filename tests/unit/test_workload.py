@@ -0,0 +1,71 @@
+"""
+Unit Tests: Workload Tagging
+
+Comment hints and iris.workload, workload priorities and per-workload
+admission caps.
+"""
+
+import asyncio
+import contextvars
+
+import pytest
+
+from iris_pgwire.admission import AdmissionController
+from iris_pgwire.session_settings import InvalidParameterValue, SessionSettings
+from iris_pgwire.workload import (
+    extract_workload_hint,
+    load_workload_priorities,
+    resolve_workload,
+)
+
+
+class TestWorkloadResolution:
+    def test_comment_hint(self):
+        assert extract_workload_hint("/* workload:ETL */ SELECT 1") == "etl"
+        assert extract_workload_hint("SELECT 1 /*workload=dashboard*/") == "dashboard"
+        assert extract_workload_hint("SELECT '/* not a hint */'") is None
+
+    def test_hint_overrides_session_setting(self, monkeypatch):
+        monkeypatch.delenv("PGWIRE_DEFAULT_WORKLOAD", raising=False)
+
+        def scenario():
+            SessionSettings().set("iris.workload", "etl")
+            return resolve_workload("SELECT 1"), resolve_workload("/* workload:oltp */ SELECT 1")
+
+        assert contextvars.copy_context().run(scenario) == ("etl", "oltp")
+
+    def test_default_workload(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_DEFAULT_WORKLOAD", "Interactive")
+
+        assert contextvars.copy_context().run(resolve_workload, "SELECT 1") == "interactive"
+
+    def test_invalid_workload_name_rejected(self):
+        with pytest.raises(InvalidParameterValue):
+            SessionSettings().set("iris.workload", "etl; DROP TABLE x")
+
+    def test_priorities_from_environment(self, monkeypatch):
+        monkeypatch.setenv("PGWIRE_WORKLOAD_PRIORITIES", "ETL=-5,oltp=5")
+
+        assert load_workload_priorities() == {"etl": -5, "oltp": 5}
+
+
+class TestWorkloadAdmission:
+    def test_workload_cap_applies_across_roles(self):
+        async def scenario():
+            controller = AdmissionController(
+                max_concurrent=0, per_role=0, role_limits={}, timeout=5, workload_limits={"etl": 1}
+            )
+            await controller.acquire("alice", "etl")
+            queued = asyncio.create_task(controller.acquire("bob", "etl"))
+            await asyncio.sleep(0)
+            await asyncio.wait_for(controller.acquire("bob", "oltp"), timeout=1)
+            blocked = not queued.done()
+
+            controller.release("alice", "etl")
+            await queued
+            return blocked, controller.stats()
+
+        blocked, stats = asyncio.run(scenario())
+
+        assert blocked
+        assert stats["running_by_workload"] == {"etl": 1, "oltp": 1}
@@ -0,0 +1,260 @@
+"""
+Unit Tests: Data-Modifying WITH Queries
+
+Writable CTEs split into one statement per CTE, the RETURNING rows of each
+handed to the statements after it as a derived table.
+"""
+
+import asyncio
+import datetime
+from decimal import Decimal
+from unittest.mock import AsyncMock, MagicMock
+
+import pytest
+
+from iris_pgwire.protocol import STATUS_IDLE, STATUS_IN_TRANSACTION, PGWireProtocol
+from iris_pgwire.writable_cte import parse_writable_cte, run_writable_cte, sql_literal
+
+INSERT_THEN_SELECT = (
+    "WITH ins AS (INSERT INTO orders (customer) VALUES (?) RETURNING id, customer) "
+    "SELECT id FROM ins WHERE customer = ?"
+)
+
+
+def _returned(rows, *names, type_oid=23):
+    return {
+        "success": True,
+        "rows": rows,
+        "columns": [{"name": name, "type_oid": type_oid} for name in names],
+        "row_count": len(rows),
+        "command_tag": f"INSERT 0 {len(rows)}",
+    }
+
+
+class FakeExecutor:
+    def __init__(self, results=None, failing=()):
+        self.results = results or {}  # SQL prefix -> result
+        self.failing = failing
+        self.executed = []
+
+    async def execute_query(self, sql, params=None, session_id=None):
+        self.executed.append((sql, params))
+        if any(sql.startswith(prefix) for prefix in self.failing):
+            return {"success": False, "error": "boom", "sqlstate": "23505"}
+        for prefix, result in self.results.items():
+            if sql.startswith(prefix):
+                return result
+        return {"success": True, "rows": [], "columns": [], "command_tag": "SELECT 0"}
+
+
+class TestParsing:
+    def test_dml_cte(self):
+        statement = parse_writable_cte(INSERT_THEN_SELECT)
+
+        assert [cte.name for cte in statement.ctes] == ["ins"]
+        assert statement.ctes[0].dml
+        assert not statement.main_is_dml
+
+    def test_dml_main_statement(self):
+        statement = parse_writable_cte(
+            "WITH src AS (SELECT id FROM staging) INSERT INTO orders (id) SELECT id FROM src;"
+        )
+
+        assert statement.main_is_dml
+        assert not statement.ctes[0].dml
+
+    @pytest.mark.parametrize(
+        "sql",
+        [
+            "WITH src AS (SELECT 1 AS id) SELECT id FROM src",
+            "WITH RECURSIVE r AS (INSERT INTO t VALUES (1) RETURNING id) SELECT * FROM r",
+            "SELECT 'WITH x AS (DELETE FROM t)'",
+            "INSERT INTO t SELECT * FROM s",
+        ],
+    )
+    def test_other_statements_not_handled(self, sql):
+        assert parse_writable_cte(sql) is None
+
+
+class TestStatements:
+    def test_cte_statement_gets_its_parameters(self):
+        statement = parse_writable_cte(INSERT_THEN_SELECT)
+
+        sql, numbers = statement.statement_sql(0, {})
+
+        assert sql == "INSERT INTO orders (customer) VALUES (?) RETURNING id, customer"
+        assert numbers == [0]
+
+    def test_reference_replaced_by_returned_rows(self):
+        statement = parse_writable_cte(INSERT_THEN_SELECT)
+        returned = _returned([[7, "a"], [8, "b'c"]], "id", "customer")
+
+        sql, numbers = statement.statement_sql(None, {"ins": returned})
+
+        assert sql == (
+            "SELECT id FROM (SELECT 7 AS id, 'a' AS customer UNION ALL SELECT 8, 'b''c') ins "
+            "WHERE customer = ?"
+        )
+        assert numbers == [1]
+
+    def test_no_rows_selects_returning_columns_of_table(self):
+        statement = parse_writable_cte(
+            "WITH d AS (DELETE FROM orders o WHERE o.id = ? RETURNING o.id) "
+            "SELECT count(*) FROM d AS gone"
+        )
+
+        sql, _ = statement.statement_sql(None, {"d": _returned([], "id")})
+
+        assert sql == (
+            "SELECT count(*) FROM (SELECT o.id FROM orders o WHERE 1 = 0) AS gone"
+        )
+
+    def test_plain_cte_inlined_into_dml(self):
+        statement = parse_writable_cte(
+            "WITH src AS (SELECT id FROM staging WHERE batch = ?), "
+            "moved AS (DELETE FROM staging WHERE batch = ? RETURNING id) "
+            "INSERT INTO orders (id) SELECT s.id FROM src s, moved"
+        )
+
+        sql, numbers = statement.statement_sql(None, {"moved": _returned([[3]], "id")})
+
+        assert sql == (
+            "INSERT INTO orders (id) SELECT s.id FROM "
+            "(SELECT id FROM staging WHERE batch = ?) s, (SELECT 3 AS id) moved"
+        )
+        assert numbers == [0]
+
+    def test_plain_cte_kept_for_select(self):
+        statement = parse_writable_cte(
+            "WITH ins AS (INSERT INTO t (a) VALUES (?) RETURNING id), "
+            "ids AS (SELECT id FROM ins) SELECT * FROM ids WHERE id > ?"
+        )
+
+        sql, numbers = statement.statement_sql(None, {"ins": _returned([[1]], "id")})
+
+        assert sql == (
+            "WITH ids AS (SELECT id FROM (SELECT 1 AS id) ins) SELECT * FROM ids WHERE id > ?"
+        )
+        assert numbers == [1]
+
+    def test_names_in_select_list_left_alone(self):
+        statement = parse_writable_cte(
+            "WITH ins AS (INSERT INTO t (a) VALUES (1) RETURNING id) "
+            "SELECT ins.id, ins FROM ins"
+        )
+
+        sql, _ = statement.statement_sql(None, {"ins": _returned([[1]], "id")})
+
+        assert sql == "SELECT ins.id, ins FROM (SELECT 1 AS id) ins"
+
+    def test_describe_sql_runs_no_dml(self):
+        statement = parse_writable_cte(
+            "WITH ins AS (INSERT INTO orders (customer) VALUES (?) RETURNING *) "
+            "SELECT * FROM ins"
+        )
+
+        assert statement.describe_sql() == (
+            "SELECT * FROM (SELECT * FROM orders WHERE 1 = 0) ins",
+            [],
+        )
+
+    def test_describe_sql_of_dml_main_statement(self):
+        statement = parse_writable_cte(
+            "WITH src AS (SELECT 1 AS id) INSERT INTO t (id) SELECT id FROM src"
+        )
+
+        assert statement.describe_sql() is None
+
+    @pytest.mark.parametrize(
+        "value,type_oid,literal",
+        [
+            (None, 23, "NULL"),
+            (True, 16, "1"),
+            (Decimal("1.50"), 1700, "1.50"),
+            (datetime.date(2024, 3, 1), 1082, "CAST('2024-03-01' AS DATE)"),
+            (
+                datetime.datetime(2024, 3, 1, 12, 30),
+                1114,
+                "CAST('2024-03-01 12:30:00' AS TIMESTAMP)",
+            ),
+            ("it's", 25, "'it''s'"),
+        ],
+    )
+    def test_literals(self, value, type_oid, literal):
+        assert sql_literal(value, type_oid) == literal
+
+
+class TestRunning:
+    def test_statements_run_in_own_transaction(self):
+        executor = FakeExecutor({"INSERT": _returned([[7, "a"]], "id", "customer")})
+        recorded = []
+
+        result = asyncio.run(
+            run_writable_cte(
+                executor,
+                parse_writable_cte(INSERT_THEN_SELECT),
+                ["a", "a"],
+                record=lambda *step: recorded.append(step[0]),
+            )
+        )
+
+        assert result["command_tag"] == "SELECT 0"
+        assert executor.executed == [
+            ("START TRANSACTION", None),
+            ("INSERT INTO orders (customer) VALUES (?) RETURNING id, customer", ["a"]),
+            ("SELECT id FROM (SELECT 7 AS id, 'a' AS customer) ins WHERE customer = ?", ["a"]),
+            ("COMMIT", None),
+        ]
+        assert recorded == ["INSERT INTO orders (customer) VALUES (?) RETURNING id, customer"]
+
+    def test_failure_rolls_back(self):
+        executor = FakeExecutor(failing=("SELECT",))
+        recorded = []
+
+        result = asyncio.run(
+            run_writable_cte(
+                executor,
+                parse_writable_cte(INSERT_THEN_SELECT),
+                ["a", "a"],
+                record=lambda *step: recorded.append(step),
+            )
+        )
+
+        assert result["sqlstate"] == "23505"
+        assert executor.executed[-1] == ("ROLLBACK", None)
+        assert recorded == []
+
+    def test_client_transaction_left_open(self):
+        executor = FakeExecutor()
+
+        asyncio.run(
+            run_writable_cte(
+                executor, parse_writable_cte(INSERT_THEN_SELECT), ["a", "a"], own_transaction=False
+            )
+        )
+
+        executed = [sql for sql, _ in executor.executed]
+        assert "START TRANSACTION" not in executed and "COMMIT" not in executed
+
+
+class TestProtocol:
+    @staticmethod
+    def _protocol(transaction_status):
+        executor = MagicMock()
+        fake = FakeExecutor({"INSERT": _returned([[7, "a"]], "id", "customer")})
+        executor.execute_query = AsyncMock(side_effect=fake.execute_query)
+        protocol = PGWireProtocol(MagicMock(), MagicMock(), executor, "writable-cte")
+        protocol.transaction_status = transaction_status
+        return protocol, fake
+
+    @pytest.mark.parametrize(
+        "transaction_status,begins", [(STATUS_IDLE, True), (STATUS_IN_TRANSACTION, False)]
+    )
+    def test_execute_statement(self, transaction_status, begins):
+        protocol, fake = self._protocol(transaction_status)
+
+        result = asyncio.run(protocol._execute_statement(INSERT_THEN_SELECT, ["a", "a"]))
+
+        assert result["success"]
+        assert (fake.executed[0][0] == "START TRANSACTION") is begins
+        assert fake.executed[-1 - begins][0].startswith("SELECT id FROM (SELECT 7 AS id")